@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts",
+	Short: "Inspect, restore, and clean up the artifact store",
+	Long: `Rendered sites and export bundles are kept in a content-addressed artifact
+store (artifacts.dir, or the S3 bucket in artifacts.s3). Every generation
+records a snapshot of the files it produced; the last artifacts.keep snapshots
+of each output are kept and can be restored.`,
+}
+
+var artifactsListCmd = &cobra.Command{
+	Use:   "list [output]",
+	Short: "List outputs, or the snapshots of one output",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runArtifactsList,
+}
+
+var artifactsRestoreCmd = &cobra.Command{
+	Use:   "restore <output>",
+	Short: "Restore an output directory to an earlier snapshot",
+	Long: `Brings an output directory back to a recorded snapshot, the latest by default.
+With --to, the snapshot's files are written to another directory instead and
+the output is left alone.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runArtifactsRestore,
+}
+
+var artifactsGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete stored files no snapshot refers to",
+	RunE:  runArtifactsGC,
+}
+
+func init() {
+	artifactsRestoreCmd.Flags().String("snapshot", "", "snapshot ID to restore (defaults to the latest)")
+	artifactsRestoreCmd.Flags().String("to", "", "write the snapshot to this directory instead of the output")
+	artifactsCmd.AddCommand(artifactsListCmd, artifactsRestoreCmd, artifactsGCCmd)
+	rootCmd.AddCommand(artifactsCmd)
+}
+
+// openArtifacts opens the artifact store configured in cfg.
+func openArtifacts(cfg *config.Config) (*artifacts.Store, error) {
+	store, err := artifacts.Open(cfg.Artifacts, cfg.OutputDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening artifact store: %w", err)
+	}
+	return store, nil
+}
+
+// printPublished reports what publishing an output changed on disk.
+func printPublished(stats artifacts.PublishStats) {
+	if stats.Snapshot == "" {
+		return
+	}
+	fmt.Printf("  %d files written, %d unchanged, %d stale removed (snapshot %s)\n",
+		stats.Written, stats.Unchanged, stats.Removed, stats.Snapshot)
+}
+
+// collectArtifacts runs garbage collection after a generation, so stored
+// files from dropped snapshots do not pile up. Failures only warn.
+func collectArtifacts(ctx context.Context, store *artifacts.Store) {
+	stats, err := store.GC(ctx)
+	if err != nil {
+		slog.Warn("artifact garbage collection failed", "phase", "publish", "err", err)
+		return
+	}
+	if stats.Removed > 0 {
+		fmt.Printf("  Reclaimed %s from %d unreferenced artifacts\n", indexer.FormatSize(stats.RemovedBytes), stats.Removed)
+	}
+}
+
+func runArtifactsList(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	if len(args) == 1 {
+		snapshots, err := store.Snapshots(ctx, filepath.Clean(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			return fmt.Errorf("no snapshots of %s", args[0])
+		}
+		fmt.Fprintln(w, "SNAPSHOT\tCREATED\tFILES")
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			sn := snapshots[i]
+			fmt.Fprintf(w, "%s\t%s\t%d\n", sn.ID, sn.Created.Local().Format("2006-01-02 15:04:05"), len(sn.Files))
+		}
+		return nil
+	}
+
+	outputs, err := store.Outputs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		fmt.Println("The artifact store is empty. Run `autodoc site` or `autodoc export` to fill it.")
+		return nil
+	}
+	fmt.Fprintln(w, "OUTPUT\tLATEST SNAPSHOT\tFILES")
+	for _, sn := range outputs {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", sn.Output, sn.ID, len(sn.Files))
+	}
+	return nil
+}
+
+func runArtifactsRestore(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	id, _ := cmd.Flags().GetString("snapshot")
+	to, _ := cmd.Flags().GetString("to")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
+	}
+	output := filepath.Clean(args[0])
+	sn, err := store.Find(ctx, output, id)
+	if err != nil {
+		return err
+	}
+
+	if to != "" {
+		if err := store.Restore(ctx, sn, to); err != nil {
+			return err
+		}
+		fmt.Printf("Restored snapshot %s of %s to %s (%d files)\n", sn.ID, output, to, len(sn.Files))
+		return nil
+	}
+
+	// Restoring in place is a publish of the old snapshot, which also
+	// removes the files newer snapshots added.
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return err
+	}
+	stageDir, err := os.MkdirTemp(filepath.Dir(output), ".staging-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	if err := store.Restore(ctx, sn, stageDir); err != nil {
+		return err
+	}
+	stats, err := store.Publish(ctx, stageDir, output)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s to snapshot %s\n", output, sn.ID)
+	printPublished(stats)
+	return nil
+}
+
+func runArtifactsGC(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
+	}
+	stats, err := store.GC(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d unreferenced artifacts (%s) and %d stale cache entries; %d kept\n",
+		stats.Removed, indexer.FormatSize(stats.RemovedBytes), stats.MemoRemoved, stats.Kept)
+	return nil
+}
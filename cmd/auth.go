@@ -69,6 +69,16 @@ Get your API key at https://openrouter.ai/settings/keys`,
 	RunE: runAuthOpenRouter,
 }
 
+var authAzureCmd = &cobra.Command{
+	Use:   "azure",
+	Short: "Store Azure OpenAI API key",
+	Long: `Store your Azure OpenAI API key for persistent use.
+
+Find the key under Keys and Endpoint on your Azure OpenAI resource, and set
+providers.azure.endpoint in .autodoc.yml to the resource's endpoint.`,
+	RunE: runAuthAzure,
+}
+
 var authStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show which providers have stored credentials",
@@ -81,7 +91,7 @@ var authLogoutCmd = &cobra.Command{
 	Long: `Remove stored credentials for a provider.
 
 If no provider is specified, removes all stored credentials.
-Valid providers: google, anthropic, openai, minimax, openrouter`,
+Valid providers: google, anthropic, openai, minimax, openrouter, azure`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runAuthLogout,
 }
@@ -93,6 +103,7 @@ func init() {
 	authCmd.AddCommand(authOpenAICmd)
 	authCmd.AddCommand(authMinimaxCmd)
 	authCmd.AddCommand(authOpenRouterCmd)
+	authCmd.AddCommand(authAzureCmd)
 	authCmd.AddCommand(authStatusCmd)
 	authCmd.AddCommand(authLogoutCmd)
 }
@@ -253,6 +264,30 @@ func runAuthOpenRouter(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runAuthAzure(cmd *cobra.Command, args []string) error {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Azure OpenAI API key: ")
+	input, _ := reader.ReadString('\n')
+	apiKey := strings.TrimSpace(input)
+	if apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+
+	creds, err := auth.Load()
+	if err != nil {
+		return fmt.Errorf("loading credentials: %w", err)
+	}
+
+	creds.Azure = &auth.APIKeyCredentials{APIKey: apiKey}
+
+	if err := auth.Save(creds); err != nil {
+		return fmt.Errorf("saving credentials: %w", err)
+	}
+
+	fmt.Println("Azure OpenAI credentials stored successfully!")
+	return nil
+}
+
 func runAuthStatus(cmd *cobra.Command, args []string) error {
 	creds, err := auth.Load()
 	if err != nil {
@@ -310,6 +345,24 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println("openrouter   not configured")
 	}
 
+	// Azure OpenAI
+	if env := os.Getenv("AZURE_OPENAI_API_KEY"); env != "" {
+		fmt.Println("azure        configured (env var)")
+	} else if creds.Azure != nil && creds.Azure.APIKey != "" {
+		fmt.Println("azure        configured (stored)")
+	} else {
+		fmt.Println("azure        not configured")
+	}
+
+	// Bedrock (AWS credentials come from the environment)
+	if os.Getenv("AWS_BEARER_TOKEN_BEDROCK") != "" {
+		fmt.Println("bedrock      configured (env var: API key)")
+	} else if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		fmt.Println("bedrock      configured (env var: AWS credentials)")
+	} else {
+		fmt.Println("bedrock      not configured")
+	}
+
 	// Ollama (always available locally)
 	fmt.Println("ollama       available (local)")
 
@@ -343,8 +396,11 @@ func runAuthLogout(cmd *cobra.Command, args []string) error {
 		case "openrouter":
 			creds.OpenRouter = nil
 			fmt.Println("OpenRouter credentials removed.")
+		case "azure":
+			creds.Azure = nil
+			fmt.Println("Azure OpenAI credentials removed.")
 		default:
-			return fmt.Errorf("unknown provider %q (valid: google, anthropic, openai, minimax, openrouter)", args[0])
+			return fmt.Errorf("unknown provider %q (valid: google, anthropic, openai, minimax, openrouter, azure)", args[0])
 		}
 	}
 
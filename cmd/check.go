@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Check .autodoc artifacts against the schemas this autodoc supports",
+	Long: `Checks the versioned artifacts under .autodoc/ (analyses.json and state.json) for
+schema compatibility and validates every file analysis, so artifacts written by a
+different autodoc release fail loudly instead of producing broken docs.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	failed := false
+	for _, s := range indexer.CheckArtifacts(dir) {
+		switch {
+		case !s.Exists && s.Err == nil:
+			fmt.Printf("- %s: not found\n", s.Name)
+		case s.Err != nil:
+			failed = true
+			fmt.Printf("x %s: %v\n", s.Name, s.Err)
+		case len(s.Issues) > 0:
+			failed = true
+			fmt.Printf("x %s: schema v%d (supported: v%d), %d validation issues\n", s.Name, s.SchemaVersion, s.Supported, len(s.Issues))
+			for _, issue := range s.Issues {
+				fmt.Printf("    %s\n", issue)
+			}
+		default:
+			note := ""
+			if s.SchemaVersion < s.Supported {
+				note = ", upgraded on next write"
+			}
+			fmt.Printf("ok %s: schema v%d (supported: v%d%s)\n", s.Name, s.SchemaVersion, s.Supported, note)
+		}
+	}
+
+	if failed {
+		fmt.Fprintln(os.Stderr, "Artifacts are not compatible with this autodoc; run `autodoc generate` to rebuild them.")
+		return fmt.Errorf("artifact check failed")
+	}
+	return nil
+}
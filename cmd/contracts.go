@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/contracts"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+var contractsCmd = &cobra.Command{
+	Use:   "contracts",
+	Short: "Generate consumer-driven contract stubs from the service links",
+	Long: `Writes a contract stub for every consumer-provider pair in the central
+registry, to bootstrap contract tests from the documented architecture. For
+HTTP links the caller is the consumer; its calls become Pact interactions, or
+with --format openapi the part of the provider's OpenAPI spec it relies on.
+For messaging links the service reading the topic is the consumer; its topics
+become Pact message files, with example contents built from the topic's
+recorded payload schema.
+
+Responses and parameters come from the provider's OpenAPI spec when its
+checkout has one. The stubs are a starting point: review the example bodies
+and add provider states before verifying them.`,
+	RunE: runContracts,
+}
+
+func init() {
+	contractsCmd.Flags().String("format", contracts.FormatPact, "format of the HTTP contracts: pact or openapi")
+	contractsCmd.Flags().String("out", "", "directory to write the contracts to (default <output_dir>/contracts)")
+	contractsCmd.Flags().String("consumer", "", "only write the contracts of this consumer")
+	contractsCmd.Flags().String("provider", "", "only write the contracts with this provider")
+	rootCmd.AddCommand(contractsCmd)
+}
+
+func runContracts(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+	consumer, _ := cmd.Flags().GetString("consumer")
+	provider, _ := cmd.Flags().GetString("provider")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		out = filepath.Join(cfg.OutputDir, "contracts")
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	repoStore := registry.NewStore(database)
+	links, err := repoStore.GetLinks(ctx, "")
+	if err != nil {
+		return fmt.Errorf("loading links: %w", err)
+	}
+	repos, err := repoStore.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing repos: %w", err)
+	}
+	specs := make(map[string][]importers.OpenAPIEndpoint)
+	for _, r := range repos {
+		if r.LocalPath != "" {
+			specs[r.Name] = contracts.LoadSpecs(r.LocalPath)
+		}
+	}
+	schemas, err := schemareg.NewStore(database).List(ctx)
+	if err != nil {
+		return err
+	}
+
+	pairs, skipped := contracts.Build(links, specs, schemas)
+	var selected []contracts.Pair
+	for _, p := range pairs {
+		if (consumer == "" || p.Consumer == consumer) && (provider == "" || p.Provider == provider) {
+			selected = append(selected, p)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no consumer-provider pairs to write contracts for\nRun 'autodoc repo sync-all' to discover the links between registered repos")
+	}
+	written, err := contracts.Write(out, format, selected)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d contract files for %d consumer-provider pairs to %s\n", len(written), len(selected), out)
+	for _, s := range skipped {
+		if (consumer == "" || s.Link.FromRepo == consumer || s.Link.ToRepo == consumer) && (provider == "" || s.Link.FromRepo == provider || s.Link.ToRepo == provider) {
+			fmt.Fprintf(os.Stderr, "  skipped %s -> %s (%s): %s\n", s.Link.FromRepo, s.Link.ToRepo, s.Link.LinkType, s.Reason)
+		}
+	}
+	return nil
+}
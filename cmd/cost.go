@@ -8,8 +8,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/docs"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
-	"github.com/ziadkadry99/auto-doc/internal/llm"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 	"github.com/ziadkadry99/auto-doc/internal/walker"
 )
@@ -41,11 +41,16 @@ func runCost(cmd *cobra.Command, args []string) error {
 	}
 
 	// Walk codebase.
+	var coverage docs.Coverage
 	files, err := walker.Walk(walker.WalkerConfig{
 		RootDir:     rootDir,
 		Include:     cfg.Include,
 		Exclude:     cfg.Exclude,
-		MaxFileSize: 0,
+		Languages:   cfg.Languages,
+		MaxFileSize: cfg.LargeFiles.IgnoreSize(),
+		OnSkip: func(relPath string, reason walker.SkipReason) {
+			coverage.Skip(relPath, string(reason), "")
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("walking codebase: %w", err)
@@ -58,7 +63,7 @@ func runCost(cmd *cobra.Command, args []string) error {
 
 	// We need an LLM provider and embedder to create the pipeline (for DryRun),
 	// but DryRun doesn't actually make API calls.
-	llmProvider, err := llm.NewProvider(string(cfg.Provider), cfg.Model)
+	llmProvider, err := createLLMProviderFromConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("creating LLM provider: %w", err)
 	}
@@ -84,6 +89,7 @@ func runCost(cmd *cobra.Command, args []string) error {
 	fmt.Println("Cost Estimate")
 	fmt.Println("=============")
 	fmt.Printf("  Total files found:   %d\n", len(files))
+	printExcluded(&coverage)
 	fmt.Printf("  Files to process:    %d (changed since last run)\n", estimate.TotalFiles)
 	fmt.Printf("  Estimated tokens:    %d\n", estimate.TotalTokensEstimate)
 	fmt.Println()
@@ -104,6 +110,7 @@ func runCost(cmd *cobra.Command, args []string) error {
 		tierCfg.Quality = tier
 		preset := config.GetPreset(cfg.Provider, tier)
 		tierCfg.Model = preset.Model
+		tierCfg.Models = nil // compare the presets, not the routing
 
 		tierPipeline := indexer.NewPipeline(llmProvider, embedder, store, &tierCfg, rootDir)
 		tierEstimate, err := tierPipeline.DryRun(ctx, files)
@@ -126,3 +133,17 @@ func runCost(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// printExcluded prints how many files ignore rules and language toggles
+// left out of the estimate.
+func printExcluded(coverage *docs.Coverage) {
+	counts := make(map[string]int)
+	for _, s := range coverage.Skipped {
+		counts[s.Reason]++
+	}
+	ignored, language := counts[string(walker.SkipIgnored)], counts[string(walker.SkipLanguage)]
+	if ignored+language == 0 {
+		return
+	}
+	fmt.Printf("  Files excluded:      %d (%d by ignore rules, %d by language)\n", ignored+language, ignored, language)
+}
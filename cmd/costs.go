@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/costs"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/traces"
+)
+
+var costsCmd = &cobra.Command{
+	Use:   "costs",
+	Short: "Attribute cloud costs to services",
+	Long: `Imports cloud billing exports whose line items are tagged with the service
+they belong to, and totals them per registered service and month. The central
+site shows each service's monthly cost on its page and a cost table in the
+system overview.`,
+}
+
+var costsImportCmd = &cobra.Command{
+	Use:   "import <export files...>",
+	Short: "Import CSV or JSON billing exports tagged by service",
+	Long: `Reads CSV or JSON billing exports: AWS Cost and Usage Reports, GCP billing
+exports, Azure cost exports, FOCUS files, or any file with a month (or date),
+a cost, and a service column. The service is read from the costs.tag cost
+allocation tag ("service" by default) and matched to registered repos like
+trace service names are, using costs.aliases. Each month an import covers
+replaces what was stored for it; use --reset to discard all stored costs.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCostsImport,
+}
+
+var costsReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Print the imported monthly cost of each service",
+	RunE:  runCostsReport,
+}
+
+func init() {
+	costsImportCmd.Flags().String("tag", "", "cost allocation tag naming the service (overrides costs.tag)")
+	costsImportCmd.Flags().Bool("reset", false, "discard previously imported costs first")
+	costsImportCmd.Flags().Bool("dry-run", false, "print the attributed costs without storing anything")
+	costsCmd.AddCommand(costsImportCmd, costsReportCmd)
+	rootCmd.AddCommand(costsCmd)
+}
+
+func runCostsImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	tag, _ := cmd.Flags().GetString("tag")
+	reset, _ := cmd.Flags().GetBool("reset")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	tag = cmp.Or(tag, cfg.Costs.Tag)
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	repos, err := registry.NewStore(database).List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing repos: %w", err)
+	}
+	repoNames := make([]string, len(repos))
+	for i, r := range repos {
+		repoNames[i] = r.Name
+	}
+
+	var lines []costs.Line
+	sources := make([]string, len(args))
+	for i, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		got, err := costs.Parse(data, tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		lines = append(lines, got...)
+		sources[i] = filepath.Base(path)
+	}
+	attributed, unattributed := costs.Attribute(lines, traces.NewResolver(repoNames, cfg.Costs.Aliases).Resolve)
+	fmt.Fprintf(os.Stderr, "Read %d line items: %d service-months attributed\n", len(lines), countAttributed(attributed))
+	if len(unattributed) > 0 {
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Not attributed to a registered service (add costs.aliases for these):\n")
+		for _, c := range unattributed {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", cmp.Or(c.Service, "(untagged)"), c.Month, costs.Format(c.Amount, c.Currency))
+		}
+		w.Flush()
+	}
+	if dryRun {
+		printCostsReport(attributed)
+		return nil
+	}
+
+	store := costs.NewStore(database)
+	if reset {
+		if err := store.Clear(ctx); err != nil {
+			return err
+		}
+	}
+	if err := store.Record(ctx, attributed, strings.Join(sources, ", ")); err != nil {
+		return err
+	}
+	stored, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	printCostsReport(stored)
+	return nil
+}
+
+func runCostsReport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	stored, err := costs.NewStore(database).List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		fmt.Println("No costs imported yet. Run `autodoc costs import` first.")
+		return nil
+	}
+	printCostsReport(stored)
+	return nil
+}
+
+// countAttributed counts the costs that belong to a registered service.
+func countAttributed(cs []costs.MonthlyCost) int {
+	n := 0
+	for _, c := range cs {
+		if c.Service != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func printCostsReport(cs []costs.MonthlyCost) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tMONTH\tCOST")
+	for _, c := range cs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", cmp.Or(c.Service, "(unattributed)"), c.Month, costs.Format(c.Amount, c.Currency))
+	}
+	w.Flush()
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/daemon"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/runlog"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+	"github.com/ziadkadry99/auto-doc/internal/site"
+	"github.com/ziadkadry99/auto-doc/internal/sitehooks"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep registered repos fresh by re-syncing them on cron schedules",
+	Long: `Runs until interrupted, syncing each registered repo when its schedule in
+the daemon section of .autodoc.yml says it is due (hourly by default). A sync
+clones a missing git checkout or pulls an existing one, runs autodoc update in
+it when daemon.index is set, and re-imports it. After each round that synced
+anything, the central site is regenerated and the architecture changes found
+are dispatched as notifications.
+
+Each round also records the topics' payload schemas, from the schema registry
+in the schema_registry section and from the .avsc and JSON Schema files in the
+checkouts, and notifies the services on a topic of each new version's field
+changes.
+
+Each import also records the repo's health: its last commit, CI, open pull
+requests, and tests. A repo without a commit for daemon.quiet_days (90 by
+default) is reported to its owners once, until it gets a commit.
+
+On the schedule in daemon.reminders (weekly by default), the teams whose
+services still use a deprecated endpoint or topic are notified, until none do.
+
+Health, Prometheus metrics, and per-repo sync state are served on --listen at
+/healthz, /metrics, and /status.`,
+	RunE: runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().String("listen", "", "address for /healthz and /metrics (default daemon.listen, or :9091)")
+	daemonCmd.Flags().Bool("sync-on-start", false, "sync every repo once at startup instead of waiting for its schedule")
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	listen, _ := cmd.Flags().GetString("listen")
+	if listen == "" {
+		listen = cfg.Daemon.Listen
+	}
+	if listen == "" {
+		listen = ":9091"
+	}
+	syncOnStart, _ := cmd.Flags().GetBool("sync-on-start")
+
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	vecStore, err := createCentralVectorStore(cfg)
+	if err != nil {
+		return fmt.Errorf("creating vector store: %w", err)
+	}
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
+	}
+	repoStore := registry.NewStore(database)
+	importer := registry.NewImporter(repoStore, vecStore, cfg.Quality)
+	projectName := projectNameFromWd()
+
+	jobs := daemon.Jobs{
+		Import: func(ctx context.Context, repo *registry.Repository) error {
+			if _, err := reimportRepo(ctx, cfg, database, importer, repo); err != nil {
+				return err
+			}
+			return vecStore.Persist(ctx, filepath.Join(cfg.OutputDir, "vectordb"))
+		},
+		Publish: func(ctx context.Context) error {
+			validation := &site.ValidationReport{}
+			pages, published, err := runCentralSite(cfg, store, filepath.Join(cfg.OutputDir, "site"), projectName, "", validation)
+			if err != nil {
+				return err
+			}
+			printPublished(published)
+			announcePublished(ctx, cfg, sitehooks.Event{Command: "daemon", Project: projectName, Format: site.FormatHTML, Pages: pages}, published)
+			collectArtifacts(ctx, store)
+			return reportValidation(validation, cfg.Site.Strict)
+		},
+	}
+	schemas := schemareg.NewStore(database)
+	schemaRegistry := schemareg.New(cfg.SchemaRegistry)
+	jobs.Schemas = func(ctx context.Context) ([]schemareg.Schema, error) {
+		return syncSchemas(ctx, repoStore, schemas, schemaRegistry)
+	}
+	ctxStore := contextengine.NewStore(database)
+	jobs.Deprecations = func(ctx context.Context) ([]deprecation.Usage, error) {
+		return deprecationUsages(ctx, cfg, repoStore, ctxStore)
+	}
+	if quietAfter := repohealth.QuietAfter(cfg.Daemon.QuietDays); quietAfter > 0 {
+		health := repohealth.NewStore(database)
+		jobs.Quiet = func(ctx context.Context) ([]repohealth.Health, error) {
+			return health.NewlyQuiet(ctx, time.Now().Add(-quietAfter))
+		}
+	}
+	if cfg.Daemon.Index {
+		self, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("finding the autodoc binary: %w", err)
+		}
+		jobs.Index = func(ctx context.Context, repo registry.Repository) error {
+			update := exec.CommandContext(ctx, self, "update")
+			update.Dir = repo.LocalPath
+			update.Stdout = os.Stderr
+			update.Stderr = os.Stderr
+			return update.Run()
+		}
+	}
+
+	d, err := daemon.New(repoStore, cfg.Daemon, jobs)
+	if err != nil {
+		return err
+	}
+	templates, err := notificationTemplates(cfg)
+	if err != nil {
+		return err
+	}
+	dispatcher := notifications.NewDispatcher(notifications.NewStore(database))
+	dispatcher.SetTagSource(repoStore)
+	dispatcher.SetTemplates(templates)
+	d.SetNotifications(dispatcher, orgstructure.NewStore(database))
+	d.SetRunLog(runlog.Open(cfg.OutputDir))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: listen, Handler: d.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+			stop()
+		}
+	}()
+	defer srv.Shutdown(context.Background())
+
+	fmt.Fprintf(os.Stderr, "autodoc daemon v%s: health and metrics on %s\n", Version, listen)
+	if err := d.Run(ctx, syncOnStart); err != nil {
+		return err
+	}
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serving health and metrics: %w", err)
+	default:
+		fmt.Fprintln(os.Stderr, "\nShutting down daemon...")
+		return nil
+	}
+}
+
+// syncSchemas records the new versions in the schema registry, when one is
+// configured, and the schemas declared in the registered checkouts, and
+// returns the new versions of schemas already known. A registry that can't
+// be read is skipped with a warning.
+func syncSchemas(ctx context.Context, repos *registry.Store, store *schemareg.Store, client *schemareg.Client) ([]schemareg.Schema, error) {
+	var found []schemareg.Schema
+	if client != nil {
+		known, err := store.Latest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fetched, err := client.Fetch(ctx, known)
+		if err != nil {
+			slog.Warn("could not read the schema registry", "phase", "schemas", "err", err)
+		}
+		found = append(found, fetched...)
+	}
+	list, err := repos.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range list {
+		if r.Parent == "" && r.LocalPath != "" {
+			found = append(found, schemareg.ScanRepo(r.Name, r.LocalPath)...)
+		}
+	}
+	return store.Record(ctx, found)
+}
+
+// deprecationUsages loads the deprecated endpoints of the registered repos
+// and finds the services still using them.
+func deprecationUsages(ctx context.Context, cfg *config.Config, repos *registry.Store, facts contextengine.FactSource) ([]deprecation.Usage, error) {
+	list, err := repos.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := deprecation.Load(ctx, cfg.Deprecations, facts, list)
+	if err != nil {
+		return nil, err
+	}
+	links, err := repos.GetLinks(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return deprecation.Usages(deps, links), nil
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/export"
+	"github.com/ziadkadry99/auto-doc/internal/site"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a curated, customer-facing docs bundle",
+	Long: `Builds a branding-safe copy of the generated documentation for customers.
+Only pages matching export.pages (and, if set, the "##" sections listed in
+export.sections) are included, internal names are replaced using the
+export.aliases map, and links to pages that were not exported are removed.`,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().String("output", "", "override output directory (defaults to {outputDir}/export)")
+	exportCmd.Flags().Bool("site", false, "also render the bundle as a static HTML site in {output}/site")
+	exportCmd.Flags().String("title", "Documentation", "project name shown on the exported site")
+	rootCmd.AddCommand(exportCmd)
+
+	for _, c := range []*cobra.Command{exportHTMLCmd, exportPDFCmd} {
+		c.Flags().String("docs", "", "markdown docs to export (defaults to {outputDir}/docs)")
+		c.Flags().String("output", "", "output file (defaults to {outputDir}/export/docs.html or docs.pdf)")
+		c.Flags().String("title", "", "title of the document (defaults to the working directory's name)")
+		c.Flags().String("mermaid", "mmdc", "mermaid-cli executable used to rasterize diagrams; empty leaves them as source")
+		exportCmd.AddCommand(c)
+	}
+	exportPDFCmd.Flags().String("browser", "", "Chromium-based browser used to print the PDF (defaults to the first found on PATH)")
+}
+
+var exportHTMLCmd = &cobra.Command{
+	Use:   "html",
+	Short: "Export the docs as one self-contained HTML file",
+	Long: `Renders every generated page into a single HTML file with the stylesheet,
+images, and Mermaid diagrams embedded, so it opens offline and can be shared
+where hosting the static site is not possible. Diagrams are rasterized with
+mermaid-cli (mmdc); without it they are shown as source.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExportDocument(cmd, "html")
+	},
+}
+
+var exportPDFCmd = &cobra.Command{
+	Use:   "pdf",
+	Short: "Export the docs as a PDF",
+	Long: `Renders every generated page into a single document, as "export html" does,
+and prints it to PDF with a headless Chromium-based browser (chromium or
+google-chrome on the PATH, or --browser). Each page starts on a new sheet.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExportDocument(cmd, "pdf")
+	},
+}
+
+func runExportDocument(cmd *cobra.Command, format string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	docsDir, _ := cmd.Flags().GetString("docs")
+	if docsDir == "" {
+		docsDir = filepath.Join(cfg.OutputDir, "docs")
+	}
+	if _, err := os.Stat(docsDir); os.IsNotExist(err) {
+		return fmt.Errorf("docs directory not found at %s\nRun `autodoc generate` first to create documentation", docsDir)
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = filepath.Join(cfg.OutputDir, "export", "docs."+format)
+	}
+	title, _ := cmd.Flags().GetString("title")
+	if title == "" {
+		title = projectNameFromWd()
+	}
+
+	// Find the browser before rendering, so a missing one fails fast.
+	var browser string
+	if format == "pdf" {
+		browser, _ = cmd.Flags().GetString("browser")
+		if browser == "" {
+			if browser, err = export.FindBrowser(); err != nil {
+				return err
+			}
+		}
+	}
+
+	page := &site.SinglePage{DocsDir: docsDir, ProjectName: title}
+	if mmdc, _ := cmd.Flags().GetString("mermaid"); mmdc != "" {
+		if _, err := exec.LookPath(mmdc); err == nil {
+			page.Diagrams = export.MermaidCLI{Command: mmdc}
+		} else {
+			slog.Warn("mermaid CLI not found; diagrams will be shown as source. Install it with `npm install -g @mermaid-js/mermaid-cli`", "command", mmdc)
+		}
+	}
+	document, err := page.Render(ctx)
+	if err != nil {
+		return fmt.Errorf("rendering document: %w", err)
+	}
+	for _, err := range page.Unrendered {
+		slog.Warn("diagram left as source", "err", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	if format == "pdf" {
+		if err := export.PrintPDF(ctx, browser, document, output); err != nil {
+			return fmt.Errorf("printing PDF: %w", err)
+		}
+	} else if err := os.WriteFile(output, document, 0o644); err != nil {
+		return fmt.Errorf("writing document: %w", err)
+	}
+	fmt.Printf("Exported docs to %s\n", output)
+	return nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	docsDir := filepath.Join(cfg.OutputDir, "docs")
+	if _, err := os.Stat(docsDir); os.IsNotExist(err) {
+		return fmt.Errorf("docs directory not found at %s\nRun `autodoc generate` first to create documentation", docsDir)
+	}
+
+	outputDir, _ := cmd.Flags().GetString("output")
+	if outputDir == "" {
+		outputDir = filepath.Join(cfg.OutputDir, "export")
+	}
+	bundleDir := filepath.Join(outputDir, "docs")
+
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Export into a staging directory and publish the bundle through the
+	// artifact store, so pages removed from the whitelist do not linger.
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	stageDir, err := os.MkdirTemp(outputDir, ".staging-export-")
+	if err != nil {
+		return fmt.Errorf("creating staging dir: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	exporter := export.NewExporter(docsDir, stageDir, cfg.Export)
+	count, err := exporter.Export()
+	if err != nil {
+		return fmt.Errorf("exporting docs: %w", err)
+	}
+	// A bundle written before the store existed has no snapshot to say
+	// which of its pages are stale, so start it from scratch once.
+	if prev, err := store.Latest(ctx, bundleDir); err == nil && prev == nil {
+		if err := os.RemoveAll(bundleDir); err != nil {
+			return fmt.Errorf("cleaning export directory: %w", err)
+		}
+	}
+	published, err := store.Publish(ctx, stageDir, bundleDir)
+	if err != nil {
+		return fmt.Errorf("publishing export: %w", err)
+	}
+	fmt.Printf("Exported %d pages to %s\n", count, bundleDir)
+	printPublished(published)
+
+	if withSite, _ := cmd.Flags().GetBool("site"); withSite {
+		title, _ := cmd.Flags().GetString("title")
+		siteDir := filepath.Join(outputDir, "site")
+		generator := site.NewSiteGenerator(bundleDir, siteDir, title)
+		generator.Artifacts = store
+		generator.Assets = siteAssets(cfg)
+		generator.Theme = siteTheme(cfg)
+		pageCount, err := generator.Generate()
+		if err != nil {
+			return fmt.Errorf("generating export site: %w", err)
+		}
+		fmt.Printf("Static site generated: %s (%d pages)\n", siteDir, pageCount)
+		printPublished(generator.Published)
+	}
+
+	collectArtifacts(ctx, store)
+	return nil
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,9 +15,13 @@ import (
 	"github.com/ziadkadry99/auto-doc/internal/config"
 	bizctx "github.com/ziadkadry99/auto-doc/internal/context"
 	"github.com/ziadkadry99/auto-doc/internal/docs"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
 	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
 	"github.com/ziadkadry99/auto-doc/internal/progress"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 	"github.com/ziadkadry99/auto-doc/internal/walker"
 )
@@ -30,12 +36,15 @@ var generateCmd = &cobra.Command{
 func init() {
 	generateCmd.Flags().Bool("dry-run", false, "estimate costs without making API calls")
 	generateCmd.Flags().Int("concurrency", 0, "max parallel LLM calls (overrides config)")
+	generateCmd.Flags().Float64("max-cost", 0, "stop analyzing files before the run costs more than this many USD (overrides config)")
+	generateCmd.Flags().Int("max-tokens", 0, "stop analyzing files before the run uses more than this many tokens (overrides config)")
+	generateCmd.Flags().String("llm-cache", "", "LLM cache mode: readwrite, replay, or off (overrides config)")
 	generateCmd.Flags().Bool("interactive", false, "collect business context interactively")
 	generateCmd.Flags().String("context-file", "", "path to a business context JSON file")
 	rootCmd.AddCommand(generateCmd)
 }
 
-func runGenerate(cmd *cobra.Command, args []string) error {
+func runGenerate(cmd *cobra.Command, args []string) (err error) {
 	start := time.Now()
 	ctx := context.Background()
 
@@ -50,11 +59,17 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	if concurrency > 0 {
 		cfg.MaxConcurrency = concurrency
 	}
+	if err := applyLLMCacheFlag(cmd, cfg); err != nil {
+		return err
+	}
 
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	interactive, _ := cmd.Flags().GetBool("interactive")
 	contextFile, _ := cmd.Flags().GetString("context-file")
 
+	run := startRun(cfg, "generate", projectNameFromWd())
+	defer func() { finishRun(run, err) }()
+
 	// Collect or load business context.
 	var businessCtx *bizctx.BusinessContext
 
@@ -67,7 +82,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 			businessCtx = collected
 			savePath := filepath.Join(".autodoc", "context.json")
 			if err := businessCtx.Save(savePath); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: could not save context: %v\n", err)
+				slog.Warn("could not save context", "phase", "context", "err", err)
 			} else if verbose {
 				fmt.Fprintf(os.Stderr, "Business context saved to %s\n", savePath)
 			}
@@ -87,7 +102,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf("loading context file: %w", err)
 			}
 			if verbose {
-				fmt.Fprintf(os.Stderr, "Warning: could not load context: %v\n", err)
+				slog.Warn("could not load context", "phase", "context", "err", err)
 			}
 		}
 		if loaded != nil {
@@ -109,11 +124,20 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "Scanning files in %s...\n", rootDir)
 	}
 
+	var tooLarge []string
+	var coverage docs.Coverage
 	files, err := walker.Walk(walker.WalkerConfig{
 		RootDir:     rootDir,
 		Include:     cfg.Include,
 		Exclude:     cfg.Exclude,
-		MaxFileSize: 0, // Use default.
+		Languages:   cfg.Languages,
+		MaxFileSize: cfg.LargeFiles.IgnoreSize(),
+		OnTooLarge: func(relPath string, size int64) {
+			tooLarge = append(tooLarge, fmt.Sprintf("%s (%s)", relPath, indexer.FormatSize(size)))
+		},
+		OnSkip: func(relPath string, reason walker.SkipReason) {
+			coverage.Skip(relPath, string(reason), "")
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("walking codebase: %w", err)
@@ -129,7 +153,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize LLM provider.
-	llmProvider, err := llm.NewProvider(string(cfg.Provider), cfg.Model)
+	llmProvider, err := createIndexingProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating LLM provider: %w", err)
 	}
@@ -139,9 +163,10 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("creating embedder: %w", err)
 	}
+	embedCache := cacheEmbeddings(cfg, embedder)
 
 	// Initialize vector store.
-	store, err := vectordb.NewChromemStore(embedder)
+	store, err := createVectorStore(cfg, embedCache)
 	if err != nil {
 		return fmt.Errorf("creating vector store: %w", err)
 	}
@@ -154,8 +179,10 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Create pipeline.
-	pipeline := indexer.NewPipeline(llmProvider, embedder, store, cfg, rootDir)
+	// Create pipeline. Each task calls through its own metered provider, so
+	// the summary can break the cost down by task.
+	meter := llm.NewMeter()
+	pipeline := indexer.NewPipeline(meter.Provider(llmProvider, config.TaskAnalysis), embedCache, store, cfg, rootDir)
 
 	// Handle dry-run mode.
 	if dryRun {
@@ -164,9 +191,14 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("dry run failed: %w", err)
 		}
 		printCostEstimate(estimate, cfg)
+		printExcluded(&coverage)
+		run.Summary = "dry run: cost estimate only"
 		return nil
 	}
 
+	budget := analysisBudget(cmd, cfg)
+	pipeline.SetBudget(budget)
+
 	// Set up progress reporting.
 	reporter := progress.NewReporter()
 	reporter.Start(len(files))
@@ -184,7 +216,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	// Save analyses for dependency-aware incremental updates.
 	if len(result.Analyses) > 0 {
 		if err := indexer.SaveAnalyses(rootDir, result.Analyses); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to save analyses cache: %v\n", err)
+			slog.Warn("failed to save analyses cache", "phase", "analysis", "err", err)
 		} else if verbose {
 			fmt.Fprintf(os.Stderr, "Saved %d analyses to .autodoc/analyses.json\n", len(result.Analyses))
 		}
@@ -193,6 +225,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	// Generate markdown documentation.
 	docGen := docs.NewDocGenerator(cfg.OutputDir)
 	docGen.BusinessContext = businessCtx
+	docGen.Views = mapviews.ForMap(cfg.Site.Views, config.MapComponent)
 
 	// Collect analyses from the pipeline results for doc generation.
 	// We need to re-walk files to collect analyses that were already stored.
@@ -204,37 +237,66 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "Generating markdown documentation...\n")
 	}
 
-	// Generate documentation for all tiers.
-	allDocs, err := getAllFileAnalyses(ctx, store, files)
+	// Generate documentation for all tiers. Files skipped as unchanged keep
+	// their cached analyses, which carry the function and type details the
+	// vector store documents may omit.
+	stored := result.Analyses
+	if cached, err := indexer.LoadAnalyses(rootDir); err == nil {
+		if stored == nil {
+			stored = make(map[string]indexer.FileAnalysis, len(cached))
+		}
+		for path, a := range cached {
+			if _, ok := stored[path]; !ok {
+				stored[path] = a
+			}
+		}
+	}
+	allDocs, err := getAllFileAnalyses(ctx, store, files, stored)
+	coverage.Account(files, allDocs, stored, result.Failed, result.Deferred)
+	docGen.Coverage = &coverage
+	if err := docGen.GenerateCoverage(); err != nil {
+		slog.Warn("failed to generate coverage report", "phase", "docs", "err", err)
+	}
+	overviewProvider := meter.Provider(llmProvider, config.TaskOverview)
 	if err == nil && len(allDocs) > 0 {
 		if err := docGen.GenerateFileDocs(allDocs); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to generate file docs: %v\n", err)
+			slog.Warn("failed to generate file docs", "phase", "docs", "err", err)
+		}
+		if err := docGen.GenerateDirectoryDocs(ctx, allDocs, overviewProvider, cfg.ModelFor(config.TaskOverview)); err != nil {
+			slog.Warn("failed to generate directory overviews", "phase", "docs", "err", err)
+		}
+		if err := docGen.GenerateAPIReference(allDocs); err != nil {
+			slog.Warn("failed to generate API reference", "phase", "docs", "err", err)
 		}
 
 		// Enhanced index with LLM-generated overview and features (all tiers).
 		if verbose {
 			fmt.Fprintf(os.Stderr, "Generating enhanced home page...\n")
 		}
-		if err := docGen.GenerateEnhancedIndex(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: enhanced index generation failed, falling back to basic index: %v\n", err)
+		if overviewProvider == nil {
+			if err := docGen.GenerateIndex(allDocs); err != nil {
+				slog.Warn("failed to generate index", "phase", "docs", "err", err)
+			}
+		} else if err := docGen.GenerateEnhancedIndex(ctx, allDocs, overviewProvider, cfg.ModelFor(config.TaskOverview)); err != nil {
+			slog.Warn("enhanced index generation failed, falling back to basic index", "phase", "docs", "err", err)
 			if err := docGen.GenerateIndex(allDocs); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to generate index: %v\n", err)
+				slog.Warn("failed to generate index", "phase", "docs", "err", err)
 			}
 		}
 
 		// Architecture overview for Normal and Max tiers only.
-		if cfg.Quality != config.QualityLite {
+		if cfg.Quality != config.QualityLite && llmProvider != nil {
 			if verbose {
 				fmt.Fprintf(os.Stderr, "Generating architecture overview...\n")
 			}
-			if err := docGen.GenerateArchitecture(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: architecture generation failed: %v\n", err)
+			if err := docGen.GenerateArchitecture(ctx, allDocs, meter.Provider(llmProvider, config.TaskArchitecture), cfg.ModelFor(config.TaskArchitecture)); err != nil {
+				slog.Warn("architecture generation failed", "phase", "architecture", "err", err)
 			} else {
 				// Index the architecture doc into the vector store.
 				archPath := filepath.Join(cfg.OutputDir, "docs", "architecture.md")
 				if archDocs, archErr := indexArchitecture(archPath); archErr == nil && len(archDocs) > 0 {
 					if err := store.AddDocuments(ctx, archDocs); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to index architecture doc: %v\n", err)
+						slog.Warn("failed to index architecture doc", "phase", "architecture", "err", err)
 					} else if verbose {
 						fmt.Fprintf(os.Stderr, "Indexed %d architecture sections into vector store\n", len(archDocs))
 					}
@@ -243,10 +305,39 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Architecture Decision Records: render a Decisions page and index them.
+	adrs, err := importers.LoadRepoADRs(rootDir)
+	if err != nil {
+		slog.Warn("failed to read ADRs", "phase", "decisions", "err", err)
+	} else if len(adrs) > 0 {
+		services, flowNames := knownServicesAndFlows(ctx, cfg, rootDir)
+		importers.LinkADRs(adrs, services, flowNames)
+		if err := docGen.GenerateDecisions(adrs); err != nil {
+			slog.Warn("failed to generate decisions page", "phase", "decisions", "err", err)
+		}
+		if err := store.AddDocuments(ctx, importers.ADRDocuments(adrs, "")); err != nil {
+			slog.Warn("failed to index ADRs", "phase", "decisions", "err", err)
+		} else if verbose {
+			fmt.Fprintf(os.Stderr, "Indexed %d architecture decision records\n", len(adrs))
+		}
+	}
+
 	// Persist the vector store (after architecture indexing).
 	if err := store.Persist(ctx, vectorDir); err != nil {
 		return fmt.Errorf("persisting vector store: %w", err)
 	}
+	saveEmbeddingCache(cfg, embedCache)
+	reportLLMCache(cfg, llmProvider)
+
+	run.Summary = fmt.Sprintf("%d files processed, %d skipped, %d failed", result.FilesProcessed, result.FilesSkipped, result.FilesFailed)
+	analyzed := make([]string, 0, len(result.Analyses))
+	for path := range result.Analyses {
+		analyzed = append(analyzed, path)
+	}
+	sort.Strings(analyzed)
+	for _, path := range analyzed {
+		run.Changed("analyzed %s", path)
+	}
 
 	// Print summary.
 	duration := time.Since(start)
@@ -255,12 +346,8 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Files processed: %d\n", result.FilesProcessed)
 	fmt.Printf("  Files skipped:   %d (unchanged)\n", result.FilesSkipped)
 	fmt.Printf("  Files failed:    %d\n", result.FilesFailed)
-	fmt.Printf("  Tokens used:     %d input, %d output\n", result.TotalInputTokens, result.TotalOutputTokens)
-
-	cost := llm.EstimateCost(cfg.Model, result.TotalInputTokens, result.TotalOutputTokens)
-	if cost > 0 {
-		fmt.Printf("  Estimated cost:  $%.4f\n", cost)
-	}
+	fmt.Printf("  Coverage:        %d%% (%d of %d files)\n", coverage.Percent(), coverage.Documented, coverage.Considered())
+	printTaskUsage(meter, 16)
 	fmt.Printf("  Duration:        %s\n", duration.Round(time.Millisecond))
 	fmt.Printf("  Output:          %s\n", cfg.OutputDir)
 
@@ -270,33 +357,176 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "  - %v\n", e)
 		}
 	}
+	printDiscrepancies(result.Discrepancies)
+	printLargeFiles(result.Truncated, tooLarge, cfg.LargeFiles.IgnoreSize())
+	printRedactions(result.Redacted)
+	printDeferred(result.Deferred, budget)
 
 	return nil
 }
 
-// getAllFileAnalyses reconstructs minimal FileAnalysis objects from the vector store.
-func getAllFileAnalyses(ctx context.Context, store vectordb.VectorStore, files []walker.FileInfo) ([]indexer.FileAnalysis, error) {
+// analysisBudget is the spending cap for file analysis: the --max-cost and
+// --max-tokens flags, falling back to max_cost_usd and max_tokens in the
+// config. It returns nil when neither is set.
+func analysisBudget(cmd *cobra.Command, cfg *config.Config) *indexer.Budget {
+	if v, _ := cmd.Flags().GetFloat64("max-cost"); v > 0 {
+		cfg.MaxCostUSD = v
+	}
+	if v, _ := cmd.Flags().GetInt("max-tokens"); v > 0 {
+		cfg.MaxTokens = v
+	}
+	model := cfg.ModelFor(config.TaskAnalysis)
+	budget := indexer.NewBudget(model, cfg.MaxCostUSD, cfg.MaxTokens)
+	if _, _, priced := llm.Pricing(string(cfg.Provider), model); budget != nil && cfg.MaxCostUSD > 0 && !priced &&
+		(verbose || cmd.Flags().Changed("max-cost")) {
+		slog.Warn("no prices known for the model, so the cost limit can't be enforced; use --max-tokens instead", "model", model)
+	}
+	return budget
+}
+
+// printTaskUsage prints the tokens and cost of a run, broken down by task
+// when more than one task called the LLM. Labels are padded to width, to
+// line up with the rest of the summary.
+func printTaskUsage(meter *llm.Meter, width int) {
+	usage := meter.Usage()
+	if len(usage) == 0 {
+		return
+	}
+	total := meter.Total()
+	fmt.Printf("  %-*s %d input, %d output\n", width, "Tokens used:", total.InputTokens, total.OutputTokens)
+	if total.CostUSD > 0 {
+		fmt.Printf("  %-*s $%.4f\n", width, "Estimated cost:", total.CostUSD)
+	}
+	if total.CachedCalls > 0 {
+		fmt.Printf("  %-*s %d of %d calls\n", width, "Cached:", total.CachedCalls, total.Calls)
+	}
+	if len(usage) < 2 {
+		return
+	}
+	for _, u := range usage {
+		line := fmt.Sprintf("    %-*s %d calls, %d input, %d output", width-2, u.Task+":", u.Calls, u.InputTokens, u.OutputTokens)
+		if u.CostUSD > 0 {
+			line += fmt.Sprintf(", $%.4f", u.CostUSD)
+		}
+		fmt.Println(line)
+	}
+}
+
+// printDeferred lists the files the budget left for the next run.
+func printDeferred(deferred []string, budget *indexer.Budget) {
+	if len(deferred) == 0 {
+		return
+	}
+	in, out, cost := budget.Spent()
+	fmt.Fprintf(os.Stderr, "\nBudget of %s reached after %d input and %d output tokens", budget, in, out)
+	if cost > 0 {
+		fmt.Fprintf(os.Stderr, " ($%.4f)", cost)
+	}
+	fmt.Fprintf(os.Stderr, "; deferred to the next run (%d):\n", len(deferred))
+	for _, path := range deferred {
+		fmt.Fprintf(os.Stderr, "  - %s\n", path)
+	}
+}
+
+// printDiscrepancies lists what the crosscheck analyzer corrected or doubts
+// in the LLM's file analyses.
+func printDiscrepancies(ds []indexer.Discrepancy) {
+	if len(ds) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nCross-check (%d):\n", len(ds))
+	for _, d := range ds {
+		fmt.Fprintf(os.Stderr, "  - %s\n", d)
+	}
+}
+
+// printRedactions lists, per file, the secrets and personal data masked
+// before the source was sent to the LLM.
+func printRedactions(reports []indexer.RedactionReport) {
+	if len(reports) == 0 {
+		return
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].FilePath < reports[j].FilePath })
+	fmt.Fprintf(os.Stderr, "\nRedacted before analysis (%d files):\n", len(reports))
+	for _, r := range reports {
+		fmt.Fprintf(os.Stderr, "  - %s\n", r)
+	}
+}
+
+// printLargeFiles lists the files that were sampled or stubbed for their
+// size, and those left out of the index entirely.
+func printLargeFiles(truncated []indexer.TruncationReport, ignored []string, ignoreAbove int64) {
+	if len(truncated) > 0 {
+		fmt.Fprintf(os.Stderr, "\nLarge files (%d):\n", len(truncated))
+		for _, t := range truncated {
+			fmt.Fprintf(os.Stderr, "  - %s\n", t)
+		}
+	}
+	if len(ignored) > 0 {
+		fmt.Fprintf(os.Stderr, "\nIgnored, over %s (%d):\n", indexer.FormatSize(ignoreAbove), len(ignored))
+		for _, path := range ignored {
+			fmt.Fprintf(os.Stderr, "  - %s\n", path)
+		}
+	}
+}
+
+// knownServicesAndFlows returns the names ADRs are linked to: this project
+// and, when there is a central database, the registered services and the
+// flows recorded in it.
+func knownServicesAndFlows(ctx context.Context, cfg *config.Config, rootDir string) (services, flowNames []string) {
+	if abs, err := filepath.Abs(rootDir); err == nil {
+		services = append(services, filepath.Base(abs))
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "autodoc.db")); err != nil {
+		return services, nil
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		slog.Warn("failed to open central database", "phase", "decisions", "err", err)
+		return services, nil
+	}
+	defer database.Close()
+
+	if repos, err := registry.NewStore(database).List(ctx); err == nil {
+		for _, r := range repos {
+			services = append(services, r.Name)
+		}
+	}
+	if allFlows, err := flows.NewStore(database).ListFlows(ctx); err == nil {
+		for _, f := range allFlows {
+			flowNames = append(flowNames, f.Name)
+		}
+	}
+	return services, flowNames
+}
+
+// getAllFileAnalyses collects the FileAnalysis of every file for doc
+// generation. Stored analyses are used when they match the file's content
+// hash; otherwise the file-level fields are recovered from the vector store.
+func getAllFileAnalyses(ctx context.Context, store vectordb.VectorStore, files []walker.FileInfo, stored map[string]indexer.FileAnalysis) ([]indexer.FileAnalysis, error) {
 	var analyses []indexer.FileAnalysis
 	for _, f := range files {
-		docs, err := store.GetByFilePath(ctx, f.RelPath)
-		if err != nil || len(docs) == 0 {
+		if a, ok := stored[f.RelPath]; ok && a.ContentHash == f.ContentHash && !a.Skip {
+			analyses = append(analyses, a)
 			continue
 		}
 
-		analysis := indexer.FileAnalysis{
-			FilePath:    f.RelPath,
-			Language:    f.Language,
-			ContentHash: f.ContentHash,
+		docs, err := store.GetByFilePath(ctx, f.RelPath)
+		if err != nil || len(docs) == 0 {
+			continue
 		}
 
-		// Extract summary from the file-level document.
+		// Extract the file-level document (the dependency document shares its type).
 		for _, doc := range docs {
-			if doc.Metadata.Type == vectordb.DocTypeFile {
-				analysis.Summary = doc.Content
+			if doc.Metadata.Type == vectordb.DocTypeFile && doc.Metadata.Symbol == "" {
+				analysis := indexer.ParseFileDocument(doc.Content)
+				analysis.FilePath = f.RelPath
+				analysis.Language = f.Language
+				analysis.ContentHash = f.ContentHash
+				analyses = append(analyses, analysis)
+				break
 			}
 		}
-
-		analyses = append(analyses, analysis)
 	}
 	return analyses, nil
 }
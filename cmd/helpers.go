@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
 
 	"github.com/ziadkadry99/auto-doc/internal/auth"
 	"github.com/ziadkadry99/auto-doc/internal/config"
 	"github.com/ziadkadry99/auto-doc/internal/embeddings"
 	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
 // createEmbedderFromConfig creates an embeddings.Embedder based on config.
@@ -17,7 +25,7 @@ func createEmbedderFromConfig(cfg *config.Config) (embeddings.Embedder, error) {
 	if provider == "" {
 		provider = cfg.Provider
 	}
-	model := cfg.EmbeddingModel
+	model := cfg.ModelFor(config.TaskEmbedding)
 	if model == "" {
 		preset := config.GetPreset(provider, cfg.Quality)
 		model = preset.EmbeddingModel
@@ -44,6 +52,14 @@ func createEmbedderFromConfig(cfg *config.Config) (embeddings.Embedder, error) {
 		return nil, fmt.Errorf("Google API credentials not found.\nRun `autodoc auth google` or set GOOGLE_API_KEY")
 	case config.ProviderOllama:
 		return embeddings.NewOllamaEmbedder(model, 768, ""), nil
+	case config.ProviderLocal:
+		local := cfg.Providers.Local
+		if len(local.Command) > 0 {
+			return embeddings.NewLocalEmbedder(cfg.ModelFor(config.TaskEmbedding), local.Command, local.Dimensions), nil
+		}
+		return embeddings.NewPythonEmbedder(local.Python, cfg.ModelFor(config.TaskEmbedding), local.Dimensions), nil
+	case config.ProviderMock:
+		return embeddings.NewMockEmbedder(0), nil
 	default:
 		// For providers without native embeddings, fall back to OpenAI.
 		apiKey := auth.GetAPIKey("openai")
@@ -54,9 +70,199 @@ func createEmbedderFromConfig(cfg *config.Config) (embeddings.Embedder, error) {
 	}
 }
 
-// createLLMProviderFromConfig creates an LLM provider based on config settings.
+// createVectorStore creates the vector store configured under vector_store,
+// wrapped for hybrid search unless search.mode is vector. The local store
+// starts empty, and callers load it from the output directory; the
+// server-backed stores read the shared index as they go.
+func createVectorStore(cfg *config.Config, embedder embeddings.Embedder) (vectordb.VectorStore, error) {
+	store, err := createBackendStore(cfg, embedder)
+	if err != nil || cfg.Search.Mode == config.SearchVector {
+		return store, err
+	}
+	hybrid := vectordb.NewHybridStore(store, createReranker(cfg))
+	if !usesLocalVectorStore(cfg) {
+		// Other users write to a shared store too.
+		hybrid.RefreshEvery = 5 * time.Minute
+	}
+	return hybrid, nil
+}
+
+// createBackendStore creates the configured vector store backend.
+func createBackendStore(cfg *config.Config, embedder embeddings.Embedder) (vectordb.VectorStore, error) {
+	vs := cfg.VectorStore
+	if vs.Backend == "" || vs.Backend == config.VectorStoreLocal {
+		return vectordb.NewChromemStore(embedder)
+	}
+	storeURL := vs.URL
+	if storeURL == "" {
+		storeURL = os.Getenv("AUTODOC_VECTOR_STORE_URL")
+	}
+	if storeURL == "" {
+		return nil, fmt.Errorf("vector_store.url is required for the %s backend (or set AUTODOC_VECTOR_STORE_URL)", vs.Backend)
+	}
+	if vs.Backend == config.VectorStoreQdrant {
+		return vectordb.NewQdrantStore(storeURL, vs.Collection, os.Getenv("QDRANT_API_KEY"), embedder), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return vectordb.NewPgvectorStore(ctx, storeURL, vs.Collection, embedder)
+}
+
+// createReranker returns the reranker search.rerank selects, or nil. An LLM
+// that can't be created disables reranking rather than search.
+func createReranker(cfg *config.Config) vectordb.Reranker {
+	if cfg.Search.Rerank != config.RerankLLM {
+		return nil
+	}
+	provider, err := createLLMProviderFromConfig(cfg)
+	if err != nil {
+		slog.Warn("search reranking disabled", "phase", "search", "err", err)
+		return nil
+	}
+	return vectordb.NewLLMReranker(provider, cfg.ModelFor(config.TaskRerank))
+}
+
+// usesLocalVectorStore reports whether the index is the file in the output
+// directory rather than a shared server.
+func usesLocalVectorStore(cfg *config.Config) bool {
+	return cfg.VectorStore.Backend == "" || cfg.VectorStore.Backend == config.VectorStoreLocal
+}
+
+// cacheEmbeddings wraps an embedder in the embedding cache kept in the
+// output directory, so reindexing doesn't pay again for unchanged chunks.
+// Save the cache with saveEmbeddingCache once the vector store is persisted.
+func cacheEmbeddings(cfg *config.Config, e embeddings.Embedder) *embeddings.CachedEmbedder {
+	cache := embeddings.NewCachedEmbedder(e)
+	if err := cache.Load(filepath.Join(cfg.OutputDir, embeddings.CacheFile)); err != nil {
+		slog.Warn("ignoring the embedding cache", "phase", "cache", "err", err)
+	}
+	return cache
+}
+
+// saveEmbeddingCache writes the embedding cache back to the output directory.
+func saveEmbeddingCache(cfg *config.Config, cache *embeddings.CachedEmbedder) {
+	if err := cache.Save(filepath.Join(cfg.OutputDir, embeddings.CacheFile)); err != nil {
+		slog.Warn("could not save the embedding cache", "phase", "cache", "err", err)
+		return
+	}
+	if verbose && cache.Hits+cache.Misses > 0 {
+		fmt.Fprintf(os.Stderr, "Embeddings: %d reused from the cache, %d computed\n", cache.Hits, cache.Misses)
+	}
+}
+
+// cachedVectorStore saves the embedding cache whenever the store is persisted.
+type cachedVectorStore struct {
+	vectordb.VectorStore
+	cfg   *config.Config
+	cache *embeddings.CachedEmbedder
+}
+
+func (s cachedVectorStore) Persist(ctx context.Context, dir string) error {
+	if err := s.VectorStore.Persist(ctx, dir); err != nil {
+		return err
+	}
+	saveEmbeddingCache(s.cfg, s.cache)
+	return nil
+}
+
+// createLLMProviderFromConfig creates an LLM provider based on config
+// settings, answering repeated prompts from the LLM cache unless
+// llm_cache.mode is off.
 func createLLMProviderFromConfig(cfg *config.Config) (llm.Provider, error) {
-	return llm.NewProvider(string(cfg.Provider), cfg.Model)
+	p, err := llm.NewProviderWithOptions(string(cfg.Provider), cfg.Model, providerOptions(cfg))
+	switch cfg.LLMCache.Mode {
+	case config.LLMCacheOff:
+		return p, err
+	case config.LLMCacheReplay:
+		// Replaying needs no provider, and so no API key.
+		if err != nil {
+			return llm.NewReplayProvider(string(cfg.Provider), llmCacheDir(cfg)), nil
+		}
+		return llm.NewCachedProvider(p, llmCacheDir(cfg), true), nil
+	}
+	if err != nil || cfg.Provider == config.ProviderMock {
+		return p, err
+	}
+	return llm.NewCachedProvider(p, llmCacheDir(cfg), false), nil
+}
+
+// applyLLMCacheFlag applies the --llm-cache flag over llm_cache.mode.
+func applyLLMCacheFlag(cmd *cobra.Command, cfg *config.Config) error {
+	mode, _ := cmd.Flags().GetString("llm-cache")
+	if mode == "" {
+		return nil
+	}
+	switch mode {
+	case config.LLMCacheReadWrite, config.LLMCacheReplay, config.LLMCacheOff:
+		cfg.LLMCache.Mode = mode
+		return nil
+	}
+	return fmt.Errorf("invalid --llm-cache %q: must be one of readwrite, replay, off", mode)
+}
+
+// llmCacheDir is where the LLM cache keeps its responses.
+func llmCacheDir(cfg *config.Config) string {
+	if cfg.LLMCache.Dir != "" {
+		return cfg.LLMCache.Dir
+	}
+	return filepath.Join(cfg.OutputDir, "llm-cache")
+}
+
+// reportLLMCache prunes the LLM cache behind p, if there is one, and in
+// verbose mode reports how many prompts it answered.
+func reportLLMCache(cfg *config.Config, p llm.Provider) {
+	cache, ok := p.(*llm.CachedProvider)
+	if !ok {
+		return
+	}
+	maxAge := cfg.LLMCache.MaxAgeDays
+	if maxAge == 0 {
+		maxAge = 30
+	}
+	if _, err := cache.Prune(time.Duration(maxAge) * 24 * time.Hour); err != nil {
+		slog.Warn("could not prune the LLM cache", "phase", "cache", "err", err)
+	}
+	if hits, misses := cache.Stats(); verbose && hits+misses > 0 {
+		fmt.Fprintf(os.Stderr, "LLM responses: %d reused from the cache, %d requested\n", hits, misses)
+	}
+}
+
+// providerOptions collects the configured provider's settings.
+func providerOptions(cfg *config.Config) llm.ProviderOptions {
+	p := cfg.Providers
+	switch cfg.Provider {
+	case config.ProviderAzure:
+		deployments := make(map[string]string, len(p.Azure.Deployments))
+		for _, d := range p.Azure.Deployments {
+			deployments[d.Model] = d.Deployment
+		}
+		return llm.ProviderOptions{
+			BaseURL:           p.Azure.Endpoint,
+			APIVersion:        p.Azure.APIVersion,
+			Deployments:       deployments,
+			RequestsPerMinute: p.Azure.RequestsPerMinute,
+		}
+	case config.ProviderBedrock:
+		return llm.ProviderOptions{Region: p.Bedrock.Region, RequestsPerMinute: p.Bedrock.RequestsPerMinute}
+	case config.ProviderOpenAICompatible:
+		return llm.ProviderOptions{BaseURL: p.OpenAICompatible.BaseURL, RequestsPerMinute: p.OpenAICompatible.RequestsPerMinute}
+	case config.ProviderOllama:
+		return llm.ProviderOptions{BaseURL: p.Ollama.Host, RequestsPerMinute: p.Ollama.RequestsPerMinute}
+	}
+	return llm.ProviderOptions{}
+}
+
+// createIndexingProvider creates the LLM provider for generate and update.
+// The static analyzer indexes without an LLM, so there a provider that
+// can't be created (e.g. no API key) is not an error: it returns nil, and
+// the LLM-written overview and architecture pages are skipped.
+func createIndexingProvider(cfg *config.Config) (llm.Provider, error) {
+	p, err := createLLMProviderFromConfig(cfg)
+	if err != nil && cfg.Analyzer == config.AnalyzerStatic {
+		fmt.Fprintf(os.Stderr, "Note: no LLM provider (%v); indexing with the static analyzer only\n", strings.SplitN(err.Error(), "\n", 2)[0])
+		return nil, nil
+	}
+	return p, err
 }
 
 // loadConfig loads and validates the config, providing a user-friendly error.
@@ -65,5 +271,8 @@ func loadConfig() (*config.Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("loading config: %w\nRun `autodoc init` to create a config file", err)
 	}
+	for _, p := range cfg.Providers.Pricing {
+		llm.SetPricing(p.Model, p.InputPerMillion, p.OutputPerMillion)
+	}
 	return cfg, nil
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/incident"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
+)
+
+var incidentCmd = &cobra.Command{
+	Use:   "incident <service|endpoint>",
+	Short: "Print the incident context for a failing service or endpoint",
+	Long: `Gathers everything a responder needs in one place: the services that call the
+failing one (directly and transitively), its owning teams and who is on call,
+commits and architecture changes from the last incident.change_days days, the
+flows it takes part in, and its runbooks.
+
+The argument is a registered service name, or an endpoint such as
+"POST /api/orders" when only the failing endpoint is known. Use --endpoint to
+narrow a service's blast radius to the callers of one endpoint.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runIncident,
+}
+
+func init() {
+	incidentCmd.Flags().String("endpoint", "", "only count callers of this endpoint in the blast radius")
+	incidentCmd.Flags().Bool("json", false, "output the bundle as JSON")
+	rootCmd.AddCommand(incidentCmd)
+}
+
+func runIncident(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	ctxStore := contextengine.NewStore(database)
+	builder := incident.NewBuilder(cfg.Incident, database, oncall.NewResolver(cfg.OnCall, ctxStore), ctxStore)
+	bundle, err := builder.Build(ctx, args[0], endpoint)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bundle)
+	}
+	fmt.Print(bundle.Markdown())
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+var librariesCmd = &cobra.Command{
+	Use:   "libraries",
+	Short: "Find the internal libraries the services share and link them",
+	Long: `Reads the package manifests in the registered services' checkouts and finds
+the internal libraries they depend on: the packages a registered repo
+publishes, and those in the namespace of one, such as github.com/acme/ or
+@acme/, that two or more services share. Each service is linked to the
+libraries it depends on, replacing the library links found before, so the
+libraries become nodes of the service map.
+
+Re-linking a repo does the same. To see which services a change to a
+library rebuilds and redeploys, run "autodoc incident <library>".`,
+	RunE: runLibraries,
+}
+
+func init() {
+	librariesCmd.Flags().Bool("json", false, "output the libraries as JSON")
+	rootCmd.AddCommand(librariesCmd)
+}
+
+func runLibraries(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	libs, err := registry.NewStore(database).LinkLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(libs)
+	}
+	if len(libs) == 0 {
+		fmt.Println("No shared internal libraries found.")
+		return nil
+	}
+	for _, l := range libs {
+		name := l.Name
+		if l.Repo != "" {
+			name += " (published by " + l.Repo + ")"
+		}
+		services := make([]string, len(l.Users))
+		for i, u := range l.Users {
+			services[i] = u.Service
+		}
+		fmt.Printf("%s [%s]: %s\n", name, l.Ecosystem, strings.Join(services, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade persisted state to the current schema versions",
+	Long: `Upgrades the database, analyses.json, state.json, and vector store metadata
+written by older autodoc versions, so upgrading autodoc never requires wiping
+accumulated state. Everything is also upgraded automatically when loaded; use
+--dry-run to see what would change.`,
+	RunE: runMigrate,
+}
+
+var migrateVectorStoreCmd = &cobra.Command{
+	Use:   "vector-store",
+	Short: "Copy the local vector store into the configured pgvector or Qdrant backend",
+	Long: `Copies every document in the local vector store ({output_dir}/vectordb)
+into the backend configured under vector_store, with the embeddings it already
+has, so moving to a shared index costs no embedding calls. Documents already in
+the backend are replaced. The local store is left as it is.`,
+	RunE: runMigrateVectorStore,
+}
+
+func init() {
+	migrateCmd.Flags().Bool("dry-run", false, "Report pending migrations without applying them")
+	migrateCmd.AddCommand(migrateVectorStoreCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	verb := "Applied"
+	if dryRun {
+		verb = "Pending"
+	}
+
+	// Database.
+	dbPath := filepath.Join(cfg.OutputDir, "autodoc.db")
+	if _, err := os.Stat(dbPath); err == nil {
+		version, pending, err := pendingDBMigrations(dbPath)
+		if err != nil {
+			return fmt.Errorf("autodoc.db: %w", err)
+		}
+		fmt.Printf("autodoc.db: schema v%d -> v%d\n", version, db.LatestVersion())
+		for _, m := range pending {
+			fmt.Printf("  %s: %d %s\n", verb, m.Version, m.Name)
+		}
+		if !dryRun && len(pending) > 0 {
+			// Opening the database applies pending migrations.
+			database, err := db.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("autodoc.db: %w", err)
+			}
+			database.Close()
+		}
+	}
+
+	// JSON artifacts.
+	report, err := indexer.MigrateArtifacts(rootDir, dryRun)
+	if err != nil {
+		return err
+	}
+	for _, m := range report {
+		fmt.Printf("%s: schema v%d -> v%d\n", m.Artifact, m.From, m.To)
+		for _, step := range m.Steps {
+			fmt.Printf("  %s: %s\n", verb, step)
+		}
+	}
+
+	// Vector store metadata.
+	vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
+	if _, err := os.Stat(vectorDir); err == nil {
+		embedder, err := createEmbedderFromConfig(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping vector store: %v\n", err)
+			return nil
+		}
+		store, err := vectordb.NewChromemStore(embedder)
+		if err != nil {
+			return fmt.Errorf("creating vector store: %w", err)
+		}
+		if err := store.Load(ctx, vectorDir); err != nil {
+			return fmt.Errorf("loading vector store: %w", err)
+		}
+		n, err := store.MigrateMetadata(ctx, dryRun)
+		if err != nil {
+			return fmt.Errorf("vector store: %w", err)
+		}
+		fmt.Printf("vectordb: metadata schema upgrades %s for %d of %d documents\n", strings.ToLower(verb), n, store.Count())
+		if !dryRun && n > 0 {
+			if err := store.Persist(ctx, vectorDir); err != nil {
+				return fmt.Errorf("saving vector store: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// vectorStoreMigrationBatch is how many documents are copied at a time.
+const vectorStoreMigrationBatch = 500
+
+func runMigrateVectorStore(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if usesLocalVectorStore(cfg) {
+		return fmt.Errorf("vector_store.backend is local; set it to pgvector or qdrant to migrate")
+	}
+
+	embedder, err := createEmbedderFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("creating embedder: %w", err)
+	}
+	local, err := vectordb.NewChromemStore(embedder)
+	if err != nil {
+		return fmt.Errorf("creating vector store: %w", err)
+	}
+	vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
+	if err := local.Load(ctx, vectorDir); err != nil {
+		return fmt.Errorf("loading the local vector store from %s: %w", vectorDir, err)
+	}
+	docs, vectors, err := local.Export(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the local vector store: %w", err)
+	}
+	if len(docs) == 0 {
+		fmt.Println("The local vector store is empty; nothing to migrate.")
+		return nil
+	}
+
+	dest, err := createVectorStore(cfg, embedder)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", cfg.VectorStore.Backend, err)
+	}
+	importer, ok := dest.(vectordb.Importer)
+	if !ok {
+		return fmt.Errorf("the %s backend can't import documents", cfg.VectorStore.Backend)
+	}
+	for start := 0; start < len(docs); start += vectorStoreMigrationBatch {
+		end := min(start+vectorStoreMigrationBatch, len(docs))
+		if err := importer.ImportDocuments(ctx, docs[start:end], vectors[start:end]); err != nil {
+			return fmt.Errorf("copying documents %d-%d: %w", start+1, end, err)
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Copied %d of %d documents\n", end, len(docs))
+		}
+	}
+	fmt.Printf("Copied %d documents to %s; the backend now holds %d.\n", len(docs), cfg.VectorStore.Backend, dest.Count())
+	return nil
+}
+
+// pendingDBMigrations reports a database's schema version and the
+// migrations not yet applied to it, without applying them.
+func pendingDBMigrations(path string) (int, []db.Migration, error) {
+	database, err := db.OpenUnmigrated(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer database.Close()
+
+	version, err := database.SchemaVersion()
+	if err != nil {
+		return 0, nil, err
+	}
+	pending, err := database.PendingMigrations()
+	return version, pending, err
+}
@@ -0,0 +1,526 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage notification routing rules and mute windows",
+	Long: `Routing rules send notifications to webhooks and Slack channels based on the
+notification type, severity, affected services (glob patterns), link type, repo
+tags, and teams. They apply on top of per-team preferences. Mute windows silence
+notifications globally or for a team or service.`,
+}
+
+var notifyRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "List routing rules",
+	RunE:  runNotifyRulesList,
+}
+
+var notifyRulesAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create or replace a routing rule",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNotifyRulesAdd,
+}
+
+var notifyRulesRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Delete a routing rule",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNotifyRulesRemove,
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show which rules a sample notification would hit",
+	Long: `Evaluates every routing rule against a sample notification and prints why each
+rule matched or not, followed by the destinations it would be delivered to.
+Nothing is stored or sent. Repo tags are read from the registry. With --preview,
+the message each destination would receive is printed using the templates
+configured under notifications.templates.`,
+	RunE: runNotifyTest,
+}
+
+var notifyMuteCmd = &cobra.Command{
+	Use:   "mute",
+	Short: "Silence notifications for a window of time",
+	Long: `Creates a mute window. Without --team or --service the mute is global. Muted
+notifications are still recorded but are not delivered or included in digests.
+
+  autodoc notify mute --type staleness_detected --until 2026-12-31 --reason "re-platforming"
+  autodoc notify mute --team payments --for 4h
+  autodoc notify mute --service legacy-billing`,
+	RunE: runNotifyMute,
+}
+
+var notifyMutesCmd = &cobra.Command{
+	Use:   "mutes",
+	Short: "List current and upcoming mute windows",
+	RunE:  runNotifyMutes,
+}
+
+var notifyUnmuteCmd = &cobra.Command{
+	Use:   "unmute <id>",
+	Short: "End a mute window",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runNotifyUnmute,
+}
+
+func init() {
+	addMatchFlags := func(c *cobra.Command) {
+		c.Flags().StringSlice("type", nil, "Notification type (repeatable)")
+		c.Flags().StringSlice("service", nil, "Affected service; glob patterns in rules (repeatable)")
+		c.Flags().StringSlice("link-type", nil, "Link type, e.g. http, grpc, kafka (repeatable)")
+		c.Flags().StringSlice("team", nil, "Affected team (repeatable)")
+	}
+
+	addMatchFlags(notifyRulesAddCmd)
+	notifyRulesAddCmd.Flags().String("min-severity", "", "Minimum severity: info, warning, or critical")
+	notifyRulesAddCmd.Flags().StringSlice("tag", nil, "Repo tag any affected service must carry (repeatable)")
+	notifyRulesAddCmd.Flags().StringSlice("webhook", nil, "Webhook URL to POST the notification to (repeatable)")
+	notifyRulesAddCmd.Flags().StringSlice("slack", nil, "Slack incoming webhook URL (repeatable)")
+	notifyRulesAddCmd.Flags().Int("priority", 0, "Evaluation order; lower runs first")
+	notifyRulesAddCmd.Flags().Bool("stop", false, "Stop evaluating later rules when this one matches")
+	notifyRulesAddCmd.Flags().Bool("disabled", false, "Save the rule without enabling it")
+	notifyRulesAddCmd.Flags().String("locale", "", "Message language for the rule's destinations, e.g. de (default: notifications.locale)")
+
+	addMatchFlags(notifyTestCmd)
+	notifyTestCmd.Flags().String("severity", string(notifications.SeverityInfo), "Notification severity")
+	notifyTestCmd.Flags().String("title", "Sample notification", "Title of the sample notification")
+	notifyTestCmd.Flags().String("message", "", "Message of the sample notification")
+	notifyTestCmd.Flags().Bool("preview", false, "Print the rendered message for each destination")
+
+	notifyMuteCmd.Flags().String("team", "", "Mute only this team")
+	notifyMuteCmd.Flags().String("service", "", "Mute only this service")
+	notifyMuteCmd.Flags().StringSlice("type", nil, "Mute only this notification type (repeatable)")
+	notifyMuteCmd.Flags().String("from", "", "Start of the window (YYYY-MM-DD or RFC 3339; default now)")
+	notifyMuteCmd.Flags().String("until", "", "End of the window (YYYY-MM-DD or RFC 3339)")
+	notifyMuteCmd.Flags().Duration("for", 0, "Length of the window, e.g. 4h (instead of --until)")
+	notifyMuteCmd.Flags().String("reason", "", "Why notifications are muted")
+	notifyMutesCmd.Flags().Bool("all", false, "Include expired mute windows")
+
+	notifyRulesCmd.AddCommand(notifyRulesAddCmd, notifyRulesRemoveCmd)
+	notifyCmd.AddCommand(notifyRulesCmd, notifyTestCmd, notifyMuteCmd, notifyMutesCmd, notifyUnmuteCmd)
+	rootCmd.AddCommand(notifyCmd)
+}
+
+func runNotifyRulesList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	rules, err := notifications.NewStore(database).ListRules(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Println("No routing rules. Use `autodoc notify rules add` to create one.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PRIORITY\tNAME\tMATCH\tDESTINATIONS\tFLAGS")
+	for _, r := range rules {
+		var flags []string
+		if r.Stop {
+			flags = append(flags, "stop")
+		}
+		if !r.Enabled {
+			flags = append(flags, "disabled")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", r.Priority, r.Name, describeMatch(r.Match), describeDestinations(r.Destinations), strings.Join(flags, ","))
+	}
+	return w.Flush()
+}
+
+func runNotifyRulesAdd(cmd *cobra.Command, args []string) error {
+	types, _ := cmd.Flags().GetStringSlice("type")
+	services, _ := cmd.Flags().GetStringSlice("service")
+	linkTypes, _ := cmd.Flags().GetStringSlice("link-type")
+	teams, _ := cmd.Flags().GetStringSlice("team")
+	minSeverity, _ := cmd.Flags().GetString("min-severity")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	webhooks, _ := cmd.Flags().GetStringSlice("webhook")
+	slacks, _ := cmd.Flags().GetStringSlice("slack")
+	priority, _ := cmd.Flags().GetInt("priority")
+	stop, _ := cmd.Flags().GetBool("stop")
+	disabled, _ := cmd.Flags().GetBool("disabled")
+	locale, _ := cmd.Flags().GetString("locale")
+
+	rule := notifications.Rule{
+		Name:     args[0],
+		Priority: priority,
+		Match: notifications.RuleMatch{
+			Types:       toNotificationTypes(types),
+			MinSeverity: notifications.Severity(minSeverity),
+			Services:    services,
+			LinkTypes:   linkTypes,
+			RepoTags:    tags,
+			Teams:       teams,
+		},
+		Stop:    stop,
+		Enabled: !disabled,
+	}
+	for _, u := range webhooks {
+		rule.Destinations = append(rule.Destinations, notifications.Destination{Channel: notifications.ChannelWebhook, URL: u, Locale: locale})
+	}
+	for _, u := range slacks {
+		rule.Destinations = append(rule.Destinations, notifications.Destination{Channel: notifications.ChannelSlack, URL: u, Locale: locale})
+	}
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	if err := notifications.NewStore(database).SaveRule(context.Background(), &rule); err != nil {
+		return err
+	}
+	fmt.Printf("Rule %q saved: %s -> %s\n", rule.Name, describeMatch(rule.Match), describeDestinations(rule.Destinations))
+	return nil
+}
+
+func runNotifyRulesRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	if err := notifications.NewStore(database).DeleteRule(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Rule %q removed\n", args[0])
+	return nil
+}
+
+func runNotifyTest(cmd *cobra.Command, args []string) error {
+	types, _ := cmd.Flags().GetStringSlice("type")
+	services, _ := cmd.Flags().GetStringSlice("service")
+	linkTypes, _ := cmd.Flags().GetStringSlice("link-type")
+	teams, _ := cmd.Flags().GetStringSlice("team")
+	severity, _ := cmd.Flags().GetString("severity")
+	title, _ := cmd.Flags().GetString("title")
+	message, _ := cmd.Flags().GetString("message")
+	preview, _ := cmd.Flags().GetBool("preview")
+	if len(types) > 1 || len(linkTypes) > 1 {
+		return fmt.Errorf("a sample notification has a single --type and --link-type")
+	}
+
+	n := notifications.Notification{
+		Title:            title,
+		Message:          message,
+		Severity:         notifications.Severity(severity),
+		AffectedServices: services,
+		AffectedTeams:    teams,
+	}
+	if len(types) == 1 {
+		n.Type = notifications.NotificationType(types[0])
+	}
+	if len(linkTypes) == 1 {
+		n.LinkType = linkTypes[0]
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	templates, err := notificationTemplates(cfg)
+	if err != nil {
+		return err
+	}
+	dispatcher := notifications.NewDispatcher(notifications.NewStore(database))
+	dispatcher.SetTagSource(registry.NewStore(database))
+	dispatcher.SetTemplates(templates)
+	results, err := dispatcher.Explain(context.Background(), n)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("No routing rules defined.")
+		return nil
+	}
+
+	for _, res := range results {
+		mark := "  "
+		if res.Matched {
+			mark = "✓ "
+		}
+		fmt.Printf("%s%s (priority %d): %s\n", mark, res.Rule.Name, res.Rule.Priority, res.Reason)
+	}
+	dests := notifications.MatchedDestinations(results)
+	fmt.Println()
+	if len(dests) == 0 {
+		fmt.Println("No rule matched; only team preferences would apply.")
+		return nil
+	}
+	fmt.Println("Would deliver to:")
+	for _, d := range dests {
+		fmt.Printf("  %s %s\n", d.Channel, d.URL)
+		if !preview {
+			continue
+		}
+		payload, err := dispatcher.Payload(d, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("    %s\n", payload)
+	}
+	return nil
+}
+
+// notificationTemplates builds the message templates from the config.
+func notificationTemplates(cfg *config.Config) (*notifications.Templates, error) {
+	nc := cfg.Notifications
+	defs := make([]notifications.TemplateDef, len(nc.Templates))
+	for i, t := range nc.Templates {
+		defs[i] = notifications.TemplateDef{
+			Type:    notifications.NotificationType(t.Type),
+			Channel: t.Channel,
+			Locale:  t.Locale,
+			Text:    t.Text,
+		}
+	}
+	templates, err := notifications.NewTemplates(nc.Locale, defs, nc.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("notifications.templates: %w", err)
+	}
+	return templates, nil
+}
+
+func runNotifyMute(cmd *cobra.Command, args []string) error {
+	team, _ := cmd.Flags().GetString("team")
+	service, _ := cmd.Flags().GetString("service")
+	types, _ := cmd.Flags().GetStringSlice("type")
+	from, _ := cmd.Flags().GetString("from")
+	until, _ := cmd.Flags().GetString("until")
+	length, _ := cmd.Flags().GetDuration("for")
+	reason, _ := cmd.Flags().GetString("reason")
+
+	m := notifications.Mute{Scope: notifications.MuteGlobal, Types: toNotificationTypes(types), Reason: reason}
+	switch {
+	case team != "" && service != "":
+		return fmt.Errorf("specify either --team or --service, not both")
+	case team != "":
+		m.Scope, m.Target = notifications.MuteTeam, team
+	case service != "":
+		m.Scope, m.Target = notifications.MuteService, service
+	}
+
+	m.StartsAt = time.Now().UTC()
+	if from != "" {
+		t, err := parseMuteTime(from)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		m.StartsAt = t
+	}
+	switch {
+	case until != "" && length > 0:
+		return fmt.Errorf("specify either --until or --for, not both")
+	case until != "":
+		t, err := parseMuteTime(until)
+		if err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+		m.EndsAt = t
+	case length > 0:
+		m.EndsAt = m.StartsAt.Add(length)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	if err := notifications.NewStore(database).CreateMute(context.Background(), &m); err != nil {
+		return err
+	}
+	fmt.Printf("Muted %s (id %s)\n", describeMute(m), m.ID)
+	return nil
+}
+
+func runNotifyMutes(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	now := time.Now().UTC()
+	since := now
+	if all {
+		since = time.Time{}
+	}
+	mutes, err := notifications.NewStore(database).ListMutes(context.Background(), since)
+	if err != nil {
+		return err
+	}
+	if len(mutes) == 0 {
+		fmt.Println("No mute windows.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tMUTES\tREASON")
+	for _, m := range mutes {
+		state := "active"
+		switch {
+		case now.Before(m.StartsAt):
+			state = "upcoming"
+		case !m.Active(now):
+			state = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID, state, describeMute(m), m.Reason)
+	}
+	return w.Flush()
+}
+
+func runNotifyUnmute(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	if err := notifications.NewStore(database).DeleteMute(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Mute %s removed\n", args[0])
+	return nil
+}
+
+// parseMuteTime accepts a date (midnight UTC) or an RFC 3339 timestamp.
+func parseMuteTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", v)
+	}
+	return t.UTC(), nil
+}
+
+// describeMute renders what a mute silences and when.
+func describeMute(m notifications.Mute) string {
+	what := "all notifications"
+	if len(m.Types) > 0 {
+		types := make([]string, len(m.Types))
+		for i, t := range m.Types {
+			types[i] = string(t)
+		}
+		what = strings.Join(types, ", ") + " notifications"
+	}
+	if m.Scope != notifications.MuteGlobal {
+		what += fmt.Sprintf(" for %s %s", m.Scope, m.Target)
+	}
+	window := "from " + m.StartsAt.Format("2006-01-02 15:04")
+	if m.EndsAt.IsZero() {
+		window += " until removed"
+	} else {
+		window += " to " + m.EndsAt.Format("2006-01-02 15:04") + " UTC"
+	}
+	return what + " " + window
+}
+
+func toNotificationTypes(values []string) []notifications.NotificationType {
+	types := make([]notifications.NotificationType, len(values))
+	for i, v := range values {
+		types[i] = notifications.NotificationType(v)
+	}
+	return types
+}
+
+// describeMatch renders a rule's conditions on one line.
+func describeMatch(m notifications.RuleMatch) string {
+	var parts []string
+	if len(m.Types) > 0 {
+		types := make([]string, len(m.Types))
+		for i, t := range m.Types {
+			types[i] = string(t)
+		}
+		parts = append(parts, "type="+strings.Join(types, "|"))
+	}
+	if m.MinSeverity != "" {
+		parts = append(parts, "severity>="+string(m.MinSeverity))
+	}
+	for _, f := range []struct {
+		name   string
+		values []string
+	}{
+		{"service", m.Services},
+		{"link", m.LinkTypes},
+		{"tag", m.RepoTags},
+		{"team", m.Teams},
+	} {
+		if len(f.values) > 0 {
+			parts = append(parts, f.name+"="+strings.Join(f.values, "|"))
+		}
+	}
+	if len(parts) == 0 {
+		return "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+func describeDestinations(dests []notifications.Destination) string {
+	parts := make([]string, len(dests))
+	for i, d := range dests {
+		parts[i] = d.Channel + ":" + d.URL
+	}
+	return strings.Join(parts, ", ")
+}
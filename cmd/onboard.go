@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+)
+
+var onboardCmd = &cobra.Command{
+	Use:   "onboard <service>",
+	Short: "Print an onboarding guide for a service",
+	Long: `Composes a walkthrough for an engineer new to a registered service: what it
+is for, the files where its programs start and its HTTP routes are served,
+how to run it locally (from its Makefile and Compose file), the flows it takes
+part in, the services it calls and that call it, and the owning team's
+contacts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOnboard,
+}
+
+func init() {
+	onboardCmd.Flags().Bool("json", false, "output the guide as JSON")
+	rootCmd.AddCommand(onboardCmd)
+}
+
+func runOnboard(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	guide, err := onboarding.NewBuilder(database).Build(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(guide)
+	}
+	fmt.Print(guide.Markdown())
+	return nil
+}
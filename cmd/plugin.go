@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/plugin"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Install, pin, and verify shareable plugins",
+	Long: `Manages plugins (analyzers, detectors, publishers) shared between projects.
+Each plugin ships a plugin.yaml manifest with its version, capabilities, and the
+checksum of its artifact, signed by its publisher. Installed plugins are pinned
+in .autodoc/plugins.lock so every machine runs the exact same versions.`,
+}
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <dir|archive.tar.gz|url>",
+	Short: "Verify a plugin and pin it in plugins.lock",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPluginInstall,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the plugins pinned in plugins.lock",
+	RunE:  runPluginList,
+}
+
+var pluginVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check installed plugins against their pins and signatures",
+	RunE:  runPluginVerify,
+}
+
+var pluginSignCmd = &cobra.Command{
+	Use:   "sign <dir>",
+	Short: "Compute a plugin's checksum and sign its manifest",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPluginSign,
+}
+
+var pluginKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate an Ed25519 publisher key pair",
+	RunE:  runPluginKeygen,
+}
+
+func init() {
+	pluginInstallCmd.Flags().Bool("allow-unsigned", false, "Accept a plugin without a signature")
+	pluginSignCmd.Flags().String("key", "", "File holding the base64 private key (required)")
+	pluginSignCmd.Flags().String("publisher", "", "Publisher name the signature is made under (required)")
+	pluginKeygenCmd.Flags().String("out", "autodoc-plugin.key", "File to write the private key to")
+	pluginSignCmd.MarkFlagRequired("key")
+	pluginSignCmd.MarkFlagRequired("publisher")
+
+	pluginCmd.AddCommand(pluginInstallCmd, pluginListCmd, pluginVerifyCmd, pluginSignCmd, pluginKeygenCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
+
+// loadPluginTrust builds the plugin trust settings from config.
+func loadPluginTrust() (plugin.Trust, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return plugin.Trust{}, err
+	}
+	return plugin.TrustFromConfig(cfg.Plugins)
+}
+
+func runPluginInstall(cmd *cobra.Command, args []string) error {
+	trust, err := loadPluginTrust()
+	if err != nil {
+		return err
+	}
+	if allow, _ := cmd.Flags().GetBool("allow-unsigned"); allow {
+		trust.AllowUnsigned = true
+	}
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	m, err := plugin.Install(context.Background(), rootDir, args[0], trust)
+	if err != nil {
+		return err
+	}
+	signer := "unsigned"
+	if m.Signature != "" {
+		signer = "signed by " + m.Publisher
+	}
+	fmt.Printf("Installed %s %s (%s, %s)\n", m.Name, m.Version, strings.Join(m.Capabilities, ", "), signer)
+	return nil
+}
+
+func runPluginList(cmd *cobra.Command, args []string) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	lock, err := plugin.LoadLock(rootDir)
+	if err != nil {
+		return err
+	}
+	if len(lock.Plugins) == 0 {
+		fmt.Println("No plugins installed.")
+		return nil
+	}
+	fmt.Printf("%-24s %-12s %-24s %s\n", "NAME", "VERSION", "CAPABILITIES", "PUBLISHER")
+	for _, e := range lock.Plugins {
+		publisher := e.Publisher
+		if publisher == "" {
+			publisher = "(unsigned)"
+		}
+		fmt.Printf("%-24s %-12s %-24s %s\n", e.Name, e.Version, strings.Join(e.Capabilities, ","), publisher)
+	}
+	return nil
+}
+
+func runPluginVerify(cmd *cobra.Command, args []string) error {
+	trust, err := loadPluginTrust()
+	if err != nil {
+		return err
+	}
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	results, err := plugin.VerifyInstalled(rootDir, trust)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("x %s %s: %v\n", r.Entry.Name, r.Entry.Version, r.Err)
+			continue
+		}
+		fmt.Printf("ok %s %s\n", r.Entry.Name, r.Entry.Version)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d plugins failed verification", failed, len(results))
+	}
+	return nil
+}
+
+func runPluginSign(cmd *cobra.Command, args []string) error {
+	keyFile, _ := cmd.Flags().GetString("key")
+	publisher, _ := cmd.Flags().GetString("publisher")
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	m, err := plugin.Sign(args[0], publisher, strings.TrimSpace(string(key)))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Signed %s %s as %s (%s)\n", m.Name, m.Version, publisher, m.Checksum)
+	return nil
+}
+
+func runPluginKeygen(cmd *cobra.Command, args []string) error {
+	out, _ := cmd.Flags().GetString("out")
+	pub, priv, err := plugin.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, []byte(priv+"\n"), 0o600); err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+	fmt.Printf("Private key written to %s. Share the public key with consumers:\n\n", out)
+	fmt.Printf("plugins:\n  trusted_keys:\n    <publisher>: %s\n", pub)
+	return nil
+}
@@ -48,7 +48,7 @@ func runQuery(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create and load vector store.
-	store, err := vectordb.NewChromemStore(embedder)
+	store, err := createVectorStore(cfg, embedder)
 	if err != nil {
 		return fmt.Errorf("creating vector store: %w", err)
 	}
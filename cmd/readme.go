@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+	"github.com/ziadkadry99/auto-doc/internal/readmesync"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+var syncReadmeCmd = &cobra.Command{
+	Use:   "sync-readme [service...]",
+	Short: "Write each service's generated overview into its repo's README.md",
+	Long: `Writes a generated overview of each registered service — its summary,
+endpoints, dependencies, and owning team — into its README.md, between
+<!-- autodoc:begin --> and <!-- autodoc:end --> markers, so source repos stay
+self-describing. Text outside the markers is kept; a README without them gets
+the section appended. Services split out of a monorepo write the README in
+their subdirectory. Without arguments every registered service with a local
+checkout is synced.
+
+With --pr the change is committed to a branch (in a temporary worktree, so the
+checkout is left alone), pushed to origin, and proposed as a GitHub pull
+request; the services of a monorepo are proposed in one pull request. The token is read from GITHUB_TOKEN or GH_TOKEN; set GITHUB_API_URL
+for GitHub Enterprise.`,
+	RunE: runSyncReadme,
+}
+
+func init() {
+	syncReadmeCmd.Flags().Bool("pr", false, "open a GitHub pull request instead of writing the checkout")
+	syncReadmeCmd.Flags().String("branch", readmesync.DefaultBranch, "branch to propose the change from with --pr")
+	rootCmd.AddCommand(syncReadmeCmd)
+}
+
+func runSyncReadme(cmd *cobra.Command, args []string) error {
+	openPR, _ := cmd.Flags().GetBool("pr")
+	branch, _ := cmd.Flags().GetString("branch")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	var gh *readmesync.GitHub
+	if openPR {
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			token = os.Getenv("GH_TOKEN")
+		}
+		if token == "" {
+			return fmt.Errorf("--pr needs a GitHub token in GITHUB_TOKEN or GH_TOKEN")
+		}
+		gh = &readmesync.GitHub{Token: token, APIURL: os.Getenv("GITHUB_API_URL"), Branch: branch}
+	}
+
+	ctx := context.Background()
+	repos, err := registry.NewStore(database).List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing repos: %w", err)
+	}
+	for _, name := range args {
+		if !slices.ContainsFunc(repos, func(r registry.Repository) bool { return r.Name == name }) {
+			return fmt.Errorf("service %q is not registered", name)
+		}
+	}
+
+	builder := onboarding.NewBuilder(database)
+	var failed int
+	// With --pr, the services of a monorepo are proposed together, since
+	// they share a checkout and the branch.
+	var checkouts []string
+	proposals := make(map[string][]readmesync.README)
+	for _, r := range repos {
+		if len(args) > 0 && !slices.Contains(args, r.Name) {
+			continue
+		}
+		if r.LocalPath == "" {
+			fmt.Printf("  %s: skipped, no local checkout\n", r.Name)
+			continue
+		}
+		guide, err := builder.Build(ctx, r.Name)
+		if err != nil {
+			fmt.Printf("  %s: %v\n", r.Name, err)
+			failed++
+			continue
+		}
+
+		if gh != nil {
+			if _, ok := proposals[r.LocalPath]; !ok {
+				checkouts = append(checkouts, r.LocalPath)
+			}
+			proposals[r.LocalPath] = append(proposals[r.LocalPath], readmesync.README{Subdir: r.Subdir, Guide: guide})
+			continue
+		}
+
+		dir := filepath.Join(r.LocalPath, filepath.FromSlash(r.Subdir))
+		changed, err := readmesync.Write(dir, guide)
+		switch {
+		case err != nil:
+			fmt.Printf("  %s: %v\n", r.Name, err)
+			failed++
+		case changed:
+			fmt.Printf("  %s: updated %s\n", r.Name, filepath.Join(dir, "README.md"))
+		default:
+			fmt.Printf("  %s: up to date\n", r.Name)
+		}
+	}
+	for _, dir := range checkouts {
+		readmes := proposals[dir]
+		url, updated, err := gh.Propose(ctx, dir, readmes)
+		for _, rd := range readmes {
+			switch service := rd.Guide.Service; {
+			case err != nil:
+				fmt.Printf("  %s: %v\n", service, err)
+				failed++
+			case slices.Contains(updated, service):
+				fmt.Printf("  %s: %s\n", service, url)
+			default:
+				fmt.Printf("  %s: up to date\n", service)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d README(s) could not be synced", failed)
+	}
+	return nil
+}
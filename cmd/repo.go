@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -15,7 +18,12 @@ import (
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
 	"github.com/ziadkadry99/auto-doc/internal/db"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/readmesync"
 	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
@@ -53,6 +61,29 @@ var repoSyncCmd = &cobra.Command{
 	RunE:  runRepoSync,
 }
 
+var repoTagCmd = &cobra.Command{
+	Use:   "tag <name> [tag...]",
+	Short: "Show or replace a repository's tags",
+	Long: `Tags are free-form labels such as "tier-1" or "pci" that notification routing
+rules can match on. With no tags, prints the repository's current tags; otherwise
+replaces them. Pass --clear to remove all tags.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRepoTag,
+}
+
+var repoGroupCmd = &cobra.Command{
+	Use:   "group <name>",
+	Short: "Show or set a repository's domain, bounded context, and environment",
+	Long: `Groups organize the central site once there are too many services to browse
+flat: the sidebar nests services under their domain and bounded context, each
+domain gets an overview page with its own diagram, and the service map can be
+filtered by domain, context, or environment. With no flags, prints the
+repository's current grouping; pass an empty value to clear one. Groups set
+under central_site.groups in the config take precedence.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepoGroup,
+}
+
 var repoSyncAllCmd = &cobra.Command{
 	Use:   "sync-all",
 	Short: "Sync all registered repositories",
@@ -63,12 +94,21 @@ func init() {
 	repoAddCmd.Flags().String("url", "", "Git URL to clone")
 	repoAddCmd.Flags().String("path", "", "Local path to the repository")
 	repoAddCmd.Flags().String("display-name", "", "Display name for the repository")
+	repoAddCmd.Flags().StringSlice("tag", nil, "Tag for notification routing (repeatable)")
+	repoTagCmd.Flags().Bool("clear", false, "Remove all tags")
+	for _, c := range []*cobra.Command{repoAddCmd, repoGroupCmd} {
+		c.Flags().String("domain", "", "Business domain, e.g. commerce")
+		c.Flags().String("context", "", "Bounded context within the domain, e.g. checkout")
+		c.Flags().String("environment", "", "Environment, e.g. prod or staging")
+	}
 
 	repoCmd.AddCommand(repoAddCmd)
 	repoCmd.AddCommand(repoListCmd)
 	repoCmd.AddCommand(repoRemoveCmd)
 	repoCmd.AddCommand(repoSyncCmd)
 	repoCmd.AddCommand(repoSyncAllCmd)
+	repoCmd.AddCommand(repoTagCmd)
+	repoCmd.AddCommand(repoGroupCmd)
 	rootCmd.AddCommand(repoCmd)
 }
 
@@ -83,7 +123,8 @@ func createCentralVectorStore(cfg *config.Config) (vectordb.VectorStore, error)
 		return nil, fmt.Errorf("creating embedder: %w", err)
 	}
 
-	store, err := vectordb.NewChromemStore(embedder)
+	cache := cacheEmbeddings(cfg, embedder)
+	store, err := createVectorStore(cfg, cache)
 	if err != nil {
 		return nil, fmt.Errorf("creating vector store: %w", err)
 	}
@@ -94,7 +135,7 @@ func createCentralVectorStore(cfg *config.Config) (vectordb.VectorStore, error)
 		fmt.Fprintf(os.Stderr, "Note: starting with empty vector store (%v)\n", err)
 	}
 
-	return store, nil
+	return cachedVectorStore{VectorStore: store, cfg: cfg, cache: cache}, nil
 }
 
 func runRepoAdd(cmd *cobra.Command, args []string) error {
@@ -102,6 +143,10 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 	gitURL, _ := cmd.Flags().GetString("url")
 	localPath, _ := cmd.Flags().GetString("path")
 	displayName, _ := cmd.Flags().GetString("display-name")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	domain, _ := cmd.Flags().GetString("domain")
+	boundedContext, _ := cmd.Flags().GetString("context")
+	environment, _ := cmd.Flags().GetString("environment")
 
 	if gitURL == "" && localPath == "" {
 		return fmt.Errorf("either --url or --path is required")
@@ -133,8 +178,11 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	repo := &registry.Repository{
-		Name:        name,
-		DisplayName: displayName,
+		Name:           name,
+		DisplayName:    displayName,
+		Domain:         domain,
+		BoundedContext: boundedContext,
+		Environment:    environment,
 	}
 	if displayName == "" {
 		repo.DisplayName = name
@@ -192,6 +240,11 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 	if err := repoStore.Add(context.Background(), repo); err != nil {
 		return fmt.Errorf("registering repository: %w", err)
 	}
+	if len(tags) > 0 {
+		if err := repoStore.SetTags(context.Background(), name, tags); err != nil {
+			return err
+		}
+	}
 
 	// Import artifacts.
 	vecStore, err := createCentralVectorStore(cfg)
@@ -200,6 +253,10 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	importer := registry.NewImporter(repoStore, vecStore, cfg.Quality)
+	children, err := syncMonorepo(context.Background(), database, repoStore, importer, repo)
+	if err != nil {
+		return err
+	}
 	fmt.Fprintf(os.Stderr, "Importing %s...\n", name)
 	if err := importer.ImportRepo(context.Background(), repo); err != nil {
 		return fmt.Errorf("importing repository: %w", err)
@@ -221,16 +278,23 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 		flowStore := flows.NewStore(database)
 		linker := registry.NewLinker(repoStore, ctxStore, flowStore)
 		fmt.Fprintf(os.Stderr, "Discovering cross-service links...\n")
-		if linkErr := linker.DiscoverLinks(context.Background(), repo, llmProvider, cfg.Model); linkErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: link discovery failed: %v\n", linkErr)
+		if linkErr := linker.DiscoverLinks(context.Background(), repo, llmProvider, cfg.ModelFor(config.TaskLinks)); linkErr != nil {
+			slog.Warn("link discovery failed", "phase", "links", "repo", repo.Name, "err", linkErr)
 		} else {
 			links, _ := repoStore.GetLinks(context.Background(), name)
 			if len(links) > 0 {
 				fmt.Fprintf(os.Stderr, "  Discovered %d cross-service link(s)\n", len(links))
 			}
 		}
+		for i := range children {
+			if linkErr := linker.DiscoverLinks(context.Background(), &children[i], llmProvider, cfg.ModelFor(config.TaskLinks)); linkErr != nil {
+				slog.Warn("link discovery failed", "phase", "links", "repo", children[i].Name, "err", linkErr)
+			}
+		}
 	}
 
+	recordSnapshot(context.Background(), repoStore, "")
+
 	fmt.Printf("Repository %q registered successfully\n", name)
 	fmt.Printf("  Status: %s\n", repo.Status)
 	fmt.Printf("  Files: %d\n", repo.FileCount)
@@ -238,10 +302,118 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 	if repo.Summary != "" {
 		fmt.Printf("  Summary: %s\n", repo.Summary)
 	}
+	if len(children) > 0 {
+		fmt.Printf("  Services:\n")
+		for _, c := range children {
+			fmt.Printf("    %s (%s, %d files)\n", c.Name, c.Subdir, c.FileCount)
+		}
+	}
 
 	return nil
 }
 
+func runRepoTag(cmd *cobra.Command, args []string) error {
+	name, tags := args[0], args[1:]
+	clearTags, _ := cmd.Flags().GetBool("clear")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	repoStore := registry.NewStore(database)
+	repo, err := repoStore.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if repo == nil {
+		return fmt.Errorf("repository %q not found", name)
+	}
+
+	if len(tags) > 0 || clearTags {
+		if err := repoStore.SetTags(ctx, name, tags); err != nil {
+			return err
+		}
+	}
+	current, err := repoStore.GetTags(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(current) == 0 {
+		fmt.Printf("%s has no tags\n", name)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", name, strings.Join(current, ", "))
+	return nil
+}
+
+func runRepoGroup(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	repoStore := registry.NewStore(database)
+	repo, err := repoStore.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if repo == nil {
+		return fmt.Errorf("repository %q not found", name)
+	}
+
+	changed := false
+	for flag, field := range map[string]*string{
+		"domain":      &repo.Domain,
+		"context":     &repo.BoundedContext,
+		"environment": &repo.Environment,
+	} {
+		if cmd.Flags().Changed(flag) {
+			*field, _ = cmd.Flags().GetString(flag)
+			changed = true
+		}
+	}
+	if changed {
+		if err := repoStore.Update(ctx, repo); err != nil {
+			return fmt.Errorf("updating repository: %w", err)
+		}
+	}
+
+	group := repoGroupLabel(*repo)
+	if group == "" {
+		fmt.Printf("%s is not in a group\n", name)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", name, group)
+	return nil
+}
+
+// repoGroupLabel renders a repo's grouping, e.g. "commerce/checkout (prod)".
+func repoGroupLabel(r registry.Repository) string {
+	label := r.Domain
+	if r.BoundedContext != "" {
+		label = strings.TrimPrefix(label+"/"+r.BoundedContext, "/")
+	}
+	if r.Environment != "" {
+		label = strings.TrimSpace(label + " (" + r.Environment + ")")
+	}
+	return label
+}
+
 func runRepoList(cmd *cobra.Command, args []string) error {
 	cfg, err := loadConfig()
 	if err != nil {
@@ -266,7 +438,7 @@ func runRepoList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tSTATUS\tFILES\tTYPE\tLAST INDEXED\tSUMMARY")
+	fmt.Fprintln(w, "NAME\tSTATUS\tFILES\tTYPE\tGROUP\tLAST INDEXED\tSUMMARY")
 	for _, r := range repos {
 		lastIndexed := r.LastIndexedAt
 		if lastIndexed == "" {
@@ -278,8 +450,16 @@ func runRepoList(cmd *cobra.Command, args []string) error {
 		if len(summary) > 60 {
 			summary = summary[:57] + "..."
 		}
-		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
-			r.Name, r.Status, r.FileCount, r.SourceType, lastIndexed, summary)
+		sourceType := r.SourceType
+		if r.Parent != "" {
+			sourceType = "in " + r.Parent
+		}
+		group := repoGroupLabel(r)
+		if group == "" {
+			group = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			r.Name, r.Status, r.FileCount, sourceType, group, lastIndexed, summary)
 	}
 	w.Flush()
 
@@ -311,30 +491,46 @@ func runRepoRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("repository %q not found", name)
 	}
 
+	// A monorepo takes its sub-services with it.
+	names := []string{name}
+	children, err := repoStore.Children(context.Background(), name)
+	if err != nil {
+		return fmt.Errorf("listing sub-services: %w", err)
+	}
+	for _, c := range children {
+		names = append(names, c.Name)
+	}
+
 	// Clean up vector store entries.
 	vecStore, err := createCentralVectorStore(cfg)
 	if err == nil {
-		vecStore.DeleteByRepoID(context.Background(), name)
+		for _, n := range names {
+			vecStore.DeleteByRepoID(context.Background(), n)
+		}
 		vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
 		vecStore.Persist(context.Background(), vectorDir)
 	}
 
-	// Remove from database.
-	if err := repoStore.Remove(context.Background(), name); err != nil {
-		return fmt.Errorf("removing repository: %w", err)
+	// Remove from database, sub-services first.
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := repoStore.Remove(context.Background(), names[i]); err != nil {
+			return fmt.Errorf("removing repository %s: %w", names[i], err)
+		}
 	}
 
 	fmt.Printf("Repository %q removed\n", name)
 	return nil
 }
 
-func runRepoSync(cmd *cobra.Command, args []string) error {
+func runRepoSync(cmd *cobra.Command, args []string) (err error) {
 	name := args[0]
 
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	run := startRun(cfg, "repo sync", name)
+	defer func() { finishRun(run, err) }()
 
 	database, err := openCentralDB(cfg)
 	if err != nil {
@@ -371,9 +567,11 @@ func runRepoSync(cmd *cobra.Command, args []string) error {
 
 	importer := registry.NewImporter(repoStore, vecStore, cfg.Quality)
 	fmt.Fprintf(os.Stderr, "Re-importing %s...\n", name)
-	if err := importer.ImportRepo(context.Background(), repo); err != nil {
-		return fmt.Errorf("importing repository: %w", err)
+	children, err := reimportRepo(context.Background(), cfg, database, importer, repo)
+	if err != nil {
+		return err
 	}
+	recordSnapshot(context.Background(), repoStore, run.ID)
 
 	// Persist vector store.
 	vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
@@ -381,27 +579,23 @@ func runRepoSync(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("persisting vector store: %w", err)
 	}
 
-	// Re-discover cross-service links.
-	llmProvider, llmErr := createLLMProviderFromConfig(cfg)
-	if llmErr == nil {
-		ctxStore := contextengine.NewStore(database)
-		flowStore := flows.NewStore(database)
-		linker := registry.NewLinker(repoStore, ctxStore, flowStore)
-		fmt.Fprintf(os.Stderr, "Discovering cross-service links...\n")
-		if linkErr := linker.DiscoverLinks(context.Background(), repo, llmProvider, cfg.Model); linkErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: link discovery failed: %v\n", linkErr)
-		}
-	}
-
 	fmt.Printf("Repository %q synced successfully (%d files)\n", name, repo.FileCount)
+	run.Changed("synced %s (%d files)", name, repo.FileCount)
+	for _, c := range children {
+		fmt.Printf("  %s: %d files\n", c.Name, c.FileCount)
+		run.Changed("synced %s (%d files)", c.Name, c.FileCount)
+	}
+	run.Summary = fmt.Sprintf("%d files", repo.FileCount)
 	return nil
 }
 
-func runRepoSyncAll(cmd *cobra.Command, args []string) error {
+func runRepoSyncAll(cmd *cobra.Command, args []string) (err error) {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
+	run := startRun(cfg, "repo sync-all", "")
+	defer func() { finishRun(run, err) }()
 
 	database, err := openCentralDB(cfg)
 	if err != nil {
@@ -425,11 +619,22 @@ func runRepoSyncAll(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("creating vector store: %w", err)
 	}
 
+	// A full re-index touches every service; mute notifications until it
+	// finishes so it doesn't set off a notification storm.
+	unmute, err := notifications.NewStore(database).SuppressDuring(context.Background(), "bulk re-index: repo sync-all", 6*time.Hour)
+	if err != nil {
+		return fmt.Errorf("muting notifications: %w", err)
+	}
+	defer unmute()
+
 	importer := registry.NewImporter(repoStore, vecStore, cfg.Quality)
 	var errors []string
 
 	for _, r := range repos {
 		repo := r // copy
+		if repo.Parent != "" {
+			continue // synced with its monorepo below
+		}
 		fmt.Fprintf(os.Stderr, "Syncing %s...\n", repo.Name)
 
 		// Git pull if needed.
@@ -443,12 +648,26 @@ func runRepoSyncAll(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		children, err := syncMonorepo(context.Background(), database, repoStore, importer, &repo)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", repo.Name, err))
+		}
 		if err := importer.ImportRepo(context.Background(), &repo); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: import failed: %v", repo.Name, err))
 			continue
 		}
 
 		fmt.Printf("  %s: synced (%d files)\n", repo.Name, repo.FileCount)
+		run.Changed("synced %s (%d files)", repo.Name, repo.FileCount)
+		for _, c := range children {
+			fmt.Printf("    %s: synced (%d files)\n", c.Name, c.FileCount)
+			run.Changed("synced %s (%d files)", c.Name, c.FileCount)
+		}
+	}
+
+	// Sub-services may have been added or removed above.
+	if repos, err = repoStore.List(context.Background()); err != nil {
+		return fmt.Errorf("listing repositories: %w", err)
 	}
 
 	// Persist vector store.
@@ -467,18 +686,154 @@ func runRepoSyncAll(cmd *cobra.Command, args []string) error {
 		for _, r := range repos {
 			repo := r
 			fmt.Fprintf(os.Stderr, "  Analyzing %s...\n", repo.Name)
-			if linkErr := linker.DiscoverLinks(context.Background(), &repo, llmProvider, cfg.Model); linkErr != nil {
-				fmt.Fprintf(os.Stderr, "  Warning: link discovery failed for %s: %v\n", repo.Name, linkErr)
+			if linkErr := linker.DiscoverLinks(context.Background(), &repo, llmProvider, cfg.ModelFor(config.TaskLinks)); linkErr != nil {
+				slog.Warn("link discovery failed", "phase", "links", "repo", repo.Name, "err", linkErr)
 			}
 		}
 		allLinks, _ := repoStore.GetLinks(context.Background(), "")
 		fmt.Fprintf(os.Stderr, "  Total cross-service links: %d\n", len(allLinks))
 	}
+	recordSnapshot(context.Background(), repoStore, run.ID)
 
 	if len(errors) > 0 {
 		fmt.Fprintf(os.Stderr, "\nErrors:\n%s\n", strings.Join(errors, "\n"))
 	}
 
 	fmt.Printf("\nSynced %d/%d repositories\n", len(repos)-len(errors), len(repos))
+	run.Summary = fmt.Sprintf("synced %d/%d repositories", len(repos)-len(errors), len(repos))
+	for _, e := range errors {
+		run.Changed("failed %s", e)
+	}
 	return nil
 }
+
+// recordSnapshot adds the architecture as it now stands to the service
+// map's timeline.
+func recordSnapshot(ctx context.Context, repoStore *registry.Store, runID string) {
+	if _, err := repoStore.RecordSnapshot(ctx, runID); err != nil {
+		slog.Warn("could not snapshot the architecture", "err", err)
+	}
+}
+
+// reimportRepo imports a registered repository, and the sub-services of a
+// monorepo, into the central database and re-discovers their cross-service
+// links when an LLM provider is configured. It returns the sub-services.
+func reimportRepo(ctx context.Context, cfg *config.Config, database *db.DB, importer *registry.Importer, repo *registry.Repository) ([]registry.Repository, error) {
+	repoStore := registry.NewStore(database)
+	var children []registry.Repository
+	if repo.Parent == "" {
+		var err error
+		if children, err = syncMonorepo(ctx, database, repoStore, importer, repo); err != nil {
+			return nil, err
+		}
+	}
+	if err := importer.ImportRepo(ctx, repo); err != nil {
+		return nil, fmt.Errorf("importing repository: %w", err)
+	}
+	recordHealth(ctx, database, repo, children)
+
+	llmProvider, llmErr := createLLMProviderFromConfig(cfg)
+	if llmErr == nil {
+		linker := registry.NewLinker(repoStore, contextengine.NewStore(database), flows.NewStore(database))
+		fmt.Fprintf(os.Stderr, "Discovering cross-service links...\n")
+		if linkErr := linker.DiscoverLinks(ctx, repo, llmProvider, cfg.ModelFor(config.TaskLinks)); linkErr != nil {
+			slog.Warn("link discovery failed", "phase", "links", "repo", repo.Name, "err", linkErr)
+		}
+		for i := range children {
+			if linkErr := linker.DiscoverLinks(ctx, &children[i], llmProvider, cfg.ModelFor(config.TaskLinks)); linkErr != nil {
+				slog.Warn("link discovery failed", "phase", "links", "repo", children[i].Name, "err", linkErr)
+			}
+		}
+	}
+	return children, nil
+}
+
+// recordHealth gathers the health of a repository and its sub-services from
+// the checkout, counting open pull requests on GitHub when GITHUB_TOKEN or
+// GH_TOKEN is set. Sub-services share their monorepo's pull requests.
+func recordHealth(ctx context.Context, database *db.DB, repo *registry.Repository, children []registry.Repository) {
+	var prs repohealth.PullRequests
+	if token := cmp.Or(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN")); token != "" {
+		prs = &readmesync.GitHub{Token: token, APIURL: os.Getenv("GITHUB_API_URL")}
+	}
+	store := repohealth.NewStore(database)
+	h := repohealth.Gather(ctx, *repo, prs)
+	if err := store.Record(ctx, h); err != nil {
+		slog.Warn("could not record repository health", "repo", repo.Name, "err", err)
+	}
+	for _, child := range children {
+		ch := repohealth.Gather(ctx, child, nil)
+		ch.OpenPRs = h.OpenPRs
+		if err := store.Record(ctx, ch); err != nil {
+			slog.Warn("could not record repository health", "repo", child.Name, "err", err)
+		}
+	}
+}
+
+// syncMonorepo splits a registered repository into the sub-services its own
+// .autodoc.yml declares or discovers, imports each one, and records their
+// declared owners. It must run before the parent is imported, so that the
+// parent leaves the sub-services' files out. A repository that looks like a
+// monorepo but is not configured as one gets a hint.
+func syncMonorepo(ctx context.Context, database *db.DB, repoStore *registry.Store, importer *registry.Importer, parent *registry.Repository) ([]registry.Repository, error) {
+	repoCfg, err := config.Load(filepath.Join(parent.LocalPath, ".autodoc.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("loading %s config: %w", parent.Name, err)
+	}
+	analyses, err := indexer.LoadAnalyses(parent.LocalPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading analyses for %s: %w", parent.Name, err)
+	}
+
+	services := indexer.DiscoverSubServices(analyses, repoCfg.Monorepo)
+	if len(services) == 0 {
+		if detected := indexer.DetectSubServices(analyses); len(detected) >= 2 {
+			var dirs []string
+			for _, d := range detected {
+				dirs = append(dirs, d.Path)
+			}
+			fmt.Fprintf(os.Stderr, "Note: %s looks like a monorepo (%s); set monorepo.discover in its .autodoc.yml to document each service separately\n",
+				parent.Name, strings.Join(dirs, ", "))
+		}
+	}
+
+	children, err := repoStore.SyncSubServices(ctx, parent, services)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		fmt.Fprintf(os.Stderr, "Importing %s (%s)...\n", children[i].Name, children[i].Subdir)
+		if err := importer.ImportRepo(ctx, &children[i]); err != nil {
+			slog.Warn("could not import sub-service", "phase", "import", "repo", children[i].Name, "err", err)
+		}
+	}
+	assignOwners(ctx, database, services)
+	return children, nil
+}
+
+// assignOwners records the teams a monorepo's config names as the owners of
+// its sub-services.
+func assignOwners(ctx context.Context, database *db.DB, services []indexer.SubService) {
+	orgStore := orgstructure.NewStore(database)
+	teams, err := orgStore.ListTeams(ctx)
+	if err != nil {
+		return
+	}
+	teamIDs := make(map[string]string)
+	for _, t := range teams {
+		teamIDs[strings.ToLower(t.Name)] = t.ID
+	}
+	for _, svc := range services {
+		for _, owner := range svc.Owners {
+			id, ok := teamIDs[strings.ToLower(owner)]
+			if !ok {
+				slog.Warn("owner is not a known team", "phase", "ownership", "repo", svc.Name, "owner", owner)
+				continue
+			}
+			o := &orgstructure.ServiceOwnership{TeamID: id, RepoID: svc.Name, Confidence: "confirmed", Source: "autodoc.yml"}
+			if err := orgStore.SetOwnership(ctx, o); err != nil {
+				slog.Warn("could not set owner", "phase", "ownership", "repo", svc.Name, "err", err)
+			}
+		}
+	}
+}
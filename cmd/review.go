@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/review"
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review the central site's pages before they are published",
+	Long: `With central_site.review.enabled, "autodoc site --central" holds back every
+page generated for the first time, or changed in substance since it was last
+approved, and keeps publishing the version last approved, or leaves a new page
+out, until a reviewer approves it. Changes to timestamps and wrapping alone
+don't need review.
+
+Pages can also be reviewed at /review, or through /api/review, on
+"autodoc server". Approved pages are published by the next site build.`,
+}
+
+var reviewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the pages awaiting review",
+	RunE:  runReviewList,
+}
+
+var reviewShowCmd = &cobra.Command{
+	Use:   "show <page>",
+	Short: "Show the changes to a page awaiting review",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runReviewShow,
+}
+
+var reviewApproveCmd = &cobra.Command{
+	Use:   "approve [pages...]",
+	Short: "Approve pages for publishing",
+	Long: `Approves pages awaiting review, by their paths as "autodoc review list" prints
+them. With --all, approves every page awaiting review, e.g. to adopt the site
+as it is when turning review on.`,
+	RunE: runReviewDecide,
+}
+
+var reviewRejectCmd = &cobra.Command{
+	Use:   "reject <pages...>",
+	Short: "Reject pages, keeping the version last approved published",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runReviewDecide,
+}
+
+func init() {
+	reviewListCmd.Flags().String("status", string(review.StatusPending), "list pages with this status: pending, approved, rejected, or all")
+	reviewListCmd.Flags().Bool("json", false, "output the pages as JSON")
+	for _, c := range []*cobra.Command{reviewShowCmd, reviewApproveCmd, reviewRejectCmd} {
+		c.Flags().String("site", "", "audience of the site variant the page belongs to (default: the full site)")
+	}
+	for _, c := range []*cobra.Command{reviewApproveCmd, reviewRejectCmd} {
+		c.Flags().String("note", "", "note recorded with the decision")
+		c.Flags().String("reviewer", "", "who is deciding (default: $USER)")
+	}
+	reviewApproveCmd.Flags().Bool("all", false, "approve every page awaiting review")
+	reviewCmd.AddCommand(reviewListCmd, reviewShowCmd, reviewApproveCmd, reviewRejectCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
+
+// openReviewStore opens the review store of the central database; the
+// returned func closes the database.
+func openReviewStore() (*review.Store, func(), error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening database: %w", err)
+	}
+	return review.NewStore(database), func() { database.Close() }, nil
+}
+
+func runReviewList(cmd *cobra.Command, args []string) error {
+	status, _ := cmd.Flags().GetString("status")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if status == "all" {
+		status = ""
+	}
+
+	store, closeDB, err := openReviewStore()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	pages, err := store.List(context.Background(), review.Status(status))
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pages)
+	}
+	if len(pages) == 0 {
+		fmt.Println("No pages found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAGE\tSITE\tSTATUS\tGENERATED\tREVIEWER")
+	for _, p := range pages {
+		state := string(p.Status)
+		if p.Status == review.StatusPending && p.IsNew() {
+			state += " (new)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Path, cmp.Or(p.Site, "-"), state, p.GeneratedAt.Format("2006-01-02 15:04"), cmp.Or(p.Reviewer, "-"))
+	}
+	return w.Flush()
+}
+
+func runReviewShow(cmd *cobra.Command, args []string) error {
+	site, _ := cmd.Flags().GetString("site")
+	store, closeDB, err := openReviewStore()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	p, err := store.Get(context.Background(), site, args[0])
+	if err != nil {
+		return err
+	}
+	if p.IsNew() {
+		fmt.Printf("%s is new (%s):\n\n", p.Path, p.Status)
+	} else {
+		fmt.Printf("%s (%s), changes since the version last approved:\n\n", p.Path, p.Status)
+	}
+	fmt.Print(p.Diff())
+	return nil
+}
+
+func runReviewDecide(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	site, _ := cmd.Flags().GetString("site")
+	note, _ := cmd.Flags().GetString("note")
+	reviewer, _ := cmd.Flags().GetString("reviewer")
+	reviewer = cmp.Or(reviewer, os.Getenv("USER"), "unknown")
+	all, _ := cmd.Flags().GetBool("all")
+	if !all && len(args) == 0 {
+		return fmt.Errorf("name the pages to approve, or pass --all")
+	}
+
+	store, closeDB, err := openReviewStore()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if all {
+		n, err := store.ApproveAll(ctx, reviewer, note)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Approved %d pages; they are published by the next site build.\n", n)
+		return nil
+	}
+	decide, verb := store.Approve, "Approved"
+	if cmd.Name() == "reject" {
+		decide, verb = store.Reject, "Rejected"
+	}
+	for _, path := range args {
+		if _, err := decide(ctx, review.Decision{Site: site, Path: path, Reviewer: reviewer, Note: note}); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", verb, path)
+	}
+	return nil
+}
@@ -2,14 +2,18 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/runlog"
 )
 
 var (
-	cfgFile string
-	verbose bool
+	cfgFile   string
+	verbose   bool
+	logFormat string
 )
 
 var rootCmd = &cobra.Command{
@@ -19,6 +23,13 @@ var rootCmd = &cobra.Command{
 documentation and builds a semantic vector database for intelligent
 code navigation. It integrates with AI agents via MCP for instant
 codebase understanding.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		level := slog.LevelInfo
+		if verbose {
+			level = slog.LevelDebug
+		}
+		return runlog.Setup(os.Stderr, logFormat, level)
+	},
 }
 
 func Execute() error {
@@ -28,6 +39,7 @@ func Execute() error {
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", ".autodoc.yml", "config file path")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "format of warnings and other log lines on stderr: text or json")
 }
 
 func exitOnError(err error) {
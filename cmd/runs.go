@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/runlog"
+)
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List generation runs from the audit log",
+	Long: `Every generate, update, site, repo sync, and daemon round is recorded in
+<output_dir>/runs.jsonl with its run ID, who started it, when, how it ended,
+and what it changed. The run ID also tags each log line the run writes, so
+--log-format json output can be joined back to the run.
+
+Actors come from $AUTODOC_ACTOR, the CI user on GitHub Actions or GitLab CI,
+or the local user.`,
+	Args: cobra.NoArgs,
+	RunE: runRunsList,
+}
+
+var runsShowCmd = &cobra.Command{
+	Use:   "show <run-id>",
+	Short: "Show one run and everything it changed",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRunsShow,
+}
+
+func init() {
+	runsCmd.Flags().String("command", "", "only runs of this command, e.g. generate or \"repo sync\"")
+	runsCmd.Flags().String("project", "", "only runs over this project or repo")
+	runsCmd.Flags().String("status", "", "only runs that are running, succeeded, or failed")
+	runsCmd.Flags().Duration("since", 0, "only runs started within this long, e.g. 24h")
+	runsCmd.Flags().Int("limit", 20, "maximum runs to list; 0 lists all")
+	runsCmd.Flags().Bool("json", false, "print the runs as JSON")
+	runsShowCmd.Flags().Bool("json", false, "print the run as JSON")
+	runsCmd.AddCommand(runsShowCmd)
+	rootCmd.AddCommand(runsCmd)
+}
+
+// startRun records the start of a run in the audit log and tags every log
+// line until the run finishes with its ID. Failing to record the run only
+// warns.
+func startRun(cfg *config.Config, command, project string) *runlog.Run {
+	run, err := runlog.Open(cfg.OutputDir).Start(command, project)
+	if err != nil {
+		slog.Warn("could not record the run in the audit log", "err", err)
+	}
+	slog.SetDefault(run.Logger())
+	return run
+}
+
+// finishRun records how a run started with startRun ended.
+func finishRun(run *runlog.Run, err error) {
+	if err := run.Finish(err); err != nil {
+		slog.Warn("could not record the run in the audit log", "err", err)
+	}
+}
+
+// recordPublished summarises a generated site or bundle on its run.
+func recordPublished(run *runlog.Run, pages int, stats artifacts.PublishStats) {
+	run.Summary = fmt.Sprintf("%d pages", pages)
+	if stats.Snapshot != "" {
+		run.Summary += fmt.Sprintf("; %d files written, %d unchanged, %d removed (snapshot %s)", stats.Written, stats.Unchanged, stats.Removed, stats.Snapshot)
+	}
+}
+
+func runRunsList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	f := runlog.Filter{}
+	f.Command, _ = cmd.Flags().GetString("command")
+	f.Project, _ = cmd.Flags().GetString("project")
+	status, _ := cmd.Flags().GetString("status")
+	switch s := runlog.Status(status); s {
+	case "", runlog.StatusRunning, runlog.StatusSucceeded, runlog.StatusFailed:
+		f.Status = s
+	default:
+		return fmt.Errorf("--status must be running, succeeded, or failed")
+	}
+	if since, _ := cmd.Flags().GetDuration("since"); since > 0 {
+		f.Since = time.Now().Add(-since)
+	}
+	f.Limit, _ = cmd.Flags().GetInt("limit")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	runs, err := runlog.Open(cfg.OutputDir).List(f)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if runs == nil {
+			runs = []runlog.Run{}
+		}
+		return printJSON(runs)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "RUN\tSTARTED\tDURATION\tCOMMAND\tPROJECT\tACTOR\tSTATUS\tSUMMARY")
+	for _, r := range runs {
+		duration := "-"
+		if !r.FinishedAt.IsZero() {
+			duration = r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		summary := r.Summary
+		if r.Error != "" {
+			summary = r.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			duration, r.Command, r.Project, r.Actor, r.Status, summary)
+	}
+	return nil
+}
+
+func runRunsShow(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	run, err := runlog.Open(cfg.OutputDir).Get(args[0])
+	if err != nil {
+		return err
+	}
+	if run == nil {
+		return fmt.Errorf("no run %q in the audit log", args[0])
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return printJSON(run)
+	}
+
+	fmt.Printf("Run %s: %s", run.ID, run.Command)
+	if run.Project != "" {
+		fmt.Printf(" (%s)", run.Project)
+	}
+	fmt.Printf("\n  Actor:    %s\n  Started:  %s\n", run.Actor, run.StartedAt.Local().Format(time.RFC1123))
+	if !run.FinishedAt.IsZero() {
+		fmt.Printf("  Finished: %s (%s)\n", run.FinishedAt.Local().Format(time.RFC1123), run.FinishedAt.Sub(run.StartedAt).Round(time.Second))
+	}
+	fmt.Printf("  Status:   %s\n", run.Status)
+	if run.Error != "" {
+		fmt.Printf("  Error:    %s\n", run.Error)
+	}
+	if run.Summary != "" {
+		fmt.Printf("  Summary:  %s\n", run.Summary)
+	}
+	if len(run.Changes) > 0 {
+		fmt.Printf("\nChanges:\n  %s\n", strings.Join(run.Changes, "\n  "))
+		if run.Omitted > 0 {
+			fmt.Printf("  ... and %d more\n", run.Omitted)
+		}
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -3,26 +3,44 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
 	mcpserver "github.com/ziadkadry99/auto-doc/internal/mcp"
+	"github.com/ziadkadry99/auto-doc/internal/site"
+	"github.com/ziadkadry99/auto-doc/internal/siteauth"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the MCP server for AI agent integration",
-	Long:  `Starts a Model Context Protocol (MCP) server on stdio, exposing codebase search tools for AI agents like Claude Code.`,
+	Long: `Starts a Model Context Protocol (MCP) server on stdio, exposing codebase search tools for AI agents like Claude Code.
+
+With --http, serves the static documentation site over HTTP instead, together
+with a /api/search endpoint backed by the vector store, so the site's search is
+semantic. When a provider is configured, searches also get an LLM-written
+answer citing the doc pages it used; questions draw on the architecture facts
+recorded in the central database too, as the MCP ask_architecture tool does. When the
+config has an auth section, visitors must sign in and only see the repos their
+groups are allowed to.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("loading config: %w", err)
 		}
 
+		if httpMode, _ := cmd.Flags().GetBool("http"); httpMode {
+			return runServeHTTP(cmd, cfg)
+		}
+
 		// Create embedder for query embedding during search.
 		embedder, err := createEmbedderFromConfig(cfg)
 		if err != nil {
@@ -30,7 +48,7 @@ var serveCmd = &cobra.Command{
 		}
 
 		// Create and load vector store.
-		store, err := vectordb.NewChromemStore(embedder)
+		store, err := createVectorStore(cfg, embedder)
 		if err != nil {
 			return fmt.Errorf("creating vector store: %w", err)
 		}
@@ -38,11 +56,11 @@ var serveCmd = &cobra.Command{
 		vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
 		if err := store.Load(context.Background(), vectorDir); err != nil {
 			// Log warning but continue — store may be empty if generate hasn't run yet.
-			fmt.Fprintf(os.Stderr, "Warning: could not load vector store from %s: %v\n", vectorDir, err)
+			slog.Warn("could not load vector store", "path", vectorDir, "err", err)
 			fmt.Fprintf(os.Stderr, "Search results will be empty. Run `autodoc generate` first.\n")
 		}
 
-		docsDir := cfg.OutputDir
+		docsDir := filepath.Join(cfg.OutputDir, "docs")
 
 		// Set version from the cmd package variable.
 		mcpserver.Version = Version
@@ -50,11 +68,142 @@ var serveCmd = &cobra.Command{
 		fmt.Fprintf(os.Stderr, "autodoc MCP server started on stdio (docs=%s, documents=%d)\n", docsDir, store.Count())
 
 		srv := mcpserver.NewServer(store, embedder, docsDir)
+		if sessionMemory, _ := cmd.Flags().GetBool("session-memory"); sessionMemory {
+			srv.EnableSessionMemory()
+		}
 		return srv.Serve()
 	},
 }
 
 func init() {
+	serveCmd.Flags().Bool("session-memory", false, "remember each MCP session's recently discussed services, flows, and files so tools can omit them")
+	serveCmd.Flags().Bool("http", false, "serve the static site with semantic /api/search over HTTP instead of MCP on stdio")
+	serveCmd.Flags().Int("port", 8080, "port for --http mode")
+	serveCmd.Flags().String("site-dir", "", "site directory for --http mode (defaults to {outputDir}/site; generated if missing)")
+	serveCmd.Flags().Bool("no-llm", false, "in --http mode, return search results without LLM-written answers")
+	serveCmd.Flags().Bool("open", false, "in --http mode, open the browser automatically")
 	rootCmd.AddCommand(serveCmd)
 }
 
+// runServeHTTP serves the static site and /api/search for `autodoc serve --http`.
+func runServeHTTP(cmd *cobra.Command, cfg *config.Config) error {
+	siteDir, _ := cmd.Flags().GetString("site-dir")
+	if siteDir == "" {
+		siteDir = filepath.Join(cfg.OutputDir, "site")
+	}
+
+	// Build the site on first use so `autodoc serve --http` works straight after generate.
+	if _, err := os.Stat(filepath.Join(siteDir, "index.html")); os.IsNotExist(err) {
+		docsDir := filepath.Join(cfg.OutputDir, "docs")
+		if _, err := os.Stat(docsDir); os.IsNotExist(err) {
+			return fmt.Errorf("no site at %s and no docs at %s\nRun `autodoc generate` first", siteDir, docsDir)
+		}
+		generator := site.NewSiteGenerator(docsDir, siteDir, projectNameFromWd())
+		generator.LogoPath = cfg.Logo
+		generator.Assets = siteAssets(cfg)
+		generator.Theme = siteTheme(cfg)
+		pageCount, err := generator.Generate()
+		if err != nil {
+			return fmt.Errorf("generating site: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Static site generated: %s (%d pages)\n", siteDir, pageCount)
+	}
+
+	store := loadSearchStore(cfg)
+
+	var llmProvider llm.Provider
+	if noLLM, _ := cmd.Flags().GetBool("no-llm"); !noLLM && store != nil {
+		if p, err := createLLMProviderFromConfig(cfg); err == nil {
+			llmProvider = p
+			fmt.Fprintln(os.Stderr, "LLM-powered search answers enabled")
+		} else {
+			fmt.Fprintf(os.Stderr, "LLM answers unavailable: %v\n", err)
+		}
+	}
+
+	authn, err := siteauth.New(context.Background(), cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("configuring authentication: %w", err)
+	}
+
+	facts, closeFacts := loadFactSource(cfg, llmProvider)
+	defer closeFacts()
+
+	var handler http.Handler
+	if authn != nil {
+		var languageDirs []string
+		for _, lang := range site.NewSiteLanguages(cfg.I18n.SourceLanguage, cfg.I18n.Languages) {
+			if lang.Dir != "" {
+				languageDirs = append(languageDirs, lang.Dir)
+			}
+		}
+		authn.SetLanguages(languageDirs)
+		handler = authn.Middleware(withMetricsAPI(site.NewHandler(siteDir, store, llmProvider, cfg.ModelFor(config.TaskQA), facts, authn.RepoAccess), cfg, authn.RepoAccess))
+		fmt.Fprintf(os.Stderr, "Sign-in required (%s)\n", cfg.Auth.Provider)
+	} else {
+		handler = withMetricsAPI(site.NewHandler(siteDir, store, llmProvider, cfg.ModelFor(config.TaskQA), facts, nil), cfg, nil)
+	}
+
+	port, _ := cmd.Flags().GetInt("port")
+	openBrowser, _ := cmd.Flags().GetBool("open")
+	return site.ServeHandler(handler, port, openBrowser)
+}
+
+// loadSearchStore loads the vector store backing /api/search. It returns nil
+// when the store is missing or empty, in which case the site falls back to
+// its local index search.
+func loadSearchStore(cfg *config.Config) vectordb.VectorStore {
+	vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
+	if _, err := os.Stat(vectorDir); err != nil && usesLocalVectorStore(cfg) {
+		fmt.Fprintln(os.Stderr, "AI search unavailable (no vector DB found — run `autodoc generate` first)")
+		return nil
+	}
+
+	embedder, err := createEmbedderFromConfig(cfg)
+	if err == nil {
+		store, storeErr := createVectorStore(cfg, embedder)
+		if storeErr == nil {
+			if loadErr := store.Load(context.Background(), vectorDir); loadErr == nil {
+				if n := store.Count(); n > 0 {
+					fmt.Fprintf(os.Stderr, "AI search enabled (%d documents indexed)\n", n)
+					return store
+				}
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr, "AI search unavailable (vector DB could not be loaded — file search still works)")
+	return nil
+}
+
+// loadFactSource opens the central database's recorded architecture facts
+// for answering questions in the site's search. It returns nil when there is
+// no LLM to answer with or no central database; the returned func closes the
+// database.
+func loadFactSource(cfg *config.Config, llmProvider llm.Provider) (contextengine.FactSource, func()) {
+	if llmProvider == nil {
+		return nil, func() {}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "autodoc.db")); err != nil {
+		return nil, func() {}
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Architecture facts unavailable for answers: %v\n", err)
+		return nil, func() {}
+	}
+	fmt.Fprintln(os.Stderr, "Architecture Q&A enabled (questions draw on recorded facts)")
+	return contextengine.NewStore(database), func() { database.Close() }
+}
+
+// projectNameFromWd derives the project name from the working directory.
+func projectNameFromWd() string {
+	projectName := "Documentation"
+	if wd, err := os.Getwd(); err == nil {
+		projectName = filepath.Base(wd)
+	}
+	if projectName == "." || projectName == "" {
+		projectName = "Documentation"
+	}
+	return projectName
+}
+
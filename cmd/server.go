@@ -3,13 +3,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/spf13/cobra"
 
+	"github.com/ziadkadry99/auto-doc/internal/api"
+	"github.com/ziadkadry99/auto-doc/internal/apikeys"
 	"github.com/ziadkadry99/auto-doc/internal/audit"
 	"github.com/ziadkadry99/auto-doc/internal/backlog"
 	"github.com/ziadkadry99/auto-doc/internal/bots"
@@ -20,10 +25,14 @@ import (
 	"github.com/ziadkadry99/auto-doc/internal/db"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
 	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/incident"
 	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
 	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
 	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/review"
 	"github.com/ziadkadry99/auto-doc/internal/server"
+	"github.com/ziadkadry99/auto-doc/internal/siteauth"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
@@ -32,7 +41,12 @@ var serverPort int
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Start the Phase 4 central documentation server",
-	Long:  `Starts the autodoc central documentation server with REST API, chat dashboard, and multi-repo support.`,
+	Long: `Starts the autodoc central documentation server with REST API, chat dashboard, and multi-repo support.
+
+The read-only architecture API under /api/v1 (repos, links, flows, ownership,
+facts, notifications, and search) takes API keys created with
+POST /api/apikeys, which only members of auth.admin_groups may call; its
+OpenAPI spec is served at /api/v1/openapi.yaml.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
@@ -46,14 +60,14 @@ var serverCmd = &cobra.Command{
 		}
 
 		// Create and load vector store.
-		store, err := vectordb.NewChromemStore(embedder)
+		store, err := createVectorStore(cfg, embedder)
 		if err != nil {
 			return fmt.Errorf("creating vector store: %w", err)
 		}
 
 		vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
 		if err := store.Load(context.Background(), vectorDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not load vector store from %s: %v\n", vectorDir, err)
+			slog.Warn("could not load vector store", "path", vectorDir, "err", err)
 		}
 
 		// Create LLM provider.
@@ -70,16 +84,33 @@ var serverCmd = &cobra.Command{
 		}
 		defer database.Close()
 
+		// Optional sign-in for the notifications API.
+		authn, err := siteauth.New(context.Background(), cfg.Auth)
+		if err != nil {
+			return fmt.Errorf("configuring authentication: %w", err)
+		}
+
+		// Reviewer groups can only be checked for signed-in users; without
+		// sign-in anyone could approve pages under any name.
+		if len(cfg.CentralSite.Review.Reviewers) > 0 && authn == nil {
+			return fmt.Errorf("central_site.review.reviewers needs auth to be configured, so reviewers can be identified")
+		}
+
+		notifTemplates, err := notificationTemplates(cfg)
+		if err != nil {
+			return err
+		}
+
 		// Create and start server.
 		srv := server.New(server.Config{
 			Port:     serverPort,
 			DataDir:  cfg.OutputDir,
 			DocsDir:  cfg.OutputDir,
 			AllowAll: true,
-		}, database, store, embedder, llmProvider, cfg.Model)
+		}, database, store, embedder, llmProvider, cfg.ModelFor(config.TaskQA))
 
 		// Register all feature routes.
-		registerAllRoutes(srv, database, llmProvider, cfg.Model, store)
+		registerAllRoutes(srv, database, llmProvider, cfg.ModelFor(config.TaskQA), store, authn, notifTemplates, cfg)
 
 		// Graceful shutdown.
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -101,7 +132,9 @@ var serverCmd = &cobra.Command{
 }
 
 // registerAllRoutes wires up all Phase 4 feature routes.
-func registerAllRoutes(srv *server.Server, database *db.DB, llmProvider interface{}, model string, store vectordb.VectorStore) {
+// When authn is non-nil, the notifications and preferences API requires sign-in.
+// cfg supplies the on-call schedules and runbooks behind the bots' incident command.
+func registerAllRoutes(srv *server.Server, database *db.DB, llmProvider interface{}, model string, store vectordb.VectorStore, authn *siteauth.Authenticator, notifTemplates *notifications.Templates, cfg *config.Config) {
 	r := srv.Router()
 
 	// Audit Trail
@@ -123,7 +156,22 @@ func registerAllRoutes(srv *server.Server, database *db.DB, llmProvider interfac
 	// Notifications
 	notifStore := notifications.NewStore(database)
 	notifDispatcher := notifications.NewDispatcher(notifStore)
-	notifications.RegisterRoutes(r, notifStore, notifDispatcher)
+	notifDispatcher.SetTagSource(registry.NewStore(database))
+	notifDispatcher.SetTemplates(notifTemplates)
+	var notifRouter chi.Router = r
+	if authn != nil {
+		notifRouter = r.With(authn.RequireAuth)
+	}
+	notifications.RegisterRoutes(notifRouter, notifStore, notifDispatcher)
+
+	// Page review, for central sites gated on approval. Reviewer groups
+	// are only configured along with sign-in.
+	reviewStore := review.NewStore(database)
+	var reviewRouter chi.Router = r
+	if authn != nil {
+		reviewRouter = r.With(authn.RequireAuth)
+	}
+	review.RegisterRoutes(reviewRouter, reviewStore, cfg.CentralSite.Review.Reviewers)
 
 	// Knowledge Backlog
 	backlogStore := backlog.NewStore(database)
@@ -144,6 +192,7 @@ func registerAllRoutes(srv *server.Server, database *db.DB, llmProvider interfac
 
 	// Bots (Slack & Teams)
 	botProcessor := bots.NewProcessor(ctxEngine, backlogStore)
+	botProcessor.SetIncidentBuilder(incident.NewBuilder(cfg.Incident, database, oncall.NewResolver(cfg.OnCall, ctxStore), ctxStore))
 	botGateway := bots.NewGateway(botProcessor)
 	slackHandler := bots.NewSlackHandler(botGateway, "")
 	teamsHandler := bots.NewTeamsHandler(botGateway)
@@ -158,9 +207,31 @@ func registerAllRoutes(srv *server.Server, database *db.DB, llmProvider interfac
 		OutputDir: srv.ServerConfig().DataDir,
 	})
 
+	// Partner API keys and the public read-only API. Only signed-in
+	// administrators may manage keys.
+	keyStore := apikeys.NewStore(database)
+	var keyAdmin func(http.Handler) http.Handler
+	if authn != nil && len(cfg.Auth.AdminGroups) > 0 {
+		requireAdmin := siteauth.RequireGroups(cfg.Auth.AdminGroups)
+		keyAdmin = func(next http.Handler) http.Handler { return authn.RequireAuth(requireAdmin(next)) }
+	} else {
+		slog.Warn("API key management is off: it needs auth with auth.admin_groups configured")
+	}
+	visibility := func(repo string) string { return repoVisibility(cfg.CentralSite, repo) }
+	apikeys.RegisterRoutes(r, keyStore, repoStore, visibility, keyAdmin)
+
+	// Key-scoped architecture model API with its OpenAPI spec
+	api.RegisterRoutes(r, api.Deps{
+		Keys:          keyStore,
+		Repos:         repoStore,
+		Flows:         flowStore,
+		Org:           orgStore,
+		Facts:         ctxStore,
+		Notifications: notifStore,
+		Search:        store,
+	})
+
 	_ = confStore
-	_ = orgStore
-	_ = flowStore
 	_ = notifDispatcher
 }
 
@@ -3,18 +3,44 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
 	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/costs"
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/deployenv"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
 	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
+	"github.com/ziadkadry99/auto-doc/internal/metrics"
+	"github.com/ziadkadry99/auto-doc/internal/narratives"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/overrides"
 	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/review"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
 	"github.com/ziadkadry99/auto-doc/internal/site"
-	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+	"github.com/ziadkadry99/auto-doc/internal/sitehooks"
+	"github.com/ziadkadry99/auto-doc/internal/techstack"
+	"github.com/ziadkadry99/auto-doc/internal/slo"
+	"github.com/ziadkadry99/auto-doc/internal/threatmodel"
+	"github.com/ziadkadry99/auto-doc/internal/traces"
 )
 
 var siteCmd = &cobra.Command{
@@ -30,36 +56,66 @@ func init() {
 	siteCmd.Flags().Bool("open", false, "open browser automatically when serving")
 	siteCmd.Flags().String("output", "", "override output directory (defaults to {outputDir}/site)")
 	siteCmd.Flags().Bool("central", false, "generate a combined multi-repo site from all registered repositories")
+	siteCmd.Flags().String("audience", "", "with --central, only include repos visible to this audience (public, internal, team:<name>); skips configured variants")
+	siteCmd.Flags().Bool("offline", false, "vendor D3 and Mermaid into the site instead of loading them from CDNs")
+	siteCmd.Flags().Bool("strict", false, "fail when the site has broken links, Mermaid diagrams that don't parse, or references to unregistered services")
+	siteCmd.Flags().String("format", site.FormatHTML, "output format: html (built-in site), mkdocs (markdown + mkdocs.yml), or docusaurus (markdown + sidebars.js)")
 	rootCmd.AddCommand(siteCmd)
 }
 
-func runSite(cmd *cobra.Command, args []string) error {
+func runSite(cmd *cobra.Command, args []string) (err error) {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
+	if offline, _ := cmd.Flags().GetBool("offline"); offline {
+		cfg.Site.Offline = true
+	}
+	if strict, _ := cmd.Flags().GetBool("strict"); strict {
+		cfg.Site.Strict = true
+	}
 	central, _ := cmd.Flags().GetBool("central")
+	format, _ := cmd.Flags().GetString("format")
+	if err := site.ValidateFormat(format); err != nil {
+		return err
+	}
+	markdownOnly := format == site.FormatMkDocs || format == site.FormatDocusaurus
+	if serve, _ := cmd.Flags().GetBool("serve"); markdownOnly && (central || serve) {
+		return fmt.Errorf("--format %s writes markdown for %s to build; it can't be combined with --central or --serve", format, format)
+	}
 
 	// Determine output directory.
 	outputDir, _ := cmd.Flags().GetString("output")
 	if outputDir == "" {
 		outputDir = filepath.Join(cfg.OutputDir, "site")
+		if markdownOnly {
+			outputDir = filepath.Join(cfg.OutputDir, format)
+		}
 	}
 
 	// Derive project name from the working directory.
-	projectName := "Documentation"
-	if wd, wdErr := os.Getwd(); wdErr == nil {
-		projectName = filepath.Base(wd)
+	projectName := projectNameFromWd()
+
+	command := "site"
+	if central {
+		command = "site --central"
 	}
-	if projectName == "." || projectName == "" {
-		projectName = "Documentation"
+	run := startRun(cfg, command, projectName)
+	defer func() { finishRun(run, err) }()
+
+	store, err := openArtifacts(cfg)
+	if err != nil {
+		return err
 	}
 
 	var pageCount int
+	var published artifacts.PublishStats
+	validation := &site.ValidationReport{}
 
 	if central {
-		pageCount, err = runCentralSite(cfg, outputDir, projectName)
+		audience, _ := cmd.Flags().GetString("audience")
+		pageCount, published, err = runCentralSite(cfg, store, outputDir, projectName, audience, validation)
 	} else {
 		// Verify that docs have been generated.
 		docsDir := filepath.Join(cfg.OutputDir, "docs")
@@ -67,15 +123,48 @@ func runSite(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("docs directory not found at %s\nRun `autodoc generate` first to create documentation", docsDir)
 		}
 
+		if markdownOnly {
+			adapter, err := site.NewAdapter(format, docsDir, outputDir, projectName)
+			if err != nil {
+				return err
+			}
+			adapter.LogoPath = cfg.Logo
+			adapter.Artifacts = store
+			if pageCount, err = adapter.Write(); err != nil {
+				return fmt.Errorf("writing %s docs: %w", format, err)
+			}
+			fmt.Printf("%s docs written: %s (%d pages)\n", format, outputDir, pageCount)
+			printPublished(adapter.Published)
+			recordPublished(run, pageCount, adapter.Published)
+			announcePublished(context.Background(), cfg, sitehooks.Event{Command: command, Project: projectName, Format: format, RunID: run.ID, Pages: pageCount}, adapter.Published)
+			printAdapterHint(format, outputDir)
+			collectArtifacts(context.Background(), store)
+			return nil
+		}
+
 		generator := site.NewSiteGenerator(docsDir, outputDir, projectName)
 		generator.LogoPath = cfg.Logo
+		generator.Artifacts = store
+		generator.Assets = siteAssets(cfg)
+		generator.Theme = siteTheme(cfg)
+		generator.Validation = validation
+		generator.Languages, generator.Translator = siteTranslation(cfg, store)
 		pageCount, err = generator.Generate()
+		published = generator.Published
+		reportTranslation(generator.Translator)
 	}
 	if err != nil {
 		return fmt.Errorf("generating site: %w", err)
 	}
 
 	fmt.Printf("Static site generated: %s (%d pages)\n", outputDir, pageCount)
+	printPublished(published)
+	recordPublished(run, pageCount, published)
+	announcePublished(context.Background(), cfg, sitehooks.Event{Command: command, Project: projectName, Format: format, RunID: run.ID, Pages: pageCount}, published)
+	collectArtifacts(context.Background(), store)
+	if err := reportValidation(validation, cfg.Site.Strict); err != nil {
+		return err
+	}
 
 	// Optionally serve the site.
 	serve, _ := cmd.Flags().GetBool("serve")
@@ -84,25 +173,7 @@ func runSite(cmd *cobra.Command, args []string) error {
 		openBrowser, _ := cmd.Flags().GetBool("open")
 
 		// Try to load the vector store for AI-powered search.
-		var store vectordb.VectorStore
-		vectorDir := filepath.Join(cfg.OutputDir, "vectordb")
-		if _, statErr := os.Stat(vectorDir); statErr == nil {
-			embedder, embErr := createEmbedderFromConfig(cfg)
-			if embErr == nil {
-				chromemStore, storeErr := vectordb.NewChromemStore(embedder)
-				if storeErr == nil {
-					if loadErr := chromemStore.Load(context.Background(), vectorDir); loadErr == nil && chromemStore.Count() > 0 {
-						store = chromemStore
-						fmt.Printf("AI search enabled (%d documents indexed)\n", chromemStore.Count())
-					}
-				}
-			}
-			if store == nil {
-				fmt.Println("AI search unavailable (vector DB could not be loaded — file search still works)")
-			}
-		} else {
-			fmt.Println("AI search unavailable (no vector DB found — run `autodoc generate` first)")
-		}
+		store := loadSearchStore(cfg)
 
 		// Try to create an LLM provider for search answer synthesis.
 		var llmProvider llm.Provider
@@ -111,8 +182,12 @@ func runSite(cmd *cobra.Command, args []string) error {
 			fmt.Println("LLM-powered search answers enabled")
 		}
 
+		facts, closeFacts := loadFactSource(cfg, llmProvider)
+		defer closeFacts()
+
 		fmt.Printf("Serving at http://localhost:%d — press Ctrl+C to stop\n", port)
-		if err := site.Serve(outputDir, port, openBrowser, store, llmProvider, cfg.Model); err != nil {
+		handler := withMetricsAPI(site.NewHandler(outputDir, store, llmProvider, cfg.ModelFor(config.TaskQA), facts, nil), cfg, nil)
+		if err := site.ServeHandler(handler, port, openBrowser); err != nil {
 			return fmt.Errorf("serving site: %w", err)
 		}
 	}
@@ -120,14 +195,67 @@ func runSite(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// announcePublished tells the endpoints under site.webhooks that a site was
+// published, with what the publish changed. Deliveries that fail only
+// warn: the site is out either way.
+func announcePublished(ctx context.Context, cfg *config.Config, ev sitehooks.Event, stats artifacts.PublishStats) {
+	if len(cfg.Site.Webhooks) == 0 {
+		return
+	}
+	hooks := make([]sitehooks.Hook, len(cfg.Site.Webhooks))
+	for i, w := range cfg.Site.Webhooks {
+		// Send refuses hooks left without a secret.
+		hooks[i] = sitehooks.Hook{URL: w.URL}
+		if w.SecretEnv != "" {
+			hooks[i].Secret = os.Getenv(w.SecretEnv)
+		}
+	}
+	ev.Snapshot = stats.Snapshot
+	ev.Changes = sitehooks.Changes{
+		Added:     stats.AddedFiles,
+		Changed:   stats.ChangedFiles,
+		Removed:   stats.RemovedFiles,
+		Unchanged: stats.Unchanged,
+	}
+	if err := sitehooks.NewSender(hooks).Send(ctx, ev); err != nil {
+		slog.Warn("site webhook delivery failed", "phase", "publish", "err", err)
+	}
+}
+
+// printAdapterHint says how to build, or merge in, the markdown an adapter
+// wrote.
+func printAdapterHint(format, outputDir string) {
+	switch format {
+	case site.FormatMkDocs:
+		fmt.Printf("Build it with `mkdocs build -f %s` (needs mkdocs-material).\n", filepath.Join(outputDir, "mkdocs.yml"))
+	case site.FormatDocusaurus:
+		fmt.Printf("Copy %s into your Docusaurus project and use its sidebars.js. The pages are plain markdown: set markdown.format to \"detect\", and for diagrams add @docusaurus/theme-mermaid with markdown.mermaid enabled.\n", filepath.Join(outputDir, "docs"))
+	}
+}
+
 // runCentralSite generates a combined multi-repo site from all registered repositories.
-func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, error) {
+// With an audience, only that filtered site is built; otherwise the full site
+// is built along with any variants configured under central_site.variants.
+func runCentralSite(cfg *config.Config, store *artifacts.Store, outputDir, projectName, audience string, validation *site.ValidationReport) (int, artifacts.PublishStats, error) {
 	ctx := context.Background()
 
+	if err := site.ValidateAudience(audience); err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+	defaultVisibility := cfg.CentralSite.DefaultVisibility
+	if err := site.ValidateVisibility(defaultVisibility); err != nil {
+		return 0, artifacts.PublishStats{}, fmt.Errorf("central_site.default_visibility: %w", err)
+	}
+	for name, v := range cfg.CentralSite.Visibility {
+		if err := site.ValidateVisibility(v); err != nil {
+			return 0, artifacts.PublishStats{}, fmt.Errorf("central_site.visibility.%s: %w", name, err)
+		}
+	}
+
 	// Open the central database.
 	database, err := openCentralDB(cfg)
 	if err != nil {
-		return 0, fmt.Errorf("opening central database: %w\nHave you registered any repos with `autodoc repo add`?", err)
+		return 0, artifacts.PublishStats{}, fmt.Errorf("opening central database: %w\nHave you registered any repos with `autodoc repo add`?", err)
 	}
 	defer database.Close()
 
@@ -135,13 +263,29 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 	repoStore := registry.NewStore(database)
 	repos, err := repoStore.List(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("listing repos: %w", err)
+		return 0, artifacts.PublishStats{}, fmt.Errorf("listing repos: %w", err)
 	}
 	if len(repos) == 0 {
-		return 0, fmt.Errorf("no repositories registered\nUse `autodoc repo add <name> --path <path>` to register repos first")
+		return 0, artifacts.PublishStats{}, fmt.Errorf("no repositories registered\nUse `autodoc repo add <name> --path <path>` to register repos first")
 	}
 
 	// Convert repos to site RepoInfo.
+	ctxStore := contextengine.NewStore(database)
+	onCall := oncall.NewResolver(cfg.OnCall, ctxStore)
+	subdirs := make(map[string][]string) // monorepo -> its sub-services' directories
+	for _, r := range repos {
+		if r.Parent != "" {
+			subdirs[r.Parent] = append(subdirs[r.Parent], r.Subdir)
+		}
+	}
+	health, err := repohealth.NewStore(database).List(ctx)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+	healthByRepo := make(map[string]*repohealth.Health, len(health))
+	for i := range health {
+		healthByRepo[health[i].Repo] = &health[i]
+	}
 	siteRepos := make([]site.RepoInfo, len(repos))
 	for i, r := range repos {
 		docsDir := filepath.Join(r.LocalPath, ".autodoc", "docs")
@@ -149,7 +293,7 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 			docsDir = "" // No docs available for this repo.
 		}
 		// Detect primary language from analyses.
-		lang := detectRepoLanguage(r.LocalPath)
+		lang := detectRepoLanguage(r.LocalPath, r.Subdir, subdirs[r.Name])
 
 		siteRepos[i] = site.RepoInfo{
 			Name:          r.Name,
@@ -161,13 +305,24 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 			Language:      lang,
 			LastCommitSHA: r.LastCommitSHA,
 			DocsDir:       docsDir,
+			Parent:        r.Parent,
+			Subdir:        r.Subdir,
+			Excludes:      subdirs[r.Name],
+			Visibility:    repoVisibility(cfg.CentralSite, r.Name),
+			Logo:          repoLogo(cfg.CentralSite, r.Name, r.LocalPath),
+			Health:        healthByRepo[r.Name],
 		}
+		siteRepos[i].Domain, siteRepos[i].BoundedContext, siteRepos[i].Environment = repoGroup(cfg.CentralSite, r)
+		if l := onCall.Lookup(ctx, r.Name); l != nil {
+			siteRepos[i].OnCall = siteOnCall(*l)
+		}
+		siteRepos[i].Facts = serviceFacts(ctx, ctxStore, r.Name)
 	}
 
 	// Load cross-service links.
 	links, err := repoStore.GetLinks(ctx, "")
 	if err != nil {
-		return 0, fmt.Errorf("loading links: %w", err)
+		return 0, artifacts.PublishStats{}, fmt.Errorf("loading links: %w", err)
 	}
 	siteLinks := make([]site.LinkInfo, len(links))
 	for i, l := range links {
@@ -180,6 +335,22 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 		}
 	}
 
+	// Check the links against production traces, if any were imported.
+	observed, err := traces.NewStore(database).List(ctx)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+	var runtimeOnly []site.LinkInfo
+	if len(observed) > 0 {
+		siteLinks, runtimeOnly = siteRuntime(siteLinks, traces.Compare(links, observed))
+	}
+
+	// Tag links with the environments they exist in.
+	siteLinks, environments, err := siteEnvironments(cfg.Environments, repos, subdirs, siteLinks, siteRepos)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
 	// Load flows.
 	flowStore := flows.NewStore(database)
 	allFlows, _ := flowStore.ListFlows(ctx)
@@ -194,6 +365,118 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 		}
 	}
 
+	// Load the architecture changelog.
+	changes, err := repoStore.ListChanges(ctx, time.Time{})
+	if err != nil {
+		return 0, artifacts.PublishStats{}, fmt.Errorf("loading architecture changes: %w", err)
+	}
+	siteChanges := make([]site.ChangeInfo, len(changes))
+	for i, c := range changes {
+		services := []string{c.Repo}
+		if c.FromRepo != "" {
+			services = []string{c.FromRepo, c.ToRepo}
+		}
+		siteChanges[i] = site.ChangeInfo{
+			Date:      c.ChangedAt,
+			Summary:   c.Describe(),
+			Services:  services,
+			CommitSHA: c.CommitSHA,
+			CommitURL: c.CommitURL(),
+		}
+	}
+
+	// Load the snapshots the service map's timeline replays.
+	var history []site.GraphSnapshot
+	if months := cfg.CentralSite.HistoryMonths; months >= 0 {
+		if months == 0 {
+			months = 12
+		}
+		snaps, err := repoStore.ListSnapshots(ctx, time.Now().AddDate(0, -months, 0))
+		if err != nil {
+			return 0, artifacts.PublishStats{}, fmt.Errorf("loading architecture snapshots: %w", err)
+		}
+		for _, s := range snaps {
+			gs := site.GraphSnapshot{TakenAt: s.TakenAt, Services: s.Services}
+			for _, l := range s.Links {
+				gs.Links = append(gs.Links, site.LinkInfo{FromRepo: l.From, ToRepo: l.To, LinkType: l.Type})
+			}
+			history = append(history, gs)
+		}
+	}
+
+	// Load teams and service ownership.
+	siteTeams, err := loadSiteTeams(ctx, orgstructure.NewStore(database))
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load service level objectives from config and context facts.
+	repoNames := make([]string, len(repos))
+	for i, r := range repos {
+		repoNames[i] = r.Name
+	}
+	slos, err := slo.Load(ctx, cfg.SLOs, ctxStore, repoNames)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the data classes to follow for the PII flow.
+	dataClasses, err := dataclass.Load(ctx, cfg.DataClasses, ctxStore)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load corrections to links and flows made in conversation.
+	corrections, err := overrides.Load(ctx, ctxStore)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the notes security teams made on the flows' threat models.
+	threatNotes, err := threatmodel.Load(ctx, ctxStore)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the edits made to the flows' narratives in conversation.
+	narrativeEdits, err := narratives.Load(ctx, ctxStore)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the deprecated endpoints, from the checkouts, config, and facts.
+	deprecations, err := deprecation.Load(ctx, cfg.Deprecations, ctxStore, repos)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the services' monthly costs from imported billing exports.
+	serviceCosts, err := costs.NewStore(database).List(ctx)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Load the topics' schema versions the daemon recorded.
+	schemaVersions, err := schemareg.NewStore(database).List(ctx)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+
+	// Overlay current traffic from Prometheus on the service map.
+	var snapshot *metrics.Snapshot
+	if client := newMetricsClient(cfg, repoNames); client != nil {
+		if snapshot, err = client.Snapshot(ctx); err != nil {
+			slog.Warn("could not fetch metrics", "phase", "metrics", "url", cfg.Metrics.PrometheusURL, "err", err)
+		}
+	}
+
+	// Load flow templates from config and the shared templates file.
+	flowTemplates, err := siteFlowTemplates(cfg.CentralSite)
+	if err != nil {
+		return 0, artifacts.PublishStats{}, err
+	}
+	hopLatency, latencyHints := siteLatency(cfg.CentralSite)
+
 	// Generate the combined site.
 	gen := &site.CentralSiteGenerator{
 		OutputDir:   outputDir,
@@ -202,37 +485,514 @@ func runCentralSite(cfg *config.Config, outputDir, projectName string) (int, err
 		Links:       siteLinks,
 		Flows:       siteFlows,
 		LogoPath:    cfg.Logo,
+		Audience:    audience,
+		Changes:     siteChanges,
+		Teams:       siteTeams,
+		SLOs:        slos,
+		RuntimeOnly: runtimeOnly,
+		Overrides:   corrections,
+		DataClasses: dataClasses,
+		ThreatNotes: threatNotes,
+		Metrics:     snapshot,
+		Artifacts:   store,
+		Assets:      siteAssets(cfg),
+		Theme:       siteTheme(cfg),
+		PagesDir:    cfg.CentralSite.PagesDir,
+		Validation:  validation,
+
+		FlowTemplates:  flowTemplates,
+		Model:          cfg.ModelFor(config.TaskFlows),
+		NarrativeCache: filepath.Join(cfg.OutputDir, "flow-narratives.json"),
+		NarrativeEdits: narrativeEdits,
+		NarrativeLocks: filepath.Join(cfg.OutputDir, "flow-narrative-locks.json"),
+		HopLatency:     hopLatency,
+		LatencyHints:   latencyHints,
+
+		Environments:   environments,
+		History:        history,
+		Views:          mapviews.ForMap(cfg.Site.Views, config.MapService),
+		SchemaVersions: schemaVersions,
+		Deprecations:   deprecations,
+		Costs:          serviceCosts,
+		QuietAfter:     repohealth.QuietAfter(cfg.Daemon.QuietDays),
+	}
+	if provider, err := createLLMProviderFromConfig(cfg); err == nil {
+		gen.Provider = provider
+	}
+	if embedder, err := createEmbedderFromConfig(cfg); err == nil {
+		cache := cacheEmbeddings(cfg, embedder)
+		defer saveEmbeddingCache(cfg, cache)
+		gen.Embedder = cache
+	}
+	if cfg.CentralSite.Review.Enabled {
+		gen.Review = review.NewStore(database)
+	}
+	gen.Languages, gen.Translator = siteTranslation(cfg, store)
+	defer reportTranslation(gen.Translator)
+
+	// Variants are generated first because Generate mutates the generator's inputs.
+	if audience == "" && len(cfg.CentralSite.Variants) > 0 {
+		variants := make([]site.SiteVariant, len(cfg.CentralSite.Variants))
+		for i, v := range cfg.CentralSite.Variants {
+			variants[i] = site.SiteVariant{Name: v.Name, Audience: v.Audience, OutputDir: v.Output}
+		}
+		counts, err := gen.GenerateVariants(variants)
+		if err != nil {
+			return 0, artifacts.PublishStats{}, err
+		}
+		for _, v := range variants {
+			fmt.Printf("Site variant %s (%s): %d pages\n", v.Name, v.Audience, counts[v.Name])
+		}
 	}
 
 	fmt.Printf("Generating central site for %d repositories...\n", len(repos))
-	return gen.Generate()
+	n, err := gen.Generate()
+	if err == nil && len(gen.NarrativeConflicts) > 0 {
+		notifyNarrativeConflicts(ctx, cfg, repoStore, notifications.NewStore(database), gen.NarrativeConflicts)
+	}
+	if err == nil && len(gen.Pending) > 0 {
+		fmt.Printf("Pages held back awaiting review: %d (see `autodoc review list`)\n", len(gen.Pending))
+	}
+	return n, gen.Published, err
 }
 
-// detectRepoLanguage determines the primary programming language of a repo from its analyses.
-func detectRepoLanguage(repoPath string) string {
-	analyses, err := indexer.LoadAnalyses(repoPath)
-	if err != nil || len(analyses) == 0 {
+// notifyNarrativeConflicts tells the flows' services when a regeneration
+// changed a section of a narrative someone edited.
+func notifyNarrativeConflicts(ctx context.Context, cfg *config.Config, repoStore *registry.Store, store *notifications.Store, conflicts []site.NarrativeConflict) {
+	dispatcher := notifications.NewDispatcher(store)
+	dispatcher.SetTagSource(repoStore)
+	if templates, err := notificationTemplates(cfg); err == nil {
+		dispatcher.SetTemplates(templates)
+	}
+	for _, c := range conflicts {
+		if err := dispatcher.Dispatch(ctx, narrativeConflictNotification(c)); err != nil {
+			slog.Warn("could not send narrative conflict notification", "flow", c.Edit.Flow, "err", err)
+		}
+	}
+}
+
+// narrativeConflictNotification reports a regenerated section of an edited
+// narrative: a warning when the pinned text was kept over it, as someone
+// should reconcile the two, and info when it replaced an amendment.
+func narrativeConflictNotification(c site.NarrativeConflict) notifications.Notification {
+	section := "narrative"
+	if c.Edit.Section != "" {
+		section = fmt.Sprintf("%q section", c.Edit.Section)
+	}
+	n := notifications.Notification{
+		Type:             notifications.TypeNarrativeConflict,
+		Severity:         notifications.SeverityInfo,
+		AffectedServices: c.Services,
+	}
+	now := "It is no longer generated."
+	if generated := strings.Join(strings.Fields(c.Generated), " "); generated != "" {
+		now = "It is now generated as: " + generated
+	}
+	if c.Edit.Pinned {
+		n.Severity = notifications.SeverityWarning
+		n.Title = fmt.Sprintf("The %s of the %s flow changed under a pin", section, c.Edit.Flow)
+		n.Message = fmt.Sprintf("The text pinned by %s was kept. %s Pin it again, or remove the pin, to accept the change.", c.Edit.Provenance(), now)
+		return n
+	}
+	n.Title = fmt.Sprintf("The %s of the %s flow was regenerated over an amendment", section, c.Edit.Flow)
+	n.Message = fmt.Sprintf("The amendment by %s no longer applies. %s Pin the amendment to keep it instead.", c.Edit.Provenance(), now)
+	return n
+}
+
+// siteAssets returns how generated sites load D3 and Mermaid, keeping the
+// downloaded libraries in site.assets_dir or <output_dir>/assets.
+func siteAssets(cfg *config.Config) *site.Assets {
+	dir := cfg.Site.AssetsDir
+	if dir == "" {
+		dir = filepath.Join(cfg.OutputDir, "assets")
+	}
+	return site.NewAssets(dir, cfg.Site.Offline)
+}
+
+// serviceFacts returns the current context facts about a service, by key;
+// the newest wins when a key has several.
+func serviceFacts(ctx context.Context, store *contextengine.Store, service string) map[string]string {
+	facts, err := store.GetCurrentFacts(ctx, "", "service", service)
+	if err != nil {
+		slog.Warn("could not load context facts", "phase", "quality", "repo", service, "err", err)
+		return nil
+	}
+	byKey := make(map[string]string, len(facts))
+	for _, f := range facts {
+		if _, ok := byKey[f.Key]; !ok {
+			byKey[f.Key] = f.Value
+		}
+	}
+	return byKey
+}
+
+// maxValidationIssues is how many validation issues are printed; the
+// site's validation page lists them all.
+const maxValidationIssues = 20
+
+// reportValidation prints what validating the site found, and fails when
+// strict and anything was.
+func reportValidation(report *site.ValidationReport, strict bool) error {
+	fmt.Printf("Validation: %s\n", report.Summary())
+	for i, issue := range report.Issues {
+		if i == maxValidationIssues {
+			fmt.Printf("  ... and %d more (see site-validation.html)\n", len(report.Issues)-i)
+			break
+		}
+		where := issue.Page
+		if where == "" {
+			where = "registry"
+		}
+		fmt.Printf("  %-15s %s: %s\n", issue.Kind, where, issue.Detail)
+	}
+	if strict && len(report.Issues) > 0 {
+		return fmt.Errorf("site validation failed: %s", report.Summary())
+	}
+	return nil
+}
+
+// siteTheme returns the site's branding, or nil when site.theme is empty.
+func siteTheme(cfg *config.Config) *site.Theme {
+	t := cfg.Site.Theme
+	if t == (config.ThemeConfig{}) {
+		return nil
+	}
+	return &site.Theme{
+		PrimaryColor:     t.PrimaryColor,
+		PrimaryColorDark: t.PrimaryColorDark,
+		Font:             t.Font,
+		CodeFont:         t.CodeFont,
+		CSSPath:          t.CustomCSS,
+		JSPath:           t.CustomJS,
+		FaviconPath:      t.Favicon,
+		Footer:           t.Footer,
+	}
+}
+
+// repoLogo returns the logo for a repo on the central site: the one
+// central_site.repo_logos gives it, or else the logo in the repo's own
+// .autodoc.yml, resolved against the repo.
+func repoLogo(cs config.CentralSiteConfig, name, localPath string) string {
+	if logo, ok := cs.RepoLogos[name]; ok {
+		return logo
+	}
+	if localPath == "" {
+		return ""
+	}
+	repoCfg, err := config.Load(filepath.Join(localPath, ".autodoc.yml"))
+	if err != nil || repoCfg.Logo == "" {
 		return ""
 	}
-	// Skip non-programming "languages" when counting.
-	skip := map[string]bool{
-		"unknown": true, "": true, "YAML": true, "Docker": true,
-		"JSON": true, "XML": true, "Markdown": true, "Text": true,
-		"TOML": true, "INI": true, "Properties": true, "Shell": true,
+	if filepath.IsAbs(repoCfg.Logo) {
+		return repoCfg.Logo
+	}
+	return filepath.Join(localPath, repoCfg.Logo)
+}
+
+// siteTranslation returns the languages the site is published in and the
+// translator that produces them, or nothing when i18n.languages is empty.
+// Without a working LLM provider, the site is published in the source
+// language only.
+func siteTranslation(cfg *config.Config, store *artifacts.Store) ([]site.SiteLanguage, *site.Translator) {
+	if len(cfg.I18n.Languages) == 0 {
+		return nil, nil
+	}
+	provider, err := createLLMProviderFromConfig(cfg)
+	if err != nil {
+		slog.Warn("not translating the site", "phase", "translate", "err", err)
+		return nil, nil
+	}
+	model := cfg.ModelFor(config.TaskTranslation)
+	languages := site.NewSiteLanguages(cfg.I18n.SourceLanguage, cfg.I18n.Languages)
+	return languages, &site.Translator{Provider: provider, Model: model, Artifacts: store}
+}
+
+// reportTranslation warns about passages the translator had to leave in
+// the source language.
+func reportTranslation(t *site.Translator) {
+	if t != nil && t.Untranslated > 0 {
+		slog.Warn("passages could not be translated and were left in the source language; they will be retried on the next run", "phase", "translate", "passages", t.Untranslated)
+	}
+}
+
+// siteFlowTemplates converts the configured flow templates, those in
+// flow_templates_file first, for the site generator.
+func siteFlowTemplates(cfg config.CentralSiteConfig) ([]site.FlowTemplate, error) {
+	templates := cfg.FlowTemplates
+	if cfg.FlowTemplatesFile != "" {
+		fromFile, err := config.LoadFlowTemplates(cfg.FlowTemplatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("central_site.flow_templates_file: %w", err)
+		}
+		templates = append(fromFile, templates...)
+	}
+	out := make([]site.FlowTemplate, len(templates))
+	for i, t := range templates {
+		out[i] = site.FlowTemplate{Name: t.Name, Orchestrator: t.Orchestrator, Exclude: t.Exclude, Narrative: t.Narrative}
+		out[i].Budget, _ = time.ParseDuration(t.Budget)
+		for _, p := range t.Phases {
+			out[i].Phases = append(out[i].Phases, site.FlowPhase{Name: p.Name, Services: p.Services, Sequential: p.Sequential})
+		}
+	}
+	return out, nil
+}
+
+// siteLatency converts the configured per-hop latency and latency hints,
+// which Validate has checked, for the site generator.
+func siteLatency(cfg config.CentralSiteConfig) (time.Duration, []site.LatencyHint) {
+	hop, _ := time.ParseDuration(cfg.HopLatency)
+	hints := make([]site.LatencyHint, len(cfg.LatencyHints))
+	for i, h := range cfg.LatencyHints {
+		d, _ := time.ParseDuration(h.Latency)
+		hints[i] = site.LatencyHint{From: h.From, To: h.To, Latency: d}
+	}
+	return hop, hints
+}
+
+// siteRuntime marks links with what production traces say about them, and
+// returns the observed calls no link accounts for.
+func siteRuntime(links []site.LinkInfo, report traces.Report) ([]site.LinkInfo, []site.LinkInfo) {
+	type key struct{ from, to, typ string }
+	status := make(map[key]traces.LinkCheck)
+	label := make(map[key]string)
+	for name, checks := range map[string][]traces.LinkCheck{
+		"observed":     report.Observed,
+		"unobserved":   report.Unobserved,
+		"unverifiable": report.Unverifiable,
+	} {
+		for _, c := range checks {
+			k := key{c.Link.FromRepo, c.Link.ToRepo, c.Link.LinkType}
+			status[k], label[k] = c, name
+		}
+	}
+	for i, l := range links {
+		k := key{l.FromRepo, l.ToRepo, l.LinkType}
+		links[i].Runtime = label[k]
+		if c := status[k]; c.Observed != nil {
+			links[i].ObservedCalls = c.Observed.Calls
+			links[i].ObservedLatencyMs = c.Observed.LatencyMs
+		}
+	}
+
+	missing := make([]site.LinkInfo, len(report.Missing))
+	for i, e := range report.Missing {
+		missing[i] = site.LinkInfo{
+			FromRepo:      e.From,
+			ToRepo:        e.To,
+			LinkType:      e.Protocol,
+			Endpoints:     e.Endpoints,
+			Runtime:           "observed",
+			ObservedCalls:     e.Calls,
+			ObservedLatencyMs: e.LatencyMs,
+		}
+	}
+	return links, missing
+}
+
+// siteEnvironments tags links with the environments the repos' deployment
+// config and the environments config put them in, adding the links
+// nothing else found, such as a call to a mock that only staging makes. It
+// also returns the order to show the environments in.
+func siteEnvironments(cfg config.EnvironmentsConfig, repos []registry.Repository, subdirs map[string][]string, links []site.LinkInfo, siteRepos []site.RepoInfo) ([]site.LinkInfo, []string, error) {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.Name
+	}
+	var scanned []deployenv.Link
+	for _, r := range repos {
+		if r.LocalPath == "" {
+			continue
+		}
+		root := filepath.Join(r.LocalPath, filepath.FromSlash(r.Subdir))
+		if _, err := os.Stat(root); err != nil {
+			continue
+		}
+		found, err := deployenv.Scan(r.Name, root, names, subdirs[r.Name])
+		if err != nil {
+			slog.Warn("could not scan deployment config", "phase", "environments", "repo", r.Name, "err", err)
+			continue
+		}
+		scanned = append(scanned, found...)
+	}
+	tagged, err := deployenv.Merge(cfg, scanned)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var envs []string
+	for _, r := range siteRepos {
+		if r.Environment != "" {
+			envs = append(envs, r.Environment)
+		}
+	}
+	for _, t := range tagged {
+		envs = append(envs, t.Environment)
+	}
+	order := deployenv.Sort(envs, deployenv.Order(cfg))
+
+	type pair struct{ from, to string }
+	var pairs []pair
+	byPair := make(map[pair][]deployenv.Link)
+	for _, t := range tagged {
+		p := pair{t.From, t.To}
+		if byPair[p] == nil {
+			pairs = append(pairs, p)
+		}
+		byPair[p] = append(byPair[p], t)
+	}
+	environmentsOf := func(ts []deployenv.Link) []string {
+		var out []string
+		for _, t := range ts {
+			out = append(out, t.Environment)
+		}
+		return deployenv.Sort(out, order)
+	}
+	found := make(map[pair]bool)
+	for i, l := range links {
+		p := pair{l.FromRepo, l.ToRepo}
+		if ts, ok := byPair[p]; ok {
+			links[i].Environments = environmentsOf(ts)
+			found[p] = true
+		}
+	}
+	for _, p := range pairs {
+		if found[p] {
+			continue
+		}
+		ts := byPair[p]
+		var sources []string
+		for _, t := range ts {
+			if !slices.Contains(sources, t.Source) {
+				sources = append(sources, t.Source)
+			}
+		}
+		reason := "Found in deployment config: " + strings.Join(sources, ", ")
+		if sources[0] == "config" && len(sources) == 1 {
+			reason = "Declared in the environments config"
+		}
+		links = append(links, site.LinkInfo{
+			FromRepo:     p.from,
+			ToRepo:       p.to,
+			LinkType:     ts[0].Type,
+			Reason:       reason,
+			Environments: environmentsOf(ts),
+		})
+	}
+	return links, order, nil
+}
+
+// newMetricsClient returns a Prometheus client for the service map that
+// resolves metric labels to registered repos, or nil when metrics are not
+// configured.
+func newMetricsClient(cfg *config.Config, repoNames []string) *metrics.Client {
+	resolver := traces.NewResolver(repoNames, cfg.Traces.Aliases)
+	return metrics.New(cfg.Metrics, func(name string) string {
+		resolved, _ := resolver.Resolve(name)
+		return resolved
+	})
+}
+
+// withMetricsAPI adds the api/metrics endpoint that a served service map
+// polls for fresh numbers, when Prometheus is configured.
+func withMetricsAPI(h http.Handler, cfg *config.Config, access site.AccessFilter) http.Handler {
+	if cfg.Metrics.PrometheusURL == "" {
+		return h
+	}
+	var repoNames []string
+	if database, err := openCentralDB(cfg); err == nil {
+		if repos, err := registry.NewStore(database).List(context.Background()); err == nil {
+			for _, r := range repos {
+				repoNames = append(repoNames, r.Name)
+			}
+		}
+		database.Close()
 	}
-	langCount := make(map[string]int)
-	for _, a := range analyses {
-		if a.Language != "" && !skip[a.Language] {
-			langCount[a.Language]++
+	mux := http.NewServeMux()
+	mux.Handle("/api/metrics", metrics.Handler(newMetricsClient(cfg, repoNames), access))
+	mux.Handle("/", h)
+	return mux
+}
+
+// repoGroup returns a repo's domain, bounded context, and environment: as
+// configured under central_site.groups, falling back to the registry's.
+func repoGroup(cs config.CentralSiteConfig, r registry.Repository) (domain, boundedContext, environment string) {
+	domain, boundedContext, environment = r.Domain, r.BoundedContext, r.Environment
+	if g, ok := cs.Groups[r.Name]; ok {
+		if g.Domain != "" {
+			domain = g.Domain
+		}
+		if g.Context != "" {
+			boundedContext = g.Context
+		}
+		if g.Environment != "" {
+			environment = g.Environment
 		}
 	}
-	topLang := ""
-	topCount := 0
-	for lang, count := range langCount {
-		if count > topCount {
-			topLang = lang
-			topCount = count
+	return domain, boundedContext, environment
+}
+
+// repoVisibility returns a repo's configured visibility, falling back to the
+// configured default.
+func repoVisibility(cfg config.CentralSiteConfig, name string) string {
+	if v, ok := cfg.Visibility[name]; ok && v != "" {
+		return v
+	}
+	return cfg.DefaultVisibility
+}
+
+// loadSiteTeams converts the org structure's teams, members, and service
+// ownership into site TeamInfo.
+func loadSiteTeams(ctx context.Context, orgStore *orgstructure.Store) ([]site.TeamInfo, error) {
+	teams, err := orgStore.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading teams: %w", err)
+	}
+	siteTeams := make([]site.TeamInfo, 0, len(teams))
+	for _, t := range teams {
+		members, err := orgStore.ListMembers(ctx, t.ID)
+		if err != nil {
+			return nil, err
 		}
+		ownerships, err := orgStore.ListOwnerships(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		info := site.TeamInfo{
+			Name:         t.Name,
+			DisplayName:  t.DisplayName,
+			SlackChannel: t.SlackChannel,
+			Email:        t.Email,
+		}
+		for _, m := range members {
+			info.Members = append(info.Members, site.TeamMemberInfo{UserID: m.UserID, Role: m.Role})
+		}
+		for _, o := range ownerships {
+			info.Services = append(info.Services, o.RepoID)
+		}
+		sort.Strings(info.Services)
+		siteTeams = append(siteTeams, info)
+	}
+	return siteTeams, nil
+}
+
+// detectRepoLanguage determines the primary programming language of a repo
+// from the analyses of the files under subdir, less the excluded directories.
+func detectRepoLanguage(repoPath, subdir string, exclude []string) string {
+	analyses, err := indexer.LoadAnalyses(repoPath)
+	if err != nil || len(analyses) == 0 {
+		return ""
+	}
+	if languages := techstack.Languages(indexer.ScopeAnalyses(analyses, subdir, exclude)); len(languages) > 0 {
+		return languages[0].Name
+	}
+	return ""
+}
+
+// siteOnCall converts an on-call lookup for the service page.
+func siteOnCall(l oncall.Lookup) *site.OnCallInfo {
+	info := &site.OnCallInfo{Schedule: l.Schedule, Error: l.Err}
+	for _, r := range l.Responders {
+		info.Responders = append(info.Responders, r.String())
 	}
-	return topLang
+	return info
 }
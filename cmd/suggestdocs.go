@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/docsuggest"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/readmesync"
+)
+
+var suggestDocsCmd = &cobra.Command{
+	Use:   "suggest-docs [path]",
+	Short: "Suggest doc comments for undocumented exported symbols",
+	Long: `Uses the per-function analyses in .autodoc/analyses.json to write doc comments
+for exported functions, methods, and types that have none: godoc for Go, JSDoc
+for JavaScript and TypeScript, Javadoc and KDoc for Java and Kotlin, XML docs
+for C#, rustdoc for Rust, and docstrings for Python. Symbols with an existing
+comment, and symbols the analysis has no summary for, are left alone.
+
+The suggestions are written as a patch (.autodoc/doc-comments.patch by
+default) to review and apply with git apply. --apply writes them into the
+source files instead, and --pr proposes them as a GitHub pull request, read
+from GITHUB_TOKEN or GH_TOKEN as for sync-readme. Run autodoc generate first.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSuggestDocs,
+}
+
+func init() {
+	suggestDocsCmd.Flags().String("out", "", "patch file to write (default .autodoc/doc-comments.patch)")
+	suggestDocsCmd.Flags().Bool("apply", false, "write the comments into the source files instead of a patch")
+	suggestDocsCmd.Flags().Bool("pr", false, "open a GitHub pull request with the comments")
+	suggestDocsCmd.Flags().String("branch", "autodoc/doc-comments", "branch to propose the comments from with --pr")
+	rootCmd.AddCommand(suggestDocsCmd)
+}
+
+func runSuggestDocs(cmd *cobra.Command, args []string) error {
+	out, _ := cmd.Flags().GetString("out")
+	apply, _ := cmd.Flags().GetBool("apply")
+	openPR, _ := cmd.Flags().GetBool("pr")
+	branch, _ := cmd.Flags().GetString("branch")
+	if apply && openPR {
+		return fmt.Errorf("--apply and --pr cannot be combined")
+	}
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	analyses, err := indexer.LoadAnalyses(dir)
+	if err != nil {
+		return fmt.Errorf("loading analyses: %w", err)
+	}
+	if len(analyses) == 0 {
+		return fmt.Errorf("no analyses found in %s — run `autodoc generate` first", dir)
+	}
+
+	if openPR {
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			token = os.Getenv("GH_TOKEN")
+		}
+		if token == "" {
+			return fmt.Errorf("--pr needs a GitHub token in GITHUB_TOKEN or GH_TOKEN")
+		}
+		gh := &readmesync.GitHub{Token: token, APIURL: os.Getenv("GITHUB_API_URL"), Branch: branch}
+		var count int
+		url, err := gh.ProposeChange(context.Background(), dir, readmesync.Change{
+			Title: "Add doc comments to undocumented exported symbols",
+			Body:  "Suggested doc comments for exported functions, methods, and types that had none, written from autodoc's analyses of them. Please review the wording before merging.",
+			Apply: func(worktree string) ([]string, error) {
+				suggestions, err := docsuggest.Suggest(worktree, analyses)
+				if err != nil {
+					return nil, err
+				}
+				count = len(suggestions)
+				return docsuggest.Apply(worktree, suggestions)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if url == "" {
+			fmt.Println("Every exported symbol with an analysis is documented.")
+			return nil
+		}
+		fmt.Printf("Proposed %d doc comments: %s\n", count, url)
+		return nil
+	}
+
+	suggestions, err := docsuggest.Suggest(dir, analyses)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("Every exported symbol with an analysis is documented.")
+		return nil
+	}
+
+	if apply {
+		files, err := docsuggest.Apply(dir, suggestions)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added %d doc comments to %d files:\n  %s\n", len(suggestions), len(files), strings.Join(files, "\n  "))
+		return nil
+	}
+
+	patch, err := docsuggest.Patch(dir, suggestions)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		out = filepath.Join(dir, ".autodoc", "doc-comments.patch")
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, []byte(patch), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d suggested doc comments to %s; review it, then run `git apply %s`.\n", len(suggestions), out, out)
+	return nil
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/traces"
+)
+
+var tracesCmd = &cobra.Command{
+	Use:   "traces",
+	Short: "Validate the dependency graph against production traces",
+	Long: `Imports distributed traces and compares the service-to-service calls seen at
+runtime with the links detected from code. Links seen in traces are marked as
+observed in production, links never seen are reported as candidates for
+removal, and calls with no matching link are reported as missed by static
+analysis.`,
+}
+
+var tracesImportCmd = &cobra.Command{
+	Use:   "import [trace files...]",
+	Short: "Import OTLP/Jaeger trace files, or fetch from Jaeger or Tempo",
+	Long: `Reads OTLP/JSON (as written by the OpenTelemetry Collector's file exporter) or
+Jaeger JSON trace files. Without files, recent traces for every registered
+repo are fetched from traces.jaeger_url or traces.tempo_url (or --jaeger /
+--tempo). Observed calls accumulate across imports; use --reset to start over.`,
+	RunE: runTracesImport,
+}
+
+var tracesReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Compare detected links with the calls observed in traces",
+	RunE:  runTracesReport,
+}
+
+func init() {
+	tracesImportCmd.Flags().String("jaeger", "", "Jaeger query URL (overrides traces.jaeger_url)")
+	tracesImportCmd.Flags().String("tempo", "", "Tempo URL (overrides traces.tempo_url)")
+	tracesImportCmd.Flags().Duration("lookback", time.Hour, "how far back to fetch traces from Jaeger or Tempo")
+	tracesImportCmd.Flags().Int("limit", 100, "traces to fetch per service")
+	tracesImportCmd.Flags().Bool("reset", false, "discard previously imported observations first")
+	tracesImportCmd.Flags().Bool("add-missing", false, "register calls missed by static analysis as service links")
+	tracesImportCmd.Flags().Bool("dry-run", false, "print the comparison without storing anything")
+	tracesCmd.AddCommand(tracesImportCmd, tracesReportCmd)
+	rootCmd.AddCommand(tracesCmd)
+}
+
+func runTracesImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	jaegerURL, _ := cmd.Flags().GetString("jaeger")
+	tempoURL, _ := cmd.Flags().GetString("tempo")
+	lookback, _ := cmd.Flags().GetDuration("lookback")
+	limit, _ := cmd.Flags().GetInt("limit")
+	reset, _ := cmd.Flags().GetBool("reset")
+	addMissing, _ := cmd.Flags().GetBool("add-missing")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	repoStore := registry.NewStore(database)
+	repos, err := repoStore.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing repos: %w", err)
+	}
+	repoNames := make([]string, len(repos))
+	for i, r := range repos {
+		repoNames[i] = r.Name
+	}
+
+	var spans []traces.Span
+	if len(args) > 0 {
+		for _, path := range args {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", path, err)
+			}
+			got, err := traces.Parse(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			spans = append(spans, got...)
+		}
+	} else {
+		if jaegerURL == "" && tempoURL == "" {
+			jaegerURL, tempoURL = cfg.Traces.JaegerURL, cfg.Traces.TempoURL
+		}
+		switch {
+		case jaegerURL != "":
+			spans, err = traces.FetchJaeger(ctx, jaegerURL, repoNames, lookback, limit)
+		case tempoURL != "":
+			spans, err = traces.FetchTempo(ctx, tempoURL, repoNames, lookback, limit)
+		default:
+			return fmt.Errorf("no trace files given and no trace source configured\nPass OTLP or Jaeger JSON files, --jaeger, --tempo, or set traces.jaeger_url / traces.tempo_url")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	edges := traces.Edges(spans, traces.NewResolver(repoNames, cfg.Traces.Aliases))
+	fmt.Fprintf(os.Stderr, "Read %d spans: %d cross-service calls between registered services\n", len(spans), len(edges))
+
+	links, err := repoStore.GetLinks(ctx, "")
+	if err != nil {
+		return fmt.Errorf("loading links: %w", err)
+	}
+	if dryRun {
+		printTracesReport(traces.Compare(links, edges))
+		return nil
+	}
+
+	store := traces.NewStore(database)
+	if reset {
+		if err := store.Clear(ctx); err != nil {
+			return err
+		}
+	}
+	if err := store.Record(ctx, edges); err != nil {
+		return err
+	}
+	observed, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	report := traces.Compare(links, observed)
+
+	if addMissing && len(report.Missing) > 0 {
+		for _, e := range report.Missing {
+			link := traces.MissingLink(e)
+			if err := repoStore.SaveLink(ctx, &link); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Added %d link(s) discovered from traces\n", len(report.Missing))
+		if links, err = repoStore.GetLinks(ctx, ""); err != nil {
+			return fmt.Errorf("loading links: %w", err)
+		}
+		report = traces.Compare(links, observed)
+	}
+	printTracesReport(report)
+	return nil
+}
+
+func runTracesReport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	links, err := registry.NewStore(database).GetLinks(ctx, "")
+	if err != nil {
+		return fmt.Errorf("loading links: %w", err)
+	}
+	observed, err := traces.NewStore(database).List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(observed) == 0 {
+		fmt.Println("No traces imported yet. Run `autodoc traces import` first.")
+		return nil
+	}
+	printTracesReport(traces.Compare(links, observed))
+	return nil
+}
+
+func printTracesReport(r traces.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Observed in production (%d):\n", len(r.Observed))
+	for _, c := range r.Observed {
+		errs := ""
+		if c.Observed.Errors > 0 {
+			errs = fmt.Sprintf(", %d errors", c.Observed.Errors)
+		}
+		fmt.Fprintf(w, "  %s -> %s\t%s\t%d calls%s\n", c.Link.FromRepo, c.Link.ToRepo, c.Link.LinkType, c.Observed.Calls, errs)
+	}
+	fmt.Fprintf(w, "\nNot observed, candidates for removal (%d):\n", len(r.Unobserved))
+	for _, c := range r.Unobserved {
+		fmt.Fprintf(w, "  %s -> %s\t%s\t%s\n", c.Link.FromRepo, c.Link.ToRepo, c.Link.LinkType, c.Link.Reason)
+	}
+	if len(r.Unverifiable) > 0 {
+		fmt.Fprintf(w, "\nUnverifiable, caller emits no traces (%d):\n", len(r.Unverifiable))
+		for _, c := range r.Unverifiable {
+			fmt.Fprintf(w, "  %s -> %s\t%s\n", c.Link.FromRepo, c.Link.ToRepo, c.Link.LinkType)
+		}
+	}
+	fmt.Fprintf(w, "\nMissed by static analysis (%d):\n", len(r.Missing))
+	for _, e := range r.Missing {
+		fmt.Fprintf(w, "  %s -> %s\t%s\t%d calls\t%s\n", e.From, e.To, e.Protocol, e.Calls, strings.Join(e.Endpoints, ", "))
+	}
+	w.Flush()
+}
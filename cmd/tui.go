@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ziadkadry99/auto-doc/internal/tui"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse the central server's state in an interactive terminal dashboard",
+	Long: `Opens a terminal dashboard over the central database: the registered repos
+with their index status, the change notifications still waiting to be
+delivered, and a service dependency browser where enter follows a link to the
+service at its other end and esc goes back.
+
+Keys: 1-3 or tab switch views, arrows or j/k move, r reloads, q quits. The
+dashboard also reloads on its own every --refresh interval.`,
+	RunE: runTUI,
+}
+
+func init() {
+	tuiCmd.Flags().Duration("refresh", 10*time.Second, "how often to reload the dashboard")
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	refresh, _ := cmd.Flags().GetDuration("refresh")
+	if refresh <= 0 {
+		return fmt.Errorf("--refresh must be positive")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	database, err := openCentralDB(cfg)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return tui.Run(ctx, os.Stdin, os.Stdout, func(ctx context.Context) (*tui.Snapshot, error) {
+		return tui.Load(ctx, database)
+	}, refresh)
+}
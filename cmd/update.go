@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"time"
@@ -13,8 +16,8 @@ import (
 	"github.com/ziadkadry99/auto-doc/internal/docs"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
 	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
 	"github.com/ziadkadry99/auto-doc/internal/progress"
-	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 	"github.com/ziadkadry99/auto-doc/internal/walker"
 )
 
@@ -29,10 +32,13 @@ func init() {
 	updateCmd.Flags().Bool("force", false, "skip git diff and re-process all files")
 	updateCmd.Flags().Bool("diagrams-only", false, "only regenerate architecture diagrams without re-analyzing files")
 	updateCmd.Flags().Int("concurrency", 0, "max parallel LLM calls (overrides config)")
+	updateCmd.Flags().Float64("max-cost", 0, "stop analyzing files before the run costs more than this many USD (overrides config)")
+	updateCmd.Flags().Int("max-tokens", 0, "stop analyzing files before the run uses more than this many tokens (overrides config)")
+	updateCmd.Flags().String("llm-cache", "", "LLM cache mode: readwrite, replay, or off (overrides config)")
 	rootCmd.AddCommand(updateCmd)
 }
 
-func runUpdate(cmd *cobra.Command, args []string) error {
+func runUpdate(cmd *cobra.Command, args []string) (err error) {
 	start := time.Now()
 	ctx := context.Background()
 
@@ -47,10 +53,16 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if concurrency > 0 {
 		cfg.MaxConcurrency = concurrency
 	}
+	if err := applyLLMCacheFlag(cmd, cfg); err != nil {
+		return err
+	}
 
 	force, _ := cmd.Flags().GetBool("force")
 	diagramsOnly, _ := cmd.Flags().GetBool("diagrams-only")
 
+	run := startRun(cfg, "update", projectNameFromWd())
+	defer func() { finishRun(run, err) }()
+
 	rootDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting working directory: %w", err)
@@ -70,13 +82,19 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 	// Diagrams-only mode: skip all file analysis, just regenerate high-level docs.
 	if diagramsOnly {
+		run.Summary = "diagrams only"
 		return runDiagramsOnly(ctx, cfg, rootDir)
 	}
 
 	// Load stored analyses for dependency expansion.
 	storedAnalyses, err := indexer.LoadAnalyses(rootDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not load analyses cache: %v\n", err)
+		// Don't overwrite analyses written by a newer autodoc.
+		var schemaErr *indexer.SchemaError
+		if errors.As(err, &schemaErr) {
+			return err
+		}
+		slog.Warn("could not load analyses cache", "phase", "analysis", "err", err)
 		storedAnalyses = make(map[string]indexer.FileAnalysis)
 	}
 
@@ -91,10 +109,12 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("detecting git changes: %w", err)
 		}
+		modified = withDeferred(modified, added, deleted, state.Deferred)
 
 		totalChanges := len(modified) + len(added) + len(deleted)
 		if totalChanges == 0 {
 			fmt.Println("No changes since last index.")
+			run.Summary = "no changes"
 			return nil
 		}
 
@@ -105,7 +125,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize LLM provider.
-	llmProvider, err := llm.NewProvider(string(cfg.Provider), cfg.Model)
+	llmProvider, err := createIndexingProvider(cfg)
 	if err != nil {
 		return fmt.Errorf("creating LLM provider: %w", err)
 	}
@@ -115,9 +135,10 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("creating embedder: %w", err)
 	}
+	embedCache := cacheEmbeddings(cfg, embedder)
 
 	// Initialize vector store.
-	store, err := vectordb.NewChromemStore(embedder)
+	store, err := createVectorStore(cfg, embedCache)
 	if err != nil {
 		return fmt.Errorf("creating vector store: %w", err)
 	}
@@ -133,24 +154,26 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	for _, filePath := range deleted {
 		// Remove vector store entries.
 		if err := store.DeleteByFilePath(ctx, filePath); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to delete vector entries for %s: %v\n", filePath, err)
+			slog.Warn("failed to delete vector entries", "phase", "cleanup", "file", filePath, "err", err)
 		}
 
 		// Remove markdown doc file.
 		docPath := filepath.Join(cfg.OutputDir, "docs", filePath+".md")
 		if err := os.Remove(docPath); err != nil && !os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "Warning: failed to remove doc %s: %v\n", docPath, err)
+			slog.Warn("failed to remove doc", "phase", "cleanup", "file", docPath, "err", err)
 		}
 
 		// Remove from state and analyses.
 		delete(state.FileHashes, filePath)
 		delete(storedAnalyses, filePath)
 		deletedCount++
+		run.Changed("deleted %s", filePath)
 	}
 
 	// Expand changed files via dependency graph (skip in force mode).
 	var directlyChanged, depAffected []string
 	var filesToProcess []walker.FileInfo
+	var tooLarge []string
 
 	if force {
 		// Walk entire codebase for force mode.
@@ -158,7 +181,11 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			RootDir:     rootDir,
 			Include:     cfg.Include,
 			Exclude:     cfg.Exclude,
-			MaxFileSize: 0,
+			Languages:   cfg.Languages,
+			MaxFileSize: cfg.LargeFiles.IgnoreSize(),
+			OnTooLarge: func(relPath string, size int64) {
+				tooLarge = append(tooLarge, fmt.Sprintf("%s (%s)", relPath, indexer.FormatSize(size)))
+			},
 		})
 		if err != nil {
 			return fmt.Errorf("walking codebase: %w", err)
@@ -194,7 +221,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			RootDir:     rootDir,
 			Include:     cfg.Include,
 			Exclude:     cfg.Exclude,
-			MaxFileSize: 0,
+			Languages:   cfg.Languages,
+			MaxFileSize: cfg.LargeFiles.IgnoreSize(),
+			OnTooLarge: func(relPath string, size int64) {
+				if expandedSet[relPath] {
+					tooLarge = append(tooLarge, fmt.Sprintf("%s (%s)", relPath, indexer.FormatSize(size)))
+				}
+			},
 		})
 		if err != nil {
 			return fmt.Errorf("walking codebase: %w", err)
@@ -207,10 +240,17 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Process changed files through the pipeline.
+	// Process changed files through the pipeline. Each task calls through
+	// its own metered provider, so the summary can break the cost down by task.
+	meter := llm.NewMeter()
 	updatedCount := 0
-	var totalInputTokens, totalOutputTokens int
 	var pipelineErrors []error
+	var discrepancies []indexer.Discrepancy
+	var truncated []indexer.TruncationReport
+	var redacted []indexer.RedactionReport
+	var deferred []string
+	failed := make(map[string]error)
+	budget := analysisBudget(cmd, cfg)
 
 	if len(filesToProcess) > 0 {
 		if verbose {
@@ -221,7 +261,10 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		if pipelineConcurrency < 1 {
 			pipelineConcurrency = 4
 		}
-		analyzer := indexer.NewFileAnalyzer(llmProvider, cfg.Quality, cfg.Model)
+		analyzer := indexer.NewFileAnalyzer(meter.Provider(llmProvider, config.TaskAnalysis), cfg.Quality, cfg.ModelFor(config.TaskAnalysis))
+		analyzer.SetMode(cfg.Analyzer)
+		analyzer.SetLargeFiles(cfg.LargeFiles)
+		analyzer.SetScrub(cfg.Scrub)
 
 		// Set up progress reporting.
 		reporter := progress.NewReporter()
@@ -229,13 +272,17 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		batcher := indexer.NewBatcher(pipelineConcurrency, analyzer, func(processed int, total int, currentFile string) {
 			reporter.Update(processed, currentFile)
 		})
+		batcher.SetBudget(budget)
 
 		batchResult := batcher.ProcessFiles(ctx, filesToProcess)
 		reporter.Finish()
 
 		pipelineErrors = append(pipelineErrors, batchResult.Errors...)
-		totalInputTokens = batchResult.InputTokens
-		totalOutputTokens = batchResult.OutputTokens
+		discrepancies = batchResult.Discrepancies
+		truncated = batchResult.Truncated
+		redacted = batchResult.Redacted
+		deferred = batchResult.Deferred
+		maps.Copy(failed, batchResult.Failed)
 
 		// Chunk, embed, and store each analysis.
 		for _, ar := range batchResult.Results {
@@ -244,11 +291,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			// Delete old documents for this file before adding new ones.
 			if err := store.DeleteByFilePath(ctx, ar.Analysis.FilePath); err != nil {
 				pipelineErrors = append(pipelineErrors, fmt.Errorf("delete old docs for %s: %w", ar.Analysis.FilePath, err))
+				failed[ar.Analysis.FilePath] = err
 				continue
 			}
 
 			if err := store.AddDocuments(ctx, chunks); err != nil {
 				pipelineErrors = append(pipelineErrors, fmt.Errorf("store docs for %s: %w", ar.Analysis.FilePath, err))
+				failed[ar.Analysis.FilePath] = err
 				continue
 			}
 
@@ -256,51 +305,72 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			// Merge new analysis into stored analyses.
 			storedAnalyses[ar.Analysis.FilePath] = *ar.Analysis
 			updatedCount++
+			run.Changed("updated %s", ar.Analysis.FilePath)
 		}
 	}
 
 	// Save updated analyses.
 	if err := indexer.SaveAnalyses(rootDir, storedAnalyses); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to save analyses cache: %v\n", err)
+		slog.Warn("failed to save analyses cache", "phase", "analysis", "err", err)
 	}
 
 	// Persist the vector store.
 	if err := store.Persist(ctx, vectorDir); err != nil {
 		return fmt.Errorf("persisting vector store: %w", err)
 	}
+	saveEmbeddingCache(cfg, embedCache)
 
 	// Determine which high-level docs to regenerate.
 	var regenAdvice *indexer.RegenerationAdvice
-	if !force && (updatedCount > 0 || deletedCount > 0) {
+	if !force && llmProvider != nil && (updatedCount > 0 || deletedCount > 0) {
 		if verbose {
 			fmt.Fprintf(os.Stderr, "Asking LLM which docs need regeneration...\n")
 		}
-		regenAdvice, err = indexer.DecideRegeneration(ctx, llmProvider, cfg.Model, directlyChanged, depAffected, storedAnalyses)
+		regenAdvice, err = indexer.DecideRegeneration(ctx, meter.Provider(llmProvider, config.TaskOverview), cfg.ModelFor(config.TaskOverview), directlyChanged, depAffected, storedAnalyses)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: regeneration decision failed, regenerating all: %v\n", err)
+			slog.Warn("regeneration decision failed, regenerating all", "phase", "docs", "err", err)
 			regenAdvice = nil // will fall through to regenerate everything
 		}
 	}
 
 	// Walk all files for doc regeneration.
+	var coverage docs.Coverage
 	allFiles, err := walker.Walk(walker.WalkerConfig{
 		RootDir:     rootDir,
 		Include:     cfg.Include,
 		Exclude:     cfg.Exclude,
-		MaxFileSize: 0,
+		Languages:   cfg.Languages,
+		MaxFileSize: cfg.LargeFiles.IgnoreSize(),
+		OnSkip: func(relPath string, reason walker.SkipReason) {
+			coverage.Skip(relPath, string(reason), "")
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("walking codebase for doc regen: %w", err)
 	}
 
 	docGen := docs.NewDocGenerator(cfg.OutputDir)
+	docGen.Views = mapviews.ForMap(cfg.Site.Views, config.MapComponent)
 
-	allDocs, err := getAllFileAnalyses(ctx, store, allFiles)
+	allDocs, err := getAllFileAnalyses(ctx, store, allFiles, storedAnalyses)
+	coverage.Account(allFiles, allDocs, storedAnalyses, failed, deferred)
+	docGen.Coverage = &coverage
+	if err := docGen.GenerateCoverage(); err != nil {
+		slog.Warn("failed to generate coverage report", "phase", "docs", "err", err)
+	}
 	if err == nil && len(allDocs) > 0 {
 		// Regenerate file docs for updated files.
 		if updatedCount > 0 || deletedCount > 0 {
 			if err := docGen.GenerateFileDocs(allDocs); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to generate file docs: %v\n", err)
+				slog.Warn("failed to generate file docs", "phase", "docs", "err", err)
+			}
+			// Directory summaries are cached, so only directories with
+			// changed files cost an LLM call.
+			if err := docGen.GenerateDirectoryDocs(ctx, allDocs, meter.Provider(llmProvider, config.TaskOverview), cfg.ModelFor(config.TaskOverview)); err != nil {
+				slog.Warn("failed to generate directory overviews", "phase", "docs", "err", err)
+			}
+			if err := docGen.GenerateAPIReference(allDocs); err != nil {
+				slog.Warn("failed to generate API reference", "phase", "docs", "err", err)
 			}
 		}
 
@@ -311,12 +381,17 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			regenAdvice.ProjectOverview || regenAdvice.FeaturePages || regenAdvice.ComponentMap
 		shouldRegenArch := force || regenAdvice == nil || regenAdvice.Architecture
 
-		if shouldRegenEnhanced {
+		if llmProvider == nil {
+			if err := docGen.GenerateIndex(allDocs); err != nil {
+				slog.Warn("failed to generate index", "phase", "docs", "err", err)
+			}
+			shouldRegenArch = false
+		} else if shouldRegenEnhanced {
 			fmt.Println("Regenerating project overview, features & component map...")
-			if err := docGen.GenerateEnhancedIndex(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: enhanced index regeneration failed: %v\n", err)
+			if err := docGen.GenerateEnhancedIndex(ctx, allDocs, meter.Provider(llmProvider, config.TaskOverview), cfg.ModelFor(config.TaskOverview)); err != nil {
+				slog.Warn("enhanced index regeneration failed", "phase", "docs", "err", err)
 				if err := docGen.GenerateIndex(allDocs); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to generate index: %v\n", err)
+					slog.Warn("failed to generate index", "phase", "docs", "err", err)
 				}
 			}
 		} else {
@@ -326,22 +401,25 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		// Architecture overview for Normal and Max tiers.
 		if cfg.Quality != config.QualityLite && shouldRegenArch {
 			fmt.Println("Regenerating architecture overview...")
-			if err := docGen.GenerateArchitecture(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: architecture regeneration failed: %v\n", err)
+			if err := docGen.GenerateArchitecture(ctx, allDocs, meter.Provider(llmProvider, config.TaskArchitecture), cfg.ModelFor(config.TaskArchitecture)); err != nil {
+				slog.Warn("architecture regeneration failed", "phase", "architecture", "err", err)
 			}
-		} else if cfg.Quality != config.QualityLite {
+		} else if cfg.Quality != config.QualityLite && llmProvider != nil {
 			fmt.Println("Skipping architecture overview (no change needed)")
 		}
 	}
 
 	// Update and save state.
 	state.LastCommitSHA = indexer.GetGitCommitSHA(rootDir)
+	state.Deferred = deferred
 	if err := state.SaveState(rootDir); err != nil {
 		return fmt.Errorf("saving state: %w", err)
 	}
 
 	// Calculate unchanged count.
 	unchangedCount := len(allFiles) - updatedCount - deletedCount
+	reportLLMCache(cfg, llmProvider)
+	run.Summary = fmt.Sprintf("%d files updated, %d deleted, %d unchanged", updatedCount, deletedCount, unchangedCount)
 
 	// Print summary.
 	duration := time.Since(start)
@@ -353,14 +431,9 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("  Files deleted:     %d\n", deletedCount)
 	fmt.Printf("  Files unchanged:   %d\n", unchangedCount)
+	fmt.Printf("  Coverage:          %d%% (%d of %d files)\n", coverage.Percent(), coverage.Documented, coverage.Considered())
 
-	if totalInputTokens > 0 || totalOutputTokens > 0 {
-		fmt.Printf("  Tokens used:       %d input, %d output\n", totalInputTokens, totalOutputTokens)
-		cost := llm.EstimateCost(cfg.Model, totalInputTokens, totalOutputTokens)
-		if cost > 0 {
-			fmt.Printf("  Estimated cost:    $%.4f\n", cost)
-		}
-	}
+	printTaskUsage(meter, 18)
 
 	fmt.Printf("  Duration:          %s\n", duration.Round(time.Millisecond))
 	fmt.Printf("  Output:            %s\n", cfg.OutputDir)
@@ -375,10 +448,32 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "  - %v\n", e)
 		}
 	}
+	printDiscrepancies(discrepancies)
+	printLargeFiles(truncated, tooLarge, cfg.LargeFiles.IgnoreSize())
+	printRedactions(redacted)
+	printDeferred(deferred, budget)
 
 	return nil
 }
 
+// withDeferred adds the files an earlier run's budget deferred to the
+// modified files, unless git already lists them.
+func withDeferred(modified, added, deleted, deferred []string) []string {
+	listed := make(map[string]bool)
+	for _, list := range [][]string{modified, added, deleted} {
+		for _, f := range list {
+			listed[f] = true
+		}
+	}
+	for _, f := range deferred {
+		if !listed[f] {
+			modified = append(modified, f)
+			listed[f] = true
+		}
+	}
+	return modified
+}
+
 // runDiagramsOnly regenerates only the architecture diagrams using cached
 // file analyses, without re-analyzing any files or updating the vector store.
 func runDiagramsOnly(ctx context.Context, cfg *config.Config, rootDir string) error {
@@ -402,24 +497,25 @@ func runDiagramsOnly(ctx context.Context, cfg *config.Config, rootDir string) er
 	}
 
 	// Initialize LLM provider.
-	llmProvider, err := llm.NewProvider(string(cfg.Provider), cfg.Model)
+	llmProvider, err := createLLMProviderFromConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("creating LLM provider: %w", err)
 	}
 
 	docGen := docs.NewDocGenerator(cfg.OutputDir)
+	docGen.Views = mapviews.ForMap(cfg.Site.Views, config.MapComponent)
 
 	// Regenerate enhanced index (includes architecture diagram).
 	fmt.Println("Regenerating project overview, features & component map...")
-	if err := docGen.GenerateEnhancedIndex(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: enhanced index regeneration failed: %v\n", err)
+	if err := docGen.GenerateEnhancedIndex(ctx, allDocs, llmProvider, cfg.ModelFor(config.TaskOverview)); err != nil {
+		slog.Warn("enhanced index regeneration failed", "phase", "docs", "err", err)
 	}
 
 	// Regenerate architecture overview.
 	if cfg.Quality != config.QualityLite {
 		fmt.Println("Regenerating architecture overview...")
-		if err := docGen.GenerateArchitecture(ctx, allDocs, llmProvider, cfg.Model); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: architecture regeneration failed: %v\n", err)
+		if err := docGen.GenerateArchitecture(ctx, allDocs, llmProvider, cfg.ModelFor(config.TaskArchitecture)); err != nil {
+			slog.Warn("architecture regeneration failed", "phase", "architecture", "err", err)
 		}
 	}
 
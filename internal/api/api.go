@@ -0,0 +1,515 @@
+// Package api serves the architecture model over a versioned, read-only
+// HTTP API under /api/v1, so portals and scripts can query repos, links,
+// flows, ownership, facts, notifications, and search without importing Go
+// packages, plus a GraphQL endpoint for graph-shaped questions that would
+// take several REST calls. Every endpoint except the OpenAPI spec and the
+// GraphQL schema requires an API key (see package apikeys) granting the
+// endpoint's scope.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ziadkadry99/auto-doc/internal/apikeys"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// Prefix is the path every API route is mounted under.
+const Prefix = "/api/v1"
+
+// maxSearchLimit caps the results one search returns.
+const maxSearchLimit = 50
+
+// Deps are the stores the API reads. Keys is required; a route whose store
+// is nil answers 503.
+type Deps struct {
+	Keys          *apikeys.Store
+	Repos         *registry.Store
+	Flows         *flows.Store
+	Org           *orgstructure.Store
+	Facts         *contextengine.Store
+	Notifications *notifications.Store
+	Search        vectordb.VectorStore
+}
+
+// RegisterRoutes mounts the API on r.
+func RegisterRoutes(r chi.Router, deps Deps) {
+	limiter := apikeys.NewLimiter()
+	require := func(scope apikeys.Scope, endpoint string) func(http.Handler) http.Handler {
+		return apikeys.Require(deps.Keys, limiter, scope, "v1/"+endpoint)
+	}
+
+	r.Route(Prefix, func(r chi.Router) {
+		r.Get("/openapi.yaml", handleSpecYAML)
+		r.Get("/openapi.json", handleSpecJSON)
+
+		r.With(require(apikeys.ScopeRepos, "repos")).Get("/repos", handleListRepos(deps.Repos))
+		r.With(require(apikeys.ScopeRepos, "repos")).Get("/repos/{name}", handleGetRepo(deps.Repos))
+		r.With(require(apikeys.ScopeRepos, "links")).Get("/links", handleListLinks(deps.Repos))
+		r.With(require(apikeys.ScopeFlows, "flows")).Get("/flows", handleListFlows(deps.Flows))
+		r.With(require(apikeys.ScopeFlows, "flows")).Get("/flows/{id}", handleGetFlow(deps.Flows))
+		r.With(require(apikeys.ScopeOwnership, "teams")).Get("/teams", handleListTeams(deps.Org))
+		r.With(require(apikeys.ScopeOwnership, "ownership")).Get("/ownership", handleListOwnership(deps.Org))
+		r.With(require(apikeys.ScopeFacts, "facts")).Get("/facts", handleListFacts(deps.Facts))
+		r.With(require(apikeys.ScopeNotifications, "notifications")).Get("/notifications", handleListNotifications(deps.Notifications))
+		r.With(require(apikeys.ScopeSearch, "search")).Get("/search", handleSearch(deps.Search))
+
+		// GraphQL checks scopes field by field, so any valid key gets in.
+		r.Get("/graphql/schema", handleGraphQLSchema)
+		r.With(apikeys.Authenticate(deps.Keys, limiter, "v1/graphql")).Get("/graphql", handleGraphQL(deps))
+		r.With(apikeys.Authenticate(deps.Keys, limiter, "v1/graphql")).Post("/graphql", handleGraphQL(deps))
+	})
+}
+
+// Repo is the API's view of a registered repository. It leaves out the
+// server's local checkout path.
+type Repo struct {
+	Name          string    `json:"name"`
+	DisplayName   string    `json:"display_name,omitempty"`
+	SourceType    string    `json:"source_type"`
+	SourceURL     string    `json:"source_url,omitempty"`
+	Status        string    `json:"status"`
+	Summary       string    `json:"summary,omitempty"`
+	FileCount     int       `json:"file_count"`
+	LastCommitSHA string    `json:"last_commit_sha,omitempty"`
+	LastIndexedAt string    `json:"last_indexed_at,omitempty"`
+	Parent        string    `json:"parent,omitempty"`
+	Subdir        string    `json:"subdir,omitempty"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newRepo(r registry.Repository, tags []string) Repo {
+	if tags == nil {
+		tags = []string{}
+	}
+	return Repo{
+		Name:          r.Name,
+		DisplayName:   r.DisplayName,
+		SourceType:    r.SourceType,
+		SourceURL:     r.SourceURL,
+		Status:        r.Status,
+		Summary:       r.Summary,
+		FileCount:     r.FileCount,
+		LastCommitSHA: r.LastCommitSHA,
+		LastIndexedAt: r.LastIndexedAt,
+		Parent:        r.Parent,
+		Subdir:        r.Subdir,
+		Tags:          tags,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// Owner is a team owning a service, with the team's contacts.
+type Owner struct {
+	Service      string `json:"service"`
+	TeamID       string `json:"team_id"`
+	Team         string `json:"team"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Confidence   string `json:"confidence"`
+	Source       string `json:"source"`
+}
+
+// SearchResult is one document matching a search.
+type SearchResult struct {
+	FilePath   string  `json:"file_path"`
+	RepoID     string  `json:"repo_id,omitempty"`
+	Symbol     string  `json:"symbol,omitempty"`
+	Type       string  `json:"type"`
+	Language   string  `json:"language,omitempty"`
+	Similarity float64 `json:"similarity"`
+	Content    string  `json:"content"`
+	LineStart  int     `json:"line_start,omitempty"`
+	LineEnd    int     `json:"line_end,omitempty"`
+}
+
+func handleListRepos(store *registry.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "repository registry")
+			return
+		}
+		repos, err := store.List(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tags, err := store.AllTags(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tag := r.URL.Query().Get("tag")
+		out := make([]Repo, 0, len(repos))
+		for _, repo := range repos {
+			if tag != "" && !contains(tags[repo.Name], tag) {
+				continue
+			}
+			out = append(out, newRepo(repo, tags[repo.Name]))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+func handleGetRepo(store *registry.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "repository registry")
+			return
+		}
+		repo, err := store.Get(r.Context(), chi.URLParam(r, "name"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if repo == nil {
+			writeError(w, http.StatusNotFound, "repository not found")
+			return
+		}
+		tags, err := store.GetTags(r.Context(), repo.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, newRepo(*repo, tags))
+	}
+}
+
+// handleListLinks lists the links between services. With ?service= it
+// lists only that service's links, both ways; ?direction=outgoing or
+// incoming narrows them to what it calls or what calls it.
+func handleListLinks(store *registry.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "repository registry")
+			return
+		}
+		q := r.URL.Query()
+		service, direction := q.Get("service"), q.Get("direction")
+		switch direction {
+		case "", "outgoing", "incoming":
+		default:
+			writeError(w, http.StatusBadRequest, "direction must be outgoing or incoming")
+			return
+		}
+		links, err := store.GetLinks(r.Context(), service)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		out := make([]registry.ServiceLink, 0, len(links))
+		for _, l := range links {
+			if service != "" && ((direction == "outgoing" && l.FromRepo != service) || (direction == "incoming" && l.ToRepo != service)) {
+				continue
+			}
+			if l.Endpoints == nil {
+				l.Endpoints = []string{}
+			}
+			out = append(out, l)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// handleListFlows lists flows, matching ?q= against their names and
+// descriptions and ?service= against the services they involve.
+func handleListFlows(store *flows.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "flow store")
+			return
+		}
+		q := r.URL.Query()
+		var (
+			all []flows.Flow
+			err error
+		)
+		if query := q.Get("q"); query != "" {
+			all, err = store.SearchFlows(r.Context(), query)
+		} else {
+			all, err = store.ListFlows(r.Context())
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		service := q.Get("service")
+		out := make([]flows.Flow, 0, len(all))
+		for _, f := range all {
+			if service != "" && !containsFold(f.Services, service) {
+				continue
+			}
+			out = append(out, f)
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+func handleGetFlow(store *flows.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "flow store")
+			return
+		}
+		flow, err := store.GetFlow(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, "flow not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, flow)
+	}
+}
+
+func handleListTeams(store *orgstructure.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "org structure")
+			return
+		}
+		teams, err := store.ListTeams(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for i := range teams {
+			members, err := store.ListMembers(r.Context(), teams[i].ID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			teams[i].Members = members
+		}
+		if teams == nil {
+			teams = []orgstructure.Team{}
+		}
+		writeJSON(w, http.StatusOK, teams)
+	}
+}
+
+// handleListOwnership lists which team owns each service, or only
+// ?service=, sorted by service.
+func handleListOwnership(store *orgstructure.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "org structure")
+			return
+		}
+		teams, err := store.ListTeams(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		service := r.URL.Query().Get("service")
+		out := []Owner{}
+		for _, t := range teams {
+			ownerships, err := store.ListOwnerships(r.Context(), t.ID)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			name := t.Name
+			if t.DisplayName != "" {
+				name = t.DisplayName
+			}
+			for _, o := range ownerships {
+				if service != "" && !strings.EqualFold(o.RepoID, service) {
+					continue
+				}
+				out = append(out, Owner{
+					Service:      o.RepoID,
+					TeamID:       t.ID,
+					Team:         name,
+					SlackChannel: t.SlackChannel,
+					Email:        t.Email,
+					Confidence:   o.Confidence,
+					Source:       o.Source,
+				})
+			}
+		}
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// handleListFacts lists the current architecture facts, filtered by ?repo=,
+// ?scope=, and ?scope_id=, or searched with ?q=.
+func handleListFacts(store *contextengine.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "context store")
+			return
+		}
+		q := r.URL.Query()
+		var (
+			facts []contextengine.Fact
+			err   error
+		)
+		if query := q.Get("q"); query != "" {
+			limit, ok := parseLimit(w, q.Get("limit"), 50, 500)
+			if !ok {
+				return
+			}
+			facts, err = store.SearchFacts(r.Context(), query, limit)
+		} else {
+			facts, err = store.GetCurrentFacts(r.Context(), q.Get("repo"), q.Get("scope"), q.Get("scope_id"))
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if facts == nil {
+			facts = []contextengine.Fact{}
+		}
+		writeJSON(w, http.StatusOK, facts)
+	}
+}
+
+// handleListNotifications lists architecture change notifications, newest
+// first, filtered by ?type=, ?severity=, and ?since= (RFC 3339).
+func handleListNotifications(store *notifications.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "notification store")
+			return
+		}
+		q := r.URL.Query()
+		filter := notifications.ListFilter{
+			Type:     notifications.NotificationType(q.Get("type")),
+			Severity: notifications.Severity(q.Get("severity")),
+		}
+		if v := q.Get("since"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "since must be an RFC 3339 time")
+				return
+			}
+			filter.Since = t
+		}
+		limit, ok := parseLimit(w, q.Get("limit"), 100, 1000)
+		if !ok {
+			return
+		}
+		filter.Limit = limit
+		if v := q.Get("offset"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+				return
+			}
+			filter.Offset = n
+		}
+		list, err := store.List(r.Context(), filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if list == nil {
+			list = []notifications.Notification{}
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// handleSearch searches the indexed docs for ?q=, optionally within ?repo=.
+func handleSearch(store vectordb.VectorStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeUnavailable(w, "vector store")
+			return
+		}
+		q := r.URL.Query()
+		query := strings.TrimSpace(q.Get("q"))
+		if query == "" {
+			writeError(w, http.StatusBadRequest, "q is required")
+			return
+		}
+		limit, ok := parseLimit(w, q.Get("limit"), 10, maxSearchLimit)
+		if !ok {
+			return
+		}
+		var filter *vectordb.SearchFilter
+		if repo := q.Get("repo"); repo != "" {
+			filter = &vectordb.SearchFilter{RepoID: &repo}
+		}
+		results, err := store.Search(r.Context(), query, limit, filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		out := make([]SearchResult, len(results))
+		for i, res := range results {
+			meta := res.Document.Metadata
+			out[i] = SearchResult{
+				FilePath:   meta.FilePath,
+				RepoID:     meta.RepoID,
+				Symbol:     meta.Symbol,
+				Type:       string(meta.Type),
+				Language:   meta.Language,
+				Similarity: float64(res.Similarity),
+				Content:    res.Document.Content,
+				LineStart:  meta.LineStart,
+				LineEnd:    meta.LineEnd,
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// parseLimit reads a ?limit= value, defaulting to def and capped at max.
+// It writes a 400 and returns false when the value is not a positive number.
+func parseLimit(w http.ResponseWriter, v string, def, max int) (int, bool) {
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return 0, false
+	}
+	return min(n, max), true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeUnavailable(w http.ResponseWriter, what string) {
+	writeError(w, http.StatusServiceUnavailable, what+" not configured")
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ziadkadry99/auto-doc/internal/apikeys"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// fakeSearchStore returns its documents for any query, honouring only the
+// repo filter.
+type fakeSearchStore struct {
+	vectordb.VectorStore
+	docs []vectordb.Document
+}
+
+func (f *fakeSearchStore) Search(_ context.Context, _ string, limit int, filter *vectordb.SearchFilter) ([]vectordb.SearchResult, error) {
+	var results []vectordb.SearchResult
+	for _, d := range f.docs {
+		if filter != nil && filter.RepoID != nil && d.Metadata.RepoID != *filter.RepoID {
+			continue
+		}
+		results = append(results, vectordb.SearchResult{Document: d, Similarity: 0.8})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func setup(t *testing.T) (Deps, chi.Router) {
+	t.Helper()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	deps := Deps{
+		Keys:          apikeys.NewStore(database),
+		Repos:         registry.NewStore(database),
+		Flows:         flows.NewStore(database),
+		Org:           orgstructure.NewStore(database),
+		Facts:         contextengine.NewStore(database),
+		Notifications: notifications.NewStore(database),
+		Search: &fakeSearchStore{docs: []vectordb.Document{
+			{ID: "1", Content: "Charges cards.", Metadata: vectordb.DocumentMetadata{FilePath: "charge.go", RepoID: "payments", Type: vectordb.DocTypeFile}},
+			{ID: "2", Content: "Takes orders.", Metadata: vectordb.DocumentMetadata{FilePath: "main.go", RepoID: "orders", Type: vectordb.DocTypeFile}},
+		}},
+	}
+	r := chi.NewRouter()
+	RegisterRoutes(r, deps)
+	return deps, r
+}
+
+func get(t *testing.T, r chi.Router, path, key string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if v != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: decoding %s: %v", path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+func TestArchitectureAPI(t *testing.T) {
+	deps, r := setup(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"orders", "payments", "gateway"} {
+		if err := deps.Repos.Add(ctx, &registry.Repository{Name: name, SourceType: "local", LocalPath: "/srv/" + name, Status: "ready"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deps.Repos.SetTags(ctx, "payments", []string{"pci"})
+	deps.Repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /orders"}})
+	deps.Repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"})
+	deps.Flows.CreateFlow(ctx, &flows.Flow{Name: "checkout", Services: []string{"gateway", "orders", "payments"}})
+	deps.Flows.CreateFlow(ctx, &flows.Flow{Name: "signup", Services: []string{"gateway", "users"}})
+	team := &orgstructure.Team{Name: "commerce", SlackChannel: "#commerce"}
+	deps.Org.CreateTeam(ctx, team)
+	deps.Org.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team.ID, RepoID: "orders", Confidence: "high", Source: "codeowners"})
+	deps.Facts.SaveFact(ctx, contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "purpose", Value: "Takes orders", Source: "user"})
+	deps.Notifications.Create(ctx, notifications.Notification{Type: notifications.TypeServiceAdded, Severity: notifications.SeverityInfo, Title: "payments added"})
+
+	_, key, err := deps.Keys.Create(ctx, "portal", apikeys.Scopes, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var repos []Repo
+	if code := get(t, r, "/api/v1/repos?tag=pci", key, &repos); code != http.StatusOK || len(repos) != 1 || repos[0].Name != "payments" || repos[0].Tags[0] != "pci" {
+		t.Errorf("repos by tag: %d %+v", code, repos)
+	}
+	var raw map[string]any
+	get(t, r, "/api/v1/repos/orders", key, &raw)
+	if raw["name"] != "orders" || raw["local_path"] != nil {
+		t.Errorf("repo = %v; want orders without its local path", raw)
+	}
+	if code := get(t, r, "/api/v1/repos/billing", key, nil); code != http.StatusNotFound {
+		t.Errorf("unknown repo: status %d, want 404", code)
+	}
+
+	var links []registry.ServiceLink
+	get(t, r, "/api/v1/links?service=orders&direction=incoming", key, &links)
+	if len(links) != 1 || links[0].FromRepo != "gateway" {
+		t.Errorf("incoming links = %+v", links)
+	}
+	if code := get(t, r, "/api/v1/links?service=orders&direction=sideways", key, nil); code != http.StatusBadRequest {
+		t.Errorf("bad direction: status %d, want 400", code)
+	}
+
+	var fl []flows.Flow
+	get(t, r, "/api/v1/flows?service=payments", key, &fl)
+	if len(fl) != 1 || fl[0].Name != "checkout" {
+		t.Errorf("flows = %+v", fl)
+	}
+
+	var owners []Owner
+	get(t, r, "/api/v1/ownership?service=orders", key, &owners)
+	if len(owners) != 1 || owners[0].Team != "commerce" || owners[0].SlackChannel != "#commerce" {
+		t.Errorf("owners = %+v", owners)
+	}
+
+	var facts []contextengine.Fact
+	get(t, r, "/api/v1/facts?scope=service&scope_id=orders", key, &facts)
+	if len(facts) != 1 || facts[0].Value != "Takes orders" {
+		t.Errorf("facts = %+v", facts)
+	}
+
+	var notes []notifications.Notification
+	get(t, r, "/api/v1/notifications?type=service_added", key, &notes)
+	if len(notes) != 1 {
+		t.Errorf("notifications = %+v", notes)
+	}
+	if code := get(t, r, "/api/v1/notifications?since=yesterday", key, nil); code != http.StatusBadRequest {
+		t.Errorf("bad since: status %d, want 400", code)
+	}
+
+	var results []SearchResult
+	get(t, r, "/api/v1/search?q=cards&repo=payments", key, &results)
+	if len(results) != 1 || results[0].FilePath != "charge.go" {
+		t.Errorf("search = %+v", results)
+	}
+	if code := get(t, r, "/api/v1/search", key, nil); code != http.StatusBadRequest {
+		t.Errorf("search without q: status %d, want 400", code)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	deps, r := setup(t)
+	ctx := context.Background()
+
+	_, flowsOnly, err := deps.Keys.Create(ctx, "flows-only", []apikeys.Scope{apikeys.ScopeFlows}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := get(t, r, "/api/v1/flows", flowsOnly, nil); code != http.StatusOK {
+		t.Errorf("flows with flows scope: status %d", code)
+	}
+	if code := get(t, r, "/api/v1/repos", flowsOnly, nil); code != http.StatusForbidden {
+		t.Errorf("repos with flows scope: status %d, want 403", code)
+	}
+	if code := get(t, r, "/api/v1/flows", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("no key: status %d, want 401", code)
+	}
+	if code := get(t, r, "/api/v1/openapi.json", "", nil); code != http.StatusOK {
+		t.Errorf("spec without key: status %d, want 200", code)
+	}
+}
+
+// TestSpecCoversRoutes keeps openapi.yaml in step with the mounted routes.
+func TestSpecCoversRoutes(t *testing.T) {
+	_, r := setup(t)
+
+	var spec struct {
+		Paths map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(specYAML, &spec); err != nil {
+		t.Fatalf("parsing openapi.yaml: %v", err)
+	}
+	var documented []string
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+Prefix+path)
+		}
+	}
+
+	var mounted []string
+	chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		mounted = append(mounted, method+" "+strings.TrimSuffix(route, "/"))
+		return nil
+	})
+
+	sort.Strings(documented)
+	sort.Strings(mounted)
+	if strings.Join(documented, "\n") != strings.Join(mounted, "\n") {
+		t.Errorf("spec paths:\n%s\nmounted routes:\n%s", strings.Join(documented, "\n"), strings.Join(mounted, "\n"))
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	deps, r := setup(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"checkout", "cart", "payments", "ledger"} {
+		deps.Repos.Add(ctx, &registry.Repository{Name: name, SourceType: "local", Status: "ready"})
+	}
+	deps.Repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "checkout", ToRepo: "payments", LinkType: "http"})
+	deps.Repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "checkout", ToRepo: "cart", LinkType: "http"})
+	deps.Repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "cart", ToRepo: "ledger", LinkType: "kafka"})
+	commerce := &orgstructure.Team{Name: "commerce"}
+	money := &orgstructure.Team{Name: "money"}
+	deps.Org.CreateTeam(ctx, commerce)
+	deps.Org.CreateTeam(ctx, money)
+	for repo, team := range map[string]string{"checkout": commerce.ID, "cart": commerce.ID, "payments": money.ID, "ledger": money.ID} {
+		deps.Org.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team, RepoID: repo, Source: "codeowners"})
+	}
+
+	_, key, _ := deps.Keys.Create(ctx, "portal", apikeys.Scopes, 1000)
+	_, reposOnly, _ := deps.Keys.Create(ctx, "repos-only", []apikeys.Scope{apikeys.ScopeRepos}, 0)
+
+	post := func(key, query string) map[string]any {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(string(body)))
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("POST /graphql: %d %s", w.Code, w.Body)
+		}
+		return resp
+	}
+
+	// Services commerce owns that call services money owns.
+	resp := post(key, `{ services(team: "commerce") { name calls(toTeam: "money") { type to { name owners { name } } } } }`)
+	got, _ := json.Marshal(resp["data"])
+	var want any
+	json.Unmarshal([]byte(`{"services":[{"name":"cart","calls":[{"type":"kafka","to":{"name":"ledger","owners":[{"name":"money"}]}}]},`+
+		`{"name":"checkout","calls":[{"type":"http","to":{"name":"payments","owners":[{"name":"money"}]}}]}]}`), &want)
+	if !reflect.DeepEqual(resp["data"], want) || resp["errors"] != nil {
+		t.Errorf("data = %s, errors = %v\nwant %s", got, resp["errors"], want)
+	}
+
+	// A key without the ownership scope sees services but not owners.
+	resp = post(reposOnly, `{ service(name: "cart") { name consumers { name } } teams { name } }`)
+	got, _ = json.Marshal(resp["data"])
+	if string(got) != `{"service":{"consumers":[{"name":"checkout"}],"name":"cart"},"teams":null}` {
+		t.Errorf("data = %s", got)
+	}
+	if errs, _ := resp["errors"].([]any); len(errs) != 1 || !strings.Contains(errs[0].(map[string]any)["message"].(string), "ownership") {
+		t.Errorf("errors = %v", resp["errors"])
+	}
+
+	// GET, with variables.
+	var byGet map[string]any
+	path := "/api/v1/graphql?" + url.Values{
+		"query":     {`query($name: String!) { service(name: $name) { dependencies { name } } }`},
+		"variables": {`{"name": "checkout"}`},
+	}.Encode()
+	if code := get(t, r, path, key, &byGet); code != http.StatusOK {
+		t.Fatalf("GET /graphql: status %d", code)
+	}
+	if got, _ := json.Marshal(byGet["data"]); string(got) != `{"service":{"dependencies":[{"name":"cart"},{"name":"payments"}]}}` {
+		t.Errorf("GET data = %s, errors = %v", got, byGet["errors"])
+	}
+
+	// Too deep a query is refused outright.
+	resp = post(key, `{ services { `+strings.Repeat("dependencies { ", 12)+"name"+strings.Repeat(" }", 13)+` }`)
+	if resp["data"] != nil || resp["errors"] == nil {
+		t.Errorf("over the depth limit: data = %v, errors = %v", resp["data"], resp["errors"])
+	}
+
+	if code := get(t, r, "/api/v1/graphql?query=%7Bteams%7Bname%7D%7D", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("no key: status %d, want 401", code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/graphql/schema", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "type Service {") || !strings.Contains(w.Body.String(), "    toTeam: String\n  ): [Edge!]\n") {
+		t.Errorf("schema:\n%s", w.Body)
+	}
+}
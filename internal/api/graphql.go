@@ -0,0 +1,787 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/ziadkadry99/auto-doc/internal/apikeys"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// Limits on one GraphQL query.
+const (
+	// maxDepth is how deeply selection sets may nest, so a query can't walk
+	// the cyclic service graph indefinitely.
+	maxDepth = 12
+	// maxQueryBytes bounds the query text, and with it how many fields
+	// aliases and fragments can make it select.
+	maxQueryBytes = 10_000
+	// maxValues is how many list items one response may hold, since each
+	// level of nested lists multiplies them: a query within the depth limit
+	// can still fan out across the whole graph.
+	maxValues = 50_000
+	// maxGraphQLRequestBytes bounds a POSTed request.
+	maxGraphQLRequestBytes = 1 << 20
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// schema is the GraphQL view of the architecture model. Its resolvers read
+// the stores through the per-request graph in the context, and check the
+// scopes of the request's API key field by field, so a key sees the same
+// data through GraphQL as through the REST routes.
+var schema = graphql.MustParseSchema(schemaSDL, &query{},
+	graphql.UseStringDescriptions(),
+	graphql.MaxDepth(maxDepth),
+	graphql.MaxQueryLength(maxQueryBytes),
+)
+
+// handleGraphQL serves queries against schema. It accepts a POSTed JSON
+// request ({"query", "operationName", "variables"}) or a GET with query,
+// operationName, and JSON-encoded variables parameters.
+func handleGraphQL(deps Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLRequestBytes)).Decode(&req); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+		} else {
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if v := q.Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					writeGraphQLError(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+					return
+				}
+			}
+		}
+		if req.Query == "" {
+			writeGraphQLError(w, http.StatusBadRequest, "query is required")
+			return
+		}
+
+		g := &graph{deps: deps}
+		resp := schema.Exec(context.WithValue(r.Context(), graphKey{}, g), req.Query, req.OperationName, req.Variables)
+		if g.values.Load() > maxValues {
+			// A response cut off at the limit is dropped rather than
+			// returned with arbitrary holes in it.
+			resp = &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%v", errTooManyValues)}}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: msg}}})
+}
+
+// handleGraphQLSchema serves the schema in SDL.
+func handleGraphQLSchema(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(schemaSDL))
+}
+
+var errTooManyValues = fmt.Errorf("the response would hold more than %d list items; narrow the query", maxValues)
+
+type graphKey struct{}
+
+// graph loads the parts of the model one query touches, once each, so
+// resolving a field for every service doesn't go back to the database.
+// Resolvers run concurrently, so each part is loaded under a sync.Once.
+type graph struct {
+	deps   Deps
+	values atomic.Int64 // list items resolved so far
+
+	reposOnce sync.Once
+	reposErr  error
+	repos     map[string]*registry.Repository
+	names     []string
+	tags      map[string][]string
+
+	linksOnce sync.Once
+	linksErr  error
+	links     []registry.ServiceLink
+
+	orgOnce sync.Once
+	orgErr  error
+	teams   []orgstructure.Team
+	owned   map[string][]string // team ID to services
+	owners  map[string][]int    // service to indexes into teams
+
+	flowsOnce sync.Once
+	flowsErr  error
+	flows     []flows.Flow
+}
+
+func graphFrom(ctx context.Context) *graph {
+	return ctx.Value(graphKey{}).(*graph)
+}
+
+// spend counts n list items against the response's limit.
+func (g *graph) spend(n int) error {
+	if g.values.Add(int64(n)) > maxValues {
+		return errTooManyValues
+	}
+	return nil
+}
+
+func (g *graph) loadRepos(ctx context.Context) error {
+	g.reposOnce.Do(func() {
+		if g.deps.Repos == nil {
+			g.reposErr = fmt.Errorf("repository registry is not available")
+			return
+		}
+		repos, err := g.deps.Repos.List(ctx)
+		if err != nil {
+			g.reposErr = err
+			return
+		}
+		tags, err := g.deps.Repos.AllTags(ctx)
+		if err != nil {
+			g.reposErr = err
+			return
+		}
+		g.repos = make(map[string]*registry.Repository, len(repos))
+		for i := range repos {
+			g.repos[repos[i].Name] = &repos[i]
+			g.names = append(g.names, repos[i].Name)
+		}
+		g.tags = tags
+	})
+	return g.reposErr
+}
+
+func (g *graph) loadLinks(ctx context.Context) error {
+	g.linksOnce.Do(func() {
+		if g.deps.Repos == nil {
+			g.linksErr = fmt.Errorf("repository registry is not available")
+			return
+		}
+		g.links, g.linksErr = g.deps.Repos.GetLinks(ctx, "")
+	})
+	return g.linksErr
+}
+
+func (g *graph) loadOrg(ctx context.Context) error {
+	g.orgOnce.Do(func() {
+		if g.deps.Org == nil {
+			g.orgErr = fmt.Errorf("org structure is not available")
+			return
+		}
+		teams, err := g.deps.Org.ListTeams(ctx)
+		if err != nil {
+			g.orgErr = err
+			return
+		}
+		g.owned = make(map[string][]string)
+		g.owners = make(map[string][]int)
+		for i := range teams {
+			members, err := g.deps.Org.ListMembers(ctx, teams[i].ID)
+			if err != nil {
+				g.orgErr = err
+				return
+			}
+			teams[i].Members = members
+			ownerships, err := g.deps.Org.ListOwnerships(ctx, teams[i].ID)
+			if err != nil {
+				g.orgErr = err
+				return
+			}
+			for _, o := range ownerships {
+				g.owned[teams[i].ID] = append(g.owned[teams[i].ID], o.RepoID)
+				g.owners[o.RepoID] = append(g.owners[o.RepoID], i)
+			}
+		}
+		g.teams = teams
+	})
+	return g.orgErr
+}
+
+func (g *graph) loadFlows(ctx context.Context) error {
+	g.flowsOnce.Do(func() {
+		if g.deps.Flows == nil {
+			g.flowsErr = fmt.Errorf("flow store is not available")
+			return
+		}
+		g.flows, g.flowsErr = g.deps.Flows.ListFlows(ctx)
+	})
+	return g.flowsErr
+}
+
+// service returns the node for name, registered or not.
+func (g *graph) service(ctx context.Context, name string) (*service, error) {
+	if err := g.loadRepos(ctx); err != nil {
+		return nil, err
+	}
+	return &service{name: name, repo: g.repos[name], tags: g.tags[name]}, nil
+}
+
+func (g *graph) services(ctx context.Context, names []string) (*[]*service, error) {
+	out := make([]*service, 0, len(names))
+	for _, name := range names {
+		s, err := g.service(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return list(g, out)
+}
+
+// findTeam returns the team whose ID, name, or display name is name.
+func (g *graph) findTeam(ctx context.Context, name string) (*orgstructure.Team, error) {
+	if err := g.loadOrg(ctx); err != nil {
+		return nil, err
+	}
+	for i, t := range g.teams {
+		if strings.EqualFold(t.ID, name) || strings.EqualFold(t.Name, name) || strings.EqualFold(t.DisplayName, name) {
+			return &g.teams[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ownedBy reports whether a team named teamName owns the service. An
+// unknown team owns nothing.
+func (g *graph) ownedBy(ctx context.Context, svc, teamName string) (bool, error) {
+	t, err := g.findTeam(ctx, teamName)
+	if err != nil || t == nil {
+		return false, err
+	}
+	return contains(g.owned[t.ID], svc), nil
+}
+
+// edges returns the links from (outgoing) or to a service, of linkType if
+// set, whose other end is owned by teamName if set.
+func (g *graph) edges(ctx context.Context, svc string, outgoing bool, linkType, teamName string) ([]registry.ServiceLink, error) {
+	if err := g.loadLinks(ctx); err != nil {
+		return nil, err
+	}
+	var out []registry.ServiceLink
+	for _, l := range g.links {
+		self, other := l.ToRepo, l.FromRepo
+		if outgoing {
+			self, other = l.FromRepo, l.ToRepo
+		}
+		if self != svc || (linkType != "" && !strings.EqualFold(l.LinkType, linkType)) {
+			continue
+		}
+		if teamName != "" {
+			ok, err := g.ownedBy(ctx, other, teamName)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// neighbours returns the distinct services at the other end of a
+// service's links.
+func (g *graph) neighbours(ctx context.Context, svc string, outgoing bool) (*[]*service, error) {
+	links, err := g.edges(ctx, svc, outgoing, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, l := range links {
+		name := l.FromRepo
+		if outgoing {
+			name = l.ToRepo
+		}
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return g.services(ctx, names)
+}
+
+// list returns items as the value of a nullable list field, counting them
+// against the response's limit.
+func list[T any](g *graph, items []T) (*[]T, error) {
+	if err := g.spend(len(items)); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return &items, nil
+}
+
+// need checks the request's API key grants every scope.
+func need(ctx context.Context, scopes ...apikeys.Scope) error {
+	key := apikeys.FromContext(ctx)
+	for _, s := range scopes {
+		if key == nil || !key.HasScope(s) {
+			return fmt.Errorf("API key does not grant the %q scope", s)
+		}
+	}
+	return nil
+}
+
+// optional returns a string as the value of a nullable field.
+func optional(s string) *string { return &s }
+
+// deref returns an optional argument's value, "" when it is absent.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// timestamp renders a time in RFC 3339, or null when it is zero.
+func timestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	return optional(t.UTC().Format(time.RFC3339))
+}
+
+// query resolves the root Query type.
+type query struct{}
+
+func (query) Services(ctx context.Context, args struct{ Tag, Team *string }) (*[]*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadRepos(ctx); err != nil {
+		return nil, err
+	}
+	tag, teamName := deref(args.Tag), deref(args.Team)
+	if teamName != "" {
+		if err := need(ctx, apikeys.ScopeOwnership); err != nil {
+			return nil, err
+		}
+	}
+	var out []*service
+	for _, name := range g.names {
+		if tag != "" && !contains(g.tags[name], tag) {
+			continue
+		}
+		if teamName != "" {
+			ok, err := g.ownedBy(ctx, name, teamName)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		out = append(out, &service{name: name, repo: g.repos[name], tags: g.tags[name]})
+	}
+	return list(g, out)
+}
+
+func (query) Service(ctx context.Context, args struct{ Name string }) (*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	s, err := graphFrom(ctx).service(ctx, args.Name)
+	if err != nil || s.repo == nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (query) Edges(ctx context.Context, args struct{ Type *string }) (*[]*edge, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadLinks(ctx); err != nil {
+		return nil, err
+	}
+	var out []*edge
+	for _, l := range g.links {
+		if t := deref(args.Type); t == "" || strings.EqualFold(l.LinkType, t) {
+			out = append(out, &edge{l})
+		}
+	}
+	return list(g, out)
+}
+
+func (query) Flows(ctx context.Context, args struct{ Query, Service *string }) (*[]*flow, error) {
+	if err := need(ctx, apikeys.ScopeFlows); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadFlows(ctx); err != nil {
+		return nil, err
+	}
+	q, svc := strings.ToLower(deref(args.Query)), deref(args.Service)
+	var out []*flow
+	for _, f := range g.flows {
+		if q != "" && !strings.Contains(strings.ToLower(f.Name+"\n"+f.Description), q) {
+			continue
+		}
+		if svc != "" && !containsFold(f.Services, svc) {
+			continue
+		}
+		out = append(out, &flow{f})
+	}
+	return list(g, out)
+}
+
+func (query) Flow(ctx context.Context, args struct{ ID graphql.ID }) (*flow, error) {
+	if err := need(ctx, apikeys.ScopeFlows); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadFlows(ctx); err != nil {
+		return nil, err
+	}
+	for _, f := range g.flows {
+		if f.ID == string(args.ID) || strings.EqualFold(f.Name, string(args.ID)) {
+			return &flow{f}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (query) Teams(ctx context.Context) (*[]*team, error) {
+	if err := need(ctx, apikeys.ScopeOwnership); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadOrg(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]*team, len(g.teams))
+	for i := range g.teams {
+		out[i] = &team{&g.teams[i]}
+	}
+	return list(g, out)
+}
+
+func (query) Team(ctx context.Context, args struct{ Name string }) (*team, error) {
+	if err := need(ctx, apikeys.ScopeOwnership); err != nil {
+		return nil, err
+	}
+	t, err := graphFrom(ctx).findTeam(ctx, args.Name)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return &team{t}, nil
+}
+
+func (query) Facts(ctx context.Context, args struct {
+	Repo, Scope, ScopeID, Key, Query *string
+	Limit                            *int32
+}) (*[]*fact, error) {
+	if err := need(ctx, apikeys.ScopeFacts); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if g.deps.Facts == nil {
+		return nil, fmt.Errorf("context store is not available")
+	}
+	var (
+		facts []contextengine.Fact
+		err   error
+	)
+	if q := deref(args.Query); q != "" {
+		limit := 50
+		if args.Limit != nil {
+			limit = int(*args.Limit)
+		}
+		facts, err = g.deps.Facts.SearchFacts(ctx, q, min(limit, 500))
+	} else {
+		facts, err = g.deps.Facts.GetCurrentFacts(ctx, deref(args.Repo), deref(args.Scope), deref(args.ScopeID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return list(g, factNodes(filterFacts(facts, deref(args.Key))))
+}
+
+// service is a node of the graph. repo is nil for a service that links or
+// flows name but the registry doesn't hold.
+type service struct {
+	name string
+	repo *registry.Repository
+	tags []string
+}
+
+// registryField returns a registry field of a service, null for an
+// unregistered one.
+func (s *service) registryField(get func(r *registry.Repository) string) *string {
+	if s.repo == nil {
+		return nil
+	}
+	return optional(get(s.repo))
+}
+
+func (s *service) Name() string     { return s.name }
+func (s *service) Registered() bool { return s.repo != nil }
+func (s *service) DisplayName() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.DisplayName })
+}
+func (s *service) Summary() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.Summary })
+}
+func (s *service) Status() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.Status })
+}
+func (s *service) SourceURL() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.SourceURL })
+}
+func (s *service) LastIndexedAt() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.LastIndexedAt })
+}
+func (s *service) Parent() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.Parent })
+}
+func (s *service) Subdir() *string {
+	return s.registryField(func(r *registry.Repository) string { return r.Subdir })
+}
+
+func (s *service) FileCount() *int32 {
+	if s.repo == nil {
+		return nil
+	}
+	n := int32(s.repo.FileCount)
+	return &n
+}
+
+func (s *service) Tags(ctx context.Context) ([]string, error) {
+	if err := graphFrom(ctx).spend(len(s.tags)); err != nil {
+		return nil, err
+	}
+	if s.tags == nil {
+		return []string{}, nil
+	}
+	return s.tags, nil
+}
+
+func (s *service) Calls(ctx context.Context, args struct{ Type, ToTeam *string }) (*[]*edge, error) {
+	return s.links(ctx, true, deref(args.Type), deref(args.ToTeam))
+}
+
+func (s *service) CalledBy(ctx context.Context, args struct{ Type, FromTeam *string }) (*[]*edge, error) {
+	return s.links(ctx, false, deref(args.Type), deref(args.FromTeam))
+}
+
+// links resolves calls and calledBy. Filtering by team needs the
+// ownership scope too.
+func (s *service) links(ctx context.Context, outgoing bool, linkType, teamName string) (*[]*edge, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	if teamName != "" {
+		if err := need(ctx, apikeys.ScopeOwnership); err != nil {
+			return nil, err
+		}
+	}
+	g := graphFrom(ctx)
+	links, err := g.edges(ctx, s.name, outgoing, linkType, teamName)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*edge, len(links))
+	for i, l := range links {
+		out[i] = &edge{l}
+	}
+	return list(g, out)
+}
+
+func (s *service) Dependencies(ctx context.Context) (*[]*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	return graphFrom(ctx).neighbours(ctx, s.name, true)
+}
+
+func (s *service) Consumers(ctx context.Context) (*[]*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	return graphFrom(ctx).neighbours(ctx, s.name, false)
+}
+
+func (s *service) Owners(ctx context.Context) (*[]*team, error) {
+	if err := need(ctx, apikeys.ScopeOwnership); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadOrg(ctx); err != nil {
+		return nil, err
+	}
+	var out []*team
+	for _, i := range g.owners[s.name] {
+		out = append(out, &team{&g.teams[i]})
+	}
+	return list(g, out)
+}
+
+func (s *service) Flows(ctx context.Context) (*[]*flow, error) {
+	if err := need(ctx, apikeys.ScopeFlows); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if err := g.loadFlows(ctx); err != nil {
+		return nil, err
+	}
+	var out []*flow
+	for _, f := range g.flows {
+		if containsFold(f.Services, s.name) {
+			out = append(out, &flow{f})
+		}
+	}
+	return list(g, out)
+}
+
+func (s *service) Facts(ctx context.Context, args struct{ Key *string }) (*[]*fact, error) {
+	if err := need(ctx, apikeys.ScopeFacts); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	if g.deps.Facts == nil {
+		return nil, fmt.Errorf("context store is not available")
+	}
+	facts, err := g.deps.Facts.GetCurrentFacts(ctx, "", "service", s.name)
+	if err != nil {
+		return nil, err
+	}
+	return list(g, factNodes(filterFacts(facts, deref(args.Key))))
+}
+
+// edge is a link between two services.
+type edge struct{ link registry.ServiceLink }
+
+func (e *edge) From(ctx context.Context) (*service, error) {
+	return graphFrom(ctx).service(ctx, e.link.FromRepo)
+}
+
+func (e *edge) To(ctx context.Context) (*service, error) {
+	return graphFrom(ctx).service(ctx, e.link.ToRepo)
+}
+
+func (e *edge) Type() string    { return e.link.LinkType }
+func (e *edge) Reason() *string { return optional(e.link.Reason) }
+
+func (e *edge) Endpoints(ctx context.Context) ([]string, error) {
+	if err := graphFrom(ctx).spend(len(e.link.Endpoints)); err != nil {
+		return nil, err
+	}
+	if e.link.Endpoints == nil {
+		return []string{}, nil
+	}
+	return e.link.Endpoints, nil
+}
+
+// flow is a cross-service flow.
+type flow struct{ f flows.Flow }
+
+func (f *flow) ID() graphql.ID       { return graphql.ID(f.f.ID) }
+func (f *flow) Name() string         { return f.f.Name }
+func (f *flow) Description() *string { return optional(f.f.Description) }
+func (f *flow) Narrative() *string   { return optional(f.f.Narrative) }
+func (f *flow) EntryPoint() *string  { return optional(f.f.EntryPoint) }
+func (f *flow) ExitPoint() *string   { return optional(f.f.ExitPoint) }
+func (f *flow) UpdatedAt() *string   { return timestamp(f.f.UpdatedAt) }
+
+func (f *flow) Services(ctx context.Context) (*[]*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	return graphFrom(ctx).services(ctx, f.f.Services)
+}
+
+// team is a team from the org structure.
+type team struct{ t *orgstructure.Team }
+
+func (t *team) ID() graphql.ID        { return graphql.ID(t.t.ID) }
+func (t *team) Name() string          { return t.t.Name }
+func (t *team) DisplayName() *string  { return optional(t.t.DisplayName) }
+func (t *team) SlackChannel() *string { return optional(t.t.SlackChannel) }
+func (t *team) Email() *string        { return optional(t.t.Email) }
+
+func (t *team) Members(ctx context.Context) ([]*member, error) {
+	if err := graphFrom(ctx).spend(len(t.t.Members)); err != nil {
+		return nil, err
+	}
+	out := make([]*member, len(t.t.Members))
+	for i := range t.t.Members {
+		out[i] = &member{t.t.Members[i]}
+	}
+	return out, nil
+}
+
+func (t *team) Services(ctx context.Context) (*[]*service, error) {
+	if err := need(ctx, apikeys.ScopeRepos); err != nil {
+		return nil, err
+	}
+	g := graphFrom(ctx)
+	names := append([]string(nil), g.owned[t.t.ID]...)
+	sort.Strings(names)
+	return g.services(ctx, names)
+}
+
+// member is a member of a team.
+type member struct{ m orgstructure.TeamMember }
+
+func (m *member) UserID() string { return m.m.UserID }
+func (m *member) Role() *string  { return optional(m.m.Role) }
+
+// fact is a current context-engine fact.
+type fact struct{ f contextengine.Fact }
+
+func factNodes(facts []contextengine.Fact) []*fact {
+	out := make([]*fact, len(facts))
+	for i := range facts {
+		out[i] = &fact{facts[i]}
+	}
+	return out
+}
+
+func (f *fact) ID() graphql.ID      { return graphql.ID(f.f.ID) }
+func (f *fact) RepoID() *string     { return optional(f.f.RepoID) }
+func (f *fact) Scope() string       { return f.f.Scope }
+func (f *fact) ScopeID() *string    { return optional(f.f.ScopeID) }
+func (f *fact) Key() string         { return f.f.Key }
+func (f *fact) Value() string       { return f.f.Value }
+func (f *fact) Source() *string     { return optional(f.f.Source) }
+func (f *fact) ProvidedBy() *string { return optional(f.f.ProvidedBy) }
+func (f *fact) Version() int32      { return int32(f.f.Version) }
+func (f *fact) UpdatedAt() *string  { return timestamp(f.f.UpdatedAt) }
+
+func filterFacts(facts []contextengine.Fact, key string) []contextengine.Fact {
+	if key == "" {
+		return facts
+	}
+	var out []contextengine.Fact
+	for _, f := range facts {
+		if f.Key == key {
+			out = append(out, f)
+		}
+	}
+	return out
+}
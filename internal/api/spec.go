@@ -0,0 +1,39 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+var specJSON = sync.OnceValues(func() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(specYAML, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+})
+
+// handleSpecYAML serves the embedded OpenAPI document.
+func handleSpecYAML(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(specYAML)
+}
+
+// handleSpecJSON serves the OpenAPI document converted to JSON, for tools
+// that don't read YAML.
+func handleSpecJSON(w http.ResponseWriter, r *http.Request) {
+	data, err := specJSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "parsing OpenAPI spec: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,239 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/site"
+	"github.com/ziadkadry99/auto-doc/internal/siteauth"
+)
+
+func setupRouter(t *testing.T) (*Store, *registry.Store, chi.Router) {
+	t.Helper()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	store := NewStore(database)
+	repoStore := registry.NewStore(database)
+	authn, err := siteauth.New(context.Background(), config.AuthConfig{Provider: "proxy"})
+	if err != nil {
+		t.Fatalf("siteauth.New: %v", err)
+	}
+	requireAdmin := siteauth.RequireGroups([]string{"platform-admins"})
+	admin := func(next http.Handler) http.Handler { return authn.RequireAuth(requireAdmin(next)) }
+	r := chi.NewRouter()
+	RegisterRoutes(r, store, repoStore, publicRepos("orders"), admin)
+	return store, repoStore, r
+}
+
+// publicRepos returns a visibility lookup under which only the named repos
+// are public.
+func publicRepos(names ...string) func(string) string {
+	return func(repo string) string {
+		for _, n := range names {
+			if n == repo {
+				return site.VisibilityPublic
+			}
+		}
+		return ""
+	}
+}
+
+func get(r chi.Router, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestKeyManagementRequiresAdmin(t *testing.T) {
+	store, repoStore, r := setupRouter(t)
+	create := func(user, groups string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/apikeys", strings.NewReader(`{"name":"portal","scopes":["services"]}`))
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+			req.Header.Set("X-Forwarded-Groups", groups)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := create("", ""); code != http.StatusUnauthorized {
+		t.Errorf("anonymous create: status = %d, want 401", code)
+	}
+	if code := create("mallory", "engineering"); code != http.StatusForbidden {
+		t.Errorf("non-admin create: status = %d, want 403", code)
+	}
+	if code := create("ada", "platform-admins"); code != http.StatusCreated {
+		t.Errorf("admin create: status = %d, want 201", code)
+	}
+
+	// Without an admin check, key management isn't mounted at all.
+	bare := chi.NewRouter()
+	RegisterRoutes(bare, store, repoStore, publicRepos(), nil)
+	w := httptest.NewRecorder()
+	bare.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/apikeys", strings.NewReader(`{"name":"x"}`)))
+	if w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unprotected create: status = %d, want it unmounted", w.Code)
+	}
+}
+
+func TestCreateAuthenticateRevoke(t *testing.T) {
+	store, _, _ := setupRouter(t)
+	ctx := context.Background()
+
+	key, raw, err := store.Create(ctx, "partner-a", []Scope{ScopeServices}, 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if key.RateLimit != DefaultRateLimit {
+		t.Errorf("RateLimit = %d, want %d", key.RateLimit, DefaultRateLimit)
+	}
+
+	got, err := store.Authenticate(ctx, raw)
+	if err != nil || got == nil {
+		t.Fatalf("Authenticate: %v, %v", got, err)
+	}
+	if got.ID != key.ID || !got.HasScope(ScopeServices) || got.HasScope(ScopeEndpoints) {
+		t.Errorf("unexpected key: %+v", got)
+	}
+
+	if err := store.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if got, _ := store.Authenticate(ctx, raw); got != nil {
+		t.Error("expected revoked key to fail authentication")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter()
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.Allow("k", 2); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	ok, _, wait := l.Allow("k", 2)
+	if ok {
+		t.Fatal("third request should be throttled")
+	}
+	if wait <= 0 || wait > 30*time.Second {
+		t.Errorf("wait = %v, want (0, 30s]", wait)
+	}
+
+	now = now.Add(30 * time.Second)
+	if ok, _, _ := l.Allow("k", 2); !ok {
+		t.Error("request after refill should be allowed")
+	}
+	if ok, _, _ := l.Allow("other", 2); !ok {
+		t.Error("buckets should be per key")
+	}
+}
+
+func TestPublicAPI(t *testing.T) {
+	store, repoStore, r := setupRouter(t)
+	ctx := context.Background()
+
+	if err := repoStore.Add(ctx, &registry.Repository{Name: "orders", SourceType: "local", LocalPath: "/srv/orders", Summary: "Order management"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "web", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /orders", "GET /orders/{id}"}})
+	// ledger keeps the default, internal visibility: partners don't see it.
+	if err := repoStore.Add(ctx, &registry.Repository{Name: "ledger", SourceType: "local", LocalPath: "/srv/ledger", Summary: "General ledger"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "orders", ToRepo: "ledger", LinkType: "http", Endpoints: []string{"POST /journal"}})
+
+	_, raw, _ := store.Create(ctx, "partner-a", []Scope{ScopeServices}, 1)
+
+	if w := get(r, "/api/public/v1/services", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no key: status = %d, want 401", w.Code)
+	}
+	if w := get(r, "/api/public/v1/services", "adk_bogus"); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad key: status = %d, want 401", w.Code)
+	}
+	if w := get(r, "/api/public/v1/endpoints", raw); w.Code != http.StatusForbidden {
+		t.Errorf("missing scope: status = %d, want 403", w.Code)
+	}
+
+	w := get(r, "/api/public/v1/services", raw)
+	if w.Code != http.StatusOK {
+		t.Fatalf("services: status = %d, body %s", w.Code, w.Body.String())
+	}
+	var services []ServiceSummary
+	json.Unmarshal(w.Body.Bytes(), &services)
+	if len(services) != 1 || services[0].Name != "orders" || services[0].Summary != "Order management" {
+		t.Errorf("unexpected services: %+v", services)
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("X-RateLimit-Limit = %q, want 1", w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	w = get(r, "/api/public/v1/services", raw)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("over limit: status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Endpoint inventory for a key with the endpoints scope.
+	_, raw2, _ := store.Create(ctx, "partner-b", []Scope{ScopeEndpoints}, 10)
+	w = get(r, "/api/public/v1/endpoints", raw2)
+	var inventory []ServiceEndpoints
+	json.Unmarshal(w.Body.Bytes(), &inventory)
+	if len(inventory) != 1 || inventory[0].Service != "orders" || len(inventory[0].Endpoints) != 2 {
+		t.Errorf("unexpected inventory: %+v", inventory)
+	}
+}
+
+func TestUsageReport(t *testing.T) {
+	store, _, r := setupRouter(t)
+	ctx := context.Background()
+
+	key, raw, _ := store.Create(ctx, "partner-a", []Scope{ScopeServices}, 2)
+	for i := 0; i < 3; i++ {
+		get(r, "/api/public/v1/services", raw)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/apikeys/"+key.ID+"/usage", nil)
+	req.Header.Set("X-Forwarded-User", "ada")
+	req.Header.Set("X-Forwarded-Groups", "platform-admins")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("usage: status = %d", w.Code)
+	}
+	var report struct {
+		Total     int     `json:"total"`
+		Throttled int     `json:"throttled"`
+		Usage     []Usage `json:"usage"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Total != 3 || report.Throttled != 1 {
+		t.Errorf("total = %d, throttled = %d, want 3 and 1", report.Total, report.Throttled)
+	}
+	if len(report.Usage) != 1 || report.Usage[0].Endpoint != "services" {
+		t.Errorf("unexpected usage rows: %+v", report.Usage)
+	}
+
+	if got, _ := store.Get(ctx, key.ID); got == nil || got.LastUsedAt == nil {
+		t.Error("expected last_used_at to be set")
+	}
+}
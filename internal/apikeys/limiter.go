@@ -0,0 +1,60 @@
+package apikeys
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter enforces per-key requests-per-minute budgets with a token bucket
+// per key. Unlike the LLM rate limiter it never waits: callers over budget
+// are rejected so partners get an immediate 429.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewLimiter creates an empty per-key limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow consumes one request from the key's budget of rpm requests per
+// minute. It returns whether the request is allowed, the whole requests
+// still available, and how long until the next request is available when
+// it is not.
+func (l *Limiter) Allow(keyID string, rpm int) (bool, int, time.Duration) {
+	if rpm <= 0 {
+		rpm = DefaultRateLimit
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[keyID]
+	if !ok {
+		b = &bucket{tokens: float64(rpm), lastFill: now}
+		l.buckets[keyID] = b
+	}
+
+	// Refill based on elapsed time, capped at one minute's budget.
+	perSecond := float64(rpm) / 60.0
+	b.tokens += now.Sub(b.lastFill).Seconds() * perSecond
+	if b.tokens > float64(rpm) {
+		b.tokens = float64(rpm)
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
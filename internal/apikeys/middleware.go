@@ -0,0 +1,84 @@
+package apikeys
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type contextKey struct{}
+
+// FromContext returns the API key that authenticated the request, if any.
+func FromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(contextKey{}).(*APIKey)
+	return key
+}
+
+// Require returns middleware that authenticates requests by API key, checks
+// the key grants scope, enforces the key's rate limit, and records usage
+// under endpoint. Keys are read from "Authorization: Bearer <key>" or the
+// X-API-Key header.
+func Require(store *Store, limiter *Limiter, scope Scope, endpoint string) func(http.Handler) http.Handler {
+	return guard(store, limiter, scope, endpoint)
+}
+
+// Authenticate is like Require but admits a key with any scopes, for
+// endpoints that check scopes per request with FromContext.
+func Authenticate(store *Store, limiter *Limiter, endpoint string) func(http.Handler) http.Handler {
+	return guard(store, limiter, "", endpoint)
+}
+
+// guard authenticates and rate-limits requests, checking scope unless it is
+// empty.
+func guard(store *Store, limiter *Limiter, scope Scope, endpoint string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := requestKey(r)
+			if raw == "" {
+				writeError(w, http.StatusUnauthorized, "missing API key")
+				return
+			}
+
+			key, err := store.Authenticate(r.Context(), raw)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if key == nil {
+				writeError(w, http.StatusUnauthorized, "invalid or revoked API key")
+				return
+			}
+			if scope != "" && !key.HasScope(scope) {
+				writeError(w, http.StatusForbidden, fmt.Sprintf("API key does not grant the %q scope", scope))
+				return
+			}
+
+			allowed, remaining, retryAfter := limiter.Allow(key.ID, key.RateLimit)
+			if err := store.RecordUsage(r.Context(), key.ID, endpoint, !allowed); err != nil {
+				log.Printf("apikeys: %v", err)
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, key)))
+		})
+	}
+}
+
+// requestKey extracts the raw API key from the request headers.
+func requestKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
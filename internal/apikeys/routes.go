@@ -0,0 +1,202 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/site"
+)
+
+// RegisterRoutes mounts the key-scoped public read-only API and, behind
+// admin, the API key management routes. Keys grant access to everything
+// their scopes cover, so management is only mounted when admin is non-nil:
+// it must sign the user in and check they are an administrator. The public
+// API only lists services whose visibility, as returned by visibility, is
+// public.
+func RegisterRoutes(r chi.Router, store *Store, repoStore *registry.Store, visibility func(repo string) string, admin func(http.Handler) http.Handler) {
+	if admin != nil {
+		r.With(admin).Route("/api/apikeys", func(r chi.Router) {
+			r.Get("/", handleList(store))
+			r.Post("/", handleCreate(store))
+			r.Delete("/{id}", handleRevoke(store))
+			r.Get("/{id}/usage", handleUsage(store))
+		})
+	}
+
+	limiter := NewLimiter()
+	r.Route("/api/public/v1", func(r chi.Router) {
+		r.With(Require(store, limiter, ScopeServices, "services")).Get("/services", handlePublicServices(repoStore, visibility))
+		r.With(Require(store, limiter, ScopeEndpoints, "endpoints")).Get("/endpoints", handlePublicEndpoints(repoStore, visibility))
+	})
+}
+
+func handleList(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := store.List(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if keys == nil {
+			keys = []APIKey{}
+		}
+		writeJSON(w, http.StatusOK, keys)
+	}
+}
+
+func handleCreate(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name      string  `json:"name"`
+			Scopes    []Scope `json:"scopes"`
+			RateLimit int     `json:"rate_limit"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Name == "" {
+			writeError(w, http.StatusBadRequest, "name is required")
+			return
+		}
+		for _, s := range req.Scopes {
+			if !s.Valid() {
+				writeError(w, http.StatusBadRequest, "unknown scope: "+string(s))
+				return
+			}
+		}
+
+		key, raw, err := store.Create(r.Context(), req.Name, req.Scopes, req.RateLimit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, struct {
+			*APIKey
+			Key string `json:"key"`
+		}{key, raw})
+	}
+}
+
+func handleRevoke(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := store.Revoke(r.Context(), chi.URLParam(r, "id")); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleUsage(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		key, err := store.Get(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if key == nil {
+			writeError(w, http.StatusNotFound, "api key not found")
+			return
+		}
+
+		usage, err := store.Usage(r.Context(), id, r.URL.Query().Get("since"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if usage == nil {
+			usage = []Usage{}
+		}
+
+		total, throttled := 0, 0
+		for _, u := range usage {
+			total += u.Requests
+			throttled += u.Throttled
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"key":       key,
+			"total":     total,
+			"throttled": throttled,
+			"usage":     usage,
+		})
+	}
+}
+
+func handlePublicServices(repoStore *registry.Store, visibility func(string) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repos, err := repoStore.List(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		services := make([]ServiceSummary, 0, len(repos))
+		for _, repo := range repos {
+			if !site.VisibleTo(visibility(repo.Name), site.AudiencePublic) {
+				continue
+			}
+			services = append(services, ServiceSummary{
+				Name:          repo.Name,
+				DisplayName:   repo.DisplayName,
+				Summary:       repo.Summary,
+				Status:        repo.Status,
+				LastIndexedAt: repo.LastIndexedAt,
+			})
+		}
+		writeJSON(w, http.StatusOK, services)
+	}
+}
+
+func handlePublicEndpoints(repoStore *registry.Store, visibility func(string) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		links, err := repoStore.GetLinks(r.Context(), r.URL.Query().Get("service"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Endpoints on a link belong to the service being called.
+		byService := make(map[string]map[string]bool)
+		for _, l := range links {
+			if service := r.URL.Query().Get("service"); service != "" && l.ToRepo != service {
+				continue
+			}
+			if !site.VisibleTo(visibility(l.ToRepo), site.AudiencePublic) {
+				continue
+			}
+			if byService[l.ToRepo] == nil {
+				byService[l.ToRepo] = make(map[string]bool)
+			}
+			for _, ep := range l.Endpoints {
+				byService[l.ToRepo][ep] = true
+			}
+		}
+
+		inventory := make([]ServiceEndpoints, 0, len(byService))
+		for service, set := range byService {
+			endpoints := make([]string, 0, len(set))
+			for ep := range set {
+				endpoints = append(endpoints, ep)
+			}
+			sort.Strings(endpoints)
+			inventory = append(inventory, ServiceEndpoints{Service: service, Endpoints: endpoints})
+		}
+		sort.Slice(inventory, func(i, j int) bool { return inventory[i].Service < inventory[j].Service })
+		writeJSON(w, http.StatusOK, inventory)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
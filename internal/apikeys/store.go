@@ -0,0 +1,208 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// keyPrefix marks autodoc API keys so they are recognisable in logs and secret scanners.
+const keyPrefix = "adk_"
+
+// Store manages API keys and their usage counters.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a new API key store.
+func NewStore(d *db.DB) *Store {
+	return &Store{db: d}
+}
+
+// Create generates a new API key and returns its record and the raw key.
+// The raw key is not stored and cannot be recovered later.
+func (s *Store) Create(ctx context.Context, name string, scopes []Scope, rateLimit int) (*APIKey, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("generating key: %w", err)
+	}
+	raw := keyPrefix + hex.EncodeToString(buf)
+
+	if scopes == nil {
+		scopes = []Scope{}
+	}
+	if rateLimit <= 0 {
+		rateLimit = DefaultRateLimit
+	}
+	key := &APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Prefix:    raw[:len(keyPrefix)+6],
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling scopes: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, name, key_prefix, key_hash, scopes, rate_limit, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.Prefix, hashKey(raw), string(scopesJSON), key.RateLimit, key.CreatedAt,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("creating api key: %w", err)
+	}
+	return key, raw, nil
+}
+
+// Authenticate looks up an active key by its raw value. It returns nil when
+// the key is unknown or revoked.
+func (s *Store) Authenticate(ctx context.Context, raw string) (*APIKey, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, name, key_prefix, scopes, rate_limit, created_at, last_used_at, revoked_at
+		 FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL`, hashKey(raw))
+	key, err := scanKey(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authenticating api key: %w", err)
+	}
+	return key, nil
+}
+
+// Get retrieves a key by ID. Returns nil if not found.
+func (s *Store) Get(ctx context.Context, id string) (*APIKey, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, name, key_prefix, scopes, rate_limit, created_at, last_used_at, revoked_at
+		 FROM api_keys WHERE id = ?`, id)
+	key, err := scanKey(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting api key: %w", err)
+	}
+	return key, nil
+}
+
+// List returns all keys, including revoked ones, newest first.
+func (s *Store) List(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, key_prefix, scopes, rate_limit, created_at, last_used_at, revoked_at
+		 FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke disables a key. Revoked keys stay listed for usage reporting.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("revoking api key: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("api key %s not found or already revoked", id)
+	}
+	return nil
+}
+
+// RecordUsage counts one request by a key against an endpoint for today.
+func (s *Store) RecordUsage(ctx context.Context, keyID, endpoint string, throttled bool) error {
+	now := time.Now().UTC()
+	throttledCount := 0
+	if throttled {
+		throttledCount = 1
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_key_usage (key_id, day, endpoint, requests, throttled) VALUES (?, ?, ?, 1, ?)
+		 ON CONFLICT(key_id, day, endpoint) DO UPDATE SET requests = requests + 1, throttled = throttled + excluded.throttled`,
+		keyID, now.Format("2006-01-02"), endpoint, throttledCount,
+	)
+	if err != nil {
+		return fmt.Errorf("recording api key usage: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now, keyID); err != nil {
+		return fmt.Errorf("updating api key last use: %w", err)
+	}
+	return nil
+}
+
+// Usage returns a key's usage counters from the given day (YYYY-MM-DD)
+// onwards. An empty since returns all recorded usage.
+func (s *Store) Usage(ctx context.Context, keyID, since string) ([]Usage, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key_id, day, endpoint, requests, throttled FROM api_key_usage
+		 WHERE key_id = ? AND day >= ? ORDER BY day DESC, endpoint`, keyID, since)
+	if err != nil {
+		return nil, fmt.Errorf("getting api key usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []Usage
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.KeyID, &u.Day, &u.Endpoint, &u.Requests, &u.Throttled); err != nil {
+			return nil, fmt.Errorf("scanning api key usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row scanner) (*APIKey, error) {
+	var key APIKey
+	var scopesJSON string
+	var lastUsed, revoked sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopesJSON, &key.RateLimit,
+		&key.CreatedAt, &lastUsed, &revoked); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopesJSON), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("unmarshaling scopes: %w", err)
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return &key, nil
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
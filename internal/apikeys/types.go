@@ -0,0 +1,85 @@
+package apikeys
+
+import "time"
+
+// Scope names a group of public read-only endpoints an API key may call.
+type Scope string
+
+const (
+	ScopeServices  Scope = "services"
+	ScopeEndpoints Scope = "endpoints"
+
+	// Scopes for the architecture model API under /api/v1.
+	ScopeRepos         Scope = "repos" // repos and the links between them
+	ScopeFlows         Scope = "flows"
+	ScopeOwnership     Scope = "ownership" // teams and the services they own
+	ScopeFacts         Scope = "facts"
+	ScopeNotifications Scope = "notifications"
+	ScopeSearch        Scope = "search"
+)
+
+// Scopes lists every scope a key can be granted.
+var Scopes = []Scope{
+	ScopeServices, ScopeEndpoints,
+	ScopeRepos, ScopeFlows, ScopeOwnership, ScopeFacts, ScopeNotifications, ScopeSearch,
+}
+
+// Valid reports whether s is a known scope.
+func (s Scope) Valid() bool {
+	for _, known := range Scopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRateLimit is the per-key request budget per minute when none is set.
+const DefaultRateLimit = 60
+
+// APIKey is a partner-facing credential for the public read-only API.
+// The raw key is only returned once, at creation; the store keeps a hash.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // first characters of the key, for identification
+	Scopes     []Scope    `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"` // requests per minute
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants access to the given scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is the request count for one key, endpoint, and UTC day.
+type Usage struct {
+	KeyID     string `json:"key_id"`
+	Day       string `json:"day"` // YYYY-MM-DD
+	Endpoint  string `json:"endpoint"`
+	Requests  int    `json:"requests"`
+	Throttled int    `json:"throttled"`
+}
+
+// ServiceSummary is the public view of a registered service.
+type ServiceSummary struct {
+	Name          string `json:"name"`
+	DisplayName   string `json:"display_name"`
+	Summary       string `json:"summary"`
+	Status        string `json:"status"`
+	LastIndexedAt string `json:"last_indexed_at"`
+}
+
+// ServiceEndpoints lists the endpoints other services are known to call on a service.
+type ServiceEndpoints struct {
+	Service   string   `json:"service"`
+	Endpoints []string `json:"endpoints"`
+}
@@ -0,0 +1,230 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store := NewStore(NewLocalBackend(filepath.Join(root, "store")), 0)
+	out := filepath.Join(root, "site")
+
+	stage1 := filepath.Join(root, "stage1")
+	writeTree(t, stage1, map[string]string{
+		"index.html":      "home",
+		"api/orders.html": "orders",
+		"api/old.html":    "old",
+	})
+	stats, err := store.Publish(ctx, stage1, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Written != 3 || stats.Removed != 0 {
+		t.Errorf("first publish = %+v", stats)
+	}
+	writeTree(t, out, map[string]string{"CNAME": "docs.example.com"}) // not ours
+
+	stage2 := filepath.Join(root, "stage2")
+	writeTree(t, stage2, map[string]string{
+		"index.html":      "home, updated",
+		"api/orders.html": "orders",
+	})
+	stats, err = store.Publish(ctx, stage2, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Written != 1 || stats.Unchanged != 1 || stats.Removed != 1 {
+		t.Errorf("second publish = %+v", stats)
+	}
+	if !reflect.DeepEqual(stats.ChangedFiles, []string{"index.html"}) || stats.AddedFiles != nil || !reflect.DeepEqual(stats.RemovedFiles, []string{"api/old.html"}) {
+		t.Errorf("second publish changed %v, added %v, removed %v", stats.ChangedFiles, stats.AddedFiles, stats.RemovedFiles)
+	}
+	if _, err := os.Stat(filepath.Join(out, "api", "old.html")); !os.IsNotExist(err) {
+		t.Error("stale page was not removed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "index.html")); string(data) != "home, updated" {
+		t.Errorf("index.html = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(out, "CNAME")); err != nil {
+		t.Error("a file no snapshot produced was removed")
+	}
+
+	snapshots, err := store.Snapshots(ctx, out)
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("Snapshots = %d, %v", len(snapshots), err)
+	}
+	restored := filepath.Join(root, "restored")
+	if err := store.Restore(ctx, &snapshots[0], restored); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored, "api", "old.html")); string(data) != "old" {
+		t.Errorf("restored old.html = %q", data)
+	}
+}
+
+func TestKeepAndGC(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store := NewStore(NewLocalBackend(filepath.Join(root, "store")), 2)
+	out := filepath.Join(root, "site")
+
+	for _, version := range []string{"v1", "v2", "v3"} {
+		stage := filepath.Join(root, "stage-"+version)
+		writeTree(t, stage, map[string]string{"index.html": version, "style.css": "body{}"})
+		if _, err := store.Publish(ctx, stage, out); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // distinct snapshot IDs
+	}
+	snapshots, _ := store.Snapshots(ctx, out)
+	if len(snapshots) != 2 || snapshots[1].Files["index.html"] != Hash([]byte("v3")) {
+		t.Fatalf("kept snapshots = %+v", snapshots)
+	}
+
+	// A memoized page whose content no snapshot uses is collected too.
+	key := MemoKey("page", "orphan")
+	if err := store.Remember(ctx, key, []byte("orphan")); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := store.Lookup(ctx, key); !ok || string(data) != "orphan" {
+		t.Fatalf("Lookup = %q, %v", data, ok)
+	}
+
+	stats, err := store.GC(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// v1 and the orphan go; v2, v3, and the shared stylesheet stay.
+	if stats.Removed != 2 || stats.Kept != 3 || stats.MemoRemoved != 1 {
+		t.Errorf("GC = %+v", stats)
+	}
+	if _, ok := store.Lookup(ctx, key); ok {
+		t.Error("memo entry survived GC")
+	}
+	if _, err := store.Get(ctx, Hash([]byte("v2"))); err != nil {
+		t.Errorf("v2 was collected: %v", err)
+	}
+}
+
+func TestMemoKey(t *testing.T) {
+	if MemoKey("ab", "c") == MemoKey("a", "bc") {
+		t.Error("MemoKey parts run together")
+	}
+}
+
+// fakeS3 is an in-memory, path-style S3 bucket.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+f.bucket), "/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		type content struct {
+			Key  string
+			Size int64
+		}
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []content
+		}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, content{k, int64(len(f.objects[k]))})
+		}
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Backend(t *testing.T) {
+	fake := &fakeS3{bucket: "docs", objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	store, err := Open(config.ArtifactsConfig{S3: config.S3Config{
+		Bucket: "docs", Prefix: "autodoc", Endpoint: srv.URL, PathStyle: true,
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	stage := filepath.Join(root, "stage")
+	writeTree(t, stage, map[string]string{"index.html": "home"})
+	out := filepath.Join(root, "site")
+	if _, err := store.Publish(ctx, stage, out); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := Hash([]byte("home"))
+	if string(fake.objects["autodoc/objects/"+hash[:2]+"/"+hash]) != "home" {
+		t.Errorf("objects in bucket: %v", fake.objects)
+	}
+	sn, err := store.Latest(ctx, out)
+	if err != nil || sn == nil || sn.Files["index.html"] != hash {
+		t.Fatalf("Latest = %+v, %v", sn, err)
+	}
+	if stats, err := store.GC(ctx); err != nil || stats.Removed != 0 || stats.Kept != 1 {
+		t.Errorf("GC = %+v, %v", stats, err)
+	}
+}
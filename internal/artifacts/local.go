@@ -0,0 +1,90 @@
+package artifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalBackend keeps blobs as files under a directory.
+type LocalBackend struct {
+	Dir string
+}
+
+// NewLocalBackend returns a backend rooted at dir.
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{Dir: dir}
+}
+
+func (b *LocalBackend) path(key string) string {
+	return filepath.Join(b.Dir, filepath.FromSlash(key))
+}
+
+// Get reads the blob under key.
+func (b *LocalBackend) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(b.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put writes the blob under key.
+func (b *LocalBackend) Put(_ context.Context, key string, data []byte) error {
+	return writeFileAtomic(b.path(key), data)
+}
+
+// Exists reports whether a blob is stored under key.
+func (b *LocalBackend) Exists(_ context.Context, key string) (bool, error) {
+	_, err := os.Stat(b.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete removes the blob under key; a missing blob is not an error.
+func (b *LocalBackend) Delete(_ context.Context, key string) error {
+	p := b.path(key)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	removeEmptyParents(filepath.Dir(p), b.Dir)
+	return nil
+}
+
+// List returns the blobs whose keys start with prefix, sorted by key.
+func (b *LocalBackend) List(_ context.Context, prefix string) ([]Object, error) {
+	// Walk from the deepest directory the prefix names.
+	root := b.Dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = b.path(prefix[:i])
+	}
+	var objs []Object
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.Dir, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			objs = append(objs, Object{Key: key, Size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Key < objs[j].Key })
+	return objs, nil
+}
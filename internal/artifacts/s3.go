@@ -0,0 +1,237 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/awsauth"
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// S3Backend keeps blobs in an S3 bucket, signing requests with AWS
+// Signature Version 4. It speaks plain HTTP rather than pulling in the AWS
+// SDK, and works with S3-compatible services such as MinIO.
+type S3Backend struct {
+	bucket    string
+	prefix    string
+	region    string
+	endpoint  *url.URL
+	pathStyle bool
+	creds     awsauth.Source
+	http      *http.Client
+	now       func() time.Time
+}
+
+// NewS3Backend returns a backend for the bucket in cfg. Its credentials
+// come from the variables cfg names, or else from the AWS credential chain.
+func NewS3Backend(cfg config.S3Config) (*S3Backend, error) {
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid artifacts.s3.endpoint %q", cfg.Endpoint)
+	}
+	b := &S3Backend{
+		bucket:    cfg.Bucket,
+		prefix:    strings.TrimLeft(cfg.Prefix, "/"),
+		region:    region,
+		endpoint:  u,
+		pathStyle: cfg.PathStyle,
+		http:      &http.Client{Timeout: 60 * time.Second},
+		now:       time.Now,
+	}
+	if cfg.AccessKeyEnv != "" || cfg.SecretKeyEnv != "" {
+		accessEnv, secretEnv := cfg.AccessKeyEnv, cfg.SecretKeyEnv
+		if accessEnv == "" {
+			accessEnv = "AWS_ACCESS_KEY_ID"
+		}
+		if secretEnv == "" {
+			secretEnv = "AWS_SECRET_ACCESS_KEY"
+		}
+		creds := awsauth.Static{
+			AccessKeyID:     os.Getenv(accessEnv),
+			SecretAccessKey: os.Getenv(secretEnv),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return nil, fmt.Errorf("artifact store bucket %s: set %s and %s", cfg.Bucket, accessEnv, secretEnv)
+		}
+		b.creds = creds
+	} else {
+		chain := awsauth.NewChain()
+		if _, err := chain.Credentials(context.Background()); err != nil {
+			return nil, fmt.Errorf("artifact store bucket %s: %w", cfg.Bucket, err)
+		}
+		b.creds = chain
+	}
+	if b.prefix != "" && !strings.HasSuffix(b.prefix, "/") {
+		b.prefix += "/"
+	}
+	return b, nil
+}
+
+// objectURL returns the URL of key, or of the bucket when key is empty.
+func (b *S3Backend) objectURL(key string) *url.URL {
+	u := *b.endpoint
+	if b.pathStyle {
+		u.Path = "/" + b.bucket
+	} else {
+		u.Host = b.bucket + "." + u.Host
+		u.Path = ""
+	}
+	if key != "" {
+		u.Path += "/" + b.prefix + key
+	} else if u.Path == "" {
+		u.Path = "/"
+	}
+	return &u
+}
+
+func (b *S3Backend) do(ctx context.Context, method string, u *url.URL, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	creds, err := b.creds.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s3 credentials: %w", err)
+	}
+	awsauth.Sign(req, body, creds, b.region, "s3", b.now())
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, u.Path, err)
+	}
+	return resp, nil
+}
+
+// s3Error reads the status and message of a failed response.
+func s3Error(method string, resp *http.Response) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3 %s: %s: %s", method, e.Code, e.Message)
+	}
+	return fmt.Errorf("s3 %s: HTTP %d", method, resp.StatusCode)
+}
+
+// Get downloads the object under key.
+func (b *S3Backend) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, s3Error("GET", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put uploads data under key.
+func (b *S3Backend) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, b.objectURL(key), data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("PUT", resp)
+	}
+	return nil
+}
+
+// Exists reports whether an object is stored under key.
+func (b *S3Backend) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := b.do(ctx, http.MethodHead, b.objectURL(key), nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("s3 HEAD: HTTP %d", resp.StatusCode)
+}
+
+// Delete removes the object under key; a missing object is not an error.
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, b.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error("DELETE", resp)
+	}
+	return nil
+}
+
+// List returns the objects whose keys start with prefix, following
+// ListObjectsV2 continuation tokens.
+func (b *S3Backend) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objs []Object
+	token := ""
+	for {
+		u := b.objectURL("")
+		q := url.Values{"list-type": {"2"}, "prefix": {b.prefix + prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = q.Encode()
+		resp, err := b.do(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("LIST", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding s3 listing: %w", err)
+		}
+		for _, c := range page.Contents {
+			objs = append(objs, Object{Key: strings.TrimPrefix(c.Key, b.prefix), Size: c.Size})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Key < objs[j].Key })
+	return objs, nil
+}
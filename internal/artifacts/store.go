@@ -0,0 +1,494 @@
+// Package artifacts keeps rendered sites and export bundles in a
+// content-addressed store. Each file is stored once under the SHA-256 of its
+// content, each generation records a snapshot naming the files it produced,
+// and publishing a snapshot rewrites only the files that changed and removes
+// the ones the previous snapshot had but this one does not. Rendered pages
+// are also memoized by the hash of their inputs, so unchanged pages are not
+// rendered again.
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// DefaultKeep is how many snapshots of each output are kept.
+const DefaultKeep = 5
+
+// ErrNotFound is returned by a Backend for a key it does not hold.
+var ErrNotFound = errors.New("artifact not found")
+
+// Backend holds the store's blobs under slash-separated keys.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Object is one entry in a Backend listing.
+type Object struct {
+	Key  string
+	Size int64
+}
+
+// Key prefixes within a backend.
+const (
+	objectsPrefix   = "objects/"
+	manifestsPrefix = "manifests/"
+	memoPrefix      = "memo/"
+)
+
+// Store is a content-addressed artifact store.
+type Store struct {
+	backend Backend
+	keep    int
+}
+
+// NewStore returns a store over backend that keeps keep snapshots per
+// output; keep <= 0 means DefaultKeep.
+func NewStore(backend Backend, keep int) *Store {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	return &Store{backend: backend, keep: keep}
+}
+
+// Open returns the store described by cfg: the S3 bucket when one is set,
+// otherwise a directory, which defaults to {outputDir}/artifacts.
+func Open(cfg config.ArtifactsConfig, outputDir string) (*Store, error) {
+	if cfg.S3.Bucket != "" {
+		backend, err := NewS3Backend(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return NewStore(backend, cfg.Keep), nil
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(outputDir, "artifacts")
+	}
+	return NewStore(NewLocalBackend(dir), cfg.Keep), nil
+}
+
+// Hash returns the content address of data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func objectKey(hash string) string {
+	return objectsPrefix + hash[:2] + "/" + hash
+}
+
+// Put stores data and returns its hash. Content already in the store is not
+// written again.
+func (s *Store) Put(ctx context.Context, data []byte) (string, error) {
+	hash := Hash(data)
+	key := objectKey(hash)
+	ok, err := s.backend.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if err := s.backend.Put(ctx, key, data); err != nil {
+			return "", fmt.Errorf("storing artifact %s: %w", hash[:12], err)
+		}
+	}
+	return hash, nil
+}
+
+// Get returns the content stored under hash.
+func (s *Store) Get(ctx context.Context, hash string) ([]byte, error) {
+	if len(hash) < 2 {
+		return nil, ErrNotFound
+	}
+	return s.backend.Get(ctx, objectKey(hash))
+}
+
+// MemoKey hashes the inputs of a rendering step into a key for Lookup and
+// Remember. Each part is length-prefixed so that adjacent parts cannot run
+// together.
+func MemoKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Lookup returns the artifact previously rendered from the inputs behind
+// key, if it is still in the store.
+func (s *Store) Lookup(ctx context.Context, key string) ([]byte, bool) {
+	ref, err := s.backend.Get(ctx, memoPrefix+key)
+	if err != nil {
+		return nil, false
+	}
+	data, err := s.Get(ctx, strings.TrimSpace(string(ref)))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Remember stores data as the artifact rendered from the inputs behind key.
+func (s *Store) Remember(ctx context.Context, key string, data []byte) error {
+	hash, err := s.Put(ctx, data)
+	if err != nil {
+		return err
+	}
+	return s.backend.Put(ctx, memoPrefix+key, []byte(hash))
+}
+
+// Snapshot records the files one generation of an output produced.
+type Snapshot struct {
+	Output  string            `json:"output"`
+	ID      string            `json:"id"`
+	Created time.Time         `json:"created"`
+	Files   map[string]string `json:"files"` // slash-separated relative path -> content hash
+}
+
+// outputKey turns an output name such as ".autodoc/site" into a key segment
+// that is readable and safe in object keys, with a hash suffix so distinct
+// names never collide.
+func outputKey(output string) string {
+	output = filepath.Clean(output)
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return '_'
+	}, strings.Trim(filepath.ToSlash(output), "./"))
+	return safe + "-" + Hash([]byte(output))[:8]
+}
+
+// Snapshots returns the snapshots of an output, oldest first.
+func (s *Store) Snapshots(ctx context.Context, output string) ([]Snapshot, error) {
+	objs, err := s.backend.List(ctx, manifestsPrefix+outputKey(output)+"/")
+	if err != nil {
+		return nil, err
+	}
+	return s.loadSnapshots(ctx, objs)
+}
+
+// Outputs returns the latest snapshot of every output in the store.
+func (s *Store) Outputs(ctx context.Context) ([]Snapshot, error) {
+	all, err := s.allSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]Snapshot)
+	for _, sn := range all {
+		latest[sn.Output] = sn // oldest first, so the last one wins
+	}
+	outputs := make([]Snapshot, 0, len(latest))
+	for _, sn := range latest {
+		outputs = append(outputs, sn)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Output < outputs[j].Output })
+	return outputs, nil
+}
+
+func (s *Store) allSnapshots(ctx context.Context) ([]Snapshot, error) {
+	objs, err := s.backend.List(ctx, manifestsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadSnapshots(ctx, objs)
+}
+
+func (s *Store) loadSnapshots(ctx context.Context, objs []Object) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	for _, o := range objs {
+		data, err := s.backend.Get(ctx, o.Key)
+		if err != nil {
+			return nil, err
+		}
+		var sn Snapshot
+		if err := json.Unmarshal(data, &sn); err != nil {
+			return nil, fmt.Errorf("reading snapshot %s: %w", o.Key, err)
+		}
+		snapshots = append(snapshots, sn)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].ID < snapshots[j].ID })
+	return snapshots, nil
+}
+
+// Latest returns the newest snapshot of an output, or nil if it has none.
+func (s *Store) Latest(ctx context.Context, output string) (*Snapshot, error) {
+	snapshots, err := s.Snapshots(ctx, output)
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return &snapshots[len(snapshots)-1], nil
+}
+
+// Find returns the snapshot of an output with the given ID, or the latest
+// one when id is empty.
+func (s *Store) Find(ctx context.Context, output, id string) (*Snapshot, error) {
+	if id == "" {
+		sn, err := s.Latest(ctx, output)
+		if err == nil && sn == nil {
+			err = fmt.Errorf("no snapshots of %s", output)
+		}
+		return sn, err
+	}
+	snapshots, err := s.Snapshots(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if snapshots[i].ID == id {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no snapshot %s of %s", id, output)
+}
+
+// PublishStats counts what Publish did to the output directory.
+type PublishStats struct {
+	Snapshot  string // ID of the recorded snapshot
+	Written   int
+	Unchanged int
+	Removed   int
+	// AddedFiles, ChangedFiles, and RemovedFiles are the slash-separated
+	// paths, sorted, of the files new since the previous snapshot, those
+	// whose content changed, and those no longer produced. Files rewritten
+	// only because they were missing from disk are in none of them.
+	AddedFiles   []string
+	ChangedFiles []string
+	RemovedFiles []string
+}
+
+// Publish stores every file under stageDir, brings outDir in line with them,
+// and records them as a new snapshot of outDir. Files are only rewritten when
+// their content changed, and files from the previous snapshot that are no
+// longer produced are deleted. Files in outDir that no snapshot produced are
+// left alone.
+func (s *Store) Publish(ctx context.Context, stageDir, outDir string) (PublishStats, error) {
+	var stats PublishStats
+	prev, err := s.Latest(ctx, outDir)
+	if err != nil {
+		return stats, err
+	}
+
+	files := make(map[string]string)
+	err = filepath.Walk(stageDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(stageDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		hash, err := s.Put(ctx, data)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files[rel] = hash
+
+		dest := filepath.Join(outDir, filepath.FromSlash(rel))
+		var prevHash string
+		if prev != nil {
+			prevHash = prev.Files[rel]
+		}
+		if prevHash == hash {
+			if fi, err := os.Stat(dest); err == nil && fi.Size() == info.Size() {
+				stats.Unchanged++
+				return nil
+			}
+		}
+		if err := writeFileAtomic(dest, data); err != nil {
+			return err
+		}
+		stats.Written++
+		switch prevHash {
+		case "":
+			stats.AddedFiles = append(stats.AddedFiles, rel)
+		case hash:
+		default:
+			stats.ChangedFiles = append(stats.ChangedFiles, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("publishing %s: %w", outDir, err)
+	}
+
+	if prev != nil {
+		for rel := range prev.Files {
+			if _, ok := files[rel]; ok {
+				continue
+			}
+			dest := filepath.Join(outDir, filepath.FromSlash(rel))
+			if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+				return stats, fmt.Errorf("removing stale %s: %w", rel, err)
+			}
+			stats.Removed++
+			stats.RemovedFiles = append(stats.RemovedFiles, rel)
+			removeEmptyParents(filepath.Dir(dest), outDir)
+		}
+		sort.Strings(stats.RemovedFiles)
+	}
+
+	sn, err := s.record(ctx, outDir, files)
+	if err != nil {
+		return stats, err
+	}
+	stats.Snapshot = sn.ID
+	return stats, nil
+}
+
+// record saves a snapshot of an output and drops its snapshots beyond the
+// store's limit.
+func (s *Store) record(ctx context.Context, output string, files map[string]string) (*Snapshot, error) {
+	now := time.Now().UTC()
+	sn := &Snapshot{Output: filepath.Clean(output), ID: now.Format("20060102T150405.000000000Z"), Created: now, Files: files}
+	data, err := json.MarshalIndent(sn, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	dir := manifestsPrefix + outputKey(output) + "/"
+	if err := s.backend.Put(ctx, dir+sn.ID+".json", data); err != nil {
+		return nil, fmt.Errorf("recording snapshot: %w", err)
+	}
+
+	objs, err := s.backend.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Key < objs[j].Key })
+	for len(objs) > s.keep {
+		if err := s.backend.Delete(ctx, objs[0].Key); err != nil {
+			return nil, fmt.Errorf("pruning snapshot: %w", err)
+		}
+		objs = objs[1:]
+	}
+	return sn, nil
+}
+
+// Restore writes the files of a snapshot into dir.
+func (s *Store) Restore(ctx context.Context, sn *Snapshot, dir string) error {
+	for rel, hash := range sn.Files {
+		data, err := s.Get(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("restoring %s: %w", rel, err)
+		}
+		if err := writeFileAtomic(filepath.Join(dir, filepath.FromSlash(rel)), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GCStats counts what GC removed.
+type GCStats struct {
+	Kept         int
+	Removed      int
+	RemovedBytes int64
+	MemoRemoved  int
+}
+
+// GC deletes the stored files no snapshot refers to, along with the memo
+// entries that pointed at them.
+func (s *Store) GC(ctx context.Context) (GCStats, error) {
+	var stats GCStats
+	snapshots, err := s.allSnapshots(ctx)
+	if err != nil {
+		return stats, err
+	}
+	live := make(map[string]bool)
+	for _, sn := range snapshots {
+		for _, hash := range sn.Files {
+			live[hash] = true
+		}
+	}
+
+	objs, err := s.backend.List(ctx, objectsPrefix)
+	if err != nil {
+		return stats, err
+	}
+	for _, o := range objs {
+		if live[o.Key[strings.LastIndex(o.Key, "/")+1:]] {
+			stats.Kept++
+			continue
+		}
+		if err := s.backend.Delete(ctx, o.Key); err != nil {
+			return stats, err
+		}
+		stats.Removed++
+		stats.RemovedBytes += o.Size
+	}
+
+	memos, err := s.backend.List(ctx, memoPrefix)
+	if err != nil {
+		return stats, err
+	}
+	for _, o := range memos {
+		ref, err := s.backend.Get(ctx, o.Key)
+		if err == nil && live[strings.TrimSpace(string(ref))] {
+			continue
+		}
+		if err := s.backend.Delete(ctx, o.Key); err != nil {
+			return stats, err
+		}
+		stats.MemoRemoved++
+	}
+	return stats, nil
+}
+
+// writeFileAtomic writes data to path through a temporary file, so readers
+// of a served site never see a half-written page.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// removeEmptyParents deletes dir and its ancestors up to, but not
+// including, root while they are empty.
+func removeEmptyParents(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
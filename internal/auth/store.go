@@ -28,6 +28,7 @@ type Credentials struct {
 	OpenAI    *APIKeyCredentials `json:"openai,omitempty"`
 	MiniMax    *APIKeyCredentials `json:"minimax,omitempty"`
 	OpenRouter *APIKeyCredentials `json:"openrouter,omitempty"`
+	Azure      *APIKeyCredentials `json:"azure,omitempty"`
 }
 
 // CredentialPath returns the path to the credentials file (~/.autodoc/credentials.json).
@@ -110,6 +111,10 @@ func GetAPIKey(provider string) string {
 		if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
 			return key
 		}
+	case "azure":
+		if key := os.Getenv("AZURE_OPENAI_API_KEY"); key != "" {
+			return key
+		}
 	}
 
 	// Priority 2: Stored credentials.
@@ -139,6 +144,10 @@ func GetAPIKey(provider string) string {
 		if creds.OpenRouter != nil {
 			return creds.OpenRouter.APIKey
 		}
+	case "azure":
+		if creds.Azure != nil {
+			return creds.Azure.APIKey
+		}
 	}
 
 	return ""
@@ -0,0 +1,289 @@
+package awsauth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials sign requests. Temporary credentials carry a session token
+// and the time they expire.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero for long-lived keys
+}
+
+// Source supplies the credentials to sign each request with.
+type Source interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// Static is a Source of fixed credentials.
+type Static Credentials
+
+// Credentials returns s.
+func (s Static) Credentials(context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// ErrNoCredentials is returned when no link of the chain has credentials.
+var ErrNoCredentials = errors.New("no AWS credentials found")
+
+// refreshBefore is how long before temporary credentials expire they are
+// fetched again.
+const refreshBefore = 5 * time.Minute
+
+// Chain is a Source that looks for credentials where the AWS SDKs do, in
+// order:
+//
+//   - AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+//   - the AWS_PROFILE profile (or "default") of the shared credentials
+//     file, ~/.aws/credentials or AWS_SHARED_CREDENTIALS_FILE
+//   - the container credentials endpoint of an ECS task or EKS pod, from
+//     AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or _FULL_URI
+//   - the role of the EC2 instance, from the instance metadata service,
+//     unless AWS_EC2_METADATA_DISABLED is true
+//
+// Temporary credentials are kept until shortly before they expire.
+type Chain struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	cached Credentials
+}
+
+// NewChain returns a Chain.
+func NewChain() *Chain {
+	return &Chain{client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+// Credentials returns the first credentials found.
+func (c *Chain) Credentials(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached.AccessKeyID != "" && (c.cached.Expires.IsZero() || time.Until(c.cached.Expires) > refreshBefore) {
+		return c.cached, nil
+	}
+
+	links := []func(context.Context) (Credentials, error){
+		fromEnv, fromSharedFile, c.fromContainer, c.fromInstance,
+	}
+	for _, link := range links {
+		creds, err := link(ctx)
+		if err != nil {
+			return Credentials{}, err
+		}
+		if creds.AccessKeyID != "" {
+			c.cached = creds
+			return creds, nil
+		}
+	}
+	return Credentials{}, ErrNoCredentials
+}
+
+func fromEnv(context.Context) (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, nil
+	}
+	return creds, nil
+}
+
+// fromSharedFile reads the profile's keys from the shared credentials file.
+// A missing file or profile is not an error; a profile without both keys is.
+func fromSharedFile(context.Context) (Credentials, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credentials{}, nil
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, nil
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("reading AWS credentials file: %w", err)
+	}
+	defer f.Close()
+
+	var creds Credentials
+	found, inProfile := false, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			found = found || inProfile
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inProfile || !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Credentials{}, fmt.Errorf("reading AWS credentials file: %w", err)
+	}
+	if found && (creds.AccessKeyID == "" || creds.SecretAccessKey == "") {
+		return Credentials{}, fmt.Errorf("profile %s in %s has no aws_access_key_id and aws_secret_access_key", profile, path)
+	}
+	return creds, nil
+}
+
+// fromContainer fetches the task or pod role's credentials.
+func (c *Chain) fromContainer(ctx context.Context) (Credentials, error) {
+	url := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		url = "http://169.254.170.2" + rel
+	}
+	if url == "" {
+		return Credentials{}, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("container credentials endpoint: %w", err)
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("reading container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	creds, err := c.fetchRoleCredentials(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("container credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// fromInstance fetches the EC2 instance role's credentials with IMDSv2.
+// Off EC2 the metadata service doesn't answer, which only means there are
+// no credentials here.
+func (c *Chain) fromInstance(ctx context.Context) (Credentials, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, nil
+	}
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("instance metadata: %w", err)
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "300")
+	token, err := c.get(req)
+	if err != nil {
+		return Credentials{}, nil
+	}
+
+	rolesURL := endpoint + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("instance metadata: %w", err)
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+	roles, err := c.get(req)
+	if err != nil {
+		// An instance without a role has no credentials to offer.
+		return Credentials{}, nil
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return Credentials{}, nil
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL+role, nil)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("instance metadata: %w", err)
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+	creds, err := c.fetchRoleCredentials(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("instance role %s credentials: %w", role, err)
+	}
+	return creds, nil
+}
+
+// fetchRoleCredentials decodes the JSON the container and instance
+// endpoints answer with.
+func (c *Chain) fetchRoleCredentials(req *http.Request) (Credentials, error) {
+	data, err := c.get(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var resp struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+		Expiration      time.Time
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Credentials{}, fmt.Errorf("decoding credentials: %w", err)
+	}
+	if resp.AccessKeyID == "" || resp.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("response has no AccessKeyId and SecretAccessKey")
+	}
+	return Credentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+		Expires:         resp.Expiration,
+	}, nil
+}
+
+// get returns the body of a 200 response.
+func (c *Chain) get(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return data, nil
+}
@@ -155,6 +155,22 @@ func TestProcessorIntentDefault(t *testing.T) {
 	}
 }
 
+func TestProcessorIntentIncident(t *testing.T) {
+	p := NewProcessor(nil, nil)
+	msg := IncomingMessage{
+		Platform:  PlatformSlack,
+		ChannelID: "C123",
+		Text:      "incident orders POST /api/orders",
+	}
+	resp, err := p.HandleMessage(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Text, "incident bundles not configured") {
+		t.Errorf("expected error about incident bundles, got: %s", resp.Text)
+	}
+}
+
 func TestProcessorEmptyMessage(t *testing.T) {
 	p := NewProcessor(nil, nil)
 	msg := IncomingMessage{
@@ -380,6 +396,33 @@ func TestSlackSignatureVerification(t *testing.T) {
 	}
 }
 
+func TestSlackSlashCommand(t *testing.T) {
+	mock := &mockHandler{}
+	gw := NewGateway(mock)
+	handler := NewSlackHandler(gw, "")
+
+	form := "command=%2Fincident&text=orders&channel_id=C1&user_id=U1&user_name=dev"
+	req := httptest.NewRequest(http.MethodPost, "/api/bots/slack/commands", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.HandleCommand(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if mock.lastMsg.Text != "incident orders" || mock.lastMsg.ChannelID != "C1" {
+		t.Errorf("handler got %+v", mock.lastMsg)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["response_type"] != "in_channel" || resp["text"] != "mock response" {
+		t.Errorf("response = %v", resp)
+	}
+}
+
 // --- Teams handler tests ---
 
 func TestTeamsMessageActivity(t *testing.T) {
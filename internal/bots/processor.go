@@ -7,12 +7,14 @@ import (
 
 	"github.com/ziadkadry99/auto-doc/internal/backlog"
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/incident"
 )
 
 // Processor connects incoming bot messages to the context engine and backlog.
 type Processor struct {
 	ctxEngine    *contextengine.Engine
 	backlogStore *backlog.Store
+	incidents    *incident.Builder
 }
 
 // NewProcessor creates a new message processor.
@@ -23,11 +25,17 @@ func NewProcessor(engine *contextengine.Engine, backlogStore *backlog.Store) *Pr
 	}
 }
 
+// SetIncidentBuilder enables the "incident" command.
+func (p *Processor) SetIncidentBuilder(b *incident.Builder) {
+	p.incidents = b
+}
+
 // HandleMessage processes an incoming message and returns a response.
 // It detects intent from the message text:
 //   - "ask " or "?" prefix -> use engine.AskQuestion
 //   - "context " or "info " prefix -> use engine.ProcessInput
 //   - "questions" or "backlog" -> return top priority questions
+//   - "incident " prefix -> return the incident bundle for a service or endpoint
 //   - default -> use engine.ProcessInput (treat as context provision)
 func (p *Processor) HandleMessage(ctx context.Context, msg IncomingMessage) (*OutgoingMessage, error) {
 	text := strings.TrimSpace(msg.Text)
@@ -64,6 +72,9 @@ func (p *Processor) HandleMessage(ctx context.Context, msg IncomingMessage) (*Ou
 	case lower == "questions" || lower == "backlog":
 		responseText, err = p.handleBacklog(ctx)
 
+	case strings.HasPrefix(lower, "incident "):
+		responseText, err = p.handleIncident(ctx, msg.Platform, strings.TrimSpace(text[9:]))
+
 	default:
 		responseText, err = p.handleContext(ctx, msg, text)
 	}
@@ -121,3 +132,28 @@ func (p *Processor) handleBacklog(ctx context.Context) (string, error) {
 	}
 	return b.String(), nil
 }
+
+// httpMethods start an endpoint, as in "incident POST /api/orders".
+var httpMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true, "OPTIONS": true,
+}
+
+// handleIncident answers "incident <service> [endpoint]" or
+// "incident <endpoint>".
+func (p *Processor) handleIncident(ctx context.Context, platform Platform, args string) (string, error) {
+	if p.incidents == nil {
+		return "", fmt.Errorf("incident bundles not configured")
+	}
+	target, endpoint := args, ""
+	if first, rest, ok := strings.Cut(args, " "); ok && !httpMethods[strings.ToUpper(first)] && !strings.HasPrefix(first, "/") {
+		target, endpoint = first, strings.TrimSpace(rest)
+	}
+	bundle, err := p.incidents.Build(ctx, target, endpoint)
+	if err != nil {
+		return "", err
+	}
+	if platform == PlatformSlack {
+		return bundle.Slack(), nil
+	}
+	return bundle.Markdown(), nil
+}
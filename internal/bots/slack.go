@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -110,6 +111,53 @@ func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleCommand handles Slack slash commands (HTTP POST, form-encoded). The
+// command name becomes the first word of the message, so "/incident orders"
+// is handled like the message "incident orders"; a command named "/autodoc"
+// passes its text through unchanged.
+func (h *SlackHandler) HandleCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if h.signingSecret != "" {
+		if !h.verifySignature(r, body) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(form.Get("text"))
+	if command := strings.TrimPrefix(form.Get("command"), "/"); command != "" && command != "autodoc" {
+		text = strings.TrimSpace(command + " " + text)
+	}
+
+	msg := IncomingMessage{
+		Platform:  PlatformSlack,
+		ChannelID: form.Get("channel_id"),
+		UserID:    form.Get("user_id"),
+		UserName:  form.Get("user_name"),
+		Text:      text,
+	}
+	resp, err := h.gateway.Process(r.Context(), msg)
+	if err != nil {
+		http.Error(w, "processing error", http.StatusInternalServerError)
+		return
+	}
+
+	// Answer in the channel, so everyone on the incident sees it.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"response_type": "in_channel", "text": resp.Text})
+}
+
 // verifySignature verifies the Slack request signature using HMAC-SHA256.
 func (h *SlackHandler) verifySignature(r *http.Request, body []byte) bool {
 	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
@@ -3,7 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
@@ -57,12 +61,17 @@ func (c *Config) Save(path string) error {
 
 // validProviders is the set of recognized provider values.
 var validProviders = map[ProviderType]bool{
-	ProviderAnthropic: true,
-	ProviderOpenAI:    true,
-	ProviderGoogle:    true,
-	ProviderOllama:    true,
+	ProviderAnthropic:  true,
+	ProviderOpenAI:     true,
+	ProviderGoogle:     true,
+	ProviderOllama:     true,
 	ProviderMiniMax:    true,
 	ProviderOpenRouter: true,
+	ProviderMock:       true,
+
+	ProviderAzure:            true,
+	ProviderBedrock:          true,
+	ProviderOpenAICompatible: true,
 }
 
 // validQualityTiers is the set of recognized quality tier values.
@@ -78,21 +87,36 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("provider is required")
 	}
 	if !validProviders[c.Provider] {
-		return fmt.Errorf("invalid provider %q: must be one of anthropic, openai, google, ollama, minimax, openrouter", c.Provider)
+		return fmt.Errorf("invalid provider %q: must be one of anthropic, openai, google, ollama, minimax, openrouter, azure, bedrock, openai-compatible, mock", c.Provider)
 	}
 
 	if c.Model == "" {
 		return fmt.Errorf("model is required")
 	}
 
-	if c.EmbeddingProvider != "" && !validProviders[c.EmbeddingProvider] {
+	for task := range c.Models {
+		if !slices.Contains(Tasks, task) {
+			return fmt.Errorf("models: unknown task %q (want one of %s)", task, strings.Join(Tasks, ", "))
+		}
+	}
+
+	if c.EmbeddingProvider != "" && c.EmbeddingProvider != ProviderLocal && !validProviders[c.EmbeddingProvider] {
 		return fmt.Errorf("invalid embedding_provider %q", c.EmbeddingProvider)
 	}
+	if c.Providers.Local.Dimensions < 0 {
+		return fmt.Errorf("providers.local.dimensions must be non-negative")
+	}
 
 	if c.Quality != "" && !validQualityTiers[c.Quality] {
 		return fmt.Errorf("invalid quality %q: must be one of lite, normal, max", c.Quality)
 	}
 
+	switch c.Analyzer {
+	case "", AnalyzerLLM, AnalyzerStatic, AnalyzerCrossCheck:
+	default:
+		return fmt.Errorf("invalid analyzer %q: must be one of llm, static, crosscheck", c.Analyzer)
+	}
+
 	if c.OutputDir == "" {
 		return fmt.Errorf("output_dir is required")
 	}
@@ -105,9 +129,250 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_cost_usd must be non-negative")
 	}
 
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must be non-negative")
+	}
+
+	switch c.LLMCache.Mode {
+	case "", LLMCacheReadWrite, LLMCacheReplay, LLMCacheOff:
+	default:
+		return fmt.Errorf("invalid llm_cache.mode %q: must be one of readwrite, replay, off", c.LLMCache.Mode)
+	}
+	if c.LLMCache.MaxAgeDays < 0 {
+		return fmt.Errorf("llm_cache.max_age_days must be non-negative")
+	}
+
+	switch c.VectorStore.Backend {
+	case "", VectorStoreLocal, VectorStorePgvector, VectorStoreQdrant:
+	default:
+		return fmt.Errorf("invalid vector_store.backend %q: must be one of local, pgvector, qdrant", c.VectorStore.Backend)
+	}
+	if c.VectorStore.Collection != "" && !sqlIdentifier.MatchString(c.VectorStore.Collection) {
+		return fmt.Errorf("vector_store.collection %q must be letters, digits, and underscores", c.VectorStore.Collection)
+	}
+	switch c.Search.Mode {
+	case "", SearchHybrid, SearchVector:
+	default:
+		return fmt.Errorf("invalid search.mode %q: must be one of hybrid, vector", c.Search.Mode)
+	}
+	switch c.Search.Rerank {
+	case "", RerankNone, RerankLLM:
+	default:
+		return fmt.Errorf("invalid search.rerank %q: must be one of none, llm", c.Search.Rerank)
+	}
+
+	if err := c.LargeFiles.validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, svc := range c.Monorepo.Services {
+		switch {
+		case svc.Name == "" || svc.Path == "":
+			return fmt.Errorf("monorepo.services[%d]: name and path are required", i)
+		case strings.ContainsAny(svc.Name, "/\\ "):
+			return fmt.Errorf("monorepo.services[%d]: name %q must not contain slashes or spaces", i, svc.Name)
+		case filepath.IsAbs(svc.Path) || svc.Path == ".." || strings.HasPrefix(filepath.ToSlash(svc.Path), "../"):
+			return fmt.Errorf("monorepo.services[%d]: path %q must be inside the repository", i, svc.Path)
+		case seen[svc.Name]:
+			return fmt.Errorf("monorepo.services[%d]: duplicate service name %q", i, svc.Name)
+		}
+		seen[svc.Name] = true
+	}
+
+	if c.Artifacts.Keep < 0 {
+		return fmt.Errorf("artifacts.keep must be non-negative")
+	}
+	if c.Incident.ChangeDays < 0 {
+		return fmt.Errorf("incident.change_days must be non-negative")
+	}
+	if err := ValidateFlowTemplates(c.CentralSite.FlowTemplates); err != nil {
+		return fmt.Errorf("central_site.%w", err)
+	}
+	if d := c.CentralSite.HopLatency; d != "" {
+		if v, err := time.ParseDuration(d); err != nil || v <= 0 {
+			return fmt.Errorf("central_site.hop_latency: %q is not a positive duration such as 50ms", d)
+		}
+	}
+	for i, h := range c.CentralSite.LatencyHints {
+		if h.From == "" || h.To == "" {
+			return fmt.Errorf("central_site.latency_hints[%d]: from and to are required", i)
+		}
+		if v, err := time.ParseDuration(h.Latency); err != nil || v <= 0 {
+			return fmt.Errorf("central_site.latency_hints[%d]: latency %q is not a positive duration such as 120ms", i, h.Latency)
+		}
+	}
+
+	if err := c.Providers.validate(); err != nil {
+		return err
+	}
+
+	if err := c.I18n.validate(); err != nil {
+		return err
+	}
+
+	for i, p := range c.Scrub.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("scrub.patterns[%d]: %w", i, err)
+		}
+	}
+
+	for i, v := range c.Site.Views {
+		switch {
+		case v.Name == "":
+			return fmt.Errorf("site.views[%d]: name is required", i)
+		case v.Map != "" && v.Map != MapService && v.Map != MapComponent:
+			return fmt.Errorf("site.views[%d]: invalid map %q: must be service or component", i, v.Map)
+		}
+	}
+
+	if len(c.CentralSite.Review.Reviewers) > 0 && c.Auth.Provider == "" {
+		return fmt.Errorf("central_site.review.reviewers needs auth to be configured, so reviewers can be identified")
+	}
+
+	for i, w := range c.Site.Webhooks {
+		if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+			return fmt.Errorf("site.webhooks[%d]: url %q must be an http or https URL", i, w.URL)
+		}
+		if w.SecretEnv == "" {
+			return fmt.Errorf("site.webhooks[%d]: secret_env is required, so deliveries are signed", i)
+		}
+	}
+
+	switch c.Auth.Provider {
+	case "", "proxy":
+	case "oidc":
+		if c.Auth.IssuerURL == "" || c.Auth.ClientID == "" || c.Auth.RedirectURL == "" {
+			return fmt.Errorf("auth provider oidc requires issuer_url, client_id, and redirect_url")
+		}
+	default:
+		return fmt.Errorf("invalid auth provider %q: must be oidc or proxy", c.Auth.Provider)
+	}
+
+	return nil
+}
+
+// sqlIdentifier matches names that are safe as a Postgres table or a
+// Qdrant collection without quoting.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// languageCode matches language codes such as "ja", "pt-BR", or "zh-Hant".
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+func (c I18nConfig) validate() error {
+	if c.SourceLanguage != "" && !languageCode.MatchString(c.SourceLanguage) {
+		return fmt.Errorf("i18n.source_language: %q is not a language code such as en", c.SourceLanguage)
+	}
+	source := c.SourceLanguage
+	if source == "" {
+		source = "en"
+	}
+	seen := map[string]bool{source: true}
+	for i, lang := range c.Languages {
+		switch {
+		case !languageCode.MatchString(lang):
+			return fmt.Errorf("i18n.languages[%d]: %q is not a language code such as es or pt-BR", i, lang)
+		case seen[lang]:
+			return fmt.Errorf("i18n.languages[%d]: %q is listed twice, or is the source language", i, lang)
+		}
+		seen[lang] = true
+	}
 	return nil
 }
 
+func (c ProvidersConfig) validate() error {
+	rates := []struct {
+		name string
+		rpm  int
+	}{
+		{"azure", c.Azure.RequestsPerMinute},
+		{"bedrock", c.Bedrock.RequestsPerMinute},
+		{"openai_compatible", c.OpenAICompatible.RequestsPerMinute},
+		{"ollama", c.Ollama.RequestsPerMinute},
+	}
+	for _, r := range rates {
+		if r.rpm < 0 {
+			return fmt.Errorf("providers.%s.requests_per_minute must be non-negative", r.name)
+		}
+	}
+	seen := make(map[string]bool)
+	for i, d := range c.Azure.Deployments {
+		switch {
+		case d.Model == "" || d.Deployment == "":
+			return fmt.Errorf("providers.azure.deployments[%d]: model and deployment are required", i)
+		case seen[d.Model]:
+			return fmt.Errorf("providers.azure.deployments[%d]: model %q is listed twice", i, d.Model)
+		}
+		seen[d.Model] = true
+	}
+	for i, p := range c.Pricing {
+		if p.Model == "" {
+			return fmt.Errorf("providers.pricing[%d]: model is required", i)
+		}
+		if p.InputPerMillion < 0 || p.OutputPerMillion < 0 {
+			return fmt.Errorf("providers.pricing[%d]: prices must be non-negative", i)
+		}
+	}
+	return nil
+}
+
+// ValidateFlowTemplates checks that every flow template names its flow and
+// orchestrator, and that every phase matches some service.
+func ValidateFlowTemplates(templates []FlowTemplateConfig) error {
+	for i, t := range templates {
+		if strings.TrimSpace(t.Name) == "" || strings.TrimSpace(t.Orchestrator) == "" {
+			return fmt.Errorf("flow_templates[%d]: name and orchestrator are required", i)
+		}
+		for j, p := range t.Phases {
+			if strings.TrimSpace(p.Name) == "" || len(p.Services) == 0 {
+				return fmt.Errorf("flow_templates[%d].phases[%d]: name and services are required", i, j)
+			}
+		}
+		if t.Budget != "" {
+			if v, err := time.ParseDuration(t.Budget); err != nil || v <= 0 {
+				return fmt.Errorf("flow_templates[%d]: budget %q is not a positive duration such as 800ms", i, t.Budget)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFlowTemplates reads the flow_templates list from a YAML file.
+func LoadFlowTemplates(path string) ([]FlowTemplateConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		FlowTemplates []FlowTemplateConfig `yaml:"flow_templates"`
+	}
+	if err := yamlv3.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := ValidateFlowTemplates(file.FlowTemplates); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file.FlowTemplates, nil
+}
+
+// ModelFor returns the model to use for a task: the one Models routes it
+// to, or else the model for the task's older setting (i18n.model for
+// translation, embedding_model for embeddings), or else Model.
+func (c *Config) ModelFor(task string) string {
+	if m := c.Models[task]; m != "" {
+		return m
+	}
+	switch task {
+	case TaskTranslation:
+		if c.I18n.Model != "" {
+			return c.I18n.Model
+		}
+	case TaskEmbedding:
+		return c.EmbeddingModel
+	}
+	return c.Model
+}
+
 // APIKeyEnvVar returns the conventional environment variable name for
 // the API key of the given provider.
 func APIKeyEnvVar(provider ProviderType) string {
@@ -122,6 +387,10 @@ func APIKeyEnvVar(provider ProviderType) string {
 		return "MINIMAX_API_KEY"
 	case ProviderOpenRouter:
 		return "OPENROUTER_API_KEY"
+	case ProviderAzure:
+		return "AZURE_OPENAI_API_KEY"
+	case ProviderBedrock:
+		return "AWS_BEARER_TOKEN_BEDROCK"
 	default:
 		return ""
 	}
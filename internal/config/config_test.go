@@ -71,6 +71,31 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestLoadSLOs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".autodoc.yml")
+	yml := `provider: anthropic
+model: claude-sonnet-4-5-20250929
+quality: normal
+output_dir: .autodoc
+slos:
+  - service: payments
+    objective: "99.95% p99=250ms"
+  - service: payments
+    endpoint: POST /charges
+    availability: "99.99%"
+`
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.SLOs) != 2 || cfg.SLOs[1].Endpoint != "POST /charges" || cfg.SLOs[1].Availability != "99.99%" {
+		t.Errorf("slos = %+v", cfg.SLOs)
+	}
+}
+
 func TestLoadMissingFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "nonexistent.yml")
@@ -146,6 +171,18 @@ func TestValidateInvalidQuality(t *testing.T) {
 	}
 }
 
+func TestValidateInvalidAnalyzer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Analyzer = "treesitter"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for invalid analyzer")
+	}
+	cfg.Analyzer = AnalyzerStatic
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("static analyzer: %v", err)
+	}
+}
+
 func TestValidateEmptyOutputDir(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.OutputDir = ""
@@ -170,6 +207,95 @@ func TestValidateNegativeCost(t *testing.T) {
 	}
 }
 
+func TestModelFor(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Model = "main"
+	cfg.EmbeddingModel = "embed"
+	cfg.I18n.Model = "translate"
+	cfg.Models = map[string]string{TaskAnalysis: "cheap"}
+
+	tests := map[string]string{
+		TaskAnalysis:     "cheap",
+		TaskArchitecture: "main",
+		TaskTranslation:  "translate",
+		TaskEmbedding:    "embed",
+	}
+	for task, want := range tests {
+		if got := cfg.ModelFor(task); got != want {
+			t.Errorf("ModelFor(%q) = %q, want %q", task, got, want)
+		}
+	}
+
+	cfg.I18n.Model = ""
+	if got := cfg.ModelFor(TaskTranslation); got != "main" {
+		t.Errorf("translation without i18n.model = %q, want main", got)
+	}
+}
+
+func TestValidateUnknownTaskModel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Models = map[string]string{"summaries": "m"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for an unknown task in models")
+	}
+}
+
+func TestValidateLLMCacheMode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LLMCache.Mode = LLMCacheReplay
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("replay mode: %v", err)
+	}
+	cfg.LLMCache.Mode = "record"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for an unknown llm_cache.mode")
+	}
+}
+
+func TestValidateLocalEmbeddingProvider(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EmbeddingProvider = ProviderLocal
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("local embedding_provider: %v", err)
+	}
+	cfg.Provider = ProviderLocal
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for local as the LLM provider")
+	}
+}
+
+func TestValidateVectorStore(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.VectorStore = VectorStoreConfig{Backend: VectorStorePgvector, Collection: "team_docs"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("pgvector backend: %v", err)
+	}
+	cfg.VectorStore.Collection = "docs; DROP TABLE users"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for a collection that isn't an identifier")
+	}
+	cfg.VectorStore = VectorStoreConfig{Backend: "weaviate"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for an unknown backend")
+	}
+}
+
+func TestValidateSearch(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Search = SearchConfig{Mode: SearchVector, Rerank: RerankLLM}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("vector search with LLM reranking: %v", err)
+	}
+	cfg.Search = SearchConfig{Mode: "bm25"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for an unknown search mode")
+	}
+	cfg.Search = SearchConfig{Rerank: "cohere"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for an unknown reranker")
+	}
+}
+
 func TestGetPreset(t *testing.T) {
 	p := GetPreset(ProviderAnthropic, QualityLite)
 	if p.Model != "claude-haiku-4-5-20251001" {
@@ -230,3 +356,186 @@ func TestSplitAndTrim(t *testing.T) {
 		}
 	}
 }
+
+func TestLargeFilesThresholds(t *testing.T) {
+	var lf LargeFilesConfig
+	if s, st := lf.Thresholds("a.js"); s != DefaultSampleAbove || st != DefaultStubAbove {
+		t.Errorf("defaults = %d, %d", s, st)
+	}
+	lf = LargeFilesConfig{
+		SampleAbove: "100KB",
+		Limits: []FileSizeLimit{
+			{Pattern: "db/dumps/**", StubAbove: "0"},
+			{Pattern: "**/*.sql", SampleAbove: "1.5MB"},
+		},
+	}
+	if s, st := lf.Thresholds("src/app.js"); s != 100<<10 || st != DefaultStubAbove {
+		t.Errorf("src/app.js = %d, %d", s, st)
+	}
+	if s, st := lf.Thresholds("db/dumps/prod.sql"); s != 3<<19 || st != 0 {
+		t.Errorf("db/dumps/prod.sql = %d, %d", s, st)
+	}
+	if lf.IgnoreSize() != DefaultIgnoreAbove {
+		t.Errorf("IgnoreSize = %d", lf.IgnoreSize())
+	}
+
+	cfg := DefaultConfig()
+	cfg.LargeFiles.StubAbove = "4 parsecs"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for invalid size")
+	}
+	cfg.LargeFiles = LargeFilesConfig{Limits: []FileSizeLimit{{StubAbove: "1MB"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for limit without pattern")
+	}
+}
+
+func TestValidateMonorepo(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Monorepo.Services = []SubServiceConfig{
+		{Name: "orders", Path: "services/orders"},
+		{Name: "billing", Path: "services/billing"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid monorepo: %v", err)
+	}
+	for _, bad := range []SubServiceConfig{
+		{Name: "orders", Path: "services/dup"},
+		{Name: "web", Path: "../web"},
+		{Name: "a/b", Path: "a/b"},
+		{Name: "empty"},
+	} {
+		cfg.Monorepo.Services = []SubServiceConfig{{Name: "orders", Path: "services/orders"}, bad}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected validation error for %+v", bad)
+		}
+	}
+}
+
+func TestLoadFlowTemplates(t *testing.T) {
+	templates, err := LoadFlowTemplates(filepath.Join("..", "..", "examples", "flow-templates", "train-ticket.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 9 || templates[0].Orchestrator != "preserve-service" || len(templates[0].Phases) != 6 {
+		t.Errorf("train-ticket templates = %+v", templates)
+	}
+
+	cfg := DefaultConfig()
+	cfg.CentralSite.FlowTemplates = []FlowTemplateConfig{{Name: "Checkout", Phases: []FlowPhaseConfig{{Name: "Pay"}}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("a template without an orchestrator should fail validation")
+	}
+	cfg.CentralSite.FlowTemplates[0].Orchestrator = "checkout"
+	if err := cfg.Validate(); err == nil {
+		t.Error("a phase without services should fail validation")
+	}
+	cfg.CentralSite.FlowTemplates[0].Phases[0].Services = []string{"payment"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid template: %v", err)
+	}
+	cfg.CentralSite.FlowTemplates[0].Budget = "fast"
+	if err := cfg.Validate(); err == nil {
+		t.Error("a budget that isn't a duration should fail validation")
+	}
+	cfg.CentralSite.FlowTemplates[0].Budget = "800ms"
+	cfg.CentralSite.LatencyHints = []LatencyHintConfig{{From: "orders", To: "payments", Latency: "-5ms"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("a negative latency hint should fail validation")
+	}
+	cfg.CentralSite.LatencyHints[0].Latency = "180ms"
+	cfg.CentralSite.HopLatency = "30ms"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid latency settings: %v", err)
+	}
+}
+
+func TestValidateProviders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Provider = ProviderBedrock
+	cfg.Model = GetPreset(ProviderBedrock, QualityNormal).Model
+	cfg.Providers.Azure.Deployments = []AzureDeployment{{Model: "gpt-4.1", Deployment: "docs"}}
+	cfg.Providers.Pricing = []ModelPricing{{Model: "my-model", InputPerMillion: 1, OutputPerMillion: 2}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid providers: %v", err)
+	}
+	bad := []func(c *ProvidersConfig){
+		func(c *ProvidersConfig) { c.Bedrock.RequestsPerMinute = -1 },
+		func(c *ProvidersConfig) { c.Azure.Deployments = append(c.Azure.Deployments, AzureDeployment{Model: "gpt-4.1", Deployment: "other"}) },
+		func(c *ProvidersConfig) { c.Azure.Deployments = []AzureDeployment{{Model: "gpt-4o"}} },
+		func(c *ProvidersConfig) { c.Pricing = []ModelPricing{{Model: "m", InputPerMillion: -1}} },
+	}
+	for i, mutate := range bad {
+		c := *cfg
+		c.Providers.Azure.Deployments = append([]AzureDeployment(nil), cfg.Providers.Azure.Deployments...)
+		mutate(&c.Providers)
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d should fail validation", i)
+		}
+	}
+}
+
+func TestValidateI18n(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.I18n.Languages = []string{"es", "pt-BR", "zh-Hant"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid languages: %v", err)
+	}
+	for _, langs := range [][]string{{"Spanish"}, {"es", "es"}, {"en"}} {
+		cfg.I18n.Languages = langs
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("languages %v should fail validation", langs)
+		}
+	}
+	cfg.I18n.SourceLanguage = "de"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("en is a target once de is the source: %v", err)
+	}
+}
+
+func TestValidateReviewers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CentralSite.Review = ReviewConfig{Enabled: true, Reviewers: []string{"docs-approvers"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("reviewers without auth should fail validation")
+	}
+	cfg.Auth.Provider = "proxy"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("reviewers with auth: %v", err)
+	}
+}
+
+func TestValidateSiteWebhooks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Site.Webhooks = []SiteWebhookConfig{{URL: "https://portal.example.com/hooks/autodoc", SecretEnv: "PORTAL_WEBHOOK_SECRET"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid webhook: %v", err)
+	}
+	for _, url := range []string{"", "portal.example.com/hooks"} {
+		cfg.Site.Webhooks = []SiteWebhookConfig{{URL: url, SecretEnv: "PORTAL_WEBHOOK_SECRET"}}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("webhook url %q should fail validation", url)
+		}
+	}
+	cfg.Site.Webhooks = []SiteWebhookConfig{{URL: "https://portal.example.com/hooks/autodoc"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("a webhook without secret_env should fail validation")
+	}
+}
+
+func TestValidateSiteViews(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Site.Views = []SavedViewConfig{
+		{Name: "Payments slice", State: "group=domain%3Dpayments"},
+		{Name: "Parser", Map: MapComponent, State: "node=internal%2Fparser%2Fparser.go"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid views: %v", err)
+	}
+	for _, v := range []SavedViewConfig{{State: "node=web"}, {Name: "Web", Map: "services"}} {
+		cfg.Site.Views = []SavedViewConfig{v}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("view %+v should fail validation", v)
+		}
+	}
+}
@@ -38,6 +38,26 @@ var qualityPresets = map[ProviderType]map[QualityTier]QualityPreset{
 		QualityNormal: {Model: "minimax/minimax-m2.5", EmbeddingModel: "text-embedding-3-small"},
 		QualityMax:    {Model: "minimax/minimax-m2.5", EmbeddingModel: "text-embedding-3-large"},
 	},
+	ProviderAzure: {
+		QualityLite:   {Model: "gpt-4o-mini", EmbeddingModel: "text-embedding-3-small"},
+		QualityNormal: {Model: "gpt-4o", EmbeddingModel: "text-embedding-3-small"},
+		QualityMax:    {Model: "gpt-4o", EmbeddingModel: "text-embedding-3-large"},
+	},
+	ProviderBedrock: {
+		QualityLite:   {Model: "us.anthropic.claude-haiku-4-5-20251001-v1:0", EmbeddingModel: "text-embedding-3-small"},
+		QualityNormal: {Model: "us.anthropic.claude-sonnet-4-5-20250929-v1:0", EmbeddingModel: "text-embedding-3-small"},
+		QualityMax:    {Model: "us.anthropic.claude-opus-4-1-20250805-v1:0", EmbeddingModel: "text-embedding-3-large"},
+	},
+	ProviderOpenAICompatible: {
+		QualityLite:   {Model: "meta-llama/Llama-3.1-8B-Instruct", EmbeddingModel: "text-embedding-3-small"},
+		QualityNormal: {Model: "meta-llama/Llama-3.1-8B-Instruct", EmbeddingModel: "text-embedding-3-small"},
+		QualityMax:    {Model: "meta-llama/Llama-3.1-70B-Instruct", EmbeddingModel: "text-embedding-3-large"},
+	},
+	ProviderMock: {
+		QualityLite:   {Model: "mock", EmbeddingModel: "mock"},
+		QualityNormal: {Model: "mock", EmbeddingModel: "mock"},
+		QualityMax:    {Model: "mock", EmbeddingModel: "mock"},
+	},
 }
 
 // DefaultExcludes are glob patterns excluded from analysis by default.
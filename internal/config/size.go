@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Default large-file thresholds, in bytes.
+const (
+	DefaultSampleAbove int64 = 256 << 10
+	DefaultStubAbove   int64 = 4 << 20
+	DefaultIgnoreAbove int64 = 64 << 20
+)
+
+// ParseSize parses a byte size such as "512", "256KB", "4MB", or "1GB".
+// Units are binary (1KB = 1024 bytes) and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// sizeOr parses s, falling back to def when s is empty or invalid. Invalid
+// sizes are rejected by Validate.
+func sizeOr(s string, def int64) int64 {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	n, err := ParseSize(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Thresholds returns the sample and stub thresholds for a file at relPath.
+// Files larger than sample are analyzed from a sample of their content;
+// files larger than stub are not analyzed at all.
+func (c LargeFilesConfig) Thresholds(relPath string) (sample, stub int64) {
+	sample = sizeOr(c.SampleAbove, DefaultSampleAbove)
+	stub = sizeOr(c.StubAbove, DefaultStubAbove)
+	for _, l := range c.Limits {
+		if ok, _ := doublestar.Match(l.Pattern, relPath); !ok {
+			continue
+		}
+		sample = sizeOr(l.SampleAbove, sample)
+		stub = sizeOr(l.StubAbove, stub)
+	}
+	return sample, stub
+}
+
+// IgnoreSize returns the size above which files are left out of the index.
+func (c LargeFilesConfig) IgnoreSize() int64 {
+	return sizeOr(c.IgnoreAbove, DefaultIgnoreAbove)
+}
+
+func (c LargeFilesConfig) validate() error {
+	for _, s := range [][2]string{
+		{"sample_above", c.SampleAbove},
+		{"stub_above", c.StubAbove},
+		{"ignore_above", c.IgnoreAbove},
+	} {
+		if s[1] == "" {
+			continue
+		}
+		if _, err := ParseSize(s[1]); err != nil {
+			return fmt.Errorf("large_files.%s: %w", s[0], err)
+		}
+	}
+	for i, l := range c.Limits {
+		if l.Pattern == "" {
+			return fmt.Errorf("large_files.limits[%d]: pattern is required", i)
+		}
+		if !doublestar.ValidatePattern(l.Pattern) {
+			return fmt.Errorf("large_files.limits[%d]: invalid pattern %q", i, l.Pattern)
+		}
+		for _, s := range [][2]string{{"sample_above", l.SampleAbove}, {"stub_above", l.StubAbove}} {
+			if s[1] == "" {
+				continue
+			}
+			if _, err := ParseSize(s[1]); err != nil {
+				return fmt.Errorf("large_files.limits[%d].%s: %w", i, s[0], err)
+			}
+		}
+	}
+	return nil
+}
@@ -9,33 +9,97 @@ const (
 	QualityMax    QualityTier = "max"
 )
 
+// AnalyzerMode selects how source files are analyzed during indexing.
+type AnalyzerMode string
+
+const (
+	AnalyzerLLM        AnalyzerMode = "llm"        // the LLM analyzes every file (default)
+	AnalyzerStatic     AnalyzerMode = "static"     // language parsers only; no LLM calls
+	AnalyzerCrossCheck AnalyzerMode = "crosscheck" // LLM analysis checked against the parsers
+)
+
 // ProviderType identifies an LLM provider.
 type ProviderType string
 
 const (
-	ProviderAnthropic ProviderType = "anthropic"
-	ProviderOpenAI    ProviderType = "openai"
-	ProviderGoogle    ProviderType = "google"
-	ProviderOllama    ProviderType = "ollama"
+	ProviderAnthropic  ProviderType = "anthropic"
+	ProviderOpenAI     ProviderType = "openai"
+	ProviderGoogle     ProviderType = "google"
+	ProviderOllama     ProviderType = "ollama"
 	ProviderMiniMax    ProviderType = "minimax"
 	ProviderOpenRouter ProviderType = "openrouter"
+	ProviderMock       ProviderType = "mock" // answers locally without an API; for demos and CI
+
+	ProviderAzure            ProviderType = "azure"             // Azure OpenAI, routed to deployments
+	ProviderBedrock          ProviderType = "bedrock"           // AWS Bedrock, through the Converse API
+	ProviderOpenAICompatible ProviderType = "openai-compatible" // a local server such as vLLM, LM Studio, or Ollama's /v1
+
+	// ProviderLocal runs an embedding model on this machine; it is only an
+	// embedding_provider.
+	ProviderLocal ProviderType = "local"
+)
+
+// Tasks that can be routed to their own model with Config.Models.
+const (
+	TaskAnalysis     = "analysis"     // per-file analysis
+	TaskOverview     = "overview"     // directory overviews, the home page, regeneration decisions
+	TaskArchitecture = "architecture" // the architecture overview
+	TaskFlows        = "flows"        // flow narratives on the central site
+	TaskLinks        = "links"        // cross-repo link discovery
+	TaskQA           = "qa"           // answers in search, chat, and the API
+	TaskTranslation  = "translation"  // translating the site
+	TaskEmbedding    = "embedding"    // embeddings, from the embedding provider
+	TaskRerank       = "rerank"       // reranking search results, with search.rerank: llm
 )
 
+// Tasks lists every task, in the order usage is reported.
+var Tasks = []string{TaskAnalysis, TaskOverview, TaskArchitecture, TaskFlows, TaskLinks, TaskQA, TaskRerank, TaskTranslation, TaskEmbedding}
+
 // Config is the top-level autodoc configuration, corresponding to .autodoc.yml.
 type Config struct {
-	Provider          ProviderType `yaml:"provider" koanf:"provider"`
-	Model             string       `yaml:"model" koanf:"model"`
-	EmbeddingProvider ProviderType `yaml:"embedding_provider" koanf:"embedding_provider"`
-	EmbeddingModel    string       `yaml:"embedding_model" koanf:"embedding_model"`
-	Quality           QualityTier  `yaml:"quality" koanf:"quality"`
-	OutputDir         string       `yaml:"output_dir" koanf:"output_dir"`
-	Logo              string       `yaml:"logo" koanf:"logo"`
-	Include           []string     `yaml:"include" koanf:"include"`
-	Exclude           []string     `yaml:"exclude" koanf:"exclude"`
-	ContextFile       string       `yaml:"context_file" koanf:"context_file"`
-	CI                CIConfig     `yaml:"ci" koanf:"ci"`
-	MaxConcurrency    int          `yaml:"max_concurrency" koanf:"max_concurrency"`
-	MaxCostUSD        float64      `yaml:"max_cost_usd" koanf:"max_cost_usd"`
+	Provider          ProviderType         `yaml:"provider" koanf:"provider"`
+	Model             string               `yaml:"model" koanf:"model"`
+	Models            map[string]string    `yaml:"models,omitempty" koanf:"models"` // task → model, overriding Model; see ModelFor
+	EmbeddingProvider ProviderType         `yaml:"embedding_provider" koanf:"embedding_provider"`
+	EmbeddingModel    string               `yaml:"embedding_model" koanf:"embedding_model"`
+	Quality           QualityTier          `yaml:"quality" koanf:"quality"`
+	Analyzer          AnalyzerMode         `yaml:"analyzer,omitempty" koanf:"analyzer"`
+	OutputDir         string               `yaml:"output_dir" koanf:"output_dir"`
+	Logo              string               `yaml:"logo" koanf:"logo"`
+	Include           []string             `yaml:"include" koanf:"include"`
+	Exclude           []string             `yaml:"exclude" koanf:"exclude"`
+	Languages         map[string]bool      `yaml:"languages,omitempty" koanf:"languages"` // language → on/off; unlisted languages are on
+	ContextFile       string               `yaml:"context_file" koanf:"context_file"`
+	CI                CIConfig             `yaml:"ci" koanf:"ci"`
+	MaxConcurrency    int                  `yaml:"max_concurrency" koanf:"max_concurrency"`
+	MaxCostUSD        float64              `yaml:"max_cost_usd" koanf:"max_cost_usd"`
+	MaxTokens         int                  `yaml:"max_tokens,omitempty" koanf:"max_tokens"`
+	Export            ExportConfig         `yaml:"export,omitempty" koanf:"export"`
+	Auth              AuthConfig           `yaml:"auth,omitempty" koanf:"auth"`
+	CentralSite       CentralSiteConfig    `yaml:"central_site,omitempty" koanf:"central_site"`
+	Plugins           PluginsConfig        `yaml:"plugins,omitempty" koanf:"plugins"`
+	OnCall            OnCallConfig         `yaml:"oncall,omitempty" koanf:"oncall"`
+	Notifications     NotificationsConfig  `yaml:"notifications,omitempty" koanf:"notifications"`
+	SLOs              []SLOConfig          `yaml:"slos,omitempty" koanf:"slos"`
+	DataClasses       []DataClassConfig    `yaml:"data_classes,omitempty" koanf:"data_classes"`
+	Deprecations      []DeprecationConfig  `yaml:"deprecations,omitempty" koanf:"deprecations"`
+	Environments      EnvironmentsConfig   `yaml:"environments,omitempty" koanf:"environments"`
+	Traces            TracesConfig         `yaml:"traces,omitempty" koanf:"traces"`
+	Costs             CostsConfig          `yaml:"costs,omitempty" koanf:"costs"`
+	Metrics           MetricsConfig        `yaml:"metrics,omitempty" koanf:"metrics"`
+	SchemaRegistry    SchemaRegistryConfig `yaml:"schema_registry,omitempty" koanf:"schema_registry"`
+	LargeFiles        LargeFilesConfig     `yaml:"large_files,omitempty" koanf:"large_files"`
+	Scrub             ScrubConfig          `yaml:"scrub,omitempty" koanf:"scrub"`
+	Monorepo          MonorepoConfig       `yaml:"monorepo,omitempty" koanf:"monorepo"`
+	Artifacts         ArtifactsConfig      `yaml:"artifacts,omitempty" koanf:"artifacts"`
+	Incident          IncidentConfig       `yaml:"incident,omitempty" koanf:"incident"`
+	I18n              I18nConfig           `yaml:"i18n,omitempty" koanf:"i18n"`
+	Providers         ProvidersConfig      `yaml:"providers,omitempty" koanf:"providers"`
+	LLMCache          LLMCacheConfig       `yaml:"llm_cache,omitempty" koanf:"llm_cache"`
+	VectorStore       VectorStoreConfig    `yaml:"vector_store,omitempty" koanf:"vector_store"`
+	Search            SearchConfig         `yaml:"search,omitempty" koanf:"search"`
+	Daemon            DaemonConfig         `yaml:"daemon,omitempty" koanf:"daemon"`
+	Site              SiteConfig           `yaml:"site,omitempty" koanf:"site"`
 }
 
 // CIConfig holds CI-specific settings.
@@ -43,3 +107,738 @@ type CIConfig struct {
 	AutoCommit  bool `yaml:"auto_commit" koanf:"auto_commit"`
 	FailOnError bool `yaml:"fail_on_error" koanf:"fail_on_error"`
 }
+
+// ExportConfig controls the curated, customer-facing docs bundle produced by
+// `autodoc export`. Only whitelisted pages and sections are exported.
+type ExportConfig struct {
+	// Pages are glob patterns (relative to the docs directory) of pages to include.
+	Pages []string `yaml:"pages,omitempty" koanf:"pages"`
+	// Sections are the "##" headings to keep on exported pages. When empty,
+	// whole pages are exported.
+	Sections []string `yaml:"sections,omitempty" koanf:"sections"`
+	// Aliases maps internal names (services, teams) to public names. Keys must
+	// not contain dots, which the config loader treats as nesting.
+	Aliases map[string]string `yaml:"aliases,omitempty" koanf:"aliases"`
+}
+
+// I18nConfig publishes the documentation site in more languages. The
+// generated prose is translated by the configured LLM when the site is
+// built, and each language gets its own copy of the site in a subdirectory,
+// with its own search index and a language switcher on every page.
+type I18nConfig struct {
+	// SourceLanguage is the language the docs are generated in; defaults to "en".
+	SourceLanguage string `yaml:"source_language,omitempty" koanf:"source_language"`
+	// Languages are the codes of the languages to translate into, e.g. "es" or "pt-BR".
+	Languages []string `yaml:"languages,omitempty" koanf:"languages"`
+	// Model overrides the model used for translation.
+	Model string `yaml:"model,omitempty" koanf:"model"`
+}
+
+// LLM cache modes.
+const (
+	LLMCacheReadWrite = "readwrite" // answer repeated prompts from the cache and cache new answers
+	LLMCacheReplay    = "replay"    // answer only from the cache; a new prompt is an error
+	LLMCacheOff       = "off"
+)
+
+// LLMCacheConfig controls the on-disk cache of LLM responses, keyed by a
+// hash of the prompt.
+type LLMCacheConfig struct {
+	// Mode is readwrite (the default), replay, or off.
+	Mode string `yaml:"mode,omitempty" koanf:"mode"`
+	// Dir holds the cached responses; defaults to llm-cache in the output directory.
+	Dir string `yaml:"dir,omitempty" koanf:"dir"`
+	// MaxAgeDays drops responses no run has used for this many days; defaults to 30.
+	MaxAgeDays int `yaml:"max_age_days,omitempty" koanf:"max_age_days"`
+}
+
+// Vector store backends.
+const (
+	VectorStoreLocal    = "local"    // chromem-go, persisted in the output directory
+	VectorStorePgvector = "pgvector" // a Postgres table with the pgvector extension
+	VectorStoreQdrant   = "qdrant"
+)
+
+// VectorStoreConfig selects where the search index lives. The local store is
+// a file in the output directory; pgvector and Qdrant keep it on a server,
+// so several users and the central server can share one index.
+type VectorStoreConfig struct {
+	// Backend is local (the default), pgvector, or qdrant.
+	Backend string `yaml:"backend,omitempty" koanf:"backend"`
+	// URL is the Postgres connection URL, e.g.
+	// postgres://autodoc@db:5432/autodoc, or the Qdrant URL,
+	// e.g. http://qdrant:6333; falls back to AUTODOC_VECTOR_STORE_URL.
+	// Passwords can come from PGPASSWORD, and Qdrant API keys from QDRANT_API_KEY.
+	URL string `yaml:"url,omitempty" koanf:"url"`
+	// Collection is the Postgres table or Qdrant collection; defaults to autodoc_documents.
+	Collection string `yaml:"collection,omitempty" koanf:"collection"`
+}
+
+// Search modes.
+const (
+	SearchHybrid = "hybrid" // BM25 and vector similarity, fused by rank
+	SearchVector = "vector" // vector similarity only
+)
+
+// Search rerankers.
+const (
+	RerankNone = "none"
+	RerankLLM  = "llm"
+)
+
+// SearchConfig controls how queries are answered from the index, in
+// `autodoc query`, the MCP tools, and /api/search.
+type SearchConfig struct {
+	// Mode is hybrid (the default) or vector.
+	Mode string `yaml:"mode,omitempty" koanf:"mode"`
+	// Rerank is none (the default) or llm, which has the rerank task's
+	// model grade the top candidates.
+	Rerank string `yaml:"rerank,omitempty" koanf:"rerank"`
+}
+
+// ProvidersConfig holds what providers need beyond an API key: where to
+// reach them, how fast they may be called, and the prices of models the
+// built-in table doesn't know.
+type ProvidersConfig struct {
+	Azure            AzureConfig            `yaml:"azure,omitempty" koanf:"azure"`
+	Bedrock          BedrockConfig          `yaml:"bedrock,omitempty" koanf:"bedrock"`
+	OpenAICompatible OpenAICompatibleConfig `yaml:"openai_compatible,omitempty" koanf:"openai_compatible"`
+	Ollama           OllamaConfig           `yaml:"ollama,omitempty" koanf:"ollama"`
+	Local            LocalEmbeddingsConfig  `yaml:"local,omitempty" koanf:"local"`
+	// Pricing adds or overrides model prices, used for cost estimates and
+	// budgets.
+	Pricing []ModelPricing `yaml:"pricing,omitempty" koanf:"pricing"`
+}
+
+// AzureConfig configures Azure OpenAI. The API key comes from
+// AZURE_OPENAI_API_KEY or `autodoc auth azure`.
+type AzureConfig struct {
+	// Endpoint is the resource endpoint, e.g. https://my-resource.openai.azure.com;
+	// falls back to AZURE_OPENAI_ENDPOINT.
+	Endpoint   string `yaml:"endpoint,omitempty" koanf:"endpoint"`
+	APIVersion string `yaml:"api_version,omitempty" koanf:"api_version"` // defaults to 2024-10-21
+	// Deployments route models to the deployments serving them. A model
+	// without one is sent to the deployment named after it.
+	Deployments       []AzureDeployment `yaml:"deployments,omitempty" koanf:"deployments"`
+	RequestsPerMinute int               `yaml:"requests_per_minute,omitempty" koanf:"requests_per_minute"`
+}
+
+// AzureDeployment names the Azure deployment that serves a model. It is a
+// list entry rather than a map key because model names contain dots.
+type AzureDeployment struct {
+	Model      string `yaml:"model" koanf:"model"`
+	Deployment string `yaml:"deployment" koanf:"deployment"`
+}
+
+// BedrockConfig configures AWS Bedrock. Credentials come from the standard
+// AWS environment variables, or AWS_BEARER_TOKEN_BEDROCK for a Bedrock API key.
+type BedrockConfig struct {
+	Region            string `yaml:"region,omitempty" koanf:"region"` // falls back to AWS_REGION, then us-east-1
+	RequestsPerMinute int    `yaml:"requests_per_minute,omitempty" koanf:"requests_per_minute"`
+}
+
+// OpenAICompatibleConfig points the openai-compatible provider at a server
+// speaking the OpenAI Chat Completions API.
+type OpenAICompatibleConfig struct {
+	BaseURL           string `yaml:"base_url,omitempty" koanf:"base_url"` // defaults to http://localhost:8000/v1 (vLLM)
+	RequestsPerMinute int    `yaml:"requests_per_minute,omitempty" koanf:"requests_per_minute"`
+}
+
+// OllamaConfig configures the ollama provider.
+type OllamaConfig struct {
+	Host              string `yaml:"host,omitempty" koanf:"host"` // falls back to OLLAMA_HOST, then http://localhost:11434
+	RequestsPerMinute int    `yaml:"requests_per_minute,omitempty" koanf:"requests_per_minute"`
+}
+
+// LocalEmbeddingsConfig configures the local embedding provider, which runs
+// embedding_model (a sentence-transformers model name, or the path of a
+// model on disk) in a worker process.
+type LocalEmbeddingsConfig struct {
+	// Python is the interpreter for the built-in worker, which needs
+	// sentence-transformers, or onnxruntime and tokenizers for ONNX models;
+	// defaults to python3.
+	Python string `yaml:"python,omitempty" koanf:"python"`
+	// Command replaces the built-in worker with another program speaking
+	// its protocol; the model is appended as the last argument.
+	Command []string `yaml:"command,omitempty" koanf:"command"`
+	// Dimensions of the model's vectors; reported by the worker when unset.
+	Dimensions int `yaml:"dimensions,omitempty" koanf:"dimensions"`
+}
+
+// ModelPricing is a model's price in USD per million tokens.
+type ModelPricing struct {
+	Model            string  `yaml:"model" koanf:"model"`
+	InputPerMillion  float64 `yaml:"input_per_million" koanf:"input_per_million"`
+	OutputPerMillion float64 `yaml:"output_per_million" koanf:"output_per_million"`
+}
+
+// AuthConfig enables sign-in for the served documentation site
+// (`autodoc serve --http`) and the notifications API. Leaving Provider empty
+// disables authentication.
+type AuthConfig struct {
+	// Provider is "oidc" for an OpenID Connect identity provider, or "proxy"
+	// when a trusted reverse proxy (e.g. a SAML gateway) authenticates users
+	// and forwards their identity in headers.
+	Provider     string `yaml:"provider,omitempty" koanf:"provider"`
+	IssuerURL    string `yaml:"issuer_url,omitempty" koanf:"issuer_url"`
+	ClientID     string `yaml:"client_id,omitempty" koanf:"client_id"`
+	ClientSecret string `yaml:"client_secret,omitempty" koanf:"client_secret"` // falls back to AUTODOC_OIDC_CLIENT_SECRET
+	RedirectURL  string `yaml:"redirect_url,omitempty" koanf:"redirect_url"`   // e.g. https://docs.example.com/auth/callback
+	GroupsClaim  string `yaml:"groups_claim,omitempty" koanf:"groups_claim"`   // defaults to "groups"
+
+	UserHeader   string `yaml:"user_header,omitempty" koanf:"user_header"`     // proxy mode; defaults to X-Forwarded-User
+	GroupsHeader string `yaml:"groups_header,omitempty" koanf:"groups_header"` // proxy mode; defaults to X-Forwarded-Groups
+
+	// SessionSecret signs session cookies. Falls back to AUTODOC_SESSION_SECRET,
+	// then to a random per-process secret (sessions end on restart).
+	SessionSecret string `yaml:"session_secret,omitempty" koanf:"session_secret"`
+	// AllowedGroups, when set, restricts sign-in to members of these groups.
+	AllowedGroups []string `yaml:"allowed_groups,omitempty" koanf:"allowed_groups"`
+	// AdminGroups are the groups whose members may create and revoke API
+	// keys on `autodoc server`; without them key management is off.
+	AdminGroups []string `yaml:"admin_groups,omitempty" koanf:"admin_groups"`
+	// Access restricts repos' docs to members of certain groups. Repos not
+	// matched by any rule are visible to every signed-in user.
+	Access []RepoAccessRule `yaml:"access,omitempty" koanf:"access"`
+}
+
+// RepoAccessRule grants the listed groups access to repos matching the patterns.
+type RepoAccessRule struct {
+	Repos  []string `yaml:"repos" koanf:"repos"` // repo names or glob patterns
+	Groups []string `yaml:"groups" koanf:"groups"`
+}
+
+// SiteConfig controls how generated sites load D3 and Mermaid, how they
+// are branded, and whether validation failures fail the build.
+type SiteConfig struct {
+	// Offline vendors the libraries into the site instead of loading them
+	// from CDNs, for air-gapped networks.
+	Offline bool `yaml:"offline,omitempty" koanf:"offline"`
+	// AssetsDir is where the libraries are downloaded to once and read
+	// from after; defaults to <output_dir>/assets.
+	AssetsDir string `yaml:"assets_dir,omitempty" koanf:"assets_dir"`
+	// Theme brands the site's pages.
+	Theme ThemeConfig `yaml:"theme,omitempty" koanf:"theme"`
+	// Strict fails site generation when validation finds broken links,
+	// Mermaid diagrams that don't parse, or unregistered services.
+	Strict bool `yaml:"strict,omitempty" koanf:"strict"`
+	// Views are named states of the interactive maps, listed in their
+	// Views menus for everyone who reads the site.
+	Views []SavedViewConfig `yaml:"views,omitempty" koanf:"views"`
+	// Webhooks are told whenever a site is published, with what changed.
+	Webhooks []SiteWebhookConfig `yaml:"webhooks,omitempty" koanf:"webhooks"`
+}
+
+// SiteWebhookConfig is an endpoint POSTed a signed site.published event
+// after every site build:
+//
+//	site:
+//	  webhooks:
+//	    - url: https://portal.example.com/hooks/autodoc
+//	      secret_env: PORTAL_WEBHOOK_SECRET
+type SiteWebhookConfig struct {
+	URL string `yaml:"url" koanf:"url"`
+	// SecretEnv names the variable holding the key deliveries are signed
+	// with. It is required: unsigned deliveries are never sent.
+	SecretEnv string `yaml:"secret_env" koanf:"secret_env"`
+}
+
+// SavedViewConfig is a named state of an interactive map, as the map's
+// "Copy link" puts it in the URL hash:
+//
+//	site:
+//	  views:
+//	    - name: Payments slice
+//	      map: service
+//	      state: group=domain%3Dpayments&node=payments
+type SavedViewConfig struct {
+	Name string `yaml:"name" koanf:"name"`
+	// Map is "service" for the central site's service map (the default)
+	// or "component" for a repo's component map.
+	Map string `yaml:"map,omitempty" koanf:"map"`
+	// State is the URL hash, or a whole link to the view.
+	State string `yaml:"state" koanf:"state"`
+}
+
+// Interactive maps a saved view can belong to.
+const (
+	MapService   = "service"
+	MapComponent = "component"
+)
+
+// ThemeConfig brands generated sites without forking their templates:
+//
+//	site:
+//	  theme:
+//	    primary_color: "#0b5cad"
+//	    font: "Inter, sans-serif"
+//	    custom_css: branding/site.css
+//	    favicon: branding/favicon.png
+//	    footer: "© Example Corp — [Engineering handbook](https://handbook.example.com)"
+type ThemeConfig struct {
+	// PrimaryColor is the accent and link color; PrimaryColorDark is the
+	// same in dark mode and defaults to PrimaryColor.
+	PrimaryColor     string `yaml:"primary_color,omitempty" koanf:"primary_color"`
+	PrimaryColorDark string `yaml:"primary_color_dark,omitempty" koanf:"primary_color_dark"`
+	// Font and CodeFont are CSS font-family lists for text and code.
+	Font     string `yaml:"font,omitempty" koanf:"font"`
+	CodeFont string `yaml:"code_font,omitempty" koanf:"code_font"`
+	// CustomCSS and CustomJS are files loaded on every page after the
+	// built-in stylesheet and script.
+	CustomCSS string `yaml:"custom_css,omitempty" koanf:"custom_css"`
+	CustomJS  string `yaml:"custom_js,omitempty" koanf:"custom_js"`
+	Favicon   string `yaml:"favicon,omitempty" koanf:"favicon"`
+	// Footer is markdown shown at the foot of every page.
+	Footer string `yaml:"footer,omitempty" koanf:"footer"`
+}
+
+// CentralSiteConfig controls who sees which repos on the combined multi-repo
+// site (`autodoc site --central`).
+type CentralSiteConfig struct {
+	// Visibility maps repo names to public, internal, or restricted:<team>.
+	Visibility map[string]string `yaml:"visibility,omitempty" koanf:"visibility"`
+	// DefaultVisibility applies to repos not listed in Visibility; defaults to internal.
+	DefaultVisibility string `yaml:"default_visibility,omitempty" koanf:"default_visibility"`
+	// Variants are extra audience-filtered sites built alongside the full one.
+	Variants []SiteVariantConfig `yaml:"variants,omitempty" koanf:"variants"`
+	// FlowTemplates name the business flows around orchestrator services.
+	FlowTemplates []FlowTemplateConfig `yaml:"flow_templates,omitempty" koanf:"flow_templates"`
+	// FlowTemplatesFile is a YAML file with more templates under a
+	// flow_templates key, for sharing them between configs.
+	FlowTemplatesFile string `yaml:"flow_templates_file,omitempty" koanf:"flow_templates_file"`
+	// HopLatency is the latency assumed for a call with no hint or
+	// observed latency, e.g. "50ms" (the default).
+	HopLatency string `yaml:"hop_latency,omitempty" koanf:"hop_latency"`
+	// LatencyHints give the expected latency of calls between services,
+	// overriding what traces and metrics measured.
+	LatencyHints []LatencyHintConfig `yaml:"latency_hints,omitempty" koanf:"latency_hints"`
+	// RepoLogos maps repo names to logo images shown on their pages and in
+	// the services table. A repo not listed uses the logo in its own
+	// .autodoc.yml, if any.
+	RepoLogos map[string]string `yaml:"repo_logos,omitempty" koanf:"repo_logos"`
+	// PagesDir holds hand-written markdown (runbooks, onboarding guides,
+	// RFC indexes) merged into the site; each page's front matter places
+	// it in the sidebar.
+	PagesDir string `yaml:"pages_dir,omitempty" koanf:"pages_dir"`
+	// Groups maps repo names to their domain, bounded context, and
+	// environment, overriding what `autodoc repo group` recorded.
+	Groups map[string]RepoGroupConfig `yaml:"groups,omitempty" koanf:"groups"`
+	// HistoryMonths is how far back the service map's timeline goes; 0
+	// means the default of 12, and a negative value hides the timeline.
+	HistoryMonths int `yaml:"history_months,omitempty" koanf:"history_months"`
+	// Review holds new and changed pages back from the site until a
+	// reviewer approves them.
+	Review ReviewConfig `yaml:"review,omitempty" koanf:"review"`
+}
+
+// ReviewConfig gates the central site's pages behind approval:
+//
+//	review:
+//	  enabled: true
+//	  reviewers: [docs-approvers]
+type ReviewConfig struct {
+	Enabled bool `yaml:"enabled,omitempty" koanf:"enabled"`
+	// Reviewers are the groups whose members may approve and reject
+	// pages when sign-in is configured; empty lets every signed-in user.
+	Reviewers []string `yaml:"reviewers,omitempty" koanf:"reviewers"`
+}
+
+// RepoGroupConfig places a repo in the central site's groups:
+//
+//	groups:
+//	  orders:
+//	    domain: commerce
+//	    context: checkout
+//	    environment: prod
+type RepoGroupConfig struct {
+	Domain      string `yaml:"domain,omitempty" koanf:"domain"`
+	Context     string `yaml:"context,omitempty" koanf:"context"`
+	Environment string `yaml:"environment,omitempty" koanf:"environment"`
+}
+
+// LatencyHintConfig is the expected latency of one service's calls to
+// another, for modelling flows' critical paths:
+//
+//	latency_hints:
+//	  - from: orders
+//	    to: payments
+//	    latency: 180ms
+type LatencyHintConfig struct {
+	From    string `yaml:"from" koanf:"from"`
+	To      string `yaml:"to" koanf:"to"`
+	Latency string `yaml:"latency" koanf:"latency"`
+}
+
+// FlowTemplateConfig describes a named flow: the service that orchestrates
+// it, the phases its calls fall into, and optionally a narrative skeleton.
+type FlowTemplateConfig struct {
+	Name string `yaml:"name" koanf:"name"`
+	// Orchestrator matches the orchestrating service by name substring.
+	Orchestrator string `yaml:"orchestrator" koanf:"orchestrator"`
+	// Exclude skips orchestrators whose names contain any of these.
+	Exclude []string          `yaml:"exclude,omitempty" koanf:"exclude"`
+	Phases  []FlowPhaseConfig `yaml:"phases,omitempty" koanf:"phases"`
+	// Narrative is a text/template for the flow's narrative, with
+	// .Orchestrator, .Targets, .TargetCount, .Phases (each with .Name and
+	// .Services), and .CriticalPath (e.g. "230ms") or .CriticalPathMs.
+	// When empty the narrative is written by the LLM.
+	Narrative string `yaml:"narrative,omitempty" koanf:"narrative"`
+	// Budget is the end-to-end latency the flow must stay within, e.g.
+	// "800ms". When empty, the orchestrator's SLO latency target is used.
+	Budget string `yaml:"budget,omitempty" koanf:"budget"`
+}
+
+// FlowPhaseConfig is one step of a flow template. A call belongs to the
+// first phase with a service substring matching its target. Phases run one
+// after another; the calls within a phase run in parallel unless
+// Sequential is set.
+type FlowPhaseConfig struct {
+	Name       string   `yaml:"name" koanf:"name"`
+	Services   []string `yaml:"services" koanf:"services"`
+	Sequential bool     `yaml:"sequential,omitempty" koanf:"sequential"`
+}
+
+// PluginsConfig controls which plugins `autodoc plugin install` and
+// `autodoc plugin verify` accept.
+type PluginsConfig struct {
+	// TrustedKeys maps publisher names to base64 Ed25519 public keys. A
+	// plugin must be signed by one of these keys unless AllowUnsigned is set.
+	// Publisher names must not contain dots.
+	TrustedKeys map[string]string `yaml:"trusted_keys,omitempty" koanf:"trusted_keys"`
+	// AllowUnsigned accepts plugins without a signature. Checksums are still
+	// verified.
+	AllowUnsigned bool `yaml:"allow_unsigned,omitempty" koanf:"allow_unsigned"`
+}
+
+// OnCallConfig maps services to PagerDuty or Opsgenie schedules so generated
+// docs can show who to page. Schedules can also be attached to a service as
+// an "oncall_schedule" context fact.
+type OnCallConfig struct {
+	// Schedules maps service names to "pagerduty:<schedule id>" or
+	// "opsgenie:<schedule id or name>". Service names must not contain dots.
+	Schedules map[string]string `yaml:"schedules,omitempty" koanf:"schedules"`
+	// PagerDutyTokenEnv names the variable holding the PagerDuty API key;
+	// defaults to PAGERDUTY_TOKEN.
+	PagerDutyTokenEnv string `yaml:"pagerduty_token_env,omitempty" koanf:"pagerduty_token_env"`
+	// OpsgenieKeyEnv names the variable holding the Opsgenie API key;
+	// defaults to OPSGENIE_API_KEY.
+	OpsgenieKeyEnv string `yaml:"opsgenie_key_env,omitempty" koanf:"opsgenie_key_env"`
+	// OpsgenieURL overrides the Opsgenie API host, e.g. for EU accounts.
+	OpsgenieURL string `yaml:"opsgenie_url,omitempty" koanf:"opsgenie_url"`
+}
+
+// IncidentConfig tunes the bundles produced by `autodoc incident`, the MCP
+// get_incident_bundle tool, and the Slack "incident" command. Runbooks can
+// also be attached to a service as "runbook" context facts.
+type IncidentConfig struct {
+	// Runbooks maps service names to runbook URLs or paths. Service names
+	// must not contain dots.
+	Runbooks map[string][]string `yaml:"runbooks,omitempty" koanf:"runbooks"`
+	// ChangeDays is how many days of commits and architecture changes count
+	// as recent; defaults to 7.
+	ChangeDays int `yaml:"change_days,omitempty" koanf:"change_days"`
+}
+
+// NotificationsConfig customises the wording of delivered notifications.
+type NotificationsConfig struct {
+	// Locale is the default message language, e.g. "de"; routing rule
+	// destinations can override it. Defaults to English.
+	Locale string `yaml:"locale,omitempty" koanf:"locale"`
+	// Templates replace the built-in message for a channel, optionally for
+	// one notification type and locale.
+	Templates []NotificationTemplateConfig `yaml:"templates,omitempty" koanf:"templates"`
+	// Messages maps locales to label translations used by templates (the t,
+	// severity, and typeName functions), e.g. {de: {critical: Dringend}}.
+	Messages map[string]map[string]string `yaml:"messages,omitempty" koanf:"messages"`
+}
+
+// NotificationTemplateConfig is a Go text/template for one channel's message.
+type NotificationTemplateConfig struct {
+	Channel string `yaml:"channel" koanf:"channel"`         // slack or webhook
+	Type    string `yaml:"type,omitempty" koanf:"type"`     // notification type; empty for all
+	Locale  string `yaml:"locale,omitempty" koanf:"locale"` // empty for all locales
+	Text    string `yaml:"text" koanf:"text"`
+}
+
+// SLOConfig declares a service level objective for a service or one of its
+// endpoints. Either Objective or the Availability/Latency pair may be used:
+//
+//	slos:
+//	  - service: payments
+//	    objective: "99.95% p99=250ms"
+//	  - service: payments
+//	    endpoint: POST /charges
+//	    availability: "99.99%"
+//	    latency: p95=120ms
+type SLOConfig struct {
+	Service      string `yaml:"service" koanf:"service"`
+	Endpoint     string `yaml:"endpoint,omitempty" koanf:"endpoint"`
+	Objective    string `yaml:"objective,omitempty" koanf:"objective"`
+	Availability string `yaml:"availability,omitempty" koanf:"availability"`
+	Latency      string `yaml:"latency,omitempty" koanf:"latency"`
+}
+
+// DataClassConfig tags a kind of sensitive data, so the central site can
+// show which schemas, services, and topics carry it. Fields are the field
+// names that hold it, matched by their words whatever the casing, so
+// "card_number" also finds cardNumber and billing_card_number; the name
+// itself is used when none are given:
+//
+//	data_classes:
+//	  - name: email
+//	    category: PII
+//	    fields: [email, email_address]
+//	  - name: card number
+//	    category: PCI
+//	    fields: [card_number, pan]
+type DataClassConfig struct {
+	Name     string   `yaml:"name" koanf:"name"`
+	Category string   `yaml:"category,omitempty" koanf:"category"` // e.g. PII, PCI, PHI
+	Fields   []string `yaml:"fields,omitempty" koanf:"fields"`
+}
+
+// DeprecationConfig marks a service's endpoint, RPC, or topic as
+// deprecated, so the central site can list the services still using it and
+// the daemon can remind their teams until none do. Sunset is the date it is
+// due to be removed, as YYYY-MM-DD:
+//
+//	deprecations:
+//	  - service: payments
+//	    endpoint: POST /v1/charges
+//	    sunset: "2027-01-31"
+//	    replacement: POST /v2/charges
+type DeprecationConfig struct {
+	Service     string `yaml:"service" koanf:"service"`
+	Endpoint    string `yaml:"endpoint" koanf:"endpoint"`
+	Sunset      string `yaml:"sunset,omitempty" koanf:"sunset"`
+	Replacement string `yaml:"replacement,omitempty" koanf:"replacement"`
+	Note        string `yaml:"note,omitempty" koanf:"note"`
+}
+
+// EnvironmentsConfig says which links and external dependencies exist in
+// which deployment environments, so the central site can show each
+// environment's architecture. Links found in the repos' deployment config
+// (Kubernetes overlays, Helm values, .env and tfvars files) are tagged
+// automatically; entries here replace what the scan found for the same
+// caller and callee:
+//
+//	environments:
+//	  order: [dev, staging, prod]
+//	  links:
+//	    - from: payments
+//	      to: psp-mock
+//	      environments: [dev, staging]
+//	    - from: payments
+//	      to: stripe
+//	      type: http
+//	      environments: [prod]
+type EnvironmentsConfig struct {
+	Order []string                `yaml:"order,omitempty" koanf:"order"` // display order; others follow
+	Links []EnvironmentLinkConfig `yaml:"links,omitempty" koanf:"links"`
+}
+
+// EnvironmentLinkConfig tags a link, adding it if nothing detected it.
+type EnvironmentLinkConfig struct {
+	From         string   `yaml:"from" koanf:"from"`
+	To           string   `yaml:"to" koanf:"to"`
+	Type         string   `yaml:"type,omitempty" koanf:"type"`
+	Environments []string `yaml:"environments" koanf:"environments"`
+}
+
+// TracesConfig tells `autodoc traces import` where to read production traces
+// from when no trace files are given.
+type TracesConfig struct {
+	JaegerURL string `yaml:"jaeger_url,omitempty" koanf:"jaeger_url"` // Jaeger query service, e.g. http://jaeger:16686
+	TempoURL  string `yaml:"tempo_url,omitempty" koanf:"tempo_url"`   // Grafana Tempo, e.g. http://tempo:3200
+	// Aliases maps service names used in traces to repo names where they
+	// differ beyond case and suffixes such as "-service". Names must not
+	// contain dots.
+	Aliases map[string]string `yaml:"aliases,omitempty" koanf:"aliases"`
+}
+
+// CostsConfig tells `autodoc costs import` how line items in cloud billing
+// exports name the service they belong to.
+type CostsConfig struct {
+	Tag string `yaml:"tag,omitempty" koanf:"tag"` // cost allocation tag or label; default "service"
+	// Aliases maps tag values to repo names where they differ beyond case
+	// and suffixes such as "-service". Names must not contain dots.
+	Aliases map[string]string `yaml:"aliases,omitempty" koanf:"aliases"`
+}
+
+// DaemonConfig schedules `autodoc daemon`, which keeps the registered repos
+// fresh. A schedule is a five-field cron expression or a descriptor such as
+// @hourly or "@every 30m"; "off" leaves a repo to manual syncs.
+//
+//	daemon:
+//	  schedule: "@hourly"
+//	  repos:
+//	    payments: "*/15 * * * *"
+//	    legacy-billing: "off"
+//	  index: true
+//	  reminders: "0 9 * * 1"
+//	  quiet_days: 60
+type DaemonConfig struct {
+	Schedule string `yaml:"schedule,omitempty" koanf:"schedule"` // default for every repo; default @hourly
+	// Repos overrides the schedule per repo. Names must not contain dots.
+	Repos map[string]string `yaml:"repos,omitempty" koanf:"repos"`
+	// Index runs `autodoc update` in each checkout before importing it, so
+	// repos that aren't documented by their own CI stay current too.
+	Index  bool   `yaml:"index,omitempty" koanf:"index"`
+	Listen string `yaml:"listen,omitempty" koanf:"listen"` // address for /healthz and /metrics; default :9091
+	// Reminders is when the teams still using deprecated endpoints are
+	// reminded, as a schedule; default @weekly, "off" to never remind.
+	Reminders string `yaml:"reminders,omitempty" koanf:"reminders"`
+	// QuietDays is how many days a repo may go without a commit before its
+	// owners are told it has gone quiet; default 90, negative to never.
+	QuietDays int `yaml:"quiet_days,omitempty" koanf:"quiet_days"`
+}
+
+// MetricsConfig points the central site's service map at a Prometheus
+// server for per-edge request rate, error rate, and latency. The default
+// queries read the service graph metrics produced by Tempo's metrics
+// generator or the OpenTelemetry Collector's servicegraph connector. A
+// custom query must return one series per edge, labelled with the caller and
+// callee in ClientLabel and ServerLabel; "$window" is replaced by Window.
+type MetricsConfig struct {
+	PrometheusURL    string `yaml:"prometheus_url,omitempty" koanf:"prometheus_url"`
+	Window           string `yaml:"window,omitempty" koanf:"window"`                         // rate window; default 5m
+	RequestRateQuery string `yaml:"request_rate_query,omitempty" koanf:"request_rate_query"` // requests per second
+	ErrorRateQuery   string `yaml:"error_rate_query,omitempty" koanf:"error_rate_query"`     // failed fraction, 0 to 1
+	LatencyQuery     string `yaml:"latency_query,omitempty" koanf:"latency_query"`           // seconds
+	ClientLabel      string `yaml:"client_label,omitempty" koanf:"client_label"`             // default client
+	ServerLabel      string `yaml:"server_label,omitempty" koanf:"server_label"`             // default server
+	// Refresh is how often, in seconds, a served map polls for fresh
+	// numbers; 0 means the default of 30, and a negative value disables it.
+	Refresh int `yaml:"refresh,omitempty" koanf:"refresh"`
+}
+
+// SchemaRegistryConfig points autodoc at a Confluent Schema Registry, whose
+// subjects give the Events page's topics their payload schemas. The daemon
+// records every new version, from the registry and from the Avro and JSON
+// Schema files in the registered repos, and notifies the services on the
+// topic of the fields it added, removed, or changed.
+//
+//	schema_registry:
+//	  url: https://schema-registry.internal:8081
+//	  username: autodoc
+//	  password_env: SCHEMA_REGISTRY_PASSWORD
+type SchemaRegistryConfig struct {
+	URL      string `yaml:"url,omitempty" koanf:"url"`
+	Username string `yaml:"username,omitempty" koanf:"username"` // for basic auth, or a Confluent Cloud API key
+	// PasswordEnv names the variable holding the password or API secret;
+	// defaults to SCHEMA_REGISTRY_PASSWORD.
+	PasswordEnv string `yaml:"password_env,omitempty" koanf:"password_env"`
+}
+
+// MonorepoConfig splits a repository into several logical services when it
+// is registered with the central server. Services can be declared, found
+// automatically under cmd/*, services/*, and apps/*, or both; a declared
+// service overrides a discovered one at the same path. Files outside every
+// service stay with the repository itself.
+//
+//	monorepo:
+//	  discover: true
+//	  services:
+//	    - name: orders
+//	      path: services/orders
+//	      display_name: Orders API
+//	      owners: [commerce]
+type MonorepoConfig struct {
+	Discover bool               `yaml:"discover,omitempty" koanf:"discover"`
+	Services []SubServiceConfig `yaml:"services,omitempty" koanf:"services"`
+}
+
+// SubServiceConfig declares one service inside a monorepo.
+type SubServiceConfig struct {
+	Name        string   `yaml:"name" koanf:"name"`
+	Path        string   `yaml:"path" koanf:"path"` // directory relative to the repository root
+	DisplayName string   `yaml:"display_name,omitempty" koanf:"display_name"`
+	Owners      []string `yaml:"owners,omitempty" koanf:"owners"` // team names
+}
+
+// LargeFilesConfig sets how oversized files such as webpack bundles and SQL
+// dumps are indexed. Sizes are written like "512KB" or "4MB"; empty values
+// take the defaults. Limits entries override the top-level sizes for
+// matching paths, and the last matching entry wins:
+//
+//	large_files:
+//	  sample_above: 256KB   # analyze a sample of the file instead of all of it
+//	  stub_above: 4MB       # write a stub page without analyzing the file
+//	  ignore_above: 64MB    # leave the file out of the index entirely
+//	  limits:
+//	    - pattern: "db/dumps/**"
+//	      stub_above: "0"
+type LargeFilesConfig struct {
+	SampleAbove string          `yaml:"sample_above,omitempty" koanf:"sample_above"`
+	StubAbove   string          `yaml:"stub_above,omitempty" koanf:"stub_above"`
+	IgnoreAbove string          `yaml:"ignore_above,omitempty" koanf:"ignore_above"`
+	Limits      []FileSizeLimit `yaml:"limits,omitempty" koanf:"limits"`
+}
+
+// FileSizeLimit overrides the large-file thresholds for paths matching
+// Pattern.
+type FileSizeLimit struct {
+	Pattern     string `yaml:"pattern" koanf:"pattern"`
+	SampleAbove string `yaml:"sample_above,omitempty" koanf:"sample_above"`
+	StubAbove   string `yaml:"stub_above,omitempty" koanf:"stub_above"`
+}
+
+// ScrubConfig sets what is redacted from source before it is sent to an
+// LLM. Secrets such as API keys, private keys, and connection strings are
+// redacted unless Disabled is set; string literals and personal data can
+// be masked too:
+//
+//	scrub:
+//	  strings: true        # mask every string literal
+//	  pii: true            # mask email addresses, phone numbers, IPs, card numbers
+//	  patterns:            # more regular expressions to redact
+//	    - "ACME-[0-9]{8}"
+type ScrubConfig struct {
+	Disabled bool     `yaml:"disabled,omitempty" koanf:"disabled"`
+	Strings  bool     `yaml:"strings,omitempty" koanf:"strings"`
+	PII      bool     `yaml:"pii,omitempty" koanf:"pii"`
+	Patterns []string `yaml:"patterns,omitempty" koanf:"patterns"`
+}
+
+// ArtifactsConfig sets where rendered sites and export bundles are kept.
+// Each file is stored once under the hash of its content, every generation
+// records a snapshot of the files it produced, and snapshots beyond Keep are
+// dropped so garbage collection can reclaim their files. With S3 set, the
+// store lives in a bucket, so CI runners can share it:
+//
+//	artifacts:
+//	  keep: 10
+//	  s3:
+//	    bucket: docs-artifacts
+//	    prefix: autodoc/
+//	    region: eu-west-1
+type ArtifactsConfig struct {
+	Dir  string   `yaml:"dir,omitempty" koanf:"dir"`   // local store; defaults to {output_dir}/artifacts
+	Keep int      `yaml:"keep,omitempty" koanf:"keep"` // snapshots kept per output; defaults to 5
+	S3   S3Config `yaml:"s3,omitempty" koanf:"s3"`
+}
+
+// S3Config points the artifact store at an S3 bucket or an S3-compatible
+// service such as MinIO. Credentials are found like the AWS SDKs find them.
+type S3Config struct {
+	Bucket   string `yaml:"bucket,omitempty" koanf:"bucket"`
+	Prefix   string `yaml:"prefix,omitempty" koanf:"prefix"`
+	Region   string `yaml:"region,omitempty" koanf:"region"`     // defaults to us-east-1
+	Endpoint string `yaml:"endpoint,omitempty" koanf:"endpoint"` // e.g. http://minio:9000; defaults to AWS
+	// PathStyle addresses the bucket as {endpoint}/{bucket} rather than
+	// {bucket}.{endpoint}, as most S3-compatible services expect.
+	PathStyle bool `yaml:"path_style,omitempty" koanf:"path_style"`
+	// AccessKeyEnv and SecretKeyEnv name variables holding the credentials,
+	// e.g. for a MinIO account, in place of the AWS credential chain; either
+	// alone defaults the other to its AWS_ variable. AWS_SESSION_TOKEN is
+	// used when set.
+	AccessKeyEnv string `yaml:"access_key_env,omitempty" koanf:"access_key_env"`
+	SecretKeyEnv string `yaml:"secret_key_env,omitempty" koanf:"secret_key_env"`
+}
+
+// SiteVariantConfig describes one audience-filtered copy of the central site.
+type SiteVariantConfig struct {
+	Name     string `yaml:"name" koanf:"name"`
+	Audience string `yaml:"audience" koanf:"audience"`       // public, internal, team:<name>, or all
+	Output   string `yaml:"output,omitempty" koanf:"output"` // defaults to {site output}-<name>
+}
@@ -180,7 +180,7 @@ You MUST respond with valid JSON matching this schema:
     {
       "scope": "service|endpoint|flow|org|domain|topic",
       "scope_id": "identifier (e.g. service name)",
-      "key": "description|purpose|owner|dependency|technology|protocol|data_flow|business_context",
+      "key": "description|purpose|owner|dependency|technology|protocol|data_flow|business_context|slo",
       "value": "the extracted fact",
       "confidence": "high|medium|low",
       "explanation": "why you extracted this"
@@ -203,7 +203,15 @@ Rules:
 - If the user mentions relationships between services, create facts for both sides
 - If something is ambiguous, add a clarification question
 - Be aggressive about extracting useful information
-- The summary should confirm what you understood back to the user`
+- The summary should confirm what you understood back to the user
+- Service level objectives use scope "service" and key "slo", or "slo:<endpoint>" for one endpoint (e.g. "slo:POST /charges"). The value must use the form "99.9% p99=300ms": an availability percentage and/or a latency target as p<percentile>=<duration>
+- Corrections to which services call which use scope "service" (the caller) and key "calls:<callee>". The value is "no" when the caller does not (or no longer) call the callee, or "yes" optionally followed by the protocol and endpoints (e.g. "yes http POST /charges, POST /refunds")
+- Corrections to flows use scope "flow" with the flow name as scope_id: key "exclude" with value "yes" when the flow is not real, or key "services" with the services in order (e.g. "web -> orders -> payments")
+- Corrections to whether an endpoint requires authentication use scope "service" and key "auth:<METHOD /path>", or "auth:*" for all of the service's endpoints. The value is "yes" optionally followed by how it authenticates (e.g. "yes API gateway JWT"), "no" when it doesn't, or "public" when it is open to anyone on purpose
+- Sensitive data classes (e.g. "card numbers are PCI data") use scope "org" and key "data_class:<name>" (e.g. "data_class:card number"). The value is the category, optionally followed by a colon and the field names that hold it (e.g. "PCI: card_number, pan")
+- Deprecated endpoints and topics use scope "service" (the provider) and key "deprecated:<endpoint>" (e.g. "deprecated:POST /v1/charges" or "deprecated:orders.legacy"). The value is the sunset date as YYYY-MM-DD, or "yes" when none is given, optionally followed by a comma and "use <replacement>" (e.g. "2027-01-31, use POST /v2/charges"); or "no" when it is not (or no longer) deprecated
+- Notes on a flow's threat model use scope "flow" with the flow name as scope_id and key "threat:<threat ID>", with IDs as on the Threat Model page (e.g. "threat:orders->payments/T"). The value is "mitigated", "accepted", "not applicable", or "open", optionally followed by a note (e.g. "mitigated: mTLS between all services"); or a new description of the threat
+- Edits to a flow's narrative use scope "flow" with the flow name as scope_id and key "narrative" for the whole narrative, or "narrative:<section>" for one paragraph, named by its bold label (e.g. "narrative:payment"), or "overview" for the opening one. The value is the new text; "pinned: <text>" when the user wants it kept even when the narrative is regenerated; or "pinned" alone to keep the text as it is`
 
 const questionSystemPrompt = `You are an architecture documentation assistant. Answer questions about the software architecture based on the known facts provided. Be specific, reference actual service names and relationships. If you don't have enough information to answer fully, say what you do know and what's missing.`
 
@@ -241,9 +249,7 @@ func buildQuestionPrompt(question string, facts []Fact) string {
 
 	b.WriteString("## Known Architecture Facts\n")
 	if len(facts) > 0 {
-		for _, f := range facts {
-			fmt.Fprintf(&b, "- [%s] %s.%s = %s (source: %s)\n", f.Scope, f.ScopeID, f.Key, f.Value, f.Source)
-		}
+		b.WriteString(FormatFacts(facts))
 	} else {
 		b.WriteString("(No facts available yet)\n")
 	}
@@ -253,6 +259,16 @@ func buildQuestionPrompt(question string, facts []Fact) string {
 	return b.String()
 }
 
+// FormatFacts lists facts one per line for an LLM prompt, as AskQuestion
+// shows them.
+func FormatFacts(facts []Fact) string {
+	var b strings.Builder
+	for _, f := range facts {
+		fmt.Fprintf(&b, "- [%s] %s.%s = %s (source: %s)\n", f.Scope, f.ScopeID, f.Key, f.Value, f.Source)
+	}
+	return b.String()
+}
+
 func parseExtractionResponse(content string) (*ContextUpdate, error) {
 	// Try to find JSON in the response (may be wrapped in markdown code blocks).
 	jsonStr := content
@@ -22,6 +22,12 @@ func NewStore(database *db.DB) *Store {
 	return &Store{db: database}
 }
 
+// FactSource reads current facts. *Store implements it; packages that only
+// read facts take a FactSource so tests can supply their own.
+type FactSource interface {
+	GetCurrentFacts(ctx context.Context, repoID, scope, scopeID string) ([]Fact, error)
+}
+
 // SaveFact inserts or updates a fact. If it already exists (same repo/scope/scope_id/key),
 // the old version is superseded and a new version is created.
 func (s *Store) SaveFact(ctx context.Context, f Fact) (*Fact, error) {
@@ -0,0 +1,243 @@
+// Package contracts bootstraps consumer-driven contract tests from the
+// documented architecture. Every consumer-provider pair gets the HTTP calls
+// the consumer makes and the messages it reads, written as Pact files or as
+// the part of the provider's OpenAPI spec the consumer relies on, for teams
+// to fill in and verify.
+package contracts
+
+import (
+	"cmp"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+// Pair is what a consumer relies on a provider for. For an HTTP link the
+// caller is the consumer; for a messaging link the service reading the
+// messages is.
+type Pair struct {
+	Consumer string
+	Provider string
+	Calls    []Call
+	Messages []Message
+}
+
+// Call is an HTTP request the consumer makes.
+type Call struct {
+	Method string
+	Path   string // as documented, with parameters like {id} or :id
+	// Spec is the provider's documentation of the operation, when its
+	// OpenAPI spec has it.
+	Spec *importers.OpenAPIEndpoint
+}
+
+// Message is a message the consumer reads from the provider.
+type Message struct {
+	Topic  string
+	Broker string // the link type, e.g. kafka
+	// Schema is the latest recorded version of the topic's payload schema.
+	Schema *schemareg.Schema
+}
+
+// Skipped is a link no contract could be written for, and why.
+type Skipped struct {
+	Link   registry.ServiceLink
+	Reason string
+}
+
+func isAsync(linkType string) bool {
+	switch strings.ToLower(linkType) {
+	case "kafka", "amqp", "event", "pubsub", "queue", "sqs", "sns", "nats":
+		return true
+	}
+	return false
+}
+
+// Build groups links into consumer-provider pairs, attaching to each call
+// the provider's documentation of it, from specs by repo, and to each
+// message its topic's latest schema version. Pairs are ordered by consumer
+// and then provider.
+func Build(links []registry.ServiceLink, specs map[string][]importers.OpenAPIEndpoint, schemas []schemareg.Schema) ([]Pair, []Skipped) {
+	// A topic's payload is its value subject's, failing that its key's.
+	latest := make(map[string]schemareg.Schema)
+	for _, suffix := range []string{"-key", "-value"} {
+		for _, s := range schemas {
+			if topic, ok := strings.CutSuffix(s.Subject, suffix); ok && topic != "" {
+				if prev, ok := latest[topic]; !ok || prev.Subject != s.Subject || s.Version > prev.Version {
+					latest[topic] = s
+				}
+			}
+		}
+	}
+
+	byPair := make(map[[2]string]*Pair)
+	pair := func(consumer, provider string) *Pair {
+		k := [2]string{consumer, provider}
+		if byPair[k] == nil {
+			byPair[k] = &Pair{Consumer: consumer, Provider: provider}
+		}
+		return byPair[k]
+	}
+	var skipped []Skipped
+	for _, l := range links {
+		if len(l.Endpoints) == 0 {
+			skipped = append(skipped, Skipped{l, "no endpoints or topics detected"})
+			continue
+		}
+		if isAsync(l.LinkType) {
+			p := pair(l.ToRepo, l.FromRepo)
+			for _, topic := range l.Endpoints {
+				if slices.ContainsFunc(p.Messages, func(m Message) bool { return m.Topic == topic }) {
+					continue
+				}
+				m := Message{Topic: topic, Broker: l.LinkType}
+				if s, ok := latest[topic]; ok {
+					m.Schema = &s
+				}
+				p.Messages = append(p.Messages, m)
+			}
+			continue
+		}
+		var calls []Call
+		for _, e := range l.Endpoints {
+			c, ok := parseCall(e)
+			if !ok {
+				continue
+			}
+			c.Spec = findOperation(specs[l.ToRepo], c)
+			calls = append(calls, c)
+		}
+		if len(calls) == 0 {
+			skipped = append(skipped, Skipped{l, "no HTTP endpoints; only HTTP and messaging contracts are generated"})
+			continue
+		}
+		p := pair(l.FromRepo, l.ToRepo)
+		for _, c := range calls {
+			if !slices.ContainsFunc(p.Calls, func(o Call) bool { return o.Method == c.Method && o.Path == c.Path }) {
+				p.Calls = append(p.Calls, c)
+			}
+		}
+	}
+
+	pairs := make([]Pair, 0, len(byPair))
+	for _, p := range byPair {
+		pairs = append(pairs, *p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Consumer != pairs[j].Consumer {
+			return pairs[i].Consumer < pairs[j].Consumer
+		}
+		return pairs[i].Provider < pairs[j].Provider
+	})
+	return pairs, skipped
+}
+
+// parseCall reads an endpoint like "POST /api/charge", or a bare path,
+// which is taken as a GET.
+func parseCall(endpoint string) (Call, bool) {
+	fields := strings.Fields(endpoint)
+	switch {
+	case len(fields) == 1 && strings.HasPrefix(fields[0], "/"):
+		return Call{Method: "GET", Path: fields[0]}, true
+	case len(fields) == 2 && strings.HasPrefix(fields[1], "/"):
+		method := strings.ToUpper(fields[0])
+		switch method {
+		case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS":
+			return Call{Method: method, Path: fields[1]}, true
+		}
+	}
+	return Call{}, false
+}
+
+var pathParam = regexp.MustCompile(`\{[^}/]*\}|:[A-Za-z_]\w*|<[^>/]*>`)
+
+// TemplatePath turns a path's parameters, {id}, :id, or <id>, into "{}" so
+// paths documented in different styles compare equal.
+func TemplatePath(p string) string {
+	return strings.TrimRight(pathParam.ReplaceAllString(p, "{}"), "/")
+}
+
+// findOperation returns the provider's documentation of the call: the
+// operation with its method on the same path, or, failing that, on a path
+// the call's ends with, for specs written without the gateway's prefix.
+func findOperation(ops []importers.OpenAPIEndpoint, c Call) *importers.OpenAPIEndpoint {
+	want := TemplatePath(c.Path)
+	var suffix *importers.OpenAPIEndpoint
+	for i, op := range ops {
+		if op.Method != c.Method {
+			continue
+		}
+		got := TemplatePath(op.Path)
+		if got == want {
+			return &ops[i]
+		}
+		if suffix == nil && got != "" && strings.HasSuffix(want, got) {
+			suffix = &ops[i]
+		}
+	}
+	return suffix
+}
+
+// examplePath fills in a path's parameters, which Pact requests can't
+// leave open.
+func examplePath(p string) string {
+	return pathParam.ReplaceAllString(p, "1")
+}
+
+// successStatus is the call's documented success status, or 200.
+func (c Call) successStatus() int {
+	best := 0
+	if c.Spec != nil {
+		for code := range c.Spec.Responses {
+			if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 && (best == 0 || n < best) {
+				best = n
+			}
+		}
+	}
+	return cmp.Or(best, 200)
+}
+
+// LoadSpecs reads the OpenAPI and Swagger specs in the checkout at root:
+// JSON and YAML files with "openapi" or "swagger" in their name, outside
+// hidden and dependency directories.
+func LoadSpecs(root string) []importers.OpenAPIEndpoint {
+	var ops []importers.OpenAPIEndpoint
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		switch filepath.Ext(name) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+		if !strings.Contains(name, "openapi") && !strings.Contains(name, "swagger") {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		if got, err := importers.ParseOpenAPI(string(content)); err == nil {
+			ops = append(ops, got...)
+		}
+		return nil
+	})
+	return ops
+}
@@ -0,0 +1,153 @@
+package contracts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+func TestContracts(t *testing.T) {
+	payments := t.TempDir()
+	os.MkdirAll(filepath.Join(payments, "api"), 0o755)
+	os.WriteFile(filepath.Join(payments, "api", "openapi.yaml"), []byte(`openapi: 3.0.0
+paths:
+  /charges:
+    post:
+      summary: Charge a card
+      responses:
+        "201": {description: Charged}
+        "402": {description: Declined}
+  /charges/{chargeId}:
+    get:
+      parameters:
+        - {name: chargeId, in: path}
+        - {name: expand, in: query}
+      responses:
+        "200": {description: The charge}
+`), 0o644)
+	specs := map[string][]importers.OpenAPIEndpoint{"payments": LoadSpecs(payments)}
+	if len(specs["payments"]) != 2 {
+		t.Fatalf("LoadSpecs = %+v", specs["payments"])
+	}
+
+	links := []registry.ServiceLink{
+		{FromRepo: "checkout", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /api/charges", "get /api/charges/:id", "Charge"}},
+		{FromRepo: "checkout", ToRepo: "inventory", LinkType: "grpc", Endpoints: []string{"Reserve"}},
+		{FromRepo: "checkout", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"orders.placed"}},
+		{FromRepo: "checkout", ToRepo: "audit", LinkType: "amqp"},
+	}
+	schemas := []schemareg.Schema{
+		{Subject: "orders.placed-key", Version: 4, Fields: []schemareg.Field{{Name: "id", Type: "string"}}},
+		{Subject: "orders.placed-value", Version: 1, Fields: []schemareg.Field{{Name: "id", Type: "string"}}},
+		{Subject: "orders.placed-value", Version: 2, Name: "OrderPlaced", Fields: []schemareg.Field{
+			{Name: "id", Type: "string"},
+			{Name: "total", Type: "long"},
+			{Name: "note", Type: "null|string"},
+			{Name: "customer", Type: "Customer"},
+			{Name: "customer.email", Type: "string"},
+			{Name: "lines", Type: "array<Line>"},
+			{Name: "lines[].sku", Type: "string"},
+			{Name: "tags", Type: "array<string>"},
+		}},
+	}
+
+	pairs, skipped := Build(links, specs, schemas)
+	if len(pairs) != 2 || pairs[0].Consumer != "checkout" || pairs[0].Provider != "payments" || pairs[1].Consumer != "mailer" || pairs[1].Provider != "checkout" {
+		t.Fatalf("pairs = %+v; want checkout calling payments and mailer reading checkout's messages", pairs)
+	}
+	if len(skipped) != 2 || skipped[0].Link.ToRepo != "inventory" || skipped[1].Link.ToRepo != "audit" {
+		t.Errorf("skipped = %+v; want the gRPC link and the link with no topics", skipped)
+	}
+	calls := pairs[0].Calls
+	if len(calls) != 2 || calls[0].Spec == nil || calls[0].Spec.Path != "/charges" || calls[1].Method != "GET" || calls[1].Spec == nil || calls[1].Spec.Path != "/charges/{chargeId}" {
+		t.Errorf("calls = %+v; want both matched to the spec, without the gateway's /api prefix", calls)
+	}
+	if m := pairs[1].Messages; len(m) != 1 || m[0].Schema == nil || m[0].Schema.Version != 2 {
+		t.Errorf("messages = %+v; want the value subject's latest version", m)
+	}
+
+	dir := t.TempDir()
+	written, err := Write(dir, FormatPact, pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 || filepath.Base(written[0]) != "checkout-payments.json" || filepath.Base(written[1]) != "mailer-checkout-messages.json" {
+		t.Fatalf("written = %v", written)
+	}
+	var httpContract struct {
+		Consumer     struct{ Name string }
+		Interactions []struct {
+			Description string
+			Request     struct{ Method, Path string }
+			Response    struct{ Status int }
+		}
+		Metadata struct {
+			PactSpecification struct{ Version string }
+		}
+	}
+	data, _ := os.ReadFile(written[0])
+	if err := json.Unmarshal(data, &httpContract); err != nil {
+		t.Fatal(err)
+	}
+	if httpContract.Consumer.Name != "checkout" || httpContract.Metadata.PactSpecification.Version != "3.0.0" || len(httpContract.Interactions) != 2 {
+		t.Fatalf("HTTP pact:\n%s", data)
+	}
+	if i := httpContract.Interactions[0]; i.Description != "Charge a card" || i.Request.Method != "POST" || i.Request.Path != "/api/charges" || i.Response.Status != 201 {
+		t.Errorf("first interaction = %+v", i)
+	}
+	if i := httpContract.Interactions[1]; i.Request.Path != "/api/charges/1" || i.Response.Status != 200 {
+		t.Errorf("second interaction = %+v", i)
+	}
+
+	data, _ = os.ReadFile(written[1])
+	for _, want := range []string{
+		`"description": "OrderPlaced on orders.placed"`,
+		`"schema": "orders.placed-value v2"`,
+		`"email": "string"`,
+		`"lines": [`,
+		`"sku": "string"`,
+		`"note": "string"`,
+		`"total": 0`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message pact missing %s:\n%s", want, data)
+		}
+	}
+
+	written, err = Write(dir, FormatOpenAPI, pairs[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(written[0])
+	for _, want := range []string{
+		"title: payments, as used by checkout",
+		"    /api/charges/{id}:\n        get:\n            parameters:\n                - name: id\n                  in: path\n                  required: true",
+		"                - name: expand\n                  in: query",
+		"    /api/charges:\n        post:\n            summary: Charge a card",
+		`"201":` + "\n                    description: Charged",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("OpenAPI contract missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "chargeId") {
+		t.Errorf("the spec's name for a path parameter leaked into the consumer's path:\n%s", data)
+	}
+	if _, err := Write(dir, "wsdl", pairs); err == nil {
+		t.Error("an unknown format should fail")
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	for _, p := range []string{"/users/{id}/orders/", "/users/:id/orders", "/users/<int:id>/orders"} {
+		if got := TemplatePath(p); got != "/users/{}/orders" {
+			t.Errorf("TemplatePath(%q) = %q", p, got)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package contracts
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+// Formats for the HTTP contracts. Message contracts are Pact message files
+// in both, since OpenAPI can't describe them.
+const (
+	FormatPact    = "pact"
+	FormatOpenAPI = "openapi"
+)
+
+// Write writes the pairs' contracts into dir in the given format and
+// returns the paths of the files written: <consumer>-<provider>.json (or
+// .openapi.yaml) for HTTP calls, and <consumer>-<provider>-messages.json for
+// messages.
+func Write(dir, format string, pairs []Pair) ([]string, error) {
+	if format != FormatPact && format != FormatOpenAPI {
+		return nil, fmt.Errorf("unknown contract format %q (want %s or %s)", format, FormatPact, FormatOpenAPI)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	write := func(name string, data []byte) error {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+		written = append(written, path)
+		return nil
+	}
+	for _, p := range pairs {
+		base := p.Consumer + "-" + p.Provider
+		if len(p.Calls) > 0 {
+			var data []byte
+			var err error
+			name := base + ".json"
+			if format == FormatOpenAPI {
+				name = base + ".openapi.yaml"
+				data, err = yaml.Marshal(openAPIContract(p))
+			} else {
+				data, err = json.MarshalIndent(httpPact(p), "", "  ")
+			}
+			if err != nil {
+				return written, fmt.Errorf("encoding %s: %w", name, err)
+			}
+			if err := write(name, data); err != nil {
+				return written, err
+			}
+		}
+		if len(p.Messages) > 0 {
+			data, err := json.MarshalIndent(messagePact(p), "", "  ")
+			if err != nil {
+				return written, fmt.Errorf("encoding %s-messages.json: %w", base, err)
+			}
+			if err := write(base+"-messages.json", data); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Pact files, version 3 of the specification.
+type (
+	pact struct {
+		Consumer     pactParty         `json:"consumer"`
+		Provider     pactParty         `json:"provider"`
+		Interactions []pactInteraction `json:"interactions,omitempty"`
+		Messages     []pactMessage     `json:"messages,omitempty"`
+		Metadata     pactMetadata      `json:"metadata"`
+	}
+	pactParty struct {
+		Name string `json:"name"`
+	}
+	pactInteraction struct {
+		Description string       `json:"description"`
+		Request     pactRequest  `json:"request"`
+		Response    pactResponse `json:"response"`
+	}
+	pactRequest struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+	pactResponse struct {
+		Status int `json:"status"`
+	}
+	pactMessage struct {
+		Description string         `json:"description"`
+		Contents    map[string]any `json:"contents"`
+		MetaData    map[string]any `json:"metaData"`
+	}
+	pactMetadata struct {
+		PactSpecification struct {
+			Version string `json:"version"`
+		} `json:"pactSpecification"`
+		GeneratedBy string `json:"generatedBy"`
+	}
+)
+
+func newPact(consumer, provider string) pact {
+	p := pact{Consumer: pactParty{consumer}, Provider: pactParty{provider}}
+	p.Metadata.PactSpecification.Version = "3.0.0"
+	p.Metadata.GeneratedBy = "autodoc contracts"
+	return p
+}
+
+// httpPact is a Pact with an interaction per call. Parameters in the path
+// are filled in with 1, and the response is the documented success status
+// with no body; both are for the consumer's team to refine.
+func httpPact(p Pair) pact {
+	out := newPact(p.Consumer, p.Provider)
+	for _, c := range p.Calls {
+		description := "a request to " + c.Method + " " + c.Path
+		if c.Spec != nil && c.Spec.Summary != "" {
+			description = c.Spec.Summary
+		}
+		out.Interactions = append(out.Interactions, pactInteraction{
+			Description: description,
+			Request:     pactRequest{Method: c.Method, Path: examplePath(c.Path)},
+			Response:    pactResponse{Status: c.successStatus()},
+		})
+	}
+	return out
+}
+
+// messagePact is a message Pact with a message per topic, its contents an
+// example built from the topic's schema, or empty when it has none.
+func messagePact(p Pair) pact {
+	out := newPact(p.Consumer, p.Provider)
+	for _, m := range p.Messages {
+		msg := pactMessage{
+			Description: "a message on " + m.Topic,
+			Contents:    map[string]any{},
+			MetaData:    map[string]any{"topic": m.Topic, "broker": m.Broker, "contentType": "application/json"},
+		}
+		if s := m.Schema; s != nil {
+			if s.Name != "" {
+				msg.Description = s.Name + " on " + m.Topic
+			}
+			msg.Contents = Example(s.Fields)
+			msg.MetaData["schema"] = s.Label()
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+	return out
+}
+
+// Example builds an example payload from a schema's fields, nesting them by
+// their paths, with a placeholder value of each field's type.
+func Example(fields []schemareg.Field) map[string]any {
+	root := map[string]any{}
+	for _, f := range fields {
+		node := root
+		segments := strings.Split(f.Name, ".")
+		for _, seg := range segments[:len(segments)-1] {
+			node = child(node, seg)
+		}
+		last := segments[len(segments)-1]
+		if _, ok := node[last]; !ok {
+			node[last] = placeholder(f.Type)
+		}
+	}
+	return root
+}
+
+// child returns the object a path segment names in node, creating it: the
+// field's value, the first item of an array ("lines[]"), or the value of a
+// map ("attrs{}").
+func child(node map[string]any, seg string) map[string]any {
+	name, array := strings.CutSuffix(seg, "[]")
+	name, mapped := strings.CutSuffix(name, "{}")
+	next := map[string]any{}
+	switch {
+	case array:
+		if items, ok := node[name].([]any); ok && len(items) > 0 {
+			if m, ok := items[0].(map[string]any); ok {
+				return m
+			}
+		}
+		node[name] = []any{next}
+	case mapped:
+		if values, ok := node[name].(map[string]any); ok {
+			if m, ok := values["key"].(map[string]any); ok {
+				return m
+			}
+		}
+		node[name] = map[string]any{"key": next}
+	default:
+		if m, ok := node[name].(map[string]any); ok {
+			return m
+		}
+		node[name] = next
+	}
+	return next
+}
+
+// placeholder is an example value of a type as schemareg describes it.
+// Named types, records and enums alike, are taken as objects.
+func placeholder(t string) any {
+	if alternatives := strings.Split(t, "|"); len(alternatives) > 1 {
+		for _, a := range alternatives {
+			if a != "null" {
+				return placeholder(a)
+			}
+		}
+		return nil
+	}
+	if inner, ok := strings.CutPrefix(t, "array<"); ok {
+		return []any{placeholder(strings.TrimSuffix(inner, ">"))}
+	}
+	if inner, ok := strings.CutPrefix(t, "map<"); ok {
+		return map[string]any{"key": placeholder(strings.TrimSuffix(inner, ">"))}
+	}
+	if inner, ok := strings.CutPrefix(t, "repeated "); ok {
+		return []any{placeholder(inner)}
+	}
+	base, _, _ := strings.Cut(t, "(")
+	switch base {
+	case "string", "bytes", "uuid":
+		return "string"
+	case "int", "long", "integer", "number", "float", "double", "int32", "int64", "uint32", "uint64", "sint32", "sint64":
+		return 0
+	case "boolean", "bool":
+		return false
+	case "null":
+		return nil
+	}
+	return map[string]any{}
+}
+
+// OpenAPI contracts: the part of the provider's API the consumer calls.
+type (
+	openAPIDoc struct {
+		OpenAPI string                          `yaml:"openapi"`
+		Info    openAPIInfo                     `yaml:"info"`
+		Paths   map[string]map[string]operation `yaml:"paths"`
+	}
+	openAPIInfo struct {
+		Title       string `yaml:"title"`
+		Version     string `yaml:"version"`
+		Description string `yaml:"description"`
+	}
+	operation struct {
+		Summary    string              `yaml:"summary,omitempty"`
+		Parameters []parameter         `yaml:"parameters,omitempty"`
+		Responses  map[string]response `yaml:"responses"`
+	}
+	parameter struct {
+		Name     string            `yaml:"name"`
+		In       string            `yaml:"in"`
+		Required bool              `yaml:"required,omitempty"`
+		Schema   map[string]string `yaml:"schema"`
+	}
+	response struct {
+		Description string `yaml:"description"`
+	}
+)
+
+var specParameter = regexp.MustCompile(`^(.+) \(in (\w+)\)$`)
+
+// openAPIContract describes the calls as an OpenAPI document, taking each
+// operation's summary, parameters, and responses from the provider's spec
+// when it has them.
+func openAPIContract(p Pair) openAPIDoc {
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       p.Provider + ", as used by " + p.Consumer,
+			Version:     "contract",
+			Description: fmt.Sprintf("The operations of %s that %s calls, generated by autodoc contracts from the documented architecture.", p.Provider, p.Consumer),
+		},
+		Paths: make(map[string]map[string]operation),
+	}
+	for _, c := range p.Calls {
+		path := pathParam.ReplaceAllStringFunc(c.Path, func(param string) string {
+			return "{" + strings.Trim(param, "{}:<>") + "}"
+		})
+		op := operation{Responses: map[string]response{}}
+		for _, m := range pathParam.FindAllString(path, -1) {
+			name := strings.Trim(m, "{}")
+			op.Parameters = append(op.Parameters, parameter{Name: name, In: "path", Required: true, Schema: map[string]string{"type": "string"}})
+		}
+		if s := c.Spec; s != nil {
+			op.Summary = s.Summary
+			// Path parameters are the consumer's, named as it names them.
+			for _, param := range s.Parameters {
+				if m := specParameter.FindStringSubmatch(param); m != nil && m[2] != "path" {
+					op.Parameters = append(op.Parameters, parameter{Name: m[1], In: m[2], Schema: map[string]string{"type": "string"}})
+				}
+			}
+			for code, description := range s.Responses {
+				op.Responses[code] = response{Description: cmp.Or(description, "Response")}
+			}
+		}
+		if len(op.Responses) == 0 {
+			op.Responses[strconv.Itoa(c.successStatus())] = response{Description: "Success"}
+		}
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]operation)
+		}
+		doc.Paths[path][strings.ToLower(c.Method)] = op
+	}
+	return doc
+}
@@ -0,0 +1,411 @@
+// Package costs reads cloud billing exports whose line items are tagged by
+// service (AWS Cost and Usage Reports, GCP billing exports, Azure cost
+// exports, FOCUS files, or hand-made CSV and JSON), and totals them per
+// service and month, so the docs can put a price on each part of the system.
+package costs
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultTag is the cost allocation tag (or label) naming the service a
+// line item belongs to.
+const DefaultTag = "service"
+
+// Line is one line item of a billing export.
+type Line struct {
+	Service  string // the tag's value; "" when the line item isn't tagged
+	Month    string // YYYY-MM
+	Amount   float64
+	Currency string
+}
+
+// MonthlyCost is what a service cost in a month. Service is "" for the spend
+// no registered service accounts for.
+type MonthlyCost struct {
+	Service  string  `json:"service"`
+	Month    string  `json:"month"` // YYYY-MM
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+// Columns of the exports this package reads, by normalized name (lower case,
+// letters and digits only), most specific first.
+var (
+	monthColumns = []string{
+		"month", "invoicemonth", "billingperiod", "billingperiodstart", "billbillingperiodstartdate",
+		"billingperiodstartdate", "chargeperiodstart", "lineitemusagestartdate", "usagestartdate",
+		"usagestarttime", "usagedate", "date",
+	}
+	amountColumns = []string{
+		"cost", "amount", "lineitemunblendedcost", "unblendedcost", "billedcost", "costinbillingcurrency",
+		"pretaxcost", "netcost", "lineitemnetunblendedcost", "effectivecost",
+	}
+	currencyColumns = []string{
+		"currency", "lineitemcurrencycode", "currencycode", "billingcurrency", "billingcurrencycode",
+	}
+)
+
+// Parse reads a billing export: CSV with a header row, or JSON holding an
+// array of line items (or an object with one). tag is the cost allocation
+// tag naming each line item's service; it is found in columns such as
+// "service", "tag:service", "resourceTags/user:service",
+// "resource_tags_user_service", or "labels.service", in a JSON "tags" object
+// or column (Azure, FOCUS), or in a GCP "labels" list of key/value pairs.
+func Parse(data []byte, tag string) ([]Line, error) {
+	tag = strings.ToLower(cmp.Or(tag, DefaultTag))
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))) // UTF-8 BOM
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	var rows []map[string]string
+	var err error
+	if trimmed[0] == '[' || trimmed[0] == '{' {
+		rows, err = jsonRows(trimmed)
+	} else {
+		rows, err = csvRows(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	serviceCol := serviceColumn(rows, tag)
+	monthCol := column(rows, monthColumns)
+	amountCol := column(rows, amountColumns)
+	currencyCol := column(rows, currencyColumns)
+	if monthCol == "" {
+		return nil, fmt.Errorf("no month or date column found")
+	}
+	if amountCol == "" {
+		return nil, fmt.Errorf("no cost or amount column found")
+	}
+	if serviceCol == "" {
+		return nil, fmt.Errorf("no %q tag column found", tag)
+	}
+
+	var lines []Line
+	for i, row := range rows {
+		if row[amountCol] == "" && row[monthCol] == "" {
+			continue
+		}
+		month, err := parseMonth(row[monthCol])
+		if err != nil {
+			return nil, fmt.Errorf("line item %d: %w", i+1, err)
+		}
+		amount, err := parseAmount(row[amountCol])
+		if err != nil {
+			return nil, fmt.Errorf("line item %d: %w", i+1, err)
+		}
+		currency := "USD"
+		if currencyCol != "" && row[currencyCol] != "" {
+			currency = strings.ToUpper(row[currencyCol])
+		}
+		lines = append(lines, Line{Service: strings.TrimSpace(row[serviceCol]), Month: month, Amount: amount, Currency: currency})
+	}
+	return lines, nil
+}
+
+// Attribute totals lines per registered service, month, and currency.
+// resolve maps a tag value to a registered repo, as traces.Resolver does.
+// Spend with no tag, or tagged with a service that isn't registered, is
+// totalled under the service "" and also returned by tag value, so aliases
+// can be added for it.
+func Attribute(lines []Line, resolve func(string) (string, bool)) (costs, unattributed []MonthlyCost) {
+	type key struct{ service, month, currency string }
+	attributed := make(map[key]float64)
+	other := make(map[key]float64)
+	for _, l := range lines {
+		repo, ok := "", false
+		if l.Service != "" {
+			repo, ok = resolve(l.Service)
+		}
+		if !ok {
+			attributed[key{"", l.Month, l.Currency}] += l.Amount
+			other[key{l.Service, l.Month, l.Currency}] += l.Amount
+			continue
+		}
+		attributed[key{repo, l.Month, l.Currency}] += l.Amount
+	}
+	collect := func(m map[key]float64) []MonthlyCost {
+		out := make([]MonthlyCost, 0, len(m))
+		for k, amount := range m {
+			out = append(out, MonthlyCost{Service: k.service, Month: k.month, Currency: k.currency, Amount: round(amount)})
+		}
+		Sort(out)
+		return out
+	}
+	return collect(attributed), collect(other)
+}
+
+// Sort orders costs by service, then newest month first, then currency.
+func Sort(costs []MonthlyCost) {
+	sort.Slice(costs, func(i, j int) bool {
+		a, b := costs[i], costs[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Month != b.Month {
+			return a.Month > b.Month
+		}
+		return a.Currency < b.Currency
+	})
+}
+
+// round rounds an amount to cents, dropping the float noise of summing many
+// small line items.
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// csvRows reads a CSV export into rows keyed by header.
+func csvRows(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i >= len(record) {
+				break
+			}
+			value := strings.TrimSpace(record[i])
+			// Azure and FOCUS exports put all tags in one JSON column.
+			if strings.HasPrefix(value, "{") && strings.EqualFold(lastSegment(name), "tags") {
+				var tags map[string]any
+				if json.Unmarshal([]byte(value), &tags) == nil {
+					flatten(row, "tags", tags)
+					continue
+				}
+			}
+			row[name] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// jsonRows reads a JSON export into rows keyed by dotted path.
+func jsonRows(data []byte) ([]map[string]string, error) {
+	var items []map[string]any
+	if data[0] == '{' {
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w", err)
+		}
+		for _, key := range []string{"costs", "items", "rows", "lineItems", "data"} {
+			if raw, ok := wrapper[key]; ok {
+				data = raw
+				break
+			}
+		}
+		if data[0] == '{' {
+			return nil, fmt.Errorf("JSON export has no list of line items")
+		}
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	rows := make([]map[string]string, len(items))
+	for i, item := range items {
+		rows[i] = make(map[string]string)
+		flatten(rows[i], "", item)
+	}
+	return rows, nil
+}
+
+// flatten writes v's values into row under their dotted paths. Lists of
+// {"key": ..., "value": ...} pairs, as GCP exports labels, become keys.
+func flatten(row map[string]string, prefix string, v any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flatten(row, join(k), child)
+		}
+	case []any:
+		for _, item := range v {
+			pair, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if k, ok := pair["key"].(string); ok {
+				flatten(row, join(k), pair["value"])
+			}
+		}
+	case string:
+		row[prefix] = strings.TrimSpace(v)
+	case float64:
+		row[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+	default:
+		row[prefix] = fmt.Sprint(v)
+	}
+}
+
+// serviceColumn finds the column holding the tag: one named after the tag
+// with a tag prefix ("tag:service", "labels.service") wins over one named
+// just "service".
+func serviceColumn(rows []map[string]string, tag string) string {
+	var plain string
+	for _, name := range columns(rows) {
+		lower := strings.ToLower(name)
+		if lower == tag {
+			plain = name
+			continue
+		}
+		if strings.ToLower(lastSegment(name)) == tag {
+			return name
+		}
+		for _, prefix := range []string{"resource_tags_user_", "resource_tags_", "tags_", "labels_"} {
+			if rest, ok := strings.CutPrefix(lower, prefix); ok && rest == tag {
+				return name
+			}
+		}
+	}
+	return plain
+}
+
+// column returns the first of rows' columns matching wanted, in wanted's
+// order, or "".
+func column(rows []map[string]string, wanted []string) string {
+	byName := make(map[string]string)
+	for _, name := range columns(rows) {
+		n := normalize(name)
+		if _, ok := byName[n]; !ok {
+			byName[n] = name
+		}
+	}
+	for _, w := range wanted {
+		if name, ok := byName[w]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// columns lists the columns of rows, sorted.
+func columns(rows []map[string]string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, row := range rows {
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// lastSegment returns the part of a column name after its last ":", "/", or
+// ".", as in "resourceTags/user:service".
+func lastSegment(name string) string {
+	return name[strings.LastIndexAny(name, ":/.")+1:]
+}
+
+// normalize lower-cases a column name and drops all but letters and digits.
+func normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var monthLayouts = []string{
+	"2006-01", "200601", "2006-01-02", time.RFC3339, "2006-01-02T15:04:05Z", "2006-01-02T15:04Z",
+	"2006-01-02 15:04:05", "2006/01/02", "01/02/2006",
+}
+
+// parseMonth reads the month of a billing period or usage date.
+func parseMonth(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	// AWS billing periods are ranges like 2024-01-01T00:00:00Z/2024-02-01T00:00:00Z.
+	if start, _, ok := strings.Cut(s, "/"); ok && len(start) >= 10 && start[4] == '-' {
+		s = start
+	}
+	for _, layout := range monthLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01"), nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized date %q", s)
+}
+
+// parseAmount reads a cost, ignoring currency symbols and thousands
+// separators.
+func parseAmount(s string) (float64, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, s)
+	if cleaned == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognized cost %q", s)
+	}
+	return amount, nil
+}
+
+// currencySymbols are written before amounts in their currency.
+var currencySymbols = map[string]string{"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹"}
+
+// Format writes an amount with its currency and thousands separators, e.g.
+// "$1,234.50" or "1,234.50 CHF".
+func Format(amount float64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	whole, cents, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + b.String() + "." + cents
+	}
+	return sign + b.String() + "." + cents + " " + currency
+}
@@ -0,0 +1,125 @@
+package costs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func TestParse(t *testing.T) {
+	cur := "identity/LineItemId,bill/BillingPeriodStartDate,lineItem/UnblendedCost,lineItem/CurrencyCode,resourceTags/user:service\n" +
+		"a,2026-09-01T00:00:00Z,10.25,USD,orders-service\n" +
+		"b,2026-09-01T00:00:00Z,4.75,USD,orders-service\n" +
+		"c,2026-09-01T00:00:00Z,\"1,000.00\",USD,\n" +
+		"d,2026-08-01T00:00:00Z,12,USD,payments\n"
+	lines, err := Parse([]byte(cur), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 4 || lines[0] != (Line{Service: "orders-service", Month: "2026-09", Amount: 10.25, Currency: "USD"}) || lines[2].Amount != 1000 {
+		t.Errorf("CUR lines = %+v", lines)
+	}
+
+	gcp := `[
+		{"invoice": {"month": "202609"}, "cost": 3.5, "currency": "EUR", "labels": [{"key": "team", "value": "core"}, {"key": "app", "value": "ledger"}]},
+		{"invoice": {"month": "202609"}, "cost": 1.5, "currency": "EUR", "labels": []}
+	]`
+	lines, err = Parse([]byte(gcp), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0] != (Line{Service: "ledger", Month: "2026-09", Amount: 3.5, Currency: "EUR"}) || lines[1].Service != "" {
+		t.Errorf("GCP lines = %+v", lines)
+	}
+
+	azure := "Date,CostInBillingCurrency,BillingCurrencyCode,Tags\n09/14/2026,2.00,GBP,\"{\"\"service\"\": \"\"payments\"\"}\"\n"
+	lines, err = Parse([]byte(azure), "service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0] != (Line{Service: "payments", Month: "2026-09", Amount: 2, Currency: "GBP"}) {
+		t.Errorf("Azure lines = %+v", lines)
+	}
+
+	if _, err := Parse([]byte("month,cost\n2026-09,1\n"), "service"); err == nil || !strings.Contains(err.Error(), `"service" tag`) {
+		t.Errorf("missing tag column: err = %v", err)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	resolve := func(s string) (string, bool) {
+		name := strings.TrimSuffix(strings.ToLower(s), "-service")
+		return name, name == "orders" || name == "payments"
+	}
+	got, unattributed := Attribute([]Line{
+		{Service: "orders-service", Month: "2026-09", Amount: 10.1, Currency: "USD"},
+		{Service: "Orders", Month: "2026-09", Amount: 0.2, Currency: "USD"},
+		{Service: "payments", Month: "2026-08", Amount: 5, Currency: "USD"},
+		{Service: "search", Month: "2026-09", Amount: 7, Currency: "USD"},
+		{Month: "2026-09", Amount: 1, Currency: "USD"},
+	}, resolve)
+	want := []MonthlyCost{
+		{Service: "", Month: "2026-09", Currency: "USD", Amount: 8},
+		{Service: "orders", Month: "2026-09", Currency: "USD", Amount: 10.3},
+		{Service: "payments", Month: "2026-08", Currency: "USD", Amount: 5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Attribute = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cost %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if len(unattributed) != 2 || unattributed[0].Service != "" || unattributed[1].Service != "search" {
+		t.Errorf("unattributed = %+v", unattributed)
+	}
+}
+
+func TestStore(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+	store := NewStore(database)
+
+	if err := store.Record(ctx, []MonthlyCost{
+		{Service: "orders", Month: "2026-08", Currency: "USD", Amount: 9},
+		{Service: "orders", Month: "2026-09", Currency: "USD", Amount: 4},
+		{Service: "payments", Month: "2026-09", Currency: "USD", Amount: 2},
+	}, "august.csv"); err != nil {
+		t.Fatal(err)
+	}
+	// A fuller export of September replaces it, and keeps August.
+	if err := store.Record(ctx, []MonthlyCost{{Service: "orders", Month: "2026-09", Currency: "USD", Amount: 11}}, "september.csv"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Month != "2026-09" || got[0].Amount != 11 || got[1].Month != "2026-08" {
+		t.Errorf("List = %+v", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{1234.5, "USD", "$1,234.50"},
+		{999, "EUR", "€999.00"},
+		{1234567.891, "CHF", "1,234,567.89 CHF"},
+		{-12, "USD", "-$12.00"},
+	} {
+		if got := Format(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("Format(%v, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
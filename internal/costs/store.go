@@ -0,0 +1,85 @@
+package costs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// Store persists monthly costs per service.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a Store backed by the given database.
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+// Record saves costs. Each month in costs replaces what was stored for that
+// month, since billing exports restate a month in full until it closes;
+// other months are kept.
+func (s *Store) Record(ctx context.Context, costs []MonthlyCost, source string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("saving costs: %w", err)
+	}
+	defer tx.Rollback()
+
+	cleared := make(map[string]bool)
+	now := time.Now().UTC()
+	for _, c := range costs {
+		if !cleared[c.Month] {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM service_costs WHERE month = ?`, c.Month); err != nil {
+				return fmt.Errorf("replacing costs for %s: %w", c.Month, err)
+			}
+			cleared[c.Month] = true
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO service_costs (service, month, currency, amount, source, imported_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(service, month, currency) DO UPDATE SET
+				amount = service_costs.amount + excluded.amount,
+				source = excluded.source,
+				imported_at = excluded.imported_at`,
+			c.Service, c.Month, c.Currency, c.Amount, source, now)
+		if err != nil {
+			return fmt.Errorf("saving cost of %s for %s: %w", c.Service, c.Month, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saving costs: %w", err)
+	}
+	return nil
+}
+
+// List returns all stored costs, by service, newest month first.
+func (s *Store) List(ctx context.Context) ([]MonthlyCost, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT service, month, currency, amount
+		FROM service_costs ORDER BY service, month DESC, currency`)
+	if err != nil {
+		return nil, fmt.Errorf("querying service costs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MonthlyCost
+	for rows.Next() {
+		var c MonthlyCost
+		if err := rows.Scan(&c.Service, &c.Month, &c.Currency, &c.Amount); err != nil {
+			return nil, fmt.Errorf("scanning service cost: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Clear deletes all stored costs.
+func (s *Store) Clear(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM service_costs`); err != nil {
+		return fmt.Errorf("clearing service costs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,631 @@
+// Package daemon keeps the central server's repos fresh on a schedule. Each
+// registered repo is pulled (or cloned) when its cron schedule says it is
+// due, optionally re-indexed in its checkout, and re-imported; after a round
+// that changed anything the central site is regenerated and the
+// architecture changes and new schema versions it found are sent out as
+// notifications, along with the repos that have gone quiet. On a schedule of
+// its own, the teams still using deprecated endpoints are reminded.
+package daemon
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/runlog"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+// DefaultSchedule is used for repos when the config doesn't set one.
+const DefaultSchedule = "@hourly"
+
+// DefaultReminders is when the users of deprecated endpoints are reminded
+// when the config doesn't say.
+const DefaultReminders = "@weekly"
+
+// Jobs are the steps the daemon runs; they are supplied by the caller so the
+// daemon doesn't depend on how indexing and publishing are wired up.
+type Jobs struct {
+	// Index brings the checkout's own docs up to date. Optional.
+	Index func(ctx context.Context, repo registry.Repository) error
+	// Import re-imports the repo and its sub-services into the central
+	// database and re-discovers their links.
+	Import func(ctx context.Context, repo *registry.Repository) error
+	// Publish regenerates the central site. Optional.
+	Publish func(ctx context.Context) error
+	// Schemas records the topics' payload schemas and returns the new
+	// versions of those already known. Optional.
+	Schemas func(ctx context.Context) ([]schemareg.Schema, error)
+	// Deprecations returns the deprecated endpoints and the services still
+	// using them, for the scheduled reminders. Optional.
+	Deprecations func(ctx context.Context) ([]deprecation.Usage, error)
+	// Quiet returns the repos that have gone without a commit for too long
+	// and haven't been reported since, marking them reported. Optional.
+	Quiet func(ctx context.Context) ([]repohealth.Health, error)
+}
+
+// Daemon runs the scheduled syncs.
+type Daemon struct {
+	repos     *registry.Store
+	jobs      Jobs
+	fallback  Schedule
+	schedules map[string]Schedule // nil value: scheduling is off for the repo
+	reminders Schedule            // nil when reminders are off
+
+	dispatcher *notifications.Dispatcher
+	org        *orgstructure.Store
+	runs       *runlog.Log
+
+	// git runs a git command; replaced in tests.
+	git func(ctx context.Context, args ...string) error
+	now func() time.Time
+	log *slog.Logger
+
+	mu        sync.Mutex
+	started   time.Time
+	lastTick  time.Time
+	state     map[string]*repoState
+	publishes map[string]int // by result
+	notified  int
+	// nextReminder is when the users of deprecated endpoints are next
+	// reminded; zero until the first pass schedules it.
+	nextReminder time.Time
+}
+
+// repoState is what the daemon knows about one repo's syncs.
+type repoState struct {
+	Next        time.Time     `json:"next_sync,omitempty"`
+	Syncs       int           `json:"syncs"`
+	Failures    int           `json:"failures"`
+	LastError   string        `json:"last_error,omitempty"`
+	LastSuccess time.Time     `json:"last_success,omitempty"`
+	Duration    time.Duration `json:"last_duration_ns"`
+}
+
+// New creates a daemon over the repos in the registry, checking the
+// schedules in cfg.
+func New(repos *registry.Store, cfg config.DaemonConfig, jobs Jobs) (*Daemon, error) {
+	if jobs.Import == nil {
+		return nil, errors.New("daemon: an import job is required")
+	}
+	expr := cfg.Schedule
+	if expr == "" {
+		expr = DefaultSchedule
+	}
+	fallback, err := ParseSchedule(expr)
+	if err != nil {
+		return nil, fmt.Errorf("daemon.schedule: %w", err)
+	}
+	d := &Daemon{
+		repos:     repos,
+		jobs:      jobs,
+		fallback:  fallback,
+		schedules: make(map[string]Schedule),
+		git:       runGit,
+		now:       time.Now,
+		log:       slog.Default(),
+		state:     make(map[string]*repoState),
+		publishes: make(map[string]int),
+	}
+	for name, expr := range cfg.Repos {
+		if strings.EqualFold(strings.TrimSpace(expr), "off") {
+			d.schedules[name] = nil
+			continue
+		}
+		s, err := ParseSchedule(expr)
+		if err != nil {
+			return nil, fmt.Errorf("daemon.repos.%s: %w", name, err)
+		}
+		d.schedules[name] = s
+	}
+	if expr := cmp.Or(cfg.Reminders, DefaultReminders); !strings.EqualFold(strings.TrimSpace(expr), "off") {
+		if d.reminders, err = ParseSchedule(expr); err != nil {
+			return nil, fmt.Errorf("daemon.reminders: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// SetNotifications makes the daemon dispatch the architecture changes each
+// round finds. Owners, when set, fills in the teams that own the affected
+// services.
+func (d *Daemon) SetNotifications(dispatcher *notifications.Dispatcher, owners *orgstructure.Store) {
+	d.dispatcher = dispatcher
+	d.org = owners
+}
+
+// SetRunLog records each round in the audit log of generation runs.
+func (d *Daemon) SetRunLog(l *runlog.Log) {
+	d.runs = l
+}
+
+// schedule returns the repo's schedule, or nil when it is off.
+func (d *Daemon) schedule(repo string) Schedule {
+	if s, ok := d.schedules[repo]; ok {
+		return s
+	}
+	return d.fallback
+}
+
+// Run syncs repos as they come due until ctx is cancelled. With syncNow
+// every repo is synced once right away instead of waiting for its first
+// scheduled time. Repos registered while the daemon runs are picked up
+// within a minute.
+func (d *Daemon) Run(ctx context.Context, syncNow bool) error {
+	d.mu.Lock()
+	d.started = d.now()
+	d.mu.Unlock()
+
+	for {
+		repos, err := d.repos.List(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.log.Error("listing repositories", "err", err)
+		}
+		if due := d.plan(repos, syncNow); len(due) > 0 {
+			d.round(ctx, due)
+		}
+		if d.reminderDue() {
+			d.remind(ctx)
+		}
+		syncNow = false
+
+		wait := time.Minute
+		if next := d.nextDue(); !next.IsZero() {
+			wait = min(max(next.Sub(d.now()), time.Second), time.Minute)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// plan records when each top-level repo is next due and returns the repos
+// that are due now. Sub-services are synced with their monorepo.
+func (d *Daemon) plan(repos []registry.Repository, syncNow bool) []registry.Repository {
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastTick = now
+
+	seen := make(map[string]bool)
+	var due []registry.Repository
+	for _, r := range repos {
+		if r.Parent != "" {
+			continue
+		}
+		seen[r.Name] = true
+		sched := d.schedule(r.Name)
+		st := d.state[r.Name]
+		if st == nil {
+			st = &repoState{}
+			d.state[r.Name] = st
+		}
+		if sched == nil {
+			st.Next = time.Time{}
+			continue
+		}
+		if syncNow {
+			st.Next = now
+		} else if st.Next.IsZero() {
+			st.Next = sched.Next(now)
+		}
+		if !st.Next.IsZero() && !now.Before(st.Next) {
+			due = append(due, r)
+		}
+	}
+	for name := range d.state {
+		if !seen[name] {
+			delete(d.state, name) // unregistered
+		}
+	}
+	return due
+}
+
+// nextDue returns the earliest next sync or reminder, or the zero time if
+// none is scheduled.
+func (d *Daemon) nextDue() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	next := d.nextReminder
+	for _, st := range d.state {
+		if !st.Next.IsZero() && (next.IsZero() || st.Next.Before(next)) {
+			next = st.Next
+		}
+	}
+	return next
+}
+
+// round syncs the due repos one after another and then the schemas, and
+// publishes the site and dispatches notifications if anything changed,
+// including for the repos that have gone quiet.
+// Each round is one run in the audit log.
+func (d *Daemon) round(ctx context.Context, due []registry.Repository) {
+	run := d.startRun()
+	log := d.log.With("run_id", run.ID)
+	since := time.Now().UTC()
+	synced := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		repo := &due[i]
+		start := d.now()
+		log.Info("syncing", "repo", repo.Name)
+		err := d.sync(ctx, repo)
+		d.finish(repo.Name, start, err)
+		if err != nil {
+			log.Error("sync failed", "repo", repo.Name, "err", err)
+			run.Changed("failed %s: %v", repo.Name, err)
+			continue
+		}
+		synced++
+		log.Info("synced", "repo", repo.Name, "files", repo.FileCount)
+		run.Changed("synced %s (%d files)", repo.Name, repo.FileCount)
+	}
+	run.Summary = fmt.Sprintf("synced %d/%d repositories", synced, len(due))
+
+	var schemas []schemareg.Schema
+	if d.jobs.Schemas != nil && ctx.Err() == nil {
+		var err error
+		if schemas, err = d.jobs.Schemas(ctx); err != nil {
+			log.Error("syncing schemas", "phase", "schemas", "err", err)
+		}
+		for _, s := range schemas {
+			run.Changed("new schema version %s", s.Label())
+		}
+	}
+
+	var quiet []repohealth.Health
+	if synced > 0 && d.jobs.Quiet != nil && ctx.Err() == nil {
+		var err error
+		if quiet, err = d.jobs.Quiet(ctx); err != nil {
+			log.Error("checking for quiet repositories", "phase", "health", "err", err)
+		}
+	}
+
+	if synced > 0 && d.repos != nil {
+		if _, err := d.repos.RecordSnapshot(ctx, run.ID); err != nil {
+			log.Warn("could not snapshot the architecture", "err", err)
+		}
+	}
+	if synced > 0 || len(schemas) > 0 {
+		if d.jobs.Publish != nil {
+			result := "success"
+			if err := d.jobs.Publish(ctx); err != nil {
+				result = "failure"
+				log.Error("regenerating the site", "phase", "publish", "err", err)
+			} else {
+				run.Changed("regenerated the central site")
+			}
+			d.mu.Lock()
+			d.publishes[result]++
+			d.mu.Unlock()
+		}
+		if err := d.notify(ctx, since, run, schemas, quiet); err != nil {
+			log.Error("dispatching notifications", "phase", "notify", "err", err)
+		}
+	}
+
+	var err error
+	if failed := len(due) - synced; failed > 0 {
+		err = fmt.Errorf("%d of %d repositories did not sync", failed, len(due))
+	}
+	if err := run.Finish(err); err != nil {
+		log.Warn("could not record the run in the audit log", "err", err)
+	}
+}
+
+// startRun starts the audit log entry for a round.
+func (d *Daemon) startRun() *runlog.Run {
+	if d.runs == nil {
+		return &runlog.Run{ID: runlog.NewID(), Command: "daemon"}
+	}
+	run, err := d.runs.Start("daemon", "")
+	if err != nil {
+		d.log.Warn("could not record the run in the audit log", "err", err)
+	}
+	return run
+}
+
+// sync brings one repo up to date: clone or pull, index, import.
+func (d *Daemon) sync(ctx context.Context, repo *registry.Repository) error {
+	if repo.SourceType == "git" {
+		if _, err := os.Stat(filepath.Join(repo.LocalPath, ".git")); os.IsNotExist(err) {
+			if repo.SourceURL == "" {
+				return fmt.Errorf("checkout %s is missing and there is no source URL to clone", repo.LocalPath)
+			}
+			if err := d.git(ctx, "clone", repo.SourceURL, repo.LocalPath); err != nil {
+				return fmt.Errorf("git clone: %w", err)
+			}
+		} else if err := d.git(ctx, "-C", repo.LocalPath, "pull", "--ff-only"); err != nil {
+			return fmt.Errorf("git pull: %w", err)
+		}
+	}
+	if d.jobs.Index != nil {
+		if err := d.jobs.Index(ctx, *repo); err != nil {
+			return fmt.Errorf("indexing: %w", err)
+		}
+	}
+	if err := d.jobs.Import(ctx, repo); err != nil {
+		return fmt.Errorf("importing: %w", err)
+	}
+	return nil
+}
+
+// finish records a sync's outcome and schedules the repo's next one.
+func (d *Daemon) finish(name string, start time.Time, err error) {
+	end := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := d.state[name]
+	if st == nil {
+		st = &repoState{}
+		d.state[name] = st
+	}
+	st.Syncs++
+	st.Duration = end.Sub(start)
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	} else {
+		st.LastError = ""
+		st.LastSuccess = end
+	}
+	if sched := d.schedule(name); sched != nil {
+		st.Next = sched.Next(end)
+	}
+}
+
+// reminderDue reports whether the users of deprecated endpoints are due a
+// reminder, and schedules the next one. The first pass only schedules it,
+// so restarting the daemon doesn't send reminders early.
+func (d *Daemon) reminderDue() bool {
+	if d.reminders == nil || d.jobs.Deprecations == nil || d.dispatcher == nil {
+		return false
+	}
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.nextReminder.IsZero() {
+		d.nextReminder = d.reminders.Next(now)
+		return false
+	}
+	if now.Before(d.nextReminder) {
+		return false
+	}
+	d.nextReminder = d.reminders.Next(now)
+	return true
+}
+
+// remind notifies the teams of the services still using each deprecated
+// endpoint, until none do. Each reminder is one run in the audit log.
+func (d *Daemon) remind(ctx context.Context) {
+	run := d.startRun()
+	log := d.log.With("run_id", run.ID)
+	usages, err := d.jobs.Deprecations(ctx)
+	reminded := 0
+	for _, u := range usages {
+		if len(u.Consumers) == 0 || ctx.Err() != nil {
+			continue
+		}
+		n := deprecationNotification(u, d.now())
+		n.AffectedTeams = d.owners(ctx, n.AffectedServices)
+		if err = d.dispatcher.Dispatch(ctx, n); err != nil {
+			break
+		}
+		reminded++
+		run.Changed("notified: %s", n.Title)
+		d.mu.Lock()
+		d.notified++
+		d.mu.Unlock()
+	}
+	if err != nil {
+		log.Error("reminding the users of deprecated endpoints", "phase", "deprecations", "err", err)
+	}
+	run.Summary = fmt.Sprintf("deprecation reminders sent: %d", reminded)
+	if err := run.Finish(err); err != nil {
+		log.Warn("could not record the run in the audit log", "err", err)
+	}
+}
+
+// notify dispatches a notification for each architecture change recorded
+// since the round started, each new schema version, and each repo that has
+// gone quiet.
+func (d *Daemon) notify(ctx context.Context, since time.Time, run *runlog.Run, schemas []schemareg.Schema, quiet []repohealth.Health) error {
+	if d.dispatcher == nil {
+		return nil
+	}
+	changes, err := d.repos.ListChanges(ctx, since)
+	if err != nil {
+		return err
+	}
+	var pending []notifications.Notification
+	// ListChanges is newest first; send them in the order they happened.
+	for i := len(changes) - 1; i >= 0; i-- {
+		pending = append(pending, changeNotification(changes[i]))
+	}
+	if len(schemas) > 0 {
+		links, err := d.repos.GetLinks(ctx, "")
+		if err != nil {
+			return err
+		}
+		for _, s := range schemas {
+			pending = append(pending, schemaNotification(s, links))
+		}
+	}
+	for _, h := range quiet {
+		pending = append(pending, quietNotification(h, d.now()))
+	}
+	for _, n := range pending {
+		n.AffectedTeams = d.owners(ctx, n.AffectedServices)
+		if err := d.dispatcher.Dispatch(ctx, n); err != nil {
+			return err
+		}
+		run.Changed("notified: %s", n.Title)
+		d.mu.Lock()
+		d.notified++
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// changeNotification describes an architecture change as a notification.
+func changeNotification(c registry.ArchChange) notifications.Notification {
+	n := notifications.Notification{
+		Title:    c.Describe(),
+		Severity: notifications.SeverityInfo,
+	}
+	switch c.Kind {
+	case registry.ChangeServiceAdded:
+		n.Type = notifications.TypeServiceAdded
+		n.AffectedServices = []string{c.Repo}
+	case registry.ChangeServiceRemoved:
+		n.Type = notifications.TypeServiceRemoved
+		n.Severity = notifications.SeverityWarning
+		n.AffectedServices = []string{c.Repo}
+	default:
+		n.Type = notifications.TypeRelationshipChanged
+		n.LinkType = c.LinkType
+		n.AffectedServices = []string{c.FromRepo, c.ToRepo}
+		if c.Kind == registry.ChangeDependencyRemoved {
+			n.Severity = notifications.SeverityWarning
+		}
+	}
+	n.Message = "Detected by a scheduled sync of " + c.Repo + "."
+	if url := c.CommitURL(); url != "" {
+		n.Message += " Commit: " + url
+	}
+	return n
+}
+
+// quietNotification tells the owners of a repo that has gone without a
+// commit for a while, whose docs may be describing a service nobody runs.
+func quietNotification(h repohealth.Health, now time.Time) notifications.Notification {
+	return notifications.Notification{
+		Type:     notifications.TypeStalenessDetected,
+		Severity: notifications.SeverityInfo,
+		Title:    fmt.Sprintf("%s has had no commits for %d days", h.Repo, int(now.Sub(h.LastCommitAt).Hours()/24)),
+		Message: fmt.Sprintf("Its last commit was on %s. If it is retired, unregister it so its docs stop describing it as running.",
+			h.LastCommitAt.Format(time.DateOnly)),
+		AffectedServices: []string{h.Repo},
+	}
+}
+
+// schemaNotification describes a new schema version as a notification to
+// the services producing and consuming its topic, or declaring its file.
+// Removing a field or changing its type is a warning.
+func schemaNotification(s schemareg.Schema, links []registry.ServiceLink) notifications.Notification {
+	n := notifications.Notification{
+		Type:     notifications.TypeSchemaChanged,
+		Severity: notifications.SeverityInfo,
+		Title:    "Schema " + s.Label(),
+	}
+	var changes []string
+	for _, c := range s.Changes {
+		changes = append(changes, c.String())
+		if c.Breaking() {
+			n.Severity = notifications.SeverityWarning
+		}
+	}
+	if len(changes) == 0 {
+		n.Message = "A new version with the same fields."
+	} else {
+		n.Message = "Fields: " + strings.Join(changes, "; ") + "."
+	}
+
+	if s.Repo != "" {
+		n.AffectedServices = append(n.AffectedServices, s.Repo)
+	}
+	if topic := schemareg.Topic(s.Subject); topic != "" {
+		for _, l := range links {
+			if !slices.Contains(l.Endpoints, topic) {
+				continue
+			}
+			n.LinkType = cmp.Or(n.LinkType, l.LinkType)
+			for _, svc := range []string{l.FromRepo, l.ToRepo} {
+				if !slices.Contains(n.AffectedServices, svc) {
+					n.AffectedServices = append(n.AffectedServices, svc)
+				}
+			}
+		}
+		n.Message += " Topic: " + topic + "."
+	}
+	sort.Strings(n.AffectedServices)
+	return n
+}
+
+// deprecationNotification reminds the services still using a deprecated
+// endpoint. It is a warning, and critical from a week before the sunset.
+func deprecationNotification(u deprecation.Usage, now time.Time) notifications.Notification {
+	n := notifications.Notification{
+		Type:             notifications.TypeDeprecatedInUse,
+		Severity:         notifications.SeverityWarning,
+		Title:            fmt.Sprintf("Deprecated %s of %s still in use", u.Endpoint, u.Service),
+		AffectedServices: u.Consumers,
+	}
+	n.Message = "Still used by " + strings.Join(u.Consumers, ", ") + "."
+	if days, ok := u.DaysLeft(now); ok {
+		n.Message += fmt.Sprintf(" Sunset %s (%s).", u.Sunset.Format(time.DateOnly), u.Countdown(now))
+		if days <= 7 {
+			n.Severity = notifications.SeverityCritical
+		}
+	}
+	if u.Replacement != "" {
+		n.Message += " Use " + u.Replacement + " instead."
+	}
+	if u.Note != "" {
+		n.Message += " " + strings.TrimSuffix(u.Note, ".") + "."
+	}
+	return n
+}
+
+// owners returns the IDs of the teams that own any of services.
+func (d *Daemon) owners(ctx context.Context, services []string) []string {
+	if d.org == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var teams []string
+	for _, svc := range services {
+		ownerships, err := d.org.GetOwnership(ctx, svc)
+		if err != nil {
+			continue
+		}
+		for _, o := range ownerships {
+			if !seen[o.TeamID] {
+				seen[o.TeamID] = true
+				teams = append(teams, o.TeamID)
+			}
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,377 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/runlog"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Sunday 1 March 2026, 12:34.
+	from := time.Date(2026, 3, 1, 12, 34, 56, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"@hourly", time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 1, 12, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches.
+		{"0 0 15 * fri", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"5,10 12 1 3 *", time.Date(2027, 3, 1, 12, 5, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2026, 3, 1, 14, 4, 56, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	never, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := never.Next(from); !got.IsZero() {
+		t.Errorf("February 30th: Next = %v, want the zero time", got)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "* * * * someday", "@often", "@every 10s"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", expr)
+		}
+	}
+}
+
+func TestNewSchedules(t *testing.T) {
+	if _, err := New(nil, config.DaemonConfig{}, Jobs{}); err == nil {
+		t.Error("New without an import job should fail")
+	}
+	jobs := Jobs{Import: func(context.Context, *registry.Repository) error { return nil }}
+	if _, err := New(nil, config.DaemonConfig{Repos: map[string]string{"orders": "every day"}}, jobs); err == nil || !strings.Contains(err.Error(), "daemon.repos.orders") {
+		t.Errorf("a bad repo schedule should name the repo, got %v", err)
+	}
+	d, err := New(nil, config.DaemonConfig{Repos: map[string]string{"legacy": "off", "orders": "*/5 * * * *"}}, jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.schedule("legacy") != nil || d.schedule("orders") == nil || d.schedule("other") != d.fallback {
+		t.Error("off, per-repo, and default schedules should be resolved by repo name")
+	}
+}
+
+func TestRound(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	repos := registry.NewStore(database)
+	checkout := t.TempDir()
+	os.Mkdir(filepath.Join(checkout, ".git"), 0o755)
+	for _, r := range []registry.Repository{
+		{Name: "orders", SourceType: "git", SourceURL: "https://github.com/acme/orders", LocalPath: checkout},
+		{Name: "payments", SourceType: "git", SourceURL: "https://github.com/acme/payments", LocalPath: filepath.Join(t.TempDir(), "payments")},
+		{Name: "ledger", SourceType: "local", LocalPath: t.TempDir()},
+		{Name: "ledger-api", SourceType: "local", Parent: "ledger"},
+	} {
+		r := r
+		if err := repos.Add(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	org := orgstructure.NewStore(database)
+	team := &orgstructure.Team{Name: "commerce"}
+	if err := org.CreateTeam(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+	org.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team.ID, RepoID: "orders", Confidence: "confirmed", Source: "manual"})
+
+	var imported, indexed []string
+	published := 0
+	d, err := New(repos, config.DaemonConfig{Repos: map[string]string{"ledger": "off"}, Index: true}, Jobs{
+		Index: func(_ context.Context, repo registry.Repository) error {
+			indexed = append(indexed, repo.Name)
+			return nil
+		},
+		Import: func(ctx context.Context, repo *registry.Repository) error {
+			imported = append(imported, repo.Name)
+			if repo.Name == "payments" {
+				return errors.New("boom")
+			}
+			return repos.RecordChanges(ctx, []registry.ArchChange{{
+				Repo: "orders", Kind: registry.ChangeDependencyAdded, FromRepo: "orders", ToRepo: "payments",
+				LinkType: "grpc", Endpoints: []string{"Charge"}, CommitSHA: "abc123", SourceURL: repo.SourceURL,
+			}})
+		},
+		Publish: func(context.Context) error {
+			published++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gitCalls []string
+	d.git = func(_ context.Context, args ...string) error {
+		gitCalls = append(gitCalls, strings.Join(args, " "))
+		return nil
+	}
+	d.log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	runs := runlog.Open(t.TempDir())
+	d.SetRunLog(runs)
+	d.SetNotifications(notifications.NewDispatcher(notifications.NewStore(database)), org)
+
+	list, err := repos.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.started = time.Now()
+	if due := d.plan(list, false); len(due) != 0 {
+		t.Errorf("nothing should be due before its first scheduled time, got %d", len(due))
+	}
+	due := d.plan(list, true)
+	if len(due) != 2 {
+		t.Fatalf("due = %v; want orders and payments (ledger is off, ledger-api goes with ledger)", due)
+	}
+	d.round(ctx, due)
+
+	if got := strings.Join(gitCalls, "; "); !strings.Contains(got, "-C "+checkout+" pull --ff-only") || !strings.Contains(got, "clone https://github.com/acme/payments") {
+		t.Errorf("git calls = %s; want a pull of orders and a clone of payments", got)
+	}
+	if strings.Join(indexed, ",") != "orders,payments" || strings.Join(imported, ",") != "orders,payments" {
+		t.Errorf("indexed %v, imported %v", indexed, imported)
+	}
+	if published != 1 {
+		t.Errorf("published %d times, want once after the round", published)
+	}
+
+	sent, err := notifications.NewStore(database).List(ctx, notifications.ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("notifications = %+v, want one", sent)
+	}
+	n := sent[0]
+	if n.Type != notifications.TypeRelationshipChanged || n.Title != "orders added dependency on payments (Charge)" || n.LinkType != "grpc" ||
+		!strings.Contains(n.Message, "https://github.com/acme/orders/commit/abc123") || strings.Join(n.AffectedTeams, ",") != team.ID {
+		t.Errorf("notification = %+v", n)
+	}
+
+	logged, err := runs.List(runlog.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0].Command != "daemon" || logged[0].Status != runlog.StatusFailed || logged[0].Summary != "synced 1/2 repositories" {
+		t.Fatalf("audit log = %+v; want one failed daemon round", logged)
+	}
+	if got := strings.Join(logged[0].Changes, "\n"); !strings.Contains(got, "synced orders (0 files)") || !strings.Contains(got, "failed payments: importing: boom") ||
+		!strings.Contains(got, "regenerated the central site") || !strings.Contains(got, "notified: orders added dependency on payments") {
+		t.Errorf("round changes:\n%s", got)
+	}
+
+	if st := d.state["payments"]; st.Failures != 1 || !strings.Contains(st.LastError, "boom") || st.Next.IsZero() {
+		t.Errorf("payments state = %+v", st)
+	}
+
+	rec := httptest.NewRecorder()
+	d.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	metrics := rec.Body.String()
+	for _, want := range []string{
+		"autodoc_daemon_up 1",
+		`autodoc_daemon_syncs_total{repo="orders",result="success"} 1`,
+		`autodoc_daemon_syncs_total{repo="payments",result="failure"} 1`,
+		`autodoc_daemon_next_sync_timestamp_seconds{repo="ledger"} 0.000`,
+		`autodoc_daemon_publishes_total{result="success"} 1`,
+		"autodoc_daemon_notifications_total 1",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	d.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 200 {
+		t.Errorf("healthz = %d %s", rec.Code, rec.Body)
+	}
+	d.now = func() time.Time { return time.Now().Add(3 * time.Hour) }
+	rec = httptest.NewRecorder()
+	d.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 503 {
+		t.Errorf("a stalled scheduler should be unhealthy, got %d", rec.Code)
+	}
+}
+
+func TestSchemaNotification(t *testing.T) {
+	links := []registry.ServiceLink{
+		{FromRepo: "orders", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"orders.placed"}},
+		{FromRepo: "orders", ToRepo: "billing", LinkType: "kafka", Endpoints: []string{"orders.placed", "orders.cancelled"}},
+		{FromRepo: "orders", ToRepo: "payments", LinkType: "http"},
+	}
+	n := schemaNotification(schemareg.Schema{
+		Subject: "orders.placed-value", Version: 3,
+		Changes: []schemareg.FieldChange{{Field: "email", New: "string"}, {Field: "phone", Old: "string"}},
+	}, links)
+	if n.Type != notifications.TypeSchemaChanged || n.Severity != notifications.SeverityWarning || n.LinkType != "kafka" ||
+		n.Title != "Schema orders.placed-value v3" || strings.Join(n.AffectedServices, ",") != "billing,mailer,orders" ||
+		n.Message != "Fields: added email (string); removed phone (string). Topic: orders.placed." {
+		t.Errorf("notification = %+v", n)
+	}
+
+	// A schema file's version goes to the repo declaring it; adding a
+	// field breaks no one.
+	n = schemaNotification(schemareg.Schema{
+		Subject: "ledger:schemas/entry.avsc#Entry", Version: 2, Name: "Entry", Repo: "ledger", Source: "schemas/entry.avsc",
+		Changes: []schemareg.FieldChange{{Field: "memo", New: "null|string"}},
+	}, links)
+	if n.Severity != notifications.SeverityInfo || n.Title != "Schema Entry v2 (ledger: schemas/entry.avsc)" || strings.Join(n.AffectedServices, ",") != "ledger" {
+		t.Errorf("schema file notification = %+v", n)
+	}
+}
+
+func TestReminders(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	if d, err := New(registry.NewStore(database), config.DaemonConfig{Reminders: "off"}, Jobs{Import: func(context.Context, *registry.Repository) error { return nil }}); err != nil || d.reminders != nil {
+		t.Fatalf("reminders off = %v, %v", d.reminders, err)
+	}
+
+	// Monday 18 January 2027, 08:00.
+	now := time.Date(2027, 1, 18, 8, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 21, 0, 0, 0, 0, time.UTC)
+	d, err := New(registry.NewStore(database), config.DaemonConfig{Reminders: "0 9 * * mon"}, Jobs{
+		Import: func(context.Context, *registry.Repository) error { return nil },
+		Deprecations: func(context.Context) ([]deprecation.Usage, error) {
+			return []deprecation.Usage{
+				{Deprecation: deprecation.Deprecation{Service: "payments", Endpoint: "POST /v1/charges", Sunset: sunset, Replacement: "POST /v2/charges"}, Consumers: []string{"checkout", "web"}},
+				{Deprecation: deprecation.Deprecation{Service: "payments", Endpoint: "POST /v1/refunds"}},
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.now = func() time.Time { return now }
+	d.log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	runs := runlog.Open(t.TempDir())
+	d.SetRunLog(runs)
+	d.SetNotifications(notifications.NewDispatcher(notifications.NewStore(database)), nil)
+
+	if d.reminderDue() {
+		t.Error("the first pass should only schedule the reminder")
+	}
+	if want := now.Add(time.Hour); !d.nextReminder.Equal(want) || !d.nextDue().Equal(want) {
+		t.Errorf("next reminder = %v, next due = %v; want %v", d.nextReminder, d.nextDue(), want)
+	}
+	now = now.Add(time.Hour)
+	if !d.reminderDue() {
+		t.Fatal("the reminder should be due at its scheduled time")
+	}
+	d.remind(ctx)
+
+	sent, err := notifications.NewStore(database).List(ctx, notifications.ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("notifications = %+v; want one for the endpoint still in use", sent)
+	}
+	n := sent[0]
+	if n.Type != notifications.TypeDeprecatedInUse || n.Severity != notifications.SeverityCritical ||
+		n.Title != "Deprecated POST /v1/charges of payments still in use" || strings.Join(n.AffectedServices, ",") != "checkout,web" ||
+		n.Message != "Still used by checkout, web. Sunset 2027-01-21 (in 3 days). Use POST /v2/charges instead." {
+		t.Errorf("notification = %+v", n)
+	}
+	if logged, err := runs.List(runlog.Filter{}); err != nil || len(logged) != 1 || logged[0].Summary != "deprecation reminders sent: 1" {
+		t.Errorf("audit log = %+v, %v", logged, err)
+	}
+	if d.reminderDue() || !d.nextReminder.Equal(time.Date(2027, 1, 25, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("the next reminder should be a week later, got %v", d.nextReminder)
+	}
+}
+
+func TestQuietRepos(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	repos := registry.NewStore(database)
+	if err := repos.Add(ctx, &registry.Repository{Name: "legacy-billing", SourceType: "local", LocalPath: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2027, 1, 18, 8, 0, 0, 0, time.UTC)
+	lastCommit := time.Date(2026, 9, 1, 15, 30, 0, 0, time.UTC)
+	d, err := New(repos, config.DaemonConfig{}, Jobs{
+		Import: func(context.Context, *registry.Repository) error { return nil },
+		Quiet: func(context.Context) ([]repohealth.Health, error) {
+			return []repohealth.Health{{Repo: "legacy-billing", LastCommitAt: lastCommit}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.now = func() time.Time { return now }
+	d.log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	d.SetNotifications(notifications.NewDispatcher(notifications.NewStore(database)), nil)
+
+	list, err := repos.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.round(ctx, d.plan(list, true))
+
+	sent, err := notifications.NewStore(database).List(ctx, notifications.ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("notifications = %+v; want one for the quiet repo", sent)
+	}
+	n := sent[0]
+	if n.Type != notifications.TypeStalenessDetected || n.Title != "legacy-billing has had no commits for 138 days" ||
+		!strings.HasPrefix(n.Message, "Its last commit was on 2026-09-01.") || strings.Join(n.AffectedServices, ",") != "legacy-billing" {
+		t.Errorf("notification = %+v", n)
+	}
+}
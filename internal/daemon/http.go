@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stallAfter is how long the scheduling loop may go without a pass before
+// the daemon reports itself unhealthy. The loop passes at least once a
+// minute, but a pass waits for the round it starts.
+const stallAfter = 2 * time.Hour
+
+// Handler serves the daemon's health check at /healthz, Prometheus metrics
+// at /metrics, and each repo's sync state as JSON at /status.
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", d.handleHealth)
+	mux.HandleFunc("GET /metrics", d.handleMetrics)
+	mux.HandleFunc("GET /status", d.handleStatus)
+	return mux
+}
+
+func (d *Daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	started, last := d.started, d.lastTick
+	d.mu.Unlock()
+	switch {
+	case started.IsZero():
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+	case d.now().Sub(last) > stallAfter:
+		http.Error(w, fmt.Sprintf("scheduler stalled: last pass %s ago", d.now().Sub(last).Round(time.Second)), http.StatusServiceUnavailable)
+	default:
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+func (d *Daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	status := struct {
+		Started   time.Time            `json:"started"`
+		LastPass  time.Time            `json:"last_pass"`
+		Repos     map[string]repoState `json:"repos"`
+		Publishes map[string]int       `json:"publishes"`
+		Notified  int                  `json:"notifications"`
+		// NextReminder is when the users of deprecated endpoints are next
+		// reminded.
+		NextReminder time.Time `json:"next_reminder,omitempty"`
+	}{d.started, d.lastTick, make(map[string]repoState, len(d.state)), make(map[string]int), d.notified, d.nextReminder}
+	for name, st := range d.state {
+		status.Repos[name] = *st
+	}
+	for k, v := range d.publishes {
+		status.Publishes[k] = v
+	}
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(status)
+}
+
+// handleMetrics writes the Prometheus text exposition format.
+func (d *Daemon) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make([]string, 0, len(d.state))
+	for name := range d.state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	seconds := func(t time.Time) float64 {
+		if t.IsZero() {
+			return 0
+		}
+		return float64(t.UnixNano()) / 1e9
+	}
+
+	metric("autodoc_daemon_up", "gauge", "Whether the scheduling loop is running.")
+	up := 0
+	if !d.started.IsZero() && d.now().Sub(d.lastTick) <= stallAfter {
+		up = 1
+	}
+	fmt.Fprintf(&sb, "autodoc_daemon_up %d\n", up)
+
+	metric("autodoc_daemon_syncs_total", "counter", "Repo syncs by result.")
+	for _, name := range names {
+		st := d.state[name]
+		fmt.Fprintf(&sb, "autodoc_daemon_syncs_total{repo=%q,result=\"success\"} %d\n", name, st.Syncs-st.Failures)
+		fmt.Fprintf(&sb, "autodoc_daemon_syncs_total{repo=%q,result=\"failure\"} %d\n", name, st.Failures)
+	}
+	metric("autodoc_daemon_sync_duration_seconds", "gauge", "How long the repo's last sync took.")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "autodoc_daemon_sync_duration_seconds{repo=%q} %g\n", name, d.state[name].Duration.Seconds())
+	}
+	metric("autodoc_daemon_last_success_timestamp_seconds", "gauge", "When the repo last synced successfully; 0 if never.")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "autodoc_daemon_last_success_timestamp_seconds{repo=%q} %.3f\n", name, seconds(d.state[name].LastSuccess))
+	}
+	metric("autodoc_daemon_next_sync_timestamp_seconds", "gauge", "When the repo is next due; 0 if its schedule is off.")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "autodoc_daemon_next_sync_timestamp_seconds{repo=%q} %.3f\n", name, seconds(d.state[name].Next))
+	}
+
+	metric("autodoc_daemon_publishes_total", "counter", "Central site regenerations by result.")
+	for _, result := range []string{"success", "failure"} {
+		fmt.Fprintf(&sb, "autodoc_daemon_publishes_total{result=%q} %d\n", result, d.publishes[result])
+	}
+	metric("autodoc_daemon_notifications_total", "counter", "Architecture change notifications dispatched.")
+	fmt.Fprintf(&sb, "autodoc_daemon_notifications_total %d\n", d.notified)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,169 @@
+// Package dataclass tags kinds of sensitive data, such as email addresses
+// or card numbers, and finds the schemas and events whose fields carry
+// them.
+package dataclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// FactKeyPrefix is followed by the class name in an org-scoped
+// context-engine fact declaring a data class, e.g. "data_class:email". The
+// value is the category, optionally followed by a colon and the field
+// names, e.g. "PII: email, email_address".
+const FactKeyPrefix = "data_class:"
+
+// Class is a kind of sensitive data.
+type Class struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category,omitempty"`
+	Fields   []string `json:"fields"` // never empty: the name when none are declared
+	Source   string   `json:"source"` // "config" or "fact"
+}
+
+// Label renders the class with its category, e.g. "email (PII)".
+func (c Class) Label() string {
+	if c.Category == "" {
+		return c.Name
+	}
+	return c.Name + " (" + c.Category + ")"
+}
+
+// Matches reports whether a field name holds the class: whether the words of
+// one of the class's field names appear, in order and together, among the
+// field's. "card_number" matches cardNumber and billingCardNumber but not
+// card or numbers.
+func (c Class) Matches(field string) bool {
+	words := Words(field)
+	for _, f := range c.Fields {
+		want := Words(f)
+		if len(want) == 0 {
+			continue
+		}
+		for i := 0; i+len(want) <= len(words); i++ {
+			if equalWords(words[i:i+len(want)], want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Words splits an identifier into its lower-cased words, at underscores,
+// hyphens, dots, spaces, and case changes: "billingCardNumber",
+// "billing_card_number", and "HTTPStatus" give "billing card number" and
+// "http status".
+func Words(ident string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	runes := []rune(ident)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(cur) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// Classify returns, for each class, the fields that hold it, in the
+// classes' order. Classes no field holds are left out.
+func Classify(fields []string, classes []Class) map[string][]string {
+	out := make(map[string][]string)
+	for _, c := range classes {
+		for _, f := range fields {
+			if c.Matches(f) {
+				out[c.Name] = append(out[c.Name], f)
+			}
+		}
+	}
+	return out
+}
+
+// FromFact reads a data class declared in conversation.
+func FromFact(f contextengine.Fact) (Class, bool) {
+	name, ok := strings.CutPrefix(f.Key, FactKeyPrefix)
+	name = strings.TrimSpace(name)
+	if !ok || f.Scope != "org" || name == "" {
+		return Class{}, false
+	}
+	category, fields, _ := strings.Cut(f.Value, ":")
+	c := Class{Name: name, Category: strings.TrimSpace(category), Source: "fact"}
+	for _, field := range strings.FieldsFunc(fields, func(r rune) bool { return r == ',' || r == ';' }) {
+		if field = strings.TrimSpace(field); field != "" {
+			c.Fields = append(c.Fields, field)
+		}
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = []string{name}
+	}
+	return c, true
+}
+
+// Load collects the data classes declared in config and in conversation.
+// A config entry overrides a fact for the same class name. The result is
+// sorted by name.
+func Load(ctx context.Context, declared []config.DataClassConfig, facts contextengine.FactSource) ([]Class, error) {
+	found := make(map[string]Class)
+	if facts != nil {
+		fs, err := facts.GetCurrentFacts(ctx, "", "org", "")
+		if err != nil {
+			return nil, fmt.Errorf("loading data class facts: %w", err)
+		}
+		// Newest first: keep the first fact for each class.
+		for _, f := range fs {
+			c, ok := FromFact(f)
+			if _, seen := found[strings.ToLower(c.Name)]; ok && !seen {
+				found[strings.ToLower(c.Name)] = c
+			}
+		}
+	}
+	for _, d := range declared {
+		name := strings.TrimSpace(d.Name)
+		if name == "" {
+			return nil, fmt.Errorf("data_classes: a class has no name")
+		}
+		c := Class{Name: name, Category: d.Category, Fields: d.Fields, Source: "config"}
+		if len(c.Fields) == 0 {
+			c.Fields = []string{name}
+		}
+		found[strings.ToLower(name)] = c
+	}
+	out := make([]Class, 0, len(found))
+	for _, c := range found {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+	return out, nil
+}
@@ -0,0 +1,166 @@
+package dataclass
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func TestWords(t *testing.T) {
+	for in, want := range map[string][]string{
+		"billingCardNumber":   {"billing", "card", "number"},
+		"billing_card_number": {"billing", "card", "number"},
+		"HTTPStatus":          {"http", "status"},
+		"card number":         {"card", "number"},
+		"address2":            {"address2"},
+	} {
+		if got := Words(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("Words(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	card := Class{Name: "card number", Fields: []string{"card number", "pan"}}
+	for field, want := range map[string]bool{
+		"cardNumber":          true,
+		"billing_card_number": true,
+		"PAN":                 true,
+		"card":                false,
+		"card_numbers":        false,
+		"panel":               false,
+	} {
+		if got := card.Matches(field); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := contextengine.NewStore(database)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "org", ScopeID: "acme", Key: "data_class:email", Value: "PII: email, email_address"})
+	store.SaveFact(ctx, contextengine.Fact{Scope: "org", ScopeID: "acme", Key: "data_class:card number", Value: "PII"})
+	store.SaveFact(ctx, contextengine.Fact{Scope: "org", ScopeID: "acme", Key: "mission", Value: "Sell things."})
+
+	got, err := Load(ctx, []config.DataClassConfig{{Name: "Card Number", Category: "PCI", Fields: []string{"card_number", "pan"}}}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Class{
+		{Name: "Card Number", Category: "PCI", Fields: []string{"card_number", "pan"}, Source: "config"},
+		{Name: "email", Category: "PII", Fields: []string{"email", "email_address"}, Source: "fact"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+	if _, err := Load(ctx, []config.DataClassConfig{{Category: "PII"}}, nil); err == nil {
+		t.Error("a class without a name should be an error")
+	}
+}
+
+func TestScanSchemas(t *testing.T) {
+	tests := []struct {
+		file, src string
+		want      []string // "kind name line: fields"
+	}{
+		{
+			file: "orders.proto",
+			src: `syntax = "proto3";
+
+message OrderPlaced {
+  string order_id = 1;
+  Customer customer = 2; // who ordered
+  message Customer {
+    string email = 1;
+    map<string, string> tags = 2;
+  }
+  repeated string items = 3;
+}
+`,
+			want: []string{
+				"protobuf message OrderPlaced 3: [order_id customer items]",
+				"protobuf message Customer 6: [email tags]",
+			},
+		},
+		{
+			file: "signup.avsc",
+			src: `{
+  "type": "record",
+  "name": "UserSignedUp",
+  "fields": [
+    {"name": "email", "type": "string"},
+    {"name": "address", "type": {"type": "record", "name": "Address", "fields": [{"name": "postcode", "type": "string"}]}}
+  ]
+}`,
+			want: []string{
+				"Avro record Address 6: [postcode]",
+				"Avro record UserSignedUp 3: [email address]",
+			},
+		},
+		{
+			file: "schema.sql",
+			src: `CREATE TABLE IF NOT EXISTS public.customers (
+    id BIGINT PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    amount NUMERIC(10, 2),
+    UNIQUE (email)
+);`,
+			want: []string{"table customers 1: [id email amount]"},
+		},
+		{
+			file: "schema.graphql",
+			src: `type User {
+  id: ID!
+  phoneNumber(format: String): String
+}
+input SignupInput { email: String! }
+`,
+			want: []string{"GraphQL type User 1: [id phoneNumber]", "GraphQL type SignupInput 5: []"},
+		},
+		{
+			file: "openapi.yaml",
+			src: `openapi: 3.0.0
+paths:
+  /customers:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Customer"}
+components:
+  schemas:
+    Customer:
+      type: object
+      properties:
+        name: {type: string}
+        billing:
+          type: object
+          properties:
+            card_number: {type: string}
+`,
+			want: []string{"JSON schema Customer 12: [billing card_number name]"},
+		},
+		{file: "values.yaml", src: "replicas: 3\n"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range ScanSchemas(tt.file, []byte(tt.src)) {
+			got = append(got, fmt.Sprintf("%s %s %d: %v", s.Kind, s.Name, s.Line, s.Fields))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package dataclass
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a data structure declared in a schema or event definition.
+type Schema struct {
+	Name   string
+	Kind   string // "protobuf message", "Avro record", "table", "GraphQL type", or "JSON schema"
+	Line   int    // 1-based line declaring it
+	Fields []string
+}
+
+// ScanSchemas finds the schemas a file declares: protobuf messages, Avro
+// records, SQL tables, GraphQL types, and JSON Schema or OpenAPI schemas.
+// Other files have none.
+func ScanSchemas(filePath string, content []byte) []Schema {
+	src := string(content)
+	switch ext := strings.ToLower(path.Ext(filePath)); ext {
+	case ".proto":
+		return protoSchemas(src)
+	case ".avsc":
+		return avroSchemas(src)
+	case ".sql":
+		return sqlSchemas(src)
+	case ".graphql", ".graphqls", ".gql":
+		return graphqlSchemas(src)
+	case ".json", ".yaml", ".yml":
+		return jsonSchemas(filePath, content)
+	}
+	return nil
+}
+
+// lineOf returns the 1-based line of the first occurrence of needle in src
+// at or after offset, or 1.
+func lineOf(src, needle string, offset int) int {
+	i := strings.Index(src[offset:], needle)
+	if i < 0 {
+		return 1
+	}
+	return strings.Count(src[:offset+i], "\n") + 1
+}
+
+var (
+	protoMessageRe = regexp.MustCompile(`^\s*message\s+(\w+)\s*\{`)
+	protoFieldRe   = regexp.MustCompile(`^\s*(?:repeated\s+|optional\s+|required\s+)?(?:map\s*<[^>]*>|[\w.]+)\s+(\w+)\s*=\s*\d+`)
+)
+
+// protoSchemas reads protobuf messages, nested ones included.
+func protoSchemas(src string) []Schema {
+	var schemas []Schema
+	var open []int   // indexes in schemas of the enclosing messages
+	var depths []int // brace depth each message opened at
+	depth := 0
+	for i, line := range strings.Split(src, "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		if m := protoMessageRe.FindStringSubmatch(line); m != nil {
+			schemas = append(schemas, Schema{Name: m[1], Kind: "protobuf message", Line: i + 1})
+			open = append(open, len(schemas)-1)
+			depths = append(depths, depth)
+		} else if m := protoFieldRe.FindStringSubmatch(line); m != nil && len(open) > 0 && depth == depths[len(depths)-1]+1 {
+			s := &schemas[open[len(open)-1]]
+			s.Fields = append(s.Fields, m[1])
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		for len(open) > 0 && depth <= depths[len(depths)-1] {
+			open, depths = open[:len(open)-1], depths[:len(depths)-1]
+		}
+	}
+	return schemas
+}
+
+// avroSchemas reads Avro records, nested ones included.
+func avroSchemas(src string) []Schema {
+	var root any
+	if err := json.Unmarshal([]byte(src), &root); err != nil {
+		return nil
+	}
+	var schemas []Schema
+	var walk func(v any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case []any: // a union, or a file of several schemas
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]any:
+			if t["type"] == "record" {
+				name, _ := t["name"].(string)
+				s := Schema{Name: name, Kind: "Avro record", Line: lineOf(src, `"`+name+`"`, 0)}
+				fields, _ := t["fields"].([]any)
+				for _, f := range fields {
+					if fm, ok := f.(map[string]any); ok {
+						if n, ok := fm["name"].(string); ok {
+							s.Fields = append(s.Fields, n)
+						}
+						walk(fm["type"])
+					}
+				}
+				schemas = append(schemas, s)
+				return
+			}
+			walk(t["items"])
+			walk(t["values"])
+			walk(t["type"])
+		}
+	}
+	walk(root)
+	return schemas
+}
+
+var (
+	sqlTableRe      = regexp.MustCompile(`(?i)\bcreate\s+(?:temporary\s+|temp\s+)?table\s+(?:if\s+not\s+exists\s+)?([\w."` + "`" + `\[\]]+)\s*\(`)
+	sqlConstraintRe = regexp.MustCompile(`(?i)^(?:primary|foreign|constraint|unique|key|index|check|exclude)\b`)
+)
+
+// sqlSchemas reads the columns of CREATE TABLE statements.
+func sqlSchemas(src string) []Schema {
+	var schemas []Schema
+	for _, m := range sqlTableRe.FindAllStringSubmatchIndex(src, -1) {
+		name := strings.Trim(src[m[2]:m[3]], "\"`[]")
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = strings.Trim(name[i+1:], "\"`[]")
+		}
+		s := Schema{Name: name, Kind: "table", Line: strings.Count(src[:m[0]], "\n") + 1}
+		// The column list runs to the parenthesis closing the one m ends at.
+		depth, item := 1, m[1]
+		for i := m[1]; i < len(src) && depth > 0; i++ {
+			switch src[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				if depth--; depth > 0 {
+					continue
+				}
+			case ',':
+				if depth > 1 {
+					continue
+				}
+			default:
+				continue
+			}
+			if col := sqlColumn(src[item:i]); col != "" {
+				s.Fields = append(s.Fields, col)
+			}
+			item = i + 1
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas
+}
+
+// sqlColumn returns the column a CREATE TABLE item defines, or "" for a
+// table constraint.
+func sqlColumn(item string) string {
+	item = strings.TrimSpace(item)
+	if item == "" || sqlConstraintRe.MatchString(item) {
+		return ""
+	}
+	name, _, _ := strings.Cut(item, " ")
+	return strings.Trim(name, "\"`[]")
+}
+
+var (
+	graphqlTypeRe  = regexp.MustCompile(`^\s*(?:extend\s+)?(?:type|input|interface)\s+(\w+)[^{]*\{`)
+	graphqlFieldRe = regexp.MustCompile(`^\s*(\w+)\s*(?:\([^)]*\))?\s*:`)
+)
+
+// graphqlSchemas reads GraphQL object, input, and interface types.
+func graphqlSchemas(src string) []Schema {
+	var schemas []Schema
+	inType := false
+	for i, line := range strings.Split(src, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		switch {
+		case !inType:
+			if m := graphqlTypeRe.FindStringSubmatch(line); m != nil {
+				schemas = append(schemas, Schema{Name: m[1], Kind: "GraphQL type", Line: i + 1})
+				inType = !strings.Contains(line, "}")
+			}
+		case strings.Contains(line, "}"):
+			inType = false
+		default:
+			if m := graphqlFieldRe.FindStringSubmatch(line); m != nil {
+				s := &schemas[len(schemas)-1]
+				s.Fields = append(s.Fields, m[1])
+			}
+		}
+	}
+	return schemas
+}
+
+// jsonSchemas reads the schemas of an OpenAPI or Swagger document, or a
+// JSON Schema. Other JSON and YAML files have none.
+func jsonSchemas(filePath string, content []byte) []Schema {
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil || doc == nil {
+		return nil
+	}
+	src := string(content)
+	var named map[string]any
+	section := ""
+	switch {
+	case doc["openapi"] != nil:
+		components, _ := doc["components"].(map[string]any)
+		named, _ = components["schemas"].(map[string]any)
+		section = "schemas"
+	case doc["swagger"] != nil:
+		named, _ = doc["definitions"].(map[string]any)
+		section = "definitions"
+	case doc["$schema"] != nil || doc["properties"] != nil && doc["type"] == "object":
+		name, _ := doc["title"].(string)
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+		}
+		return []Schema{{Name: name, Kind: "JSON schema", Line: 1, Fields: schemaProperties(doc)}}
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Look for each schema's line after the section holding them, past
+	// references to it from the paths.
+	offset := max(strings.Index(src, section+`"`), strings.Index(src, section+":"), 0)
+	var schemas []Schema
+	for _, name := range names {
+		def, _ := named[name].(map[string]any)
+		if fields := schemaProperties(def); len(fields) > 0 {
+			schemas = append(schemas, Schema{Name: name, Kind: "JSON schema", Line: lineOf(src, name, offset), Fields: fields})
+		}
+	}
+	return schemas
+}
+
+// schemaProperties returns the property names of a JSON Schema object and
+// of the objects and arrays of objects nested in it, sorted within each
+// object.
+func schemaProperties(def map[string]any) []string {
+	var fields []string
+	var walk func(def map[string]any)
+	walk = func(def map[string]any) {
+		props, _ := def["properties"].(map[string]any)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fields = append(fields, name)
+			if p, ok := props[name].(map[string]any); ok {
+				walk(p)
+				if items, ok := p["items"].(map[string]any); ok {
+					walk(items)
+				}
+			}
+		}
+	}
+	walk(def)
+	return fields
+}
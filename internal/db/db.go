@@ -58,13 +58,8 @@ func OpenMemory() (*DB, error) {
 	return d, nil
 }
 
-// migrate runs all schema migrations.
-func (d *DB) migrate() error {
-	_, err := d.Exec(schema)
-	return err
-}
-
-// schema contains the full database schema. New tables are added here.
+// schema is the baseline database schema (migration 1). Later schema
+// changes are appended to migrations instead of edited in here.
 const schema = `
 CREATE TABLE IF NOT EXISTS audit_entries (
     id TEXT PRIMARY KEY,
@@ -281,4 +276,41 @@ CREATE TABLE IF NOT EXISTS service_links (
 
 CREATE INDEX IF NOT EXISTS idx_service_links_from ON service_links(from_repo);
 CREATE INDEX IF NOT EXISTS idx_service_links_to ON service_links(to_repo);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    key_prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL DEFAULT '[]',
+    rate_limit INTEGER NOT NULL DEFAULT 60,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    last_used_at DATETIME,
+    revoked_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    throttled INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day, endpoint)
+);
+
+CREATE TABLE IF NOT EXISTS architecture_changes (
+    id TEXT PRIMARY KEY,
+    changed_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    repo TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    from_repo TEXT NOT NULL DEFAULT '',
+    to_repo TEXT NOT NULL DEFAULT '',
+    link_type TEXT NOT NULL DEFAULT '',
+    endpoints TEXT NOT NULL DEFAULT '[]',
+    removed_endpoints TEXT NOT NULL DEFAULT '[]',
+    commit_sha TEXT NOT NULL DEFAULT '',
+    source_url TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_architecture_changes_time ON architecture_changes(changed_at);
 `
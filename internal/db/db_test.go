@@ -1,6 +1,8 @@
 package db
 
 import (
+	"database/sql"
+	"path/filepath"
 	"testing"
 )
 
@@ -16,6 +18,8 @@ func TestOpenMemory(t *testing.T) {
 		"audit_entries", "confidence_metadata", "facts",
 		"knowledge_questions", "teams", "flows",
 		"notifications", "chat_sessions", "import_sources", "api_tokens",
+		"api_keys", "api_key_usage", "architecture_changes", "schema_migrations",
+		"notification_rules", "repository_tags", "notification_mutes",
 	}
 
 	for _, table := range tables {
@@ -39,3 +43,46 @@ func TestMigrateIdempotent(t *testing.T) {
 		t.Fatalf("second migrate() error: %v", err)
 	}
 }
+
+func TestMigrationsTracked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "autodoc.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if v, err := d.SchemaVersion(); err != nil || v != LatestVersion() {
+		t.Fatalf("SchemaVersion() = %d, %v; want %d", v, err, LatestVersion())
+	}
+	d.Close()
+
+	// Simulate a database created before migrations were tracked: only the
+	// baseline schema, and no schema_migrations table.
+	path = filepath.Join(t.TempDir(), "old.db")
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	old, err := OpenUnmigrated(path)
+	if err != nil {
+		t.Fatalf("OpenUnmigrated() error: %v", err)
+	}
+	pending, err := old.PendingMigrations()
+	old.Close()
+	if err != nil || len(pending) != len(migrations) {
+		t.Fatalf("PendingMigrations() = %d, %v; want all %d", len(pending), err, len(migrations))
+	}
+
+	d, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopening untracked database: %v", err)
+	}
+	defer d.Close()
+	if pending, err := d.PendingMigrations(); err != nil || len(pending) != 0 {
+		t.Errorf("expected no pending migrations after Open, got %d, %v", len(pending), err)
+	}
+}
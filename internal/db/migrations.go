@@ -0,0 +1,272 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// Migration is one forward-only, versioned change to the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// migrations lists every schema migration in version order. Append new
+// migrations rather than editing applied ones. The baseline uses IF NOT EXISTS
+// throughout, so it also adopts databases created before migrations were
+// tracked without touching their data.
+var migrations = []Migration{
+	{Version: 1, Name: "baseline schema", SQL: schema},
+	{Version: 2, Name: "notification routing rules and repository tags", SQL: notificationRoutingSchema},
+	{Version: 3, Name: "notification mutes", SQL: notificationMutesSchema},
+	{Version: 4, Name: "production trace observations", SQL: traceObservationsSchema},
+	{Version: 5, Name: "monorepo sub-services", SQL: monorepoSchema},
+	{Version: 6, Name: "trace observation latency", SQL: traceLatencySchema},
+	{Version: 7, Name: "repository groups", SQL: repositoryGroupsSchema},
+	{Version: 8, Name: "architecture snapshots", SQL: architectureSnapshotsSchema},
+	{Version: 9, Name: "schema versions", SQL: schemaVersionsSchema},
+	{Version: 10, Name: "repository health", SQL: repoHealthSchema},
+	{Version: 11, Name: "service costs", SQL: serviceCostsSchema},
+	{Version: 12, Name: "page reviews", SQL: reviewPagesSchema},
+}
+
+// LatestVersion returns the schema version a fully migrated database has.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+const notificationRoutingSchema = `
+ALTER TABLE notifications ADD COLUMN link_type TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS notification_rules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    match TEXT NOT NULL DEFAULT '{}',
+    destinations TEXT NOT NULL DEFAULT '[]',
+    stop INTEGER NOT NULL DEFAULT 0,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS repository_tags (
+    repo_name TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY(repo_name, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_tags_tag ON repository_tags(tag);
+`
+
+const traceObservationsSchema = `
+CREATE TABLE IF NOT EXISTS trace_observations (
+    from_service TEXT NOT NULL,
+    to_service TEXT NOT NULL,
+    protocol TEXT NOT NULL DEFAULT '',
+    calls INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    endpoints TEXT NOT NULL DEFAULT '[]',
+    first_seen DATETIME,
+    last_seen DATETIME,
+    imported_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY(from_service, to_service)
+);
+`
+
+const notificationMutesSchema = `
+ALTER TABLE notifications ADD COLUMN suppressed_by TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS notification_mutes (
+    id TEXT PRIMARY KEY,
+    scope TEXT NOT NULL CHECK(scope IN ('global','team','service')),
+    target TEXT NOT NULL DEFAULT '',
+    types TEXT NOT NULL DEFAULT '[]',
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_mutes_ends ON notification_mutes(ends_at);
+`
+
+const monorepoSchema = `
+ALTER TABLE repositories ADD COLUMN parent TEXT NOT NULL DEFAULT '';
+ALTER TABLE repositories ADD COLUMN subdir TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_repositories_parent ON repositories(parent);
+`
+
+const traceLatencySchema = `
+ALTER TABLE trace_observations ADD COLUMN latency_ms REAL NOT NULL DEFAULT 0;
+`
+
+const repositoryGroupsSchema = `
+ALTER TABLE repositories ADD COLUMN domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE repositories ADD COLUMN bounded_context TEXT NOT NULL DEFAULT '';
+ALTER TABLE repositories ADD COLUMN environment TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_repositories_domain ON repositories(domain);
+`
+
+const architectureSnapshotsSchema = `
+CREATE TABLE IF NOT EXISTS architecture_snapshots (
+    id TEXT PRIMARY KEY,
+    taken_at DATETIME NOT NULL DEFAULT (datetime('now')),
+    run_id TEXT NOT NULL DEFAULT '',
+    services TEXT NOT NULL DEFAULT '[]',
+    links TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_architecture_snapshots_time ON architecture_snapshots(taken_at);
+`
+
+const schemaVersionsSchema = `
+CREATE TABLE IF NOT EXISTS schema_versions (
+    subject TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    schema_type TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL DEFAULT '',
+    fields TEXT NOT NULL DEFAULT '[]',
+    changes TEXT NOT NULL DEFAULT '[]',
+    repo TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    seen_at DATETIME NOT NULL,
+    PRIMARY KEY (subject, version)
+);
+`
+
+const repoHealthSchema = `
+CREATE TABLE IF NOT EXISTS repo_health (
+    repo TEXT PRIMARY KEY,
+    last_commit_at DATETIME,
+    ci_provider TEXT NOT NULL DEFAULT '',
+    ci_badge_url TEXT NOT NULL DEFAULT '',
+    open_prs INTEGER NOT NULL DEFAULT -1,
+    has_tests INTEGER NOT NULL DEFAULT 0,
+    checked_at DATETIME NOT NULL,
+    quiet_notified_at DATETIME
+);
+`
+
+const serviceCostsSchema = `
+CREATE TABLE IF NOT EXISTS service_costs (
+    service TEXT NOT NULL,
+    month TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    amount REAL NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    imported_at DATETIME NOT NULL,
+    PRIMARY KEY (service, month, currency)
+);
+`
+
+const reviewPagesSchema = `
+CREATE TABLE IF NOT EXISTS review_pages (
+    site TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL,
+    status TEXT NOT NULL,
+    content TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    approved TEXT NOT NULL DEFAULT '',
+    approved_hash TEXT NOT NULL DEFAULT '',
+    reviewer TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    generated_at DATETIME NOT NULL,
+    reviewed_at DATETIME,
+    PRIMARY KEY (site, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_pages_status ON review_pages(status);
+`
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT (datetime('now'))
+);
+`
+
+// OpenUnmigrated opens an existing database without applying pending
+// migrations, for reporting what an upgrade would change.
+func OpenUnmigrated(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	sqlDB, err := sql.Open("sqlite", path+"?_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+	return &DB{DB: sqlDB, path: path}, nil
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 for a
+// database that predates migration tracking.
+func (d *DB) SchemaVersion() (int, error) {
+	var exists int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("checking schema_migrations: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+	var version sql.NullInt64
+	if err := d.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// PendingMigrations returns the migrations not yet applied, in order.
+func (d *DB) PendingMigrations() ([]Migration, error) {
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than this autodoc supports (%d); upgrade autodoc", version, LatestVersion())
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// migrate applies pending schema migrations, each in its own transaction.
+func (d *DB) migrate() error {
+	if _, err := d.Exec(migrationsTable); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	pending, err := d.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		tx, err := d.Begin()
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %d: %w", m.Version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Package demo writes a synthetic multi-repo system for `autodoc demo`: ten
+// small services in Go, Python, and JavaScript that call each other over
+// HTTP, each with its own .autodoc.yml set up for the mock provider. The
+// "users" service is the single-repo sample from testdata/sample_project.
+package demo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Call is an outbound HTTP call from one demo service to another.
+type Call struct {
+	Service string
+	Method  string // GET or POST
+	Path    string
+}
+
+// Service is one repository of the demo system.
+type Service struct {
+	Name     string
+	Language string // go, python, or javascript
+	Port     int
+	Team     string
+	Summary  string
+	Routes   []string
+	Calls    []Call
+	// SLO is declared in the central config, e.g. "99.9% p99=300ms"; empty
+	// for services without one.
+	SLO string
+}
+
+// Services is the demo system. Services are listed so that every service
+// comes after the ones it calls, which is the order they must be registered
+// in for link discovery to see their dependencies.
+var Services = []Service{
+	{
+		Name: "users", Language: "go", Port: 8080, Team: "identity",
+		Summary: "Stores user accounts and validates bearer tokens.",
+		Routes:  []string{"/api/users", "/api/health"},
+		SLO:     "99.95% p99=200ms",
+	},
+	{
+		Name: "inventory", Language: "go", Port: 8081, Team: "fulfilment",
+		Summary: "Tracks stock levels per warehouse.",
+		Routes:  []string{"/api/stock", "/api/reservations"},
+		SLO:     "99.9% p99=150ms",
+	},
+	{
+		Name: "notifications", Language: "javascript", Port: 8082, Team: "platform",
+		Summary: "Sends order and shipping emails.",
+		Routes:  []string{"/api/emails"},
+	},
+	{
+		Name: "ledger", Language: "go", Port: 8083, Team: "payments",
+		Summary: "Append-only record of every money movement.",
+		Routes:  []string{"/api/entries"},
+		SLO:     "99.99% p99=100ms",
+	},
+	{
+		Name: "catalog", Language: "python", Port: 8084, Team: "storefront",
+		Summary: "Serves product details and availability.",
+		Routes:  []string{"/api/products"},
+		Calls:   []Call{{Service: "inventory", Method: "GET", Path: "/api/stock"}},
+		SLO:     "99.9% p95=250ms",
+	},
+	{
+		Name: "payments", Language: "go", Port: 8085, Team: "payments",
+		Summary: "Charges cards and records the result in the ledger.",
+		Routes:  []string{"/api/charges"},
+		Calls:   []Call{{Service: "ledger", Method: "POST", Path: "/api/entries"}},
+		SLO:     "99.95% p99=400ms",
+	},
+	{
+		Name: "shipping", Language: "go", Port: 8086, Team: "fulfilment",
+		Summary: "Books shipments and notifies customers.",
+		Routes:  []string{"/api/shipments"},
+		Calls: []Call{
+			{Service: "inventory", Method: "POST", Path: "/api/reservations"},
+			{Service: "notifications", Method: "POST", Path: "/api/emails"},
+		},
+	},
+	{
+		Name: "orders", Language: "go", Port: 8087, Team: "storefront",
+		Summary: "Places orders: checks the catalog, charges payment, and books shipping.",
+		Routes:  []string{"/api/orders"},
+		Calls: []Call{
+			{Service: "users", Method: "GET", Path: "/api/users"},
+			{Service: "catalog", Method: "GET", Path: "/api/products"},
+			{Service: "payments", Method: "POST", Path: "/api/charges"},
+			{Service: "shipping", Method: "POST", Path: "/api/shipments"},
+		},
+		SLO: "99.9% p99=800ms",
+	},
+	{
+		Name: "search", Language: "python", Port: 8088, Team: "storefront",
+		Summary: "Full-text product search.",
+		Routes:  []string{"/api/search"},
+		Calls:   []Call{{Service: "catalog", Method: "GET", Path: "/api/products"}},
+	},
+	{
+		Name: "gateway", Language: "javascript", Port: 8000, Team: "platform",
+		Summary: "Public API gateway that routes client requests to backend services.",
+		Routes:  []string{"/users", "/orders", "/search"},
+		Calls: []Call{
+			{Service: "users", Method: "GET", Path: "/api/users"},
+			{Service: "orders", Method: "POST", Path: "/api/orders"},
+			{Service: "search", Method: "GET", Path: "/api/search"},
+		},
+		SLO: "99.9% p99=1s",
+	},
+}
+
+// Lookup returns the demo service with the given name.
+func Lookup(name string) (Service, bool) {
+	for _, s := range Services {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Service{}, false
+}
+
+// URL is the in-cluster address of a service endpoint.
+func (s Service) URL(path string) string {
+	return fmt.Sprintf("http://%s:%d%s", s.Name, s.Port, path)
+}
+
+// Write creates the demo system under dir: one directory per service plus a
+// central .autodoc.yml at the root for the combined site. Existing files are
+// overwritten.
+func Write(dir string) error {
+	for _, svc := range Services {
+		files, err := svc.Files()
+		if err != nil {
+			return err
+		}
+		for name, content := range files {
+			path := filepath.Join(dir, svc.Name, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return fmt.Errorf("writing %s: %w", path, err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".autodoc.yml"), []byte(CentralConfig()), 0o644); err != nil {
+		return fmt.Errorf("writing central config: %w", err)
+	}
+	return nil
+}
+
+// Files returns the service's repository contents keyed by slash-separated
+// path.
+func (s Service) Files() (map[string]string, error) {
+	files := map[string]string{
+		".autodoc.yml": repoConfig,
+		"README.md":    fmt.Sprintf("# %s\n\n%s\n\nOwned by the %s team. Listens on port %d.\n", s.Name, s.Summary, s.Team, s.Port),
+		"config.yaml":  s.configYAML(),
+	}
+	switch s.Language {
+	case "go":
+		if s.Name == "users" {
+			files["main.go"] = usersMain
+			files["auth/middleware.go"] = usersAuthMiddleware
+		} else {
+			files["main.go"] = s.goMain()
+		}
+		files["Dockerfile"] = goDockerfile(s.Port)
+	case "python":
+		files["app.py"] = s.pythonApp()
+		files["requirements.txt"] = "flask==3.0.0\nrequests==2.31.0\n"
+	case "javascript":
+		files["server.js"] = s.nodeServer()
+		files["package.json"] = fmt.Sprintf("{\n  \"name\": %q,\n  \"main\": \"server.js\",\n  \"dependencies\": {\"express\": \"^4.19.0\"}\n}\n", s.Name)
+	default:
+		return nil, fmt.Errorf("demo service %s: unsupported language %q", s.Name, s.Language)
+	}
+	return files, nil
+}
+
+// repoConfig is each service's .autodoc.yml.
+const repoConfig = `provider: mock
+model: mock
+embedding_provider: mock
+embedding_model: mock
+quality: lite
+output_dir: .autodoc
+`
+
+// CentralConfig is the .autodoc.yml for the combined site: the mock provider,
+// plus SLOs so the service and flow pages have something to show.
+func CentralConfig() string {
+	var b strings.Builder
+	b.WriteString(repoConfig + "slos:\n")
+	for _, s := range Services {
+		if s.SLO != "" {
+			fmt.Fprintf(&b, "  - service: %s\n    objective: %q\n", s.Name, s.SLO)
+		}
+	}
+	return b.String()
+}
+
+func (s Service) configYAML() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server:\n  port: %d\n  host: 0.0.0.0\n", s.Port)
+	if len(s.Calls) > 0 {
+		b.WriteString("upstreams:\n")
+		for _, c := range s.Calls {
+			callee, _ := Lookup(c.Service)
+			fmt.Fprintf(&b, "  %s: %s\n", c.Service, callee.URL(""))
+		}
+	}
+	b.WriteString("logging:\n  level: info\n  format: json\n")
+	return b.String()
+}
+
+func (s Service) goMain() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\npackage main\n\nimport (\n\t\"bytes\"\n\t\"fmt\"\n\t\"net/http\"\n)\n\n", s.Summary)
+	b.WriteString("func main() {\n\tmux := http.NewServeMux()\n")
+	for _, r := range s.Routes {
+		fmt.Fprintf(&b, "\tmux.HandleFunc(%q, %s)\n", r, handlerName(r))
+	}
+	fmt.Fprintf(&b, "\n\tfmt.Println(\"%s starting on :%d\")\n\thttp.ListenAndServe(\":%d\", mux)\n}\n", s.Name, s.Port, s.Port)
+	for i, r := range s.Routes {
+		fmt.Fprintf(&b, "\n// %s serves %s.\n", handlerName(r), r)
+		fmt.Fprintf(&b, "func %s(w http.ResponseWriter, r *http.Request) {\n", handlerName(r))
+		if i == 0 {
+			for _, c := range s.Calls {
+				callee, _ := Lookup(c.Service)
+				if c.Method == "POST" {
+					fmt.Fprintf(&b, "\tif _, err := http.Post(%q, \"application/json\", bytes.NewReader(nil)); err != nil {\n\t\thttp.Error(w, \"%s unavailable\", http.StatusBadGateway)\n\t\treturn\n\t}\n", callee.URL(c.Path), c.Service)
+				} else {
+					fmt.Fprintf(&b, "\tif _, err := http.Get(%q); err != nil {\n\t\thttp.Error(w, \"%s unavailable\", http.StatusBadGateway)\n\t\treturn\n\t}\n", callee.URL(c.Path), c.Service)
+				}
+			}
+		}
+		b.WriteString("\tw.Write([]byte(`{\"status\": \"ok\"}`))\n}\n")
+	}
+	return b.String()
+}
+
+func (s Service) pythonApp() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", s.Summary)
+	b.WriteString("import requests\nfrom flask import Flask, jsonify\n\napp = Flask(__name__)\n")
+	for i, r := range s.Routes {
+		fmt.Fprintf(&b, "\n\n@app.route(%q)\ndef %s():\n    \"\"\"%s\"\"\"\n", r, strings.ToLower(strings.Replace(handlerName(r), "handle", "handle_", 1)), "Serve "+r+".")
+		if i == 0 {
+			for _, c := range s.Calls {
+				callee, _ := Lookup(c.Service)
+				fmt.Fprintf(&b, "    requests.%s(%q, timeout=2)\n", strings.ToLower(c.Method), callee.URL(c.Path))
+			}
+		}
+		b.WriteString("    return jsonify(status=\"ok\")\n")
+	}
+	fmt.Fprintf(&b, "\n\nif __name__ == \"__main__\":\n    app.run(host=\"0.0.0.0\", port=%d)\n", s.Port)
+	return b.String()
+}
+
+func (s Service) nodeServer() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", s.Summary)
+	b.WriteString("const express = require(\"express\");\n\nconst app = express();\napp.use(express.json());\n")
+	for i, r := range s.Routes {
+		fmt.Fprintf(&b, "\n// %s serves %s.\nasync function %s(req, res) {\n", handlerName(r), r, handlerName(r))
+		// Spread the calls across the routes, one route per call.
+		for j, c := range s.Calls {
+			if j%len(s.Routes) == i {
+				callee, _ := Lookup(c.Service)
+				fmt.Fprintf(&b, "  await fetch(%q, { method: %q });\n", callee.URL(c.Path), c.Method)
+			}
+		}
+		b.WriteString("  res.json({ status: \"ok\" });\n}\n")
+		fmt.Fprintf(&b, "app.all(%q, %s);\n", r, handlerName(r))
+	}
+	fmt.Fprintf(&b, "\napp.listen(%d, () => console.log(\"%s listening on :%d\"));\n", s.Port, s.Name, s.Port)
+	return b.String()
+}
+
+// handlerName turns a route such as "/api/stock" into "handleStock".
+func handlerName(route string) string {
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	last := parts[len(parts)-1]
+	return "handle" + strings.ToUpper(last[:1]) + last[1:]
+}
+
+func goDockerfile(port int) string {
+	return fmt.Sprintf(`FROM golang:1.22-alpine AS builder
+
+WORKDIR /app
+COPY . .
+RUN CGO_ENABLED=0 go build -o server .
+
+FROM alpine:3.19
+WORKDIR /app
+COPY --from=builder /app/server .
+EXPOSE %d
+CMD ["./server"]
+`, port)
+}
@@ -0,0 +1,85 @@
+package demo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/slo"
+)
+
+func TestServicesOrderedCalleesFirst(t *testing.T) {
+	if len(Services) != 10 {
+		t.Errorf("len(Services) = %d, want 10", len(Services))
+	}
+	registered := make(map[string]bool)
+	for _, s := range Services {
+		for _, c := range s.Calls {
+			if !registered[c.Service] {
+				t.Errorf("%s calls %s, which is listed after it", s.Name, c.Service)
+			}
+		}
+		registered[s.Name] = true
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := Write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	detector := flows.NewDetector()
+	for _, s := range Services {
+		cfg, err := config.Load(filepath.Join(dir, s.Name, ".autodoc.yml"))
+		if err != nil {
+			t.Fatalf("%s config: %v", s.Name, err)
+		}
+		if cfg.Provider != config.ProviderMock || cfg.EmbeddingProvider != config.ProviderMock {
+			t.Errorf("%s: provider = %s/%s, want mock/mock", s.Name, cfg.Provider, cfg.EmbeddingProvider)
+		}
+
+		// Every declared call must be visible to the cross-service call
+		// detector, or link discovery won't find it.
+		files, _ := s.Files()
+		var detected []string
+		for name, content := range files {
+			for _, call := range detector.DetectPatterns(content, name) {
+				detected = append(detected, call.Target)
+			}
+		}
+		for _, c := range s.Calls {
+			callee, _ := Lookup(c.Service)
+			want := callee.URL(c.Path)
+			found := false
+			for _, target := range detected {
+				if strings.Contains(target, want) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%s: call to %s not detected in %v", s.Name, want, detected)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "users", "auth", "middleware.go")); err != nil {
+		t.Errorf("users service should include the sample project's auth middleware: %v", err)
+	}
+
+	cfg, err := config.Load(filepath.Join(dir, ".autodoc.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slos, err := slo.Load(context.Background(), cfg.SLOs, nil, nil)
+	if err != nil {
+		t.Fatalf("central SLOs: %v", err)
+	}
+	if _, ok := slos["orders"]; !ok {
+		t.Errorf("expected an SLO for orders, got %v", slos)
+	}
+}
@@ -0,0 +1,73 @@
+package demo
+
+// The users service is testdata/sample_project, kept in sync by hand.
+
+const usersMain = `package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users", handleUsers)
+	mux.HandleFunc("/api/health", handleHealth)
+
+	fmt.Println("Server starting on :8080")
+	http.ListenAndServe(":8080", mux)
+}
+
+func handleUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Write([]byte(` + "`" + `[{"id": 1, "name": "Alice"}]` + "`" + `))
+	case http.MethodPost:
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(` + "`" + `{"id": 2, "name": "Bob"}` + "`" + `))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(` + "`" + `{"status": "ok"}` + "`" + `))
+}
+`
+
+const usersAuthMiddleware = `package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AuthMiddleware validates Bearer tokens before passing requests to the next handler.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := extractToken(r)
+		if token == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !validateToken(token) {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func extractToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+func validateToken(token string) bool {
+	// In production, this would verify JWT signature and expiration.
+	return len(token) > 0
+}
+`
@@ -0,0 +1,126 @@
+// Package deployenv tags cross-service links and external dependencies
+// with the deployment environments they exist in, such as a service that
+// calls a mock payment provider in staging but the real one in prod. Tags
+// come from the repos' deployment config and from the environments config.
+package deployenv
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// Link is a call that exists in one environment.
+type Link struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Type        string `json:"type,omitempty"` // http, grpc, kafka, amqp, or a database scheme such as postgres
+	Environment string `json:"environment"`
+	// Source is where the tag came from: "config", or the path of the
+	// deployment file relative to the caller's repo.
+	Source string `json:"source"`
+}
+
+// aliases maps the spellings of environment names found in file names and
+// directories to their canonical names.
+var aliases = map[string]string{
+	"dev": "dev", "development": "dev", "develop": "dev",
+	"qa": "qa", "uat": "uat", "sandbox": "sandbox",
+	"stage": "staging", "staging": "staging", "stg": "staging", "preprod": "preprod",
+	"prod": "prod", "production": "prod", "prd": "prod", "live": "prod",
+}
+
+// defaultOrder orders the canonical environments from least to most
+// production-like.
+var defaultOrder = []string{"dev", "qa", "sandbox", "uat", "staging", "preprod", "prod"}
+
+// Canonical returns the canonical name of an environment, e.g. "prod" for
+// "production", or "" when name is not a known environment.
+func Canonical(name string) string {
+	return aliases[strings.ToLower(name)]
+}
+
+// normalize is Canonical for names given in config, which may name
+// environments it does not know.
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if c := Canonical(name); c != "" {
+		return c
+	}
+	return name
+}
+
+// Sort orders environments by order, then from least to most
+// production-like, then by name, dropping duplicates.
+func Sort(envs, order []string) []string {
+	rank := func(env string) int {
+		if i := slices.Index(order, env); i >= 0 {
+			return i
+		}
+		if i := slices.Index(defaultOrder, env); i >= 0 {
+			return len(order) + i
+		}
+		return len(order) + len(defaultOrder)
+	}
+	out := slices.Clone(envs)
+	sort.SliceStable(out, func(i, j int) bool {
+		if ri, rj := rank(out[i]), rank(out[j]); ri != rj {
+			return ri < rj
+		}
+		return out[i] < out[j]
+	})
+	return slices.Compact(out)
+}
+
+// Order returns the display order the config asks for, by canonical name.
+func Order(cfg config.EnvironmentsConfig) []string {
+	out := make([]string, 0, len(cfg.Order))
+	for _, e := range cfg.Order {
+		if e = normalize(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Merge combines the links found by scanning with those declared in config.
+// A config entry replaces every scanned link with the same caller and
+// callee. The result is sorted by caller, callee, and environment, with one
+// link per environment.
+func Merge(cfg config.EnvironmentsConfig, scanned []Link) ([]Link, error) {
+	declared := make(map[[2]string]bool)
+	var out []Link
+	for _, l := range cfg.Links {
+		if l.From == "" || l.To == "" {
+			return nil, fmt.Errorf("environments: a link needs both from and to")
+		}
+		if len(l.Environments) == 0 {
+			return nil, fmt.Errorf("environments: link %s → %s names no environments", l.From, l.To)
+		}
+		declared[[2]string{l.From, l.To}] = true
+		for _, e := range l.Environments {
+			out = append(out, Link{From: l.From, To: l.To, Type: l.Type, Environment: normalize(e), Source: "config"})
+		}
+	}
+	for _, l := range scanned {
+		if !declared[[2]string{l.From, l.To}] {
+			out = append(out, l)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Environment < b.Environment
+	})
+	return slices.CompactFunc(out, func(a, b Link) bool {
+		return a.From == b.From && a.To == b.To && a.Environment == b.Environment
+	}), nil
+}
@@ -0,0 +1,111 @@
+package deployenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"deploy/overlays/staging/config.env": "PSP_URL=http://psp-mock.staging.svc.cluster.local:8080\nORDERS_URL=http://orders:8080\nLOG_LEVEL=debug\n",
+		"deploy/overlays/prod/deployment.yaml": `spec:
+  containers:
+    - name: payments
+      env:
+        - name: PSP_URL
+          value: "https://api.stripe.com/v1"
+        - name: ORDERS_URL
+          value: http://orders.prod.svc.cluster.local
+        - name: KAFKA_BROKERS
+          value: kafka-1.prod:9092,kafka-2.prod:9092
+        - name: CALLBACK_URL
+          value: https://${PUBLIC_HOST}/callback
+`,
+		"helm/values-dev.yaml": "db:\n  host: localhost\n  port: 5432\n",
+		".env.production":      "REDIS_ADDR=cache.internal:6379\n",
+		"src/dev/client.go":    `const ordersURL = "http://orders-dev:8080"`,
+		"docker-compose.yml":   "ORDERS_URL: http://orders:8080\n",
+		"ledger/.env.prod":     "LEDGER_DB_URL=postgres://ledger-db/ledger\n",
+		"src/main/resources/application-staging.properties": "spring.datasource.url=jdbc:postgresql://payments-db.staging:5432/payments\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Scan("payments", root, []string{"payments", "orders-service"}, []string{"ledger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Link{
+		{From: "payments", To: "cache", Type: "redis", Environment: "prod", Source: ".env.production"},
+		{From: "payments", To: "stripe", Type: "http", Environment: "prod", Source: "deploy/overlays/prod/deployment.yaml"},
+		{From: "payments", To: "orders-service", Type: "http", Environment: "prod", Source: "deploy/overlays/prod/deployment.yaml"},
+		{From: "payments", To: "kafka-1", Type: "kafka", Environment: "prod", Source: "deploy/overlays/prod/deployment.yaml"},
+		{From: "payments", To: "kafka-2", Type: "kafka", Environment: "prod", Source: "deploy/overlays/prod/deployment.yaml"},
+		{From: "payments", To: "psp-mock", Type: "http", Environment: "staging", Source: "deploy/overlays/staging/config.env"},
+		{From: "payments", To: "orders-service", Type: "http", Environment: "staging", Source: "deploy/overlays/staging/config.env"},
+		{From: "payments", To: "payments-db", Type: "postgres", Environment: "staging", Source: "src/main/resources/application-staging.properties"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	repos := []string{"orders", "billing-service"}
+	for host, want := range map[string]string{
+		"orders.prod.svc.cluster.local": "orders",
+		"billing.internal":              "billing-service",
+		"billing-service":               "billing-service",
+		"api.stripe.com":                "stripe",
+		"hooks.payments.example.co.uk":  "example",
+		"psp-mock.staging":              "psp-mock",
+		"ledger-db":                     "ledger-db",
+	} {
+		if got := Resolve(host, repos); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cfg := config.EnvironmentsConfig{
+		Order: []string{"Production", "staging"},
+		Links: []config.EnvironmentLinkConfig{
+			{From: "payments", To: "stripe", Type: "http", Environments: []string{"production"}},
+		},
+	}
+	scanned := []Link{
+		{From: "payments", To: "stripe", Environment: "staging", Source: "a.env"},
+		{From: "payments", To: "psp-mock", Environment: "staging", Source: "a.env"},
+		{From: "payments", To: "psp-mock", Environment: "staging", Source: "b.env"},
+	}
+	got, err := Merge(cfg, scanned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Link{
+		{From: "payments", To: "psp-mock", Environment: "staging", Source: "a.env"},
+		{From: "payments", To: "stripe", Type: "http", Environment: "prod", Source: "config"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+
+	if _, err := Merge(config.EnvironmentsConfig{Links: []config.EnvironmentLinkConfig{{From: "a", To: "b"}}}, nil); err == nil {
+		t.Error("Merge accepted a link without environments")
+	}
+
+	if got := Sort([]string{"prod", "dev", "perf", "staging", "dev"}, Order(cfg)); !reflect.DeepEqual(got, []string{"prod", "staging", "dev", "perf"}) {
+		t.Errorf("Sort = %v", got)
+	}
+}
@@ -0,0 +1,237 @@
+package deployenv
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// configExts are the extensions of the files deployment config lives in.
+var configExts = map[string]bool{
+	".yaml": true, ".yml": true, ".json": true, ".env": true, ".properties": true,
+	".tfvars": true, ".tf": true, ".hcl": true, ".toml": true, ".conf": true, ".ini": true,
+}
+
+// dependencyKeys are the last words of setting names that point at another
+// service, such as PAYMENTS_URL, db.host, or bootstrap.servers.
+var dependencyKeys = map[string]bool{
+	"url": true, "urls": true, "uri": true, "host": true, "hostname": true, "endpoint": true,
+	"addr": true, "address": true, "brokers": true, "servers": true, "dsn": true,
+}
+
+// schemeTypes maps URL schemes to link types.
+var schemeTypes = map[string]string{
+	"http": "http", "https": "http", "grpc": "grpc", "grpcs": "grpc",
+	"kafka": "kafka", "amqp": "amqp", "amqps": "amqp", "nats": "nats",
+	"postgres": "postgres", "postgresql": "postgres", "mysql": "mysql",
+	"redis": "redis", "rediss": "redis", "mongodb": "mongodb", "mongodb+srv": "mongodb",
+}
+
+// setting matches a key and value in YAML, JSON, .env, properties, TOML,
+// and Terraform files, including list items and exported variables.
+var setting = regexp.MustCompile(`^\s*(?:-\s+)?(?:export\s+)?["']?([A-Za-z_][A-Za-z0-9_.\-]*)["']?\s*[:=]\s*(.*)$`)
+
+// hostname matches a DNS name.
+var hostname = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// Scan finds the services and external dependencies that service's
+// deployment config points at in each environment. A file belongs to an
+// environment when its name or a directory above it names one, as in
+// overlays/staging/config.env, values-prod.yaml, or .env.production.
+// Hosts are matched against repos, so payments.prod.svc.cluster.local is
+// the payments repo; other hosts are named for their domain, so
+// api.stripe.com is stripe. Directories under root in skip, such as a
+// monorepo's sub-services, are not searched.
+func Scan(service, root string, repos, skip []string) ([]Link, error) {
+	var links []Link
+	seen := make(map[[2]string]bool)
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if p != root && (slices.ContainsFunc(walker.DefaultExcludes, func(e string) bool { return strings.EqualFold(e, d.Name()) }) || slices.Contains(skip, rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		env := environmentOf(rel)
+		if env == "" || !isConfigFile(d.Name()) {
+			return nil
+		}
+		for _, dep := range scanFile(p) {
+			to := Resolve(dep.host, repos)
+			if to == "" || to == service || seen[[2]string{to, env}] {
+				continue
+			}
+			seen[[2]string{to, env}] = true
+			links = append(links, Link{From: service, To: to, Type: dep.linkType, Environment: env, Source: rel})
+		}
+		return nil
+	})
+	return links, err
+}
+
+// isConfigFile reports whether a file name is one deployment config is
+// kept in.
+func isConfigFile(name string) bool {
+	return configExts[strings.ToLower(path.Ext(name))] || strings.HasPrefix(name, ".env")
+}
+
+// environmentOf returns the environment a file's path names, the one
+// nearest the file when it names several, or "".
+func environmentOf(rel string) string {
+	env := ""
+	for _, part := range strings.Split(rel, "/") {
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+			if c := Canonical(word); c != "" {
+				env = c
+			}
+		}
+	}
+	return env
+}
+
+// dependency is a host a setting points at.
+type dependency struct {
+	host     string
+	linkType string
+}
+
+// scanFile returns the hosts a file's dependency settings point at. In
+// Kubernetes env lists, where the setting's name and value are separate
+// keys, the value takes the preceding name.
+func scanFile(p string) []dependency {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var deps []dependency
+	name := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := setting.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		key, value := m[1], unquote(m[2])
+		switch strings.ToLower(key) {
+		case "name":
+			name = value
+			continue
+		case "value":
+			key, name = name, ""
+		}
+		words := dataclass.Words(key)
+		if len(words) == 0 || !dependencyKeys[words[len(words)-1]] {
+			continue
+		}
+		for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if d, ok := parseDependency(unquote(v), words); ok {
+				deps = append(deps, d)
+			}
+		}
+	}
+	return deps
+}
+
+// unquote trims a value's trailing comma, comment, and quotes.
+func unquote(v string) string {
+	v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), ","))
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end >= 0 {
+			return v[1 : end+1]
+		}
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
+
+// parseDependency reads the host from a URL or host[:port] value, with the
+// link type its scheme or setting name implies. Templated values are
+// skipped, since the host isn't known until deploy time.
+func parseDependency(value string, keyWords []string) (dependency, bool) {
+	if value == "" || strings.ContainsAny(value, "${}<>") {
+		return dependency{}, false
+	}
+	var d dependency
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(strings.TrimPrefix(value, "jdbc:"))
+		if err != nil {
+			return dependency{}, false
+		}
+		d.host, d.linkType = u.Hostname(), schemeTypes[strings.ToLower(u.Scheme)]
+	} else {
+		d.host, _, _ = strings.Cut(value, "/")
+		if h, _, err := net.SplitHostPort(d.host); err == nil {
+			d.host = h
+		}
+	}
+	d.host = strings.ToLower(strings.TrimSuffix(d.host, "."))
+	if !hostname.MatchString(d.host) || net.ParseIP(d.host) != nil || d.host == "localhost" || !strings.ContainsAny(d.host, "abcdefghijklmnopqrstuvwxyz") {
+		return dependency{}, false
+	}
+	if d.linkType == "" {
+		for _, w := range keyWords {
+			switch w {
+			case "grpc":
+				d.linkType = "grpc"
+			case "kafka", "brokers":
+				d.linkType = "kafka"
+			case "amqp", "rabbit", "rabbitmq":
+				d.linkType = "amqp"
+			case "redis":
+				d.linkType = "redis"
+			}
+		}
+	}
+	return d, true
+}
+
+// internalSuffixes are the labels of cluster-internal DNS names, such as
+// orders.prod.svc.cluster.local, that name no organization.
+var internalSuffixes = map[string]bool{"local": true, "internal": true, "svc": true, "cluster": true, "lan": true, "localdomain": true, "consul": true}
+
+// Resolve names the service a host belongs to: the first of its labels
+// that is a repo's name, with or without a -service suffix, or else the
+// organization it belongs to, such as stripe for api.stripe.com, or its
+// first label for internal names.
+func Resolve(host string, repos []string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for _, label := range labels {
+		for _, r := range repos {
+			lr := strings.ToLower(r)
+			if label == lr || label == strings.TrimSuffix(lr, "-service") || label == strings.TrimSuffix(lr, "-svc") || strings.TrimSuffix(label, "-service") == lr {
+				return r
+			}
+		}
+	}
+	n := len(labels)
+	if n < 2 || slices.ContainsFunc(labels[1:], func(l string) bool { return internalSuffixes[l] }) {
+		return labels[0]
+	}
+	org := labels[n-2]
+	// Second-level registries such as co.uk and com.au.
+	if n >= 3 && len(labels[n-1]) == 2 && slices.Contains([]string{"co", "com", "org", "net", "gov", "ac"}, org) {
+		org = labels[n-3]
+	}
+	return org
+}
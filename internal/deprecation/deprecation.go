@@ -0,0 +1,298 @@
+// Package deprecation tracks the endpoints, RPCs, and topics providers have
+// deprecated, and which services still use them. An endpoint is deprecated
+// by its OpenAPI spec or an annotation in the provider's source, by the
+// deprecations config, or by a context-engine fact; its consumers are found
+// in the links' endpoints.
+package deprecation
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// FactKey is the context-engine fact, scoped to the providing service, that
+// deprecates one of its endpoints: "deprecated:<endpoint>", e.g.
+// "deprecated:POST /v1/charges". See ParseFact for its value.
+const FactKey = "deprecated"
+
+// Where a deprecation was declared.
+const (
+	SourceConfig     = "config"
+	SourceFact       = "fact"
+	SourceOpenAPI    = "openapi"
+	SourceAnnotation = "annotation"
+)
+
+// Deprecation is an endpoint, RPC, or topic its provider has deprecated.
+type Deprecation struct {
+	Service string `json:"service"`
+	// Endpoint is as the links name it: "POST /v1/charges", a path, an RPC
+	// method, or a topic.
+	Endpoint    string    `json:"endpoint"`
+	Sunset      time.Time `json:"sunset,omitempty"` // zero when no date is set
+	Replacement string    `json:"replacement,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	Source      string    `json:"source"`
+	// Evidence is where the source or spec marks it, e.g.
+	// "api/ChargeController.java:12 (@Deprecated)"; empty for config and
+	// facts.
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// DaysLeft is the number of whole days from now until the sunset, negative
+// once it has passed; ok is false when no sunset is set.
+func (d Deprecation) DaysLeft(now time.Time) (days int, ok bool) {
+	if d.Sunset.IsZero() {
+		return 0, false
+	}
+	y, m, day := now.Date()
+	today := time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+	return int(d.Sunset.Sub(today).Hours() / 24), true
+}
+
+// Countdown describes the time left until the sunset, e.g. "in 12 days",
+// "today", or "3 days overdue".
+func (d Deprecation) Countdown(now time.Time) string {
+	days, ok := d.DaysLeft(now)
+	switch {
+	case !ok:
+		return "no sunset date"
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days > 0:
+		return fmt.Sprintf("in %d days", days)
+	case days == -1:
+		return "1 day overdue"
+	}
+	return fmt.Sprintf("%d days overdue", -days)
+}
+
+// ParseFact reads a deprecation fact's value: "yes", or the sunset date as
+// YYYY-MM-DD, optionally followed by a comma and "use <replacement>" or a
+// note, e.g. "2027-01-31, use POST /v2/charges". The value "no" undoes a
+// deprecation found in the source; undeprecated reports it.
+func ParseFact(value string) (d Deprecation, undeprecated bool, err error) {
+	head, rest, _ := strings.Cut(value, ",")
+	head = strings.ToLower(strings.TrimSpace(head))
+	switch head {
+	case "no", "false":
+		return Deprecation{}, true, nil
+	case "yes", "true", "deprecated":
+	default:
+		if d.Sunset, err = time.Parse(time.DateOnly, head); err != nil {
+			return Deprecation{}, false, fmt.Errorf("bad deprecation %q: want yes or a sunset date such as 2027-01-31", value)
+		}
+	}
+	d.Replacement, d.Note = replacement(rest)
+	return d, false, nil
+}
+
+// replacement splits a note saying what to use instead, "use POST /v2", from
+// any other note.
+func replacement(note string) (use, other string) {
+	note = strings.TrimSpace(note)
+	if r, ok := strings.CutPrefix(note, "use "); ok {
+		return strings.TrimSpace(r), ""
+	}
+	if r, ok := strings.CutPrefix(note, "Use "); ok {
+		return strings.TrimSpace(r), ""
+	}
+	return "", note
+}
+
+// Load collects the deprecations of the registered repos' endpoints: those
+// their checkouts mark, then facts, then config, each overriding the ones
+// before for the same service and endpoint. Facts that don't parse are
+// skipped, so one bad declaration doesn't hide the rest. The result is
+// ordered by service and then endpoint.
+func Load(ctx context.Context, declared []config.DeprecationConfig, facts contextengine.FactSource, repos []registry.Repository) ([]Deprecation, error) {
+	type key struct{ service, endpoint string }
+	found := make(map[key]Deprecation)
+	add := func(d Deprecation) {
+		found[key{strings.ToLower(d.Service), normalize(d.Endpoint)}] = d
+	}
+
+	subdirs := make(map[string][]string)
+	for _, r := range repos {
+		if r.Parent != "" {
+			subdirs[r.Parent] = append(subdirs[r.Parent], r.Subdir)
+		}
+	}
+	for _, r := range repos {
+		if r.LocalPath == "" {
+			continue
+		}
+		root := r.LocalPath
+		if r.Parent != "" {
+			root = filepath.Join(root, filepath.FromSlash(r.Subdir))
+		}
+		for _, d := range Scan(r.Name, root, subdirs[r.Name]) {
+			k := key{strings.ToLower(d.Service), normalize(d.Endpoint)}
+			if prev, ok := found[k]; ok {
+				// Marked twice, in the spec and the source: keep what
+				// either says.
+				d.Sunset = orTime(prev.Sunset, d.Sunset)
+				d.Replacement = cmp.Or(prev.Replacement, d.Replacement)
+				d.Note = cmp.Or(prev.Note, d.Note)
+				d.Source, d.Evidence = prev.Source, prev.Evidence
+			}
+			add(d)
+		}
+	}
+
+	if facts != nil {
+		for _, r := range repos {
+			fs, err := facts.GetCurrentFacts(ctx, "", "service", r.Name)
+			if err != nil {
+				return nil, fmt.Errorf("loading facts for %s: %w", r.Name, err)
+			}
+			for _, f := range fs {
+				endpoint, ok := strings.CutPrefix(f.Key, FactKey+":")
+				if !ok || strings.TrimSpace(endpoint) == "" {
+					continue
+				}
+				d, undeprecated, err := ParseFact(f.Value)
+				if err != nil {
+					continue
+				}
+				if undeprecated {
+					delete(found, key{strings.ToLower(r.Name), normalize(endpoint)})
+					continue
+				}
+				d.Service, d.Endpoint, d.Source = r.Name, strings.TrimSpace(endpoint), SourceFact
+				add(d)
+			}
+		}
+	}
+
+	for _, c := range declared {
+		if c.Service == "" || c.Endpoint == "" {
+			return nil, fmt.Errorf("deprecations: each entry needs a service and an endpoint")
+		}
+		d := Deprecation{Service: c.Service, Endpoint: c.Endpoint, Replacement: c.Replacement, Note: c.Note, Source: SourceConfig}
+		if c.Sunset != "" {
+			sunset, err := time.Parse(time.DateOnly, c.Sunset)
+			if err != nil {
+				return nil, fmt.Errorf("deprecations: %s %s: bad sunset %q: want a date such as 2027-01-31", c.Service, c.Endpoint, c.Sunset)
+			}
+			d.Sunset = sunset
+		}
+		add(d)
+	}
+
+	out := make([]Deprecation, 0, len(found))
+	for _, d := range found {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	return out, nil
+}
+
+func orTime(a, b time.Time) time.Time {
+	if a.IsZero() {
+		return b
+	}
+	return a
+}
+
+// Scan finds the endpoints the checkout at root marks deprecated: operations
+// with deprecated: true in its OpenAPI and Swagger specs, their x-sunset
+// extension giving the sunset, and routes its source annotates (see
+// indexer.ScanDeprecatedRoutes). Hidden, dependency, and test directories
+// are skipped, as are the directories in exclude, relative to root, which
+// belong to other services.
+func Scan(service, root string, exclude []string) []Deprecation {
+	var out []Deprecation
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || testDirs[name] || slices.Contains(exclude, rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		if isSpec(name) {
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			ops, err := importers.ParseOpenAPI(string(content))
+			if err != nil {
+				return nil
+			}
+			for _, op := range ops {
+				if !op.Deprecated {
+					continue
+				}
+				dep := Deprecation{Service: service, Endpoint: op.Method + " " + op.Path, Source: SourceOpenAPI, Evidence: rel}
+				dep.Sunset, _ = time.Parse(time.DateOnly, op.Sunset)
+				out = append(out, dep)
+			}
+			return nil
+		}
+		if testFile.MatchString(name) {
+			return nil
+		}
+		language := walker.DetectLanguage(name)
+		switch language {
+		case "Go", "Python", "Java", "TypeScript", "JavaScript":
+		default:
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		for _, r := range indexer.ScanDeprecatedRoutes(rel, content, language) {
+			dep := Deprecation{Service: service, Endpoint: r.Route, Source: SourceAnnotation, Evidence: fmt.Sprintf("%s:%d (%s)", rel, r.Line, r.Evidence)}
+			dep.Replacement, dep.Note = replacement(r.Note)
+			out = append(out, dep)
+		}
+		return nil
+	})
+	return out
+}
+
+var (
+	testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "testdata": true}
+	testFile = regexp.MustCompile(`(_test\.go|\.(test|spec)\.[jt]sx?|^test_.*\.py|_test\.py|test\.java)$`)
+)
+
+// isSpec reports whether a file is named like an OpenAPI or Swagger spec.
+func isSpec(name string) bool {
+	switch path.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return strings.Contains(name, "openapi") || strings.Contains(name, "swagger")
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package deprecation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+type fakeFacts []contextengine.Fact
+
+func (f fakeFacts) GetCurrentFacts(ctx context.Context, repoID, scope, scopeID string) ([]contextengine.Fact, error) {
+	var out []contextengine.Fact
+	for _, fact := range f {
+		if fact.Scope == scope && fact.ScopeID == scopeID {
+			out = append(out, fact)
+		}
+	}
+	return out, nil
+}
+
+func TestParseFact(t *testing.T) {
+	d, undeprecated, err := ParseFact("2027-01-31, use POST /v2/charges")
+	if err != nil || undeprecated || d.Sunset.Format(time.DateOnly) != "2027-01-31" || d.Replacement != "POST /v2/charges" {
+		t.Errorf("ParseFact(date, use) = %+v, %v, %v", d, undeprecated, err)
+	}
+	if d, _, err := ParseFact("yes, being folded into billing"); err != nil || !d.Sunset.IsZero() || d.Note != "being folded into billing" {
+		t.Errorf("ParseFact(yes, note) = %+v, %v", d, err)
+	}
+	if _, undeprecated, err := ParseFact("no"); err != nil || !undeprecated {
+		t.Errorf("ParseFact(no) should undo the deprecation, got %v, %v", undeprecated, err)
+	}
+	if _, _, err := ParseFact("next quarter"); err == nil {
+		t.Error("a value that is neither yes, no, nor a date should fail")
+	}
+}
+
+func TestLoadAndUsages(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte(content), 0o644)
+	}
+	write("payments/api/openapi.yaml", `openapi: 3.0.0
+paths:
+  /v1/charges:
+    post:
+      deprecated: true
+      x-sunset: "2027-01-31"
+  /v1/charges/{id}:
+    get:
+      deprecated: true
+  /v2/charges:
+    post:
+      summary: Charge a card
+`)
+	write("payments/src/RefundController.java", `@RestController
+public class RefundController {
+    @Deprecated("use POST /v2/refunds")
+    @PostMapping("/v1/refunds")
+    public Refund refund() { return null; }
+}
+`)
+	write("payments/src/test/RefundControllerTest.java", `public class RefundControllerTest {
+    @Deprecated
+    @PostMapping("/v1/test-only")
+    public void x() {}
+}
+`)
+	write("payments/ledger/app.py", `@app.get("/entries", deprecated=True)
+def entries():
+    return []
+`)
+	repos := []registry.Repository{
+		{Name: "payments", LocalPath: filepath.Join(root, "payments")},
+		{Name: "ledger", LocalPath: filepath.Join(root, "payments"), Parent: "payments", Subdir: "ledger"},
+		{Name: "orders"},
+	}
+	facts := fakeFacts{
+		{Scope: "service", ScopeID: "payments", Key: "deprecated:get /v1/charges/:id", Value: "no"},
+		{Scope: "service", ScopeID: "orders", Key: "deprecated:orders.legacy", Value: "2026-11-30, use orders.placed"},
+		{Scope: "service", ScopeID: "orders", Key: "deprecated:GET /orders", Value: "someday"},
+	}
+	declared := []config.DeprecationConfig{{Service: "payments", Endpoint: "POST /v1/refunds", Sunset: "2027-03-01"}}
+
+	deps, err := Load(context.Background(), declared, facts, repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range deps {
+		got = append(got, d.Service+" "+d.Endpoint+" "+d.Source)
+	}
+	want := "ledger GET /entries annotation; orders orders.legacy fact; payments POST /v1/charges openapi; payments POST /v1/refunds config"
+	if strings.Join(got, "; ") != want {
+		t.Fatalf("Load =\n%s\nwant\n%s", strings.Join(got, "; "), want)
+	}
+	if d := deps[0]; d.Evidence != "app.py:2 (deprecated=True)" {
+		t.Errorf("the sub-service's route should be its own, got %+v", d)
+	}
+	if d := deps[2]; d.Sunset.Format(time.DateOnly) != "2027-01-31" || d.Evidence != "api/openapi.yaml" {
+		t.Errorf("OpenAPI deprecation = %+v", d)
+	}
+
+	links := []registry.ServiceLink{
+		{FromRepo: "checkout", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /api/v1/charges", "POST /v2/refunds"}},
+		{FromRepo: "web", ToRepo: "payments", LinkType: "http", Endpoints: []string{"post /v1/charges"}},
+		{FromRepo: "web", ToRepo: "payments", LinkType: "http", Endpoints: []string{"GET /v1/charges"}},
+		{FromRepo: "orders", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"orders.legacy"}},
+		{FromRepo: "mailer", ToRepo: "orders", LinkType: "http", Endpoints: []string{"orders.legacy"}},
+	}
+	usages := Usages(deps, links)
+	got = nil
+	for _, u := range usages {
+		got = append(got, u.Endpoint+" <- "+strings.Join(u.Consumers, ","))
+	}
+	want = "orders.legacy <- mailer; POST /v1/charges <- checkout,web; POST /v1/refunds <- ; GET /entries <- "
+	if strings.Join(got, "; ") != want {
+		t.Errorf("Usages =\n%s\nwant\n%s", strings.Join(got, "; "), want)
+	}
+
+	now := time.Date(2027, 1, 21, 15, 0, 0, 0, time.UTC)
+	for sunset, want := range map[string]string{"2027-01-31": "in 10 days", "2027-01-22": "tomorrow", "2027-01-21": "today", "2027-01-18": "3 days overdue", "": "no sunset date"} {
+		d := Deprecation{}
+		d.Sunset, _ = time.Parse(time.DateOnly, sunset)
+		if got := d.Countdown(now); got != want {
+			t.Errorf("Countdown to %q = %q, want %q", sunset, got, want)
+		}
+	}
+
+	if _, err := Load(context.Background(), []config.DeprecationConfig{{Service: "payments", Endpoint: "GET /x", Sunset: "31/01/2027"}}, nil, nil); err == nil {
+		t.Error("a sunset that isn't a date should fail")
+	}
+}
@@ -0,0 +1,100 @@
+package deprecation
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/contracts"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// Usage is a deprecation and the services still using it.
+type Usage struct {
+	Deprecation
+	// Consumers are the services calling the endpoint or reading the
+	// topic, sorted; empty once nothing uses it.
+	Consumers []string `json:"consumers"`
+}
+
+func isAsync(linkType string) bool {
+	switch strings.ToLower(linkType) {
+	case "kafka", "amqp", "event", "pubsub", "queue", "sqs", "sns", "nats":
+		return true
+	}
+	return false
+}
+
+// Usages finds the services still using each deprecation in the links: the
+// callers of an HTTP endpoint or RPC the deprecating service provides, and
+// the readers of a topic it produces. Usages are ordered by sunset, soonest
+// first and undated last, then by service and endpoint.
+func Usages(deps []Deprecation, links []registry.ServiceLink) []Usage {
+	out := make([]Usage, len(deps))
+	for i, d := range deps {
+		out[i].Deprecation = d
+		for _, l := range links {
+			provider, consumer := l.ToRepo, l.FromRepo
+			if isAsync(l.LinkType) {
+				provider, consumer = l.FromRepo, l.ToRepo
+			}
+			if !strings.EqualFold(provider, d.Service) || slices.Contains(out[i].Consumers, consumer) {
+				continue
+			}
+			if slices.ContainsFunc(l.Endpoints, d.Matches) {
+				out[i].Consumers = append(out[i].Consumers, consumer)
+			}
+		}
+		sort.Strings(out[i].Consumers)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Sunset, out[j].Sunset
+		if !a.Equal(b) {
+			return !a.IsZero() && (b.IsZero() || a.Before(b))
+		}
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	return out
+}
+
+// Matches reports whether an endpoint as a link names it is the deprecated
+// one. HTTP endpoints match on method, when both have one, and path, with
+// parameters in any style, also when the caller's path adds a gateway's
+// prefix; RPCs and topics match by name.
+func (d Deprecation) Matches(endpoint string) bool {
+	method, p, isHTTP := splitHTTP(d.Endpoint)
+	usedMethod, used, usedHTTP := splitHTTP(endpoint)
+	if !isHTTP || !usedHTTP {
+		return !isHTTP && !usedHTTP && strings.EqualFold(strings.TrimSpace(d.Endpoint), strings.TrimSpace(endpoint))
+	}
+	if method != "" && usedMethod != "" && method != usedMethod {
+		return false
+	}
+	p, used = contracts.TemplatePath(p), contracts.TemplatePath(used)
+	return p == used || (p != "" && strings.HasSuffix(used, p))
+}
+
+// splitHTTP reads an endpoint like "POST /v1/charges", or a bare path,
+// which has no method.
+func splitHTTP(endpoint string) (method, path string, ok bool) {
+	fields := strings.Fields(endpoint)
+	switch {
+	case len(fields) == 1 && strings.HasPrefix(fields[0], "/"):
+		return "", fields[0], true
+	case len(fields) == 2 && strings.HasPrefix(fields[1], "/"):
+		return strings.ToUpper(fields[0]), fields[1], true
+	}
+	return "", "", false
+}
+
+// normalize keys an endpoint so the same one declared in different styles,
+// such as "post /charges/:id" and "POST /charges/{id}", is the same.
+func normalize(endpoint string) string {
+	if method, p, ok := splitHTTP(endpoint); ok {
+		return strings.TrimSpace(method + " " + contracts.TemplatePath(p))
+	}
+	return strings.ToLower(strings.TrimSpace(endpoint))
+}
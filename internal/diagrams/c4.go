@@ -0,0 +1,244 @@
+package diagrams
+
+import (
+	"fmt"
+	"strings"
+)
+
+// C4ComponentsFile is a repo's C4 container and its components as JSON,
+// written beside its docs, which the central site reads to give the repo
+// a Component view in the system's workspace.
+const C4ComponentsFile = "c4-components.json"
+
+// C4Model is a software system described at the first three levels of the
+// C4 model: the people and external systems around it (System Context),
+// the containers it is made of (Container), and each container's
+// components (Component).
+//
+// Builders give every element an ID, from C4ID, unique across the model;
+// a component's need only be unique within its container.
+type C4Model struct {
+	ID          string
+	Name        string
+	Description string
+	People      []C4Element
+	Externals   []C4Element // external software systems
+	Containers  []C4Container
+	// Relationships link people, containers, and externals.
+	Relationships []C4Relationship
+}
+
+// C4Element is a person, software system, or component.
+type C4Element struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Technology  string `json:"technology,omitempty"`
+}
+
+// C4Container is a separately deployed part of the system, with its
+// components and the relationships between them.
+type C4Container struct {
+	C4Element
+	Components    []C4Element      `json:"components"`
+	Relationships []C4Relationship `json:"relationships"`
+}
+
+// C4Relationship is a use of one element by another; From and To are IDs.
+type C4Relationship struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Label      string `json:"label"`
+	Technology string `json:"technology,omitempty"`
+}
+
+// C4ID converts a name into an identifier that both Mermaid C4 and the
+// Structurizr DSL accept.
+func C4ID(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	id := b.String()
+	if id == "" || id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	return id
+}
+
+// C4IDs hands out identifiers from C4ID, suffixed where needed to keep
+// them unique.
+type C4IDs map[string]bool
+
+// New returns an unused identifier for name.
+func (ids C4IDs) New(name string) string {
+	id := C4ID(name)
+	for i := 2; ids[id]; i++ {
+		id = fmt.Sprintf("%s_%d", C4ID(name), i)
+	}
+	ids[id] = true
+	return id
+}
+
+// c4Text makes s safe inside a quoted C4 string: one line, no double
+// quotes, which neither notation can escape everywhere.
+func c4Text(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), `"`, "'")
+}
+
+// SystemContextMermaid renders the System Context view as a Mermaid C4
+// diagram: the system as one box, with the people who use it and the
+// external systems it uses. Relationships are lifted from the containers
+// to the system.
+func (m C4Model) SystemContextMermaid() string {
+	var b strings.Builder
+	b.WriteString("C4Context\n")
+	fmt.Fprintf(&b, "    title System Context of %s\n", c4Text(m.Name))
+	for _, p := range m.People {
+		fmt.Fprintf(&b, "    Person(%s, \"%s\", \"%s\")\n", p.ID, c4Text(p.Name), c4Text(p.Description))
+	}
+	system := m.ID
+	fmt.Fprintf(&b, "    System(%s, \"%s\", \"%s\")\n", system, c4Text(m.Name), c4Text(m.Description))
+	for _, e := range m.Externals {
+		fmt.Fprintf(&b, "    System_Ext(%s, \"%s\", \"%s\")\n", e.ID, c4Text(e.Name), c4Text(e.Description))
+	}
+	inside := make(map[string]bool, len(m.Containers))
+	for _, c := range m.Containers {
+		inside[c.ID] = true
+	}
+	// One relationship per pair, labelled with what the first link says.
+	seen := make(map[string]bool)
+	for _, r := range m.Relationships {
+		from, to := r.From, r.To
+		if inside[from] {
+			from = system
+		}
+		if inside[to] {
+			to = system
+		}
+		if from == to || seen[from+">"+to] {
+			continue
+		}
+		seen[from+">"+to] = true
+		writeMermaidRel(&b, C4Relationship{From: from, To: to, Label: r.Label, Technology: r.Technology})
+	}
+	return b.String()
+}
+
+// ContainerMermaid renders the Container view as a Mermaid C4 diagram: the
+// system's containers inside its boundary, with the people and external
+// systems around them.
+func (m C4Model) ContainerMermaid() string {
+	var b strings.Builder
+	b.WriteString("C4Container\n")
+	fmt.Fprintf(&b, "    title Containers of %s\n", c4Text(m.Name))
+	for _, p := range m.People {
+		fmt.Fprintf(&b, "    Person(%s, \"%s\", \"%s\")\n", p.ID, c4Text(p.Name), c4Text(p.Description))
+	}
+	fmt.Fprintf(&b, "    System_Boundary(%s, \"%s\") {\n", m.ID, c4Text(m.Name))
+	for _, c := range m.Containers {
+		fmt.Fprintf(&b, "        Container(%s, \"%s\", \"%s\", \"%s\")\n", c.ID, c4Text(c.Name), c4Text(c.Technology), c4Text(c.Description))
+	}
+	b.WriteString("    }\n")
+	for _, e := range m.Externals {
+		fmt.Fprintf(&b, "    System_Ext(%s, \"%s\", \"%s\")\n", e.ID, c4Text(e.Name), c4Text(e.Description))
+	}
+	for _, r := range m.Relationships {
+		writeMermaidRel(&b, r)
+	}
+	return b.String()
+}
+
+// ComponentMermaid renders the container's Component view as a Mermaid C4
+// diagram. Component IDs are prefixed with the container's, as in
+// Structurizr.
+func (c C4Container) ComponentMermaid() string {
+	var b strings.Builder
+	b.WriteString("C4Component\n")
+	fmt.Fprintf(&b, "    title Components of %s\n", c4Text(c.Name))
+	fmt.Fprintf(&b, "    Container_Boundary(%s, \"%s\") {\n", c.ID, c4Text(c.Name))
+	for _, comp := range c.Components {
+		fmt.Fprintf(&b, "        Component(%s_%s, \"%s\", \"%s\", \"%s\")\n", c.ID, comp.ID, c4Text(comp.Name), c4Text(comp.Technology), c4Text(comp.Description))
+	}
+	b.WriteString("    }\n")
+	for _, r := range c.Relationships {
+		writeMermaidRel(&b, C4Relationship{From: c.ID + "_" + r.From, To: c.ID + "_" + r.To, Label: r.Label, Technology: r.Technology})
+	}
+	return b.String()
+}
+
+func writeMermaidRel(b *strings.Builder, r C4Relationship) {
+	if r.Technology != "" {
+		fmt.Fprintf(b, "    Rel(%s, %s, \"%s\", \"%s\")\n", r.From, r.To, c4Text(r.Label), c4Text(r.Technology))
+		return
+	}
+	fmt.Fprintf(b, "    Rel(%s, %s, \"%s\")\n", r.From, r.To, c4Text(r.Label))
+}
+
+// Structurizr renders the model as a Structurizr DSL workspace with a
+// System Context view, a Container view, and a Component view for every
+// container with components. Component identifiers are prefixed with
+// their container's, since the DSL's identifiers are global.
+func (m C4Model) Structurizr() string {
+	system := m.ID
+	var b strings.Builder
+	fmt.Fprintf(&b, "workspace \"%s\" \"%s\" {\n\n", c4Text(m.Name), c4Text(m.Description))
+	b.WriteString("    model {\n")
+	for _, p := range m.People {
+		fmt.Fprintf(&b, "        %s = person \"%s\" \"%s\"\n", p.ID, c4Text(p.Name), c4Text(p.Description))
+	}
+	fmt.Fprintf(&b, "        %s = softwareSystem \"%s\" \"%s\" {\n", system, c4Text(m.Name), c4Text(m.Description))
+	for _, c := range m.Containers {
+		fmt.Fprintf(&b, "            %s = container \"%s\" \"%s\" \"%s\"", c.ID, c4Text(c.Name), c4Text(c.Description), c4Text(c.Technology))
+		if len(c.Components) == 0 {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(" {\n")
+		for _, comp := range c.Components {
+			fmt.Fprintf(&b, "                %s_%s = component \"%s\" \"%s\" \"%s\"\n", c.ID, comp.ID, c4Text(comp.Name), c4Text(comp.Description), c4Text(comp.Technology))
+		}
+		b.WriteString("            }\n")
+	}
+	b.WriteString("        }\n")
+	for _, e := range m.Externals {
+		fmt.Fprintf(&b, "        %s = softwareSystem \"%s\" \"%s\" {\n            tags \"External\"\n        }\n", e.ID, c4Text(e.Name), c4Text(e.Description))
+	}
+	b.WriteString("\n")
+	for _, r := range m.Relationships {
+		writeStructurizrRel(&b, r.From, r.To, r)
+	}
+	for _, c := range m.Containers {
+		for _, r := range c.Relationships {
+			writeStructurizrRel(&b, c.ID+"_"+r.From, c.ID+"_"+r.To, r)
+		}
+	}
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    views {\n")
+	fmt.Fprintf(&b, "        systemContext %s \"SystemContext\" {\n            include *\n            autolayout lr\n        }\n", system)
+	fmt.Fprintf(&b, "        container %s \"Containers\" {\n            include *\n            autolayout lr\n        }\n", system)
+	for _, c := range m.Containers {
+		if len(c.Components) > 0 {
+			fmt.Fprintf(&b, "        component %s \"Components-%s\" {\n            include *\n            autolayout lr\n        }\n", c.ID, c.ID)
+		}
+	}
+	b.WriteString("        styles {\n")
+	b.WriteString("            element \"Person\" {\n                shape person\n            }\n")
+	b.WriteString("            element \"External\" {\n                background #999999\n                color #ffffff\n            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n}\n")
+	return b.String()
+}
+
+func writeStructurizrRel(b *strings.Builder, from, to string, r C4Relationship) {
+	fmt.Fprintf(b, "        %s -> %s \"%s\"", from, to, c4Text(r.Label))
+	if r.Technology != "" {
+		fmt.Fprintf(b, " \"%s\"", c4Text(r.Technology))
+	}
+	b.WriteString("\n")
+}
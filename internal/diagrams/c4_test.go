@@ -0,0 +1,97 @@
+package diagrams
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestC4IDs(t *testing.T) {
+	ids := make(C4IDs)
+	for _, tc := range []struct{ name, want string }{
+		{"order-service", "order_service"},
+		{"order service", "order_service_2"},
+		{"3ds", "_3ds"},
+	} {
+		if got := ids.New(tc.name); got != tc.want {
+			t.Errorf("New(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestC4Model(t *testing.T) {
+	m := C4Model{
+		ID:     "shop_system",
+		Name:   "Shop",
+		People: []C4Element{{ID: "user", Name: "User"}},
+		Externals: []C4Element{
+			{ID: "stripe", Name: "stripe", Description: "External dependency"},
+		},
+		Containers: []C4Container{
+			{C4Element: C4Element{ID: "web", Name: "web", Technology: "TypeScript"}},
+			{
+				C4Element:     C4Element{ID: "orders", Name: "orders", Description: `Takes "orders"`, Technology: "Go"},
+				Components:    []C4Element{{ID: "api", Name: "API"}, {ID: "store", Name: "Store"}},
+				Relationships: []C4Relationship{{From: "api", To: "store", Label: "uses"}},
+			},
+		},
+		Relationships: []C4Relationship{
+			{From: "user", To: "web", Label: "Uses", Technology: "UI app"},
+			{From: "web", To: "orders", Label: "http"},
+			{From: "orders", To: "stripe", Label: "http"},
+			{From: "web", To: "stripe", Label: "http"},
+		},
+	}
+
+	context := m.SystemContextMermaid()
+	for _, want := range []string{
+		"C4Context\n",
+		`System(shop_system, "Shop", "")`,
+		`Rel(user, shop_system, "Uses", "UI app")`,
+		`Rel(shop_system, stripe, "http")`,
+	} {
+		if !strings.Contains(context, want) {
+			t.Errorf("context diagram lacks %q:\n%s", want, context)
+		}
+	}
+	// Calls between containers are inside the system, and the two calls
+	// to stripe are one relationship.
+	if n := strings.Count(context, "Rel("); n != 2 {
+		t.Errorf("context diagram has %d relationships, want 2:\n%s", n, context)
+	}
+
+	containers := m.ContainerMermaid()
+	for _, want := range []string{
+		`System_Boundary(shop_system, "Shop") {`,
+		`Container(orders, "orders", "Go", "Takes 'orders'")`,
+		`Rel(web, orders, "http")`,
+	} {
+		if !strings.Contains(containers, want) {
+			t.Errorf("container diagram lacks %q:\n%s", want, containers)
+		}
+	}
+
+	components := m.Containers[1].ComponentMermaid()
+	if !strings.Contains(components, `Component(orders_api, "API", "", "")`) || !strings.Contains(components, `Rel(orders_api, orders_store, "uses")`) {
+		t.Errorf("component diagram:\n%s", components)
+	}
+
+	dsl := m.Structurizr()
+	for _, want := range []string{
+		`shop_system = softwareSystem "Shop" "" {`,
+		`orders_store = component "Store" "" ""`,
+		`orders_api -> orders_store "uses"`,
+		`user -> web "Uses" "UI app"`,
+		`component orders "Components-orders" {`,
+		`tags "External"`,
+	} {
+		if !strings.Contains(dsl, want) {
+			t.Errorf("workspace lacks %q:\n%s", want, dsl)
+		}
+	}
+	if strings.Contains(dsl, `component web`) {
+		t.Errorf("workspace has a component view of a container without components:\n%s", dsl)
+	}
+	if strings.Count(dsl, "{") != strings.Count(dsl, "}") {
+		t.Errorf("workspace braces don't balance:\n%s", dsl)
+	}
+}
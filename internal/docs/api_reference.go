@@ -0,0 +1,236 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// apiPackage is the exported API of one directory, for the API Reference.
+type apiPackage struct {
+	Path      string
+	Languages []string
+	Types     []apiType
+	Functions []apiFunc
+}
+
+type apiType struct {
+	Name    string
+	File    string
+	Class   indexer.ClassDoc
+	Fields  []indexer.FieldDoc
+	Methods []indexer.FunctionDoc
+}
+
+type apiFunc struct {
+	File string
+	Func indexer.FunctionDoc
+}
+
+// GenerateAPIReference writes api-reference.md, listing the exported
+// functions and types of every package (directory) with their signatures,
+// parameters, and fields. It does nothing when no file exports anything.
+func (g *DocGenerator) GenerateAPIReference(analyses []indexer.FileAnalysis) error {
+	content := RenderAPIReference(analyses)
+	if content == "" {
+		return nil
+	}
+	docsDir := filepath.Join(g.OutputDir, "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(docsDir, "api-reference.md"), []byte(content), 0o644)
+}
+
+// RenderAPIReference renders the API Reference page, or "" when there is no
+// exported API. Test files are left out.
+func RenderAPIReference(analyses []indexer.FileAnalysis) string {
+	pkgs := collectAPI(analyses)
+	if len(pkgs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("# API Reference\n\n")
+	b.WriteString("The exported functions and types of each package, taken from the per-file analyses.\n\n")
+	b.WriteString("| Package | Languages | Types | Functions |\n")
+	b.WriteString("|---------|-----------|-------|-----------|\n")
+	for _, p := range pkgs {
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %d | %d |\n",
+			p.Path, anchorize(p.Path), strings.Join(p.Languages, ", "), len(p.Types), len(p.Functions))
+	}
+	b.WriteString("\n")
+
+	for _, p := range pkgs {
+		fmt.Fprintf(&b, "## %s\n\n", p.Path)
+		qualifier := path.Base(p.Path)
+		if p.Path == "(root)" {
+			qualifier = ""
+		}
+		for _, t := range p.Types {
+			writeAPIType(&b, qualifier, t)
+		}
+		for _, f := range p.Functions {
+			writeAPIFunc(&b, "###", qualify(qualifier, f.Func.Name), f.File, f.Func)
+		}
+	}
+	return b.String()
+}
+
+// collectAPI groups the exported API by directory, in path order.
+func collectAPI(analyses []indexer.FileAnalysis) []apiPackage {
+	byDir := make(map[string]*apiPackage)
+	langs := make(map[string]map[string]bool)
+	for _, a := range analyses {
+		if a.Skip || isTestFile(a.FilePath) {
+			continue
+		}
+		filePath := filepath.ToSlash(a.FilePath)
+		dir := path.Dir(filePath)
+		if dir == "." {
+			dir = "(root)"
+		}
+		var types []apiType
+		for _, c := range a.Classes {
+			if !isExported(a.Language, c.Name, "") {
+				continue
+			}
+			t := apiType{Name: c.Name, File: filePath, Class: c}
+			for _, f := range c.Fields {
+				if isExported(a.Language, f.Name, "") {
+					t.Fields = append(t.Fields, f)
+				}
+			}
+			for _, m := range c.Methods {
+				if isExported(a.Language, m.Name, m.Signature) {
+					t.Methods = append(t.Methods, m)
+				}
+			}
+			types = append(types, t)
+		}
+		var funcs []apiFunc
+		for _, fn := range a.Functions {
+			if isExported(a.Language, fn.Name, fn.Signature) {
+				funcs = append(funcs, apiFunc{File: filePath, Func: fn})
+			}
+		}
+		if len(types) == 0 && len(funcs) == 0 {
+			continue
+		}
+		p := byDir[dir]
+		if p == nil {
+			p = &apiPackage{Path: dir}
+			byDir[dir] = p
+			langs[dir] = make(map[string]bool)
+		}
+		p.Types = append(p.Types, types...)
+		p.Functions = append(p.Functions, funcs...)
+		if a.Language != "" && a.Language != "unknown" {
+			langs[dir][a.Language] = true
+		}
+	}
+
+	pkgs := make([]apiPackage, 0, len(byDir))
+	for dir, p := range byDir {
+		for l := range langs[dir] {
+			p.Languages = append(p.Languages, l)
+		}
+		sort.Strings(p.Languages)
+		sort.SliceStable(p.Types, func(i, j int) bool { return p.Types[i].Name < p.Types[j].Name })
+		sort.SliceStable(p.Functions, func(i, j int) bool { return p.Functions[i].Func.Name < p.Functions[j].Func.Name })
+		pkgs = append(pkgs, *p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Path < pkgs[j].Path })
+	return pkgs
+}
+
+func writeAPIType(b *strings.Builder, qualifier string, t apiType) {
+	fmt.Fprintf(b, "### %s\n\n", qualify(qualifier, t.Name))
+	fmt.Fprintf(b, "Type · defined in [%s](%s)\n\n", path.Base(t.File), apiFileLink(t.File, t.Name))
+	if t.Class.Summary != "" {
+		b.WriteString(strings.TrimSpace(t.Class.Summary) + "\n\n")
+	}
+	if len(t.Fields) > 0 {
+		b.WriteString("| Field | Type | Description |\n")
+		b.WriteString("|-------|------|-------------|\n")
+		for _, f := range t.Fields {
+			fmt.Fprintf(b, "| %s | %s | %s |\n", tableCell(f.Name), codeCell(f.Type), tableCell(f.Description))
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range t.Methods {
+		name := m.Name
+		if !strings.Contains(name, ".") {
+			name = t.Name + "." + name
+		}
+		writeAPIFunc(b, "####", qualify(qualifier, name), t.File, m)
+	}
+}
+
+func writeAPIFunc(b *strings.Builder, heading, name, file string, fn indexer.FunctionDoc) {
+	fmt.Fprintf(b, "%s %s\n\n", heading, name)
+	if fn.Signature != "" {
+		fmt.Fprintf(b, "```\n%s\n```\n\n", strings.TrimSpace(fn.Signature))
+	}
+	if fn.Summary != "" {
+		b.WriteString(strings.TrimSpace(fn.Summary) + "\n\n")
+	}
+	if len(fn.Parameters) > 0 {
+		b.WriteString("| Parameter | Type | Description |\n")
+		b.WriteString("|-----------|------|-------------|\n")
+		for _, p := range fn.Parameters {
+			fmt.Fprintf(b, "| %s | %s | %s |\n", tableCell(p.Name), codeCell(p.Type), tableCell(p.Description))
+		}
+		b.WriteString("\n")
+	}
+	if fn.Returns != "" {
+		fmt.Fprintf(b, "**Returns:** %s\n\n", strings.TrimSpace(fn.Returns))
+	}
+	fmt.Fprintf(b, "Source: [%s](%s)\n\n", path.Base(file), apiFileLink(file, fn.Name))
+}
+
+// apiFileLink links to a symbol's section on its file page.
+func apiFileLink(file, symbol string) string {
+	return file + ".md#" + anchorize(symbol)
+}
+
+func qualify(qualifier, name string) string {
+	if qualifier == "" {
+		return name
+	}
+	return qualifier + "." + name
+}
+
+// tableCell keeps a value on one line and escapes pipes for a markdown table.
+func tableCell(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(s, "\r", ""), "\n", " "))
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func codeCell(s string) string {
+	if s = tableCell(s); s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// isTestFile reports whether a path follows a common test file naming
+// convention.
+func isTestFile(p string) bool {
+	base := strings.ToLower(path.Base(filepath.ToSlash(p)))
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	switch {
+	case strings.HasSuffix(stem, "_test"), strings.HasPrefix(stem, "test_"):
+		return true
+	case strings.HasSuffix(stem, ".test"), strings.HasSuffix(stem, ".spec"):
+		return true
+	case strings.HasSuffix(stem, "test") && (strings.HasSuffix(base, ".java") || strings.HasSuffix(base, ".kt") || strings.HasSuffix(base, ".cs")):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package docs
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/diagrams"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// GenerateC4Components writes the repo's C4 Component view: its features,
+// the interactive map's groups, as components, one using another when a
+// file of the first depends on a file of the second. It writes
+// c4-components.md, with a Mermaid C4 diagram and a Structurizr DSL
+// workspace, and diagrams.C4ComponentsFile.
+func (g *DocGenerator) GenerateC4Components(analyses []indexer.FileAnalysis, features []Feature) error {
+	name := projectNameFromWd(g.OutputDir)
+	data := buildMapData(analyses, features, name)
+	container := buildC4Container(data, features)
+
+	docsDir := filepath.Join(g.OutputDir, "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		return err
+	}
+	jsonBytes, err := json.MarshalIndent(container, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling C4 components: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(docsDir, diagrams.C4ComponentsFile), jsonBytes, 0o644); err != nil {
+		return err
+	}
+
+	slugs := make(map[string]string, len(features))
+	for _, f := range features {
+		slugs[f.Name] = f.Slug
+	}
+	files := make(map[string]int)
+	for _, n := range data.Nodes {
+		files[n.Group]++
+	}
+	model := diagrams.C4Model{ID: "system_" + container.ID, Name: name, Containers: []diagrams.C4Container{container}}
+
+	var b strings.Builder
+	b.WriteString("# C4 Components\n\n")
+	fmt.Fprintf(&b, "The C4 Component view of %s: its features as components, and which use which. A component uses another when one of its files depends on a file of the other.\n\n", name)
+	b.WriteString("```mermaid\n" + container.ComponentMermaid() + "```\n\n")
+	b.WriteString("| Component | Technology | Files | Description |\n")
+	b.WriteString("|-----------|------------|-------|-------------|\n")
+	for _, c := range container.Components {
+		label := c.Name
+		if slug := slugs[c.Name]; slug != "" {
+			label = fmt.Sprintf("[%s](features/%s.md)", c.Name, slug)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", label, cmp.Or(c.Technology, "—"), files[c.Name], cmp.Or(c.Description, "—"))
+	}
+	b.WriteString("\n## Structurizr DSL\n\n")
+	b.WriteString("The same view as a [Structurizr](https://structurizr.com/) workspace, to render or extend with its tools.\n\n")
+	b.WriteString("```\n" + model.Structurizr() + "```\n\n")
+	b.WriteString("[Back to Home](index.md)\n")
+	return os.WriteFile(filepath.Join(docsDir, "c4-components.md"), []byte(b.String()), 0o644)
+}
+
+// buildC4Container turns the interactive map's data into a C4 container
+// whose components are its feature groups.
+func buildC4Container(data mapData, features []Feature) diagrams.C4Container {
+	descriptions := make(map[string]string, len(features))
+	for _, f := range features {
+		descriptions[f.Name] = firstSentence(f.Description)
+	}
+	langs := make(map[string]map[string]int)
+	all := make(map[string]int)
+	group := make(map[string]string, len(data.Nodes))
+	for _, n := range data.Nodes {
+		group[n.ID] = n.Group
+		if n.Lang == "" {
+			continue
+		}
+		if langs[n.Group] == nil {
+			langs[n.Group] = make(map[string]int)
+		}
+		langs[n.Group][n.Lang]++
+		all[n.Lang]++
+	}
+
+	c := diagrams.C4Container{
+		C4Element:     diagrams.C4Element{ID: diagrams.C4ID(data.ProjectName), Name: data.ProjectName, Technology: mostCommon(all)},
+		Components:    []diagrams.C4Element{},
+		Relationships: []diagrams.C4Relationship{},
+	}
+	ids := make(diagrams.C4IDs)
+	compID := make(map[string]string)
+	for _, f := range data.Features {
+		if !slices.ContainsFunc(data.Nodes, func(n mapNode) bool { return n.Group == f.Name }) {
+			continue
+		}
+		compID[f.Name] = ids.New(f.Name)
+		description := descriptions[f.Name]
+		if f.Name == "Other" && description == "" {
+			description = "Files in no feature"
+		}
+		c.Components = append(c.Components, diagrams.C4Element{ID: compID[f.Name], Name: f.Name, Description: description, Technology: mostCommon(langs[f.Name])})
+	}
+	seen := make(map[string]bool)
+	for _, e := range data.Edges {
+		from, to := compID[group[e.Source]], compID[group[e.Target]]
+		if from == "" || to == "" || from == to || seen[from+">"+to] {
+			continue
+		}
+		seen[from+">"+to] = true
+		c.Relationships = append(c.Relationships, diagrams.C4Relationship{From: from, To: to, Label: "uses"})
+	}
+	slices.SortFunc(c.Relationships, func(a, b diagrams.C4Relationship) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+	return c
+}
+
+// mostCommon returns the key with the highest count, the first by name on
+// a tie.
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for k, n := range counts {
+		if n > counts[best] || n == counts[best] && k < best {
+			best = k
+		}
+	}
+	return best
+}
@@ -0,0 +1,195 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// Reasons a walked file has no docs. Files the walker left out carry its
+// walker.SkipReason instead.
+const (
+	ReasonFailed     = "analysis failed"
+	ReasonIrrelevant = "not relevant"
+	ReasonDeferred   = "deferred by budget"
+	ReasonPending    = "not analyzed"
+)
+
+// Coverage is how much of a repo the docs cover, and which files were
+// skipped and why.
+type Coverage struct {
+	Documented int
+	Skipped    []SkippedFile
+}
+
+// SkippedFile is a file the docs don't cover.
+type SkippedFile struct {
+	Path   string
+	Reason string
+	// Detail adds to the reason, e.g. the error an analysis failed with.
+	Detail string
+}
+
+// Skip records a file the docs don't cover.
+func (c *Coverage) Skip(path, reason, detail string) {
+	c.Skipped = append(c.Skipped, SkippedFile{Path: path, Reason: reason, Detail: detail})
+}
+
+// Account sorts the walked files into documented ones, which have an
+// analysis in documented, and skipped ones. A skipped file failed if it is
+// in failed, was deferred if it is in deferred, and is irrelevant if its
+// analysis in stored says so; otherwise it has not been analyzed yet.
+func (c *Coverage) Account(files []walker.FileInfo, documented []indexer.FileAnalysis, stored map[string]indexer.FileAnalysis, failed map[string]error, deferred []string) {
+	has := make(map[string]bool, len(documented))
+	for _, a := range documented {
+		has[a.FilePath] = true
+	}
+	wasDeferred := make(map[string]bool, len(deferred))
+	for _, path := range deferred {
+		wasDeferred[path] = true
+	}
+	for _, f := range files {
+		switch {
+		case has[f.RelPath]:
+			c.Documented++
+		case failed[f.RelPath] != nil:
+			c.Skip(f.RelPath, ReasonFailed, failed[f.RelPath].Error())
+		case wasDeferred[f.RelPath]:
+			c.Skip(f.RelPath, ReasonDeferred, "")
+		case stored[f.RelPath].Skip:
+			c.Skip(f.RelPath, ReasonIrrelevant, "")
+		default:
+			c.Skip(f.RelPath, ReasonPending, "")
+		}
+	}
+}
+
+// Considered is the number of files not left out on purpose, by an ignore
+// pattern or a language toggle: the files the docs should cover.
+func (c *Coverage) Considered() int {
+	n := c.Documented
+	for _, s := range c.Skipped {
+		if !excluded(s.Reason) {
+			n++
+		}
+	}
+	return n
+}
+
+// excluded reports whether a skip reason means the file was left out on
+// purpose.
+func excluded(reason string) bool {
+	return reason == string(walker.SkipIgnored) || reason == string(walker.SkipLanguage)
+}
+
+// Percent is the share of considered files the docs cover, rounded down so
+// that a repo with a skipped file never shows 100%.
+func (c *Coverage) Percent() int {
+	n := c.Considered()
+	if n == 0 {
+		return 0
+	}
+	return c.Documented * 100 / n
+}
+
+// coverageLabel labels the index page's link to the coverage page, or is
+// empty when there is none.
+func (g *DocGenerator) coverageLabel() string {
+	if g.Coverage == nil {
+		return ""
+	}
+	return fmt.Sprintf("Coverage: %d%% of files documented", g.Coverage.Percent())
+}
+
+// GenerateCoverage writes coverage.md, listing the files the docs don't
+// cover and why. It does nothing when g.Coverage is nil.
+func (g *DocGenerator) GenerateCoverage() error {
+	if g.Coverage == nil {
+		return nil
+	}
+	docsDir := filepath.Join(g.OutputDir, "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(docsDir, "coverage.md"), []byte(RenderCoverage(g.Coverage)), 0o644)
+}
+
+// coverageReasons orders the reasons on the coverage page: what can be
+// fixed first, files left out on purpose last.
+var coverageReasons = []string{
+	ReasonFailed,
+	ReasonPending,
+	ReasonDeferred,
+	string(walker.SkipTooLarge),
+	string(walker.SkipUnreadable),
+	string(walker.SkipBinary),
+	ReasonIrrelevant,
+	string(walker.SkipLanguage),
+	string(walker.SkipIgnored),
+}
+
+// RenderCoverage renders the coverage page markdown.
+func RenderCoverage(c *Coverage) string {
+	byReason := make(map[string][]SkippedFile)
+	for _, s := range c.Skipped {
+		byReason[s.Reason] = append(byReason[s.Reason], s)
+	}
+	var other []string
+	for reason := range byReason {
+		if !slices.Contains(coverageReasons, reason) {
+			other = append(other, reason)
+		}
+	}
+	sort.Strings(other)
+	reasons := append(slices.Clone(coverageReasons), other...)
+
+	var b strings.Builder
+	b.WriteString("# Coverage\n\n")
+	fmt.Fprintf(&b, "**%d%%** of files are documented: %d of %d.", c.Percent(), c.Documented, c.Considered())
+	if n := len(byReason[string(walker.SkipIgnored)]) + len(byReason[string(walker.SkipLanguage)]); n > 0 {
+		fmt.Fprintf(&b, " Files left out by an ignore pattern or language toggle (%d) don't count.", n)
+	}
+	b.WriteString(" Directories autodoc always skips, such as `.git` and `node_modules`, aren't listed.\n\n")
+	if len(c.Skipped) == 0 {
+		b.WriteString("No files were skipped.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Reason | Files |\n")
+	b.WriteString("|--------|-------|\n")
+	for _, reason := range reasons {
+		if n := len(byReason[reason]); n > 0 {
+			fmt.Fprintf(&b, "| [%s](#%s) | %d |\n", capitalize(reason), anchorize(reason), n)
+		}
+	}
+
+	for _, reason := range reasons {
+		files := byReason[reason]
+		if len(files) == 0 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		fmt.Fprintf(&b, "\n## %s\n\n", capitalize(reason))
+		for _, f := range files {
+			fmt.Fprintf(&b, "- `%s`", f.Path)
+			if f.Detail != "" {
+				fmt.Fprintf(&b, ": %s", strings.Join(strings.Fields(f.Detail), " "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,80 @@
+package docs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+)
+
+// GenerateDecisions writes decisions.md listing the project's Architecture
+// Decision Records with their status, outcome, and the services and flows
+// each one mentions. It does nothing when adrs is empty.
+func (g *DocGenerator) GenerateDecisions(adrs []importers.ADR) error {
+	if len(adrs) == 0 {
+		return nil
+	}
+
+	docsDir := filepath.Join(g.OutputDir, "docs")
+	if err := os.MkdirAll(docsDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(docsDir, "decisions.md"), []byte(RenderDecisions(adrs)), 0o644)
+}
+
+// RenderDecisions renders the Decisions page markdown for a set of ADRs.
+func RenderDecisions(adrs []importers.ADR) string {
+	var b strings.Builder
+	b.WriteString("# Decisions\n\n")
+	b.WriteString("Architecture Decision Records discovered in this repository.\n\n")
+
+	b.WriteString("| ADR | Title | Status | Date |\n")
+	b.WriteString("|-----|-------|--------|------|\n")
+	for _, adr := range adrs {
+		b.WriteString(fmt.Sprintf("| %s | [%s](#%s) | %s | %s |\n",
+			adrLabel(adr), tableCell(adr.Title), anchorize(adrHeading(adr)), tableCell(adr.Status), tableCell(adr.Date)))
+	}
+	b.WriteString("\n")
+
+	for _, adr := range adrs {
+		b.WriteString(fmt.Sprintf("## %s\n\n", adrHeading(adr)))
+		b.WriteString(fmt.Sprintf("**Status:** %s", adr.Status))
+		if adr.Date != "" {
+			b.WriteString(fmt.Sprintf(" · **Date:** %s", adr.Date))
+		}
+		b.WriteString(fmt.Sprintf(" · **Source:** `%s`\n\n", adr.FilePath))
+		if adr.Context != "" {
+			b.WriteString("### Context\n\n" + adr.Context + "\n\n")
+		}
+		if adr.Decision != "" {
+			b.WriteString("### Decision\n\n" + adr.Decision + "\n\n")
+		}
+		if adr.Consequences != "" {
+			b.WriteString("### Consequences\n\n" + adr.Consequences + "\n\n")
+		}
+		if len(adr.RelatedServices) > 0 {
+			b.WriteString("**Related services:** " + strings.Join(adr.RelatedServices, ", ") + "\n\n")
+		}
+		if len(adr.RelatedFlows) > 0 {
+			b.WriteString("**Related flows:** " + strings.Join(adr.RelatedFlows, ", ") + "\n\n")
+		}
+		b.WriteString("---\n\n")
+	}
+	return b.String()
+}
+
+func adrLabel(adr importers.ADR) string {
+	if adr.Number > 0 {
+		return fmt.Sprintf("ADR-%04d", adr.Number)
+	}
+	return "ADR"
+}
+
+func adrHeading(adr importers.ADR) string {
+	if adr.Number > 0 {
+		return fmt.Sprintf("%s: %s", adrLabel(adr), adr.Title)
+	}
+	return adr.Title
+}
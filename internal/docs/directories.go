@@ -0,0 +1,483 @@
+package docs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ziadkadry99/auto-doc/internal/diagrams"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+// DirRollup is the overview page of one directory: what its files do
+// together, the API it exposes, and how its files depend on each other.
+type DirRollup struct {
+	Path      string
+	Summary   string
+	Languages []string
+	Files     []indexer.FileAnalysis // files directly in the directory
+	Subdirs   []SubdirEntry
+	API       []APIEntry
+	Diagram   string // diagram JSON of the dependencies within the directory
+}
+
+// SubdirEntry is a directory nested directly under a rollup's directory.
+type SubdirEntry struct {
+	Path    string
+	Name    string
+	Files   int // files anywhere below it
+	Summary string
+}
+
+// APIEntry is one exported function or type.
+type APIEntry struct {
+	Name      string
+	Kind      string // function or type
+	File      string
+	Signature string
+	Summary   string
+}
+
+// rollupCacheFile keeps LLM-written directory summaries between runs, keyed
+// by a fingerprint of the files below each directory.
+const rollupCacheFile = "rollups.json"
+
+type rollupCacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Summary     string `json:"summary"`
+}
+
+// GenerateDirectoryDocs writes an index.md overview page for every
+// directory holding documented files, at {OutputDir}/docs/{dir}/index.md.
+// With a provider, each directory gets an LLM-written summary, reused until
+// a file below the directory changes; without one, or when the call fails,
+// the summary is assembled from the file summaries.
+func (g *DocGenerator) GenerateDirectoryDocs(ctx context.Context, analyses []indexer.FileAnalysis, provider llm.Provider, model string) error {
+	rollups := BuildDirRollups(analyses)
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	cachePath := filepath.Join(g.OutputDir, rollupCacheFile)
+	cache := make(map[string]rollupCacheEntry)
+	if data, err := os.ReadFile(cachePath); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	if provider != nil {
+		g.summarizeDirs(ctx, rollups, analyses, cache, provider, model)
+	}
+
+	// Parents list their subdirectories with the subdirectory's summary.
+	byPath := make(map[string]*DirRollup, len(rollups))
+	for i := range rollups {
+		byPath[rollups[i].Path] = &rollups[i]
+	}
+	for i := range rollups {
+		for j := range rollups[i].Subdirs {
+			if sub := byPath[rollups[i].Subdirs[j].Path]; sub != nil {
+				rollups[i].Subdirs[j].Summary = subdirBlurb(*sub)
+			}
+		}
+	}
+
+	tmpl, err := template.New("dirdoc").Funcs(templateFuncs).Parse(dirDocTemplate)
+	if err != nil {
+		return err
+	}
+	docsDir := filepath.Join(g.OutputDir, "docs")
+	for _, r := range rollups {
+		outPath := filepath.Join(docsDir, filepath.FromSlash(r.Path), "index.md")
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return err
+		}
+		f, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		err = tmpl.Execute(f, r)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if provider != nil {
+		data, err := json.MarshalIndent(cache, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(cachePath, data, 0o644)
+	}
+	return nil
+}
+
+// BuildDirRollups groups analyses by directory, with a summary assembled
+// from the file summaries. Directories are returned in path order; files at
+// the repository root get no rollup, since index.md already covers them.
+func BuildDirRollups(analyses []indexer.FileAnalysis) []DirRollup {
+	direct := make(map[string][]indexer.FileAnalysis)
+	below := make(map[string]int)
+	children := make(map[string]map[string]bool)
+	for _, a := range analyses {
+		if a.Skip {
+			continue
+		}
+		dir := path.Dir(filepath.ToSlash(a.FilePath))
+		if dir == "." {
+			continue
+		}
+		direct[dir] = append(direct[dir], a)
+		for d := dir; d != "."; d = path.Dir(d) {
+			below[d]++
+			if parent := path.Dir(d); parent != "." {
+				if children[parent] == nil {
+					children[parent] = make(map[string]bool)
+				}
+				children[parent][d] = true
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(below))
+	for d := range below {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	rollups := make([]DirRollup, 0, len(dirs))
+	for _, d := range dirs {
+		files := direct[d]
+		sort.Slice(files, func(i, j int) bool { return files[i].FilePath < files[j].FilePath })
+		r := DirRollup{Path: d, Files: files}
+
+		subdirs := make([]string, 0, len(children[d]))
+		for sub := range children[d] {
+			subdirs = append(subdirs, sub)
+		}
+		sort.Strings(subdirs)
+		for _, sub := range subdirs {
+			r.Subdirs = append(r.Subdirs, SubdirEntry{Path: sub, Name: path.Base(sub), Files: below[sub]})
+		}
+
+		langs := make(map[string]bool)
+		for _, f := range files {
+			if f.Language != "" && f.Language != "unknown" {
+				langs[f.Language] = true
+			}
+			r.API = append(r.API, exportedAPI(f)...)
+		}
+		for l := range langs {
+			r.Languages = append(r.Languages, l)
+		}
+		sort.Strings(r.Languages)
+
+		r.Diagram = dirDependencyDiagram(files, subdirs)
+		r.Summary = fallbackDirSummary(r)
+		rollups = append(rollups, r)
+	}
+	return rollups
+}
+
+// summarizeDirs asks the LLM for a summary of each directory whose files
+// changed since its cached summary was written.
+func (g *DocGenerator) summarizeDirs(ctx context.Context, rollups []DirRollup, analyses []indexer.FileAnalysis, cache map[string]rollupCacheEntry, provider llm.Provider, model string) {
+	const maxConcurrency = 4
+	sem := make(chan struct{}, maxConcurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	live := make(map[string]bool, len(rollups))
+	fingerprints := make(map[string]string)
+	var stale []*DirRollup
+	for i := range rollups {
+		r := &rollups[i]
+		live[r.Path] = true
+		fp := dirFingerprint(r.Path, analyses)
+		if c, ok := cache[r.Path]; ok && c.Fingerprint == fp && c.Summary != "" {
+			r.Summary = c.Summary
+			continue
+		}
+		fingerprints[r.Path] = fp
+		stale = append(stale, r)
+	}
+
+	for _, r := range stale {
+		wg.Add(1)
+		go func(r *DirRollup, fp string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp, err := provider.Complete(ctx, llm.CompletionRequest{
+				Model: model,
+				Messages: []llm.Message{
+					{Role: llm.RoleSystem, Content: "You are a technical writer producing detailed documentation for a software project. Be specific, reference actual code, and write clearly."},
+					{Role: llm.RoleUser, Content: dirSummaryPrompt(r, analyses)},
+				},
+				MaxTokens:   512,
+				Temperature: 0.3,
+			})
+			if err != nil {
+				return // keep the assembled summary
+			}
+			summary := strings.TrimSpace(resp.Content)
+			if summary == "" {
+				return
+			}
+			mu.Lock()
+			r.Summary = summary
+			cache[r.Path] = rollupCacheEntry{Fingerprint: fp, Summary: summary}
+			mu.Unlock()
+		}(r, fingerprints[r.Path])
+	}
+	wg.Wait()
+
+	for dir := range cache {
+		if !live[dir] {
+			delete(cache, dir)
+		}
+	}
+}
+
+// dirSummaryPrompt describes a directory's files, and those of its
+// subdirectories more briefly, for the LLM.
+func dirSummaryPrompt(r *DirRollup, analyses []indexer.FileAnalysis) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summarize the `%s` directory of this project in one paragraph (2-4 sentences): what it is responsible for and how its parts fit together. Reference file and type names in backticks. Do not use headers or bullet lists.\n\n", r.Path)
+	if len(r.Files) > 0 {
+		b.WriteString("Files in the directory:\n")
+		for _, f := range r.Files {
+			fmt.Fprintf(&b, "- `%s`: %s", path.Base(f.FilePath), f.Summary)
+			if f.Purpose != "" {
+				fmt.Fprintf(&b, " Purpose: %s", f.Purpose)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(r.Subdirs) > 0 {
+		b.WriteString("\nFiles in subdirectories:\n")
+		n := 0
+		for _, a := range analyses {
+			if a.Skip || path.Dir(a.FilePath) == r.Path || !strings.HasPrefix(a.FilePath, r.Path+"/") {
+				continue
+			}
+			if n++; n > 40 {
+				b.WriteString("- ...\n")
+				break
+			}
+			fmt.Fprintf(&b, "- `%s`: %s\n", strings.TrimPrefix(a.FilePath, r.Path+"/"), firstSentence(a.Summary))
+		}
+	}
+	return b.String()
+}
+
+// dirFingerprint hashes the paths and content hashes of every file below
+// dir, so a cached summary is only reused while they are unchanged.
+func dirFingerprint(dir string, analyses []indexer.FileAnalysis) string {
+	var entries []string
+	for _, a := range analyses {
+		if !a.Skip && strings.HasPrefix(a.FilePath, dir+"/") {
+			entries = append(entries, a.FilePath+"\x00"+a.ContentHash+"\x00"+a.Summary)
+		}
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// fallbackDirSummary describes a directory from its contents alone.
+func fallbackDirSummary(r DirRollup) string {
+	s := dirContents(r)
+	var purposes []string
+	for _, f := range r.Files {
+		if sentence := firstSentence(f.Summary); sentence != "" {
+			purposes = append(purposes, sentence)
+		}
+		if len(purposes) == 3 {
+			break
+		}
+	}
+	if len(purposes) > 0 {
+		s += " " + strings.Join(purposes, " ")
+	}
+	return s
+}
+
+// subdirBlurb is the one-sentence summary a parent lists for r. An assembled
+// summary opens by counting files, which says little in a table that already
+// has a Files column, so the sentence after the count is used instead.
+func subdirBlurb(r DirRollup) string {
+	s := r.Summary
+	if rest, ok := strings.CutPrefix(s, dirContents(r)); ok && strings.TrimSpace(rest) != "" {
+		s = rest
+	}
+	return firstSentence(s)
+}
+
+// dirContents counts the files and subdirectories of r in a sentence.
+func dirContents(r DirRollup) string {
+	var parts []string
+	if n := len(r.Files); n > 0 {
+		lang := ""
+		if len(r.Languages) == 1 {
+			lang = r.Languages[0] + " "
+		}
+		parts = append(parts, plural(n, lang+"file"))
+	}
+	if n := len(r.Subdirs); n > 0 {
+		parts = append(parts, plural(n, "subdirectory"))
+	}
+	return fmt.Sprintf("`%s` holds %s.", r.Path, strings.Join(parts, " and "))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// firstSentence returns s up to and including its first full stop.
+func firstSentence(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\n", " "))
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// exportedAPI lists the functions and types of a file that other packages
+// can use.
+func exportedAPI(a indexer.FileAnalysis) []APIEntry {
+	var api []APIEntry
+	file := path.Base(a.FilePath)
+	for _, c := range a.Classes {
+		if isExported(a.Language, c.Name, "") {
+			api = append(api, APIEntry{Name: c.Name, Kind: "type", File: file, Summary: firstSentence(c.Summary)})
+		}
+	}
+	for _, fn := range a.Functions {
+		if isExported(a.Language, fn.Name, fn.Signature) {
+			api = append(api, APIEntry{Name: fn.Name, Kind: "function", File: file, Signature: fn.Signature, Summary: firstSentence(fn.Summary)})
+		}
+	}
+	return api
+}
+
+// isExported guesses whether a symbol is part of a file's public API from
+// each language's visibility conventions: capitalized names in Go, no
+// leading underscore in Python and scripts, no private or protected
+// modifier in Java-like languages, and "pub" in Rust.
+func isExported(language, name, signature string) bool {
+	// Methods are listed as Type.Method; the method name decides.
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return false
+	}
+	sig := " " + strings.TrimSpace(signature) + " "
+	switch strings.ToLower(language) {
+	case "go":
+		r, _ := utf8.DecodeRuneInString(name)
+		return unicode.IsUpper(r)
+	case "rust":
+		return signature == "" || strings.HasPrefix(strings.TrimSpace(signature), "pub")
+	case "java", "kotlin", "c#", "scala", "swift", "php":
+		return !strings.Contains(sig, " private ") && !strings.Contains(sig, " protected ") && !strings.Contains(sig, " fileprivate ")
+	case "typescript":
+		return !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") && !strings.Contains(sig, " private ")
+	}
+	return !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#")
+}
+
+// dirDependencyDiagram draws the imports between the files of a directory
+// and into its subdirectories. It returns "" when there are none.
+func dirDependencyDiagram(files []indexer.FileAnalysis, subdirs []string) string {
+	stems := make(map[string]string) // module name -> file name
+	for _, f := range files {
+		base := path.Base(f.FilePath)
+		stems[strings.TrimSuffix(base, path.Ext(base))] = base
+	}
+
+	deps := make(map[string][]string)
+	for _, f := range files {
+		from := path.Base(f.FilePath)
+		seen := make(map[string]bool)
+		for _, d := range f.Dependencies {
+			if d.Type != "" && d.Type != "import" {
+				continue
+			}
+			to := ""
+			// Files of a Go package share one namespace and never import
+			// each other, so only subdirectories can be targets.
+			if target, ok := stems[importModule(d.Name)]; ok && target != from && !strings.EqualFold(f.Language, "go") {
+				to = target
+			} else {
+				for _, sub := range subdirs {
+					if depMatchesSubdir(d.Name, sub) {
+						to = path.Base(sub) + "/"
+						break
+					}
+				}
+			}
+			if to != "" && !seen[to] {
+				seen[to] = true
+				deps[from] = append(deps[from], to)
+			}
+		}
+	}
+	if len(deps) == 0 {
+		return ""
+	}
+	return diagrams.DependencyDiagram(deps)
+}
+
+// sourceExts are stripped from import paths that name a file, like
+// "./models.js".
+var sourceExts = map[string]bool{
+	".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true, ".py": true,
+}
+
+// importModule reduces an import to the module name it refers to:
+// "./models.js", "app.services.models", and ".models" all become "models".
+func importModule(dep string) string {
+	dep = strings.Trim(strings.TrimSpace(dep), `"'`)
+	if i := strings.LastIndex(dep, "/"); i >= 0 {
+		dep = dep[i+1:]
+	}
+	if ext := path.Ext(dep); sourceExts[ext] {
+		dep = strings.TrimSuffix(dep, ext)
+	}
+	if i := strings.LastIndex(dep, "."); i >= 0 {
+		dep = dep[i+1:]
+	}
+	return dep
+}
+
+// depMatchesSubdir reports whether an import path points into sub, such as
+// "github.com/acme/app/internal/config" or "./config" for "internal/config".
+func depMatchesSubdir(dep, sub string) bool {
+	dep = strings.Trim(strings.TrimSpace(dep), `"'`)
+	if rel, ok := strings.CutPrefix(dep, "./"); ok {
+		base := path.Base(sub)
+		return rel == base || strings.HasPrefix(rel, base+"/")
+	}
+	dep = strings.ReplaceAll(strings.TrimLeft(dep, "."), ".", "/") // Python dotted imports
+	return dep == sub || strings.HasSuffix(dep, "/"+sub) || strings.HasPrefix(dep, sub+"/") || strings.Contains(dep, "/"+sub+"/")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -50,6 +51,8 @@ type EnhancedIndex struct {
 	ArchDiagram     string
 	DepDiagram      string
 	Analyses        []indexer.FileAnalysis
+	Coverage        string // label of the link to the coverage page, if any
+	C4Components    bool   // whether c4-components.md was written
 }
 
 // GenerateEnhancedIndex creates an enhanced index.md with project overview,
@@ -152,6 +155,7 @@ Output ONLY the raw JSON on a single line, no markdown formatting, no code fence
 	data := parseEnhancedIndexResponse(resp.Content)
 	data.ProjectName = projectNameFromWd(g.OutputDir)
 	data.Analyses = analyses
+	data.Coverage = g.coverageLabel()
 
 	// Use LLM-generated architecture diagram; fall back to a simple one if not provided.
 	if data.ArchDiagram == "" {
@@ -180,7 +184,13 @@ Output ONLY the raw JSON on a single line, no markdown formatting, no code fence
 
 	// Generate interactive component map.
 	if err := g.GenerateInteractiveMap(analyses, data.Features); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: interactive map generation failed: %v\n", err)
+		slog.Warn("interactive map generation failed", "phase", "docs", "err", err)
+	}
+	// And the C4 Component view of the same feature groups.
+	if err := g.GenerateC4Components(analyses, data.Features); err != nil {
+		slog.Warn("C4 component view generation failed", "phase", "docs", "err", err)
+	} else {
+		data.C4Components = true
 	}
 
 	// Write enhanced index.md.
@@ -2,12 +2,14 @@ package docs
 
 import (
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
 
 	bizctx "github.com/ziadkadry99/auto-doc/internal/context"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
 )
 
 // DocGenerator renders analysis results into markdown documentation files.
@@ -17,6 +19,11 @@ type DocGenerator struct {
 	// ArchDiagram is set by GenerateEnhancedIndex so GenerateArchitecture
 	// can reuse the same diagram instead of generating a separate one.
 	ArchDiagram string
+	// Coverage, if set, is linked from the index page with its percentage
+	// and written out by GenerateCoverage.
+	Coverage *Coverage
+	// Views are the component map's saved views from the site config.
+	Views []mapviews.View
 }
 
 // NewDocGenerator creates a DocGenerator that writes to the given output directory.
@@ -89,6 +96,9 @@ func (g *DocGenerator) GenerateIndex(analyses []indexer.FileAnalysis) error {
 			{Label: "Architecture", Href: "architecture.md"},
 		},
 	}
+	if label := g.coverageLabel(); label != "" {
+		data.QuickLinks = append(data.QuickLinks, quickLink{Label: label, Href: "coverage.md"})
+	}
 
 	return tmpl.Execute(f, data)
 }
@@ -102,6 +112,8 @@ var templateFuncs = template.FuncMap{
 		}
 		return "`" + s + "`"
 	},
+	"base": path.Base,
+	"join": strings.Join,
 	"mdlink": func(filePath string) string {
 		return filePath + ".md"
 	},
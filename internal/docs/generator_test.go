@@ -1,12 +1,20 @@
 package docs
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/ziadkadry99/auto-doc/internal/diagrams"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/walker"
 )
 
 func sampleAnalyses() []indexer.FileAnalysis {
@@ -205,3 +213,314 @@ func TestAnchorize(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateDecisions(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewDocGenerator(tmpDir)
+
+	adrs := []importers.ADR{
+		{
+			Number:          1,
+			Title:           "Use PostgreSQL",
+			Status:          "accepted",
+			Decision:        "Use PostgreSQL for order data.",
+			FilePath:        "docs/adr/0001-use-postgresql.md",
+			RelatedServices: []string{"order-service"},
+		},
+		{
+			Number:   2,
+			Title:    "Retry | dead-letter failed payments",
+			Status:   "proposed",
+			FilePath: "docs/adr/0002-retry.md",
+		},
+	}
+	if err := gen.GenerateDecisions(adrs); err != nil {
+		t.Fatalf("GenerateDecisions failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "docs", "decisions.md"))
+	if err != nil {
+		t.Fatalf("decisions.md not created: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# Decisions", "## ADR-0001: Use PostgreSQL", "(#adr-0001-use-postgresql)", "**Related services:** order-service",
+		`| ADR-0002 | [Retry \| dead-letter failed payments](#adr-0002-retry--dead-letter-failed-payments) | proposed |`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("decisions.md missing %q", want)
+		}
+	}
+
+	if err := NewDocGenerator(t.TempDir()).GenerateDecisions(nil); err != nil {
+		t.Errorf("GenerateDecisions(nil) should be a no-op, got %v", err)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	files := []walker.FileInfo{
+		{RelPath: "cmd/main.go"},
+		{RelPath: "internal/config/config.go"},
+		{RelPath: "internal/big.go"},
+		{RelPath: "internal/broken.go"},
+		{RelPath: "internal/later.go"},
+		{RelPath: "LICENSE"},
+		{RelPath: "internal/new.go"},
+	}
+	stored := map[string]indexer.FileAnalysis{"LICENSE": {FilePath: "LICENSE", Skip: true}}
+	failed := map[string]error{"internal/broken.go": errors.New("rate limited\nretry later")}
+
+	var c Coverage
+	c.Skip("debug.log", string(walker.SkipIgnored), "")
+	c.Skip("logo.png", string(walker.SkipBinary), "")
+	c.Account(files, sampleAnalyses(), stored, failed, []string{"internal/later.go"})
+
+	if c.Documented != 2 || c.Considered() != 8 || c.Percent() != 25 {
+		t.Errorf("coverage = %d of %d (%d%%), want 2 of 8 (25%%)", c.Documented, c.Considered(), c.Percent())
+	}
+
+	tmpDir := t.TempDir()
+	gen := NewDocGenerator(tmpDir)
+	gen.Coverage = &c
+	if err := gen.GenerateCoverage(); err != nil {
+		t.Fatalf("GenerateCoverage failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "docs", "coverage.md"))
+	if err != nil {
+		t.Fatalf("coverage.md not created: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"**25%** of files are documented: 2 of 8. Files left out by an ignore pattern or language toggle (1) don't count.",
+		"| [Analysis failed](#analysis-failed) | 1 |",
+		"- `internal/broken.go`: rate limited retry later",
+		"## Deferred by budget\n\n- `internal/later.go`",
+		"## Not relevant\n\n- `LICENSE`",
+		"## Not analyzed\n\n- `internal/big.go`\n- `internal/new.go`",
+		"## Binary\n\n- `logo.png`",
+		"## Ignored pattern\n\n- `debug.log`",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("coverage.md missing %q", want)
+		}
+	}
+	if strings.Index(content, "## Analysis failed") > strings.Index(content, "## Ignored pattern") {
+		t.Error("fixable reasons should come before ignored files")
+	}
+
+	if err := gen.GenerateIndex(sampleAnalyses()); err != nil {
+		t.Fatalf("GenerateIndex failed: %v", err)
+	}
+	index, _ := os.ReadFile(filepath.Join(tmpDir, "docs", "index.md"))
+	if !strings.Contains(string(index), "[Coverage: 25% of files documented](coverage.md)") {
+		t.Errorf("index.md does not link the coverage report:\n%s", index)
+	}
+}
+
+func TestBuildDirRollups(t *testing.T) {
+	analyses := append(sampleAnalyses(),
+		indexer.FileAnalysis{
+			FilePath:     "cmd/root.go",
+			Language:     "go",
+			Summary:      "Defines the root command. Wires subcommands.",
+			Functions:    []indexer.FunctionDoc{{Name: "Execute", Signature: "func Execute() error"}, {Name: "initConfig"}},
+			Dependencies: []indexer.Dependency{{Name: "github.com/acme/app/internal/config", Type: "import"}},
+		},
+		indexer.FileAnalysis{
+			FilePath:     "app/services/orders.py",
+			Language:     "python",
+			Summary:      "Order service.",
+			Functions:    []indexer.FunctionDoc{{Name: "create_order"}, {Name: "_validate"}},
+			Dependencies: []indexer.Dependency{{Name: "app.services.models", Type: "import"}},
+		},
+		indexer.FileAnalysis{FilePath: "app/services/models.py", Language: "python", Summary: "Order models."},
+		indexer.FileAnalysis{FilePath: "README.md", Language: "markdown", Summary: "Root files get no rollup."},
+	)
+
+	rollups := BuildDirRollups(analyses)
+	byPath := make(map[string]DirRollup)
+	for _, r := range rollups {
+		byPath[r.Path] = r
+	}
+	for _, want := range []string{"app", "app/services", "cmd", "internal", "internal/config"} {
+		if _, ok := byPath[want]; !ok {
+			t.Errorf("missing rollup for %s", want)
+		}
+	}
+	if len(rollups) != 5 {
+		t.Errorf("rollups = %d, want 5", len(rollups))
+	}
+
+	internal := byPath["internal"]
+	if len(internal.Subdirs) != 1 || internal.Subdirs[0].Name != "config" || internal.Subdirs[0].Files != 1 {
+		t.Errorf("internal subdirs = %+v", internal.Subdirs)
+	}
+
+	var api []string
+	for _, e := range byPath["cmd"].API {
+		api = append(api, e.Name)
+	}
+	if strings.Join(api, ",") != "App,Execute" {
+		t.Errorf("cmd API = %v, want [App Execute]", api)
+	}
+	if d := byPath["cmd"].Diagram; d != "" {
+		t.Errorf("cmd has no subdirectories, but got diagram %s", d)
+	}
+
+	services := byPath["app/services"]
+	if len(services.API) != 1 || services.API[0].Name != "create_order" {
+		t.Errorf("services API = %+v", services.API)
+	}
+	if !strings.Contains(services.Diagram, "orders.py") || !strings.Contains(services.Diagram, "models.py") {
+		t.Errorf("services diagram missing sibling import: %s", services.Diagram)
+	}
+	if !strings.Contains(services.Summary, "2 python files") {
+		t.Errorf("services summary = %q", services.Summary)
+	}
+}
+
+func TestDepMatchesSubdir(t *testing.T) {
+	cases := []struct {
+		dep, sub string
+		want     bool
+	}{
+		{"github.com/acme/app/internal/config", "internal/config", true},
+		{"./config", "internal/config", true},
+		{"./config/loader", "internal/config", true},
+		{"app.services.models", "app/services", true},
+		{"github.com/acme/app/internal/configx", "internal/config", false},
+		{"fmt", "internal/config", false},
+	}
+	for _, c := range cases {
+		if got := depMatchesSubdir(c.dep, c.sub); got != c.want {
+			t.Errorf("depMatchesSubdir(%q, %q) = %v, want %v", c.dep, c.sub, got, c.want)
+		}
+	}
+}
+
+// countingProvider counts the completions it serves.
+type countingProvider struct {
+	llm.MockProvider
+	calls atomic.Int32
+}
+
+func (p *countingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.calls.Add(1)
+	return p.MockProvider.Complete(ctx, req)
+}
+
+func TestGenerateDirectoryDocs(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewDocGenerator(tmpDir)
+	analyses := sampleAnalyses()
+
+	if err := gen.GenerateDirectoryDocs(context.Background(), analyses, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "docs", "internal", "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# internal/", "[config/](config/index.md)", "Configuration loading and validation."} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("internal/index.md missing %q:\n%s", want, data)
+		}
+	}
+	data, _ = os.ReadFile(filepath.Join(tmpDir, "docs", "cmd", "index.md"))
+	if !strings.Contains(string(data), "[main.go](main.go.md)") || !strings.Contains(string(data), "App") {
+		t.Errorf("cmd/index.md missing file link or API:\n%s", data)
+	}
+
+	// Summaries come from the provider once and are cached until a file
+	// below the directory changes.
+	provider := &countingProvider{}
+	if err := gen.GenerateDirectoryDocs(context.Background(), analyses, provider, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := provider.calls.Load(); n != 3 {
+		t.Errorf("first run made %d calls, want 3", n)
+	}
+	analyses[1].Summary = "Configuration loading, validation, and defaults."
+	if err := gen.GenerateDirectoryDocs(context.Background(), analyses, provider, ""); err != nil {
+		t.Fatal(err)
+	}
+	// internal/config changed, and so did internal, which holds it.
+	if n := provider.calls.Load(); n != 5 {
+		t.Errorf("after one change, %d calls in total, want 5", n)
+	}
+}
+
+func TestRenderAPIReference(t *testing.T) {
+	analyses := append(sampleAnalyses(),
+		indexer.FileAnalysis{
+			FilePath:  "cmd/main_test.go",
+			Language:  "go",
+			Functions: []indexer.FunctionDoc{{Name: "TestRun", Signature: "func TestRun(t *testing.T)"}},
+		},
+		indexer.FileAnalysis{
+			FilePath: "web/api.ts",
+			Language: "typescript",
+			Functions: []indexer.FunctionDoc{{
+				Name:       "fetchOrder",
+				Signature:  "function fetchOrder(id: string): Promise<Order | null>",
+				Parameters: []indexer.ParamDoc{{Name: "id", Type: "string | number", Description: "Order ID"}},
+			}},
+		},
+	)
+	md := RenderAPIReference(analyses)
+
+	for _, want := range []string{
+		"# API Reference",
+		"| [cmd](#cmd) | go | 1 | 0 |",
+		"### cmd.App",
+		"#### cmd.App.Start",
+		"func (a *App) Start() error",
+		"| Name | `string` | Application name |",
+		"## web",
+		"### web.fetchOrder",
+		"| id | `string \\| number` | Order ID |",
+		"Source: [api.ts](web/api.ts.md#fetchorder)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("API reference missing %q:\n%s", want, md)
+		}
+	}
+	// Unexported functions, test files, and packages without exports are left out.
+	for _, unwanted := range []string{"cmd.main", "cmd.run", "TestRun", "internal/config"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("API reference should not contain %q", unwanted)
+		}
+	}
+
+	if RenderAPIReference(sampleAnalyses()[1:]) != "" {
+		t.Error("expected no page when nothing is exported")
+	}
+}
+
+func TestBuildC4Container(t *testing.T) {
+	analyses := []indexer.FileAnalysis{
+		{FilePath: "cmd/root.go", Language: "go", Dependencies: []indexer.Dependency{{Name: "github.com/acme/shop/internal/store", Type: "import"}}},
+		{FilePath: "internal/store/store.go", Language: "go"},
+		{FilePath: "internal/store/schema.sql", Language: "sql"},
+		{FilePath: "scripts/seed.py", Language: "python"},
+	}
+	features := []Feature{
+		{Name: "CLI", Description: "Runs the shop. Has flags.", Files: []string{"cmd/root.go"}},
+		{Name: "Storage", Description: "Keeps orders.", Files: []string{"internal/store/store.go", "internal/store/schema.sql"}},
+		{Name: "Unused", Files: []string{"gone.go"}},
+	}
+	c := buildC4Container(buildMapData(analyses, features, "shop"), features)
+
+	if c.ID != "shop" || c.Technology != "go" {
+		t.Errorf("container = %+v", c.C4Element)
+	}
+	want := []diagrams.C4Element{
+		{ID: "CLI", Name: "CLI", Description: "Runs the shop.", Technology: "go"},
+		{ID: "Storage", Name: "Storage", Description: "Keeps orders.", Technology: "go"},
+		{ID: "Other", Name: "Other", Description: "Files in no feature", Technology: "python"},
+	}
+	if !reflect.DeepEqual(c.Components, want) {
+		t.Errorf("components = %+v, want %+v", c.Components, want)
+	}
+	if !reflect.DeepEqual(c.Relationships, []diagrams.C4Relationship{{From: "CLI", To: "Storage", Label: "uses"}}) {
+		t.Errorf("relationships = %+v", c.Relationships)
+	}
+}
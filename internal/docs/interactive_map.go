@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
 )
 
 type mapNode struct {
@@ -37,10 +38,11 @@ type mapFeature struct {
 }
 
 type mapData struct {
-	ProjectName string       `json:"projectName"`
-	Nodes       []mapNode    `json:"nodes"`
-	Edges       []mapEdge    `json:"edges"`
-	Features    []mapFeature `json:"features"`
+	ProjectName string          `json:"projectName"`
+	Nodes       []mapNode       `json:"nodes"`
+	Edges       []mapEdge       `json:"edges"`
+	Features    []mapFeature    `json:"features"`
+	Views       []mapviews.View `json:"views,omitempty"`
 }
 
 var featureColors = []string{
@@ -53,6 +55,7 @@ var featureColors = []string{
 // force-directed graph showing files, their feature groups, and dependencies.
 func (g *DocGenerator) GenerateInteractiveMap(analyses []indexer.FileAnalysis, features []Feature) error {
 	data := buildMapData(analyses, features, projectNameFromWd(g.OutputDir))
+	data.Views = g.Views
 
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
@@ -105,14 +108,10 @@ func buildMapData(analyses []indexer.FileAnalysis, features []Feature, projectNa
 			typeNames = append(typeNames, c.Name)
 		}
 
-		// Get deps from structured field, or fall back to parsing from summary.
-		depNames := make([]string, 0)
+		depNames := make([]string, 0, len(a.Dependencies))
 		for _, d := range a.Dependencies {
 			depNames = append(depNames, d.Name)
 		}
-		if len(depNames) == 0 {
-			depNames = parseSummaryDeps(a.Summary)
-		}
 
 		group := fileFeature[a.FilePath]
 		if group == "" {
@@ -146,14 +145,10 @@ func buildMapData(analyses []indexer.FileAnalysis, features []Feature, projectNa
 	seen := make(map[string]bool)
 	edges := make([]mapEdge, 0)
 	for _, a := range analyses {
-		// Get deps from structured field, or parse from summary.
 		var depNames []string
 		for _, d := range a.Dependencies {
 			depNames = append(depNames, d.Name)
 		}
-		if len(depNames) == 0 {
-			depNames = parseSummaryDeps(a.Summary)
-		}
 
 		matched := make(map[string]bool) // one edge per target package
 		for _, depName := range depNames {
@@ -204,36 +199,6 @@ func buildMapData(analyses []indexer.FileAnalysis, features []Feature, projectNa
 	return mapData{ProjectName: projectName, Nodes: nodes, Edges: edges, Features: feats}
 }
 
-// parseSummaryDeps extracts dependency names from the summary text.
-// Handles the lite tier format where deps appear as:
-// "Dependencies: pkg1 (import), pkg2 (api_call), ..."
-func parseSummaryDeps(summary string) []string {
-	idx := strings.Index(summary, "Dependencies:")
-	if idx < 0 {
-		return nil
-	}
-	after := summary[idx+len("Dependencies:"):]
-	// Trim at newline or next field marker.
-	for _, marker := range []string{"\n", "Functions:", "Classes:", "Key Logic:"} {
-		if i := strings.Index(after, marker); i >= 0 {
-			after = after[:i]
-		}
-	}
-	var deps []string
-	for _, part := range strings.Split(after, ",") {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-		// Remove type suffix like " (import)"
-		if i := strings.Index(part, " ("); i > 0 {
-			part = part[:i]
-		}
-		deps = append(deps, strings.TrimSpace(part))
-	}
-	return deps
-}
-
 // depMatchesPkg returns true if the dependency name plausibly refers to pkgPath.
 func depMatchesPkg(depName, pkgPath string) bool {
 	if depName == pkgPath {
@@ -344,7 +309,7 @@ body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Helvetica,Arial,san
 <div id="toolbar">
  <div class="toolbar-section"><a href="index.html" class="back-link">&#8592; Back to Docs</a><span class="title">Component Map</span></div>
  <div class="toolbar-section"><input type="text" id="search" placeholder="Search files, types..."></div>
- <div class="toolbar-section"><span id="stats"></span><button class="btn" id="btn-fit">Fit</button><button class="btn" id="btn-labels">Labels</button><button class="btn" id="btn-theme">&#9788;</button></div>
+ <div class="toolbar-section"><span id="stats"></span>` + mapviews.Menu + `<button class="btn" id="btn-fit">Fit</button><button class="btn" id="btn-labels">Labels</button><button class="btn" id="btn-theme">&#9788;</button></div>
 </div>
 <div id="main">
  <div id="sidebar"><div class="sidebar-hdr">Features</div><div id="feature-list"></div><div class="sidebar-stats" id="sidebar-stats"></div></div>
@@ -369,7 +334,7 @@ var width=svgEl.clientWidth, height=svgEl.clientHeight;
 var svg=d3.select(svgEl);
 var container=svg.append('g');
 
-var zoom=d3.zoom().scaleExtent([0.05,10]).on('zoom',function(e){container.attr('transform',e.transform);});
+var zoom=d3.zoom().scaleExtent([0.05,10]).on('zoom',function(e){container.attr('transform',e.transform);}).on('end',function(){viewChanged();});
 svg.call(zoom);
 
 // Arrow markers
@@ -487,8 +452,9 @@ function onClick(event,d){
  highlightConnected(d);
  tip.classList.add('hidden');
  showInfo(d);
+ viewChanged();
 }
-svg.on('click',function(){selectedId=null;resetHighlight();document.getElementById('info-panel').classList.add('hidden');});
+svg.on('click',function(){selectedId=null;resetHighlight();document.getElementById('info-panel').classList.add('hidden');viewChanged();});
 
 function showInfo(d){
  var h='';
@@ -522,11 +488,11 @@ function showInfo(d){
   el.addEventListener('click',function(){
    var id=el.getAttribute('data-id');
    var n=data.nodes.find(function(nd){return nd.id===id;});
-   if(n){selectedId=n.id;highlightConnected(n);showInfo(n);}
+   if(n){selectedId=n.id;highlightConnected(n);showInfo(n);viewChanged();}
   });
  });
 }
-document.getElementById('info-close').addEventListener('click',function(){selectedId=null;resetHighlight();document.getElementById('info-panel').classList.add('hidden');});
+document.getElementById('info-close').addEventListener('click',function(){selectedId=null;resetHighlight();document.getElementById('info-panel').classList.add('hidden');viewChanged();});
 
 // Feature sidebar
 function buildFeatureList(){
@@ -556,11 +522,13 @@ function applyVisibility(){
   var t=typeof e.target==='object'?e.target:nodeMap[e.target];
   return (s&&hiddenFeats[s.group])||(t&&hiddenFeats[t.group])?'none':null;
  });
+ viewChanged();
 }
 
 // Search
 document.getElementById('search').addEventListener('input',function(){
  var q=this.value.toLowerCase().trim();
+ viewChanged();
  if(!q){nodeEls.classed('dimmed',false).attr('r',function(d){return sizeScale(d.size);});labelEls.classed('dimmed',false);edgeEls.classed('dimmed',false);return;}
  var matches={};
  data.nodes.forEach(function(n){
@@ -574,7 +542,7 @@ document.getElementById('search').addEventListener('input',function(){
 
 // Controls
 document.getElementById('btn-fit').addEventListener('click',zoomToFit);
-document.getElementById('btn-labels').addEventListener('click',function(){showLabels=!showLabels;labelEls.style('display',showLabels?null:'none');});
+document.getElementById('btn-labels').addEventListener('click',function(){showLabels=!showLabels;labelEls.style('display',showLabels?null:'none');viewChanged();});
 document.getElementById('btn-theme').addEventListener('click',function(){
  document.body.classList.toggle('light');document.body.classList.toggle('dark');
  this.textContent=document.body.classList.contains('light')?'\u263E':'\u2606';
@@ -603,10 +571,40 @@ window.addEventListener('resize',function(){
  sim.force('center',d3.forceCenter(width/2,height/2));sim.alpha(0.1).restart();
 });
 
+// Saved views: hidden features, the search, the selected file, labels, and zoom.
+function viewState(){
+ var p=new URLSearchParams();
+ Object.keys(hiddenFeats).sort().forEach(function(f){p.append('hide',f);});
+ var q=document.getElementById('search').value.trim();
+ if(q)p.set('q',q);
+ if(selectedId)p.set('node',selectedId);
+ if(!showLabels)p.set('labels','0');
+ var t=d3.zoomTransform(svgEl);
+ if(t.k!==1||t.x||t.y)p.set('zoom',[t.k,t.x,t.y].map(function(v){return +v.toFixed(2);}).join(','));
+ return p;
+}
+function applyViewState(p){
+ hiddenFeats={};
+ p.getAll('hide').forEach(function(f){hiddenFeats[f]=true;});
+ document.querySelectorAll('#feature-list input').forEach(function(el){el.checked=!hiddenFeats[el.getAttribute('data-feat')];});
+ applyVisibility();
+ showLabels=p.get('labels')!=='0';
+ labelEls.style('display',showLabels?null:'none');
+ var search=document.getElementById('search');
+ search.value=p.get('q')||'';
+ search.dispatchEvent(new Event('input'));
+ var n=nodeMap[p.get('node')];
+ if(n){selectedId=n.id;highlightConnected(n);showInfo(n);}
+ else{selectedId=null;document.getElementById('info-panel').classList.add('hidden');if(!search.value)resetHighlight();}
+ var z=(p.get('zoom')||'').split(',').map(Number);
+ if(z.length===3&&z.every(isFinite))svg.call(zoom.transform,d3.zoomIdentity.translate(z[1],z[2]).scale(z[0]));
+}
+
 // Boot
 buildFeatureList();
 updateStats();
-setTimeout(zoomToFit,2000);
+if(!/(^#|&)zoom=/.test(location.hash))setTimeout(zoomToFit,2000);
+` + mapviews.JS + `
 })();
 </script>
 </body>
@@ -2,7 +2,9 @@ package docs
 
 const fileDocTemplate = `# {{ .FilePath }}
 
-{{ if .Summary }}## Summary
+{{ with .Truncation }}> **Note:** {{ .Note }}
+
+{{ end }}{{ if .Summary }}## Summary
 
 {{ .Summary }}
 {{ end }}
@@ -221,7 +223,9 @@ const enhancedIndexTemplate = `# {{ .ProjectName }} — Documentation
 ## Quick Links
 
 - [Architecture](architecture.md)
-`
+{{ if .C4Components }}- [C4 Components](c4-components.md)
+{{ end }}{{ if .Coverage }}- [{{ .Coverage }}](coverage.md)
+{{ end }}`
 
 const featureTemplate = `# {{ .Feature.Name }}
 
@@ -235,3 +239,40 @@ const featureTemplate = `# {{ .Feature.Name }}
 {{ end }}
 [Back to Home](../index.md)
 `
+
+const dirDocTemplate = `# {{ .Path }}/
+
+[Up](../index.md)
+
+## Summary
+
+{{ .Summary }}
+{{ if .Languages }}
+**Languages:** {{ join .Languages ", " }}
+{{ end }}
+{{ if .Subdirs }}## Subdirectories
+
+| Directory | Files | Summary |
+|-----------|-------|---------|
+{{ range .Subdirs }}| [{{ .Name }}/]({{ .Name }}/index.md) | {{ .Files }} | {{ oneline .Summary }} |
+{{ end }}
+{{ end }}
+{{ if .Files }}## Files
+
+| File | Summary |
+|------|---------|
+{{ range .Files }}| [{{ base .FilePath }}]({{ base .FilePath }}.md) | {{ oneline .Summary }} |
+{{ end }}
+{{ end }}
+{{ if .API }}## Exported API
+
+| Name | Kind | File | Summary |
+|------|------|------|---------|
+{{ range .API }}| {{ if .Signature }}{{ code (oneline .Signature) }}{{ else }}{{ code .Name }}{{ end }} | {{ .Kind }} | [{{ .File }}]({{ .File }}.md#{{ anchorize .Name }}) | {{ oneline .Summary }} |
+{{ end }}
+{{ end }}
+{{ if .Diagram }}## Internal Dependencies
+
+<div class="arch-diagram" data-graph='{{ jsonattr .Diagram }}'></div>
+{{ end }}
+`
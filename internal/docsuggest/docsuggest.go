@@ -0,0 +1,531 @@
+// Package docsuggest turns per-function analyses into suggested doc
+// comments — godoc, JSDoc, Javadoc/KDoc, XML docs, rustdoc, and Python
+// docstrings — for exported symbols that have none, bridging the generated
+// docs back into the codebase as a patch.
+package docsuggest
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// Suggestion is a doc comment proposed for an undocumented exported symbol.
+type Suggestion struct {
+	File   string // relative to the repo root, slash-separated
+	Line   int    // the symbol's declaration line, 1-based
+	Symbol string
+	// At is the 0-based line the comment is inserted before: above the
+	// declaration and its annotations, or below the signature for Python
+	// docstrings.
+	At      int
+	Comment []string // the comment's lines, indented
+}
+
+// Languages lists the languages suggestions are made for.
+var Languages = []string{"Go", "Python", "JavaScript", "TypeScript", "Java", "Kotlin", "C#", "Rust"}
+
+// symbol is an analyzed function, method, or type.
+type symbol struct {
+	name    string // as analyzed, e.g. "Store.Get" or "get_user"
+	class   string // the enclosing class of a method
+	isType  bool
+	line    int
+	summary string
+	params  []indexer.ParamDoc
+	returns string
+}
+
+// Suggest returns suggestions for the undocumented exported symbols of
+// every analyzed file under root, by file and position. Symbols the analysis
+// has no summary for are skipped.
+func Suggest(root string, analyses map[string]indexer.FileAnalysis) ([]Suggestion, error) {
+	var out []Suggestion
+	for _, a := range analyses {
+		if a.Skip || !supported(a.Language) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(a.FilePath)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		syms := symbols(a)
+		var found []Suggestion
+		if a.Language == "Go" {
+			found = suggestGo(a.FilePath, content, lines, syms)
+		} else {
+			found = suggestScanned(a.Language, lines, syms)
+		}
+		for i := range found {
+			found[i].File = filepath.ToSlash(a.FilePath)
+		}
+		out = append(out, found...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].At < out[j].At
+	})
+	return out, nil
+}
+
+func supported(language string) bool {
+	for _, l := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// symbols flattens an analysis's functions, types, and methods.
+func symbols(a indexer.FileAnalysis) []symbol {
+	var out []symbol
+	fn := func(f indexer.FunctionDoc, class string) symbol {
+		return symbol{name: f.Name, class: class, line: f.LineStart, summary: f.Summary, params: f.Parameters, returns: f.Returns}
+	}
+	for _, f := range a.Functions {
+		out = append(out, fn(f, ""))
+	}
+	for _, c := range a.Classes {
+		out = append(out, symbol{name: c.Name, isType: true, line: c.LineStart, summary: c.Summary})
+		for _, m := range c.Methods {
+			out = append(out, fn(m, c.Name))
+		}
+	}
+	return out
+}
+
+// baseName strips a symbol's receiver or class, e.g. "Get" for
+// "(s *Store) Get" or "Store.Get".
+func baseName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "(") {
+		if i := strings.Index(name, ")"); i >= 0 {
+			name = name[i+1:]
+		}
+	}
+	name, _, _ = strings.Cut(name, "(")
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexAny(name, ".#: "); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// lookup finds the analyzed symbol for a declaration: by name, preferring
+// one in the declaration's class and near its line.
+func lookup(syms []symbol, name, class string, line int) *symbol {
+	var best *symbol
+	bestScore := -1
+	for i := range syms {
+		s := &syms[i]
+		if baseName(s.name) != name || s.summary == "" {
+			continue
+		}
+		score := 1
+		if class != "" && (s.class == class || strings.Contains(s.name, class+".") || strings.Contains(s.name, class+")")) {
+			score += 2
+		}
+		if s.line > 0 && abs(s.line-line) <= 2 {
+			score += 4
+		}
+		if score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// suggestGo finds undocumented exported declarations with go/ast.
+func suggestGo(path string, content []byte, lines []string, syms []symbol) []Suggestion {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil || (err != nil && len(file.Decls) == 0) || ast.IsGenerated(file) {
+		return nil
+	}
+	var out []Suggestion
+	add := func(name, class string, pos token.Pos, isType bool) {
+		line := fset.Position(pos).Line
+		s := lookup(syms, name, class, line)
+		if s == nil || s.isType != isType {
+			return
+		}
+		indent := indentOf(lines[line-1])
+		out = append(out, Suggestion{Line: line, Symbol: name, At: line - 1, Comment: lineComment(indent, "//", goSentence(name, s.summary))})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil || !d.Name.IsExported() {
+				continue
+			}
+			class := ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				class = recvName(d.Recv.List[0].Type)
+				if !ast.IsExported(class) {
+					continue
+				}
+			}
+			add(d.Name.Name, class, d.Pos(), false)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Doc != nil || (d.Doc != nil && !d.Lparen.IsValid()) || !ts.Name.IsExported() {
+					continue
+				}
+				pos := ts.Pos()
+				if !d.Lparen.IsValid() {
+					pos = d.Pos()
+				}
+				add(ts.Name.Name, "", pos, true)
+			}
+		}
+	}
+	return out
+}
+
+// recvName returns a receiver's type name, e.g. "Store" for "*Store[T]".
+func recvName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(t.X)
+	case *ast.IndexExpr:
+		return recvName(t.X)
+	case *ast.IndexListExpr:
+		return recvName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// goSentence turns a summary into a godoc sentence starting with the
+// symbol's name, e.g. "Charge charges a card." for "Charges a card", or
+// "Order is a placed order." for "A placed order".
+func goSentence(name, summary string) string {
+	summary = sentence(summary)
+	if strings.HasPrefix(summary, name+" ") {
+		return summary
+	}
+	switch first, _, _ := strings.Cut(summary, " "); first {
+	case "A", "An", "The":
+		return name + " is " + lowerFirst(summary)
+	}
+	return name + " " + lowerFirst(summary)
+}
+
+// boilerplateRe matches summary openings that restate what the symbol is.
+var boilerplateRe = regexp.MustCompile(`(?i)^(?:this|the) (?:function|method|class|struct|type|interface|constructor|helper)\s+(?:is used to\s+)?`)
+
+// sentence cleans a summary into one sentence ending in a period.
+func sentence(summary string) string {
+	s := strings.Join(strings.Fields(summary), " ")
+	s = boilerplateRe.ReplaceAllString(s, "")
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	s = string(r)
+	if !strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "!") && !strings.HasSuffix(s, "?") {
+		s += "."
+	}
+	return s
+}
+
+// lowerFirst lowercases a sentence's first letter unless its first word
+// is an acronym or identifier, e.g. "HTTP" or "UserID".
+func lowerFirst(s string) string {
+	word, _, _ := strings.Cut(s, " ")
+	r := []rune(word)
+	if len(r) > 1 && strings.IndexFunc(string(r[1:]), unicode.IsUpper) >= 0 {
+		return s
+	}
+	rs := []rune(s)
+	rs[0] = unicode.ToLower(rs[0])
+	return string(rs)
+}
+
+// declRe matches the declarations of the scanned languages, capturing the
+// declared name.
+var declRe = map[string]*regexp.Regexp{
+	"Python":     regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s+(\w+)`),
+	"JavaScript": regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\*?\s+(\w+)|class\s+(\w+)|(?:const|let|var)\s+(\w+)\s*=|(?:static\s+)?(?:async\s+)?(?:get\s+|set\s+)?(#?\w+)\s*\()`),
+	"Java":       regexp.MustCompile(`\b(?:class|interface|enum|record)\s+(\w+)|(\w+)\s*\(`),
+	"Kotlin":     regexp.MustCompile(`\b(?:class|interface|object)\s+(\w+)|\bfun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(\w+)\s*\(`),
+	"C#":         regexp.MustCompile(`\b(?:class|interface|struct|enum|record)\s+(\w+)|(\w+)\s*(?:<[^>]*>)?\s*\(`),
+	"Rust":       regexp.MustCompile(`\b(?:fn|struct|enum|trait|type)\s+(\w+)`),
+}
+
+func init() {
+	declRe["TypeScript"] = declRe["JavaScript"]
+}
+
+// suggestScanned finds undocumented exported declarations of the other
+// languages from the analysis's line numbers.
+func suggestScanned(language string, lines []string, syms []symbol) []Suggestion {
+	var out []Suggestion
+	exportedClasses := make(map[string]bool)
+	for _, s := range syms {
+		if s.isType {
+			if line := declLine(language, lines, s); line > 0 && exported(language, lines[line-1], baseName(s.name), true, true) {
+				exportedClasses[s.name] = true
+			}
+		}
+	}
+	for _, s := range syms {
+		if s.summary == "" {
+			continue
+		}
+		name := baseName(s.name)
+		line := declLine(language, lines, s)
+		if line == 0 || !exported(language, lines[line-1], name, s.class == "", s.class == "" || exportedClasses[s.class]) {
+			continue
+		}
+		var sug Suggestion
+		var ok bool
+		if language == "Python" {
+			sug, ok = docstring(lines, line, s)
+		} else {
+			sug, ok = commentAbove(language, lines, line, s)
+		}
+		if ok {
+			sug.Line, sug.Symbol = line, name
+			out = append(out, sug)
+		}
+	}
+	return out
+}
+
+// declLine returns the 1-based line declaring a symbol: the analyzed line,
+// or a line near it when the analysis is off by a few, or 0 when none is.
+func declLine(language string, lines []string, s symbol) int {
+	name := baseName(s.name)
+	re := declRe[language]
+	for _, d := range []int{0, 1, -1, 2, -2, 3, -3} {
+		i := s.line + d
+		if s.line <= 0 || i < 1 || i > len(lines) {
+			continue
+		}
+		for _, m := range re.FindAllStringSubmatch(lines[i-1], -1) {
+			for _, g := range m[1:] {
+				if g == name {
+					return i
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// exported reports whether a declaration is part of its module's public
+// API. topLevel is false for methods; classExported is whether a method's
+// class is exported.
+func exported(language, decl, name string, topLevel, classExported bool) bool {
+	trimmed := strings.TrimSpace(decl)
+	switch language {
+	case "Python":
+		return !strings.HasPrefix(name, "_") && classExported
+	case "JavaScript", "TypeScript":
+		if topLevel {
+			return strings.HasPrefix(trimmed, "export ")
+		}
+		return classExported && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") &&
+			!strings.HasPrefix(trimmed, "private ") && !strings.HasPrefix(trimmed, "protected ") && name != "constructor"
+	case "Java", "C#":
+		return classExported && strings.Contains(" "+trimmed, " public ")
+	case "Kotlin":
+		for _, m := range []string{"private ", "internal ", "protected "} {
+			if strings.Contains(" "+trimmed, " "+m) {
+				return false
+			}
+		}
+		return classExported && !strings.HasPrefix(trimmed, "override ")
+	case "Rust":
+		return strings.HasPrefix(trimmed, "pub ")
+	}
+	return false
+}
+
+// commentAbove suggests a comment above a declaration, unless it already
+// has one. Annotations, decorators, and attributes stay next to the
+// declaration.
+func commentAbove(language string, lines []string, line int, s symbol) (Suggestion, bool) {
+	at := line - 1 // 0-based index of the declaration
+	for at > 0 {
+		prev := strings.TrimSpace(lines[at-1])
+		if strings.HasPrefix(prev, "@") || strings.HasPrefix(prev, "#[") || (language == "C#" && strings.HasPrefix(prev, "[")) {
+			at--
+			continue
+		}
+		break
+	}
+	if at > 0 {
+		prev := strings.TrimSpace(lines[at-1])
+		for _, p := range []string{"//", "/*", "*", "#!"} {
+			if strings.HasPrefix(prev, p) {
+				return Suggestion{}, false
+			}
+		}
+	}
+	indent := indentOf(lines[line-1])
+	text := sentence(s.summary)
+	var comment []string
+	switch language {
+	case "Rust":
+		comment = lineComment(indent, "///", text)
+	case "C#":
+		comment = append(comment, indent+"/// <summary>")
+		comment = append(comment, lineComment(indent, "///", xmlEscaper.Replace(text))...)
+		comment = append(comment, indent+"/// </summary>")
+		for _, p := range documentedParams(s) {
+			comment = append(comment, indent+`/// <param name="`+xmlEscaper.Replace(p.Name)+`">`+xmlEscaper.Replace(p.Description)+"</param>")
+		}
+		if s.returns != "" && !s.isType {
+			comment = append(comment, indent+"/// <returns>"+xmlEscaper.Replace(s.returns)+"</returns>")
+		}
+	default: // JSDoc, Javadoc, KDoc
+		returns := "@return"
+		if language == "JavaScript" || language == "TypeScript" {
+			returns = "@returns"
+		}
+		comment = append(comment, indent+"/**")
+		comment = append(comment, lineComment(indent, " *", blockEscape(text))...)
+		params := documentedParams(s)
+		if len(params) > 0 || (s.returns != "" && !s.isType) {
+			comment = append(comment, indent+" *")
+		}
+		for _, p := range params {
+			comment = append(comment, indent+" * @param "+p.Name+" "+blockEscape(p.Description))
+		}
+		if s.returns != "" && !s.isType {
+			comment = append(comment, indent+" * "+returns+" "+blockEscape(s.returns))
+		}
+		comment = append(comment, indent+" */")
+	}
+	return Suggestion{At: at, Comment: comment}, true
+}
+
+// xmlEscaper escapes text for C# XML doc comments.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// blockEscape keeps text from closing the /** */ comment it goes in.
+func blockEscape(text string) string {
+	return strings.ReplaceAll(text, "*/", `*\/`)
+}
+
+// docstring suggests a Google-style docstring below a Python def or
+// class, unless it already has one.
+func docstring(lines []string, line int, s symbol) (Suggestion, bool) {
+	end := line - 1
+	for end < len(lines) && !strings.HasSuffix(strings.TrimSpace(stripHashComment(lines[end])), ":") {
+		end++
+	}
+	if end >= len(lines) {
+		return Suggestion{}, false
+	}
+	indent := indentOf(lines[line-1]) + "    "
+	for _, l := range lines[end+1:] {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		body := strings.TrimLeft(strings.TrimSpace(l), "rRbBuU")
+		if strings.HasPrefix(body, `"""`) || strings.HasPrefix(body, "'''") || strings.HasPrefix(body, `"`) || strings.HasPrefix(body, "'") {
+			return Suggestion{}, false
+		}
+		indent = indentOf(l)
+		break
+	}
+	text := sentence(s.summary)
+	params := documentedParams(s)
+	if len(params) == 0 && (s.returns == "" || s.isType) && len(indent)+len(text)+6 <= 88 {
+		return Suggestion{At: end + 1, Comment: []string{indent + `"""` + text + `"""`}}, true
+	}
+	comment := wrap(indent+`"""`, text, 88)
+	if len(params) > 0 {
+		comment = append(comment, "", indent+"Args:")
+		for _, p := range params {
+			comment = append(comment, wrap(indent+"    "+p.Name+": ", p.Description, 88)...)
+		}
+	}
+	if s.returns != "" && !s.isType {
+		comment = append(comment, "", indent+"Returns:")
+		comment = append(comment, wrap(indent+"    ", s.returns, 88)...)
+	}
+	comment = append(comment, indent+`"""`)
+	return Suggestion{At: end + 1, Comment: comment}, true
+}
+
+// documentedParams returns the parameters the analysis described.
+func documentedParams(s symbol) []indexer.ParamDoc {
+	var out []indexer.ParamDoc
+	for _, p := range s.params {
+		if p.Name != "" && strings.TrimSpace(p.Description) != "" && p.Name != "self" && p.Name != "cls" {
+			p.Description = strings.Join(strings.Fields(p.Description), " ")
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func stripHashComment(line string) string {
+	if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// lineComment wraps text into lines of a line comment, e.g. "// ...".
+func lineComment(indent, marker, text string) []string {
+	return wrap(indent+marker+" ", text, 80)
+}
+
+// wrap wraps text after prefix at width columns; continuation lines get
+// prefix's indentation and comment marker, without any label after it.
+func wrap(prefix, text string, width int) []string {
+	cont := prefix
+	if trimmed := strings.TrimRight(prefix, " "); strings.HasSuffix(trimmed, ":") || strings.HasSuffix(trimmed, `"""`) {
+		cont = indentOf(prefix)
+		if strings.HasSuffix(trimmed, ":") {
+			cont += "    "
+		}
+	}
+	var out []string
+	line := prefix
+	for _, w := range strings.Fields(text) {
+		if line != prefix && line != cont && len(line)+len(w) > width {
+			out = append(out, strings.TrimRight(line, " "))
+			line = cont
+		}
+		line += w + " "
+	}
+	return append(out, strings.TrimRight(line, " "))
+}
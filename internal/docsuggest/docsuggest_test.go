@@ -0,0 +1,212 @@
+package docsuggest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const goSource = `package store
+
+// Store holds users.
+type Store struct{}
+
+type User struct{ ID string }
+
+func (s *Store) Get(id string) (*User, error) {
+	return nil, nil
+}
+
+// Put saves a user.
+func (s *Store) Put(u *User) error { return nil }
+
+func helper() {}
+`
+
+const pySource = `class Users:
+    def find(self, name):
+        return None
+
+    def _cache(self):
+        pass
+
+
+def load(path: str) -> dict:
+    """Loads users."""
+    return {}
+`
+
+const tsSource = `export class Client {
+  private token = "";
+
+  async fetchUser(id: string) {
+    return null;
+  }
+}
+
+function internal() {}
+`
+
+func TestSuggest(t *testing.T) {
+	root := writeFiles(t, map[string]string{"store/store.go": goSource, "users.py": pySource, "client.ts": tsSource})
+	analyses := map[string]indexer.FileAnalysis{
+		"store/store.go": {FilePath: "store/store.go", Language: "Go",
+			Functions: []indexer.FunctionDoc{{Name: "helper", Summary: "Helps.", LineStart: 15}},
+			Classes: []indexer.ClassDoc{
+				{Name: "Store", Summary: "Store holds users.", LineStart: 4, Methods: []indexer.FunctionDoc{
+					{Name: "Get", Summary: "Returns the user with the given ID", LineStart: 8},
+					{Name: "Put", Summary: "Put saves a user.", LineStart: 13},
+				}},
+				{Name: "User", Summary: "This struct is a registered user account.", LineStart: 6},
+			}},
+		"users.py": {FilePath: "users.py", Language: "Python",
+			Functions: []indexer.FunctionDoc{{Name: "load", Summary: "Loads users.", LineStart: 9}},
+			Classes: []indexer.ClassDoc{{Name: "Users", LineStart: 1, Methods: []indexer.FunctionDoc{
+				{Name: "find", Summary: "Finds a user by name", LineStart: 2, Returns: "The user, or None.",
+					Parameters: []indexer.ParamDoc{{Name: "self"}, {Name: "name", Description: "The user's name."}}},
+				{Name: "_cache", Summary: "Caches users.", LineStart: 5},
+			}}}},
+		"client.ts": {FilePath: "client.ts", Language: "TypeScript",
+			Functions: []indexer.FunctionDoc{{Name: "internal", Summary: "Internal.", LineStart: 9}},
+			Classes: []indexer.ClassDoc{{Name: "Client", Summary: "API client.", LineStart: 1, Methods: []indexer.FunctionDoc{
+				// The analysis is a line off.
+				{Name: "fetchUser", Summary: "Fetches a user", LineStart: 3, Returns: "The user.",
+					Parameters: []indexer.ParamDoc{{Name: "id", Type: "string", Description: "The user's ID."}}},
+			}}}},
+	}
+
+	got, err := Suggest(root, analyses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var symbols []string
+	for _, s := range got {
+		symbols = append(symbols, s.File+":"+s.Symbol)
+	}
+	want := "client.ts:Client client.ts:fetchUser store/store.go:User store/store.go:Get users.py:find"
+	if strings.Join(symbols, " ") != want {
+		t.Fatalf("suggested for %v, want %s", symbols, want)
+	}
+
+	comments := make(map[string]string)
+	for _, s := range got {
+		comments[s.Symbol] = strings.Join(s.Comment, "\n")
+	}
+	if c := comments["Get"]; c != "// Get returns the user with the given ID." {
+		t.Errorf("Get comment = %q", c)
+	}
+	if c := comments["User"]; c != "// User is a registered user account." {
+		t.Errorf("User comment = %q", c)
+	}
+	if c := comments["fetchUser"]; c != "  /**\n   * Fetches a user.\n   *\n   * @param id The user's ID.\n   * @returns The user.\n   */" {
+		t.Errorf("fetchUser comment = %q", c)
+	}
+	if c := comments["find"]; c != "        \"\"\"Finds a user by name.\n\n        Args:\n            name: The user's name.\n\n        Returns:\n            The user, or None.\n        \"\"\"" {
+		t.Errorf("find docstring = %q", c)
+	}
+}
+
+func TestSuggestEscapesComments(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"Parser.java": "public class Parser {\n    public int parse(String s) {\n        return 0;\n    }\n}\n",
+		"Parser.cs":   "public class Parser\n{\n    public int Parse(string s)\n    {\n        return 0;\n    }\n}\n",
+	})
+	analyses := map[string]indexer.FileAnalysis{
+		"Parser.java": {FilePath: "Parser.java", Language: "Java",
+			Classes: []indexer.ClassDoc{{Name: "Parser", Summary: "Strips /* comments */ from input.", LineStart: 1, Methods: []indexer.FunctionDoc{
+				{Name: "parse", Summary: "Counts tokens", LineStart: 2, Returns: "The count, */ excluded.",
+					Parameters: []indexer.ParamDoc{{Name: "s", Description: "Text ending in */."}}},
+			}}}},
+		"Parser.cs": {FilePath: "Parser.cs", Language: "C#",
+			Classes: []indexer.ClassDoc{{Name: "Parser", Summary: "Parses <T> & friends.", LineStart: 1, Methods: []indexer.FunctionDoc{
+				{Name: "Parse", Summary: "Counts tokens", LineStart: 3, Returns: "A count < 10.",
+					Parameters: []indexer.ParamDoc{{Name: "s", Description: "The </param> text."}}},
+			}}}},
+	}
+
+	got, err := Suggest(root, analyses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := make(map[string]string)
+	for _, s := range got {
+		comments[s.File+":"+s.Symbol] = strings.Join(s.Comment, "\n")
+	}
+	if c := comments["Parser.java:Parser"]; c != "/**\n * Strips /* comments *\\/ from input.\n */" {
+		t.Errorf("Java class comment = %q", c)
+	}
+	if c := comments["Parser.java:parse"]; strings.Count(c, "*/") != 1 || !strings.Contains(c, `@param s Text ending in *\/.`) {
+		t.Errorf("Java method comment = %q, want only its own */", c)
+	}
+	if c := comments["Parser.cs:Parser"]; !strings.Contains(c, "/// Parses &lt;T&gt; &amp; friends.") {
+		t.Errorf("C# class comment = %q", c)
+	}
+	if c := comments["Parser.cs:Parse"]; !strings.Contains(c, `<param name="s">The &lt;/param&gt; text.</param>`) || !strings.Contains(c, "<returns>A count &lt; 10.</returns>") {
+		t.Errorf("C# method comment = %q", c)
+	}
+}
+
+func TestPatchAndApply(t *testing.T) {
+	root := writeFiles(t, map[string]string{"store/store.go": goSource})
+	analyses := map[string]indexer.FileAnalysis{
+		"store/store.go": {FilePath: "store/store.go", Language: "Go", Classes: []indexer.ClassDoc{
+			{Name: "User", Summary: "A registered user.", LineStart: 6},
+			{Name: "Store", Methods: []indexer.FunctionDoc{{Name: "Get", Summary: "Returns a user.", LineStart: 8}}},
+		}},
+	}
+	suggestions, err := Suggest(root, analyses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch, err := Patch(root, suggestions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `diff --git a/store/store.go b/store/store.go
+--- a/store/store.go
++++ b/store/store.go
+@@ -3,8 +3,10 @@
+ // Store holds users.
+ type Store struct{}
+ 
++// User is a registered user.
+ type User struct{ ID string }
+ 
++// Get returns a user.
+ func (s *Store) Get(id string) (*User, error) {
+ 	return nil, nil
+ }
+`
+	if patch != want {
+		t.Errorf("Patch =\n%s\nwant\n%s", patch, want)
+	}
+
+	files, err := Apply(root, suggestions)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Apply = %v, %v", files, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "store", "store.go"))
+	if !strings.Contains(string(data), "// User is a registered user.\ntype User") || !strings.Contains(string(data), "// Get returns a user.\nfunc (s *Store) Get") {
+		t.Errorf("applied source:\n%s", data)
+	}
+	if again, _ := Suggest(root, analyses); len(again) != 0 {
+		t.Errorf("Suggest after Apply = %+v, want none", again)
+	}
+}
@@ -0,0 +1,146 @@
+package docsuggest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each hunk.
+const contextLines = 3
+
+// source is a file split into lines, remembering whether it ended in a
+// newline.
+type source struct {
+	lines       []string
+	endsNewline bool
+}
+
+func readSource(root, file string) (source, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return source{}, err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	src := source{endsNewline: strings.HasSuffix(text, "\n")}
+	if text != "" {
+		src.lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	return src, nil
+}
+
+// byFile groups suggestions by file, keeping their order.
+func byFile(suggestions []Suggestion) ([]string, map[string][]Suggestion) {
+	var files []string
+	groups := make(map[string][]Suggestion)
+	for _, s := range suggestions {
+		if _, ok := groups[s.File]; !ok {
+			files = append(files, s.File)
+		}
+		groups[s.File] = append(groups[s.File], s)
+	}
+	return files, groups
+}
+
+// Patch renders suggestions as a unified diff against the files under
+// root, for `git apply`. Suggestions must be sorted by file and position, as
+// Suggest returns them.
+func Patch(root string, suggestions []Suggestion) (string, error) {
+	var b strings.Builder
+	files, groups := byFile(suggestions)
+	for _, file := range files {
+		src, err := readSource(root, file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", file, file, file, file)
+		writeHunks(&b, src, groups[file])
+	}
+	return b.String(), nil
+}
+
+// writeHunks writes the hunks inserting a file's suggestions, merging
+// insertions whose context overlaps.
+func writeHunks(b *strings.Builder, src source, sugs []Suggestion) {
+	n := len(src.lines)
+	added := 0 // lines inserted by earlier hunks
+	for i := 0; i < len(sugs); {
+		start := max(sugs[i].At-contextLines, 0)
+		end := min(sugs[i].At+contextLines, n)
+		j := i + 1
+		for j < len(sugs) && sugs[j].At-contextLines <= end {
+			end = min(sugs[j].At+contextLines, n)
+			j++
+		}
+		var body []string
+		inserted := 0
+		k := i
+		for line := start; line <= end; line++ {
+			for k < j && sugs[k].At == line {
+				for _, c := range sugs[k].Comment {
+					body = append(body, "+"+c)
+				}
+				inserted += len(sugs[k].Comment)
+				k++
+			}
+			if line < end {
+				body = append(body, " "+src.lines[line])
+				if line == n-1 && !src.endsNewline {
+					body = append(body, `\ No newline at end of file`)
+				}
+			}
+		}
+		fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(start, end-start), hunkRange(start+added, end-start+inserted))
+		b.WriteString(strings.Join(body, "\n") + "\n")
+		added += inserted
+		i = j
+	}
+}
+
+// hunkRange renders a hunk's 0-based start and line count as a unified
+// diff range.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Apply writes suggestions into the files under root and returns the files
+// it changed. Suggestions must be sorted by file and position, as Suggest
+// returns them.
+func Apply(root string, suggestions []Suggestion) ([]string, error) {
+	files, groups := byFile(suggestions)
+	for _, file := range files {
+		src, err := readSource(root, file)
+		if err != nil {
+			return nil, err
+		}
+		var out []string
+		sugs := groups[file]
+		for i, line := range src.lines {
+			for len(sugs) > 0 && sugs[0].At == i {
+				out = append(out, sugs[0].Comment...)
+				sugs = sugs[1:]
+			}
+			out = append(out, line)
+		}
+		for _, s := range sugs {
+			out = append(out, s.Comment...)
+		}
+		text := strings.Join(out, "\n")
+		if src.endsNewline {
+			text += "\n"
+		}
+		path := filepath.Join(root, filepath.FromSlash(file))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(text), info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,218 @@
+package embeddings
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheFile is the embedding cache's file name, kept in the output
+// directory next to analyses.json.
+const CacheFile = "embedding-cache.gob.gz"
+
+// cacheMaxIdleRuns is how many saves an entry survives without being used
+// before it is dropped, so the cache doesn't keep the vectors of code that
+// has long since changed.
+const cacheMaxIdleRuns = 5
+
+// Batch sizes for texts the cache has no vector for: the first request
+// carries startBatch texts, and each success doubles the size, up to
+// maxBatch texts or maxBatchChars characters, while each failure halves it.
+const (
+	startBatch    = 32
+	maxBatch      = 512
+	maxBatchChars = 400_000 // ~100k tokens
+)
+
+// CachedEmbedder remembers the vector of every text it embeds, keyed by a
+// hash of the model and the text, so reindexing only pays for chunks that
+// changed. Texts it has no vector for are sent in batches whose size adapts
+// to what the API accepts.
+type CachedEmbedder struct {
+	Embedder
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	run     int // one more than the run that saved the loaded cache
+	batch   int
+	// Hits and Misses count the texts served from the cache and embedded.
+	Hits, Misses int
+}
+
+type cacheEntry struct {
+	Vector  []float32
+	LastRun int
+}
+
+// cacheFile is the persisted cache.
+type cacheFile struct {
+	Model   string
+	Run     int
+	Entries map[string]cacheEntry
+}
+
+// NewCachedEmbedder wraps an embedder with an empty cache.
+func NewCachedEmbedder(e Embedder) *CachedEmbedder {
+	return &CachedEmbedder{Embedder: e, entries: make(map[string]cacheEntry), batch: startBatch}
+}
+
+func (c *CachedEmbedder) key(text string) string {
+	sum := sha256.Sum256([]byte(c.Name() + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Embed returns cached vectors for the texts it has seen and embeds the rest.
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	var missing []string
+	missingAt := make(map[string][]int)
+
+	c.mu.Lock()
+	for i, text := range texts {
+		k := c.key(text)
+		if e, ok := c.entries[k]; ok {
+			e.LastRun = c.run
+			c.entries[k] = e
+			out[i] = e.Vector
+			c.Hits++
+			continue
+		}
+		if _, queued := missingAt[k]; !queued {
+			missing = append(missing, text)
+		}
+		missingAt[k] = append(missingAt[k], i)
+	}
+	c.mu.Unlock()
+
+	for len(missing) > 0 {
+		n := c.batchSize(missing)
+		vectors, err := c.Embedder.Embed(ctx, missing[:n])
+		if err == nil && len(vectors) != n {
+			err = fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), n)
+		}
+		if err != nil {
+			if n == 1 || ctx.Err() != nil {
+				return nil, err
+			}
+			c.adapt(false)
+			continue
+		}
+		c.adapt(true)
+
+		c.mu.Lock()
+		for j, text := range missing[:n] {
+			k := c.key(text)
+			c.entries[k] = cacheEntry{Vector: vectors[j], LastRun: c.run}
+			for _, i := range missingAt[k] {
+				out[i] = vectors[j]
+			}
+			c.Misses++
+		}
+		c.mu.Unlock()
+		missing = missing[n:]
+	}
+	return out, nil
+}
+
+// batchSize is how many of texts go in the next request.
+func (c *CachedEmbedder) batchSize(texts []string) int {
+	c.mu.Lock()
+	limit := c.batch
+	c.mu.Unlock()
+	n, chars := 0, 0
+	for n < len(texts) && n < limit {
+		chars += len(texts[n])
+		if n > 0 && chars > maxBatchChars {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// adapt grows the batch size after a request succeeds and shrinks it after
+// one fails.
+func (c *CachedEmbedder) adapt(ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		c.batch = min(c.batch*2, maxBatch)
+	} else {
+		c.batch = max(c.batch/2, 1)
+	}
+}
+
+// Load reads a cache saved by Save. A missing file, or one written for
+// another model, leaves the cache empty.
+func (c *CachedEmbedder) Load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("reading embedding cache: %w", err)
+	}
+	var file cacheFile
+	if err := gob.NewDecoder(zr).Decode(&file); err != nil {
+		return fmt.Errorf("reading embedding cache: %w", err)
+	}
+	if file.Model != c.Name() {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.run = file.Run + 1
+	for k, e := range file.Entries {
+		c.entries[k] = e
+	}
+	return nil
+}
+
+// Save writes the cache, leaving out entries no run has used in a while.
+func (c *CachedEmbedder) Save(path string) error {
+	c.mu.Lock()
+	file := cacheFile{Model: c.Name(), Run: c.run, Entries: make(map[string]cacheEntry, len(c.entries))}
+	for k, e := range c.entries {
+		if c.run-e.LastRun < cacheMaxIdleRuns {
+			file.Entries[k] = e
+		}
+	}
+	c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".embedding-cache-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	zw := gzip.NewWriter(tmp)
+	if err := gob.NewEncoder(zw).Encode(file); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing embedding cache: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
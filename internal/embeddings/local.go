@@ -0,0 +1,242 @@
+package embeddings
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// localWorker is the built-in worker script, run with the configured Python.
+//
+//go:embed local_worker.py
+var localWorker string
+
+// DefaultLocalModel is a small sentence-transformers model that runs well
+// on a CPU.
+const DefaultLocalModel = "sentence-transformers/all-MiniLM-L6-v2"
+
+// LocalEmbedder generates embeddings with a model running on this machine,
+// in a worker process it starts on first use and keeps for the rest of the
+// run, so air-gapped environments get semantic search without any API. The
+// built-in worker runs the model with sentence-transformers, or with
+// onnxruntime for an exported ONNX model. Any other program can stand in
+// for it by speaking the same protocol: one JSON line announcing the
+// dimensions, then a line of embeddings for each line of texts; see
+// local_worker.py.
+type LocalEmbedder struct {
+	model      string
+	command    []string
+	dimensions int
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *tailBuffer
+	err    error // why the worker couldn't start, so it isn't retried
+}
+
+// NewLocalEmbedder creates a local embedder for model, a sentence-transformers
+// model name or the path of a model on disk. command is the worker to run,
+// with the model appended as its last argument; empty runs the built-in
+// worker with python3. dimensions may be 0, in which case the worker reports
+// them when it starts.
+func NewLocalEmbedder(model string, command []string, dimensions int) *LocalEmbedder {
+	if model == "" {
+		model = DefaultLocalModel
+	}
+	return &LocalEmbedder{model: model, command: command, dimensions: dimensions}
+}
+
+// NewPythonEmbedder creates a local embedder that runs the built-in worker
+// with the given Python interpreter, e.g. one from a virtualenv that has
+// sentence-transformers installed.
+func NewPythonEmbedder(python, model string, dimensions int) *LocalEmbedder {
+	if python == "" {
+		python = "python3"
+	}
+	return NewLocalEmbedder(model, []string{python, "-c", localWorker}, dimensions)
+}
+
+func (e *LocalEmbedder) Name() string {
+	return "local/" + e.model
+}
+
+// Dimensions returns the configured dimensions or, failing that, the ones
+// the worker reports, starting it if need be. It returns 0 if the worker
+// can't start.
+func (e *LocalEmbedder) Dimensions() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dimensions == 0 {
+		_ = e.start()
+	}
+	return e.dimensions
+}
+
+type localHello struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+type localRequest struct {
+	Texts []string `json:"texts"`
+}
+
+type localReply struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+func (e *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// The worker answers one request at a time.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.start(); err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(localRequest{Texts: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshal local embedding request: %w", err)
+	}
+	if _, err := e.stdin.Write(append(line, '\n')); err != nil {
+		return nil, e.failed(fmt.Errorf("writing to embedding worker: %w", err))
+	}
+	var reply localReply
+	if err := e.read(&reply); err != nil {
+		return nil, e.failed(err)
+	}
+	if reply.Error != "" {
+		return nil, fmt.Errorf("embedding worker: %s", reply.Error)
+	}
+	if len(reply.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding worker returned %d embeddings for %d texts", len(reply.Embeddings), len(texts))
+	}
+	return reply.Embeddings, nil
+}
+
+// Close stops the worker.
+func (e *LocalEmbedder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cmd == nil {
+		return nil
+	}
+	e.stdin.Close() // the worker exits at the end of its input
+	err := e.cmd.Wait()
+	e.cmd = nil
+	return err
+}
+
+// start launches the worker and reads its greeting, unless it is running.
+// Loading a model can take a while, so this happens once per run.
+func (e *LocalEmbedder) start() error {
+	if e.cmd != nil {
+		return nil
+	}
+	if e.err != nil {
+		return e.err
+	}
+	command := e.command
+	if len(command) == 0 {
+		command = []string{"python3", "-c", localWorker}
+	}
+	args := append(append([]string{}, command[1:]...), e.model)
+	cmd := exec.Command(command[0], args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	e.stderr = &tailBuffer{max: 4096}
+	cmd.Stderr = e.stderr
+	if err := cmd.Start(); err != nil {
+		e.err = fmt.Errorf("starting embedding worker %s: %w", command[0], err)
+		return e.err
+	}
+	e.cmd, e.stdin, e.stdout = cmd, stdin, bufio.NewReaderSize(stdout, 1<<20)
+
+	var hello localHello
+	if err := e.read(&hello); err != nil {
+		e.err = e.failed(fmt.Errorf("loading local embedding model %s: %w", e.model, err))
+		return e.err
+	}
+	if e.dimensions == 0 {
+		e.dimensions = hello.Dimensions
+	} else if hello.Dimensions != 0 && hello.Dimensions != e.dimensions {
+		e.err = e.failed(fmt.Errorf("local embedding model %s has %d dimensions, not the configured %d", e.model, hello.Dimensions, e.dimensions))
+		return e.err
+	}
+	return nil
+}
+
+// read decodes the worker's next line into v.
+func (e *LocalEmbedder) read(v any) error {
+	line, err := e.stdout.ReadBytes('\n')
+	if err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("embedding worker exited")
+		}
+		return err
+	}
+	if err := json.Unmarshal(line, v); err != nil {
+		return fmt.Errorf("embedding worker sent %q: %w", strings.TrimSpace(string(line)), err)
+	}
+	return nil
+}
+
+// failed stops a worker that broke the protocol and adds what it printed to
+// stderr to err, which is where Python puts its tracebacks.
+func (e *LocalEmbedder) failed(err error) error {
+	if e.cmd != nil {
+		e.stdin.Close()
+		_ = e.cmd.Process.Kill()
+		_ = e.cmd.Wait()
+		e.cmd = nil
+	}
+	if tail := strings.TrimSpace(e.stderr.String()); tail != "" {
+		return fmt.Errorf("%w\n%s", err, tail)
+	}
+	return err
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if over := b.buf.Len() - b.max; over > 0 {
+		b.buf.Next(over)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,87 @@
+"""Embedding worker for autodoc's local embedder.
+
+Loads a model once, then answers requests over stdin/stdout, one JSON
+object per line:
+
+    -> {"model": "...", "dimensions": 384}           (once, at startup)
+    <- {"texts": ["...", "..."]}
+    -> {"embeddings": [[...], [...]]} or {"error": "..."}
+
+A model path ending in .onnx, or a directory holding model.onnx, runs on
+onnxruntime with the tokenizer next to it; anything else is loaded with
+sentence-transformers, from a local path or the Hugging Face cache. Set
+HF_HUB_OFFLINE=1 to keep sentence-transformers from reaching the network.
+"""
+
+import json
+import os
+import sys
+
+
+def load_onnx(path):
+    import numpy as np
+    import onnxruntime
+    from tokenizers import Tokenizer
+
+    if os.path.isdir(path):
+        directory, model_file = path, os.path.join(path, "model.onnx")
+    else:
+        directory, model_file = os.path.dirname(path), path
+    session = onnxruntime.InferenceSession(model_file, providers=["CPUExecutionProvider"])
+    tokenizer = Tokenizer.from_file(os.path.join(directory, "tokenizer.json"))
+    tokenizer.enable_padding()
+    tokenizer.enable_truncation(max_length=512)
+    inputs = {i.name for i in session.get_inputs()}
+
+    def embed(texts):
+        encoded = tokenizer.encode_batch(texts)
+        ids = np.array([e.ids for e in encoded], dtype=np.int64)
+        mask = np.array([e.attention_mask for e in encoded], dtype=np.int64)
+        feed = {"input_ids": ids, "attention_mask": mask}
+        if "token_type_ids" in inputs:
+            feed["token_type_ids"] = np.zeros_like(ids)
+        hidden = session.run(None, feed)[0]
+        # Mean pooling over the real tokens, then L2 normalization.
+        weights = mask[..., None].astype(hidden.dtype)
+        pooled = (hidden * weights).sum(axis=1) / np.clip(weights.sum(axis=1), 1e-9, None)
+        pooled /= np.clip(np.linalg.norm(pooled, axis=1, keepdims=True), 1e-12, None)
+        return pooled.tolist()
+
+    dimensions = len(embed(["dimensions"])[0])
+    return embed, dimensions
+
+
+def load_sentence_transformers(name):
+    from sentence_transformers import SentenceTransformer
+
+    model = SentenceTransformer(name)
+
+    def embed(texts):
+        return model.encode(texts, normalize_embeddings=True).tolist()
+
+    return embed, model.get_sentence_embedding_dimension()
+
+
+def main():
+    model = sys.argv[1]
+    if model.endswith(".onnx") or os.path.isfile(os.path.join(model, "model.onnx")):
+        embed, dimensions = load_onnx(model)
+    else:
+        embed, dimensions = load_sentence_transformers(model)
+
+    out = sys.stdout
+    out.write(json.dumps({"model": model, "dimensions": dimensions}) + "\n")
+    out.flush()
+    for line in sys.stdin:
+        if not line.strip():
+            continue
+        try:
+            reply = {"embeddings": embed(json.loads(line)["texts"])}
+        except Exception as e:  # report the failure and keep serving
+            reply = {"error": str(e)}
+        out.write(json.dumps(reply) + "\n")
+        out.flush()
+
+
+if __name__ == "__main__":
+    main()
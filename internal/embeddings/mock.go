@@ -0,0 +1,65 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// MockEmbedder produces deterministic embeddings locally by hashing words
+// into a fixed number of buckets. Texts that share words land near each
+// other, which is enough for search to behave sensibly in demos and tests
+// without an embeddings API.
+type MockEmbedder struct {
+	dimensions int
+}
+
+// NewMockEmbedder creates a mock embedder; dimensions defaults to 256.
+func NewMockEmbedder(dimensions int) *MockEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &MockEmbedder{dimensions: dimensions}
+}
+
+func (e *MockEmbedder) Name() string {
+	return "mock"
+}
+
+func (e *MockEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+func (e *MockEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, e.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, w := range words {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			vec[h.Sum32()%uint32(e.dimensions)]++
+		}
+		// Empty texts still need a non-zero vector to be comparable.
+		if len(words) == 0 {
+			vec[0] = 1
+		}
+		var norm float64
+		for _, v := range vec {
+			norm += float64(v * v)
+		}
+		norm = math.Sqrt(norm)
+		for j := range vec {
+			vec[j] = float32(float64(vec[j]) / norm)
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
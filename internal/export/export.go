@@ -0,0 +1,307 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// Exporter builds a curated, customer-facing copy of the generated markdown
+// docs. Only whitelisted pages (and optionally sections) are copied, internal
+// names are replaced through the alias map, and links to pages that were not
+// exported are reduced to plain text so the bundle never points at internal docs.
+type Exporter struct {
+	DocsDir   string
+	OutputDir string
+	Pages     []string
+	Sections  []string
+	Aliases   map[string]string
+}
+
+// NewExporter creates an Exporter from the export section of the config.
+func NewExporter(docsDir, outputDir string, cfg config.ExportConfig) *Exporter {
+	return &Exporter{
+		DocsDir:   docsDir,
+		OutputDir: outputDir,
+		Pages:     cfg.Pages,
+		Sections:  cfg.Sections,
+		Aliases:   cfg.Aliases,
+	}
+}
+
+// Export writes the curated bundle to OutputDir and returns the number of
+// pages exported.
+func (e *Exporter) Export() (int, error) {
+	if len(e.Pages) == 0 {
+		return 0, fmt.Errorf("no pages whitelisted for export; set export.pages in .autodoc.yml")
+	}
+
+	pages, err := e.selectPages()
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, fmt.Errorf("no pages in %s match the export whitelist", e.DocsDir)
+	}
+
+	exported := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		exported[p] = true
+	}
+
+	if err := os.MkdirAll(e.OutputDir, 0o755); err != nil {
+		return 0, fmt.Errorf("creating export directory: %w", err)
+	}
+
+	for _, rel := range pages {
+		data, err := os.ReadFile(filepath.Join(e.DocsDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return 0, fmt.Errorf("reading %s: %w", rel, err)
+		}
+
+		content := string(data)
+		if len(e.Sections) > 0 {
+			content = FilterSections(content, e.Sections)
+		}
+		content = rewriteContent(content, rel, exported, e.Aliases)
+
+		outPath := filepath.Join(e.OutputDir, filepath.FromSlash(aliasPath(rel, e.Aliases)))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return 0, fmt.Errorf("creating directory for %s: %w", rel, err)
+		}
+		if err := os.WriteFile(outPath, []byte(content), 0o644); err != nil {
+			return 0, fmt.Errorf("writing %s: %w", outPath, err)
+		}
+	}
+
+	// Give the bundle a landing page when the whitelist did not include one.
+	if !exported["index.md"] {
+		if err := e.writeIndex(pages); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pages), nil
+}
+
+// selectPages returns the sorted relative paths of markdown pages that match
+// the page whitelist.
+func (e *Exporter) selectPages() ([]string, error) {
+	var pages []string
+	err := filepath.Walk(e.DocsDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(e.DocsDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range e.Pages {
+			if ok, _ := doublestar.Match(filepath.ToSlash(pattern), rel); ok {
+				pages = append(pages, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning docs directory: %w", err)
+	}
+	sort.Strings(pages)
+	return pages, nil
+}
+
+// writeIndex writes an index.md linking every exported page.
+func (e *Exporter) writeIndex(pages []string) error {
+	var b strings.Builder
+	b.WriteString("# Documentation\n\n")
+	for _, rel := range pages {
+		target := aliasPath(rel, e.Aliases)
+		title := pageTitle(filepath.Join(e.OutputDir, filepath.FromSlash(target)), target)
+		b.WriteString(fmt.Sprintf("- [%s](%s)\n", title, target))
+	}
+	return os.WriteFile(filepath.Join(e.OutputDir, "index.md"), []byte(b.String()), 0o644)
+}
+
+// pageTitle returns the first H1 of an exported page, or its path as a fallback.
+func pageTitle(filePath, rel string) string {
+	data, err := os.ReadFile(filePath)
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "# ") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			}
+		}
+	}
+	return strings.TrimSuffix(rel, ".md")
+}
+
+// FilterSections keeps the page preamble (everything before the first "##"
+// heading) plus the "##" sections whose heading matches one of the allowed
+// names, case-insensitively. Nested headings stay with their parent section.
+func FilterSections(content string, allowed []string) string {
+	allow := make(map[string]bool, len(allowed))
+	for _, s := range allowed {
+		allow[strings.ToLower(strings.TrimSpace(s))] = true
+	}
+
+	var out []string
+	keep := true
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "## ") {
+			heading := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "## ")))
+			keep = allow[heading]
+		}
+		if keep {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// mdLinkRe matches inline markdown links; the target is captured without any
+// optional title.
+var mdLinkRe = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+
+// rewriteContent applies the alias map to a page. Links to exported pages are
+// pointed at their aliased paths. Links to local pages or files that were not
+// exported, and to URLs naming an aliased service, are replaced with their
+// link text, as are local images, whose files are not part of the bundle.
+func rewriteContent(content, pageRel string, exported map[string]bool, aliases map[string]string) string {
+	pageDir := path.Dir(pageRel)
+	slugs := slugAliases(aliases)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range mdLinkRe.FindAllStringSubmatchIndex(content, -1) {
+		b.WriteString(ApplyAliases(content[last:loc[0]], aliases))
+		last = loc[1]
+
+		image := content[loc[2]:loc[3]]
+		text := ApplyAliases(content[loc[4]:loc[5]], aliases)
+		target := content[loc[6]:loc[7]]
+
+		file, anchor := target, ""
+		if i := strings.Index(target, "#"); i >= 0 {
+			file, anchor = target[:i], target[i:]
+		}
+		switch {
+		case strings.Contains(file, "://") || strings.HasPrefix(file, "mailto:"):
+			// A URL can't be aliased without breaking it, so one that names
+			// an internal service is dropped.
+			if ApplyAliases(target, slugs) != target {
+				b.WriteString(text)
+			} else {
+				b.WriteString(image + "[" + text + "](" + target + ")")
+			}
+			continue
+		case image != "":
+			b.WriteString(text)
+			continue
+		case file == "":
+			b.WriteString("[" + text + "](" + ApplyAliases(anchor, slugs) + ")")
+			continue
+		}
+
+		resolved := path.Clean(path.Join(pageDir, file))
+		if strings.HasPrefix(file, "/") {
+			resolved = strings.TrimPrefix(path.Clean(file), "/")
+		}
+		if !exported[resolved] {
+			b.WriteString(text)
+			continue
+		}
+		b.WriteString("[" + text + "](" + aliasPath(file, aliases) + ApplyAliases(anchor, slugs) + ")")
+	}
+	b.WriteString(ApplyAliases(content[last:], aliases))
+	return b.String()
+}
+
+// aliasPath applies the alias map to a relative page path, using URL-safe
+// forms of the public names.
+func aliasPath(p string, aliases map[string]string) string {
+	return ApplyAliases(p, slugAliases(aliases))
+}
+
+// slugAliases returns the alias map with each public name lowercased and
+// spaces replaced by dashes.
+func slugAliases(aliases map[string]string) map[string]string {
+	slugs := make(map[string]string, len(aliases))
+	for name, alias := range aliases {
+		slugs[name] = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(alias)), " ", "-")
+	}
+	return slugs
+}
+
+// ApplyAliases replaces each internal name in s with its public alias. Matches
+// are case-insensitive and only on whole names, so an alias for "orders" does
+// not touch "orders-db". Longer names are replaced first.
+func ApplyAliases(s string, aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return s
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if i == 0 || !isNameByte(s[i-1]) {
+			if name, ok := matchName(s[i:], names); ok {
+				b.WriteString(aliases[name])
+				i += len(name)
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// matchName returns the first name that prefixes s case-insensitively and is
+// not followed by another name character.
+func matchName(s string, names []string) (string, bool) {
+	for _, name := range names {
+		if len(s) < len(name) || !strings.EqualFold(s[:len(name)], name) {
+			continue
+		}
+		if len(s) > len(name) && isNameByte(s[len(name)]) {
+			continue
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// isNameByte reports whether c can be part of a service or identifier name.
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
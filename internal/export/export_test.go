@@ -0,0 +1,141 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func TestApplyAliases(t *testing.T) {
+	aliases := map[string]string{
+		"orders-svc":   "Ordering API",
+		"payments-svc": "Payments API",
+	}
+
+	got := ApplyAliases("Orders-svc calls payments-svc, not orders-svc-db.", aliases)
+	want := "Ordering API calls Payments API, not orders-svc-db."
+	if got != want {
+		t.Errorf("ApplyAliases = %q, want %q", got, want)
+	}
+
+	// An alias that contains its own name must not be applied twice.
+	got = ApplyAliases("orders", map[string]string{"orders": "Orders API"})
+	if got != "Orders API" {
+		t.Errorf("ApplyAliases = %q, want %q", got, "Orders API")
+	}
+}
+
+func TestFilterSections(t *testing.T) {
+	content := "# Orders\n\nIntro.\n\n## Internals\n\nsecret\n\n## API\n\n### POST /orders\n\n```\n## not a heading\n```\n\n## Deployment\n\nk8s\n"
+	got := FilterSections(content, []string{"api"})
+
+	if !strings.Contains(got, "Intro.") {
+		t.Error("expected preamble to be kept")
+	}
+	if !strings.Contains(got, "### POST /orders") || !strings.Contains(got, "## not a heading") {
+		t.Error("expected API section with nested heading and code block")
+	}
+	if strings.Contains(got, "secret") || strings.Contains(got, "k8s") {
+		t.Errorf("expected non-whitelisted sections to be dropped, got:\n%s", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	docsDir := t.TempDir()
+	outDir := filepath.Join(t.TempDir(), "bundle")
+
+	files := map[string]string{
+		"api/orders-svc.md":      "# orders-svc API\n\nSee [flows](../flows/checkout.md) and [internals](../internal/db.md).\n\n## Endpoints\n\nGET /orders\n\n## Runbook\n\nPage the orders-svc on-call.\n",
+		"flows/checkout.md":      "# Checkout\n\nCalls [orders-svc](../api/orders-svc.md#endpoints).\n",
+		"internal/db.md":         "# Database\n",
+		"architecture.md":        "# Architecture\n",
+		"api/payments-notes.txt": "not markdown",
+	}
+	for rel, content := range files {
+		p := filepath.Join(docsDir, rel)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := NewExporter(docsDir, outDir, config.ExportConfig{
+		Pages:    []string{"api/**", "flows/*.md"},
+		Sections: []string{"Endpoints"},
+		Aliases:  map[string]string{"orders-svc": "Ordering API"},
+	})
+	count, err := exp.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	api, err := os.ReadFile(filepath.Join(outDir, "api", "ordering-api.md"))
+	if err != nil {
+		t.Fatalf("expected aliased page path: %v", err)
+	}
+	apiText := string(api)
+	if strings.Contains(apiText, "orders-svc") {
+		t.Errorf("internal name leaked into export:\n%s", apiText)
+	}
+	if strings.Contains(apiText, "Runbook") {
+		t.Error("expected non-whitelisted section to be dropped")
+	}
+	if strings.Contains(apiText, "internal/db.md") || !strings.Contains(apiText, "and internals.") {
+		t.Errorf("expected link to unexported page to become plain text, got:\n%s", apiText)
+	}
+	if !strings.Contains(apiText, "[flows](../flows/checkout.md)") {
+		t.Errorf("expected link to exported page to be kept, got:\n%s", apiText)
+	}
+
+	flow, err := os.ReadFile(filepath.Join(outDir, "flows", "checkout.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(flow), "[Ordering API](../api/ordering-api.md#endpoints)") {
+		t.Errorf("expected link to aliased page, got:\n%s", flow)
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "architecture.md")); !os.IsNotExist(err) {
+		t.Error("expected non-whitelisted page to be excluded")
+	}
+	index, err := os.ReadFile(filepath.Join(outDir, "index.md"))
+	if err != nil {
+		t.Fatalf("expected generated index: %v", err)
+	}
+	if !strings.Contains(string(index), "[Ordering API API](api/ordering-api.md)") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+}
+
+func TestRewriteContentTargets(t *testing.T) {
+	aliases := map[string]string{"orders-service": "Ordering API"}
+	exported := map[string]bool{"api/orders-service.md": true}
+	content := "Source: [orders](https://github.com/acme/orders-service).\n" +
+		"Spec: [spec](https://example.com/openapi).\n" +
+		"![orders-service diagram](../diagrams/orders-service.png)\n" +
+		"![badge](https://img.shields.io/badge/build-passing.svg)\n" +
+		"Notes: [notes](payments-notes.txt), [top](#orders-service).\n"
+
+	got := rewriteContent(content, "flows/checkout.md", exported, aliases)
+	want := "Source: orders.\n" +
+		"Spec: [spec](https://example.com/openapi).\n" +
+		"Ordering API diagram\n" +
+		"![badge](https://img.shields.io/badge/build-passing.svg)\n" +
+		"Notes: notes, [top](#ordering-api).\n"
+	if got != want {
+		t.Errorf("rewriteContent =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExport_RequiresWhitelist(t *testing.T) {
+	exp := NewExporter(t.TempDir(), t.TempDir(), config.ExportConfig{})
+	if _, err := exp.Export(); err == nil {
+		t.Error("expected error when no pages are whitelisted")
+	}
+}
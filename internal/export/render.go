@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MermaidCLI rasterizes Mermaid diagrams with mermaid-cli (mmdc), which
+// renders them in its own headless browser.
+type MermaidCLI struct {
+	// Command is the mmdc executable; defaults to "mmdc" on the PATH.
+	Command string
+}
+
+// Rasterize renders one diagram to a PNG on a white background.
+func (m MermaidCLI) Rasterize(ctx context.Context, source string) ([]byte, error) {
+	command := m.Command
+	if command == "" {
+		command = "mmdc"
+	}
+	dir, err := os.MkdirTemp("", "autodoc-mermaid-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "diagram.mmd"), filepath.Join(dir, "diagram.png")
+	if err := os.WriteFile(in, []byte(source), 0o644); err != nil {
+		return nil, err
+	}
+	if err := run(ctx, command, "-i", in, "-o", out, "-b", "white", "-q"); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(out)
+}
+
+// browserCandidates are the Chromium-based browsers PrintPDF looks for on
+// the PATH, in order.
+var browserCandidates = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+
+// FindBrowser returns the first headless-capable browser on the PATH.
+func FindBrowser() (string, error) {
+	for _, name := range browserCandidates {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no Chromium-based browser found on PATH (tried %s); pass --browser", strings.Join(browserCandidates, ", "))
+}
+
+// PrintPDF prints an HTML document to a PDF file with a headless
+// Chromium-based browser.
+func PrintPDF(ctx context.Context, browser string, document []byte, pdfPath string) error {
+	dir, err := os.MkdirTemp("", "autodoc-pdf-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	htmlPath := filepath.Join(dir, "document.html")
+	if err := os.WriteFile(htmlPath, document, 0o644); err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(pdfPath)
+	if err != nil {
+		return err
+	}
+	return run(ctx, browser,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--no-pdf-header-footer",
+		"--user-data-dir="+filepath.Join(dir, "profile"),
+		"--print-to-pdf="+abs,
+		"file://"+filepath.ToSlash(htmlPath),
+	)
+}
+
+// run runs a command, folding its output into the error when it fails.
+func run(ctx context.Context, name string, args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", filepath.Base(name), err, msg)
+		}
+		return fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package golden compares generated files against checked-in snapshots.
+//
+// Snapshots are compared semantically rather than byte for byte: timestamps
+// are masked, JSON is compared by value with sorted keys, and trailing
+// whitespace and blank lines are ignored, so formatting noise doesn't fail a
+// test. Run the tests with -update to rewrite the snapshots after an
+// intended change, then review the diff in git.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/linediff"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// timestampRe matches the date-and-time stamps generated pages carry, e.g.
+// "2026-01-02 15:04 UTC" or "2026-01-02T15:04:05.123Z". Bare dates are left
+// alone: they come from fixture data, not from the clock.
+var timestampRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}(:\d{2}(\.\d+)?)?( ?UTC|Z|[+-]\d{2}:?\d{2})?`)
+
+// Timestamp replaces timestamps in normalized output.
+const Timestamp = "<TIMESTAMP>"
+
+// Normalize returns the comparable form of a file's content. name selects
+// the normalization by extension.
+func Normalize(name string, data []byte) string {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.HasSuffix(name, ".json") {
+		var v any
+		if err := json.Unmarshal(data, &v); err == nil {
+			// Re-encoding sorts object keys and fixes the indentation.
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if enc.Encode(v) == nil {
+				s = buf.String()
+			}
+		}
+	}
+	s = timestampRe.ReplaceAllString(s, Timestamp)
+
+	// Drop trailing whitespace and blank lines. Indentation is kept: it is
+	// significant in markdown, and in <pre> blocks.
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Options controls which files CompareDir checks.
+type Options struct {
+	// Skip lists glob patterns, matched against slash-separated paths
+	// relative to the output directory, for files that are not snapshotted
+	// (e.g. static assets copied verbatim from a Go constant).
+	Skip []string
+}
+
+func (o Options) skip(rel string) bool {
+	for _, pattern := range o.Skip {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareDir checks every file under gotDir against the snapshot with the
+// same relative path under goldenDir, reporting changed, new, and missing
+// files as test errors. With -update it rewrites goldenDir instead.
+func CompareDir(t testing.TB, goldenDir, gotDir string, opts Options) {
+	t.Helper()
+	got, err := readDir(gotDir, opts)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+
+	if *update {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatalf("clearing golden files: %v", err)
+		}
+		for rel, content := range got {
+			path := filepath.Join(goldenDir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("writing golden files: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("writing golden files: %v", err)
+			}
+		}
+		t.Logf("updated %d golden files in %s", len(got), goldenDir)
+		return
+	}
+
+	want, err := readDir(goldenDir, opts)
+	if err != nil {
+		t.Fatalf("reading golden files (run with -update to create them): %v", err)
+	}
+	for _, rel := range sortedKeys(want) {
+		g, ok := got[rel]
+		switch {
+		case !ok:
+			t.Errorf("%s: no longer generated", rel)
+		case g != want[rel]:
+			t.Errorf("%s differs from golden file (-want +got):\n%s", rel, linediff.Diff(want[rel], g))
+		}
+	}
+	for _, rel := range sortedKeys(got) {
+		if _, ok := want[rel]; !ok {
+			t.Errorf("%s: generated but has no golden file", rel)
+		}
+	}
+	if t.Failed() {
+		t.Log("if the change is intended, rerun with -update and review the golden diff")
+	}
+}
+
+// readDir loads and normalizes every file under dir, keyed by its
+// slash-separated relative path.
+func readDir(dir string, opts Options) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if opts.skip(rel) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = Normalize(rel, data)
+		return nil
+	})
+	return files, err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
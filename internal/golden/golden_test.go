@@ -0,0 +1,38 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	got := Normalize("index.md", []byte("# Site  \r\n\r\n*Generated on 2026-10-16 09:30 UTC*\nBuilt 2026-10-16T09:30:12.5Z, released 2026-10-01.\n"))
+	want := "# Site\n*Generated on <TIMESTAMP>*\nBuilt <TIMESTAMP>, released 2026-10-01.\n"
+	if got != want {
+		t.Errorf("Normalize(md) = %q, want %q", got, want)
+	}
+
+	a := Normalize("index.json", []byte(`{"b":1,"a":"<x>"}`))
+	b := Normalize("index.json", []byte("{\n \"a\": \"<x>\",\n \"b\": 1\n}"))
+	if a != b || !strings.Contains(a, `"<x>"`) {
+		t.Errorf("JSON with different key order and indentation normalized differently:\n%s\n%s", a, b)
+	}
+}
+
+func TestCompareDir(t *testing.T) {
+	goldenDir, out := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(goldenDir, "index.html", "<h1>Site</h1>\n<p>As of <TIMESTAMP></p>\n")
+	write(out, "index.html", "<h1>Site</h1>\n\n<p>As of 2026-10-16 09:30 UTC</p>  \n")
+	write(out, "style.css", "body {}\n")
+
+	CompareDir(t, goldenDir, out, Options{Skip: []string{"*.css"}})
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -81,6 +82,29 @@ func ParseADRDirectory(dir string) ([]ADR, error) {
 	return adrs, nil
 }
 
+// LoadRepoADRs detects and parses the ADR directory under projectRoot.
+// File paths on the returned ADRs are relative to projectRoot. It returns
+// nil without error when the project has no ADR directory.
+func LoadRepoADRs(projectRoot string) ([]ADR, error) {
+	dir := DetectADRDirectory(projectRoot)
+	if dir == "" {
+		return nil, nil
+	}
+	adrs, err := ParseADRDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range adrs {
+		if rel, err := filepath.Rel(projectRoot, adrs[i].FilePath); err == nil {
+			adrs[i].FilePath = filepath.ToSlash(rel)
+		}
+	}
+	sort.SliceStable(adrs, func(i, j int) bool {
+		return adrs[i].Number < adrs[j].Number
+	})
+	return adrs, nil
+}
+
 func parseADRSections(content string) map[string]string {
 	sections := map[string]string{}
 	lines := strings.Split(content, "\n")
@@ -163,3 +187,34 @@ func normalizeStatus(s string) string {
 		return lower
 	}
 }
+
+// LinkADRs records which of the given services and flows each ADR mentions.
+// Matching is case-insensitive against the title, context, decision, and
+// consequences text.
+func LinkADRs(adrs []ADR, services, flowNames []string) {
+	for i := range adrs {
+		text := strings.ToLower(adrs[i].Title + "\n" + adrs[i].Context + "\n" + adrs[i].Decision + "\n" + adrs[i].Consequences)
+		adrs[i].RelatedServices = mentionedNames(text, services)
+		adrs[i].RelatedFlows = mentionedNames(text, flowNames)
+	}
+}
+
+// mentionedNames returns the candidates that appear in lowered text. Names
+// with dashes or underscores also match their space-separated form so that
+// "order-service" is found in "the order service".
+func mentionedNames(text string, candidates []string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, name := range candidates {
+		lower := strings.ToLower(strings.TrimSpace(name))
+		if lower == "" || seen[lower] {
+			continue
+		}
+		spaced := strings.NewReplacer("-", " ", "_", " ").Replace(lower)
+		if strings.Contains(text, lower) || strings.Contains(text, spaced) {
+			found = append(found, name)
+			seen[lower] = true
+		}
+	}
+	return found
+}
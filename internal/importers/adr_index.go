@@ -0,0 +1,71 @@
+package importers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// ADRDocuments converts parsed ADRs into vector store documents of type
+// DocTypeDecision. FilePath is kept as recorded on the ADR, so callers should
+// make it relative to the repo root before indexing.
+func ADRDocuments(adrs []ADR, repoID string) []vectordb.Document {
+	now := time.Now()
+	docs := make([]vectordb.Document, 0, len(adrs))
+	for _, adr := range adrs {
+		docs = append(docs, vectordb.Document{
+			ID:      adrDocumentID(adr, repoID),
+			Content: ADRContent(adr),
+			Metadata: vectordb.DocumentMetadata{
+				FilePath:    filepath.ToSlash(adr.FilePath),
+				Type:        vectordb.DocTypeDecision,
+				Symbol:      adr.Title,
+				RepoID:      repoID,
+				LastUpdated: now,
+			},
+		})
+	}
+	return docs
+}
+
+// ADRContent renders an ADR as plain text for embedding and display.
+func ADRContent(adr ADR) string {
+	var parts []string
+	if adr.Number > 0 {
+		parts = append(parts, fmt.Sprintf("Decision: ADR-%04d %s", adr.Number, adr.Title))
+	} else {
+		parts = append(parts, fmt.Sprintf("Decision: %s", adr.Title))
+	}
+	parts = append(parts, fmt.Sprintf("Status: %s", adr.Status))
+	if adr.Date != "" {
+		parts = append(parts, fmt.Sprintf("Date: %s", adr.Date))
+	}
+	if adr.Context != "" {
+		parts = append(parts, fmt.Sprintf("Context: %s", adr.Context))
+	}
+	if adr.Decision != "" {
+		parts = append(parts, fmt.Sprintf("Outcome: %s", adr.Decision))
+	}
+	if adr.Consequences != "" {
+		parts = append(parts, fmt.Sprintf("Consequences: %s", adr.Consequences))
+	}
+	if len(adr.RelatedServices) > 0 {
+		parts = append(parts, fmt.Sprintf("Related services: %s", strings.Join(adr.RelatedServices, ", ")))
+	}
+	if len(adr.RelatedFlows) > 0 {
+		parts = append(parts, fmt.Sprintf("Related flows: %s", strings.Join(adr.RelatedFlows, ", ")))
+	}
+	parts = append(parts, fmt.Sprintf("File: %s", filepath.ToSlash(adr.FilePath)))
+	return strings.Join(parts, "\n")
+}
+
+func adrDocumentID(adr ADR, repoID string) string {
+	id := "adr:" + filepath.ToSlash(adr.FilePath)
+	if repoID != "" {
+		id = "adr:" + repoID + ":" + filepath.ToSlash(adr.FilePath)
+	}
+	return id
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
 func setupTestStore(t *testing.T) *Store {
@@ -150,6 +153,61 @@ Proposed
 	}
 }
 
+func TestLinkADRs(t *testing.T) {
+	adrs := []ADR{
+		{Title: "Route checkout through the order service", Decision: "The payment-service is called only by order."},
+		{Title: "Adopt structured logging"},
+	}
+	LinkADRs(adrs, []string{"order-service", "payment-service", "user-service"}, []string{"Checkout"})
+
+	if got := strings.Join(adrs[0].RelatedServices, ","); got != "order-service,payment-service" {
+		t.Errorf("expected order-service and payment-service, got %q", got)
+	}
+	if len(adrs[0].RelatedFlows) != 1 || adrs[0].RelatedFlows[0] != "Checkout" {
+		t.Errorf("expected Checkout flow, got %v", adrs[0].RelatedFlows)
+	}
+	if len(adrs[1].RelatedServices) != 0 || len(adrs[1].RelatedFlows) != 0 {
+		t.Errorf("expected no links for unrelated ADR, got %v / %v", adrs[1].RelatedServices, adrs[1].RelatedFlows)
+	}
+}
+
+func TestLoadRepoADRs(t *testing.T) {
+	root := t.TempDir()
+	adrDir := filepath.Join(root, "docs", "adr")
+	if err := os.MkdirAll(adrDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(adrDir, "0002-use-kafka.md"), []byte("# Use Kafka\n\n## Status\n\nAccepted\n"), 0o644)
+	os.WriteFile(filepath.Join(adrDir, "0001-use-postgres.md"), []byte("# Use Postgres\n\n## Status\n\nAccepted\n"), 0o644)
+
+	adrs, err := LoadRepoADRs(root)
+	if err != nil {
+		t.Fatalf("LoadRepoADRs: %v", err)
+	}
+	if len(adrs) != 2 {
+		t.Fatalf("expected 2 ADRs, got %d", len(adrs))
+	}
+	if adrs[0].Number != 1 || adrs[0].FilePath != "docs/adr/0001-use-postgres.md" {
+		t.Errorf("expected sorted, root-relative ADRs, got %+v", adrs[0])
+	}
+
+	docs := ADRDocuments(adrs, "billing")
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	if docs[0].Metadata.Type != vectordb.DocTypeDecision || docs[0].Metadata.RepoID != "billing" {
+		t.Errorf("unexpected metadata: %+v", docs[0].Metadata)
+	}
+	if !strings.Contains(docs[0].Content, "ADR-0001 Use Postgres") {
+		t.Errorf("expected ADR label in content, got %q", docs[0].Content)
+	}
+
+	none, err := LoadRepoADRs(t.TempDir())
+	if err != nil || none != nil {
+		t.Errorf("expected nil, nil for repo without ADRs, got %v, %v", none, err)
+	}
+}
+
 // --- OpenAPI Parser Tests ---
 
 func TestParseOpenAPI_JSON(t *testing.T) {
@@ -210,16 +268,23 @@ paths:
       responses:
         "200":
           description: OK
+  /v1/health:
+    get:
+      deprecated: true
+      x-sunset: 2027-01-31
 `
 	endpoints, err := ParseOpenAPI(spec)
 	if err != nil {
 		t.Fatalf("ParseOpenAPI: %v", err)
 	}
-	if len(endpoints) != 1 {
-		t.Fatalf("expected 1 endpoint, got %d", len(endpoints))
+	if len(endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d", len(endpoints))
 	}
-	if endpoints[0].Summary != "Health check" {
-		t.Errorf("unexpected summary: %q", endpoints[0].Summary)
+	if endpoints[0].Summary != "Health check" || endpoints[0].Deprecated {
+		t.Errorf("unexpected first endpoint: %+v", endpoints[0])
+	}
+	if !endpoints[1].Deprecated || endpoints[1].Sunset != "2027-01-31" {
+		t.Errorf("expected the deprecated endpoint with its sunset, got %+v", endpoints[1])
 	}
 }
 
@@ -235,6 +300,165 @@ func TestFormatEndpointsMarkdown(t *testing.T) {
 	}
 }
 
+// --- Dependency Manifest Tests ---
+
+func TestLoadRepoDependencies(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": `module example.com/orders
+
+go 1.22
+
+require github.com/BurntSushi/toml v1.3.2
+
+require (
+	github.com/go-chi/chi/v5 v5.0.12
+	golang.org/x/text v0.14.0 // indirect
+)
+`,
+		"web/package.json":                    `{"dependencies": {"react": "^18.2.0"}, "devDependencies": {"jest": "29.7.0"}}`,
+		"web/node_modules/react/package.json": `{"name": "react", "license": "MIT"}`,
+		"ml/requirements.txt":                 "# pinned\nrequests==2.31.0\nNumpy>=1.24,<2 ; python_version >= '3.9'\n-r base.txt\n",
+		"ml/requirements-dev.txt":             "pytest\n",
+		"ml/pyproject.toml": `[project]
+name = "ml"
+dependencies = [
+  "pydantic[email]>=2",
+]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+fastapi = { version = "^0.110", extras = ["all"] }
+`,
+		"legacy/pom.xml": `<project>
+  <properties><log4j.version>2.14.1</log4j.version></properties>
+  <dependencies>
+    <dependency><groupId>org.apache.logging.log4j</groupId><artifactId>log4j-core</artifactId><version>${log4j.version}</version></dependency>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId><version>4.13.2</version><scope>test</scope></dependency>
+  </dependencies>
+</project>`,
+		"app/build.gradle.kts": `dependencies {
+    implementation("com.squareup.okhttp3:okhttp:4.12.0")
+    testImplementation("org.junit.jupiter:junit-jupiter:5.10.0")
+}`,
+		"rs/Cargo.toml": `[package]
+name = "rs"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+anyhow = "1"
+
+[dev-dependencies.criterion]
+version = "0.5"
+`,
+		"vendor/github.com/x/y/go.mod": "module github.com/x/y\n\nrequire github.com/z/z v1.0.0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	licenseDir := filepath.Join(modCache, "github.com", "!burnt!sushi", "toml@v1.3.2")
+	os.MkdirAll(licenseDir, 0o755)
+	os.WriteFile(filepath.Join(licenseDir, "COPYING"), []byte("The MIT License (MIT)\n\nPermission is hereby granted, free of charge, ..."), 0o644)
+
+	deps, err := LoadRepoDependencies(root)
+	if err != nil {
+		t.Fatalf("LoadRepoDependencies: %v", err)
+	}
+	got := make(map[string]Dependency)
+	for _, d := range deps {
+		got[d.Ecosystem+" "+d.Name] = d
+	}
+	want := []Dependency{
+		{Name: "github.com/BurntSushi/toml", Version: "v1.3.2", Ecosystem: "Go", Manifest: "go.mod", License: "MIT"},
+		{Name: "golang.org/x/text", Version: "v0.14.0", Ecosystem: "Go", Manifest: "go.mod"},
+		{Name: "react", Version: "^18.2.0", Ecosystem: "npm", Manifest: "web/package.json", License: "MIT"},
+		{Name: "jest", Version: "29.7.0", Ecosystem: "npm", Manifest: "web/package.json", Dev: true},
+		{Name: "requests", Version: "2.31.0", Ecosystem: "PyPI", Manifest: "ml/requirements.txt"},
+		{Name: "numpy", Version: ">=1.24,<2", Ecosystem: "PyPI", Manifest: "ml/requirements.txt"},
+		{Name: "pytest", Ecosystem: "PyPI", Manifest: "ml/requirements-dev.txt", Dev: true},
+		{Name: "pydantic", Version: ">=2", Ecosystem: "PyPI", Manifest: "ml/pyproject.toml"},
+		{Name: "fastapi", Version: "^0.110", Ecosystem: "PyPI", Manifest: "ml/pyproject.toml"},
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Ecosystem: "Maven", Manifest: "legacy/pom.xml"},
+		{Name: "junit:junit", Version: "4.13.2", Ecosystem: "Maven", Manifest: "legacy/pom.xml", Dev: true},
+		{Name: "com.squareup.okhttp3:okhttp", Version: "4.12.0", Ecosystem: "Maven", Manifest: "app/build.gradle.kts"},
+		{Name: "org.junit.jupiter:junit-jupiter", Version: "5.10.0", Ecosystem: "Maven", Manifest: "app/build.gradle.kts", Dev: true},
+		{Name: "serde", Version: "1.0", Ecosystem: "Cargo", Manifest: "rs/Cargo.toml"},
+		{Name: "criterion", Version: "0.5", Ecosystem: "Cargo", Manifest: "rs/Cargo.toml", Dev: true},
+	}
+	for _, w := range want {
+		if got[w.Ecosystem+" "+w.Name] != w {
+			t.Errorf("got %+v, want %+v", got[w.Ecosystem+" "+w.Name], w)
+		}
+	}
+	if len(deps) != 17 {
+		t.Errorf("expected 17 dependencies, got %d: %+v", len(deps), deps)
+	}
+	if _, ok := got["PyPI python"]; ok {
+		t.Error("the Python version constraint is not a dependency")
+	}
+	if _, ok := got["Go github.com/z/z"]; ok {
+		t.Error("vendored manifests should not be read")
+	}
+	if deps[0].Ecosystem != "Cargo" || deps[len(deps)-1].Ecosystem != "npm" {
+		t.Errorf("expected dependencies sorted by ecosystem, got %s first and %s last", deps[0].Ecosystem, deps[len(deps)-1].Ecosystem)
+	}
+}
+
+func TestLoadRepoModules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                      "module github.com/acme/orders // the service\n\ngo 1.22\n",
+		"web/package.json":            `{"name": "@acme/orders-web", "dependencies": {"react": "^18.2.0"}}`,
+		"ml/pyproject.toml":           "[project]\nname = \"Orders_ML\"\ndependencies = []\n",
+		"lib/pom.xml":                 `<project><parent><groupId>com.acme</groupId></parent><artifactId>orders-client</artifactId></project>`,
+		"rs/Cargo.toml":               "[package]\nname = \"orders-rs\"\n\n[dependencies]\nserde = \"1\"\n",
+		"tools/package.json":          `{"private": true}`,
+		"node_modules/x/package.json": `{"name": "x"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	modules, err := LoadRepoModules(root)
+	if err != nil {
+		t.Fatalf("LoadRepoModules: %v", err)
+	}
+	want := []Module{
+		{Name: "orders-rs", Ecosystem: "Cargo", Manifest: "rs/Cargo.toml"},
+		{Name: "github.com/acme/orders", Ecosystem: "Go", Manifest: "go.mod"},
+		{Name: "com.acme:orders-client", Ecosystem: "Maven", Manifest: "lib/pom.xml"},
+		{Name: "orders-ml", Ecosystem: "PyPI", Manifest: "ml/pyproject.toml"},
+		{Name: "@acme/orders-web", Ecosystem: "npm", Manifest: "web/package.json"},
+	}
+	if len(modules) != len(want) {
+		t.Fatalf("expected %d modules, got %+v", len(want), modules)
+	}
+	for i, w := range want {
+		if modules[i] != w {
+			t.Errorf("module %d: got %+v, want %+v", i, modules[i], w)
+		}
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	for text, want := range map[string]string{
+		"Apache License\nVersion 2.0, January 2004":                              "Apache-2.0",
+		"Redistribution and use in source and binary forms ... Neither the name": "BSD-3-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999":          "LGPL-2.1",
+		"All rights reserved.": "",
+	} {
+		if got := DetectLicense(text); got != want {
+			t.Errorf("DetectLicense(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
 // --- HTML to Plain Text ---
 
 func TestHtmlToPlainText(t *testing.T) {
@@ -0,0 +1,641 @@
+package importers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// manifestParsers parse package manifests by file name; requirements files
+// also match by pattern, see manifestParser.
+var manifestParsers = map[string]func(content []byte) []Dependency{
+	"go.mod":           parseGoMod,
+	"package.json":     parsePackageJSON,
+	"pyproject.toml":   parsePyproject,
+	"pom.xml":          parsePom,
+	"build.gradle":     parseGradle,
+	"build.gradle.kts": parseGradle,
+	"Cargo.toml":       parseCargo,
+}
+
+// manifestParser returns the parser for a manifest's file name, or nil.
+func manifestParser(name string) func(content []byte) []Dependency {
+	if p, ok := manifestParsers[name]; ok {
+		return p
+	}
+	if strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt") {
+		dev := strings.Contains(name, "dev") || strings.Contains(name, "test")
+		return func(content []byte) []Dependency { return parseRequirements(content, dev) }
+	}
+	return nil
+}
+
+// importEcosystems maps a source language to the ecosystem its imports are
+// published in.
+var importEcosystems = map[string]string{
+	"Go":         "Go",
+	"JavaScript": "npm",
+	"TypeScript": "npm",
+	"Python":     "PyPI",
+	"Rust":       "Cargo",
+}
+
+// ImportEcosystem returns the ecosystem the imports of source in language
+// are published in, as Dependency.Ecosystem names it, or "" if unknown.
+func ImportEcosystem(language string) string {
+	return importEcosystems[language]
+}
+
+// LoadRepoDependencies finds the package manifests under projectRoot and
+// returns the third-party libraries they declare, sorted by ecosystem and
+// name. Manifest paths are relative to projectRoot. Directories the walker
+// always skips, such as node_modules and vendor, are not searched.
+func LoadRepoDependencies(projectRoot string) ([]Dependency, error) {
+	var deps []Dependency
+	err := filepath.WalkDir(projectRoot, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != projectRoot && isSkippedDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parse := manifestParser(d.Name())
+		if parse == nil {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(projectRoot, p)
+		if err != nil {
+			return nil
+		}
+		for _, dep := range parse(content) {
+			dep.Manifest = filepath.ToSlash(rel)
+			deps = append(deps, dep)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	addLicenses(projectRoot, deps)
+	sort.SliceStable(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Manifest < b.Manifest
+	})
+	return deps, nil
+}
+
+func isSkippedDir(name string) bool {
+	for _, excl := range walker.DefaultExcludes {
+		if strings.EqualFold(name, excl) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseGoMod(content []byte) []Dependency {
+	var deps []Dependency
+	inRequire := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		case !inRequire:
+			continue
+		}
+		if len(fields) >= 2 {
+			deps = append(deps, Dependency{Name: fields[0], Version: fields[1], Ecosystem: "Go"})
+		}
+	}
+	return deps
+}
+
+func parsePackageJSON(content []byte) []Dependency {
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil
+	}
+	var deps []Dependency
+	for _, group := range []struct {
+		deps map[string]string
+		dev  bool
+	}{{pkg.Dependencies, false}, {pkg.OptionalDependencies, false}, {pkg.DevDependencies, true}} {
+		for name, version := range group.deps {
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm", Dev: group.dev})
+		}
+	}
+	return deps
+}
+
+// pythonRequirement matches a PEP 508 requirement: a name, optional
+// extras, and an optional version specifier.
+var pythonRequirement = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(\(?[=<>!~][^;#]*)?`)
+
+// parsePythonRequirement parses one requirement such as "requests>=2.28".
+// An exact pin "==1.2.3" is recorded as 1.2.3.
+func parsePythonRequirement(req string, dev bool) (Dependency, bool) {
+	m := pythonRequirement.FindStringSubmatch(strings.TrimSpace(req))
+	if m == nil {
+		return Dependency{}, false
+	}
+	name := strings.ReplaceAll(strings.ToLower(m[1]), "_", "-")
+	version := strings.Trim(strings.TrimSpace(m[2]), "()")
+	if v, ok := strings.CutPrefix(version, "=="); ok && !strings.ContainsAny(v, ",*") {
+		version = strings.TrimSpace(v)
+	}
+	return Dependency{Name: name, Version: version, Ecosystem: "PyPI", Dev: dev}, true
+}
+
+func parseRequirements(content []byte, dev bool) []Dependency {
+	var deps []Dependency
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if dep, ok := parsePythonRequirement(line, dev); ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+var (
+	tomlVersion = regexp.MustCompile(`version\s*=\s*["']([^"']*)["']`)
+	tomlQuoted  = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+)
+
+// tomlSections splits a TOML file into its tables' lines, by table name.
+// It understands enough TOML for manifests: tables, keys, and arrays of
+// strings that span lines.
+func tomlSections(content []byte) map[string][]string {
+	sections := make(map[string][]string)
+	section := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		sections[section] = append(sections[section], line)
+	}
+	return sections
+}
+
+// tomlKey splits a "key = value" line, unquoting the key.
+func tomlKey(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.Trim(strings.TrimSpace(key), `"'`), strings.TrimSpace(value), true
+}
+
+// tomlString returns a TOML value's string: the value itself when it is a
+// string, or its version key when it is an inline table.
+func tomlString(value string) string {
+	if strings.HasPrefix(value, "{") {
+		m := tomlVersion.FindStringSubmatch(value)
+		if m == nil {
+			return ""
+		}
+		return m[1]
+	}
+	value, _, _ = strings.Cut(value, "#")
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// tomlStrings returns the strings in the array a key's lines hold.
+func tomlStrings(lines []string) []string {
+	var out []string
+	for _, m := range tomlQuoted.FindAllStringSubmatch(strings.Join(lines, "\n"), -1) {
+		out = append(out, m[1]+m[2])
+	}
+	return out
+}
+
+// tomlArray returns the lines of the array assigned to key in lines.
+func tomlArray(lines []string, key string) []string {
+	for i, line := range lines {
+		k, v, ok := tomlKey(line)
+		if !ok || k != key || !strings.HasPrefix(v, "[") {
+			continue
+		}
+		array := []string{v}
+		for j := i + 1; j < len(lines) && !strings.Contains(array[len(array)-1], "]"); j++ {
+			array = append(array, lines[j])
+		}
+		return array
+	}
+	return nil
+}
+
+func parsePyproject(content []byte) []Dependency {
+	sections := tomlSections(content)
+	var deps []Dependency
+	add := func(reqs []string, dev bool) {
+		for _, req := range reqs {
+			if dep, ok := parsePythonRequirement(req, dev); ok {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	// PEP 621.
+	add(tomlStrings(tomlArray(sections["project"], "dependencies")), false)
+	for _, line := range sections["project.optional-dependencies"] {
+		if k, _, ok := tomlKey(line); ok {
+			add(tomlStrings(tomlArray(sections["project.optional-dependencies"], k)), true)
+		}
+	}
+	// Poetry.
+	for name, lines := range sections {
+		dev := name == "tool.poetry.dev-dependencies" || (strings.HasPrefix(name, "tool.poetry.group.") && strings.HasSuffix(name, ".dependencies"))
+		if name != "tool.poetry.dependencies" && !dev {
+			continue
+		}
+		for _, line := range lines {
+			k, v, ok := tomlKey(line)
+			if !ok || strings.EqualFold(k, "python") {
+				continue
+			}
+			deps = append(deps, Dependency{Name: strings.ToLower(k), Version: tomlString(v), Ecosystem: "PyPI", Dev: dev})
+		}
+	}
+	return deps
+}
+
+func parseCargo(content []byte) []Dependency {
+	var deps []Dependency
+	for name, lines := range tomlSections(content) {
+		table := name
+		if strings.HasPrefix(name, "target.") {
+			table = name[strings.LastIndex(name, ".")+1:]
+		}
+		var dev bool
+		switch table {
+		case "dependencies", "workspace.dependencies":
+		case "dev-dependencies", "build-dependencies":
+			dev = true
+		default:
+			// [dependencies.name] declares one dependency as a table.
+			for _, prefix := range []string{"dependencies.", "dev-dependencies.", "build-dependencies."} {
+				if dep, ok := strings.CutPrefix(name, prefix); ok {
+					deps = append(deps, Dependency{Name: dep, Version: tomlString("{" + strings.Join(lines, ", ") + "}"), Ecosystem: "Cargo", Dev: prefix != "dependencies."})
+				}
+			}
+			continue
+		}
+		for _, line := range lines {
+			if k, v, ok := tomlKey(line); ok {
+				deps = append(deps, Dependency{Name: k, Version: tomlString(v), Ecosystem: "Cargo", Dev: dev})
+			}
+		}
+	}
+	return deps
+}
+
+// pomProperty matches a ${property} reference in a pom.xml value.
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+func parsePom(content []byte) []Dependency {
+	var pom struct {
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Managed      []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+		Dependencies []pomDependency `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil {
+		return nil
+	}
+	props := make(map[string]string)
+	for _, e := range pom.Properties.Entries {
+		props[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+	resolve := func(s string) string {
+		return pomProperty.ReplaceAllStringFunc(s, func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+	managed := make(map[string]string)
+	for _, d := range pom.Managed {
+		managed[d.GroupID+":"+d.ArtifactID] = d.Version
+	}
+	var deps []Dependency
+	for _, d := range pom.Dependencies {
+		name := strings.TrimSpace(d.GroupID) + ":" + strings.TrimSpace(d.ArtifactID)
+		version := d.Version
+		if version == "" {
+			version = managed[name]
+		}
+		deps = append(deps, Dependency{Name: name, Version: resolve(strings.TrimSpace(version)), Ecosystem: "Maven", Dev: d.Scope == "test"})
+	}
+	return deps
+}
+
+// gradleDependency matches a dependency declared as "group:artifact:version"
+// in a configuration such as implementation or testImplementation. Gradle
+// resolves these from Maven repositories, so they share Maven's ecosystem.
+var gradleDependency = regexp.MustCompile(`\b(\w+)\s*\(?\s*["']([^"':\s]+):([^"':\s]+)(?::([^"'@\s]+))?(?:@\w+)?["']`)
+
+func parseGradle(content []byte) []Dependency {
+	var deps []Dependency
+	for _, m := range gradleDependency.FindAllStringSubmatch(string(content), -1) {
+		config := m[1]
+		switch {
+		case strings.HasSuffix(config, "mplementation"), strings.HasSuffix(config, "Only"), config == "api",
+			config == "compile", config == "runtime", config == "annotationProcessor", config == "kapt":
+		default:
+			continue
+		}
+		dev := strings.HasPrefix(config, "test") || strings.HasPrefix(config, "androidTest")
+		deps = append(deps, Dependency{Name: m[2] + ":" + m[3], Version: m[4], Ecosystem: "Maven", Dev: dev})
+	}
+	return deps
+}
+
+// moduleParsers read the name of the package a manifest publishes, by the
+// manifest's file name; they return "" when it names none.
+var moduleParsers = map[string]struct {
+	ecosystem string
+	parse     func(content []byte) string
+}{
+	"go.mod":         {"Go", goModModule},
+	"package.json":   {"npm", packageJSONName},
+	"pyproject.toml": {"PyPI", pyprojectName},
+	"pom.xml":        {"Maven", pomName},
+	"Cargo.toml":     {"Cargo", cargoName},
+}
+
+// LoadRepoModules finds the package manifests under projectRoot and returns
+// the packages they publish, so other repos' dependencies on them can be
+// told apart from third-party libraries. Manifest paths are relative to
+// projectRoot, and the same directories as LoadRepoDependencies skips are
+// not searched.
+func LoadRepoModules(projectRoot string) ([]Module, error) {
+	var modules []Module
+	err := filepath.WalkDir(projectRoot, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != projectRoot && isSkippedDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parser, ok := moduleParsers[d.Name()]
+		if !ok {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(projectRoot, p)
+		if err != nil {
+			return nil
+		}
+		if name := parser.parse(content); name != "" {
+			modules = append(modules, Module{Name: name, Ecosystem: parser.ecosystem, Manifest: filepath.ToSlash(rel)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(modules, func(i, j int) bool {
+		if modules[i].Ecosystem != modules[j].Ecosystem {
+			return modules[i].Ecosystem < modules[j].Ecosystem
+		}
+		return modules[i].Name < modules[j].Name
+	})
+	return modules, nil
+}
+
+func goModModule(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+func packageJSONName(content []byte) string {
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Name
+}
+
+func pyprojectName(content []byte) string {
+	sections := tomlSections(content)
+	for _, table := range []string{"project", "tool.poetry"} {
+		for _, line := range sections[table] {
+			if k, v, ok := tomlKey(line); ok && k == "name" {
+				// Normalized as requirements are, see parsePythonRequirement.
+				return strings.ReplaceAll(strings.ToLower(tomlString(v)), "_", "-")
+			}
+		}
+	}
+	return ""
+}
+
+func pomName(content []byte) string {
+	var pom struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Parent     struct {
+			GroupID string `xml:"groupId"`
+		} `xml:"parent"`
+	}
+	if err := xml.Unmarshal(content, &pom); err != nil || strings.TrimSpace(pom.ArtifactID) == "" {
+		return ""
+	}
+	// A module without a groupId inherits its parent's.
+	group := strings.TrimSpace(pom.GroupID)
+	if group == "" {
+		group = strings.TrimSpace(pom.Parent.GroupID)
+	}
+	return group + ":" + strings.TrimSpace(pom.ArtifactID)
+}
+
+func cargoName(content []byte) string {
+	for _, line := range tomlSections(content)["package"] {
+		if k, v, ok := tomlKey(line); ok && k == "name" {
+			return tomlString(v)
+		}
+	}
+	return ""
+}
+
+// addLicenses fills in the licenses it can find on disk: installed npm
+// packages' package.json, and Go modules in the module cache.
+func addLicenses(projectRoot string, deps []Dependency) {
+	modCache := goModCache()
+	for i, d := range deps {
+		switch d.Ecosystem {
+		case "npm":
+			pkg := filepath.Join(projectRoot, filepath.FromSlash(path.Dir(d.Manifest)), "node_modules", filepath.FromSlash(d.Name), "package.json")
+			deps[i].License = npmLicense(pkg)
+		case "Go":
+			if modCache != "" {
+				deps[i].License = dirLicense(filepath.Join(modCache, escapeModulePath(d.Name)+"@"+d.Version))
+			}
+		}
+	}
+}
+
+func npmLicense(packageJSON string) string {
+	content, err := os.ReadFile(packageJSON)
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		License json.RawMessage `json:"license"`
+	}
+	if json.Unmarshal(content, &pkg) != nil || len(pkg.License) == 0 {
+		return ""
+	}
+	var license string
+	if json.Unmarshal(pkg.License, &license) == nil {
+		return license
+	}
+	var typed struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(pkg.License, &typed) == nil {
+		return typed.Type
+	}
+	return ""
+}
+
+// goModCache is where the go command keeps downloaded modules.
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// escapeModulePath escapes a module path as the module cache does: each
+// upper-case letter becomes "!" and its lower-case form.
+func escapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return filepath.FromSlash(b.String())
+}
+
+// dirLicense identifies the license file in dir, if there is one.
+func dirLicense(dir string) string {
+	for _, name := range []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "COPYING", "license"} {
+		if content, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			return DetectLicense(string(content))
+		}
+	}
+	return ""
+}
+
+// DetectLicense names the license a license file's text grants, as an SPDX
+// identifier, or returns "" when it doesn't recognize it.
+func DetectLicense(text string) string {
+	has := func(s string) bool { return strings.Contains(text, s) }
+	switch {
+	case has("Apache License") && has("Version 2.0"):
+		return "Apache-2.0"
+	case has("GNU AFFERO GENERAL PUBLIC LICENSE"):
+		return "AGPL-3.0"
+	case has("GNU LESSER GENERAL PUBLIC LICENSE"):
+		if has("Version 2.1") {
+			return "LGPL-2.1"
+		}
+		return "LGPL-3.0"
+	case has("GNU GENERAL PUBLIC LICENSE"):
+		if has("Version 2") {
+			return "GPL-2.0"
+		}
+		return "GPL-3.0"
+	case has("Mozilla Public License") && has("2.0"):
+		return "MPL-2.0"
+	case has("Permission is hereby granted, free of charge"):
+		return "MIT"
+	case has("Permission to use, copy, modify, and/or distribute"):
+		return "ISC"
+	case has("Redistribution and use in source and binary forms"):
+		if has("Neither the name") || has("names of its") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case has("This is free and unencumbered software"):
+		return "Unlicense"
+	}
+	return ""
+}
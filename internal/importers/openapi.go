@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -58,6 +59,15 @@ func ParseOpenAPI(content string) ([]OpenAPIEndpoint, error) {
 			if d, ok := op["description"].(string); ok {
 				ep.Description = d
 			}
+			if d, ok := op["deprecated"].(bool); ok {
+				ep.Deprecated = d
+			}
+			switch sunset := op["x-sunset"].(type) {
+			case string:
+				ep.Sunset = sunset
+			case time.Time: // YAML reads an unquoted date as a timestamp
+				ep.Sunset = sunset.Format(time.DateOnly)
+			}
 
 			// Extract parameters.
 			if params, ok := op["parameters"].([]interface{}); ok {
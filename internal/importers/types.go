@@ -62,6 +62,29 @@ type ADR struct {
 	Consequences string `json:"consequences"`
 	Date         string `json:"date,omitempty"`
 	FilePath     string `json:"file_path"`
+	// RelatedServices and RelatedFlows are filled in by LinkADRs with the
+	// service and flow names the ADR text mentions.
+	RelatedServices []string `json:"related_services,omitempty"`
+	RelatedFlows    []string `json:"related_flows,omitempty"`
+}
+
+// Dependency is a third-party library a repo declares in a package
+// manifest such as go.mod or package.json.
+type Dependency struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"` // as declared; may be a range such as ^1.2.0
+	Ecosystem string `json:"ecosystem"`         // Go, npm, PyPI, Maven, or Cargo
+	Manifest  string `json:"manifest"`          // path of the manifest, relative to the repo root
+	Dev       bool   `json:"dev,omitempty"`     // needed only to build or test
+	License   string `json:"license,omitempty"` // SPDX identifier, when it can be found locally
+}
+
+// Module is a package a repo publishes, named in one of its package
+// manifests, such as the module path in go.mod or the name in package.json.
+type Module struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"` // Go, npm, PyPI, Maven, or Cargo
+	Manifest  string `json:"manifest"`  // path of the manifest, relative to the repo root
 }
 
 // OpenAPIEndpoint represents a parsed endpoint from an OpenAPI spec.
@@ -73,4 +96,8 @@ type OpenAPIEndpoint struct {
 	Parameters  []string          `json:"parameters,omitempty"`
 	RequestBody string            `json:"request_body,omitempty"`
 	Responses   map[string]string `json:"responses,omitempty"`
+	// Deprecated is the operation's deprecated flag, and Sunset its
+	// x-sunset extension, the date it is due to be removed.
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Sunset     string `json:"sunset,omitempty"`
 }
@@ -0,0 +1,543 @@
+// Package incident assembles what a responder needs when a service or
+// endpoint starts failing: who is affected upstream, who owns it and is on
+// call, what changed recently, which flows it takes part in, and where its
+// runbooks are. The same bundle backs `autodoc incident`, the MCP
+// get_incident_bundle tool, and the Slack "incident" command.
+package incident
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/libraries"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// RunbookFactKey is the context-engine fact, scoped to a service, that holds
+// a runbook URL or path. A service can have several.
+const RunbookFactKey = "runbook"
+
+// Defaults for a Builder's zero values.
+const (
+	DefaultChangeDays = 7
+	DefaultMaxDepth   = 3
+	maxCommits        = 10
+	maxRunbookFiles   = 10
+)
+
+// Bundle is the incident context for one service.
+type Bundle struct {
+	Service   string    `json:"service"`
+	Endpoint  string    `json:"endpoint,omitempty"`
+	Generated time.Time `json:"generated"`
+	Summary   string    `json:"summary,omitempty"`
+	// BlastRadius lists the services that call the failing one, directly
+	// or through others, nearest first.
+	BlastRadius []Impact `json:"blast_radius"`
+	// Dependencies are the services the failing one calls; one of them may
+	// be the real cause.
+	Dependencies []registry.ServiceLink `json:"dependencies"`
+	Owners       []Owner                `json:"owners"`
+	OnCall       []oncall.Lookup        `json:"oncall"`
+	Changes      []registry.ArchChange  `json:"changes"`
+	Commits      []Commit               `json:"commits"`
+	Flows        []FlowRef              `json:"flows"`
+	Runbooks     []string               `json:"runbooks"`
+	Since        time.Time              `json:"since"`
+	// Warnings name the parts of the bundle that could not be loaded.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Impact is one service in the blast radius.
+type Impact struct {
+	Service   string   `json:"service"`
+	Depth     int      `json:"depth"` // 1 calls the failing service directly
+	Via       string   `json:"via"`   // the service it calls on the way
+	LinkType  string   `json:"link_type"`
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// Relation says how the impacted service reaches Via: it "calls" it, or
+// "depends on" it when Via is a shared library, so a change to it
+// rebuilds and redeploys the service.
+func (imp Impact) Relation() string {
+	if imp.LinkType == libraries.LinkType {
+		return "depends on"
+	}
+	return "calls"
+}
+
+// Owner is a team that owns the service.
+type Owner struct {
+	Team         string `json:"team"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Confidence   string `json:"confidence,omitempty"`
+}
+
+// Commit is a recent commit touching the service.
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	URL     string    `json:"url,omitempty"`
+}
+
+// FlowRef is a flow the service takes part in.
+type FlowRef struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Services    []string `json:"services"`
+}
+
+// LogFunc lists the commits made under dir since a time, newest first,
+// limited to the paths given (all of dir when there are none).
+type LogFunc func(ctx context.Context, dir string, paths []string, since time.Time, limit int) ([]Commit, error)
+
+// Builder assembles bundles from the central database. Only Repos is
+// required; the other sources are skipped when nil.
+type Builder struct {
+	Repos  *registry.Store
+	Flows  *flows.Store
+	Org    *orgstructure.Store
+	OnCall *oncall.Resolver
+	Facts  contextengine.FactSource
+
+	// Runbooks maps service names to runbook URLs or paths.
+	Runbooks map[string][]string
+	// ChangeDays is how far back changes count as recent; 0 means
+	// DefaultChangeDays.
+	ChangeDays int
+	// MaxDepth bounds how many hops of callers the blast radius follows; 0
+	// means DefaultMaxDepth.
+	MaxDepth int
+	// Log lists recent commits; defaults to GitLog.
+	Log LogFunc
+
+	now func() time.Time
+}
+
+// NewBuilder returns a builder reading the central database, with runbooks
+// and the change window from cfg.
+func NewBuilder(cfg config.IncidentConfig, database *db.DB, onCall *oncall.Resolver, facts contextengine.FactSource) *Builder {
+	return &Builder{
+		Repos:      registry.NewStore(database),
+		Flows:      flows.NewStore(database),
+		Org:        orgstructure.NewStore(database),
+		OnCall:     onCall,
+		Facts:      facts,
+		Runbooks:   cfg.Runbooks,
+		ChangeDays: cfg.ChangeDays,
+	}
+}
+
+// Build assembles the bundle for target, which names a registered service
+// or, failing that, an endpoint one service serves, such as
+// "POST /api/orders". A non-empty endpoint narrows the blast radius to the
+// callers of that endpoint.
+func (b *Builder) Build(ctx context.Context, target, endpoint string) (*Bundle, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, fmt.Errorf("name a service or endpoint")
+	}
+	repos, err := b.Repos.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+	links, err := b.Repos.GetLinks(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("loading links: %w", err)
+	}
+
+	var repo *registry.Repository
+	for i := range repos {
+		if strings.EqualFold(repos[i].Name, target) {
+			repo = &repos[i]
+			break
+		}
+	}
+	service := target
+	if repo != nil {
+		service = repo.Name
+	} else if endpoint == "" {
+		if service, err = serviceForEndpoint(links, target); err != nil {
+			return nil, err
+		}
+		endpoint = target
+		for i := range repos {
+			if repos[i].Name == service {
+				repo = &repos[i]
+			}
+		}
+	}
+	if repo == nil && !linked(links, service) {
+		return nil, fmt.Errorf("no registered service or called endpoint matches %q", target)
+	}
+
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	days := b.ChangeDays
+	if days == 0 {
+		days = DefaultChangeDays
+	}
+	depth := b.MaxDepth
+	if depth == 0 {
+		depth = DefaultMaxDepth
+	}
+	bundle := &Bundle{
+		Service:     service,
+		Endpoint:    endpoint,
+		Generated:   now().UTC(),
+		Since:       now().UTC().AddDate(0, 0, -days),
+		BlastRadius: BlastRadius(links, service, endpoint, depth),
+	}
+	if repo != nil {
+		bundle.Summary = repo.Summary
+	}
+	for _, l := range links {
+		if strings.EqualFold(l.FromRepo, service) {
+			bundle.Dependencies = append(bundle.Dependencies, l)
+		}
+	}
+
+	b.addOwners(ctx, bundle, repo)
+	b.addOnCall(ctx, bundle)
+	b.addChanges(ctx, bundle, repo)
+	b.addFlows(ctx, bundle)
+	b.addRunbooks(ctx, bundle, repo)
+	return bundle, nil
+}
+
+// BlastRadius follows links backwards from service to the services that
+// call it, up to maxDepth hops, nearest first. With an endpoint, only direct
+// callers of that endpoint start the walk.
+func BlastRadius(links []registry.ServiceLink, service, endpoint string, maxDepth int) []Impact {
+	seen := map[string]bool{strings.ToLower(service): true}
+	frontier := []string{service}
+	var impacts []Impact
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		var next []Impact
+		for _, svc := range frontier {
+			for _, l := range links {
+				if !strings.EqualFold(l.ToRepo, svc) || seen[strings.ToLower(l.FromRepo)] {
+					continue
+				}
+				endpoints := l.Endpoints
+				if depth == 1 && endpoint != "" {
+					endpoints = matchingEndpoints(l.Endpoints, endpoint)
+					if len(endpoints) == 0 {
+						continue
+					}
+				}
+				seen[strings.ToLower(l.FromRepo)] = true
+				next = append(next, Impact{Service: l.FromRepo, Depth: depth, Via: svc, LinkType: l.LinkType, Endpoints: endpoints})
+			}
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i].Service < next[j].Service })
+		frontier = frontier[:0]
+		for _, imp := range next {
+			frontier = append(frontier, imp.Service)
+		}
+		impacts = append(impacts, next...)
+	}
+	return impacts
+}
+
+// serviceForEndpoint finds the one service whose callers use endpoint.
+func serviceForEndpoint(links []registry.ServiceLink, endpoint string) (string, error) {
+	found := make(map[string]bool)
+	for _, l := range links {
+		if len(matchingEndpoints(l.Endpoints, endpoint)) > 0 {
+			found[l.ToRepo] = true
+		}
+	}
+	services := make([]string, 0, len(found))
+	for s := range found {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+	switch len(services) {
+	case 0:
+		return "", fmt.Errorf("no registered service or called endpoint matches %q", endpoint)
+	case 1:
+		return services[0], nil
+	}
+	return "", fmt.Errorf("endpoint %q is served by several services (%s); name the service too", endpoint, strings.Join(services, ", "))
+}
+
+func linked(links []registry.ServiceLink, service string) bool {
+	for _, l := range links {
+		if strings.EqualFold(l.FromRepo, service) || strings.EqualFold(l.ToRepo, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingEndpoints returns the endpoints that match want. Want may omit the
+// method ("/api/orders" matches "POST /api/orders"), and path parameters
+// such as {id} or :id match any segment.
+func matchingEndpoints(endpoints []string, want string) []string {
+	var out []string
+	for _, e := range endpoints {
+		if endpointMatches(e, want) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func endpointMatches(endpoint, want string) bool {
+	method, path := splitEndpoint(endpoint)
+	wantMethod, wantPath := splitEndpoint(want)
+	if wantMethod != "" && method != "" && !strings.EqualFold(method, wantMethod) {
+		return false
+	}
+	a := strings.Split(strings.Trim(path, "/"), "/")
+	b := strings.Split(strings.Trim(wantPath, "/"), "/")
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if isParam(a[i]) || isParam(b[i]) {
+			continue
+		}
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitEndpoint(e string) (method, path string) {
+	e = strings.TrimSpace(e)
+	if m, p, ok := strings.Cut(e, " "); ok {
+		return m, strings.TrimSpace(p)
+	}
+	return "", e
+}
+
+func isParam(seg string) bool {
+	return strings.HasPrefix(seg, ":") || (strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"))
+}
+
+func (b *Builder) addOwners(ctx context.Context, bundle *Bundle, repo *registry.Repository) {
+	if b.Org == nil {
+		return
+	}
+	names := []string{bundle.Service}
+	if repo != nil && repo.Parent != "" {
+		names = append(names, repo.Parent) // fall back to the monorepo's owners
+	}
+	owners, err := FindOwners(ctx, b.Org, names...)
+	if err != nil {
+		bundle.Warnings = append(bundle.Warnings, fmt.Sprintf("ownership: %v", err))
+	}
+	bundle.Owners = owners
+}
+
+// FindOwners returns the teams owning the first of names that has owners,
+// with their contacts. Pass a sub-service and then its monorepo to fall
+// back to the monorepo's owners.
+func FindOwners(ctx context.Context, org *orgstructure.Store, names ...string) ([]Owner, error) {
+	for _, name := range names {
+		ownerships, err := org.GetOwnership(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		var owners []Owner
+		for _, o := range ownerships {
+			owner := Owner{Team: o.TeamID, Confidence: o.Confidence}
+			if team, err := org.GetTeam(ctx, o.TeamID); err == nil {
+				owner.Team = team.Name
+				if team.DisplayName != "" {
+					owner.Team = team.DisplayName
+				}
+				owner.SlackChannel = team.SlackChannel
+				owner.Email = team.Email
+			}
+			owners = append(owners, owner)
+		}
+		if len(owners) > 0 {
+			return owners, nil
+		}
+	}
+	return nil, nil
+}
+
+// addOnCall looks up who is on call for the service and for its direct
+// callers, whose owners may need to know too.
+func (b *Builder) addOnCall(ctx context.Context, bundle *Bundle) {
+	if b.OnCall == nil {
+		return
+	}
+	services := []string{bundle.Service}
+	for _, imp := range bundle.BlastRadius {
+		if imp.Depth == 1 {
+			services = append(services, imp.Service)
+		}
+	}
+	bundle.OnCall = b.OnCall.LookupAll(ctx, services)
+}
+
+func (b *Builder) addChanges(ctx context.Context, bundle *Bundle, repo *registry.Repository) {
+	changes, err := b.Repos.ListChanges(ctx, bundle.Since)
+	if err != nil {
+		bundle.Warnings = append(bundle.Warnings, fmt.Sprintf("architecture changes: %v", err))
+	}
+	for _, c := range changes {
+		if strings.EqualFold(c.Repo, bundle.Service) || strings.EqualFold(c.FromRepo, bundle.Service) || strings.EqualFold(c.ToRepo, bundle.Service) {
+			bundle.Changes = append(bundle.Changes, c)
+		}
+	}
+
+	if repo == nil || repo.LocalPath == "" {
+		return
+	}
+	dir, exclude := b.Repos.Scope(ctx, repo)
+	var paths []string
+	if dir != "" {
+		paths = append(paths, dir)
+	}
+	for _, ex := range exclude {
+		paths = append(paths, ":(exclude)"+ex)
+	}
+	log := b.Log
+	if log == nil {
+		log = GitLog
+	}
+	commits, err := log(ctx, repo.LocalPath, paths, bundle.Since, maxCommits)
+	if err != nil {
+		bundle.Warnings = append(bundle.Warnings, fmt.Sprintf("recent commits: %v", err))
+		return
+	}
+	for i := range commits {
+		commits[i].URL = registry.ArchChange{CommitSHA: commits[i].SHA, SourceURL: repo.SourceURL}.CommitURL()
+	}
+	bundle.Commits = commits
+}
+
+func (b *Builder) addFlows(ctx context.Context, bundle *Bundle) {
+	if b.Flows == nil {
+		return
+	}
+	all, err := b.Flows.ListFlows(ctx)
+	if err != nil {
+		bundle.Warnings = append(bundle.Warnings, fmt.Sprintf("flows: %v", err))
+		return
+	}
+	for _, f := range all {
+		for _, svc := range f.Services {
+			if strings.EqualFold(svc, bundle.Service) {
+				bundle.Flows = append(bundle.Flows, FlowRef{Name: f.Name, Description: f.Description, Services: f.Services})
+				break
+			}
+		}
+	}
+}
+
+// addRunbooks collects runbooks from config, context facts, and markdown
+// files in the service's checkout with "runbook" in their path.
+func (b *Builder) addRunbooks(ctx context.Context, bundle *Bundle, repo *registry.Repository) {
+	seen := make(map[string]bool)
+	add := func(rb string) {
+		rb = strings.TrimSpace(rb)
+		if rb != "" && !seen[rb] {
+			seen[rb] = true
+			bundle.Runbooks = append(bundle.Runbooks, rb)
+		}
+	}
+	for _, rb := range b.Runbooks[bundle.Service] {
+		add(rb)
+	}
+	if b.Facts != nil {
+		if facts, err := b.Facts.GetCurrentFacts(ctx, "", "service", bundle.Service); err == nil {
+			for _, f := range facts {
+				if f.Key == RunbookFactKey {
+					add(f.Value)
+				}
+			}
+		}
+	}
+	if repo != nil && repo.LocalPath != "" {
+		dir, exclude := b.Repos.Scope(ctx, repo)
+		for _, rb := range findRunbooks(repo.LocalPath, dir, exclude) {
+			add(rb)
+		}
+	}
+}
+
+// findRunbooks returns the markdown files under root/dir whose path mentions
+// "runbook", relative to root, skipping hidden, vendored, and excluded
+// directories.
+func findRunbooks(root, dir string, exclude []string) []string {
+	skip := make(map[string]bool)
+	for _, ex := range exclude {
+		skip[filepath.ToSlash(ex)] = true
+	}
+	var found []string
+	filepath.WalkDir(filepath.Join(root, dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			name := d.Name()
+			if rel != "." && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || skip[rel]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(rel), ".md") && strings.Contains(strings.ToLower(rel), "runbook") {
+			found = append(found, rel)
+			if len(found) == maxRunbookFiles {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// GitLog lists recent commits with git.
+func GitLog(ctx context.Context, dir string, paths []string, since time.Time, limit int) ([]Commit, error) {
+	args := []string{"-C", dir, "log", "--since=" + since.Format(time.RFC3339), "-n", strconv.Itoa(limit),
+		"--format=%H%x1f%an%x1f%aI%x1f%s"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var commits []Commit
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		parts := strings.SplitN(sc.Text(), "\x1f", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, parts[2])
+		commits = append(commits, Commit{SHA: parts[0], Author: parts[1], Date: date, Subject: parts[3]})
+	}
+	return commits, sc.Err()
+}
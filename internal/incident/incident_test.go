@@ -0,0 +1,190 @@
+package incident
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+var testLinks = []registry.ServiceLink{
+	{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders", "GET /api/orders/{id}"}},
+	{FromRepo: "admin", ToRepo: "orders", LinkType: "http", Endpoints: []string{"GET /api/orders/{id}"}},
+	{FromRepo: "web", ToRepo: "gateway", LinkType: "http"},
+	{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"},
+}
+
+func TestBlastRadius(t *testing.T) {
+	got := BlastRadius(testLinks, "orders", "", 3)
+	want := []string{"admin@1", "gateway@1", "web@2"}
+	if len(got) != len(want) {
+		t.Fatalf("BlastRadius = %+v", got)
+	}
+	for i, imp := range got {
+		if name := imp.Service + "@" + string(rune('0'+imp.Depth)); name != want[i] {
+			t.Errorf("impact %d = %s, want %s", i, name, want[i])
+		}
+	}
+	if got[2].Via != "gateway" {
+		t.Errorf("web reaches orders via %q, want gateway", got[2].Via)
+	}
+
+	// Only the gateway calls the create endpoint.
+	got = BlastRadius(testLinks, "orders", "/api/orders", 3)
+	if len(got) != 2 || got[0].Service != "gateway" || got[1].Service != "web" {
+		t.Errorf("BlastRadius(/api/orders) = %+v", got)
+	}
+	if strings.Join(got[0].Endpoints, ",") != "POST /api/orders" {
+		t.Errorf("matched endpoints = %v", got[0].Endpoints)
+	}
+
+	if got := BlastRadius(testLinks, "orders", "", 1); len(got) != 2 {
+		t.Errorf("depth 1 = %+v", got)
+	}
+}
+
+func TestBlastRadiusOfLibrary(t *testing.T) {
+	links := append([]registry.ServiceLink{
+		{FromRepo: "orders", ToRepo: "github.com/acme/lib-auth", LinkType: "library", Reason: "depends on github.com/acme/lib-auth v1.4.0 (go.mod)"},
+		{FromRepo: "payments", ToRepo: "github.com/acme/lib-auth", LinkType: "library"},
+	}, testLinks...)
+	got := BlastRadius(links, "github.com/acme/lib-auth", "", 2)
+	want := []string{"orders@1", "payments@1", "admin@2", "gateway@2"}
+	if len(got) != len(want) {
+		t.Fatalf("BlastRadius = %+v", got)
+	}
+	for i, imp := range got {
+		if name := imp.Service + "@" + string(rune('0'+imp.Depth)); name != want[i] {
+			t.Errorf("impact %d = %s, want %s", i, name, want[i])
+		}
+	}
+	if d := impactDetail(got[0]); d != "depends on github.com/acme/lib-auth (library)" {
+		t.Errorf("library impact = %q", d)
+	}
+	if d := impactDetail(got[2]); d != "calls orders (http, 2 hops away): GET /api/orders/{id}" {
+		t.Errorf("caller impact = %q", d)
+	}
+}
+
+func TestEndpointMatches(t *testing.T) {
+	cases := []struct {
+		endpoint, want string
+		match          bool
+	}{
+		{"POST /api/orders", "/api/orders", true},
+		{"POST /api/orders", "post /api/orders", true},
+		{"POST /api/orders", "GET /api/orders", false},
+		{"GET /api/orders/{id}", "GET /api/orders/42", true},
+		{"GET /api/orders/:id", "/api/orders/42", true},
+		{"GET /api/orders/{id}", "/api/orders", false},
+	}
+	for _, c := range cases {
+		if got := endpointMatches(c.endpoint, c.want); got != c.match {
+			t.Errorf("endpointMatches(%q, %q) = %v, want %v", c.endpoint, c.want, got, c.match)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	checkout := t.TempDir()
+	os.MkdirAll(filepath.Join(checkout, "docs", "runbooks"), 0o755)
+	os.WriteFile(filepath.Join(checkout, "docs", "runbooks", "orders-down.md"), []byte("# Orders down"), 0o644)
+	os.WriteFile(filepath.Join(checkout, "README.md"), []byte("# Orders"), 0o644)
+
+	repos := registry.NewStore(database)
+	for _, r := range []registry.Repository{
+		{Name: "orders", Summary: "Takes orders.", LocalPath: checkout, SourceURL: "https://github.com/acme/orders"},
+		{Name: "gateway"}, {Name: "admin"}, {Name: "web"}, {Name: "payments"},
+	} {
+		r := r
+		r.SourceType = "local"
+		if err := repos.Add(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, l := range testLinks {
+		l := l
+		if err := repos.SaveLink(ctx, &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repos.RecordChanges(ctx, []registry.ArchChange{{Repo: "orders", Kind: registry.ChangeDependencyAdded, FromRepo: "orders", ToRepo: "payments"}})
+
+	org := orgstructure.NewStore(database)
+	team := &orgstructure.Team{Name: "commerce", SlackChannel: "#commerce"}
+	if err := org.CreateTeam(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+	org.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team.ID, RepoID: "orders"})
+
+	flowStore := flows.NewStore(database)
+	flowStore.CreateFlow(ctx, &flows.Flow{Name: "checkout", Services: []string{"web", "gateway", "orders", "payments"}})
+	flowStore.CreateFlow(ctx, &flows.Flow{Name: "signup", Services: []string{"web", "users"}})
+
+	b := NewBuilder(config.IncidentConfig{Runbooks: map[string][]string{"orders": {"https://wiki/orders"}}}, database, nil, nil)
+	b.Log = func(ctx context.Context, dir string, paths []string, since time.Time, limit int) ([]Commit, error) {
+		if dir != checkout {
+			t.Errorf("git log in %s, want %s", dir, checkout)
+		}
+		return []Commit{{SHA: "0123456789abcdef", Author: "dev", Subject: "Retry payment calls"}}, nil
+	}
+
+	bundle, err := b.Build(ctx, "POST /api/orders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Service != "orders" || bundle.Endpoint != "POST /api/orders" {
+		t.Errorf("resolved %q / %q, want orders / POST /api/orders", bundle.Service, bundle.Endpoint)
+	}
+	if len(bundle.BlastRadius) != 2 {
+		t.Errorf("blast radius = %+v", bundle.BlastRadius)
+	}
+	if len(bundle.Owners) != 1 || bundle.Owners[0].Team != "commerce" {
+		t.Errorf("owners = %+v", bundle.Owners)
+	}
+	if len(bundle.Flows) != 1 || bundle.Flows[0].Name != "checkout" {
+		t.Errorf("flows = %+v", bundle.Flows)
+	}
+	// Registering orders was itself a change.
+	if len(bundle.Changes) != 2 || len(bundle.Commits) != 1 {
+		t.Errorf("changes = %+v, commits = %+v", bundle.Changes, bundle.Commits)
+	}
+	if bundle.Commits[0].URL != "https://github.com/acme/orders/commit/0123456789abcdef" {
+		t.Errorf("commit URL = %q", bundle.Commits[0].URL)
+	}
+	if strings.Join(bundle.Runbooks, ",") != "https://wiki/orders,docs/runbooks/orders-down.md" {
+		t.Errorf("runbooks = %v", bundle.Runbooks)
+	}
+
+	md := bundle.Markdown()
+	for _, want := range []string{"# Incident: orders (POST /api/orders)", "**gateway** calls orders", "**commerce** (#commerce)", "0123456", "checkout", "orders-down.md"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if slack := bundle.Slack(); !strings.Contains(slack, "*Blast radius*") || strings.Contains(slack, "## ") {
+		t.Errorf("slack rendering:\n%s", slack)
+	}
+
+	if _, err := b.Build(ctx, "GET /api/orders/7", ""); err != nil {
+		t.Errorf("endpoint with a path parameter: %v", err)
+	}
+	if _, err := b.Build(ctx, "nope", ""); err == nil {
+		t.Error("unknown target should fail")
+	}
+}
@@ -0,0 +1,195 @@
+package incident
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the bundle for the terminal and MCP clients.
+func (b *Bundle) Markdown() string {
+	var sb strings.Builder
+	title := b.Service
+	if b.Endpoint != "" {
+		title += " (" + b.Endpoint + ")"
+	}
+	fmt.Fprintf(&sb, "# Incident: %s\n\n", title)
+	if b.Summary != "" {
+		sb.WriteString(b.Summary + "\n\n")
+	}
+
+	sb.WriteString("## Blast Radius\n\n")
+	if len(b.BlastRadius) == 0 {
+		sb.WriteString("No known service calls this one.\n\n")
+	} else {
+		for _, imp := range b.BlastRadius {
+			fmt.Fprintf(&sb, "- **%s** %s\n", imp.Service, impactDetail(imp))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(b.Dependencies) > 0 {
+		sb.WriteString("## Dependencies\n\n")
+		for _, l := range b.Dependencies {
+			fmt.Fprintf(&sb, "- **%s** (%s)%s\n", l.ToRepo, l.LinkType, endpointList(l.Endpoints))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Owners\n\n")
+	if len(b.Owners) == 0 && len(b.OnCall) == 0 {
+		sb.WriteString("No owning team or on-call schedule is recorded.\n\n")
+	}
+	for _, o := range b.Owners {
+		fmt.Fprintf(&sb, "- **%s**%s\n", o.Team, ownerContact(o))
+	}
+	for _, l := range b.OnCall {
+		role := "caller"
+		if l.Service == b.Service {
+			role = "owner"
+		}
+		fmt.Fprintf(&sb, "- On call for **%s** (%s, `%s`): %s\n", l.Service, role, l.Schedule, l.Format())
+	}
+	if len(b.Owners) > 0 || len(b.OnCall) > 0 {
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "## Recent Changes (since %s)\n\n", b.Since.Format("2006-01-02"))
+	if len(b.Commits) == 0 && len(b.Changes) == 0 {
+		sb.WriteString("No recent commits or architecture changes.\n\n")
+	}
+	for _, c := range b.Commits {
+		sha := c.SHA
+		if len(sha) > 8 {
+			sha = sha[:8]
+		}
+		if c.URL != "" {
+			sha = fmt.Sprintf("[%s](%s)", sha, c.URL)
+		}
+		fmt.Fprintf(&sb, "- %s %s — %s, %s\n", sha, c.Subject, c.Author, c.Date.Format("2006-01-02 15:04"))
+	}
+	for _, c := range b.Changes {
+		fmt.Fprintf(&sb, "- %s: %s\n", c.ChangedAt.Format("2006-01-02"), c.Describe())
+	}
+	if len(b.Commits) > 0 || len(b.Changes) > 0 {
+		sb.WriteString("\n")
+	}
+
+	if len(b.Flows) > 0 {
+		sb.WriteString("## Participating Flows\n\n")
+		for _, f := range b.Flows {
+			fmt.Fprintf(&sb, "- **%s** (%s)", f.Name, strings.Join(f.Services, " → "))
+			if f.Description != "" {
+				sb.WriteString(": " + f.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Runbooks\n\n")
+	if len(b.Runbooks) == 0 {
+		sb.WriteString("No runbooks found. Add them under incident.runbooks or as a \"runbook\" fact.\n")
+	}
+	for _, rb := range b.Runbooks {
+		fmt.Fprintf(&sb, "- %s\n", rb)
+	}
+
+	if len(b.Warnings) > 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range b.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+	}
+	return sb.String()
+}
+
+// Slack renders the bundle as Slack mrkdwn, which has bold text and links
+// but no headings.
+func (b *Bundle) Slack() string {
+	var sb strings.Builder
+	title := b.Service
+	if b.Endpoint != "" {
+		title += " `" + b.Endpoint + "`"
+	}
+	fmt.Fprintf(&sb, ":rotating_light: *Incident context: %s*\n", title)
+
+	sb.WriteString("\n*Blast radius*\n")
+	if len(b.BlastRadius) == 0 {
+		sb.WriteString("No known service calls this one.\n")
+	}
+	for _, imp := range b.BlastRadius {
+		fmt.Fprintf(&sb, "• *%s* %s\n", imp.Service, impactDetail(imp))
+	}
+
+	sb.WriteString("\n*Who to page*\n")
+	if len(b.Owners) == 0 && len(b.OnCall) == 0 {
+		sb.WriteString("No owning team or on-call schedule is recorded.\n")
+	}
+	for _, o := range b.Owners {
+		fmt.Fprintf(&sb, "• %s%s\n", o.Team, ownerContact(o))
+	}
+	for _, l := range b.OnCall {
+		fmt.Fprintf(&sb, "• On call for %s: %s\n", l.Service, l.Format())
+	}
+
+	if len(b.Commits) > 0 || len(b.Changes) > 0 {
+		fmt.Fprintf(&sb, "\n*Changed since %s*\n", b.Since.Format("Jan 2"))
+		for _, c := range b.Commits {
+			sha := c.SHA
+			if len(sha) > 8 {
+				sha = sha[:8]
+			}
+			if c.URL != "" {
+				sha = fmt.Sprintf("<%s|%s>", c.URL, sha)
+			}
+			fmt.Fprintf(&sb, "• %s %s (%s)\n", sha, c.Subject, c.Author)
+		}
+		for _, c := range b.Changes {
+			fmt.Fprintf(&sb, "• %s\n", c.Describe())
+		}
+	}
+
+	if len(b.Flows) > 0 {
+		names := make([]string, len(b.Flows))
+		for i, f := range b.Flows {
+			names[i] = f.Name
+		}
+		fmt.Fprintf(&sb, "\n*Flows:* %s\n", strings.Join(names, ", "))
+	}
+	if len(b.Runbooks) > 0 {
+		sb.WriteString("\n*Runbooks*\n")
+		for _, rb := range b.Runbooks {
+			fmt.Fprintf(&sb, "• %s\n", rb)
+		}
+	}
+	return sb.String()
+}
+
+func impactDetail(imp Impact) string {
+	s := fmt.Sprintf("%s %s (%s", imp.Relation(), imp.Via, imp.LinkType)
+	if imp.Depth > 1 {
+		s += fmt.Sprintf(", %d hops away", imp.Depth)
+	}
+	return s + ")" + endpointList(imp.Endpoints)
+}
+
+func endpointList(endpoints []string) string {
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(endpoints, ", ")
+}
+
+func ownerContact(o Owner) string {
+	var parts []string
+	if o.SlackChannel != "" {
+		parts = append(parts, o.SlackChannel)
+	}
+	if o.Email != "" {
+		parts = append(parts, o.Email)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -18,6 +18,9 @@ type FileAnalyzer struct {
 	provider llm.Provider
 	tier     config.QualityTier
 	model    string
+	mode     config.AnalyzerMode
+	sizes    config.LargeFilesConfig
+	scrubber *Scrubber
 }
 
 // NewFileAnalyzer creates a new FileAnalyzer.
@@ -29,11 +32,33 @@ func NewFileAnalyzer(provider llm.Provider, tier config.QualityTier, model strin
 	}
 }
 
+// SetMode selects the analyzer: the LLM (the default), the static parsers
+// alone, or the LLM cross-checked against the static parsers.
+func (a *FileAnalyzer) SetMode(mode config.AnalyzerMode) {
+	a.mode = mode
+}
+
+// SetLargeFiles sets the size thresholds above which files are sampled or
+// stubbed instead of analyzed in full.
+func (a *FileAnalyzer) SetLargeFiles(sizes config.LargeFilesConfig) {
+	a.sizes = sizes
+}
+
+// SetScrub sets what is redacted from source before it is sent to the LLM.
+func (a *FileAnalyzer) SetScrub(cfg config.ScrubConfig) {
+	a.scrubber = NewScrubber(cfg)
+}
+
 // AnalyzeResult holds both the analysis and token usage from a single file analysis.
 type AnalyzeResult struct {
 	Analysis     *FileAnalysis
 	InputTokens  int
 	OutputTokens int
+	// Discrepancies are the corrections and doubts from cross-checking the
+	// LLM analysis against the source.
+	Discrepancies []Discrepancy
+	// Redactions are the values masked in the source sent to the LLM.
+	Redactions []Redaction
 }
 
 // completeWithRetry calls the LLM with exponential backoff on rate limit errors.
@@ -72,10 +97,44 @@ func (a *FileAnalyzer) completeWithRetry(ctx context.Context, req llm.Completion
 	return nil, fmt.Errorf("unreachable")
 }
 
-// Analyze sends a file to the LLM and returns the structured analysis.
+// Analyze sends a file to the LLM and returns the structured analysis. In
+// static mode the file is analyzed by StaticAnalyze instead, with no LLM
+// call. Files over the stub threshold, and bundles and dumps over the
+// sample threshold, get a stub analysis; other files over the sample
+// threshold are analyzed from a sample.
 func (a *FileAnalyzer) Analyze(ctx context.Context, filePath string, content []byte, language string) (*AnalyzeResult, error) {
-	contentStr := string(content)
+	sampleAbove, stubAbove := a.sizes.Thresholds(filePath)
+	size := int64(len(content))
+	if size > stubAbove {
+		return &AnalyzeResult{Analysis: stubAnalysis(filePath, content, language, "over the "+FormatSize(stubAbove)+" stub limit")}, nil
+	}
+	if size > sampleAbove {
+		if kind := bundleKind(content); kind != "" {
+			return &AnalyzeResult{Analysis: stubAnalysis(filePath, content, language, kind)}, nil
+		}
+	}
+
+	if a.mode == config.AnalyzerStatic {
+		analysis := StaticAnalyze(filePath, content, language)
+		ExtractReferences(analysis, content)
+		return &AnalyzeResult{Analysis: analysis}, nil
+	}
+
+	// Secrets are masked before sampling, so a sample can't cut one in two
+	// and leave part of it unmatched.
+	source, redactions := a.scrubber.Scrub(content)
+	var truncation *Truncation
+	if size > sampleAbove {
+		var analyzed int64
+		source, analyzed = sampleContent(source, sampleAbove)
+		truncation = &Truncation{Action: TruncationSampled, Size: size, Analyzed: analyzed, Reason: "over the " + FormatSize(sampleAbove) + " sample limit"}
+	}
+
+	contentStr := string(source)
 	messages := buildMessages(a.tier, filePath, contentStr, language)
+	if truncation != nil {
+		messages[len(messages)-1].Content += "\n\n" + fmt.Sprintf(sampledFileNote, FormatSize(size), FormatSize(truncation.Analyzed))
+	}
 
 	resp, err := a.completeWithRetry(ctx, llm.CompletionRequest{
 		Model:       a.model,
@@ -161,11 +220,24 @@ func (a *FileAnalyzer) Analyze(ctx context.Context, filePath string, content []b
 	analysis.FilePath = filePath
 	analysis.Language = language
 	analysis.ContentHash = computeHash(content)
+	analysis.Truncation = truncation
+	analysis.Normalize()
+	if truncation == nil {
+		// A sampled file's line numbers don't point into content.
+		ExtractReferences(analysis, content)
+	}
+
+	var discrepancies []Discrepancy
+	if a.mode == config.AnalyzerCrossCheck && !analysis.Skip {
+		discrepancies = CrossCheck(analysis, StaticAnalyze(filePath, content, language), content)
+	}
 
 	return &AnalyzeResult{
-		Analysis:     analysis,
-		InputTokens:  resp.InputTokens,
-		OutputTokens: resp.OutputTokens,
+		Analysis:      analysis,
+		InputTokens:   resp.InputTokens,
+		OutputTokens:  resp.OutputTokens,
+		Discrepancies: discrepancies,
+		Redactions:    redactions,
 	}, nil
 }
 
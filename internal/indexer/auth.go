@@ -0,0 +1,382 @@
+package indexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Authentication states of an endpoint.
+const (
+	AuthRequired = "required" // middleware, a decorator, an annotation, or config requires it
+	AuthPublic   = "public"   // explicitly open to anyone, e.g. @PermitAll or permitAll()
+	AuthNone     = "none"     // nothing found requires it
+)
+
+// EndpointAuth is what an endpoint's source says about whether it requires
+// authentication.
+type EndpointAuth struct {
+	Route string // "GET /orders", as in the RoutesKeyLogic entry
+	Line  int    // 1-based line registering the route
+	Auth  string
+	// Evidence names what decided Auth, e.g. "@login_required" or
+	// "r.Use(requireAuth)"; empty for AuthNone.
+	Evidence string
+}
+
+// ScanEndpointAuth finds the HTTP routes a file registers, as StaticAnalyze
+// does, and whether each requires authentication. It looks at the route's
+// decorators or annotations and its class's, at middleware passed with the
+// route or wrapped around its handler, and at middleware installed earlier
+// in the same block on the same router (Use calls, before_request hooks,
+// router-wide FastAPI dependencies). Security and gateway config is read by
+// ScanAuthRules. Files in other languages, or without routes, have none.
+func ScanEndpointAuth(filePath string, content []byte, language string) []EndpointAuth {
+	var f *staticFacts
+	switch language {
+	case "Go":
+		f = analyzeGo(filePath, content)
+	case "Python":
+		f = analyzePython(content)
+	case "Java":
+		f = analyzeJava(content)
+	case "TypeScript", "JavaScript":
+		f = analyzeScript(content)
+	}
+	if f == nil || len(f.routes) == 0 {
+		return nil
+	}
+	python := language == "Python"
+	s := lexSource(string(content), python)
+	guards := authGuards(s, python)
+	out := make([]EndpointAuth, 0, len(f.routes))
+	for _, route := range f.routes {
+		line := f.routeLines[route]
+		e := EndpointAuth{Route: route, Line: line + 1, Auth: AuthNone}
+		if auth, evidence := routeAuth(s, line, python); auth != "" {
+			e.Auth, e.Evidence = auth, evidence
+		} else if g := applyingGuard(s, guards, line, route); g != nil {
+			e.Auth, e.Evidence = AuthRequired, g.evidence
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+var (
+	quotedRe     = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+	identRe      = regexp.MustCompile(`\w+`)
+	dependsRe    = regexp.MustCompile(`\b(?:Depends|Security)\(\s*([\w.]+)`)
+	wrapperRe    = regexp.MustCompile(`^\s*([\w.]+)\s*\(`)
+	routeCallRe  = regexp.MustCompile("\\(\\s*(?:\"[A-Z]+\"\\s*,\\s*)?[\"'`]/")
+	useRe        = regexp.MustCompile(`^\s*([\w.]+)\.(Use|use)\(`)
+	groupRe      = regexp.MustCompile(`^\s*(\w+)\s*:?=\s*[\w.]+\.(?:Group|group)\(`)
+	appDepsRe    = regexp.MustCompile(`\b(?:APIRouter|FastAPI)\(`)
+	classLineRe  = regexp.MustCompile(`\bclass\s+\w+`)
+	pyDefLineRe  = regexp.MustCompile(`^\s*(?:async\s+)?def\s`)
+	notAuthWords = []string{"optional", "skip", "noauth", "no_auth", "unauth", "public", "anonymous", "csrf", "allowany", "permitall"}
+	authWords    = []string{
+		"jwt", "bearer", "login_required", "loginrequired", "loggedin", "logged_in", "signedin", "signed_in",
+		"requirelogin", "require_login", "requires_login", "current_user", "currentuser", "apikey", "api_key",
+		"verifytoken", "verify_token", "validatetoken", "validate_token", "checktoken", "check_token",
+		"tokenrequired", "token_required", "preauthorize", "rolesallowed", "secured", "hasrole", "has_role",
+		"requirerole", "require_role", "requires_role", "roles_required", "rolesguard", "isadmin", "is_admin",
+		"requireadmin", "require_admin", "admin_required", "adminrequired", "adminonly", "admin_only",
+		"permission_required", "haspermission", "has_permission", "protect",
+	}
+	// publicWords are whole identifiers marking a route open on purpose.
+	publicWords = map[string]bool{
+		"permitall": true, "allowany": true, "allowanonymous": true, "public": true, "ispublic": true,
+		"public_route": true, "publicroute": true, "skipauth": true, "skip_auth": true, "noauth": true,
+		"no_auth": true, "anonymous": true, "unauthenticated": true,
+	}
+	// argAnnotations are decorators whose arguments, rather than their
+	// names, say how a route is protected.
+	argAnnotations = map[string]bool{"useguards": true, "permission_classes": true, "authentication_classes": true}
+)
+
+// authIdent reports whether an identifier names an authentication or
+// authorization check, such as requireAuth, JwtAuthGuard, or
+// login_required.
+func authIdent(id string) bool {
+	l := strings.ToLower(id)
+	if l == "protected" { // the keyword
+		return false
+	}
+	for _, w := range notAuthWords {
+		if strings.Contains(l, w) {
+			return false
+		}
+	}
+	if strings.Contains(l, "auth") && (!strings.Contains(l, "author") || strings.Contains(l, "authoriz") || strings.Contains(l, "authorit")) {
+		return true
+	}
+	for _, w := range authWords {
+		if strings.Contains(l, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// idents returns the identifiers in code, outside string literals.
+func idents(code string) []string {
+	return identRe.FindAllString(quotedRe.ReplaceAllString(code, `""`), -1)
+}
+
+// firstAuthIdent returns the first identifier in code naming an
+// authentication check, or "".
+func firstAuthIdent(code string) string {
+	for _, id := range idents(code) {
+		if authIdent(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// annotationAuth reads one decorator or annotation.
+func annotationAuth(ann string) (auth, evidence string) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(ann, "@"), "(")
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	args := ""
+	if strings.Contains(ann, "(") {
+		args = parenContents(ann)
+	}
+	switch {
+	case publicWords[strings.ToLower(name)]:
+		return AuthPublic, "@" + name
+	case authIdent(name):
+		return AuthRequired, "@" + name
+	case argAnnotations[strings.ToLower(name)]:
+		for _, id := range idents(args) {
+			if publicWords[strings.ToLower(id)] {
+				return AuthPublic, "@" + name + "(" + id + ")"
+			}
+		}
+		if id := firstAuthIdent(args); id != "" {
+			return AuthRequired, "@" + name + "(" + id + ")"
+		}
+	}
+	return dependsAuth(args)
+}
+
+// dependsAuth finds a FastAPI dependency that authenticates, e.g.
+// Depends(get_current_user).
+func dependsAuth(code string) (auth, evidence string) {
+	for _, m := range dependsRe.FindAllStringSubmatch(code, -1) {
+		if authIdent(m[1]) {
+			return AuthRequired, "Depends(" + m[1] + ")"
+		}
+	}
+	return "", ""
+}
+
+// routeAuth reads what the route registered on line says itself: its
+// decorators or annotations, its signature, the middleware registered
+// with it, and its class's annotations. It returns "" when they say
+// nothing.
+func routeAuth(s *scanned, line int, python bool) (auth, evidence string) {
+	for _, ann := range s.annotationsAbove(line) {
+		if auth, evidence = annotationAuth(ann); auth != "" {
+			return auth, evidence
+		}
+	}
+	stmt, _ := s.joinStatement(line)
+	if auth, evidence = dependsAuth(stmt); auth != "" {
+		return auth, evidence
+	}
+	if strings.Contains(stmt, "@AuthenticationPrincipal") {
+		return AuthRequired, "@AuthenticationPrincipal"
+	}
+	if _, evidence = registrationAuth(stmt); evidence != "" {
+		return AuthRequired, evidence
+	}
+	if python {
+		return "", ""
+	}
+	for j := line - 1; j >= 0; j-- {
+		if !s.inLiteral[j] && s.depth[j] < s.depth[line] && classLineRe.MatchString(s.code[j]) {
+			for _, ann := range s.annotationsAbove(j) {
+				if auth, evidence = annotationAuth(ann); auth != "" {
+					return auth, evidence
+				}
+			}
+			break
+		}
+	}
+	return "", ""
+}
+
+// registrationAuth reads a route registration call, such as
+// r.With(auth).Get("/x", h), app.get('/x', requireAuth, h), or
+// mux.Handle("/x", auth(h)). It returns the router the route is registered
+// on and the middleware that authenticates it, if any.
+func registrationAuth(stmt string) (recv, evidence string) {
+	loc := routeCallRe.FindStringIndex(stmt)
+	if loc == nil {
+		return "", ""
+	}
+	chain := stmt[:loc[0]]
+	if i := strings.LastIndex(chain, "."); i >= 0 {
+		chain = chain[:i]
+	}
+	if ids := idents(chain); len(ids) > 0 {
+		recv = ids[0]
+	}
+	if id := firstAuthIdent(chain); id != "" {
+		return recv, id
+	}
+	args := nonLiteral(splitParams(parenContents(stmt[loc[0]:])))
+	if len(args) == 0 {
+		return recv, ""
+	}
+	for _, a := range args[:len(args)-1] {
+		if id := firstAuthIdent(a); id != "" {
+			return recv, id
+		}
+	}
+	handler := args[len(args)-1]
+	for range 5 {
+		m := wrapperRe.FindStringSubmatch(handler)
+		if m == nil {
+			break
+		}
+		if firstAuthIdent(m[1]) != "" {
+			return recv, m[1] + "(…)"
+		}
+		handler = parenContents(handler)
+	}
+	return recv, ""
+}
+
+// nonLiteral drops the arguments that are string literals, such as a
+// route's path and method.
+func nonLiteral(args []string) []string {
+	var out []string
+	for _, a := range args {
+		a = strings.TrimSpace(a)
+		if a != "" && quotedRe.FindString(a) != a {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// authGuard is middleware that authenticates the routes registered after it
+// in its block, on its router.
+type authGuard struct {
+	line, indent int
+	recv         string
+	prefix       string // only routes under this path, when set
+	fileWide     bool   // every route in the file, wherever it is registered
+	evidence     string
+}
+
+// authGuards finds the authenticating middleware a file installs.
+func authGuards(s *scanned, python bool) []authGuard {
+	var guards []authGuard
+	for i, line := range s.code {
+		if s.inLiteral[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if m := useRe.FindStringSubmatch(line); m != nil {
+			stmt, _ := s.joinStatement(i)
+			args := splitParams(parenContents(stmt))
+			prefix := ""
+			if len(args) > 0 {
+				if p := strings.TrimSpace(args[0]); quotedRe.FindString(p) == p && len(p) > 2 && p[1] == '/' {
+					prefix = p[1 : len(p)-1]
+				}
+			}
+			if id := firstAuthIdent(strings.Join(nonLiteral(args), ",")); id != "" {
+				guards = append(guards, authGuard{line: i, indent: indentOf(line), recv: m[1], prefix: prefix, evidence: m[1] + "." + m[2] + "(" + id + ")"})
+			}
+			continue
+		}
+		if m := groupRe.FindStringSubmatch(line); m != nil {
+			stmt, _ := s.joinStatement(i)
+			if id := firstAuthIdent(strings.Join(nonLiteral(splitParams(parenContents(stmt))), ",")); id != "" {
+				guards = append(guards, authGuard{line: i, indent: indentOf(line), recv: m[1], evidence: m[1] + " group (" + id + ")"})
+			}
+			continue
+		}
+		if !python {
+			continue
+		}
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, "@") && (strings.Contains(t, "before_request") || strings.Contains(t, "before_app_request")) {
+			if id := firstAuthIdent(pyHookBody(s, i)); id != "" {
+				guards = append(guards, authGuard{line: i, fileWide: true, evidence: "before_request hook (" + id + ")"})
+			}
+			continue
+		}
+		if appDepsRe.MatchString(line) {
+			stmt, _ := s.joinStatement(i)
+			if _, evidence := dependsAuth(stmt); evidence != "" {
+				guards = append(guards, authGuard{line: i, fileWide: true, evidence: "router dependencies: " + evidence})
+			}
+		}
+	}
+	return guards
+}
+
+// pyHookBody returns the code of the function decorated on line.
+func pyHookBody(s *scanned, line int) string {
+	def := -1
+	for j := line + 1; j < len(s.code); j++ {
+		if pyDefLineRe.MatchString(s.code[j]) {
+			def = j
+			break
+		}
+		if !strings.HasPrefix(strings.TrimSpace(s.code[j]), "@") {
+			return ""
+		}
+	}
+	if def < 0 {
+		return ""
+	}
+	var body []string
+	for j := def + 1; j < len(s.code); j++ {
+		if t := strings.TrimSpace(s.code[j]); t != "" && !s.inLiteral[j] && indentOf(s.code[j]) <= indentOf(s.code[def]) {
+			break
+		}
+		body = append(body, s.code[j])
+	}
+	return strings.Join(body, "\n")
+}
+
+// applyingGuard returns the guard authenticating the route registered on
+// line, or nil. A guard applies to routes on its router registered after
+// it and before its block ends.
+func applyingGuard(s *scanned, guards []authGuard, line int, route string) *authGuard {
+	stmt, _ := s.joinStatement(line)
+	recv, _ := registrationAuth(stmt)
+	_, path, _ := strings.Cut(route, " ")
+	if path == "" {
+		path = route
+	}
+	for i := range guards {
+		g := &guards[i]
+		if g.fileWide {
+			return g
+		}
+		if g.line >= line || (g.recv != recv && g.recv != "app" && recv != "") {
+			continue
+		}
+		if g.prefix != "" && path != g.prefix && !strings.HasPrefix(path, strings.TrimSuffix(g.prefix, "/")+"/") {
+			continue
+		}
+		inScope := true
+		for j := g.line + 1; j < line && inScope; j++ {
+			if !s.inLiteral[j] && strings.TrimSpace(s.code[j]) != "" && indentOf(s.code[j]) < g.indent {
+				inScope = false
+			}
+		}
+		if inScope {
+			return g
+		}
+	}
+	return nil
+}
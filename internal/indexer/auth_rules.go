@@ -0,0 +1,259 @@
+package indexer
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// AuthRule is a rule in security or gateway config deciding whether the
+// paths it matches require authentication.
+type AuthRule struct {
+	Pattern  string // path pattern: * matches a segment, ** any number
+	Method   string // empty for every method
+	Auth     string
+	Evidence string
+	// Gateway is set for rules from an API gateway or reverse proxy, which
+	// front other services' routes as well as their own repo's.
+	Gateway bool
+}
+
+// Matches reports whether the rule covers route, "METHOD /path".
+func (r AuthRule) Matches(route string) bool {
+	method, p, ok := strings.Cut(route, " ")
+	if !ok {
+		method, p = "", route
+	}
+	if r.Method != "" && method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	matched, _ := doublestar.Match(r.Pattern, p)
+	return matched
+}
+
+// ScanAuthRules reads the authentication rules in a config or source file:
+// Spring Security request matchers, Kong declarative config, and nginx
+// location blocks. Other files have none.
+func ScanAuthRules(filePath string, content []byte) []AuthRule {
+	base := strings.ToLower(path.Base(filePath))
+	src := string(content)
+	switch ext := path.Ext(base); {
+	case ext == ".java" || ext == ".kt":
+		return springRules(src)
+	case (ext == ".yml" || ext == ".yaml") && (strings.HasPrefix(base, "kong") || strings.Contains(src, "_format_version")):
+		return kongRules(content)
+	case base == "nginx.conf" || ext == ".conf" && strings.Contains(src, "location"):
+		return nginxRules(src)
+	}
+	return nil
+}
+
+var (
+	springMatcherRe = regexp.MustCompile(`\.(?:requestMatchers|antMatchers|mvcMatchers|pathMatchers|regexMatchers)\(([^)]*)\)\s*\.(\w+)|\.(?:anyRequest|anyExchange)\(\)\s*\.(\w+)|\bauthorize\(\s*"([^"]+)"\s*,\s*(\w+)`)
+	springMethodRe  = regexp.MustCompile(`HttpMethod\.([A-Z]+)`)
+)
+
+// springAccess maps Spring Security access rules to authentication states.
+var springAccess = map[string]string{
+	"permitAll": AuthPublic, "anonymous": AuthPublic,
+	"authenticated": AuthRequired, "fullyAuthenticated": AuthRequired, "rememberMe": AuthRequired,
+	"hasRole": AuthRequired, "hasAnyRole": AuthRequired, "hasAuthority": AuthRequired,
+	"hasAnyAuthority": AuthRequired, "access": AuthRequired, "denyAll": AuthRequired,
+}
+
+// springRules reads Spring Security's request matchers, in order; the
+// first matching rule decides.
+func springRules(src string) []AuthRule {
+	code := strings.Join(lexSource(src, false).code, "\n")
+	var rules []AuthRule
+	for _, m := range springMatcherRe.FindAllStringSubmatch(code, -1) {
+		switch {
+		case m[2] != "":
+			auth, ok := springAccess[m[2]]
+			if !ok {
+				continue
+			}
+			method := ""
+			if mm := springMethodRe.FindStringSubmatch(m[1]); mm != nil {
+				method = mm[1]
+			}
+			for _, q := range firstQuotedRe.FindAllStringSubmatch(m[1], -1) {
+				rules = append(rules, AuthRule{Pattern: q[1], Method: method, Auth: auth, Evidence: m[2] + "() for " + q[1]})
+			}
+		case m[3] != "":
+			if auth, ok := springAccess[m[3]]; ok {
+				rules = append(rules, AuthRule{Pattern: "/**", Auth: auth, Evidence: "anyRequest()." + m[3] + "()"})
+			}
+		case m[4] != "":
+			if auth, ok := springAccess[m[5]]; ok {
+				rules = append(rules, AuthRule{Pattern: m[4], Auth: auth, Evidence: m[5] + " for " + m[4]})
+			}
+		}
+	}
+	return rules
+}
+
+// kongAuthPlugins are the Kong plugins that authenticate requests.
+var kongAuthPlugins = map[string]bool{
+	"jwt": true, "key-auth": true, "key-auth-enc": true, "oauth2": true, "oauth2-introspection": true,
+	"basic-auth": true, "hmac-auth": true, "ldap-auth": true, "ldap-auth-advanced": true,
+	"openid-connect": true, "mtls-auth": true, "session": true,
+}
+
+type kongPlugin struct {
+	Name    string `yaml:"name"`
+	Enabled *bool  `yaml:"enabled"`
+}
+
+type kongRoute struct {
+	Paths   []string     `yaml:"paths"`
+	Methods []string     `yaml:"methods"`
+	Plugins []kongPlugin `yaml:"plugins"`
+}
+
+// kongAuth returns the first enabled authentication plugin in plugins.
+func kongAuth(plugins []kongPlugin) string {
+	for _, p := range plugins {
+		if kongAuthPlugins[p.Name] && (p.Enabled == nil || *p.Enabled) {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// kongRules reads a Kong declarative config: a route requires
+// authentication when it, its service, or the whole gateway has an
+// authentication plugin. Kong route paths are prefixes.
+func kongRules(content []byte) []AuthRule {
+	var cfg struct {
+		Plugins  []kongPlugin `yaml:"plugins"`
+		Routes   []kongRoute  `yaml:"routes"`
+		Services []struct {
+			Plugins []kongPlugin `yaml:"plugins"`
+			Routes  []kongRoute  `yaml:"routes"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil
+	}
+	var rules []AuthRule
+	add := func(r kongRoute, inherited ...[]kongPlugin) {
+		plugin := kongAuth(r.Plugins)
+		for _, ps := range inherited {
+			if plugin == "" {
+				plugin = kongAuth(ps)
+			}
+		}
+		if plugin == "" {
+			return
+		}
+		methods := r.Methods
+		if len(methods) == 0 {
+			methods = []string{""}
+		}
+		for _, p := range r.Paths {
+			if strings.HasPrefix(p, "~") { // a regex route
+				continue
+			}
+			for _, m := range methods {
+				rules = append(rules, AuthRule{Pattern: prefixPattern(p), Method: m, Auth: AuthRequired, Evidence: "Kong " + plugin + " plugin on " + p, Gateway: true})
+			}
+		}
+	}
+	for _, svc := range cfg.Services {
+		for _, r := range svc.Routes {
+			add(r, svc.Plugins, cfg.Plugins)
+		}
+	}
+	for _, r := range cfg.Routes {
+		add(r, cfg.Plugins)
+	}
+	return rules
+}
+
+// prefixPattern turns a path prefix into a pattern matching it and
+// everything under it.
+func prefixPattern(prefix string) string {
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix == "" {
+		return "/**"
+	}
+	return prefix + "/**"
+}
+
+var (
+	nginxLocationRe = regexp.MustCompile(`^location\s+(?:(=|\^~)\s+)?(/\S*)\s*\{`)
+	nginxAuthRe     = regexp.MustCompile(`^(auth_request|auth_basic|auth_jwt)\s+("[^"]*"|\S+)\s*;`)
+)
+
+// nginxRules reads nginx location blocks. Authentication directives are
+// inherited from the enclosing server block unless a location turns them
+// off. Regex locations are skipped.
+func nginxRules(src string) []AuthRule {
+	type block struct {
+		location string // path, for location blocks
+		exact    bool
+		auth     string // the directive authenticating requests, or "off"
+	}
+	var stack []block
+	var rules []AuthRule
+	inherited := func() string {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].auth != "" {
+				return stack[i].auth
+			}
+		}
+		return ""
+	}
+	var lines []string
+	for _, line := range strings.Split(src, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		lines = append(lines, line)
+	}
+	// One statement or brace per line, so one-line blocks read like others.
+	src = strings.NewReplacer("{", "{\n", "}", "\n}\n", ";", ";\n").Replace(strings.Join(lines, "\n"))
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasSuffix(line, "{"):
+			b := block{}
+			if m := nginxLocationRe.FindStringSubmatch(line); m != nil {
+				b.location, b.exact = m[2], m[1] == "="
+			}
+			stack = append(stack, b)
+		case line == "}" && len(stack) > 0:
+			b := stack[len(stack)-1]
+			auth := inherited()
+			stack = stack[:len(stack)-1]
+			if b.location == "" {
+				if b.auth != "" && b.auth != "off" {
+					// A server or http block: everything it serves.
+					rules = append(rules, AuthRule{Pattern: "/**", Auth: AuthRequired, Evidence: "nginx " + b.auth, Gateway: true})
+				}
+				continue
+			}
+			pattern := prefixPattern(b.location)
+			if b.exact {
+				pattern = b.location
+			}
+			switch auth {
+			case "":
+			case "off":
+				rules = append(rules, AuthRule{Pattern: pattern, Auth: AuthPublic, Evidence: "nginx location " + b.location + " turns authentication off", Gateway: true})
+			default:
+				rules = append(rules, AuthRule{Pattern: pattern, Auth: AuthRequired, Evidence: "nginx " + auth + " on " + b.location, Gateway: true})
+			}
+		default:
+			if m := nginxAuthRe.FindStringSubmatch(line); m != nil && len(stack) > 0 {
+				if m[2] == "off" {
+					stack[len(stack)-1].auth = "off"
+				} else {
+					stack[len(stack)-1].auth = m[1]
+				}
+			}
+		}
+	}
+	return rules
+}
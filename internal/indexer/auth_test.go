@@ -0,0 +1,188 @@
+package indexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScanEndpointAuth(t *testing.T) {
+	tests := []struct {
+		name, file, language, src string
+		want                      []string // "route auth evidence"
+	}{
+		{
+			name: "chi", file: "router.go", language: "Go",
+			src: `package api
+
+func routes(r chi.Router) {
+	r.Get("/health", health)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(requireAuth)
+		r.Get("/users", listUsers)
+	})
+	r.With(jwtauth.Verifier(tokenAuth)).Post("/orders", createOrder)
+	r.Handle("/metrics", basicAuth(promhttp.Handler()))
+	r.Get("/author/{id}", getAuthor)
+}
+`,
+			want: []string{
+				"GET /health none ",
+				"GET /users required r.Use(requireAuth)",
+				"POST /orders required jwtauth",
+				"/metrics required basicAuth(…)",
+				"GET /author/{id} none ",
+			},
+		},
+		{
+			name: "express", file: "app.js", language: "JavaScript",
+			src: `const app = express();
+app.get('/status', (req, res) => res.send('ok'));
+app.post('/login', (req, res) => {
+  res.json({ token: sign(req.body) });
+});
+app.get('/me', passport.authenticate('jwt', { session: false }), (req, res) => res.json(req.user));
+app.use(requireAuth);
+app.delete('/orders/:id', (req, res) => res.sendStatus(204));
+`,
+			want: []string{
+				"GET /status none ",
+				"POST /login none ",
+				"GET /me required authenticate",
+				"DELETE /orders/:id required app.use(requireAuth)",
+			},
+		},
+		{
+			name: "flask and fastapi", file: "views.py", language: "Python",
+			src: `@app.route("/public")
+def public():
+    return "hi"
+
+@app.route("/account")
+@login_required
+def account():
+    return current_user.name
+
+@router.get("/items/{id}")
+async def item(id: int, user: User = Depends(get_current_user)):
+    return {}
+
+@router.get("/open", dependencies=[Depends(rate_limit)])
+async def open_items():
+    return []
+`,
+			want: []string{
+				"GET /public none ",
+				"GET /account required @login_required",
+				"GET /items/{id} required Depends(get_current_user)",
+				"GET /open none ",
+			},
+		},
+		{
+			name: "spring", file: "OrderController.java", language: "Java",
+			src: `@RestController
+@RequestMapping("/orders")
+@PreAuthorize("isAuthenticated()")
+public class OrderController {
+    @GetMapping("/{id}")
+    public Order get(@PathVariable String id) { return null; }
+
+    @PermitAll
+    @GetMapping("/catalog")
+    public List<Item> catalog() { return null; }
+}
+`,
+			want: []string{
+				"GET /orders/{id} required @PreAuthorize",
+				"GET /orders/catalog public @PermitAll",
+			},
+		},
+		{
+			name: "nest", file: "users.controller.ts", language: "TypeScript",
+			src: `@Controller('users')
+export class UsersController {
+  @Get('me')
+  @UseGuards(JwtAuthGuard)
+  me() {}
+
+  @Public()
+  @Post('signup')
+  signup() {}
+
+  @Get('stats')
+  @UseGuards(ThrottlerGuard)
+  stats() {}
+}
+`,
+			want: []string{
+				"GET /users/me required @UseGuards(JwtAuthGuard)",
+				"POST /users/signup public @Public",
+				"GET /users/stats none ",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range ScanEndpointAuth(tt.file, []byte(tt.src), tt.language) {
+				got = append(got, fmt.Sprintf("%s %s %s", e.Route, e.Auth, e.Evidence))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanAuthRules(t *testing.T) {
+	spring := ScanAuthRules("SecurityConfig.java", []byte(`http.authorizeHttpRequests(auth -> auth
+    .requestMatchers(HttpMethod.GET, "/catalog/**").permitAll()
+    .requestMatchers("/admin/**").hasRole("ADMIN")
+    .anyRequest().authenticated());`))
+	kong := ScanAuthRules("kong.yml", []byte(`_format_version: "3.0"
+services:
+  - name: orders
+    url: http://orders:8080
+    plugins:
+      - name: jwt
+    routes:
+      - paths: [/api/orders]
+  - name: docs
+    url: http://docs:8080
+    routes:
+      - paths: [/docs]
+`))
+	nginx := ScanAuthRules("nginx.conf", []byte(`server {
+    auth_request /auth;
+    location /api/ { proxy_pass http://api; }
+    location = /healthz {
+        auth_request off;
+    }
+}
+`))
+	var got []string
+	for _, rules := range [][]AuthRule{spring, kong, nginx} {
+		for _, r := range rules {
+			got = append(got, fmt.Sprintf("%s %s %s %v", r.Method, r.Pattern, r.Auth, r.Gateway))
+		}
+	}
+	want := []string{
+		"GET /catalog/** public false",
+		" /admin/** required false",
+		" /** required false",
+		" /api/orders/** required true",
+		" /api/** required true",
+		" /healthz public true",
+		" /** required true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	if !kong[0].Matches("POST /api/orders") || !kong[0].Matches("GET /api/orders/{id}") || kong[0].Matches("GET /api/ordersx") {
+		t.Error("a Kong route should match its path and everything under it")
+	}
+	if spring[0].Matches("POST /catalog/items") {
+		t.Error("a rule for GET should not match POST")
+	}
+}
@@ -2,12 +2,14 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
 
+	"github.com/ziadkadry99/auto-doc/internal/config"
 	"github.com/ziadkadry99/auto-doc/internal/walker"
 )
 
@@ -16,6 +18,7 @@ type Batcher struct {
 	concurrency int
 	analyzer    *FileAnalyzer
 	onProgress  ProgressFunc
+	budget      *Budget
 }
 
 // NewBatcher creates a new Batcher with the given concurrency limit.
@@ -30,12 +33,26 @@ func NewBatcher(concurrency int, analyzer *FileAnalyzer, onProgress ProgressFunc
 	}
 }
 
+// SetBudget caps what the batch may spend; nil means no cap. Files the
+// budget has no room for are listed in BatchResult.Deferred.
+func (b *Batcher) SetBudget(budget *Budget) {
+	b.budget = budget
+}
+
 // BatchResult holds collected results and errors from batch processing.
 type BatchResult struct {
-	Results      []AnalyzeResult
-	Errors       []error
-	InputTokens  int
-	OutputTokens int
+	Results       []AnalyzeResult
+	Errors        []error
+	InputTokens   int
+	OutputTokens  int
+	Discrepancies []Discrepancy
+	Truncated     []TruncationReport
+	// Deferred are the files not analyzed because the budget ran out.
+	Deferred []string
+	// Failed maps each file that could not be analyzed to why.
+	Failed map[string]error
+	// Redacted lists what was masked in each file before the LLM saw it.
+	Redacted []RedactionReport
 }
 
 // ProcessFiles analyzes a list of files concurrently.
@@ -53,14 +70,30 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 	sem := make(chan struct{}, b.concurrency)
 	var mu sync.Mutex
 	var processed int64
-	result := &BatchResult{}
+	result := &BatchResult{Failed: make(map[string]error)}
+
+	deferFile := func(f walker.FileInfo) {
+		mu.Lock()
+		result.Deferred = append(result.Deferred, f.RelPath)
+		mu.Unlock()
+		count := atomic.AddInt64(&processed, 1)
+		if b.onProgress != nil {
+			b.onProgress(int(count), total, f.RelPath)
+		}
+	}
 
 	var wg sync.WaitGroup
 	for _, file := range files {
+		if b.budget.Exceeded() {
+			deferFile(file)
+			continue
+		}
+
 		// Check circuit breaker before starting new work.
 		if atomic.LoadInt64(&quotaExhausted) > 0 {
 			mu.Lock()
 			result.Errors = append(result.Errors, fmt.Errorf("analyze %s: skipped (API quota exhausted)", file.RelPath))
+			result.Failed[file.RelPath] = errors.New("skipped (API quota exhausted)")
 			mu.Unlock()
 			count := atomic.AddInt64(&processed, 1)
 			if b.onProgress != nil {
@@ -73,6 +106,7 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 		case <-ctx.Done():
 			mu.Lock()
 			result.Errors = append(result.Errors, ctx.Err())
+			result.Failed[file.RelPath] = ctx.Err()
 			mu.Unlock()
 			count := atomic.AddInt64(&processed, 1)
 			if b.onProgress != nil {
@@ -82,15 +116,32 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 		case sem <- struct{}{}:
 		}
 
+		// Stop starting files once the budget can't cover the next one;
+		// files already being analyzed finish.
+		var estIn, estOut int
+		if b.budget != nil && b.analyzer.mode != config.AnalyzerStatic {
+			estIn, estOut = estimateFileTokens(file, b.analyzer.tier, b.analyzer.sizes)
+			if !b.budget.reserve(estIn, estOut) {
+				<-sem
+				deferFile(file)
+				continue
+			}
+		}
+
 		wg.Add(1)
-		go func(f walker.FileInfo) {
+		go func(f walker.FileInfo, estIn, estOut int) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			var usedIn, usedOut int
+			if estIn+estOut > 0 {
+				defer func() { b.budget.charge(estIn, estOut, usedIn, usedOut) }()
+			}
 
 			content, err := os.ReadFile(f.Path)
 			if err != nil {
 				mu.Lock()
 				result.Errors = append(result.Errors, fmt.Errorf("read %s: %w", f.RelPath, err))
+				result.Failed[f.RelPath] = err
 				mu.Unlock()
 				count := atomic.AddInt64(&processed, 1)
 				if b.onProgress != nil {
@@ -103,6 +154,7 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 			mu.Lock()
 			if err != nil {
 				result.Errors = append(result.Errors, fmt.Errorf("analyze %s: %w", f.RelPath, err))
+				result.Failed[f.RelPath] = err
 				// Detect quota exhaustion and trip circuit breaker.
 				errStr := err.Error()
 				if strings.Contains(errStr, "RESOURCE_EXHAUSTED") || strings.Contains(errStr, "quota") {
@@ -110,9 +162,17 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 					cancel()
 				}
 			} else {
+				usedIn, usedOut = ar.InputTokens, ar.OutputTokens
 				result.Results = append(result.Results, *ar)
 				result.InputTokens += ar.InputTokens
 				result.OutputTokens += ar.OutputTokens
+				result.Discrepancies = append(result.Discrepancies, ar.Discrepancies...)
+				if t := ar.Analysis.Truncation; t != nil {
+					result.Truncated = append(result.Truncated, TruncationReport{FilePath: f.RelPath, Truncation: *t})
+				}
+				if len(ar.Redactions) > 0 {
+					result.Redacted = append(result.Redacted, RedactionReport{FilePath: f.RelPath, Redactions: ar.Redactions})
+				}
 			}
 			mu.Unlock()
 
@@ -120,7 +180,7 @@ func (b *Batcher) ProcessFiles(ctx context.Context, files []walker.FileInfo) *Ba
 			if b.onProgress != nil {
 				b.onProgress(int(count), total, f.RelPath)
 			}
-		}(file)
+		}(file, estIn, estOut)
 	}
 
 	wg.Wait()
@@ -0,0 +1,122 @@
+package indexer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// Budget caps what a run may spend on file analysis, in US dollars, in
+// tokens, or both. The Batcher checks it before starting each file: a file
+// only starts when what has been spent, plus the estimates for the files
+// still being analyzed, plus its own estimate, fits the budget. Files that
+// don't fit are deferred rather than failed, so the next run picks them up.
+type Budget struct {
+	MaxCostUSD float64 // 0 means no cost limit
+	MaxTokens  int     // 0 means no token limit
+	Model      string  // priced with llm.EstimateCost
+
+	mu                      sync.Mutex
+	inputTokens             int
+	outputTokens            int
+	reservedIn, reservedOut int
+	exceeded                bool
+}
+
+// NewBudget returns a Budget, or nil when neither limit is set.
+func NewBudget(model string, maxCostUSD float64, maxTokens int) *Budget {
+	if maxCostUSD <= 0 && maxTokens <= 0 {
+		return nil
+	}
+	return &Budget{MaxCostUSD: maxCostUSD, MaxTokens: maxTokens, Model: model}
+}
+
+// reserve sets aside the estimated tokens for a file about to be analyzed
+// and reports whether they fit. Once a file doesn't fit, no later file is
+// started either, so the run stops rather than picking off small files.
+func (b *Budget) reserve(in, out int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exceeded {
+		return false
+	}
+	if !b.fits(b.inputTokens+b.reservedIn+in, b.outputTokens+b.reservedOut+out) {
+		b.exceeded = true
+		return false
+	}
+	b.reservedIn += in
+	b.reservedOut += out
+	return true
+}
+
+// charge replaces a file's reservation with what its analysis actually used.
+func (b *Budget) charge(reservedIn, reservedOut, in, out int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reservedIn -= reservedIn
+	b.reservedOut -= reservedOut
+	b.inputTokens += in
+	b.outputTokens += out
+}
+
+func (b *Budget) fits(in, out int) bool {
+	if b.MaxTokens > 0 && in+out > b.MaxTokens {
+		return false
+	}
+	if b.MaxCostUSD > 0 && llm.EstimateCost(b.Model, in, out) > b.MaxCostUSD {
+		return false
+	}
+	return true
+}
+
+// Exceeded reports whether the budget stopped the run from starting a file.
+func (b *Budget) Exceeded() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// Spent returns the tokens charged so far and what they cost.
+func (b *Budget) Spent() (inputTokens, outputTokens int, costUSD float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inputTokens, b.outputTokens, llm.EstimateCost(b.Model, b.inputTokens, b.outputTokens)
+}
+
+// String describes the limits, e.g. "$5.00 or 2000000 tokens".
+func (b *Budget) String() string {
+	switch {
+	case b.MaxCostUSD > 0 && b.MaxTokens > 0:
+		return fmt.Sprintf("$%.2f or %d tokens", b.MaxCostUSD, b.MaxTokens)
+	case b.MaxCostUSD > 0:
+		return fmt.Sprintf("$%.2f", b.MaxCostUSD)
+	}
+	return fmt.Sprintf("%d tokens", b.MaxTokens)
+}
+
+// promptOverheadTokens approximates the instructions sent with every file.
+const promptOverheadTokens = 800
+
+// estimateFileTokens is how many tokens analyzing a file is expected to
+// take, using the same rule of thumb as DryRun: a token per four bytes of
+// what the analyzer reads, plus the tier's typical output.
+func estimateFileTokens(f walker.FileInfo, tier config.QualityTier, sizes config.LargeFilesConfig) (in, out int) {
+	return promptOverheadTokens + int(analyzedSize(sizes, f))/4, outputTokensPerFile(tier)
+}
+
+// outputTokensPerFile is the typical length of an analysis at each tier.
+func outputTokensPerFile(tier config.QualityTier) int {
+	switch tier {
+	case config.QualityMax:
+		return 3000
+	case config.QualityNormal:
+		return 1500
+	}
+	return 500
+}
@@ -14,6 +14,9 @@ func ChunkAnalysisForRepo(analysis *FileAnalysis, tier config.QualityTier, repoI
 	docs := ChunkAnalysis(analysis, tier)
 	for i := range docs {
 		docs[i].Metadata.RepoID = repoID
+		if docs[i].Metadata.SymbolID != "" {
+			docs[i].Metadata.SymbolID = vectordb.SymbolID(repoID, analysis.FilePath, docs[i].Metadata.Symbol)
+		}
 	}
 	return docs
 }
@@ -24,27 +27,9 @@ func ChunkAnalysis(analysis *FileAnalysis, tier config.QualityTier) []vectordb.D
 	now := time.Now()
 
 	// File-level summary document.
-	var fileSummaryParts []string
-	fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("File: %s", analysis.FilePath))
-	fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("Language: %s", analysis.Language))
-	fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("Summary: %s", analysis.Summary))
-	if analysis.Purpose != "" {
-		fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("Purpose: %s", analysis.Purpose))
-	}
-	if len(analysis.Dependencies) > 0 {
-		var deps []string
-		for _, d := range analysis.Dependencies {
-			deps = append(deps, fmt.Sprintf("%s (%s)", d.Name, d.Type))
-		}
-		fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("Dependencies: %s", strings.Join(deps, ", ")))
-	}
-	if len(analysis.KeyLogic) > 0 {
-		fileSummaryParts = append(fileSummaryParts, fmt.Sprintf("Key Logic: %s", strings.Join(analysis.KeyLogic, "; ")))
-	}
-
 	docs = append(docs, vectordb.Document{
 		ID:      fmt.Sprintf("file:%s", analysis.FilePath),
-		Content: strings.Join(fileSummaryParts, "\n"),
+		Content: FormatFileDocument(analysis),
 		Metadata: vectordb.DocumentMetadata{
 			FilePath:    analysis.FilePath,
 			ContentHash: analysis.ContentHash,
@@ -87,49 +72,132 @@ func ChunkAnalysis(analysis *FileAnalysis, tier config.QualityTier) []vectordb.D
 		}
 	}
 
-	// Function-level documents (Normal and Max tiers).
+	// Function-level documents (Normal and Max tiers). Methods get their
+	// own documents too, named Type.method, so each symbol can be found and
+	// referenced on its own.
 	if tier != config.QualityLite {
-		for _, fn := range analysis.Functions {
-			content := buildFunctionContent(analysis.FilePath, fn)
-			docs = append(docs, vectordb.Document{
-				ID:      fmt.Sprintf("func:%s:%s", analysis.FilePath, fn.Name),
+		symbolDoc := func(kind, name, content string, typ vectordb.DocumentType, lineStart, lineEnd int) vectordb.Document {
+			return vectordb.Document{
+				ID:      fmt.Sprintf("%s:%s:%s", kind, analysis.FilePath, name),
 				Content: content,
 				Metadata: vectordb.DocumentMetadata{
 					FilePath:    analysis.FilePath,
-					LineStart:   fn.LineStart,
-					LineEnd:     fn.LineEnd,
+					LineStart:   lineStart,
+					LineEnd:     lineEnd,
 					ContentHash: analysis.ContentHash,
-					Type:        vectordb.DocTypeFunction,
+					Type:        typ,
 					Language:    analysis.Language,
-					Symbol:      fn.Name,
+					Symbol:      name,
+					SymbolID:    vectordb.SymbolID("", analysis.FilePath, name),
 					LastUpdated: now,
 				},
-			})
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, fn := range analysis.Functions {
+			seen[fn.Name] = true
+			docs = append(docs, symbolDoc("func", fn.Name, buildFunctionContent(analysis.FilePath, fn), vectordb.DocTypeFunction, fn.LineStart, fn.LineEnd))
 		}
 
 		// Class-level documents.
 		for _, cls := range analysis.Classes {
-			content := buildClassContent(analysis.FilePath, cls)
-			docs = append(docs, vectordb.Document{
-				ID:      fmt.Sprintf("class:%s:%s", analysis.FilePath, cls.Name),
-				Content: content,
-				Metadata: vectordb.DocumentMetadata{
-					FilePath:    analysis.FilePath,
-					LineStart:   cls.LineStart,
-					LineEnd:     cls.LineEnd,
-					ContentHash: analysis.ContentHash,
-					Type:        vectordb.DocTypeClass,
-					Language:    analysis.Language,
-					Symbol:      cls.Name,
-					LastUpdated: now,
-				},
-			})
+			docs = append(docs, symbolDoc("class", cls.Name, buildClassContent(analysis.FilePath, cls), vectordb.DocTypeClass, cls.LineStart, cls.LineEnd))
+			for _, m := range cls.Methods {
+				m.Name = cls.Name + "." + m.Name
+				if seen[m.Name] {
+					continue
+				}
+				seen[m.Name] = true
+				docs = append(docs, symbolDoc("func", m.Name, buildFunctionContent(analysis.FilePath, m), vectordb.DocTypeFunction, m.LineStart, m.LineEnd))
+			}
 		}
 	}
 
 	return docs
 }
 
+// File document field labels. The file-level vector store document is
+// written by FormatFileDocument and read back only by ParseFileDocument, so
+// the layout is owned by this package.
+const (
+	fileDocFile         = "File: "
+	fileDocLanguage     = "Language: "
+	fileDocSummary      = "Summary: "
+	fileDocPurpose      = "Purpose: "
+	fileDocDependencies = "Dependencies: "
+	fileDocKeyLogic     = "Key Logic: "
+)
+
+// FormatFileDocument renders the file-level document stored in the vector
+// store for an analysis.
+func FormatFileDocument(analysis *FileAnalysis) string {
+	var parts []string
+	parts = append(parts, fileDocFile+analysis.FilePath)
+	parts = append(parts, fileDocLanguage+analysis.Language)
+	parts = append(parts, fileDocSummary+analysis.Summary)
+	if analysis.Purpose != "" {
+		parts = append(parts, fileDocPurpose+analysis.Purpose)
+	}
+	if len(analysis.Dependencies) > 0 {
+		var deps []string
+		for _, d := range analysis.Dependencies {
+			deps = append(deps, fmt.Sprintf("%s (%s)", d.Name, d.Type))
+		}
+		parts = append(parts, fileDocDependencies+strings.Join(deps, ", "))
+	}
+	if len(analysis.KeyLogic) > 0 {
+		parts = append(parts, fileDocKeyLogic+strings.Join(analysis.KeyLogic, "; "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ParseFileDocument recovers the file-level fields of an analysis from a
+// document written by FormatFileDocument. Functions and classes are not part
+// of the file document and are left empty.
+func ParseFileDocument(content string) FileAnalysis {
+	var a FileAnalysis
+	var field *string
+	var deps, keyLogic string
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, fileDocFile):
+			a.FilePath, field = strings.TrimPrefix(line, fileDocFile), nil
+		case strings.HasPrefix(line, fileDocLanguage):
+			a.Language, field = strings.TrimPrefix(line, fileDocLanguage), nil
+		case strings.HasPrefix(line, fileDocSummary):
+			a.Summary, field = strings.TrimPrefix(line, fileDocSummary), &a.Summary
+		case strings.HasPrefix(line, fileDocPurpose):
+			a.Purpose, field = strings.TrimPrefix(line, fileDocPurpose), &a.Purpose
+		case strings.HasPrefix(line, fileDocDependencies):
+			deps, field = strings.TrimPrefix(line, fileDocDependencies), nil
+		case strings.HasPrefix(line, fileDocKeyLogic):
+			keyLogic, field = strings.TrimPrefix(line, fileDocKeyLogic), nil
+		case field != nil:
+			// Summaries and purposes may span several lines.
+			*field += "\n" + line
+		}
+	}
+
+	for _, part := range strings.Split(deps, ", ") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d := Dependency{Name: part}
+		if i := strings.LastIndex(part, " ("); i > 0 && strings.HasSuffix(part, ")") {
+			d.Name, d.Type = part[:i], part[i+2:len(part)-1]
+		}
+		a.Dependencies = append(a.Dependencies, d)
+	}
+	for _, item := range strings.Split(keyLogic, "; ") {
+		if item = strings.TrimSpace(item); item != "" {
+			a.KeyLogic = append(a.KeyLogic, item)
+		}
+	}
+	return a
+}
+
 func buildFunctionContent(filePath string, fn FunctionDoc) string {
 	var parts []string
 	parts = append(parts, fmt.Sprintf("Function: %s", fn.Name))
@@ -147,10 +215,37 @@ func buildFunctionContent(filePath string, fn FunctionDoc) string {
 	if fn.Returns != "" {
 		parts = append(parts, fmt.Sprintf("Returns: %s", fn.Returns))
 	}
+	if len(fn.Calls) > 0 {
+		parts = append(parts, symbolDocCalls+strings.Join(fn.Calls, ", "))
+	}
+	if len(fn.Uses) > 0 {
+		parts = append(parts, symbolDocUses+strings.Join(fn.Uses, ", "))
+	}
 	parts = append(parts, fmt.Sprintf("File: %s", filePath))
 	return strings.Join(parts, "\n")
 }
 
+// Reference labels in function documents, read back by
+// ParseSymbolReferences.
+const (
+	symbolDocCalls = "Calls: "
+	symbolDocUses  = "Uses types: "
+)
+
+// ParseSymbolReferences recovers the calls and type uses from a function
+// document written by ChunkAnalysis.
+func ParseSymbolReferences(content string) (calls, uses []string) {
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, symbolDocCalls):
+			calls = strings.Split(strings.TrimPrefix(line, symbolDocCalls), ", ")
+		case strings.HasPrefix(line, symbolDocUses):
+			uses = strings.Split(strings.TrimPrefix(line, symbolDocUses), ", ")
+		}
+	}
+	return calls, uses
+}
+
 func buildClassContent(filePath string, cls ClassDoc) string {
 	var parts []string
 	parts = append(parts, fmt.Sprintf("Class/Type: %s", cls.Name))
@@ -0,0 +1,296 @@
+package indexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of configuration a service reads.
+const (
+	KnobEnv     = "env"     // an environment variable
+	KnobFlag    = "flag"    // a command-line flag
+	KnobSetting = "setting" // a key in a config file or property source
+	KnobFeature = "feature" // a feature flag
+)
+
+// ConfigKnob is one piece of runtime configuration a file reads.
+type ConfigKnob struct {
+	Kind string // a Knob* kind
+	// Name is the variable, flag, key, or feature flag, e.g. "DATABASE_URL",
+	// "--port", "orders.timeout", or "new-checkout".
+	Name string
+	// Default is the value used when it isn't set, as written in the
+	// source; empty when the source gives none.
+	Default string
+	Line    int // 1-based
+	// Evidence is how it is read, e.g. "os.Getenv", "flag.Int", an
+	// `envconfig` struct tag, or "LaunchDarkly".
+	Evidence string
+}
+
+var (
+	// Reads of one environment variable, with an optional default as the
+	// second argument: os.Getenv, os.getenv, os.environ.get,
+	// System.getenv, env::var, and getEnv("X", "fallback")-style helpers.
+	envCallRe  = regexp.MustCompile(`\b(os\.Getenv|os\.LookupEnv|os\.getenv|os\.environ\.get|System\.getenv|(?:std::)?env::var(?:_os)?|\w*[gG]et_?[eE]nv\w*|\w*[eE]nv(?:Or|OrDefault|_or)\w*)\(\s*["']([A-Za-z_][A-Za-z0-9_]*)["']`)
+	envIndexRe = regexp.MustCompile(`\b(os\.environ|process\.env)\[\s*["']([A-Za-z_][A-Za-z0-9_]*)["']\s*\]`)
+	envPropRe  = regexp.MustCompile(`\b(process\.env|import\.meta\.env)\.([A-Za-z_][A-Za-z0-9_]*)(?:\s*(?:\|\||\?\?)\s*("[^"]*"|'[^']*'|` + "`[^`]*`" + `|[\w.]+))?`)
+	cmpOrEnvRe = regexp.MustCompile(`\bcmp\.Or\(\s*os\.Getenv\(\s*"([A-Za-z_][A-Za-z0-9_]*)"\s*\)\s*,\s*("[^"]*"|[\w.]+)`)
+	// Struct tags naming an environment variable: caarlos0/env's env and
+	// envDefault, kelseyhightower/envconfig's envconfig and default.
+	envTagRe     = regexp.MustCompile("`[^`]*\\b(env|envconfig):\"([A-Za-z_][A-Za-z0-9_]*)[^\"]*\"[^`]*`")
+	envDefaultRe = regexp.MustCompile(`\b(?:envDefault|default):"([^"]*)"`)
+	// clap's #[arg(env = "X", default_value = "d")].
+	clapEnvRe     = regexp.MustCompile(`#\[(?:arg|clap)\([^\]]*\benv\s*=\s*"([A-Za-z_][A-Za-z0-9_]*)"`)
+	clapDefaultRe = regexp.MustCompile(`\bdefault_value(?:_t)?\s*=\s*("[^"]*"|[\w.]+)`)
+
+	goFlagRe     = regexp.MustCompile(`\b(flag|pflag|(?:Persistent)?Flags\(\))\.(String|Int|Int64|Uint|Uint64|Bool|Float64|Duration|StringSlice|StringArray|IntSlice|Count)(Var)?(P)?\(`)
+	pyArgRe      = regexp.MustCompile(`\.add_argument\(|@click\.option\(|\btyper\.Option\(`)
+	scriptOptRe  = regexp.MustCompile(`\.(?:option|requiredOption)\(\s*["'` + "`" + `]([^"'` + "`" + `]*)["'` + "`" + `]`)
+	longOptionRe = regexp.MustCompile(`--[A-Za-z0-9][\w-]*`)
+
+	viperGetRe     = regexp.MustCompile(`\bviper\.(?:Get\w*|IsSet)\(\s*"([^"]+)"`)
+	viperDefaultRe = regexp.MustCompile(`\bviper\.SetDefault\(\s*"([^"]+)"\s*,\s*("[^"]*"|[^)]+)\)`)
+	springValueRe  = regexp.MustCompile(`@Value\(\s*"\$\{([^}:]+)(?::([^}]*))?\}"\s*\)`)
+	propertyRe     = regexp.MustCompile(`\b(?:getProperty|System\.getProperty)\(\s*"([\w.\-]+)"\s*(?:,\s*("[^"]*"|[\w.]+))?\)`)
+
+	settingsClassRe = regexp.MustCompile(`^class\s+\w+\(\s*(?:\w+\.)?BaseSettings\s*\)\s*:`)
+	settingsFieldRe = regexp.MustCompile(`^\s+([a-z_][a-z0-9_]*)\s*:\s*[^=]+?(?:=\s*(.+?))?\s*$`)
+)
+
+// featureSDKs are the feature flag SDKs recognized, the calls that check a
+// flag, and the position of the fallback value after the flag's key; 0 when
+// the call takes none. A file must mention the SDK's marker for its calls
+// to count, since their names are otherwise common.
+var featureSDKs = []struct {
+	provider string
+	markers  []string
+	call     *regexp.Regexp
+	fallback int
+}{
+	// LaunchDarkly: BoolVariation(key, context, fallback), variation(...).
+	{"LaunchDarkly", []string{"launchdarkly", "ldclient", "ld_client"}, regexp.MustCompile(`\b(?:[bB]ool|[sS]tring|[iI]nt|[fF]loat64|[fF]loat|[dD]ouble|JSON|[jJ]son(?:Value)?)?[vV]ariation(?:Detail)?\(`), 2},
+	// Unleash: IsEnabled(key), isEnabled(key), is_enabled(key).
+	{"Unleash", []string{"unleash"}, regexp.MustCompile(`\b(?:IsEnabled|isEnabled|is_enabled|GetVariant|getVariant|get_variant)\(`), 0},
+	// OpenFeature: BooleanValue(ctx, key, fallback, ...), getBooleanValue(key, fallback).
+	{"OpenFeature", []string{"openfeature"}, regexp.MustCompile(`\b(?:get_?)?(?:[bB]oolean|[sS]tring|[nN]umber|[iI]nteger|[iI]nt|[fF]loat|[oO]bject)_?[vV]alue(?:Details)?\(`), 1},
+	// Flagsmith: is_feature_enabled(key), hasFeature(key).
+	{"Flagsmith", []string{"flagsmith"}, regexp.MustCompile(`\b(?:is_feature_enabled|isFeatureEnabled|hasFeature|get_feature_value|getValue)\(`), 0},
+}
+
+// ScanConfig finds the runtime configuration a file reads: environment
+// variables (through the standard library, helpers taking a fallback, and
+// env struct tags and settings classes), command-line flags (Go's flag and
+// Cobra/pflag, argparse, click, commander), config keys (Viper, Spring
+// @Value, system properties), and the feature flags it checks through the
+// LaunchDarkly, Unleash, OpenFeature, or Flagsmith SDKs. Code in comments
+// doesn't count.
+func ScanConfig(content []byte, language string) []ConfigKnob {
+	python := language == "Python"
+	s := lexSource(string(content), python)
+	code := strings.Join(s.code, "\n")
+	lineAt := func(offset int) int { return strings.Count(code[:offset], "\n") + 1 }
+
+	var out []ConfigKnob
+	seen := make(map[string]bool)
+	add := func(k ConfigKnob) {
+		k.Default = literalValue(k.Default)
+		key := k.Kind + "\x00" + k.Name + "\x00" + k.Evidence
+		if k.Name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, k)
+	}
+	args := func(openParen int) []string {
+		return splitParams(parenContents(code[openParen:]))
+	}
+
+	for _, m := range cmpOrEnvRe.FindAllStringSubmatchIndex(code, -1) {
+		add(ConfigKnob{Kind: KnobEnv, Name: code[m[2]:m[3]], Default: code[m[4]:m[5]], Line: lineAt(m[0]), Evidence: "os.Getenv"})
+	}
+	for _, m := range envCallRe.FindAllStringSubmatchIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobEnv, Name: code[m[4]:m[5]], Line: lineAt(m[0]), Evidence: code[m[2]:m[3]]}
+		if a := args(m[3]); len(a) > 1 && k.Evidence != "os.Getenv" && k.Evidence != "os.LookupEnv" {
+			k.Default = a[1]
+		}
+		add(k)
+	}
+	for _, m := range envIndexRe.FindAllStringSubmatchIndex(code, -1) {
+		add(ConfigKnob{Kind: KnobEnv, Name: code[m[4]:m[5]], Line: lineAt(m[0]), Evidence: code[m[2]:m[3]]})
+	}
+	for _, m := range envPropRe.FindAllStringSubmatchIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobEnv, Name: code[m[4]:m[5]], Line: lineAt(m[0]), Evidence: code[m[2]:m[3]]}
+		if m[6] >= 0 {
+			k.Default = code[m[6]:m[7]]
+		}
+		add(k)
+	}
+	for _, m := range envTagRe.FindAllStringSubmatchIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobEnv, Name: code[m[4]:m[5]], Line: lineAt(m[0]), Evidence: "`" + code[m[2]:m[3]] + "` struct tag"}
+		if d := envDefaultRe.FindStringSubmatch(code[m[0]:m[1]]); d != nil {
+			k.Default = `"` + d[1] + `"`
+		}
+		add(k)
+	}
+	for _, m := range clapEnvRe.FindAllStringSubmatchIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobEnv, Name: code[m[2]:m[3]], Line: lineAt(m[0]), Evidence: "clap"}
+		attr := code[m[0]:]
+		attr = attr[:strings.Index(attr, "]")+1]
+		if d := clapDefaultRe.FindStringSubmatch(attr); d != nil {
+			k.Default = d[1]
+		}
+		add(k)
+	}
+	if python {
+		scanSettingsClasses(s, add)
+	}
+
+	for _, m := range goFlagRe.FindAllStringSubmatchIndex(code, -1) {
+		a := args(m[1] - 1)
+		if m[6] >= 0 { // the Var forms take the variable first
+			a = a[min(1, len(a)):]
+		}
+		name, rest := "", []string(nil)
+		if len(a) > 0 {
+			name, rest = literalValue(a[0]), a[1:]
+		}
+		if m[8] >= 0 && len(rest) > 0 { // the P forms take a shorthand next
+			rest = rest[1:]
+		}
+		if name == "" || strings.ContainsAny(name, " (") {
+			continue
+		}
+		k := ConfigKnob{Kind: KnobFlag, Name: "--" + name, Line: lineAt(m[0]), Evidence: code[m[2]:m[3]] + "." + code[m[4]:m[5]]}
+		if len(rest) > 0 && code[m[4]:m[5]] != "Count" {
+			k.Default = rest[0]
+		}
+		add(k)
+	}
+	for _, m := range pyArgRe.FindAllStringIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobFlag, Line: lineAt(m[0]), Evidence: strings.Trim(code[m[0]:m[1]], ".@(")}
+		for _, a := range args(m[1] - 1) {
+			if d, ok := strings.CutPrefix(a, "default="); ok {
+				k.Default = d
+			} else if k.Name == "" {
+				k.Name = longOptionRe.FindString(literalValue(a))
+			}
+		}
+		add(k)
+	}
+	if language == "JavaScript" || language == "TypeScript" {
+		for _, m := range scriptOptRe.FindAllStringSubmatchIndex(code, -1) {
+			k := ConfigKnob{Kind: KnobFlag, Name: longOptionRe.FindString(code[m[2]:m[3]]), Line: lineAt(m[0]), Evidence: "commander"}
+			if a := args(strings.Index(code[m[0]:], "(") + m[0]); len(a) > 2 {
+				k.Default = a[2]
+			}
+			add(k)
+		}
+	}
+
+	defaults := make(map[string]string)
+	for _, m := range viperDefaultRe.FindAllStringSubmatch(code, -1) {
+		defaults[m[1]] = strings.TrimSpace(m[2])
+	}
+	for _, m := range viperGetRe.FindAllStringSubmatchIndex(code, -1) {
+		name := code[m[2]:m[3]]
+		add(ConfigKnob{Kind: KnobSetting, Name: name, Default: defaults[name], Line: lineAt(m[0]), Evidence: "viper"})
+	}
+	for _, m := range springValueRe.FindAllStringSubmatchIndex(code, -1) {
+		k := ConfigKnob{Kind: KnobSetting, Name: code[m[2]:m[3]], Line: lineAt(m[0]), Evidence: "@Value"}
+		if m[4] >= 0 {
+			k.Default = `"` + code[m[4]:m[5]] + `"`
+		}
+		add(k)
+	}
+	if language == "Java" || language == "Kotlin" {
+		for _, m := range propertyRe.FindAllStringSubmatchIndex(code, -1) {
+			k := ConfigKnob{Kind: KnobSetting, Name: code[m[2]:m[3]], Line: lineAt(m[0]), Evidence: "getProperty"}
+			if m[4] >= 0 {
+				k.Default = code[m[4]:m[5]]
+			}
+			add(k)
+		}
+	}
+
+	lower := strings.ToLower(string(content))
+	for _, sdk := range featureSDKs {
+		if !containsAny(lower, sdk.markers) {
+			continue
+		}
+		for _, m := range sdk.call.FindAllStringIndex(code, -1) {
+			a := args(m[1] - 1)
+			key := -1
+			for i, arg := range a {
+				if isStringLiteral(arg) {
+					key = i
+					break
+				}
+			}
+			if key < 0 {
+				continue
+			}
+			k := ConfigKnob{Kind: KnobFeature, Name: literalValue(a[key]), Line: lineAt(m[0]), Evidence: sdk.provider}
+			if sdk.fallback > 0 && key+sdk.fallback < len(a) {
+				k.Default = a[key+sdk.fallback]
+			}
+			add(k)
+		}
+	}
+	return out
+}
+
+// scanSettingsClasses reads the fields of pydantic BaseSettings classes,
+// which are set from the environment variables of the same name.
+func scanSettingsClasses(s *scanned, add func(ConfigKnob)) {
+	for i := 0; i < len(s.code); i++ {
+		if !settingsClassRe.MatchString(s.code[i]) {
+			continue
+		}
+		indent := indentOf(s.code[i])
+		for j := i + 1; j < len(s.code); j++ {
+			line := s.code[j]
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if indentOf(line) <= indent {
+				break
+			}
+			m := settingsFieldRe.FindStringSubmatch(line)
+			if m == nil || m[1] == "model_config" || indentOf(line) > indent+4 {
+				continue
+			}
+			def := m[2]
+			if strings.HasPrefix(def, "Field(") {
+				def = ""
+				if a := splitParams(parenContents(m[2])); len(a) > 0 && !strings.Contains(a[0], "=") {
+					def = a[0]
+				}
+			}
+			add(ConfigKnob{Kind: KnobEnv, Name: strings.ToUpper(m[1]), Default: def, Line: j + 1, Evidence: "BaseSettings"})
+		}
+	}
+}
+
+// literalValue unquotes a string literal; other values are kept as
+// written.
+func literalValue(v string) string {
+	v = strings.TrimSpace(v)
+	if isStringLiteral(v) {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+func isStringLiteral(v string) bool {
+	return len(v) >= 2 && strings.ContainsRune(`"'`+"`", rune(v[0])) && v[len(v)-1] == v[0]
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package indexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScanConfig(t *testing.T) {
+	tests := []struct {
+		name, language, src string
+		want                []string // "kind name=default line evidence"
+	}{
+		{
+			name: "go", language: "Go",
+			src: "package main\n\n" +
+				"import ld \"github.com/launchdarkly/go-server-sdk/v7\"\n\n" +
+				"type Config struct {\n" +
+				"\tDatabaseURL string `env:\"DATABASE_URL,required\"`\n" +
+				"\tTimeout time.Duration `envconfig:\"TIMEOUT\" default:\"5s\"`\n" +
+				"}\n\n" +
+				"func main() {\n" +
+				"\tport := cmp.Or(os.Getenv(\"PORT\"), \"8080\")\n" +
+				"\t// os.Getenv(\"COMMENTED_OUT\")\n" +
+				"\tregion := os.Getenv(\"AWS_REGION\")\n" +
+				"\tworkers := flag.Int(\"workers\", 4, \"worker count\")\n" +
+				"\tcmd.Flags().StringVarP(&mode, \"mode\", \"m\", \"fast\", \"run mode\")\n" +
+				"\tviper.SetDefault(\"cache.ttl\", \"10m\")\n" +
+				"\tttl := viper.GetDuration(\"cache.ttl\")\n" +
+				"\tnewCheckout, _ := client.BoolVariation(\"new-checkout\", user, false)\n" +
+				"}\n",
+			want: []string{
+				"env PORT=8080 11 os.Getenv",
+				"env AWS_REGION= 13 os.Getenv",
+				"env DATABASE_URL= 6 `env` struct tag",
+				"env TIMEOUT=5s 7 `envconfig` struct tag",
+				"flag --workers=4 14 flag.Int",
+				"flag --mode=fast 15 Flags().String",
+				"setting cache.ttl=10m 17 viper",
+				"feature new-checkout=false 18 LaunchDarkly",
+			},
+		},
+		{
+			name: "python", language: "Python",
+			src: `from UnleashClient import UnleashClient
+from pydantic_settings import BaseSettings
+
+class Settings(BaseSettings):
+    redis_url: str = "redis://localhost:6379"
+    debug: bool = False
+
+timeout = int(os.environ.get("TIMEOUT", "30"))
+secret = os.environ["SECRET_KEY"]
+parser.add_argument("--dry-run", action="store_true", default=False, help="print only")
+if unleash.is_enabled("beta-search"):
+    pass
+`,
+			want: []string{
+				"env TIMEOUT=30 8 os.environ.get",
+				"env SECRET_KEY= 9 os.environ",
+				"env REDIS_URL=redis://localhost:6379 5 BaseSettings",
+				"env DEBUG=False 6 BaseSettings",
+				"flag --dry-run=False 10 add_argument",
+				"feature beta-search= 11 Unleash",
+			},
+		},
+		{
+			name: "typescript", language: "TypeScript",
+			src: `import { OpenFeature } from '@openfeature/server-sdk';
+const port = process.env.PORT ?? '3000';
+const key = process.env["API_KEY"];
+program.option('--verbose', 'log more', false);
+const enabled = await client.getBooleanValue('dark-mode', false);
+`,
+			want: []string{
+				"env API_KEY= 3 process.env",
+				"env PORT=3000 2 process.env",
+				"flag --verbose=false 4 commander",
+				"feature dark-mode=false 5 OpenFeature",
+			},
+		},
+		{
+			name: "spring", language: "Java",
+			src: `public class PaymentsConfig {
+    @Value("${payments.retries:3}")
+    private int retries;
+    private final String key = System.getenv("STRIPE_KEY");
+}
+`,
+			want: []string{
+				"env STRIPE_KEY= 4 System.getenv",
+				"setting payments.retries=3 2 @Value",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, k := range ScanConfig([]byte(tt.src), tt.language) {
+			got = append(got, fmt.Sprintf("%s %s=%s %d %s", k.Kind, k.Name, k.Default, k.Line, k.Evidence))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
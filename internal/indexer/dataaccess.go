@@ -0,0 +1,237 @@
+package indexer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Data stores a DataAccess can name; SQL tables name none, as the query
+// doesn't say which database it runs on.
+const (
+	StoreMongoDB = "mongodb"
+	StoreRedis   = "redis"
+)
+
+// DataAccess is a read from or write to a data store a file's code makes.
+type DataAccess struct {
+	// Store is StoreMongoDB or StoreRedis; empty for SQL tables.
+	Store string
+	// Table is the table or collection, as the query names it or as its
+	// model names it by the usual convention (OrderItem is order_items);
+	// empty for caches.
+	Table string
+	Write bool
+	Line  int // 1-based
+	// Evidence is how the access is made, e.g. "SQL", "GORM", "Django ORM",
+	// "Prisma", or "Spring Data".
+	Evidence string
+}
+
+var (
+	sqlStartRe = regexp.MustCompile(`(?is)^\s*(?:select|insert|update|delete|with|merge|replace|upsert|truncate)\s`)
+	sqlWriteRe = regexp.MustCompile(`(?i)\b(?:insert\s+(?:ignore\s+)?into|update|delete\s+from|merge\s+into|replace\s+into|upsert\s+into|truncate(?:\s+table)?)\s+["` + "`" + `\[]?([A-Za-z_][\w.]*)`)
+	sqlReadRe  = regexp.MustCompile(`(?i)\b(delete\s+)?(?:from|join)\s+["` + "`" + `\[]?([A-Za-z_][\w.]*)`)
+	// sqlNotTables are the words after UPDATE and FROM that aren't tables,
+	// as in ON CONFLICT DO UPDATE SET and SELECT ... FOR UPDATE NOWAIT.
+	sqlNotTables = map[string]bool{"set": true, "select": true, "where": true, "lateral": true, "only": true, "nowait": true, "skip": true, "of": true, "unnest": true, "dual": true}
+
+	gormModelRe   = regexp.MustCompile(`\.(?:Model\(\s*&(\w+)\{|Table\(\s*"(\w+)"|(?:Create|Save|Delete|First|Find|Take|Last)\(\s*&(\w+)\{)`)
+	gormWriteRe   = regexp.MustCompile(`\.(?:Create|Save|Update|Updates|UpdateColumn|UpdateColumns|Delete|Exec)\(`)
+	gormReadRe    = regexp.MustCompile(`\.(?:First|Find|Take|Last|Scan|Count|Pluck|Rows)\(`)
+	djangoRe      = regexp.MustCompile(`\b([A-Z]\w*)\.objects\.(\w+)\(`)
+	sqlalchemyRe  = regexp.MustCompile(`\b(?:session\.(query|add|merge|delete)\(\s*([A-Z]\w*)|\b(select|insert|update|delete)\(\s*([A-Z]\w*)\s*\))`)
+	prismaRe      = regexp.MustCompile(`\bprisma\.(\w+)\.(\w+)\(`)
+	modelCallRe   = regexp.MustCompile(`\b([A-Z]\w*)\.(\w+)\(`)
+	repositoryRe  = regexp.MustCompile(`\b([a-z]\w*?)(?:Repository|Repo)\.(\w+)\(`)
+	mongoCollRe   = regexp.MustCompile(`(?i)\.collection\(\s*["'](\w+)["']\s*\)\s*\.(\w+)\(|\bdb\[\s*["'](\w+)["']\s*\]\.(\w+)\(|\bdb\.(\w+)\.(\w+)\(`)
+	redisClientRe = regexp.MustCompile(`(?i)\b(\w*(?:redis|rdb|cache)\w*)\.(\w+)\(`)
+)
+
+// ormWrites and ormReads are the methods of model and repository APIs
+// (Sequelize, TypeORM, Mongoose, Spring Data, Django) that write and read,
+// lowercased.
+var (
+	ormWrites = map[string]bool{"create": true, "bulkcreate": true, "bulk_create": true, "bulk_update": true, "get_or_create": true, "update_or_create": true,
+		"update": true, "updateone": true, "updatemany": true, "destroy": true, "upsert": true, "findorcreate": true, "insert": true, "insertmany": true,
+		"deleteone": true, "deletemany": true, "delete": true, "remove": true, "findoneandupdate": true, "findbyidandupdate": true,
+		"findbyidanddelete": true, "save": true, "saveall": true, "saveandflush": true, "deleteall": true, "deletebyid": true, "createmany": true}
+	ormReads = map[string]bool{"findall": true, "findone": true, "findbypk": true, "findandcountall": true, "count": true, "find": true, "findbyid": true,
+		"aggregate": true, "countdocuments": true, "findmany": true, "findunique": true, "findfirst": true, "filter": true, "get": true, "all": true,
+		"exclude": true, "values": true, "values_list": true, "exists": true, "first": true, "last": true, "findoneby": true, "findby": true, "findallbyid": true}
+	mongoWrites = map[string]bool{"insertone": true, "insertmany": true, "updateone": true, "updatemany": true, "replaceone": true, "deleteone": true,
+		"deletemany": true, "findoneandupdate": true, "findoneanddelete": true, "findoneandreplace": true, "bulkwrite": true, "insert": true, "save": true}
+	mongoReads  = map[string]bool{"find": true, "findone": true, "aggregate": true, "countdocuments": true, "distinct": true, "estimateddocumentcount": true}
+	redisWrites = map[string]bool{"set": true, "setex": true, "setnx": true, "mset": true, "hset": true, "hmset": true, "hdel": true, "del": true,
+		"delete": true, "unlink": true, "incr": true, "incrby": true, "decr": true, "expire": true, "lpush": true, "rpush": true, "lpop": true, "rpop": true, "sadd": true, "srem": true,
+		"zadd": true, "zrem": true, "xadd": true, "getdel": true, "getset": true}
+	redisReads = map[string]bool{"get": true, "mget": true, "hget": true, "hgetall": true, "hmget": true, "exists": true, "lrange": true, "smembers": true,
+		"sismember": true, "zrange": true, "zrangebyscore": true, "zscore": true, "ttl": true, "scan": true, "keys": true, "llen": true,
+		"scard": true, "zcard": true, "xread": true, "xrange": true}
+	// notModels are the JavaScript built-ins whose static methods look like
+	// a model's.
+	notModels = map[string]bool{"Object": true, "Array": true, "Promise": true, "Date": true, "Math": true, "JSON": true, "Reflect": true,
+		"Symbol": true, "Number": true, "String": true, "Buffer": true, "Map": true, "Set": true, "Error": true}
+)
+
+// ScanDataAccess finds the reads and writes a file makes to data stores:
+// SQL statements in its string literals; GORM, Django ORM, SQLAlchemy,
+// Prisma, Sequelize, TypeORM, Mongoose, and Spring Data calls; MongoDB
+// collection calls; and Redis commands. Each table, store, and mode is
+// listed once, at its first line.
+func ScanDataAccess(content []byte, language string) []DataAccess {
+	s := lexSource(string(content), language == "Python")
+	var out []DataAccess
+	seen := make(map[DataAccess]bool)
+	add := func(store, table string, write bool, line int, evidence string) {
+		key := DataAccess{Store: store, Table: table, Write: write}
+		if seen[key] || sqlNotTables[strings.ToLower(table)] {
+			return
+		}
+		seen[key] = true
+		out = append(out, DataAccess{Store: store, Table: table, Write: write, Line: line, Evidence: evidence})
+	}
+
+	for _, l := range s.literals {
+		if !sqlStartRe.MatchString(l.text) {
+			continue
+		}
+		for _, m := range sqlWriteRe.FindAllStringSubmatch(l.text, -1) {
+			add("", m[1], true, l.line+1, "SQL")
+		}
+		for _, m := range sqlReadRe.FindAllStringSubmatch(l.text, -1) {
+			if m[1] == "" {
+				add("", m[2], false, l.line+1, "SQL")
+			}
+		}
+	}
+
+	code := strings.Join(s.code, "\n")
+	gorm := strings.Contains(code, "gorm")
+	redis := strings.Contains(strings.ToLower(code), "redis")
+	var modelEvidence string
+	switch {
+	case strings.Contains(code, "sequelize") || strings.Contains(code, "Sequelize"):
+		modelEvidence = "Sequelize"
+	case strings.Contains(code, "mongoose"):
+		modelEvidence = "Mongoose"
+	case strings.Contains(code, "typeorm"):
+		modelEvidence = "TypeORM"
+	}
+	for i, line := range s.code {
+		n := i + 1
+		switch language {
+		case "Go":
+			if gorm {
+				if m := gormModelRe.FindStringSubmatch(line); m != nil {
+					table := m[2]
+					if table == "" {
+						table = tableName(m[1] + m[3])
+					}
+					if gormWriteRe.MatchString(line) {
+						add("", table, true, n, "GORM")
+					}
+					if gormReadRe.MatchString(line) {
+						add("", table, false, n, "GORM")
+					}
+				}
+			}
+		case "Python":
+			for _, m := range djangoRe.FindAllStringSubmatch(line, -1) {
+				method := m[2]
+				rest := line[strings.Index(line, m[0])+len(m[0]):]
+				if ormWrites[method] || strings.Contains(rest, ".update(") || strings.Contains(rest, ".delete(") {
+					add("", tableName(m[1]), true, n, "Django ORM")
+				} else if ormReads[method] {
+					add("", tableName(m[1]), false, n, "Django ORM")
+				}
+			}
+			for _, m := range sqlalchemyRe.FindAllStringSubmatch(line, -1) {
+				op, model := m[1]+m[3], m[2]+m[4]
+				add("", tableName(model), op != "query" && op != "select", n, "SQLAlchemy")
+			}
+		case "JavaScript", "TypeScript":
+			for _, m := range prismaRe.FindAllStringSubmatch(line, -1) {
+				method := strings.ToLower(m[2])
+				if ormWrites[method] || ormReads[method] {
+					add("", tableName(m[1]), ormWrites[method], n, "Prisma")
+				}
+			}
+			if modelEvidence != "" {
+				for _, m := range modelCallRe.FindAllStringSubmatch(line, -1) {
+					method := strings.ToLower(m[2])
+					if !notModels[m[1]] && (ormWrites[method] || ormReads[method]) {
+						add("", tableName(m[1]), ormWrites[method], n, modelEvidence)
+					}
+				}
+			}
+		}
+		if evidence := repositoryEvidence[language]; evidence != "" {
+			for _, m := range repositoryRe.FindAllStringSubmatch(line, -1) {
+				if write, ok := repositoryMethod(m[2]); ok {
+					add("", tableName(m[1]), write, n, evidence)
+				}
+			}
+		}
+		for _, m := range mongoCollRe.FindAllStringSubmatch(line, -1) {
+			coll, method := m[1]+m[3]+m[5], strings.ToLower(strings.ReplaceAll(m[2]+m[4]+m[6], "_", ""))
+			if mongoWrites[method] || mongoReads[method] {
+				add(StoreMongoDB, coll, mongoWrites[method], n, "MongoDB")
+			}
+		}
+		if redis {
+			for _, m := range redisClientRe.FindAllStringSubmatch(line, -1) {
+				method := strings.ToLower(m[2])
+				if redisWrites[method] || redisReads[method] {
+					add(StoreRedis, "", redisWrites[method], n, "Redis")
+				}
+			}
+		}
+	}
+	return out
+}
+
+// repositoryEvidence names the repository APIs of the languages that have
+// them.
+var repositoryEvidence = map[string]string{"Java": "Spring Data", "Kotlin": "Spring Data", "JavaScript": "TypeORM", "TypeScript": "TypeORM"}
+
+// repositoryMethod says whether a repository method, as Spring Data and
+// TypeORM name them, writes; ok is false for methods that are neither
+// reads nor writes.
+func repositoryMethod(method string) (write, ok bool) {
+	lower := strings.ToLower(method)
+	for _, p := range []string{"save", "delete", "insert", "update", "remove", "upsert", "create"} {
+		if strings.HasPrefix(lower, p) {
+			return true, true
+		}
+	}
+	for _, p := range []string{"find", "get", "count", "exists", "read", "query", "search", "stream"} {
+		if strings.HasPrefix(lower, p) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// tableName names a model's table by the usual convention: snake_case and
+// plural, so OrderItem is order_items and Category is categories.
+func tableName(model string) string {
+	var b strings.Builder
+	for i, r := range model {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(model[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	switch {
+	case strings.HasSuffix(name, "s"):
+		return name
+	case strings.HasSuffix(name, "y") && !strings.HasSuffix(name, "ay") && !strings.HasSuffix(name, "ey") && !strings.HasSuffix(name, "oy"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	}
+	return name + "s"
+}
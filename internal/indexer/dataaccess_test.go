@@ -0,0 +1,95 @@
+package indexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScanDataAccess(t *testing.T) {
+	tests := []struct {
+		name, language, src string
+		want                []string // "store/table read|write line evidence"
+	}{
+		{
+			name: "sql", language: "Go",
+			src: `package orders
+
+const insertOrder = "INSERT INTO orders (id, total) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET total = $2"
+
+// "SELECT * FROM audit" in a comment doesn't count.
+func list(db *sql.DB) {
+	db.Query(` + "`" + `SELECT o.id FROM orders o
+		JOIN customers c ON c.id = o.customer_id FOR UPDATE OF o` + "`" + `)
+	db.Exec("DELETE FROM carts WHERE expires_at < now()")
+}
+`,
+			want: []string{"/orders write 3 SQL", "/orders read 7 SQL", "/customers read 7 SQL", "/carts write 9 SQL"},
+		},
+		{
+			name: "gorm", language: "Go",
+			src: `package orders
+
+import "gorm.io/gorm"
+
+func save(db *gorm.DB, o Order) {
+	db.Create(&OrderItem{OrderID: o.ID})
+	db.Model(&Order{}).Where("id = ?", o.ID).Updates(o)
+	db.Table("refunds").Where("order_id = ?", o.ID).Find(&refunds)
+}
+`,
+			want: []string{"/order_items write 6 GORM", "/orders write 7 GORM", "/refunds read 8 GORM"},
+		},
+		{
+			name: "django and redis", language: "Python",
+			src: `import redis
+
+cache = redis.Redis()
+
+def ship(order_id):
+    order = Order.objects.get(pk=order_id)
+    Shipment.objects.create(order=order)
+    Order.objects.filter(pk=order_id).update(status="shipped")
+    cache.delete(f"order:{order_id}")
+    return cache.get("stats")
+`,
+			want: []string{"/orders read 6 Django ORM", "/shipments write 7 Django ORM", "/orders write 8 Django ORM", "redis/ write 9 Redis", "redis/ read 10 Redis"},
+		},
+		{
+			name: "prisma, repositories and mongo", language: "TypeScript",
+			src: `export async function refund(id: string) {
+  const payment = await prisma.payment.findUnique({ where: { id } });
+  await this.refundRepository.save({ paymentId: id });
+  await db.collection("ledger").insertOne({ id });
+}
+`,
+			want: []string{"/payments read 2 Prisma", "/refunds write 3 TypeORM", "mongodb/ledger write 4 MongoDB"},
+		},
+		{
+			name: "spring data", language: "Java",
+			src: `class InvoiceService {
+    Invoice issue(Invoice i) {
+        customerRepository.findById(i.customerId());
+        return invoiceRepository.save(i);
+    }
+}
+`,
+			want: []string{"/customers read 3 Spring Data", "/invoices write 4 Spring Data"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range ScanDataAccess([]byte(tt.src), tt.language) {
+				mode := "read"
+				if a.Write {
+					mode = "write"
+				}
+				got = append(got, fmt.Sprintf("%s/%s %s %d %s", a.Store, a.Table, mode, a.Line, a.Evidence))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScanDataAccess() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
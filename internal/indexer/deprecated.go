@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DeprecatedRoute is an HTTP route its source marks as deprecated.
+type DeprecatedRoute struct {
+	Route string // "GET /orders", as in the RoutesKeyLogic entry
+	Line  int    // 1-based line registering the route
+	// Evidence is the marker found, e.g. "@Deprecated" or "deprecated=True".
+	Evidence string
+	// Note is what the marker says about the deprecation, e.g. "use
+	// POST /v2/charges"; empty when it says nothing.
+	Note string
+}
+
+var (
+	deprecatedFlagRe    = regexp.MustCompile(`(?i)\bdeprecated\s*[=:]\s*true\b`)
+	deprecatedCommentRe = regexp.MustCompile(`(?im)(?:@deprecated\b|^[\s/#*]*deprecated:)[ \t]*(.*)$`)
+)
+
+// ScanDeprecatedRoutes finds the HTTP routes a file registers, as
+// StaticAnalyze does, that are marked deprecated: by a @Deprecated or
+// @deprecated annotation or decorator on the route, a deprecated=True or
+// deprecated: true argument (FastAPI, Swagger and NestJS annotations), or
+// a "Deprecated:" or @deprecated tag in the comment above it.
+func ScanDeprecatedRoutes(filePath string, content []byte, language string) []DeprecatedRoute {
+	var f *staticFacts
+	switch language {
+	case "Go":
+		f = analyzeGo(filePath, content)
+	case "Python":
+		f = analyzePython(content)
+	case "Java":
+		f = analyzeJava(content)
+	case "TypeScript", "JavaScript":
+		f = analyzeScript(content)
+	}
+	if f == nil || len(f.routes) == 0 {
+		return nil
+	}
+	s := lexSource(string(content), language == "Python")
+	var out []DeprecatedRoute
+	for _, route := range f.routes {
+		line := f.routeLines[route]
+		if evidence, note, ok := routeDeprecation(s, line); ok {
+			out = append(out, DeprecatedRoute{Route: route, Line: line + 1, Evidence: evidence, Note: note})
+		}
+	}
+	return out
+}
+
+// routeDeprecation reads whether the route registered on line is marked
+// deprecated, looking at its annotations or decorators, the registration
+// itself, and the comment above them.
+func routeDeprecation(s *scanned, line int) (evidence, note string, ok bool) {
+	for _, ann := range s.annotationsAbove(line) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(ann, "@"), "(")
+		name = strings.TrimSpace(name)
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if strings.EqualFold(name, "deprecated") {
+			// A message passed alone, as in @deprecated("use v2"); Java's
+			// since= and forRemoval= say nothing worth repeating.
+			args := strings.TrimSpace(parenContents(ann))
+			if strings.Contains(args, "=") {
+				args = ""
+			}
+			return "@" + name, strings.Trim(args, `"'`), true
+		}
+		if m := deprecatedFlagRe.FindString(ann); m != "" {
+			return m, "", true
+		}
+	}
+	stmt, _ := s.joinStatement(line)
+	if m := deprecatedFlagRe.FindString(stmt); m != "" {
+		return m, "", true
+	}
+
+	// The comment ending just above the route, past its annotations.
+	l := line - 1
+	for l >= 0 && strings.HasPrefix(strings.TrimSpace(s.code[l]), "@") {
+		l--
+	}
+	for l >= 0 && strings.TrimSpace(s.code[l]) == "" {
+		found := false
+		for _, c := range s.comments {
+			if c.endLine != l {
+				continue
+			}
+			if m := deprecatedCommentRe.FindStringSubmatch(c.text); m != nil {
+				marker := "Deprecated:"
+				if strings.Contains(strings.ToLower(m[0]), "@deprecated") {
+					marker = "@deprecated"
+				}
+				return marker, strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[1]), "*/")), true
+			}
+			l = c.line - 1
+			found = strings.HasPrefix(c.text, "//") || strings.HasPrefix(c.text, "#")
+			break
+		}
+		if !found {
+			break
+		}
+	}
+	return "", "", false
+}
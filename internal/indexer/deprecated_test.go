@@ -0,0 +1,76 @@
+package indexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScanDeprecatedRoutes(t *testing.T) {
+	tests := []struct {
+		name, file, language, src string
+		want                      []string // "route line evidence: note"
+	}{
+		{
+			name: "spring", file: "ChargeController.java", language: "Java",
+			src: `@RestController
+public class ChargeController {
+    @Deprecated(since = "2.0")
+    @PostMapping("/v1/charges")
+    public Charge charge() { return null; }
+
+    @GetMapping("/v1/refunds")
+    @Operation(summary = "List refunds", deprecated = true)
+    public List<Refund> refunds() { return null; }
+
+    @PostMapping("/v2/charges")
+    public Charge chargeV2() { return null; }
+}
+`,
+			want: []string{
+				"POST /v1/charges 5 @Deprecated: ",
+				"GET /v1/refunds 9 deprecated = true: ",
+			},
+		},
+		{
+			name: "fastapi", file: "api.py", language: "Python",
+			src: `@router.get("/items", deprecated=True)
+async def items():
+    return []
+
+# Deprecated: use /v2/orders
+@router.get("/orders")
+async def orders():
+    return []
+
+@router.get("/v2/orders")
+async def orders_v2():
+    return []
+`,
+			want: []string{
+				"GET /items 2 deprecated=True: ",
+				"GET /orders 7 Deprecated:: use /v2/orders",
+			},
+		},
+		{
+			name: "express", file: "app.js", language: "JavaScript",
+			src: `/**
+ * Lists users.
+ * @deprecated use GET /v2/users
+ */
+app.get('/users', listUsers);
+app.get('/v2/users', listUsers);
+`,
+			want: []string{"GET /users 5 @deprecated: use GET /v2/users"},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range ScanDeprecatedRoutes(tt.file, []byte(tt.src), tt.language) {
+			got = append(got, fmt.Sprintf("%s %d %s: %s", r.Route, r.Line, r.Evidence, r.Note))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
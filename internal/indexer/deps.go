@@ -2,6 +2,7 @@ package indexer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,13 +10,23 @@ import (
 )
 
 // SaveAnalyses persists file analyses to .autodoc/analyses.json inside the given directory.
+// Analyses are normalized and validated first; a *ValidationError is returned
+// instead of writing analyses that break the schema.
 func SaveAnalyses(dir string, analyses map[string]FileAnalysis) error {
+	for path, a := range analyses {
+		a.Normalize()
+		analyses[path] = a
+	}
+	if issues := ValidateAnalyses(analyses); len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+
 	autodocDir := filepath.Join(dir, ".autodoc")
 	if err := os.MkdirAll(autodocDir, 0o755); err != nil {
 		return fmt.Errorf("create .autodoc dir: %w", err)
 	}
 
-	data, err := json.MarshalIndent(analyses, "", "  ")
+	data, err := json.MarshalIndent(analysesFile{SchemaVersion: AnalysesSchemaVersion, Analyses: analyses}, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal analyses: %w", err)
 	}
@@ -24,7 +35,8 @@ func SaveAnalyses(dir string, analyses map[string]FileAnalysis) error {
 }
 
 // LoadAnalyses reads file analyses from .autodoc/analyses.json inside the given directory.
-// Returns an empty map if the file does not exist.
+// Returns an empty map if the file does not exist. Files from older schema
+// versions are upgraded; files from a newer autodoc return a *SchemaError.
 func LoadAnalyses(dir string) (map[string]FileAnalysis, error) {
 	path := filepath.Join(dir, ".autodoc", "analyses.json")
 	data, err := os.ReadFile(path)
@@ -35,8 +47,12 @@ func LoadAnalyses(dir string) (map[string]FileAnalysis, error) {
 		return nil, fmt.Errorf("read analyses: %w", err)
 	}
 
-	var analyses map[string]FileAnalysis
-	if err := json.Unmarshal(data, &analyses); err != nil {
+	analyses, _, err := decodeAnalyses(data)
+	if err != nil {
+		var schemaErr *SchemaError
+		if errors.As(err, &schemaErr) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("unmarshal analyses: %w", err)
 	}
 	if analyses == nil {
@@ -1,6 +1,7 @@
 package indexer
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -222,6 +223,118 @@ func TestLoadAnalyses_MissingFile(t *testing.T) {
 	}
 }
 
+func TestLoadAnalyses_LegacyLayout(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"main.go": {"language": "go", "summary": "Entry point"}}`
+	if err := os.MkdirAll(filepath.Join(dir, ".autodoc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".autodoc", "analyses.json"), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadAnalyses(dir)
+	if err != nil {
+		t.Fatalf("LoadAnalyses failed on legacy layout: %v", err)
+	}
+	if a := loaded["main.go"]; a.FilePath != "main.go" || a.Summary != "Entry point" {
+		t.Errorf("unexpected legacy analysis: %+v", a)
+	}
+
+	status := CheckArtifacts(dir)[0]
+	if !status.OK() || status.SchemaVersion != 1 {
+		t.Errorf("expected legacy analyses.json to check as compatible v1, got %+v", status)
+	}
+}
+
+func TestLoadAnalyses_NewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".autodoc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	newer := `{"schema_version": 99, "analyses": {}}`
+	if err := os.WriteFile(filepath.Join(dir, ".autodoc", "analyses.json"), []byte(newer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadAnalyses(dir)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Version != 99 {
+		t.Fatalf("expected SchemaError for version 99, got %v", err)
+	}
+	if CheckArtifacts(dir)[0].OK() {
+		t.Error("expected CheckArtifacts to flag the newer schema")
+	}
+}
+
+func TestMigrateArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".autodoc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".autodoc", "analyses.json")
+	legacy := []byte(`{"main.go": {"language": "go", "summary": "Entry point"}}`)
+	if err := os.WriteFile(path, legacy, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := MigrateArtifacts(dir, true)
+	if err != nil || len(report) != 1 || !report[0].Pending() || len(report[0].Steps) != 1 {
+		t.Fatalf("dry run: unexpected report %+v, %v", report, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(legacy) {
+		t.Error("dry run should not rewrite analyses.json")
+	}
+
+	if _, err := MigrateArtifacts(dir, false); err != nil {
+		t.Fatalf("MigrateArtifacts: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if version, _ := artifactVersion(data); version != AnalysesSchemaVersion {
+		t.Errorf("expected analyses.json rewritten at v%d, got v%d", AnalysesSchemaVersion, version)
+	}
+	loaded, err := LoadAnalyses(dir)
+	if err != nil || loaded["main.go"].FilePath != "main.go" {
+		t.Errorf("migrated analyses not loadable: %v %+v", err, loaded)
+	}
+}
+
+func TestSaveAnalyses_NormalizesAndValidates(t *testing.T) {
+	dir := t.TempDir()
+	analyses := map[string]FileAnalysis{
+		"api.go": {
+			FilePath: "api.go",
+			Language: "go",
+			Dependencies: []Dependency{
+				{Name: " payments ", Type: "HTTP"},
+				{Name: "", Type: "import"},
+				{Name: "orders-db", Type: "db"},
+			},
+			Functions: []FunctionDoc{{Name: "Handle", LineStart: 20, LineEnd: 10}},
+		},
+	}
+	if err := SaveAnalyses(dir, analyses); err != nil {
+		t.Fatalf("SaveAnalyses failed: %v", err)
+	}
+	a := analyses["api.go"]
+	want := []Dependency{{Name: "payments", Type: DepAPICall}, {Name: "orders-db", Type: DepDatabase}}
+	if len(a.Dependencies) != len(want) || a.Dependencies[0] != want[0] || a.Dependencies[1] != want[1] {
+		t.Errorf("dependencies not normalized: %+v", a.Dependencies)
+	}
+	if a.Functions[0].LineStart != 0 || a.Functions[0].LineEnd != 0 {
+		t.Errorf("inverted line range not cleared: %+v", a.Functions[0])
+	}
+
+	bad := map[string]FileAnalysis{"a.go": {FilePath: "b.go", Language: "go"}}
+	var validationErr *ValidationError
+	if err := SaveAnalyses(dir, bad); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for mismatched file_path, got %v", err)
+	}
+	if issues := (&FileAnalysis{FilePath: "x.go", Language: "go", Dependencies: []Dependency{{Name: "x", Type: "carrier-pigeon"}}}).Validate(); len(issues) != 1 {
+		t.Errorf("expected unknown dependency type to be reported, got %v", issues)
+	}
+}
+
 func TestParseRegenerationAdvice_Valid(t *testing.T) {
 	response := `PROJECT_OVERVIEW: YES
 ARCHITECTURE: NO
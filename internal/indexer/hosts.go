@@ -0,0 +1,48 @@
+package indexer
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Host is a host a file's code names in a URL.
+type Host struct {
+	Name string // lowercased, without port
+	Line int    // 1-based
+}
+
+var urlHostRe = regexp.MustCompile(`\b(?:https?|wss?|grpcs?)://(?:[^\s/@"'` + "`" + `]+@)?([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+
+// hashCommentLanguages are the languages whose comments start with #.
+var hashCommentLanguages = map[string]bool{"Python": true, "Ruby": true, "Shell": true, "YAML": true, "TOML": true}
+
+// ScanHosts finds the hosts of the URLs a file's code names, once each,
+// in order. URLs in comments don't count, nor do IP addresses, localhost,
+// and the example domains.
+func ScanHosts(content []byte, language string) []Host {
+	s := lexSource(string(content), hashCommentLanguages[language])
+	var out []Host
+	seen := make(map[string]bool)
+	for i, line := range s.code {
+		for _, m := range urlHostRe.FindAllStringSubmatch(line, -1) {
+			host := strings.ToLower(strings.TrimSuffix(m[1], "."))
+			if seen[host] || net.ParseIP(host) != nil || host == "localhost" || isExampleHost(host) {
+				continue
+			}
+			seen[host] = true
+			out = append(out, Host{Name: host, Line: i + 1})
+		}
+	}
+	return out
+}
+
+// isExampleHost reports whether host is reserved for examples (RFC 2606).
+func isExampleHost(host string) bool {
+	for _, d := range []string{"example.com", "example.org", "example.net"} {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return strings.HasSuffix(host, ".example") || strings.HasSuffix(host, ".test") || strings.HasSuffix(host, ".invalid")
+}
@@ -246,6 +246,31 @@ func TestChunkAnalysis_Normal(t *testing.T) {
 	}
 }
 
+func TestFileDocument_Roundtrip(t *testing.T) {
+	analysis := &FileAnalysis{
+		FilePath:     "handler.go",
+		Language:     "go",
+		Summary:      "HTTP handler.\nServes the orders API.",
+		Purpose:      "Expose orders over REST.",
+		Dependencies: []Dependency{{Name: "orders-service", Type: DepAPICall}, {Name: "net/http", Type: DepImport}},
+		KeyLogic:     []string{"Validates input", "Retries on 503"},
+	}
+
+	parsed := ParseFileDocument(FormatFileDocument(analysis))
+	if parsed.FilePath != analysis.FilePath || parsed.Language != analysis.Language {
+		t.Errorf("unexpected header fields: %+v", parsed)
+	}
+	if parsed.Summary != analysis.Summary || parsed.Purpose != analysis.Purpose {
+		t.Errorf("summary/purpose mismatch: %q / %q", parsed.Summary, parsed.Purpose)
+	}
+	if len(parsed.Dependencies) != 2 || parsed.Dependencies[0] != analysis.Dependencies[0] || parsed.Dependencies[1] != analysis.Dependencies[1] {
+		t.Errorf("dependencies mismatch: %+v", parsed.Dependencies)
+	}
+	if len(parsed.KeyLogic) != 2 || parsed.KeyLogic[1] != "Retries on 503" {
+		t.Errorf("key logic mismatch: %+v", parsed.KeyLogic)
+	}
+}
+
 func TestSplitLargeFile(t *testing.T) {
 	// Create content that exceeds max tokens.
 	var lines []string
@@ -516,6 +541,83 @@ func TestPipeline_Run(t *testing.T) {
 	}
 }
 
+func TestPipeline_Budget(t *testing.T) {
+	dir := t.TempDir()
+	var files []walker.FileInfo
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("file%d.go", i)
+		os.WriteFile(filepath.Join(dir, name), []byte("package f"), 0o644)
+		files = append(files, walker.FileInfo{
+			Path:        filepath.Join(dir, name),
+			RelPath:     name,
+			Language:    "Go",
+			ContentHash: "hash" + name,
+		})
+	}
+	provider := &mockProvider{
+		response: &llm.CompletionResponse{
+			Content:      `{"summary": "A file.", "purpose": "Testing."}`,
+			InputTokens:  100,
+			OutputTokens: 50,
+		},
+	}
+	cfg := &config.Config{Quality: config.QualityLite, Model: "test-model", MaxConcurrency: 1}
+
+	// Each file is estimated at 1300 tokens and uses 150, so only two files
+	// fit in 1500 tokens: the third would need 300 spent plus 1300.
+	pipeline := NewPipeline(provider, &mockEmbedder{}, &mockStore{}, cfg, dir)
+	budget := NewBudget(cfg.Model, 0, 1500)
+	pipeline.SetBudget(budget)
+	result, err := pipeline.Run(context.Background(), files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FilesProcessed != 2 || len(result.Errors) != 0 {
+		t.Errorf("processed %d files with errors %v, want 2 and none", result.FilesProcessed, result.Errors)
+	}
+	if want := []string{"file2.go", "file3.go"}; fmt.Sprint(result.Deferred) != fmt.Sprint(want) {
+		t.Errorf("deferred %v, want %v", result.Deferred, want)
+	}
+	if in, out, _ := budget.Spent(); in != 200 || out != 100 || !budget.Exceeded() {
+		t.Errorf("spent %d+%d tokens (exceeded %v), want 200+100 and exceeded", in, out, budget.Exceeded())
+	}
+	state, err := LoadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(state.Deferred) != fmt.Sprint(result.Deferred) {
+		t.Errorf("state deferred %v, want %v", state.Deferred, result.Deferred)
+	}
+
+	// Without a budget the next run picks up the deferred files.
+	pipeline.SetBudget(nil)
+	result, err = pipeline.Run(context.Background(), files)
+	if err != nil {
+		t.Fatalf("unexpected error on re-run: %v", err)
+	}
+	if result.FilesProcessed != 2 || result.FilesSkipped != 2 || len(result.Deferred) != 0 {
+		t.Errorf("re-run processed %d, skipped %d, deferred %v; want 2, 2, none", result.FilesProcessed, result.FilesSkipped, result.Deferred)
+	}
+}
+
+func TestBudget_Cost(t *testing.T) {
+	// gpt-4o-mini: $0.15 per 1M input tokens, $0.60 per 1M output tokens.
+	b := NewBudget("gpt-4o-mini", 0.01, 0)
+	if !b.reserve(20_000, 10_000) { // $0.009
+		t.Fatal("expected the first reservation to fit")
+	}
+	if b.reserve(5_000, 5_000) { // $0.00375 more
+		t.Error("expected the second reservation not to fit")
+	}
+	b.charge(20_000, 10_000, 10_000, 0)
+	if b.reserve(1, 1) {
+		t.Error("expected no reservations once the budget was exceeded")
+	}
+	if NewBudget("gpt-4o-mini", 0, 0) != nil {
+		t.Error("expected no budget without limits")
+	}
+}
+
 func TestPipeline_DryRun(t *testing.T) {
 	dir := t.TempDir()
 	provider := &mockProvider{}
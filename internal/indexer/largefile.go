@@ -0,0 +1,166 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Truncation actions for oversized files.
+const (
+	TruncationSampled = "sampled" // analyzed from a sample of the content
+	TruncationStubbed = "stubbed" // documented with a stub page, not analyzed
+)
+
+// Truncation records that a file was too large to analyze in full.
+type Truncation struct {
+	Action   string `json:"action"`
+	Size     int64  `json:"size"`           // file size in bytes
+	Analyzed int64  `json:"analyzed_bytes"` // bytes the analyzer saw
+	Reason   string `json:"reason"`
+}
+
+// Note is the line shown on the file's doc page.
+func (t *Truncation) Note() string {
+	if t.Action == TruncationStubbed {
+		return fmt.Sprintf("This file (%s) was not analyzed: %s.", FormatSize(t.Size), t.Reason)
+	}
+	return fmt.Sprintf("This file (%s) was documented from a %s sample of its content: %s.",
+		FormatSize(t.Size), FormatSize(t.Analyzed), t.Reason)
+}
+
+// TruncationReport names a file that was sampled or stubbed.
+type TruncationReport struct {
+	FilePath string
+	Truncation
+}
+
+func (r TruncationReport) String() string {
+	if r.Action == TruncationStubbed {
+		return fmt.Sprintf("%s: stubbed, %s (%s)", r.FilePath, r.Reason, FormatSize(r.Size))
+	}
+	return fmt.Sprintf("%s: sampled %s of %s (%s)", r.FilePath, FormatSize(r.Analyzed), FormatSize(r.Size), r.Reason)
+}
+
+// FormatSize renders a byte count as B, KB, MB, or GB.
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// bundleKind recognizes content that says nothing useful when sampled:
+// minified bundles, generated code, and database dumps. It returns "" for
+// ordinary source.
+func bundleKind(content []byte) string {
+	head := content
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	lower := bytes.ToLower(head)
+	switch {
+	case bytes.Contains(content, []byte("__webpack_require__")):
+		return "webpack bundle"
+	case bytes.Contains(lower, []byte("mysql dump")), bytes.Contains(lower, []byte("postgresql database dump")):
+		return "database dump"
+	case isGenerated(string(head)):
+		return "generated code"
+	}
+	// Minified code packs the whole file onto a handful of lines.
+	if lines := bytes.Count(content, []byte("\n")) + 1; len(content)/lines > 500 {
+		return "minified code"
+	}
+	return ""
+}
+
+// stubAnalysis documents an oversized file without analyzing it.
+func stubAnalysis(filePath string, content []byte, language, reason string) *FileAnalysis {
+	size := int64(len(content))
+	what := "File"
+	if language != "" && language != "unknown" {
+		what = language + " file"
+	}
+	a := &FileAnalysis{
+		FilePath:    filePath,
+		Language:    language,
+		Summary:     fmt.Sprintf("%s of %s, too large to analyze (%s).", what, FormatSize(size), reason),
+		ContentHash: computeHash(content),
+		Truncation:  &Truncation{Action: TruncationStubbed, Size: size, Reason: reason},
+	}
+	a.Normalize()
+	return a
+}
+
+// sampleContent cuts content down to at most budget bytes: the head, three
+// windows from the middle, and the tail, each trimmed to whole lines and
+// separated by markers that say how much was left out. It returns the
+// sample and how many bytes of content it kept.
+func sampleContent(content []byte, budget int64) ([]byte, int64) {
+	n := int64(len(content))
+	if n <= budget {
+		return content, n
+	}
+	head := budget / 2
+	tail := budget / 5
+	window := (budget - head - tail) / 3
+
+	type part struct{ start, end int64 }
+	parts := []part{{0, head}}
+	for _, at := range []int64{n / 4, n / 2, 3 * n / 4} {
+		parts = append(parts, part{at - window/2, at + window/2})
+	}
+	parts = append(parts, part{n - tail, n})
+
+	var buf bytes.Buffer
+	var analyzed int64
+	prevEnd := int64(0)
+	for _, p := range parts {
+		start := lineStart(content, max(p.start, prevEnd))
+		end := p.end
+		if end <= start {
+			continue
+		}
+		if end < n {
+			// Keep whole lines, unless the window holds no line break.
+			if nl := bytes.LastIndexByte(content[start:end], '\n'); nl >= 0 {
+				end = start + int64(nl) + 1
+			}
+		}
+		if start > prevEnd {
+			omitted := content[prevEnd:start]
+			fmt.Fprintf(&buf, "\n... [%d lines (%s) omitted] ...\n\n", bytes.Count(omitted, []byte("\n")), FormatSize(int64(len(omitted))))
+		}
+		buf.Write(content[start:end])
+		analyzed += end - start
+		prevEnd = end
+	}
+	if prevEnd < n {
+		omitted := content[prevEnd:]
+		fmt.Fprintf(&buf, "\n... [%d lines (%s) omitted] ...\n", bytes.Count(omitted, []byte("\n")), FormatSize(int64(len(omitted))))
+	}
+	return buf.Bytes(), analyzed
+}
+
+// lineStart moves i forward to the start of the next line, or to the end
+// of content.
+func lineStart(content []byte, i int64) int64 {
+	if i <= 0 {
+		return 0
+	}
+	if i >= int64(len(content)) {
+		return int64(len(content))
+	}
+	if content[i-1] == '\n' {
+		return i
+	}
+	nl := bytes.IndexByte(content[i:], '\n')
+	if nl < 0 {
+		return int64(len(content))
+	}
+	return i + int64(nl) + 1
+}
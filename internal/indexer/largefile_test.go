@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+// recordingProvider wraps the mock provider and keeps every prompt it sees.
+type recordingProvider struct {
+	llm.MockProvider
+	prompts []string
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	return p.MockProvider.Complete(ctx, req)
+}
+
+// numberedLines returns n lines of ordinary Python, each naming its line.
+func numberedLines(n int) []byte {
+	var buf bytes.Buffer
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&buf, "def handler_%04d(): return %d\n", i, i)
+	}
+	return buf.Bytes()
+}
+
+func TestSampleContent(t *testing.T) {
+	src := numberedLines(2000)
+	budget := int64(len(src) / 10)
+	out, analyzed := sampleContent(src, budget)
+
+	if analyzed > budget || analyzed < budget/2 {
+		t.Errorf("analyzed %d bytes of a %d budget", analyzed, budget)
+	}
+	s := string(out)
+	for _, want := range []string{"handler_0001", "handler_10", "handler_2000", "omitted] ..."} {
+		if !strings.Contains(s, want) {
+			t.Errorf("sample is missing %q", want)
+		}
+	}
+	// Every kept line is whole.
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" && !strings.HasPrefix(line, "def handler_") && !strings.HasPrefix(line, "... [") {
+			t.Errorf("partial line %q", line)
+		}
+	}
+
+	if out, n := sampleContent(src, int64(len(src))); n != int64(len(src)) || !bytes.Equal(out, src) {
+		t.Error("content within budget should be returned unchanged")
+	}
+}
+
+func TestBundleKind(t *testing.T) {
+	minified := []byte("!function(e){" + strings.Repeat("var a=1;", 2000) + "}();")
+	tests := []struct {
+		content []byte
+		want    string
+	}{
+		{minified, "minified code"},
+		{[]byte("/******/ (() => {\n var __webpack_require__ = {};\n})();\n"), "webpack bundle"},
+		{[]byte("-- MySQL dump 10.13\n\nCREATE TABLE orders (id int);\n"), "database dump"},
+		{[]byte("// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb\n"), "generated code"},
+		{numberedLines(100), ""},
+	}
+	for _, tt := range tests {
+		if got := bundleKind(tt.content); got != tt.want {
+			t.Errorf("bundleKind(%.30q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeLargeFiles(t *testing.T) {
+	ctx := context.Background()
+	src := numberedLines(2000) // about 62 KB
+
+	// Over the stub limit: a stub page and no LLM call.
+	p := &recordingProvider{}
+	a := NewFileAnalyzer(p, config.QualityNormal, "mock")
+	a.SetLargeFiles(config.LargeFilesConfig{SampleAbove: "8KB", StubAbove: "32KB"})
+	res, err := a.Analyze(ctx, "app/handlers.py", src, "Python")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := res.Analysis.Truncation
+	if len(p.prompts) != 0 || tr == nil || tr.Action != TruncationStubbed || res.Analysis.ContentHash == "" {
+		t.Fatalf("stub result = %+v, prompts = %d", res.Analysis, len(p.prompts))
+	}
+	if !strings.Contains(tr.Note(), "not analyzed") {
+		t.Errorf("stub note = %q", tr.Note())
+	}
+
+	// A per-path limit raises the stub threshold, so the file is sampled.
+	a.SetLargeFiles(config.LargeFilesConfig{
+		SampleAbove: "8KB",
+		StubAbove:   "32KB",
+		Limits:      []config.FileSizeLimit{{Pattern: "app/**", StubAbove: "1MB"}},
+	})
+	res, err = a.Analyze(ctx, "app/handlers.py", src, "Python")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr = res.Analysis.Truncation
+	if tr == nil || tr.Action != TruncationSampled || tr.Size != int64(len(src)) || tr.Analyzed > 8<<10 {
+		t.Fatalf("sampled truncation = %+v", tr)
+	}
+	if len(p.prompts) == 0 || !strings.Contains(p.prompts[0], "too large to send in full") || strings.Contains(p.prompts[0], "handler_0600()") {
+		t.Error("sampled prompt should carry the note and leave out the middle of the file")
+	}
+	if res.Analysis.ContentHash != computeHash(src) {
+		t.Error("content hash should cover the whole file, not the sample")
+	}
+
+	// Minified bundles over the sample limit are stubbed rather than sampled.
+	minified := []byte("!function(e){" + strings.Repeat("var a=1;", 2000) + "}();")
+	res, err = a.Analyze(ctx, "static/app.min.js", minified, "JavaScript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := res.Analysis.Truncation; tr == nil || tr.Action != TruncationStubbed || tr.Reason != "minified code" {
+		t.Errorf("minified truncation = %+v", tr)
+	}
+}
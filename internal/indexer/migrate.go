@@ -0,0 +1,178 @@
+package indexer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// artifactMigration upgrades an artifact's JSON from version From to From+1.
+type artifactMigration struct {
+	From int
+	Name string
+	Up   func(data []byte) ([]byte, error)
+}
+
+// analysesMigrations upgrades analyses.json, one entry per version step.
+var analysesMigrations = []artifactMigration{
+	{From: 1, Name: "wrap analyses in a versioned envelope", Up: upgradeAnalysesV1},
+}
+
+// stateMigrations upgrades state.json. Version 1 is current.
+var stateMigrations []artifactMigration
+
+// upgradeAnalysesV1 wraps the bare version 1 map and fills in file_path,
+// which version 1 writers did not always set.
+func upgradeAnalysesV1(data []byte) ([]byte, error) {
+	var analyses map[string]FileAnalysis
+	if err := json.Unmarshal(data, &analyses); err != nil {
+		return nil, err
+	}
+	for path, a := range analyses {
+		if a.FilePath == "" {
+			a.FilePath = path
+			analyses[path] = a
+		}
+	}
+	return json.Marshal(analysesFile{SchemaVersion: 2, Analyses: analyses})
+}
+
+// artifactVersion returns the schema_version of a JSON artifact, or 1 for
+// artifacts written before versioning.
+func artifactVersion(data []byte) (int, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return 0, err
+	}
+	version := 1
+	// A version 1 analyses map could hold a file named schema_version, whose
+	// value is an object rather than a number.
+	if raw, ok := probe["schema_version"]; ok && len(raw) > 0 && raw[0] != '{' {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return 0, fmt.Errorf("schema_version: %w", err)
+		}
+	}
+	return max(version, 1), nil
+}
+
+// migrateArtifact upgrades data from version to current by applying each
+// step in turn. It returns the upgraded data and the names of the steps.
+func migrateArtifact(artifact string, data []byte, version, current int, steps []artifactMigration) ([]byte, []string, error) {
+	if err := CheckCompatibility(artifact, version, current); err != nil {
+		return nil, nil, err
+	}
+	var applied []string
+	for v := version; v < current; v++ {
+		step, ok := findMigration(steps, v)
+		if !ok {
+			return nil, applied, fmt.Errorf("%s: no migration from schema version %d", artifact, v)
+		}
+		upgraded, err := step.Up(data)
+		if err != nil {
+			return nil, applied, fmt.Errorf("%s: migrating from version %d (%s): %w", artifact, v, step.Name, err)
+		}
+		data = upgraded
+		applied = append(applied, step.Name)
+	}
+	return data, applied, nil
+}
+
+func findMigration(steps []artifactMigration, from int) (artifactMigration, bool) {
+	for _, s := range steps {
+		if s.From == from {
+			return s, true
+		}
+	}
+	return artifactMigration{}, false
+}
+
+// decodeState reads state.json in any supported layout.
+func decodeState(data []byte) (*IndexState, error) {
+	version, err := artifactVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err = migrateArtifact("state.json", data, version, StateSchemaVersion, stateMigrations)
+	if err != nil {
+		return nil, err
+	}
+	var state IndexState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	state.SchemaVersion = StateSchemaVersion
+	if state.FileHashes == nil {
+		state.FileHashes = make(map[string]string)
+	}
+	return &state, nil
+}
+
+// ArtifactMigration describes the upgrade of one artifact under .autodoc/.
+type ArtifactMigration struct {
+	Artifact string
+	Path     string
+	From     int
+	To       int
+	Steps    []string
+}
+
+// Pending reports whether the artifact needs upgrading.
+func (m ArtifactMigration) Pending() bool {
+	return m.From < m.To
+}
+
+// artifactSpecs lists the versioned JSON artifacts and their migrations.
+var artifactSpecs = []struct {
+	name    string
+	current int
+	steps   []artifactMigration
+}{
+	{"analyses.json", AnalysesSchemaVersion, analysesMigrations},
+	{"state.json", StateSchemaVersion, stateMigrations},
+}
+
+// MigrateArtifacts upgrades the indexer's JSON artifacts under dir/.autodoc
+// to the current schema versions. With dryRun it only reports what would
+// change. Artifacts are also upgraded in memory whenever they are loaded, so
+// running this is only needed to rewrite them on disk ahead of time.
+func MigrateArtifacts(dir string, dryRun bool) ([]ArtifactMigration, error) {
+	var report []ArtifactMigration
+	for _, spec := range artifactSpecs {
+		path := filepath.Join(dir, ".autodoc", spec.name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("reading %s: %w", spec.name, err)
+		}
+
+		version, err := artifactVersion(data)
+		if err != nil {
+			return report, fmt.Errorf("reading %s: %w", spec.name, err)
+		}
+		upgraded, steps, err := migrateArtifact(spec.name, data, version, spec.current, spec.steps)
+		if err != nil {
+			return report, err
+		}
+		report = append(report, ArtifactMigration{Artifact: spec.name, Path: path, From: version, To: spec.current, Steps: steps})
+		if dryRun || len(steps) == 0 {
+			continue
+		}
+
+		var v any
+		if err := json.Unmarshal(upgraded, &v); err != nil {
+			return report, fmt.Errorf("%s: %w", spec.name, err)
+		}
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", spec.name, err)
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			return report, fmt.Errorf("writing %s: %w", spec.name, err)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,140 @@
+package indexer
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// SubService is one logical service inside a monorepo.
+type SubService struct {
+	Name        string
+	Path        string // directory relative to the repository root, slash-separated
+	DisplayName string
+	Owners      []string
+	Discovered  bool // found by layout rather than declared in config
+}
+
+// serviceParents are the directories whose children are each a service.
+// Under cmd/ a child only counts when it holds a main entry point, since
+// cmd/ directories are often thin wrappers around shared code.
+var serviceParents = []string{"cmd", "services", "apps"}
+
+// mainEntryPoints are the base names that mark a cmd/ child as a service.
+var mainEntryPoints = map[string]bool{
+	"main.go": true, "main.py": true, "__main__.py": true, "app.py": true,
+	"main.ts": true, "main.js": true, "index.ts": true, "index.js": true,
+	"server.ts": true, "server.js": true, "Main.java": true, "main.rs": true,
+}
+
+// DiscoverSubServices returns the services declared in cfg and, when
+// cfg.Discover is set, those found in the analyzed file layout. It returns
+// nothing for a repository that is not configured as a monorepo.
+func DiscoverSubServices(analyses map[string]FileAnalysis, cfg config.MonorepoConfig) []SubService {
+	var services []SubService
+	declared := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, s := range cfg.Services {
+		p := cleanServicePath(s.Path)
+		display := s.DisplayName
+		if display == "" {
+			display = s.Name
+		}
+		services = append(services, SubService{Name: s.Name, Path: p, DisplayName: display, Owners: s.Owners})
+		declared[p] = true
+		names[s.Name] = true
+	}
+	if cfg.Discover {
+		for _, s := range DetectSubServices(analyses) {
+			if declared[s.Path] || names[s.Name] {
+				continue
+			}
+			names[s.Name] = true
+			services = append(services, s)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Path < services[j].Path })
+	return services
+}
+
+// DetectSubServices finds the services a monorepo layout implies: each
+// directory under services/ or apps/ with analyzed files, and each one
+// under cmd/ with a main entry point. Two directories with the same base
+// name keep only the first.
+func DetectSubServices(analyses map[string]FileAnalysis) []SubService {
+	found := make(map[string]bool)
+	for filePath := range analyses {
+		parts := strings.Split(filePath, "/")
+		if len(parts) < 3 {
+			continue
+		}
+		for _, parent := range serviceParents {
+			if parts[0] != parent {
+				continue
+			}
+			if parent == "cmd" && (len(parts) != 3 || !mainEntryPoints[parts[2]]) {
+				continue
+			}
+			found[parts[0]+"/"+parts[1]] = true
+		}
+	}
+
+	dirs := make([]string, 0, len(found))
+	for d := range found {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	var services []SubService
+	names := make(map[string]bool)
+	for _, d := range dirs {
+		name := path.Base(d)
+		if names[name] {
+			continue
+		}
+		names[name] = true
+		services = append(services, SubService{Name: name, Path: d, DisplayName: name, Discovered: true})
+	}
+	return services
+}
+
+// ScopeAnalyses returns the analyses of files under dir (the whole repo when
+// dir is empty), leaving out files under any of the excluded directories.
+func ScopeAnalyses(analyses map[string]FileAnalysis, dir string, exclude []string) map[string]FileAnalysis {
+	scoped := make(map[string]FileAnalysis)
+	for filePath, a := range analyses {
+		if InServiceDir(filePath, dir, exclude) {
+			scoped[filePath] = a
+		}
+	}
+	return scoped
+}
+
+// InServiceDir reports whether a repo-relative file path belongs to the
+// service rooted at dir, given the directories of its nested services.
+func InServiceDir(filePath, dir string, exclude []string) bool {
+	filePath = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(filePath, "\\", "/")), "/")
+	if dir != "" && !underDir(filePath, cleanServicePath(dir)) {
+		return false
+	}
+	for _, ex := range exclude {
+		if underDir(filePath, cleanServicePath(ex)) {
+			return false
+		}
+	}
+	return true
+}
+
+func underDir(filePath, dir string) bool {
+	return dir == "" || filePath == dir || strings.HasPrefix(filePath, dir+"/")
+}
+
+func cleanServicePath(p string) string {
+	p = path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	if p == "." {
+		return ""
+	}
+	return strings.TrimPrefix(p, "./")
+}
@@ -0,0 +1,83 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func monorepoAnalyses(paths ...string) map[string]FileAnalysis {
+	m := make(map[string]FileAnalysis)
+	for _, p := range paths {
+		m[p] = FileAnalysis{FilePath: p}
+	}
+	return m
+}
+
+func TestDetectSubServices(t *testing.T) {
+	analyses := monorepoAnalyses(
+		"cmd/api/main.go",
+		"cmd/tools/helpers.go", // no entry point
+		"services/orders/server.py",
+		"services/orders/models/order.py",
+		"apps/web/src/index.ts",
+		"apps/api/main.go", // same base name as cmd/api
+		"internal/db/db.go",
+		"README.md",
+	)
+	got := DetectSubServices(analyses)
+	want := []string{"apps/api", "apps/web", "services/orders"}
+	if len(got) != len(want) {
+		t.Fatalf("DetectSubServices = %+v, want paths %v", got, want)
+	}
+	for i, s := range got {
+		if s.Path != want[i] || !s.Discovered {
+			t.Errorf("service %d = %+v, want path %s", i, s, want[i])
+		}
+	}
+}
+
+func TestDiscoverSubServices(t *testing.T) {
+	analyses := monorepoAnalyses("services/orders/app.py", "services/billing/app.py", "cmd/worker/main.go")
+
+	if got := DiscoverSubServices(analyses, config.MonorepoConfig{}); len(got) != 0 {
+		t.Errorf("unconfigured repo should have no services, got %+v", got)
+	}
+
+	cfg := config.MonorepoConfig{
+		Discover: true,
+		Services: []config.SubServiceConfig{
+			{Name: "ordering", Path: "./services/orders/", DisplayName: "Ordering API", Owners: []string{"commerce"}},
+		},
+	}
+	got := DiscoverSubServices(analyses, cfg)
+	if len(got) != 3 {
+		t.Fatalf("DiscoverSubServices = %+v", got)
+	}
+	byName := make(map[string]SubService)
+	for _, s := range got {
+		byName[s.Name] = s
+	}
+	if s := byName["ordering"]; s.Path != "services/orders" || s.Discovered || s.Owners[0] != "commerce" {
+		t.Errorf("declared service = %+v", s)
+	}
+	if _, ok := byName["orders"]; ok {
+		t.Error("declared path should override the discovered service")
+	}
+	if s := byName["worker"]; s.Path != "cmd/worker" || !s.Discovered {
+		t.Errorf("worker = %+v", s)
+	}
+}
+
+func TestScopeAnalyses(t *testing.T) {
+	analyses := monorepoAnalyses("services/orders/app.py", "services/orders-v2/app.py", "services/billing/app.py", "lib/shared.py")
+
+	orders := ScopeAnalyses(analyses, "services/orders", nil)
+	if len(orders) != 1 || orders["services/orders/app.py"].FilePath == "" {
+		t.Errorf("orders scope = %v", orders)
+	}
+	root := ScopeAnalyses(analyses, "", []string{"services/orders", "services/billing"})
+	if len(root) != 2 || root["lib/shared.py"].FilePath == "" || root["services/orders-v2/app.py"].FilePath == "" {
+		t.Errorf("root scope = %v", root)
+	}
+}
@@ -21,6 +21,7 @@ type Pipeline struct {
 	cfg         *config.Config
 	rootDir     string
 	onProgress  ProgressFunc
+	budget      *Budget
 }
 
 // NewPipeline creates a new Pipeline.
@@ -45,6 +46,11 @@ func (p *Pipeline) SetProgressFunc(fn ProgressFunc) {
 	p.onProgress = fn
 }
 
+// SetBudget caps what the run may spend on file analysis; nil means no cap.
+func (p *Pipeline) SetBudget(b *Budget) {
+	p.budget = b
+}
+
 // Run executes the full indexing pipeline.
 func (p *Pipeline) Run(ctx context.Context, files []walker.FileInfo) (*PipelineResult, error) {
 	start := time.Now()
@@ -80,19 +86,29 @@ func (p *Pipeline) Run(ctx context.Context, files []walker.FileInfo) (*PipelineR
 	if concurrency < 1 {
 		concurrency = 4
 	}
-	analyzer := NewFileAnalyzer(p.llmProvider, p.cfg.Quality, p.cfg.Model)
+	analyzer := NewFileAnalyzer(p.llmProvider, p.cfg.Quality, p.cfg.ModelFor(config.TaskAnalysis))
+	analyzer.SetMode(p.cfg.Analyzer)
+	analyzer.SetLargeFiles(p.cfg.LargeFiles)
+	analyzer.SetScrub(p.cfg.Scrub)
 	batcher := NewBatcher(concurrency, analyzer, p.onProgress)
+	batcher.SetBudget(p.budget)
 
 	batchResult := batcher.ProcessFiles(ctx, changed)
 	result.Errors = append(result.Errors, batchResult.Errors...)
 	result.TotalInputTokens = batchResult.InputTokens
 	result.TotalOutputTokens = batchResult.OutputTokens
 	result.FilesFailed = len(batchResult.Errors)
+	result.Discrepancies = batchResult.Discrepancies
+	result.Truncated = batchResult.Truncated
+	result.Deferred = batchResult.Deferred
+	result.Failed = batchResult.Failed
+	result.Redacted = batchResult.Redacted
 
 	// Chunk, embed, and store each analysis.
 	for _, ar := range batchResult.Results {
 		// The LLM can mark files as irrelevant to documentation.
-		// Record the hash so we don't re-analyze them, but skip storage.
+		// Record the hash so we don't re-analyze them, and keep the analysis
+		// so coverage can say why they have no docs, but skip storage.
 		if ar.Analysis.Skip {
 			if h, ok := walkerHashes[ar.Analysis.FilePath]; ok {
 				state.FileHashes[ar.Analysis.FilePath] = h
@@ -101,6 +117,7 @@ func (p *Pipeline) Run(ctx context.Context, files []walker.FileInfo) (*PipelineR
 			}
 			// Remove any previously stored docs for this file.
 			_ = p.store.DeleteByFilePath(ctx, ar.Analysis.FilePath)
+			result.Analyses[ar.Analysis.FilePath] = *ar.Analysis
 			result.FilesSkipped++
 			continue
 		}
@@ -110,11 +127,13 @@ func (p *Pipeline) Run(ctx context.Context, files []walker.FileInfo) (*PipelineR
 		// Delete old documents for this file before adding new ones.
 		if err := p.store.DeleteByFilePath(ctx, ar.Analysis.FilePath); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("delete old docs for %s: %w", ar.Analysis.FilePath, err))
+			result.Failed[ar.Analysis.FilePath] = err
 			continue
 		}
 
 		if err := p.store.AddDocuments(ctx, docs); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("store docs for %s: %w", ar.Analysis.FilePath, err))
+			result.Failed[ar.Analysis.FilePath] = err
 			continue
 		}
 
@@ -144,6 +163,7 @@ func (p *Pipeline) Run(ctx context.Context, files []walker.FileInfo) (*PipelineR
 
 	// Save index state.
 	state.LastCommitSHA = GetGitCommitSHA(p.rootDir)
+	state.Deferred = result.Deferred
 	if err := state.SaveState(p.rootDir); err != nil {
 		return result, fmt.Errorf("save state: %w", err)
 	}
@@ -171,30 +191,35 @@ func (p *Pipeline) DryRun(ctx context.Context, files []walker.FileInfo) (*CostEs
 		CostBreakdown: make(map[string]float64),
 	}
 
+	// The static analyzer makes no LLM calls; only embeddings cost anything.
+	if p.cfg.Analyzer == config.AnalyzerStatic {
+		var sourceTokens int
+		for _, f := range changed {
+			sourceTokens += int(analyzedSize(p.cfg.LargeFiles, f)) / 4
+		}
+		embeddingCost := float64(sourceTokens/2) / 1_000_000 * 0.10
+		estimate.TotalTokensEstimate = sourceTokens / 2
+		estimate.CostBreakdown["analysis"] = 0
+		estimate.CostBreakdown["embeddings"] = embeddingCost
+		estimate.EstimatedCost = embeddingCost
+		return estimate, nil
+	}
+
 	// Estimate tokens: ~1 token per 4 characters of source code.
 	var totalInputTokens int
 	for _, f := range changed {
-		fileTokens := int(f.Size) / 4
+		fileTokens := int(analyzedSize(p.cfg.LargeFiles, f)) / 4
 		totalInputTokens += fileTokens
 	}
 
 	// Output tokens estimate: ~500 per file for lite, ~1500 for normal, ~3000 for max.
-	var outputPerFile int
-	switch p.cfg.Quality {
-	case config.QualityMax:
-		outputPerFile = 3000
-	case config.QualityNormal:
-		outputPerFile = 1500
-	default:
-		outputPerFile = 500
-	}
-	totalOutputTokens := len(changed) * outputPerFile
+	totalOutputTokens := len(changed) * outputTokensPerFile(p.cfg.Quality)
 
 	estimate.TotalTokensEstimate = totalInputTokens + totalOutputTokens
 
-	// Cost estimation using approximate rates (per 1M tokens).
-	inputCostPerM := 3.0   // $3 per 1M input tokens (rough average)
-	outputCostPerM := 15.0 // $15 per 1M output tokens (rough average)
+	// Cost estimation using the model's rates (per 1M tokens), or rough
+	// averages for models without known prices.
+	inputCostPerM, outputCostPerM := p.pricing(config.TaskAnalysis)
 
 	analysisCost := float64(totalInputTokens)/1_000_000*inputCostPerM +
 		float64(totalOutputTokens)/1_000_000*outputCostPerM
@@ -207,8 +232,9 @@ func (p *Pipeline) DryRun(ctx context.Context, files []walker.FileInfo) (*CostEs
 
 	// Architecture pass (only for Normal and Max).
 	if p.cfg.Quality != config.QualityLite && len(changed) > 0 {
-		archCost := float64(len(changed)*200)/1_000_000*inputCostPerM +
-			2000.0/1_000_000*outputCostPerM
+		archInPerM, archOutPerM := p.pricing(config.TaskArchitecture)
+		archCost := float64(len(changed)*200)/1_000_000*archInPerM +
+			2000.0/1_000_000*archOutPerM
 		estimate.CostBreakdown["architecture"] = archCost
 		analysisCost += archCost
 	}
@@ -218,6 +244,29 @@ func (p *Pipeline) DryRun(ctx context.Context, files []walker.FileInfo) (*CostEs
 	return estimate, nil
 }
 
+// pricing returns the rates (per 1M tokens) of the model a task is routed
+// to, or rough averages for models without known prices.
+func (p *Pipeline) pricing(task string) (inputPerM, outputPerM float64) {
+	inputPerM, outputPerM, ok := llm.Pricing(string(p.cfg.Provider), p.cfg.ModelFor(task))
+	if !ok {
+		return 3.0, 15.0 // $3 per 1M input tokens, $15 per 1M output tokens (rough averages)
+	}
+	return inputPerM, outputPerM
+}
+
+// analyzedSize is how much of a file the analyzer reads: all of it, a
+// sample, or nothing for a stub.
+func analyzedSize(sizes config.LargeFilesConfig, f walker.FileInfo) int64 {
+	sample, stub := sizes.Thresholds(f.RelPath)
+	switch {
+	case f.Size > stub:
+		return 0
+	case f.Size > sample:
+		return sample
+	}
+	return f.Size
+}
+
 // buildReverseDependencyDocs creates documents for dependencies that are used by 2+ files.
 // This enables "what depends on X" / blast-radius queries.
 // Only service-level dependencies are indexed (api_call, database, event, and
@@ -156,6 +156,11 @@ func buildMessages(tier config.QualityTier, filePath string, content string, lan
 	}
 }
 
+// sampledFileNote is appended to the prompt for a file that was too large to
+// send in full, so the summary describes the whole file rather than the
+// excerpt.
+const sampledFileNote = `Note: this file is %s, too large to send in full. You are reading a %s sample: the beginning, three excerpts from the middle, and the end, with "... omitted ..." markers at the gaps. Describe the whole file as far as the sample allows, and omit line numbers for anything after the first gap.`
+
 // buildFallbackMessages constructs a simpler prompt for retry after parse failure.
 func buildFallbackMessages(filePath string, content string) []llm.Message {
 	userPrompt := fmt.Sprintf(fallbackPromptTemplate, filePath, content)
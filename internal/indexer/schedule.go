@@ -0,0 +1,208 @@
+package indexer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ScheduledJob is work a file schedules to run on a clock.
+type ScheduledJob struct {
+	// Name is the job's name where the schedule gives one, otherwise its
+	// handler's.
+	Name string
+	// Schedule is the cron expression, descriptor ("@daily"), or interval
+	// ("every 30s", "fixedRate=60000") as written.
+	Schedule string
+	// Handler is the function or task the job runs; empty when it is an
+	// inline function or a container.
+	Handler string
+	Line    int // 1-based
+	// Evidence is the scheduler, e.g. "robfig/cron", "@Scheduled",
+	// "Celery beat", or "CronJob".
+	Evidence string
+	// Image is the container image a Kubernetes CronJob runs.
+	Image string
+}
+
+var (
+	// Go: robfig/cron's AddFunc and AddJob, gocron's CronJob and
+	// DurationJob with the NewTask that follows.
+	robfigCronRe = regexp.MustCompile(`\.(?:AddFunc|AddJob)\(\s*"([^"]+)"\s*,\s*([\w.]+)?`)
+	gocronRe     = regexp.MustCompile(`\bgocron\.(CronJob|DurationJob)\(\s*("[^"]+"|[^,)]+)[^)]*\)\s*,\s*gocron\.NewTask\(\s*([\w.]+)?`)
+	// Java and Kotlin: Spring's @Scheduled and Quartz cron schedules.
+	springScheduledRe = regexp.MustCompile(`@Scheduled\(([^)]*)\)`)
+	quartzCronRe      = regexp.MustCompile(`\bcronSchedule\(\s*"([^"]+)"`)
+	// JavaScript and TypeScript: node-cron, cron's CronJob, node-schedule,
+	// and NestJS's @Cron and @Interval.
+	nodeCronRe    = regexp.MustCompile(`\b(cron\.schedule|new\s+CronJob|schedule\.scheduleJob|CronJob\.from)\(\s*(?:\{\s*cronTime\s*:\s*)?["'` + "`" + `]([^"'` + "`" + `]+)["'` + "`" + `]\s*,\s*([\w.]+)?`)
+	nestCronRe    = regexp.MustCompile(`@(Cron|Interval)\(\s*(?:["']([^"']+)["']|(CronExpression\.\w+)|(\d+))`)
+	methodNameRe  = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|final|suspend|override|fun|def|void|[\w<>\[\]]+)\s+)*(\w+)\s*\(`)
+	pyJobDecorRe  = regexp.MustCompile(`@\w+\.scheduled_job\(\s*["'](\w+)["']\s*,?\s*([^)]*)\)`)
+	apschedulerRe = regexp.MustCompile(`\.add_job\(\s*([\w.]+)\s*,\s*["'](\w+)["']\s*,?\s*([^)]*)\)`)
+	pyScheduleRe  = regexp.MustCompile(`\bschedule\.every\(([^)]*)\)\.([\w.]+?)(?:\.at\(\s*["']([^"']+)["']\s*\))?\.do\(\s*([\w.]+)`)
+	// Celery beat: "name": {"task": "app.tasks.x", "schedule": crontab(...)}.
+	celeryBeatRe   = regexp.MustCompile(`["']([\w .:-]+)["']\s*:\s*\{\s*["']task["']\s*:\s*["']([\w.]+)["']\s*,\s*["']schedule["']\s*:\s*((?:crontab|timedelta|schedule)\([^)]*\)|[\d.]+)`)
+	periodicTaskRe = regexp.MustCompile(`\.add_periodic_task\(\s*((?:crontab|timedelta)\([^)]*\)|[\d.]+)\s*,\s*([\w.]+)`)
+	// Kubernetes CronJob manifests.
+	cronJobKindRe  = regexp.MustCompile(`(?m)^kind:\s*CronJob\s*$`)
+	cronJobNameRe  = regexp.MustCompile(`(?m)^metadata:\s*\n(?:\s+.*\n)*?\s+name:\s*["']?([\w.-]+)`)
+	cronJobSchedRe = regexp.MustCompile(`(?m)^\s+schedule:\s*["']?([^"'\n#]+?)["']?\s*(?:#.*)?$`)
+	cronJobImageRe = regexp.MustCompile(`(?m)^\s+-?\s*image:\s*["']?([^"'\s]+)`)
+)
+
+// ScanScheduledJobs finds the jobs a file schedules: robfig/cron and
+// gocron in Go; Spring @Scheduled and Quartz in Java; node-cron, cron,
+// node-schedule, and NestJS @Cron in JavaScript and TypeScript;
+// APScheduler, schedule, and Celery beat in Python; and Kubernetes
+// CronJob manifests in YAML. Code in comments doesn't count.
+func ScanScheduledJobs(content []byte, language string) []ScheduledJob {
+	if language == "YAML" {
+		return scanCronJobs(string(content))
+	}
+	s := lexSource(string(content), language == "Python")
+	code := strings.Join(s.code, "\n")
+	lineAt := func(offset int) int { return strings.Count(code[:offset], "\n") + 1 }
+	// handlerAfter names the function declared after an annotation or
+	// decorator on line (1-based).
+	handlerAfter := func(line int) string {
+		for l := line; l < len(s.code) && l < line+5; l++ {
+			t := strings.TrimSpace(s.code[l])
+			if t == "" || strings.HasPrefix(t, "@") {
+				continue
+			}
+			if m := methodNameRe.FindStringSubmatch(t); m != nil {
+				return m[1]
+			}
+			return ""
+		}
+		return ""
+	}
+	group := func(m []int, i int) string {
+		if m[2*i] < 0 {
+			return ""
+		}
+		return code[m[2*i]:m[2*i+1]]
+	}
+
+	var out []ScheduledJob
+	add := func(j ScheduledJob) {
+		j.Schedule = strings.Join(strings.Fields(j.Schedule), " ")
+		switch j.Handler {
+		case "func", "function", "async", "lambda", "new": // an inline function
+			j.Handler = ""
+		}
+		if j.Name == "" {
+			j.Name = j.Handler[strings.LastIndex(j.Handler, ".")+1:]
+		}
+		if j.Name == "" {
+			j.Name = "job on line " + strconv.Itoa(j.Line)
+		}
+		out = append(out, j)
+	}
+
+	switch language {
+	case "Go":
+		if strings.Contains(code, "cron") {
+			for _, m := range robfigCronRe.FindAllStringSubmatchIndex(code, -1) {
+				add(ScheduledJob{Schedule: group(m, 1), Handler: group(m, 2), Line: lineAt(m[0]), Evidence: "robfig/cron"})
+			}
+		}
+		for _, m := range gocronRe.FindAllStringSubmatchIndex(code, -1) {
+			schedule := literalValue(group(m, 2))
+			if group(m, 1) == "DurationJob" {
+				schedule = "every " + schedule
+			}
+			add(ScheduledJob{Schedule: schedule, Handler: group(m, 3), Line: lineAt(m[0]), Evidence: "gocron"})
+		}
+	case "Java", "Kotlin":
+		for _, m := range springScheduledRe.FindAllStringSubmatchIndex(code, -1) {
+			var parts []string
+			for _, arg := range splitParams(group(m, 1)) {
+				k, v, ok := strings.Cut(arg, "=")
+				k, v = strings.TrimSpace(k), literalValue(v)
+				switch {
+				case !ok:
+					continue
+				case k == "cron":
+					parts = append([]string{v}, parts...)
+				case strings.HasPrefix(k, "fixed") || k == "initialDelay" || k == "timeUnit" || k == "zone":
+					parts = append(parts, k+"="+v)
+				}
+			}
+			line := lineAt(m[0])
+			add(ScheduledJob{Schedule: strings.Join(parts, ", "), Handler: handlerAfter(line), Line: line, Evidence: "@Scheduled"})
+		}
+		for _, m := range quartzCronRe.FindAllStringSubmatchIndex(code, -1) {
+			add(ScheduledJob{Schedule: group(m, 1), Line: lineAt(m[0]), Evidence: "Quartz"})
+		}
+	case "JavaScript", "TypeScript":
+		for _, m := range nodeCronRe.FindAllStringSubmatchIndex(code, -1) {
+			evidence := map[string]string{"cron.schedule": "node-cron", "schedule.scheduleJob": "node-schedule"}[group(m, 1)]
+			if evidence == "" {
+				evidence = "cron"
+			}
+			add(ScheduledJob{Schedule: group(m, 2), Handler: group(m, 3), Line: lineAt(m[0]), Evidence: evidence})
+		}
+		for _, m := range nestCronRe.FindAllStringSubmatchIndex(code, -1) {
+			schedule := group(m, 2) + group(m, 3)
+			if group(m, 1) == "Interval" {
+				schedule = "every " + group(m, 4) + "ms"
+			}
+			line := lineAt(m[0])
+			add(ScheduledJob{Schedule: schedule, Handler: handlerAfter(line), Line: line, Evidence: "@" + group(m, 1)})
+		}
+	case "Python":
+		for _, m := range pyJobDecorRe.FindAllStringSubmatchIndex(code, -1) {
+			line := lineAt(m[0])
+			add(ScheduledJob{Schedule: group(m, 1) + " " + group(m, 2), Handler: handlerAfter(line), Line: line, Evidence: "APScheduler"})
+		}
+		for _, m := range apschedulerRe.FindAllStringSubmatchIndex(code, -1) {
+			add(ScheduledJob{Schedule: group(m, 2) + " " + group(m, 3), Handler: group(m, 1), Line: lineAt(m[0]), Evidence: "APScheduler"})
+		}
+		for _, m := range pyScheduleRe.FindAllStringSubmatchIndex(code, -1) {
+			schedule := strings.TrimSpace("every " + group(m, 1) + " " + group(m, 2))
+			if at := group(m, 3); at != "" {
+				schedule += " at " + at
+			}
+			add(ScheduledJob{Schedule: schedule, Handler: group(m, 4), Line: lineAt(m[0]), Evidence: "schedule"})
+		}
+		for _, m := range celeryBeatRe.FindAllStringSubmatchIndex(code, -1) {
+			add(ScheduledJob{Name: group(m, 1), Schedule: group(m, 3), Handler: group(m, 2), Line: lineAt(m[0]), Evidence: "Celery beat"})
+		}
+		for _, m := range periodicTaskRe.FindAllStringSubmatchIndex(code, -1) {
+			add(ScheduledJob{Schedule: group(m, 1), Handler: strings.TrimSuffix(strings.TrimSuffix(group(m, 2), ".s"), ".si"), Line: lineAt(m[0]), Evidence: "Celery beat"})
+		}
+	}
+	return out
+}
+
+// scanCronJobs reads the Kubernetes CronJobs in a YAML file, which may
+// hold several documents.
+func scanCronJobs(src string) []ScheduledJob {
+	var out []ScheduledJob
+	line := 1
+	for _, doc := range strings.Split(src, "\n---") {
+		start := line
+		line += strings.Count(doc, "\n") + 1
+		loc := cronJobKindRe.FindStringIndex(doc)
+		if loc == nil {
+			continue
+		}
+		j := ScheduledJob{Line: start + strings.Count(doc[:loc[0]], "\n"), Evidence: "CronJob"}
+		if m := cronJobNameRe.FindStringSubmatch(doc); m != nil {
+			j.Name = m[1]
+		}
+		if m := cronJobSchedRe.FindStringSubmatch(doc); m != nil {
+			j.Schedule = strings.TrimSpace(m[1])
+		}
+		if m := cronJobImageRe.FindStringSubmatch(doc); m != nil {
+			j.Image = m[1]
+		}
+		if j.Name == "" {
+			j.Name = "cronjob on line " + strconv.Itoa(j.Line)
+		}
+		out = append(out, j)
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package indexer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScanScheduledJobs(t *testing.T) {
+	tests := []struct {
+		name, language, src string
+		want                []string // "name | schedule | handler | line evidence"
+	}{
+		{
+			name: "go", language: "Go",
+			src: `package main
+
+import "github.com/robfig/cron/v3"
+
+func main() {
+	c := cron.New()
+	c.AddFunc("0 2 * * *", reports.Nightly)
+	// c.AddFunc("@hourly", disabled)
+	c.AddFunc("@every 5m", func() { sweep() })
+}
+`,
+			want: []string{
+				"Nightly | 0 2 * * * | reports.Nightly | 7 robfig/cron",
+				"job on line 9 | @every 5m |  | 9 robfig/cron",
+			},
+		},
+		{
+			name: "spring", language: "Java",
+			src: `@Component
+public class Reconciler {
+    @Scheduled(cron = "0 0 3 * * *", zone = "UTC")
+    public void reconcile() {}
+
+    @Scheduled(fixedRate = 60000)
+    void poll() {}
+}
+`,
+			want: []string{
+				"reconcile | 0 0 3 * * *, zone=UTC | reconcile | 3 @Scheduled",
+				"poll | fixedRate=60000 | poll | 6 @Scheduled",
+			},
+		},
+		{
+			name: "nest", language: "TypeScript",
+			src: `import * as cron from 'node-cron';
+cron.schedule('*/15 * * * *', syncInventory);
+
+export class Tasks {
+  @Cron(CronExpression.EVERY_DAY_AT_MIDNIGHT)
+  async purge() {}
+}
+`,
+			want: []string{
+				"syncInventory | */15 * * * * | syncInventory | 2 node-cron",
+				"purge | CronExpression.EVERY_DAY_AT_MIDNIGHT | purge | 5 @Cron",
+			},
+		},
+		{
+			name: "celery", language: "Python",
+			src: `app.conf.beat_schedule = {
+    "send-digest": {
+        "task": "mail.tasks.send_digest",
+        "schedule": crontab(hour=7, minute=30),
+    },
+}
+
+scheduler.add_job(cleanup, "interval", minutes=10)
+`,
+			want: []string{
+				"cleanup | interval minutes=10 | cleanup | 8 APScheduler",
+				"send-digest | crontab(hour=7, minute=30) | mail.tasks.send_digest | 2 Celery beat",
+			},
+		},
+		{
+			name: "cronjob", language: "YAML",
+			src: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: ledger-close
+spec:
+  schedule: "0 0 1 * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: close
+              image: registry.example.com/ledger:1.4.2
+`,
+			want: []string{"ledger-close | 0 0 1 * * |  | 7 CronJob registry.example.com/ledger:1.4.2"},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, j := range ScanScheduledJobs([]byte(tt.src), tt.language) {
+			s := fmt.Sprintf("%s | %s | %s | %d %s", j.Name, j.Schedule, j.Handler, j.Line, j.Evidence)
+			if j.Image != "" {
+				s += " " + j.Image
+			}
+			got = append(got, s)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
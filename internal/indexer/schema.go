@@ -0,0 +1,294 @@
+package indexer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Schema versions of the artifacts the indexer writes under .autodoc/. Bump a
+// version whenever a change would break an existing reader, and teach the
+// loader to upgrade the older layout.
+const (
+	// AnalysesSchemaVersion is the analyses.json layout. Version 1 was a bare
+	// map of file path to FileAnalysis; version 2 wraps it with the version.
+	AnalysesSchemaVersion = 2
+	// StateSchemaVersion is the state.json layout. Files written before
+	// versioning have no schema_version and are read as version 1.
+	StateSchemaVersion = 1
+)
+
+// Dependency types the analyzer prompts ask for. Consumers such as the
+// service linker and interactive map key off these values.
+const (
+	DepImport   = "import"
+	DepAPICall  = "api_call"
+	DepGRPC     = "grpc"
+	DepDatabase = "database"
+	DepEvent    = "event"
+)
+
+// depTypeAliases maps values models commonly return instead of the
+// documented dependency types.
+var depTypeAliases = map[string]string{
+	"":        DepImport,
+	"library": DepImport,
+	"package": DepImport,
+	"module":  DepImport,
+	"http":    DepAPICall,
+	"rest":    DepAPICall,
+	"api":     DepAPICall,
+	"rpc":     DepGRPC,
+	"db":      DepDatabase,
+	"cache":   DepDatabase,
+	"queue":   DepEvent,
+	"message": DepEvent,
+	"pubsub":  DepEvent,
+	"kafka":   DepEvent,
+}
+
+// IsDependencyType reports whether t is one of the documented dependency types.
+func IsDependencyType(t string) bool {
+	switch t {
+	case DepImport, DepAPICall, DepGRPC, DepDatabase, DepEvent:
+		return true
+	}
+	return false
+}
+
+// SchemaError reports an artifact written by a newer autodoc than this one.
+type SchemaError struct {
+	Artifact  string
+	Version   int
+	Supported int
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s has schema version %d but this autodoc supports up to %d; upgrade autodoc or regenerate with `autodoc generate`",
+		e.Artifact, e.Version, e.Supported)
+}
+
+// CheckCompatibility returns a *SchemaError when an artifact's schema version
+// is newer than the supported one. Older versions are upgraded on load.
+func CheckCompatibility(artifact string, version, supported int) error {
+	if version > supported {
+		return &SchemaError{Artifact: artifact, Version: version, Supported: supported}
+	}
+	return nil
+}
+
+// ValidationIssue is one way an analysis breaks the analyses.json contract.
+type ValidationIssue struct {
+	FilePath string
+	Field    string
+	Message  string
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.FilePath, i.Field, i.Message)
+}
+
+// ValidationError wraps the issues that stopped analyses from being saved.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		msgs = append(msgs, issue.String())
+	}
+	return fmt.Sprintf("%d invalid analyses: %s", len(e.Issues), strings.Join(msgs, "; "))
+}
+
+// Normalize coerces model output into the documented contract: it trims
+// names, drops nameless dependencies, maps dependency type aliases to the
+// documented types, and clears inverted line ranges.
+func (a *FileAnalysis) Normalize() {
+	deps := a.Dependencies[:0]
+	for _, d := range a.Dependencies {
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			continue
+		}
+		d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+		if alias, ok := depTypeAliases[d.Type]; ok {
+			d.Type = alias
+		}
+		deps = append(deps, d)
+	}
+	a.Dependencies = deps
+
+	for i := range a.Functions {
+		normalizeFunction(&a.Functions[i])
+	}
+	for i := range a.Classes {
+		c := &a.Classes[i]
+		c.Name = strings.TrimSpace(c.Name)
+		if c.LineEnd < c.LineStart {
+			c.LineStart, c.LineEnd = 0, 0
+		}
+		for j := range c.Methods {
+			normalizeFunction(&c.Methods[j])
+		}
+	}
+}
+
+func normalizeFunction(fn *FunctionDoc) {
+	fn.Name = strings.TrimSpace(fn.Name)
+	if fn.LineEnd < fn.LineStart {
+		fn.LineStart, fn.LineEnd = 0, 0
+	}
+}
+
+// Validate checks a single analysis against the contract consumers rely on.
+func (a *FileAnalysis) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(field, msg string) {
+		issues = append(issues, ValidationIssue{FilePath: a.FilePath, Field: field, Message: msg})
+	}
+
+	if a.FilePath == "" {
+		add("file_path", "must not be empty")
+	}
+	if a.Language == "" && !a.Skip {
+		add("language", "must not be empty")
+	}
+	for i, d := range a.Dependencies {
+		field := fmt.Sprintf("dependencies[%d]", i)
+		if d.Name == "" {
+			add(field+".name", "must not be empty")
+		}
+		if !IsDependencyType(d.Type) {
+			add(field+".type", fmt.Sprintf("unknown dependency type %q", d.Type))
+		}
+	}
+	for i, fn := range a.Functions {
+		validateFunction(fmt.Sprintf("functions[%d]", i), fn, add)
+	}
+	for i, c := range a.Classes {
+		field := fmt.Sprintf("classes[%d]", i)
+		if c.Name == "" {
+			add(field+".name", "must not be empty")
+		}
+		if c.LineEnd < c.LineStart {
+			add(field, fmt.Sprintf("line_end %d is before line_start %d", c.LineEnd, c.LineStart))
+		}
+		for j, m := range c.Methods {
+			validateFunction(fmt.Sprintf("%s.methods[%d]", field, j), m, add)
+		}
+	}
+	return issues
+}
+
+func validateFunction(field string, fn FunctionDoc, add func(field, msg string)) {
+	if fn.Name == "" {
+		add(field+".name", "must not be empty")
+	}
+	if fn.LineEnd < fn.LineStart {
+		add(field, fmt.Sprintf("line_end %d is before line_start %d", fn.LineEnd, fn.LineStart))
+	}
+}
+
+// ValidateAnalyses checks every analysis and that each is stored under its
+// own file path.
+func ValidateAnalyses(analyses map[string]FileAnalysis) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, path := range sortedKeys(analyses) {
+		a := analyses[path]
+		if a.FilePath != path {
+			issues = append(issues, ValidationIssue{FilePath: path, Field: "file_path", Message: fmt.Sprintf("stored under %q but file_path is %q", path, a.FilePath)})
+		}
+		issues = append(issues, a.Validate()...)
+	}
+	return issues
+}
+
+func sortedKeys(analyses map[string]FileAnalysis) []string {
+	keys := make([]string, 0, len(analyses))
+	for k := range analyses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// analysesFile is the version 2 layout of analyses.json.
+type analysesFile struct {
+	SchemaVersion int                     `json:"schema_version"`
+	Analyses      map[string]FileAnalysis `json:"analyses"`
+}
+
+// decodeAnalyses reads analyses.json in any supported layout, upgrading
+// older layouts, and returns the analyses with the schema version they were
+// written with.
+func decodeAnalyses(data []byte) (map[string]FileAnalysis, int, error) {
+	version, err := artifactVersion(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, _, err = migrateArtifact("analyses.json", data, version, AnalysesSchemaVersion, analysesMigrations)
+	if err != nil {
+		return nil, version, err
+	}
+
+	var file analysesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, version, err
+	}
+	return file.Analyses, version, nil
+}
+
+// ArtifactStatus is the compatibility checker's verdict on one artifact.
+type ArtifactStatus struct {
+	Name          string
+	Path          string
+	Exists        bool
+	SchemaVersion int
+	Supported     int
+	Err           error
+	Issues        []ValidationIssue
+}
+
+// OK reports whether the artifact can be read by this autodoc as is.
+func (s ArtifactStatus) OK() bool {
+	return s.Err == nil && len(s.Issues) == 0
+}
+
+// CheckArtifacts checks the indexer artifacts under dir/.autodoc against the
+// schemas this autodoc reads, so an indexer and a docs generator from
+// different releases fail loudly instead of silently producing bad docs.
+func CheckArtifacts(dir string) []ArtifactStatus {
+	autodocDir := filepath.Join(dir, ".autodoc")
+
+	analyses := ArtifactStatus{Name: "analyses.json", Path: filepath.Join(autodocDir, "analyses.json"), Supported: AnalysesSchemaVersion}
+	if data, err := os.ReadFile(analyses.Path); err == nil {
+		analyses.Exists = true
+		loaded, version, err := decodeAnalyses(data)
+		analyses.SchemaVersion = version
+		if err != nil {
+			analyses.Err = err
+		} else {
+			analyses.Issues = ValidateAnalyses(loaded)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		analyses.Err = err
+	}
+
+	state := ArtifactStatus{Name: "state.json", Path: filepath.Join(autodocDir, "state.json"), Supported: StateSchemaVersion}
+	if data, err := os.ReadFile(state.Path); err == nil {
+		state.Exists = true
+		state.SchemaVersion, state.Err = artifactVersion(data)
+		if state.Err == nil {
+			_, state.Err = decodeState(data)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		state.Err = err
+	}
+
+	return []ArtifactStatus{analyses, state}
+}
@@ -0,0 +1,195 @@
+package indexer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// redactedPrefix starts the text that replaces a redacted value.
+const redactedPrefix = "[REDACTED:"
+
+// Redaction is one value masked in a file before it was sent to the LLM.
+type Redaction struct {
+	Kind string // e.g. "aws-access-key", "private-key", "email"
+	Line int
+}
+
+// RedactionReport lists what was masked in one file.
+type RedactionReport struct {
+	FilePath   string
+	Redactions []Redaction
+}
+
+func (r RedactionReport) String() string {
+	lines := make(map[string][]string)
+	var kinds []string
+	for _, red := range r.Redactions {
+		if _, ok := lines[red.Kind]; !ok {
+			kinds = append(kinds, red.Kind)
+		}
+		lines[red.Kind] = append(lines[red.Kind], fmt.Sprint(red.Line))
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		label := "line"
+		if len(lines[kind]) > 1 {
+			label = "lines"
+		}
+		parts[i] = fmt.Sprintf("%d %s (%s %s)", len(lines[kind]), kind, label, strings.Join(lines[kind], ", "))
+	}
+	return r.FilePath + ": " + strings.Join(parts, ", ")
+}
+
+// scrubRule masks what re matches, or only the first submatch from group
+// on that took part when group is set, unless keep says the value is
+// harmless.
+type scrubRule struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+	keep  func(value string) bool
+}
+
+// secretRules find credentials. Connection strings and assignments keep
+// everything but the secret itself, so the LLM still sees what they're for.
+var secretRules = []scrubRule{
+	{kind: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{kind: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{kind: "slack-token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{kind: "stripe-key", re: regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{kind: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{kind: "api-key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{kind: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{kind: "connection-string", re: regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@'"]+:([^\s@'"/]+)@`), group: 1, keep: isPlaceholder},
+	{kind: "secret", re: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret|private[_-]?key)["']?\s*[:=]+\s*["']([^"'\s]{6,})["']`), group: 1, keep: isPlaceholder},
+}
+
+// piiRules find personal data.
+var piiRules = []scrubRule{
+	{kind: "email", re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), keep: func(v string) bool {
+		return strings.HasSuffix(v, "@example.com") || strings.HasSuffix(v, ".example.com")
+	}},
+	{kind: "card-number", re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), keep: func(v string) bool { return !luhn(v) }},
+	{kind: "ssn", re: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{kind: "phone", re: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?\(?\d{1,4}\)?[\s.-]?\d{3,4}[\s.-]?\d{3,4}|\(\d{3}\)\s?\d{3}-\d{4})\b`)},
+	{kind: "ip-address", re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`), keep: func(v string) bool {
+		return v == "127.0.0.1" || v == "0.0.0.0" || strings.HasPrefix(v, "255.")
+	}},
+}
+
+// stringRule masks the contents of single-line string literals.
+var stringRule = scrubRule{kind: "string", re: regexp.MustCompile(`"((?:[^"\\\n]|\\.)+)"|'((?:[^'\\\n]|\\.){2,})'`), group: 1}
+
+// isPlaceholder reports whether a secret's value is a reference to one,
+// such as ${DB_PASSWORD} or <password>, rather than the secret itself.
+func isPlaceholder(v string) bool {
+	return strings.HasPrefix(v, "$") || strings.HasPrefix(v, "<") || strings.HasPrefix(v, "{") || strings.HasPrefix(v, "%")
+}
+
+// luhn reports whether the digits in v pass the Luhn checksum card numbers
+// carry.
+func luhn(v string) bool {
+	sum, double := 0, false
+	for i := len(v) - 1; i >= 0; i-- {
+		c := v[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Scrubber masks secrets and, optionally, string literals and personal
+// data in source before it is sent to an LLM. A nil Scrubber masks nothing.
+type Scrubber struct {
+	rules []scrubRule
+}
+
+// NewScrubber returns the Scrubber cfg describes, or nil when it masks
+// nothing. Patterns that don't compile are skipped; config validation
+// rejects them.
+func NewScrubber(cfg config.ScrubConfig) *Scrubber {
+	var rules []scrubRule
+	if !cfg.Disabled {
+		rules = append(rules, secretRules...)
+	}
+	for _, p := range cfg.Patterns {
+		if re, err := regexp.Compile(p); err == nil {
+			rules = append(rules, scrubRule{kind: "pattern", re: re})
+		}
+	}
+	if cfg.PII {
+		rules = append(rules, piiRules...)
+	}
+	if cfg.Strings {
+		rules = append(rules, stringRule)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Scrubber{rules: rules}
+}
+
+// Scrub returns content with each value the rules match replaced by
+// [REDACTED:kind], and what it replaced. Line numbers are kept: a
+// multi-line value is replaced by as many lines.
+func (s *Scrubber) Scrub(content []byte) ([]byte, []Redaction) {
+	if s == nil {
+		return content, nil
+	}
+	text := string(content)
+	var redactions []Redaction
+	for _, r := range s.rules {
+		locs := r.re.FindAllStringSubmatchIndex(text, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last, line, counted := 0, 1, 0
+		for _, loc := range locs {
+			start, end := loc[0], loc[1]
+			if r.group > 0 {
+				start = -1
+				for g := r.group; 2*g+1 < len(loc); g++ {
+					if loc[2*g] >= 0 {
+						start, end = loc[2*g], loc[2*g+1]
+						break
+					}
+				}
+			}
+			if start < 0 {
+				continue
+			}
+			value := text[start:end]
+			if strings.Contains(value, redactedPrefix) || (r.keep != nil && r.keep(value)) {
+				continue
+			}
+			line += strings.Count(text[counted:start], "\n")
+			counted = start
+			redactions = append(redactions, Redaction{Kind: r.kind, Line: line})
+
+			b.WriteString(text[last:start])
+			b.WriteString(redactedPrefix + r.kind + "]")
+			b.WriteString(strings.Repeat("\n", strings.Count(value, "\n")))
+			last = end
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	sort.SliceStable(redactions, func(i, j int) bool { return redactions[i].Line < redactions[j].Line })
+	return []byte(text), redactions
+}
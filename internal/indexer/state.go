@@ -12,9 +12,13 @@ import (
 
 // IndexState tracks which files have been indexed and their content hashes.
 type IndexState struct {
+	SchemaVersion int               `json:"schema_version,omitempty"`
 	LastCommitSHA string            `json:"last_commit_sha"`
 	FileHashes    map[string]string `json:"file_hashes"`
 	LastUpdated   time.Time         `json:"last_updated"`
+	// Deferred are the files the last run's budget left unanalyzed; the
+	// next update processes them whether or not git reports them changed.
+	Deferred []string `json:"deferred,omitempty"`
 }
 
 // LoadState reads index state from .autodoc/state.json inside the given directory.
@@ -30,14 +34,7 @@ func LoadState(dir string) (*IndexState, error) {
 		return nil, err
 	}
 
-	var state IndexState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, err
-	}
-	if state.FileHashes == nil {
-		state.FileHashes = make(map[string]string)
-	}
-	return &state, nil
+	return decodeState(data)
 }
 
 // SaveState writes the index state to .autodoc/state.json inside the given directory.
@@ -47,6 +44,7 @@ func (s *IndexState) SaveState(dir string) error {
 		return err
 	}
 
+	s.SchemaVersion = StateSchemaVersion
 	s.LastUpdated = time.Now()
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
@@ -0,0 +1,433 @@
+package indexer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RoutesKeyLogic prefixes the key-logic entry that lists the HTTP routes a
+// file exposes, comma-separated as "METHOD /path".
+const RoutesKeyLogic = "HTTP routes: "
+
+// StaticAnalyze builds a FileAnalysis from the source alone, without an LLM.
+// Go is parsed with go/ast; Python, Java, TypeScript, and JavaScript are read
+// by lightweight scanners that understand their comments, strings, and
+// block structure. Other files get a minimal analysis from their string
+// literals. The result always has the same schema as an LLM analysis, so it
+// can replace one in lite-tier indexing or check one with CrossCheck.
+func StaticAnalyze(filePath string, content []byte, language string) *FileAnalysis {
+	var f *staticFacts
+	switch language {
+	case "Go":
+		f = analyzeGo(filePath, content)
+	case "Python":
+		f = analyzePython(content)
+	case "Java":
+		f = analyzeJava(content)
+	case "TypeScript", "JavaScript":
+		f = analyzeScript(content)
+	}
+	if f == nil {
+		f = analyzeGeneric(content, language)
+	}
+
+	a := f.analysis(filePath, language)
+	a.ContentHash = computeHash(content)
+	a.Normalize()
+	return a
+}
+
+// staticFacts is what a language analyzer extracts from one file. Each
+// list is in source order.
+type staticFacts struct {
+	pkg       string // Go package or Java package
+	doc       string // file or package doc comment
+	generated bool
+
+	functions []FunctionDoc
+	classes   []ClassDoc
+	deps      []Dependency
+	routes    []string // "GET /orders", or just "/orders" when the method is unknown
+	serves    []string // gRPC services implemented here
+	ports     []string
+	env       []string
+	tables    []string
+	literals  []string // string literals, scanned for URLs and SQL
+
+	// routeLines is the 0-based line registering each route.
+	routeLines map[string]int
+}
+
+// addRoute records a route registered on line, unless it is known.
+func (f *staticFacts) addRoute(route string, line int) {
+	if _, ok := f.routeLines[route]; ok {
+		return
+	}
+	if f.routeLines == nil {
+		f.routeLines = make(map[string]int)
+	}
+	f.routes = append(f.routes, route)
+	f.routeLines[route] = line
+}
+
+func (f *staticFacts) addDep(name, typ string) {
+	for _, d := range f.deps {
+		if d.Name == name && d.Type == typ {
+			return
+		}
+	}
+	f.deps = append(f.deps, Dependency{Name: name, Type: typ})
+}
+
+// analysis renders the facts in the FileAnalysis schema, with a summary
+// written from the concrete values found: routes, ports, called services,
+// data stores, and environment variables.
+func (f *staticFacts) analysis(filePath, language string) *FileAnalysis {
+	f.scanLiterals()
+
+	a := &FileAnalysis{
+		FilePath:     filePath,
+		Language:     language,
+		Functions:    f.functions,
+		Classes:      f.classes,
+		Dependencies: f.deps,
+		Skip:         f.generated,
+	}
+
+	var s []string
+	noun := language + " file"
+	if language == "" || language == "unknown" {
+		noun = "File"
+	}
+	if f.pkg != "" {
+		noun += " in package " + f.pkg
+	}
+	methods := 0
+	for _, c := range f.classes {
+		methods += len(c.Methods)
+	}
+	switch {
+	case len(f.functions)+methods+len(f.classes) > 0:
+		s = append(s, fmt.Sprintf("%s declaring %s and %s.", noun, plural(len(f.functions)+methods, "function"), plural(len(f.classes), "type")))
+	default:
+		s = append(s, noun+".")
+	}
+	if len(f.routes) > 0 {
+		s = append(s, "It exposes "+strings.Join(f.routes, ", ")+".")
+		a.KeyLogic = append(a.KeyLogic, RoutesKeyLogic+strings.Join(f.routes, ", "))
+	}
+	if len(f.serves) > 0 {
+		s = append(s, "It implements the "+strings.Join(f.serves, ", ")+" gRPC service(s).")
+		a.KeyLogic = append(a.KeyLogic, "gRPC services: "+strings.Join(f.serves, ", "))
+	}
+	if len(f.ports) > 0 {
+		s = append(s, "It listens on port "+strings.Join(f.ports, ", ")+".")
+	}
+	var calls []string
+	byType := make(map[string][]string)
+	for _, d := range f.deps {
+		byType[d.Type] = append(byType[d.Type], d.Name)
+	}
+	if names := byType[DepAPICall]; len(names) > 0 {
+		calls = append(calls, strings.Join(names, ", ")+" over HTTP")
+	}
+	if names := byType[DepGRPC]; len(names) > 0 {
+		calls = append(calls, strings.Join(names, ", ")+" over gRPC")
+	}
+	if len(calls) > 0 {
+		s = append(s, "It calls "+strings.Join(calls, " and ")+".")
+	}
+	if names := byType[DepDatabase]; len(names) > 0 {
+		s = append(s, "It uses "+strings.Join(names, ", ")+".")
+	}
+	if names := byType[DepEvent]; len(names) > 0 {
+		s = append(s, "It produces or consumes events via "+strings.Join(names, ", ")+".")
+	}
+	if len(f.tables) > 0 {
+		s = append(s, "It queries the "+strings.Join(f.tables, ", ")+" table(s).")
+		a.KeyLogic = append(a.KeyLogic, "SQL tables: "+strings.Join(f.tables, ", "))
+	}
+	if len(f.env) > 0 {
+		s = append(s, "It reads "+strings.Join(f.env, ", ")+".")
+		a.KeyLogic = append(a.KeyLogic, "Environment variables: "+strings.Join(f.env, ", "))
+	}
+	a.Summary = strings.Join(s, " ")
+
+	switch {
+	case f.doc != "":
+		a.Purpose = firstSentence(f.doc)
+	case len(f.classes) == 1:
+		a.Purpose = fmt.Sprintf("Defines %s.", f.classes[0].Name)
+	default:
+		a.Purpose = fmt.Sprintf("Implements %s.", strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)))
+	}
+	return a
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// firstSentence returns the first sentence of a doc comment, on one line.
+func firstSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	for i := 0; i+1 < len(doc); i++ {
+		if (doc[i] == '.' || doc[i] == '!' || doc[i] == '?') && doc[i+1] == ' ' {
+			return doc[:i+1]
+		}
+	}
+	return doc
+}
+
+var (
+	// literalURLRe matches URLs in string literals. The host is captured
+	// whole; serviceHost reduces it to a service name.
+	literalURLRe = regexp.MustCompile(`^(?:https?|grpc|dns)://(?:[^@/\s]*@)?([A-Za-z0-9][A-Za-z0-9.-]*)`)
+	// dsnRe matches connection strings, e.g. postgres://... or jdbc:mysql://...
+	dsnRe = regexp.MustCompile(`^(?:jdbc:)?(postgres|postgresql|mysql|mariadb|mongodb(?:\+srv)?|redis|rediss|amqp|amqps|kafka|nats|sqlserver|clickhouse)://`)
+	// sqlRe matches the start of a SQL statement.
+	sqlRe = regexp.MustCompile(`(?is)^\s*(select|insert|update|delete|with|merge)\s`)
+	// sqlTableRe captures the table after FROM, JOIN, INTO, or UPDATE.
+	sqlTableRe = regexp.MustCompile(`(?i)\b(?:from|join|into|update)\s+["` + "`" + `]?([A-Za-z_][\w.]*)`)
+	// portRe matches listen addresses such as ":8080" or "0.0.0.0:8080".
+	portRe = regexp.MustCompile(`^(?:0\.0\.0\.0|\[::\])?:(\d{2,5})$`)
+)
+
+// dsnStores maps connection string schemes to data store names.
+var dsnStores = map[string]struct{ name, typ string }{
+	"postgres":    {"postgres", DepDatabase},
+	"postgresql":  {"postgres", DepDatabase},
+	"mysql":       {"mysql", DepDatabase},
+	"mariadb":     {"mysql", DepDatabase},
+	"mongodb":     {"mongodb", DepDatabase},
+	"mongodb+srv": {"mongodb", DepDatabase},
+	"redis":       {"redis", DepDatabase},
+	"rediss":      {"redis", DepDatabase},
+	"sqlserver":   {"sqlserver", DepDatabase},
+	"clickhouse":  {"clickhouse", DepDatabase},
+	"amqp":        {"rabbitmq", DepEvent},
+	"amqps":       {"rabbitmq", DepEvent},
+	"kafka":       {"kafka", DepEvent},
+	"nats":        {"nats", DepEvent},
+}
+
+// scanLiterals finds called services, data stores, SQL tables, and listen
+// ports in the file's string literals.
+func (f *staticFacts) scanLiterals() {
+	for _, lit := range f.literals {
+		lit = strings.TrimSpace(lit)
+		if m := dsnRe.FindStringSubmatch(lit); m != nil {
+			store := dsnStores[strings.ToLower(m[1])]
+			f.addDep(store.name, store.typ)
+			continue
+		}
+		if m := literalURLRe.FindStringSubmatch(lit); m != nil {
+			if name := serviceHost(m[1]); name != "" {
+				typ := DepAPICall
+				if strings.HasPrefix(lit, "grpc://") || strings.HasPrefix(lit, "dns://") {
+					typ = DepGRPC
+				}
+				f.addDep(name, typ)
+			}
+			continue
+		}
+		if m := portRe.FindStringSubmatch(lit); m != nil {
+			f.ports = appendUnique(f.ports, m[1])
+			continue
+		}
+		if sqlRe.MatchString(lit) {
+			for _, m := range sqlTableRe.FindAllStringSubmatch(lit, -1) {
+				f.tables = appendUnique(f.tables, m[1])
+			}
+		}
+	}
+}
+
+// serviceHost returns the service a URL host names: the first label of a
+// cluster-local name, the host itself for other names, and "" for loopback,
+// documentation, and schema hosts that are not real dependencies.
+func serviceHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	switch {
+	case host == "localhost", host == "0.0.0.0", strings.HasPrefix(host, "127."),
+		strings.HasSuffix(host, "example.com"), strings.HasSuffix(host, "example.org"),
+		strings.HasSuffix(host, "w3.org"), strings.HasSuffix(host, "apache.org"),
+		strings.HasSuffix(host, "schemas.xmlsoap.org"), strings.HasSuffix(host, "json-schema.org"):
+		return ""
+	case strings.HasSuffix(host, ".svc.cluster.local"), strings.HasSuffix(host, ".svc"), strings.HasSuffix(host, ".internal"):
+		host, _, _ = strings.Cut(host, ".")
+	}
+	return host
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// Discrepancy is a difference CrossCheck found between an LLM analysis and
+// the source.
+type Discrepancy struct {
+	FilePath string
+	Kind     string // DiscrepancyUnknownSymbol, DiscrepancyMissedDependency, or DiscrepancyUnverifiedDependency
+	Name     string
+	Detail   string
+}
+
+// Discrepancy kinds.
+const (
+	// DiscrepancyUnknownSymbol is a function or type the LLM described that
+	// does not appear in the source. It is removed from the analysis.
+	DiscrepancyUnknownSymbol = "unknown_symbol"
+	// DiscrepancyMissedDependency is a service, data store, or event
+	// dependency found in the source that the LLM left out. It is added.
+	DiscrepancyMissedDependency = "missed_dependency"
+	// DiscrepancyUnverifiedDependency is a non-import dependency the LLM
+	// reported whose name does not appear in the source. It is kept, since
+	// it may come from configuration, but flagged for review.
+	DiscrepancyUnverifiedDependency = "unverified_dependency"
+)
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", d.FilePath, d.Kind, d.Name, d.Detail)
+}
+
+// CrossCheck corrects an LLM analysis against a static analysis of the same
+// source and reports what it changed or doubts. Functions and types the
+// source never mentions are dropped, their line ranges and signatures are
+// taken from the parser, and service-level dependencies the LLM missed are
+// added. The LLM's prose is left alone.
+func CrossCheck(a, static *FileAnalysis, source []byte) []Discrepancy {
+	var out []Discrepancy
+	report := func(kind, name, detail string) {
+		out = append(out, Discrepancy{FilePath: a.FilePath, Kind: kind, Name: name, Detail: detail})
+	}
+
+	declared := make(map[string]FunctionDoc)
+	for _, fn := range static.Functions {
+		declared[symbolName(fn.Name)] = fn
+	}
+	types := make(map[string]ClassDoc)
+	for _, c := range static.Classes {
+		types[symbolName(c.Name)] = c
+		for _, m := range c.Methods {
+			declared[symbolName(m.Name)] = m
+		}
+	}
+	src := string(source)
+
+	checkFunc := func(fn *FunctionDoc) bool {
+		name := symbolName(fn.Name)
+		if s, ok := declared[name]; ok {
+			fn.LineStart, fn.LineEnd = s.LineStart, s.LineEnd
+			if s.Signature != "" {
+				fn.Signature = s.Signature
+			}
+			return true
+		}
+		if name != "" && mentions(src, name) {
+			return true
+		}
+		report(DiscrepancyUnknownSymbol, fn.Name, "function not found in the source; removed")
+		return false
+	}
+
+	funcs := a.Functions[:0]
+	for _, fn := range a.Functions {
+		if checkFunc(&fn) {
+			funcs = append(funcs, fn)
+		}
+	}
+	a.Functions = funcs
+
+	classes := a.Classes[:0]
+	for _, c := range a.Classes {
+		name := symbolName(c.Name)
+		if s, ok := types[name]; ok {
+			c.LineStart, c.LineEnd = s.LineStart, s.LineEnd
+		} else if name == "" || !mentions(src, name) {
+			report(DiscrepancyUnknownSymbol, c.Name, "type not found in the source; removed")
+			continue
+		}
+		methods := c.Methods[:0]
+		for _, m := range c.Methods {
+			if checkFunc(&m) {
+				methods = append(methods, m)
+			}
+		}
+		c.Methods = methods
+		classes = append(classes, c)
+	}
+	a.Classes = classes
+
+	lowerSrc := strings.ToLower(src)
+	for _, d := range a.Dependencies {
+		if d.Type == DepImport {
+			continue
+		}
+		if !strings.Contains(lowerSrc, strings.ToLower(d.Name)) {
+			report(DiscrepancyUnverifiedDependency, d.Name, fmt.Sprintf("%s dependency not mentioned in the source", d.Type))
+		}
+	}
+	for _, d := range static.Dependencies {
+		if d.Type == DepImport || hasDependency(a.Dependencies, d.Name) {
+			continue
+		}
+		a.Dependencies = append(a.Dependencies, d)
+		report(DiscrepancyMissedDependency, d.Name, fmt.Sprintf("%s dependency found in the source; added", d.Type))
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out
+}
+
+// symbolName reduces the names models write for a symbol, such as
+// "(s *Server) Start", "Server.Start", or "start(ctx)", to its identifier.
+func symbolName(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") {
+		if i := strings.Index(s, ")"); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	s, _, _ = strings.Cut(s, "(")
+	s, _, _ = strings.Cut(s, "<")
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexAny(s, ". #:"); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+var identChars = regexp.MustCompile(`^\w+$`)
+
+// mentions reports whether ident appears in src as a whole word.
+func mentions(src, ident string) bool {
+	if !identChars.MatchString(ident) {
+		return strings.Contains(src, ident)
+	}
+	return regexp.MustCompile(`\b` + ident + `\b`).MatchString(src)
+}
+
+// hasDependency reports whether deps already covers name, allowing for the
+// suffixes models add, e.g. "payments-service" for "payments".
+func hasDependency(deps []Dependency, name string) bool {
+	name = strings.ToLower(name)
+	for _, d := range deps {
+		n := strings.ToLower(d.Name)
+		if n == name || strings.Contains(n, name) || strings.Contains(name, n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package indexer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// goStores maps Go import paths (by prefix) to the data stores and brokers
+// they connect to.
+var goStores = []struct {
+	prefix, name, typ string
+}{
+	{"github.com/lib/pq", "postgres", DepDatabase},
+	{"github.com/jackc/pgx", "postgres", DepDatabase},
+	{"gorm.io/driver/postgres", "postgres", DepDatabase},
+	{"github.com/go-sql-driver/mysql", "mysql", DepDatabase},
+	{"gorm.io/driver/mysql", "mysql", DepDatabase},
+	{"github.com/mattn/go-sqlite3", "sqlite", DepDatabase},
+	{"modernc.org/sqlite", "sqlite", DepDatabase},
+	{"gorm.io/driver/sqlite", "sqlite", DepDatabase},
+	{"github.com/redis/go-redis", "redis", DepDatabase},
+	{"github.com/go-redis/redis", "redis", DepDatabase},
+	{"github.com/gomodule/redigo", "redis", DepDatabase},
+	{"go.mongodb.org/mongo-driver", "mongodb", DepDatabase},
+	{"github.com/aws/aws-sdk-go-v2/service/dynamodb", "dynamodb", DepDatabase},
+	{"github.com/elastic/go-elasticsearch", "elasticsearch", DepDatabase},
+	{"github.com/segmentio/kafka-go", "kafka", DepEvent},
+	{"github.com/IBM/sarama", "kafka", DepEvent},
+	{"github.com/Shopify/sarama", "kafka", DepEvent},
+	{"github.com/confluentinc/confluent-kafka-go", "kafka", DepEvent},
+	{"github.com/twmb/franz-go", "kafka", DepEvent},
+	{"github.com/nats-io/nats.go", "nats", DepEvent},
+	{"github.com/rabbitmq/amqp091-go", "rabbitmq", DepEvent},
+	{"github.com/streadway/amqp", "rabbitmq", DepEvent},
+	{"cloud.google.com/go/pubsub", "pubsub", DepEvent},
+}
+
+// sqlDrivers maps database/sql driver names to data store names.
+var sqlDrivers = map[string]string{
+	"postgres": "postgres", "pgx": "postgres", "cloudsqlpostgres": "postgres",
+	"mysql": "mysql", "sqlite": "sqlite", "sqlite3": "sqlite",
+	"sqlserver": "sqlserver", "mssql": "sqlserver", "clickhouse": "clickhouse",
+}
+
+// routeMethods are the router methods that register a handler for a path,
+// across net/http, chi, gorilla/mux, gin, and echo.
+var routeMethods = map[string]string{
+	"HandleFunc": "", "Handle": "",
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"GET": "GET", "POST": "POST", "PUT": "PUT", "PATCH": "PATCH", "DELETE": "DELETE",
+	"Any": "", "Method": "",
+}
+
+// analyzeGo parses a Go file with go/ast. It returns nil when the file
+// does not parse far enough to have a package clause.
+func analyzeGo(filePath string, content []byte) *staticFacts {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil || file.Name == nil {
+		return nil
+	}
+	_ = err // a partial AST is still worth reading
+
+	f := &staticFacts{pkg: file.Name.Name, generated: ast.IsGenerated(file)}
+	if file.Doc != nil {
+		f.doc = file.Doc.Text()
+	}
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	usesGRPC := false
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		f.addDep(p, DepImport)
+		if strings.HasPrefix(p, "google.golang.org/grpc") || strings.HasSuffix(p, "pb") || strings.Contains(p, "proto") {
+			usesGRPC = true
+		}
+		for _, s := range goStores {
+			if p == s.prefix || strings.HasPrefix(p, s.prefix+"/") {
+				f.addDep(s.name, s.typ)
+			}
+		}
+	}
+
+	types := make(map[string]int) // type name -> index in f.classes
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			c := ClassDoc{Name: ts.Name.Name, LineStart: line(ts.Pos()), LineEnd: line(ts.End())}
+			switch {
+			case ts.Doc != nil:
+				c.Summary = firstSentence(ts.Doc.Text())
+			case gen.Doc != nil && len(gen.Specs) == 1:
+				c.Summary = firstSentence(gen.Doc.Text())
+			}
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				for _, field := range st.Fields.List {
+					typ := goExpr(fset, field.Type)
+					desc := ""
+					if field.Doc != nil {
+						desc = firstSentence(field.Doc.Text())
+					} else if field.Comment != nil {
+						desc = firstSentence(field.Comment.Text())
+					}
+					if len(field.Names) == 0 {
+						c.Fields = append(c.Fields, FieldDoc{Name: typ, Type: typ, Description: desc})
+					}
+					for _, n := range field.Names {
+						c.Fields = append(c.Fields, FieldDoc{Name: n.Name, Type: typ, Description: desc})
+					}
+				}
+			}
+			if it, ok := ts.Type.(*ast.InterfaceType); ok {
+				for _, m := range it.Methods.List {
+					for _, n := range m.Names {
+						c.Methods = append(c.Methods, FunctionDoc{
+							Name:      n.Name,
+							Signature: n.Name + strings.TrimPrefix(goExpr(fset, m.Type), "func"),
+							LineStart: line(m.Pos()),
+							LineEnd:   line(m.End()),
+						})
+					}
+				}
+			}
+			types[c.Name] = len(f.classes)
+			f.classes = append(f.classes, c)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		fn := FunctionDoc{
+			Name:      fd.Name.Name,
+			Signature: goSignature(fset, fd),
+			LineStart: line(fd.Pos()),
+			LineEnd:   line(fd.End()),
+		}
+		if fd.Doc != nil {
+			fn.Summary = firstSentence(fd.Doc.Text())
+		}
+		for _, p := range fd.Type.Params.List {
+			typ := goExpr(fset, p.Type)
+			for _, n := range p.Names {
+				fn.Parameters = append(fn.Parameters, ParamDoc{Name: n.Name, Type: typ})
+			}
+		}
+		if res := fd.Type.Results; res != nil {
+			var rs []string
+			for _, r := range res.List {
+				rs = append(rs, goExpr(fset, r.Type))
+			}
+			fn.Returns = strings.Join(rs, ", ")
+		}
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			recv := receiverType(fd.Recv.List[0].Type)
+			if i, ok := types[recv]; ok {
+				f.classes[i].Methods = append(f.classes[i].Methods, fn)
+				continue
+			}
+			fn.Name = recv + "." + fn.Name
+		}
+		f.functions = append(f.functions, fn)
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				if s, err := strconv.Unquote(n.Value); err == nil {
+					f.literals = append(f.literals, s)
+				}
+			}
+		case *ast.CallExpr:
+			f.goCall(n, usesGRPC, line(n.Pos())-1)
+		}
+		return true
+	})
+	return f
+}
+
+// goCall records what a call tells us: registered routes, gRPC clients and
+// servers, SQL drivers, and environment variables. gRPC constructors are
+// only recognized in files that import gRPC or generated protobuf code.
+// line is the 0-based line the call starts on.
+func (f *staticFacts) goCall(call *ast.CallExpr, usesGRPC bool, line int) {
+	var pkg, name string
+	switch fn := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		name = fn.Sel.Name
+		if id, ok := fn.X.(*ast.Ident); ok {
+			pkg = id.Name
+		}
+	case *ast.Ident:
+		name = fn.Name
+	default:
+		return
+	}
+	arg := func(i int) string {
+		if i >= len(call.Args) {
+			return ""
+		}
+		if lit, ok := call.Args[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			s, _ := strconv.Unquote(lit.Value)
+			return s
+		}
+		return ""
+	}
+
+	switch {
+	case usesGRPC && strings.HasPrefix(name, "New") && strings.HasSuffix(name, "Client") && len(name) > len("NewClient") && len(call.Args) == 1:
+		// Generated gRPC constructors take only the connection.
+		f.addDep(strings.TrimSuffix(strings.TrimPrefix(name, "New"), "Client"), DepGRPC)
+	case usesGRPC && strings.HasPrefix(name, "Register") && strings.HasSuffix(name, "Server") && len(name) > len("RegisterServer") && len(call.Args) == 2:
+		f.serves = appendUnique(f.serves, strings.TrimSuffix(strings.TrimPrefix(name, "Register"), "Server"))
+	case (pkg == "sql" || pkg == "sqlx") && (name == "Open" || name == "Connect" || name == "MustOpen" || name == "MustConnect"):
+		if store, ok := sqlDrivers[arg(0)]; ok {
+			f.addDep(store, DepDatabase)
+		}
+	case pkg == "os" && (name == "Getenv" || name == "LookupEnv"):
+		if v := arg(0); v != "" {
+			f.env = appendUnique(f.env, v)
+		}
+	default:
+		method, ok := routeMethods[name]
+		if !ok || len(call.Args) < 2 {
+			return
+		}
+		p := arg(0)
+		if name == "Method" { // chi: r.Method("GET", "/path", h)
+			method, p = arg(0), arg(1)
+		}
+		if !strings.HasPrefix(p, "/") && !strings.Contains(p, " /") {
+			return
+		}
+		route := p
+		if method != "" {
+			route = method + " " + p
+		}
+		f.addRoute(route, line)
+	}
+}
+
+// goSignature prints a function declaration without its body or doc.
+func goSignature(fset *token.FileSet, fd *ast.FuncDecl) string {
+	sig := *fd
+	sig.Body = nil
+	sig.Doc = nil
+	return goExpr(fset, &sig)
+}
+
+func goExpr(fset *token.FileSet, n ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// receiverType returns the type name of a method receiver, without the
+// pointer or type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,915 @@
+package indexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The scanners in this file read Python, Java, TypeScript, and JavaScript
+// without a full parser. A small lexer strips comments and records string
+// literals and brace depth, so declarations can be matched line by line
+// without being fooled by code inside strings and comments. Tree-sitter
+// grammars would be more exact but need cgo, which autodoc avoids so it
+// cross-compiles as a single static binary.
+
+// scanned is a source file after lexing. Slices are indexed by 0-based
+// line number.
+type scanned struct {
+	lines     []string // original text
+	code      []string // comments blanked out; strings kept
+	depth     []int    // brace depth at the start of the line
+	paren     []int    // parenthesis depth at the start of the line
+	inLiteral []bool   // the line starts inside a multi-line string or comment
+	literals  []span   // string literals
+	comments  []span   // comments
+	braces    []brace  // braces outside strings and comments, in order
+}
+
+// span is a string literal or comment, with the lines it spans.
+type span struct {
+	text          string
+	line, endLine int
+}
+
+// brace is one { or } and the depth outside it.
+type brace struct {
+	line  int
+	depth int
+	open  bool
+}
+
+// lexSource lexes C-style source (// and /* */ comments, ', ", and `
+// strings) or, with python set, Python source (# comments, ' and "
+// strings). Both allow triple-quoted strings, which covers Python
+// docstrings and Java text blocks.
+func lexSource(src string, python bool) *scanned {
+	s := &scanned{lines: strings.Split(src, "\n")}
+	code := []byte(src)
+	line, depth, paren := 0, 0, 0
+	s.depth = append(s.depth, 0)
+	s.paren = append(s.paren, 0)
+	s.inLiteral = append(s.inLiteral, false)
+
+	const (
+		stCode = iota
+		stLineComment
+		stBlockComment
+		stString
+	)
+	state := stCode
+	var quote string // closing delimiter of the current string
+	start, startLine := 0, 0
+
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if c == '\n' {
+			switch state {
+			case stLineComment:
+				s.comments = append(s.comments, span{text: src[start:i], line: startLine, endLine: line})
+				state = stCode
+			case stString:
+				if len(quote) == 1 && quote != "`" {
+					// Unterminated single-line string; give up on it.
+					state = stCode
+				}
+			}
+			line++
+			s.depth = append(s.depth, depth)
+			s.paren = append(s.paren, paren)
+			s.inLiteral = append(s.inLiteral, state == stString || state == stBlockComment)
+			continue
+		}
+
+		switch state {
+		case stLineComment:
+			code[i] = ' '
+		case stBlockComment:
+			if c == '*' && i+1 < len(code) && code[i+1] == '/' {
+				code[i], code[i+1] = ' ', ' '
+				i++
+				s.comments = append(s.comments, span{text: src[start : i+1], line: startLine, endLine: line})
+				state = stCode
+			} else {
+				code[i] = ' '
+			}
+		case stString:
+			if c == '\\' && i+1 < len(code) && code[i+1] != '\n' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(src[i:], quote) {
+				s.literals = append(s.literals, span{text: src[start:i], line: startLine, endLine: line})
+				i += len(quote) - 1
+				state = stCode
+			}
+		default:
+			switch {
+			case python && c == '#', !python && c == '/' && i+1 < len(code) && code[i+1] == '/':
+				state, start, startLine = stLineComment, i, line
+				code[i] = ' '
+			case !python && c == '/' && i+1 < len(code) && code[i+1] == '*':
+				state, start, startLine = stBlockComment, i, line
+				code[i], code[i+1] = ' ', ' '
+				i++
+			case c == '"' || c == '\'' || (!python && c == '`'):
+				quote = string(c)
+				if strings.HasPrefix(src[i:], strings.Repeat(quote, 3)) {
+					quote = strings.Repeat(quote, 3)
+				}
+				i += len(quote) - 1
+				state, start, startLine = stString, i+1, line
+			case c == '{':
+				s.braces = append(s.braces, brace{line: line, depth: depth, open: true})
+				depth++
+			case c == '}':
+				if depth > 0 {
+					depth--
+				}
+				s.braces = append(s.braces, brace{line: line, depth: depth})
+			case c == '(' || c == '[':
+				paren++
+			case c == ')' || c == ']':
+				if paren > 0 {
+					paren--
+				}
+			}
+		}
+	}
+	if state == stLineComment {
+		s.comments = append(s.comments, span{text: src[start:], line: startLine, endLine: line})
+	}
+	s.code = strings.Split(string(code), "\n")
+	return s
+}
+
+// blockEnd returns the line of the brace closing the first block opened on
+// lines start through start+lookahead, or start when there is none.
+func (s *scanned) blockEnd(start, lookahead int) int {
+	for i, b := range s.braces {
+		if b.line < start || !b.open {
+			continue
+		}
+		if b.line > start+lookahead {
+			break
+		}
+		for _, e := range s.braces[i+1:] {
+			if !e.open && e.depth == b.depth {
+				return e.line
+			}
+		}
+		return len(s.lines) - 1
+	}
+	return start
+}
+
+// literalsOn returns the string literals starting on line.
+func (s *scanned) literalsOn(line int) []string {
+	var out []string
+	for _, l := range s.literals {
+		if l.line == line {
+			out = append(out, l.text)
+		}
+	}
+	return out
+}
+
+// docBefore returns the comment ending just above line, skipping any
+// annotation or decorator lines in between. Consecutive line comments are
+// joined.
+func (s *scanned) docBefore(line int) string {
+	l := line - 1
+	for l >= 0 && strings.HasPrefix(strings.TrimSpace(s.code[l]), "@") {
+		l--
+	}
+	var parts []string
+	for l >= 0 && strings.TrimSpace(s.code[l]) == "" {
+		found := false
+		for _, c := range s.comments {
+			if c.endLine != l {
+				continue
+			}
+			parts = append([]string{commentText(c.text)}, parts...)
+			l = c.line - 1
+			found = strings.HasPrefix(c.text, "//") || strings.HasPrefix(c.text, "#")
+			break
+		}
+		if !found {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// annotationsAbove returns the annotation or decorator lines directly above
+// line, top to bottom.
+func (s *scanned) annotationsAbove(line int) []string {
+	var out []string
+	for l := line - 1; l >= 0; l-- {
+		t := strings.TrimSpace(s.code[l])
+		if !strings.HasPrefix(t, "@") {
+			break
+		}
+		out = append([]string{t}, out...)
+	}
+	return out
+}
+
+// fileDoc returns the comment at the top of the file, skipping license
+// headers, shebangs, and encoding lines.
+func (s *scanned) fileDoc() string {
+	for i, code := range s.code {
+		if strings.TrimSpace(code) != "" && !s.inLiteral[i] {
+			break
+		}
+		for _, c := range s.comments {
+			if c.line == i {
+				text := commentText(c.text)
+				lower := strings.ToLower(text)
+				if strings.Contains(lower, "copyright") || strings.Contains(lower, "license") ||
+					strings.HasPrefix(text, "!") || strings.Contains(text, "-*-") {
+					break // license header, shebang, or encoding line
+				}
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// commentText strips comment markers and Javadoc tags from a comment.
+func commentText(c string) string {
+	c = strings.TrimPrefix(c, "/**")
+	c = strings.TrimPrefix(c, "/*")
+	c = strings.TrimSuffix(c, "*/")
+	var out []string
+	for _, l := range strings.Split(c, "\n") {
+		l = strings.TrimSpace(l)
+		l = strings.TrimLeft(l, "/#*")
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "@") {
+			break
+		}
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// joinStatement joins the code lines of a declaration from start until its
+// parentheses close, so multi-line signatures read as one.
+func (s *scanned) joinStatement(start int) (string, int) {
+	end := start
+	for end+1 < len(s.code) && s.paren[end+1] > s.paren[start] {
+		end++
+	}
+	return strings.Join(strings.Fields(strings.Join(s.code[start:end+1], " ")), " "), end
+}
+
+// parenContents returns the text between the first ( in s and its match.
+func parenContents(s string) string {
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return ""
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			if s[i] == '>' && i > 0 && (s[i-1] == '=' || s[i-1] == '-') {
+				continue // => or ->
+			}
+			depth--
+			if depth == 0 {
+				return s[open+1 : i]
+			}
+		}
+	}
+	return s[open+1:]
+}
+
+// splitParams splits a parameter list on top-level commas.
+func splitParams(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			if s[i] == '>' && i > 0 && (s[i-1] == '=' || s[i-1] == '-') {
+				continue
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		out = append(out, last)
+	}
+	return out
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// isGenerated reports whether a file carries a generated-code marker near
+// the top.
+func isGenerated(src string) bool {
+	head := src
+	if len(head) > 1000 {
+		head = head[:1000]
+	}
+	return generatedRe.MatchString(head)
+}
+
+var generatedRe = regexp.MustCompile(`(?i)(code generated .* do not edit|@generated|autogenerated file)`)
+
+// storeImport maps an imported module or package to the data store or
+// broker it connects to, by prefix.
+func storeImport(f *staticFacts, module string, stores []struct{ prefix, name, typ string }) {
+	for _, s := range stores {
+		if module == s.prefix || strings.HasPrefix(module, s.prefix+".") || strings.HasPrefix(module, s.prefix+"/") {
+			f.addDep(s.name, s.typ)
+		}
+	}
+}
+
+// joinRoute joins a router or controller prefix and a path.
+func joinRoute(prefix, p string) string {
+	switch {
+	case prefix == "":
+	case p == "":
+		p = prefix
+	default:
+		p = strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(p, "/")
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// Python.
+
+var (
+	pyDefRe       = regexp.MustCompile(`^(\s*)(?:async\s+)?def\s+(\w+)\s*\(`)
+	pyClassRe     = regexp.MustCompile(`^(\s*)class\s+(\w+)\s*[(:]`)
+	pyFieldRe     = regexp.MustCompile(`^\s*(\w+)\s*:\s*([^=]+?)\s*(?:=.*)?$`)
+	pyImportRe    = regexp.MustCompile(`^\s*import\s+(.+)`)
+	pyFromRe      = regexp.MustCompile(`^\s*from\s+(\S+)\s+import\b`)
+	pyRouteRe     = regexp.MustCompile(`^@\w+(?:\.\w+)*\.(route|get|post|put|patch|delete|api_route)\(\s*[rf]?(['"])(.*?)['"](.*)`)
+	pyMethodsRe   = regexp.MustCompile(`methods\s*=\s*[\[(]([^\])]*)`)
+	pyPrefixRe    = regexp.MustCompile(`(?:APIRouter|Blueprint)\(.*\b(?:url_)?prefix\s*=\s*['"]([^'"]+)['"]`)
+	pyStubRe      = regexp.MustCompile(`\b(\w+)Stub\(`)
+	pyServicerRe  = regexp.MustCompile(`\badd_(\w+)Servicer_to_server\(`)
+	pyEnvRe       = regexp.MustCompile(`\bos\.(?:getenv|environ\.get)\(\s*['"](\w+)['"]|\bos\.environ\[\s*['"](\w+)['"]\s*\]`)
+	pyPortRe      = regexp.MustCompile(`\bport\s*=\s*(\d{2,5})\b`)
+	pyQuoteMarker = regexp.MustCompile(`^[rRbBuUfF]{0,2}("""|'''|"|')`)
+)
+
+var pyStores = []struct{ prefix, name, typ string }{
+	{"psycopg2", "postgres", DepDatabase}, {"psycopg", "postgres", DepDatabase}, {"asyncpg", "postgres", DepDatabase},
+	{"pymysql", "mysql", DepDatabase}, {"MySQLdb", "mysql", DepDatabase}, {"mysql", "mysql", DepDatabase}, {"aiomysql", "mysql", DepDatabase},
+	{"sqlite3", "sqlite", DepDatabase}, {"aiosqlite", "sqlite", DepDatabase},
+	{"redis", "redis", DepDatabase}, {"aioredis", "redis", DepDatabase},
+	{"pymongo", "mongodb", DepDatabase}, {"motor", "mongodb", DepDatabase},
+	{"elasticsearch", "elasticsearch", DepDatabase},
+	{"kafka", "kafka", DepEvent}, {"confluent_kafka", "kafka", DepEvent}, {"aiokafka", "kafka", DepEvent},
+	{"pika", "rabbitmq", DepEvent}, {"aio_pika", "rabbitmq", DepEvent}, {"nats", "nats", DepEvent},
+	{"google.cloud.pubsub", "pubsub", DepEvent}, {"google.cloud.pubsub_v1", "pubsub", DepEvent},
+}
+
+func analyzePython(content []byte) *staticFacts {
+	src := string(content)
+	s := lexSource(src, true)
+	f := &staticFacts{generated: isGenerated(src)}
+	for _, l := range s.literals {
+		f.literals = append(f.literals, l.text)
+	}
+
+	// docstring returns the string literal opening the block after line.
+	docstring := func(after int) string {
+		for i := after + 1; i < len(s.code); i++ {
+			t := strings.TrimSpace(s.code[i])
+			if t == "" {
+				continue
+			}
+			if pyQuoteMarker.MatchString(t) {
+				if lits := s.literalsOn(i); len(lits) > 0 {
+					return strings.TrimSpace(lits[0])
+				}
+			}
+			return ""
+		}
+		return ""
+	}
+	// blockEnd returns the last line indented under the block opened on start.
+	blockEnd := func(start, indent int) int {
+		end := start
+		for i := start + 1; i < len(s.code); i++ {
+			if s.inLiteral[i] || s.paren[i] > 0 {
+				end = i
+				continue
+			}
+			if strings.TrimSpace(s.code[i]) == "" {
+				continue
+			}
+			if indentOf(s.code[i]) <= indent {
+				break
+			}
+			end = i
+		}
+		return end
+	}
+
+	f.doc = docstring(-1)
+	if f.doc == "" {
+		f.doc = s.fileDoc()
+	}
+	usesGRPC := false
+	prefix := ""
+	if m := pyPrefixRe.FindStringSubmatch(src); m != nil && len(pyPrefixRe.FindAllString(src, -1)) == 1 {
+		prefix = m[1]
+	}
+
+	type scope struct {
+		indent int
+		class  int // index in f.classes, or -1 for a function
+	}
+	var stack []scope
+	for i, line := range s.code {
+		if s.inLiteral[i] || s.paren[i] > 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := indentOf(line)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		var parent *scope
+		if len(stack) > 0 {
+			parent = &stack[len(stack)-1]
+		}
+
+		if m := pyImportRe.FindStringSubmatch(line); m != nil {
+			for _, mod := range strings.Split(m[1], ",") {
+				mod, _, _ = strings.Cut(strings.TrimSpace(mod), " ")
+				f.addDep(mod, DepImport)
+				storeImport(f, mod, pyStores)
+				usesGRPC = usesGRPC || mod == "grpc" || strings.HasSuffix(mod, "_pb2_grpc")
+			}
+			continue
+		}
+		if m := pyFromRe.FindStringSubmatch(line); m != nil {
+			if strings.Trim(m[1], ".") != "" {
+				f.addDep(m[1], DepImport)
+			}
+			storeImport(f, m[1], pyStores)
+			usesGRPC = usesGRPC || m[1] == "grpc" || strings.Contains(line, "_pb2_grpc")
+			continue
+		}
+
+		if m := pyClassRe.FindStringSubmatch(line); m != nil {
+			if parent != nil && parent.class < 0 {
+				continue // a class inside a function
+			}
+			end := blockEnd(i, indent)
+			c := ClassDoc{Name: m[2], Summary: firstSentence(docstring(i)), LineStart: i + 1, LineEnd: end + 1}
+			if parent != nil {
+				c.Name = f.classes[parent.class].Name + "." + c.Name
+			}
+			bodyIndent := -1
+			for j := i + 1; j <= end; j++ {
+				if s.inLiteral[j] || s.paren[j] > 0 || strings.TrimSpace(s.code[j]) == "" {
+					continue
+				}
+				if bodyIndent < 0 {
+					bodyIndent = indentOf(s.code[j])
+				}
+				if indentOf(s.code[j]) != bodyIndent {
+					continue
+				}
+				if fm := pyFieldRe.FindStringSubmatch(s.code[j]); fm != nil && !strings.HasPrefix(strings.TrimSpace(s.code[j]), "def ") {
+					c.Fields = append(c.Fields, FieldDoc{Name: fm[1], Type: fm[2]})
+				}
+			}
+			stack = append(stack, scope{indent: indent, class: len(f.classes)})
+			f.classes = append(f.classes, c)
+			continue
+		}
+
+		if m := pyDefRe.FindStringSubmatch(line); m != nil {
+			sig, sigEnd := s.joinStatement(i)
+			sig = strings.TrimSuffix(sig, ":")
+			end := blockEnd(sigEnd, indent)
+			fn := FunctionDoc{Name: m[2], Signature: sig, Summary: firstSentence(docstring(sigEnd)), LineStart: i + 1, LineEnd: end + 1}
+			for _, p := range splitParams(parenContents(sig)) {
+				name, typ, _ := strings.Cut(p, ":")
+				name, _, _ = strings.Cut(name, "=")
+				typ, _, _ = strings.Cut(typ, "=")
+				name = strings.TrimSpace(name)
+				if name == "self" || name == "cls" || name == "*" || name == "/" || name == "" {
+					continue
+				}
+				fn.Parameters = append(fn.Parameters, ParamDoc{Name: name, Type: strings.TrimSpace(typ)})
+			}
+			if _, ret, ok := strings.Cut(sig[strings.LastIndex(sig, ")")+1:], "->"); ok {
+				fn.Returns = strings.TrimSpace(ret)
+			}
+			for _, a := range s.annotationsAbove(i) {
+				if rm := pyRouteRe.FindStringSubmatch(a); rm != nil {
+					methods := []string{strings.ToUpper(rm[1])}
+					if rm[1] == "route" || rm[1] == "api_route" {
+						methods = []string{"GET"}
+						if mm := pyMethodsRe.FindStringSubmatch(rm[4]); mm != nil {
+							methods = nil
+							for _, v := range strings.Split(mm[1], ",") {
+								if v = strings.ToUpper(strings.Trim(strings.TrimSpace(v), `'"`)); v != "" {
+									methods = append(methods, v)
+								}
+							}
+						}
+					}
+					for _, method := range methods {
+						f.addRoute(method+" "+joinRoute(prefix, rm[3]), i)
+					}
+				}
+			}
+			switch {
+			case parent == nil:
+				f.functions = append(f.functions, fn)
+			case parent.class >= 0:
+				f.classes[parent.class].Methods = append(f.classes[parent.class].Methods, fn)
+			}
+			stack = append(stack, scope{indent: indent, class: -1})
+		}
+	}
+
+	code := strings.Join(s.code, "\n")
+	if usesGRPC {
+		for _, m := range pyStubRe.FindAllStringSubmatch(code, -1) {
+			f.addDep(m[1], DepGRPC)
+		}
+		for _, m := range pyServicerRe.FindAllStringSubmatch(code, -1) {
+			f.serves = appendUnique(f.serves, m[1])
+		}
+	}
+	for _, m := range pyEnvRe.FindAllStringSubmatch(code, -1) {
+		f.env = appendUnique(f.env, m[1]+m[2])
+	}
+	for _, m := range pyPortRe.FindAllStringSubmatch(code, -1) {
+		f.ports = appendUnique(f.ports, m[1])
+	}
+	return f
+}
+
+// Java.
+
+var (
+	javaPackageRe = regexp.MustCompile(`^\s*package\s+([\w.]+)\s*;`)
+	javaImportRe  = regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.*]+)\s*;`)
+	javaTypeRe    = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*(class|interface|enum|record|@interface)\s+(\w+)`)
+	javaMethodRe  = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*((?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*)(?:<[^>]+>\s+)?(?:([\w.<>\[\]?, ]+?)\s+)?(\w+)\s*\(`)
+	javaFieldRe   = regexp.MustCompile(`^\s*(?:(?:public|protected|private|static|final|transient|volatile)\s+)*([\w.<>\[\]?, ]+?)\s+(\w+)\s*(?:=[^;]*)?;\s*$`)
+	javaMappingRe = regexp.MustCompile(`^@(Get|Post|Put|Patch|Delete|Request)Mapping\b(?:\((.*)\))?`)
+	javaVerbRe    = regexp.MustCompile(`RequestMethod\.(\w+)`)
+	javaJaxRSRe   = regexp.MustCompile(`^@(GET|POST|PUT|PATCH|DELETE)\b`)
+	javaPathRe    = regexp.MustCompile(`^@Path\(\s*(?:value\s*=\s*)?"([^"]*)"`)
+	javaStubRe    = regexp.MustCompile(`\b(\w+)Grpc\.new(?:Blocking|Future)?Stub\(`)
+	javaImplRe    = regexp.MustCompile(`\b(\w+)Grpc\.\w+ImplBase\b`)
+	javaEnvRe     = regexp.MustCompile(`\bSystem\.getenv\(\s*"(\w+)"`)
+	javaTopicRe   = regexp.MustCompile(`@KafkaListener\([^)]*topics\s*=\s*\{?\s*"([^"]+)"`)
+	firstQuotedRe = regexp.MustCompile(`"([^"]*)"`)
+)
+
+var javaKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"new": true, "throw": true, "else": true, "synchronized": true, "super": true, "this": true, "try": true,
+}
+
+var javaStores = []struct{ prefix, name, typ string }{
+	{"org.postgresql", "postgres", DepDatabase}, {"com.mysql", "mysql", DepDatabase}, {"org.mariadb", "mysql", DepDatabase},
+	{"redis.clients.jedis", "redis", DepDatabase}, {"io.lettuce", "redis", DepDatabase}, {"org.springframework.data.redis", "redis", DepDatabase},
+	{"com.mongodb", "mongodb", DepDatabase}, {"org.springframework.data.mongodb", "mongodb", DepDatabase},
+	{"org.elasticsearch", "elasticsearch", DepDatabase}, {"co.elastic", "elasticsearch", DepDatabase},
+	{"org.apache.kafka", "kafka", DepEvent}, {"org.springframework.kafka", "kafka", DepEvent},
+	{"com.rabbitmq", "rabbitmq", DepEvent}, {"org.springframework.amqp", "rabbitmq", DepEvent},
+	{"io.nats", "nats", DepEvent}, {"com.google.cloud.pubsub", "pubsub", DepEvent},
+}
+
+func analyzeJava(content []byte) *staticFacts {
+	src := string(content)
+	s := lexSource(src, false)
+	f := &staticFacts{generated: isGenerated(src)}
+	for _, l := range s.literals {
+		f.literals = append(f.literals, l.text)
+	}
+
+	type class struct {
+		index  int // in f.classes
+		body   int // brace depth of the class body
+		end    int
+		enum   bool
+		prefix string // class-level route prefix
+	}
+	var stack []class
+	for i, line := range s.code {
+		if s.inLiteral[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		for len(stack) > 0 && i > stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+		if m := javaPackageRe.FindStringSubmatch(line); m != nil {
+			f.pkg = m[1]
+			continue
+		}
+		if m := javaImportRe.FindStringSubmatch(line); m != nil {
+			f.addDep(m[1], DepImport)
+			storeImport(f, m[1], javaStores)
+			continue
+		}
+		if m := javaTypeRe.FindStringSubmatch(line); m != nil {
+			end := s.blockEnd(i, 5)
+			c := ClassDoc{Name: m[2], Summary: firstSentence(s.docBefore(i)), LineStart: i + 1, LineEnd: end + 1}
+			cl := class{index: len(f.classes), body: s.depth[i] + 1, end: end, enum: m[1] == "enum"}
+			for _, a := range s.annotationsAbove(i) {
+				if am := javaMappingRe.FindStringSubmatch(a); am != nil && am[1] == "Request" {
+					if q := firstQuotedRe.FindStringSubmatch(am[2]); q != nil {
+						cl.prefix = q[1]
+					}
+				}
+				if pm := javaPathRe.FindStringSubmatch(a); pm != nil {
+					cl.prefix = pm[1]
+				}
+			}
+			if len(stack) > 0 {
+				c.Name = f.classes[stack[len(stack)-1].index].Name + "." + c.Name
+			} else if f.doc == "" {
+				f.doc = s.docBefore(i)
+			}
+			stack = append(stack, cl)
+			f.classes = append(f.classes, c)
+			continue
+		}
+		if len(stack) == 0 || s.depth[i] != stack[len(stack)-1].body {
+			continue
+		}
+		cl := stack[len(stack)-1]
+
+		if cl.enum && !strings.Contains(line, ";") && !strings.Contains(line, "{") || cl.enum && strings.HasSuffix(strings.TrimSpace(line), ");") {
+			continue // enum constants
+		}
+		if m := javaMethodRe.FindStringSubmatch(line); m != nil && !javaKeywords[m[3]] && !javaKeywords[strings.TrimSpace(m[2])] {
+			sig, sigEnd := s.joinStatement(i)
+			sig, _, _ = strings.Cut(sig, "{")
+			sig = strings.TrimSuffix(strings.TrimSpace(sig), ";")
+			end := s.blockEnd(sigEnd, 1)
+			if !strings.Contains(strings.Join(s.code[i:sigEnd+1], " "), "{") {
+				end = sigEnd // abstract or interface method
+			}
+			fn := FunctionDoc{Name: m[3], Signature: sig, Summary: firstSentence(s.docBefore(i)), Returns: strings.TrimSpace(m[2]), LineStart: i + 1, LineEnd: end + 1}
+			for _, p := range splitParams(parenContents(sig)) {
+				words := strings.Fields(p)
+				for len(words) > 0 && (strings.HasPrefix(words[0], "@") || words[0] == "final") {
+					words = words[1:]
+				}
+				if len(words) >= 2 {
+					fn.Parameters = append(fn.Parameters, ParamDoc{Name: words[len(words)-1], Type: strings.Join(words[:len(words)-1], " ")})
+				}
+			}
+			f.classes[cl.index].Methods = append(f.classes[cl.index].Methods, fn)
+
+			for _, a := range append(s.annotationsAbove(i), strings.TrimSpace(line)) {
+				if am := javaMappingRe.FindStringSubmatch(a); am != nil {
+					method := strings.ToUpper(am[1])
+					if method == "REQUEST" {
+						method = "GET"
+						if vm := javaVerbRe.FindStringSubmatch(am[2]); vm != nil {
+							method = vm[1]
+						}
+					}
+					p := ""
+					if q := firstQuotedRe.FindStringSubmatch(am[2]); q != nil {
+						p = q[1]
+					}
+					f.addRoute(method+" "+joinRoute(cl.prefix, p), i)
+				}
+				if vm := javaJaxRSRe.FindStringSubmatch(a); vm != nil {
+					p := ""
+					for _, b := range s.annotationsAbove(i) {
+						if pm := javaPathRe.FindStringSubmatch(b); pm != nil {
+							p = pm[1]
+						}
+					}
+					f.addRoute(vm[1]+" "+joinRoute(cl.prefix, p), i)
+				}
+			}
+			continue
+		}
+		if m := javaFieldRe.FindStringSubmatch(line); m != nil && !javaKeywords[m[1]] && m[1] != "package" && m[1] != "import" {
+			f.classes[cl.index].Fields = append(f.classes[cl.index].Fields, FieldDoc{Name: m[2], Type: m[1], Description: firstSentence(s.docBefore(i))})
+		}
+	}
+
+	code := strings.Join(s.code, "\n")
+	for _, m := range javaStubRe.FindAllStringSubmatch(code, -1) {
+		f.addDep(m[1], DepGRPC)
+	}
+	for _, m := range javaImplRe.FindAllStringSubmatch(code, -1) {
+		f.serves = appendUnique(f.serves, m[1])
+	}
+	for _, m := range javaEnvRe.FindAllStringSubmatch(code, -1) {
+		f.env = appendUnique(f.env, m[1])
+	}
+	for _, m := range javaTopicRe.FindAllStringSubmatch(code, -1) {
+		f.addDep(m[1], DepEvent)
+	}
+	return f
+}
+
+// TypeScript and JavaScript.
+
+var (
+	tsImportRe     = regexp.MustCompile(`(?:\bfrom|\bimport|\brequire\()\s*\(?\s*['"]([^'"\n]+)['"]`)
+	tsFuncRe       = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)\s*(?:<[^>]*>)?\s*\(`)
+	tsArrowRe      = regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)?|\w+\s*=>)`)
+	tsClassRe      = regexp.MustCompile(`^\s*(?:@\w+(?:\([^)]*\))?\s+)*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(class|interface)\s+(\w+)`)
+	tsMethodRe     = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*\*?(\w+)\s*\??\s*(?:<[^>]*>)?\s*\(`)
+	tsPropRe       = regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|readonly|declare)\s+)*(\w+)\s*[?!]?\s*:\s*([^;=]+?)\s*[;,]?\s*$`)
+	tsRouteRe      = regexp.MustCompile(`\b(?:app|router|server|fastify|api|routes?|r)\.(get|post|put|patch|delete|all)\(\s*['"` + "`" + `](/[^'"` + "`" + `]*)`)
+	tsControllerRe = regexp.MustCompile(`^@Controller\(\s*(?:['"]([^'"]*)['"])?`)
+	tsVerbRe       = regexp.MustCompile(`^@(Get|Post|Put|Patch|Delete|All)\(\s*(?:['"]([^'"]*)['"])?`)
+	tsClientRe     = regexp.MustCompile(`\bnew\s+(?:\w+\.)*(\w+)Client\(`)
+	tsAddServiceRe = regexp.MustCompile(`\.addService\(\s*(?:\w+\.)*(\w+)\.service\b`)
+	tsEnvRe        = regexp.MustCompile(`\bprocess\.env\.(\w+)|\bprocess\.env\[\s*['"](\w+)['"]\s*\]`)
+	tsListenRe     = regexp.MustCompile(`\.listen\(\s*(\d{2,5})\b`)
+)
+
+var tsKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"function": true, "new": true, "throw": true, "else": true, "super": true, "await": true, "typeof": true,
+}
+
+var tsStores = []struct{ prefix, name, typ string }{
+	{"pg", "postgres", DepDatabase}, {"pg-promise", "postgres", DepDatabase}, {"postgres", "postgres", DepDatabase},
+	{"mysql", "mysql", DepDatabase}, {"mysql2", "mysql", DepDatabase},
+	{"sqlite3", "sqlite", DepDatabase}, {"better-sqlite3", "sqlite", DepDatabase},
+	{"redis", "redis", DepDatabase}, {"ioredis", "redis", DepDatabase},
+	{"mongodb", "mongodb", DepDatabase}, {"mongoose", "mongodb", DepDatabase},
+	{"@elastic/elasticsearch", "elasticsearch", DepDatabase},
+	{"kafkajs", "kafka", DepEvent}, {"node-rdkafka", "kafka", DepEvent},
+	{"amqplib", "rabbitmq", DepEvent}, {"nats", "nats", DepEvent}, {"@google-cloud/pubsub", "pubsub", DepEvent},
+}
+
+func analyzeScript(content []byte) *staticFacts {
+	src := string(content)
+	s := lexSource(src, false)
+	f := &staticFacts{generated: isGenerated(src), doc: s.fileDoc()}
+	for _, l := range s.literals {
+		f.literals = append(f.literals, l.text)
+	}
+	code := strings.Join(s.code, "\n")
+
+	usesGRPC := false
+	for _, m := range tsImportRe.FindAllStringSubmatch(code, -1) {
+		f.addDep(m[1], DepImport)
+		storeImport(f, m[1], tsStores)
+		usesGRPC = usesGRPC || strings.Contains(m[1], "grpc") || strings.Contains(m[1], "connectrpc")
+	}
+
+	type class struct {
+		index  int
+		body   int
+		end    int
+		prefix string
+	}
+	var stack []class
+	for i, line := range s.code {
+		if s.inLiteral[i] || strings.TrimSpace(line) == "" {
+			continue
+		}
+		for len(stack) > 0 && i > stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+		if s.depth[i] == 0 {
+			if m := tsClassRe.FindStringSubmatch(line); m != nil {
+				end := s.blockEnd(i, 5)
+				cl := class{index: len(f.classes), body: 1, end: end}
+				for _, a := range s.annotationsAbove(i) {
+					if cm := tsControllerRe.FindStringSubmatch(a); cm != nil {
+						cl.prefix = cm[1]
+					}
+				}
+				stack = append(stack, cl)
+				f.classes = append(f.classes, ClassDoc{Name: m[2], Summary: firstSentence(s.docBefore(i)), LineStart: i + 1, LineEnd: end + 1})
+				continue
+			}
+			m := tsFuncRe.FindStringSubmatch(line)
+			if m == nil {
+				m = tsArrowRe.FindStringSubmatch(line)
+			}
+			if m != nil {
+				sig, sigEnd := s.joinStatement(i)
+				if !strings.Contains(sig, "=>") && !strings.Contains(sig, "function") {
+					continue // a plain variable
+				}
+				sig, _, _ = strings.Cut(sig, "{")
+				sig = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sig), "=>"))
+				end := s.blockEnd(sigEnd, 1)
+				fn := FunctionDoc{Name: m[1], Signature: sig, Summary: firstSentence(s.docBefore(i)), LineStart: i + 1, LineEnd: end + 1}
+				fn.Parameters = scriptParams(sig)
+				f.functions = append(f.functions, fn)
+			}
+			continue
+		}
+		if len(stack) == 0 || s.depth[i] != stack[len(stack)-1].body {
+			continue
+		}
+		cl := stack[len(stack)-1]
+		if m := tsMethodRe.FindStringSubmatch(line); m != nil && !tsKeywords[m[1]] {
+			sig, sigEnd := s.joinStatement(i)
+			sig, _, _ = strings.Cut(sig, "{")
+			sig = strings.TrimSuffix(strings.TrimSpace(sig), ";")
+			end := sigEnd
+			if strings.Contains(strings.Join(s.code[i:sigEnd+1], " "), "{") {
+				end = s.blockEnd(sigEnd, 0)
+			}
+			fn := FunctionDoc{Name: m[1], Signature: sig, Summary: firstSentence(s.docBefore(i)), LineStart: i + 1, LineEnd: end + 1}
+			fn.Parameters = scriptParams(sig)
+			f.classes[cl.index].Methods = append(f.classes[cl.index].Methods, fn)
+			for _, a := range s.annotationsAbove(i) {
+				if vm := tsVerbRe.FindStringSubmatch(a); vm != nil {
+					f.addRoute(strings.ToUpper(vm[1])+" "+joinRoute(cl.prefix, vm[2]), i)
+				}
+			}
+			continue
+		}
+		if m := tsPropRe.FindStringSubmatch(line); m != nil {
+			f.classes[cl.index].Fields = append(f.classes[cl.index].Fields, FieldDoc{Name: m[1], Type: m[2], Description: firstSentence(s.docBefore(i))})
+		}
+	}
+
+	for _, m := range tsRouteRe.FindAllStringSubmatchIndex(code, -1) {
+		f.addRoute(strings.ToUpper(code[m[2]:m[3]])+" "+code[m[4]:m[5]], strings.Count(code[:m[0]], "\n"))
+	}
+	if usesGRPC {
+		for _, m := range tsClientRe.FindAllStringSubmatch(code, -1) {
+			f.addDep(m[1], DepGRPC)
+		}
+		for _, m := range tsAddServiceRe.FindAllStringSubmatch(code, -1) {
+			f.serves = appendUnique(f.serves, m[1])
+		}
+	}
+	for _, m := range tsEnvRe.FindAllStringSubmatch(code, -1) {
+		f.env = appendUnique(f.env, m[1]+m[2])
+	}
+	for _, m := range tsListenRe.FindAllStringSubmatch(code, -1) {
+		f.ports = appendUnique(f.ports, m[1])
+	}
+	return f
+}
+
+// scriptParams reads "name: Type = default" parameters from a TypeScript
+// or JavaScript signature.
+func scriptParams(sig string) []ParamDoc {
+	var out []ParamDoc
+	for _, p := range splitParams(parenContents(sig)) {
+		name, typ, _ := strings.Cut(p, ":")
+		name, _, _ = strings.Cut(name, "=")
+		typ, _, _ = strings.Cut(typ, "=")
+		name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "?"))
+		if name == "" {
+			continue
+		}
+		out = append(out, ParamDoc{Name: name, Type: strings.TrimSpace(typ)})
+	}
+	return out
+}
+
+// Other files.
+
+var (
+	genericURLRe  = regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^\s"'<>` + "`" + `]+`)
+	genericNoteRe = regexp.MustCompile(`^\s*(?://|#|--|;)\s*(.+)`)
+)
+
+// analyzeGeneric reads the URLs and connection strings in files with no
+// language scanner, such as configuration and manifests. A comment on the
+// first line is taken as the file's purpose.
+func analyzeGeneric(content []byte, language string) *staticFacts {
+	src := string(content)
+	f := &staticFacts{generated: isGenerated(src), literals: genericURLRe.FindAllString(src, -1)}
+	first, _, _ := strings.Cut(src, "\n")
+	if m := genericNoteRe.FindStringSubmatch(first); m != nil && language != "Markdown" && !strings.HasPrefix(m[1], "!") {
+		f.doc = strings.TrimSpace(m[1])
+	}
+	return f
+}
@@ -0,0 +1,362 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+const goSource = `// Package orders serves the orders API. It stores orders in Postgres.
+package orders
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	pb "example.com/gen/paymentspb"
+	"google.golang.org/grpc"
+)
+
+// Server handles order requests.
+type Server struct {
+	db       *sqlx.DB // order storage
+	payments pb.PaymentServiceClient
+}
+
+// NewServer connects to the database and the payments service.
+func NewServer(conn *grpc.ClientConn) (*Server, error) {
+	db, err := sqlx.Connect("postgres", os.Getenv("ORDERS_DSN"))
+	if err != nil {
+		return nil, err
+	}
+	return &Server{db: db, payments: pb.NewPaymentServiceClient(conn)}, nil
+}
+
+// Routes registers the HTTP handlers.
+func (s *Server) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /orders", s.list)
+	mux.HandleFunc("POST /orders", s.create)
+	http.ListenAndServe(":8080", mux)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	rows, _ := s.db.Query("SELECT id, total FROM orders JOIN order_items ON order_items.order_id = orders.id")
+	_ = rows
+	http.Get("http://inventory.prod.svc.cluster.local:8080/stock")
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {}
+
+var _ = sql.ErrNoRows
+`
+
+func TestStaticAnalyzeGo(t *testing.T) {
+	a := StaticAnalyze("orders/server.go", []byte(goSource), "Go")
+
+	if a.Purpose != "Package orders serves the orders API." {
+		t.Errorf("Purpose = %q", a.Purpose)
+	}
+	if len(a.Functions) != 1 || a.Functions[0].Name != "NewServer" || a.Functions[0].LineStart != 21 || a.Functions[0].LineEnd != 27 {
+		t.Errorf("Functions = %+v", a.Functions)
+	}
+	if got := a.Functions[0].Signature; got != "func NewServer(conn *grpc.ClientConn) (*Server, error)" {
+		t.Errorf("Signature = %q", got)
+	}
+	if len(a.Classes) != 1 || len(a.Classes[0].Methods) != 3 || len(a.Classes[0].Fields) != 2 {
+		t.Fatalf("Classes = %+v", a.Classes)
+	}
+	if a.Classes[0].Fields[0].Description != "order storage" {
+		t.Errorf("field description = %q", a.Classes[0].Fields[0].Description)
+	}
+
+	deps := make(map[string]string)
+	for _, d := range a.Dependencies {
+		deps[d.Name] = d.Type
+	}
+	for name, typ := range map[string]string{
+		"net/http":       DepImport,
+		"postgres":       DepDatabase,
+		"PaymentService": DepGRPC,
+		"inventory":      DepAPICall,
+	} {
+		if deps[name] != typ {
+			t.Errorf("dependency %s = %q, want %q (all: %v)", name, deps[name], typ, deps)
+		}
+	}
+	for _, want := range []string{"GET /orders, POST /orders", "port 8080", "PaymentService over gRPC", "orders, order_items", "ORDERS_DSN"} {
+		if !strings.Contains(a.Summary, want) {
+			t.Errorf("summary missing %q: %s", want, a.Summary)
+		}
+	}
+	if a.Skip {
+		t.Error("hand-written file marked skip")
+	}
+
+	gen := StaticAnalyze("x.pb.go", []byte("// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage x\n"), "Go")
+	if !gen.Skip {
+		t.Error("generated file not marked skip")
+	}
+}
+
+const pythonSource = `"""Checkout API. Accepts carts and charges payments."""
+import os
+from fastapi import APIRouter
+import psycopg2
+import grpc
+from gen import payments_pb2_grpc
+
+router = APIRouter(prefix="/api")
+
+# A comment mentioning def fake(): and "quotes".
+class Cart:
+    """A shopping cart."""
+    user_id: str
+    items: list[str] = []
+
+    def total(self, currency: str = "USD") -> int:
+        """Sum the item prices."""
+        return 0
+
+
+@router.post("/checkout")
+async def checkout(cart: Cart,
+                   dry_run: bool = False) -> dict:
+    """Charge the cart."""
+    stub = payments_pb2_grpc.PaymentServiceStub(grpc.insecure_channel(os.environ["PAYMENTS_ADDR"]))
+
+    def helper():
+        pass
+    return {"url": "http://orders:8080/orders"}
+`
+
+func TestStaticAnalyzePython(t *testing.T) {
+	a := StaticAnalyze("checkout.py", []byte(pythonSource), "Python")
+
+	if a.Purpose != "Checkout API." {
+		t.Errorf("Purpose = %q", a.Purpose)
+	}
+	if len(a.Functions) != 1 || a.Functions[0].Name != "checkout" || a.Functions[0].Summary != "Charge the cart." {
+		t.Fatalf("Functions = %+v", a.Functions)
+	}
+	fn := a.Functions[0]
+	if fn.LineStart != 22 || fn.LineEnd != 29 || len(fn.Parameters) != 2 || fn.Parameters[1].Type != "bool" || fn.Returns != "dict" {
+		t.Errorf("checkout = %+v", fn)
+	}
+	if len(a.Classes) != 1 || len(a.Classes[0].Methods) != 1 || len(a.Classes[0].Fields) != 2 || a.Classes[0].Summary != "A shopping cart." {
+		t.Errorf("Classes = %+v", a.Classes)
+	}
+	for _, want := range []string{"POST /api/checkout", "PaymentService over gRPC", "orders over HTTP", "postgres", "PAYMENTS_ADDR"} {
+		if !strings.Contains(a.Summary, want) {
+			t.Errorf("summary missing %q: %s", want, a.Summary)
+		}
+	}
+}
+
+const javaSource = `package com.example.orders;
+
+import org.springframework.web.bind.annotation.*;
+import org.springframework.kafka.annotation.KafkaListener;
+
+/**
+ * REST API for orders. Backed by the order repository.
+ */
+@RestController
+@RequestMapping("/api/orders")
+public class OrderController {
+    private final OrderRepository repo;
+
+    public OrderController(OrderRepository repo) {
+        this.repo = repo;
+    }
+
+    /** Lists all orders. */
+    @GetMapping
+    public List<Order> list() {
+        String s = "}";
+        return repo.findAll();
+    }
+
+    @PostMapping("/{id}/cancel")
+    public void cancel(@PathVariable long id) {
+        restTemplate.postForObject("http://payments-service/refunds", id, Void.class);
+    }
+
+    @KafkaListener(topics = "order-events")
+    void onEvent(String msg) {}
+}
+`
+
+func TestStaticAnalyzeJava(t *testing.T) {
+	a := StaticAnalyze("OrderController.java", []byte(javaSource), "Java")
+
+	if a.Purpose != "REST API for orders." {
+		t.Errorf("Purpose = %q", a.Purpose)
+	}
+	if len(a.Classes) != 1 {
+		t.Fatalf("Classes = %+v", a.Classes)
+	}
+	c := a.Classes[0]
+	if c.LineStart != 11 || c.LineEnd != 32 || len(c.Fields) != 1 {
+		t.Errorf("class = %+v", c)
+	}
+	var names []string
+	for _, m := range c.Methods {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "OrderController,list,cancel,onEvent" {
+		t.Errorf("methods = %v", names)
+	}
+	if m := c.Methods[1]; m.Summary != "Lists all orders." || m.Returns != "List<Order>" || m.LineEnd != 23 {
+		t.Errorf("list = %+v", m)
+	}
+	for _, want := range []string{"GET /api/orders, POST /api/orders/{id}/cancel", "payments-service over HTTP", "kafka, order-events"} {
+		if !strings.Contains(a.Summary, want) {
+			t.Errorf("summary missing %q: %s", want, a.Summary)
+		}
+	}
+}
+
+const tsSource = `// Gateway routes for the storefront.
+import express from 'express';
+import { createClient } from "redis";
+const grpc = require('@grpc/grpc-js');
+
+/** Starts the server. */
+export async function start(port: number = 3000): Promise<void> {
+  const app = express();
+  app.get('/health', (req, res) => res.send('ok'));
+  app.post("/cart", handler);
+  const inventory = new InventoryServiceClient(process.env.INVENTORY_ADDR, grpc.credentials.createInsecure());
+  await fetch(` + "`http://catalog:8080/products`" + `);
+  app.listen(8080);
+}
+
+export const handler = async (req: Request, res: Response) => {
+  res.json({});
+};
+
+const limit = (a + b) * 2;
+
+export class CartStore {
+  private items: string[];
+
+  constructor(private readonly ttl: number) {}
+
+  add(item: string): void {
+    if (item) {
+      this.items.push(item);
+    }
+  }
+}
+`
+
+func TestStaticAnalyzeTypeScript(t *testing.T) {
+	a := StaticAnalyze("gateway.ts", []byte(tsSource), "TypeScript")
+
+	if a.Purpose != "Gateway routes for the storefront." {
+		t.Errorf("Purpose = %q", a.Purpose)
+	}
+	if len(a.Functions) != 2 || a.Functions[0].Name != "start" || a.Functions[1].Name != "handler" {
+		t.Fatalf("Functions = %+v", a.Functions)
+	}
+	if fn := a.Functions[0]; fn.LineStart != 7 || fn.LineEnd != 14 || fn.Summary != "Starts the server." || len(fn.Parameters) != 1 || fn.Parameters[0].Type != "number" {
+		t.Errorf("start = %+v", fn)
+	}
+	if len(a.Classes) != 1 || len(a.Classes[0].Methods) != 2 || len(a.Classes[0].Fields) != 1 || a.Classes[0].Methods[1].LineEnd != 31 {
+		t.Errorf("Classes = %+v", a.Classes)
+	}
+	for _, want := range []string{"GET /health, POST /cart", "port 8080", "catalog over HTTP", "InventoryService over gRPC", "redis", "INVENTORY_ADDR"} {
+		if !strings.Contains(a.Summary, want) {
+			t.Errorf("summary missing %q: %s", want, a.Summary)
+		}
+	}
+}
+
+func TestStaticAnalyzeGeneric(t *testing.T) {
+	src := "# Deployment settings\nDATABASE_URL: postgres://app@db:5432/app\nPAYMENTS: http://payments:9000\nDOCS: https://example.com/x\n"
+	a := StaticAnalyze("config.yaml", []byte(src), "YAML")
+	if a.Purpose != "Deployment settings" || len(a.Dependencies) != 2 {
+		t.Errorf("analysis = %+v", a)
+	}
+	if issues := a.Validate(); len(issues) > 0 {
+		t.Errorf("invalid analysis: %v", issues)
+	}
+}
+
+func TestCrossCheck(t *testing.T) {
+	llmAnalysis := &FileAnalysis{
+		FilePath: "orders/server.go",
+		Summary:  "Serves orders.",
+		Functions: []FunctionDoc{
+			{Name: "NewServer", LineStart: 1, LineEnd: 2},
+			{Name: "ValidateOrder", Summary: "Validates an order."},
+		},
+		Classes: []ClassDoc{
+			{Name: "Server", Methods: []FunctionDoc{{Name: "(s *Server) Routes"}, {Name: "Shutdown"}}},
+			{Name: "OrderCache"},
+		},
+		Dependencies: []Dependency{
+			{Name: "postgres", Type: DepDatabase},
+			{Name: "billing", Type: DepAPICall},
+		},
+	}
+	static := StaticAnalyze("orders/server.go", []byte(goSource), "Go")
+	ds := CrossCheck(llmAnalysis, static, []byte(goSource))
+
+	if len(llmAnalysis.Functions) != 1 || llmAnalysis.Functions[0].LineStart != 21 {
+		t.Errorf("Functions = %+v", llmAnalysis.Functions)
+	}
+	if len(llmAnalysis.Classes) != 1 || len(llmAnalysis.Classes[0].Methods) != 1 || llmAnalysis.Classes[0].LineStart != 15 {
+		t.Errorf("Classes = %+v", llmAnalysis.Classes)
+	}
+	if llmAnalysis.Summary != "Serves orders." {
+		t.Errorf("summary changed to %q", llmAnalysis.Summary)
+	}
+
+	kinds := make(map[string][]string)
+	for _, d := range ds {
+		kinds[d.Kind] = append(kinds[d.Kind], d.Name)
+	}
+	if got := strings.Join(kinds[DiscrepancyUnknownSymbol], ","); got != "ValidateOrder,Shutdown,OrderCache" {
+		t.Errorf("unknown symbols = %q", got)
+	}
+	if got := strings.Join(kinds[DiscrepancyUnverifiedDependency], ","); got != "billing" {
+		t.Errorf("unverified = %q", got)
+	}
+	if got := strings.Join(kinds[DiscrepancyMissedDependency], ","); got != "PaymentService,inventory" {
+		t.Errorf("missed = %q", got)
+	}
+}
+
+func TestFileAnalyzerModes(t *testing.T) {
+	ctx := context.Background()
+
+	// Static mode never calls the provider.
+	static := NewFileAnalyzer(nil, config.QualityLite, "")
+	static.SetMode(config.AnalyzerStatic)
+	res, err := static.Analyze(ctx, "orders/server.go", []byte(goSource), "Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.InputTokens != 0 || res.Analysis.ContentHash == "" || !strings.Contains(res.Analysis.Summary, "GET /orders") {
+		t.Errorf("static result = %+v", res.Analysis)
+	}
+
+	cross := NewFileAnalyzer(llm.NewMockProvider(), config.QualityNormal, "mock")
+	cross.SetMode(config.AnalyzerCrossCheck)
+	res, err = cross.Analyze(ctx, "orders/server.go", []byte(goSource), "Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.InputTokens == 0 {
+		t.Error("crosscheck mode did not call the LLM")
+	}
+	if !hasDependency(res.Analysis.Dependencies, "postgres") || len(res.Discrepancies) == 0 {
+		t.Errorf("crosscheck result = %+v, discrepancies = %v", res.Analysis.Dependencies, res.Discrepancies)
+	}
+}
@@ -0,0 +1,240 @@
+package indexer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// maxReferences caps the calls and type uses recorded per symbol.
+const maxReferences = 30
+
+// ExtractReferences records, on each function and method of the analysis,
+// the functions it calls and the types it uses, read from the source. Go is
+// parsed with go/ast; other languages are scanned within each function's
+// line range, so functions without line numbers get no references. Names
+// are kept as written at the call site (e.g. "store.Search"); they are
+// resolved to definitions when queried.
+func ExtractReferences(a *FileAnalysis, content []byte) {
+	if a.Language == "Go" {
+		if refs := goReferences(a.FilePath, content); refs != nil {
+			applyReferences(a, refs)
+			return
+		}
+	}
+	s := lexSource(string(content), a.Language == "Python")
+	scan := func(fn *FunctionDoc) {
+		if fn.LineStart <= 0 || fn.LineEnd < fn.LineStart || fn.LineStart > len(s.code) {
+			return
+		}
+		body := strings.Join(s.code[fn.LineStart-1:min(fn.LineEnd, len(s.code))], "\n")
+		fn.Calls, fn.Uses = scanReferences(body, fn.Name)
+	}
+	for i := range a.Functions {
+		scan(&a.Functions[i])
+	}
+	for i := range a.Classes {
+		for j := range a.Classes[i].Methods {
+			scan(&a.Classes[i].Methods[j])
+		}
+	}
+}
+
+// symbolRefs are the calls and type uses of one Go function.
+type symbolRefs struct {
+	calls, uses []string
+}
+
+// goReferences maps each function in a Go file to its references, keyed by
+// name, or "Recv.Name" for methods. It returns nil if the file doesn't parse.
+func goReferences(filePath string, content []byte) map[string]symbolRefs {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.SkipObjectResolution)
+	if file == nil || (err != nil && len(file.Decls) == 0) {
+		return nil
+	}
+	refs := make(map[string]symbolRefs)
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		key := fd.Name.Name
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			key = receiverType(fd.Recv.List[0].Type) + "." + key
+		}
+		var r refList
+		var types refList
+		goTypeRefs(fd.Type, &types)
+		if fd.Body != nil {
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					if name := goCallName(n.Fun); name != "" && !goBuiltins[name] {
+						r.add(name)
+					}
+				case *ast.CompositeLit:
+					goTypeRefs(n.Type, &types)
+				case *ast.ValueSpec:
+					goTypeRefs(n.Type, &types)
+				case *ast.TypeAssertExpr:
+					goTypeRefs(n.Type, &types)
+				}
+				return true
+			})
+		}
+		refs[key] = symbolRefs{calls: r, uses: types}
+	}
+	return refs
+}
+
+// goCallName names the function a call expression calls: "f", "pkg.F", or
+// "x.Method". Calls through other expressions name just the method.
+func goCallName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		if id, ok := f.X.(*ast.Ident); ok {
+			return id.Name + "." + f.Sel.Name
+		}
+		return f.Sel.Name
+	case *ast.IndexExpr: // generic instantiation, f[T](...)
+		return goCallName(f.X)
+	case *ast.IndexListExpr:
+		return goCallName(f.X)
+	}
+	return ""
+}
+
+// goTypeRefs adds the named types in a type expression to types, skipping
+// predeclared ones.
+func goTypeRefs(expr ast.Node, types *refList) {
+	if expr == nil {
+		return
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := t.X.(*ast.Ident); ok {
+				types.add(id.Name + "." + t.Sel.Name)
+			}
+			return false
+		case *ast.Ident:
+			if !goBuiltins[t.Name] {
+				types.add(t.Name)
+			}
+		case *ast.Field:
+			// Parameter names aren't types.
+			goTypeRefs(t.Type, types)
+			return false
+		}
+		return true
+	})
+}
+
+// goBuiltins are predeclared functions and types, which aren't worth
+// recording as references.
+var goBuiltins = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true,
+	"delete": true, "imag": true, "len": true, "make": true, "max": true, "min": true,
+	"new": true, "panic": true, "print": true, "println": true, "real": true, "recover": true,
+	"any": true, "bool": true, "byte": true, "comparable": true, "error": true, "rune": true, "string": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+	"nil": true, "true": true, "false": true, "iota": true,
+}
+
+// applyReferences copies Go references onto the analysis's functions and
+// methods, matching them by name or by "Type.Name".
+func applyReferences(a *FileAnalysis, refs map[string]symbolRefs) {
+	set := func(fn *FunctionDoc, keys ...string) {
+		for _, k := range keys {
+			if r, ok := refs[k]; ok {
+				fn.Calls, fn.Uses = r.calls, r.uses
+				return
+			}
+		}
+	}
+	for i := range a.Functions {
+		set(&a.Functions[i], a.Functions[i].Name)
+	}
+	for i := range a.Classes {
+		c := &a.Classes[i]
+		for j := range c.Methods {
+			set(&c.Methods[j], c.Name+"."+c.Methods[j].Name, c.Methods[j].Name)
+		}
+	}
+}
+
+var (
+	// callRe matches a call: an identifier, optionally qualified, followed
+	// by an opening parenthesis.
+	callRe = regexp.MustCompile(`\b((?:[A-Za-z_$][\w$]*\.)?[A-Za-z_$][\w$]*)\s*\(`)
+	// typeUseRe matches capitalized names after new, extends, implements,
+	// a type annotation's colon, or a return arrow.
+	typeUseRe = regexp.MustCompile(`(?:\bnew\s+|\bextends\s+|\bimplements\s+|\bthrows\s+|\bcatch\s*\(\s*|:\s*|->\s*)([A-Z][\w]*)`)
+)
+
+// callKeywords are words followed by a parenthesis that aren't calls.
+var callKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"function": true, "def": true, "class": true, "elif": true, "with": true, "except": true,
+	"and": true, "or": true, "not": true, "in": true, "print": true, "super": true, "this": true,
+	"typeof": true, "await": true, "async": true, "yield": true, "lambda": true, "assert": true,
+	"synchronized": true, "new": true, "throw": true, "sizeof": true, "fn": true, "func": true,
+}
+
+// scanReferences finds the calls and type uses in a function's source text,
+// skipping the function's own name where it is declared.
+func scanReferences(body, self string) (calls, uses []string) {
+	var c, u refList
+	short := self[strings.LastIndex(self, ".")+1:]
+	declared := false
+	for _, m := range callRe.FindAllStringSubmatch(body, -1) {
+		name := m[1]
+		if callKeywords[name] {
+			continue
+		}
+		if !declared && name == short {
+			declared = true // the declaration itself, e.g. def name(
+			continue
+		}
+		c.add(name)
+	}
+	for _, m := range typeUseRe.FindAllStringSubmatch(body, -1) {
+		u.add(m[1])
+	}
+	return c, u
+}
+
+// refList is a list of names in first-seen order, without duplicates, and
+// capped at maxReferences.
+type refList []string
+
+func (r *refList) add(name string) {
+	if len(*r) >= maxReferences {
+		return
+	}
+	for _, n := range *r {
+		if n == name {
+			return
+		}
+	}
+	*r = append(*r, name)
+}
+
+// CallsSymbol reports whether a call or type use, as recorded by
+// ExtractReferences, refers to a symbol named name: either exactly, or
+// through a qualifier, as "store.Search" refers to Search and
+// "Store.Search".
+func CallsSymbol(ref, name string) bool {
+	if ref == name {
+		return true
+	}
+	short := name[strings.LastIndex(name, ".")+1:]
+	return strings.HasSuffix(ref, "."+short) || ref == short
+}
@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+const symbolsGoSource = `package auth
+
+type Token struct{ Subject string }
+
+type Verifier struct{ keys KeySet }
+
+func (v *Verifier) Verify(raw string) (*Token, error) {
+	claims, err := v.keys.Parse(raw)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &Token{Subject: claims.Subject}, nil
+}
+
+func wrapError(err error) error {
+	return fmt.Errorf("verify: %w", err)
+}
+`
+
+func TestExtractReferences_Go(t *testing.T) {
+	a := StaticAnalyze("auth/verify.go", []byte(symbolsGoSource), "Go")
+	ExtractReferences(a, []byte(symbolsGoSource))
+
+	var verify *FunctionDoc
+	for i := range a.Classes {
+		for j := range a.Classes[i].Methods {
+			if a.Classes[i].Name == "Verifier" && a.Classes[i].Methods[j].Name == "Verify" {
+				verify = &a.Classes[i].Methods[j]
+			}
+		}
+	}
+	if verify == nil {
+		t.Fatalf("Verifier.Verify not found in %+v", a.Classes)
+	}
+	// v.keys.Parse is called through a field, so only the method is named.
+	if want := []string{"Parse", "wrapError"}; !reflect.DeepEqual(verify.Calls, want) {
+		t.Errorf("Verify calls = %q, want %q", verify.Calls, want)
+	}
+	if !reflect.DeepEqual(verify.Uses, []string{"Token"}) {
+		t.Errorf("Verify uses = %q, want [Token]", verify.Uses)
+	}
+	for _, fn := range a.Functions {
+		if fn.Name == "wrapError" && !reflect.DeepEqual(fn.Calls, []string{"fmt.Errorf"}) {
+			t.Errorf("wrapError calls = %q", fn.Calls)
+		}
+	}
+}
+
+func TestExtractReferences_Python(t *testing.T) {
+	src := `class Checkout:
+    def submit(self, cart):
+        # validate(cart) is in a comment
+        order = Order(cart)
+        self.gateway.charge(order.total)
+        return receipt_for(order)
+`
+	a := &FileAnalysis{
+		FilePath: "shop/checkout.py",
+		Language: "Python",
+		Classes: []ClassDoc{{Name: "Checkout", LineStart: 1, LineEnd: 6, Methods: []FunctionDoc{
+			{Name: "submit", LineStart: 2, LineEnd: 6},
+		}}},
+	}
+	ExtractReferences(a, []byte(src))
+	got := a.Classes[0].Methods[0].Calls
+	if want := []string{"Order", "gateway.charge", "receipt_for"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("submit calls = %q, want %q", got, want)
+	}
+}
+
+func TestChunkAnalysis_SymbolDocuments(t *testing.T) {
+	a := &FileAnalysis{
+		FilePath: "auth/verify.go",
+		Language: "Go",
+		Functions: []FunctionDoc{
+			{Name: "wrapError", Summary: "Wraps errors.", Calls: []string{"fmt.Errorf"}},
+		},
+		Classes: []ClassDoc{{Name: "Verifier", Summary: "Verifies tokens.", Methods: []FunctionDoc{
+			{Name: "Verify", Summary: "Checks a token.", Calls: []string{"Parse", "wrapError"}, Uses: []string{"Token"}},
+		}}},
+	}
+
+	docs := ChunkAnalysisForRepo(a, config.QualityNormal, "auth")
+	byID := make(map[string]vectordb.Document)
+	for _, d := range docs {
+		byID[d.ID] = d
+	}
+	method, ok := byID["func:auth/verify.go:Verifier.Verify"]
+	if !ok {
+		t.Fatalf("no method document among %d documents", len(docs))
+	}
+	if method.Metadata.Symbol != "Verifier.Verify" || method.Metadata.SymbolID != "auth:auth/verify.go#Verifier.Verify" {
+		t.Errorf("method metadata = %+v", method.Metadata)
+	}
+	calls, uses := ParseSymbolReferences(method.Content)
+	if !reflect.DeepEqual(calls, []string{"Parse", "wrapError"}) || !reflect.DeepEqual(uses, []string{"Token"}) {
+		t.Errorf("references read back as %q and %q from:\n%s", calls, uses, method.Content)
+	}
+	if id := byID["class:auth/verify.go:Verifier"].Metadata.SymbolID; id != "auth:auth/verify.go#Verifier" {
+		t.Errorf("class symbol ID = %q", id)
+	}
+	if repo, path, name, ok := vectordb.ParseSymbolID(method.Metadata.SymbolID); !ok || repo != "auth" || path != "auth/verify.go" || name != "Verifier.Verify" {
+		t.Errorf("ParseSymbolID = %q %q %q %v", repo, path, name, ok)
+	}
+
+	for _, d := range ChunkAnalysis(a, config.QualityLite) {
+		if strings.HasPrefix(d.ID, "func:") {
+			t.Errorf("lite tier produced symbol document %s", d.ID)
+		}
+	}
+}
+
+func TestCallsSymbol(t *testing.T) {
+	tests := []struct {
+		ref, name string
+		want      bool
+	}{
+		{"wrapError", "wrapError", true},
+		{"v.keys.Parse", "KeySet.Parse", true},
+		{"Parse", "KeySet.Parse", true},
+		{"store.Search", "Search", true},
+		{"ParseAll", "Parse", false},
+		{"Search", "Store.Find", false},
+	}
+	for _, tt := range tests {
+		if got := CallsSymbol(tt.ref, tt.name); got != tt.want {
+			t.Errorf("CallsSymbol(%q, %q) = %v, want %v", tt.ref, tt.name, got, tt.want)
+		}
+	}
+}
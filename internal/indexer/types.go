@@ -17,6 +17,8 @@ type FileAnalysis struct {
 	// Skip is set by the LLM when a file is not relevant to the project's
 	// documentation (e.g. .gitignore, lock files, boilerplate configs).
 	Skip bool `json:"skip,omitempty"`
+	// Truncation is set when the file was too large to analyze in full.
+	Truncation *Truncation `json:"truncation,omitempty"`
 }
 
 // FunctionDoc describes a single function or method found in a file.
@@ -28,6 +30,10 @@ type FunctionDoc struct {
 	Returns    string     `json:"returns,omitempty"`
 	LineStart  int        `json:"line_start,omitempty"`
 	LineEnd    int        `json:"line_end,omitempty"`
+	// Calls and Uses are the functions this one calls and the types it
+	// uses, as written in the source; see ExtractReferences.
+	Calls []string `json:"calls,omitempty"`
+	Uses  []string `json:"uses,omitempty"`
 }
 
 // ParamDoc describes a function parameter.
@@ -71,6 +77,11 @@ type PipelineResult struct {
 	Duration          time.Duration
 	Errors            []error
 	Analyses          map[string]FileAnalysis
+	Discrepancies     []Discrepancy      // from the crosscheck analyzer
+	Truncated         []TruncationReport // files sampled or stubbed for size
+	Deferred          []string           // files left for the next run by the budget
+	Failed            map[string]error   // files that could not be analyzed or stored, and why
+	Redacted          []RedactionReport  // what was masked in each file before the LLM saw it
 }
 
 // CostEstimate provides a cost breakdown without making API calls.
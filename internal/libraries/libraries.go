@@ -0,0 +1,190 @@
+// Package libraries finds the internal libraries the services share: the
+// packages the system's own repos publish, or that sit in their namespace,
+// such as github.com/acme/lib-auth or @acme/ui, with the services that
+// depend on each. A change to one of them rebuilds and redeploys those
+// services, so they are linked to it for the blast radius.
+package libraries
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+)
+
+// LinkType is the type of the links from services to the libraries they
+// depend on.
+const LinkType = "library"
+
+// Repo is a registered repo's package manifests: the packages it publishes
+// and the libraries it depends on.
+type Repo struct {
+	Name    string
+	Modules []importers.Module
+	Deps    []importers.Dependency
+}
+
+// Load reads the manifests of the repo checked out at root. Manifests under
+// skip, the slash-separated directories of services split out of a
+// monorepo, belong to those services and are left out.
+func Load(name, root string, skip []string) (Repo, error) {
+	r := Repo{Name: name}
+	modules, err := importers.LoadRepoModules(root)
+	if err != nil {
+		return r, fmt.Errorf("reading %s's manifests: %w", name, err)
+	}
+	deps, err := importers.LoadRepoDependencies(root)
+	if err != nil {
+		return r, fmt.Errorf("reading %s's manifests: %w", name, err)
+	}
+	skipped := func(manifest string) bool {
+		for _, dir := range skip {
+			if dir = strings.Trim(dir, "/"); dir != "" && strings.HasPrefix(manifest, dir+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, m := range modules {
+		if !skipped(m.Manifest) {
+			r.Modules = append(r.Modules, m)
+		}
+	}
+	for _, d := range deps {
+		if !skipped(d.Manifest) {
+			r.Deps = append(r.Deps, d)
+		}
+	}
+	return r, nil
+}
+
+// User is a service's dependency on a library.
+type User struct {
+	Service  string `json:"service"`
+	Version  string `json:"version,omitempty"` // as declared
+	Manifest string `json:"manifest"`
+}
+
+// Library is an internal library and the services that depend on it.
+type Library struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+	// Repo is the registered repo that publishes the library; empty when
+	// it is only known by its namespace.
+	Repo  string `json:"repo,omitempty"`
+	Users []User `json:"users"`
+}
+
+// Node names the library in the service map: the repo that publishes it,
+// or else its package name.
+func (l Library) Node() string {
+	return cmp.Or(l.Repo, l.Name)
+}
+
+// Reason explains a user's link to the library, e.g. "depends on
+// github.com/acme/lib-auth v1.4.0 (go.mod)".
+func (l Library) Reason(u User) string {
+	s := "depends on " + l.Name
+	if u.Version != "" {
+		s += " " + u.Version
+	}
+	return s + " (" + u.Manifest + ")"
+}
+
+// Find finds the internal libraries repos depend on. A library is internal
+// when a repo publishes it, or when it shares the namespace of a package a
+// repo publishes, such as its Go module's organization or its npm scope;
+// one only known by its namespace counts when two or more services share
+// it. Libraries needed only to build or test, and a repo's dependencies on
+// its own packages, don't count. The most used libraries come first.
+func Find(repos []Repo) []Library {
+	publishers := make(map[string]string)
+	namespaces := make(map[string]bool)
+	for _, r := range repos {
+		for _, m := range r.Modules {
+			key := m.Ecosystem + "\x00" + m.Name
+			if _, ok := publishers[key]; !ok {
+				publishers[key] = r.Name
+			}
+			if ns := namespace(m.Ecosystem, m.Name); ns != "" {
+				namespaces[m.Ecosystem+"\x00"+ns] = true
+			}
+		}
+	}
+
+	var libs []Library
+	index := make(map[string]int)
+	for _, r := range repos {
+		for _, d := range r.Deps {
+			key := d.Ecosystem + "\x00" + d.Name
+			publisher, published := publishers[key]
+			if d.Dev || publisher == r.Name || (!published && !namespaces[d.Ecosystem+"\x00"+namespace(d.Ecosystem, d.Name)]) {
+				continue
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(libs)
+				index[key] = i
+				libs = append(libs, Library{Name: d.Name, Ecosystem: d.Ecosystem, Repo: publisher})
+			}
+			users := libs[i].Users
+			if len(users) > 0 && users[len(users)-1].Service == r.Name {
+				continue // declared by another of the repo's manifests
+			}
+			libs[i].Users = append(users, User{Service: r.Name, Version: d.Version, Manifest: d.Manifest})
+		}
+	}
+
+	shared := libs[:0]
+	for _, l := range libs {
+		if l.Repo != "" || len(l.Users) > 1 {
+			shared = append(shared, l)
+		}
+	}
+	sort.SliceStable(shared, func(i, j int) bool {
+		if len(shared[i].Users) != len(shared[j].Users) {
+			return len(shared[i].Users) > len(shared[j].Users)
+		}
+		return shared[i].Name < shared[j].Name
+	})
+	return shared
+}
+
+// codeHosts host many organizations' Go modules, so a module's namespace
+// there is its organization rather than the host.
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+}
+
+// namespace returns the part of a package name its organization owns, such
+// as "github.com/acme/" or "@acme/", or "" when the ecosystem has no
+// namespaces or the name isn't in one.
+func namespace(ecosystem, name string) string {
+	switch ecosystem {
+	case "Go":
+		parts := strings.Split(name, "/")
+		switch {
+		case len(parts) < 2 || !strings.Contains(parts[0], "."):
+			return ""
+		case codeHosts[parts[0]] && len(parts) < 3:
+			return ""
+		case codeHosts[parts[0]]:
+			return path.Join(parts[0], parts[1]) + "/"
+		}
+		return parts[0] + "/"
+	case "npm":
+		if scope, _, ok := strings.Cut(name, "/"); ok && strings.HasPrefix(scope, "@") {
+			return scope + "/"
+		}
+	case "Maven":
+		if group, _, ok := strings.Cut(name, ":"); ok {
+			return group + ":"
+		}
+	}
+	return ""
+}
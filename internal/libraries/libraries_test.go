@@ -0,0 +1,117 @@
+package libraries
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+)
+
+func TestFind(t *testing.T) {
+	repos := []Repo{
+		{
+			Name:    "orders",
+			Modules: []importers.Module{{Name: "github.com/acme/orders", Ecosystem: "Go", Manifest: "go.mod"}},
+			Deps: []importers.Dependency{
+				{Name: "github.com/acme/lib-auth", Version: "v1.4.0", Ecosystem: "Go", Manifest: "go.mod"},
+				{Name: "github.com/acme/lib-metrics", Version: "v0.3.0", Ecosystem: "Go", Manifest: "go.mod"},
+				{Name: "github.com/acme/testkit", Version: "v0.1.0", Ecosystem: "Go", Manifest: "go.mod", Dev: true},
+				{Name: "github.com/go-chi/chi/v5", Version: "v5.0.12", Ecosystem: "Go", Manifest: "go.mod"},
+			},
+		},
+		{
+			Name:    "billing",
+			Modules: []importers.Module{{Name: "github.com/acme/billing", Ecosystem: "Go", Manifest: "go.mod"}},
+			Deps: []importers.Dependency{
+				{Name: "github.com/acme/lib-auth", Version: "v1.3.2", Ecosystem: "Go", Manifest: "go.mod"},
+				{Name: "github.com/acme/lib-auth", Version: "v1.3.2", Ecosystem: "Go", Manifest: "tools/go.mod"},
+				{Name: "github.com/acme/orders", Version: "v0.9.0", Ecosystem: "Go", Manifest: "go.mod"},
+			},
+		},
+		{
+			Name: "web",
+			Modules: []importers.Module{
+				{Name: "@acme/web", Ecosystem: "npm", Manifest: "package.json"},
+				{Name: "@acme/ui", Ecosystem: "npm", Manifest: "packages/ui/package.json"},
+			},
+			Deps: []importers.Dependency{
+				{Name: "@acme/ui", Version: "workspace:*", Ecosystem: "npm", Manifest: "package.json"},
+				{Name: "@acme/analytics", Version: "^2.0.0", Ecosystem: "npm", Manifest: "package.json"},
+				{Name: "react", Version: "^18.2.0", Ecosystem: "npm", Manifest: "package.json"},
+			},
+		},
+	}
+
+	want := []Library{
+		{Name: "github.com/acme/lib-auth", Ecosystem: "Go", Users: []User{
+			{Service: "orders", Version: "v1.4.0", Manifest: "go.mod"},
+			{Service: "billing", Version: "v1.3.2", Manifest: "go.mod"},
+		}},
+		{Name: "github.com/acme/orders", Ecosystem: "Go", Repo: "orders", Users: []User{
+			{Service: "billing", Version: "v0.9.0", Manifest: "go.mod"},
+		}},
+	}
+	if got := Find(repos); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLibraryNodeAndReason(t *testing.T) {
+	lib := Library{Name: "github.com/acme/lib-auth", Ecosystem: "Go"}
+	if got := lib.Node(); got != "github.com/acme/lib-auth" {
+		t.Errorf("Node() = %q", got)
+	}
+	if got := lib.Reason(User{Service: "orders", Version: "v1.4.0", Manifest: "go.mod"}); got != "depends on github.com/acme/lib-auth v1.4.0 (go.mod)" {
+		t.Errorf("Reason() = %q", got)
+	}
+	lib.Repo = "lib-auth"
+	if got := lib.Node(); got != "lib-auth" {
+		t.Errorf("Node() = %q, want the publishing repo", got)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		ecosystem, name, want string
+	}{
+		{"Go", "github.com/acme/lib-auth", "github.com/acme/"},
+		{"Go", "github.com/acme", ""},
+		{"Go", "go.acme.io/platform/auth", "go.acme.io/"},
+		{"Go", "orders", ""},
+		{"npm", "@acme/ui", "@acme/"},
+		{"npm", "react", ""},
+		{"Maven", "com.acme:auth-client", "com.acme:"},
+		{"PyPI", "acme-auth", ""},
+	}
+	for _, tt := range tests {
+		if got := namespace(tt.ecosystem, tt.name); got != tt.want {
+			t.Errorf("namespace(%q, %q) = %q, want %q", tt.ecosystem, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSkipsSplitServices(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module github.com/acme/platform\n\nrequire github.com/acme/lib-auth v1.4.0\n",
+		"services/orders/go.mod": "module github.com/acme/orders\n\nrequire github.com/acme/lib-metrics v0.3.0\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte(content), 0o644)
+	}
+
+	r, err := Load("platform", root, []string{"services/orders"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(r.Modules) != 1 || r.Modules[0].Name != "github.com/acme/platform" {
+		t.Errorf("expected only the platform module, got %+v", r.Modules)
+	}
+	if len(r.Deps) != 1 || r.Deps[0].Name != "github.com/acme/lib-auth" {
+		t.Errorf("expected only the platform's dependency, got %+v", r.Deps)
+	}
+}
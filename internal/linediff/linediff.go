@@ -0,0 +1,133 @@
+// Package linediff renders line diffs of text for people to read, as in
+// golden-file test failures and the changes a page review shows.
+package linediff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 2
+
+// maxCells bounds the table the diff is computed with, len(old) by
+// len(new) lines once their common start and end are set aside. Past it
+// the differing middle is shown as removed and then added in full, which
+// is still correct, only less precise.
+const maxCells = 4 << 20
+
+// op is a line of the diff.
+type op struct {
+	kind byte // ' ', '-', or '+'
+	line string
+}
+
+// Diff returns a line diff of old and new, with removed lines prefixed by
+// "-", added ones by "+", and runs of unchanged lines elided. It returns ""
+// when they are equal.
+func Diff(old, new string) string {
+	if old == new {
+		return ""
+	}
+	var a, b []string
+	if old != "" {
+		a = strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	}
+	if new != "" {
+		b = strings.Split(strings.TrimSuffix(new, "\n"), "\n")
+	}
+
+	// Text mostly changes in a few places, so the lines it starts and ends
+	// with in common are set aside before the quadratic part.
+	var head, tail []op
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, op{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, op{' ', a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	ops := append(head, middle(a, b)...)
+	for k := len(tail) - 1; k >= 0; k-- {
+		ops = append(ops, tail[k])
+	}
+	return render(ops)
+}
+
+// middle diffs the lines between the common start and end by their
+// longest common subsequence.
+func middle(a, b []string) []op {
+	var ops []op
+	if len(a)*len(b) > maxCells {
+		for _, line := range a {
+			ops = append(ops, op{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, op{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// render writes the changed lines and the context around them.
+func render(ops []op) string {
+	show := make([]bool, len(ops))
+	for k, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		for c := max(0, k-contextLines); c <= min(len(ops)-1, k+contextLines); c++ {
+			show[c] = true
+		}
+	}
+	var buf bytes.Buffer
+	elided := false
+	for k, o := range ops {
+		if !show[k] {
+			if !elided {
+				buf.WriteString("  ...\n")
+				elided = true
+			}
+			continue
+		}
+		elided = false
+		fmt.Fprintf(&buf, "%c %s\n", o.kind, o.line)
+	}
+	return buf.String()
+}
@@ -0,0 +1,38 @@
+package linediff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	if d := Diff("a\nb\n", "a\nb\n"); d != "" {
+		t.Errorf("Diff of equal input = %q", d)
+	}
+	want := "  ...\n  c\n  d\n- e\n+ E\n  f\n  g\n  ...\n"
+	if d := Diff("a\nb\nc\nd\ne\nf\ng\nh\ni\n", "a\nb\nc\nd\nE\nf\ng\nh\ni\n"); d != want {
+		t.Errorf("Diff = %q, want %q", d, want)
+	}
+	want = "  ...\n  b\n  c\n- d\n+ D\n  e\n  f\n  g\n+ h\n"
+	if d := Diff("a\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nD\ne\nf\ng\nh\n"); d != want {
+		t.Errorf("Diff = %q, want %q", d, want)
+	}
+	if d := Diff("", "x\n"); d != "+ x\n" {
+		t.Errorf("Diff of new text = %q", d)
+	}
+}
+
+func TestDiffLargeInput(t *testing.T) {
+	// Past the table's bound the middle is shown removed, then added.
+	var a, b strings.Builder
+	for i := range 3000 {
+		fmt.Fprintf(&a, "old %d\n", i)
+		fmt.Fprintf(&b, "new %d\n", i)
+	}
+	d := Diff("same\n"+a.String()+"end\n", "same\n"+b.String()+"end\n")
+	lines := strings.Split(strings.TrimSuffix(d, "\n"), "\n")
+	if len(lines) != 6002 || lines[0] != "  same" || lines[1] != "- old 0" || lines[3001] != "+ new 0" || lines[6001] != "  end" {
+		t.Errorf("Diff of large input: %d lines, starting %q", len(lines), lines[:3])
+	}
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/awsauth"
+)
+
+// BedrockProvider implements Provider using the AWS Bedrock Converse API,
+// which takes the same request shape for every model family on Bedrock.
+type BedrockProvider struct {
+	endpoint string
+	region   string
+	model    string
+	creds    awsauth.Source
+	apiKey   string // a Bedrock API key, sent instead of signing
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewBedrockProvider creates a Bedrock provider that signs its requests
+// with AWS credentials from creds.
+func NewBedrockProvider(region string, creds awsauth.Source, model string) *BedrockProvider {
+	return &BedrockProvider{
+		endpoint: fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region),
+		region:   region,
+		model:    model,
+		creds:    creds,
+		client:   &http.Client{},
+		now:      time.Now,
+	}
+}
+
+// NewBedrockProviderWithAPIKey creates a Bedrock provider that
+// authenticates with a Bedrock API key.
+func NewBedrockProviderWithAPIKey(region, apiKey, model string) *BedrockProvider {
+	p := NewBedrockProvider(region, nil, model)
+	p.apiKey = apiKey
+	return p
+}
+
+// SetEndpoint overrides the regional endpoint, e.g. for a VPC endpoint.
+func (p *BedrockProvider) SetEndpoint(endpoint string) {
+	p.endpoint = strings.TrimSuffix(endpoint, "/")
+}
+
+func (p *BedrockProvider) Name() string {
+	return "bedrock"
+}
+
+type bedrockContent struct {
+	Text string `json:"text"`
+}
+
+type bedrockMessage struct {
+	Role    string           `json:"role"`
+	Content []bedrockContent `json:"content"`
+}
+
+type bedrockInferenceConfig struct {
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	Temperature float64 `json:"temperature"`
+}
+
+type bedrockConverseRequest struct {
+	Messages        []bedrockMessage       `json:"messages"`
+	System          []bedrockContent       `json:"system,omitempty"`
+	InferenceConfig bedrockInferenceConfig `json:"inferenceConfig"`
+}
+
+type bedrockConverseResponse struct {
+	Output struct {
+		Message bedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+}
+
+func (p *BedrockProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+
+	apiReq := bedrockConverseRequest{
+		InferenceConfig: bedrockInferenceConfig{MaxTokens: maxTokens, Temperature: req.Temperature},
+	}
+	for _, msg := range req.Messages {
+		if msg.Role == RoleSystem {
+			apiReq.System = append(apiReq.System, bedrockContent{Text: msg.Content})
+			continue
+		}
+		apiReq.Messages = append(apiReq.Messages, bedrockMessage{
+			Role:    string(msg.Role),
+			Content: []bedrockContent{{Text: msg.Content}},
+		})
+	}
+	// Converse has no JSON mode; the prompts ask for JSON themselves.
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bedrock request: %w", err)
+	}
+
+	url := p.endpoint + "/model/" + awsauth.URIEncode(model, true) + "/converse"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	} else {
+		creds, err := p.creds.Credentials(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("bedrock credentials: %w", err)
+		}
+		awsauth.Sign(httpReq, body, creds, p.region, "bedrock", p.now())
+	}
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bedrock response: %w", err)
+	}
+
+	// Throttling comes back as 429, which the analyzer's retry recognizes.
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bedrock returned status %d: %s", httpResp.StatusCode, string(respBody))
+	}
+
+	var apiResp bedrockConverseResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bedrock response: %w", err)
+	}
+
+	var content strings.Builder
+	for _, c := range apiResp.Output.Message.Content {
+		content.WriteString(c.Text)
+	}
+
+	return &CompletionResponse{
+		Content:      content.String(),
+		InputTokens:  apiResp.Usage.InputTokens,
+		OutputTokens: apiResp.Usage.OutputTokens,
+		Model:        model,
+		FinishReason: apiResp.StopReason,
+	}, nil
+}
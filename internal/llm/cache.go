@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheMiss is returned in replay mode for a request the cache has no
+// response for.
+var ErrCacheMiss = errors.New("no cached LLM response for this prompt")
+
+// CachedProvider answers requests it has seen before from responses kept
+// on disk, one JSON file per request, named by a hash of the provider, the
+// model, the messages, and the sampling settings. Rerunning a run over
+// unchanged files then costs nothing, and a recorded run can be replayed
+// without a network connection or an API key.
+type CachedProvider struct {
+	provider Provider // nil for a replay-only cache
+	name     string
+	dir      string
+	replay   bool
+
+	hits, misses atomic.Int64
+}
+
+// NewCachedProvider wraps p with the cache in dir. In replay mode, requests
+// the cache has no response for fail with ErrCacheMiss instead of reaching p.
+func NewCachedProvider(p Provider, dir string, replay bool) *CachedProvider {
+	return &CachedProvider{provider: p, name: p.Name(), dir: dir, replay: replay}
+}
+
+// NewReplayProvider replays the responses cached for the named provider,
+// without a provider to ask about new prompts, so a recorded run can be
+// replayed where the provider can't be created, e.g. without an API key.
+func NewReplayProvider(name, dir string) *CachedProvider {
+	return &CachedProvider{name: name, dir: dir, replay: true}
+}
+
+func (c *CachedProvider) Name() string {
+	return c.name
+}
+
+// cachedResponse is a response as stored on disk.
+type cachedResponse struct {
+	Content      string `json:"content"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+	Model        string `json:"model"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// Complete returns the cached response for req, or asks the wrapped
+// provider and caches its answer. Cached responses are marked Cached and
+// report no tokens, so budgets and usage reports count them as free.
+func (c *CachedProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	path := c.path(req)
+	if data, err := os.ReadFile(path); err == nil {
+		var cached cachedResponse
+		if err := json.Unmarshal(data, &cached); err == nil {
+			c.hits.Add(1)
+			// Touch the entry so Prune knows it is still in use.
+			now := time.Now()
+			_ = os.Chtimes(path, now, now)
+			return &CompletionResponse{
+				Content:      cached.Content,
+				Model:        cached.Model,
+				FinishReason: cached.FinishReason,
+				Cached:       true,
+			}, nil
+		}
+	}
+	if c.replay {
+		return nil, fmt.Errorf("%w (%s)", ErrCacheMiss, filepath.Base(path))
+	}
+
+	resp, err := c.provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	c.misses.Add(1)
+	if err := c.store(path, resp); err != nil {
+		// A cache that can't be written only costs the next run money.
+		slog.Warn("could not cache LLM response", "phase", "cache", "err", err)
+	}
+	return resp, nil
+}
+
+// Stats returns how many requests were answered from the cache and how
+// many reached the provider.
+func (c *CachedProvider) Stats() (hits, misses int) {
+	return int(c.hits.Load()), int(c.misses.Load())
+}
+
+// Prune removes the responses no run has used for longer than maxAge, so
+// the cache doesn't keep the analyses of code that has long since changed.
+func (c *CachedProvider) Prune(maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// path is where the response to req is cached. Entries are spread over
+// 256 subdirectories so none grows too large to list.
+func (c *CachedProvider) path(req CompletionRequest) string {
+	key := struct {
+		Provider    string
+		Model       string
+		Messages    []Message
+		MaxTokens   int
+		Temperature float64
+		JSONMode    bool
+	}{c.name, req.Model, req.Messages, req.MaxTokens, req.Temperature, req.JSONMode}
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, hash[:2], hash+".json")
+}
+
+func (c *CachedProvider) store(path string, resp *CompletionResponse) error {
+	data, err := json.MarshalIndent(cachedResponse{
+		Content:      resp.Content,
+		InputTokens:  resp.InputTokens,
+		OutputTokens: resp.OutputTokens,
+		Model:        resp.Model,
+		FinishReason: resp.FinishReason,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Concurrent workers may cache the same prompt; writing to a temporary
+	// file and renaming it keeps readers from seeing half an entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".llm-cache-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
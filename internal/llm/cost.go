@@ -1,5 +1,10 @@
 package llm
 
+import (
+	"strings"
+	"sync"
+)
+
 // modelPricing holds per-model pricing in USD per 1M tokens.
 type modelPricing struct {
 	InputPerMillion  float64
@@ -12,6 +17,7 @@ var priceTable = map[string]modelPricing{
 	"claude-sonnet-4-5-20250929": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
 	"claude-haiku-4-5-20251001":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
 	"claude-opus-4-6":            {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+	"claude-opus-4-1-20250805":   {InputPerMillion: 15.00, OutputPerMillion: 75.00},
 
 	// OpenAI models
 	"gpt-4o":      {InputPerMillion: 2.50, OutputPerMillion: 10.00},
@@ -20,12 +26,80 @@ var priceTable = map[string]modelPricing{
 	// Google models
 	"gemini-2.0-flash": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
 	"gemini-1.5-pro":   {InputPerMillion: 1.25, OutputPerMillion: 5.00},
+
+	// Bedrock models. Anthropic models on Bedrock are priced as on the
+	// Anthropic API, by the entries above; see bedrockModel.
+	"amazon.nova-micro-v1:0":          {InputPerMillion: 0.035, OutputPerMillion: 0.14},
+	"amazon.nova-lite-v1:0":           {InputPerMillion: 0.06, OutputPerMillion: 0.24},
+	"amazon.nova-pro-v1:0":            {InputPerMillion: 0.80, OutputPerMillion: 3.20},
+	"meta.llama3-3-70b-instruct-v1:0": {InputPerMillion: 0.72, OutputPerMillion: 0.72},
+	"mistral.mistral-large-2407-v1:0": {InputPerMillion: 2.00, OutputPerMillion: 6.00},
+}
+
+// localProviders run models on the user's own hardware, so their tokens
+// cost nothing.
+var localProviders = map[string]bool{"ollama": true, "openai-compatible": true, "mock": true}
+
+var (
+	customPricesMu sync.RWMutex
+	customPrices   = map[string]modelPricing{}
+)
+
+// SetPricing adds or overrides a model's prices, in USD per 1M tokens.
+func SetPricing(model string, inputPerMillion, outputPerMillion float64) {
+	customPricesMu.Lock()
+	defer customPricesMu.Unlock()
+	customPrices[model] = modelPricing{InputPerMillion: inputPerMillion, OutputPerMillion: outputPerMillion}
+}
+
+// lookupPricing finds a model's prices: the configured ones first, then the
+// built-in table, under the model's own name or its Bedrock base name.
+func lookupPricing(model string) (modelPricing, bool) {
+	customPricesMu.RLock()
+	pricing, ok := customPrices[model]
+	customPricesMu.RUnlock()
+	if ok {
+		return pricing, true
+	}
+	if pricing, ok := priceTable[model]; ok {
+		return pricing, true
+	}
+	pricing, ok = priceTable[bedrockModel(model)]
+	return pricing, ok
+}
+
+// bedrockModel strips what Bedrock adds to a model ID: the geography of a
+// cross-region inference profile, and for Anthropic models the vendor
+// prefix and version suffix, so "us.anthropic.claude-haiku-4-5-20251001-v1:0"
+// is priced as "claude-haiku-4-5-20251001".
+func bedrockModel(id string) string {
+	for _, geo := range []string{"us.", "eu.", "apac.", "global."} {
+		id = strings.TrimPrefix(id, geo)
+	}
+	if rest, ok := strings.CutPrefix(id, "anthropic."); ok {
+		if i := strings.LastIndex(rest, "-v"); i > 0 {
+			rest = rest[:i]
+		}
+		return rest
+	}
+	return id
+}
+
+// Pricing returns a model's prices in USD per 1M tokens when served by the
+// given provider: nothing for local providers, and the price table's
+// otherwise. ok is false for models the table doesn't know.
+func Pricing(provider, model string) (inputPerMillion, outputPerMillion float64, ok bool) {
+	if localProviders[provider] {
+		return 0, 0, true
+	}
+	pricing, ok := lookupPricing(model)
+	return pricing.InputPerMillion, pricing.OutputPerMillion, ok
 }
 
 // EstimateCost returns the estimated cost in USD for the given model and token counts.
 // Returns 0 if the model is not found in the price table.
 func EstimateCost(model string, inputTokens, outputTokens int) float64 {
-	pricing, ok := priceTable[model]
+	pricing, ok := lookupPricing(model)
 	if !ok {
 		return 0
 	}
@@ -1,17 +1,49 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/ziadkadry99/auto-doc/internal/auth"
+	"github.com/ziadkadry99/auto-doc/internal/awsauth"
 	"golang.org/x/oauth2"
 )
 
+// ProviderOptions are the settings some providers need beyond an API key.
+type ProviderOptions struct {
+	// BaseURL is the Azure OpenAI endpoint, the OpenAI-compatible server,
+	// the Ollama host, or a Bedrock endpoint replacing the regional one.
+	BaseURL string
+	// APIVersion is the Azure OpenAI API version.
+	APIVersion string
+	// Deployments maps models to the Azure deployments serving them.
+	Deployments map[string]string
+	// Region is the AWS region for Bedrock.
+	Region string
+	// RequestsPerMinute, when positive, caps the rate of requests.
+	RequestsPerMinute int
+}
+
 // NewProvider creates a new LLM provider based on the given provider type and model.
-// Supported provider types: "anthropic", "openai", "google", "ollama".
+// Supported provider types: "anthropic", "openai", "google", "ollama",
+// "minimax", "openrouter", "azure", "bedrock", "openai-compatible", and "mock".
 // Credential lookup order: env var → stored credentials → error.
 func NewProvider(providerType string, model string) (Provider, error) {
+	return NewProviderWithOptions(providerType, model, ProviderOptions{})
+}
+
+// NewProviderWithOptions creates a provider like NewProvider, with the
+// given settings, rate limited when opts.RequestsPerMinute is set.
+func NewProviderWithOptions(providerType string, model string, opts ProviderOptions) (Provider, error) {
+	p, err := newProvider(providerType, model, opts)
+	if err != nil || opts.RequestsPerMinute <= 0 {
+		return p, err
+	}
+	return NewRateLimitedProvider(p, opts.RequestsPerMinute), nil
+}
+
+func newProvider(providerType string, model string, opts ProviderOptions) (Provider, error) {
 	switch providerType {
 	case "anthropic":
 		apiKey := auth.GetAPIKey("anthropic")
@@ -40,12 +72,61 @@ func NewProvider(providerType string, model string) (Provider, error) {
 		return nil, fmt.Errorf("Google API credentials not found.\nRun `autodoc auth google` or set GOOGLE_API_KEY")
 
 	case "ollama":
-		host := os.Getenv("OLLAMA_HOST")
+		host := opts.BaseURL
+		if host == "" {
+			host = os.Getenv("OLLAMA_HOST")
+		}
 		if host == "" {
 			host = "http://localhost:11434"
 		}
 		return NewOllamaProvider(host, model), nil
 
+	case "azure":
+		apiKey := auth.GetAPIKey("azure")
+		if apiKey == "" {
+			return nil, fmt.Errorf("Azure OpenAI API key not found.\nRun `autodoc auth azure` or set AZURE_OPENAI_API_KEY")
+		}
+		endpoint := opts.BaseURL
+		if endpoint == "" {
+			endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		}
+		if endpoint == "" {
+			return nil, fmt.Errorf("Azure OpenAI endpoint not set.\nSet providers.azure.endpoint in .autodoc.yml or AZURE_OPENAI_ENDPOINT")
+		}
+		return NewAzureOpenAIProvider(apiKey, endpoint, opts.APIVersion, opts.Deployments, model), nil
+
+	case "bedrock":
+		region := opts.Region
+		for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+			if region == "" {
+				region = os.Getenv(env)
+			}
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		var p *BedrockProvider
+		if apiKey := os.Getenv("AWS_BEARER_TOKEN_BEDROCK"); apiKey != "" {
+			p = NewBedrockProviderWithAPIKey(region, apiKey, model)
+		} else {
+			creds := awsauth.NewChain()
+			if _, err := creds.Credentials(context.Background()); err != nil {
+				return nil, fmt.Errorf("AWS credentials not found: %w.\nSet AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (and AWS_SESSION_TOKEN), use a profile from ~/.aws/credentials, run with an IAM role, or set AWS_BEARER_TOKEN_BEDROCK", err)
+			}
+			p = NewBedrockProvider(region, creds, model)
+		}
+		if opts.BaseURL != "" {
+			p.SetEndpoint(opts.BaseURL)
+		}
+		return p, nil
+
+	case "openai-compatible":
+		baseURL := opts.BaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:8000/v1"
+		}
+		return NewOpenAICompatibleProvider(baseURL, os.Getenv("OPENAI_COMPATIBLE_API_KEY"), model), nil
+
 	case "minimax":
 		apiKey := auth.GetAPIKey("minimax")
 		if apiKey == "" {
@@ -60,6 +141,9 @@ func NewProvider(providerType string, model string) (Provider, error) {
 		}
 		return NewOpenRouterProvider(apiKey, model), nil
 
+	case "mock":
+		return NewMockProvider(), nil
+
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
@@ -2,13 +2,21 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/awsauth"
 )
 
-// MockProvider is a test provider that records calls and returns canned responses.
-type MockProvider struct {
+// recordingProvider is a test provider that records calls and returns canned responses.
+type recordingProvider struct {
 	mu        sync.Mutex
 	Calls     []CompletionRequest
 	Response  *CompletionResponse
@@ -16,8 +24,8 @@ type MockProvider struct {
 	ProvName  string
 }
 
-func NewMockProvider(name string) *MockProvider {
-	return &MockProvider{
+func newRecordingProvider(name string) *recordingProvider {
+	return &recordingProvider{
 		ProvName: name,
 		Response: &CompletionResponse{
 			Content:      "mock response",
@@ -29,11 +37,11 @@ func NewMockProvider(name string) *MockProvider {
 	}
 }
 
-func (m *MockProvider) Name() string {
+func (m *recordingProvider) Name() string {
 	return m.ProvName
 }
 
-func (m *MockProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+func (m *recordingProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.Calls = append(m.Calls, req)
@@ -43,7 +51,7 @@ func (m *MockProvider) Complete(ctx context.Context, req CompletionRequest) (*Co
 	return m.Response, nil
 }
 
-func (m *MockProvider) CallCount() int {
+func (m *recordingProvider) CallCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	return len(m.Calls)
@@ -51,8 +59,8 @@ func (m *MockProvider) CallCount() int {
 
 // --- Tests ---
 
-func TestMockProviderRecordsCalls(t *testing.T) {
-	mock := NewMockProvider("test")
+func TestRecordingProviderRecordsCalls(t *testing.T) {
+	mock := newRecordingProvider("test")
 	ctx := context.Background()
 
 	req := CompletionRequest{
@@ -160,7 +168,7 @@ func TestFactoryCreatesGoogleProvider(t *testing.T) {
 }
 
 func TestRateLimiterPassesThrough(t *testing.T) {
-	mock := NewMockProvider("test")
+	mock := newRecordingProvider("test")
 	rl := NewRateLimitedProvider(mock, 60)
 
 	ctx := context.Background()
@@ -182,7 +190,7 @@ func TestRateLimiterPassesThrough(t *testing.T) {
 }
 
 func TestRateLimiterLimitsRequests(t *testing.T) {
-	mock := NewMockProvider("test")
+	mock := newRecordingProvider("test")
 	// Allow only 2 requests per minute.
 	rl := NewRateLimitedProvider(mock, 2)
 
@@ -277,3 +285,282 @@ func TestRoles(t *testing.T) {
 		t.Errorf("RoleAssistant = %q, want 'assistant'", RoleAssistant)
 	}
 }
+
+func TestFactoryCreatesMockProvider(t *testing.T) {
+	p, err := NewProvider("mock", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name() != "mock" {
+		t.Errorf("Name() = %q, want mock", p.Name())
+	}
+}
+
+func TestMockProviderFileAnalysis(t *testing.T) {
+	prompt := "Analyze this go file and return a JSON object\n\nFile path: cmd/main.go\n\n```go\n// Serves orders.\npackage main\n\nfunc main() {\n\tmux.HandleFunc(\"/api/orders\", handleOrders)\n\thttp.ListenAndServe(\":8087\", mux)\n}\n\nfunc handleOrders() {\n\thttp.Get(\"http://payments:8085/api/charges\")\n}\n```"
+	resp, err := NewMockProvider().Complete(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: RoleSystem, Content: "You are a senior software engineer."}, {Role: RoleUser, Content: prompt}},
+		JSONMode: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Summary      string
+		Purpose      string
+		Functions    []struct{ Name string }
+		Dependencies []struct{ Name, Type string }
+	}
+	if err := json.Unmarshal([]byte(resp.Content), &got); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, resp.Content)
+	}
+	if len(got.Functions) != 2 || got.Functions[0].Name != "main" || got.Functions[1].Name != "handleOrders" {
+		t.Errorf("functions = %+v", got.Functions)
+	}
+	if len(got.Dependencies) != 1 || got.Dependencies[0].Name != "payments" || got.Dependencies[0].Type != "api_call" {
+		t.Errorf("dependencies = %+v", got.Dependencies)
+	}
+	if got.Purpose != "Serves orders." {
+		t.Errorf("purpose = %q", got.Purpose)
+	}
+	for _, want := range []string{"port 8087", "/api/orders", "payments"} {
+		if !strings.Contains(got.Summary, want) {
+			t.Errorf("summary %q missing %q", got.Summary, want)
+		}
+	}
+}
+
+func TestMockProviderLinkDiscovery(t *testing.T) {
+	prompt := "## Known Services\n\nSERVICE: payments\n\nSERVICE: users\n\n## New/Updated Service: orders\n\n### Detected Outbound Calls\n" +
+		"- http call to http://payments:8085/api/charges (POST) in main.go:12\n" +
+		"- http call to \"http://users:8080/api/users\", timeout=2 (get) in app.py:3\n" +
+		"- http call to http://unknown:9000/x (GET) in main.go:20\n"
+	resp, err := NewMockProvider().Complete(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: RoleSystem, Content: "You are analyzing how services in a distributed system interact."}, {Role: RoleUser, Content: prompt}},
+		JSONMode: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Dependencies []struct {
+			From, To  string
+			Endpoints []string
+		}
+		Flows []struct{ Services []string }
+	}
+	if err := json.Unmarshal([]byte(resp.Content), &got); err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, resp.Content)
+	}
+	if len(got.Dependencies) != 2 || got.Dependencies[0].To != "payments" || got.Dependencies[1].To != "users" {
+		t.Fatalf("dependencies = %+v", got.Dependencies)
+	}
+	if got.Dependencies[0].From != "orders" || got.Dependencies[0].Endpoints[0] != "/api/charges" {
+		t.Errorf("dependency = %+v", got.Dependencies[0])
+	}
+	if len(got.Flows) != 1 || strings.Join(got.Flows[0].Services, ",") != "orders,payments,users" {
+		t.Errorf("flows = %+v", got.Flows)
+	}
+}
+
+func TestMockProviderSections(t *testing.T) {
+	prompt := "Files:\n- api/server.go: serves\n- api/routes.go: routes\n- main.go: entry\n\n===PROJECT_OVERVIEW===\noverview\n\n===FEATURES===\nFEATURE: Name\n"
+	resp, err := NewMockProvider().Complete(context.Background(), CompletionRequest{
+		Messages: []Message{{Role: RoleUser, Content: prompt}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"===PROJECT_OVERVIEW===", "FEATURE: Api\n", "FILES: api/server.go, api/routes.go", "FEATURE: Core\n", "FILES: main.go"} {
+		if !strings.Contains(resp.Content, want) {
+			t.Errorf("response missing %q:\n%s", want, resp.Content)
+		}
+	}
+}
+
+func TestBedrockProviderConverse(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody bedrockConverseRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.EscapedPath(), r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &gotBody)
+		w.Write([]byte(`{"output":{"message":{"role":"assistant","content":[{"text":"Hello"},{"text":" there"}]}},"stopReason":"end_turn","usage":{"inputTokens":12,"outputTokens":3}}`))
+	}))
+	defer srv.Close()
+
+	p := NewBedrockProvider("eu-west-1", awsauth.Static{AccessKeyID: "AKID", SecretAccessKey: "secret"}, "anthropic.claude-haiku-4-5-20251001-v1:0")
+	p.SetEndpoint(srv.URL)
+	resp, err := p.Complete(context.Background(), CompletionRequest{
+		Messages:    []Message{{Role: RoleSystem, Content: "Be brief."}, {Role: RoleUser, Content: "Hi"}},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/model/anthropic.claude-haiku-4-5-20251001-v1%3A0/converse" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(gotAuth, "/eu-west-1/bedrock/aws4_request") {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if len(gotBody.System) != 1 || gotBody.System[0].Text != "Be brief." || len(gotBody.Messages) != 1 || gotBody.Messages[0].Role != "user" {
+		t.Errorf("request body = %+v", gotBody)
+	}
+	if gotBody.InferenceConfig.MaxTokens != 4096 || gotBody.InferenceConfig.Temperature != 0.2 {
+		t.Errorf("inference config = %+v", gotBody.InferenceConfig)
+	}
+	if resp.Content != "Hello there" || resp.InputTokens != 12 || resp.OutputTokens != 3 || resp.FinishReason != "end_turn" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestAzureOpenAIProviderRoutesToDeployments(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery+" key="+r.Header.Get("api-key"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	p := NewAzureOpenAIProvider("az-key", srv.URL, "", map[string]string{"gpt-4o": "docs-gpt4o"}, "gpt-4o")
+	for _, model := range []string{"", "gpt-4.1-mini"} {
+		if _, err := p.Complete(context.Background(), CompletionRequest{Model: model, Messages: []Message{{Role: RoleUser, Content: "Hi"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{
+		"/openai/deployments/docs-gpt4o/chat/completions?api-version=" + DefaultAzureAPIVersion + " key=az-key",
+		"/openai/deployments/gpt-41-mini/chat/completions?api-version=" + DefaultAzureAPIVersion + " key=az-key",
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(paths, "\n"), strings.Join(want, "\n"))
+	}
+	if p.Name() != "azure" {
+		t.Errorf("name = %q", p.Name())
+	}
+}
+
+func TestFactoryCreatesNewProviders(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_API_KEY", "test-key")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://example.openai.azure.com")
+	t.Setenv("AWS_BEARER_TOKEN_BEDROCK", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	for _, name := range []string{"azure", "bedrock", "openai-compatible"} {
+		p, err := NewProvider(name, "some-model")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.Name() != name {
+			t.Errorf("expected name %q, got %q", name, p.Name())
+		}
+	}
+	if p, _ := NewProvider("bedrock", "m"); p.(*BedrockProvider).endpoint != "https://bedrock-runtime.us-east-1.amazonaws.com" {
+		t.Errorf("bedrock endpoint = %q", p.(*BedrockProvider).endpoint)
+	}
+
+	p, err := NewProviderWithOptions("openai-compatible", "m", ProviderOptions{BaseURL: "http://vllm:8000/v1", RequestsPerMinute: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*RateLimitedProvider); !ok {
+		t.Errorf("expected a rate-limited provider, got %T", p)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	if _, err := NewProvider("bedrock", "m"); err == nil {
+		t.Error("expected an error for bedrock without AWS credentials")
+	}
+}
+
+func TestPricing(t *testing.T) {
+	in, out, ok := Pricing("bedrock", "us.anthropic.claude-sonnet-4-5-20250929-v1:0")
+	if !ok || in != 3 || out != 15 {
+		t.Errorf("bedrock claude pricing = %v, %v, %v", in, out, ok)
+	}
+	if in, out, ok := Pricing("ollama", "llama3"); !ok || in != 0 || out != 0 {
+		t.Errorf("local pricing = %v, %v, %v", in, out, ok)
+	}
+	if _, _, ok := Pricing("openai", "my-finetune"); ok {
+		t.Error("expected no pricing for an unknown model")
+	}
+	SetPricing("my-finetune", 1, 2)
+	if cost := EstimateCost("my-finetune", 1_000_000, 1_000_000); cost != 3 {
+		t.Errorf("custom pricing cost = %v, want 3", cost)
+	}
+}
+
+func TestMeterCountsUsageByTask(t *testing.T) {
+	meter := NewMeter()
+	mock := NewMockProvider()
+	ctx := context.Background()
+	req := CompletionRequest{Model: "gpt-4o", Messages: []Message{{Role: RoleUser, Content: "Summarize the request handling in this service."}}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := meter.Provider(mock, "analysis").Complete(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := meter.Provider(mock, "architecture").Complete(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := meter.Usage()
+	if len(usage) != 2 || usage[0].Task != "analysis" || usage[1].Task != "architecture" {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if usage[0].Calls != 2 || usage[1].Calls != 1 {
+		t.Errorf("calls = %d, %d; want 2, 1", usage[0].Calls, usage[1].Calls)
+	}
+	total := meter.Total()
+	if total.Calls != 3 || total.InputTokens != 3*usage[1].InputTokens {
+		t.Errorf("total = %+v", total)
+	}
+	if usage[0].InputTokens > 0 && usage[0].CostUSD == 0 {
+		t.Error("expected gpt-4o usage to be priced")
+	}
+	if meter.Provider(nil, "qa") != nil {
+		t.Error("expected a nil provider to stay nil")
+	}
+}
+
+func TestCachedProviderReplaysResponses(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	req := CompletionRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "Summarize the request handling in this service."}}}
+
+	cache := NewCachedProvider(NewMockProvider(), dir, false)
+	first, err := cache.Complete(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.Complete(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Cached || second.Content != first.Content || second.InputTokens != 0 {
+		t.Errorf("second response = %+v, want the first one from the cache, free", second)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("stats = %d hits, %d misses", hits, misses)
+	}
+
+	replay := NewReplayProvider("mock", dir)
+	got, err := replay.Complete(ctx, req)
+	if err != nil || got.Content != first.Content {
+		t.Errorf("replay = %+v, %v", got, err)
+	}
+	req.Temperature = 0.2
+	if _, err := replay.Complete(ctx, req); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss for a new prompt, got %v", err)
+	}
+
+	if n, err := cache.Prune(-time.Hour); err != nil || n != 1 {
+		t.Errorf("Prune removed %d entries (%v), want 1", n, err)
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// TaskUsage is what one task has used across a run.
+type TaskUsage struct {
+	Task         string
+	Calls        int
+	CachedCalls  int // calls answered from the LLM cache, included in Calls
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64 // 0 for models without known prices
+}
+
+// Meter accounts for LLM usage by task. Each task calls through its own
+// metered provider, so a run can report what its per-file analysis cost
+// apart from, say, its architecture overview.
+type Meter struct {
+	mu    sync.Mutex
+	order []string
+	usage map[string]*TaskUsage
+}
+
+// NewMeter creates an empty Meter.
+func NewMeter() *Meter {
+	return &Meter{usage: make(map[string]*TaskUsage)}
+}
+
+// Provider wraps p so its usage is counted under task. It returns nil for
+// a nil provider, so callers that run without an LLM stay without one.
+func (m *Meter) Provider(p Provider, task string) Provider {
+	if p == nil {
+		return nil
+	}
+	return &meteredProvider{Provider: p, meter: m, task: task}
+}
+
+// Usage returns each task's usage, in the order the tasks were first used.
+func (m *Meter) Usage() []TaskUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]TaskUsage, 0, len(m.order))
+	for _, task := range m.order {
+		out = append(out, *m.usage[task])
+	}
+	return out
+}
+
+// Total sums the usage of every task.
+func (m *Meter) Total() TaskUsage {
+	var total TaskUsage
+	for _, u := range m.Usage() {
+		total.Calls += u.Calls
+		total.CachedCalls += u.CachedCalls
+		total.InputTokens += u.InputTokens
+		total.OutputTokens += u.OutputTokens
+		total.CostUSD += u.CostUSD
+	}
+	return total
+}
+
+func (m *Meter) record(task, model string, resp *CompletionResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.usage[task]
+	if !ok {
+		u = &TaskUsage{Task: task}
+		m.usage[task] = u
+		m.order = append(m.order, task)
+	}
+	u.Calls++
+	if resp.Cached {
+		u.CachedCalls++
+	}
+	u.InputTokens += resp.InputTokens
+	u.OutputTokens += resp.OutputTokens
+	u.CostUSD += EstimateCost(model, resp.InputTokens, resp.OutputTokens)
+}
+
+type meteredProvider struct {
+	Provider
+	meter *Meter
+	task  string
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	// The request names the model as configured, which is what prices are
+	// listed under; responses often name a dated snapshot instead.
+	model := req.Model
+	if model == "" {
+		model = resp.Model
+	}
+	p.meter.record(p.task, model, resp)
+	return resp, nil
+}
@@ -7,9 +7,12 @@ import (
 )
 
 // OpenAIProvider implements Provider using the OpenAI Chat Completions API.
+// It also serves Azure OpenAI and OpenAI-compatible servers, which speak the
+// same API.
 type OpenAIProvider struct {
 	client *openai.Client
 	model  string
+	name   string
 }
 
 // NewOpenAIProvider creates a new OpenAI provider.
@@ -18,11 +21,53 @@ func NewOpenAIProvider(apiKey string, model string) *OpenAIProvider {
 	return &OpenAIProvider{
 		client: client,
 		model:  model,
+		name:   "openai",
+	}
+}
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is
+// configured.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// NewAzureOpenAIProvider creates a provider for an Azure OpenAI resource.
+// Requests for a model go to the deployment deployments maps it to; a model
+// without one goes to the deployment named after it, minus dots and colons,
+// which deployment names can't contain.
+func NewAzureOpenAIProvider(apiKey, endpoint, apiVersion string, deployments map[string]string, model string) *OpenAIProvider {
+	cfg := openai.DefaultAzureConfig(apiKey, endpoint)
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	cfg.APIVersion = apiVersion
+	fallback := cfg.AzureModelMapperFunc
+	cfg.AzureModelMapperFunc = func(model string) string {
+		if d, ok := deployments[model]; ok {
+			return d
+		}
+		return fallback(model)
+	}
+	return &OpenAIProvider{
+		client: openai.NewClientWithConfig(cfg),
+		model:  model,
+		name:   "azure",
+	}
+}
+
+// NewOpenAICompatibleProvider creates a provider for a server that speaks
+// the OpenAI Chat Completions API, such as vLLM, LM Studio, or Ollama's /v1
+// endpoint. apiKey may be empty for servers that don't check one.
+func NewOpenAICompatibleProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = baseURL
+	return &OpenAIProvider{
+		client: openai.NewClientWithConfig(cfg),
+		model:  model,
+		name:   "openai-compatible",
 	}
 }
 
 func (p *OpenAIProvider) Name() string {
-	return "openai"
+	return p.name
 }
 
 func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
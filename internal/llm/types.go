@@ -31,4 +31,7 @@ type CompletionResponse struct {
 	OutputTokens int
 	Model        string
 	FinishReason string
+	// Cached is set when the response came from a CachedProvider rather
+	// than a new call, so it cost nothing.
+	Cached bool
 }
@@ -0,0 +1,127 @@
+// Package mapviews gives the interactive maps saved views: the map's state
+// (filters, the selected node, zoom) kept in the URL hash so any state can
+// be shared as a link, and a Views menu listing the views named in the
+// site config alongside the ones readers save in their own browsers.
+package mapviews
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// View is a named map state, as the map's data carries it.
+type View struct {
+	Name  string `json:"name"`
+	State string `json:"state"` // the URL hash, without the '#'
+}
+
+// ForMap returns the configured views of one map, config.MapService or
+// config.MapComponent. A view's state may be a whole link to it.
+func ForMap(views []config.SavedViewConfig, m string) []View {
+	var out []View
+	for _, v := range views {
+		if cmp.Or(v.Map, config.MapService) != m {
+			continue
+		}
+		state := v.State
+		if i := strings.IndexByte(state, '#'); i >= 0 {
+			state = state[i+1:]
+		}
+		out = append(out, View{Name: v.Name, State: state})
+	}
+	return out
+}
+
+// Menu is the Views menu, for the map's toolbar.
+const Menu = `<select class="btn" id="views-menu" title="Saved views and links to this one"></select>`
+
+// JS runs the Views menu and keeps the URL hash in step with the map. It
+// goes inside the map's script, after the map is drawn, and expects data
+// (with data.views from ForMap) and two functions from it: viewState,
+// which returns the map's state as URLSearchParams, and applyViewState,
+// which restores one, resetting whatever the params leave out. The map
+// calls viewChanged whenever its state changes.
+const JS = `
+// ===== Saved views: the map's state in the URL hash =====
+var siteViews = data.views || [];
+var viewsKey = 'autodoc-views:' + location.pathname;
+var viewsMenu = document.getElementById('views-menu');
+var viewsReady = false, restoringView = false, viewTimer = null;
+function viewNameHTML(s){ return String(s).replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/"/g, '&quot;'); }
+// Views readers save live in their browser, per map.
+function localViews(){
+  try { return JSON.parse(localStorage.getItem(viewsKey)) || []; } catch(e){ return []; }
+}
+function saveLocalViews(views){
+  try { localStorage.setItem(viewsKey, JSON.stringify(views)); } catch(e){}
+  buildViewsMenu();
+}
+function currentViewHash(){
+  var state = viewState().toString();
+  return state ? '#' + state : '';
+}
+// viewChanged puts the map's state in the URL without adding to the
+// browser's history, once the map settles.
+function viewChanged(){
+  if(!viewsReady || restoringView) return;
+  clearTimeout(viewTimer);
+  viewTimer = setTimeout(function(){
+    var hash = currentViewHash();
+    if(hash !== location.hash) history.replaceState(null, '', hash || location.pathname + location.search);
+  }, 300);
+}
+function openView(hash){
+  restoringView = true;
+  try { applyViewState(new URLSearchParams(hash.replace(/^#/, ''))); }
+  finally { restoringView = false; }
+}
+function goToView(state){
+  history.pushState(null, '', state ? '#' + state : location.pathname + location.search);
+  openView(state);
+}
+function flashViewsMenu(text){
+  viewsMenu.options[0].textContent = text;
+  setTimeout(function(){ viewsMenu.options[0].textContent = 'Views…'; }, 1500);
+}
+function buildViewsMenu(){
+  var mine = localViews(), html = '<option value="">Views…</option>';
+  function group(label, views, prefix){
+    if(!views.length) return;
+    html += '<optgroup label="' + label + '">';
+    views.forEach(function(v, i){ html += '<option value="' + prefix + i + '">' + viewNameHTML(v.name) + '</option>'; });
+    html += '</optgroup>';
+  }
+  group('Site views', siteViews, 'site:');
+  group('My views', mine, 'mine:');
+  html += '<optgroup label="This view"><option value="save">Save as…</option><option value="copy">Copy link</option>';
+  if(mine.length) html += '<option value="remove">Remove a saved view…</option>';
+  viewsMenu.innerHTML = html + '</optgroup>';
+}
+viewsMenu.onchange = function(){
+  var choice = viewsMenu.value, mine = localViews(), name;
+  viewsMenu.value = '';
+  if(choice.indexOf('site:') === 0) goToView(siteViews[+choice.slice(5)].state);
+  else if(choice.indexOf('mine:') === 0) goToView(mine[+choice.slice(5)].state);
+  else if(choice === 'save'){
+    name = prompt('Name this view');
+    if(!name) return;
+    mine = mine.filter(function(v){ return v.name !== name; });
+    mine.push({name: name, state: viewState().toString()});
+    saveLocalViews(mine);
+    flashViewsMenu('Saved');
+  } else if(choice === 'copy'){
+    var url = location.href.split('#')[0] + currentViewHash();
+    if(navigator.clipboard) navigator.clipboard.writeText(url).then(function(){ flashViewsMenu('Link copied'); }, function(){ prompt('Copy this link', url); });
+    else prompt('Copy this link', url);
+  } else if(choice === 'remove'){
+    name = prompt('Remove which view? ' + mine.map(function(v){ return v.name; }).join(', '));
+    if(name) saveLocalViews(mine.filter(function(v){ return v.name !== name; }));
+  }
+};
+window.addEventListener('hashchange', function(){ openView(location.hash); });
+buildViewsMenu();
+if(location.hash.length > 1) openView(location.hash);
+viewsReady = true;
+`
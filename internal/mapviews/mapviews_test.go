@@ -0,0 +1,26 @@
+package mapviews
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func TestForMap(t *testing.T) {
+	views := []config.SavedViewConfig{
+		{Name: "Payments slice", State: "group=domain%3Dpayments"},
+		{Name: "Parser", Map: config.MapComponent, State: "#node=parser.go"},
+		{Name: "Checkout route", Map: config.MapService, State: "https://docs.example.com/service-map.html#from=web&to=ledger"},
+	}
+	want := []View{
+		{Name: "Payments slice", State: "group=domain%3Dpayments"},
+		{Name: "Checkout route", State: "from=web&to=ledger"},
+	}
+	if got := ForMap(views, config.MapService); !reflect.DeepEqual(got, want) {
+		t.Errorf("service map views = %+v, want %+v", got, want)
+	}
+	if got := ForMap(views, config.MapComponent); !reflect.DeepEqual(got, []View{{Name: "Parser", State: "node=parser.go"}}) {
+		t.Errorf("component map views = %+v", got)
+	}
+}
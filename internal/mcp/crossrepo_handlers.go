@@ -3,13 +3,21 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
 	"github.com/ziadkadry99/auto-doc/internal/docs"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/incident"
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/review"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
 // handleSearchAcrossRepos performs semantic search across all indexed repos.
@@ -39,19 +47,28 @@ func (s *Server) handleSearchAcrossRepos(ctx context.Context, request mcp.CallTo
 
 // handleGetServiceContext combines facts and search results to build complete service context.
 func (s *Server) handleGetServiceContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	service, err := request.RequireString("service")
+	service, err := s.focusArg(ctx, request, "service", focusService)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: service"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("# Service Context: %s\n\n", service))
+	s.writeServiceContext(ctx, &sb, service, "##", 5, nil)
 
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// writeServiceContext writes a service's facts, ownership, related decisions,
+// and top docs as sections headed at the given markdown level. Documents
+// whose IDs are in seen are skipped and newly written ones are added, so
+// batch responses don't repeat a document under several services.
+func (s *Server) writeServiceContext(ctx context.Context, sb *strings.Builder, service, heading string, docLimit int, seen map[string]bool) {
 	// Get facts from context engine if available.
 	if s.phase4 != nil && s.phase4.CtxStore != nil {
 		facts, err := s.phase4.CtxStore.GetCurrentFacts(ctx, "", "service", service)
 		if err == nil && len(facts) > 0 {
-			sb.WriteString("## Known Facts\n\n")
+			sb.WriteString(heading + " Known Facts\n\n")
 			for _, f := range facts {
 				sb.WriteString(fmt.Sprintf("- **%s**: %s (source: %s)\n", f.Key, f.Value, f.Source))
 			}
@@ -63,7 +80,7 @@ func (s *Server) handleGetServiceContext(ctx context.Context, request mcp.CallTo
 	if s.phase4 != nil && s.phase4.OrgStore != nil {
 		ownerships, err := s.phase4.OrgStore.GetOwnership(ctx, service)
 		if err == nil && len(ownerships) > 0 {
-			sb.WriteString("## Ownership\n\n")
+			sb.WriteString(heading + " Ownership\n\n")
 			for _, o := range ownerships {
 				sb.WriteString(fmt.Sprintf("- Team: %s (confidence: %s, source: %s)\n", o.TeamID, o.Confidence, o.Source))
 			}
@@ -71,21 +88,122 @@ func (s *Server) handleGetServiceContext(ctx context.Context, request mcp.CallTo
 		}
 	}
 
+	// Architecture decisions that mention this service.
+	if decisions := s.relatedDecisions(ctx, service); len(decisions) > 0 {
+		sb.WriteString(heading + " Architecture Decisions\n\n")
+		writeDecisionList(sb, decisions)
+	}
+
 	// Search for related documentation.
-	results, err := s.store.Search(ctx, service, 5, nil)
-	if err == nil && len(results) > 0 {
-		sb.WriteString("## Related Documentation\n\n")
+	results, err := s.store.Search(ctx, service, docLimit, nil)
+	if err != nil || len(results) == 0 {
+		return
+	}
+	if seen != nil {
+		fresh := results[:0]
+		for _, r := range results {
+			if !seen[r.Document.ID] {
+				seen[r.Document.ID] = true
+				fresh = append(fresh, r)
+			}
+		}
+		results = fresh
+	}
+	if len(results) > 0 {
+		sb.WriteString(heading + " Related Documentation\n\n")
 		sb.WriteString(formatSearchResults(results))
 	}
+}
+
+// maxBatchServices bounds how many services one batch lookup may request.
+const maxBatchServices = 20
+
+// handleGetServicesContextBatch returns context for several services in one
+// response, plus the flows and links that connect them.
+func (s *Server) handleGetServicesContextBatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requested, err := request.RequireStringSlice("services")
+	if err != nil {
+		return mcp.NewToolResultError("missing required parameter: services"), nil
+	}
+
+	// Trim and de-duplicate while keeping the caller's order.
+	var services []string
+	inBatch := make(map[string]bool)
+	for _, svc := range requested {
+		svc = strings.TrimSpace(svc)
+		if svc == "" || inBatch[strings.ToLower(svc)] {
+			continue
+		}
+		inBatch[strings.ToLower(svc)] = true
+		services = append(services, svc)
+	}
+	if len(services) == 0 {
+		return mcp.NewToolResultError("services must contain at least one service name"), nil
+	}
+	if len(services) > maxBatchServices {
+		return mcp.NewToolResultError(fmt.Sprintf("too many services: %d (max %d)", len(services), maxBatchServices)), nil
+	}
+
+	docLimit := request.GetInt("doc_limit", 3)
+	if docLimit <= 0 {
+		docLimit = 3
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Services Context: %s\n\n", strings.Join(services, ", ")))
+
+	// Connections among the requested services.
+	if len(services) > 1 && s.phase4 != nil && s.phase4.RepoStore != nil {
+		links, err := s.phase4.RepoStore.GetLinks(ctx, "")
+		if err == nil {
+			var lines []string
+			for _, l := range links {
+				if inBatch[strings.ToLower(l.FromRepo)] && inBatch[strings.ToLower(l.ToRepo)] {
+					lines = append(lines, fmt.Sprintf("- %s → %s (%s)", l.FromRepo, l.ToRepo, l.LinkType))
+				}
+			}
+			if len(lines) > 0 {
+				sb.WriteString("## Connections Between These Services\n\n")
+				sb.WriteString(strings.Join(lines, "\n") + "\n\n")
+			}
+		}
+	}
+	if len(services) > 1 && s.phase4 != nil && s.phase4.FlowStore != nil {
+		allFlows, err := s.phase4.FlowStore.ListFlows(ctx)
+		if err == nil {
+			var lines []string
+			for _, f := range allFlows {
+				var involved []string
+				for _, svc := range f.Services {
+					if inBatch[strings.ToLower(svc)] {
+						involved = append(involved, svc)
+					}
+				}
+				if len(involved) > 1 {
+					lines = append(lines, fmt.Sprintf("- %s (%s)", f.Name, strings.Join(involved, ", ")))
+				}
+			}
+			if len(lines) > 0 {
+				sb.WriteString("## Shared Flows\n\n")
+				sb.WriteString(strings.Join(lines, "\n") + "\n\n")
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, svc := range services {
+		sb.WriteString(fmt.Sprintf("## Service: %s\n\n", svc))
+		s.writeServiceContext(ctx, &sb, svc, "###", docLimit, seen)
+	}
 
 	return mcp.NewToolResultText(sb.String()), nil
 }
 
 // handleGetBlastRadius searches for references to a service across all docs.
 func (s *Server) handleGetBlastRadius(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	service, err := request.RequireString("service")
+	service, err := s.focusArg(ctx, request, "service", focusService)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: service"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	endpoint := request.GetString("endpoint", "")
@@ -108,6 +226,24 @@ func (s *Server) handleGetBlastRadius(ctx context.Context, request mcp.CallToolR
 	}
 	sb.WriteString("\n\n")
 
+	// Follow the service map back from the service, so the services that
+	// depend on a shared library or call the service are listed.
+	if s.phase4 != nil && s.phase4.RepoStore != nil {
+		if links, err := s.phase4.RepoStore.GetLinks(ctx, ""); err == nil {
+			if impacts := incident.BlastRadius(links, service, endpoint, incident.DefaultMaxDepth); len(impacts) > 0 {
+				sb.WriteString("## Affected Services\n\n")
+				for _, imp := range impacts {
+					fmt.Fprintf(&sb, "- %s %s %s (%s", imp.Service, imp.Relation(), imp.Via, imp.LinkType)
+					if imp.Depth > 1 {
+						fmt.Fprintf(&sb, "; %d hops away", imp.Depth)
+					}
+					sb.WriteString(")\n")
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
 	if len(results) == 0 {
 		sb.WriteString("No references found to this service in any documentation.\n")
 		return mcp.NewToolResultText(sb.String()), nil
@@ -146,17 +282,140 @@ func (s *Server) handleGetBlastRadius(ctx context.Context, request mcp.CallToolR
 		}
 	}
 
+	if decisions := s.relatedDecisions(ctx, service); len(decisions) > 0 {
+		sb.WriteString("## Relevant Decisions\n\n")
+		writeDecisionList(&sb, decisions)
+	}
+
+	s.writeWhoToPage(ctx, &sb, service)
+
 	sb.WriteString("## References\n\n")
 	sb.WriteString(formatSearchResults(results))
 
 	return mcp.NewToolResultText(sb.String()), nil
 }
 
+// handleGetIncidentBundle assembles the incident context for a failing
+// service or endpoint.
+func (s *Server) handleGetIncidentBundle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	target, err := s.focusArg(ctx, request, "service", focusService)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if s.phase4 == nil || s.phase4.RepoStore == nil {
+		return mcp.NewToolResultError("Repository registry not configured. Phase 4 dependencies are required for this tool."), nil
+	}
+
+	builder := &incident.Builder{
+		Repos:      s.phase4.RepoStore,
+		Flows:      s.phase4.FlowStore,
+		Org:        s.phase4.OrgStore,
+		OnCall:     s.phase4.OnCall,
+		Runbooks:   s.phase4.Incident.Runbooks,
+		ChangeDays: s.phase4.Incident.ChangeDays,
+	}
+	if s.phase4.CtxStore != nil {
+		builder.Facts = s.phase4.CtxStore
+	}
+	bundle, err := builder.Build(ctx, target, request.GetString("endpoint", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	s.rememberFocus(ctx, focusService, bundle.Service)
+	return mcp.NewToolResultText(bundle.Markdown()), nil
+}
+
+// handleGetOnboardingGuide composes the onboarding guide for a service.
+func (s *Server) handleGetOnboardingGuide(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	service, err := s.focusArg(ctx, request, "service", focusService)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if s.phase4 == nil || s.phase4.RepoStore == nil {
+		return mcp.NewToolResultError("Repository registry not configured. Phase 4 dependencies are required for this tool."), nil
+	}
+
+	builder := &onboarding.Builder{
+		Repos: s.phase4.RepoStore,
+		Flows: s.phase4.FlowStore,
+		Org:   s.phase4.OrgStore,
+	}
+	guide, err := builder.Build(ctx, service)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	s.rememberFocus(ctx, focusService, guide.Service)
+	return mcp.NewToolResultText(guide.Markdown()), nil
+}
+
+// writeWhoToPage lists the current on-call for a service and for the services
+// that directly depend on it.
+func (s *Server) writeWhoToPage(ctx context.Context, sb *strings.Builder, service string) {
+	if s.phase4 == nil || s.phase4.OnCall == nil {
+		return
+	}
+	services := []string{service}
+	if s.phase4.RepoStore != nil {
+		if links, err := s.phase4.RepoStore.GetLinks(ctx, ""); err == nil {
+			seen := map[string]bool{service: true}
+			for _, l := range links {
+				if strings.EqualFold(l.ToRepo, service) && !seen[l.FromRepo] {
+					seen[l.FromRepo] = true
+					services = append(services, l.FromRepo)
+				}
+			}
+		}
+	}
+	lookups := s.phase4.OnCall.LookupAll(ctx, services)
+	if len(lookups) == 0 {
+		return
+	}
+	sb.WriteString("## Who to Page\n\n")
+	for _, l := range lookups {
+		role := "dependent"
+		if l.Service == service {
+			role = "owner"
+		}
+		sb.WriteString(fmt.Sprintf("- **%s** (%s, `%s`): %s\n", l.Service, role, l.Schedule, l.Format()))
+	}
+	sb.WriteString("\n")
+}
+
+// relatedDecisions returns indexed ADRs that mention the given service by name.
+func (s *Server) relatedDecisions(ctx context.Context, service string) []vectordb.SearchResult {
+	decisionType := vectordb.DocTypeDecision
+	results, err := s.store.Search(ctx, service, 10, &vectordb.SearchFilter{Type: &decisionType})
+	if err != nil {
+		return nil
+	}
+	needle := strings.ToLower(service)
+	var matched []vectordb.SearchResult
+	for _, r := range results {
+		if strings.Contains(strings.ToLower(r.Document.Content), needle) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// writeDecisionList renders ADR search results as a markdown bullet list.
+func writeDecisionList(sb *strings.Builder, decisions []vectordb.SearchResult) {
+	for _, d := range decisions {
+		meta := d.Document.Metadata
+		line := fmt.Sprintf("- **%s** (`%s`", meta.Symbol, meta.FilePath)
+		if meta.RepoID != "" {
+			line += fmt.Sprintf(", repo: %s", meta.RepoID)
+		}
+		sb.WriteString(line + ")\n")
+	}
+	sb.WriteString("\n")
+}
+
 // handleGetFlow retrieves a flow by name.
 func (s *Server) handleGetFlow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	flowName, err := request.RequireString("flow_name")
+	flowName, err := s.focusArg(ctx, request, "flow_name", focusFlow)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: flow_name"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	if s.phase4 == nil || s.phase4.FlowStore == nil {
@@ -175,12 +434,24 @@ func (s *Server) handleGetFlow(ctx context.Context, request mcp.CallToolRequest)
 
 	// Return the first match.
 	f := allFlows[0]
+	s.rememberFocus(ctx, focusFlow, f.Name)
 	b, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("marshaling flow: %v", err)), nil
 	}
 
 	var sb strings.Builder
+	writeFlowMarkdown(&sb, f)
+	sb.WriteString("## Raw Data\n\n```json\n")
+	sb.Write(b)
+	sb.WriteString("\n```\n")
+
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// writeFlowMarkdown writes a flow's description, narrative, diagram, and
+// services.
+func writeFlowMarkdown(sb *strings.Builder, f flows.Flow) {
 	sb.WriteString(fmt.Sprintf("# Flow: %s\n\n", f.Name))
 	if f.Description != "" {
 		sb.WriteString(fmt.Sprintf("**Description**: %s\n\n", f.Description))
@@ -198,11 +469,6 @@ func (s *Server) handleGetFlow(ctx context.Context, request mcp.CallToolRequest)
 		}
 		sb.WriteString("\n")
 	}
-	sb.WriteString("## Raw Data\n\n```json\n")
-	sb.Write(b)
-	sb.WriteString("\n```\n")
-
-	return mcp.NewToolResultText(sb.String()), nil
 }
 
 // handleAskArchitecture answers a free-form architecture question.
@@ -279,9 +545,9 @@ func (s *Server) handleGetTeamServices(ctx context.Context, request mcp.CallTool
 
 // handleProvideContext saves a user-provided fact about a service.
 func (s *Server) handleProvideContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	service, err := request.RequireString("service")
+	service, err := s.focusArg(ctx, request, "service", focusService)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: service"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	ctxValue, err := request.RequireString("context")
@@ -347,9 +613,9 @@ func (s *Server) handleListRepos(ctx context.Context, request mcp.CallToolReques
 
 // handleGetRepoDetails gets full details for a specific repo.
 func (s *Server) handleGetRepoDetails(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	name, err := request.RequireString("name")
+	name, err := s.focusArg(ctx, request, "name", focusService)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: name"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	if s.phase4 == nil || s.phase4.RepoStore == nil {
@@ -513,3 +779,144 @@ func (s *Server) handleGetServiceMapData(ctx context.Context, request mcp.CallTo
 	return mcp.NewToolResultText(string(b)), nil
 }
 
+
+// handleGetTeamCoupling returns the team dependency matrix and coupling hotspots.
+func (s *Server) handleGetTeamCoupling(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if s.phase4 == nil || s.phase4.RepoStore == nil || s.phase4.OrgStore == nil {
+		return mcp.NewToolResultError("Repository and org structure stores not configured. Phase 4 dependencies are required for this tool."), nil
+	}
+
+	focus := ""
+	if team := request.GetString("team", ""); team != "" {
+		teams, err := s.phase4.OrgStore.ListTeams(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("listing teams: %v", err)), nil
+		}
+		for _, t := range teams {
+			if strings.EqualFold(t.Name, team) || t.ID == team {
+				focus = t.Name
+				break
+			}
+		}
+		if focus == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Team %q not found.", team)), nil
+		}
+	}
+
+	links, err := s.phase4.RepoStore.GetLinks(ctx, "")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("getting links: %v", err)), nil
+	}
+	deps := make([]orgstructure.Dependency, len(links))
+	for i, l := range links {
+		deps[i] = orgstructure.Dependency{From: l.FromRepo, To: l.ToRepo, Type: l.LinkType}
+	}
+	m, err := s.phase4.OrgStore.TeamMatrix(ctx, deps)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("building team matrix: %v", err)), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Team Coupling\n\n")
+	if m.CrossTeam == 0 {
+		sb.WriteString("No cross-service links cross a team boundary.\n")
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+	sb.WriteString(fmt.Sprintf("%d links cross team boundaries (%d touch unowned services).\n\n", m.CrossTeam, m.Unowned))
+
+	sb.WriteString("## Dependencies (from → to: links)\n\n")
+	for _, from := range m.Teams {
+		for _, to := range m.Teams {
+			n := m.Counts[from][to]
+			if n == 0 || from == to || (focus != "" && from != focus && to != focus) {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("- %s → %s: %d\n", from, to, n))
+		}
+	}
+
+	sb.WriteString("\n## Coupling Hotspots\n\n")
+	hotspots := 0
+	for _, h := range m.Hotspots {
+		if focus != "" && h.TeamA != focus && h.TeamB != focus {
+			continue
+		}
+		hotspots++
+		sb.WriteString(fmt.Sprintf("- %s ↔ %s: %d links (%.0f%% of cross-team links)\n", h.TeamA, h.TeamB, h.Links, h.Share*100))
+	}
+	if hotspots == 0 {
+		sb.WriteString("None.\n")
+	}
+
+	sb.WriteString("\n## Boundary Load\n\n")
+	for _, b := range m.Boundaries {
+		if focus != "" && b.Team != focus {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %d outbound, %d inbound, %d internal, %d partner teams\n", b.Team, b.Outbound, b.Inbound, b.Internal, b.Partners))
+	}
+
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleGetPageReviews lists the pages awaiting review, or shows the
+// changes to one of them.
+func (s *Server) handleGetPageReviews(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if s.phase4 == nil || s.phase4.Reviews == nil {
+		return mcp.NewToolResultError("Page review not configured. Enable central_site.review to use this tool."), nil
+	}
+	path := request.GetString("path", "")
+	site := request.GetString("site", "")
+
+	if path != "" {
+		p, err := s.phase4.Reviews.Get(ctx, site, path)
+		if errors.Is(err, review.ErrNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("Page %q has not been generated for review.", path)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("getting page: %v", err)), nil
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("# %s\n\n", p.Path))
+		sb.WriteString(fmt.Sprintf("**Status:** %s | **Hash:** %s | **Generated:** %s\n", p.Status, p.Hash, p.GeneratedAt.Format("2006-01-02 15:04")))
+		if p.Reviewer != "" {
+			sb.WriteString(fmt.Sprintf("**Reviewer:** %s", p.Reviewer))
+			if p.Note != "" {
+				sb.WriteString(fmt.Sprintf(" (%s)", p.Note))
+			}
+			sb.WriteString("\n")
+		}
+		if p.IsNew() {
+			sb.WriteString("\nA new page:\n\n")
+		} else {
+			sb.WriteString("\nChanges since the version last approved:\n\n")
+		}
+		sb.WriteString("```diff\n" + p.Diff() + "```\n")
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+
+	pages, err := s.phase4.Reviews.List(ctx, review.StatusPending)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("listing pages: %v", err)), nil
+	}
+	if site != "" {
+		pages = slices.DeleteFunc(pages, func(p review.Page) bool { return p.Site != site })
+	}
+	if len(pages) == 0 {
+		return mcp.NewToolResultText("No pages await review."), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Pages Awaiting Review (%d)\n\n", len(pages)))
+	for _, p := range pages {
+		kind := "changed"
+		if p.IsNew() {
+			kind = "new"
+		}
+		sb.WriteString(fmt.Sprintf("- **%s**", p.Path))
+		if p.Site != "" {
+			sb.WriteString(fmt.Sprintf(" (site: %s)", p.Site))
+		}
+		sb.WriteString(fmt.Sprintf(": %s, generated %s, hash %s\n", kind, p.GeneratedAt.Format("2006-01-02 15:04"), p.Hash))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
@@ -23,9 +23,22 @@ var getServiceContextTool = mcp.NewTool("get_service_context",
 	),
 )
 
+// getServicesContextBatchTool retrieves context for several services in one call.
+var getServicesContextBatchTool = mcp.NewTool("get_services_context_batch",
+	mcp.WithDescription("Get consolidated context (facts, ownership, decisions, top docs) for several services in one call, plus the links and flows connecting them. Use instead of repeated get_service_context calls, e.g. when a change touches multiple services."),
+	mcp.WithArray("services",
+		mcp.Required(),
+		mcp.Description("Names of the services to get context for (max 20)"),
+		mcp.WithStringItems(),
+	),
+	mcp.WithNumber("doc_limit",
+		mcp.Description("Maximum related documents per service (default 3); documents are not repeated across services"),
+	),
+)
+
 // getBlastRadiusTool shows services affected if a service or endpoint changes.
 var getBlastRadiusTool = mcp.NewTool("get_blast_radius",
-	mcp.WithDescription("Determine which services would be affected if a given service or endpoint changes. Searches for references and dependencies across all documentation."),
+	mcp.WithDescription("Determine which services would be affected if a given service or endpoint changes. Lists the services that call it or, for a shared library, depend on it, and searches for references across all documentation."),
 	mcp.WithString("service",
 		mcp.Required(),
 		mcp.Description("Name of the service that is changing"),
@@ -35,6 +48,27 @@ var getBlastRadiusTool = mcp.NewTool("get_blast_radius",
 	),
 )
 
+// getIncidentBundleTool gathers incident-response context for a failing service.
+var getIncidentBundleTool = mcp.NewTool("get_incident_bundle",
+	mcp.WithDescription("Get everything needed to respond to an incident on a service or endpoint in one call: the blast radius of calling services, owning teams and who is on call, recent commits and architecture changes, participating flows, and runbook links."),
+	mcp.WithString("service",
+		mcp.Required(),
+		mcp.Description("Service reporting errors, or an endpoint such as \"POST /api/orders\" when the service is unknown"),
+	),
+	mcp.WithString("endpoint",
+		mcp.Description("Failing endpoint of the service (optional, narrows the blast radius to its callers)"),
+	),
+)
+
+// getOnboardingGuideTool composes an onboarding walkthrough for a service.
+var getOnboardingGuideTool = mcp.NewTool("get_onboarding_guide",
+	mcp.WithDescription("Get an onboarding walkthrough for a service: its purpose, key entry points, how to run it locally (from its Makefile and Compose file), the flows it takes part in, its dependencies and consumers, and the owning team's contacts."),
+	mcp.WithString("service",
+		mcp.Required(),
+		mcp.Description("Service to onboard onto"),
+	),
+)
+
 // getFlowTool retrieves a named data flow.
 var getFlowTool = mcp.NewTool("get_flow",
 	mcp.WithDescription("Get a named cross-service data flow including its narrative, diagram, and the services involved."),
@@ -86,6 +120,14 @@ var getServiceMapDataTool = mcp.NewTool("get_service_map_data",
 	mcp.WithDescription("Get the service map data (nodes and edges) as JSON for all registered services and their connections."),
 )
 
+// getTeamCouplingTool returns the team-to-team dependency matrix and coupling hotspots.
+var getTeamCouplingTool = mcp.NewTool("get_team_coupling",
+	mcp.WithDescription("Get the service dependency graph projected onto team ownership: a team-to-team dependency matrix, per-team boundary load, and coupling hotspots where many links cross a single team boundary. Useful for Conway's-law questions such as which teams must coordinate on a change."),
+	mcp.WithString("team",
+		mcp.Description("Only show dependencies to and from this team (name or ID; optional)"),
+	),
+)
+
 // provideContextTool allows AI assistants to feed back context about a service.
 var provideContextTool = mcp.NewTool("provide_context",
 	mcp.WithDescription("Provide additional context or knowledge about a service. This information is saved as a fact and used to improve future documentation and answers."),
@@ -98,3 +140,14 @@ var provideContextTool = mcp.NewTool("provide_context",
 		mcp.Description("The context or knowledge to save"),
 	),
 )
+
+// getPageReviewsTool lists the central site's pages awaiting review.
+var getPageReviewsTool = mcp.NewTool("get_page_reviews",
+	mcp.WithDescription("List the central site's generated pages awaiting review before they are published, or, given a page, show what changed in it since the version last approved. Only approved content is published when review is enabled; reviewers approve pages themselves, with `autodoc review` or on the server's /review page."),
+	mcp.WithString("path",
+		mcp.Description("Path of a page to show the changes to, e.g. flows.md (optional)"),
+	),
+	mcp.WithString("site",
+		mcp.Description("Audience of the site variant the page belongs to (default: the full site)"),
+	),
+)
@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -10,7 +12,10 @@ import (
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
 	"github.com/ziadkadry99/auto-doc/internal/db"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
 	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/review"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
@@ -49,11 +54,16 @@ func TestCrossRepoToolDefinitions(t *testing.T) {
 	}{
 		{"search_across_repos", searchAcrossReposTool, "search_across_repos"},
 		{"get_service_context", getServiceContextTool, "get_service_context"},
+		{"get_services_context_batch", getServicesContextBatchTool, "get_services_context_batch"},
 		{"get_blast_radius", getBlastRadiusTool, "get_blast_radius"},
+		{"get_incident_bundle", getIncidentBundleTool, "get_incident_bundle"},
+		{"get_onboarding_guide", getOnboardingGuideTool, "get_onboarding_guide"},
 		{"get_flow", getFlowTool, "get_flow"},
 		{"ask_architecture", askArchitectureTool, "ask_architecture"},
 		{"get_team_services", getTeamServicesTool, "get_team_services"},
 		{"provide_context", provideContextTool, "provide_context"},
+		{"get_team_coupling", getTeamCouplingTool, "get_team_coupling"},
+		{"get_page_reviews", getPageReviewsTool, "get_page_reviews"},
 	}
 
 	for _, tt := range tests {
@@ -133,6 +143,33 @@ func TestHandleSearchAcrossRepos(t *testing.T) {
 	})
 }
 
+func TestHandleGetServiceContext_Decisions(t *testing.T) {
+	docs := []vectordb.Document{
+		{
+			ID:      "adr:billing:docs/adr/0001-split-ledger.md",
+			Content: "Decision: ADR-0001 Split the ledger\nRelated services: ledger-service",
+			Metadata: vectordb.DocumentMetadata{
+				FilePath: "docs/adr/0001-split-ledger.md",
+				Type:     vectordb.DocTypeDecision,
+				Symbol:   "Split the ledger",
+				RepoID:   "billing",
+			},
+		},
+	}
+	srv, _ := newTestServerWithPhase4(t, docs)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"service": "ledger-service"}
+	result, err := srv.handleGetServiceContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := extractText(result)
+	if !strings.Contains(text, "## Architecture Decisions") || !strings.Contains(text, "Split the ledger") {
+		t.Errorf("expected ADR in service context, got: %s", text)
+	}
+}
+
 func TestHandleGetServiceContext(t *testing.T) {
 	docs := []vectordb.Document{
 		{
@@ -191,6 +228,56 @@ func TestHandleGetServiceContext(t *testing.T) {
 	})
 }
 
+func TestHandleGetServicesContextBatch(t *testing.T) {
+	docs := []vectordb.Document{
+		{ID: "1", Content: "Orders API.", Metadata: vectordb.DocumentMetadata{FilePath: "orders/api.go", Type: vectordb.DocTypeFile}},
+		{ID: "2", Content: "Payments client.", Metadata: vectordb.DocumentMetadata{FilePath: "payments/client.go", Type: vectordb.DocTypeFile}},
+	}
+	srv, database := newTestServerWithPhase4(t, docs)
+	ctx := context.Background()
+
+	contextengine.NewStore(database).SaveFact(ctx, contextengine.Fact{
+		Scope: "service", ScopeID: "payments", Key: "pci", Value: "In PCI scope", Source: "user",
+	})
+	flows.NewStore(database).CreateFlow(ctx, &flows.Flow{Name: "Checkout", Services: []string{"orders", "payments", "email"}})
+
+	t.Run("consolidated", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"services": []any{"orders", "payments", "Orders", " "}}
+
+		result, err := srv.handleGetServicesContextBatch(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := extractText(result)
+		for _, want := range []string{"## Service: orders", "## Service: payments", "In PCI scope", "## Shared Flows", "Checkout (orders, payments)"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("expected %q in output, got: %s", want, text)
+			}
+		}
+		if strings.Count(text, "## Service: ") != 2 {
+			t.Errorf("expected duplicate service names to be merged, got: %s", text)
+		}
+		// The mock store returns every document for every query; each should appear once.
+		if strings.Count(text, "Orders API.") != 1 {
+			t.Errorf("expected documents not to repeat across services, got: %s", text)
+		}
+	})
+
+	t.Run("missing services", func(t *testing.T) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"services": []any{}}
+
+		result, err := srv.handleGetServicesContextBatch(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.IsError {
+			t.Error("expected error for empty services")
+		}
+	})
+}
+
 func TestHandleGetBlastRadius(t *testing.T) {
 	docs := []vectordb.Document{
 		{
@@ -249,6 +336,64 @@ func TestHandleGetBlastRadius(t *testing.T) {
 		}
 	})
 
+	t.Run("who to page", func(t *testing.T) {
+		repoStore := registry.NewStore(database)
+		if err := repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "order-service", ToRepo: "user-service", LinkType: "http"}); err != nil {
+			t.Fatalf("SaveLink: %v", err)
+		}
+		srv.phase4.RepoStore = repoStore
+		srv.phase4.OnCall = &oncall.Resolver{
+			Schedules: map[string]string{"user-service": "pagerduty:PUSER", "order-service": "opsgenie:orders"},
+			Providers: map[string]oncall.Provider{oncall.ProviderPagerDuty: staticOnCall{{Name: "Ada Lovelace", Level: 1}}},
+		}
+		defer func() { srv.phase4.RepoStore, srv.phase4.OnCall = nil, nil }()
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"service": "user-service"}
+		result, err := srv.handleGetBlastRadius(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := extractText(result)
+		for _, want := range []string{
+			"## Affected Services\n\n- order-service calls user-service (http)",
+			"## Who to Page",
+			"**user-service** (owner, `pagerduty:PUSER`): Ada Lovelace (L1)",
+			"**order-service** (dependent, `opsgenie:orders`): unavailable: opsgenie is not configured",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("expected %q in output, got: %s", want, text)
+			}
+		}
+	})
+
+	t.Run("shared library", func(t *testing.T) {
+		repoStore := registry.NewStore(database)
+		for _, from := range []string{"order-service", "payment-service"} {
+			if err := repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: from, ToRepo: "github.com/acme/lib-auth", LinkType: "library"}); err != nil {
+				t.Fatalf("SaveLink: %v", err)
+			}
+		}
+		srv.phase4.RepoStore = repoStore
+		defer func() { srv.phase4.RepoStore = nil }()
+
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = map[string]any{"service": "github.com/acme/lib-auth"}
+		result, err := srv.handleGetBlastRadius(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		text := extractText(result)
+		for _, want := range []string{
+			"- order-service depends on github.com/acme/lib-auth (library)",
+			"- payment-service depends on github.com/acme/lib-auth (library)",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("expected %q in output, got: %s", want, text)
+			}
+		}
+	})
+
 	t.Run("missing service", func(t *testing.T) {
 		req := mcp.CallToolRequest{}
 		req.Params.Arguments = map[string]any{}
@@ -263,6 +408,115 @@ func TestHandleGetBlastRadius(t *testing.T) {
 	})
 }
 
+func TestHandleGetIncidentBundle(t *testing.T) {
+	srv, database := newTestServerWithPhase4(t, nil)
+	ctx := context.Background()
+
+	repoStore := registry.NewStore(database)
+	for _, name := range []string{"orders", "gateway"} {
+		if err := repoStore.Add(ctx, &registry.Repository{Name: name, SourceType: "local"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders"}})
+	srv.phase4.RepoStore = repoStore
+	srv.phase4.Incident.Runbooks = map[string][]string{"orders": {"https://wiki/orders"}}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"service": "orders"}
+	result, err := srv.handleGetIncidentBundle(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	text := extractText(result)
+	for _, want := range []string{"# Incident: orders", "**gateway** calls orders", "https://wiki/orders"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in output, got: %s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]any{"service": "unknown"}
+	if result, _ := srv.handleGetIncidentBundle(ctx, req); !result.IsError {
+		t.Error("expected error for unknown service")
+	}
+}
+
+func TestHandleReviewChange_CrossRepo(t *testing.T) {
+	srv, database := newTestServerWithPhase4(t, []vectordb.Document{{
+		ID:       "file:orders/api.go",
+		Content:  "File: orders/api.go\nSummary: Order API.",
+		Metadata: vectordb.DocumentMetadata{FilePath: "orders/api.go", Type: vectordb.DocTypeFile, RepoID: "orders"},
+	}})
+	ctx := context.Background()
+
+	repoStore := registry.NewStore(database)
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders"}})
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "web", ToRepo: "gateway", LinkType: "http"})
+	srv.phase4.RepoStore = repoStore
+	flows.NewStore(database).CreateFlow(ctx, &flows.Flow{Name: "Checkout", Services: []string{"web", "orders"}})
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"diff": "--- a/orders/api.go\n+++ b/orders/api.go\n@@ -1 +1 @@\n-a\n+b\n"}
+	result, err := srv.handleReviewChange(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := extractText(result)
+	for _, want := range []string{
+		"- gateway calls orders (http: POST /api/orders)",
+		"- web calls gateway (http; 2 hops away)",
+		"### Affected Flows\n\n- Checkout",
+		`- Flow narrative "Checkout"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in output, got: %s", want, text)
+		}
+	}
+}
+
+func TestCrossRepoResources(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatalf("opening in-memory db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	ctx := context.Background()
+
+	checkout := t.TempDir()
+	os.MkdirAll(filepath.Join(checkout, ".autodoc", "docs"), 0o755)
+	os.WriteFile(filepath.Join(checkout, ".autodoc", "docs", "index.md"), []byte("Orders docs index"), 0o644)
+	repoStore := registry.NewStore(database)
+	repoStore.Add(ctx, &registry.Repository{Name: "orders", Summary: "Takes orders.", SourceType: "local", LocalPath: checkout})
+	repoStore.Add(ctx, &registry.Repository{Name: "gateway", SourceType: "local"})
+	repoStore.SaveLink(ctx, &registry.ServiceLink{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders"}})
+	flowStore := flows.NewStore(database)
+	flowStore.CreateFlow(ctx, &flows.Flow{Name: "Place Order", Narrative: "The gateway forwards the order.", Services: []string{"gateway", "orders"}})
+
+	srv := NewServer(&mockStore{}, &mockEmbedder{}, t.TempDir())
+	srv.SetPhase4Deps(Phase4Deps{FlowStore: flowStore, RepoStore: repoStore})
+
+	text, ok := readResource(t, srv, "autodoc://services/orders")
+	if !ok {
+		t.Fatal("expected the orders service page")
+	}
+	for _, want := range []string{"# orders", "Takes orders.", "## Used By\n\n- [gateway](autodoc://services/gateway) (http): POST /api/orders", "- [Place Order](autodoc://flows/Place%20Order)", "Orders docs index"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("service page missing %q:\n%s", want, text)
+		}
+	}
+
+	text, ok = readResource(t, srv, "autodoc://flows/Place%20Order")
+	if !ok || !strings.Contains(text, "# Flow: Place Order") || !strings.Contains(text, "The gateway forwards the order.") {
+		t.Errorf("flow page = %q", text)
+	}
+	if _, ok := readResource(t, srv, "autodoc://services/billing"); ok {
+		t.Error("expected an error for an unregistered service")
+	}
+}
+
 func TestHandleGetFlow(t *testing.T) {
 	srv, database := newTestServerWithPhase4(t, nil)
 	ctx := context.Background()
@@ -587,3 +841,102 @@ func extractText(result *mcp.CallToolResult) string {
 	}
 	return ""
 }
+
+func TestHandleGetTeamCoupling(t *testing.T) {
+	srv, database := newTestServerWithPhase4(t, nil)
+	ctx := context.Background()
+
+	req := mcp.CallToolRequest{}
+	result, err := srv.handleGetTeamCoupling(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error without a repository store")
+	}
+
+	repoStore := registry.NewStore(database)
+	srv.phase4.RepoStore = repoStore
+	for _, l := range []registry.ServiceLink{
+		{FromRepo: "orders", ToRepo: "payments", LinkType: "http"},
+		{FromRepo: "cart", ToRepo: "payments", LinkType: "http"},
+		{FromRepo: "orders", ToRepo: "ledger", LinkType: "grpc"},
+	} {
+		if err := repoStore.SaveLink(ctx, &l); err != nil {
+			t.Fatalf("SaveLink: %v", err)
+		}
+	}
+	for name, repos := range map[string][]string{"checkout": {"orders", "cart"}, "payments": {"payments", "ledger"}} {
+		team := &orgstructure.Team{Name: name, Source: "manual"}
+		if err := srv.phase4.OrgStore.CreateTeam(ctx, team); err != nil {
+			t.Fatalf("CreateTeam: %v", err)
+		}
+		for _, repo := range repos {
+			if err := srv.phase4.OrgStore.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team.ID, RepoID: repo, Confidence: "high", Source: "manual"}); err != nil {
+				t.Fatalf("SetOwnership: %v", err)
+			}
+		}
+	}
+
+	req.Params.Arguments = map[string]any{"team": "checkout"}
+	result, err = srv.handleGetTeamCoupling(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"- checkout → payments: 3", "- checkout ↔ payments: 3 links (100% of cross-team links)", "- checkout: 3 outbound"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("result missing %q:\n%s", want, text)
+		}
+	}
+
+	req.Params.Arguments = map[string]any{"team": "nobody"}
+	if result, _ := srv.handleGetTeamCoupling(ctx, req); !result.IsError {
+		t.Error("expected error for unknown team")
+	}
+}
+
+func TestHandlePageReviews(t *testing.T) {
+	srv, database := newTestServerWithPhase4(t, nil)
+	ctx := context.Background()
+	reviews := review.NewStore(database)
+	srv.phase4.Reviews = reviews
+	if _, _, err := reviews.Gate(ctx, "", map[string]string{"flows.md": "# Flows\n\nCheckout.\n"}); err != nil {
+		t.Fatalf("Gate: %v", err)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{}
+	result, err := srv.handleGetPageReviews(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := extractText(result); !strings.Contains(text, "**flows.md**: new") {
+		t.Errorf("expected flows.md pending, got: %s", text)
+	}
+
+	req.Params.Arguments = map[string]any{"path": "flows.md"}
+	result, _ = srv.handleGetPageReviews(ctx, req)
+	if text := extractText(result); !strings.Contains(text, "+ Checkout.") {
+		t.Errorf("expected the diff, got: %s", text)
+	}
+}
+
+func TestHandlePageReviews_NoDeps(t *testing.T) {
+	srv := NewServer(&mockStore{}, &mockEmbedder{}, t.TempDir())
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{}
+	if result, _ := srv.handleGetPageReviews(context.Background(), req); !result.IsError {
+		t.Error("expected error when review is not configured")
+	}
+}
+
+// staticOnCall is an on-call provider that always returns the same responders.
+type staticOnCall []oncall.Responder
+
+func (s staticOnCall) OnCall(ctx context.Context, scheduleID string) ([]oncall.Responder, error) {
+	return s, nil
+}
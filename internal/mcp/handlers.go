@@ -44,9 +44,9 @@ func (s *Server) handleSearchCodebase(ctx context.Context, request mcp.CallToolR
 
 // handleGetFileDocs reads and returns the AI-generated documentation for a specific file.
 func (s *Server) handleGetFileDocs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	filePath, err := request.RequireString("file_path")
+	filePath, err := s.focusArg(ctx, request, "file_path", focusFile)
 	if err != nil {
-		return mcp.NewToolResultError("missing required parameter: file_path"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	docPath := filepath.Join(s.docsDir, filePath+".md")
@@ -1,10 +1,13 @@
 package mcp
 
 import (
+	"github.com/ziadkadry99/auto-doc/internal/config"
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/oncall"
 	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
 	"github.com/ziadkadry99/auto-doc/internal/registry"
+	"github.com/ziadkadry99/auto-doc/internal/review"
 )
 
 // Phase4Deps holds optional Phase 4 dependencies for cross-repo tools.
@@ -14,10 +17,16 @@ type Phase4Deps struct {
 	FlowStore *flows.Store
 	OrgStore  *orgstructure.Store
 	RepoStore *registry.Store
+	Reviews   *review.Store         // optional; pages awaiting review for get_page_reviews
+	OnCall    *oncall.Resolver      // optional; adds "Who to Page" to blast-radius output
+	Incident  config.IncidentConfig // runbooks and change window for get_incident_bundle
 }
 
-// SetPhase4Deps sets the optional Phase 4 dependencies and registers cross-repo tools.
+// SetPhase4Deps sets the optional Phase 4 dependencies and registers
+// cross-repo tools and resources.
 func (s *Server) SetPhase4Deps(deps Phase4Deps) {
 	s.phase4 = &deps
 	s.registerCrossRepoTools()
+	s.registerCrossRepoResources()
+	s.relaxFocusTools()
 }
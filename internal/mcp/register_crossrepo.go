@@ -4,7 +4,10 @@ package mcp
 func (s *Server) registerCrossRepoTools() {
 	s.mcp.AddTool(searchAcrossReposTool, s.handleSearchAcrossRepos)
 	s.mcp.AddTool(getServiceContextTool, s.handleGetServiceContext)
+	s.mcp.AddTool(getServicesContextBatchTool, s.handleGetServicesContextBatch)
 	s.mcp.AddTool(getBlastRadiusTool, s.handleGetBlastRadius)
+	s.mcp.AddTool(getIncidentBundleTool, s.handleGetIncidentBundle)
+	s.mcp.AddTool(getOnboardingGuideTool, s.handleGetOnboardingGuide)
 	s.mcp.AddTool(getFlowTool, s.handleGetFlow)
 	s.mcp.AddTool(askArchitectureTool, s.handleAskArchitecture)
 	s.mcp.AddTool(getTeamServicesTool, s.handleGetTeamServices)
@@ -13,4 +16,6 @@ func (s *Server) registerCrossRepoTools() {
 	s.mcp.AddTool(getRepoDetailsTool, s.handleGetRepoDetails)
 	s.mcp.AddTool(getSystemDiagramTool, s.handleGetSystemDiagram)
 	s.mcp.AddTool(getServiceMapDataTool, s.handleGetServiceMapData)
+	s.mcp.AddTool(getTeamCouplingTool, s.handleGetTeamCoupling)
+	s.mcp.AddTool(getPageReviewsTool, s.handleGetPageReviews)
 }
@@ -0,0 +1,279 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// Resource URIs. Doc pages are addressed by their path under the docs
+// directory, services by registry name, and flows by name or ID.
+const (
+	resourceScheme          = "autodoc://"
+	systemOverviewURI       = resourceScheme + "system/overview"
+	docResourceTemplate     = resourceScheme + "docs/{+path}"
+	serviceResourceTemplate = resourceScheme + "services/{name}"
+	flowResourceTemplate    = resourceScheme + "flows/{name}"
+	markdownMIME            = "text/markdown"
+)
+
+// topLevelPages are the generated pages listed as resources, in order,
+// with their titles. Every other page is read through the docs template.
+var topLevelPages = []struct{ file, title string }{
+	{"index.md", "Project overview"},
+	{"architecture.md", "Architecture"},
+	{"api-reference.md", "API reference"},
+	{"decisions.md", "Architecture decisions"},
+}
+
+func docURI(page string) string { return resourceScheme + "docs/" + page }
+
+func serviceURI(name string) string { return resourceScheme + "services/" + url.PathEscape(name) }
+
+func flowURI(name string) string { return resourceScheme + "flows/" + url.PathEscape(name) }
+
+// registerResources exposes the generated docs as MCP resources: the
+// system overview, the top-level pages and feature pages present when the
+// server starts, and a template for reading any page by path.
+func (s *Server) registerResources() {
+	s.mcp.AddResource(mcp.NewResource(systemOverviewURI, "System overview",
+		mcp.WithResourceDescription("How the system fits together: its services, their dependencies, and its flows"),
+		mcp.WithMIMEType(markdownMIME),
+	), s.readSystemOverview)
+
+	for _, p := range topLevelPages {
+		if s.docExists(p.file) {
+			s.mcp.AddResource(mcp.NewResource(docURI(p.file), p.title, mcp.WithMIMEType(markdownMIME)), s.readDocResource)
+		}
+	}
+	features, _ := filepath.Glob(filepath.Join(s.docsDir, "features", "*.md"))
+	sort.Strings(features)
+	for _, f := range features {
+		name := strings.TrimSuffix(filepath.Base(f), ".md")
+		s.mcp.AddResource(mcp.NewResource(docURI("features/"+filepath.Base(f)), "Feature: "+name,
+			mcp.WithMIMEType(markdownMIME),
+		), s.readDocResource)
+	}
+
+	s.mcp.AddResourceTemplate(mcp.NewResourceTemplate(docResourceTemplate, "Documentation page",
+		mcp.WithTemplateDescription("A generated documentation page by its path, e.g. internal/server/server.go.md for a file's docs or internal/server/index.md for a directory's"),
+		mcp.WithTemplateMIMEType(markdownMIME),
+	), s.readDocResource)
+}
+
+// registerCrossRepoResources adds a resource per registered service and per
+// flow, and templates for reading them by name. The listing is taken when
+// the dependencies are set; the templates read the current state.
+func (s *Server) registerCrossRepoResources() {
+	ctx := context.Background()
+	if s.phase4.RepoStore != nil {
+		if repos, err := s.phase4.RepoStore.List(ctx); err == nil {
+			for _, r := range repos {
+				s.mcp.AddResource(mcp.NewResource(serviceURI(r.Name), "Service: "+repoTitle(r),
+					mcp.WithResourceDescription(r.Summary),
+					mcp.WithMIMEType(markdownMIME),
+				), s.readServiceResource)
+			}
+		}
+		s.mcp.AddResourceTemplate(mcp.NewResourceTemplate(serviceResourceTemplate, "Service page",
+			mcp.WithTemplateDescription("A registered service: its summary, dependencies, dependents, flows, and documentation index"),
+			mcp.WithTemplateMIMEType(markdownMIME),
+		), s.readServiceResource)
+	}
+	if s.phase4.FlowStore != nil {
+		if all, err := s.phase4.FlowStore.ListFlows(ctx); err == nil {
+			for _, f := range all {
+				s.mcp.AddResource(mcp.NewResource(flowURI(f.Name), "Flow: "+f.Name,
+					mcp.WithResourceDescription(f.Description),
+					mcp.WithMIMEType(markdownMIME),
+				), s.readFlowResource)
+			}
+		}
+		s.mcp.AddResourceTemplate(mcp.NewResourceTemplate(flowResourceTemplate, "Flow",
+			mcp.WithTemplateDescription("A cross-service flow by name or ID: its narrative, diagram, and services"),
+			mcp.WithTemplateMIMEType(markdownMIME),
+		), s.readFlowResource)
+	}
+}
+
+func repoTitle(r registry.Repository) string {
+	if r.DisplayName != "" {
+		return r.DisplayName
+	}
+	return r.Name
+}
+
+// markdownContents wraps a page as the contents of the resource at uri.
+func markdownContents(uri, text string) []mcp.ResourceContents {
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: markdownMIME, Text: text}}
+}
+
+// resourceArg returns a template variable, taking it from the end of the
+// URI when the handler was reached through a listed resource.
+func resourceArg(request mcp.ReadResourceRequest, name, prefix string) string {
+	var v string
+	switch a := request.Params.Arguments[name].(type) {
+	case string:
+		v = a
+	case []string:
+		v = strings.Join(a, "/")
+	default:
+		v = strings.TrimPrefix(request.Params.URI, prefix)
+	}
+	if u, err := url.PathUnescape(v); err == nil {
+		v = u
+	}
+	return v
+}
+
+// docExists reports whether a page exists under the docs directory.
+func (s *Server) docExists(page string) bool {
+	_, err := os.Stat(filepath.Join(s.docsDir, filepath.FromSlash(page)))
+	return err == nil
+}
+
+// readDoc reads a page under the docs directory, refusing paths that leave it.
+func (s *Server) readDoc(page string) (string, error) {
+	clean := path.Clean("/" + page)[1:]
+	if clean == "" || clean != strings.TrimPrefix(page, "/") {
+		return "", fmt.Errorf("invalid documentation path %q", page)
+	}
+	data, err := os.ReadFile(filepath.Join(s.docsDir, filepath.FromSlash(clean)))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no documentation page %q; run `autodoc generate` to generate it", page)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", page, err)
+	}
+	return string(data), nil
+}
+
+func (s *Server) readDocResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	page := resourceArg(request, "path", resourceScheme+"docs/")
+	if !strings.HasSuffix(page, ".md") && !strings.HasSuffix(page, ".mmd") {
+		return nil, fmt.Errorf("documentation pages end in .md or .mmd, got %q", page)
+	}
+	text, err := s.readDoc(page)
+	if err != nil {
+		return nil, err
+	}
+	mime := markdownMIME
+	if strings.HasSuffix(page, ".mmd") {
+		mime = "text/plain"
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, MIMEType: mime, Text: text}}, nil
+}
+
+// readSystemOverview returns the cross-repo system overview when one has
+// been generated, and the repository's architecture page otherwise.
+func (s *Server) readSystemOverview(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	for _, page := range []string{"system-overview.md", "architecture.md"} {
+		if s.docExists(page) {
+			text, err := s.readDoc(page)
+			if err != nil {
+				return nil, err
+			}
+			return markdownContents(request.Params.URI, text), nil
+		}
+	}
+	return nil, fmt.Errorf("no system overview or architecture page found; run `autodoc generate` to generate them")
+}
+
+// readServiceResource renders a service page from the registry: what it
+// is, what it calls and what calls it, the flows it takes part in, and
+// its documentation index.
+func (s *Server) readServiceResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := resourceArg(request, "name", resourceScheme+"services/")
+	repo, err := s.phase4.RepoStore.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("no service named %q is registered", name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", repoTitle(*repo))
+	if repo.Summary != "" {
+		fmt.Fprintf(&sb, "%s\n\n", repo.Summary)
+	}
+	if repo.Parent != "" {
+		fmt.Fprintf(&sb, "Part of the %s monorepo, in `%s`.\n\n", repo.Parent, repo.Subdir)
+	}
+
+	if links, err := s.phase4.RepoStore.GetLinks(ctx, repo.Name); err == nil {
+		var deps, dependents []string
+		for _, l := range links {
+			line := func(other string) string {
+				item := fmt.Sprintf("- [%s](%s) (%s)", other, serviceURI(other), l.LinkType)
+				if len(l.Endpoints) > 0 {
+					item += ": " + strings.Join(l.Endpoints, ", ")
+				}
+				return item
+			}
+			switch {
+			case strings.EqualFold(l.FromRepo, repo.Name):
+				deps = append(deps, line(l.ToRepo))
+			case strings.EqualFold(l.ToRepo, repo.Name):
+				dependents = append(dependents, line(l.FromRepo))
+			}
+		}
+		writeResourceList(&sb, "Depends On", deps)
+		writeResourceList(&sb, "Used By", dependents)
+	}
+
+	if s.phase4.FlowStore != nil {
+		if all, err := s.phase4.FlowStore.ListFlows(ctx); err == nil {
+			var names []string
+			for _, f := range all {
+				if containsFold(f.Services, repo.Name) {
+					names = append(names, fmt.Sprintf("- [%s](%s)", f.Name, flowURI(f.Name)))
+				}
+			}
+			writeResourceList(&sb, "Flows", names)
+		}
+	}
+
+	if repo.LocalPath != "" {
+		index := filepath.Join(repo.LocalPath, ".autodoc", "docs", filepath.FromSlash(repo.Subdir), "index.md")
+		if data, err := os.ReadFile(index); err == nil {
+			sb.WriteString("## Documentation\n\n")
+			sb.Write(data)
+			sb.WriteString("\n")
+		}
+	}
+	return markdownContents(request.Params.URI, sb.String()), nil
+}
+
+func writeResourceList(sb *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "## %s\n\n%s\n\n", title, strings.Join(items, "\n"))
+}
+
+// readFlowResource renders a flow, found by exact name (ignoring case) or ID.
+func (s *Server) readFlowResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := resourceArg(request, "name", resourceScheme+"flows/")
+	all, err := s.phase4.FlowStore.ListFlows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing flows: %w", err)
+	}
+	for _, f := range all {
+		if strings.EqualFold(f.Name, name) || f.ID == name {
+			var sb strings.Builder
+			writeFlowMarkdown(&sb, f)
+			return markdownContents(request.Params.URI, sb.String()), nil
+		}
+	}
+	return nil, fmt.Errorf("no flow named %q", name)
+}
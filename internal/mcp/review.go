@@ -0,0 +1,557 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ziadkadry99/auto-doc/internal/incident"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// reviewMaxSymbols caps how many changed symbols review_change looks up
+// callers for, since each costs a search.
+const reviewMaxSymbols = 10
+
+// Change kinds of a file in a diff.
+const (
+	changeModified = "modified"
+	changeAdded    = "added"
+	changeDeleted  = "deleted"
+	changeRenamed  = "renamed"
+)
+
+// diffFile is one file in a unified diff.
+type diffFile struct {
+	Path    string // the new path, or the old one for a deleted file
+	OldPath string // the path before a rename
+	Change  string
+	// Lines are the changed line ranges in the new file; a pure deletion
+	// marks the line it happened before. Deleted files have none.
+	Lines []lineRange
+}
+
+type lineRange struct{ Start, End int }
+
+func (r lineRange) String() string {
+	if r.End > r.Start {
+		return fmt.Sprintf("%d-%d", r.Start, r.End)
+	}
+	return strconv.Itoa(r.Start)
+}
+
+// overlaps reports whether any changed range falls within start..end.
+func (f *diffFile) overlaps(start, end int) bool {
+	if end < start {
+		end = start
+	}
+	for _, r := range f.Lines {
+		if r.Start <= end && r.End >= start {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUnifiedDiff reads the files and changed lines from a unified diff,
+// with or without git's extended headers, as `git diff` or `diff -u`
+// print it.
+func parseUnifiedDiff(diff string) []diffFile {
+	var files []*diffFile
+	var cur *diffFile
+	var oldLeft, newLeft, newLine int // remaining lines of the current hunk
+	expectOld := false                // a git header is waiting for its --- line
+
+	// mark records a change at line n of the new file.
+	mark := func(n int) {
+		if last := len(cur.Lines) - 1; last >= 0 && n <= cur.Lines[last].End+1 {
+			cur.Lines[last].End = max(cur.Lines[last].End, n)
+			return
+		}
+		cur.Lines = append(cur.Lines, lineRange{n, n})
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if cur != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				mark(newLine)
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				mark(max(newLine, 1))
+				oldLeft--
+			case strings.HasPrefix(line, `\`): // \ No newline at end of file
+			default:
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			cur = &diffFile{Change: changeModified}
+			files = append(files, cur)
+			expectOld = true
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				cur.OldPath, cur.Path = strings.TrimPrefix(a, "a/"), b
+			}
+		case strings.HasPrefix(line, "--- "):
+			// Without git's headers, each file starts at its --- line.
+			if !expectOld {
+				cur = &diffFile{Change: changeModified}
+				files = append(files, cur)
+			}
+			expectOld = false
+			if p := diffPath(line[4:], "a/"); p != "" {
+				cur.OldPath = p
+			} else {
+				cur.Change = changeAdded
+			}
+		case strings.HasPrefix(line, "+++ ") && cur != nil:
+			if p := diffPath(line[4:], "b/"); p != "" {
+				cur.Path = p
+			} else {
+				cur.Change, cur.Path = changeDeleted, cur.OldPath
+			}
+		case strings.HasPrefix(line, "new file mode") && cur != nil:
+			cur.Change = changeAdded
+		case strings.HasPrefix(line, "deleted file mode") && cur != nil:
+			cur.Change = changeDeleted
+		case strings.HasPrefix(line, "rename from ") && cur != nil:
+			cur.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to ") && cur != nil:
+			cur.Path = strings.TrimPrefix(line, "rename to ")
+			cur.Change = changeRenamed
+		case strings.HasPrefix(line, "@@ ") && cur != nil:
+			oldLeft, newLine, newLeft = parseHunkHeader(line)
+		}
+	}
+
+	out := make([]diffFile, 0, len(files))
+	for _, f := range files {
+		if f.Path == "" {
+			f.Path = f.OldPath
+		}
+		if f.Path == "" {
+			continue
+		}
+		switch {
+		case f.Change == changeDeleted:
+			f.Lines = nil
+		case f.Change == changeModified && f.OldPath != "" && f.OldPath != f.Path:
+			f.Change = changeRenamed
+		}
+		if f.Change != changeRenamed {
+			f.OldPath = ""
+		}
+		out = append(out, *f)
+	}
+	return out
+}
+
+// diffPath reads the path from a --- or +++ line, dropping git's a/ or b/
+// prefix and any timestamp. It returns "" for /dev/null.
+func diffPath(s, prefix string) string {
+	if tab := strings.IndexByte(s, '\t'); tab >= 0 {
+		s = s[:tab]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+// parseHunkHeader reads "@@ -a,b +c,d @@" into the old and new line counts
+// and the first new line. A missing count is 1.
+func parseHunkHeader(line string) (oldCount, newStart, newCount int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0
+	}
+	span := func(s string) (int, int) {
+		start, count, found := strings.Cut(s[1:], ",")
+		n, _ := strconv.Atoi(start)
+		c := 1
+		if found {
+			c, _ = strconv.Atoi(count)
+		}
+		return n, c
+	}
+	_, oldCount = span(fields[1])
+	newStart, newCount = span(fields[2])
+	return oldCount, newStart, newCount
+}
+
+// reviewedFile is a changed file with what the index knows about it.
+type reviewedFile struct {
+	diffFile
+	Indexed  bool
+	Summary  string
+	Services []string
+	Routes   []string
+	Symbols  []vectordb.Document // functions and types the change touches
+}
+
+// handleReviewChange maps a diff onto the indexed model: the symbols it
+// touches, their callers, the services, endpoints, and flows involved, and
+// the documentation pages that will go stale.
+func (s *Server) handleReviewChange(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	diff, err := request.RequireString("diff")
+	if err != nil {
+		return mcp.NewToolResultError("missing required parameter: diff"), nil
+	}
+	repo := request.GetString("repo", "")
+	changed := parseUnifiedDiff(diff)
+	if len(changed) == 0 {
+		return mcp.NewToolResultError("No changed files found. Pass a unified diff, e.g. the output of `git diff`."), nil
+	}
+
+	inDiff := make(map[string]bool)
+	for _, f := range changed {
+		inDiff[f.Path] = true
+		if f.OldPath != "" {
+			inDiff[f.OldPath] = true
+		}
+	}
+
+	files := make([]reviewedFile, 0, len(changed))
+	services := make(map[string]bool)
+	for _, f := range changed {
+		rf, err := s.reviewFile(ctx, f, repo)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+		}
+		for _, svc := range rf.Services {
+			services[svc] = true
+		}
+		files = append(files, rf)
+	}
+
+	// Callers outside the diff are the code the change can break.
+	var symbols []vectordb.Document
+	for _, f := range files {
+		for _, d := range f.Symbols {
+			if len(symbols) < reviewMaxSymbols {
+				symbols = append(symbols, d)
+			}
+		}
+	}
+	callers := make(map[string][]string) // caller symbol ID -> changed symbols it calls
+	callerDocs := make(map[string]vectordb.Document)
+	for _, def := range symbols {
+		calls, uses, err := s.symbolReferences(ctx, def.Metadata.Symbol, []vectordb.Document{def})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+		}
+		for _, c := range append(calls, uses...) {
+			if inDiff[c.Metadata.FilePath] {
+				continue
+			}
+			id := symbolIDOf(c)
+			if !containsFold(callers[id], def.Metadata.Symbol) {
+				callers[id] = append(callers[id], def.Metadata.Symbol)
+			}
+			callerDocs[id] = c
+		}
+	}
+	indirect := make(map[string]bool)
+	for _, c := range callerDocs {
+		if svc := c.Metadata.RepoID; svc != "" && !services[svc] {
+			indirect[svc] = true
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Change Review\n\n")
+	writeChangeCounts(&sb, files)
+
+	sb.WriteString("\n## Changed Files\n\n")
+	for _, f := range files {
+		writeReviewedFile(&sb, f)
+	}
+
+	sb.WriteString("\n## Impact\n")
+	if len(services)+len(indirect) > 0 {
+		sb.WriteString("\n### Services\n\n")
+		for _, svc := range sortedKeys(services) {
+			fmt.Fprintf(&sb, "- %s (changed directly)\n", svc)
+		}
+		for _, svc := range sortedKeys(indirect) {
+			fmt.Fprintf(&sb, "- %s (calls changed code)\n", svc)
+		}
+	}
+
+	var routes []string
+	for _, f := range files {
+		for _, r := range f.Routes {
+			routes = append(routes, fmt.Sprintf("%s (in `%s`)", r, f.Path))
+		}
+	}
+	if len(routes) > 0 {
+		sb.WriteString("\n### Endpoints\n\nThe changed files expose:\n\n")
+		for _, r := range routes {
+			fmt.Fprintf(&sb, "- %s\n", r)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\n### Callers of Changed Code (%d)\n\n", len(callers))
+	if len(callers) == 0 {
+		sb.WriteString("None found in the index outside the changed files.\n")
+	}
+	for _, id := range sortedKeys(callers) {
+		c := callerDocs[id]
+		fmt.Fprintf(&sb, "- %s at %s: uses %s\n", c.Metadata.Symbol, symbolLocation(c), strings.Join(callers[id], ", "))
+	}
+
+	affectedFlows := s.writeReviewCrossRepo(ctx, &sb, sortedKeys(services))
+
+	sb.WriteString("\n## Docs to Update\n\n")
+	s.writeDocsToUpdate(&sb, files, len(routes) > 0, affectedFlows)
+
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// reviewFile looks up a changed file's documents: its summary and routes,
+// the services that own it, and the symbols whose lines the change touches.
+// Renamed files are looked up under their old path, which the index still has.
+func (s *Server) reviewFile(ctx context.Context, f diffFile, repo string) (reviewedFile, error) {
+	rf := reviewedFile{diffFile: f}
+	lookup := f.Path
+	if f.OldPath != "" {
+		lookup = f.OldPath
+	}
+	docs, err := s.store.GetByFilePath(ctx, lookup)
+	if err != nil {
+		return rf, err
+	}
+	for _, d := range docs {
+		md := d.Metadata
+		if repo != "" && md.RepoID != repo {
+			continue
+		}
+		rf.Indexed = true
+		if md.RepoID != "" && !containsFold(rf.Services, md.RepoID) {
+			rf.Services = append(rf.Services, md.RepoID)
+		}
+		switch md.Type {
+		case vectordb.DocTypeFile:
+			a := indexer.ParseFileDocument(d.Content)
+			rf.Summary = a.Summary
+			for _, k := range a.KeyLogic {
+				if list, ok := strings.CutPrefix(k, indexer.RoutesKeyLogic); ok {
+					for _, r := range strings.Split(list, ",") {
+						rf.Routes = append(rf.Routes, strings.TrimSpace(r))
+					}
+				}
+			}
+		case vectordb.DocTypeFunction, vectordb.DocTypeClass:
+			// Deleting or moving a file changes every symbol in it: symbol
+			// IDs include the path.
+			if f.Change == changeDeleted || f.Change == changeRenamed ||
+				md.LineStart > 0 && f.overlaps(md.LineStart, md.LineEnd) {
+				rf.Symbols = append(rf.Symbols, d)
+			}
+		}
+	}
+	if repo != "" && len(rf.Services) == 0 {
+		rf.Services = []string{repo}
+	}
+	sort.Slice(rf.Symbols, func(i, j int) bool {
+		return rf.Symbols[i].Metadata.LineStart < rf.Symbols[j].Metadata.LineStart
+	})
+	return rf, nil
+}
+
+func writeChangeCounts(sb *strings.Builder, files []reviewedFile) {
+	counts := make(map[string]int)
+	for _, f := range files {
+		counts[f.Change]++
+	}
+	var parts []string
+	for _, c := range []string{changeModified, changeAdded, changeDeleted, changeRenamed} {
+		if counts[c] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[c], c))
+		}
+	}
+	fmt.Fprintf(sb, "%d file(s) changed: %s.\n", len(files), strings.Join(parts, ", "))
+}
+
+func writeReviewedFile(sb *strings.Builder, f reviewedFile) {
+	fmt.Fprintf(sb, "### %s (%s)\n\n", f.Path, f.Change)
+	if f.OldPath != "" {
+		fmt.Fprintf(sb, "Renamed from `%s`.\n", f.OldPath)
+	}
+	if len(f.Lines) > 0 {
+		ranges := make([]string, len(f.Lines))
+		for i, r := range f.Lines {
+			ranges[i] = r.String()
+		}
+		fmt.Fprintf(sb, "Changed lines: %s\n", strings.Join(ranges, ", "))
+	}
+	if !f.Indexed {
+		if f.Change == changeAdded {
+			sb.WriteString("New file; it isn't indexed yet.\n\n")
+		} else {
+			sb.WriteString("Not in the index; its impact can't be traced.\n\n")
+		}
+		return
+	}
+	if len(f.Services) > 0 {
+		fmt.Fprintf(sb, "Service: %s\n", strings.Join(f.Services, ", "))
+	}
+	if f.Summary != "" {
+		fmt.Fprintf(sb, "Summary: %s\n", f.Summary)
+	}
+	if len(f.Symbols) > 0 {
+		sb.WriteString("Changed symbols:\n")
+		for _, d := range f.Symbols {
+			fmt.Fprintf(sb, "- %s (%s) at %s [%s]\n", d.Metadata.Symbol, d.Metadata.Type, symbolLocation(d), symbolIDOf(d))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// writeReviewCrossRepo adds the services upstream of the changed ones and
+// the flows they take part in, when the registry and flow store are
+// available, and returns the names of the affected flows.
+func (s *Server) writeReviewCrossRepo(ctx context.Context, sb *strings.Builder, services []string) []string {
+	if s.phase4 == nil || len(services) == 0 {
+		return nil
+	}
+	if s.phase4.RepoStore != nil {
+		if links, err := s.phase4.RepoStore.GetLinks(ctx, ""); err == nil {
+			var impacts []incident.Impact
+			seen := make(map[string]bool)
+			for _, svc := range services {
+				seen[strings.ToLower(svc)] = true
+			}
+			for _, svc := range services {
+				for _, imp := range incident.BlastRadius(links, svc, "", incident.DefaultMaxDepth) {
+					if !seen[strings.ToLower(imp.Service)] {
+						seen[strings.ToLower(imp.Service)] = true
+						impacts = append(impacts, imp)
+					}
+				}
+			}
+			if len(impacts) > 0 {
+				sb.WriteString("\n### Upstream Services\n\n")
+				for _, imp := range impacts {
+					fmt.Fprintf(sb, "- %s %s %s (%s", imp.Service, imp.Relation(), imp.Via, imp.LinkType)
+					if len(imp.Endpoints) > 0 {
+						fmt.Fprintf(sb, ": %s", strings.Join(imp.Endpoints, ", "))
+					}
+					if imp.Depth > 1 {
+						fmt.Fprintf(sb, "; %d hops away", imp.Depth)
+					}
+					sb.WriteString(")\n")
+				}
+			}
+		}
+	}
+
+	var names []string
+	if s.phase4.FlowStore != nil {
+		if all, err := s.phase4.FlowStore.ListFlows(ctx); err == nil {
+			for _, f := range all {
+				for _, svc := range f.Services {
+					if containsFold(services, svc) {
+						names = append(names, f.Name)
+						break
+					}
+				}
+			}
+		}
+	}
+	if len(names) > 0 {
+		sb.WriteString("\n### Affected Flows\n\n")
+		for _, name := range names {
+			fmt.Fprintf(sb, "- %s\n", name)
+		}
+	}
+	return names
+}
+
+// writeDocsToUpdate lists the generated pages the change makes stale:
+// each file's page and its directory summary, the architecture overview
+// when files come or go, the API reference when endpoints may change, and
+// the narratives of affected flows.
+func (s *Server) writeDocsToUpdate(sb *strings.Builder, files []reviewedFile, endpoints bool, flowNames []string) {
+	exists := func(page string) bool {
+		_, err := os.Stat(filepath.Join(s.docsDir, filepath.FromSlash(page)))
+		return err == nil
+	}
+	var lines []string
+	dirs := make(map[string]bool)
+	structural := false
+	for _, f := range files {
+		switch f.Change {
+		case changeAdded:
+			lines = append(lines, fmt.Sprintf("- `%s.md`: new page for the new file", f.Path))
+			structural = true
+		case changeDeleted:
+			if exists(f.Path + ".md") {
+				lines = append(lines, fmt.Sprintf("- `%s.md`: remove; the file is deleted", f.Path))
+			}
+			structural = true
+		case changeRenamed:
+			if exists(f.OldPath + ".md") {
+				lines = append(lines, fmt.Sprintf("- `%s.md`: move to `%s.md`", f.OldPath, f.Path))
+			}
+			structural = true
+		default:
+			if exists(f.Path + ".md") {
+				lines = append(lines, fmt.Sprintf("- `%s.md`: the file's documentation", f.Path))
+			} else if f.Indexed {
+				lines = append(lines, fmt.Sprintf("- `%s.md`: not generated yet", f.Path))
+			}
+		}
+		if dir := path.Dir(f.Path); dir != "." && !dirs[dir] && exists(path.Join(dir, "index.md")) {
+			dirs[dir] = true
+			lines = append(lines, fmt.Sprintf("- `%s/index.md`: the directory summary", dir))
+		}
+	}
+	if structural && exists("architecture.md") {
+		lines = append(lines, "- `architecture.md`: files were added, removed, or moved")
+	}
+	if endpoints && exists("api-reference.md") {
+		lines = append(lines, "- `api-reference.md`: the changed files expose endpoints")
+	}
+	for _, name := range flowNames {
+		lines = append(lines, fmt.Sprintf("- Flow narrative %q: the change touches a service in it", name))
+	}
+	if len(lines) == 0 {
+		sb.WriteString("No generated pages cover the changed files.\n")
+		return
+	}
+	sb.WriteString(strings.Join(lines, "\n"))
+	sb.WriteString("\n\nRun `autodoc generate` after merging to refresh the generated pages.\n")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -17,6 +17,7 @@ type Server struct {
 	docsDir  string
 	mcp      *server.MCPServer
 	phase4   *Phase4Deps
+	memory   *sessionMemory // nil unless session memory is enabled
 }
 
 // NewServer creates a new MCP server with the given dependencies.
@@ -31,9 +32,11 @@ func NewServer(store vectordb.VectorStore, embedder embeddings.Embedder, docsDir
 		"autodoc",
 		Version,
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
 	)
 
 	s.registerTools()
+	s.registerResources()
 
 	return s
 }
@@ -44,6 +47,8 @@ func (s *Server) registerTools() {
 	s.mcp.AddTool(getFileDocsTool, s.handleGetFileDocs)
 	s.mcp.AddTool(getArchitectureTool, s.handleGetArchitecture)
 	s.mcp.AddTool(getDiagramTool, s.handleGetDiagram)
+	s.mcp.AddTool(getSymbolTool, s.handleGetSymbol)
+	s.mcp.AddTool(reviewChangeTool, s.handleReviewChange)
 }
 
 // Serve starts the MCP server on stdio. Stdout is used for MCP protocol
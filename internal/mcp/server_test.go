@@ -2,8 +2,11 @@ package mcp
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -40,6 +43,12 @@ func (m *mockStore) Search(_ context.Context, query string, limit int, filter *v
 		if filter != nil && filter.Type != nil && doc.Metadata.Type != *filter.Type {
 			continue
 		}
+		if filter != nil && filter.Symbol != nil && doc.Metadata.Symbol != *filter.Symbol {
+			continue
+		}
+		if filter != nil && filter.RepoID != nil && doc.Metadata.RepoID != *filter.RepoID {
+			continue
+		}
 		results = append(results, vectordb.SearchResult{
 			Document:   doc,
 			Similarity: 0.95,
@@ -78,6 +87,8 @@ func TestToolDefinitions(t *testing.T) {
 		{"get_file_docs", getFileDocsTool, "get_file_docs"},
 		{"get_architecture", getArchitectureTool, "get_architecture"},
 		{"get_diagram", getDiagramTool, "get_diagram"},
+		{"get_symbol", getSymbolTool, "get_symbol"},
+		{"review_change", reviewChangeTool, "review_change"},
 	}
 
 	for _, tt := range tests {
@@ -289,6 +300,245 @@ func TestHandleGetArchitecture(t *testing.T) {
 	})
 }
 
+func TestHandleGetSymbol(t *testing.T) {
+	symbolDoc := func(repo, path, symbol string, typ vectordb.DocumentType, content string) vectordb.Document {
+		return vectordb.Document{
+			ID:      string(typ) + ":" + path + ":" + symbol,
+			Content: content,
+			Metadata: vectordb.DocumentMetadata{
+				FilePath: path, LineStart: 10, LineEnd: 20, Type: typ, Symbol: symbol, RepoID: repo,
+				SymbolID: vectordb.SymbolID(repo, path, symbol),
+			},
+		}
+	}
+	store := &mockStore{docs: []vectordb.Document{
+		symbolDoc("auth", "auth/verify.go", "Verifier.Verify", vectordb.DocTypeFunction, "Function: Verifier.Verify\nSummary: Checks a token.\nUses types: Token"),
+		symbolDoc("auth", "auth/token.go", "Token", vectordb.DocTypeClass, "Class/Type: Token\nSummary: A verified token."),
+		symbolDoc("api", "api/login.go", "handleLogin", vectordb.DocTypeFunction, "Function: handleLogin\nCalls: verifier.Verify, writeJSON"),
+		symbolDoc("api", "api/logout.go", "handleLogout", vectordb.DocTypeFunction, "Function: handleLogout\nCalls: writeJSON"),
+	}}
+	srv := NewServer(store, &mockEmbedder{}, "/tmp/docs")
+	ctx := context.Background()
+	call := func(args map[string]any) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := srv.handleGetSymbol(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	result := call(map[string]any{"symbol": "Verify"})
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"ID: auth:auth/verify.go#Verifier.Verify", "Location: auth/verify.go:10-20 (repo auth)", "## Called by (1)", "- handleLogin at api/login.go:10-20 (repo api)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("get_symbol output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "handleLogout") {
+		t.Errorf("handleLogout doesn't call Verify:\n%s", text)
+	}
+
+	result = call(map[string]any{"symbol": "auth:auth/token.go#Token"})
+	text = result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "## Used by (1)\n- Verifier.Verify at auth/verify.go") {
+		t.Errorf("expected Verifier.Verify to use Token:\n%s", text)
+	}
+
+	if result := call(map[string]any{"symbol": "Token", "repo": "api"}); !result.IsError {
+		t.Error("expected an error for a symbol not defined in the repo")
+	}
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/pay/charge.go b/pay/charge.go
+index 1111111..2222222 100644
+--- a/pay/charge.go
++++ b/pay/charge.go
+@@ -10,3 +10,4 @@ func Charge() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	return
+@@ -40,3 +41,2 @@ func Refund() {
+ 	x := 1
+--- removed line that looks like a header
+ 	y := 2
+diff --git a/pay/new.go b/pay/new.go
+new file mode 100644
+--- /dev/null
++++ b/pay/new.go
+@@ -0,0 +1,2 @@
++package pay
++
+diff --git a/pay/old.go b/pay/old.go
+deleted file mode 100644
+--- a/pay/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package pay
+diff --git a/pay/a.go b/pay/b.go
+similarity index 100%
+rename from pay/a.go
+rename to pay/b.go
+`
+	files := parseUnifiedDiff(diff)
+	if len(files) != 4 {
+		t.Fatalf("got %d files, want 4: %+v", len(files), files)
+	}
+	want := []struct{ path, old, change, lines string }{
+		{"pay/charge.go", "", changeModified, "[11-12 42]"},
+		{"pay/new.go", "", changeAdded, "[1-2]"},
+		{"pay/old.go", "", changeDeleted, "[]"},
+		{"pay/b.go", "pay/a.go", changeRenamed, "[]"},
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.Path != w.path || f.OldPath != w.old || f.Change != w.change || fmt.Sprint(f.Lines) != w.lines {
+			t.Errorf("file %d = %+v, want %+v", i, f, w)
+		}
+	}
+
+	plain := "--- svc/a.py\t2026-01-01\n+++ svc/a.py\t2026-01-02\n@@ -3 +3 @@\n-x = 1\n+x = 2\n"
+	files = parseUnifiedDiff(plain)
+	if len(files) != 1 || files[0].Path != "svc/a.py" || files[0].Change != changeModified || fmt.Sprint(files[0].Lines) != "[3]" {
+		t.Errorf("plain diff parsed as %+v", files)
+	}
+}
+
+func TestHandleReviewChange(t *testing.T) {
+	docsDir := t.TempDir()
+	for _, page := range []string{"pay/charge.go.md", "pay/index.md", "architecture.md", "api-reference.md"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(docsDir, page)), 0o755)
+		os.WriteFile(filepath.Join(docsDir, page), []byte("# page"), 0o644)
+	}
+	fn := func(repo, path, symbol string, start, end int, content string) vectordb.Document {
+		return vectordb.Document{
+			ID:      "func:" + path + ":" + symbol,
+			Content: content,
+			Metadata: vectordb.DocumentMetadata{
+				FilePath: path, Type: vectordb.DocTypeFunction, Symbol: symbol, RepoID: repo,
+				LineStart: start, LineEnd: end,
+			},
+		}
+	}
+	store := &mockStore{docs: []vectordb.Document{
+		{
+			ID:       "file:pay/charge.go",
+			Content:  "File: pay/charge.go\nSummary: Charges cards.\nKey Logic: HTTP routes: POST /charge, POST /refund",
+			Metadata: vectordb.DocumentMetadata{FilePath: "pay/charge.go", Type: vectordb.DocTypeFile, RepoID: "payments"},
+		},
+		fn("payments", "pay/charge.go", "Charge", 5, 20, "Function: Charge"),
+		fn("payments", "pay/charge.go", "Refund", 30, 45, "Function: Refund"),
+		fn("checkout", "web/cart.go", "submitCart", 1, 10, "Function: submitCart\nCalls: pay.Charge"),
+		fn("checkout", "web/admin.go", "issueRefund", 1, 10, "Function: issueRefund\nCalls: pay.Refund"),
+	}}
+	srv := NewServer(store, &mockEmbedder{}, docsDir)
+
+	diff := "diff --git a/pay/charge.go b/pay/charge.go\n--- a/pay/charge.go\n+++ b/pay/charge.go\n@@ -12,3 +12,3 @@\n a\n-b\n+c\n d\n" +
+		"diff --git a/pay/util.go b/pay/util.go\nnew file mode 100644\n--- /dev/null\n+++ b/pay/util.go\n@@ -0,0 +1 @@\n+package pay\n"
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"diff": diff}
+	result, err := srv.handleReviewChange(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{
+		"2 file(s) changed: 1 modified, 1 added.",
+		"Changed lines: 13",
+		"- Charge (function) at pay/charge.go:5-20 (repo payments)",
+		"New file; it isn't indexed yet.",
+		"- payments (changed directly)",
+		"- checkout (calls changed code)",
+		"- POST /charge (in `pay/charge.go`)",
+		"### Callers of Changed Code (1)\n\n- submitCart at web/cart.go:1-10 (repo checkout): uses Charge",
+		"- `pay/charge.go.md`: the file's documentation",
+		"- `pay/index.md`: the directory summary",
+		"- `pay/util.go.md`: new page for the new file",
+		"- `architecture.md`",
+		"- `api-reference.md`",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("review_change output missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Refund (function)") || strings.Contains(text, "issueRefund") {
+		t.Errorf("Refund isn't in the changed lines:\n%s", text)
+	}
+
+	req.Params.Arguments = map[string]any{"diff": "not a diff"}
+	if result, _ := srv.handleReviewChange(context.Background(), req); !result.IsError {
+		t.Error("expected an error for input without changed files")
+	}
+}
+
+// readResource sends a resources/read request through the MCP server, so
+// URIs are matched against the registered resources and templates.
+func readResource(t *testing.T, srv *Server, uri string) (string, bool) {
+	t.Helper()
+	msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri)
+	resp := srv.mcp.HandleMessage(context.Background(), []byte(msg))
+	rpc, ok := resp.(mcp.JSONRPCResponse)
+	if !ok {
+		return "", false
+	}
+	result, ok := rpc.Result.(mcp.ReadResourceResult)
+	if !ok || len(result.Contents) == 0 {
+		t.Fatalf("unexpected resources/read result: %#v", rpc.Result)
+	}
+	return result.Contents[0].(mcp.TextResourceContents).Text, true
+}
+
+func TestResources(t *testing.T) {
+	docsDir := t.TempDir()
+	for page, content := range map[string]string{
+		"index.md":                   "# Project",
+		"architecture.md":            "# Architecture",
+		"features/checkout.md":       "# Checkout",
+		"internal/server/main.go.md": "# main.go",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(docsDir, page)), 0o755)
+		os.WriteFile(filepath.Join(docsDir, page), []byte(content), 0o644)
+	}
+	srv := NewServer(&mockStore{}, &mockEmbedder{}, docsDir)
+
+	resp := srv.mcp.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`))
+	list := resp.(mcp.JSONRPCResponse).Result.(mcp.ListResourcesResult)
+	var uris []string
+	for _, r := range list.Resources {
+		uris = append(uris, r.URI)
+	}
+	for _, want := range []string{"autodoc://system/overview", "autodoc://docs/index.md", "autodoc://docs/architecture.md", "autodoc://docs/features/checkout.md"} {
+		if !slices.Contains(uris, want) {
+			t.Errorf("resources/list missing %s: %v", want, uris)
+		}
+	}
+	if slices.Contains(uris, "autodoc://docs/api-reference.md") {
+		t.Error("listed a page that wasn't generated")
+	}
+
+	if text, _ := readResource(t, srv, "autodoc://system/overview"); text != "# Architecture" {
+		t.Errorf("system overview without a system-overview.md = %q, want the architecture page", text)
+	}
+	if text, _ := readResource(t, srv, "autodoc://docs/internal/server/main.go.md"); text != "# main.go" {
+		t.Errorf("file page = %q", text)
+	}
+	for _, uri := range []string{"autodoc://docs/../secret.md", "autodoc://docs/missing.md", "autodoc://docs/internal/server"} {
+		if _, ok := readResource(t, srv, uri); ok {
+			t.Errorf("expected an error reading %s", uri)
+		}
+	}
+}
+
 func TestHandleGetDiagram(t *testing.T) {
 	docsDir := t.TempDir()
 	ctx := context.Background()
@@ -398,3 +648,96 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+// fakeSession is a minimal MCP client session for session memory tests.
+type fakeSession struct{ id string }
+
+func (f fakeSession) Initialize()                                         {}
+func (f fakeSession) Initialized() bool                                   { return true }
+func (f fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (f fakeSession) SessionID() string                                   { return f.id }
+
+func TestSessionMemory(t *testing.T) {
+	docsDir := t.TempDir()
+	os.MkdirAll(filepath.Join(docsDir, "internal"), 0o755)
+	os.WriteFile(filepath.Join(docsDir, "internal", "auth.go.md"), []byte("# internal/auth.go"), 0o644)
+
+	srv := NewServer(&mockStore{}, &mockEmbedder{}, docsDir)
+	call := func(ctx context.Context, args map[string]any) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		result, err := srv.handleGetFileDocs(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	// Without session memory the parameter stays required.
+	if result := call(context.Background(), map[string]any{}); !result.IsError {
+		t.Error("expected error for missing file_path without session memory")
+	}
+
+	srv.EnableSessionMemory()
+	alice := srv.mcp.WithContext(context.Background(), fakeSession{id: "alice"})
+	bob := srv.mcp.WithContext(context.Background(), fakeSession{id: "bob"})
+
+	if result := call(alice, map[string]any{"file_path": "internal/auth.go"}); result.IsError {
+		t.Fatalf("explicit file_path failed: %s", extractText(result))
+	}
+	if result := call(alice, map[string]any{}); result.IsError || !contains(extractText(result), "internal/auth.go") {
+		t.Errorf("expected omitted file_path to default to the current focus, got %q", extractText(result))
+	}
+	if result := call(bob, map[string]any{}); !result.IsError {
+		t.Error("focus should not leak between sessions")
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"service": "billing"}
+	srv.handleSetFocus(alice, req)
+	srv.memory.remember("alice", focusService, "ledger")
+	result, _ := srv.handleGetFocus(alice, mcp.CallToolRequest{})
+	if text := extractText(result); !contains(text, "**service**: ledger (recently: billing)") || !contains(text, "**file**: internal/auth.go") {
+		t.Errorf("unexpected focus: %s", text)
+	}
+
+	req.Params.Arguments = map[string]any{"clear": true}
+	srv.handleSetFocus(alice, req)
+	if srv.memory.current("alice", focusFile) != "" {
+		t.Error("clear should forget the session's focus")
+	}
+}
+
+func TestSessionMemoryRelaxesRequiredParams(t *testing.T) {
+	srv := NewServer(&mockStore{}, &mockEmbedder{}, t.TempDir())
+	required := func(tool, param string) bool {
+		t.Helper()
+		st := srv.mcp.GetTool(tool)
+		if st == nil {
+			t.Fatalf("tool %s not registered", tool)
+		}
+		return slices.Contains(st.Tool.InputSchema.Required, param)
+	}
+	if !required("get_file_docs", "file_path") {
+		t.Error("file_path should be required without session memory")
+	}
+
+	// Tools registered after session memory is enabled are relaxed too.
+	srv.EnableSessionMemory()
+	srv.SetPhase4Deps(Phase4Deps{})
+	for tool, fp := range focusParams {
+		if required(tool, fp.param) {
+			t.Errorf("%s: %s still required with session memory", tool, fp.param)
+		}
+	}
+	if !required("provide_context", "context") {
+		t.Error("provide_context: context should stay required")
+	}
+	flow := srv.mcp.GetTool("get_flow").Tool.InputSchema.Properties["flow_name"].(map[string]any)
+	if flow["description"] != "Name of the flow to retrieve (searches by name; defaults to the session's current flow)" {
+		t.Errorf("flow_name description = %q", flow["description"])
+	}
+	if getFlowTool.InputSchema.Properties["flow_name"].(map[string]any)["description"] != "Name of the flow to retrieve (searches by name)" {
+		t.Error("relaxing a tool changed its shared definition")
+	}
+}
@@ -0,0 +1,265 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// focusKind is a kind of thing a session can be focused on.
+type focusKind string
+
+const (
+	focusService focusKind = "service"
+	focusFlow    focusKind = "flow"
+	focusFile    focusKind = "file"
+)
+
+const (
+	// recentFocusLimit is how many recently discussed items are kept per kind.
+	recentFocusLimit = 5
+	// sessionIdleTTL is how long an idle session's memory is kept.
+	sessionIdleTTL = 2 * time.Hour
+	// defaultSessionID is used when the transport has no client session.
+	defaultSessionID = "default"
+)
+
+// sessionFocus holds what one session has recently discussed, most recent first.
+type sessionFocus struct {
+	recent  map[focusKind][]string
+	touched time.Time
+}
+
+// sessionMemory tracks recently discussed services, flows, and files per
+// MCP client session so tools can default omitted parameters to the
+// session's current focus.
+type sessionMemory struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionFocus
+	now      func() time.Time
+}
+
+func newSessionMemory() *sessionMemory {
+	return &sessionMemory{sessions: make(map[string]*sessionFocus), now: time.Now}
+}
+
+// session returns the focus for id, creating it and dropping idle sessions.
+// The caller must hold m.mu.
+func (m *sessionMemory) session(id string) *sessionFocus {
+	now := m.now()
+	for sid, f := range m.sessions {
+		if now.Sub(f.touched) > sessionIdleTTL {
+			delete(m.sessions, sid)
+		}
+	}
+	f := m.sessions[id]
+	if f == nil {
+		f = &sessionFocus{recent: make(map[focusKind][]string)}
+		m.sessions[id] = f
+	}
+	f.touched = now
+	return f
+}
+
+// remember makes value the current focus of its kind.
+func (m *sessionMemory) remember(id string, kind focusKind, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.session(id)
+	list := []string{value}
+	for _, v := range f.recent[kind] {
+		if !strings.EqualFold(v, value) && len(list) < recentFocusLimit {
+			list = append(list, v)
+		}
+	}
+	f.recent[kind] = list
+}
+
+// current returns the current focus of a kind, or "".
+func (m *sessionMemory) current(id string, kind focusKind) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if list := m.session(id).recent[kind]; len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// recent returns a copy of the recently discussed items of a kind.
+func (m *sessionMemory) recent(id string, kind focusKind) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.session(id).recent[kind]...)
+}
+
+// clear forgets everything the session has discussed.
+func (m *sessionMemory) clear(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// sessionID identifies the MCP client session a tool call belongs to.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		return session.SessionID()
+	}
+	return defaultSessionID
+}
+
+// EnableSessionMemory turns on per-session conversation memory. Tools that
+// take a service, flow, or file then default to the one most recently
+// discussed in the session, and the get_focus and set_focus tools are added.
+func (s *Server) EnableSessionMemory() {
+	if s.memory != nil {
+		return
+	}
+	s.memory = newSessionMemory()
+	s.mcp.AddTool(getFocusTool, s.handleGetFocus)
+	s.mcp.AddTool(setFocusTool, s.handleSetFocus)
+	s.relaxFocusTools()
+}
+
+// focusParams maps each tool whose parameter can default to the session's
+// focus to that parameter and the kind of focus it takes.
+var focusParams = map[string]struct {
+	param string
+	kind  focusKind
+}{
+	"get_file_docs":        {"file_path", focusFile},
+	"get_service_context":  {"service", focusService},
+	"get_blast_radius":     {"service", focusService},
+	"get_incident_bundle":  {"service", focusService},
+	"get_onboarding_guide": {"service", focusService},
+	"get_flow":             {"flow_name", focusFlow},
+	"get_repo_details":     {"name", focusService},
+	"provide_context":      {"service", focusService},
+}
+
+// relaxFocusTools re-registers the tools in focusParams with that parameter
+// optional, so clients see it may be omitted. It does nothing without
+// session memory, leaving the parameters required, and is called again
+// whenever more tools are registered.
+func (s *Server) relaxFocusTools() {
+	if s.memory == nil {
+		return
+	}
+	for name, fp := range focusParams {
+		t := s.mcp.GetTool(name)
+		if t == nil || !slices.Contains(t.Tool.InputSchema.Required, fp.param) {
+			continue
+		}
+		s.mcp.AddTool(withFocusDefault(t.Tool, fp.param, fp.kind), t.Handler)
+	}
+}
+
+// withFocusDefault returns a copy of tool with param optional and its
+// description noting that it defaults to the session's focus.
+func withFocusDefault(tool mcp.Tool, param string, kind focusKind) mcp.Tool {
+	var required []string
+	for _, r := range tool.InputSchema.Required {
+		if r != param {
+			required = append(required, r)
+		}
+	}
+	props := make(map[string]any, len(tool.InputSchema.Properties))
+	for name, prop := range tool.InputSchema.Properties {
+		props[name] = prop
+	}
+	if prop, ok := props[param].(map[string]any); ok {
+		relaxed := make(map[string]any, len(prop))
+		for k, v := range prop {
+			relaxed[k] = v
+		}
+		desc, _ := prop["description"].(string)
+		note := fmt.Sprintf("defaults to the session's current %s", kind)
+		if strings.HasSuffix(desc, ")") {
+			relaxed["description"] = strings.TrimSuffix(desc, ")") + "; " + note + ")"
+		} else {
+			relaxed["description"] = desc + " (" + note + ")"
+		}
+		props[param] = relaxed
+	}
+	tool.InputSchema.Required = required
+	tool.InputSchema.Properties = props
+	return tool
+}
+
+// focusArg returns the named string argument. When it is omitted and session
+// memory is enabled, the session's current focus of the given kind is used;
+// explicit values become the new focus.
+func (s *Server) focusArg(ctx context.Context, request mcp.CallToolRequest, name string, kind focusKind) (string, error) {
+	value := strings.TrimSpace(request.GetString(name, ""))
+	if s.memory == nil {
+		if value == "" {
+			return "", fmt.Errorf("missing required parameter: %s", name)
+		}
+		return value, nil
+	}
+
+	id := sessionID(ctx)
+	if value == "" {
+		value = s.memory.current(id, kind)
+		if value == "" {
+			return "", fmt.Errorf("missing required parameter: %s (no current %s focus in this session)", name, kind)
+		}
+		return value, nil
+	}
+	s.memory.remember(id, kind, value)
+	return value, nil
+}
+
+// rememberFocus records a value discovered by a tool, such as the full name
+// of a flow matched by a partial query.
+func (s *Server) rememberFocus(ctx context.Context, kind focusKind, value string) {
+	if s.memory != nil {
+		s.memory.remember(sessionID(ctx), kind, value)
+	}
+}
+
+// handleGetFocus reports the session's current focus and recent history.
+func (s *Server) handleGetFocus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := sessionID(ctx)
+	var sb strings.Builder
+	sb.WriteString("# Current Focus\n\n")
+	empty := true
+	for _, kind := range []focusKind{focusService, focusFlow, focusFile} {
+		list := s.memory.recent(id, kind)
+		if len(list) == 0 {
+			continue
+		}
+		empty = false
+		sb.WriteString(fmt.Sprintf("- **%s**: %s", kind, list[0]))
+		if len(list) > 1 {
+			sb.WriteString(fmt.Sprintf(" (recently: %s)", strings.Join(list[1:], ", ")))
+		}
+		sb.WriteString("\n")
+	}
+	if empty {
+		sb.WriteString("Nothing discussed yet in this session. Tools that take a service, flow, or file will remember it.\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleSetFocus sets or clears the session's focus explicitly.
+func (s *Server) handleSetFocus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := sessionID(ctx)
+	if request.GetBool("clear", false) {
+		s.memory.clear(id)
+	}
+	s.memory.remember(id, focusService, request.GetString("service", ""))
+	s.memory.remember(id, focusFlow, request.GetString("flow", ""))
+	s.memory.remember(id, focusFile, request.GetString("file", ""))
+	return s.handleGetFocus(ctx, request)
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// symbolReferenceCandidates is how many function documents get_symbol
+// checks for references to a symbol.
+const symbolReferenceCandidates = 50
+
+// handleGetSymbol returns a symbol's definitions and the functions that
+// call or use it. References are matched by name, so a common name can
+// pick up callers of a different symbol with the same name.
+func (s *Server) handleGetSymbol(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("symbol")
+	if err != nil {
+		return mcp.NewToolResultError("missing required parameter: symbol"), nil
+	}
+	repo := request.GetString("repo", "")
+	var filePath string
+	if r, fp, n, ok := vectordb.ParseSymbolID(name); ok {
+		name, filePath = n, fp
+		if r != "" {
+			repo = r
+		}
+	}
+
+	defs, err := s.symbolDefinitions(ctx, name, repo, filePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+	}
+	if len(defs) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No symbol named %q is indexed. Functions and types are indexed at the normal and max quality tiers; run `autodoc generate` to index them.", name)), nil
+	}
+
+	callers, users, err := s.symbolReferences(ctx, name, defs)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", name)
+	for i, d := range defs {
+		if len(defs) > 1 {
+			fmt.Fprintf(&sb, "\n## Definition %d of %d\n", i+1, len(defs))
+		} else {
+			sb.WriteString("\n## Definition\n")
+		}
+		fmt.Fprintf(&sb, "ID: %s\n", symbolIDOf(d))
+		fmt.Fprintf(&sb, "Kind: %s\n", d.Metadata.Type)
+		fmt.Fprintf(&sb, "Location: %s\n", symbolLocation(d))
+		sb.WriteString("\n")
+		sb.WriteString(d.Content)
+		sb.WriteString("\n")
+	}
+	writeSymbolRefs(&sb, "Called by", callers)
+	writeSymbolRefs(&sb, "Used by", users)
+	if len(defs) > 1 && len(callers)+len(users) > 0 {
+		sb.WriteString("\nReferences are matched by name and may belong to any of the definitions above.\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// symbolDefinitions finds the function and type documents for a symbol,
+// optionally in one repository or file. A bare method name also finds
+// Type.method documents.
+func (s *Server) symbolDefinitions(ctx context.Context, name, repo, filePath string) ([]vectordb.Document, error) {
+	filter := &vectordb.SearchFilter{Symbol: &name}
+	if repo != "" {
+		filter.RepoID = &repo
+	}
+	results, err := s.store.Search(ctx, name, 20, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 && !strings.Contains(name, ".") {
+		results, err = s.store.Search(ctx, name, 20, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var defs []vectordb.Document
+	for _, r := range results {
+		md := r.Document.Metadata
+		if md.Type != vectordb.DocTypeFunction && md.Type != vectordb.DocTypeClass {
+			continue
+		}
+		if md.Symbol != name && !strings.HasSuffix(md.Symbol, "."+name) {
+			continue
+		}
+		if (repo != "" && md.RepoID != repo) || (filePath != "" && md.FilePath != filePath) {
+			continue
+		}
+		defs = append(defs, r.Document)
+	}
+	return defs, nil
+}
+
+// symbolReferences finds the functions that call or use one of a symbol's
+// definitions, among the function documents that best match its name.
+func (s *Server) symbolReferences(ctx context.Context, name string, defs []vectordb.Document) (callers, users []vectordb.Document, err error) {
+	fnType := vectordb.DocTypeFunction
+	candidates, err := s.store.Search(ctx, name, symbolReferenceCandidates, &vectordb.SearchFilter{Type: &fnType})
+	if err != nil {
+		return nil, nil, err
+	}
+	isDef := make(map[string]bool)
+	for _, d := range defs {
+		isDef[d.ID] = true
+	}
+	for _, c := range candidates {
+		if isDef[c.Document.ID] {
+			continue
+		}
+		calls, uses := indexer.ParseSymbolReferences(c.Document.Content)
+		if refersTo(calls, defs) {
+			callers = append(callers, c.Document)
+		}
+		if refersTo(uses, defs) {
+			users = append(users, c.Document)
+		}
+	}
+	return callers, users, nil
+}
+
+// refersTo reports whether any reference names one of the definitions.
+func refersTo(refs []string, defs []vectordb.Document) bool {
+	for _, ref := range refs {
+		for _, d := range defs {
+			if indexer.CallsSymbol(ref, d.Metadata.Symbol) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// symbolIDOf returns a document's symbol ID, computing it for documents
+// indexed before symbol IDs were stored.
+func symbolIDOf(d vectordb.Document) string {
+	if d.Metadata.SymbolID != "" {
+		return d.Metadata.SymbolID
+	}
+	return vectordb.SymbolID(d.Metadata.RepoID, d.Metadata.FilePath, d.Metadata.Symbol)
+}
+
+func symbolLocation(d vectordb.Document) string {
+	loc := d.Metadata.FilePath
+	if d.Metadata.LineStart > 0 {
+		loc += fmt.Sprintf(":%d", d.Metadata.LineStart)
+		if d.Metadata.LineEnd > d.Metadata.LineStart {
+			loc += fmt.Sprintf("-%d", d.Metadata.LineEnd)
+		}
+	}
+	if d.Metadata.RepoID != "" {
+		loc += fmt.Sprintf(" (repo %s)", d.Metadata.RepoID)
+	}
+	return loc
+}
+
+func writeSymbolRefs(sb *strings.Builder, title string, docs []vectordb.Document) {
+	fmt.Fprintf(sb, "\n## %s (%d)\n", title, len(docs))
+	if len(docs) == 0 {
+		sb.WriteString("None found in the index.\n")
+		return
+	}
+	for _, d := range docs {
+		fmt.Fprintf(sb, "- %s at %s [%s]\n", d.Metadata.Symbol, symbolLocation(d), symbolIDOf(d))
+	}
+}
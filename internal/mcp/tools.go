@@ -14,7 +14,7 @@ var searchCodebaseTool = mcp.NewTool("search_codebase",
 	),
 	mcp.WithString("type_filter",
 		mcp.Description("Filter results by document type"),
-		mcp.Enum("file", "function", "class", "module", "architecture"),
+		mcp.Enum("file", "function", "class", "module", "architecture", "decision"),
 	),
 )
 
@@ -41,3 +41,49 @@ var getDiagramTool = mcp.NewTool("get_diagram",
 		mcp.Enum("architecture", "dependency", "sequence"),
 	),
 )
+
+// getFocusTool defines the get_focus MCP tool, available with session memory.
+var getFocusTool = mcp.NewTool("get_focus",
+	mcp.WithDescription("Show the service, flow, and file this session is currently focused on, plus recently discussed ones. Tools default omitted service, flow, and file parameters to the current focus."),
+)
+
+// setFocusTool defines the set_focus MCP tool, available with session memory.
+var setFocusTool = mcp.NewTool("set_focus",
+	mcp.WithDescription("Set the session's current focus so later tool calls can omit the service, flow, or file parameter."),
+	mcp.WithString("service",
+		mcp.Description("Service to focus on"),
+	),
+	mcp.WithString("flow",
+		mcp.Description("Flow to focus on"),
+	),
+	mcp.WithString("file",
+		mcp.Description("File path to focus on"),
+	),
+	mcp.WithBoolean("clear",
+		mcp.Description("Forget the session's focus and history before applying the other parameters"),
+	),
+)
+
+// getSymbolTool defines the get_symbol MCP tool.
+var getSymbolTool = mcp.NewTool("get_symbol",
+	mcp.WithDescription("Get a function, method, or type by name or symbol ID: its documentation, where it is defined, what it calls and uses, and the functions that call or use it across all indexed repositories."),
+	mcp.WithString("symbol",
+		mcp.Required(),
+		mcp.Description("Symbol name (e.g. ValidateToken or Server.Serve) or symbol ID (e.g. auth:pkg/token.go#ValidateToken)"),
+	),
+	mcp.WithString("repo",
+		mcp.Description("Only look for definitions in this repository"),
+	),
+)
+
+// reviewChangeTool defines the review_change MCP tool.
+var reviewChangeTool = mcp.NewTool("review_change",
+	mcp.WithDescription("Review a change before it merges: map a unified diff onto the documented codebase to find the functions and types it touches, the code that calls them, the services, endpoints, and flows involved, and the documentation pages that will need updating."),
+	mcp.WithString("diff",
+		mcp.Required(),
+		mcp.Description("Unified diff of the change, e.g. the output of `git diff main`"),
+	),
+	mcp.WithString("repo",
+		mcp.Description("Repository the diff belongs to, when several are indexed"),
+	),
+)
@@ -0,0 +1,227 @@
+// Package metrics reads per-edge request rate, error rate, and latency from
+// Prometheus, so the central service map can show live traffic and health
+// on top of the detected dependency graph.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// Default queries over the service graph metrics written by Tempo's metrics
+// generator and the OpenTelemetry Collector's servicegraph connector.
+const (
+	DefaultWindow           = "5m"
+	DefaultRequestRateQuery = `sum by (client, server) (rate(traces_service_graph_request_total[$window]))`
+	DefaultErrorRateQuery   = `sum by (client, server) (rate(traces_service_graph_request_failed_total[$window])) / sum by (client, server) (rate(traces_service_graph_request_total[$window]))`
+	DefaultLatencyQuery     = `histogram_quantile(0.99, sum by (client, server, le) (rate(traces_service_graph_request_server_seconds_bucket[$window])))`
+	DefaultRefresh          = 30 // seconds
+)
+
+// EdgeMetrics is the traffic on one caller-to-callee edge.
+type EdgeMetrics struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	RequestRate float64 `json:"rps"`                 // requests per second
+	ErrorRate   float64 `json:"errorRate"`           // failed fraction, 0 to 1
+	LatencyMs   float64 `json:"latencyMs,omitempty"` // 0 when unknown
+}
+
+// Snapshot is the metrics for every edge at one point in time. It is
+// embedded in the generated service map and served by Handler in the same
+// shape, so a served map can refresh itself.
+type Snapshot struct {
+	Window    string        `json:"window"`
+	FetchedAt time.Time     `json:"fetchedAt"`
+	Refresh   int           `json:"refresh,omitempty"` // seconds between polls of api/metrics; 0 disables
+	Edges     []EdgeMetrics `json:"edges"`
+}
+
+// Client queries a Prometheus server for edge metrics.
+type Client struct {
+	cfg     config.MetricsConfig
+	resolve func(string) string
+	http    *http.Client
+}
+
+// New returns a client for cfg, or nil when no Prometheus URL is
+// configured. resolve maps the service names in metric labels to repo names;
+// nil leaves them unchanged.
+func New(cfg config.MetricsConfig, resolve func(string) string) *Client {
+	if cfg.PrometheusURL == "" {
+		return nil
+	}
+	if cfg.Window == "" {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.RequestRateQuery == "" {
+		cfg.RequestRateQuery = DefaultRequestRateQuery
+	}
+	if cfg.ErrorRateQuery == "" {
+		cfg.ErrorRateQuery = DefaultErrorRateQuery
+	}
+	if cfg.LatencyQuery == "" {
+		cfg.LatencyQuery = DefaultLatencyQuery
+	}
+	if cfg.ClientLabel == "" {
+		cfg.ClientLabel = "client"
+	}
+	if cfg.ServerLabel == "" {
+		cfg.ServerLabel = "server"
+	}
+	switch {
+	case cfg.Refresh == 0:
+		cfg.Refresh = DefaultRefresh
+	case cfg.Refresh < 0:
+		cfg.Refresh = 0
+	}
+	if resolve == nil {
+		resolve = func(s string) string { return s }
+	}
+	return &Client{cfg: cfg, resolve: resolve, http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Snapshot queries the current request rate, error rate, and latency of
+// every edge. Edges with no traffic in the window are left out.
+func (c *Client) Snapshot(ctx context.Context) (*Snapshot, error) {
+	type key struct{ from, to string }
+	edges := make(map[key]*EdgeMetrics)
+	queries := []struct {
+		name  string
+		query string
+		set   func(e *EdgeMetrics, v float64)
+	}{
+		{"request rate", c.cfg.RequestRateQuery, func(e *EdgeMetrics, v float64) { e.RequestRate += v }},
+		{"error rate", c.cfg.ErrorRateQuery, func(e *EdgeMetrics, v float64) { e.ErrorRate = math.Max(e.ErrorRate, v) }},
+		{"latency", c.cfg.LatencyQuery, func(e *EdgeMetrics, v float64) { e.LatencyMs = math.Max(e.LatencyMs, v*1000) }},
+	}
+	for _, q := range queries {
+		samples, err := c.query(ctx, strings.ReplaceAll(q.query, "$window", c.cfg.Window))
+		if err != nil {
+			return nil, fmt.Errorf("querying %s: %w", q.name, err)
+		}
+		for _, s := range samples {
+			from, to := s.labels[c.cfg.ClientLabel], s.labels[c.cfg.ServerLabel]
+			if from == "" || to == "" {
+				continue
+			}
+			k := key{c.resolve(from), c.resolve(to)}
+			if k.from == k.to {
+				continue
+			}
+			e := edges[k]
+			if e == nil {
+				e = &EdgeMetrics{From: k.from, To: k.to}
+				edges[k] = e
+			}
+			q.set(e, s.value)
+		}
+	}
+
+	snap := &Snapshot{Window: c.cfg.Window, FetchedAt: time.Now().UTC(), Refresh: c.cfg.Refresh, Edges: []EdgeMetrics{}}
+	for _, e := range edges {
+		if e.RequestRate > 0 {
+			snap.Edges = append(snap.Edges, *e)
+		}
+	}
+	sort.Slice(snap.Edges, func(i, j int) bool {
+		if snap.Edges[i].From != snap.Edges[j].From {
+			return snap.Edges[i].From < snap.Edges[j].From
+		}
+		return snap.Edges[i].To < snap.Edges[j].To
+	})
+	return snap, nil
+}
+
+// sample is one series of an instant vector.
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// query runs an instant query against the Prometheus HTTP API. NaN and
+// infinite values, e.g. an error rate over zero requests, are dropped.
+func (c *Client) query(ctx context.Context, promQL string) ([]sample, error) {
+	endpoint := strings.TrimRight(c.cfg.PrometheusURL, "/") + "/api/v1/query?" + url.Values{"query": {promQL}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]any            `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("status %d: decoding response: %w", resp.StatusCode, err)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, result.Error)
+	}
+	if result.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("query returned a %s, want an instant vector", result.Data.ResultType)
+	}
+
+	var samples []sample
+	for _, r := range result.Data.Result {
+		s, _ := r.Value[1].(string)
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		samples = append(samples, sample{labels: r.Metric, value: v})
+	}
+	return samples, nil
+}
+
+// Handler serves a fresh snapshot as JSON, for a served service map to poll
+// at api/metrics. access, when non-nil, hides edges touching repos the
+// request's user may not see.
+func Handler(c *Client, access func(r *http.Request, repo string) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap, err := c.Snapshot(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if access != nil {
+			edges := snap.Edges[:0]
+			for _, e := range snap.Edges {
+				if access(r, e.From) && access(r, e.To) {
+					edges = append(edges, e)
+				}
+			}
+			snap.Edges = edges
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(snap)
+	})
+}
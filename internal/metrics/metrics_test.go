@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// fakePrometheus answers instant queries by the metric they mention.
+func fakePrometheus(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query().Get("query")
+		if strings.Contains(q, "$window") || !strings.Contains(q, "[1m]") {
+			t.Errorf("window not substituted: %s", q)
+		}
+		var result string
+		switch {
+		case strings.Contains(q, "request_failed_total"):
+			result = `{"metric":{"client":"gateway","server":"orders-service"},"value":[1700000000,"0.02"]},
+				{"metric":{"client":"orders","server":"payments"},"value":[1700000000,"NaN"]}`
+		case strings.Contains(q, "seconds_bucket"):
+			result = `{"metric":{"client":"gateway","server":"orders-service"},"value":[1700000000,"0.25"]}`
+		default:
+			result = `{"metric":{"client":"gateway","server":"orders-service"},"value":[1700000000,"12.5"]},
+				{"metric":{"client":"orders","server":"payments"},"value":[1700000000,"3"]},
+				{"metric":{"client":"orders","server":"legacy"},"value":[1700000000,"0"]},
+				{"metric":{"server":"orders"},"value":[1700000000,"9"]}`
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` + result + `]}}`))
+	}))
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := fakePrometheus(t)
+	defer srv.Close()
+
+	if New(config.MetricsConfig{}, nil) != nil {
+		t.Fatal("New without a Prometheus URL should return nil")
+	}
+	c := New(config.MetricsConfig{PrometheusURL: srv.URL, Window: "1m"}, func(s string) string {
+		return strings.TrimSuffix(s, "-service")
+	})
+	snap, err := c.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Window != "1m" || snap.Refresh != DefaultRefresh || snap.FetchedAt.IsZero() {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	want := []EdgeMetrics{
+		{From: "gateway", To: "orders", RequestRate: 12.5, ErrorRate: 0.02, LatencyMs: 250},
+		{From: "orders", To: "payments", RequestRate: 3},
+	}
+	if len(snap.Edges) != len(want) {
+		t.Fatalf("edges = %+v, want %+v", snap.Edges, want)
+	}
+	for i := range want {
+		if snap.Edges[i] != want[i] {
+			t.Errorf("edge %d = %+v, want %+v", i, snap.Edges[i], want[i])
+		}
+	}
+}
+
+func TestSnapshotQueryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(config.MetricsConfig{PrometheusURL: srv.URL}, nil).Snapshot(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("err = %v, want the Prometheus error", err)
+	}
+}
+
+func TestHandlerFiltersEdges(t *testing.T) {
+	srv := fakePrometheus(t)
+	defer srv.Close()
+
+	c := New(config.MetricsConfig{PrometheusURL: srv.URL, Window: "1m", Refresh: -1}, nil)
+	h := Handler(c, func(r *http.Request, repo string) bool { return repo != "payments" })
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Edges) != 1 || snap.Edges[0].To != "orders-service" || snap.Refresh != 0 {
+		t.Errorf("served snapshot = %+v", snap)
+	}
+}
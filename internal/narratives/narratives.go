@@ -0,0 +1,282 @@
+// Package narratives holds the edits people make in conversation to the
+// flow narratives the central site generates, stored as context-engine
+// facts. An amendment replaces a section's text until the section is
+// regenerated differently; a pin keeps its text through every
+// regeneration, and a regeneration that would have changed it is reported
+// as a conflict instead.
+package narratives
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// FactKey is the key of a flow-scoped fact editing the whole narrative;
+// followed by a colon and a section name, e.g. "narrative:payment", it
+// edits one section. The value is the section's new text; "pinned"
+// followed by a colon and text pins that text, and "pinned" alone pins
+// the text as generated at the time.
+const FactKey = "narrative"
+
+// Edit is what someone said about a section of a flow's narrative.
+type Edit struct {
+	Flow string `json:"flow"`
+	// Section names the section as Split does; empty for the whole
+	// narrative.
+	Section string `json:"section,omitempty"`
+	// Text replaces the section's; empty for a pin of the generated text.
+	Text   string `json:"text,omitempty"`
+	Pinned bool   `json:"pinned,omitempty"`
+
+	ProvidedBy string    `json:"provided_by,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Provenance renders who made the edit and when, e.g. "ada, 2026-03-04".
+func (e Edit) Provenance() string {
+	who := e.ProvidedBy
+	if who == "" {
+		who = "unknown"
+	}
+	if e.At.IsZero() {
+		return who
+	}
+	return who + ", " + e.At.Format("2006-01-02")
+}
+
+// Describe says what the edit did, e.g. "overview pinned by ada,
+// 2026-03-04".
+func (e Edit) Describe() string {
+	what := "narrative"
+	if e.Section != "" {
+		what = e.Section
+	}
+	verb := "amended"
+	if e.Pinned {
+		verb = "pinned"
+	}
+	return what + " " + verb + " by " + e.Provenance()
+}
+
+// FromFact parses a narrative edit. It reports false for other facts.
+func FromFact(f contextengine.Fact) (Edit, bool) {
+	key, section, _ := strings.Cut(f.Key, ":")
+	value := strings.TrimSpace(f.Value)
+	if key != FactKey || f.Scope != "flow" || f.ScopeID == "" || value == "" {
+		return Edit{}, false
+	}
+	e := Edit{Flow: f.ScopeID, Section: slug(section), ProvidedBy: f.ProvidedBy, At: f.UpdatedAt}
+	if rest, ok := cutPrefixFold(value, "pinned"); ok && (rest == "" || strings.ContainsAny(rest[:1], ":-—")) {
+		e.Pinned = true
+		value = strings.TrimSpace(strings.TrimLeft(rest, ":-—"))
+	}
+	e.Text = value
+	return e, true
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return strings.TrimSpace(s[len(prefix):]), true
+}
+
+// Load returns every current narrative edit, oldest first.
+func Load(ctx context.Context, facts contextengine.FactSource) ([]Edit, error) {
+	fs, err := facts.GetCurrentFacts(ctx, "", "flow", "")
+	if err != nil {
+		return nil, fmt.Errorf("loading flow facts: %w", err)
+	}
+	var out []Edit
+	for _, f := range fs {
+		if e, ok := FromFact(f); ok {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, nil
+}
+
+// Section is a paragraph of a narrative.
+type Section struct {
+	// Name is the slug of the paragraph's bold label, without numbering,
+	// e.g. "payment" for "**2. Payment:** ...", or else "overview" for the first paragraph and
+	// "paragraph-<n>" for the nth.
+	Name string
+	Text string
+}
+
+var boldLabel = regexp.MustCompile(`^\*\*([^*]+)\*\*`)
+
+// Split splits a narrative into its paragraphs.
+func Split(narrative string) []Section {
+	var sections []Section
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(strings.ReplaceAll(narrative, "\r\n", "\n"), "\n\n") {
+		p = strings.Trim(p, "\n")
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		name := ""
+		if m := boldLabel.FindStringSubmatch(strings.TrimSpace(p)); m != nil {
+			name = slug(strings.TrimLeft(m[1], "0123456789.) "))
+		}
+		if name == "" || seen[name] {
+			name = "paragraph-" + strconv.Itoa(len(sections)+1)
+			if len(sections) == 0 {
+				name = "overview"
+			}
+		}
+		seen[name] = true
+		sections = append(sections, Section{Name: name, Text: p})
+	}
+	return sections
+}
+
+// Join joins sections back into a narrative.
+func Join(sections []Section) string {
+	texts := make([]string, len(sections))
+	for i, s := range sections {
+		texts[i] = s.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug normalizes a section name, e.g. "Payment:" to "payment".
+func slug(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// Lock is what is remembered of an edit between regenerations.
+type Lock struct {
+	// EditedAt is when the edit was made; a newer edit starts a new lock.
+	EditedAt time.Time `json:"edited_at"`
+	// Baseline fingerprints the generated text the edit was made against.
+	Baseline string `json:"baseline"`
+	// Text is the generated text a pin without text of its own keeps.
+	Text string `json:"text,omitempty"`
+	// Reported fingerprints the generated text last reported as a
+	// conflict, so each is reported once.
+	Reported string `json:"reported,omitempty"`
+}
+
+// Conflict is a regeneration that changed a section someone edited.
+type Conflict struct {
+	Edit Edit
+	// Generated is the section's text as now generated: kept out of the
+	// narrative for a pin, and replacing the amendment otherwise.
+	Generated string
+}
+
+// Apply applies a flow's edits to its generated narrative, newest last,
+// remembering them in locks, keyed by section name. It returns the edited
+// narrative, the edits in effect, and the conflicts new since the last
+// call. An amendment lasts until the section is generated differently
+// than when it was made; a pin keeps its text regardless. An edit of the
+// whole narrative in effect replaces those of its sections; an edit of a
+// section the narrative doesn't have adds it at the end.
+func Apply(generated string, edits []Edit, locks map[string]Lock) (string, []Edit, []Conflict) {
+	latest := make(map[string]Edit)
+	var order []string
+	for _, e := range edits {
+		if _, ok := latest[e.Section]; !ok {
+			order = append(order, e.Section)
+		}
+		latest[e.Section] = e
+	}
+	for section := range locks {
+		if _, ok := latest[section]; !ok {
+			delete(locks, section) // the edit was retracted
+		}
+	}
+
+	var applied []Edit
+	var conflicts []Conflict
+	if e, ok := latest[""]; ok {
+		text, inEffect, conflict := apply(e, generated, true, locks)
+		conflicts = append(conflicts, conflict...)
+		if inEffect {
+			for _, section := range order {
+				if section != "" {
+					delete(locks, section)
+				}
+			}
+			return text, []Edit{e}, conflicts
+		}
+	}
+
+	sections := Split(generated)
+	for _, name := range order {
+		if name == "" {
+			continue
+		}
+		e := latest[name]
+		i := slices.IndexFunc(sections, func(s Section) bool { return s.Name == name })
+		current := ""
+		if i >= 0 {
+			current = sections[i].Text
+		}
+		text, inEffect, conflict := apply(e, current, i >= 0, locks)
+		conflicts = append(conflicts, conflict...)
+		if !inEffect {
+			continue
+		}
+		applied = append(applied, e)
+		if i >= 0 {
+			sections[i].Text = text
+		} else {
+			sections = append(sections, Section{Name: name, Text: text})
+		}
+	}
+	return Join(sections), applied, conflicts
+}
+
+// apply applies one edit to a section generated as current, reporting
+// the text to use, whether the edit is in effect, and any new conflict.
+func apply(e Edit, current string, exists bool, locks map[string]Lock) (string, bool, []Conflict) {
+	fp := fingerprint(current)
+	lock, ok := locks[e.Section]
+	if !ok || !lock.EditedAt.Equal(e.At) {
+		if e.Text == "" && !exists {
+			return current, false, nil // nothing to pin yet
+		}
+		lock = Lock{EditedAt: e.At, Baseline: fp}
+		if e.Text == "" {
+			lock.Text = current
+		}
+	}
+	text := e.Text
+	if text == "" {
+		text = lock.Text
+	}
+
+	var conflicts []Conflict
+	changed := fp != lock.Baseline
+	if changed && lock.Reported != fp {
+		lock.Reported = fp
+		conflicts = append(conflicts, Conflict{Edit: e, Generated: current})
+	}
+	locks[e.Section] = lock
+	if changed && !e.Pinned {
+		return current, false, conflicts
+	}
+	return text, true, conflicts
+}
+
+// fingerprint identifies a section's text, ignoring how it is wrapped.
+func fingerprint(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,135 @@
+package narratives
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func TestFromFact(t *testing.T) {
+	cases := []struct {
+		key, value    string
+		ok            bool
+		section, text string
+		pinned        bool
+	}{
+		{"narrative:Payment", "Charges are captured after shipping.", true, "payment", "Charges are captured after shipping.", false},
+		{"narrative:overview", "pinned", true, "overview", "", true},
+		{"narrative:fraud check", "Pinned: Fraud is scored before payment.", true, "fraud-check", "Fraud is scored before payment.", true},
+		{"narrative", "Pinned stays pinned.", true, "", "Pinned stays pinned.", false},
+		{"narrative:payment", "", false, "", "", false},
+		{"threat:orders->payments/T", "mitigated", false, "", "", false},
+	}
+	for _, c := range cases {
+		e, ok := FromFact(contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: c.key, Value: c.value})
+		if ok != c.ok || e.Section != c.section || e.Text != c.text || e.Pinned != c.pinned {
+			t.Errorf("FromFact(%s=%q) = %+v, %v", c.key, c.value, e, ok)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	narrative := "Checkout coordinates with 3 services.\n\n**1. Fraud Check:** fraud (POST /score)\n\n**2. Payment:** payments (POST /charges)\n\nThe critical path is payment."
+	want := []string{"overview", "fraud-check", "payment", "paragraph-4"}
+	sections := Split(narrative)
+	var got []string
+	for _, s := range sections {
+		got = append(got, s.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split names = %v, want %v", got, want)
+	}
+	if Join(sections) != narrative {
+		t.Errorf("Join(Split(n)) = %q", Join(sections))
+	}
+}
+
+func TestApply(t *testing.T) {
+	at := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	generated := "Checkout calls two services.\n\n**Payment:** payments charges the card."
+	edits := []Edit{
+		{Flow: "Checkout", Section: "overview", Pinned: true, ProvidedBy: "ada", At: at},
+		{Flow: "Checkout", Section: "payment", Text: "**Payment:** charged after shipping.", ProvidedBy: "bob", At: at},
+	}
+	locks := make(map[string]Lock)
+
+	got, applied, conflicts := Apply(generated, edits, locks)
+	if want := "Checkout calls two services.\n\n**Payment:** charged after shipping."; got != want || len(applied) != 2 || len(conflicts) != 0 {
+		t.Fatalf("first Apply = %q, %v, %v", got, applied, conflicts)
+	}
+
+	// The regeneration changes both sections: the pin keeps its text, the
+	// amendment gives way, and both are reported.
+	regenerated := "Checkout calls three services.\n\n**Payment:** payments authorizes the card."
+	got, applied, conflicts = Apply(regenerated, edits, locks)
+	if want := "Checkout calls two services.\n\n**Payment:** payments authorizes the card."; got != want {
+		t.Errorf("Apply after regeneration = %q, want %q", got, want)
+	}
+	if len(applied) != 1 || applied[0].Describe() != "overview pinned by ada, 2026-03-04" {
+		t.Errorf("applied = %+v, want only the pin", applied)
+	}
+	if len(conflicts) != 2 || conflicts[0].Generated != "Checkout calls three services." || conflicts[1].Edit.ProvidedBy != "bob" {
+		t.Errorf("conflicts = %+v", conflicts)
+	}
+
+	// Each conflict is reported once.
+	if _, _, conflicts = Apply(regenerated, edits, locks); len(conflicts) != 0 {
+		t.Errorf("conflicts reported again: %+v", conflicts)
+	}
+
+	// A newer edit is made against the narrative as it is now.
+	edits[1].At = at.Add(time.Hour)
+	got, _, conflicts = Apply(regenerated, edits, locks)
+	if want := "Checkout calls two services.\n\n**Payment:** charged after shipping."; got != want || len(conflicts) != 0 {
+		t.Errorf("Apply with a newer amendment = %q, %v", got, conflicts)
+	}
+
+	// A retracted edit's lock is forgotten.
+	Apply(regenerated, edits[:1], locks)
+	if _, ok := locks["payment"]; ok {
+		t.Error("the retracted amendment's lock was kept")
+	}
+}
+
+func TestApplyWholeNarrative(t *testing.T) {
+	edits := []Edit{
+		{Flow: "Checkout", Section: "overview", Text: "Overview.", At: time.Unix(1, 0)},
+		{Flow: "Checkout", Text: "Written by hand.", Pinned: true, At: time.Unix(2, 0)},
+		{Flow: "Checkout", Section: "security", Text: "**Security:** mTLS everywhere.", At: time.Unix(3, 0)},
+	}
+	got, applied, _ := Apply("Generated.", edits, make(map[string]Lock))
+	if got != "Written by hand." || len(applied) != 1 {
+		t.Errorf("Apply = %q, %+v, want the whole narrative's edit alone", got, applied)
+	}
+
+	got, applied, _ = Apply("Generated.", []Edit{edits[2]}, make(map[string]Lock))
+	if got != "Generated.\n\n**Security:** mTLS everywhere." || len(applied) != 1 {
+		t.Errorf("Apply of a new section = %q, %+v, want it added", got, applied)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := contextengine.NewStore(database)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "narrative:payment", Value: "pinned", ProvidedBy: "ada"})
+	time.Sleep(time.Millisecond)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "narrative", Value: "Written by hand.", ProvidedBy: "grace"})
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "exclude", Value: "no"})
+
+	got, err := Load(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Section != "payment" || !got[0].Pinned || got[1].Text != "Written by hand." {
+		t.Errorf("Load = %+v, want both edits, oldest first", got)
+	}
+}
@@ -17,10 +17,17 @@ type Digest struct {
 	Summary       string         `json:"summary"`
 }
 
+// TagSource looks up repository tags for routing rules that match on them.
+type TagSource interface {
+	AllTags(ctx context.Context) (map[string][]string, error)
+}
+
 // Dispatcher creates notifications and delivers them to webhook subscribers.
 type Dispatcher struct {
-	store  *Store
-	client *http.Client
+	store     *Store
+	client    *http.Client
+	tags      TagSource
+	templates *Templates
 }
 
 // NewDispatcher creates a Dispatcher backed by the given store.
@@ -33,14 +40,43 @@ func NewDispatcher(store *Store) *Dispatcher {
 	}
 }
 
-// Dispatch persists a notification and sends it to matching webhook subscribers.
+// SetTemplates sets the message templates used for Slack text and for the
+// "text" field of webhook payloads. Without templates, built-in English
+// messages are used.
+func (d *Dispatcher) SetTemplates(t *Templates) {
+	d.templates = t
+}
+
+// SetTagSource sets where repository tags for routing rules come from.
+func (d *Dispatcher) SetTagSource(src TagSource) {
+	d.tags = src
+}
+
+// Dispatch persists a notification and sends it to matching webhook
+// subscribers and routing rule destinations. Each URL receives the
+// notification at most once. Notifications silenced by an active mute are
+// recorded but not delivered.
 func (d *Dispatcher) Dispatch(ctx context.Context, n Notification) error {
+	mutes, err := d.store.ListMutes(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("loading mutes: %w", err)
+	}
+	muted := applyMutes(mutes, n, time.Now().UTC())
+	n.SuppressedBy = muted.SuppressedBy
+
 	if err := d.store.Create(ctx, n); err != nil {
 		return fmt.Errorf("creating notification: %w", err)
 	}
+	if n.SuppressedBy != "" {
+		return nil
+	}
+	sent := make(map[string]bool)
 
 	// Deliver to webhook subscribers for each affected team.
 	for _, teamID := range n.AffectedTeams {
+		if muted.MutedTeams[teamID] {
+			continue
+		}
 		prefs, err := d.store.GetPreferences(ctx, teamID)
 		if err != nil {
 			continue
@@ -49,23 +85,78 @@ func (d *Dispatcher) Dispatch(ctx context.Context, n Notification) error {
 			if pref.WebhookURL == "" {
 				continue
 			}
-			if !severityMatches(n.Severity, pref.SeverityFilter) {
+			if !severityMatches(n.Severity, pref.SeverityFilter) || sent[pref.WebhookURL] {
 				continue
 			}
-			payload, err := json.Marshal(n)
+			payload, err := d.Payload(Destination{Channel: pref.Channel, URL: pref.WebhookURL}, n)
 			if err != nil {
 				continue
 			}
+			sent[pref.WebhookURL] = true
 			_ = d.SendWebhook(ctx, pref.WebhookURL, payload)
 		}
 	}
 
+	// Deliver to the destinations of matching routing rules.
+	results, err := d.Explain(ctx, n)
+	if err != nil {
+		return err
+	}
+	for _, dest := range MatchedDestinations(results) {
+		if sent[dest.URL] {
+			continue
+		}
+		payload, err := d.Payload(dest, n)
+		if err != nil {
+			continue
+		}
+		sent[dest.URL] = true
+		_ = d.SendWebhook(ctx, dest.URL, payload)
+	}
+
 	return nil
 }
 
+// Explain evaluates the routing rules against n without delivering it.
+func (d *Dispatcher) Explain(ctx context.Context, n Notification) ([]RouteResult, error) {
+	rules, err := d.store.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading routing rules: %w", err)
+	}
+	var tags map[string][]string
+	if d.tags != nil {
+		if tags, err = d.tags.AllTags(ctx); err != nil {
+			return nil, fmt.Errorf("loading repository tags: %w", err)
+		}
+	}
+	return Route(rules, n, tags), nil
+}
+
+// Payload renders n in the format the destination expects: a Slack message,
+// or the notification as JSON, with a "text" field when a webhook template is
+// configured. A template that fails to render falls back to the built-in
+// message so the notification is still delivered.
+func (d *Dispatcher) Payload(dest Destination, n Notification) ([]byte, error) {
+	text, ok, err := d.templates.Render(dest.Channel, dest.Locale, n)
+	if err != nil {
+		text, ok, _ = (*Templates)(nil).Render(dest.Channel, "", n)
+	}
+	if dest.Channel == ChannelSlack {
+		return json.Marshal(map[string]string{"text": text})
+	}
+	if ok {
+		return json.Marshal(struct {
+			Notification
+			Text string `json:"text"`
+		}{n, text})
+	}
+	return json.Marshal(n)
+}
+
 // GenerateDigest builds a summary of notifications for a team since the given time.
 func (d *Dispatcher) GenerateDigest(ctx context.Context, teamID string, since time.Time) (*Digest, error) {
-	all, err := d.store.List(ctx, ListFilter{Since: since})
+	suppressed := false
+	all, err := d.store.List(ctx, ListFilter{Since: since, Suppressed: &suppressed})
 	if err != nil {
 		return nil, fmt.Errorf("listing notifications for digest: %w", err)
 	}
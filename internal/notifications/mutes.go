@@ -0,0 +1,231 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MuteScope says what a mute window applies to.
+type MuteScope string
+
+const (
+	MuteGlobal  MuteScope = "global"
+	MuteTeam    MuteScope = "team"
+	MuteService MuteScope = "service"
+)
+
+// Mute silences notifications for a window of time. Muted notifications are
+// still recorded, with SuppressedBy set, but are not delivered.
+type Mute struct {
+	ID       string             `json:"id"`
+	Scope    MuteScope          `json:"scope"`
+	Target   string             `json:"target,omitempty"` // team or service name; empty for global
+	Types    []NotificationType `json:"types,omitempty"`  // empty mutes every type
+	StartsAt time.Time          `json:"starts_at"`
+	EndsAt   time.Time          `json:"ends_at,omitempty"` // zero means until removed
+	Reason   string             `json:"reason,omitempty"`
+}
+
+// Validate checks the mute's scope, target, and window.
+func (m Mute) Validate() error {
+	switch m.Scope {
+	case MuteGlobal:
+		if m.Target != "" {
+			return fmt.Errorf("a global mute has no target")
+		}
+	case MuteTeam, MuteService:
+		if m.Target == "" {
+			return fmt.Errorf("a %s mute needs a target", m.Scope)
+		}
+	default:
+		return fmt.Errorf("unknown mute scope %q", m.Scope)
+	}
+	if !m.EndsAt.IsZero() && !m.EndsAt.After(m.StartsAt) {
+		return fmt.Errorf("mute must end after it starts")
+	}
+	return nil
+}
+
+// Active reports whether the mute window covers t.
+func (m Mute) Active(t time.Time) bool {
+	return !t.Before(m.StartsAt) && (m.EndsAt.IsZero() || t.Before(m.EndsAt))
+}
+
+// coversType reports whether the mute applies to notifications of type t.
+func (m Mute) coversType(t NotificationType) bool {
+	return len(m.Types) == 0 || containsType(m.Types, t)
+}
+
+// muteDecision is how the active mutes apply to one notification.
+type muteDecision struct {
+	// SuppressedBy is the ID of the mute that silences the notification
+	// entirely, or empty.
+	SuppressedBy string
+	// MutedTeams are teams whose own deliveries are silenced.
+	MutedTeams map[string]bool
+}
+
+// applyMutes decides how mutes apply to n. A global mute silences it; team
+// mutes silence delivery to those teams, and the whole notification once
+// every affected team is muted; service mutes silence it once every affected
+// service is muted.
+func applyMutes(mutes []Mute, n Notification, now time.Time) muteDecision {
+	d := muteDecision{MutedTeams: make(map[string]bool)}
+	mutedServices := make(map[string]string)
+	lastTeamMute := ""
+	for _, m := range mutes {
+		if !m.Active(now) || !m.coversType(n.Type) {
+			continue
+		}
+		switch m.Scope {
+		case MuteGlobal:
+			d.SuppressedBy = m.ID
+			return d
+		case MuteTeam:
+			d.MutedTeams[m.Target] = true
+			lastTeamMute = m.ID
+		case MuteService:
+			mutedServices[m.Target] = m.ID
+		}
+	}
+
+	if len(n.AffectedTeams) > 0 && allIn(n.AffectedTeams, d.MutedTeams) {
+		d.SuppressedBy = lastTeamMute
+		return d
+	}
+	if len(n.AffectedServices) > 0 {
+		last := ""
+		for _, svc := range n.AffectedServices {
+			id, ok := mutedServices[svc]
+			if !ok {
+				return d
+			}
+			last = id
+		}
+		d.SuppressedBy = last
+	}
+	return d
+}
+
+func allIn(values []string, set map[string]bool) bool {
+	for _, v := range values {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateMute stores a mute window. A zero StartsAt starts it now.
+func (s *Store) CreateMute(ctx context.Context, m *Mute) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	if m.StartsAt.IsZero() {
+		m.StartsAt = time.Now().UTC()
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	types, err := json.Marshal(m.Types)
+	if err != nil {
+		return fmt.Errorf("marshalling mute types: %w", err)
+	}
+	var endsAt sql.NullString
+	if !m.EndsAt.IsZero() {
+		endsAt = sql.NullString{String: m.EndsAt.UTC().Format(time.DateTime), Valid: true}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO notification_mutes (id, scope, target, types, starts_at, ends_at, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		m.ID, string(m.Scope), m.Target, string(types),
+		m.StartsAt.UTC().Format(time.DateTime), endsAt, m.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting mute: %w", err)
+	}
+	return nil
+}
+
+// ListMutes returns mute windows that have not yet ended at now, or every
+// mute when now is zero.
+func (s *Store) ListMutes(ctx context.Context, now time.Time) ([]Mute, error) {
+	query := "SELECT id, scope, target, types, starts_at, ends_at, reason FROM notification_mutes"
+	var args []any
+	if !now.IsZero() {
+		query += " WHERE ends_at IS NULL OR ends_at > ?"
+		args = append(args, now.UTC().Format(time.DateTime))
+	}
+	query += " ORDER BY starts_at"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying mutes: %w", err)
+	}
+	defer rows.Close()
+
+	var mutes []Mute
+	for rows.Next() {
+		var (
+			m                Mute
+			scope, typesJSON string
+			startsAt         string
+			endsAt           sql.NullString
+		)
+		if err := rows.Scan(&m.ID, &scope, &m.Target, &typesJSON, &startsAt, &endsAt, &m.Reason); err != nil {
+			return nil, fmt.Errorf("scanning mute: %w", err)
+		}
+		m.Scope = MuteScope(scope)
+		if err := json.Unmarshal([]byte(typesJSON), &m.Types); err != nil {
+			m.Types = nil
+		}
+		m.StartsAt = parseDBTime(startsAt)
+		if endsAt.Valid {
+			m.EndsAt = parseDBTime(endsAt.String)
+		}
+		mutes = append(mutes, m)
+	}
+	return mutes, rows.Err()
+}
+
+// DeleteMute removes a mute window, ending it immediately.
+func (s *Store) DeleteMute(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM notification_mutes WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("deleting mute: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("mute %s not found", id)
+	}
+	return nil
+}
+
+// SuppressDuring mutes all notifications while a bulk operation such as a
+// full re-index runs, so it doesn't set off a notification storm. The
+// returned function lifts the mute. The mute expires on its own after
+// maxDuration in case the process dies before lifting it.
+func (s *Store) SuppressDuring(ctx context.Context, reason string, maxDuration time.Duration) (func(), error) {
+	now := time.Now().UTC()
+	m := &Mute{Scope: MuteGlobal, StartsAt: now, EndsAt: now.Add(maxDuration), Reason: reason}
+	if err := s.CreateMute(ctx, m); err != nil {
+		return nil, err
+	}
+	return func() { s.DeleteMute(context.Background(), m.ID) }, nil
+}
+
+// parseDBTime parses a DATETIME value written by SQLite or by this package.
+func parseDBTime(v string) time.Time {
+	for _, layout := range []string{time.DateTime, time.RFC3339Nano, "2006-01-02T15:04:05Z"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -615,3 +615,382 @@ func TestSeverityMatches(t *testing.T) {
 		}
 	}
 }
+
+type staticTags map[string][]string
+
+func (s staticTags) AllTags(context.Context) (map[string][]string, error) { return s, nil }
+
+func TestRuleEvaluate(t *testing.T) {
+	tags := map[string][]string{"payments-api": {"pci", "tier-1"}}
+	n := Notification{
+		Type:             TypeRelationshipChanged,
+		Severity:         SeverityWarning,
+		AffectedServices: []string{"payments-api"},
+		AffectedTeams:    []string{"payments"},
+		LinkType:         "kafka",
+	}
+
+	tests := []struct {
+		name  string
+		match RuleMatch
+		want  bool
+	}{
+		{"empty matches all", RuleMatch{}, true},
+		{"type", RuleMatch{Types: []NotificationType{TypeRelationshipChanged}}, true},
+		{"wrong type", RuleMatch{Types: []NotificationType{TypeDocUpdated}}, false},
+		{"min severity met", RuleMatch{MinSeverity: SeverityWarning}, true},
+		{"min severity not met", RuleMatch{MinSeverity: SeverityCritical}, false},
+		{"service glob", RuleMatch{Services: []string{"payments-*"}}, true},
+		{"service glob miss", RuleMatch{Services: []string{"orders-*"}}, false},
+		{"link type", RuleMatch{LinkTypes: []string{"KAFKA"}}, true},
+		{"link type miss", RuleMatch{LinkTypes: []string{"grpc"}}, false},
+		{"repo tag", RuleMatch{RepoTags: []string{"pci"}}, true},
+		{"repo tag miss", RuleMatch{RepoTags: []string{"internal"}}, false},
+		{"team", RuleMatch{Teams: []string{"payments"}}, true},
+		{"all conditions", RuleMatch{Types: []NotificationType{TypeRelationshipChanged}, Services: []string{"payments-*"}, RepoTags: []string{"tier-1"}}, true},
+		{"one condition fails", RuleMatch{Services: []string{"payments-*"}, LinkTypes: []string{"http"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := Rule{Name: tt.name, Match: tt.match}.Evaluate(n, tags)
+			if got != tt.want {
+				t.Errorf("Evaluate = %v (%s), want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteStopAndDisabled(t *testing.T) {
+	dest := func(u string) []Destination { return []Destination{{Channel: ChannelWebhook, URL: u}} }
+	rules := []Rule{
+		{Name: "catch-all", Priority: 10, Destinations: dest("https://example.com/all"), Enabled: true},
+		{Name: "pci", Priority: 1, Match: RuleMatch{RepoTags: []string{"pci"}}, Destinations: dest("https://example.com/sec"), Stop: true, Enabled: true},
+		{Name: "off", Priority: 0, Destinations: dest("https://example.com/off")},
+	}
+	n := Notification{Type: TypeDocUpdated, Severity: SeverityInfo, AffectedServices: []string{"payments-api"}}
+
+	results := Route(rules, n, map[string][]string{"payments-api": {"pci"}})
+	if len(results) != 3 || results[0].Rule.Name != "off" || results[1].Rule.Name != "pci" {
+		t.Fatalf("rules not evaluated in priority order: %+v", results)
+	}
+	if results[0].Matched || results[2].Matched || !results[1].Matched {
+		t.Errorf("unexpected matches: %+v", results)
+	}
+	dests := MatchedDestinations(results)
+	if len(dests) != 1 || dests[0].URL != "https://example.com/sec" {
+		t.Errorf("destinations = %+v", dests)
+	}
+
+	// Without the tag, the stop rule misses and the catch-all applies.
+	results = Route(rules, n, nil)
+	if dests := MatchedDestinations(results); len(dests) != 1 || dests[0].URL != "https://example.com/all" {
+		t.Errorf("destinations without tag = %+v", dests)
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	ok := Rule{Name: "r", Destinations: []Destination{{Channel: ChannelSlack, URL: "https://hooks.slack.com/x"}}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid rule: %v", err)
+	}
+	bad := []Rule{
+		{Destinations: ok.Destinations},
+		{Name: "r"},
+		{Name: "r", Destinations: []Destination{{Channel: "pager", URL: "https://x"}}},
+		{Name: "r", Destinations: []Destination{{Channel: ChannelWebhook, URL: "ftp://x"}}},
+		{Name: "r", Match: RuleMatch{Services: []string{"["}}, Destinations: ok.Destinations},
+		{Name: "r", Match: RuleMatch{MinSeverity: "urgent"}, Destinations: ok.Destinations},
+	}
+	for i, r := range bad {
+		if err := r.Validate(); err == nil {
+			t.Errorf("rule %d: expected validation error", i)
+		}
+	}
+}
+
+func TestRuleCRUD(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	r := Rule{
+		Name:         "payments-critical",
+		Priority:     5,
+		Match:        RuleMatch{MinSeverity: SeverityCritical, Services: []string{"payments-*"}},
+		Destinations: []Destination{{Channel: ChannelWebhook, URL: "https://example.com/a"}, {Channel: ChannelSlack, URL: "https://example.com/b"}},
+		Enabled:      true,
+	}
+	if err := store.SaveRule(ctx, &r); err != nil {
+		t.Fatalf("SaveRule: %v", err)
+	}
+	// Saving under the same name replaces the rule.
+	r.Priority = 1
+	if err := store.SaveRule(ctx, &r); err != nil {
+		t.Fatalf("SaveRule update: %v", err)
+	}
+
+	rules, err := store.ListRules(ctx)
+	if err != nil {
+		t.Fatalf("ListRules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Priority != 1 || len(rules[0].Destinations) != 2 || rules[0].Match.Services[0] != "payments-*" {
+		t.Fatalf("ListRules = %+v", rules)
+	}
+
+	if err := store.DeleteRule(ctx, "payments-critical"); err != nil {
+		t.Fatalf("DeleteRule: %v", err)
+	}
+	if err := store.DeleteRule(ctx, "payments-critical"); err == nil {
+		t.Error("expected error deleting missing rule")
+	}
+}
+
+func TestDispatcherRoutingRules(t *testing.T) {
+	store := setupTestStore(t)
+	dispatcher := NewDispatcher(store)
+	dispatcher.SetTagSource(staticTags{"payments-api": {"pci"}})
+	ctx := context.Background()
+
+	hits := make(map[string]int)
+	var slackBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		if r.URL.Path == "/slack" {
+			buf := new(bytes.Buffer)
+			buf.ReadFrom(r.Body)
+			slackBody = buf.Bytes()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	for _, r := range []Rule{
+		{Name: "pci", Match: RuleMatch{RepoTags: []string{"pci"}}, Enabled: true, Destinations: []Destination{
+			{Channel: ChannelWebhook, URL: server.URL + "/security"},
+			{Channel: ChannelSlack, URL: server.URL + "/slack"},
+		}},
+		{Name: "orders", Match: RuleMatch{Services: []string{"orders-*"}}, Enabled: true, Destinations: []Destination{
+			{Channel: ChannelWebhook, URL: server.URL + "/orders"},
+		}},
+		{Name: "dup", Enabled: true, Destinations: []Destination{{Channel: ChannelWebhook, URL: server.URL + "/security"}}},
+	} {
+		if err := store.SaveRule(ctx, &r); err != nil {
+			t.Fatalf("SaveRule: %v", err)
+		}
+	}
+
+	if err := dispatcher.Dispatch(ctx, testNotification("route-1")); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if hits["/security"] != 1 || hits["/slack"] != 1 || hits["/orders"] != 0 {
+		t.Errorf("hits = %v", hits)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal(slackBody, &msg); err != nil || msg["text"] == "" {
+		t.Errorf("slack payload = %s", slackBody)
+	}
+}
+
+func TestMuteCRUD(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	active := Mute{Scope: MuteTeam, Target: "payments", Types: []NotificationType{TypeStalenessDetected}, EndsAt: now.Add(time.Hour), Reason: "re-platforming"}
+	if err := store.CreateMute(ctx, &active); err != nil {
+		t.Fatalf("CreateMute: %v", err)
+	}
+	expired := Mute{Scope: MuteGlobal, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)}
+	if err := store.CreateMute(ctx, &expired); err != nil {
+		t.Fatalf("CreateMute: %v", err)
+	}
+	if err := store.CreateMute(ctx, &Mute{Scope: MuteService}); err == nil {
+		t.Error("expected error for service mute without a target")
+	}
+
+	mutes, err := store.ListMutes(ctx, now)
+	if err != nil {
+		t.Fatalf("ListMutes: %v", err)
+	}
+	if len(mutes) != 1 || mutes[0].ID != active.ID || mutes[0].Types[0] != TypeStalenessDetected || !mutes[0].Active(now) {
+		t.Fatalf("ListMutes = %+v", mutes)
+	}
+	if all, _ := store.ListMutes(ctx, time.Time{}); len(all) != 2 {
+		t.Errorf("ListMutes(all) = %d, want 2", len(all))
+	}
+
+	if err := store.DeleteMute(ctx, active.ID); err != nil {
+		t.Fatalf("DeleteMute: %v", err)
+	}
+	if err := store.DeleteMute(ctx, active.ID); err == nil {
+		t.Error("expected error deleting missing mute")
+	}
+}
+
+func TestApplyMutes(t *testing.T) {
+	now := time.Now().UTC()
+	window := func(m Mute) Mute {
+		m.StartsAt, m.EndsAt = now.Add(-time.Hour), now.Add(time.Hour)
+		return m
+	}
+	n := Notification{
+		Type:             TypeStalenessDetected,
+		AffectedServices: []string{"billing", "ledger"},
+		AffectedTeams:    []string{"payments", "finance"},
+	}
+
+	tests := []struct {
+		name       string
+		mutes      []Mute
+		suppressed bool
+	}{
+		{"global", []Mute{window(Mute{ID: "g", Scope: MuteGlobal})}, true},
+		{"global other type", []Mute{window(Mute{ID: "g", Scope: MuteGlobal, Types: []NotificationType{TypeDocUpdated}})}, false},
+		{"global expired", []Mute{{ID: "g", Scope: MuteGlobal, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)}}, false},
+		{"global upcoming", []Mute{{ID: "g", Scope: MuteGlobal, StartsAt: now.Add(time.Hour)}}, false},
+		{"one of two teams", []Mute{window(Mute{ID: "t", Scope: MuteTeam, Target: "payments"})}, false},
+		{"every team", []Mute{window(Mute{ID: "t1", Scope: MuteTeam, Target: "payments"}), window(Mute{ID: "t2", Scope: MuteTeam, Target: "finance"})}, true},
+		{"one of two services", []Mute{window(Mute{ID: "s", Scope: MuteService, Target: "billing"})}, false},
+		{"every service", []Mute{window(Mute{ID: "s1", Scope: MuteService, Target: "billing"}), window(Mute{ID: "s2", Scope: MuteService, Target: "ledger"})}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := applyMutes(tt.mutes, n, now)
+			if (d.SuppressedBy != "") != tt.suppressed {
+				t.Errorf("SuppressedBy = %q, want suppressed=%v", d.SuppressedBy, tt.suppressed)
+			}
+		})
+	}
+
+	if d := applyMutes([]Mute{window(Mute{ID: "t", Scope: MuteTeam, Target: "payments"})}, n, now); !d.MutedTeams["payments"] || d.MutedTeams["finance"] {
+		t.Errorf("MutedTeams = %v", d.MutedTeams)
+	}
+}
+
+func TestDispatcherMutes(t *testing.T) {
+	store := setupTestStore(t)
+	dispatcher := NewDispatcher(store)
+	ctx := context.Background()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := store.SetPreference(ctx, Preference{TeamID: "platform", Channel: "webhook", SeverityFilter: SeverityInfo, DigestFrequency: FreqRealtime, WebhookURL: server.URL}); err != nil {
+		t.Fatalf("SetPreference: %v", err)
+	}
+
+	unmute, err := store.SuppressDuring(ctx, "bulk re-index", time.Hour)
+	if err != nil {
+		t.Fatalf("SuppressDuring: %v", err)
+	}
+	if err := dispatcher.Dispatch(ctx, testNotification("muted-1")); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("webhook called %d times during suppression", calls)
+	}
+	stored, err := store.GetByID(ctx, "muted-1")
+	if err != nil || stored.SuppressedBy == "" {
+		t.Fatalf("muted notification = %+v, %v", stored, err)
+	}
+	if pending, _ := store.GetPending(ctx); len(pending) != 0 {
+		t.Errorf("muted notification should not be pending: %+v", pending)
+	}
+
+	unmute()
+	if err := dispatcher.Dispatch(ctx, testNotification("live-1")); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("webhook called %d times after unmute, want 1", calls)
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	n := Notification{
+		Type:             TypeServiceAdded,
+		Severity:         SeverityCritical,
+		Title:            "fraud-service registered",
+		Message:          "New service",
+		AffectedServices: []string{"fraud-service", "orders"},
+	}
+
+	// Without templates the built-in English Slack message is unchanged.
+	text, ok, err := (*Templates)(nil).Render(ChannelSlack, "", n)
+	if err != nil || !ok || text != "*[critical] fraud-service registered*\nNew service" {
+		t.Fatalf("default render = %q, %v, %v", text, ok, err)
+	}
+	if _, ok, _ := (*Templates)(nil).Render(ChannelWebhook, "", n); ok {
+		t.Error("webhooks have no default text")
+	}
+
+	tmpls, err := NewTemplates("de", []TemplateDef{
+		{Channel: ChannelSlack, Text: `{{severity .Severity}}: {{.Title}}`},
+		{Channel: ChannelSlack, Type: TypeServiceAdded, Locale: "fr", Text: `{{typeName .Type}} — {{join .AffectedServices ", "}}`},
+		{Channel: ChannelWebhook, Locale: "en", Text: `{{t "services"}}: {{len .AffectedServices}}`},
+	}, map[string]map[string]string{"de": {"critical": "Dringend"}})
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+
+	tests := []struct {
+		channel, locale string
+		want            string
+	}{
+		{ChannelSlack, "", "Dringend: fraud-service registered"},          // default locale, config override
+		{ChannelSlack, "fr_CA", "service ajouté — fraud-service, orders"}, // regional locale falls back to language
+		{ChannelSlack, "es", "crítico: fraud-service registered"},         // any-locale template, built-in label
+		{ChannelWebhook, "en-GB", "Services: 2"},                          // t function
+	}
+	for _, tt := range tests {
+		got, ok, err := tmpls.Render(tt.channel, tt.locale, n)
+		if err != nil || !ok || got != tt.want {
+			t.Errorf("Render(%s, %q) = %q, %v, %v; want %q", tt.channel, tt.locale, got, ok, err, tt.want)
+		}
+	}
+	if _, ok, _ := tmpls.Render(ChannelWebhook, "de", n); ok {
+		t.Error("webhook template for en should not apply to de")
+	}
+
+	if _, err := NewTemplates("", []TemplateDef{{Channel: ChannelSlack, Text: "{{.Title"}}, nil); err == nil {
+		t.Error("expected a parse error")
+	}
+	if _, err := NewTemplates("", []TemplateDef{{Text: "x"}}, nil); err == nil {
+		t.Error("expected an error for a template without a channel")
+	}
+}
+
+func TestDispatcherPayloadTemplates(t *testing.T) {
+	d := NewDispatcher(nil)
+	tmpls, err := NewTemplates("", []TemplateDef{
+		{Channel: ChannelSlack, Text: "{{.Missing}}"},
+		{Channel: ChannelWebhook, Text: "{{upper .Title}}"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetTemplates(tmpls)
+	n := Notification{Severity: SeverityWarning, Title: "docs stale"}
+
+	// A template that fails to render falls back to the built-in message.
+	payload, err := d.Payload(Destination{Channel: ChannelSlack}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slack map[string]string
+	if err := json.Unmarshal(payload, &slack); err != nil || slack["text"] != "*[warning] docs stale*" {
+		t.Errorf("slack payload = %s", payload)
+	}
+
+	payload, err = d.Payload(Destination{Channel: ChannelWebhook}, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hook map[string]any
+	if err := json.Unmarshal(payload, &hook); err != nil || hook["text"] != "DOCS STALE" || hook["title"] != "docs stale" {
+		t.Errorf("webhook payload = %s", payload)
+	}
+}
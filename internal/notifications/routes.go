@@ -17,6 +17,13 @@ func RegisterRoutes(r chi.Router, store *Store, dispatcher *Dispatcher) {
 		r.Get("/digest/{teamID}", handleDigest(dispatcher))
 		r.Get("/preferences/{teamID}", handleGetPreferences(store))
 		r.Put("/preferences", handleSetPreference(store))
+		r.Get("/rules", handleListRules(store))
+		r.Put("/rules", handleSaveRule(store))
+		r.Delete("/rules/{name}", handleDeleteRule(store))
+		r.Post("/rules/test", handleTestRules(dispatcher))
+		r.Get("/mutes", handleListMutes(store))
+		r.Post("/mutes", handleCreateMute(store))
+		r.Delete("/mutes/{id}", handleDeleteMute(store))
 		r.Get("/{id}", handleGetByID(store))
 		r.Post("/{id}/deliver", handleMarkDelivered(store))
 	})
@@ -40,6 +47,12 @@ func handleList(store *Store) http.HandlerFunc {
 				filter.Delivered = &b
 			}
 		}
+		if v := q.Get("suppressed"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				filter.Suppressed = &b
+			}
+		}
 		if v := q.Get("since"); v != "" {
 			if t, err := time.Parse(time.RFC3339, v); err == nil {
 				filter.Since = t
@@ -162,6 +175,129 @@ func handleDigest(dispatcher *Dispatcher) http.HandlerFunc {
 	}
 }
 
+func handleListRules(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := store.ListRules(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, rules)
+	}
+}
+
+func handleSaveRule(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule := Rule{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := rule.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := store.SaveRule(r.Context(), &rule); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, rule)
+	}
+}
+
+func handleDeleteRule(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		if err := store.DeleteRule(r.Context(), name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	}
+}
+
+// handleTestRules shows which rules a sample notification would hit,
+// without storing or delivering it.
+func handleTestRules(dispatcher *Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		results, err := dispatcher.Explain(r.Context(), n)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"results":      results,
+			"destinations": MatchedDestinations(results),
+		})
+	}
+}
+
+func handleListMutes(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		if all, _ := strconv.ParseBool(r.URL.Query().Get("all")); all {
+			now = time.Time{}
+		}
+
+		mutes, err := store.ListMutes(r.Context(), now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, mutes)
+	}
+}
+
+func handleCreateMute(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var m Mute
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if m.StartsAt.IsZero() {
+			m.StartsAt = time.Now().UTC()
+		}
+
+		if err := m.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := store.CreateMute(r.Context(), &m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, m)
+	}
+}
+
+func handleDeleteMute(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		if err := store.DeleteMute(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,311 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Destination channels a routing rule can deliver to.
+const (
+	ChannelWebhook = "webhook" // POSTs the notification as JSON
+	ChannelSlack   = "slack"   // posts a text message to a Slack incoming webhook
+)
+
+// Destination is one place a routed notification is delivered.
+type Destination struct {
+	Channel string `json:"channel"`
+	URL     string `json:"url"`
+	Locale  string `json:"locale,omitempty"` // message language, e.g. "de"; defaults to the configured locale
+}
+
+// RuleMatch holds a rule's conditions. Every non-empty field must match, and
+// a field matches when any of its values does; an empty RuleMatch matches
+// every notification.
+type RuleMatch struct {
+	Types       []NotificationType `json:"types,omitempty"`
+	MinSeverity Severity           `json:"min_severity,omitempty"`
+	Services    []string           `json:"services,omitempty"` // glob patterns, e.g. "payments-*"
+	LinkTypes   []string           `json:"link_types,omitempty"`
+	RepoTags    []string           `json:"repo_tags,omitempty"` // tags on any affected service
+	Teams       []string           `json:"teams,omitempty"`
+}
+
+// Rule routes matching notifications to one or more destinations. Rules are
+// evaluated by ascending priority; a matching rule with Stop set ends
+// evaluation.
+type Rule struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Priority     int           `json:"priority"`
+	Match        RuleMatch     `json:"match"`
+	Destinations []Destination `json:"destinations"`
+	Stop         bool          `json:"stop"`
+	Enabled      bool          `json:"enabled"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// Validate checks the rule's conditions and destinations.
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.Match.MinSeverity != "" && !validSeverity(r.Match.MinSeverity) {
+		return fmt.Errorf("rule %s: unknown severity %q", r.Name, r.Match.MinSeverity)
+	}
+	for _, pattern := range r.Match.Services {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("rule %s: bad service pattern %q: %w", r.Name, pattern, err)
+		}
+	}
+	if len(r.Destinations) == 0 {
+		return fmt.Errorf("rule %s: at least one destination is required", r.Name)
+	}
+	for _, d := range r.Destinations {
+		if d.Channel != ChannelWebhook && d.Channel != ChannelSlack {
+			return fmt.Errorf("rule %s: unknown channel %q", r.Name, d.Channel)
+		}
+		u, err := url.Parse(d.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("rule %s: destination URL %q must be http(s)", r.Name, d.URL)
+		}
+	}
+	return nil
+}
+
+// Evaluate reports whether the rule matches n and why. tags maps service
+// names to their repository tags.
+func (r Rule) Evaluate(n Notification, tags map[string][]string) (bool, string) {
+	m := r.Match
+	if len(m.Types) > 0 && !containsType(m.Types, n.Type) {
+		return false, fmt.Sprintf("type %s is not one of %s", n.Type, joinTypes(m.Types))
+	}
+	if m.MinSeverity != "" && !severityMatches(n.Severity, m.MinSeverity) {
+		return false, fmt.Sprintf("severity %s is below %s", n.Severity, m.MinSeverity)
+	}
+	if len(m.Services) > 0 && !anyServiceMatches(m.Services, n.AffectedServices) {
+		return false, fmt.Sprintf("no affected service matches %s", strings.Join(m.Services, ", "))
+	}
+	if len(m.LinkTypes) > 0 && !containsFold(m.LinkTypes, n.LinkType) {
+		return false, fmt.Sprintf("link type %q is not one of %s", n.LinkType, strings.Join(m.LinkTypes, ", "))
+	}
+	if len(m.RepoTags) > 0 && !anyTagMatches(m.RepoTags, n.AffectedServices, tags) {
+		return false, fmt.Sprintf("no affected service is tagged %s", strings.Join(m.RepoTags, ", "))
+	}
+	if len(m.Teams) > 0 && !intersects(m.Teams, n.AffectedTeams) {
+		return false, fmt.Sprintf("no affected team is one of %s", strings.Join(m.Teams, ", "))
+	}
+	return true, "all conditions match"
+}
+
+// RouteResult is the outcome of evaluating one rule against a notification.
+type RouteResult struct {
+	Rule    Rule   `json:"rule"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+}
+
+// Route evaluates rules in priority order against n.
+func Route(rules []Rule, n Notification, tags map[string][]string) []RouteResult {
+	sortRules(rules)
+	results := make([]RouteResult, 0, len(rules))
+	stoppedBy := ""
+	for _, r := range rules {
+		res := RouteResult{Rule: r}
+		switch {
+		case !r.Enabled:
+			res.Reason = "rule is disabled"
+		case stoppedBy != "":
+			res.Reason = fmt.Sprintf("skipped: rule %s stops routing", stoppedBy)
+		default:
+			res.Matched, res.Reason = r.Evaluate(n, tags)
+			if res.Matched && r.Stop {
+				stoppedBy = r.Name
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// MatchedDestinations returns the distinct destinations of the matched rules.
+func MatchedDestinations(results []RouteResult) []Destination {
+	seen := make(map[Destination]bool)
+	var dests []Destination
+	for _, res := range results {
+		if !res.Matched {
+			continue
+		}
+		for _, d := range res.Rule.Destinations {
+			if !seen[d] {
+				seen[d] = true
+				dests = append(dests, d)
+			}
+		}
+	}
+	return dests
+}
+
+// SaveRule creates a rule, or replaces the rule with the same name.
+func (s *Store) SaveRule(ctx context.Context, r *Rule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	match, err := json.Marshal(r.Match)
+	if err != nil {
+		return fmt.Errorf("marshalling rule match: %w", err)
+	}
+	dests, err := json.Marshal(r.Destinations)
+	if err != nil {
+		return fmt.Errorf("marshalling rule destinations: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO notification_rules (id, name, priority, match, destinations, stop, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			priority = excluded.priority,
+			match = excluded.match,
+			destinations = excluded.destinations,
+			stop = excluded.stop,
+			enabled = excluded.enabled`,
+		r.ID, r.Name, r.Priority, string(match), string(dests), boolInt(r.Stop), boolInt(r.Enabled),
+	)
+	if err != nil {
+		return fmt.Errorf("saving rule: %w", err)
+	}
+	return nil
+}
+
+// ListRules returns all routing rules in evaluation order.
+func (s *Store) ListRules(ctx context.Context) ([]Rule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, priority, match, destinations, stop, enabled, created_at
+		FROM notification_rules ORDER BY priority, name`)
+	if err != nil {
+		return nil, fmt.Errorf("querying rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var (
+			r                Rule
+			matchJSON, dests string
+			stop, enabled    int
+		)
+		if err := rows.Scan(&r.ID, &r.Name, &r.Priority, &matchJSON, &dests, &stop, &enabled, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning rule: %w", err)
+		}
+		if err := json.Unmarshal([]byte(matchJSON), &r.Match); err != nil {
+			return nil, fmt.Errorf("rule %s: decoding match: %w", r.Name, err)
+		}
+		if err := json.Unmarshal([]byte(dests), &r.Destinations); err != nil {
+			return nil, fmt.Errorf("rule %s: decoding destinations: %w", r.Name, err)
+		}
+		r.Stop = stop != 0
+		r.Enabled = enabled != 0
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// DeleteRule removes the rule with the given name.
+func (s *Store) DeleteRule(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM notification_rules WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("deleting rule: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("rule %s not found", name)
+	}
+	return nil
+}
+
+func sortRules(rules []Rule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].Name < rules[j].Name
+	})
+}
+
+func validSeverity(s Severity) bool {
+	return s == SeverityInfo || s == SeverityWarning || s == SeverityCritical
+}
+
+func containsType(types []NotificationType, t NotificationType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func joinTypes(types []NotificationType) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return strings.Join(s, ", ")
+}
+
+func containsFold(values []string, v string) bool {
+	for _, x := range values {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyServiceMatches(patterns, services []string) bool {
+	for _, p := range patterns {
+		for _, svc := range services {
+			if ok, _ := path.Match(p, svc); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyTagMatches(want, services []string, tags map[string][]string) bool {
+	for _, svc := range services {
+		if intersects(want, tags[svc]) {
+			return true
+		}
+	}
+	return false
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -16,7 +16,8 @@ import (
 type ListFilter struct {
 	Type      NotificationType
 	Severity  Severity
-	Delivered *bool
+	Delivered  *bool
+	Suppressed *bool
 	Since     time.Time
 	Until     time.Time
 	Limit     int
@@ -54,10 +55,10 @@ func (s *Store) Create(ctx context.Context, n Notification) error {
 	}
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO notifications (id, type, severity, title, message, affected_services, affected_teams, delivered)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO notifications (id, type, severity, title, message, affected_services, affected_teams, link_type, delivered, suppressed_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		n.ID, string(n.Type), string(n.Severity), n.Title, n.Message,
-		string(services), string(teams), delivered,
+		string(services), string(teams), n.LinkType, delivered, n.SuppressedBy,
 	)
 	if err != nil {
 		return fmt.Errorf("inserting notification: %w", err)
@@ -68,7 +69,7 @@ func (s *Store) Create(ctx context.Context, n Notification) error {
 // GetByID retrieves a single notification.
 func (s *Store) GetByID(ctx context.Context, id string) (*Notification, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, type, severity, title, message, affected_services, affected_teams, delivered, created_at
+		SELECT id, type, severity, title, message, affected_services, affected_teams, link_type, delivered, suppressed_by, created_at
 		FROM notifications WHERE id = ?`, id)
 
 	return scanNotification(row)
@@ -97,6 +98,13 @@ func (s *Store) List(ctx context.Context, filter ListFilter) ([]Notification, er
 		clauses = append(clauses, "delivered = ?")
 		args = append(args, v)
 	}
+	if filter.Suppressed != nil {
+		if *filter.Suppressed {
+			clauses = append(clauses, "suppressed_by != ''")
+		} else {
+			clauses = append(clauses, "suppressed_by = ''")
+		}
+	}
 	if !filter.Since.IsZero() {
 		clauses = append(clauses, "created_at >= ?")
 		args = append(args, filter.Since.UTC().Format(time.DateTime))
@@ -106,7 +114,7 @@ func (s *Store) List(ctx context.Context, filter ListFilter) ([]Notification, er
 		args = append(args, filter.Until.UTC().Format(time.DateTime))
 	}
 
-	query := "SELECT id, type, severity, title, message, affected_services, affected_teams, delivered, created_at FROM notifications"
+	query := "SELECT id, type, severity, title, message, affected_services, affected_teams, link_type, delivered, suppressed_by, created_at FROM notifications"
 	if len(clauses) > 0 {
 		query += " WHERE " + strings.Join(clauses, " AND ")
 	}
@@ -149,10 +157,10 @@ func (s *Store) MarkDelivered(ctx context.Context, id string) error {
 	return nil
 }
 
-// GetPending returns all undelivered notifications.
+// GetPending returns all undelivered notifications that were not muted.
 func (s *Store) GetPending(ctx context.Context) ([]Notification, error) {
-	delivered := false
-	return s.List(ctx, ListFilter{Delivered: &delivered})
+	delivered, suppressed := false, false
+	return s.List(ctx, ListFilter{Delivered: &delivered, Suppressed: &suppressed})
 }
 
 // SetPreference upserts a notification preference.
@@ -222,7 +230,7 @@ func scanInto(sc scanner) (*Notification, error) {
 	)
 
 	err := sc.Scan(&n.ID, &ntype, &severity, &n.Title, &n.Message,
-		&servicesJSON, &teamsJSON, &delivered, &ts)
+		&servicesJSON, &teamsJSON, &n.LinkType, &delivered, &n.SuppressedBy, &ts)
 	if err != nil {
 		return nil, err
 	}
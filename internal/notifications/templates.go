@@ -0,0 +1,222 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateDef customises the message for one channel. An empty Type applies
+// to every notification type and an empty Locale to every locale; the most
+// specific definition wins.
+type TemplateDef struct {
+	Type    NotificationType `json:"type,omitempty"`
+	Channel string           `json:"channel"`
+	Locale  string           `json:"locale,omitempty"`
+	Text    string           `json:"text"`
+}
+
+// defaultTemplates are used for channels without a matching TemplateDef.
+// Webhook payloads are structured JSON and have no default text.
+var defaultTemplates = map[string]string{
+	ChannelSlack: `*[{{severity .Severity}}] {{.Title}}*{{if .Message}}` + "\n" + `{{.Message}}{{end}}`,
+}
+
+// builtinMessages translates the labels templates reach through the t,
+// severity, and typeName functions. English labels are the raw values so
+// untranslated output is unchanged.
+var builtinMessages = map[string]map[string]string{
+	"en": {
+		"info": "info", "warning": "warning", "critical": "critical",
+		"service_added": "service added", "service_removed": "service removed",
+		"relationship_changed": "relationship changed", "ownership_changed": "ownership changed",
+		"doc_updated": "docs updated", "context_changed": "context changed",
+		"staleness_detected": "stale docs detected", "schema_changed": "schema changed",
+		"deprecated_in_use": "deprecated endpoint still in use", "narrative_conflict": "edited narrative regenerated",
+		"services": "Services", "teams": "Teams",
+	},
+	"de": {
+		"info": "Info", "warning": "Warnung", "critical": "Kritisch",
+		"service_added": "Dienst hinzugefügt", "service_removed": "Dienst entfernt",
+		"relationship_changed": "Abhängigkeit geändert", "ownership_changed": "Zuständigkeit geändert",
+		"doc_updated": "Dokumentation aktualisiert", "context_changed": "Kontext geändert",
+		"staleness_detected": "Veraltete Dokumentation", "schema_changed": "Schema geändert",
+		"deprecated_in_use": "Veralteter Endpunkt noch in Nutzung", "narrative_conflict": "Bearbeitete Beschreibung neu erzeugt",
+		"services": "Dienste", "teams": "Teams",
+	},
+	"fr": {
+		"info": "info", "warning": "avertissement", "critical": "critique",
+		"service_added": "service ajouté", "service_removed": "service supprimé",
+		"relationship_changed": "dépendance modifiée", "ownership_changed": "responsabilité modifiée",
+		"doc_updated": "documentation mise à jour", "context_changed": "contexte modifié",
+		"staleness_detected": "documentation obsolète", "schema_changed": "schéma modifié",
+		"deprecated_in_use": "point de terminaison obsolète encore utilisé", "narrative_conflict": "récit modifié régénéré",
+		"services": "Services", "teams": "Équipes",
+	},
+	"es": {
+		"info": "info", "warning": "advertencia", "critical": "crítico",
+		"service_added": "servicio añadido", "service_removed": "servicio eliminado",
+		"relationship_changed": "dependencia modificada", "ownership_changed": "responsable modificado",
+		"doc_updated": "documentación actualizada", "context_changed": "contexto modificado",
+		"staleness_detected": "documentación obsoleta", "schema_changed": "esquema modificado",
+		"deprecated_in_use": "endpoint obsoleto aún en uso", "narrative_conflict": "narrativa editada regenerada",
+		"services": "Servicios", "teams": "Equipos",
+	},
+}
+
+// TemplateData is what a notification template is executed with. The
+// notification's fields are available directly, e.g. {{.Title}}.
+type TemplateData struct {
+	Notification
+	Channel string
+	Locale  string
+}
+
+type templateKey struct {
+	typ     NotificationType
+	channel string
+	locale  string
+}
+
+// Templates renders notification messages per type, channel, and locale.
+// A nil *Templates renders the built-in defaults in English.
+type Templates struct {
+	locale   string
+	defs     map[templateKey]*template.Template
+	messages map[string]map[string]string
+}
+
+// NewTemplates parses defs. locale is used when a destination doesn't set
+// one, and messages (locale -> key -> text) add to or override the built-in
+// label translations.
+func NewTemplates(locale string, defs []TemplateDef, messages map[string]map[string]string) (*Templates, error) {
+	t := &Templates{
+		locale:   normalizeLocale(locale),
+		defs:     make(map[templateKey]*template.Template),
+		messages: make(map[string]map[string]string),
+	}
+	for loc, msgs := range messages {
+		t.messages[normalizeLocale(loc)] = msgs
+	}
+	for _, def := range defs {
+		if def.Channel == "" {
+			return nil, fmt.Errorf("notification template for %q: channel is required", def.Type)
+		}
+		key := templateKey{typ: def.Type, channel: def.Channel, locale: normalizeLocale(def.Locale)}
+		name := fmt.Sprintf("%s/%s/%s", key.channel, orAny(string(key.typ)), orAny(key.locale))
+		tmpl, err := template.New(name).Funcs(t.funcs(key.locale)).Option("missingkey=error").Parse(def.Text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing notification template %s: %w", name, err)
+		}
+		t.defs[key] = tmpl
+	}
+	return t, nil
+}
+
+// Render returns the message text for n on channel. locale falls back to the
+// templates' default locale, then from a regional locale ("de-AT") to its
+// language ("de"). ok is false when neither a custom nor a default template
+// exists for the channel.
+func (t *Templates) Render(channel, locale string, n Notification) (text string, ok bool, err error) {
+	if t == nil {
+		t = &Templates{}
+	}
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		locale = t.locale
+	}
+	if locale == "" {
+		locale = "en"
+	}
+
+	tmpl := t.lookup(channel, locale, n.Type)
+	if tmpl == nil {
+		src, found := defaultTemplates[channel]
+		if !found {
+			return "", false, nil
+		}
+		tmpl = template.Must(template.New(channel).Funcs(t.funcs(locale)).Parse(src))
+	}
+	// Bind the label functions to the locale being rendered, which can differ
+	// from the one the template was defined for.
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		return "", true, err
+	}
+	tmpl.Funcs(t.funcs(locale))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, TemplateData{Notification: n, Channel: channel, Locale: locale}); err != nil {
+		return "", true, fmt.Errorf("rendering %s template: %w", tmpl.Name(), err)
+	}
+	return buf.String(), true, nil
+}
+
+// lookup finds the most specific custom template: an exact locale before its
+// language before any locale, and an exact type before any type.
+func (t *Templates) lookup(channel, locale string, typ NotificationType) *template.Template {
+	for _, loc := range localeChain(locale) {
+		for _, ty := range []NotificationType{typ, ""} {
+			if tmpl := t.defs[templateKey{typ: ty, channel: channel, locale: loc}]; tmpl != nil {
+				return tmpl
+			}
+		}
+	}
+	return nil
+}
+
+// Translate returns the label for key in locale, falling back to the
+// language, then English, then the key itself.
+func (t *Templates) Translate(locale, key string) string {
+	chain := localeChain(normalizeLocale(locale))
+	chain = append(chain[:len(chain)-1], "en")
+	for _, loc := range chain {
+		if t != nil {
+			if v, ok := t.messages[loc][key]; ok {
+				return v
+			}
+		}
+		if v, ok := builtinMessages[loc][key]; ok {
+			return v
+		}
+	}
+	return key
+}
+
+func (t *Templates) funcs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t":        func(key string) string { return t.Translate(locale, key) },
+		"severity": func(s Severity) string { return t.Translate(locale, string(s)) },
+		"typeName": func(ty NotificationType) string { return t.Translate(locale, string(ty)) },
+		"join":     strings.Join,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+	}
+}
+
+// localeChain lists the locales to try for locale, most specific first,
+// ending with "" (any locale).
+func localeChain(locale string) []string {
+	var chain []string
+	if locale != "" {
+		chain = append(chain, locale)
+		if lang, _, found := strings.Cut(locale, "-"); found {
+			chain = append(chain, lang)
+		}
+	}
+	return append(chain, "")
+}
+
+// normalizeLocale lower-cases a locale and uses "-" as the separator, so
+// "pt_BR" and "pt-br" are the same.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func orAny(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
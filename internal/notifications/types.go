@@ -22,6 +22,9 @@ const (
 	TypeDocUpdated         NotificationType = "doc_updated"
 	TypeContextChanged     NotificationType = "context_changed"
 	TypeStalenessDetected  NotificationType = "staleness_detected"
+	TypeSchemaChanged      NotificationType = "schema_changed"
+	TypeDeprecatedInUse    NotificationType = "deprecated_in_use"
+	TypeNarrativeConflict  NotificationType = "narrative_conflict"
 )
 
 // DigestFrequency controls how often digest summaries are sent.
@@ -42,7 +45,9 @@ type Notification struct {
 	Message          string           `json:"message"`
 	AffectedServices []string         `json:"affected_services"`
 	AffectedTeams    []string         `json:"affected_teams"`
+	LinkType         string           `json:"link_type,omitempty"` // for relationship changes: http, grpc, kafka, ...
 	Delivered        bool             `json:"delivered"`
+	SuppressedBy     string           `json:"suppressed_by,omitempty"` // ID of the mute that silenced it
 	CreatedAt        time.Time        `json:"created_at"`
 }
 
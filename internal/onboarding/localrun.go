@@ -0,0 +1,252 @@
+package onboarding
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxMakeTargets caps the Makefile targets listed when none has a
+// conventional name.
+const maxMakeTargets = 5
+
+// RunStep is one command for running the service locally.
+type RunStep struct {
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+	// Source is the file the step was read from, relative to the checkout.
+	Source string `json:"source"`
+}
+
+var (
+	makefileNames = []string{"Makefile", "makefile", "GNUmakefile"}
+	composeNames  = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
+)
+
+// makeStages order the conventional Makefile targets as a newcomer runs
+// them: set up, build, run, test. A target matches a stage when its name is
+// one of the words or starts with one followed by a dash or underscore.
+var makeStages = [][]string{
+	{"setup", "install", "deps", "bootstrap", "init"},
+	{"build", "compile"},
+	{"migrate", "seed"},
+	{"run", "start", "dev", "serve", "up", "local", "docker"},
+	{"test", "check"},
+}
+
+// LocalRunSteps reads how to run the code in dir of the checkout at root
+// from its Makefile and Compose file. When dir has neither, the checkout's
+// root is read instead, as for a sub-service run from its monorepo.
+func LocalRunSteps(root, dir string) ([]RunStep, error) {
+	dirs := []string{dir}
+	if dir != "" {
+		dirs = append(dirs, "")
+	}
+	var errs []string
+	for _, d := range dirs {
+		var steps []RunStep
+		if s, err := makeSteps(root, d); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			steps = append(steps, s...)
+		}
+		if s, err := composeSteps(root, d); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			steps = append(steps, s...)
+		}
+		if len(steps) > 0 {
+			return steps, joinErrors(errs)
+		}
+	}
+	return nil, joinErrors(errs)
+}
+
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+// findFile returns the first of names that exists in dir, relative to root.
+func findFile(root, dir string, names []string) string {
+	for _, n := range names {
+		rel := filepath.Join(filepath.FromSlash(dir), n)
+		if _, err := os.Stat(filepath.Join(root, rel)); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return ""
+}
+
+// makeTargetRe matches a rule's targets, but not variable assignments
+// such as "X := y".
+var makeTargetRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./ -]*?)\s*::?(?:[^=]|$)`)
+
+// makeTarget is a Makefile target with the comment that describes it.
+type makeTarget struct {
+	name, description string
+}
+
+// makeSteps lists the Makefile's conventional targets in the order a
+// newcomer would run them, or its first few targets if none is conventional.
+func makeSteps(root, dir string) ([]RunStep, error) {
+	rel := findFile(root, dir, makefileNames)
+	if rel == "" {
+		return nil, nil
+	}
+	targets, err := parseMakefile(filepath.Join(root, rel))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	command := "make "
+	if dir != "" {
+		command = "make -C " + dir + " "
+	}
+
+	var picked []makeTarget
+	used := make(map[string]bool)
+	for _, stage := range makeStages {
+		for _, t := range targets {
+			if !used[t.name] && matchesStage(t.name, stage) {
+				used[t.name] = true
+				picked = append(picked, t)
+			}
+		}
+	}
+	if len(picked) == 0 {
+		picked = targets[:min(len(targets), maxMakeTargets)]
+	}
+	steps := make([]RunStep, len(picked))
+	for i, t := range picked {
+		steps[i] = RunStep{Command: command + t.name, Description: t.description, Source: rel}
+	}
+	return steps, nil
+}
+
+func matchesStage(target string, words []string) bool {
+	for _, w := range words {
+		if target == w || strings.HasPrefix(target, w+"-") || strings.HasPrefix(target, w+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMakefile returns the explicit targets of a Makefile in file order,
+// skipping special and pattern targets. A target's description is a "##"
+// comment after it on the same line, or the comment lines just above it.
+func parseMakefile(path string) ([]makeTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var targets []makeTarget
+	seen := make(map[string]bool)
+	var comment []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			comment = append(comment, strings.TrimSpace(strings.TrimLeft(line, "#")))
+			continue
+		}
+		m := makeTargetRe.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(line, "\t") {
+			comment = nil
+			continue
+		}
+		description := strings.Join(comment, " ")
+		if _, trailing, ok := strings.Cut(line, "##"); ok {
+			description = strings.TrimSpace(trailing)
+		}
+		comment = nil
+		for _, name := range strings.Fields(m[1]) {
+			if strings.HasPrefix(name, ".") || strings.Contains(name, "%") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			targets = append(targets, makeTarget{name: name, description: description})
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// composeFile is the part of a Compose file a guide describes.
+type composeFile struct {
+	Services map[string]struct {
+		Image string `yaml:"image"`
+		Build any    `yaml:"build"`
+		Ports []any  `yaml:"ports"`
+	} `yaml:"services"`
+}
+
+// composeSteps describes bringing up the Compose file's services.
+func composeSteps(root, dir string) ([]RunStep, error) {
+	rel := findFile(root, dir, composeNames)
+	if rel == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	var cf composeFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", rel, err)
+	}
+	if len(cf.Services) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(cf.Services))
+	for name := range cf.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		svc := cf.Services[name]
+		var details []string
+		switch {
+		case svc.Image != "":
+			details = append(details, svc.Image)
+		case svc.Build != nil:
+			details = append(details, "built locally")
+		}
+		for _, p := range svc.Ports {
+			details = append(details, "port "+composePort(p))
+		}
+		parts[i] = name
+		if len(details) > 0 {
+			parts[i] += " (" + strings.Join(details, ", ") + ")"
+		}
+	}
+
+	command := "docker compose up"
+	if dir != "" {
+		command = "docker compose -f " + rel + " up"
+	}
+	return []RunStep{{Command: command, Description: "Starts " + strings.Join(parts, ", "), Source: rel}}, nil
+}
+
+// composePort formats a port mapping in short ("8080:80") or long syntax.
+func composePort(p any) string {
+	m, ok := p.(map[string]any)
+	if !ok {
+		return fmt.Sprint(p)
+	}
+	if published, ok := m["published"]; ok {
+		return fmt.Sprintf("%v:%v", published, m["target"])
+	}
+	return fmt.Sprint(m["target"])
+}
@@ -0,0 +1,262 @@
+// Package onboarding composes a walkthrough of one service for an engineer
+// new to it: what it is for, where its code starts, how to run it locally,
+// the flows it takes part in, what it calls and what calls it, and who owns
+// it. The same guide backs `autodoc onboard` and the MCP
+// get_onboarding_guide tool.
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/incident"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// maxEntryPoints caps the entry points a guide lists.
+const maxEntryPoints = 10
+
+// Guide is the onboarding walkthrough for one service.
+type Guide struct {
+	Service   string    `json:"service"`
+	Generated time.Time `json:"generated"`
+	Purpose   string    `json:"purpose,omitempty"`
+	// Monorepo and Subdir place a sub-service within its monorepo.
+	Monorepo    string             `json:"monorepo,omitempty"`
+	Subdir      string             `json:"subdir,omitempty"`
+	EntryPoints []EntryPoint       `json:"entry_points"`
+	LocalRun    []RunStep          `json:"local_run"`
+	Flows       []incident.FlowRef `json:"flows"`
+	// Dependencies are the services this one calls; Consumers call it.
+	Dependencies []registry.ServiceLink `json:"dependencies"`
+	Consumers    []registry.ServiceLink `json:"consumers"`
+	Owners       []incident.Owner       `json:"owners"`
+	// Docs are the generated pages worth reading next.
+	Docs []string `json:"docs,omitempty"`
+	// Warnings name the parts of the guide that could not be loaded.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// EntryPoint is a place where execution or requests enter the service.
+type EntryPoint struct {
+	File string `json:"file"`
+	// Kind is "main" for a program's entry point or "routes" for a file
+	// serving HTTP routes.
+	Kind    string   `json:"kind"`
+	Summary string   `json:"summary,omitempty"`
+	Routes  []string `json:"routes,omitempty"`
+}
+
+// Builder assembles guides from the central database. Only Repos is
+// required; the other sources are skipped when nil.
+type Builder struct {
+	Repos *registry.Store
+	Flows *flows.Store
+	Org   *orgstructure.Store
+
+	now func() time.Time
+}
+
+// NewBuilder returns a builder reading the central database.
+func NewBuilder(database *db.DB) *Builder {
+	return &Builder{
+		Repos: registry.NewStore(database),
+		Flows: flows.NewStore(database),
+		Org:   orgstructure.NewStore(database),
+	}
+}
+
+// Build assembles the guide for a registered service.
+func (b *Builder) Build(ctx context.Context, service string) (*Guide, error) {
+	service = strings.TrimSpace(service)
+	if service == "" {
+		return nil, fmt.Errorf("name a service")
+	}
+	repos, err := b.Repos.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+	var repo *registry.Repository
+	for i := range repos {
+		if strings.EqualFold(repos[i].Name, service) {
+			repo = &repos[i]
+			break
+		}
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("no registered service named %q", service)
+	}
+
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	guide := &Guide{
+		Service:   repo.Name,
+		Generated: now().UTC(),
+		Purpose:   repo.Summary,
+		Monorepo:  repo.Parent,
+		Subdir:    repo.Subdir,
+	}
+
+	links, err := b.Repos.GetLinks(ctx, repo.Name)
+	if err != nil {
+		guide.Warnings = append(guide.Warnings, fmt.Sprintf("dependencies: %v", err))
+	}
+	for _, l := range links {
+		switch {
+		case strings.EqualFold(l.FromRepo, repo.Name):
+			guide.Dependencies = append(guide.Dependencies, l)
+		case strings.EqualFold(l.ToRepo, repo.Name):
+			guide.Consumers = append(guide.Consumers, l)
+		}
+	}
+
+	b.addEntryPoints(ctx, guide, repo)
+	b.addLocalRun(ctx, guide, repo)
+	b.addFlows(ctx, guide)
+	b.addOwners(ctx, guide, repo)
+	addDocs(guide, repo)
+	return guide, nil
+}
+
+// entryFileNames are conventional names for a program's entry point, for
+// languages whose analyses don't show a main function.
+var entryFileNames = map[string]bool{
+	"main.go": true, "main.py": true, "__main__.py": true, "app.py": true, "manage.py": true, "wsgi.py": true, "asgi.py": true,
+	"index.js": true, "index.ts": true, "server.js": true, "server.ts": true, "app.js": true, "app.ts": true, "main.ts": true,
+	"main.rs": true, "Program.cs": true, "Application.java": true, "Main.java": true,
+}
+
+// addEntryPoints finds the files that start the program and the files that
+// serve HTTP routes, from the service's analyses.
+func (b *Builder) addEntryPoints(ctx context.Context, guide *Guide, repo *registry.Repository) {
+	if repo.LocalPath == "" {
+		return
+	}
+	analyses, err := b.Repos.Analyses(ctx, repo)
+	if err != nil {
+		guide.Warnings = append(guide.Warnings, fmt.Sprintf("entry points: %v", err))
+		return
+	}
+	var mains, routes []EntryPoint
+	for _, a := range analyses {
+		if a.Skip {
+			continue
+		}
+		if hasMain(a) || entryFileNames[path.Base(filepath.ToSlash(a.FilePath))] {
+			mains = append(mains, EntryPoint{File: a.FilePath, Kind: "main", Summary: a.Summary})
+			continue
+		}
+		for _, k := range a.KeyLogic {
+			if list, ok := strings.CutPrefix(k, indexer.RoutesKeyLogic); ok {
+				ep := EntryPoint{File: a.FilePath, Kind: "routes", Summary: a.Summary}
+				for _, r := range strings.Split(list, ",") {
+					ep.Routes = append(ep.Routes, strings.TrimSpace(r))
+				}
+				routes = append(routes, ep)
+				break
+			}
+		}
+	}
+	byPath := func(eps []EntryPoint) {
+		sort.Slice(eps, func(i, j int) bool { return eps[i].File < eps[j].File })
+	}
+	byPath(mains)
+	byPath(routes)
+	guide.EntryPoints = append(mains, routes...)
+	if len(guide.EntryPoints) > maxEntryPoints {
+		guide.EntryPoints = guide.EntryPoints[:maxEntryPoints]
+	}
+}
+
+func hasMain(a indexer.FileAnalysis) bool {
+	for _, fn := range a.Functions {
+		if fn.Name == "main" || fn.Name == "Main" {
+			return true
+		}
+	}
+	for _, c := range a.Classes {
+		for _, m := range c.Methods {
+			if m.Name == "main" || m.Name == "Main" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addLocalRun reads local run instructions from the service's directory,
+// falling back to its monorepo's root.
+func (b *Builder) addLocalRun(ctx context.Context, guide *Guide, repo *registry.Repository) {
+	if repo.LocalPath == "" {
+		return
+	}
+	dir, _ := b.Repos.Scope(ctx, repo)
+	steps, err := LocalRunSteps(repo.LocalPath, dir)
+	if err != nil {
+		guide.Warnings = append(guide.Warnings, fmt.Sprintf("local run instructions: %v", err))
+	}
+	guide.LocalRun = steps
+}
+
+func (b *Builder) addFlows(ctx context.Context, guide *Guide) {
+	if b.Flows == nil {
+		return
+	}
+	all, err := b.Flows.ListFlows(ctx)
+	if err != nil {
+		guide.Warnings = append(guide.Warnings, fmt.Sprintf("flows: %v", err))
+		return
+	}
+	for _, f := range all {
+		for _, svc := range f.Services {
+			if strings.EqualFold(svc, guide.Service) {
+				guide.Flows = append(guide.Flows, incident.FlowRef{Name: f.Name, Description: f.Description, Services: f.Services})
+				break
+			}
+		}
+	}
+}
+
+func (b *Builder) addOwners(ctx context.Context, guide *Guide, repo *registry.Repository) {
+	if b.Org == nil {
+		return
+	}
+	names := []string{repo.Name}
+	if repo.Parent != "" {
+		names = append(names, repo.Parent)
+	}
+	owners, err := incident.FindOwners(ctx, b.Org, names...)
+	if err != nil {
+		guide.Warnings = append(guide.Warnings, fmt.Sprintf("ownership: %v", err))
+	}
+	guide.Owners = owners
+}
+
+// addDocs lists the service's generated overview pages.
+func addDocs(guide *Guide, repo *registry.Repository) {
+	if repo.LocalPath == "" {
+		return
+	}
+	docsDir := filepath.Join(repo.LocalPath, ".autodoc", "docs")
+	pages := []string{filepath.Join(docsDir, filepath.FromSlash(repo.Subdir), "index.md")}
+	if repo.Subdir == "" {
+		pages = append(pages, filepath.Join(docsDir, "architecture.md"))
+	}
+	for _, p := range pages {
+		if _, err := os.Stat(p); err == nil {
+			guide.Docs = append(guide.Docs, p)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package onboarding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalRunSteps(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "Makefile"), `.PHONY: build run test lint
+GO := go
+VERSION ?= dev
+
+# Compile the binaries.
+build:
+	$(GO) build ./...
+
+lint: ## Run the linters
+	golangci-lint run
+
+run: build ## Start the API on :8080
+	./bin/api
+
+test:
+	$(GO) test ./...
+
+%.pb.go: %.proto
+	protoc $<
+`)
+	writeFile(t, filepath.Join(root, "docker-compose.yml"), `services:
+  db:
+    image: postgres:16
+    ports: ["5432:5432"]
+  api:
+    build: .
+    ports:
+      - target: 8080
+        published: 8080
+`)
+
+	steps, err := LocalRunSteps(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.Command+" | "+s.Description)
+	}
+	want := []string{
+		"make build | Compile the binaries.",
+		"make run | Start the API on :8080",
+		"make test | ",
+		"docker compose up | Starts api (built locally, port 8080:8080), db (postgres:16, port 5432:5432)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("steps:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A sub-service without its own files is run from the monorepo root.
+	steps, _ = LocalRunSteps(root, "services/orders")
+	if len(steps) != 4 || steps[0].Command != "make build" {
+		t.Errorf("sub-service steps = %+v", steps)
+	}
+	writeFile(t, filepath.Join(root, "services", "orders", "Makefile"), "serve:\n\tgo run .\n")
+	steps, _ = LocalRunSteps(root, "services/orders")
+	if len(steps) != 1 || steps[0].Command != "make -C services/orders serve" || steps[0].Source != "services/orders/Makefile" {
+		t.Errorf("sub-service steps = %+v", steps)
+	}
+
+	// Without conventional targets, the first few are listed.
+	other := t.TempDir()
+	writeFile(t, filepath.Join(other, "Makefile"), "proto:\n\tbuf generate\nfmt:\n\tgofmt -w .\n")
+	steps, _ = LocalRunSteps(other, "")
+	if len(steps) != 2 || steps[0].Command != "make proto" {
+		t.Errorf("unconventional steps = %+v", steps)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	checkout := t.TempDir()
+	writeFile(t, filepath.Join(checkout, "Makefile"), "run:\n\tgo run ./cmd/orders\n")
+	writeFile(t, filepath.Join(checkout, ".autodoc", "docs", "index.md"), "# Orders")
+	err = indexer.SaveAnalyses(checkout, map[string]indexer.FileAnalysis{
+		"cmd/orders/main.go": {FilePath: "cmd/orders/main.go", Language: "Go", Summary: "Starts the orders API.",
+			Functions: []indexer.FunctionDoc{{Name: "main", Signature: "func main()", Summary: "Entry point."}}},
+		"api/routes.go": {FilePath: "api/routes.go", Language: "Go", Summary: "Registers handlers.",
+			KeyLogic: []string{indexer.RoutesKeyLogic + "POST /api/orders, GET /api/orders/{id}"}},
+		"store/store.go": {FilePath: "store/store.go", Language: "Go", Summary: "Persists orders."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos := registry.NewStore(database)
+	for _, r := range []registry.Repository{
+		{Name: "orders", Summary: "Takes orders.", LocalPath: checkout},
+		{Name: "gateway"}, {Name: "payments"},
+	} {
+		r := r
+		r.SourceType = "local"
+		if err := repos.Add(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders"}})
+	repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"})
+
+	org := orgstructure.NewStore(database)
+	team := &orgstructure.Team{Name: "commerce", SlackChannel: "#commerce", Email: "commerce@example.com"}
+	if err := org.CreateTeam(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+	org.SetOwnership(ctx, &orgstructure.ServiceOwnership{TeamID: team.ID, RepoID: "orders"})
+
+	flowStore := flows.NewStore(database)
+	flowStore.CreateFlow(ctx, &flows.Flow{Name: "checkout", Description: "Buying a cart.", Services: []string{"gateway", "orders", "payments"}})
+	flowStore.CreateFlow(ctx, &flows.Flow{Name: "signup", Services: []string{"gateway", "users"}})
+
+	guide, err := NewBuilder(database).Build(ctx, "Orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guide.Service != "orders" || guide.Purpose != "Takes orders." {
+		t.Errorf("guide = %q / %q", guide.Service, guide.Purpose)
+	}
+	if len(guide.EntryPoints) != 2 || guide.EntryPoints[0].File != "cmd/orders/main.go" || guide.EntryPoints[1].Kind != "routes" {
+		t.Errorf("entry points = %+v", guide.EntryPoints)
+	}
+	if len(guide.Flows) != 1 || guide.Flows[0].Name != "checkout" {
+		t.Errorf("flows = %+v", guide.Flows)
+	}
+
+	md := guide.Markdown()
+	for _, want := range []string{
+		"# Onboarding: orders",
+		"- `cmd/orders/main.go` starts the program: Starts the orders API.",
+		"- `api/routes.go` serves POST /api/orders, GET /api/orders/{id}",
+		"1. `make run` (from Makefile)",
+		"- **checkout** (gateway → orders → payments): Buying a cart.",
+		"## Dependencies\n\n- **payments** (grpc)",
+		"## Consumers\n\n- **gateway** (http): POST /api/orders",
+		"- **commerce**: Slack #commerce, commerce@example.com",
+		filepath.Join(checkout, ".autodoc", "docs", "index.md"),
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("guide missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "signup") || strings.Contains(md, "store/store.go") {
+		t.Errorf("guide includes unrelated content:\n%s", md)
+	}
+
+	if _, err := NewBuilder(database).Build(ctx, "billing"); err == nil {
+		t.Error("expected an error for an unregistered service")
+	}
+}
@@ -0,0 +1,126 @@
+package onboarding
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the guide for the terminal and MCP clients.
+func (g *Guide) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Onboarding: %s\n\n", g.Service)
+
+	sb.WriteString("## Purpose\n\n")
+	if g.Purpose != "" {
+		sb.WriteString(g.Purpose + "\n")
+	} else {
+		sb.WriteString("No summary is recorded for this service yet.\n")
+	}
+	if g.Monorepo != "" {
+		fmt.Fprintf(&sb, "\nIt lives in the %s monorepo, under `%s`.\n", g.Monorepo, g.Subdir)
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Key Entry Points\n\n")
+	if len(g.EntryPoints) == 0 {
+		sb.WriteString("No entry points found. Run `autodoc generate` in the service's checkout to analyze its code.\n\n")
+	}
+	for _, ep := range g.EntryPoints {
+		switch ep.Kind {
+		case "routes":
+			fmt.Fprintf(&sb, "- `%s` serves %s", ep.File, strings.Join(ep.Routes, ", "))
+		default:
+			fmt.Fprintf(&sb, "- `%s` starts the program", ep.File)
+		}
+		if ep.Summary != "" {
+			sb.WriteString(": " + ep.Summary)
+		}
+		sb.WriteString("\n")
+	}
+	if len(g.EntryPoints) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Running Locally\n\n")
+	if len(g.LocalRun) == 0 {
+		sb.WriteString("No Makefile or Compose file found; check the service's README.\n\n")
+	}
+	for i, step := range g.LocalRun {
+		fmt.Fprintf(&sb, "%d. `%s`", i+1, step.Command)
+		if step.Description != "" {
+			sb.WriteString(": " + step.Description)
+		}
+		fmt.Fprintf(&sb, " (from %s)\n", step.Source)
+	}
+	if len(g.LocalRun) > 0 {
+		sb.WriteString("\n")
+	}
+
+	if len(g.Flows) > 0 {
+		sb.WriteString("## Main Flows\n\n")
+		for _, f := range g.Flows {
+			fmt.Fprintf(&sb, "- **%s** (%s)", f.Name, strings.Join(f.Services, " → "))
+			if f.Description != "" {
+				sb.WriteString(": " + f.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Dependencies\n\n")
+	if len(g.Dependencies) == 0 {
+		sb.WriteString("This service calls no other known service.\n")
+	}
+	for _, l := range g.Dependencies {
+		fmt.Fprintf(&sb, "- **%s** (%s)%s\n", l.ToRepo, l.LinkType, endpointList(l.Endpoints))
+	}
+	sb.WriteString("\n## Consumers\n\n")
+	if len(g.Consumers) == 0 {
+		sb.WriteString("No known service calls this one.\n")
+	}
+	for _, l := range g.Consumers {
+		fmt.Fprintf(&sb, "- **%s** (%s)%s\n", l.FromRepo, l.LinkType, endpointList(l.Endpoints))
+	}
+
+	sb.WriteString("\n## Owners\n\n")
+	if len(g.Owners) == 0 {
+		sb.WriteString("No owning team is recorded.\n")
+	}
+	for _, o := range g.Owners {
+		fmt.Fprintf(&sb, "- **%s**", o.Team)
+		var contacts []string
+		if o.SlackChannel != "" {
+			contacts = append(contacts, "Slack "+o.SlackChannel)
+		}
+		if o.Email != "" {
+			contacts = append(contacts, o.Email)
+		}
+		if len(contacts) > 0 {
+			sb.WriteString(": " + strings.Join(contacts, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(g.Docs) > 0 {
+		sb.WriteString("\n## Further Reading\n\n")
+		for _, d := range g.Docs {
+			fmt.Fprintf(&sb, "- %s\n", d)
+		}
+	}
+
+	if len(g.Warnings) > 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range g.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+	}
+	return sb.String()
+}
+
+func endpointList(endpoints []string) string {
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(endpoints, ", ")
+}
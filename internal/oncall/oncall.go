@@ -0,0 +1,224 @@
+// Package oncall looks up who is currently on call for a service, so docs can
+// tell readers who to page. Services are mapped to PagerDuty or Opsgenie
+// schedules through config or context-engine facts.
+package oncall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// Supported schedule providers.
+const (
+	ProviderPagerDuty = "pagerduty"
+	ProviderOpsgenie  = "opsgenie"
+)
+
+// FactKey is the context-engine fact, scoped to a service, that holds the
+// service's schedule reference.
+const FactKey = "oncall_schedule"
+
+// Responder is one person currently on call.
+type Responder struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Level int    `json:"level,omitempty"` // escalation level; 1 is paged first
+}
+
+// String renders the responder as "Name <email> (L1)".
+func (r Responder) String() string {
+	s := r.Name
+	if r.Email != "" && r.Email != r.Name {
+		s += " <" + r.Email + ">"
+	}
+	if r.Level > 0 {
+		s += fmt.Sprintf(" (L%d)", r.Level)
+	}
+	return s
+}
+
+// Schedule identifies a schedule at a provider, written "provider:id".
+type Schedule struct {
+	Provider string
+	ID       string
+}
+
+func (s Schedule) String() string {
+	return s.Provider + ":" + s.ID
+}
+
+// ParseSchedule parses a "pagerduty:PXXXXXX" or "opsgenie:<schedule>"
+// reference.
+func ParseSchedule(ref string) (Schedule, error) {
+	provider, id, ok := strings.Cut(strings.TrimSpace(ref), ":")
+	provider = strings.ToLower(provider)
+	if !ok || id == "" {
+		return Schedule{}, fmt.Errorf("schedule %q must look like provider:id", ref)
+	}
+	if provider != ProviderPagerDuty && provider != ProviderOpsgenie {
+		return Schedule{}, fmt.Errorf("schedule %q: unknown provider %q (want pagerduty or opsgenie)", ref, provider)
+	}
+	return Schedule{Provider: provider, ID: id}, nil
+}
+
+// Provider fetches the current on-call responders for a schedule.
+type Provider interface {
+	OnCall(ctx context.Context, scheduleID string) ([]Responder, error)
+}
+
+// Lookup is the on-call answer for one service. Err is set, and Responders
+// empty, when the schedule could not be fetched.
+type Lookup struct {
+	Service    string      `json:"service"`
+	Schedule   string      `json:"schedule"`
+	Responders []Responder `json:"responders,omitempty"`
+	Err        string      `json:"error,omitempty"`
+}
+
+// Resolver maps services to schedules and fetches who is on call. Lookups of
+// the same schedule are cached for the resolver's lifetime, which is meant to
+// be one generation run.
+type Resolver struct {
+	// Schedules maps service names to schedule references; these take
+	// precedence over facts.
+	Schedules map[string]string
+	Facts     contextengine.FactSource
+	Providers map[string]Provider
+
+	cache   map[string]Lookup
+	missing map[string]string // provider -> credential env var, for error hints
+}
+
+// NewResolver builds a resolver from config. Providers whose credentials are
+// not set in the environment are left out; their lookups report the missing
+// variable instead of failing the run.
+func NewResolver(cfg config.OnCallConfig, facts contextengine.FactSource) *Resolver {
+	r := &Resolver{Schedules: cfg.Schedules, Facts: facts, Providers: make(map[string]Provider)}
+	if token := os.Getenv(envOr(cfg.PagerDutyTokenEnv, "PAGERDUTY_TOKEN")); token != "" {
+		r.Providers[ProviderPagerDuty] = &PagerDuty{Token: token}
+	}
+	if key := os.Getenv(envOr(cfg.OpsgenieKeyEnv, "OPSGENIE_API_KEY")); key != "" {
+		r.Providers[ProviderOpsgenie] = &Opsgenie{APIKey: key, BaseURL: cfg.OpsgenieURL}
+	}
+	r.missing = map[string]string{
+		ProviderPagerDuty: envOr(cfg.PagerDutyTokenEnv, "PAGERDUTY_TOKEN"),
+		ProviderOpsgenie:  envOr(cfg.OpsgenieKeyEnv, "OPSGENIE_API_KEY"),
+	}
+	return r
+}
+
+// ScheduleFor returns the schedule reference for a service, or "" when none
+// is configured.
+func (r *Resolver) ScheduleFor(ctx context.Context, service string) string {
+	if ref := r.Schedules[service]; ref != "" {
+		return ref
+	}
+	if r.Facts == nil {
+		return ""
+	}
+	facts, err := r.Facts.GetCurrentFacts(ctx, "", "service", service)
+	if err != nil {
+		return ""
+	}
+	for _, f := range facts {
+		if f.Key == FactKey && strings.TrimSpace(f.Value) != "" {
+			return strings.TrimSpace(f.Value)
+		}
+	}
+	return ""
+}
+
+// Lookup returns who is on call for service, or nil when the service has no
+// schedule. Fetch errors are reported in the result rather than returned, so
+// one unreachable provider doesn't stop a docs build.
+func (r *Resolver) Lookup(ctx context.Context, service string) *Lookup {
+	ref := r.ScheduleFor(ctx, service)
+	if ref == "" {
+		return nil
+	}
+	if r.cache == nil {
+		r.cache = make(map[string]Lookup)
+	}
+	if cached, ok := r.cache[ref]; ok {
+		cached.Service = service
+		return &cached
+	}
+
+	l := Lookup{Service: service, Schedule: ref}
+	sched, err := ParseSchedule(ref)
+	if err != nil {
+		l.Err = err.Error()
+	} else if p := r.Providers[sched.Provider]; p == nil {
+		l.Err = fmt.Sprintf("%s is not configured", sched.Provider)
+		if env := r.missing[sched.Provider]; env != "" {
+			l.Err = fmt.Sprintf("%s is not configured (set %s)", sched.Provider, env)
+		}
+	} else {
+		fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		responders, err := p.OnCall(fetchCtx, sched.ID)
+		cancel()
+		if err != nil {
+			l.Err = err.Error()
+		} else {
+			sortResponders(responders)
+			l.Responders = responders
+		}
+	}
+	r.cache[ref] = l
+	return &l
+}
+
+// LookupAll returns the on-call lookups for the services that have a
+// schedule, in the order given.
+func (r *Resolver) LookupAll(ctx context.Context, services []string) []Lookup {
+	var out []Lookup
+	for _, svc := range services {
+		if l := r.Lookup(ctx, svc); l != nil {
+			out = append(out, *l)
+		}
+	}
+	return out
+}
+
+// sortResponders orders responders by escalation level, then name.
+func sortResponders(rs []Responder) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Level != rs[j].Level {
+			return rs[i].Level < rs[j].Level
+		}
+		return rs[i].Name < rs[j].Name
+	})
+}
+
+// Format renders a lookup's responders for a single line of markdown or
+// text, e.g. "Ada Lovelace <ada@example.com> (L1), Grace Hopper (L2)".
+func (l Lookup) Format() string {
+	if l.Err != "" {
+		return "unavailable: " + l.Err
+	}
+	if len(l.Responders) == 0 {
+		return "nobody is on call"
+	}
+	parts := make([]string, len(l.Responders))
+	for i, r := range l.Responders {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func envOr(name, fallback string) string {
+	if name != "" {
+		return name
+	}
+	return fallback
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
@@ -0,0 +1,157 @@
+package oncall
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+func TestParseSchedule(t *testing.T) {
+	s, err := ParseSchedule("PagerDuty:PABC123")
+	if err != nil || s.Provider != ProviderPagerDuty || s.ID != "PABC123" {
+		t.Fatalf("ParseSchedule = %+v, %v", s, err)
+	}
+	if s.String() != "pagerduty:PABC123" {
+		t.Errorf("String() = %q", s.String())
+	}
+	for _, bad := range []string{"PABC123", "pagerduty:", "victorops:team"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPagerDutyOnCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oncalls" || r.URL.Query().Get("schedule_ids[]") != "PSCHED" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Token token=secret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"oncalls": [
+			{"escalation_level": 2, "user": {"name": "Grace Hopper", "email": "grace@example.com"}},
+			{"escalation_level": 1, "user": {"name": "Ada Lovelace", "email": "ada@example.com"}},
+			{"escalation_level": 1, "user": {"name": "Ada Lovelace", "email": "ada@example.com"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	pd := &PagerDuty{Token: "secret", BaseURL: srv.URL}
+	got, err := pd.OnCall(context.Background(), "PSCHED")
+	if err != nil {
+		t.Fatalf("OnCall: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected duplicate shifts to collapse, got %+v", got)
+	}
+}
+
+func TestOpsgenieOnCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/schedules/payments/on-calls" || r.Header.Get("Authorization") != "GenieKey key" {
+			t.Errorf("unexpected request %s (%s)", r.URL, r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"data": {"onCallRecipients": ["ada@example.com"]}}`))
+	}))
+	defer srv.Close()
+
+	og := &Opsgenie{APIKey: "key", BaseURL: srv.URL}
+	got, err := og.OnCall(context.Background(), "payments")
+	if err != nil {
+		t.Fatalf("OnCall: %v", err)
+	}
+	if len(got) != 1 || got[0].Email != "ada@example.com" || got[0].String() != "ada@example.com" {
+		t.Errorf("unexpected responders %+v", got)
+	}
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+	}))
+	defer bad.Close()
+	og.BaseURL = bad.URL
+	if _, err := og.OnCall(context.Background(), "payments"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+type fakeProvider struct {
+	calls      int
+	responders []Responder
+	err        error
+}
+
+func (f *fakeProvider) OnCall(ctx context.Context, scheduleID string) ([]Responder, error) {
+	f.calls++
+	return f.responders, f.err
+}
+
+type fakeFacts []contextengine.Fact
+
+func (f fakeFacts) GetCurrentFacts(ctx context.Context, repoID, scope, scopeID string) ([]contextengine.Fact, error) {
+	var out []contextengine.Fact
+	for _, fact := range f {
+		if fact.Scope == scope && fact.ScopeID == scopeID {
+			out = append(out, fact)
+		}
+	}
+	return out, nil
+}
+
+func TestResolverLookup(t *testing.T) {
+	pd := &fakeProvider{responders: []Responder{
+		{Name: "Grace Hopper", Level: 2},
+		{Name: "Ada Lovelace", Email: "ada@example.com", Level: 1},
+	}}
+	r := &Resolver{
+		Schedules: map[string]string{"payments": "pagerduty:P1", "billing": "pagerduty:P1"},
+		Facts: fakeFacts{
+			{Scope: "service", ScopeID: "ledger", Key: FactKey, Value: "opsgenie:ledger"},
+			{Scope: "service", ScopeID: "payments", Key: FactKey, Value: "opsgenie:ignored"},
+		},
+		Providers: map[string]Provider{
+			ProviderPagerDuty: pd,
+			ProviderOpsgenie:  &fakeProvider{err: errors.New("status 401")},
+		},
+	}
+	ctx := context.Background()
+
+	l := r.Lookup(ctx, "payments")
+	if l == nil || l.Schedule != "pagerduty:P1" {
+		t.Fatalf("config schedule should win over facts, got %+v", l)
+	}
+	if want := "Ada Lovelace <ada@example.com> (L1), Grace Hopper (L2)"; l.Format() != want {
+		t.Errorf("Format() = %q, want %q", l.Format(), want)
+	}
+	if billing := r.Lookup(ctx, "billing"); billing.Service != "billing" || pd.calls != 1 {
+		t.Errorf("expected a cached lookup for the shared schedule, got %+v after %d calls", billing, pd.calls)
+	}
+
+	if l := r.Lookup(ctx, "ledger"); l == nil || !strings.Contains(l.Format(), "unavailable: status 401") {
+		t.Errorf("expected the fact schedule and its fetch error, got %+v", l)
+	}
+	if l := r.Lookup(ctx, "search"); l != nil {
+		t.Errorf("service without a schedule should have no lookup, got %+v", l)
+	}
+	if got := r.LookupAll(ctx, []string{"search", "payments", "ledger"}); len(got) != 2 {
+		t.Errorf("LookupAll returned %d lookups, want 2", len(got))
+	}
+}
+
+func TestNewResolverMissingCredentials(t *testing.T) {
+	t.Setenv("PD_KEY", "")
+	r := NewResolver(config.OnCallConfig{
+		Schedules:         map[string]string{"payments": "pagerduty:P1"},
+		PagerDutyTokenEnv: "PD_KEY",
+	}, nil)
+	l := r.Lookup(context.Background(), "payments")
+	if l == nil || l.Err != "pagerduty is not configured (set PD_KEY)" {
+		t.Errorf("unexpected lookup %+v", l)
+	}
+}
@@ -0,0 +1,121 @@
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PagerDuty reads on-call shifts from the PagerDuty REST API.
+type PagerDuty struct {
+	Token   string // REST API key
+	BaseURL string // defaults to https://api.pagerduty.com
+}
+
+// OnCall returns who is on call for the schedule, one entry per escalation
+// level the schedule is used at.
+func (p *PagerDuty) OnCall(ctx context.Context, scheduleID string) ([]Responder, error) {
+	q := url.Values{}
+	q.Set("schedule_ids[]", scheduleID)
+	q.Set("include[]", "users")
+	endpoint := strings.TrimRight(orDefault(p.BaseURL, "https://api.pagerduty.com"), "/") + "/oncalls?" + q.Encode()
+
+	var body struct {
+		Oncalls []struct {
+			EscalationLevel int `json:"escalation_level"`
+			User            struct {
+				Name    string `json:"name"`
+				Summary string `json:"summary"`
+				Email   string `json:"email"`
+			} `json:"user"`
+		} `json:"oncalls"`
+	}
+	headers := map[string]string{
+		"Authorization": "Token token=" + p.Token,
+		"Accept":        "application/vnd.pagerduty+json;version=2",
+	}
+	if err := getJSON(ctx, endpoint, headers, &body); err != nil {
+		return nil, fmt.Errorf("pagerduty schedule %s: %w", scheduleID, err)
+	}
+
+	seen := make(map[string]bool)
+	var out []Responder
+	for _, oc := range body.Oncalls {
+		name := orDefault(oc.User.Name, oc.User.Summary)
+		key := fmt.Sprintf("%s|%d", name, oc.EscalationLevel)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, Responder{Name: name, Email: oc.User.Email, Level: oc.EscalationLevel})
+	}
+	return out, nil
+}
+
+// Opsgenie reads on-call participants from the Opsgenie schedules API.
+type Opsgenie struct {
+	APIKey  string
+	BaseURL string // defaults to https://api.opsgenie.com; use https://api.eu.opsgenie.com for EU accounts
+}
+
+// OnCall returns the schedule's current on-call recipients. Opsgenie
+// identifies users by their email address, which is used as the name.
+func (o *Opsgenie) OnCall(ctx context.Context, scheduleID string) ([]Responder, error) {
+	endpoint := fmt.Sprintf("%s/v2/schedules/%s/on-calls?flat=true",
+		strings.TrimRight(orDefault(o.BaseURL, "https://api.opsgenie.com"), "/"), url.PathEscape(scheduleID))
+
+	var body struct {
+		Data struct {
+			OnCallRecipients []string `json:"onCallRecipients"`
+		} `json:"data"`
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + o.APIKey}
+	if err := getJSON(ctx, endpoint, headers, &body); err != nil {
+		return nil, fmt.Errorf("opsgenie schedule %s: %w", scheduleID, err)
+	}
+
+	out := make([]Responder, 0, len(body.Data.OnCallRecipients))
+	for _, r := range body.Data.OnCallRecipients {
+		resp := Responder{Name: r}
+		if strings.Contains(r, "@") {
+			resp.Email = r
+		}
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// getJSON GETs endpoint and decodes the JSON response into v.
+func getJSON(ctx context.Context, endpoint string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func orDefault(v, fallback string) string {
+	if v != "" {
+		return v
+	}
+	return fallback
+}
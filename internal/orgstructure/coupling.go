@@ -0,0 +1,176 @@
+package orgstructure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Dependency is a directed link between two services, as discovered by the
+// registry's linker.
+type Dependency struct {
+	From string
+	To   string
+	Type string
+}
+
+// TeamMatrix is the service dependency graph projected onto team ownership.
+type TeamMatrix struct {
+	Teams []string `json:"teams"`
+	// Counts[from][to] is the number of links from services owned by one team
+	// to services owned by another. Counts[t][t] counts links within a team.
+	Counts     map[string]map[string]int `json:"counts"`
+	Boundaries []TeamBoundary            `json:"boundaries"`
+	Hotspots   []Hotspot                 `json:"hotspots"`
+	CrossTeam  int                       `json:"cross_team_links"`
+	Unowned    int                       `json:"unowned_links"` // links touching a service no team owns
+}
+
+// TeamBoundary summarises the links crossing one team's boundary.
+type TeamBoundary struct {
+	Team     string `json:"team"`
+	Outbound int    `json:"outbound"`
+	Inbound  int    `json:"inbound"`
+	Internal int    `json:"internal"`
+	Partners int    `json:"partners"` // distinct teams on the other side
+}
+
+// Hotspot is a pair of teams whose boundary carries a disproportionate share
+// of the cross-team links: a sign the organisation and the architecture
+// disagree (Conway's law).
+type Hotspot struct {
+	TeamA string  `json:"team_a"`
+	TeamB string  `json:"team_b"`
+	Links int     `json:"links"` // both directions
+	Share float64 `json:"share"` // fraction of all cross-team links
+}
+
+// hotspotMinLinks is the fewest links a team pair needs to be a hotspot.
+const hotspotMinLinks = 3
+
+// BuildTeamMatrix projects deps onto teams. owners maps service names to the
+// teams that own them; a link between services co-owned by several teams
+// counts once for each owner pair. A pair of teams is a hotspot when its
+// boundary carries at least hotspotMinLinks links and at least one and a half
+// times the average pair's share, or carries every cross-team link.
+func BuildTeamMatrix(owners map[string][]string, deps []Dependency) *TeamMatrix {
+	m := &TeamMatrix{Counts: make(map[string]map[string]int)}
+	teamSet := make(map[string]bool)
+	for _, teams := range owners {
+		for _, t := range teams {
+			teamSet[t] = true
+		}
+	}
+	for t := range teamSet {
+		m.Teams = append(m.Teams, t)
+		m.Counts[t] = make(map[string]int)
+	}
+	sort.Strings(m.Teams)
+
+	pairs := make(map[[2]string]int)
+	for _, d := range deps {
+		from, to := owners[d.From], owners[d.To]
+		if len(from) == 0 || len(to) == 0 {
+			m.Unowned++
+			continue
+		}
+		for _, ft := range from {
+			for _, tt := range to {
+				m.Counts[ft][tt]++
+				if ft != tt {
+					m.CrossTeam++
+					pairs[pairKey(ft, tt)]++
+				}
+			}
+		}
+	}
+
+	for _, t := range m.Teams {
+		b := TeamBoundary{Team: t, Internal: m.Counts[t][t]}
+		partners := make(map[string]bool)
+		for _, other := range m.Teams {
+			if other == t {
+				continue
+			}
+			if n := m.Counts[t][other]; n > 0 {
+				b.Outbound += n
+				partners[other] = true
+			}
+			if n := m.Counts[other][t]; n > 0 {
+				b.Inbound += n
+				partners[other] = true
+			}
+		}
+		b.Partners = len(partners)
+		m.Boundaries = append(m.Boundaries, b)
+	}
+	sort.SliceStable(m.Boundaries, func(i, j int) bool {
+		return m.Boundaries[i].Inbound+m.Boundaries[i].Outbound > m.Boundaries[j].Inbound+m.Boundaries[j].Outbound
+	})
+
+	if len(pairs) > 0 {
+		average := float64(m.CrossTeam) / float64(len(pairs))
+		for key, n := range pairs {
+			if n >= hotspotMinLinks && (len(pairs) == 1 || float64(n) >= 1.5*average) {
+				m.Hotspots = append(m.Hotspots, Hotspot{TeamA: key[0], TeamB: key[1], Links: n, Share: float64(n) / float64(m.CrossTeam)})
+			}
+		}
+		sort.Slice(m.Hotspots, func(i, j int) bool {
+			if m.Hotspots[i].Links != m.Hotspots[j].Links {
+				return m.Hotspots[i].Links > m.Hotspots[j].Links
+			}
+			return m.Hotspots[i].TeamA+m.Hotspots[i].TeamB < m.Hotspots[j].TeamA+m.Hotspots[j].TeamB
+		})
+	}
+	return m
+}
+
+// Max returns the largest cross-team cell, for scaling a heatmap.
+func (m *TeamMatrix) Max() int {
+	highest := 0
+	for from, row := range m.Counts {
+		for to, n := range row {
+			if from != to && n > highest {
+				highest = n
+			}
+		}
+	}
+	return highest
+}
+
+// pairKey orders a team pair so both directions share a key.
+func pairKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// ServiceOwners maps each owned service to the names of its owning teams.
+func (s *Store) ServiceOwners(ctx context.Context) (map[string][]string, error) {
+	teams, err := s.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing teams: %w", err)
+	}
+	owners := make(map[string][]string)
+	for _, t := range teams {
+		ownerships, err := s.ListOwnerships(ctx, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("listing ownerships for %s: %w", t.Name, err)
+		}
+		for _, o := range ownerships {
+			owners[o.RepoID] = append(owners[o.RepoID], t.Name)
+		}
+	}
+	return owners, nil
+}
+
+// TeamMatrix builds the team dependency matrix for deps from the stored
+// ownership.
+func (s *Store) TeamMatrix(ctx context.Context, deps []Dependency) (*TeamMatrix, error) {
+	owners, err := s.ServiceOwners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTeamMatrix(owners, deps), nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -375,3 +376,74 @@ func TestHTTPGetOwnership(t *testing.T) {
 		t.Fatalf("got %d ownerships, want 1", len(ownerships))
 	}
 }
+
+// --- Team coupling tests ---
+
+func TestBuildTeamMatrix(t *testing.T) {
+	owners := map[string][]string{
+		"orders":   {"checkout"},
+		"cart":     {"checkout"},
+		"payments": {"payments"},
+		"ledger":   {"payments"},
+		"search":   {"discovery"},
+	}
+	deps := []Dependency{
+		{From: "orders", To: "payments"},
+		{From: "orders", To: "ledger"},
+		{From: "cart", To: "payments"},
+		{From: "payments", To: "orders"},
+		{From: "payments", To: "ledger"},
+		{From: "cart", To: "search"},
+		{From: "search", To: "inventory"}, // inventory has no owner
+	}
+
+	m := BuildTeamMatrix(owners, deps)
+	if got := strings.Join(m.Teams, ","); got != "checkout,discovery,payments" {
+		t.Errorf("Teams = %s", got)
+	}
+	if m.Counts["checkout"]["payments"] != 3 || m.Counts["payments"]["checkout"] != 1 || m.Counts["payments"]["payments"] != 1 {
+		t.Errorf("Counts = %v", m.Counts)
+	}
+	if m.CrossTeam != 5 || m.Unowned != 1 {
+		t.Errorf("CrossTeam = %d, Unowned = %d", m.CrossTeam, m.Unowned)
+	}
+	if m.Max() != 3 {
+		t.Errorf("Max = %d, want 3", m.Max())
+	}
+
+	if len(m.Hotspots) != 1 {
+		t.Fatalf("Hotspots = %+v", m.Hotspots)
+	}
+	if h := m.Hotspots[0]; h.TeamA != "checkout" || h.TeamB != "payments" || h.Links != 4 || h.Share != 0.8 {
+		t.Errorf("hotspot = %+v", h)
+	}
+
+	if b := m.Boundaries[0]; b.Team != "checkout" || b.Outbound != 4 || b.Inbound != 1 || b.Partners != 2 {
+		t.Errorf("busiest boundary = %+v", b)
+	}
+}
+
+func TestStoreTeamMatrix(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	for name, repos := range map[string][]string{"web": {"frontend"}, "api": {"backend", "worker"}} {
+		team := &Team{Name: name, Source: "manual"}
+		if err := store.CreateTeam(ctx, team); err != nil {
+			t.Fatalf("CreateTeam: %v", err)
+		}
+		for _, repo := range repos {
+			if err := store.SetOwnership(ctx, &ServiceOwnership{TeamID: team.ID, RepoID: repo, Confidence: "high", Source: "manual"}); err != nil {
+				t.Fatalf("SetOwnership: %v", err)
+			}
+		}
+	}
+
+	m, err := store.TeamMatrix(ctx, []Dependency{{From: "frontend", To: "backend"}, {From: "backend", To: "worker"}})
+	if err != nil {
+		t.Fatalf("TeamMatrix: %v", err)
+	}
+	if m.Counts["web"]["api"] != 1 || m.Counts["api"]["api"] != 1 {
+		t.Errorf("Counts = %v", m.Counts)
+	}
+}
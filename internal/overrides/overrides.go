@@ -0,0 +1,207 @@
+// Package overrides turns corrections given in conversation, and stored as
+// context-engine facts, into link and flow overrides that the central site
+// generator applies on every regeneration, whatever the analysis finds.
+package overrides
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// Fact keys. A link correction is a fact scoped to the calling service with
+// the key "calls:<callee>"; a flow correction is scoped to the flow.
+const (
+	// LinkKeyPrefix is followed by the callee, e.g. "calls:payment-service".
+	// The value is "no" when the caller does not call the callee, or "yes"
+	// optionally followed by the link type and endpoints, e.g.
+	// "yes http POST /charges, POST /refunds".
+	LinkKeyPrefix = "calls:"
+	// FlowExcludeKey set to "yes" drops the flow.
+	FlowExcludeKey = "exclude"
+	// FlowServicesKey is the flow's services in order, e.g.
+	// "web -> orders -> payments". It defines the flow if none exists.
+	FlowServicesKey = "services"
+	// AuthKeyPrefix is followed by an endpoint of the service, e.g.
+	// "auth:GET /health", or "auth:*" for all of them. The value is "yes"
+	// optionally followed by how the endpoint authenticates, e.g.
+	// "yes API gateway JWT", "no" when it doesn't, or "public" when it is
+	// open to anyone on purpose.
+	AuthKeyPrefix = "auth:"
+)
+
+// linkTypes are the link types a "yes" value may name before its endpoints.
+var linkTypes = map[string]bool{"http": true, "grpc": true, "kafka": true, "amqp": true, "event": true, "graphql": true, "websocket": true}
+
+// Override is one correction, with where it came from.
+type Override struct {
+	// Link corrections.
+	From      string   `json:"from,omitempty"`
+	To        string   `json:"to,omitempty"`
+	LinkType  string   `json:"link_type,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	// Flow corrections.
+	Flow     string   `json:"flow,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// Auth corrections: whether Service's Endpoint requires authentication.
+	// Remove means it doesn't; Public means it doesn't on purpose.
+	Service  string `json:"service,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"` // "METHOD /path", or "*" for every endpoint
+	AuthBy   string `json:"auth_by,omitempty"`
+	Public   bool   `json:"public,omitempty"`
+	// Remove suppresses the link or flow; otherwise it is added or redefined.
+	Remove bool `json:"remove"`
+
+	FactID     string    `json:"fact_id"`
+	ProvidedBy string    `json:"provided_by,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// IsFlow reports whether the override corrects a flow rather than a link.
+func (o Override) IsFlow() bool { return o.Flow != "" }
+
+// IsAuth reports whether the override corrects whether an endpoint
+// requires authentication.
+func (o Override) IsAuth() bool { return o.Endpoint != "" }
+
+// Describe renders the correction, e.g. "orders no longer calls payments".
+func (o Override) Describe() string {
+	endpoint := o.Service + " " + o.Endpoint
+	if o.Endpoint == "*" {
+		endpoint = "every " + o.Service + " endpoint"
+	}
+	switch {
+	case o.IsAuth() && o.Public:
+		return endpoint + " is public on purpose"
+	case o.IsAuth() && o.Remove:
+		return endpoint + " requires no authentication"
+	case o.IsAuth() && o.AuthBy != "":
+		return fmt.Sprintf("%s requires authentication (%s)", endpoint, o.AuthBy)
+	case o.IsAuth():
+		return endpoint + " requires authentication"
+	case o.IsFlow() && o.Remove:
+		return fmt.Sprintf("flow %q is not a real flow", o.Flow)
+	case o.IsFlow():
+		return fmt.Sprintf("flow %q goes %s", o.Flow, strings.Join(o.Services, " → "))
+	case o.Remove:
+		return fmt.Sprintf("%s no longer calls %s", o.From, o.To)
+	}
+	s := fmt.Sprintf("%s calls %s", o.From, o.To)
+	if o.LinkType != "" {
+		s += " (" + o.LinkType + ")"
+	}
+	if len(o.Endpoints) > 0 {
+		s += ": " + strings.Join(o.Endpoints, ", ")
+	}
+	return s
+}
+
+// Provenance renders who made the correction and when, e.g.
+// "ada, 2026-03-04".
+func (o Override) Provenance() string {
+	who := o.ProvidedBy
+	if who == "" {
+		who = "unknown"
+	}
+	if o.At.IsZero() {
+		return who
+	}
+	return who + ", " + o.At.Format("2006-01-02")
+}
+
+// FromFact parses a correction fact. It reports false for facts that are
+// not corrections, or whose value doesn't parse.
+func FromFact(f contextengine.Fact) (Override, bool) {
+	o := Override{FactID: f.ID, ProvidedBy: f.ProvidedBy, At: f.UpdatedAt}
+	value := strings.TrimSpace(f.Value)
+	switch {
+	case f.Scope == "service" && strings.HasPrefix(f.Key, LinkKeyPrefix):
+		o.From, o.To = f.ScopeID, strings.TrimSpace(strings.TrimPrefix(f.Key, LinkKeyPrefix))
+		if o.From == "" || o.To == "" {
+			return Override{}, false
+		}
+		yes, rest, ok := cutAnswer(value)
+		if !ok {
+			return Override{}, false
+		}
+		o.Remove = !yes
+		if yes {
+			if first, tail, _ := strings.Cut(rest, " "); linkTypes[strings.ToLower(first)] {
+				o.LinkType, rest = strings.ToLower(first), tail
+			}
+			for _, e := range strings.Split(rest, ",") {
+				if e = strings.TrimSpace(e); e != "" {
+					o.Endpoints = append(o.Endpoints, e)
+				}
+			}
+		}
+	case f.Scope == "service" && strings.HasPrefix(f.Key, AuthKeyPrefix):
+		o.Service, o.Endpoint = f.ScopeID, strings.Join(strings.Fields(strings.TrimPrefix(f.Key, AuthKeyPrefix)), " ")
+		if o.Service == "" || o.Endpoint == "" {
+			return Override{}, false
+		}
+		if first, _, _ := strings.Cut(value, " "); strings.EqualFold(strings.TrimRight(first, ",;:"), "public") {
+			o.Public, o.Remove = true, true
+			break
+		}
+		yes, rest, ok := cutAnswer(value)
+		if !ok {
+			return Override{}, false
+		}
+		o.Remove, o.AuthBy = !yes, rest
+	case f.Scope == "flow" && f.Key == FlowExcludeKey:
+		yes, _, ok := cutAnswer(value)
+		if !ok || !yes || f.ScopeID == "" {
+			return Override{}, false
+		}
+		o.Flow, o.Remove = f.ScopeID, true
+	case f.Scope == "flow" && f.Key == FlowServicesKey:
+		for _, s := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '>' || r == '→' }) {
+			if s = strings.Trim(strings.TrimSpace(s), "-"); s != "" {
+				o.Services = append(o.Services, strings.TrimSpace(s))
+			}
+		}
+		if f.ScopeID == "" || len(o.Services) < 2 {
+			return Override{}, false
+		}
+		o.Flow = f.ScopeID
+	default:
+		return Override{}, false
+	}
+	return o, true
+}
+
+// cutAnswer splits a yes/no value from whatever follows it.
+func cutAnswer(value string) (yes bool, rest string, ok bool) {
+	first, rest, _ := strings.Cut(value, " ")
+	switch strings.ToLower(strings.TrimRight(first, ",;:")) {
+	case "yes", "true", "added":
+		return true, strings.TrimSpace(rest), true
+	case "no", "false", "removed", "none":
+		return false, "", true
+	}
+	return false, "", false
+}
+
+// Load returns every current correction, oldest first, so a later
+// correction of the same link or flow is applied last.
+func Load(ctx context.Context, facts contextengine.FactSource) ([]Override, error) {
+	var out []Override
+	for _, scope := range []string{"service", "flow"} {
+		fs, err := facts.GetCurrentFacts(ctx, "", scope, "")
+		if err != nil {
+			return nil, fmt.Errorf("loading %s facts: %w", scope, err)
+		}
+		for _, f := range fs {
+			if o, ok := FromFact(f); ok {
+				out = append(out, o)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, nil
+}
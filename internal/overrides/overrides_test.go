@@ -0,0 +1,75 @@
+package overrides
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func TestFromFact(t *testing.T) {
+	cases := []struct {
+		fact contextengine.Fact
+		want string // Describe(), or "" when the fact is not a correction
+	}{
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:payments", Value: "no"}, "orders no longer calls payments"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:fraud", Value: "yes http POST /score, GET /score/{id}"}, "orders calls fraud (http): POST /score, GET /score/{id}"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:fraud", Value: "Yes"}, "orders calls fraud"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:fraud", Value: "sometimes"}, ""},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "owner", Value: "no"}, ""},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "auth:GET /health", Value: "public"}, "orders GET /health is public on purpose"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "auth:*", Value: "yes API gateway JWT"}, "every orders endpoint requires authentication (API gateway JWT)"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "auth:POST  /refunds", Value: "no"}, "orders POST /refunds requires no authentication"},
+		{contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "auth:", Value: "yes"}, ""},
+		{contextengine.Fact{Scope: "flow", ScopeID: "Refunds", Key: "exclude", Value: "yes"}, `flow "Refunds" is not a real flow`},
+		{contextengine.Fact{Scope: "flow", ScopeID: "Refunds", Key: "exclude", Value: "no"}, ""},
+		{contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "services", Value: "web -> orders → payments"}, `flow "Checkout" goes web → orders → payments`},
+		{contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "services", Value: "web"}, ""},
+	}
+	for _, c := range cases {
+		o, ok := FromFact(c.fact)
+		if got := ""; ok {
+			got = o.Describe()
+			if got != c.want {
+				t.Errorf("FromFact(%s=%q).Describe() = %q, want %q", c.fact.Key, c.fact.Value, got, c.want)
+			}
+		} else if c.want != "" {
+			t.Errorf("FromFact(%s=%q) was not parsed, want %q", c.fact.Key, c.fact.Value, c.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := contextengine.NewStore(database)
+
+	store.SaveFact(ctx, contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:payments", Value: "yes grpc", ProvidedBy: "ada"})
+	time.Sleep(time.Millisecond)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Refunds", Key: "exclude", Value: "yes", ProvidedBy: "grace"})
+	time.Sleep(time.Millisecond)
+	// A later correction supersedes the first.
+	store.SaveFact(ctx, contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "calls:payments", Value: "no", ProvidedBy: "grace"})
+	store.SaveFact(ctx, contextengine.Fact{Scope: "service", ScopeID: "orders", Key: "description", Value: "Takes orders."})
+
+	got, err := Load(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Load = %+v", got)
+	}
+	if got[0].Flow != "Refunds" || !got[1].Remove || got[1].To != "payments" {
+		t.Errorf("Load = %+v, want the flow exclusion then the link removal", got)
+	}
+	if p := got[1].Provenance(); !strings.HasPrefix(p, "grace, ") {
+		t.Errorf("Provenance() = %q", p)
+	}
+}
@@ -0,0 +1,118 @@
+// Package plugin manages shareable autodoc plugins: their manifests,
+// checksums and signatures, and the per-project lock file that pins the
+// installed versions.
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the manifest's file name in a plugin directory or archive.
+const ManifestFile = "plugin.yaml"
+
+// Capabilities a plugin can declare.
+const (
+	CapabilityAnalyzer  = "analyzer"  // extracts facts from source files
+	CapabilityDetector  = "detector"  // detects cross-service links or patterns
+	CapabilityPublisher = "publisher" // publishes generated docs elsewhere
+)
+
+var (
+	namePattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+)
+
+// Manifest describes a plugin. The checksum covers the artifact and the
+// signature covers the manifest fields, so a valid signature vouches for
+// the artifact's exact bytes.
+type Manifest struct {
+	Name         string   `yaml:"name"`
+	Version      string   `yaml:"version"`
+	Description  string   `yaml:"description,omitempty"`
+	Capabilities []string `yaml:"capabilities"`
+	Artifact     string   `yaml:"artifact"` // file in the plugin directory
+	Checksum     string   `yaml:"checksum"` // sha256:<hex> of the artifact
+	Publisher    string   `yaml:"publisher,omitempty"`
+	Signature    string   `yaml:"signature,omitempty"` // base64 Ed25519 signature of SigningPayload
+}
+
+// LoadManifest reads the manifest from a plugin directory.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading plugin manifest: %w", err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing plugin manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest into a plugin directory.
+func (m *Manifest) Save(dir string) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshaling plugin manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o644)
+}
+
+// Validate checks the manifest's fields.
+func (m *Manifest) Validate() error {
+	if !namePattern.MatchString(m.Name) {
+		return fmt.Errorf("invalid plugin name %q: use lowercase letters, digits, and dashes", m.Name)
+	}
+	if !versionPattern.MatchString(m.Version) {
+		return fmt.Errorf("plugin %s: invalid version %q: must be semantic, e.g. 1.2.0", m.Name, m.Version)
+	}
+	if len(m.Capabilities) == 0 {
+		return fmt.Errorf("plugin %s: at least one capability is required", m.Name)
+	}
+	for _, c := range m.Capabilities {
+		switch c {
+		case CapabilityAnalyzer, CapabilityDetector, CapabilityPublisher:
+		default:
+			return fmt.Errorf("plugin %s: unknown capability %q", m.Name, c)
+		}
+	}
+	if m.Artifact == "" || m.Artifact != filepath.Base(m.Artifact) || m.Artifact == ManifestFile {
+		return fmt.Errorf("plugin %s: artifact must name a file in the plugin directory", m.Name)
+	}
+	if !strings.HasPrefix(m.Checksum, "sha256:") {
+		return fmt.Errorf("plugin %s: checksum must be sha256:<hex>", m.Name)
+	}
+	return nil
+}
+
+// SigningPayload is the canonical text a publisher signs.
+func (m *Manifest) SigningPayload() []byte {
+	caps := append([]string(nil), m.Capabilities...)
+	sort.Strings(caps)
+	return []byte(fmt.Sprintf("autodoc-plugin-v1\nname=%s\nversion=%s\ncapabilities=%s\nartifact=%s\nchecksum=%s\npublisher=%s\n",
+		m.Name, m.Version, strings.Join(caps, ","), m.Artifact, m.Checksum, m.Publisher))
+}
+
+// FileChecksum returns the sha256:<hex> checksum of a file.
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePlugin creates an unsigned plugin directory.
+func writePlugin(t *testing.T, version, artifact string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "detector.wasm"), []byte(artifact), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{
+		Name:         "kafka-topics",
+		Version:      version,
+		Capabilities: []string{CapabilityDetector},
+		Artifact:     "detector.wasm",
+	}
+	var err error
+	if m.Checksum, err = FileChecksum(filepath.Join(dir, m.Artifact)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testTrust(t *testing.T) (Trust, string) {
+	t.Helper()
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base64.StdEncoding.DecodeString(pub)
+	return Trust{Keys: map[string]ed25519.PublicKey{"acme": key}}, priv
+}
+
+func TestInstallSignedPlugin(t *testing.T) {
+	trust, priv := testTrust(t)
+	src := writePlugin(t, "1.0.0", "v1")
+	if _, err := Sign(src, "acme", priv); err != nil {
+		t.Fatal(err)
+	}
+
+	project := t.TempDir()
+	m, err := Install(context.Background(), project, src, trust)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if m.Publisher != "acme" {
+		t.Errorf("publisher = %q", m.Publisher)
+	}
+
+	lock, err := LoadLock(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Find("kafka-topics")
+	if !ok || entry.Version != "1.0.0" || entry.Checksum != m.Checksum {
+		t.Fatalf("lock entry = %+v", entry)
+	}
+
+	results, err := VerifyInstalled(project, trust)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("VerifyInstalled = %+v", results)
+	}
+
+	// Tampering with the installed artifact is detected.
+	artifact := filepath.Join(InstallDir(project, "kafka-topics", "1.0.0"), "detector.wasm")
+	if err := os.WriteFile(artifact, []byte("evil"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = VerifyInstalled(project, trust)
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "checksum") {
+		t.Errorf("tampered artifact not detected: %v", results[0].Err)
+	}
+}
+
+func TestInstallRejectsUntrusted(t *testing.T) {
+	trust, _ := testTrust(t)
+	project := t.TempDir()
+
+	src := writePlugin(t, "1.0.0", "v1")
+	if _, err := Install(context.Background(), project, src, trust); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned install err = %v, want ErrUnsigned", err)
+	}
+	trust.AllowUnsigned = true
+	if _, err := Install(context.Background(), project, src, trust); err != nil {
+		t.Errorf("unsigned install with AllowUnsigned: %v", err)
+	}
+
+	// Signed by a publisher that isn't trusted.
+	_, otherPriv := testTrust(t)
+	signed := writePlugin(t, "1.1.0", "v1.1")
+	if _, err := Sign(signed, "mallory", otherPriv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(context.Background(), project, signed, trust); err == nil {
+		t.Error("expected untrusted publisher to be rejected")
+	}
+
+	// A manifest edited after signing fails verification.
+	trust, priv := testTrust(t)
+	edited := writePlugin(t, "1.2.0", "v1.2")
+	m, err := Sign(edited, "acme", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Capabilities = append(m.Capabilities, CapabilityPublisher)
+	if err := m.Save(edited); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(context.Background(), project, edited, trust); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("edited manifest err = %v", err)
+	}
+}
+
+func TestInstallRejectsRepublishedVersion(t *testing.T) {
+	trust := Trust{AllowUnsigned: true}
+	project := t.TempDir()
+
+	if _, err := Install(context.Background(), project, writePlugin(t, "1.0.0", "original"), trust); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(context.Background(), project, writePlugin(t, "1.0.0", "changed"), trust); err == nil || !strings.Contains(err.Error(), "pinned") {
+		t.Errorf("republished version err = %v", err)
+	}
+	// A new version replaces the pin.
+	if _, err := Install(context.Background(), project, writePlugin(t, "1.1.0", "changed"), trust); err != nil {
+		t.Fatal(err)
+	}
+	lock, _ := LoadLock(project)
+	if len(lock.Plugins) != 1 || lock.Plugins[0].Version != "1.1.0" {
+		t.Errorf("lock = %+v", lock.Plugins)
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	base := Manifest{Name: "x", Version: "1.0.0", Capabilities: []string{CapabilityAnalyzer}, Artifact: "a.wasm", Checksum: "sha256:00"}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("valid manifest: %v", err)
+	}
+	for name, mutate := range map[string]func(*Manifest){
+		"bad name":       func(m *Manifest) { m.Name = "Bad Name" },
+		"bad version":    func(m *Manifest) { m.Version = "latest" },
+		"no caps":        func(m *Manifest) { m.Capabilities = nil },
+		"unknown cap":    func(m *Manifest) { m.Capabilities = []string{"root"} },
+		"artifact path":  func(m *Manifest) { m.Artifact = "../a.wasm" },
+		"bad checksum":   func(m *Manifest) { m.Checksum = "md5:00" },
+		"empty artifact": func(m *Manifest) { m.Artifact = "" },
+	} {
+		m := base
+		mutate(&m)
+		if err := m.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
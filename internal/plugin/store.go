@@ -0,0 +1,306 @@
+package plugin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxArtifactSize bounds each file extracted from a plugin archive.
+const maxArtifactSize = 256 << 20
+
+// LockEntry pins one installed plugin to an exact version and checksum.
+type LockEntry struct {
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Checksum     string    `json:"checksum"`
+	Publisher    string    `json:"publisher,omitempty"`
+	Capabilities []string  `json:"capabilities"`
+	Source       string    `json:"source"`
+	InstalledAt  time.Time `json:"installed_at"`
+}
+
+// Lock is the project's plugins.lock file.
+type Lock struct {
+	Plugins []LockEntry `json:"plugins"`
+}
+
+// pluginsDir returns the directory holding installed plugins.
+func pluginsDir(projectDir string) string {
+	return filepath.Join(projectDir, ".autodoc", "plugins")
+}
+
+// lockPath returns the path of the project's lock file.
+func lockPath(projectDir string) string {
+	return filepath.Join(projectDir, ".autodoc", "plugins.lock")
+}
+
+// InstallDir returns the directory an installed plugin version lives in.
+func InstallDir(projectDir, name, version string) string {
+	return filepath.Join(pluginsDir(projectDir), name, version)
+}
+
+// LoadLock reads the project's lock file. A missing file is an empty lock.
+func LoadLock(projectDir string) (*Lock, error) {
+	data, err := os.ReadFile(lockPath(projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading plugins.lock: %w", err)
+	}
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing plugins.lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// Save writes the lock file, sorted by plugin name.
+func (l *Lock) Save(projectDir string) error {
+	sort.Slice(l.Plugins, func(i, j int) bool { return l.Plugins[i].Name < l.Plugins[j].Name })
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling plugins.lock: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(lockPath(projectDir)), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(lockPath(projectDir), data, 0o644)
+}
+
+// Find returns the pinned entry for a plugin.
+func (l *Lock) Find(name string) (LockEntry, bool) {
+	for _, e := range l.Plugins {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return LockEntry{}, false
+}
+
+func (l *Lock) put(entry LockEntry) {
+	for i, e := range l.Plugins {
+		if e.Name == entry.Name {
+			l.Plugins[i] = entry
+			return
+		}
+	}
+	l.Plugins = append(l.Plugins, entry)
+}
+
+// Install fetches a plugin from source, verifies it, copies it into
+// .autodoc/plugins/<name>/<version>/, and pins it in plugins.lock. The
+// source is a plugin directory, a .tar.gz archive, or an http(s) URL of
+// an archive. Reinstalling a pinned version whose checksum differs from
+// the pin is refused, so a republished release cannot slip in unnoticed.
+func Install(ctx context.Context, projectDir, source string, trust Trust) (*Manifest, error) {
+	staging, err := os.MkdirTemp("", "autodoc-plugin-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := fetch(ctx, source, staging); err != nil {
+		return nil, fmt.Errorf("fetching plugin %s: %w", source, err)
+	}
+	m, err := LoadManifest(staging)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(staging, m, trust); err != nil {
+		return nil, err
+	}
+
+	lock, err := LoadLock(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if pinned, ok := lock.Find(m.Name); ok && pinned.Version == m.Version && pinned.Checksum != m.Checksum {
+		return nil, fmt.Errorf("plugin %s %s: checksum %s does not match pinned %s", m.Name, m.Version, m.Checksum, pinned.Checksum)
+	}
+
+	dest := InstallDir(projectDir, m.Name, m.Version)
+	if err := os.RemoveAll(dest); err != nil {
+		return nil, err
+	}
+	if err := copyDir(staging, dest); err != nil {
+		return nil, fmt.Errorf("installing plugin %s: %w", m.Name, err)
+	}
+
+	lock.put(LockEntry{
+		Name:         m.Name,
+		Version:      m.Version,
+		Checksum:     m.Checksum,
+		Publisher:    m.Publisher,
+		Capabilities: m.Capabilities,
+		Source:       source,
+		InstalledAt:  time.Now().UTC(),
+	})
+	if err := lock.Save(projectDir); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerifyResult is the outcome of checking one pinned plugin.
+type VerifyResult struct {
+	Entry LockEntry
+	Err   error
+}
+
+// VerifyInstalled checks every plugin pinned in plugins.lock: the installed
+// files must still match the pinned version and checksum and pass Verify.
+func VerifyInstalled(projectDir string, trust Trust) ([]VerifyResult, error) {
+	lock, err := LoadLock(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]VerifyResult, 0, len(lock.Plugins))
+	for _, e := range lock.Plugins {
+		results = append(results, VerifyResult{Entry: e, Err: verifyEntry(projectDir, e, trust)})
+	}
+	return results, nil
+}
+
+func verifyEntry(projectDir string, e LockEntry, trust Trust) error {
+	dir := InstallDir(projectDir, e.Name, e.Version)
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m.Name != e.Name || m.Version != e.Version {
+		return fmt.Errorf("installed manifest is %s %s, pinned %s %s", m.Name, m.Version, e.Name, e.Version)
+	}
+	if m.Checksum != e.Checksum {
+		return fmt.Errorf("plugin %s: manifest checksum %s does not match pinned %s", e.Name, m.Checksum, e.Checksum)
+	}
+	return Verify(dir, m, trust)
+}
+
+// fetch copies or extracts source into dir.
+func fetch(ctx context.Context, source, dir string) error {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return extractTarGz(resp.Body, dir)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return copyDir(source, dir)
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return extractTarGz(f, dir)
+}
+
+// extractTarGz unpacks the regular files of a gzipped tarball into dir.
+// Entries that would land outside dir are rejected.
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		name := filepath.Clean(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes the plugin directory", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if hdr.Size > maxArtifactSize {
+				return fmt.Errorf("archive entry %q is too large", hdr.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(target, io.LimitReader(tr, maxArtifactSize), hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// copyDir copies the regular files under src into dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFile(target, f, info.Mode().Perm())
+	})
+}
+
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// ErrUnsigned is returned for unsigned plugins unless unsigned plugins are allowed.
+var ErrUnsigned = errors.New("plugin is not signed")
+
+// Trust is the set of publisher keys plugins are verified against.
+type Trust struct {
+	Keys          map[string]ed25519.PublicKey
+	AllowUnsigned bool
+}
+
+// TrustFromConfig decodes the trusted publisher keys from config.
+func TrustFromConfig(cfg config.PluginsConfig) (Trust, error) {
+	trust := Trust{Keys: make(map[string]ed25519.PublicKey, len(cfg.TrustedKeys)), AllowUnsigned: cfg.AllowUnsigned}
+	for publisher, encoded := range cfg.TrustedKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return trust, fmt.Errorf("plugins.trusted_keys.%s: not a base64 Ed25519 public key", publisher)
+		}
+		trust.Keys[publisher] = ed25519.PublicKey(key)
+	}
+	return trust, nil
+}
+
+// Verify checks a plugin directory against its manifest: the manifest must
+// be valid, the artifact must match the checksum, and the signature must be
+// from a trusted publisher.
+func Verify(dir string, m *Manifest, trust Trust) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	sum, err := FileChecksum(filepath.Join(dir, m.Artifact))
+	if err != nil {
+		return fmt.Errorf("plugin %s: reading artifact: %w", m.Name, err)
+	}
+	if sum != m.Checksum {
+		return fmt.Errorf("plugin %s: artifact checksum %s does not match manifest %s", m.Name, sum, m.Checksum)
+	}
+
+	if m.Signature == "" {
+		if trust.AllowUnsigned {
+			return nil
+		}
+		return fmt.Errorf("plugin %s: %w (set plugins.allow_unsigned to accept it)", m.Name, ErrUnsigned)
+	}
+	key, ok := trust.Keys[m.Publisher]
+	if !ok {
+		return fmt.Errorf("plugin %s: publisher %q is not in plugins.trusted_keys", m.Name, m.Publisher)
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil || !ed25519.Verify(key, m.SigningPayload(), sig) {
+		return fmt.Errorf("plugin %s: invalid signature from %s", m.Name, m.Publisher)
+	}
+	return nil
+}
+
+// GenerateKey creates a publisher key pair, base64 encoded.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// Sign computes the artifact checksum of the plugin in dir, signs the
+// manifest as publisher with the base64 private key, and saves it.
+func Sign(dir, publisher, privateKey string) (*Manifest, error) {
+	key, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("not a base64 Ed25519 private key")
+	}
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.Checksum, err = FileChecksum(filepath.Join(dir, m.Artifact)); err != nil {
+		return nil, fmt.Errorf("plugin %s: reading artifact: %w", m.Name, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	m.Publisher = publisher
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(key), m.SigningPayload()))
+	return m, m.Save(dir)
+}
@@ -0,0 +1,242 @@
+package readmesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+)
+
+// DefaultBranch is the branch README updates are proposed from.
+const DefaultBranch = "autodoc/readme-sync"
+
+// GitHub proposes README updates, and other changes to source repos, as
+// pull requests.
+type GitHub struct {
+	Token  string
+	APIURL string // e.g. https://api.github.com, or a GitHub Enterprise API
+	Branch string // the branch changes are proposed from; defaults to DefaultBranch
+	Client *http.Client
+}
+
+// githubRemoteRe matches the owner and repo of an https or ssh GitHub
+// remote URL.
+var githubRemoteRe = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?[^/]+/|ssh://git@[^/]+/|git@[^:]+:)([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// RepoFromRemote returns the "owner/repo" a git remote URL points to.
+func RepoFromRemote(remote string) (string, bool) {
+	m := githubRemoteRe.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "/" + m[2], true
+}
+
+// README is a service's README to propose an update of: the service's
+// guide, and the subdirectory of the checkout the README is in.
+type README struct {
+	Subdir string
+	Guide  *onboarding.Guide
+}
+
+// Propose commits the updated READMEs of the services in the checkout at
+// repoDir and proposes them as one pull request. The services of a
+// monorepo share a checkout and the branch changes are proposed from, so
+// they are proposed together. It returns the pull request's URL and the
+// services whose README it updates, or "" when all are up to date.
+func (gh *GitHub) Propose(ctx context.Context, repoDir string, readmes []README) (string, []string, error) {
+	var updated []string
+	url, err := gh.ProposeChange(ctx, repoDir, readmeChange(readmes, &updated))
+	if err != nil || url == "" {
+		return "", nil, err
+	}
+	return url, updated, nil
+}
+
+// readmeChange writes each README, adding the services whose README
+// changed to updated.
+func readmeChange(readmes []README, updated *[]string) Change {
+	paths := make([]string, len(readmes))
+	services := make([]string, len(readmes))
+	for i, r := range readmes {
+		paths[i] = filepath.ToSlash(filepath.Join(r.Subdir, "README.md"))
+		services[i] = r.Guide.Service
+	}
+	c := Change{
+		Title: fmt.Sprintf("Update the %s service overview in README.md", services[0]),
+		Body:  "Regenerates the service overview section of `" + paths[0] + "` (between the `autodoc` markers) from the central documentation: summary, endpoints, dependencies, and owning team.",
+	}
+	if len(readmes) > 1 {
+		c.Title = fmt.Sprintf("Update the service overviews of %s in their READMEs", strings.Join(services, ", "))
+		c.Body = "Regenerates the service overview section (between the `autodoc` markers) of `" + strings.Join(paths, "`, `") + "` from the central documentation: summary, endpoints, dependencies, and owning team."
+	}
+	c.Apply = func(worktree string) ([]string, error) {
+		var changedPaths []string
+		for i, r := range readmes {
+			changed, err := Write(filepath.Join(worktree, filepath.FromSlash(r.Subdir)), r.Guide)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				changedPaths = append(changedPaths, paths[i])
+				*updated = append(*updated, services[i])
+			}
+		}
+		return changedPaths, nil
+	}
+	return c
+}
+
+// Change is a change to propose as a pull request.
+type Change struct {
+	Title string // the commit message and pull request title
+	Body  string
+	// Apply makes the change in a checkout and returns the paths it
+	// changed, relative to the checkout; none means there is nothing to
+	// propose.
+	Apply func(dir string) ([]string, error)
+}
+
+// ProposeChange applies a change to a branch of the checkout at repoDir,
+// pushes it to origin, and opens a pull request against the default
+// branch. The change is made in a temporary worktree, so the checkout
+// itself is left alone. It returns the pull request's URL, or "" when the
+// change changes nothing.
+func (gh *GitHub) ProposeChange(ctx context.Context, repoDir string, c Change) (string, error) {
+	remote, err := git(ctx, repoDir, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	slug, ok := RepoFromRemote(remote)
+	if !ok {
+		return "", fmt.Errorf("origin %s is not a GitHub repository", remote)
+	}
+	base := "main"
+	if ref, err := git(ctx, repoDir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		base = strings.TrimPrefix(ref, "origin/")
+	}
+	branch := gh.Branch
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	if _, err := git(ctx, repoDir, "fetch", "origin", base); err != nil {
+		return "", err
+	}
+
+	worktree, err := os.MkdirTemp("", "autodoc-pr-")
+	if err != nil {
+		return "", err
+	}
+	os.Remove(worktree) // git creates it
+	if _, err := git(ctx, repoDir, "worktree", "add", "-B", branch, worktree, "origin/"+base); err != nil {
+		return "", err
+	}
+	defer git(context.WithoutCancel(ctx), repoDir, "worktree", "remove", "--force", worktree)
+
+	paths, err := c.Apply(worktree)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	for _, args := range [][]string{
+		append([]string{"add", "--"}, paths...),
+		{"commit", "-m", c.Title},
+		{"push", "--force", "origin", branch},
+	} {
+		if _, err := git(ctx, worktree, args...); err != nil {
+			return "", err
+		}
+	}
+	return gh.openPullRequest(ctx, slug, branch, base, c.Title, c.Body)
+}
+
+// openPullRequest opens a pull request from head to base, or returns the
+// open one from head when there already is one.
+func (gh *GitHub) openPullRequest(ctx context.Context, slug, head, base, title, body string) (string, error) {
+	payload, _ := json.Marshal(map[string]string{"title": title, "head": head, "base": base, "body": body})
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	status, err := gh.do(ctx, http.MethodPost, "/repos/"+slug+"/pulls", payload, &created)
+	if err != nil && status != http.StatusUnprocessableEntity {
+		return "", err
+	}
+	if err == nil {
+		return created.HTMLURL, nil
+	}
+	// 422: most likely a pull request from head is already open.
+	owner, _, _ := strings.Cut(slug, "/")
+	var open []struct {
+		HTMLURL string `json:"html_url"`
+	}
+	query := url.Values{"head": {owner + ":" + head}, "state": {"open"}}
+	if _, listErr := gh.do(ctx, http.MethodGet, "/repos/"+slug+"/pulls?"+query.Encode(), nil, &open); listErr != nil || len(open) == 0 {
+		return "", err
+	}
+	return open[0].HTMLURL, nil
+}
+
+// OpenPullRequests counts the open pull requests of the repository slug,
+// "owner/repo".
+func (gh *GitHub) OpenPullRequests(ctx context.Context, slug string) (int, error) {
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	query := url.Values{"q": {"repo:" + slug + " is:pr is:open"}, "per_page": {"1"}}
+	if _, err := gh.do(ctx, http.MethodGet, "/search/issues?"+query.Encode(), nil, &result); err != nil {
+		return 0, err
+	}
+	return result.TotalCount, nil
+}
+
+// do calls the GitHub API and decodes the response into out.
+func (gh *GitHub) do(ctx context.Context, method, path string, payload []byte, out any) (int, error) {
+	apiURL := strings.TrimRight(gh.APIURL, "/")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+gh.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := gh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("GitHub API %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp.StatusCode, json.Unmarshal(data, out)
+}
+
+// git runs git in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
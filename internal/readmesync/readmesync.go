@@ -0,0 +1,130 @@
+// Package readmesync writes a generated overview of a service — its
+// summary, endpoints, dependencies, and owning team — into a marked
+// section of the service's README.md, so source repos stay
+// self-describing. Text outside the markers is left alone.
+package readmesync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+)
+
+// The markers around the generated section. Everything between them is
+// replaced on each sync.
+const (
+	BeginMarker = "<!-- autodoc:begin -->"
+	EndMarker   = "<!-- autodoc:end -->"
+)
+
+// Render renders the generated section for a service, markers included.
+func Render(g *onboarding.Guide) string {
+	var b strings.Builder
+	b.WriteString(BeginMarker + "\n")
+	b.WriteString("<!-- Generated by `autodoc sync-readme`; edits between these markers are overwritten. -->\n\n")
+	b.WriteString("## Service Overview\n\n")
+	if g.Purpose != "" {
+		b.WriteString(g.Purpose + "\n\n")
+	}
+
+	if len(g.Owners) > 0 {
+		owners := make([]string, len(g.Owners))
+		for i, o := range g.Owners {
+			owners[i] = "**" + o.Team + "**"
+			var contacts []string
+			if o.SlackChannel != "" {
+				contacts = append(contacts, o.SlackChannel)
+			}
+			if o.Email != "" {
+				contacts = append(contacts, o.Email)
+			}
+			if len(contacts) > 0 {
+				owners[i] += " (" + strings.Join(contacts, ", ") + ")"
+			}
+		}
+		b.WriteString("**Owned by:** " + strings.Join(owners, ", ") + "\n\n")
+	}
+
+	var endpoints []string
+	for _, ep := range g.EntryPoints {
+		for _, r := range ep.Routes {
+			endpoints = append(endpoints, fmt.Sprintf("- `%s` (`%s`)", r, ep.File))
+		}
+	}
+	if len(endpoints) > 0 {
+		b.WriteString("### Endpoints\n\n")
+		b.WriteString(strings.Join(endpoints, "\n") + "\n\n")
+	}
+
+	if len(g.Dependencies) > 0 || len(g.Consumers) > 0 {
+		b.WriteString("### Dependencies\n\n")
+		for _, l := range g.Dependencies {
+			b.WriteString("- Calls **" + l.ToRepo + "**" + linkDetail(l.LinkType, l.Endpoints) + "\n")
+		}
+		for _, l := range g.Consumers {
+			b.WriteString("- Called by **" + l.FromRepo + "**" + linkDetail(l.LinkType, l.Endpoints) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(EndMarker + "\n")
+	return b.String()
+}
+
+// linkDetail renders a link's type and endpoints, e.g.
+// " (http: POST /charges)".
+func linkDetail(linkType string, endpoints []string) string {
+	switch {
+	case linkType == "" && len(endpoints) == 0:
+		return ""
+	case len(endpoints) == 0:
+		return " (" + linkType + ")"
+	case linkType == "":
+		return " (" + strings.Join(endpoints, ", ") + ")"
+	}
+	return " (" + linkType + ": " + strings.Join(endpoints, ", ") + ")"
+}
+
+// Update returns readme with its generated section replaced by section,
+// or with section appended when it has none. An empty readme becomes a
+// title and the section.
+func Update(readme []byte, section, title string) []byte {
+	if len(bytes.TrimSpace(readme)) == 0 {
+		return []byte("# " + title + "\n\n" + section)
+	}
+	begin := bytes.Index(readme, []byte(BeginMarker))
+	end := -1
+	if begin >= 0 {
+		if i := bytes.Index(readme[begin:], []byte(EndMarker)); i >= 0 {
+			end = begin + i + len(EndMarker)
+		}
+	}
+	if end < 0 {
+		out := bytes.TrimRight(readme, "\n")
+		return append(append(out, "\n\n"...), section...)
+	}
+	if end < len(readme) && readme[end] == '\n' {
+		end++
+	}
+	out := append([]byte{}, readme[:begin]...)
+	out = append(out, section...)
+	return append(out, readme[end:]...)
+}
+
+// Write updates the README.md in dir with the service's generated section
+// and reports whether it changed.
+func Write(dir string, g *onboarding.Guide) (bool, error) {
+	path := filepath.Join(dir, "README.md")
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	updated := Update(existing, Render(g), g.Service)
+	if bytes.Equal(existing, updated) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, updated, 0o644)
+}
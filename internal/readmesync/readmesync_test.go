@@ -0,0 +1,161 @@
+package readmesync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/incident"
+	"github.com/ziadkadry99/auto-doc/internal/onboarding"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+func testGuide() *onboarding.Guide {
+	return &onboarding.Guide{
+		Service:      "payments",
+		Purpose:      "Charges cards and issues refunds.",
+		EntryPoints:  []onboarding.EntryPoint{{File: "api/routes.go", Kind: "routes", Routes: []string{"POST /charges"}}},
+		Dependencies: []registry.ServiceLink{{FromRepo: "payments", ToRepo: "ledger", LinkType: "grpc"}},
+		Consumers:    []registry.ServiceLink{{FromRepo: "orders", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /charges"}}},
+		Owners:       []incident.Owner{{Team: "payments-team", SlackChannel: "#payments"}},
+	}
+}
+
+func TestRender(t *testing.T) {
+	got := Render(testGuide())
+	for _, want := range []string{
+		BeginMarker,
+		"Charges cards and issues refunds.",
+		"**Owned by:** **payments-team** (#payments)",
+		"- `POST /charges` (`api/routes.go`)",
+		"- Calls **ledger** (grpc)",
+		"- Called by **orders** (http: POST /charges)",
+		EndMarker + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	section := BeginMarker + "\nnew\n" + EndMarker + "\n"
+
+	got := string(Update([]byte("# Payments\n\nIntro.\n\n"+BeginMarker+"\nold\n"+EndMarker+"\n\n## Development\n"), section, "payments"))
+	if want := "# Payments\n\nIntro.\n\n" + section + "\n## Development\n"; got != want {
+		t.Errorf("replace = %q, want %q", got, want)
+	}
+	got = string(Update([]byte("# Payments\n\nIntro.\n"), section, "payments"))
+	if want := "# Payments\n\nIntro.\n\n" + section; got != want {
+		t.Errorf("append = %q, want %q", got, want)
+	}
+	got = string(Update(nil, section, "payments"))
+	if want := "# payments\n\n" + section; got != want {
+		t.Errorf("empty = %q, want %q", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Payments\n\nHand-written.\n"), 0o644)
+	if changed, err := Write(dir, testGuide()); err != nil || !changed {
+		t.Fatalf("first Write = %v, %v", changed, err)
+	}
+	if changed, err := Write(dir, testGuide()); err != nil || changed {
+		t.Errorf("second Write = %v, %v, want unchanged", changed, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	if !strings.HasPrefix(string(data), "# Payments\n\nHand-written.\n\n"+BeginMarker) {
+		t.Errorf("README = %q", data)
+	}
+}
+
+func TestReadmeChange(t *testing.T) {
+	// The services of a monorepo go in one change, so the one branch
+	// carries all their READMEs.
+	dir := t.TempDir()
+	payments, ledger := testGuide(), testGuide()
+	ledger.Service = "ledger"
+	for _, svc := range []string{"payments", "ledger"} {
+		os.MkdirAll(filepath.Join(dir, "services", svc), 0o755)
+	}
+	if _, err := Write(filepath.Join(dir, "services", "ledger"), ledger); err != nil {
+		t.Fatal(err)
+	}
+
+	var updated []string
+	c := readmeChange([]README{{Subdir: "services/payments", Guide: payments}, {Subdir: "services/ledger", Guide: ledger}}, &updated)
+	if !strings.Contains(c.Title, "payments, ledger") {
+		t.Errorf("title = %q", c.Title)
+	}
+	paths, err := c.Apply(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "services/payments/README.md" || len(updated) != 1 || updated[0] != "payments" {
+		t.Errorf("Apply changed %v, updated %v; want only the payments README", paths, updated)
+	}
+}
+
+func TestRepoFromRemote(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/acme/payments.git":       "acme/payments",
+		"https://x-token@github.com/acme/payments":   "acme/payments",
+		"git@github.com:acme/payments.git":           "acme/payments",
+		"ssh://git@github.example.com/acme/payments": "acme/payments",
+		"/srv/git/payments":                          "",
+	}
+	for remote, want := range cases {
+		if got, _ := RepoFromRemote(remote); got != want {
+			t.Errorf("RepoFromRemote(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	var posted map[string]string
+	existing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && existing:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"A pull request already exists"}`))
+		case r.Method == http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"html_url":"https://github.com/acme/payments/pull/1"}`))
+		case r.URL.Query().Get("head") == "acme:"+DefaultBranch:
+			w.Write([]byte(`[{"html_url":"https://github.com/acme/payments/pull/1"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	gh := &GitHub{Token: "secret", APIURL: srv.URL}
+	url, err := gh.openPullRequest(context.Background(), "acme/payments", DefaultBranch, "main", "Update README", "body")
+	if err != nil || url != "https://github.com/acme/payments/pull/1" {
+		t.Fatalf("openPullRequest = %q, %v", url, err)
+	}
+	if posted["head"] != DefaultBranch || posted["base"] != "main" {
+		t.Errorf("posted %v", posted)
+	}
+
+	existing = true
+	url, err = gh.openPullRequest(context.Background(), "acme/payments", DefaultBranch, "main", "Update README", "body")
+	if err != nil || url != "https://github.com/acme/payments/pull/1" {
+		t.Errorf("openPullRequest with an open PR = %q, %v", url, err)
+	}
+	if _, err := gh.openPullRequest(context.Background(), "acme/payments", "other", "main", "Update README", "body"); err == nil {
+		t.Error("openPullRequest: want the 422 error when no open PR is found")
+	}
+}
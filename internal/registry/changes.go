@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeKind identifies a kind of architecture change.
+type ChangeKind string
+
+const (
+	ChangeDependencyAdded   ChangeKind = "dependency_added"
+	ChangeDependencyRemoved ChangeKind = "dependency_removed"
+	ChangeDependencyChanged ChangeKind = "dependency_changed"
+	ChangeServiceAdded      ChangeKind = "service_added"
+	ChangeServiceRemoved    ChangeKind = "service_removed"
+)
+
+// ArchChange is one entry in the architecture changelog: a service or
+// cross-service dependency that appeared, disappeared, or changed when a
+// repo was re-linked.
+type ArchChange struct {
+	ID        string     `json:"id"`
+	ChangedAt time.Time  `json:"changed_at"`
+	Repo      string     `json:"repo"` // repo whose sync detected the change
+	Kind      ChangeKind `json:"kind"`
+	FromRepo  string     `json:"from_repo,omitempty"`
+	ToRepo    string     `json:"to_repo,omitempty"`
+	LinkType  string     `json:"link_type,omitempty"`
+	Endpoints []string   `json:"endpoints,omitempty"` // endpoints added (or removed, for removals)
+	Removed   []string   `json:"removed_endpoints,omitempty"`
+	CommitSHA string     `json:"commit_sha,omitempty"`
+	SourceURL string     `json:"source_url,omitempty"`
+}
+
+// Describe renders the change as a changelog sentence, e.g.
+// "order-service added dependency on fraud-service (POST /api/score)".
+func (c ArchChange) Describe() string {
+	switch c.Kind {
+	case ChangeServiceAdded:
+		return c.Repo + " was added"
+	case ChangeServiceRemoved:
+		return c.Repo + " was removed"
+	case ChangeDependencyAdded:
+		return fmt.Sprintf("%s added dependency on %s%s", c.FromRepo, c.ToRepo, endpointSuffix(c.Endpoints))
+	case ChangeDependencyRemoved:
+		return fmt.Sprintf("%s removed dependency on %s%s", c.FromRepo, c.ToRepo, endpointSuffix(c.Endpoints))
+	case ChangeDependencyChanged:
+		var parts []string
+		if len(c.Endpoints) > 0 {
+			parts = append(parts, "now calls "+strings.Join(c.Endpoints, ", "))
+		}
+		if len(c.Removed) > 0 {
+			parts = append(parts, "no longer calls "+strings.Join(c.Removed, ", "))
+		}
+		return fmt.Sprintf("%s changed its dependency on %s: %s", c.FromRepo, c.ToRepo, strings.Join(parts, "; "))
+	}
+	return string(c.Kind)
+}
+
+// CommitURL links to the commit that introduced the change, when the repo
+// has a recognisable hosted git URL. Commit pages on these hosts link to the
+// pull request that merged the commit.
+func (c ArchChange) CommitURL() string {
+	if c.CommitSHA == "" || c.SourceURL == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(c.SourceURL, "/"), ".git")
+	if rest, ok := strings.CutPrefix(base, "git@"); ok {
+		host, path, _ := strings.Cut(rest, ":")
+		base = "https://" + host + "/" + path
+	}
+	switch {
+	case strings.Contains(base, "bitbucket.org"):
+		return base + "/commits/" + c.CommitSHA
+	case strings.Contains(base, "gitlab"):
+		return base + "/-/commit/" + c.CommitSHA
+	case strings.HasPrefix(base, "http://"), strings.HasPrefix(base, "https://"):
+		return base + "/commit/" + c.CommitSHA
+	}
+	return ""
+}
+
+func endpointSuffix(endpoints []string) string {
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(endpoints, ", ") + ")"
+}
+
+// DiffLinks compares a repo's links before and after re-linking and returns
+// the architecture changes, attributed to repo.
+func DiffLinks(repo *Repository, before, after []ServiceLink) []ArchChange {
+	key := func(l ServiceLink) string { return l.FromRepo + "\x00" + l.ToRepo + "\x00" + l.LinkType }
+	old := make(map[string]ServiceLink, len(before))
+	for _, l := range before {
+		old[key(l)] = l
+	}
+	now := make(map[string]ServiceLink, len(after))
+	for _, l := range after {
+		now[key(l)] = l
+	}
+
+	change := func(kind ChangeKind, l ServiceLink) ArchChange {
+		return ArchChange{
+			Repo:      repo.Name,
+			Kind:      kind,
+			FromRepo:  l.FromRepo,
+			ToRepo:    l.ToRepo,
+			LinkType:  l.LinkType,
+			CommitSHA: repo.LastCommitSHA,
+			SourceURL: repo.SourceURL,
+		}
+	}
+
+	var changes []ArchChange
+	for k, l := range now {
+		prev, existed := old[k]
+		if !existed {
+			c := change(ChangeDependencyAdded, l)
+			c.Endpoints = l.Endpoints
+			changes = append(changes, c)
+			continue
+		}
+		added, removed := diffStrings(prev.Endpoints, l.Endpoints)
+		if len(added) > 0 || len(removed) > 0 {
+			c := change(ChangeDependencyChanged, l)
+			c.Endpoints, c.Removed = added, removed
+			changes = append(changes, c)
+		}
+	}
+	for k, l := range old {
+		if _, ok := now[k]; !ok {
+			c := change(ChangeDependencyRemoved, l)
+			c.Endpoints = l.Endpoints
+			changes = append(changes, c)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.FromRepo != b.FromRepo {
+			return a.FromRepo < b.FromRepo
+		}
+		if a.ToRepo != b.ToRepo {
+			return a.ToRepo < b.ToRepo
+		}
+		return a.Kind < b.Kind
+	})
+	return changes
+}
+
+// diffStrings returns the values only in b (added) and only in a (removed).
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// RecordChanges appends changes to the architecture changelog.
+func (s *Store) RecordChanges(ctx context.Context, changes []ArchChange) error {
+	for i := range changes {
+		c := &changes[i]
+		if c.ID == "" {
+			c.ID = uuid.NewString()
+		}
+		if c.ChangedAt.IsZero() {
+			c.ChangedAt = time.Now().UTC()
+		}
+		endpointsJSON, _ := json.Marshal(c.Endpoints)
+		removedJSON, _ := json.Marshal(c.Removed)
+		_, err := s.db.ExecContext(ctx,
+			`INSERT INTO architecture_changes (id, changed_at, repo, kind, from_repo, to_repo, link_type, endpoints, removed_endpoints, commit_sha, source_url)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.ID, c.ChangedAt, c.Repo, string(c.Kind), c.FromRepo, c.ToRepo, c.LinkType,
+			string(endpointsJSON), string(removedJSON), c.CommitSHA, c.SourceURL,
+		)
+		if err != nil {
+			return fmt.Errorf("recording architecture change: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListChanges returns architecture changes since the given time, newest
+// first. A zero since returns the whole changelog.
+func (s *Store) ListChanges(ctx context.Context, since time.Time) ([]ArchChange, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, changed_at, repo, kind, from_repo, to_repo, link_type, endpoints, removed_endpoints, commit_sha, source_url
+		 FROM architecture_changes WHERE changed_at >= ? ORDER BY changed_at DESC, from_repo, to_repo`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying architecture changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []ArchChange
+	for rows.Next() {
+		var c ArchChange
+		var kind, endpointsJSON, removedJSON string
+		if err := rows.Scan(&c.ID, &c.ChangedAt, &c.Repo, &kind, &c.FromRepo, &c.ToRepo, &c.LinkType,
+			&endpointsJSON, &removedJSON, &c.CommitSHA, &c.SourceURL); err != nil {
+			return nil, fmt.Errorf("scanning architecture change: %w", err)
+		}
+		c.Kind = ChangeKind(kind)
+		json.Unmarshal([]byte(endpointsJSON), &c.Endpoints)
+		json.Unmarshal([]byte(removedJSON), &c.Removed)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -3,6 +3,7 @@ package registry
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/ziadkadry99/auto-doc/internal/config"
 	"github.com/ziadkadry99/auto-doc/internal/flows"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
@@ -46,8 +48,8 @@ func (imp *Importer) ImportRepo(ctx context.Context, repo *Repository) error {
 		imp.store.Update(ctx, repo)
 	}
 
-	// 2. Load analyses.
-	analyses, err := indexer.LoadAnalyses(repo.LocalPath)
+	// 2. Load analyses, scoped to the repo's share of a monorepo.
+	analyses, err := imp.store.Analyses(ctx, repo)
 	if err != nil {
 		repo.Status = "error"
 		imp.store.Update(ctx, repo)
@@ -63,7 +65,7 @@ func (imp *Importer) ImportRepo(ctx context.Context, repo *Repository) error {
 	// 3. Delete existing documents for this repo from the vector store.
 	if err := imp.vecStore.DeleteByRepoID(ctx, repo.Name); err != nil {
 		// Non-fatal: may not have previous data.
-		fmt.Fprintf(os.Stderr, "Warning: could not clean old documents for repo %s: %v\n", repo.Name, err)
+		slog.Warn("could not clean old documents", "phase", "import", "repo", repo.Name, "err", err)
 	}
 
 	// 4. Re-chunk each analysis with repo_id set and add to vector store.
@@ -82,8 +84,18 @@ func (imp *Importer) ImportRepo(ctx context.Context, repo *Repository) error {
 		}
 	}
 
+	// 4b. Index the repo's ADRs, cross-linked to the services and flows they
+	// mention. Sub-services share their monorepo's ADRs, indexed with it.
+	if repo.Parent == "" {
+		if adrDocs := imp.adrDocuments(ctx, repo); len(adrDocs) > 0 {
+			if err := imp.vecStore.AddDocuments(ctx, adrDocs); err != nil {
+				slog.Warn("could not index ADRs", "phase", "import", "repo", repo.Name, "err", err)
+			}
+		}
+	}
+
 	// 5. Detect cross-service calls from source files.
-	crossCalls := imp.detectCrossServiceCalls(repo.LocalPath)
+	crossCalls := imp.DetectRepoCalls(ctx, repo)
 
 	// 6. Get the current git commit SHA.
 	commitSHA := indexer.GetGitCommitSHA(repo.LocalPath)
@@ -108,21 +120,59 @@ func (imp *Importer) ImportRepo(ctx context.Context, repo *Repository) error {
 	return nil
 }
 
+// adrDocuments loads the repo's ADRs and links them to the registered
+// services and known flows before converting them to vector documents.
+func (imp *Importer) adrDocuments(ctx context.Context, repo *Repository) []vectordb.Document {
+	adrs, err := importers.LoadRepoADRs(repo.LocalPath)
+	if err != nil || len(adrs) == 0 {
+		return nil
+	}
+
+	var serviceNames []string
+	if repos, err := imp.store.List(ctx); err == nil {
+		for _, r := range repos {
+			serviceNames = append(serviceNames, r.Name)
+		}
+	}
+	var flowNames []string
+	if allFlows, err := flows.NewStore(imp.store.db).ListFlows(ctx); err == nil {
+		for _, f := range allFlows {
+			flowNames = append(flowNames, f.Name)
+		}
+	}
+
+	importers.LinkADRs(adrs, serviceNames, flowNames)
+	return importers.ADRDocuments(adrs, repo.Name)
+}
+
 // DetectCrossServiceCalls scans source files in a repo for cross-service communication patterns.
 // Exported for use by the linker.
 func (imp *Importer) DetectCrossServiceCalls(repoPath string) []flows.CrossServiceCall {
-	return imp.detectCrossServiceCalls(repoPath)
+	return imp.detectCrossServiceCalls(repoPath, "", nil)
+}
+
+// DetectRepoCalls scans the source files a registered repository covers:
+// a sub-service's directory, or a monorepo minus its sub-services.
+func (imp *Importer) DetectRepoCalls(ctx context.Context, repo *Repository) []flows.CrossServiceCall {
+	dir, exclude := imp.store.Scope(ctx, repo)
+	return imp.detectCrossServiceCalls(repo.LocalPath, dir, exclude)
 }
 
-func (imp *Importer) detectCrossServiceCalls(repoPath string) []flows.CrossServiceCall {
+func (imp *Importer) detectCrossServiceCalls(repoPath, dir string, exclude []string) []flows.CrossServiceCall {
 	var allCalls []flows.CrossServiceCall
 
-	_ = filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+	_ = filepath.Walk(filepath.Join(repoPath, dir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
 			return nil
 		}
-		// Skip .autodoc, .git, vendor, node_modules directories.
 		rel, _ := filepath.Rel(repoPath, path)
+		if info.IsDir() {
+			if path != repoPath && !indexer.InServiceDir(filepath.ToSlash(rel), dir, exclude) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Skip .autodoc, .git, vendor, node_modules directories.
 		if hasExcludedPrefix(rel) {
 			if info.IsDir() {
 				return filepath.SkipDir
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/ziadkadry99/auto-doc/internal/libraries"
+)
+
+// LinkLibraries links the registered services to the internal libraries
+// they depend on, from the package manifests in their checkouts, replacing
+// the library links found before. A library a registered repo publishes is
+// linked to that repo; any other is a node of its own, named for its
+// package. The blast radius of a library is then the services a change to
+// it rebuilds and redeploys.
+func (s *Store) LinkLibraries(ctx context.Context) ([]libraries.Library, error) {
+	repos, err := s.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos: %w", err)
+	}
+	children := make(map[string][]string)
+	for _, r := range repos {
+		if r.Parent != "" {
+			children[r.Parent] = append(children[r.Parent], r.Subdir)
+		}
+	}
+	var loaded []libraries.Repo
+	for _, r := range repos {
+		if r.LocalPath == "" {
+			continue
+		}
+		lr, err := libraries.Load(r.Name, filepath.Join(r.LocalPath, filepath.FromSlash(r.Subdir)), children[r.Name])
+		if err != nil {
+			slog.Warn("could not read package manifests", "repo", r.Name, "err", err)
+			continue
+		}
+		loaded = append(loaded, lr)
+	}
+
+	libs := libraries.Find(loaded)
+	if err := s.DeleteLinksOfType(ctx, libraries.LinkType); err != nil {
+		return nil, fmt.Errorf("deleting library links: %w", err)
+	}
+	for _, lib := range libs {
+		for _, u := range lib.Users {
+			link := &ServiceLink{FromRepo: u.Service, ToRepo: lib.Node(), LinkType: libraries.LinkType, Reason: lib.Reason(u)}
+			if err := s.SaveLink(ctx, link); err != nil {
+				return nil, fmt.Errorf("saving library link: %w", err)
+			}
+		}
+	}
+	return libs, nil
+}
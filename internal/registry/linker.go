@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/ziadkadry99/auto-doc/internal/contextengine"
@@ -62,7 +63,7 @@ func (l *Linker) DiscoverLinks(ctx context.Context, repo *Repository, provider l
 	}
 
 	// Load the new repo's analyses.
-	analyses, err := indexer.LoadAnalyses(repo.LocalPath)
+	analyses, err := l.store.Analyses(ctx, repo)
 	if err != nil {
 		return fmt.Errorf("loading analyses for %s: %w", repo.Name, err)
 	}
@@ -70,7 +71,7 @@ func (l *Linker) DiscoverLinks(ctx context.Context, repo *Repository, provider l
 	// Detect cross-service calls from source files.
 	detector := flows.NewDetector()
 	imp := &Importer{store: l.store, detector: detector}
-	calls := imp.DetectCrossServiceCalls(repo.LocalPath)
+	calls := imp.DetectRepoCalls(ctx, repo)
 
 	// Build the LLM prompt.
 	prompt := buildLinkDiscoveryPrompt(repo, allRepos, analyses, calls, detector)
@@ -95,6 +96,9 @@ func (l *Linker) DiscoverLinks(ctx context.Context, repo *Repository, provider l
 		return fmt.Errorf("parsing link discovery result: %w", err)
 	}
 
+	// Snapshot the current links so the re-link can be diffed for the changelog.
+	before, _ := l.store.GetLinks(ctx, repo.Name)
+
 	// Delete old links for this repo before saving new ones.
 	l.store.DeleteLinks(ctx, repo.Name)
 
@@ -113,6 +117,16 @@ func (l *Linker) DiscoverLinks(ctx context.Context, repo *Repository, provider l
 		}
 	}
 
+	// Link the services to the internal libraries they share.
+	if _, err := l.store.LinkLibraries(ctx); err != nil {
+		slog.Warn("could not link shared libraries", "err", err)
+	}
+
+	// Record what changed in the architecture changelog.
+	if after, err := l.store.GetLinks(ctx, repo.Name); err == nil {
+		l.store.RecordChanges(ctx, DiffLinks(repo, before, after))
+	}
+
 	// Save discovered flows.
 	if l.flowStore != nil {
 		for _, f := range result.Flows {
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// Children returns the services split out of a monorepo.
+func (s *Store) Children(ctx context.Context, parent string) ([]Repository, error) {
+	repos, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var children []Repository
+	for _, r := range repos {
+		if r.Parent == parent {
+			children = append(children, r)
+		}
+	}
+	return children, nil
+}
+
+// Scope returns the directory a repository covers within its checkout ("" for
+// all of it) and the directories it leaves to its sub-services.
+func (s *Store) Scope(ctx context.Context, repo *Repository) (string, []string) {
+	if repo.Parent != "" {
+		return repo.Subdir, nil
+	}
+	children, err := s.Children(ctx, repo.Name)
+	if err != nil {
+		return "", nil
+	}
+	var exclude []string
+	for _, c := range children {
+		exclude = append(exclude, c.Subdir)
+	}
+	return "", exclude
+}
+
+// Analyses loads the analyses of the files a repository covers: a
+// sub-service's directory, or a monorepo minus its sub-services.
+func (s *Store) Analyses(ctx context.Context, repo *Repository) (map[string]indexer.FileAnalysis, error) {
+	analyses, err := indexer.LoadAnalyses(repo.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+	dir, exclude := s.Scope(ctx, repo)
+	if dir == "" && len(exclude) == 0 {
+		return analyses, nil
+	}
+	return indexer.ScopeAnalyses(analyses, dir, exclude), nil
+}
+
+// SyncSubServices registers each sub-service of a monorepo as a repository
+// sharing the parent's checkout, updates the ones already registered, and
+// removes those no longer found. A name already taken by a repository
+// outside this monorepo is an error.
+func (s *Store) SyncSubServices(ctx context.Context, parent *Repository, services []indexer.SubService) ([]Repository, error) {
+	existing, err := s.Children(ctx, parent.Name)
+	if err != nil {
+		return nil, err
+	}
+	stale := make(map[string]bool)
+	for _, c := range existing {
+		stale[c.Name] = true
+	}
+
+	var children []Repository
+	for _, svc := range services {
+		child, err := s.Get(ctx, svc.Name)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil && child.Parent != parent.Name {
+			return nil, fmt.Errorf("sub-service %q of %s: name is already registered as another repository; rename it under monorepo.services", svc.Name, parent.Name)
+		}
+		if child == nil {
+			child = &Repository{Name: svc.Name}
+		}
+		child.DisplayName = svc.DisplayName
+		child.SourceType = parent.SourceType
+		child.SourceURL = parent.SourceURL
+		child.LocalPath = parent.LocalPath
+		child.Parent = parent.Name
+		child.Subdir = svc.Path
+		if child.ID == "" {
+			err = s.Add(ctx, child)
+		} else {
+			err = s.Update(ctx, child)
+		}
+		if err != nil {
+			return nil, err
+		}
+		delete(stale, svc.Name)
+		children = append(children, *child)
+	}
+
+	for name := range stale {
+		if err := s.Remove(ctx, name); err != nil {
+			return nil, fmt.Errorf("removing stale sub-service %s: %w", name, err)
+		}
+	}
+	return children, nil
+}
@@ -24,7 +24,17 @@ type Repository struct {
 	Status        string    `json:"status"` // pending, indexing, ready, error
 	FileCount     int       `json:"file_count"`
 	Summary       string    `json:"summary"`
-	CreatedAt     time.Time `json:"created_at"`
+	// Parent is the monorepo this service was split out of, and Subdir its
+	// directory there. Both are empty for a standalone repository.
+	Parent string `json:"parent,omitempty"`
+	Subdir string `json:"subdir,omitempty"`
+	// Domain, BoundedContext, and Environment group services on the
+	// central site, e.g. "commerce", "checkout", and "prod"; any may be
+	// empty.
+	Domain         string    `json:"domain,omitempty"`
+	BoundedContext string    `json:"bounded_context,omitempty"`
+	Environment    string    `json:"environment,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // ServiceLink represents a discovered dependency between two repos.
@@ -59,15 +69,17 @@ func (s *Store) Add(ctx context.Context, repo *Repository) error {
 	repo.CreatedAt = time.Now().UTC()
 
 	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO repositories (id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO repositories (id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, parent, subdir, domain, bounded_context, environment, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		repo.ID, repo.Name, repo.DisplayName, repo.SourceType, repo.SourceURL,
 		repo.LocalPath, repo.LastCommitSHA, repo.LastIndexedAt, repo.Status,
-		repo.FileCount, repo.Summary, repo.CreatedAt,
+		repo.FileCount, repo.Summary, repo.Parent, repo.Subdir,
+		repo.Domain, repo.BoundedContext, repo.Environment, repo.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("adding repository: %w", err)
 	}
+	s.RecordChanges(ctx, []ArchChange{{Repo: repo.Name, Kind: ChangeServiceAdded, SourceURL: repo.SourceURL}})
 	return nil
 }
 
@@ -75,11 +87,12 @@ func (s *Store) Add(ctx context.Context, repo *Repository) error {
 func (s *Store) Get(ctx context.Context, name string) (*Repository, error) {
 	r := &Repository{}
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, created_at
+		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, parent, subdir, domain, bounded_context, environment, created_at
 		 FROM repositories WHERE name = ?`, name,
 	).Scan(&r.ID, &r.Name, &r.DisplayName, &r.SourceType, &r.SourceURL,
 		&r.LocalPath, &r.LastCommitSHA, &r.LastIndexedAt, &r.Status,
-		&r.FileCount, &r.Summary, &r.CreatedAt)
+		&r.FileCount, &r.Summary, &r.Parent, &r.Subdir,
+		&r.Domain, &r.BoundedContext, &r.Environment, &r.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -93,11 +106,12 @@ func (s *Store) Get(ctx context.Context, name string) (*Repository, error) {
 func (s *Store) GetByID(ctx context.Context, id string) (*Repository, error) {
 	r := &Repository{}
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, created_at
+		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, parent, subdir, domain, bounded_context, environment, created_at
 		 FROM repositories WHERE id = ?`, id,
 	).Scan(&r.ID, &r.Name, &r.DisplayName, &r.SourceType, &r.SourceURL,
 		&r.LocalPath, &r.LastCommitSHA, &r.LastIndexedAt, &r.Status,
-		&r.FileCount, &r.Summary, &r.CreatedAt)
+		&r.FileCount, &r.Summary, &r.Parent, &r.Subdir,
+		&r.Domain, &r.BoundedContext, &r.Environment, &r.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -110,7 +124,7 @@ func (s *Store) GetByID(ctx context.Context, id string) (*Repository, error) {
 // List returns all registered repositories.
 func (s *Store) List(ctx context.Context) ([]Repository, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, created_at
+		`SELECT id, name, display_name, source_type, source_url, local_path, last_commit_sha, last_indexed_at, status, file_count, summary, parent, subdir, domain, bounded_context, environment, created_at
 		 FROM repositories ORDER BY name`)
 	if err != nil {
 		return nil, fmt.Errorf("listing repositories: %w", err)
@@ -122,7 +136,8 @@ func (s *Store) List(ctx context.Context) ([]Repository, error) {
 		var r Repository
 		if err := rows.Scan(&r.ID, &r.Name, &r.DisplayName, &r.SourceType, &r.SourceURL,
 			&r.LocalPath, &r.LastCommitSHA, &r.LastIndexedAt, &r.Status,
-			&r.FileCount, &r.Summary, &r.CreatedAt); err != nil {
+			&r.FileCount, &r.Summary, &r.Parent, &r.Subdir,
+			&r.Domain, &r.BoundedContext, &r.Environment, &r.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scanning repository: %w", err)
 		}
 		repos = append(repos, r)
@@ -134,11 +149,13 @@ func (s *Store) List(ctx context.Context) ([]Repository, error) {
 func (s *Store) Update(ctx context.Context, repo *Repository) error {
 	res, err := s.db.ExecContext(ctx,
 		`UPDATE repositories SET display_name=?, source_type=?, source_url=?, local_path=?,
-		 last_commit_sha=?, last_indexed_at=?, status=?, file_count=?, summary=?
+		 last_commit_sha=?, last_indexed_at=?, status=?, file_count=?, summary=?, parent=?, subdir=?,
+		 domain=?, bounded_context=?, environment=?
 		 WHERE id=?`,
 		repo.DisplayName, repo.SourceType, repo.SourceURL, repo.LocalPath,
 		repo.LastCommitSHA, repo.LastIndexedAt, repo.Status, repo.FileCount,
-		repo.Summary, repo.ID,
+		repo.Summary, repo.Parent, repo.Subdir,
+		repo.Domain, repo.BoundedContext, repo.Environment, repo.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("updating repository: %w", err)
@@ -152,8 +169,9 @@ func (s *Store) Update(ctx context.Context, repo *Repository) error {
 
 // Remove deletes a repository by name.
 func (s *Store) Remove(ctx context.Context, name string) error {
-	// Also delete associated service links.
+	// Also delete associated service links and tags.
 	s.db.ExecContext(ctx, `DELETE FROM service_links WHERE from_repo = ? OR to_repo = ?`, name, name)
+	s.db.ExecContext(ctx, `DELETE FROM repository_tags WHERE repo_name = ?`, name)
 
 	res, err := s.db.ExecContext(ctx, `DELETE FROM repositories WHERE name = ?`, name)
 	if err != nil {
@@ -163,6 +181,7 @@ func (s *Store) Remove(ctx context.Context, name string) error {
 	if n == 0 {
 		return sql.ErrNoRows
 	}
+	s.RecordChanges(ctx, []ArchChange{{Repo: name, Kind: ChangeServiceRemoved}})
 	return nil
 }
 
@@ -231,3 +250,9 @@ func (s *Store) DeleteLinks(ctx context.Context, repoName string) error {
 	_, err := s.db.ExecContext(ctx, `DELETE FROM service_links WHERE from_repo = ? OR to_repo = ?`, repoName, repoName)
 	return err
 }
+
+// DeleteLinksOfType removes all service links of a given type.
+func (s *Store) DeleteLinksOfType(ctx context.Context, linkType string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM service_links WHERE link_type = ?`, linkType)
+	return err
+}
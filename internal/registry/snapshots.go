@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Snapshot is the architecture as it stood after a run: the registered
+// services and the links between them. The service map's timeline replays
+// snapshots to show how the architecture evolved.
+type Snapshot struct {
+	ID       string         `json:"id"`
+	TakenAt  time.Time      `json:"taken_at"`
+	RunID    string         `json:"run_id,omitempty"`
+	Services []string       `json:"services"`
+	Links    []SnapshotLink `json:"links"`
+}
+
+// SnapshotLink is a link in a snapshot.
+type SnapshotLink struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// RecordSnapshot snapshots the current services and links, attributed to
+// the run that produced them. It records nothing and returns false when
+// the architecture is unchanged since the last snapshot.
+func (s *Store) RecordSnapshot(ctx context.Context, runID string) (bool, error) {
+	repos, err := s.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	links, err := s.GetLinks(ctx, "")
+	if err != nil {
+		return false, err
+	}
+	snap := Snapshot{ID: uuid.NewString(), TakenAt: time.Now().UTC(), RunID: runID, Services: []string{}, Links: []SnapshotLink{}}
+	for _, r := range repos {
+		snap.Services = append(snap.Services, r.Name)
+	}
+	slices.Sort(snap.Services)
+	for _, l := range links {
+		snap.Links = append(snap.Links, SnapshotLink{From: l.FromRepo, To: l.ToRepo, Type: l.LinkType})
+	}
+	// GetLinks orders by caller and callee only.
+	slices.SortFunc(snap.Links, func(a, b SnapshotLink) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To), cmp.Compare(a.Type, b.Type))
+	})
+	servicesJSON, _ := json.Marshal(snap.Services)
+	linksJSON, _ := json.Marshal(snap.Links)
+
+	var lastServices, lastLinks string
+	err = s.db.QueryRowContext(ctx,
+		`SELECT services, links FROM architecture_snapshots ORDER BY taken_at DESC LIMIT 1`).Scan(&lastServices, &lastLinks)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("reading the last architecture snapshot: %w", err)
+	}
+	if err == nil && lastServices == string(servicesJSON) && lastLinks == string(linksJSON) {
+		return false, nil
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO architecture_snapshots (id, taken_at, run_id, services, links) VALUES (?, ?, ?, ?, ?)`,
+		snap.ID, snap.TakenAt, snap.RunID, string(servicesJSON), string(linksJSON))
+	if err != nil {
+		return false, fmt.Errorf("recording architecture snapshot: %w", err)
+	}
+	return true, nil
+}
+
+// ListSnapshots returns the snapshots taken since the given time, oldest
+// first. A zero since returns them all.
+func (s *Store) ListSnapshots(ctx context.Context, since time.Time) ([]Snapshot, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, taken_at, run_id, services, links FROM architecture_snapshots WHERE taken_at >= ? ORDER BY taken_at`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying architecture snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snaps []Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		var servicesJSON, linksJSON string
+		if err := rows.Scan(&snap.ID, &snap.TakenAt, &snap.RunID, &servicesJSON, &linksJSON); err != nil {
+			return nil, fmt.Errorf("scanning architecture snapshot: %w", err)
+		}
+		json.Unmarshal([]byte(servicesJSON), &snap.Services)
+		json.Unmarshal([]byte(linksJSON), &snap.Links)
+		snaps = append(snaps, snap)
+	}
+	return snaps, rows.Err()
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetTags replaces a repository's tags. Tags are free-form labels such as
+// "tier-1" or "pci" that notification routing rules can match on.
+func (s *Store) SetTags(ctx context.Context, repoName string, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("setting tags: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM repository_tags WHERE repo_name = ?`, repoName); err != nil {
+		return fmt.Errorf("clearing tags: %w", err)
+	}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO repository_tags (repo_name, tag) VALUES (?, ?)`, repoName, tag); err != nil {
+			return fmt.Errorf("adding tag %q: %w", tag, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTags returns a repository's tags, sorted.
+func (s *Store) GetTags(ctx context.Context, repoName string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT tag FROM repository_tags WHERE repo_name = ? ORDER BY tag`, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// AllTags returns the tags of every tagged repository, keyed by repo name.
+func (s *Store) AllTags(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT repo_name, tag FROM repository_tags`)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var repo, tag string
+		if err := rows.Scan(&repo, &tag); err != nil {
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		tags[repo] = append(tags[repo], tag)
+	}
+	for _, t := range tags {
+		sort.Strings(t)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,219 @@
+// Package repohealth gathers the signals of a repository's health when it
+// is imported: how long since its last commit, the CI building it and its
+// status badge, its open pull requests, and whether it has tests. Repos
+// without a commit for a while are reported as quiet.
+package repohealth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// DefaultQuietAfter is how long a repo may go without a commit before it
+// counts as quiet, when the config doesn't say.
+const DefaultQuietAfter = 90 * 24 * time.Hour
+
+// QuietAfter converts the daemon.quiet_days setting: zero means the
+// default, and a negative number turns quiet repos off.
+func QuietAfter(days int) time.Duration {
+	switch {
+	case days == 0:
+		return DefaultQuietAfter
+	case days < 0:
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Health is a repo's health signals, as of its last import.
+type Health struct {
+	Repo string `json:"repo"`
+	// LastCommitAt is when the repo's, or a sub-service's directory's,
+	// last commit was made; zero when the checkout has no git history.
+	LastCommitAt time.Time `json:"last_commit_at,omitempty"`
+	// CIProvider names the CI whose config the checkout has, e.g. "GitHub
+	// Actions"; CIBadgeURL is its status badge, when it has one.
+	CIProvider string `json:"ci_provider,omitempty"`
+	CIBadgeURL string `json:"ci_badge_url,omitempty"`
+	// OpenPRs counts the repository's open pull requests; -1 when they
+	// can't be counted. A sub-service has its monorepo's.
+	OpenPRs   int       `json:"open_prs"`
+	HasTests  bool      `json:"has_tests"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Quiet reports whether the repo has gone more than after without a commit.
+func (h Health) Quiet(now time.Time, after time.Duration) bool {
+	return after > 0 && !h.LastCommitAt.IsZero() && now.Sub(h.LastCommitAt) > after
+}
+
+// Age describes how long ago the last commit was, e.g. "3 days ago".
+func (h Health) Age(now time.Time) string {
+	if h.LastCommitAt.IsZero() {
+		return "no commits found"
+	}
+	switch days := int(now.Sub(h.LastCommitAt).Hours() / 24); {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 60:
+		return fmt.Sprintf("%d days ago", days)
+	default:
+		return fmt.Sprintf("%d months ago", days/30)
+	}
+}
+
+// git runs git in dir and returns its trimmed output; replaced in tests.
+var git = func(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PullRequests counts a GitHub repository's open pull requests, by its
+// "owner/repo".
+type PullRequests interface {
+	OpenPullRequests(ctx context.Context, slug string) (int, error)
+}
+
+// Gather reads a repo's health from its checkout, and its open pull
+// requests from prs when it's set and the repo is hosted on GitHub. A
+// sub-service's last commit and tests are its directory's; its CI is its
+// monorepo's. Signals that can't be read are left unknown.
+func Gather(ctx context.Context, repo registry.Repository, prs PullRequests) Health {
+	h := Health{Repo: repo.Name, OpenPRs: -1, CheckedAt: time.Now().UTC()}
+	if repo.LocalPath == "" {
+		return h
+	}
+	args := []string{"log", "-1", "--format=%cI"}
+	dir := repo.LocalPath
+	if repo.Parent != "" && repo.Subdir != "" {
+		args = append(args, "--", repo.Subdir)
+		dir = filepath.Join(dir, filepath.FromSlash(repo.Subdir))
+	}
+	if out, err := git(ctx, repo.LocalPath, args...); err == nil && out != "" {
+		if t, err := time.Parse(time.RFC3339, out); err == nil {
+			h.LastCommitAt = t.UTC()
+		}
+	}
+	h.HasTests = hasTests(dir)
+
+	host, slug := remote(repo.SourceURL)
+	branch := "main"
+	if out, err := git(ctx, repo.LocalPath, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && out != "" && out != "HEAD" {
+		branch = out
+	}
+	h.CIProvider, h.CIBadgeURL = detectCI(repo.LocalPath, host, slug, branch)
+
+	if prs != nil && slug != "" && strings.Contains(host, "github") {
+		if n, err := prs.OpenPullRequests(ctx, slug); err == nil {
+			h.OpenPRs = n
+		}
+	}
+	return h
+}
+
+// remoteRe matches the host and path of an https or ssh git remote URL.
+var remoteRe = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?([^/]+)/|ssh://git@([^/:]+)(?::\d+)?/|git@([^:]+):)(.+?)(?:\.git)?/?$`)
+
+// remote returns the host and repository path a git remote URL points to,
+// such as "github.com" and "acme/payments".
+func remote(url string) (host, slug string) {
+	m := remoteRe.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil {
+		return "", ""
+	}
+	return m[1] + m[2] + m[3], m[4]
+}
+
+// ciFiles are the config files of CI systems without a badge URL that can
+// be derived from the repo alone.
+var ciFiles = []struct{ path, provider string }{
+	{".circleci/config.yml", "CircleCI"},
+	{"Jenkinsfile", "Jenkins"},
+	{"azure-pipelines.yml", "Azure Pipelines"},
+	{".buildkite/pipeline.yml", "Buildkite"},
+	{".travis.yml", "Travis CI"},
+}
+
+// detectCI names the CI the checkout at root is configured for and, for
+// GitHub Actions and GitLab CI, the status badge of its main workflow or
+// pipeline on branch.
+func detectCI(root, host, slug, branch string) (provider, badge string) {
+	if workflows, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", "*.y*ml")); len(workflows) > 0 {
+		sort.Strings(workflows)
+		main := filepath.Base(workflows[0])
+		for _, w := range workflows {
+			name := strings.TrimSuffix(filepath.Base(w), filepath.Ext(w))
+			if slices.Contains([]string{"ci", "build", "test", "tests", "main"}, strings.ToLower(name)) {
+				main = filepath.Base(w)
+				break
+			}
+		}
+		if slug != "" && strings.Contains(host, "github") {
+			badge = fmt.Sprintf("https://%s/%s/actions/workflows/%s/badge.svg", host, slug, main)
+		}
+		return "GitHub Actions", badge
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gitlab-ci.yml")); err == nil {
+		if slug != "" && !strings.Contains(host, "github") {
+			badge = fmt.Sprintf("https://%s/%s/badges/%s/pipeline.svg", host, slug, branch)
+		}
+		return "GitLab CI", badge
+	}
+	for _, f := range ciFiles {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(f.path))); err == nil {
+			return f.provider, ""
+		}
+	}
+	return "", ""
+}
+
+var (
+	testDirs  = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "specs": true}
+	testFiles = regexp.MustCompile(`(_test\.(go|py)|\.(test|spec)\.[jt]sx?|^test_.*\.py|Tests?\.(java|kt|cs)|_spec\.rb)$`)
+)
+
+// hasTests reports whether the checkout at root has a test directory or
+// test files, outside hidden and dependency directories.
+func hasTests(root string) bool {
+	found := false
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			found = p != root && testDirs[strings.ToLower(name)]
+		} else {
+			found = testFiles.MatchString(path.Base(name))
+		}
+		if found {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,152 @@
+package repohealth
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+type fakePRs map[string]int
+
+func (f fakePRs) OpenPullRequests(_ context.Context, slug string) (int, error) {
+	return f[slug], nil
+}
+
+func TestGather(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		".github/workflows/lint.yml":    "on: push\n",
+		".github/workflows/ci.yml":      "on: push\n",
+		"main.go":                       "package main\n",
+		"services/billing/app.py":       "print('hi')\n",
+		"services/billing/test_app.py":  "def test(): pass\n",
+		"services/ledger/ledger.go":     "package ledger\n",
+		"node_modules/x/x.test.js":      "",
+		"services/ledger/.hidden/a.txt": "",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	committed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "trunk"},
+		{"add", "-A"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+committed.Format(time.RFC3339), "GIT_AUTHOR_DATE="+committed.Format(time.RFC3339))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	prs := fakePRs{"acme/platform": 4}
+	h := Gather(ctx, registry.Repository{Name: "platform", LocalPath: root, SourceURL: "git@github.com:acme/platform.git"}, prs)
+	if !h.LastCommitAt.Equal(committed) {
+		t.Errorf("LastCommitAt = %v, want %v", h.LastCommitAt, committed)
+	}
+	if h.CIProvider != "GitHub Actions" || h.CIBadgeURL != "https://github.com/acme/platform/actions/workflows/ci.yml/badge.svg" {
+		t.Errorf("CI = %q %q", h.CIProvider, h.CIBadgeURL)
+	}
+	if h.OpenPRs != 4 || !h.HasTests {
+		t.Errorf("OpenPRs = %d, HasTests = %v", h.OpenPRs, h.HasTests)
+	}
+
+	ledger := Gather(ctx, registry.Repository{Name: "ledger", Parent: "platform", Subdir: "services/ledger", LocalPath: root}, nil)
+	if ledger.HasTests || ledger.OpenPRs != -1 || ledger.CIProvider != "GitHub Actions" || ledger.CIBadgeURL != "" {
+		t.Errorf("ledger = %+v", ledger)
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, ".github")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitlab-ci.yml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitlab := Gather(ctx, registry.Repository{Name: "platform", LocalPath: root, SourceURL: "https://gitlab.example.com/acme/platform.git"}, prs)
+	if gitlab.CIBadgeURL != "https://gitlab.example.com/acme/platform/badges/trunk/pipeline.svg" || gitlab.OpenPRs != -1 {
+		t.Errorf("gitlab = %+v", gitlab)
+	}
+}
+
+func TestStoreNewlyQuiet(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := NewStore(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for _, h := range []Health{
+		{Repo: "active", LastCommitAt: now.Add(-24 * time.Hour), OpenPRs: 2, HasTests: true, CheckedAt: now},
+		{Repo: "legacy", LastCommitAt: now.Add(-200 * 24 * time.Hour), OpenPRs: -1, CIProvider: "Jenkins", CheckedAt: now},
+		{Repo: "empty", OpenPRs: -1, CheckedAt: now},
+	} {
+		if err := store.Record(ctx, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cutoff := now.Add(-DefaultQuietAfter)
+	quiet, err := store.NewlyQuiet(ctx, cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quiet) != 1 || quiet[0].Repo != "legacy" || quiet[0].CIProvider != "Jenkins" {
+		t.Fatalf("NewlyQuiet = %+v, want legacy", quiet)
+	}
+	if again, _ := store.NewlyQuiet(ctx, cutoff); len(again) != 0 {
+		t.Errorf("second NewlyQuiet = %+v, want none", again)
+	}
+
+	// Re-importing it unchanged doesn't report it again; a commit and then
+	// another quiet spell does.
+	store.Record(ctx, Health{Repo: "legacy", LastCommitAt: now.Add(-200 * 24 * time.Hour), OpenPRs: -1, CheckedAt: now})
+	if again, _ := store.NewlyQuiet(ctx, cutoff); len(again) != 0 {
+		t.Errorf("NewlyQuiet after re-import = %+v, want none", again)
+	}
+	later := now.Add(time.Hour)
+	store.Record(ctx, Health{Repo: "legacy", LastCommitAt: later, OpenPRs: -1, CheckedAt: later})
+	if again, _ := store.NewlyQuiet(ctx, later.Add(time.Minute)); len(again) != 2 || again[1].Repo != "legacy" {
+		t.Errorf("NewlyQuiet after a commit = %+v, want active and legacy", again)
+	}
+
+	all, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[1].Repo != "empty" || !all[1].LastCommitAt.IsZero() || !all[0].HasTests {
+		t.Errorf("List = %+v", all)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	h := Health{LastCommitAt: now.AddDate(0, 0, -100)}
+	if !h.Quiet(now, QuietAfter(0)) || h.Quiet(now, QuietAfter(120)) || h.Quiet(now, QuietAfter(-1)) {
+		t.Error("Quiet disagrees with quiet_days")
+	}
+	if got := h.Age(now); got != "3 months ago" {
+		t.Errorf("Age = %q", got)
+	}
+	if (Health{}).Quiet(now, DefaultQuietAfter) {
+		t.Error("a repo without commits counts as quiet")
+	}
+}
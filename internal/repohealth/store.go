@@ -0,0 +1,93 @@
+package repohealth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// Store persists each repo's latest health.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a Store backed by the given database.
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+// Record saves a repo's health, replacing what was gathered before.
+func (s *Store) Record(ctx context.Context, h Health) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO repo_health (repo, last_commit_at, ci_provider, ci_badge_url, open_prs, has_tests, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(repo) DO UPDATE SET
+			last_commit_at = excluded.last_commit_at,
+			ci_provider = excluded.ci_provider,
+			ci_badge_url = excluded.ci_badge_url,
+			open_prs = excluded.open_prs,
+			has_tests = excluded.has_tests,
+			checked_at = excluded.checked_at`,
+		h.Repo, nullTime(h.LastCommitAt), h.CIProvider, h.CIBadgeURL, h.OpenPRs, h.HasTests, h.CheckedAt)
+	if err != nil {
+		return fmt.Errorf("saving health of %s: %w", h.Repo, err)
+	}
+	return nil
+}
+
+// List returns every repo's health, by repo.
+func (s *Store) List(ctx context.Context) ([]Health, error) {
+	return s.query(ctx, `
+		SELECT repo, last_commit_at, ci_provider, ci_badge_url, open_prs, has_tests, checked_at
+		FROM repo_health ORDER BY repo`)
+}
+
+// NewlyQuiet returns the repos whose last commit is before cutoff and that
+// haven't been reported quiet since, and marks them reported, so a repo is
+// reported once each time it goes quiet.
+func (s *Store) NewlyQuiet(ctx context.Context, cutoff time.Time) ([]Health, error) {
+	quiet, err := s.query(ctx, `
+		SELECT repo, last_commit_at, ci_provider, ci_badge_url, open_prs, has_tests, checked_at
+		FROM repo_health
+		WHERE last_commit_at IS NOT NULL AND last_commit_at < ?
+			AND (quiet_notified_at IS NULL OR quiet_notified_at < last_commit_at)
+		ORDER BY repo`, cutoff.UTC())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for _, h := range quiet {
+		if _, err := s.db.ExecContext(ctx, `UPDATE repo_health SET quiet_notified_at = ? WHERE repo = ?`, now, h.Repo); err != nil {
+			return nil, fmt.Errorf("marking %s quiet: %w", h.Repo, err)
+		}
+	}
+	return quiet, nil
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]Health, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying repo health: %w", err)
+	}
+	defer rows.Close()
+	var out []Health
+	for rows.Next() {
+		var h Health
+		var lastCommit sql.NullTime
+		if err := rows.Scan(&h.Repo, &lastCommit, &h.CIProvider, &h.CIBadgeURL, &h.OpenPRs, &h.HasTests, &h.CheckedAt); err != nil {
+			return nil, fmt.Errorf("scanning repo health: %w", err)
+		}
+		if lastCommit.Valid {
+			h.LastCommitAt = lastCommit.Time.UTC()
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
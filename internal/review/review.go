@@ -0,0 +1,98 @@
+// Package review gates the central site's generated pages behind human
+// approval, for regulated environments. A page generated for the first
+// time, or changed in substance since it was last approved, waits for a
+// reviewer; until one approves it the site keeps publishing the version
+// last approved, or leaves a new page out. Reviewers approve or reject
+// pages through the API, the review page served next to it, or
+// `autodoc review`; MCP clients can only read what awaits review.
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/linediff"
+)
+
+// Status is where a page is in review.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+)
+
+var (
+	// ErrNotFound is returned for a page the gate has never seen.
+	ErrNotFound = errors.New("page not found")
+	// ErrNotPending is returned when deciding on a page not awaiting
+	// review.
+	ErrNotPending = errors.New("page is not awaiting review")
+	// ErrStale is returned when a page was regenerated since the reviewer
+	// read it.
+	ErrStale = errors.New("page changed since it was read")
+)
+
+// Page is a generated page under review.
+type Page struct {
+	// Site is the audience of the site variant the page belongs to, or
+	// empty for the full site.
+	Site   string `json:"site,omitempty"`
+	Path   string `json:"path"` // slash-separated, e.g. "flows.md"
+	Status Status `json:"status"`
+	// Content is the page as last generated, and Hash its fingerprint,
+	// which a decision can name to be sure it is about what the reviewer
+	// read.
+	Content string `json:"content"`
+	Hash    string `json:"hash"`
+	// Approved is the content last approved, which the site publishes
+	// while newer content waits; empty when none ever was.
+	Approved     string `json:"approved,omitempty"`
+	ApprovedHash string `json:"approved_hash,omitempty"`
+
+	Reviewer    string     `json:"reviewer,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// IsNew reports whether the page has never been approved.
+func (p Page) IsNew() bool {
+	return p.ApprovedHash == ""
+}
+
+// Diff returns the changes awaiting review as a line diff against the
+// approved content.
+func (p Page) Diff() string {
+	return linediff.Diff(p.Approved, p.Content)
+}
+
+// Decision is a reviewer's approval or rejection of a page.
+type Decision struct {
+	Site string `json:"site,omitempty"`
+	Path string `json:"path"`
+	// Hash, when set, must be the page's current hash: a page regenerated
+	// since the reviewer read it is not decided on.
+	Hash     string `json:"hash,omitempty"`
+	Reviewer string `json:"reviewer,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// timestamp matches the date-and-time stamps generated pages carry, e.g.
+// "2026-03-04 15:04 UTC".
+var timestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}(:\d{2}(\.\d+)?)?( ?UTC|Z|[+-]\d{2}:?\d{2})?`)
+
+// Fingerprint identifies a page's substance: two pages differing only in
+// their timestamps or how their text is wrapped and indented have the
+// same fingerprint, so regenerating a page unchanged doesn't need another
+// review.
+func Fingerprint(content string) string {
+	normalized := strings.Join(strings.Fields(timestamp.ReplaceAllString(content, "<timestamp>")), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
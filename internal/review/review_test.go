@@ -0,0 +1,108 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatalf("opening in-memory db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewStore(database)
+}
+
+func TestGate(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	gate := func(pages map[string]string) (map[string]string, []Page) {
+		t.Helper()
+		publish, pending, err := s.Gate(ctx, "", pages)
+		if err != nil {
+			t.Fatalf("Gate: %v", err)
+		}
+		return publish, pending
+	}
+
+	v1 := "# Flows\n\nGenerated 2026-03-04 15:04 UTC\n\nCheckout calls payments.\n"
+	publish, pending := gate(map[string]string{"flows.md": v1})
+	if _, ok := publish["flows.md"]; ok || len(pending) != 1 || !pending[0].IsNew() {
+		t.Fatalf("a new page should be held back: publish=%v pending=%v", publish, pending)
+	}
+
+	if _, err := s.Approve(ctx, Decision{Path: "flows.md", Hash: "other", Reviewer: "ada"}); !errors.Is(err, ErrStale) {
+		t.Errorf("Approve with a stale hash = %v, want ErrStale", err)
+	}
+	if _, err := s.Approve(ctx, Decision{Path: "flows.md", Hash: pending[0].Hash, Reviewer: "ada"}); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if _, err := s.Approve(ctx, Decision{Path: "flows.md", Reviewer: "ada"}); !errors.Is(err, ErrNotPending) {
+		t.Errorf("approving twice = %v, want ErrNotPending", err)
+	}
+
+	// Only the timestamp and wrapping changed: published without review.
+	restamped := "# Flows\n\nGenerated 2026-03-05 09:00 UTC\n\nCheckout calls\npayments.\n"
+	publish, pending = gate(map[string]string{"flows.md": restamped})
+	if publish["flows.md"] != restamped || len(pending) != 0 {
+		t.Fatalf("a restamped page should be published: publish=%v pending=%v", publish, pending)
+	}
+
+	// A material change waits, and the approved content stays published.
+	v2 := "# Flows\n\nGenerated 2026-03-06 09:00 UTC\n\nCheckout calls billing.\n"
+	publish, pending = gate(map[string]string{"flows.md": v2})
+	if publish["flows.md"] != restamped || len(pending) != 1 {
+		t.Fatalf("a changed page should be held back: publish=%v pending=%v", publish, pending)
+	}
+	if _, err := s.Reject(ctx, Decision{Path: "flows.md", Reviewer: "ada", Note: "billing is wrong"}); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	publish, pending = gate(map[string]string{"flows.md": v2})
+	if publish["flows.md"] != restamped || len(pending) != 0 {
+		t.Errorf("a rejected page should stay rejected: publish=%v pending=%v", publish, pending)
+	}
+	if p, _ := s.Get(ctx, "", "flows.md"); p.Status != StatusRejected || p.Note != "billing is wrong" {
+		t.Errorf("page = %+v, want rejected with the note", p)
+	}
+
+	// Regenerated differently, it needs review again.
+	v3 := strings.Replace(v2, "billing", "invoicing", 1)
+	if _, pending = gate(map[string]string{"flows.md": v3, "teams.md": "# Teams\n"}); len(pending) != 2 {
+		t.Fatalf("pending = %v, want flows.md and teams.md", pending)
+	}
+
+	// Pages no longer generated have their reviews withdrawn.
+	gate(map[string]string{})
+	if _, err := s.Get(ctx, "", "teams.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(teams.md) = %v, want ErrNotFound", err)
+	}
+	if p, err := s.Get(ctx, "", "flows.md"); err != nil || p.Status != StatusApproved || p.Content != restamped {
+		t.Errorf("flows.md = %+v, %v; want the approved content back", p, err)
+	}
+}
+
+func TestApproveAll(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	if _, _, err := s.Gate(ctx, "", map[string]string{"a.md": "a", "b.md": "b"}); err != nil {
+		t.Fatalf("Gate: %v", err)
+	}
+	if _, _, err := s.Gate(ctx, "internal", map[string]string{"a.md": "a"}); err != nil {
+		t.Fatalf("Gate: %v", err)
+	}
+	n, err := s.ApproveAll(ctx, "ada", "adopting the site")
+	if err != nil || n != 3 {
+		t.Fatalf("ApproveAll = %d, %v; want 3", n, err)
+	}
+	publish, pending, err := s.Gate(ctx, "internal", map[string]string{"a.md": "a"})
+	if err != nil || publish["a.md"] != "a" || len(pending) != 0 {
+		t.Errorf("Gate after ApproveAll = %v, %v, %v", publish, pending, err)
+	}
+}
@@ -0,0 +1,127 @@
+package review
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ziadkadry99/auto-doc/internal/siteauth"
+)
+
+//go:embed review.html
+var reviewHTML []byte
+
+// RegisterRoutes mounts the review endpoints under /api/review, and the
+// review page using them at /review, on the given router. When reviewers
+// lists groups, only their members may approve or reject pages.
+func RegisterRoutes(r chi.Router, store *Store, reviewers []string) {
+	r.Get("/review", serveReviewPage)
+	r.Route("/api/review", func(r chi.Router) {
+		r.Get("/", handleList(store))
+		r.Get("/page", handleGet(store))
+		r.With(RequireReviewers(reviewers)).Post("/approve", handleDecide(store.Approve))
+		r.With(RequireReviewers(reviewers)).Post("/reject", handleDecide(store.Reject))
+	})
+}
+
+// RequireReviewers lets only signed-in members of groups through, or
+// everyone when groups is empty. The user must already be identified, as
+// by siteauth's RequireAuth.
+func RequireReviewers(groups []string) func(http.Handler) http.Handler {
+	if len(groups) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return siteauth.RequireGroups(groups)
+}
+
+func serveReviewPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(reviewHTML)
+}
+
+// handleList lists the pages with the status in the query, pending by
+// default, or every page for status=all.
+func handleList(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := Status(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = StatusPending
+		case "all":
+			status = ""
+		}
+		pages, err := store.List(r.Context(), status)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if pages == nil {
+			pages = []Page{}
+		}
+		writeJSON(w, http.StatusOK, pages)
+	}
+}
+
+// pageResponse is a page with the changes awaiting review.
+type pageResponse struct {
+	Page
+	Diff string `json:"diff"`
+}
+
+func handleGet(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		p, err := store.Get(r.Context(), q.Get("site"), q.Get("path"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, pageResponse{Page: *p, Diff: p.Diff()})
+	}
+}
+
+func handleDecide(decide func(ctx context.Context, d Decision) (*Page, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var d Decision
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+			return
+		}
+		// A signed-in reviewer is who they signed in as.
+		if id := siteauth.IdentityFrom(r.Context()); id != nil {
+			d.Reviewer = cmp.Or(id.Email, id.Name, id.Subject)
+		}
+		if d.Path == "" || d.Reviewer == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "path and reviewer are required"})
+			return
+		}
+		p, err := decide(r.Context(), d)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotPending), errors.Is(err, ErrStale):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,214 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// Store persists the pages under review.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a Store backed by the given database.
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+const pageColumns = `site, path, status, content, content_hash, approved, approved_hash, reviewer, note, generated_at, reviewed_at`
+
+// Gate records the pages a site was just generated with, by path, and
+// returns the content to publish for each. A page whose substance is what
+// was last approved is published as generated; a new page, or one that
+// changed, waits for review, and its last approved content is published
+// meanwhile, or nothing when it has none. A page generated again as a
+// reviewer rejected it stays rejected. Reviews of pages no longer
+// generated are withdrawn.
+func (s *Store) Gate(ctx context.Context, site string, pages map[string]string) (map[string]string, []Page, error) {
+	known, err := s.list(ctx, `WHERE site = ?`, site)
+	if err != nil {
+		return nil, nil, err
+	}
+	byPath := make(map[string]Page, len(known))
+	for _, p := range known {
+		byPath[p.Path] = p
+	}
+
+	paths := make([]string, 0, len(pages))
+	for path := range pages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	now := time.Now().UTC()
+	publish := make(map[string]string, len(pages))
+	var pending []Page
+	for _, path := range paths {
+		content := pages[path]
+		hash := Fingerprint(content)
+		p, ok := byPath[path]
+		switch {
+		case ok && p.ApprovedHash == hash:
+			// Unchanged in substance, so the fresh timestamps are published
+			// and become the approved content.
+			p.Status, p.Content, p.Hash, p.Approved = StatusApproved, content, hash, content
+			publish[path] = content
+		case ok && p.Hash == hash && p.Status != StatusApproved:
+			if p.Status == StatusPending {
+				pending = append(pending, p)
+			}
+			if !p.IsNew() {
+				publish[path] = p.Approved
+			}
+			continue
+		default:
+			if !ok {
+				p = Page{Site: site, Path: path}
+			}
+			p.Status, p.Content, p.Hash, p.GeneratedAt = StatusPending, content, hash, now
+			p.Reviewer, p.Note, p.ReviewedAt = "", "", nil
+			pending = append(pending, p)
+			if !p.IsNew() {
+				publish[path] = p.Approved
+			}
+		}
+		if err := s.save(ctx, p); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, p := range known {
+		if _, ok := pages[p.Path]; ok || p.Status == StatusApproved {
+			continue
+		}
+		if p.IsNew() {
+			_, err = s.db.ExecContext(ctx, `DELETE FROM review_pages WHERE site = ? AND path = ?`, p.Site, p.Path)
+		} else {
+			p.Status, p.Content, p.Hash = StatusApproved, p.Approved, p.ApprovedHash
+			err = s.save(ctx, p)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("withdrawing review of %s: %w", p.Path, err)
+		}
+	}
+	return publish, pending, nil
+}
+
+// Approve approves a page awaiting review, so the site publishes it from
+// its next build.
+func (s *Store) Approve(ctx context.Context, d Decision) (*Page, error) {
+	return s.decide(ctx, d, StatusApproved)
+}
+
+// Reject rejects a page awaiting review; the site keeps publishing the
+// content last approved until the page is generated differently.
+func (s *Store) Reject(ctx context.Context, d Decision) (*Page, error) {
+	return s.decide(ctx, d, StatusRejected)
+}
+
+func (s *Store) decide(ctx context.Context, d Decision, status Status) (*Page, error) {
+	p, err := s.Get(ctx, d.Site, d.Path)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != StatusPending {
+		return nil, fmt.Errorf("%s: %w", d.Path, ErrNotPending)
+	}
+	if d.Hash != "" && d.Hash != p.Hash {
+		return nil, fmt.Errorf("%s: %w", d.Path, ErrStale)
+	}
+	now := time.Now().UTC()
+	p.Status, p.Reviewer, p.Note, p.ReviewedAt = status, d.Reviewer, d.Note, &now
+	if status == StatusApproved {
+		p.Approved, p.ApprovedHash = p.Content, p.Hash
+	}
+	if err := s.save(ctx, *p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ApproveAll approves every page awaiting review, of every site, e.g. to
+// adopt a site as it is when review is first turned on. It returns how
+// many pages it approved.
+func (s *Store) ApproveAll(ctx context.Context, reviewer, note string) (int, error) {
+	pending, err := s.List(ctx, StatusPending)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range pending {
+		if _, err := s.Approve(ctx, Decision{Site: p.Site, Path: p.Path, Hash: p.Hash, Reviewer: reviewer, Note: note}); err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), nil
+}
+
+// Get returns a page of a site.
+func (s *Store) Get(ctx context.Context, site, path string) (*Page, error) {
+	pages, err := s.list(ctx, `WHERE site = ? AND path = ?`, site, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	return &pages[0], nil
+}
+
+// List returns the pages with a status, or every page when status is
+// empty, by site and then path.
+func (s *Store) List(ctx context.Context, status Status) ([]Page, error) {
+	if status == "" {
+		return s.list(ctx, "")
+	}
+	return s.list(ctx, `WHERE status = ?`, string(status))
+}
+
+func (s *Store) list(ctx context.Context, where string, args ...any) ([]Page, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+pageColumns+` FROM review_pages `+where+` ORDER BY site, path`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying review pages: %w", err)
+	}
+	defer rows.Close()
+	var pages []Page
+	for rows.Next() {
+		var p Page
+		var status string
+		var reviewed sql.NullTime
+		if err := rows.Scan(&p.Site, &p.Path, &status, &p.Content, &p.Hash, &p.Approved, &p.ApprovedHash, &p.Reviewer, &p.Note, &p.GeneratedAt, &reviewed); err != nil {
+			return nil, fmt.Errorf("scanning review page: %w", err)
+		}
+		p.Status = Status(status)
+		if reviewed.Valid {
+			p.ReviewedAt = &reviewed.Time
+		}
+		pages = append(pages, p)
+	}
+	return pages, rows.Err()
+}
+
+func (s *Store) save(ctx context.Context, p Page) error {
+	var reviewed sql.NullTime
+	if p.ReviewedAt != nil {
+		reviewed = sql.NullTime{Time: *p.ReviewedAt, Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO review_pages (`+pageColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (site, path) DO UPDATE SET
+			status = excluded.status, content = excluded.content, content_hash = excluded.content_hash,
+			approved = excluded.approved, approved_hash = excluded.approved_hash,
+			reviewer = excluded.reviewer, note = excluded.note,
+			generated_at = excluded.generated_at, reviewed_at = excluded.reviewed_at`,
+		p.Site, p.Path, string(p.Status), p.Content, p.Hash, p.Approved, p.ApprovedHash, p.Reviewer, p.Note, p.GeneratedAt, reviewed)
+	if err != nil {
+		return fmt.Errorf("saving review of %s: %w", p.Path, err)
+	}
+	return nil
+}
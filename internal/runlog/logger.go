@@ -0,0 +1,30 @@
+package runlog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Log formats accepted by Setup.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Setup installs the process-wide logger, writing to w as logfmt-style text
+// or as JSON, one object per line.
+func Setup(w io.Writer, format string, level slog.Level) error {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch format {
+	case "", FormatText:
+		h = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q: want text or json", format)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
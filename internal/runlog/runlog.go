@@ -0,0 +1,246 @@
+// Package runlog gives each generation run an ID, tags the run's log lines
+// with it, and keeps an append-only audit log of runs: who started them,
+// when, what they changed, and how they ended.
+package runlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileName is the audit log's name inside the output directory.
+const FileName = "runs.jsonl"
+
+// maxChanges caps the changes recorded per run; a run over a large
+// codebase can touch thousands of files.
+const maxChanges = 500
+
+// Status is where a run stands.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Run is one entry in the audit log.
+type Run struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"` // e.g. "generate", "repo sync"
+	Actor      string    `json:"actor"`
+	Project    string    `json:"project,omitempty"` // project or repo the run worked on
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Summary    string    `json:"summary,omitempty"`
+	// Changes lists what the run changed, one line each, such as
+	// "modified internal/api/api.go" or "synced orders (40 files)".
+	Changes []string `json:"changes,omitempty"`
+	// Omitted counts the changes left out past the cap.
+	Omitted int `json:"omitted_changes,omitempty"`
+
+	log *Log
+}
+
+// Changed records one change the run made.
+func (r *Run) Changed(format string, args ...any) {
+	if len(r.Changes) >= maxChanges {
+		r.Omitted++
+		return
+	}
+	r.Changes = append(r.Changes, fmt.Sprintf(format, args...))
+}
+
+// Logger returns the default logger tagged with the run's ID and command.
+func (r *Run) Logger() *slog.Logger {
+	return slog.Default().With("run_id", r.ID, "command", r.Command)
+}
+
+// Finish records how the run ended: failed if err is non-nil, otherwise
+// succeeded.
+func (r *Run) Finish(err error) error {
+	r.FinishedAt = time.Now().UTC()
+	r.Status = StatusSucceeded
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+	if r.log == nil {
+		return nil
+	}
+	return r.log.append(r)
+}
+
+// Log is an audit log of runs, stored as JSON lines. A run is written when
+// it starts and again when it finishes; the last line for an ID wins, so a
+// run that crashed stays "running".
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the audit log in dir. The file is created on the first run.
+func Open(dir string) *Log {
+	return &Log{path: filepath.Join(dir, FileName)}
+}
+
+// Start records the start of a run.
+func (l *Log) Start(command, project string) (*Run, error) {
+	r := &Run{
+		ID:        NewID(),
+		Command:   command,
+		Actor:     Actor(),
+		Project:   project,
+		StartedAt: time.Now().UTC(),
+		Status:    StatusRunning,
+		log:       l,
+	}
+	return r, l.append(r)
+}
+
+func (l *Log) append(r *Run) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Filter selects runs from the log. Zero fields match everything.
+type Filter struct {
+	Command string
+	Project string
+	Status  Status
+	Since   time.Time
+	Limit   int
+}
+
+// List returns the runs matching f, newest first.
+func (l *Log) List(f Filter) ([]Run, error) {
+	all, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	var runs []Run
+	for _, r := range all {
+		switch {
+		case f.Command != "" && r.Command != f.Command:
+		case f.Project != "" && r.Project != f.Project:
+		case f.Status != "" && r.Status != f.Status:
+		case !f.Since.IsZero() && r.StartedAt.Before(f.Since):
+		default:
+			runs = append(runs, r)
+		}
+	}
+	if f.Limit > 0 && len(runs) > f.Limit {
+		runs = runs[:f.Limit]
+	}
+	return runs, nil
+}
+
+// Get returns the run with the given ID or unique ID prefix, or nil if there
+// is none.
+func (l *Log) Get(id string) (*Run, error) {
+	all, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	var found *Run
+	for i := range all {
+		if !strings.HasPrefix(all[i].ID, id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("run ID %q is ambiguous", id)
+		}
+		found = &all[i]
+	}
+	return found, nil
+}
+
+// read loads every run, keeping the last line for each ID, newest first.
+func (l *Log) read() ([]Run, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	index := make(map[string]int)
+	var runs []Run
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var r Run
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", l.path, n, err)
+		}
+		if i, ok := index[r.ID]; ok {
+			runs[i] = r
+			continue
+		}
+		index[r.ID] = len(runs)
+		runs = append(runs, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	return runs, nil
+}
+
+// NewID returns a short random run ID.
+func NewID() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+// Actor names who started a run: $AUTODOC_ACTOR, the CI actor on GitHub
+// Actions or GitLab CI, or the local user.
+func Actor() string {
+	for _, env := range []string{"AUTODOC_ACTOR", "GITHUB_ACTOR", "GITLAB_USER_LOGIN"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if v := os.Getenv("USER"); v != "" {
+		return v
+	}
+	return "unknown"
+}
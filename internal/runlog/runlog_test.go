@@ -0,0 +1,135 @@
+package runlog
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunRoundTrip(t *testing.T) {
+	t.Setenv("AUTODOC_ACTOR", "ci-bot")
+	dir := filepath.Join(t.TempDir(), ".autodoc")
+	l := Open(dir)
+
+	gen, err := l.Start("generate", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.Changed("analyzed %s", "main.go")
+	gen.Summary = "1 files processed"
+	if err := gen.Finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	sync, err := l.Start("repo sync", "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sync.StartedAt = sync.StartedAt.Add(time.Second)
+	if err := sync.Finish(errors.New("importing: boom")); err != nil {
+		t.Fatal(err)
+	}
+	crashed, err := l.Start("update", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := l.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("got %d runs, want 3 (the last line for each ID wins)", len(runs))
+	}
+	if runs[0].ID != sync.ID {
+		t.Errorf("runs should be newest first, got %s first", runs[0].Command)
+	}
+	got, err := l.Get(gen.ID[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Status != StatusSucceeded || got.Actor != "ci-bot" || got.FinishedAt.IsZero() ||
+		strings.Join(got.Changes, ",") != "analyzed main.go" || got.Summary != "1 files processed" {
+		t.Errorf("generate run = %+v", got)
+	}
+	if got, _ := l.Get(crashed.ID); got == nil || got.Status != StatusRunning {
+		t.Errorf("a run that never finished should stay running, got %+v", got)
+	}
+	if got, _ := l.Get("zzz"); got != nil {
+		t.Errorf("Get of an unknown ID = %+v, want nil", got)
+	}
+	if _, err := l.Get(""); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("a prefix matching several runs should be ambiguous, got %v", err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		f    Filter
+		want int
+	}{
+		{"command", Filter{Command: "repo sync"}, 1},
+		{"project", Filter{Project: "orders"}, 2},
+		{"status", Filter{Status: StatusFailed}, 1},
+		{"since", Filter{Since: time.Now().Add(time.Hour)}, 0},
+		{"limit", Filter{Limit: 2}, 2},
+	} {
+		runs, err := l.List(tt.f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != tt.want {
+			t.Errorf("%s filter: got %d runs, want %d", tt.name, len(runs), tt.want)
+		}
+	}
+	if runs, _ := l.List(Filter{Status: StatusFailed}); len(runs) == 1 && runs[0].Error != "importing: boom" {
+		t.Errorf("failed run error = %q", runs[0].Error)
+	}
+}
+
+func TestChangedCap(t *testing.T) {
+	r := &Run{}
+	for i := 0; i < maxChanges+3; i++ {
+		r.Changed("analyzed file%d.go", i)
+	}
+	if len(r.Changes) != maxChanges || r.Omitted != 3 {
+		t.Errorf("got %d changes and %d omitted, want %d and 3", len(r.Changes), r.Omitted, maxChanges)
+	}
+	// A run without a log, e.g. when the audit log could not be written,
+	// still finishes.
+	if err := r.Finish(nil); err != nil || r.Status != StatusSucceeded {
+		t.Errorf("Finish = %v, status %s", err, r.Status)
+	}
+}
+
+func TestListMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	l := Open(dir)
+	if runs, err := l.List(Filter{}); err != nil || len(runs) != 0 {
+		t.Errorf("a missing log should list nothing, got %v, %v", runs, err)
+	}
+	os.WriteFile(filepath.Join(dir, FileName), []byte("{\"id\":\"a\"}\nnot json\n"), 0o644)
+	if _, err := l.List(Filter{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("a corrupt line should be reported by number, got %v", err)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var b strings.Builder
+	if err := Setup(&b, FormatJSON, slog.LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	run := &Run{ID: "abc123", Command: "update"}
+	run.Logger().Warn("skipped", "file", "main.go")
+	slog.Debug("hidden")
+	if got := b.String(); !strings.Contains(got, `"run_id":"abc123"`) || !strings.Contains(got, `"file":"main.go"`) || strings.Contains(got, "hidden") {
+		t.Errorf("log output = %s", got)
+	}
+	if err := Setup(io.Discard, "xml", slog.LevelInfo); err == nil {
+		t.Error("an unknown format should be rejected")
+	}
+}
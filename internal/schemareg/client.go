@@ -0,0 +1,113 @@
+package schemareg
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// DefaultPasswordEnv holds the registry password or API secret when the
+// config doesn't name another variable.
+const DefaultPasswordEnv = "SCHEMA_REGISTRY_PASSWORD"
+
+// Client reads subjects and their versions from a Confluent Schema
+// Registry, or any registry serving its REST API.
+type Client struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+}
+
+// New returns a client for cfg, or nil when no registry URL is configured.
+func New(cfg config.SchemaRegistryConfig) *Client {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &Client{
+		url:      strings.TrimRight(cfg.URL, "/"),
+		username: cfg.Username,
+		password: os.Getenv(cmp.Or(cfg.PasswordEnv, DefaultPasswordEnv)),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Fetch returns the versions of every subject that are newer than the
+// latest in known, by subject and then version. Versions whose schema
+// can't be read are left out with a warning.
+func (c *Client) Fetch(ctx context.Context, known map[string]int) ([]Schema, error) {
+	var subjects []string
+	if err := c.get(ctx, "/subjects", &subjects); err != nil {
+		return nil, fmt.Errorf("listing subjects: %w", err)
+	}
+	sort.Strings(subjects)
+	var schemas []Schema
+	for _, subject := range subjects {
+		var versions []int
+		if err := c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions", &versions); err != nil {
+			return nil, fmt.Errorf("listing versions of %s: %w", subject, err)
+		}
+		sort.Ints(versions)
+		for _, v := range versions {
+			if v <= known[subject] {
+				continue
+			}
+			var got struct {
+				Version    int    `json:"version"`
+				SchemaType string `json:"schemaType"`
+				Schema     string `json:"schema"`
+			}
+			if err := c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/"+strconv.Itoa(v), &got); err != nil {
+				return nil, fmt.Errorf("reading %s version %d: %w", subject, v, err)
+			}
+			schemaType := cmp.Or(strings.ToUpper(got.SchemaType), TypeAvro)
+			name, fields, err := Parse(schemaType, got.Schema)
+			if err != nil {
+				slog.Warn("skipping a schema version that could not be read", "subject", subject, "version", v, "err", err)
+				continue
+			}
+			schemas = append(schemas, Schema{
+				Subject: subject,
+				Version: cmp.Or(got.Version, v),
+				Type:    schemaType,
+				Name:    name,
+				Fields:  fields,
+				Source:  c.url,
+			})
+		}
+	}
+	return schemas, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
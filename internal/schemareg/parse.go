@@ -0,0 +1,278 @@
+package schemareg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Parse reads the name and fields of a schema of the given type; an empty
+// type is Avro, as in the registry.
+func Parse(schemaType, text string) (string, []Field, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", TypeAvro:
+		return parseAvro(text)
+	case TypeJSON:
+		return parseJSONSchema(text)
+	case TypeProtobuf:
+		return parseProtobuf(text)
+	}
+	return "", nil, fmt.Errorf("unsupported schema type %q", schemaType)
+}
+
+// parseAvro reads an Avro record, flattening the fields of nested records.
+func parseAvro(text string) (string, []Field, error) {
+	var root any
+	if err := json.Unmarshal([]byte(text), &root); err != nil {
+		return "", nil, fmt.Errorf("parsing Avro schema: %w", err)
+	}
+	record, ok := root.(map[string]any)
+	if !ok || record["type"] != "record" {
+		return "", nil, fmt.Errorf("parsing Avro schema: not a record")
+	}
+	var fields []Field
+	avroFields("", record, &fields)
+	name, _ := record["name"].(string)
+	return name, fields, nil
+}
+
+func avroFields(prefix string, record map[string]any, fields *[]Field) {
+	list, _ := record["fields"].([]any)
+	for _, f := range list {
+		fm, ok := f.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := fm["name"].(string)
+		if name == "" {
+			continue
+		}
+		*fields = append(*fields, Field{Name: prefix + name, Type: avroType(fm["type"])})
+		avroNested(prefix+name, fm["type"], fields)
+	}
+}
+
+// avroNested flattens the records a field's type holds, directly, in a
+// union, or as array items.
+func avroNested(name string, t any, fields *[]Field) {
+	switch t := t.(type) {
+	case []any:
+		for _, u := range t {
+			avroNested(name, u, fields)
+		}
+	case map[string]any:
+		switch t["type"] {
+		case "record":
+			avroFields(name+".", t, fields)
+		case "array":
+			avroNested(name+"[]", t["items"], fields)
+		case "map":
+			avroNested(name+"{}", t["values"], fields)
+		}
+	}
+}
+
+// avroType describes an Avro type in one word, as in "long(timestamp-millis)",
+// "null|string", or "array<OrderLine>".
+func avroType(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []any:
+		types := make([]string, len(t))
+		for i, u := range t {
+			types[i] = avroType(u)
+		}
+		return strings.Join(types, "|")
+	case map[string]any:
+		kind, _ := t["type"].(string)
+		switch kind {
+		case "record", "enum", "fixed":
+			name, _ := t["name"].(string)
+			return name
+		case "array":
+			return "array<" + avroType(t["items"]) + ">"
+		case "map":
+			return "map<" + avroType(t["values"]) + ">"
+		}
+		if logical, _ := t["logicalType"].(string); logical != "" {
+			return kind + "(" + logical + ")"
+		}
+		return avroType(t["type"])
+	}
+	return "?"
+}
+
+// parseJSONSchema reads a JSON Schema object, flattening the properties of
+// nested objects.
+func parseJSONSchema(text string) (string, []Field, error) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		return "", nil, fmt.Errorf("parsing JSON schema: %w", err)
+	}
+	var fields []Field
+	jsonFields("", doc, &fields)
+	name, _ := doc["title"].(string)
+	return name, fields, nil
+}
+
+func jsonFields(prefix string, def map[string]any, fields *[]Field) {
+	props, _ := def["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p, _ := props[name].(map[string]any)
+		*fields = append(*fields, Field{Name: prefix + name, Type: jsonType(p)})
+		jsonFields(prefix+name+".", p, fields)
+		if items, ok := p["items"].(map[string]any); ok {
+			jsonFields(prefix+name+"[].", items, fields)
+		}
+	}
+}
+
+// jsonType describes a JSON Schema property's type in one word, as in
+// "string(date-time)", "integer|null", or "array<Line>".
+func jsonType(p map[string]any) string {
+	if ref, ok := p["$ref"].(string); ok {
+		return path.Base(ref)
+	}
+	var t string
+	switch v := p["type"].(type) {
+	case string:
+		t = v
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, u := range v {
+			if s, ok := u.(string); ok {
+				types = append(types, s)
+			}
+		}
+		t = strings.Join(types, "|")
+	default:
+		if p["properties"] != nil {
+			t = "object"
+		} else {
+			t = "any"
+		}
+	}
+	if items, ok := p["items"].(map[string]any); ok {
+		return t + "<" + jsonType(items) + ">"
+	}
+	if format, ok := p["format"].(string); ok {
+		return t + "(" + format + ")"
+	}
+	return t
+}
+
+var (
+	protoMessage = regexp.MustCompile(`^\s*message\s+(\w+)\s*\{`)
+	protoField   = regexp.MustCompile(`^\s*((?:repeated\s+|optional\s+|required\s+)?(?:map\s*<[^>]*>|[\w.]+))\s+(\w+)\s*=\s*\d+`)
+)
+
+// parseProtobuf reads the first message of a .proto schema, which the
+// registry takes as the subject's.
+func parseProtobuf(text string) (string, []Field, error) {
+	var name string
+	var fields []Field
+	depth, open := 0, -1
+	for _, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		switch {
+		case open < 0 && name == "":
+			if m := protoMessage.FindStringSubmatch(line); m != nil {
+				name, open = m[1], depth
+			}
+		case open >= 0 && depth == open+1:
+			if m := protoField.FindStringSubmatch(line); m != nil {
+				fields = append(fields, Field{Name: m[2], Type: strings.Join(strings.Fields(m[1]), " ")})
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if open >= 0 && depth <= open {
+			break
+		}
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("parsing protobuf schema: no message")
+	}
+	return name, fields, nil
+}
+
+// ScanFile reads the schemas a repo's file declares: an Avro record in an
+// .avsc file, or a JSON Schema in a .json file. Other files, OpenAPI
+// documents among them, have none.
+func ScanFile(repo, filePath string, content []byte) []Schema {
+	var schemaType string
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".avsc":
+		schemaType = TypeAvro
+	case ".json":
+		var doc map[string]any
+		if json.Unmarshal(content, &doc) != nil || doc["openapi"] != nil || doc["swagger"] != nil {
+			return nil
+		}
+		// Other files, such as tsconfig.json, can name the schema they
+		// follow in $schema too.
+		dialect, _ := doc["$schema"].(string)
+		if !strings.Contains(dialect, "json-schema.org") && (doc["properties"] == nil || doc["type"] != "object") {
+			return nil
+		}
+		schemaType = TypeJSON
+	default:
+		return nil
+	}
+	name, fields, err := Parse(schemaType, string(content))
+	if err != nil || len(fields) == 0 {
+		return nil
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	}
+	return []Schema{{
+		Subject: FileSubject(repo, filePath, name),
+		Type:    schemaType,
+		Name:    name,
+		Fields:  fields,
+		Repo:    repo,
+		Source:  filePath,
+	}}
+}
+
+// ScanRepo reads the schemas declared in the files of the checkout at root,
+// skipping hidden, vendored, and dependency directories.
+func ScanRepo(repo, root string) []Schema {
+	var schemas []Schema
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".avsc", ".json":
+		default:
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		schemas = append(schemas, ScanFile(repo, filepath.ToSlash(rel), content)...)
+		return nil
+	})
+	return schemas
+}
@@ -0,0 +1,123 @@
+// Package schemareg tracks the payload schemas of topics: the subjects of a
+// Confluent Schema Registry and the Avro and JSON Schema files in the
+// registered repos. Each version is recorded with how its fields differ
+// from the version before, so a change to a message's shape can be
+// announced to the services producing and consuming it.
+package schemareg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema types, as the registry names them. A registry version without a
+// schemaType is Avro.
+const (
+	TypeAvro     = "AVRO"
+	TypeJSON     = "JSON"
+	TypeProtobuf = "PROTOBUF"
+)
+
+// Schema is one version of a subject's schema.
+type Schema struct {
+	// Subject is the registry subject, such as "orders-value", or for a
+	// schema file, FileSubject's "repo:path#Name".
+	Subject string `json:"subject"`
+	// Version is the registry's; a schema file's versions are counted from
+	// the first one seen.
+	Version int     `json:"version"`
+	Type    string  `json:"type"`
+	Name    string  `json:"name,omitempty"` // the record, message, or title declared
+	Fields  []Field `json:"fields"`
+	// Repo is the repo declaring a schema file; empty for the registry's.
+	Repo   string    `json:"repo,omitempty"`
+	Source string    `json:"source"` // the registry URL, or the file's path
+	SeenAt time.Time `json:"seen_at"`
+	// Changes are how Fields differ from the previous version's; empty for
+	// a subject's first version.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// Field is a field of a schema. Fields of nested records and objects are
+// named by their path, as in "customer.email", with "[]" marking array
+// items.
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// FieldChange is a field added, removed, or changed in type between two
+// versions. Old is empty for an added field and New for a removed one.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (c FieldChange) String() string {
+	switch {
+	case c.Old == "":
+		return fmt.Sprintf("added %s (%s)", c.Field, c.New)
+	case c.New == "":
+		return fmt.Sprintf("removed %s (%s)", c.Field, c.Old)
+	}
+	return fmt.Sprintf("changed %s from %s to %s", c.Field, c.Old, c.New)
+}
+
+// Breaking reports whether the change can break a consumer reading the
+// field: it was removed or its type changed.
+func (c FieldChange) Breaking() bool {
+	return c.New == "" || c.Old != ""
+}
+
+// Diff compares the fields of two versions, ordering the changes by field.
+func Diff(old, new []Field) []FieldChange {
+	before := make(map[string]string, len(old))
+	for _, f := range old {
+		before[f.Name] = f.Type
+	}
+	after := make(map[string]string, len(new))
+	var changes []FieldChange
+	for _, f := range new {
+		after[f.Name] = f.Type
+		if t, ok := before[f.Name]; !ok || t != f.Type {
+			changes = append(changes, FieldChange{Field: f.Name, Old: t, New: f.Type})
+		}
+	}
+	for _, f := range old {
+		if _, ok := after[f.Name]; !ok {
+			changes = append(changes, FieldChange{Field: f.Name, Old: f.Type})
+		}
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int { return strings.Compare(a.Field, b.Field) })
+	return changes
+}
+
+// Topic returns the topic a registry subject describes under the default
+// TopicNameStrategy, "<topic>-value" or "<topic>-key", or "" for a subject
+// named another way.
+func Topic(subject string) string {
+	for _, suffix := range []string{"-value", "-key"} {
+		if topic, ok := strings.CutSuffix(subject, suffix); ok && topic != "" {
+			return topic
+		}
+	}
+	return ""
+}
+
+// FileSubject is the subject under which a schema declared in a repo's
+// file is tracked.
+func FileSubject(repo, path, name string) string {
+	return repo + ":" + path + "#" + name
+}
+
+// Label names the schema version for people: its subject and version, and
+// for a schema file, its name and where it is declared.
+func (s Schema) Label() string {
+	if s.Repo != "" {
+		return fmt.Sprintf("%s v%d (%s: %s)", s.Name, s.Version, s.Repo, s.Source)
+	}
+	return fmt.Sprintf("%s v%d", s.Subject, s.Version)
+}
@@ -0,0 +1,205 @@
+package schemareg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+const orderV1 = `{"type": "record", "name": "OrderPlaced", "fields": [
+	{"name": "order_id", "type": "string"},
+	{"name": "amount", "type": "int"},
+	{"name": "phone", "type": ["null", "string"]},
+	{"name": "customer", "type": {"type": "record", "name": "Customer", "fields": [{"name": "id", "type": "string"}]}}
+]}`
+
+const orderV2 = `{"type": "record", "name": "OrderPlaced", "fields": [
+	{"name": "order_id", "type": "string"},
+	{"name": "amount", "type": "long"},
+	{"name": "placed_at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "customer", "type": {"type": "record", "name": "Customer", "fields": [{"name": "id", "type": "string"}, {"name": "email", "type": "string"}]}}
+]}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		schemaType, text string
+		name, fields     string
+	}{
+		{"", orderV2, "OrderPlaced", "order_id:string amount:long placed_at:long(timestamp-millis) customer:Customer customer.id:string customer.email:string"},
+		{TypeJSON, `{"title": "Refund", "type": "object", "properties": {
+			"amount": {"type": "number"},
+			"issued_at": {"type": "string", "format": "date-time"},
+			"lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": ["string", "null"]}}}}
+		}}`, "Refund", "amount:number issued_at:string(date-time) lines:array<object> lines[].sku:string|null"},
+		{TypeProtobuf, `syntax = "proto3";
+message Shipment {
+  string id = 1; // the tracking number
+  repeated Parcel parcels = 2;
+  message Parcel {
+    int32 weight = 1;
+  }
+}
+message Other { string x = 1; }`, "Shipment", "id:string parcels:repeated Parcel"},
+	}
+	for _, tt := range tests {
+		name, fields, err := Parse(tt.schemaType, tt.text)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.schemaType, err)
+			continue
+		}
+		var got []string
+		for _, f := range fields {
+			got = append(got, f.Name+":"+f.Type)
+		}
+		if name != tt.name || strings.Join(got, " ") != tt.fields {
+			t.Errorf("Parse(%q) = %s, %s; want %s, %s", tt.schemaType, name, strings.Join(got, " "), tt.name, tt.fields)
+		}
+	}
+	if _, _, err := Parse("XML", "<a/>"); err == nil {
+		t.Error("an unsupported schema type should fail")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	_, v1, _ := Parse(TypeAvro, orderV1)
+	_, v2, _ := Parse(TypeAvro, orderV2)
+	var got []string
+	breaking := 0
+	for _, c := range Diff(v1, v2) {
+		got = append(got, c.String())
+		if c.Breaking() {
+			breaking++
+		}
+	}
+	want := "changed amount from int to long; added customer.email (string); removed phone (null|string); added placed_at (long(timestamp-millis))"
+	if strings.Join(got, "; ") != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", strings.Join(got, "; "), want)
+	}
+	if breaking != 2 {
+		t.Errorf("%d breaking changes, want the type change and the removal", breaking)
+	}
+}
+
+func TestTopic(t *testing.T) {
+	for subject, want := range map[string]string{"orders.placed-value": "orders.placed", "orders-key": "orders", "com.acme.OrderPlaced": "", "-value": ""} {
+		if got := Topic(subject); got != want {
+			t.Errorf("Topic(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
+
+func TestScanRepo(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "schemas"), 0o755)
+	os.MkdirAll(filepath.Join(root, "node_modules", "lib"), 0o755)
+	os.WriteFile(filepath.Join(root, "schemas", "order.avsc"), []byte(orderV1), 0o644)
+	os.WriteFile(filepath.Join(root, "schemas", "refund.json"), []byte(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object", "properties": {"amount": {"type": "number"}}}`), 0o644)
+	os.WriteFile(filepath.Join(root, "tsconfig.json"), []byte(`{"$schema": "https://json.schemastore.org/tsconfig", "compilerOptions": {}}`), 0o644)
+	os.WriteFile(filepath.Join(root, "openapi.json"), []byte(`{"openapi": "3.0.0", "properties": {}, "type": "object"}`), 0o644)
+	os.WriteFile(filepath.Join(root, "node_modules", "lib", "x.avsc"), []byte(orderV1), 0o644)
+
+	schemas := ScanRepo("orders", root)
+	if len(schemas) != 2 {
+		t.Fatalf("ScanRepo = %+v; want the Avro record and the JSON Schema", schemas)
+	}
+	if s := schemas[0]; s.Subject != "orders:schemas/order.avsc#OrderPlaced" || s.Type != TypeAvro || len(s.Fields) != 5 {
+		t.Errorf("Avro schema = %+v", s)
+	}
+	if s := schemas[1]; s.Name != "refund" || s.Type != TypeJSON || s.Label() != "refund v0 (orders: schemas/refund.json)" {
+		t.Errorf("JSON schema = %+v", s)
+	}
+}
+
+func TestClientAndStore(t *testing.T) {
+	versions := map[int]string{1: orderV1, 2: orderV2}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "autodoc" || pass != "s3cret" {
+			http.Error(w, `{"error_code": 401}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/subjects":
+			json.NewEncoder(w).Encode([]string{"orders.placed-value"})
+		case "/subjects/orders.placed-value/versions":
+			json.NewEncoder(w).Encode([]int{2, 1})
+		case "/subjects/orders.placed-value/versions/1", "/subjects/orders.placed-value/versions/2":
+			v := int(r.URL.Path[len(r.URL.Path)-1] - '0')
+			json.NewEncoder(w).Encode(map[string]any{"subject": "orders.placed-value", "version": v, "id": 10 + v, "schema": versions[v]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if New(config.SchemaRegistryConfig{}) != nil {
+		t.Error("New without a URL should return nil")
+	}
+	t.Setenv("REGISTRY_SECRET", "s3cret")
+	client := New(config.SchemaRegistryConfig{URL: srv.URL + "/", Username: "autodoc", PasswordEnv: "REGISTRY_SECRET"})
+
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := NewStore(database)
+	ctx := context.Background()
+
+	first, err := client.Fetch(ctx, map[string]int{"orders.placed-value": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].Version != 2 || first[0].Type != TypeAvro || first[0].Source != srv.URL {
+		t.Fatalf("Fetch past version 1 = %+v", first)
+	}
+	all, err := client.Fetch(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first sighting of a subject is recorded without being announced.
+	if changed, err := store.Record(ctx, all[:1]); err != nil || len(changed) != 0 {
+		t.Fatalf("Record(v1) = %+v, %v; want nothing to announce", changed, err)
+	}
+	changed, err := store.Record(ctx, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0].Version != 2 || len(changed[0].Changes) != 4 {
+		t.Fatalf("Record(v1, v2) = %+v; want version 2 with its four field changes", changed)
+	}
+	latest, err := store.Latest(ctx)
+	if err != nil || latest["orders.placed-value"] != 2 {
+		t.Errorf("Latest = %v, %v", latest, err)
+	}
+
+	// A schema file gets a new version only when its fields change.
+	file := ScanFile("orders", "order.avsc", []byte(orderV1))
+	for _, content := range []string{orderV1, orderV1, orderV2} {
+		if changed, err = store.Record(ctx, ScanFile("orders", "order.avsc", []byte(content))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(changed) != 1 || changed[0].Version != 2 || changed[0].Subject != file[0].Subject {
+		t.Errorf("schema file versions = %+v", changed)
+	}
+	stored, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 4 || stored[2].Subject != file[0].Subject || stored[3].Changes[0].String() != "changed amount from int to long" {
+		t.Errorf("List = %+v", stored)
+	}
+
+	client.password = "wrong"
+	if _, err := client.Fetch(ctx, nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("a rejected request should fail with its status, got %v", err)
+	}
+}
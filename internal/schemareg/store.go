@@ -0,0 +1,129 @@
+package schemareg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// Store persists the versions of every subject seen.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a Store backed by the given database.
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+// Record adds the versions not stored yet, each with its changes from the
+// subject's previous version, and returns the new versions of subjects that
+// were already known: the schema changes to announce. A registry version is
+// new when its number is higher than the latest stored; a schema file's is
+// new when its fields differ from the latest stored, and is numbered after
+// it.
+func (s *Store) Record(ctx context.Context, schemas []Schema) ([]Schema, error) {
+	all, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]Schema)
+	for _, v := range all {
+		latest[v.Subject] = v
+	}
+
+	schemas = slices.Clone(schemas)
+	sort.SliceStable(schemas, func(i, j int) bool {
+		if schemas[i].Subject != schemas[j].Subject {
+			return schemas[i].Subject < schemas[j].Subject
+		}
+		return schemas[i].Version < schemas[j].Version
+	})
+	now := time.Now().UTC()
+	var changed []Schema
+	for _, v := range schemas {
+		prev, known := latest[v.Subject]
+		switch {
+		case v.Repo == "" && known && v.Version <= prev.Version:
+			continue
+		case v.Repo != "" && known && slices.Equal(v.Fields, prev.Fields):
+			continue
+		case v.Repo != "":
+			v.Version = prev.Version + 1
+		}
+		if v.SeenAt.IsZero() {
+			v.SeenAt = now
+		}
+		v.Changes = nil
+		if known {
+			v.Changes = Diff(prev.Fields, v.Fields)
+		}
+		fields, err := json.Marshal(v.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling fields: %w", err)
+		}
+		changes, err := json.Marshal(v.Changes)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling changes: %w", err)
+		}
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO schema_versions (subject, version, schema_type, name, fields, changes, repo, source, seen_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			v.Subject, v.Version, v.Type, v.Name, string(fields), string(changes), v.Repo, v.Source, v.SeenAt)
+		if err != nil {
+			return nil, fmt.Errorf("saving %s version %d: %w", v.Subject, v.Version, err)
+		}
+		latest[v.Subject] = v
+		if known {
+			changed = append(changed, v)
+		}
+	}
+	return changed, nil
+}
+
+// Latest returns the latest stored version of each subject.
+func (s *Store) Latest(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT subject, MAX(version) FROM schema_versions GROUP BY subject`)
+	if err != nil {
+		return nil, fmt.Errorf("querying schema versions: %w", err)
+	}
+	defer rows.Close()
+	latest := make(map[string]int)
+	for rows.Next() {
+		var subject string
+		var version int
+		if err := rows.Scan(&subject, &version); err != nil {
+			return nil, fmt.Errorf("scanning schema version: %w", err)
+		}
+		latest[subject] = version
+	}
+	return latest, rows.Err()
+}
+
+// List returns every stored version, by subject and then version.
+func (s *Store) List(ctx context.Context) ([]Schema, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT subject, version, schema_type, name, fields, changes, repo, source, seen_at
+		FROM schema_versions ORDER BY subject, version`)
+	if err != nil {
+		return nil, fmt.Errorf("querying schema versions: %w", err)
+	}
+	defer rows.Close()
+	var schemas []Schema
+	for rows.Next() {
+		var v Schema
+		var fields, changes string
+		if err := rows.Scan(&v.Subject, &v.Version, &v.Type, &v.Name, &fields, &changes, &v.Repo, &v.Source, &v.SeenAt); err != nil {
+			return nil, fmt.Errorf("scanning schema version: %w", err)
+		}
+		json.Unmarshal([]byte(fields), &v.Fields)
+		json.Unmarshal([]byte(changes), &v.Changes)
+		schemas = append(schemas, v)
+	}
+	return schemas, rows.Err()
+}
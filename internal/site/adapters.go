@@ -0,0 +1,291 @@
+package site
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+)
+
+// Output formats for the generated docs: the built-in site, or markdown laid
+// out for an existing docs toolchain.
+const (
+	FormatHTML       = "html"
+	FormatMkDocs     = "mkdocs"
+	FormatDocusaurus = "docusaurus"
+)
+
+// ValidateFormat checks an output format name.
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatHTML, FormatMkDocs, FormatDocusaurus:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (want %s, %s, or %s)", format, FormatHTML, FormatMkDocs, FormatDocusaurus)
+}
+
+// Adapter writes the generated markdown for MkDocs (Material) or
+// Docusaurus instead of rendering it with SiteGenerator: each page gets the
+// front matter the tool expects, the navigation is written as its config
+// (mkdocs.yml or sidebars.js) in the same order as the built-in sidebar,
+// and assets go where the tool serves them from. The pages themselves go
+// in OutputDir/docs, so the output can be merged into an existing project.
+type Adapter struct {
+	Format      string
+	DocsDir     string
+	OutputDir   string
+	ProjectName string
+	LogoPath    string // Path to a logo image file (relative to project root).
+	// Artifacts, when set, publishes through the artifact store, so pages
+	// an earlier run wrote that this one doesn't are removed.
+	Artifacts *artifacts.Store
+	// Published is filled in by Write when Artifacts is set.
+	Published artifacts.PublishStats
+}
+
+// NewAdapter creates an Adapter for one of the markdown formats.
+func NewAdapter(format, docsDir, outputDir, projectName string) (*Adapter, error) {
+	if format != FormatMkDocs && format != FormatDocusaurus {
+		return nil, fmt.Errorf("no markdown adapter for format %q", format)
+	}
+	return &Adapter{
+		Format:      format,
+		DocsDir:     docsDir,
+		OutputDir:   outputDir,
+		ProjectName: projectName,
+	}, nil
+}
+
+// Write lays out the docs and returns the number of pages written.
+func (a *Adapter) Write() (int, error) {
+	if a.Artifacts == nil {
+		return a.write(a.OutputDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(a.OutputDir)), 0o755); err != nil {
+		return 0, err
+	}
+	stageDir, err := os.MkdirTemp(filepath.Dir(filepath.Clean(a.OutputDir)), ".staging-"+a.Format+"-")
+	if err != nil {
+		return 0, fmt.Errorf("creating staging dir: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	n, err := a.write(stageDir)
+	if err != nil {
+		return 0, err
+	}
+	a.Published, err = a.Artifacts.Publish(context.Background(), stageDir, a.OutputDir)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (a *Adapter) write(outDir string) (int, error) {
+	var mdPaths, assets []string
+	err := filepath.Walk(a.DocsDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(a.DocsDir, file)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(file, ".md") {
+			mdPaths = append(mdPaths, filepath.ToSlash(rel))
+		} else {
+			assets = append(assets, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walking docs dir: %w", err)
+	}
+	if len(mdPaths) == 0 {
+		return 0, fmt.Errorf("no markdown files found in %s", a.DocsDir)
+	}
+
+	docsOut := filepath.Join(outDir, "docs")
+	titleMap := make(map[string]string, len(mdPaths))
+	for _, rel := range mdPaths {
+		content, err := os.ReadFile(filepath.Join(a.DocsDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return 0, err
+		}
+		titleMap[rel] = extractTitle(string(content), rel)
+		if err := writeFile(filepath.Join(docsOut, filepath.FromSlash(rel)), []byte(a.frontMatter(rel, titleMap[rel])+string(content))); err != nil {
+			return 0, err
+		}
+	}
+	for _, rel := range assets {
+		if err := copyFile(filepath.Join(a.DocsDir, filepath.FromSlash(rel)), filepath.Join(docsOut, filepath.FromSlash(rel))); err != nil {
+			return 0, err
+		}
+	}
+
+	logo := a.copyLogo(outDir)
+	tree := BuildTree(mdPaths, titleMap)
+	if a.Format == FormatMkDocs {
+		err = writeFile(filepath.Join(outDir, "mkdocs.yml"), []byte(a.mkdocsConfig(tree, titleMap, logo)))
+	} else {
+		err = writeFile(filepath.Join(outDir, "sidebars.js"), []byte(docusaurusSidebars(tree, titleMap)))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(mdPaths), nil
+}
+
+// frontMatter is the YAML front matter for a page. Docusaurus gets the doc
+// id spelled out, since it would otherwise strip number prefixes from file
+// names and the sidebar would point at ids that don't exist; the home page
+// is served at the root.
+func (a *Adapter) frontMatter(rel, title string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", yamlString(title))
+	if a.Format == FormatDocusaurus {
+		fmt.Fprintf(&b, "id: %s\n", yamlString(path.Base(strings.TrimSuffix(rel, ".md"))))
+		fmt.Fprintf(&b, "sidebar_label: %s\n", yamlString(title))
+		if rel == "index.md" {
+			b.WriteString("slug: /\n")
+		}
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// copyLogo copies the configured logo into the tool's asset directory and
+// returns its path as the tool's config refers to it.
+func (a *Adapter) copyLogo(outDir string) string {
+	if a.LogoPath == "" {
+		return ""
+	}
+	name := "logo" + filepath.Ext(a.LogoPath)
+	dest, ref := filepath.Join(outDir, "docs", "assets", name), "assets/"+name
+	if a.Format == FormatDocusaurus {
+		dest, ref = filepath.Join(outDir, "static", "img", name), "img/"+name
+	}
+	if err := copyFile(a.LogoPath, dest); err != nil {
+		slog.Warn("could not copy logo", "phase", "assets", "path", a.LogoPath, "err", err)
+		return ""
+	}
+	return ref
+}
+
+// mkdocsConfig is an mkdocs.yml for the Material theme, with Mermaid
+// fences rendered as diagrams and the nav in sidebar order.
+func (a *Adapter) mkdocsConfig(tree *FileTree, titles map[string]string, logo string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "site_name: %s\n", yamlString(a.ProjectName))
+	b.WriteString("docs_dir: docs\n")
+	b.WriteString("theme:\n  name: material\n")
+	if logo != "" {
+		fmt.Fprintf(&b, "  logo: %s\n", yamlString(logo))
+	}
+	b.WriteString("  features:\n    - navigation.indexes\n    - search.highlight\n")
+	b.WriteString("plugins:\n  - search\n")
+	b.WriteString("markdown_extensions:\n")
+	b.WriteString("  - tables\n  - admonition\n  - toc:\n      permalink: true\n")
+	b.WriteString("  - pymdownx.superfences:\n      custom_fences:\n")
+	b.WriteString("        - name: mermaid\n          class: mermaid\n          format: !!python/name:pymdownx.superfences.fence_code_format\n")
+	b.WriteString("nav:\n")
+	if _, ok := titles["index.md"]; ok {
+		b.WriteString("  - Home: index.md\n")
+	}
+	writeMkDocsNav(&b, tree, 1)
+	return b.String()
+}
+
+func writeMkDocsNav(b *strings.Builder, node *FileTree, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, c := range node.Children {
+		switch {
+		case c.IsDir:
+			fmt.Fprintf(b, "%s- %s:\n", indent, yamlString(c.Title))
+			writeMkDocsNav(b, c, depth+1)
+		case c.Path == "index.md":
+			// Already listed as Home.
+		case c.isOverview():
+			// With navigation.indexes, a bare index page is the section's
+			// own page.
+			fmt.Fprintf(b, "%s- %s\n", indent, yamlString(c.Path))
+		default:
+			fmt.Fprintf(b, "%s- %s: %s\n", indent, yamlString(c.Title), yamlString(c.Path))
+		}
+	}
+}
+
+// docusaurusSidebars is a sidebars.js with one sidebar, "autodoc", in
+// sidebar order. A directory's index page becomes its category's page.
+func docusaurusSidebars(tree *FileTree, titles map[string]string) string {
+	var items []any
+	if _, ok := titles["index.md"]; ok {
+		items = append(items, docusaurusDoc("index.md", "Home"))
+	}
+	items = append(items, docusaurusItems(tree)...)
+	data, _ := json.MarshalIndent(map[string]any{"autodoc": items}, "", "  ")
+	return "// Generated by autodoc. Add to sidebars.js, or use as it is.\n" +
+		"/** @type {import('@docusaurus/plugin-content-docs').SidebarsConfig} */\n" +
+		"module.exports = " + string(data) + ";\n"
+}
+
+func docusaurusItems(node *FileTree) []any {
+	var items []any
+	for _, c := range node.Children {
+		switch {
+		case c.Path == "index.md" || c.isOverview():
+			// The home page, or the category's own page.
+		case c.IsDir:
+			category := map[string]any{
+				"type":  "category",
+				"label": c.Title,
+				"items": docusaurusItems(c),
+			}
+			for _, cc := range c.Children {
+				if cc.isOverview() {
+					category["link"] = map[string]any{"type": "doc", "id": docusaurusID(cc.Path)}
+				}
+			}
+			if category["items"] == nil {
+				category["items"] = []any{}
+			}
+			items = append(items, category)
+		default:
+			items = append(items, docusaurusDoc(c.Path, c.Title))
+		}
+	}
+	return items
+}
+
+func docusaurusDoc(rel, label string) map[string]any {
+	return map[string]any{"type": "doc", "id": docusaurusID(rel), "label": label}
+}
+
+// docusaurusID is the id Docusaurus gives a page, given the id front
+// matter frontMatter writes.
+func docusaurusID(rel string) string {
+	return strings.TrimSuffix(rel, ".md")
+}
+
+// yamlString quotes s for YAML; JSON strings are valid YAML scalars.
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
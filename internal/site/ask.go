@@ -0,0 +1,69 @@
+package site
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// questionWords start a query that is a question rather than a keyword
+// search.
+var questionWords = map[string]bool{
+	"what": true, "how": true, "why": true, "where": true, "which": true, "who": true, "when": true,
+	"does": true, "do": true, "is": true, "are": true, "can": true, "could": true, "should": true,
+	"would": true, "will": true, "explain": true, "describe": true,
+}
+
+// isQuestion reports whether a search query is a natural-language question.
+func isQuestion(query string) bool {
+	query = strings.TrimSpace(query)
+	if strings.HasSuffix(query, "?") {
+		return true
+	}
+	first, _, _ := strings.Cut(strings.ToLower(query), " ")
+	return questionWords[first]
+}
+
+// formatFacts lists the recorded architecture facts the request's user may
+// see, for the answer prompt. A fact belongs to its repo, or to the service
+// it describes. It returns "" when there are none or they can't be loaded;
+// the answer then rests on the search results alone.
+func formatFacts(ctx context.Context, r *http.Request, facts contextengine.FactSource, access AccessFilter) string {
+	all, err := facts.GetCurrentFacts(ctx, "", "", "")
+	if err != nil {
+		return ""
+	}
+	visible := all[:0]
+	for _, f := range all {
+		repo := f.RepoID
+		if repo == "" && f.Scope == "service" {
+			repo = f.ScopeID
+		}
+		if access == nil || repo == "" || access(r, repo) {
+			visible = append(visible, f)
+		}
+	}
+	return contextengine.FormatFacts(visible)
+}
+
+var citationRe = regexp.MustCompile(`\[(\d+)\]`)
+
+// citedResults returns the result numbers an answer cites as [n], in the
+// order first cited, ignoring numbers with no matching result.
+func citedResults(answer string, n int) []int {
+	var cited []int
+	seen := make(map[int]bool)
+	for _, m := range citationRe.FindAllStringSubmatch(answer, -1) {
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i < 1 || i > n || seen[i] {
+			continue
+		}
+		seen[i] = true
+		cited = append(cited, i)
+	}
+	return cited
+}
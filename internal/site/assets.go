@@ -0,0 +1,186 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The third-party libraries generated pages load, pinned to exact versions
+// so that their subresource-integrity hashes stay valid.
+const (
+	d3URL      = "https://cdn.jsdelivr.net/npm/d3@7.9.0/dist/d3.min.js"
+	mermaidURL = "https://cdn.jsdelivr.net/npm/mermaid@10.9.1/dist/mermaid.min.js"
+)
+
+// library is a script generated pages load from a CDN.
+type library struct {
+	url  string
+	file string // name in the assets and vendor directories
+	// legacy are unpinned URLs older templates, and the per-repo maps
+	// written by autodoc generate, load the same library from.
+	legacy []string
+}
+
+var libraries = []library{
+	{url: d3URL, file: "d3-7.9.0.min.js", legacy: []string{"https://d3js.org/d3.v7.min.js"}},
+	{url: mermaidURL, file: "mermaid-10.9.1.min.js", legacy: []string{"https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js"}},
+}
+
+// vendorDir is where offline sites keep their libraries, relative to the
+// site root.
+const vendorDir = "vendor"
+
+// Assets decides how generated pages load D3 and Mermaid: from the pinned
+// CDN URLs with subresource-integrity hashes, or, when Offline, from copies
+// vendored into the site so that it works without network access.
+//
+// The libraries are downloaded once into Dir and reused from there; on an
+// air-gapped machine, copy them into Dir by hand. When a library can't be
+// had, an offline site fails and an online one loads it without a hash.
+type Assets struct {
+	Offline bool
+	Dir     string
+	Client  *http.Client
+
+	once    sync.Once
+	scripts map[string][]byte // by library URL
+	err     error
+}
+
+// NewAssets returns the asset pipeline keeping its downloads in dir.
+func NewAssets(dir string, offline bool) *Assets {
+	return &Assets{Offline: offline, Dir: dir, Client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// load reads each library from Dir, downloading the ones it lacks.
+func (a *Assets) load(ctx context.Context) error {
+	a.once.Do(func() {
+		a.scripts = make(map[string][]byte)
+		for _, lib := range libraries {
+			data, err := a.fetch(ctx, lib)
+			if err != nil {
+				if a.Offline {
+					a.err = fmt.Errorf("vendoring %s for an offline site: %w (download it from %s into %s)", lib.file, err, lib.url, a.Dir)
+					return
+				}
+				slog.Warn("loading library without an integrity hash", "phase", "assets", "url", lib.url, "err", err)
+				continue
+			}
+			a.scripts[lib.url] = data
+		}
+	})
+	return a.err
+}
+
+func (a *Assets) fetch(ctx context.Context, lib library) ([]byte, error) {
+	path := filepath.Join(a.Dir, lib.file)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lib.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", lib.url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(a.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// prepare loads the libraries and, for an offline site, vendors them into
+// outDir.
+func (a *Assets) prepare(outDir string) error {
+	if err := a.load(context.Background()); err != nil {
+		return err
+	}
+	if !a.Offline {
+		return nil
+	}
+	dir := filepath.Join(outDir, vendorDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, lib := range libraries {
+		if err := os.WriteFile(filepath.Join(dir, lib.file), a.scripts[lib.url], 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewrite points the library script tags in page at the vendored copies,
+// basePath being the way from the page back to the site root, or at the
+// pinned CDN URLs with integrity hashes.
+func (a *Assets) rewrite(page []byte, basePath string) []byte {
+	for _, lib := range libraries {
+		tag := a.scriptTag(lib, basePath)
+		for _, url := range append([]string{lib.url}, lib.legacy...) {
+			page = bytes.ReplaceAll(page, []byte(`<script src="`+url+`"></script>`), tag)
+		}
+	}
+	return page
+}
+
+func (a *Assets) scriptTag(lib library, basePath string) []byte {
+	if a.Offline {
+		return []byte(`<script src="` + basePath + vendorDir + "/" + lib.file + `"></script>`)
+	}
+	data, ok := a.scripts[lib.url]
+	if !ok {
+		return []byte(`<script src="` + lib.url + `"></script>`)
+	}
+	return []byte(`<script src="` + lib.url + `" integrity="` + integrity(data) + `" crossorigin="anonymous"></script>`)
+}
+
+// key identifies how pages load their libraries, for page memo keys.
+func (a *Assets) key() string {
+	if a == nil {
+		return ""
+	}
+	if a.Offline {
+		return "offline"
+	}
+	var b bytes.Buffer
+	for _, lib := range libraries {
+		if data, ok := a.scripts[lib.url]; ok {
+			b.WriteString(integrity(data))
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// integrity is the subresource-integrity hash of a script.
+func integrity(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/diagrams"
+)
+
+// c4Model describes the system in C4 terms: the repos, sub-services
+// included, are its containers, with the components of their own C4
+// Component views; services that aren't registered are external systems;
+// and the user is a person when traffic enters through a UI or public
+// API. Links are the relationships.
+func (g *CentralSiteGenerator) c4Model() diagrams.C4Model {
+	ids := make(diagrams.C4IDs)
+	m := diagrams.C4Model{
+		ID:          ids.New(g.ProjectName + " system"),
+		Name:        g.ProjectName,
+		Description: fmt.Sprintf("%d services", len(g.Repos)),
+	}
+	id := make(map[string]string)
+	for _, r := range g.Repos {
+		id[r.Name] = ids.New(r.Name)
+		summary := r.Summary
+		if len(summary) > 120 {
+			summary = truncateUTF8(summary, 117) + "..."
+		}
+		c := diagrams.C4Container{
+			C4Element: diagrams.C4Element{ID: id[r.Name], Name: r.Name, Description: summary, Technology: r.Language},
+		}
+		if r.DisplayName != "" {
+			c.Name = r.DisplayName
+		}
+		if own, ok := loadC4Components(r); ok {
+			c.Components, c.Relationships = own.Components, own.Relationships
+		}
+		m.Containers = append(m.Containers, c)
+	}
+	for _, l := range g.Links {
+		for _, name := range []string{l.FromRepo, l.ToRepo} {
+			if id[name] == "" {
+				id[name] = ids.New(name)
+				m.Externals = append(m.Externals, diagrams.C4Element{ID: id[name], Name: name, Description: "External dependency"})
+			}
+		}
+		label := l.LinkType
+		if label == "" {
+			label = "depends on"
+		}
+		m.Relationships = append(m.Relationships, diagrams.C4Relationship{From: id[l.FromRepo], To: id[l.ToRepo], Label: label})
+	}
+
+	var user string
+	for _, e := range g.entryPoints {
+		if (e.Kind != EntryUI && e.Kind != EntryPublicAPI) || id[e.Service] == "" {
+			continue
+		}
+		if user == "" {
+			user = ids.New("user")
+			m.People = append(m.People, diagrams.C4Element{ID: user, Name: "User", Description: "Uses the system's apps and public APIs"})
+		}
+		m.Relationships = append(m.Relationships, diagrams.C4Relationship{From: user, To: id[e.Service], Label: "Uses", Technology: entryKindLabels[e.Kind]})
+	}
+	return m
+}
+
+// loadC4Components reads the repo's own C4 Component view, which autodoc
+// generate writes next to its docs.
+func loadC4Components(r RepoInfo) (diagrams.C4Container, bool) {
+	var c diagrams.C4Container
+	if r.DocsDir == "" {
+		return c, false
+	}
+	data, err := os.ReadFile(filepath.Join(r.docsRoot(), diagrams.C4ComponentsFile))
+	if err != nil || json.Unmarshal(data, &c) != nil || len(c.Components) == 0 {
+		return c, false
+	}
+	return c, true
+}
+
+// writeC4Page writes c4.md, the system's System Context and Container
+// views as Mermaid C4 diagrams with links to each service's Component
+// view, and workspace.dsl, the whole model as a Structurizr workspace.
+func (g *CentralSiteGenerator) writeC4Page(stagingDir string) error {
+	m := g.c4Model()
+	workspace := m.Structurizr()
+	if err := os.WriteFile(filepath.Join(stagingDir, "workspace.dsl"), []byte(workspace), 0o644); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# C4 Model\n\n")
+	b.WriteString("The system at the first three levels of the [C4 model](https://c4model.com/): its context, its containers, and their components. ")
+	b.WriteString("Each registered service is a container, and services that aren't registered are external systems.\n\n")
+
+	b.WriteString("## System Context\n\n")
+	b.WriteString("```mermaid\n" + m.SystemContextMermaid() + "```\n\n")
+
+	b.WriteString("## Containers\n\n")
+	b.WriteString("```mermaid\n" + m.ContainerMermaid() + "```\n\n")
+
+	b.WriteString("## Components\n\n")
+	b.WriteString("Each service's features are its components, from its own docs.\n\n")
+	b.WriteString("| Service | Technology | Components |\n")
+	b.WriteString("|---------|------------|------------|\n")
+	for i, r := range g.Repos {
+		c := m.Containers[i]
+		components := "—"
+		if len(c.Components) > 0 {
+			components = fmt.Sprintf("[%d components](%s/c4-components.md)", len(c.Components), r.Name)
+		}
+		technology := c.Technology
+		if technology == "" {
+			technology = "—"
+		}
+		fmt.Fprintf(&b, "| [%s](%s/index.md) | %s | %s |\n", c.Name, r.Name, technology, components)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Structurizr DSL\n\n")
+	b.WriteString("[workspace.dsl](workspace.dsl) is the same model, with a Component view for every service that has components, as a [Structurizr](https://structurizr.com/) workspace to render or extend with its tools:\n\n")
+	b.WriteString("```\n" + workspace + "```\n")
+	return os.WriteFile(filepath.Join(stagingDir, "c4.md"), []byte(b.String()), 0o644)
+}
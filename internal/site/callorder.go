@@ -0,0 +1,365 @@
+package site
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// callStep is one step of an orchestrator's calls: a single call, or calls
+// made concurrently.
+type callStep struct {
+	calls    []flowCall
+	parallel bool
+}
+
+// Markers of concurrent calls in source. Spawned calls run concurrently
+// with the calls spawned after them until a join; a fan-out runs the calls
+// inside it concurrently.
+var (
+	spawnMarkers  = []string{".Go(", "supplyAsync(", "runAsync(", ".submit(", "create_task(", "ensure_future(", "Task.Run(", "spawn("}
+	fanOutMarkers = []string{"Promise.all(", "Promise.allSettled(", "asyncio.gather(", "Task.WhenAll(", "join!("}
+	joinMarkers   = []string{"Wait(", ".join(", "allOf(", "asyncio.wait(", "WaitAll("}
+)
+
+// callOrder works out the order in which orch (a link's FromRepo) makes
+// calls: from the function in its source that makes most of them, when
+// there is one; otherwise as the LLM reads them, when a provider is set;
+// otherwise in the order given, one after another.
+func (g *CentralSiteGenerator) callOrder(orch string, calls []flowCall) []callStep {
+	if len(calls) < 2 {
+		return sequentialSteps(calls)
+	}
+	if steps, ok := g.staticCallOrder(orch, calls); ok {
+		return steps
+	}
+	if steps, ok := g.llmCallOrder(orch, calls); ok {
+		return steps
+	}
+	return sequentialSteps(calls)
+}
+
+// staticCallOrder orders the calls as orch's source makes them.
+func (g *CentralSiteGenerator) staticCallOrder(orch string, calls []flowCall) ([]callStep, bool) {
+	for _, r := range g.Repos {
+		if strings.EqualFold(r.Name, orch) && r.DocsDir != "" {
+			if src := g.callerSource(r); src != nil {
+				return src.callOrder(calls)
+			}
+			break
+		}
+	}
+	return nil, false
+}
+
+// callOrder finds the function that makes most of the calls, each where
+// one of its endpoints, or failing that its target's name, appears, and
+// orders the calls by line within it. It reports false unless that
+// function makes at least two calls and half of them; the calls it doesn't
+// make follow in the order given.
+func (s *callerSource) callOrder(calls []flowCall) ([]callStep, bool) {
+	type function struct {
+		file       string
+		start, end int // 0-based, end exclusive
+	}
+	made := make(map[function]map[int]int) // call index -> line of its first site
+	var functions []function
+	for _, p := range s.files {
+		lines := s.fileLines(p)
+		for i, c := range calls {
+			patterns := callPatterns(c)
+			for n, line := range lines {
+				if !matchesAny(patterns, line) || isRouteLine(line) || isCommentLine(line) {
+					continue
+				}
+				start, end := enclosingFunction(s.analyses[p], n, len(lines))
+				f := function{p, start, end}
+				if made[f] == nil {
+					made[f] = make(map[int]int)
+					functions = append(functions, f)
+				}
+				if _, ok := made[f][i]; !ok {
+					made[f][i] = n
+				}
+			}
+		}
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].file != functions[j].file {
+			return functions[i].file < functions[j].file
+		}
+		return functions[i].start < functions[j].start
+	})
+	var handler function
+	for _, f := range functions {
+		if len(made[f]) > len(made[handler]) {
+			handler = f
+		}
+	}
+	at := made[handler]
+	if len(at) < 2 || 2*len(at) < len(calls) {
+		return nil, false
+	}
+
+	var order []int
+	for i := range calls {
+		if _, ok := at[i]; ok {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return at[order[a]] < at[order[b]] })
+	regions := concurrentRegions(s.fileLines(handler.file), handler.start, handler.end)
+	var steps []callStep
+	last := -1
+	for _, i := range order {
+		group := -1
+		for _, r := range regions {
+			if at[i] >= r.start && at[i] <= r.end {
+				group = r.group
+				break
+			}
+		}
+		if group >= 0 && group == last {
+			steps[len(steps)-1].calls = append(steps[len(steps)-1].calls, calls[i])
+			steps[len(steps)-1].parallel = true
+		} else {
+			steps = append(steps, callStep{calls: []flowCall{calls[i]}})
+		}
+		last = group
+	}
+	for i, c := range calls {
+		if _, ok := at[i]; !ok {
+			steps = append(steps, callStep{calls: []flowCall{c}})
+		}
+	}
+	return steps, true
+}
+
+// callPatterns match a call in the caller's source: by its endpoints, or,
+// when it has none to search for, by its target's name as a host or in a
+// string, as in "http://payments:8080".
+func callPatterns(c flowCall) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, e := range c.link.Endpoints {
+		if p := endpointPattern(e); p != nil {
+			patterns = append(patterns, p)
+		}
+	}
+	if len(patterns) == 0 && len(c.to) >= 3 {
+		patterns = append(patterns, regexp.MustCompile("(?i)[/\"'`]"+regexp.QuoteMeta(c.to)+"[:/.\"'`]"))
+	}
+	return patterns
+}
+
+func matchesAny(patterns []*regexp.Regexp, line string) bool {
+	for _, p := range patterns {
+		if p.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "* ", "*/", "--"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingFunction returns the lines (0-based, end exclusive) of the
+// innermost analyzed function around line i, or the whole file when none
+// is.
+func enclosingFunction(a indexer.FileAnalysis, i, n int) (start, end int) {
+	funcs := append([]indexer.FunctionDoc(nil), a.Functions...)
+	for _, c := range a.Classes {
+		funcs = append(funcs, c.Methods...)
+	}
+	start, end = 0, n
+	for _, fn := range funcs {
+		if fn.LineStart == 0 || fn.LineStart > i+1 || fn.LineEnd < i+1 {
+			continue
+		}
+		if fn.LineStart-1 >= start && min(fn.LineEnd, n) <= end {
+			start, end = fn.LineStart-1, min(fn.LineEnd, n)
+		}
+	}
+	return start, end
+}
+
+// concurrentRegion is a run of lines, inclusive, whose calls are made
+// concurrently with the others of its group.
+type concurrentRegion struct {
+	start, end int
+	group      int
+}
+
+// concurrentRegions finds the spawned calls and fan-outs between lines
+// start and end. Spawns share a group until a join; each fan-out is a
+// group of its own.
+func concurrentRegions(lines []string, start, end int) []concurrentRegion {
+	var regions []concurrentRegion
+	groups, spawning := 0, -1
+	for n := start; n < end; n++ {
+		line := strings.TrimSpace(lines[n])
+		switch {
+		case containsAny(line, fanOutMarkers):
+			regions = append(regions, concurrentRegion{start: n, end: blockEnd(lines, n, end), group: groups})
+			groups++
+		case strings.HasPrefix(line, "go ") || containsAny(line, spawnMarkers):
+			if spawning < 0 {
+				spawning = groups
+				groups++
+			}
+			regions = append(regions, concurrentRegion{start: n, end: blockEnd(lines, n, end), group: spawning})
+		case containsAny(line, joinMarkers):
+			spawning = -1
+			continue
+		default:
+			continue
+		}
+		n = regions[len(regions)-1].end
+	}
+	return regions
+}
+
+// blockEnd returns the line, before end, on which the brackets opened on
+// line n close.
+func blockEnd(lines []string, n, end int) int {
+	depth := 0
+	for i := n; i < end; i++ {
+		for _, r := range lines[i] {
+			switch r {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+		}
+		if depth <= 0 {
+			return i
+		}
+	}
+	return end - 1
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// llmCallOrder asks the LLM the order of the calls, caching the answer
+// with the flow narratives.
+func (g *CentralSiteGenerator) llmCallOrder(orch string, calls []flowCall) ([]callStep, bool) {
+	if g.Provider == nil {
+		return nil, false
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Orchestrator: %s\n\nCalls %s makes:\n", orch, orch)
+	for i, c := range calls {
+		fmt.Fprintf(&b, "%d. %s (%s): %s", i+1, c.link.ToRepo, c.link.LinkType, operationLabel(c.link))
+		if c.link.Reason != "" {
+			b.WriteString(" — " + c.link.Reason)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nIn what order does %s make these calls when it handles a request? Answer with one line per step, in order, each listing the numbers of the calls made in that step. Put calls made concurrently on the same line, separated by commas. Answer with the numbers only.", orch)
+	answer, ok := g.cachedCompletion("call order: "+strings.ToLower(orch), "You work out how services in a microservice system call each other. Use only the calls given.", b.String(), 256)
+	if !ok {
+		return nil, false
+	}
+	return parseCallOrder(answer, calls)
+}
+
+var callNumber = regexp.MustCompile(`\d+`)
+
+// parseCallOrder reads the LLM's answer to llmCallOrder: a line per step,
+// possibly labelled ("Step 1: 2, 3"). Calls it leaves out follow in the
+// order given; it reports false when it names none.
+func parseCallOrder(answer string, calls []flowCall) ([]callStep, bool) {
+	used := make(map[int]bool)
+	var steps []callStep
+	for _, line := range strings.Split(answer, "\n") {
+		if i := strings.LastIndex(line, ":"); i >= 0 {
+			line = line[i+1:]
+		}
+		var step callStep
+		for _, m := range callNumber.FindAllString(line, -1) {
+			n, _ := strconv.Atoi(m)
+			if n < 1 || n > len(calls) || used[n-1] {
+				continue
+			}
+			used[n-1] = true
+			step.calls = append(step.calls, calls[n-1])
+		}
+		if len(step.calls) > 0 {
+			step.parallel = len(step.calls) > 1
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, false
+	}
+	for i, c := range calls {
+		if !used[i] {
+			steps = append(steps, callStep{calls: []flowCall{c}})
+		}
+	}
+	return steps, true
+}
+
+func sequentialSteps(calls []flowCall) []callStep {
+	steps := make([]callStep, len(calls))
+	for i, c := range calls {
+		steps[i] = callStep{calls: []flowCall{c}}
+	}
+	return steps
+}
+
+// stepCalls returns the steps' calls in order.
+func stepCalls(steps []callStep) []flowCall {
+	var calls []flowCall
+	for _, s := range steps {
+		calls = append(calls, s.calls...)
+	}
+	return calls
+}
+
+// writeCallSteps draws from's calls into a sequence diagram, concurrent
+// ones in a par block. then, when set, draws what follows each call.
+func writeCallSteps(b *strings.Builder, indent, from string, steps []callStep, displayName func(string) string, then func(c flowCall, indent string)) {
+	call := func(c flowCall, indent string) {
+		fmt.Fprintf(b, "%s%s->>%s: %s\n", indent, from, displayName(c.to), operationLabel(c.link))
+		if then != nil {
+			then(c, indent)
+		}
+	}
+	for _, s := range steps {
+		if !s.parallel {
+			for _, c := range s.calls {
+				call(c, indent)
+			}
+			continue
+		}
+		for i, c := range s.calls {
+			if i == 0 {
+				fmt.Fprintf(b, "%spar\n", indent)
+			} else {
+				fmt.Fprintf(b, "%sand\n", indent)
+			}
+			call(c, indent+"    ")
+		}
+		fmt.Fprintf(b, "%send\n", indent)
+	}
+}
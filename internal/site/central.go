@@ -3,14 +3,35 @@ package site
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+	"github.com/ziadkadry99/auto-doc/internal/costs"
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/embeddings"
+	"github.com/ziadkadry99/auto-doc/internal/importers"
 	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/mapviews"
+	"github.com/ziadkadry99/auto-doc/internal/metrics"
+	"github.com/ziadkadry99/auto-doc/internal/narratives"
+	"github.com/ziadkadry99/auto-doc/internal/overrides"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/review"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+	"github.com/ziadkadry99/auto-doc/internal/slo"
+	"github.com/ziadkadry99/auto-doc/internal/techstack"
+	"github.com/ziadkadry99/auto-doc/internal/threatmodel"
+	"github.com/ziadkadry99/auto-doc/internal/vendors"
 )
 
 // RepoInfo holds information about a registered repository for central site generation.
@@ -24,6 +45,50 @@ type RepoInfo struct {
 	Language      string // primary programming language (e.g., "Go", "Python", "Java")
 	LastCommitSHA string // git commit SHA when last indexed
 	DocsDir       string // path to the repo's .autodoc/docs/ directory
+	Visibility    string // public, internal, or restricted:<team>; empty means DefaultVisibility
+	Logo          string // path to the repo's logo image, shown on its pages and card
+	OnCall        *OnCallInfo
+	// Facts is what the context engine knows about the service, by key,
+	// such as "owner" or "purpose"; it counts toward the docs' quality score.
+	Facts map[string]string
+	// Monorepo layout: Parent and Subdir place a sub-service within its
+	// monorepo, and Excludes lists a monorepo's sub-service directories,
+	// whose docs belong to those services instead.
+	Parent   string
+	Subdir   string
+	Excludes []string
+	// Domain, BoundedContext, and Environment group the service in the
+	// sidebar, on the domain pages, and in the service map's filters.
+	Domain         string
+	BoundedContext string
+	Environment    string
+	// Health is what the repo's last import found of its commits, CI, pull
+	// requests, and tests; nil before it has been gathered.
+	Health *repohealth.Health
+}
+
+// sourceRoot is the repo's checkout: DocsDir is <repo>/.autodoc/docs. It
+// is "" when the repo has no docs.
+func (r RepoInfo) sourceRoot() string {
+	if r.DocsDir == "" {
+		return ""
+	}
+	return filepath.Dir(filepath.Dir(r.DocsDir))
+}
+
+// docsRoot is the directory holding the repo's own doc pages.
+func (r RepoInfo) docsRoot() string {
+	if r.DocsDir == "" || r.Subdir == "" {
+		return r.DocsDir
+	}
+	return filepath.Join(r.DocsDir, filepath.FromSlash(r.Subdir))
+}
+
+// OnCallInfo is who to page for a service, fetched at generation time.
+type OnCallInfo struct {
+	Schedule   string   // provider:id reference
+	Responders []string // formatted names, first escalation level first
+	Error      string   // set when the schedule could not be fetched
 }
 
 // LinkInfo represents a cross-service dependency for site generation.
@@ -33,6 +98,20 @@ type LinkInfo struct {
 	LinkType  string
 	Reason    string
 	Endpoints []string
+	// Runtime is what production traces say about the link: "observed",
+	// "unobserved", or "unverifiable" (the caller emits no traces). Empty
+	// when no traces have been imported.
+	Runtime       string
+	ObservedCalls int
+	// Override is the provenance of the correction that added or changed
+	// the link, e.g. "ada, 2026-03-04"; empty for detected links.
+	Override string
+	// ObservedLatencyMs is the p95 latency of the call in production
+	// traces; zero when unknown.
+	ObservedLatencyMs float64
+	// Environments are the deployment environments the link exists in,
+	// e.g. a call to a mock in staging; empty means every environment.
+	Environments []string
 }
 
 // FlowInfo represents a cross-service flow for site generation.
@@ -42,6 +121,11 @@ type FlowInfo struct {
 	Narrative   string
 	Diagram     string
 	Services    []string
+	Override    string // provenance of the correction that defined the flow
+	Edited      string // the edits made to the narrative in conversation
+	// Latency models the flow's critical path; nil for flows without a
+	// known call order, such as those loaded from the flow store.
+	Latency *LatencyModel
 }
 
 // CentralSiteGenerator creates a combined static site from multiple repositories.
@@ -52,12 +136,179 @@ type CentralSiteGenerator struct {
 	Links       []LinkInfo
 	Flows       []FlowInfo
 	LogoPath    string
+	Audience    string       // limits the site to repos this audience may see; empty means all
+	Changes     []ChangeInfo // architecture changes, newest first
+	Teams       []TeamInfo   // teams and the services they own
+	// SLOs maps lower-cased service names to their declared objectives.
+	SLOs map[string][]slo.SLO
+	// RuntimeOnly are calls seen in production traces that no detected link
+	// accounts for.
+	RuntimeOnly []LinkInfo
+	// Metrics is per-edge traffic from Prometheus, overlaid on the service
+	// map; nil when no Prometheus server is configured.
+	Metrics *metrics.Snapshot
+	// Artifacts, when set, is passed to the SiteGenerator; see
+	// SiteGenerator.Artifacts. Published is filled in by Generate.
+	Artifacts *artifacts.Store
+	Published artifacts.PublishStats
+
+	// FlowTemplates name the flows synthesized around orchestrators.
+	FlowTemplates []FlowTemplate
+	// Provider and Model, when set, write the narratives of flows whose
+	// template has no narrative skeleton, and order the calls of services
+	// whose source doesn't show it. NarrativeCache is a JSON file keeping
+	// both until a flow's calls change.
+	Provider       llm.Provider
+	Model          string
+	NarrativeCache string
+	// Embedder, when set, compares the services' and modules' summaries for
+	// the Consolidation Candidates page; otherwise they are compared by the
+	// words they share.
+	Embedder embeddings.Embedder
+	// HopLatency is assumed for calls with no hint or measured latency;
+	// zero means 50ms. LatencyHints override measured latencies.
+	HopLatency   time.Duration
+	LatencyHints []LatencyHint
+	// Languages and Translator publish the site in more languages; see the
+	// SiteGenerator fields of the same names.
+	Languages  []SiteLanguage
+	Translator *Translator
+	// Assets and Theme are passed to the SiteGenerator; see the
+	// SiteGenerator fields of the same names.
+	Assets *Assets
+	Theme  *Theme
+	// Validation, when set, collects the site's broken links, Mermaid
+	// diagrams that don't parse, and references to unregistered services;
+	// see SiteGenerator.Validation.
+	Validation *ValidationReport
+	// PagesDir holds hand-written markdown, such as runbooks and RFC
+	// indexes, merged into the site; front matter places each page in the
+	// sidebar (see pageFrontMatter).
+	PagesDir string
+
+	// Overrides are corrections from conversation, applied on top of the
+	// detected links and synthesized flows, oldest first.
+	Overrides []overrides.Override
+
+	// DataClasses are the kinds of sensitive data to find in schemas and
+	// follow across services for the PII Flow.
+	DataClasses []dataclass.Class
+
+	// ThreatNotes are what security teams said in conversation about the
+	// flows' threat models, oldest first.
+	ThreatNotes []threatmodel.Note
+
+	// NarrativeEdits are the amendments and pins made in conversation to
+	// the flows' narratives, oldest first. NarrativeLocks is a JSON file
+	// keeping what each was made against, so that regenerations changing
+	// an edited section are noticed; Generate adds those to
+	// NarrativeConflicts.
+	NarrativeEdits     []narratives.Edit
+	NarrativeLocks     string
+	NarrativeConflicts []NarrativeConflict
+
+	// Review, when set, holds new and materially changed pages back until
+	// a reviewer approves them, publishing the version last approved of
+	// each meanwhile. Generate lists the pages awaiting review in Pending.
+	Review  *review.Store
+	Pending []review.Page
+
+	// SchemaVersions are the recorded versions of the topics' payload
+	// schemas, by subject and then version, for the Events page.
+	SchemaVersions []schemareg.Schema
+
+	// Deprecations are the endpoints and topics providers have deprecated,
+	// for the Deprecations page.
+	Deprecations []deprecation.Deprecation
+	// Costs are the services' monthly cloud costs, with the spend no
+	// registered service accounts for under the service "".
+	Costs []costs.MonthlyCost
+	// QuietAfter is how long a repo may go without a commit before the
+	// landing page flags it as quiet; zero never flags one.
+	QuietAfter time.Duration
+
+	// Environments orders the deployment environments the system overview
+	// and service map can switch between; environments repos and links are
+	// tagged with that it doesn't list follow by name.
+	Environments []string
+
+	// History is the architecture as it stood after past runs, oldest
+	// first, which the service map's timeline replays.
+	History []GraphSnapshot
+
+	// Views are the service map's saved views from the site config.
+	Views []mapviews.View
+
+	// Concurrency is how many repos are copied and loaded, and how many
+	// pages rendered, at once; zero means one per CPU.
+	Concurrency int
+
+	// analyses holds each repo's file analyses, scoped to the repo, by
+	// repo name; see repoAnalyses.
+	analyses map[string]map[string]indexer.FileAnalysis
+	// scans holds what the source scanners found in each repo's files, by
+	// repo name; see repoScan.
+	scans map[string][]scannedFile
+	// examples holds harvested call sites, by target repo then endpoint.
+	examples map[string]map[string][]CallExample
+	// sources holds the repos' source read so far, by repo name; see
+	// callerSource.
+	sources    map[string]*callerSource
+	narratives map[string]narrativeCacheEntry
+	// entryPoints are where traffic enters the system, found when flows
+	// are synthesized.
+	entryPoints []EntryPoint
+	// quality holds each repo's documentation quality score, by repo name.
+	quality map[string]QualityScore
+	// dependencies holds the third-party libraries each repo declares, by
+	// repo name.
+	dependencies map[string][]importers.Dependency
+	// stacks are the tech stacks detected in the repos, by repo name.
+	stacks map[string]techstack.Stack
+	// schemas holds the schemas and event definitions in each repo's
+	// source, by repo name; see loadSchemas.
+	schemas map[string][]schemaFile
+	// dataFlow is where the declared data classes are; nil when none are
+	// declared or found.
+	dataFlow *dataFlow
+	// events is the system's messaging, by topic; nil when no link is
+	// async.
+	events *eventModel
+	// deprecations are the Deprecations with the services still using
+	// each, soonest sunset first.
+	deprecations []deprecation.Usage
+	// exposure holds each repo's HTTP endpoints and whether they require
+	// authentication, by repo name.
+	exposure map[string][]endpointExposure
+	// configuration holds the configuration each repo's source reads, by
+	// repo name.
+	configuration map[string][]configKnob
+	// jobs holds the scheduled jobs each repo runs, by repo name.
+	jobs map[string][]scheduledJob
+	// externals holds the external vendors each repo uses, by repo name.
+	externals map[string][]externalUse
+	// dataAccess holds the tables, collections, and caches each repo reads
+	// and writes, by repo name.
+	dataAccess map[string][]storeAccess
+	// overlaps are the services and modules that likely duplicate each
+	// other's functionality, for the Consolidation Candidates page.
+	overlaps []overlapCluster
 }
 
 // Generate builds the combined multi-repo static site.
 // It creates a staging docs directory with generated content and per-repo docs,
 // then delegates to the standard SiteGenerator for HTML rendering.
 func (g *CentralSiteGenerator) Generate() (int, error) {
+	// Drop repos outside the audience before anything is derived from them.
+	g.applyAudience()
+
+	// Read every repo's analyses up front; link detection, entry points,
+	// and call examples all go through them.
+	g.loadAnalyses()
+	// Read their source files once, for the pages built from what's in
+	// them.
+	g.scanSources()
+
 	// Clean up service summaries for better readability.
 	g.cleanSummaries()
 
@@ -66,10 +317,60 @@ func (g *CentralSiteGenerator) Generate() (int, error) {
 
 	// Normalize links and flows before generating.
 	g.normalizeData()
+	g.applyLinkOverrides()
+
+	// Find the scheduled jobs in each repo, for the Scheduled Jobs page
+	// and the flows' entry points.
+	g.jobs = g.loadScheduledJobs()
 
 	// Synthesize canonical flows from the link topology.
 	// This replaces LLM-generated flows with well-structured, non-overlapping journeys.
 	g.synthesizeCanonicalFlows()
+	g.applyFlowOverrides()
+	g.applyNarrativeEdits()
+	g.checkServiceReferences()
+	g.applyAudience()
+
+	// Find how callers use each endpoint, for the endpoint pages.
+	g.examples = g.harvestCallExamples()
+
+	// Grade each repo's docs, for the service cards and the leaderboard.
+	g.quality = g.scoreQuality()
+
+	// Read each repo's package manifests, for the dependency pages.
+	g.dependencies = g.loadDependencies()
+
+	// Detect each repo's tech stack, for the Tech Stack pages.
+	g.stacks = g.loadStacks()
+
+	// Find the configuration each repo reads, for the Configuration pages.
+	g.configuration = g.loadConfiguration()
+
+	// Find the SaaS and cloud vendors each repo uses, for the External
+	// Dependencies page.
+	g.externals = g.loadExternals()
+
+	// Find which tables each repo reads and writes, for the Data Stores
+	// page and the service map's edges to data stores.
+	g.dataAccess = g.loadDataAccess()
+
+	// Find which endpoints require authentication, for the Exposure Report.
+	g.exposure = g.assessExposure()
+
+	// Find the services and modules that likely duplicate each other, for
+	// the Consolidation Candidates page; the LLM's assessments are cached
+	// with the flow narratives.
+	g.overlaps = g.findOverlaps()
+	g.saveNarratives()
+
+	// Find the schemas and topics carrying sensitive data, for the PII Flow.
+	g.dataFlow = g.trackSensitiveData()
+
+	// Pivot the async links around their topics, for the Events page.
+	g.events = g.collectEvents()
+
+	// Find who still uses each deprecated endpoint, for the Deprecations page.
+	g.deprecations = g.collectDeprecations()
 
 	// Create staging docs directory.
 	stagingDir := filepath.Join(g.OutputDir, ".staging-docs")
@@ -83,22 +384,59 @@ func (g *CentralSiteGenerator) Generate() (int, error) {
 		return 0, fmt.Errorf("writing landing page: %w", err)
 	}
 
-	// 2. Copy each repo's docs into a subdirectory.
-	for _, repo := range g.Repos {
+	// 2. Copy each repo's docs into a subdirectory, several repos at once.
+	indexErrs := make([]error, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
 		if repo.DocsDir == "" {
-			continue
+			return
 		}
 		if _, err := os.Stat(repo.DocsDir); os.IsNotExist(err) {
-			continue
+			return
 		}
 		destDir := filepath.Join(stagingDir, repo.Name)
-		if err := copyDir(repo.DocsDir, destDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not copy docs for %s: %v\n", repo.Name, err)
+		if err := copyDir(repo.docsRoot(), destDir); err != nil {
+			slog.Warn("could not copy docs", "phase", "copy_docs", "repo", repo.Name, "err", err)
+		}
+		for _, ex := range repo.Excludes {
+			os.RemoveAll(filepath.Join(destDir, filepath.FromSlash(ex)))
 		}
 		// Generate a repo index if the repo docs don't have one.
 		indexPath := filepath.Join(destDir, "index.md")
 		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-			g.writeRepoIndex(destDir, repo)
+			indexErrs[i] = g.writeRepoIndex(destDir, repo)
+		} else if sections := g.serviceSections(repo); sections != "" {
+			// Generated docs have their own index; add the service sections to it.
+			existing, err := os.ReadFile(indexPath)
+			if err == nil {
+				err = os.WriteFile(indexPath, append(existing, "\n\n"+sections...), 0o644)
+			}
+			indexErrs[i] = err
+		}
+		if examples := g.examples[repo.Name]; len(examples) > 0 {
+			if err := g.writeEndpointsPage(destDir, repo, examples); err != nil {
+				slog.Warn("could not write endpoints page", "phase", "endpoints", "repo", repo.Name, "err", err)
+			}
+		}
+		if len(g.dependencies[repo.Name]) > 0 {
+			if err := g.writeServiceDependencies(destDir, repo); err != nil {
+				slog.Warn("could not write dependencies page", "phase", "dependencies", "repo", repo.Name, "err", err)
+			}
+		}
+		if _, ok := g.stacks[repo.Name]; ok {
+			if err := g.writeServiceTechStack(destDir, repo); err != nil {
+				slog.Warn("could not write tech stack page", "phase", "tech_stack", "repo", repo.Name, "err", err)
+			}
+		}
+		if len(g.configuration[repo.Name]) > 0 {
+			if err := g.writeServiceConfiguration(destDir, repo); err != nil {
+				slog.Warn("could not write configuration page", "phase", "configuration", "repo", repo.Name, "err", err)
+			}
+		}
+	})
+	for i, err := range indexErrs {
+		if err != nil {
+			return 0, fmt.Errorf("writing %s index: %w", g.Repos[i].Name, err)
 		}
 	}
 
@@ -114,23 +452,173 @@ func (g *CentralSiteGenerator) Generate() (int, error) {
 		}
 	}
 
+	// 4b. Generate the architecture changelog.
+	if len(g.Changes) > 0 {
+		if err := g.writeChangelogPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing changelog page: %w", err)
+		}
+	}
+
+	// 4c. Generate the team directory.
+	if len(g.Teams) > 0 {
+		if err := g.writeTeamPages(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing team pages: %w", err)
+		}
+	}
+
+	// 4d. Generate the documentation quality leaderboard.
+	if len(g.Repos) > 0 {
+		if err := g.writeQualityPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing quality page: %w", err)
+		}
+	}
+
+	// 4e. Generate the org-wide third-party dependency list.
+	if len(g.dependencies) > 0 {
+		if err := g.writeDependenciesPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing dependencies page: %w", err)
+		}
+	}
+
+	// 4f. Generate the Exposure Report.
+	if len(g.exposure) > 0 {
+		if err := g.writeExposurePage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing exposure report: %w", err)
+		}
+	}
+
+	// 4g. Generate the PII Flow.
+	if g.dataFlow != nil {
+		if err := g.writePIIFlowPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing PII flow page: %w", err)
+		}
+	}
+
+	// 4h. Generate the Events page.
+	if g.events != nil {
+		if err := g.writeEventsPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing events page: %w", err)
+		}
+	}
+
+	// 4i. Generate the flows' starter threat models.
+	if len(g.Flows) > 0 {
+		if err := g.writeThreatModelPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing threat model page: %w", err)
+		}
+	}
+
+	// 4j. Generate the C4 model.
+	if len(g.Repos) > 0 {
+		if err := g.writeC4Page(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing C4 model: %w", err)
+		}
+	}
+
+	// 4k. Generate the domain pages.
+	if len(g.domains()) > 0 {
+		if err := g.writeDomainPages(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing domain pages: %w", err)
+		}
+	}
+
+	// 4l. Generate the Deprecations page.
+	if len(g.deprecations) > 0 {
+		if err := g.writeDeprecationsPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing deprecations page: %w", err)
+		}
+	}
+
+	// 4m. Generate the org-wide Tech Stack matrix.
+	if len(g.stacks) > 0 {
+		if err := g.writeTechStackPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing tech stack page: %w", err)
+		}
+	}
+
+	// 4n. Generate the Scheduled Jobs page.
+	if len(g.jobs) > 0 {
+		if err := g.writeScheduledJobsPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing scheduled jobs page: %w", err)
+		}
+	}
+
+	// 4o. Generate the External Dependencies catalog.
+	if len(g.externals) > 0 {
+		if err := g.writeExternalDependenciesPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing external dependencies page: %w", err)
+		}
+	}
+
+	// 4p. Generate the Data Stores page.
+	if len(g.dataAccess) > 0 {
+		if err := g.writeDataStoresPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing data stores page: %w", err)
+		}
+	}
+
+	// 4q. Generate the Consolidation Candidates page.
+	if len(g.overlaps) > 0 {
+		if err := g.writeConsolidationPage(stagingDir); err != nil {
+			return 0, fmt.Errorf("writing consolidation page: %w", err)
+		}
+	}
+
 	// 5. Generate system-level service map (D3.js visualization).
 	if err := g.writeServiceMap(stagingDir); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not generate service map: %v\n", err)
+		slog.Warn("could not generate service map", "phase", "service_map", "err", err)
 	}
 
 	// 6. Copy HTML artifacts from repos (per-repo interactive maps, etc.).
-	for _, repo := range g.Repos {
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
 		if repo.DocsDir == "" {
-			continue
+			return
+		}
+		g.copyHTMLArtifacts(repo.docsRoot(), stagingDir, repo.Name)
+		for _, ex := range repo.Excludes {
+			os.RemoveAll(filepath.Join(stagingDir, repo.Name, filepath.FromSlash(ex)))
+		}
+	})
+
+	// 6a. Hold back the generated pages no reviewer has approved.
+	if g.Review != nil {
+		if err := g.gatePages(stagingDir); err != nil {
+			return 0, fmt.Errorf("gating pages for review: %w", err)
+		}
+	}
+
+	// 6b. Merge hand-written pages into the generated ones.
+	var nav map[string]NavEntry
+	if g.PagesDir != "" {
+		var err error
+		if nav, err = g.writeCustomPages(stagingDir); err != nil {
+			return 0, fmt.Errorf("adding custom pages: %w", err)
 		}
-		g.copyHTMLArtifacts(repo.DocsDir, stagingDir, repo.Name)
 	}
 
 	// 7. Delegate to standard SiteGenerator for HTML rendering.
 	siteGen := NewSiteGenerator(stagingDir, g.OutputDir, g.ProjectName)
+	siteGen.ShardSearch = true
 	siteGen.LogoPath = g.LogoPath
-	return siteGen.Generate()
+	siteGen.Artifacts = g.Artifacts
+	siteGen.Languages = g.Languages
+	siteGen.Translator = g.Translator
+	siteGen.Concurrency = g.Concurrency
+	siteGen.Assets = g.Assets
+	siteGen.Theme = g.Theme
+	siteGen.Nav = nav
+	siteGen.SectionGroups = g.sectionGroups()
+	siteGen.Validation = g.Validation
+	siteGen.SectionLogos = make(map[string]string)
+	for _, repo := range g.Repos {
+		if repo.Logo != "" {
+			siteGen.SectionLogos[repo.Name] = repo.Logo
+		}
+	}
+	n, err := siteGen.Generate()
+	g.Published = siteGen.Published
+	return n, err
 }
 
 // writeLandingPage creates the main index.md with service cards and navigation.
@@ -146,14 +634,62 @@ func (g *CentralSiteGenerator) writeLandingPage(stagingDir string) error {
 	b.WriteString("- [Service Map](service-map.html) — Interactive D3.js visualization of all services\n")
 	if len(g.Flows) > 0 {
 		b.WriteString("- [Cross-Service Flows](flows.md) — Data flows across services\n")
+		b.WriteString("- [Threat Model](threat-model.md) — Starter STRIDE threat models for each flow\n")
+	}
+	if len(g.domains()) > 0 {
+		b.WriteString("- [Domains](domains/index.md) — Services grouped by domain and bounded context, with a diagram of each\n")
+	}
+	if len(g.Teams) > 0 {
+		b.WriteString("- [Teams](teams/index.md) — Team directory, service ownership, and inter-team dependencies\n")
+	}
+	if len(g.Repos) > 0 {
+		b.WriteString("- [C4 Model](c4.md) — System context, containers, and components, in Mermaid and as a Structurizr workspace\n")
+		b.WriteString("- [Documentation Quality](quality.md) — Services ranked by how complete their docs are, and how to improve them\n")
+	}
+	if len(g.dependencies) > 0 {
+		b.WriteString("- [Third-Party Dependencies](dependencies.md) — Libraries in use, their versions and licenses, and which services use them\n")
+	}
+	if len(g.dataAccess) > 0 {
+		b.WriteString("- [Data Stores](data-stores.md) — Which services read and write each table, collection, and cache, and the tables services share\n")
+	}
+	if len(g.externals) > 0 {
+		b.WriteString("- [External Dependencies](external-dependencies.md) — The SaaS and cloud vendors the system depends on, which services use each, and what for\n")
+	}
+	if len(g.stacks) > 0 {
+		b.WriteString("- [Tech Stack](tech-stack.md) — Languages, runtimes, frameworks, databases, and build tools, which services use each, and where to consolidate\n")
+	}
+	if len(g.jobs) > 0 {
+		b.WriteString("- [Scheduled Jobs](scheduled-jobs.md) — Cron jobs and scheduled tasks, when they run, and what they touch\n")
+	}
+	if len(g.overlaps) > 0 {
+		b.WriteString("- [Consolidation Candidates](consolidation.md) — Services and modules that likely duplicate each other's functionality\n")
+	}
+	if len(g.exposure) > 0 {
+		b.WriteString("- [Exposure Report](exposure.md) — Which endpoints require authentication, and the internet-facing ones that don't\n")
+	}
+	if g.events != nil {
+		b.WriteString("- [Events](events.md) — Topics and queues, the services producing and consuming each, their schemas, and dead-letter and retry topics\n")
+	}
+	if len(g.deprecations) > 0 {
+		b.WriteString("- [Deprecations](deprecations.md) — Deprecated endpoints and topics, the services still using them, and the time left until each is removed\n")
+	}
+	if g.dataFlow != nil {
+		b.WriteString("- [PII Flow](pii-flow.md) — Which services, schemas, and topics carry personal and other sensitive data\n")
 	}
 	b.WriteString("\n")
 
 	// Service cards table.
 	if len(g.Repos) > 0 {
 		b.WriteString("## Services\n\n")
-		b.WriteString("| Service | Stack | Files | Status | Summary |\n")
-		b.WriteString("|---------|-------|-------|--------|---------|\n")
+		withHealth := hasHealth(g.Repos)
+		if withHealth {
+			b.WriteString("| Service | Stack | Files | Status | Docs | Health | Summary |\n")
+			b.WriteString("|---------|-------|-------|--------|------|--------|---------|\n")
+		} else {
+			b.WriteString("| Service | Stack | Files | Status | Docs | Summary |\n")
+			b.WriteString("|---------|-------|-------|--------|------|---------|\n")
+		}
+		now := time.Now()
 		for _, repo := range g.Repos {
 			displayName := repo.DisplayName
 			if displayName == "" {
@@ -164,12 +700,19 @@ func (g *CentralSiteGenerator) writeLandingPage(stagingDir string) error {
 				summary = summary[:77] + "..."
 			}
 			link := fmt.Sprintf("[%s](%s/index.md)", displayName, repo.Name)
+			if repo.Logo != "" {
+				link = fmt.Sprintf(`<img src="%s" alt="" class="service-logo">`, sectionLogo(repo.Name, repo.Logo)) + link
+			}
 			stack := repo.Language
 			if stack == "" {
 				stack = repo.SourceType
 			}
-			b.WriteString(fmt.Sprintf("| %s | %s | %d | %s | %s |\n",
-				link, stack, repo.FileCount, repo.Status, summary))
+			docs := qualityBadge(g.quality[repo.Name].Total, "quality.md#"+teamSlug(repo.Name))
+			if withHealth {
+				docs += " | " + healthCell(repo.Health, now, g.QuietAfter)
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %d | %s | %s | %s |\n",
+				link, stack, repo.FileCount, repo.Status, docs, summary))
 		}
 		b.WriteString("\n")
 	}
@@ -207,7 +750,7 @@ func (g *CentralSiteGenerator) writeLandingPage(stagingDir string) error {
 }
 
 // writeRepoIndex creates an index.md for a repo subdirectory.
-func (g *CentralSiteGenerator) writeRepoIndex(destDir string, repo RepoInfo) {
+func (g *CentralSiteGenerator) writeRepoIndex(destDir string, repo RepoInfo) error {
 	var b strings.Builder
 
 	displayName := repo.DisplayName
@@ -225,6 +768,8 @@ func (g *CentralSiteGenerator) writeRepoIndex(destDir string, repo RepoInfo) {
 	b.WriteString(fmt.Sprintf("- **Source:** %s\n", repo.SourceType))
 	b.WriteString("\n")
 
+	b.WriteString(g.serviceSections(repo))
+
 	// List the docs files in this directory.
 	entries, err := os.ReadDir(destDir)
 	if err == nil && len(entries) > 0 {
@@ -239,7 +784,153 @@ func (g *CentralSiteGenerator) writeRepoIndex(destDir string, repo RepoInfo) {
 		b.WriteString("\n")
 	}
 
-	_ = os.WriteFile(filepath.Join(destDir, "index.md"), []byte(b.String()), 0o644)
+	return os.WriteFile(filepath.Join(destDir, "index.md"), []byte(b.String()), 0o644)
+}
+
+// serviceSections renders the operational sections of a repo's index page:
+// its SLOs, who to page, and a link to its endpoints page.
+func (g *CentralSiteGenerator) serviceSections(repo RepoInfo) string {
+	var b strings.Builder
+	if slos := g.SLOs[strings.ToLower(repo.Name)]; len(slos) > 0 {
+		writeServiceSLOs(&b, slos)
+	}
+	if repo.OnCall != nil {
+		writeWhoToPage(&b, repo.OnCall)
+	}
+	if len(g.examples[repo.Name]) > 0 {
+		b.WriteString("## Endpoints\n\n")
+		b.WriteString("See [how other services call this one](endpoints.md), with examples from their source.\n\n")
+	}
+	if deps := g.dependencies[repo.Name]; len(deps) > 0 {
+		b.WriteString("## Third-Party Dependencies\n\n")
+		b.WriteString(fmt.Sprintf("This service declares %d third-party libraries; see [the full list](dependencies.md), with versions and licenses.\n\n", len(deps)))
+	}
+	if s, ok := g.stacks[repo.Name]; ok {
+		b.WriteString("## Tech Stack\n\n")
+		b.WriteString(fmt.Sprintf("Built with %s; see [the full stack](tech-stack.md).\n\n", stackSummary(s)))
+	}
+	if access := g.dataAccess[repo.Name]; len(access) > 0 {
+		b.WriteString("## Data Access\n\n")
+		b.WriteString(fmt.Sprintf("This service %s; see the [Data Stores](../data-stores.md) page for the other services using them.", dataAccessSummary(access)))
+		keys, users := g.dataStoreTables()
+		var shared []string
+		for _, k := range keys {
+			if sharedWrites(users[k]) && slices.ContainsFunc(users[k], func(u storeUser) bool { return u.Service == repo.Name }) {
+				shared = append(shared, "`"+users[k][0].name()+"`")
+			}
+		}
+		if len(shared) > 0 {
+			b.WriteString(fmt.Sprintf(" It shares %s with other services, which couples them through the schema.", strings.Join(shared, ", ")))
+		}
+		b.WriteString("\n\n")
+	}
+	if overlaps := g.serviceOverlaps(repo.Name); len(overlaps) > 0 {
+		b.WriteString("## Overlapping Functionality\n\n")
+		b.WriteString("This service may duplicate functionality found elsewhere; see [Consolidation Candidates](../consolidation.md) before building more of it.\n\n")
+		for _, o := range overlaps {
+			b.WriteString("- " + o + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if uses := g.externals[repo.Name]; len(uses) > 0 {
+		labels := make([]string, len(uses))
+		for i, u := range uses {
+			labels[i] = externalLabel(u.Vendor, u.Products)
+		}
+		b.WriteString("## External Dependencies\n\n")
+		b.WriteString(fmt.Sprintf("This service uses %s; see the [External Dependencies](../external-dependencies.md) catalog for what each is used for and who else uses it.\n\n", strings.Join(labels, ", ")))
+	}
+	if knobs := g.configuration[repo.Name]; len(knobs) > 0 {
+		b.WriteString("## Configuration\n\n")
+		b.WriteString(fmt.Sprintf("This service reads %s; see [each one](configuration.md), with its default and where it is read.\n\n", configSummary(knobs)))
+	}
+	if jobs := g.jobs[repo.Name]; len(jobs) > 0 {
+		labels := make([]string, len(jobs))
+		for i, j := range jobs {
+			labels[i] = jobLabel(j)
+		}
+		b.WriteString("## Scheduled Jobs\n\n")
+		b.WriteString(fmt.Sprintf("This service runs %s; see [all scheduled jobs](../scheduled-jobs.md), with what each one touches.\n\n", strings.Join(labels, ", ")))
+	}
+	if endpoints := g.exposure[repo.Name]; len(endpoints) > 0 {
+		open := 0
+		for _, e := range endpoints {
+			if e.Auth == indexer.AuthNone {
+				open++
+			}
+		}
+		b.WriteString("## Authentication\n\n")
+		b.WriteString(fmt.Sprintf("%d of this service's %d endpoints have no authentication found; see the [Exposure Report](../exposure.md).\n\n", open, len(endpoints)))
+	}
+	if g.dataFlow != nil && len(g.dataFlow.services[repo.Name]) > 0 {
+		g.writeServiceSensitiveData(&b, repo)
+	}
+	g.writeServiceCost(&b, repo)
+	return b.String()
+}
+
+// writeServiceSLOs renders a service's objectives as a table.
+func writeServiceSLOs(b *strings.Builder, slos []slo.SLO) {
+	b.WriteString("## Service Level Objectives\n\n")
+	b.WriteString("| Scope | Availability | Latency | Source |\n")
+	b.WriteString("|-------|--------------|---------|--------|\n")
+	for _, s := range slos {
+		scope := "Whole service"
+		if s.Endpoint != "" {
+			scope = "`" + s.Endpoint + "`"
+		}
+		availability, latency := "—", "—"
+		if s.Availability > 0 {
+			availability = slo.FormatPercent(s.Availability)
+		}
+		if s.Latency > 0 {
+			latency = fmt.Sprintf("p%g < %s", s.LatencyPercentile, s.Latency)
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", scope, availability, latency, s.Source))
+	}
+	b.WriteString("\n")
+}
+
+// writeFlowSLOs summarises the SLOs along a flow and its weakest link.
+func writeFlowSLOs(b *strings.Builder, j slo.Journey) {
+	if len(j.Links) == 0 {
+		return
+	}
+	b.WriteString("**Service levels:** ")
+	parts := make([]string, len(j.Links))
+	for i, l := range j.Links {
+		parts[i] = fmt.Sprintf("%s (%s)", l.Service, l.String())
+	}
+	b.WriteString(strings.Join(parts, " → ") + "\n\n")
+	if j.Weakest != nil {
+		b.WriteString(fmt.Sprintf("**Weakest link:** %s at %s availability", j.Weakest.Service, slo.FormatPercent(j.Weakest.Availability)))
+		if len(j.Links) > 1 {
+			b.WriteString(fmt.Sprintf("; the journey can promise at most %s end to end if every call is in series", slo.FormatPercent(j.Composite)))
+		}
+		b.WriteString(".\n\n")
+	}
+	if j.Slowest != nil && j.Slowest != j.Weakest {
+		b.WriteString(fmt.Sprintf("**Slowest link:** %s (p%g < %s).\n\n", j.Slowest.Service, j.Slowest.LatencyPercentile, j.Slowest.Latency))
+	}
+	if len(j.Uncovered) > 0 {
+		b.WriteString("*No SLO declared for: " + strings.Join(j.Uncovered, ", ") + ".*\n\n")
+	}
+}
+
+// writeWhoToPage renders a service's current on-call responders.
+func writeWhoToPage(b *strings.Builder, oc *OnCallInfo) {
+	b.WriteString("## Who to Page\n\n")
+	switch {
+	case oc.Error != "":
+		b.WriteString(fmt.Sprintf("On-call lookup for `%s` failed: %s\n\n", oc.Schedule, oc.Error))
+	case len(oc.Responders) == 0:
+		b.WriteString(fmt.Sprintf("Nobody is currently on call on `%s`.\n\n", oc.Schedule))
+	default:
+		for _, r := range oc.Responders {
+			b.WriteString("- " + r + "\n")
+		}
+		b.WriteString(fmt.Sprintf("\n*Schedule `%s`, as of %s.*\n\n", oc.Schedule, time.Now().UTC().Format("2006-01-02 15:04 UTC")))
+	}
 }
 
 // writeSystemOverview creates the system-overview.md page.
@@ -275,72 +966,50 @@ func (g *CentralSiteGenerator) writeSystemOverview(stagingDir string) error {
 		}
 		b.WriteString("\n")
 	}
+	g.writeCostByService(&b)
 
-	// System architecture diagram.
+	// System architecture diagram, one per environment when links are
+	// tagged with them.
 	if len(g.Repos) > 1 {
 		b.WriteString("## Architecture Diagram\n\n")
-		b.WriteString("```mermaid\ngraph TD\n")
-		// Build set of known repo names.
-		repoSet := make(map[string]bool)
-		for _, repo := range g.Repos {
-			repoSet[repo.Name] = true
-		}
-		// Define service nodes.
-		for _, repo := range g.Repos {
-			displayName := repo.DisplayName
-			if displayName == "" {
-				displayName = repo.Name
-			}
-			nodeID := strings.ReplaceAll(repo.Name, "-", "_")
-			b.WriteString(fmt.Sprintf("    %s[\"%s<br/>%d files\"]\n", nodeID, displayName, repo.FileCount))
-		}
-		// Collect and define external dependency nodes.
-		externalNodes := make(map[string]bool)
-		if len(g.Links) > 0 {
-			for _, link := range g.Links {
-				if !repoSet[link.ToRepo] {
-					externalNodes[link.ToRepo] = true
+		if envs := g.allEnvironments(); len(envs) > 0 {
+			b.WriteString("Dashed arrows exist only in the environments their label names.\n\n")
+			b.WriteString("<div class=\"code-tabs\">\n")
+			for _, env := range append([]string{""}, envs...) {
+				label := env
+				if env == "" {
+					label = "All environments"
 				}
-				if !repoSet[link.FromRepo] {
-					externalNodes[link.FromRepo] = true
-				}
-			}
-			for extName := range externalNodes {
-				nodeID := strings.ReplaceAll(extName, "-", "_")
-				b.WriteString(fmt.Sprintf("    %s[(\"%s\")]\n", nodeID, extName))
+				fmt.Fprintf(&b, "<div class=\"code-tab\" data-label=\"%s\">\n\n", html.EscapeString(label))
+				g.writeArchitectureDiagram(&b, env)
+				b.WriteString("</div>\n")
 			}
+			b.WriteString("</div>\n\n")
+		} else {
+			g.writeArchitectureDiagram(&b, "")
 		}
-		// Define links between services.
-		if len(g.Links) > 0 {
-			for _, link := range g.Links {
-				fromID := strings.ReplaceAll(link.FromRepo, "-", "_")
-				toID := strings.ReplaceAll(link.ToRepo, "-", "_")
-				label := link.LinkType
-				if label == "" {
-					label = "depends"
-				}
-				b.WriteString(fmt.Sprintf("    %s -->|%s| %s\n", fromID, label, toID))
-			}
-		}
-		// Style the nodes.
-		b.WriteString("\n    classDef svc fill:#1f6feb,stroke:#58a6ff,color:#fff,stroke-width:2px\n")
-		b.WriteString("    classDef ext fill:#30363d,stroke:#8b949e,color:#e6edf3,stroke-width:1px,stroke-dasharray:5\n")
-		for _, repo := range g.Repos {
-			nodeID := strings.ReplaceAll(repo.Name, "-", "_")
-			b.WriteString(fmt.Sprintf("    class %s svc\n", nodeID))
-		}
-		for extName := range externalNodes {
-			nodeID := strings.ReplaceAll(extName, "-", "_")
-			b.WriteString(fmt.Sprintf("    class %s ext\n", nodeID))
-		}
-		b.WriteString("```\n\n")
 	}
 
 	// Dependencies table.
 	if len(g.Links) > 0 {
+		traced := g.hasTraceData()
+		tagged := len(g.allEnvironments()) > 0
 		b.WriteString("## Cross-Service Dependencies\n\n")
-		b.WriteString("| From | To | Type | Reason |\n")
-		b.WriteString("|------|----|------|--------|\n")
+		b.WriteString("| From | To | Type | Reason |")
+		if tagged {
+			b.WriteString(" Environments |")
+		}
+		if traced {
+			b.WriteString(" In Production |")
+		}
+		b.WriteString("\n|------|----|------|--------|")
+		if tagged {
+			b.WriteString("--------------|")
+		}
+		if traced {
+			b.WriteString("---------------|")
+		}
+		b.WriteString("\n")
 		for _, link := range g.Links {
 			reason := link.Reason
 			if len(reason) > 100 {
@@ -350,12 +1019,29 @@ func (g *CentralSiteGenerator) writeSystemOverview(stagingDir string) error {
 			if len(link.Endpoints) > 0 {
 				endpoints = " (" + strings.Join(link.Endpoints, ", ") + ")"
 			}
-			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s%s |\n",
+			if link.Override != "" {
+				endpoints += " *(corrected: " + link.Override + ")*"
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s%s |",
 				link.FromRepo, link.ToRepo, link.LinkType, reason, endpoints))
+			if tagged {
+				b.WriteString(" " + link.environmentLabel() + " |")
+			}
+			if traced {
+				b.WriteString(" " + runtimeLabel(link) + " |")
+			}
+			b.WriteString("\n")
 		}
 		b.WriteString("\n")
 	}
 
+	if g.hasTraceData() {
+		g.writeRuntimeValidation(&b)
+	}
+	g.writeCorrections(&b)
+	g.writeFlowCoverage(&b)
+	g.writeLatencyWarnings(&b)
+
 	// Flows summary.
 	if len(g.Flows) > 0 {
 		b.WriteString("## Cross-Service Flows\n\n")
@@ -384,17 +1070,80 @@ func (g *CentralSiteGenerator) writeSystemOverview(stagingDir string) error {
 	if len(g.Flows) > 0 {
 		b.WriteString("- [Cross-Service Flows](flows.md) — Detailed flow narratives\n")
 	}
+	if len(g.Changes) > 0 {
+		b.WriteString("- [Architecture Changelog](changelog.md) — Dependencies added, removed, and changed over time\n")
+	}
 	b.WriteString("\n")
 
 	return os.WriteFile(filepath.Join(stagingDir, "system-overview.md"), []byte(b.String()), 0o644)
 }
 
+// hasTraceData reports whether production traces have been imported.
+func (g *CentralSiteGenerator) hasTraceData() bool {
+	if len(g.RuntimeOnly) > 0 {
+		return true
+	}
+	for _, l := range g.Links {
+		if l.Runtime != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeLabel renders a link's production status for the dependencies table.
+func runtimeLabel(l LinkInfo) string {
+	switch l.Runtime {
+	case "observed":
+		return fmt.Sprintf("✓ observed (%d calls)", l.ObservedCalls)
+	case "unobserved":
+		return "✗ not observed"
+	case "unverifiable":
+		return "? caller not traced"
+	}
+	return "—"
+}
+
+// writeRuntimeValidation lists where production traces disagree with the
+// detected dependency graph.
+func (g *CentralSiteGenerator) writeRuntimeValidation(b *strings.Builder) {
+	var unobserved []LinkInfo
+	for _, l := range g.Links {
+		if l.Runtime == "unobserved" {
+			unobserved = append(unobserved, l)
+		}
+	}
+	b.WriteString("## Runtime Validation\n\n")
+	if len(unobserved) == 0 && len(g.RuntimeOnly) == 0 {
+		b.WriteString("Every traced dependency matches a detected link, and every detected link from a traced service was observed in production.\n\n")
+		return
+	}
+	if len(unobserved) > 0 {
+		b.WriteString("**Not observed in production.** These links were detected in code, but the caller emits traces and was never seen making the call. They may be dead code or stale configuration, and are candidates for removal.\n\n")
+		for _, l := range unobserved {
+			b.WriteString(fmt.Sprintf("- %s → %s (%s)\n", l.FromRepo, l.ToRepo, l.LinkType))
+		}
+		b.WriteString("\n")
+	}
+	if len(g.RuntimeOnly) > 0 {
+		b.WriteString("**Missed by static analysis.** These calls appear in production traces, but no link was detected in code.\n\n")
+		for _, l := range g.RuntimeOnly {
+			endpoints := ""
+			if len(l.Endpoints) > 0 {
+				endpoints = ": " + strings.Join(l.Endpoints, ", ")
+			}
+			b.WriteString(fmt.Sprintf("- %s → %s (%s, %d calls)%s\n", l.FromRepo, l.ToRepo, l.LinkType, l.ObservedCalls, endpoints))
+		}
+		b.WriteString("\n")
+	}
+}
+
 // writeFlowsPage creates the flows.md page with all cross-service flow narratives.
 func (g *CentralSiteGenerator) writeFlowsPage(stagingDir string) error {
 	var b strings.Builder
 
 	b.WriteString("# Cross-Service Flows\n\n")
-	b.WriteString("This page describes the data flows that span multiple services in the system.\n\n")
+	b.WriteString("This page describes the data flows that span multiple services in the system. Each has a starter [threat model](threat-model.md).\n\n")
 
 	for _, f := range g.Flows {
 		b.WriteString(fmt.Sprintf("## %s\n\n", f.Name))
@@ -404,9 +1153,19 @@ func (g *CentralSiteGenerator) writeFlowsPage(stagingDir string) error {
 		} else if f.Description != "" {
 			b.WriteString(f.Description + "\n\n")
 		}
+		if f.Override != "" {
+			b.WriteString("*Corrected: " + f.Override + "*\n\n")
+		}
+		if f.Edited != "" {
+			b.WriteString("*Edited: " + f.Edited + "*\n\n")
+		}
 		if len(f.Services) > 0 {
 			b.WriteString("**Services involved:** " + strings.Join(f.Services, ", ") + "\n\n")
+			if len(g.SLOs) > 0 {
+				writeFlowSLOs(&b, slo.ForFlow(f.Services, g.SLOs))
+			}
 		}
+		writeLatencyBudget(&b, f.Latency)
 		if f.Diagram != "" {
 			b.WriteString("```mermaid\n")
 			b.WriteString(f.Diagram)
@@ -426,6 +1185,14 @@ type serviceMapNode struct {
 	Status    string `json:"status"`
 	Summary   string `json:"summary"`
 	DocLink   string `json:"docLink"`
+	// Domain, Context, and Environment are the service's groups, for the
+	// map's filter.
+	Domain      string `json:"domain,omitempty"`
+	Context     string `json:"context,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	// Cluster is the ID of the cluster the node is drawn in; see
+	// serviceMapClusters.
+	Cluster string `json:"cluster,omitempty"`
 }
 
 // serviceMapEdge is an edge in the service map.
@@ -434,13 +1201,35 @@ type serviceMapEdge struct {
 	Target   string `json:"target"`
 	LinkType string `json:"linkType"`
 	Reason   string `json:"reason"`
+	// Environments are the environments the link exists in; empty means
+	// all of them.
+	Environments []string `json:"environments,omitempty"`
+	// Removed marks links that are gone but on the timeline.
+	Removed bool `json:"removed,omitempty"`
+	// Access says how the source uses a data store target: "reads",
+	// "writes", or "reads and writes", with the tables it writes and reads.
+	Access string   `json:"access,omitempty"`
+	Writes []string `json:"writes,omitempty"`
+	Reads  []string `json:"reads,omitempty"`
 }
 
 // serviceMapData is the data passed to the D3.js service map template.
 type serviceMapData struct {
-	ProjectName string           `json:"projectName"`
-	Nodes       []serviceMapNode `json:"nodes"`
-	Edges       []serviceMapEdge `json:"edges"`
+	ProjectName string            `json:"projectName"`
+	Nodes       []serviceMapNode  `json:"nodes"`
+	Edges       []serviceMapEdge  `json:"edges"`
+	Metrics     *metrics.Snapshot `json:"metrics,omitempty"`
+	// Environments are offered by the environment switch, in order; empty
+	// when no link is tagged with one.
+	Environments []string `json:"environments,omitempty"`
+	// Clusters group the nodes of large maps; see serviceMapClusters.
+	Clusters []serviceMapCluster `json:"clusters,omitempty"`
+	// Paths are the paths between services for route mode; see
+	// serviceMapPaths.
+	Paths map[string][][]int `json:"paths,omitempty"`
+	// History is the timeline's steps; see serviceMapHistory.
+	History []serviceMapSnapshot `json:"history,omitempty"`
+	Views   []mapviews.View      `json:"views,omitempty"`
 }
 
 // writeServiceMap generates a standalone D3.js service-map.html for the central site.
@@ -458,9 +1247,17 @@ func (g *CentralSiteGenerator) writeServiceMap(stagingDir string) error {
 			Status:    r.Status,
 			Summary:   r.Summary,
 			DocLink:   r.Name + "/index.html",
+
+			Domain:      r.Domain,
+			Context:     r.BoundedContext,
+			Environment: r.Environment,
 		}
 	}
 
+	nodeIDs := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		nodeIDs[n.ID] = true
+	}
 	edges := make([]serviceMapEdge, len(g.Links))
 	for i, l := range g.Links {
 		edges[i] = serviceMapEdge{
@@ -468,6 +1265,11 @@ func (g *CentralSiteGenerator) writeServiceMap(stagingDir string) error {
 			Target:   l.ToRepo,
 			LinkType: l.LinkType,
 			Reason:   l.Reason,
+
+			Environments: l.Environments,
+		}
+		if !nodeIDs[l.ToRepo] {
+			edges[i].Access, edges[i].Writes, edges[i].Reads = g.edgeAccess(l.FromRepo, l.ToRepo, l.LinkType)
 		}
 	}
 
@@ -481,14 +1283,35 @@ func (g *CentralSiteGenerator) writeServiceMap(stagingDir string) error {
 		for _, target := range []string{e.Source, e.Target} {
 			if !nodeSet[target] {
 				nodeSet[target] = true
-				nodes = append(nodes, serviceMapNode{
+				node := serviceMapNode{
 					ID:        target,
 					Label:     target,
 					FileCount: 0,
 					Status:    "external",
 					Summary:   "External dependency",
 					DocLink:   "#",
-				})
+				}
+				// Vendors link to their entry in the External Dependencies
+				// catalog.
+				if vendor, category, product, ok := vendors.Lookup(target); ok && len(g.externals) > 0 {
+					node.Summary = fmt.Sprintf("External dependency: %s (%s)", strings.TrimSpace(vendor+" "+product), category)
+					node.DocLink = "external-dependencies.html#" + teamSlug(vendor)
+				}
+				// Shared libraries, linked by autodoc libraries, are built
+				// into the services depending on them.
+				var users int
+				for _, d := range edges {
+					if d.Target == target && d.LinkType == "library" {
+						users++
+					}
+				}
+				if users > 0 {
+					node.Summary = fmt.Sprintf("Internal library, built into %d services", users)
+					if users == 1 {
+						node.Summary = "Internal library, built into 1 service"
+					}
+				}
+				nodes = append(nodes, node)
 			}
 		}
 	}
@@ -497,7 +1320,14 @@ func (g *CentralSiteGenerator) writeServiceMap(stagingDir string) error {
 		ProjectName: g.ProjectName,
 		Nodes:       nodes,
 		Edges:       edges,
+		Metrics:     g.Metrics,
+
+		Environments: g.allEnvironments(),
+		Views:        g.Views,
 	}
+	data.Clusters = g.serviceMapClusters(data.Nodes)
+	data.Paths = serviceMapPaths(data.Edges)
+	data.History = g.serviceMapHistory(&data)
 
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -548,7 +1378,12 @@ svg{width:100%;height:100%}
 #info-content a:hover{text-decoration:underline}
 .info-stat{display:flex;justify-content:space-between;padding:4px 0;border-bottom:1px solid var(--bd);font-size:13px}
 .info-stat .label{color:var(--tx2)}
-</style>
+.edge.hot{stroke-opacity:0.9;cursor:pointer}
+#legend{position:fixed;left:16px;bottom:16px;background:var(--bg2);border:1px solid var(--bd);border-radius:8px;padding:10px 12px;font-size:12px;color:var(--tx2);z-index:10}
+#legend.hidden{display:none}
+#legend div{display:flex;align-items:center;gap:6px;margin:2px 0}
+#legend i{display:inline-block;width:18px;height:4px;border-radius:2px}
+` + serviceMapScaleCSS + serviceMapRouteCSS + serviceMapTimelineCSS + `</style>
 </head>
 <body>
 <div id="toolbar">
@@ -558,13 +1393,24 @@ svg{width:100%;height:100%}
  </div>
  <div class="toolbar-section">
   <span id="stats"></span>
+  <select class="btn" id="group-filter" hidden></select>
+  <select class="btn" id="env-filter" hidden></select>
+  <button class="btn" id="cluster-btn" hidden></button>
+  <button class="btn" id="collapse-btn" hidden></button>
+  <button class="btn" id="bundle-btn" hidden></button>
+  <button class="btn" id="route-btn" title="Show the paths between two services">Route</button>
+  ` + mapviews.Menu + `
   <button class="btn" id="theme-btn">☀️ Light</button>
  </div>
 </div>
 <div id="graph-container"><svg id="graph"></svg></div>
 <div id="tooltip" class="hidden"></div>
+<div id="legend" class="hidden"><strong>Error rate</strong> <span id="legend-window"></span><div><i style="background:#3fb950"></i>&lt; 1%</div><div><i style="background:#d29922"></i>1–5%</div><div><i style="background:#f85149"></i>&gt; 5%</div><div>Width shows requests/s</div></div>
+<canvas id="minimap" hidden></canvas>
+<div id="timeline" hidden><button class="btn" id="play-btn" title="Play how the architecture evolved">▶</button><input type="range" id="time-slider" step="1"><span id="time-label"></span></div>
+<div id="route-panel" hidden><div class="route-ends"><select class="btn" id="route-from"></select><button class="btn" id="route-swap" title="Swap">⇄</button><select class="btn" id="route-to"></select></div><div id="route-results"></div></div>
 <div id="info-panel" class="hidden"><button id="info-close">&times;</button><div id="info-content"></div></div>
-<script src="https://d3js.org/d3.v7.min.js"></script>
+<script src="` + d3URL + `"></script>
 <script>
 (function(){
 var data = ` + dataJSON + `;
@@ -625,7 +1471,7 @@ var edgeEls = edgeG.selectAll('path').data(data.edges).join('path')
 var edgeLabelG = container.append('g');
 var edgeLabelEls = edgeLabelG.selectAll('text').data(data.edges).join('text')
   .attr('class','edge-label')
-  .text(function(d){ return d.linkType || ''; });
+  .text(function(d){ return (d.linkType || '') + (d.access ? ' · ' + d.access : ''); });
 
 // Draw nodes
 var nodeG = container.append('g');
@@ -650,21 +1496,145 @@ var labelEls = labelG.selectAll('text').data(data.nodes).join('text')
   .text(function(d){ return d.label; })
   .attr('dy', 4);
 
-sim.on('tick', function(){
-  edgeEls.attr('d', function(d){
-    return 'M'+d.source.x+','+d.source.y+'L'+d.target.x+','+d.target.y;
-  });
-  edgeLabelEls
-    .attr('x', function(d){ return (d.source.x + d.target.x) / 2; })
-    .attr('y', function(d){ return (d.source.y + d.target.y) / 2 - 6; });
-  nodeEls
-    .attr('x', function(d){ return d.x - sizeScale(d.fileCount); })
-    .attr('y', function(d){ return d.y - sizeScale(d.fileCount) * 0.6; });
-  labelEls.attr('x', function(d){ return d.x; }).attr('y', function(d){ return d.y; });
-});
+// The tick handler is draw, below.
 
 // Stats
-document.getElementById('stats').textContent = data.nodes.length + ' services, ' + data.edges.length + ' connections';
+var statsText = data.nodes.filter(function(n){ return n.status !== 'removed'; }).length + ' services, ' + data.edges.filter(function(e){ return !e.removed; }).length + ' connections';
+document.getElementById('stats').textContent = statsText;
+
+// Group filter: show one domain, bounded context, or environment, with
+// the services they talk to faded, e.g. service-map.html?domain=commerce.
+// When links are tagged with environments, environments get a switch of
+// their own that also hides the links missing from the chosen one, e.g.
+// service-map.html?environment=staging.
+var groupFilter = document.getElementById('group-filter');
+var envFilter = document.getElementById('env-filter');
+var envs = data.environments || [];
+var groupKinds = [['domain','Domain'], ['context','Context']];
+if(!envs.length) groupKinds.push(['environment','Environment']);
+var groupOptions = [];
+groupKinds.forEach(function(k){
+  var values = {};
+  data.nodes.forEach(function(n){ if(n[k[0]]) values[n[k[0]]] = true; });
+  Object.keys(values).sort().forEach(function(v){ groupOptions.push({value: k[0] + '=' + encodeURIComponent(v), label: k[1] + ': ' + v}); });
+});
+function endpointId(x){ return typeof x === 'object' ? x.id : x; }
+// nodeState is the filters' verdict on each node: 'shown', 'faded', or
+// hidden (''); edgeState says whether they show an edge. applyVisibility
+// draws them.
+var nodeState = {}, edgeState = function(d){ return !d.removed; };
+data.nodes.forEach(function(n){ nodeState[n.id] = n.status === 'removed' ? '' : 'shown'; });
+function applyGroupFilter(){
+  var value = groupFilter.value, env = envFilter.value;
+  var kind = value.split('=')[0], wanted = decodeURIComponent(value.split('=')[1] || '');
+  var inEnv = {}, linked = {};
+  function edgeInEnv(e){ return edgeInTime(e) && (!env || !e.environments || e.environments.indexOf(env) >= 0); }
+  data.edges.forEach(function(e){
+    if(!edgeInEnv(e)) return;
+    linked[endpointId(e.source)] = true;
+    linked[endpointId(e.target)] = true;
+  });
+  // In an environment, externals show only when something there calls
+  // them; on the timeline, services show only when they were registered
+  // or linked then.
+  var snap = timeSnapshot();
+  data.nodes.forEach(function(n){
+    var existed = snap ? !!snap.serviceSet[n.id] || !!linked[n.id] : n.status !== 'removed';
+    inEnv[n.id] = existed && (!env || (n.status === 'external' ? !!linked[n.id] : !n.environment || n.environment === env));
+  });
+  var shown = {}, faded = {}, count = 0;
+  data.nodes.forEach(function(n){ shown[n.id] = inEnv[n.id] && (!value || n[kind] === wanted); if(shown[n.id]) count++; });
+  function edgeShown(e){ return edgeInEnv(e) && inEnv[endpointId(e.source)] && inEnv[endpointId(e.target)]; }
+  data.edges.forEach(function(e){
+    if(!edgeShown(e)) return;
+    var s = endpointId(e.source), t = endpointId(e.target);
+    if(shown[s] && !shown[t]) faded[t] = true;
+    if(shown[t] && !shown[s]) faded[s] = true;
+  });
+  nodeState = {};
+  data.nodes.forEach(function(n){ nodeState[n.id] = shown[n.id] ? 'shown' : faded[n.id] ? 'faded' : ''; });
+  edgeState = function(d){ return edgeShown(d) && (shown[endpointId(d.source)] || shown[endpointId(d.target)]); };
+  document.getElementById('stats').textContent = value || env || snap ? count + ' of ' + statsText : statsText;
+  applyVisibility();
+}
+var params = new URLSearchParams(location.search);
+if(groupOptions.length){
+  groupFilter.innerHTML = '<option value="">All services</option>' + groupOptions.map(function(o){
+    return '<option value="' + o.value + '">' + o.label.replace(/</g, '&lt;') + '</option>';
+  }).join('');
+  groupKinds.forEach(function(k){ if(params.get(k[0])) groupFilter.value = k[0] + '=' + encodeURIComponent(params.get(k[0])); });
+  groupFilter.onchange = applyGroupFilter;
+  groupFilter.hidden = false;
+}
+if(envs.length){
+  envFilter.innerHTML = '<option value="">All environments</option>' + envs.map(function(e){
+    e = e.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/"/g, '&quot;');
+    return '<option value="' + e + '">' + e + '</option>';
+  }).join('');
+  if(params.get('environment')) envFilter.value = params.get('environment');
+  envFilter.onchange = applyGroupFilter;
+  envFilter.hidden = false;
+  // Links that exist only in some environments are dashed.
+  edgeEls.style('stroke-dasharray', function(d){ return d.environments ? '6 4' : null; });
+  edgeLabelEls.text(function(d){ return (d.linkType || '') + (d.access ? ' · ' + d.access : '') + (d.environments ? ' · ' + d.environments.join(', ') : ''); });
+}
+
+// Runtime metrics: color edges by error rate and size them by traffic.
+function edgeKey(d){
+  var s = typeof d.source === 'object' ? d.source.id : d.source;
+  var t = typeof d.target === 'object' ? d.target.id : d.target;
+  return s + '>' + t;
+}
+function errorColor(rate){ return rate < 0.01 ? '#3fb950' : rate < 0.05 ? '#d29922' : '#f85149'; }
+function applyMetrics(m){
+  if(!m) return;
+  var byEdge = {};
+  (m.edges || []).forEach(function(e){ byEdge[e.from + '>' + e.to] = e; });
+  var maxRPS = d3.max(m.edges || [], function(e){ return e.rps; }) || 1;
+  var width = d3.scaleSqrt().domain([0, maxRPS]).range([1.5, 10]);
+  data.edges.forEach(function(d){ d.metrics = byEdge[edgeKey(d)] || null; });
+  edgeEls
+    .classed('hot', function(d){ return !!d.metrics; })
+    .style('stroke', function(d){ return d.metrics ? errorColor(d.metrics.errorRate) : null; })
+    .attr('stroke-width', function(d){ return d.metrics ? width(d.metrics.rps) : 2; });
+  document.getElementById('legend-window').textContent = '(last ' + m.window + ')';
+  document.getElementById('legend').classList.remove('hidden');
+  document.getElementById('stats').textContent = statsText + ' · metrics as of ' + new Date(m.fetchedAt).toLocaleTimeString();
+  redraw();
+}
+function formatMetrics(m){
+  var html = '<p><span class="badge">' + (m.rps < 10 ? m.rps.toFixed(2) : Math.round(m.rps)) + ' req/s</span>';
+  html += '<span class="badge">' + (m.errorRate * 100).toFixed(2) + '% errors</span>';
+  if(m.latencyMs) html += '<span class="badge">p99 ' + Math.round(m.latencyMs) + ' ms</span>';
+  return html + '</p>';
+}
+edgeEls
+  .on('mouseover', function(e, d){
+    var s = typeof d.source === 'object' ? d.source.label : d.source;
+    var t = typeof d.target === 'object' ? d.target.label : d.target;
+    var html = '<h3>' + s + ' → ' + t + '</h3>';
+    if(d.linkType) html += '<p><span class="badge">' + d.linkType + '</span></p>';
+    if(d.environments) html += '<p>Only in ' + d.environments.join(', ') + '</p>';
+    if(d.access) html += '<p>' + s + ' ' + d.access + (d.writes ? '<br>Writes: ' + d.writes.join(', ') : '') + (d.reads ? '<br>Reads: ' + d.reads.join(', ') : '') + '</p>';
+    if(d.metrics) html += formatMetrics(d.metrics);
+    if(d.reason) html += '<p>' + d.reason + '</p>';
+    tooltip.innerHTML = html;
+    tooltip.classList.remove('hidden');
+  })
+  .on('mousemove', function(e){ moveTooltip(e); })
+  .on('mouseout', function(){ onHoverOut(); });
+applyMetrics(data.metrics);
+
+// When served by autodoc, poll for fresh numbers; a static host has no
+// api/metrics, so the snapshot from generation time stays.
+if(data.metrics && data.metrics.refresh > 0 && location.protocol.indexOf('http') === 0){
+  var poll = setInterval(function(){
+    fetch('api/metrics', {cache: 'no-store'})
+      .then(function(r){ if(!r.ok) throw new Error(r.status); return r.json(); })
+      .then(applyMetrics)
+      .catch(function(){ clearInterval(poll); });
+  }, data.metrics.refresh * 1000);
+}
 
 // Tooltip
 var tooltip = document.getElementById('tooltip');
@@ -687,6 +1657,7 @@ var infoContent = document.getElementById('info-content');
 document.getElementById('info-close').onclick = function(){ infoPanel.classList.add('hidden'); selectedId = null; };
 
 function onClick(e, d){
+  if(routing){ pickRouteEnd(d.id); return; }
   selectedId = d.id;
   var html = '<h3>' + d.label + '</h3>';
   html += '<div class="info-stat"><span class="label">Status</span><span>' + d.status + '</span></div>';
@@ -697,15 +1668,16 @@ function onClick(e, d){
   var outgoing = data.edges.filter(function(e){ var s = typeof e.source === 'object' ? e.source.id : e.source; return s === d.id; });
   if(outgoing.length > 0){
     html += '<h4 style="margin-top:12px;font-size:13px">Calls →</h4>';
-    outgoing.forEach(function(e){ var t = typeof e.target === 'object' ? e.target.id : e.target; html += '<div class="info-stat"><span>' + t + '</span><span class="badge">' + (e.linkType||'') + '</span></div>'; });
+    outgoing.forEach(function(e){ var t = typeof e.target === 'object' ? e.target.id : e.target; html += '<div class="info-stat"><span>' + t + '</span><span class="badge">' + (e.linkType||'') + '</span></div>'; if(e.metrics) html += formatMetrics(e.metrics); });
   }
   if(incoming.length > 0){
     html += '<h4 style="margin-top:12px;font-size:13px">← Called by</h4>';
-    incoming.forEach(function(e){ var s = typeof e.source === 'object' ? e.source.id : e.source; html += '<div class="info-stat"><span>' + s + '</span><span class="badge">' + (e.linkType||'') + '</span></div>'; });
+    incoming.forEach(function(e){ var s = typeof e.source === 'object' ? e.source.id : e.source; html += '<div class="info-stat"><span>' + s + '</span><span class="badge">' + (e.linkType||'') + '</span></div>'; if(e.metrics) html += formatMetrics(e.metrics); });
   }
   html += '<p style="margin-top:12px"><a href="' + d.docLink + '">View Documentation →</a></p>';
   infoContent.innerHTML = html;
   infoPanel.classList.remove('hidden');
+  viewChanged();
 }
 
 // Theme toggle
@@ -717,6 +1689,7 @@ themeBtn.onclick = function(){
   themeBtn.textContent = isLight ? '🌙 Dark' : '☀️ Light';
 };
 
+` + serviceMapScaleJS + serviceMapRouteJS + serviceMapTimelineJS + serviceMapViewJS + mapviews.JS + `
 // Responsive
 window.addEventListener('resize', function(){
   width = svgEl.clientWidth; height = svgEl.clientHeight;
@@ -809,6 +1782,40 @@ func (g *CentralSiteGenerator) cleanSummaries() {
 	}
 }
 
+// loadAnalyses reads every repo's analyses into g.analyses, several repos
+// at once.
+func (g *CentralSiteGenerator) loadAnalyses() {
+	loaded := make([]map[string]indexer.FileAnalysis, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		loaded[i] = readRepoAnalyses(g.Repos[i])
+	})
+	g.analyses = make(map[string]map[string]indexer.FileAnalysis, len(g.Repos))
+	for i, r := range g.Repos {
+		g.analyses[r.Name] = loaded[i]
+	}
+}
+
+// repoAnalyses returns a repo's analyses, scoped to its subdirectory and
+// excludes, or nil when it has none. Repos that loadAnalyses did not see
+// are read from disk.
+func (g *CentralSiteGenerator) repoAnalyses(repo RepoInfo) map[string]indexer.FileAnalysis {
+	if analyses, ok := g.analyses[repo.Name]; ok {
+		return analyses
+	}
+	return readRepoAnalyses(repo)
+}
+
+func readRepoAnalyses(repo RepoInfo) map[string]indexer.FileAnalysis {
+	if repo.DocsDir == "" {
+		return nil
+	}
+	analyses, err := indexer.LoadAnalyses(repo.sourceRoot())
+	if err != nil || len(analyses) == 0 {
+		return nil
+	}
+	return indexer.ScopeAnalyses(analyses, repo.Subdir, repo.Excludes)
+}
+
 // augmentLinksFromAnalyses extracts gRPC/API dependencies directly from each repo's
 // analyses.json and creates links to matching registered repos. This provides
 // reliable link detection independent of LLM quality.
@@ -871,15 +1878,7 @@ func (g *CentralSiteGenerator) augmentLinksFromAnalyses() {
 
 	// For each repo, load analyses and extract gRPC/API deps.
 	for _, repo := range g.Repos {
-		if repo.DocsDir == "" {
-			continue
-		}
-		// DocsDir is like /path/to/repo/.autodoc/docs — analyses.json is in .autodoc/
-		autodocDir := filepath.Dir(repo.DocsDir)
-		analyses, err := indexer.LoadAnalyses(filepath.Dir(autodocDir))
-		if err != nil {
-			continue
-		}
+		analyses := g.repoAnalyses(repo)
 
 		for filePath, analysis := range analyses {
 			if isProto(filePath) {
@@ -960,9 +1959,11 @@ func (g *CentralSiteGenerator) normalizeData() {
 
 		// Skip links where source or target doesn't match any registered repo.
 		if _, ok := repoLookup[strings.ToLower(link.FromRepo)]; !ok {
+			g.unknownService(link.FromRepo, fmt.Sprintf("link %s → %s", link.FromRepo, link.ToRepo))
 			continue
 		}
 		if _, ok := repoLookup[strings.ToLower(link.ToRepo)]; !ok {
+			g.unknownService(link.ToRepo, fmt.Sprintf("link %s → %s", link.FromRepo, link.ToRepo))
 			continue
 		}
 
@@ -1163,32 +2164,47 @@ func operationLabel(link LinkInfo) string {
 }
 
 // generateSequenceDiagram creates a Mermaid sequence diagram for a flow
-// based on its services and the known cross-service links.
+// based on its services and the known cross-service links. Each service's
+// calls are drawn in the order it makes them (see callOrder), each call
+// followed by the calls its target makes in turn.
 func (g *CentralSiteGenerator) generateSequenceDiagram(flow FlowInfo) string {
 	if len(flow.Services) < 2 {
 		return ""
 	}
 
-	// Build set of services in this flow.
-	flowSvcs := make(map[string]bool)
+	// Build set of services in this flow, by lower-cased name.
+	flowSvcs := make(map[string]string)
 	for _, s := range flow.Services {
-		flowSvcs[strings.ToLower(s)] = true
+		flowSvcs[strings.ToLower(s)] = s
 	}
-
-	// Collect links relevant to this flow (both endpoints in the flow's service set).
-	type edge struct {
-		from, to, label string
+	displayName := func(lower string) string {
+		if s, ok := flowSvcs[lower]; ok {
+			return s
+		}
+		return lower
 	}
-	var edges []edge
+
+	// Collect each service's calls, and which of them stay in the flow's
+	// service set; calls are ordered among all of a service's calls.
+	calls := make(map[string][]flowCall)
+	inFlow := make(map[string]bool)
+	outCount := make(map[string]int)
+	inCount := make(map[string]int)
 	for _, link := range g.Links {
 		fromLower := strings.ToLower(link.FromRepo)
 		toLower := strings.ToLower(link.ToRepo)
-		if flowSvcs[fromLower] && flowSvcs[toLower] {
-			edges = append(edges, edge{link.FromRepo, link.ToRepo, operationLabel(link)})
+		calls[fromLower] = append(calls[fromLower], flowCall{to: toLower, link: link})
+		if _, ok := flowSvcs[fromLower]; !ok {
+			continue
+		}
+		if _, ok := flowSvcs[toLower]; ok {
+			inFlow[fromLower+"->"+toLower] = true
+			outCount[fromLower]++
+			inCount[toLower]++
 		}
 	}
 
-	if len(edges) == 0 {
+	if len(inFlow) == 0 {
 		return ""
 	}
 
@@ -1196,23 +2212,21 @@ func (g *CentralSiteGenerator) generateSequenceDiagram(flow FlowInfo) string {
 	b.WriteString("sequenceDiagram\n")
 
 	// Define participants in a logical order (try to put the initiator first).
-	// Count outgoing edges to find the initiator.
-	outCount := make(map[string]int)
-	for _, e := range edges {
-		outCount[e.from]++
-	}
-	// Sort services: most outgoing first (likely initiator).
+	// Sort services: those nothing in the flow calls first, then most
+	// outgoing first (likely initiator).
 	type svcCount struct {
 		name  string
+		root  bool
 		count int
 	}
 	var sorted []svcCount
 	for _, s := range flow.Services {
-		sorted = append(sorted, svcCount{s, outCount[s]})
+		lower := strings.ToLower(s)
+		sorted = append(sorted, svcCount{s, inCount[lower] == 0 && outCount[lower] > 0, outCount[lower]})
 	}
 	for i := 0; i < len(sorted); i++ {
 		for j := i + 1; j < len(sorted); j++ {
-			if sorted[j].count > sorted[i].count {
+			if sorted[j].root && !sorted[i].root || sorted[j].root == sorted[i].root && sorted[j].count > sorted[i].count {
 				sorted[i], sorted[j] = sorted[j], sorted[i]
 			}
 		}
@@ -1221,9 +2235,37 @@ func (g *CentralSiteGenerator) generateSequenceDiagram(flow FlowInfo) string {
 		b.WriteString(fmt.Sprintf("    participant %s\n", s.name))
 	}
 
-	// Write edges as sequence arrows.
-	for _, e := range edges {
-		b.WriteString(fmt.Sprintf("    %s->>%s: %s\n", e.from, e.to, e.label))
+	// Write each service's calls as sequence arrows, starting from the
+	// initiator and following each call into its target.
+	drawn := make(map[string]bool)
+	var draw func(from, indent string)
+	draw = func(from, indent string) {
+		if drawn[from] {
+			return
+		}
+		drawn[from] = true
+		var steps []callStep
+		for _, step := range g.callOrder(displayName(from), calls[from]) {
+			kept := step
+			kept.calls = nil
+			for _, c := range step.calls {
+				if inFlow[from+"->"+c.to] {
+					kept.calls = append(kept.calls, c)
+				}
+			}
+			if len(kept.calls) > 0 {
+				kept.parallel = kept.parallel && len(kept.calls) > 1
+				steps = append(steps, kept)
+			}
+		}
+		writeCallSteps(&b, indent, displayName(from), steps, displayName, func(c flowCall, indent string) {
+			draw(c.to, indent)
+		})
+	}
+	for _, s := range sorted {
+		if s.count > 0 {
+			draw(strings.ToLower(s.name), "    ")
+		}
 	}
 
 	return b.String()
@@ -1231,17 +2273,16 @@ func (g *CentralSiteGenerator) generateSequenceDiagram(flow FlowInfo) string {
 
 // synthesizeCanonicalFlows generates distinct, non-overlapping user journey flows
 // directly from the link topology instead of relying on LLM-generated flows.
-// Each flow represents a specific user journey through the system.
+// Each flow represents a specific user journey through the system: one per
+// orchestrator matched by a flow template, then one per remaining service
+// with three or more callees, then one for the leftover calls.
 func (g *CentralSiteGenerator) synthesizeCanonicalFlows() {
 	if len(g.Links) == 0 {
 		return
 	}
 
 	// Build adjacency: from -> list of (to, link)
-	type target struct {
-		to   string
-		link LinkInfo
-	}
+	type target = flowCall
 	adj := make(map[string][]target)
 	for _, link := range g.Links {
 		adj[strings.ToLower(link.FromRepo)] = append(adj[strings.ToLower(link.FromRepo)], target{
@@ -1262,15 +2303,6 @@ func (g *CentralSiteGenerator) synthesizeCanonicalFlows() {
 		return lower
 	}
 
-	// Collect all outbound targets for a service.
-	targetsOf := func(svc string) []string {
-		var result []string
-		for _, t := range adj[svc] {
-			result = append(result, t.to)
-		}
-		return result
-	}
-
 	// Track which from->to edges have been used in flows.
 	usedEdges := make(map[string]bool)
 	markEdge := func(from, to string) {
@@ -1280,267 +2312,37 @@ func (g *CentralSiteGenerator) synthesizeCanonicalFlows() {
 	var flows []FlowInfo
 
 	// --- Named Business Flows ---
-	// These are hand-crafted flow templates that match well-known microservice patterns.
-	// Each defines a flow name, a description, the orchestrator service, the logical phase ordering,
-	// and a detailed narrative.
-
-	type flowPhase struct {
-		name     string
-		services []string // service name substrings to match from targets
-	}
-
-	type namedFlow struct {
-		orchPattern string // substring match in orchestrator name
-		flowName    string
-		phases      []flowPhase
-		narrative   func(orchName string, targets []string) string
-	}
-
-	namedFlows := []namedFlow{
-		{
-			orchPattern: "preserve-service",
-			flowName:    "Ticket Booking Flow (High-Speed Trains)",
-			phases: []flowPhase{
-				{name: "1. Security Check", services: []string{"security"}},
-				{name: "2. Trip & Contact Lookup", services: []string{"travel-service", "contacts"}},
-				{name: "3. Order Creation", services: []string{"order-service"}},
-				{name: "4. Seat Assignment", services: []string{"seat"}},
-				{name: "5. Ancillary Services", services: []string{"assurance", "food", "consign", "station", "basic"}},
-				{name: "6. User & Notification", services: []string{"user", "notification", "delivery"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s orchestrates the complete ticket booking flow for high-speed trains (G/D/C prefix). When a user books a ticket, the following steps execute in sequence:
-
-**Phase 1 — Security Validation:** The service first calls ts-security-service to verify the user's identity and check for any booking restrictions or blacklist entries.
-
-**Phase 2 — Trip & Contact Lookup:** Next, it calls ts-travel-service to validate the requested trip (departure/arrival stations, date, train number, available tickets) and ts-contacts-service to retrieve or validate the passenger's contact information.
-
-**Phase 3 — Order Creation:** With validated trip and contact data, the service calls ts-order-service to create the order record. Since this is a high-speed train (G/D/C prefix), it routes to ts-order-service (not ts-order-other-service).
-
-**Phase 4 — Seat Assignment:** After order creation, ts-seat-service is called to allocate a specific seat on the train.
-
-**Phase 5 — Ancillary Services (parallelizable):** These calls can execute in parallel after the order is created:
-- ts-assurance-service — attach travel insurance if requested
-- ts-food-service — order meals (which internally aggregates from ts-train-food-service and ts-station-food-service)
-- ts-consign-service — register consignment/luggage packages (calls ts-consign-price-service for pricing)
-- ts-station-service — resolve station details
-- ts-basic-service — fetch basic trip metadata
-
-**Phase 6 — Notification:** Finally, ts-user-service updates the user's booking history, and a confirmation notification is triggered (either via direct HTTP to ts-notification-service or via RabbitMQ message queue).
-
-**Total outbound calls:** %d services. **Critical path (sequential):** ~6 hops (security → travel → contacts → order → seat → notification) = ~300ms at 50ms/hop. **With parallelization of Phase 5:** total latency drops to ~350ms.`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "preserve-other",
-			flowName:    "Ticket Booking Flow (Regular Trains)",
-			phases: []flowPhase{
-				{name: "1. Security Check", services: []string{"security"}},
-				{name: "2. Trip & Contact Lookup", services: []string{"travel2", "contacts"}},
-				{name: "3. Order Creation", services: []string{"order-other"}},
-				{name: "4. Seat Assignment", services: []string{"seat"}},
-				{name: "5. Ancillary Services", services: []string{"assurance", "food", "consign", "station", "basic", "delivery"}},
-				{name: "6. User & Notification", services: []string{"user", "notification"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s orchestrates the ticket booking flow for regular (non-high-speed) trains — K/T/Z prefix and other train types. The flow mirrors the high-speed booking but routes to different service variants:
-
-**Key routing differences from high-speed booking:**
-- Calls **ts-travel2-service** instead of ts-travel-service for trip lookup
-- Creates orders via **ts-order-other-service** instead of ts-order-service
-- May include **ts-delivery-service** for package delivery tracking
-
-The sequential flow and ancillary service calls follow the same pattern as the high-speed booking flow. This parallel service architecture allows independent scaling: high-speed train bookings (typically higher volume during business hours) can scale separately from regular train bookings.
-
-**Total outbound calls:** %d services.`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "cancel-service",
-			flowName:    "Ticket Cancellation and Refund Flow",
-			phases: []flowPhase{
-				{name: "1. Order Lookup", services: []string{"order-service", "order-other"}},
-				{name: "2. Refund Processing", services: []string{"inside-payment"}},
-				{name: "3. User Update", services: []string{"user"}},
-				{name: "4. Notification", services: []string{"notification"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s handles ticket cancellation and refund processing. The flow executes in strict sequence:
-
-**Step 1 — Order Lookup:** The service calls both ts-order-service AND ts-order-other-service to find the order (since it may be for either a high-speed or regular train). The order status is validated (must be "Not Paid" or "Paid, Not Collected").
-
-**Step 2 — Refund Processing:** If the ticket was paid, %s calls ts-inside-payment-service to process the refund. The inside-payment service credits the user's internal account balance. If the original payment was via external payment (credit card), inside-payment may call ts-payment-service to reverse the charge.
-
-**Step 3 — User Update:** ts-user-service is called to update the user's booking history and account status.
-
-**Step 4 — Cancellation Notification:** Finally, ts-notification-service sends a cancellation confirmation email using the "order_cancel_success" FreeMarker template.
-
-**Total outbound calls:** %d services. All calls are **sequential** — each step depends on the previous one. Critical path: ~%dms at 50ms/hop.`, orch, orch, len(targets), len(targets)*50)
-			},
-		},
-		{
-			orchPattern: "rebook-service",
-			flowName:    "Ticket Rebooking Flow",
-			phases: []flowPhase{
-				{name: "1. Old Order Lookup", services: []string{"order-service", "order-other"}},
-				{name: "2. New Trip Validation", services: []string{"travel-service", "travel2"}},
-				{name: "3. Seat & Route Check", services: []string{"seat", "train", "route"}},
-				{name: "4. Payment Adjustment", services: []string{"inside-payment"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s handles rebooking a ticket from one train to another, potentially crossing between high-speed and regular train types. This is one of the most complex flows because it may need to transfer an order between different order services.
-
-**Step 1 — Retrieve Old Order:** The service calls BOTH ts-order-service and ts-order-other-service to find the existing order, since the original booking could be for either train type.
-
-**Step 2 — Validate New Trip:** The service calls BOTH ts-travel-service and ts-travel2-service to check availability of the new requested trip. The train number prefix (G/D/C for high-speed, K/T/Z for regular) determines which travel service has the trip data.
-
-**Step 3 — Seat & Route Validation:** ts-seat-service checks seat availability on the new train, ts-train-service provides train configuration data, and ts-route-service validates the route.
-
-**Step 4 — Payment Adjustment:** If the new ticket costs more than the old one, ts-inside-payment-service is called to charge the price difference. If it costs less, a partial refund is issued. The inside-payment service handles the internal ledger update and, if needed, calls ts-payment-service for actual money movement.
-
-**Cross-type rebooking:** When rebooking from a regular train to a high-speed train (or vice versa), the order must be cancelled in one order service and recreated in the other. For example, rebooking from K-train to G-train means: cancel in ts-order-other-service → create in ts-order-service.
-
-**Total outbound calls:** %d services. Critical path: ~%dms at 50ms/hop (most calls are sequential due to data dependencies).`, orch, len(targets), len(targets)*50)
-			},
-		},
-		{
-			orchPattern: "travel-plan",
-			flowName:    "Trip Search and Planning Flow",
-			phases: []flowPhase{
-				{name: "1. Route Planning", services: []string{"route-plan", "route"}},
-				{name: "2. Trip Search", services: []string{"travel-service", "travel2"}},
-				{name: "3. Seat Availability", services: []string{"seat", "train"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s provides the trip search and planning functionality. When a user searches for available trains between two stations:
-
-**Step 1 — Route Planning:** ts-route-plan-service is called to find possible routes (direct and transfer routes) between the departure and arrival stations.
-
-**Step 2 — Trip Search:** Both ts-travel-service (high-speed G/D/C trains) and ts-travel2-service (regular K/T/Z trains) are queried for available trips on the found routes. This dual query ensures results include all train types.
-
-**Step 3 — Seat Availability:** For each available trip, ts-seat-service is called to get remaining seat counts and ts-train-service provides train configuration data (seat classes, carriage types).
-
-**Total outbound calls:** %d services.`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "gateway",
-			flowName:    "API Gateway Routing",
-			phases: []flowPhase{
-				{name: "Authentication", services: []string{"auth", "verification"}},
-				{name: "Business Services", services: []string{"preserve", "cancel", "rebook", "travel", "order", "payment"}},
-				{name: "Data Services", services: []string{"station", "train", "route", "config", "price", "contacts"}},
-				{name: "Admin Services", services: []string{"admin"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s serves as the API gateway / reverse proxy for the entire system, routing incoming HTTP requests to %d backend microservices. It handles:
-
-- **Authentication:** Routes login/token requests to ts-auth-service and verification code requests to ts-verification-code-service
-- **Booking operations:** Proxies to ts-preserve-service (high-speed) / ts-preserve-other-service (regular) for ticket booking
-- **Order management:** Routes to ts-order-service / ts-order-other-service based on train type
-- **Cancellation & rebooking:** Forwards to ts-cancel-service and ts-rebook-service
-- **Trip search:** Routes to ts-travel-service, ts-travel2-service, and ts-travel-plan-service
-- **Payment:** Proxies to ts-inside-payment-service and ts-payment-service
-- **Admin:** Routes admin panel requests to the 5 admin services
-- **Data lookups:** Station, train, route, config, price, and contact queries
-
-The gateway does NOT implement business logic — it purely routes and may add cross-cutting concerns (auth headers, rate limiting, logging).`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "ui-dashboard",
-			flowName:    "User Interface Flow",
-			phases: []flowPhase{
-				{name: "User Actions", services: []string{"preserve", "rebook", "cancel"}},
-				{name: "Data Display", services: []string{"travel", "order", "station", "train", "route"}},
-				{name: "Account", services: []string{"contacts", "avatar", "verification"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s is the Angular.js frontend application that provides the user interface. It makes direct API calls (typically through the gateway) to %d backend services:
-
-- **Ticket booking:** ts-preserve-service / ts-preserve-other-service for new bookings
-- **Trip search:** ts-travel-plan-service, ts-basic-service for searching available trains
-- **Order management:** ts-order-service for viewing/managing orders, ts-rebook-service for rebooking, ts-inside-payment-service for payment
-- **Admin panel:** ts-admin-basic-info-service for station/train/route/config management
-- **User account:** ts-contacts-service, ts-avatar-service, ts-verification-code-service
-- **Food ordering:** ts-food-service for meal orders, ts-assurance-service for insurance`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "admin-basic-info",
-			flowName:    "Admin: Basic Info Management",
-			phases: []flowPhase{
-				{name: "Data Management", services: []string{"station", "train", "config", "price", "contacts"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s provides a composite admin API for managing foundational reference data. It aggregates CRUD operations for %d data services:
-
-- **ts-station-service** — manage train stations (add/update/delete stations)
-- **ts-train-service** — manage train types and configurations
-- **ts-config-service** — manage system configuration key-value pairs
-- **ts-price-service** — manage pricing rules and fare tables
-- **ts-contacts-service** — manage passenger contact records
-
-This is a pure aggregator — it adds no business logic, just provides a unified admin interface for the foundational data layer.`, orch, len(targets))
-			},
-		},
-		{
-			orchPattern: "admin-travel",
-			flowName:    "Admin: Travel/Trip Management",
-			phases: []flowPhase{
-				{name: "Trip Management", services: []string{"travel", "travel2", "station", "train", "route"}},
-			},
-			narrative: func(orch string, targets []string) string {
-				return fmt.Sprintf(`%s provides admin functionality for managing train trips and schedules. It coordinates with %d services:
-
-- **ts-travel-service** — manage high-speed train trips (G/D/C prefix)
-- **ts-travel2-service** — manage regular train trips (K/T/Z and others)
-- **ts-train-service** — train type reference data
-- **ts-station-service** — station reference data
-- **ts-route-service** — route definitions
-
-Admin users can create, update, and delete trip schedules for both high-speed and regular trains through this unified interface.`, orch, len(targets))
-			},
-		},
-	}
-
-	// Process named flows first.
+	// Flow templates name the flows around known orchestrators and group
+	// their calls into phases. Orchestrators are matched in name order so
+	// that a template matching several services picks the same one each run.
+	orchNames := make([]string, 0, len(adj))
+	for svc := range adj {
+		orchNames = append(orchNames, svc)
+	}
+	sort.Strings(orchNames)
 	processedOrchestrators := make(map[string]bool)
 
-	for _, nf := range namedFlows {
-		// Find the orchestrator service matching this pattern.
-		var matchedOrch string
-		var matchedTargets []target
-		for svc, targets := range adj {
-			if strings.Contains(svc, nf.orchPattern) && !processedOrchestrators[svc] {
-				// For "preserve-service", avoid matching "preserve-other-service" and vice versa.
-				if nf.orchPattern == "preserve-service" && strings.Contains(svc, "other") {
-					continue
-				}
-				if nf.orchPattern == "travel-service" && (strings.Contains(svc, "travel2") || strings.Contains(svc, "travel-plan")) {
-					continue
-				}
-				if nf.orchPattern == "order-service" && strings.Contains(svc, "other") {
-					continue
-				}
+	for _, ft := range g.FlowTemplates {
+		matchedOrch := ""
+		for _, svc := range orchNames {
+			if !processedOrchestrators[svc] && ft.matchesOrchestrator(svc) {
 				matchedOrch = svc
-				matchedTargets = targets
 				break
 			}
 		}
 		if matchedOrch == "" {
 			continue
 		}
-
 		processedOrchestrators[matchedOrch] = true
 		orchDisplay := displayName(matchedOrch)
-		allTargets := targetsOf(matchedOrch)
+		matchedTargets := adj[matchedOrch]
 
 		// Build service list.
 		svcSet := make(map[string]bool)
 		svcSet[orchDisplay] = true
 		for _, t := range matchedTargets {
 			svcSet[displayName(t.to)] = true
+			markEdge(matchedOrch, t.to)
 		}
 		var svcList []string
 		for s := range svcSet {
@@ -1548,77 +2350,34 @@ Admin users can create, update, and delete trip schedules for both high-speed an
 		}
 		sort.Strings(svcList)
 
-		// Build phased sequence diagram.
-		var diagram strings.Builder
-		diagram.WriteString("sequenceDiagram\n")
-		diagram.WriteString(fmt.Sprintf("    participant %s\n", orchDisplay))
-
-		// Collect all target display names for participants.
-		targetDisplays := make(map[string]bool)
-		for _, t := range matchedTargets {
-			targetDisplays[displayName(t.to)] = true
-		}
-
-		// Order participants by phase.
-		addedParticipant := make(map[string]bool)
-		addedParticipant[orchDisplay] = true
-		for _, phase := range nf.phases {
-			for _, t := range matchedTargets {
-				dn := displayName(t.to)
-				if addedParticipant[dn] {
-					continue
-				}
-				for _, pat := range phase.services {
-					if strings.Contains(strings.ToLower(t.to), pat) {
-						diagram.WriteString(fmt.Sprintf("    participant %s\n", dn))
-						addedParticipant[dn] = true
-						break
-					}
-				}
-			}
-		}
-		// Add any unmatched participants.
-		for _, t := range matchedTargets {
-			dn := displayName(t.to)
-			if !addedParticipant[dn] {
-				diagram.WriteString(fmt.Sprintf("    participant %s\n", dn))
-				addedParticipant[dn] = true
-			}
-		}
-
-		// Write edges grouped by phase.
-		for _, phase := range nf.phases {
-			diagram.WriteString(fmt.Sprintf("    Note over %s: %s\n", orchDisplay, phase.name))
-			for _, t := range matchedTargets {
-				matched := false
-				for _, pat := range phase.services {
-					if strings.Contains(strings.ToLower(t.to), pat) {
-						matched = true
-						break
-					}
-				}
-				if matched {
-					label := operationLabel(t.link)
-					diagram.WriteString(fmt.Sprintf("    %s->>%s: %s\n", orchDisplay, displayName(t.to), label))
-					markEdge(matchedOrch, t.to)
-				}
+		// The template orders the phases; the source, when it shows the
+		// order of the calls, orders them within each phase.
+		if steps, ok := g.staticCallOrder(matchedOrch, matchedTargets); ok {
+			matchedTargets = stepCalls(steps)
+		}
+		phases := assignPhases(ft.Phases, matchedTargets)
+		latency := &LatencyModel{}
+		for _, p := range phases {
+			latency.Phases = append(latency.Phases, g.latencyPhase(p.name, !p.sequential, p.calls, displayName))
+		}
+		g.setBudget(latency, orchDisplay, ft.Budget)
+		narrative := ""
+		if ft.Narrative != "" {
+			var err error
+			if narrative, err = templateNarrative(ft.Narrative, orchDisplay, phases, latency, displayName); err != nil {
+				slog.Warn("could not apply flow template", "phase", "flows", "template", ft.Name, "err", err)
 			}
 		}
-		// Any targets not matched by phases.
-		for _, t := range matchedTargets {
-			key := strings.ToLower(matchedOrch) + "->" + strings.ToLower(t.to)
-			if !usedEdges[key] {
-				label := operationLabel(t.link)
-				diagram.WriteString(fmt.Sprintf("    %s->>%s: %s\n", orchDisplay, displayName(t.to), label))
-				markEdge(matchedOrch, t.to)
-			}
+		if narrative == "" {
+			narrative = g.flowNarrative(ft.Name, orchDisplay, phases, displayName)
 		}
 
 		flows = append(flows, FlowInfo{
-			Name:      nf.flowName,
-			Narrative: nf.narrative(orchDisplay, allTargets),
+			Name:      ft.Name,
+			Narrative: narrative,
 			Services:  svcList,
-			Diagram:   diagram.String(),
+			Diagram:   phasedDiagram(orchDisplay, phases, displayName),
+			Latency:   latency,
 		})
 	}
 
@@ -1635,16 +2394,14 @@ Admin users can create, update, and delete trip schedules for both high-speed an
 		}
 	}
 	sort.Slice(remaining, func(i, j int) bool {
-		return len(remaining[i].targets) > len(remaining[j].targets)
+		if len(remaining[i].targets) != len(remaining[j].targets) {
+			return len(remaining[i].targets) > len(remaining[j].targets)
+		}
+		return remaining[i].name < remaining[j].name
 	})
 
 	for _, orch := range remaining {
 		orchDisplay := displayName(orch.name)
-		var directTargets []string
-		for _, t := range orch.targets {
-			directTargets = append(directTargets, displayName(t.to))
-		}
-
 		svcSet := make(map[string]bool)
 		svcSet[orchDisplay] = true
 		for _, t := range orch.targets {
@@ -1656,30 +2413,47 @@ Admin users can create, update, and delete trip schedules for both high-speed an
 		}
 		sort.Strings(svcList)
 
-		narrative := fmt.Sprintf("%s coordinates with %d services: %s.",
-			orchDisplay, len(orch.targets), strings.Join(directTargets, ", "))
-
+		steps := g.callOrder(orchDisplay, orch.targets)
+		calls := stepCalls(steps)
 		var diagram strings.Builder
 		diagram.WriteString("sequenceDiagram\n")
 		diagram.WriteString(fmt.Sprintf("    participant %s\n", orchDisplay))
-		for _, t := range orch.targets {
+		for _, t := range calls {
 			diagram.WriteString(fmt.Sprintf("    participant %s\n", displayName(t.to)))
-		}
-		for _, t := range orch.targets {
-			label := operationLabel(t.link)
-			diagram.WriteString(fmt.Sprintf("    %s->>%s: %s\n", orchDisplay, displayName(t.to), label))
 			markEdge(orch.name, t.to)
 		}
+		writeCallSteps(&diagram, "    ", orchDisplay, steps, displayName, nil)
 
 		flowName := orchDisplay + " Interactions"
 		flows = append(flows, FlowInfo{
 			Name:      flowName,
-			Narrative: narrative,
+			Narrative: g.flowNarrative(flowName, orchDisplay, []phaseCalls{{calls: calls}}, displayName),
 			Services:  svcList,
 			Diagram:   diagram.String(),
+			Latency:   g.orchestratorLatency(orch.name, orch.targets, displayName),
 		})
 	}
 
+	// --- Entry point flows ---
+	// Follow calls from each entry point, skipping journeys an
+	// orchestrator flow already shows.
+	g.entryPoints = g.detectEntryPoints()
+	for _, e := range g.entryPoints {
+		f, hops, ok := entryFlow(e, adj, displayName)
+		if !ok || sameServices(f.Services, flows) {
+			continue
+		}
+		f.Latency = &LatencyModel{}
+		for i, hop := range hops {
+			for _, c := range hop {
+				markEdge(c.link.FromRepo, c.to)
+			}
+			f.Latency.Phases = append(f.Latency.Phases, g.latencyPhase(fmt.Sprintf("Hop %d", i+1), true, hop, displayName))
+		}
+		g.setBudget(f.Latency, e.Service, 0)
+		flows = append(flows, f)
+	}
+
 	// --- Remaining edges ---
 	var remainingEdges []LinkInfo
 	for _, link := range g.Links {
@@ -1722,7 +2496,7 @@ Admin users can create, update, and delete trip schedules for both high-speed an
 		}
 
 		flows = append(flows, FlowInfo{
-			Name:      "Supporting Service Calls",
+			Name:      supportingFlowName,
 			Narrative: "Additional service-to-service interactions that support the main user flows:\n\n- " + strings.Join(narrativeParts, "\n- "),
 			Services:  svcList,
 			Diagram:   diagram.String(),
@@ -1731,6 +2505,7 @@ Admin users can create, update, and delete trip schedules for both high-speed an
 
 	// Replace the LLM flows with synthesized ones.
 	g.Flows = flows
+	g.saveNarratives()
 }
 
 // writeArchitecturalPatterns adds an "Architectural Patterns" section to the system overview
@@ -1862,21 +2637,22 @@ func (g *CentralSiteGenerator) writeArchitecturalPatterns(b *strings.Builder) {
 		b.WriteString("\n")
 		b.WriteString("**Performance note:** Orchestrator services are the primary latency bottleneck. ")
 		b.WriteString("With synchronous HTTP calls, the critical path length equals the number of sequential hops × per-hop latency. ")
-		b.WriteString("At 50ms per HTTP hop:\n\n")
+		hopMs := int(g.hopLatency() / time.Millisecond)
+		b.WriteString(fmt.Sprintf("At %dms per HTTP hop:\n\n", hopMs))
 		for _, orch := range orchestrators {
 			lower := strings.ToLower(orch.name)
 			deps := outbound[lower]
-			criticalMs := len(deps) * 50
+			criticalMs := len(deps) * hopMs
 			b.WriteString(fmt.Sprintf("- **%s**: %d calls → worst-case %dms critical path (if all sequential). ",
 				orch.name, len(deps), criticalMs))
 			// Identify which calls could be parallel
 			parallelizable, sequential := g.analyzeCallParallelism(lower, deps, outbound)
 			if len(parallelizable) > 0 && len(sequential) > 0 {
-				seqMs := len(sequential) * 50
+				seqMs := len(sequential) * hopMs
 				b.WriteString(fmt.Sprintf("Sequential calls (~%d): %s. Parallelizable calls (~%d): %s. Optimized critical path: ~%dms.\n",
 					len(sequential), strings.Join(sequential, ", "),
 					len(parallelizable), strings.Join(parallelizable, ", "),
-					seqMs+50))
+					seqMs+hopMs))
 			}
 			b.WriteString("\n")
 		}
@@ -0,0 +1,57 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChangeInfo is one architecture change for the changelog page.
+type ChangeInfo struct {
+	Date      time.Time
+	Summary   string   // e.g. "order-service added dependency on fraud-service (POST /api/score)"
+	Services  []string // services the change involves
+	CommitSHA string
+	CommitURL string // link to the commit or PR that introduced the change, if known
+}
+
+// writeChangelogPage creates changelog.md listing architecture changes,
+// newest first, grouped by the week (starting Monday) they happened in.
+func (g *CentralSiteGenerator) writeChangelogPage(stagingDir string) error {
+	var b strings.Builder
+
+	b.WriteString("# Architecture Changelog\n\n")
+	b.WriteString("Services and cross-service dependencies that were added, removed, or changed, as detected each time a repository was synced.\n\n")
+
+	week := ""
+	for _, c := range g.Changes {
+		date := c.Date.UTC()
+		start := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		if w := start.Format("2006-01-02"); w != week {
+			if week != "" {
+				b.WriteString("\n")
+			}
+			week = w
+			b.WriteString(fmt.Sprintf("## Week of %s\n\n", week))
+		}
+
+		b.WriteString(fmt.Sprintf("- **%s**: %s", date.Format("2006-01-02"), c.Summary))
+		if c.CommitSHA != "" {
+			short := c.CommitSHA
+			if len(short) > 7 {
+				short = short[:7]
+			}
+			if c.CommitURL != "" {
+				b.WriteString(fmt.Sprintf(" ([`%s`](%s))", short, c.CommitURL))
+			} else {
+				b.WriteString(fmt.Sprintf(" (`%s`)", short))
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	return os.WriteFile(filepath.Join(stagingDir, "changelog.md"), []byte(b.String()), 0o644)
+}
@@ -0,0 +1,480 @@
+package site
+
+import "sort"
+
+// serviceMapCluster is a group of services a large service map draws
+// together and can collapse into one node.
+type serviceMapCluster struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"` // "domain" or "team"
+}
+
+// serviceMapClusters assigns the map's services to clusters, setting each
+// node's Cluster: its domain or, for services without one, the first team
+// that owns it. External dependencies stay unclustered. With fewer than two
+// clusters there is nothing to group, so it returns nil and leaves the
+// nodes alone.
+func (g *CentralSiteGenerator) serviceMapClusters(nodes []serviceMapNode) []serviceMapCluster {
+	owner := make(map[string]TeamInfo)
+	for _, t := range g.Teams {
+		for _, s := range t.Services {
+			if _, ok := owner[s]; !ok {
+				owner[s] = t
+			}
+		}
+	}
+	byID := make(map[string]serviceMapCluster)
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		var c serviceMapCluster
+		switch t, owned := owner[n.ID]; {
+		case n.Domain != "":
+			c = serviceMapCluster{ID: "domain:" + n.Domain, Label: groupTitle(n.Domain), Kind: "domain"}
+		case owned:
+			label := t.DisplayName
+			if label == "" {
+				label = t.Name
+			}
+			c = serviceMapCluster{ID: "team:" + t.Name, Label: label, Kind: "team"}
+		default:
+			continue
+		}
+		ids[i] = c.ID
+		byID[c.ID] = c
+	}
+	if len(byID) < 2 {
+		return nil
+	}
+	for i := range nodes {
+		nodes[i].Cluster = ids[i]
+	}
+	out := make([]serviceMapCluster, 0, len(byID))
+	for _, c := range byID {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// serviceMapScaleCSS styles the service map's clusters and minimap.
+const serviceMapScaleCSS = `.hull{fill:var(--ac);fill-opacity:0.06;stroke:var(--ac);stroke-opacity:0.35;stroke-width:1.5;cursor:pointer}
+.hull-label{fill:var(--tx2);font-size:12px;font-weight:600;text-anchor:middle;cursor:pointer}
+.cluster-node circle{stroke-width:2;stroke-dasharray:4 3}
+.edge.agg{stroke-opacity:0.8;cursor:pointer}
+#graph-gl,#graph-gl-labels{position:absolute;inset:0;width:100%;height:100%}
+#graph-gl-labels{pointer-events:none}
+#minimap{position:fixed;right:16px;bottom:16px;width:200px;height:140px;background:var(--bg2);border:1px solid var(--bd);border-radius:8px;z-index:10;cursor:crosshair}
+`
+
+// serviceMapScaleJS keeps large service maps readable. Past 50 services it
+// starts with the clusters collapsed, bundles the edges between clusters
+// through their centres, and shows a minimap; past 500 it draws with WebGL
+// instead of SVG when the browser supports it.
+// It runs at the end of the map's script, whose variables it shares, and
+// draws the filters' verdict (nodeState, edgeState) and the route
+// (onRoute) on top of the clusters' state.
+const serviceMapScaleJS = `
+// ===== Large maps: clusters, edge bundling, minimap, WebGL =====
+var LARGE = 50, HUGE = 500;
+var large = data.nodes.length > LARGE;
+var clusters = data.clusters || [];
+var clusterOf = {}, members = {}, centroids = {}, nodeById = {};
+clusters.forEach(function(c){ members[c.id] = []; });
+data.nodes.forEach(function(n){
+  nodeById[n.id] = n;
+  if(n.cluster && members[n.cluster]){ clusterOf[n.id] = n.cluster; members[n.cluster].push(n); }
+});
+var clustered = large && clusters.length > 0, bundled = clustered, collapsed = {};
+if(clustered) clusters.forEach(function(c){ collapsed[c.id] = true; });
+
+function isCollapsed(id){ return clustered && !!collapsed[clusterOf[id]]; }
+function endpointKey(id){ return isCollapsed(id) ? 'cluster:' + clusterOf[id] : id; }
+function position(key){ return key.indexOf('cluster:') === 0 ? centroids[key.slice(8)] : nodeById[key]; }
+function crossesClusters(d){
+  var a = clusterOf[endpointId(d.source)], b = clusterOf[endpointId(d.target)];
+  return clustered && (a || b) && a !== b;
+}
+function anyMember(c, state){ return members[c.id].some(function(n){ return state ? nodeState[n.id] === state : !!nodeState[n.id]; }); }
+
+function updateCentroids(){
+  clusters.forEach(function(c){
+    var ms = members[c.id], x = 0, y = 0, r = 0;
+    ms.forEach(function(n){ x += n.x; y += n.y; });
+    x /= ms.length || 1; y /= ms.length || 1;
+    ms.forEach(function(n){ r = Math.max(r, Math.hypot(n.x - x, n.y - y)); });
+    centroids[c.id] = {x: x, y: y, r: r};
+  });
+}
+// Pull each cluster's services together.
+function clusterForce(alpha){
+  updateCentroids();
+  data.nodes.forEach(function(n){
+    var c = centroids[clusterOf[n.id]];
+    if(!c) return;
+    n.vx -= (n.x - c.x) * alpha * 0.2;
+    n.vy -= (n.y - c.y) * alpha * 0.2;
+  });
+}
+
+// Hulls around expanded clusters; click one to collapse it.
+var hullG = container.insert('g', ':first-child');
+var hullEls = hullG.selectAll('path').data(clusters).join('path').attr('class', 'hull')
+  .on('click', function(e, c){ toggleCluster(c.id); });
+var hullLabelEls = hullG.selectAll('text').data(clusters).join('text').attr('class', 'hull-label')
+  .text(function(c){ return c.label + ' ▾'; })
+  .on('click', function(e, c){ toggleCluster(c.id); });
+var hullLine = d3.line().curve(d3.curveCatmullRomClosed.alpha(0.5));
+function hullLabelY(c){ return d3.min(members[c.id], function(n){ return n.y; }) - 48; }
+function hullPath(c){
+  var pts = [], pad = 40;
+  members[c.id].forEach(function(n){
+    if(nodeState[n.id]) pts.push([n.x - pad, n.y - pad], [n.x + pad, n.y - pad], [n.x - pad, n.y + pad], [n.x + pad, n.y + pad]);
+  });
+  var hull = d3.polygonHull(pts);
+  return hull ? hullLine(hull) : null;
+}
+
+// Edges into collapsed clusters, merged per pair of endpoints.
+var aggG = container.insert('g', function(){ return nodeG.node(); });
+var aggEdges = [], aggEls = aggG.selectAll('path'), aggLabelEls = aggG.selectAll('text');
+
+// Collapsed clusters; click one to expand it.
+var clusterColor = {};
+clusters.forEach(function(c, i){ clusterColor[c.id] = serviceColors[i % serviceColors.length]; });
+var clusterG = container.append('g');
+var clusterEls = clusterG.selectAll('g').data(clusters).join('g').attr('class', 'cluster-node').attr('cursor', 'pointer')
+  .on('click', function(e, c){ toggleCluster(c.id); })
+  .on('mouseover', function(e, c){ hoverCluster(c); }).on('mousemove', moveTooltip).on('mouseout', onHoverOut);
+clusterEls.append('circle')
+  .attr('r', function(c){ return clusterRadius(c); })
+  .attr('fill', function(c){ return clusterColor[c.id]; })
+  .attr('stroke', function(c){ return d3.color(clusterColor[c.id]).darker(0.5).toString(); });
+clusterEls.append('text').attr('class', 'node-label').attr('dy', 4)
+  .text(function(c){ return c.label + ' (' + members[c.id].length + ')'; });
+function clusterRadius(c){ return 26 + 4 * Math.sqrt(members[c.id].length); }
+function hoverCluster(c){
+  tooltip.innerHTML = '<h3>' + c.label + '</h3><p><span class="badge">' + c.kind + '</span> <span class="badge">' + members[c.id].length + ' services</span></p><p>Click to ' + (collapsed[c.id] ? 'expand' : 'collapse') + '</p>';
+  tooltip.classList.remove('hidden');
+}
+
+function toggleCluster(id){
+  collapsed[id] = !collapsed[id];
+  applyVisibility();
+  sim.alpha(0.3).restart();
+}
+
+// Toolbar: clustering, collapse all, and bundling.
+var clusterBtn = document.getElementById('cluster-btn');
+var collapseBtn = document.getElementById('collapse-btn');
+var bundleBtn = document.getElementById('bundle-btn');
+if(clusters.length){
+  clusterBtn.hidden = false;
+  clusterBtn.onclick = function(){
+    clustered = !clustered;
+    bundled = clustered;
+    applyVisibility();
+    sim.alpha(0.5).restart();
+  };
+  collapseBtn.onclick = function(){
+    var expand = clusters.some(function(c){ return collapsed[c.id]; });
+    clusters.forEach(function(c){ collapsed[c.id] = !expand; });
+    applyVisibility();
+    sim.alpha(0.3).restart();
+  };
+  bundleBtn.onclick = function(){ bundled = !bundled; applyVisibility(); };
+}
+
+// applyVisibility draws the filters' verdict with collapsed clusters
+// standing in for their services.
+function applyVisibility(){
+  function nodeDisplay(d){ return (nodeState[d.id] || onRoute && onRoute.nodes[d.id]) && !isCollapsed(d.id) ? null : 'none'; }
+  function nodeOpacity(d){ return nodeState[d.id] === 'shown' ? null : 0.35; }
+  function edgeShownNow(d){ return (edgeState(d) || onRoute && onRoute.edges.indexOf(d) >= 0) && !isCollapsed(endpointId(d.source)) && !isCollapsed(endpointId(d.target)); }
+  nodeEls.style('display', nodeDisplay).style('opacity', nodeOpacity);
+  labelEls.style('display', nodeDisplay).style('opacity', nodeOpacity);
+  edgeEls.style('display', function(d){ return edgeShownNow(d) ? null : 'none'; });
+  edgeLabelEls.style('display', function(d){ return edgeShownNow(d) && !(bundled && crossesClusters(d)) ? null : 'none'; });
+
+  var byKey = {};
+  aggEdges = [];
+  data.edges.forEach(function(d){
+    var s = endpointId(d.source), t = endpointId(d.target);
+    if(!edgeState(d) || (!isCollapsed(s) && !isCollapsed(t))) return;
+    var a = endpointKey(s), b = endpointKey(t);
+    if(a === b) return;
+    if(!byKey[a + '>' + b]){ byKey[a + '>' + b] = {source: a, target: b, links: []}; aggEdges.push(byKey[a + '>' + b]); }
+    byKey[a + '>' + b].links.push(d);
+  });
+  aggEls = aggG.selectAll('path').data(aggEdges).join('path').attr('class', 'edge agg')
+    .attr('stroke-width', function(a){ return Math.min(2 + Math.sqrt(a.links.length) * 1.5, 12); })
+    .on('mouseover', function(e, a){
+      var html = '<h3>' + endpointLabel(a.source) + ' → ' + endpointLabel(a.target) + '</h3><p><span class="badge">' + a.links.length + ' links</span></p>';
+      a.links.slice(0, 12).forEach(function(d){ html += '<p>' + d.source.label + ' → ' + d.target.label + (d.linkType ? ' <span class="badge">' + d.linkType + '</span>' : '') + '</p>'; });
+      if(a.links.length > 12) html += '<p>…</p>';
+      tooltip.innerHTML = html;
+      tooltip.classList.remove('hidden');
+    })
+    .on('mousemove', moveTooltip).on('mouseout', onHoverOut);
+  aggLabelEls = aggG.selectAll('text').data(aggEdges).join('text').attr('class', 'edge-label')
+    .text(function(a){ return a.links.length > 1 ? a.links.length + ' links' : a.links[0].linkType || ''; });
+  clusterEls.style('display', function(c){ return clustered && collapsed[c.id] && anyMember(c) ? null : 'none'; })
+    .style('opacity', function(c){ return anyMember(c, 'shown') ? null : 0.35; });
+  hullEls.style('display', function(c){ return clustered && !collapsed[c.id] && anyMember(c) ? null : 'none'; });
+  hullLabelEls.style('display', function(c){ return clustered && !collapsed[c.id] && anyMember(c) ? null : 'none'; });
+  // Route mode dims everything off the route.
+  function offRoute(d){ return !!onRoute && !onRoute.nodes[d.id]; }
+  function edgeOffRoute(d){ return !!onRoute && onRoute.edges.indexOf(d) < 0; }
+  nodeEls.classed('off-route', offRoute);
+  labelEls.classed('off-route', offRoute);
+  edgeEls.classed('off-route', edgeOffRoute).classed('on-route', function(d){ return !!onRoute && !edgeOffRoute(d); });
+  edgeLabelEls.classed('off-route', edgeOffRoute);
+  [aggEls, aggLabelEls, clusterEls, hullEls, hullLabelEls].forEach(function(els){ els.classed('off-route', !!onRoute); });
+
+  sim.force('cluster', clustered ? clusterForce : null);
+  clusterBtn.textContent = clustered ? 'Ungroup' : 'Group by ' + (clusters.some(function(c){ return c.kind === 'domain'; }) ? 'domain' : 'team');
+  collapseBtn.hidden = bundleBtn.hidden = !clustered;
+  collapseBtn.textContent = clusters.some(function(c){ return collapsed[c.id]; }) ? 'Expand all' : 'Collapse all';
+  bundleBtn.textContent = bundled ? 'Unbundle edges' : 'Bundle edges';
+  draw();
+  viewChanged();
+}
+function endpointLabel(key){
+  if(key.indexOf('cluster:') !== 0) return nodeById[key].label;
+  var id = key.slice(8);
+  return clusters.filter(function(c){ return c.id === id; })[0].label;
+}
+
+// Edges between clusters are bundled through the clusters' centres.
+var bundleLine = d3.line().curve(d3.curveBundle.beta(0.85));
+function edgePoints(d){
+  var s = d.source, t = d.target, pts = [[s.x, s.y]];
+  if(bundled && crossesClusters(d)){
+    var a = centroids[clusterOf[s.id]], b = centroids[clusterOf[t.id]];
+    if(a) pts.push([a.x, a.y]);
+    if(b) pts.push([b.x, b.y]);
+  }
+  pts.push([t.x, t.y]);
+  return pts;
+}
+function edgeMid(d){
+  var pts = edgePoints(d), a = pts[Math.floor((pts.length - 1) / 2)], b = pts[Math.ceil((pts.length - 1) / 2)];
+  return [(a[0] + b[0]) / 2, (a[1] + b[1]) / 2];
+}
+
+function draw(){
+  if(clusters.length) updateCentroids();
+  scheduleMinimap();
+  if(gl){ drawGL(); return; }
+  edgeEls.attr('d', function(d){ var pts = edgePoints(d); return pts.length > 2 ? bundleLine(pts) : 'M' + pts[0] + 'L' + pts[1]; });
+  edgeLabelEls.attr('x', function(d){ return edgeMid(d)[0]; }).attr('y', function(d){ return edgeMid(d)[1] - 6; });
+  nodeEls
+    .attr('x', function(d){ return d.x - sizeScale(d.fileCount); })
+    .attr('y', function(d){ return d.y - sizeScale(d.fileCount) * 0.6; });
+  labelEls.attr('x', function(d){ return d.x; }).attr('y', function(d){ return d.y; });
+  if(!clustered) return;
+  hullEls.attr('d', hullPath);
+  hullLabelEls
+    .attr('x', function(c){ return centroids[c.id].x; })
+    .attr('y', hullLabelY);
+  clusterEls.attr('transform', function(c){ return 'translate(' + centroids[c.id].x + ',' + centroids[c.id].y + ')'; });
+  aggEls.attr('d', function(a){ var p = position(a.source), q = position(a.target); return 'M' + p.x + ',' + p.y + 'L' + q.x + ',' + q.y; });
+  aggLabelEls
+    .attr('x', function(a){ return (position(a.source).x + position(a.target).x) / 2; })
+    .attr('y', function(a){ return (position(a.source).y + position(a.target).y) / 2 - 6; });
+}
+sim.on('tick', draw);
+function redraw(){ if(gl) drawGL(); }
+
+// Minimap: every shown service, and the part of the map in view; click or
+// drag to move the view.
+var minimap = document.getElementById('minimap');
+var zoomTarget = svgEl, miniView = null, miniPending = false;
+minimap.hidden = !large;
+function scheduleMinimap(){
+  if(minimap.hidden || miniPending) return;
+  miniPending = true;
+  requestAnimationFrame(drawMinimap);
+}
+function drawMinimap(){
+  miniPending = false;
+  var ratio = window.devicePixelRatio || 1, w = minimap.clientWidth, h = minimap.clientHeight;
+  if(minimap.width !== w * ratio){ minimap.width = w * ratio; minimap.height = h * ratio; }
+  var ctx = minimap.getContext('2d');
+  ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+  ctx.clearRect(0, 0, w, h);
+  var xs = d3.extent(data.nodes, function(d){ return d.x; }), ys = d3.extent(data.nodes, function(d){ return d.y; });
+  var pad = 60, gw = xs[1] - xs[0] + 2 * pad, gh = ys[1] - ys[0] + 2 * pad, k = Math.min(w / gw, h / gh);
+  miniView = {k: k, ox: (w - gw * k) / 2 - (xs[0] - pad) * k, oy: (h - gh * k) / 2 - (ys[0] - pad) * k};
+  data.nodes.forEach(function(n){
+    if(!nodeState[n.id]) return;
+    ctx.fillStyle = clustered && clusterOf[n.id] ? clusterColor[clusterOf[n.id]] : colorMap[n.id];
+    ctx.fillRect(n.x * k + miniView.ox - 1.5, n.y * k + miniView.oy - 1.5, 3, 3);
+  });
+  var t = d3.zoomTransform(zoomTarget);
+  ctx.strokeStyle = getComputedStyle(document.body).getPropertyValue('--ac');
+  ctx.lineWidth = 1.5;
+  ctx.strokeRect(-t.x / t.k * k + miniView.ox, -t.y / t.k * k + miniView.oy, width / t.k * k, height / t.k * k);
+}
+function miniPan(e){
+  if(!miniView) return;
+  var r = minimap.getBoundingClientRect();
+  d3.select(zoomTarget).call(zoom.translateTo, (e.clientX - r.left - miniView.ox) / miniView.k, (e.clientY - r.top - miniView.oy) / miniView.k);
+}
+minimap.addEventListener('mousedown', function(e){
+  e.preventDefault();
+  miniPan(e);
+  function up(){ window.removeEventListener('mousemove', miniPan); window.removeEventListener('mouseup', up); }
+  window.addEventListener('mousemove', miniPan);
+  window.addEventListener('mouseup', up);
+});
+zoom.on('zoom.scale', function(){ scheduleMinimap(); redraw(); });
+
+// WebGL: past HUGE services, draw edges as lines and services as points,
+// with labels once zoomed in. Without WebGL the SVG map stays.
+var gl = null, glCanvas, glLabels, glProgram, glBuffer;
+if(data.nodes.length > HUGE) initGL();
+function initGL(){
+  var canvas = document.createElement('canvas');
+  canvas.id = 'graph-gl';
+  var ctx = canvas.getContext('webgl', {antialias: true, premultipliedAlpha: false});
+  if(!ctx) return;
+  function shader(type, src){
+    var s = ctx.createShader(type);
+    ctx.shaderSource(s, src);
+    ctx.compileShader(s);
+    return s;
+  }
+  var program = ctx.createProgram();
+  ctx.attachShader(program, shader(ctx.VERTEX_SHADER,
+    'attribute vec2 a_pos; attribute vec4 a_color; attribute float a_size;' +
+    'uniform vec2 u_res; uniform vec3 u_view; uniform float u_ratio; varying vec4 v_color;' +
+    'void main(){ vec2 p = (a_pos * u_view.x + u_view.yz) / u_res * 2.0 - 1.0;' +
+    ' gl_Position = vec4(p.x, -p.y, 0.0, 1.0); gl_PointSize = a_size * u_view.x * u_ratio; v_color = a_color; }'));
+  ctx.attachShader(program, shader(ctx.FRAGMENT_SHADER,
+    'precision mediump float; varying vec4 v_color; uniform bool u_points;' +
+    'void main(){ if(u_points){ vec2 c = gl_PointCoord - 0.5; if(dot(c, c) > 0.25) discard; } gl_FragColor = v_color; }'));
+  ctx.linkProgram(program);
+  if(!ctx.getProgramParameter(program, ctx.LINK_STATUS)) return;
+
+  var graphContainer = document.getElementById('graph-container');
+  graphContainer.appendChild(canvas);
+  glLabels = document.createElement('canvas');
+  glLabels.id = 'graph-gl-labels';
+  graphContainer.appendChild(glLabels);
+  container.style('display', 'none');
+  gl = ctx; glCanvas = canvas; glProgram = program; glBuffer = ctx.createBuffer();
+  zoomTarget = canvas;
+  d3.select(canvas).call(zoom);
+
+  canvas.addEventListener('mousemove', function(e){
+    var hit = glHit(e);
+    canvas.style.cursor = hit ? 'pointer' : '';
+    if(hit && hit.node){ onHover(e, hit.node); moveTooltip(e); }
+    else if(hit){ hoverCluster(hit.cluster); moveTooltip(e); }
+    else onHoverOut();
+  });
+  canvas.addEventListener('click', function(e){
+    var hit = glHit(e);
+    if(hit && hit.node) onClick(e, hit.node);
+    else if(hit) toggleCluster(hit.cluster.id);
+  });
+  themeBtn.addEventListener('click', drawGL);
+  document.getElementById('stats').textContent += ' · WebGL';
+  statsText += ' · WebGL';
+}
+function glColor(c, alpha){ var rgb = d3.color(c).rgb(); return [rgb.r / 255, rgb.g / 255, rgb.b / 255, alpha]; }
+function nodeSize(d){ return sizeScale(d.fileCount) * 1.2; }
+// glHit finds the service or cluster under the pointer: a shown service,
+// a collapsed cluster, or an expanded cluster's label.
+function glHit(e){
+  var t = d3.zoomTransform(glCanvas), r = glCanvas.getBoundingClientRect();
+  var x = (e.clientX - r.left - t.x) / t.k, y = (e.clientY - r.top - t.y) / t.k, best = null, bestD = Infinity;
+  data.nodes.forEach(function(n){
+    if(!nodeState[n.id] || isCollapsed(n.id)) return;
+    var d = Math.hypot(n.x - x, n.y - y);
+    if(d < nodeSize(n) / 2 + 4 / t.k && d < bestD){ best = {node: n}; bestD = d; }
+  });
+  if(clustered) clusters.forEach(function(c){
+    var p = centroids[c.id];
+    if(!anyMember(c)) return;
+    if(collapsed[c.id]){
+      var d = Math.hypot(p.x - x, p.y - y);
+      if(d < clusterRadius(c) && d < bestD){ best = {cluster: c}; bestD = d; }
+    } else if(Math.abs(x - p.x) < 60 && Math.abs(y - hullLabelY(c) + 4) < 10){
+      best = {cluster: c}; bestD = 0;
+    }
+  });
+  return best;
+}
+function drawGL(){
+  var ratio = window.devicePixelRatio || 1, w = glCanvas.clientWidth, h = glCanvas.clientHeight;
+  [glCanvas, glLabels].forEach(function(c){ if(c.width !== w * ratio || c.height !== h * ratio){ c.width = w * ratio; c.height = h * ratio; } });
+  var t = d3.zoomTransform(glCanvas), style = getComputedStyle(document.body);
+  var edgeColor = style.getPropertyValue('--tx2').trim() || '#8b949e';
+  var lines = [], points = [];
+  function vertex(out, x, y, color, size){ out.push(x, y, color[0], color[1], color[2], color[3], size); }
+  var accent = style.getPropertyValue('--ac').trim() || '#58a6ff';
+  data.edges.forEach(function(d){
+    var routed = onRoute && onRoute.edges.indexOf(d) >= 0;
+    if(!(edgeState(d) || routed) || isCollapsed(d.source.id) || isCollapsed(d.target.id)) return;
+    var c = routed ? glColor(accent, 1) : glColor(d.metrics ? errorColor(d.metrics.errorRate) : edgeColor, onRoute ? 0.06 : 0.5), pts = edgePoints(d);
+    for(var i = 1; i < pts.length; i++){ vertex(lines, pts[i-1][0], pts[i-1][1], c, 0); vertex(lines, pts[i][0], pts[i][1], c, 0); }
+  });
+  aggEdges.forEach(function(a){
+    var p = position(a.source), q = position(a.target), c = glColor(edgeColor, onRoute ? 0.1 : 0.8);
+    vertex(lines, p.x, p.y, c, 0); vertex(lines, q.x, q.y, c, 0);
+  });
+  data.nodes.forEach(function(n){
+    var routed = onRoute && onRoute.nodes[n.id];
+    if(!(nodeState[n.id] || routed) || isCollapsed(n.id)) return;
+    vertex(points, n.x, n.y, glColor(colorMap[n.id], onRoute ? (routed ? 1 : 0.12) : nodeState[n.id] === 'shown' ? 1 : 0.35), nodeSize(n));
+  });
+  if(clustered) clusters.forEach(function(c){
+    if(collapsed[c.id] && anyMember(c)) vertex(points, centroids[c.id].x, centroids[c.id].y, glColor(clusterColor[c.id], anyMember(c, 'shown') ? 1 : 0.35), clusterRadius(c) * 2);
+  });
+
+  gl.viewport(0, 0, glCanvas.width, glCanvas.height);
+  gl.clearColor(0, 0, 0, 0);
+  gl.clear(gl.COLOR_BUFFER_BIT);
+  gl.enable(gl.BLEND);
+  gl.blendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA);
+  gl.useProgram(glProgram);
+  gl.uniform2f(gl.getUniformLocation(glProgram, 'u_res'), w, h);
+  gl.uniform3f(gl.getUniformLocation(glProgram, 'u_view'), t.k, t.x, t.y);
+  gl.uniform1f(gl.getUniformLocation(glProgram, 'u_ratio'), ratio);
+  gl.bindBuffer(gl.ARRAY_BUFFER, glBuffer);
+  var stride = 7 * 4;
+  [['a_pos', 2, 0], ['a_color', 4, 2], ['a_size', 1, 6]].forEach(function(a){
+    var loc = gl.getAttribLocation(glProgram, a[0]);
+    gl.enableVertexAttribArray(loc);
+    gl.vertexAttribPointer(loc, a[1], gl.FLOAT, false, stride, a[2] * 4);
+  });
+  [[lines, gl.LINES, false], [points, gl.POINTS, true]].forEach(function(batch){
+    if(!batch[0].length) return;
+    gl.uniform1i(gl.getUniformLocation(glProgram, 'u_points'), batch[2] ? 1 : 0);
+    gl.bufferData(gl.ARRAY_BUFFER, new Float32Array(batch[0]), gl.DYNAMIC_DRAW);
+    gl.drawArrays(batch[1], 0, batch[0].length / 7);
+  });
+
+  // Labels: clusters always, services once zoomed in far enough to read.
+  var ctx = glLabels.getContext('2d');
+  ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+  ctx.clearRect(0, 0, w, h);
+  ctx.textAlign = 'center';
+  ctx.fillStyle = style.getPropertyValue('--tx').trim() || '#e6edf3';
+  ctx.font = '600 12px sans-serif';
+  if(t.k >= 1.2) data.nodes.forEach(function(n){
+    var x = n.x * t.k + t.x, y = n.y * t.k + t.y;
+    if(nodeState[n.id] && !isCollapsed(n.id) && x > -50 && x < w + 50 && y > -20 && y < h + 20) ctx.fillText(n.label, x, y + 4);
+  });
+  if(clustered) clusters.forEach(function(c){
+    if(!anyMember(c)) return;
+    var p = centroids[c.id], label = collapsed[c.id] ? c.label + ' (' + members[c.id].length + ')' : c.label + ' ▾';
+    ctx.fillText(label, p.x * t.k + t.x, (collapsed[c.id] ? p.y + 4 : hullLabelY(c)) * t.k + t.y);
+  });
+}
+
+if(groupFilter.value || envFilter.value) applyGroupFilter();
+else applyVisibility();
+`
@@ -0,0 +1,170 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// configKnob is one piece of configuration a service reads, and everywhere
+// it is read.
+type configKnob struct {
+	Kind     string // an indexer.Knob* kind
+	Name     string
+	Defaults []string // the distinct defaults the source gives, in order
+	Evidence string   // how it is first read
+	Sources  []string // file:line, in order
+}
+
+// knobSections order and title the Configuration page's sections.
+var knobSections = []struct{ kind, heading, column, where string }{
+	{indexer.KnobEnv, "Environment Variables", "Variable", "Read In"},
+	{indexer.KnobFlag, "Command-Line Flags", "Flag", "Defined In"},
+	{indexer.KnobSetting, "Config Keys", "Key", "Read In"},
+	{indexer.KnobFeature, "Feature Flags", "Flag", "Checked In"},
+}
+
+// secretName matches the names of settings whose defaults aren't shown.
+var secretName = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private|api_?key|access_?key)`)
+
+// loadConfiguration finds the environment variables, flags, config keys,
+// and feature flags each repo's source reads, by repo name.
+func (g *CentralSiteGenerator) loadConfiguration() map[string][]configKnob {
+	loaded := make([][]configKnob, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		byKey := make(map[string]int)
+		for _, f := range g.repoScan(g.Repos[i]) {
+			for _, k := range f.Config {
+				key := k.Kind + "\x00" + k.Name
+				j, ok := byKey[key]
+				if !ok {
+					j = len(loaded[i])
+					byKey[key] = j
+					loaded[i] = append(loaded[i], configKnob{Kind: k.Kind, Name: k.Name, Evidence: k.Evidence})
+				}
+				knob := &loaded[i][j]
+				if k.Default != "" && !slices.Contains(knob.Defaults, k.Default) {
+					knob.Defaults = append(knob.Defaults, k.Default)
+				}
+				if source := fmt.Sprintf("%s:%d", f.Path, k.Line); !slices.Contains(knob.Sources, source) {
+					knob.Sources = append(knob.Sources, source)
+				}
+			}
+		}
+		sort.SliceStable(loaded[i], func(a, b int) bool { return loaded[i][a].Name < loaded[i][b].Name })
+	})
+	config := make(map[string][]configKnob)
+	for i, r := range g.Repos {
+		if len(loaded[i]) > 0 {
+			config[r.Name] = loaded[i]
+		}
+	}
+	return config
+}
+
+// configSummary says how much configuration a service reads, e.g. "6
+// environment variables, 2 command-line flags and 1 feature flag".
+func configSummary(knobs []configKnob) string {
+	nouns := map[string][2]string{
+		indexer.KnobEnv:     {"environment variable", "environment variables"},
+		indexer.KnobFlag:    {"command-line flag", "command-line flags"},
+		indexer.KnobSetting: {"config key", "config keys"},
+		indexer.KnobFeature: {"feature flag", "feature flags"},
+	}
+	var parts []string
+	for _, s := range knobSections {
+		n := 0
+		for _, k := range knobs {
+			if k.Kind == s.kind {
+				n++
+			}
+		}
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+nouns[s.kind][0])
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %s", n, nouns[s.kind][1]))
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// writeServiceConfiguration writes a repo's configuration.md, unless its
+// docs already have one.
+func (g *CentralSiteGenerator) writeServiceConfiguration(destDir string, repo RepoInfo) error {
+	path := filepath.Join(destDir, "configuration.md")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	displayName := repo.DisplayName
+	if displayName == "" {
+		displayName = repo.Name
+	}
+	knobs := g.configuration[repo.Name]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Configuration — %s\n\n", displayName)
+	b.WriteString("The runtime configuration this service reads, found in its source. Defaults are the fallbacks written in the code; deployments may set other values, and the defaults of secrets aren't shown.\n\n")
+	for _, s := range knobSections {
+		var rows []configKnob
+		for _, k := range knobs {
+			if k.Kind == s.kind {
+				rows = append(rows, k)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", s.heading)
+		how := "Read By"
+		if s.kind == indexer.KnobFeature {
+			how = "Provider"
+		}
+		fmt.Fprintf(&b, "| %s | Default | %s | %s |\n", s.column, how, s.where)
+		fmt.Fprintf(&b, "|%s|---------|%s|%s|\n", strings.Repeat("-", len(s.column)+2), strings.Repeat("-", len(how)+2), strings.Repeat("-", len(s.where)+2))
+		for _, k := range rows {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", k.Name, knobDefault(k), strings.ReplaceAll(k.Evidence, "|", `\|`), knobSources(k.Sources))
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// knobDefault writes a setting's defaults, "—" when the source gives none,
+// and hides those of secrets.
+func knobDefault(k configKnob) string {
+	if len(k.Defaults) == 0 {
+		return "—"
+	}
+	if secretName.MatchString(k.Name) {
+		return "*(hidden)*"
+	}
+	defaults := make([]string, len(k.Defaults))
+	for i, d := range k.Defaults {
+		defaults[i] = "`" + strings.ReplaceAll(d, "|", `\|`) + "`"
+	}
+	return strings.Join(defaults, ", ")
+}
+
+// knobSources lists where a setting is read, up to three places.
+func knobSources(sources []string) string {
+	const shown = 3
+	out := make([]string, 0, shown)
+	for _, s := range sources[:min(shown, len(sources))] {
+		out = append(out, "`"+s+"`")
+	}
+	list := strings.Join(out, ", ")
+	if len(sources) > shown {
+		list += fmt.Sprintf(" and %d more", len(sources)-shown)
+	}
+	return list
+}
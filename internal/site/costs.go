@@ -0,0 +1,211 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/costs"
+)
+
+// costMonthsShown is how many months of cost a service's page lists.
+const costMonthsShown = 6
+
+// costsByMonth is a service's cost per month, then per currency.
+type costsByMonth map[string]map[string]float64
+
+// serviceCosts groups the imported costs by service ("" for the spend no
+// registered service accounts for) and month.
+func (g *CentralSiteGenerator) serviceCosts() map[string]costsByMonth {
+	out := make(map[string]costsByMonth)
+	for _, c := range g.Costs {
+		if out[c.Service] == nil {
+			out[c.Service] = make(costsByMonth)
+		}
+		if out[c.Service][c.Month] == nil {
+			out[c.Service][c.Month] = make(map[string]float64)
+		}
+		out[c.Service][c.Month][c.Currency] += c.Amount
+	}
+	return out
+}
+
+// costMonths lists the months costs were imported for, newest first.
+func (g *CentralSiteGenerator) costMonths() []string {
+	var months []string
+	for _, c := range g.Costs {
+		if !slices.Contains(months, c.Month) {
+			months = append(months, c.Month)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(months)))
+	return months
+}
+
+// costCell writes a month's cost, in each currency it was billed in; "—"
+// when there was none.
+func costCell(amounts map[string]float64) string {
+	if len(amounts) == 0 {
+		return "—"
+	}
+	currencies := make([]string, 0, len(amounts))
+	for c := range amounts {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, len(currencies))
+	for i, c := range currencies {
+		parts[i] = costs.Format(amounts[c], c)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// costChange describes how a cost moved from one month to the next, e.g.
+// "+12%"; "new" when there was none before, and "" when the two months
+// weren't billed in one and the same currency.
+func costChange(before, after map[string]float64) string {
+	if len(before) == 0 && len(after) > 0 {
+		return "new"
+	}
+	if len(before) != 1 || len(after) > 1 {
+		return ""
+	}
+	for currency, prev := range before {
+		cur, ok := after[currency]
+		if !ok && len(after) > 0 {
+			return ""
+		}
+		if prev == 0 {
+			return ""
+		}
+		pct := math.Round((cur - prev) / prev * 100)
+		if pct > 0 {
+			return fmt.Sprintf("+%.0f%%", pct)
+		}
+		return fmt.Sprintf("%.0f%%", pct)
+	}
+	return ""
+}
+
+// monthLabel writes a YYYY-MM month as, e.g., "September 2026".
+func monthLabel(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.Format("January 2006")
+}
+
+// writeServiceCost adds a service's monthly cost, for the most recent
+// months, to its page.
+func (g *CentralSiteGenerator) writeServiceCost(b *strings.Builder, repo RepoInfo) {
+	byMonth := g.serviceCosts()[repo.Name]
+	if len(byMonth) == 0 {
+		return
+	}
+	months := g.costMonths()
+	latest := months[0]
+	b.WriteString("## Cost\n\n")
+	if amounts := byMonth[latest]; len(amounts) > 0 {
+		fmt.Fprintf(b, "This service cost %s in %s", costCell(amounts), monthLabel(latest))
+		if len(months) > 1 {
+			if change := costChange(byMonth[months[1]], amounts); change != "" && change != "new" {
+				fmt.Fprintf(b, " (%s from %s)", change, monthLabel(months[1]))
+			}
+		}
+		b.WriteString(", from cloud billing tagged with it. ")
+	} else {
+		fmt.Fprintf(b, "No cost was billed to this service in %s. ", monthLabel(latest))
+	}
+	b.WriteString("The [System Overview](../system-overview.md) compares it with the other services.\n\n")
+	b.WriteString("| Month | Cost | Change |\n")
+	b.WriteString("|-------|------|--------|\n")
+	for i, m := range months[:min(costMonthsShown, len(months))] {
+		change := ""
+		if i+1 < len(months) {
+			change = costChange(byMonth[months[i+1]], byMonth[m])
+		}
+		fmt.Fprintf(b, "| %s | %s | %s |\n", monthLabel(m), costCell(byMonth[m]), cmp.Or(change, "—"))
+	}
+	b.WriteString("\n")
+}
+
+// writeCostByService adds a table of each service's cost in the latest
+// month to the system overview, most expensive first, with spend no
+// registered service accounts for on its own row.
+func (g *CentralSiteGenerator) writeCostByService(b *strings.Builder) {
+	byService := g.serviceCosts()
+	months := g.costMonths()
+	if len(months) == 0 {
+		return
+	}
+	latest, previous := months[0], ""
+	if len(months) > 1 {
+		previous = months[1]
+	}
+
+	type row struct {
+		name   string
+		latest map[string]float64
+		before map[string]float64
+	}
+	var rows []row
+	total := make(map[string]float64)
+	for _, repo := range g.Repos {
+		byMonth := byService[repo.Name]
+		if len(byMonth) == 0 {
+			continue
+		}
+		name := repo.DisplayName
+		if name == "" {
+			name = repo.Name
+		}
+		rows = append(rows, row{name: fmt.Sprintf("[%s](%s/index.md)", name, repo.Name), latest: byMonth[latest], before: byMonth[previous]})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	// With the site limited to an audience, the spend of the services it
+	// can't see stays out, along with the spend no service accounts for.
+	if g.Audience == "" && len(byService[""]) > 0 {
+		rows = append(rows, row{name: "*Unattributed*", latest: byService[""][latest], before: byService[""][previous]})
+	}
+	sum := func(amounts map[string]float64) float64 {
+		s := 0.0
+		for _, a := range amounts {
+			s += a
+		}
+		return s
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return sum(rows[i].latest) > sum(rows[j].latest) })
+	for _, r := range rows {
+		for c, a := range r.latest {
+			total[c] += a
+		}
+	}
+
+	b.WriteString("## Cost by Service\n\n")
+	fmt.Fprintf(b, "Cloud spend in %s, from billing exports tagged by service.\n\n", monthLabel(latest))
+	b.WriteString("| Service | Cost | Change | Share |\n")
+	b.WriteString("|---------|------|--------|-------|\n")
+	for _, r := range rows {
+		share := "—"
+		if len(total) == 1 && len(r.latest) == 1 {
+			for c, a := range r.latest {
+				if total[c] > 0 {
+					share = fmt.Sprintf("%.0f%%", a/total[c]*100)
+				}
+			}
+		}
+		change := ""
+		if previous != "" {
+			change = costChange(r.before, r.latest)
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", r.name, costCell(r.latest), cmp.Or(change, "—"), share)
+	}
+	fmt.Fprintf(b, "| **Total** | **%s** | | |\n\n", costCell(total))
+}
@@ -0,0 +1,250 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// storeAccess is how a service reads and writes one table, collection, or
+// cache.
+type storeAccess struct {
+	Store       string // indexer.StoreMongoDB or StoreRedis; empty for SQL tables
+	Table       string // empty for caches
+	Read, Write bool
+	Evidence    []string // how it is accessed, in order
+	Sources     []string // file:line, in order
+}
+
+// name names what a service accesses, e.g. "orders" or "Redis".
+func (a storeAccess) name() string {
+	switch {
+	case a.Table != "":
+		return a.Table
+	case a.Store == indexer.StoreRedis:
+		return "Redis"
+	}
+	return a.Store
+}
+
+// storeLabels name the stores on the Data Stores page.
+var storeLabels = map[string]string{"": "SQL", indexer.StoreMongoDB: "MongoDB", indexer.StoreRedis: "Redis"}
+
+// loadDataAccess finds the tables, collections, and caches each repo's
+// source reads and writes, by repo name.
+func (g *CentralSiteGenerator) loadDataAccess() map[string][]storeAccess {
+	loaded := make([][]storeAccess, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		byKey := make(map[string]int)
+		for _, f := range g.repoScan(g.Repos[i]) {
+			for _, d := range f.Data {
+				key := d.Store + "\x00" + strings.ToLower(d.Table)
+				j, ok := byKey[key]
+				if !ok {
+					j = len(loaded[i])
+					byKey[key] = j
+					loaded[i] = append(loaded[i], storeAccess{Store: d.Store, Table: d.Table})
+				}
+				a := &loaded[i][j]
+				a.Read = a.Read || !d.Write
+				a.Write = a.Write || d.Write
+				if !slices.Contains(a.Evidence, d.Evidence) {
+					a.Evidence = append(a.Evidence, d.Evidence)
+				}
+				if source := fmt.Sprintf("%s:%d", f.Path, d.Line); !slices.Contains(a.Sources, source) {
+					a.Sources = append(a.Sources, source)
+				}
+			}
+		}
+		sort.SliceStable(loaded[i], func(a, b int) bool {
+			x, y := loaded[i][a], loaded[i][b]
+			if x.Store != y.Store {
+				return x.Store < y.Store
+			}
+			return x.Table < y.Table
+		})
+	})
+	access := make(map[string][]storeAccess)
+	for i, r := range g.Repos {
+		if len(loaded[i]) > 0 {
+			access[r.Name] = loaded[i]
+		}
+	}
+	return access
+}
+
+// accessVerb says how a service uses a store: "reads", "writes", or
+// "reads and writes".
+func accessVerb(read, write bool) string {
+	switch {
+	case read && write:
+		return "reads and writes"
+	case write:
+		return "writes"
+	}
+	return "reads"
+}
+
+// storeKind says which store a dependency the service map names is, by its
+// name and link type: indexer.StoreRedis, indexer.StoreMongoDB, "" for a
+// SQL database, and ok false for what isn't a data store.
+func storeKind(name, linkType string) (kind string, ok bool) {
+	lower := strings.ToLower(name)
+	tokens := strings.FieldsFunc(lower, func(r rune) bool { return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') })
+	switch {
+	case strings.Contains(lower, "redis") || strings.Contains(lower, "cache") || strings.Contains(lower, "valkey"):
+		return indexer.StoreRedis, true
+	case strings.Contains(lower, "mongo"):
+		return indexer.StoreMongoDB, true
+	case containsAny(lower, []string{"postgres", "mysql", "mariadb", "sql", "oracle", "cockroach"}),
+		slices.Contains(tokens, "db"), slices.Contains(tokens, "database"),
+		strings.EqualFold(linkType, "database"), strings.EqualFold(linkType, "db"):
+		return "", true
+	}
+	return "", false
+}
+
+// edgeAccess says how the source of a service map edge reads and writes the
+// data store it points at, with the tables it writes and reads; access is
+// "" when the target isn't a data store or no access was found.
+func (g *CentralSiteGenerator) edgeAccess(source, target, linkType string) (access string, writes, reads []string) {
+	kind, ok := storeKind(target, linkType)
+	if !ok {
+		return "", nil, nil
+	}
+	var read, write bool
+	for _, a := range g.dataAccess[source] {
+		if a.Store != kind {
+			continue
+		}
+		read, write = read || a.Read, write || a.Write
+		if a.Table == "" {
+			continue
+		}
+		if a.Write {
+			writes = append(writes, a.Table)
+		}
+		if a.Read {
+			reads = append(reads, a.Table)
+		}
+	}
+	if !read && !write {
+		return "", nil, nil
+	}
+	return accessVerb(read, write), writes, reads
+}
+
+// storeUser is a service's access to a table, for the Data Stores page.
+type storeUser struct {
+	Service string
+	storeAccess
+}
+
+// dataStoreTables pivots the services' accesses around the tables,
+// collections, and caches, by store and name.
+func (g *CentralSiteGenerator) dataStoreTables() (keys []string, users map[string][]storeUser) {
+	users = make(map[string][]storeUser)
+	for _, r := range g.Repos {
+		for _, a := range g.dataAccess[r.Name] {
+			key := a.Store + "\x00" + strings.ToLower(a.name())
+			if _, ok := users[key]; !ok {
+				keys = append(keys, key)
+			}
+			users[key] = append(users[key], storeUser{Service: r.Name, storeAccess: a})
+		}
+	}
+	sort.Strings(keys)
+	return keys, users
+}
+
+// sharedWrites reports whether a table is shared in a way that couples its
+// services: more than one service uses it, and at least one writes.
+func sharedWrites(users []storeUser) bool {
+	return len(users) > 1 && slices.ContainsFunc(users, func(u storeUser) bool { return u.Write })
+}
+
+// writeDataStoresPage writes data-stores.md, listing which services read
+// and write each table, collection, and cache, with the shared ones first.
+func (g *CentralSiteGenerator) writeDataStoresPage(stagingDir string) error {
+	keys, users := g.dataStoreTables()
+	services := func(list []storeUser, write bool) string {
+		var names []string
+		for _, u := range list {
+			if (write && u.Write) || (!write && u.Read) {
+				names = append(names, fmt.Sprintf("[%s](%s/index.md)", u.Service, u.Service))
+			}
+		}
+		if len(names) == 0 {
+			return "—"
+		}
+		return strings.Join(names, ", ")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Data Stores\n\n")
+	fmt.Fprintf(&b, "Which services read and write each of %d tables, collections, and caches, from the queries, ORM calls, and cache commands in their source. Tables are named as the queries name them, or after their ORM models.\n\n", len(keys))
+
+	var shared []string
+	for _, k := range keys {
+		if sharedWrites(users[k]) {
+			shared = append(shared, k)
+		}
+	}
+	if len(shared) > 0 {
+		b.WriteString("## Shared Tables\n\n")
+		b.WriteString("These are used by more than one service, and written by at least one: each service depends on the others' use of its schema, and a change to it, a migration, or a lock held by one can break the rest. Consider giving each an owner the others call instead.\n\n")
+		b.WriteString("| Table | Store | Written By | Read By |\n")
+		b.WriteString("|-------|-------|------------|---------|\n")
+		for _, k := range shared {
+			u := users[k]
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", u[0].name(), storeLabels[u[0].Store], services(u, true), services(u, false))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## All Tables\n\n")
+	b.WriteString("| Table | Store | Written By | Read By | Found In |\n")
+	b.WriteString("|-------|-------|------------|---------|----------|\n")
+	for _, k := range keys {
+		u := users[k]
+		var found []string
+		for _, x := range u {
+			found = append(found, x.Sources...)
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", u[0].name(), storeLabels[u[0].Store], services(u, true), services(u, false), knobSources(found))
+	}
+	b.WriteString("\n")
+	return os.WriteFile(filepath.Join(stagingDir, "data-stores.md"), []byte(b.String()), 0o644)
+}
+
+// dataAccessSummary says what a service writes and reads, e.g. "writes
+// orders and Redis, and reads customers".
+func dataAccessSummary(access []storeAccess) string {
+	var writes, reads []string
+	for _, a := range access {
+		if a.Write {
+			writes = append(writes, "`"+a.name()+"`")
+		}
+		if a.Read && !a.Write {
+			reads = append(reads, "`"+a.name()+"`")
+		}
+	}
+	list := func(items []string) string {
+		if len(items) == 1 {
+			return items[0]
+		}
+		return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	}
+	switch {
+	case len(writes) > 0 && len(reads) > 0:
+		return "writes " + list(writes) + ", and only reads " + list(reads)
+	case len(writes) > 0:
+		return "writes " + list(writes)
+	}
+	return "only reads " + list(reads)
+}
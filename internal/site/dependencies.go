@@ -0,0 +1,182 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// loadDependencies reads the third-party libraries each repo's package
+// manifests declare, scoped to the repo, by repo name. Repos without any
+// are left out.
+func (g *CentralSiteGenerator) loadDependencies() map[string][]importers.Dependency {
+	loaded := make([][]importers.Dependency, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
+		if repo.DocsDir == "" {
+			return
+		}
+		deps, err := importers.LoadRepoDependencies(repo.sourceRoot())
+		if err != nil {
+			return
+		}
+		for _, d := range deps {
+			if indexer.InServiceDir(d.Manifest, repo.Subdir, repo.Excludes) {
+				loaded[i] = append(loaded[i], d)
+			}
+		}
+	})
+	deps := make(map[string][]importers.Dependency)
+	for i, r := range g.Repos {
+		if len(loaded[i]) > 0 {
+			deps[r.Name] = loaded[i]
+		}
+	}
+	return deps
+}
+
+// dependencyCell is a table cell for an optional value.
+func dependencyCell(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return "`" + s + "`"
+}
+
+// writeServiceDependencies writes a repo's dependencies.md, unless its docs
+// already have one.
+func (g *CentralSiteGenerator) writeServiceDependencies(destDir string, repo RepoInfo) error {
+	path := filepath.Join(destDir, "dependencies.md")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	displayName := repo.DisplayName
+	if displayName == "" {
+		displayName = repo.Name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Third-Party Dependencies — %s\n\n", displayName)
+	b.WriteString("Libraries declared in this service's package manifests. The [org-wide list](../dependencies.md) shows which other services use them, and in which versions.\n\n")
+	b.WriteString("| Library | Version | Ecosystem | Scope | License | Manifest |\n")
+	b.WriteString("|---------|---------|-----------|-------|---------|----------|\n")
+	for _, d := range g.dependencies[repo.Name] {
+		scope := "runtime"
+		if d.Dev {
+			scope = "dev"
+		}
+		license := d.License
+		if license == "" {
+			license = "—"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | `%s` |\n", d.Name, dependencyCell(d.Version), d.Ecosystem, scope, license, d.Manifest)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// libraryVersion is one version of a library in use across the repos.
+type libraryVersion struct {
+	Ecosystem string
+	Name      string
+	Version   string
+	License   string
+	// Services use this version, by repo name; true if only to build or
+	// test.
+	Services map[string]bool
+}
+
+// rollupDependencies groups every repo's dependencies by library and
+// version, sorted by library name.
+func (g *CentralSiteGenerator) rollupDependencies() []*libraryVersion {
+	byKey := make(map[string]*libraryVersion)
+	var rollup []*libraryVersion
+	for _, repo := range g.Repos {
+		for _, d := range g.dependencies[repo.Name] {
+			key := d.Ecosystem + "\x00" + d.Name + "\x00" + d.Version
+			lv, ok := byKey[key]
+			if !ok {
+				lv = &libraryVersion{Ecosystem: d.Ecosystem, Name: d.Name, Version: d.Version, Services: make(map[string]bool)}
+				byKey[key] = lv
+				rollup = append(rollup, lv)
+			}
+			if lv.License == "" {
+				lv.License = d.License
+			}
+			dev, seen := lv.Services[repo.Name]
+			lv.Services[repo.Name] = d.Dev && (dev || !seen)
+		}
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		a, b := rollup[i], rollup[j]
+		if a.Name != b.Name {
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		return a.Version < b.Version
+	})
+	return rollup
+}
+
+// writeDependenciesPage writes the org-wide dependencies.md: each library,
+// the versions in use, and the services using each version.
+func (g *CentralSiteGenerator) writeDependenciesPage(stagingDir string) error {
+	rollup := g.rollupDependencies()
+	versions := make(map[string][]string)
+	var libraries []string
+	for _, lv := range rollup {
+		key := lv.Ecosystem + " " + lv.Name
+		if _, ok := versions[key]; !ok {
+			libraries = append(libraries, key)
+		}
+		version := lv.Version
+		if version == "" {
+			version = "unspecified"
+		}
+		versions[key] = append(versions[key], version)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Third-Party Dependencies\n\n")
+	fmt.Fprintf(&b, "%d libraries in use across %d services, from their package manifests. Search this page for a library to see who uses which version.\n\n", len(libraries), len(g.dependencies))
+
+	b.WriteString("| Library | Version | Ecosystem | License | Services |\n")
+	b.WriteString("|---------|---------|-----------|---------|----------|\n")
+	for _, lv := range rollup {
+		services := make([]string, 0, len(lv.Services))
+		for name := range lv.Services {
+			services = append(services, name)
+		}
+		sort.Strings(services)
+		for i, name := range services {
+			services[i] = fmt.Sprintf("[%s](%s/dependencies.md)", name, name)
+			if lv.Services[name] {
+				services[i] += " (dev)"
+			}
+		}
+		license := lv.License
+		if license == "" {
+			license = "—"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", lv.Name, dependencyCell(lv.Version), lv.Ecosystem, license, strings.Join(services, ", "))
+	}
+
+	var drift []string
+	for _, key := range libraries {
+		if len(versions[key]) > 1 {
+			ecosystem, name, _ := strings.Cut(key, " ")
+			drift = append(drift, fmt.Sprintf("- `%s` (%s): %s", name, ecosystem, strings.Join(versions[key], ", ")))
+		}
+	}
+	if len(drift) > 0 {
+		b.WriteString("\n## Libraries in More Than One Version\n\n")
+		b.WriteString(strings.Join(drift, "\n") + "\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "dependencies.md"), []byte(b.String()), 0o644)
+}
@@ -0,0 +1,117 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// collectDeprecations finds the services still using each deprecated
+// endpoint or topic in the links, as corrected.
+func (g *CentralSiteGenerator) collectDeprecations() []deprecation.Usage {
+	if len(g.Deprecations) == 0 {
+		return nil
+	}
+	links := make([]registry.ServiceLink, len(g.Links))
+	for i, l := range g.Links {
+		links[i] = registry.ServiceLink{FromRepo: l.FromRepo, ToRepo: l.ToRepo, LinkType: l.LinkType, Endpoints: l.Endpoints}
+	}
+	return deprecation.Usages(g.Deprecations, links)
+}
+
+// writeDeprecationsPage writes deprecations.md: the deprecated endpoints
+// and topics still in use, soonest sunset first, with the time left and the
+// services to move off them, then those nothing uses any more.
+func (g *CentralSiteGenerator) writeDeprecationsPage(stagingDir string) error {
+	now := time.Now()
+	var inUse, unused []deprecation.Usage
+	consumers := make(map[string]bool)
+	for _, u := range g.deprecations {
+		if len(u.Consumers) == 0 {
+			unused = append(unused, u)
+			continue
+		}
+		inUse = append(inUse, u)
+		for _, c := range u.Consumers {
+			consumers[c] = true
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Deprecations\n\n")
+	fmt.Fprintf(&b, "%d deprecated endpoints and topics, %d of them still used by %d services, found in the links between services. ", len(g.deprecations), len(inUse), len(consumers))
+	b.WriteString("The daemon reminds the teams owning the services still using one on its reminder schedule, until none do.\n\n")
+
+	b.WriteString("## Still in Use\n\n")
+	if len(inUse) == 0 {
+		b.WriteString("Nothing uses a deprecated endpoint or topic any more.\n\n")
+	} else {
+		b.WriteString("| Endpoint | Provider | Sunset | Time Left | Still Used By | Use Instead | Marked In |\n")
+		b.WriteString("|----------|----------|--------|-----------|---------------|-------------|-----------|\n")
+		for _, u := range inUse {
+			countdown := u.Countdown(now)
+			if days, ok := u.DaysLeft(now); ok && days <= 7 {
+				countdown = "**" + countdown + "**"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s | %s |\n",
+				u.Endpoint, g.serviceLinks([]string{u.Service}), sunsetDate(u.Deprecation), countdown,
+				g.serviceLinks(u.Consumers), replacementCell(u.Deprecation), markedIn(u.Deprecation))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(unused) > 0 {
+		b.WriteString("## No Longer Used\n\n")
+		b.WriteString("No link uses these any more, so they can be removed, unless they are called from outside the system.\n\n")
+		b.WriteString("| Endpoint | Provider | Sunset | Marked In |\n")
+		b.WriteString("|----------|----------|--------|-----------|\n")
+		for _, u := range unused {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", u.Endpoint, g.serviceLinks([]string{u.Service}), sunsetDate(u.Deprecation), markedIn(u.Deprecation))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Deprecating an Endpoint\n\n")
+	b.WriteString("An endpoint is deprecated by any of:\n\n")
+	b.WriteString("- `deprecated: true` on the operation in the provider's OpenAPI spec, with an `x-sunset: 2027-01-31` extension for its sunset date\n")
+	b.WriteString("- a `@Deprecated` annotation or decorator on the route, `deprecated=True` on a FastAPI route, or a `Deprecated:` or `@deprecated` comment above it\n")
+	b.WriteString("- an entry in the `deprecations` section of `.autodoc.yml`, with its `sunset` and `replacement`\n")
+	b.WriteString("- telling the context engine, e.g. \"payments is retiring POST /v1/charges on 2027-01-31; use POST /v2/charges\"\n\n")
+	b.WriteString("Config overrides conversation, which overrides the source; telling the context engine an endpoint is not deprecated undoes a marker in the source.\n")
+	return os.WriteFile(filepath.Join(stagingDir, "deprecations.md"), []byte(b.String()), 0o644)
+}
+
+func sunsetDate(d deprecation.Deprecation) string {
+	if d.Sunset.IsZero() {
+		return "—"
+	}
+	return d.Sunset.Format(time.DateOnly)
+}
+
+func replacementCell(d deprecation.Deprecation) string {
+	cell := "—"
+	if d.Replacement != "" {
+		cell = "`" + d.Replacement + "`"
+	}
+	if d.Note != "" {
+		cell += " " + strings.ReplaceAll(d.Note, "|", `\|`)
+	}
+	return strings.TrimPrefix(cell, "— ")
+}
+
+// markedIn says where a deprecation is declared.
+func markedIn(d deprecation.Deprecation) string {
+	switch d.Source {
+	case deprecation.SourceConfig:
+		return "config"
+	case deprecation.SourceFact:
+		return "conversation"
+	}
+	return "`" + strings.ReplaceAll(cmp.Or(d.Evidence, d.Source), "|", `\|`) + "`"
+}
@@ -0,0 +1,286 @@
+package site
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// Entry point kinds: where traffic enters the system from outside it.
+const (
+	EntryUI        = "ui"          // a frontend users interact with
+	EntryPublicAPI = "public-http" // routes no registered service calls
+	EntryScheduler = "scheduler"   // jobs started by a clock
+	EntryConsumer  = "consumer"    // consumes messages no registered service produces
+)
+
+const (
+	// supportingFlowName is the catch-all flow for edges no other flow
+	// covers; appearing only in it doesn't count as being in a flow.
+	supportingFlowName = "Supporting Service Calls"
+	// maxEntryFlowHops bounds how far an entry flow follows calls.
+	maxEntryFlowHops = 6
+)
+
+// EntryPoint is a service where requests or work enter the system.
+type EntryPoint struct {
+	Service string
+	Kind    string
+	Reason  string
+}
+
+// entryKindLabels name the kinds on the site.
+var entryKindLabels = map[string]string{
+	EntryUI:        "UI app",
+	EntryPublicAPI: "Public HTTP",
+	EntryScheduler: "Scheduler",
+	EntryConsumer:  "Message consumer",
+}
+
+// Name tokens that mark a service as a frontend or a scheduler.
+var (
+	uiTokens        = map[string]bool{"ui": true, "web": true, "frontend": true, "portal": true, "dashboard": true, "webapp": true, "spa": true, "console": true}
+	schedulerTokens = map[string]bool{"cron": true, "scheduler": true, "job": true, "jobs": true, "batch": true, "timer": true}
+)
+
+// isAsyncLink reports whether a link delivers messages rather than calls.
+func isAsyncLink(linkType string) bool {
+	switch strings.ToLower(linkType) {
+	case "kafka", "amqp", "event", "pubsub", "queue", "sqs", "sns", "nats":
+		return true
+	}
+	return false
+}
+
+// detectEntryPoints finds the services nothing registered calls, and says
+// how traffic reaches each: a frontend, a public API, a scheduler, or a
+// consumer of messages produced outside the system. Services running
+// scheduled jobs are entry points whether or not they are called. Services
+// with neither callers nor calls of their own are left to the orphan
+// report.
+func (g *CentralSiteGenerator) detectEntryPoints() []EntryPoint {
+	calledSync := make(map[string]bool)
+	producedTo := make(map[string]bool)
+	calls := make(map[string]int)
+	for _, l := range g.Links {
+		to := strings.ToLower(l.ToRepo)
+		if isAsyncLink(l.LinkType) {
+			producedTo[to] = true
+		} else {
+			calledSync[to] = true
+		}
+		calls[strings.ToLower(l.FromRepo)]++
+	}
+
+	var entries []EntryPoint
+	for _, r := range g.Repos {
+		name := strings.ToLower(r.Name)
+		// A service that runs scheduled jobs starts flows of its own,
+		// however it is called otherwise.
+		if jobs := g.jobs[r.Name]; len(jobs) > 0 {
+			entries = append(entries, EntryPoint{Service: r.Name, Kind: EntryScheduler, Reason: jobsReason(jobs)})
+			continue
+		}
+		if calledSync[name] {
+			continue
+		}
+		tokens := strings.FieldsFunc(name, func(c rune) bool { return c == '-' || c == '_' || c == '.' || c == '/' })
+		summary := strings.ToLower(r.Summary)
+		e := EntryPoint{Service: r.Name}
+		switch {
+		case anyToken(tokens, schedulerTokens) || strings.Contains(summary, "cron") || strings.Contains(summary, "scheduled job"):
+			e.Kind, e.Reason = EntryScheduler, "runs on a schedule"
+		case anyToken(tokens, uiTokens) || strings.Contains(summary, "frontend") || strings.Contains(summary, "user interface"):
+			e.Kind, e.Reason = EntryUI, "serves the user interface"
+		case !producedTo[name] && consumesEvents(g.repoAnalyses(r)):
+			e.Kind, e.Reason = EntryConsumer, "consumes messages no registered service produces"
+		case calls[name] > 0 && !producedTo[name]:
+			e.Kind, e.Reason = EntryPublicAPI, "exposes routes no registered service calls"
+		default:
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func anyToken(tokens []string, set map[string]bool) bool {
+	for _, t := range tokens {
+		if set[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// consumesEvents reports whether a repo's analyses list event
+// dependencies, i.e. topics or queues it reads from or writes to.
+func consumesEvents(analyses map[string]indexer.FileAnalysis) bool {
+	for _, a := range analyses {
+		for _, d := range a.Dependencies {
+			if d.Type == indexer.DepEvent {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// entryFlow follows calls breadth-first from an entry point, up to
+// maxEntryFlowHops away, and describes the journey along with the calls it
+// followed, hop by hop. It reports false when the entry point calls nothing.
+func entryFlow(e EntryPoint, adj map[string][]flowCall, displayName func(string) string) (FlowInfo, [][]flowCall, bool) {
+	start := strings.ToLower(e.Service)
+	visited := map[string]bool{start: true}
+	frontier := []string{start}
+	var hops [][]flowCall
+	for len(frontier) > 0 && len(hops) < maxEntryFlowHops {
+		var hop []flowCall
+		var next []string
+		for _, from := range frontier {
+			for _, c := range adj[from] {
+				hop = append(hop, c)
+				if !visited[c.to] {
+					visited[c.to] = true
+					next = append(next, c.to)
+				}
+			}
+		}
+		if len(hop) == 0 {
+			break
+		}
+		hops = append(hops, hop)
+		frontier = next
+	}
+	if len(hops) == 0 {
+		return FlowInfo{}, nil, false
+	}
+
+	entryName := displayName(start)
+	var services []string
+	for svc := range visited {
+		services = append(services, displayName(svc))
+	}
+	sort.Strings(services)
+
+	var d strings.Builder
+	d.WriteString("sequenceDiagram\n")
+	fmt.Fprintf(&d, "    participant %s\n", entryName)
+	added := map[string]bool{entryName: true}
+	for _, hop := range hops {
+		for _, c := range hop {
+			if to := displayName(c.to); !added[to] {
+				fmt.Fprintf(&d, "    participant %s\n", to)
+				added[to] = true
+			}
+		}
+	}
+
+	var n strings.Builder
+	hopsLabel := "hops"
+	if len(hops) == 1 {
+		hopsLabel = "hop"
+	}
+	fmt.Fprintf(&n, "Starts at %s (%s: %s) and reaches %d services in %d %s:\n",
+		entryName, entryKindLabels[e.Kind], e.Reason, len(services)-1, len(hops), hopsLabel)
+	for i, hop := range hops {
+		steps := make([]string, len(hop))
+		for j, c := range hop {
+			from, to := displayName(strings.ToLower(c.link.FromRepo)), displayName(c.to)
+			label := operationLabel(c.link)
+			fmt.Fprintf(&d, "    %s->>%s: %s\n", from, to, label)
+			steps[j] = fmt.Sprintf("%s → %s (%s)", from, to, label)
+		}
+		fmt.Fprintf(&n, "\n**Hop %d:** %s\n", i+1, strings.Join(steps, ", "))
+	}
+
+	return FlowInfo{
+		Name:        entryName + " Entry Flow",
+		Description: fmt.Sprintf("Everything reachable from the entry point %s (%s).", entryName, entryKindLabels[e.Kind]),
+		Narrative:   strings.TrimRight(n.String(), "\n"),
+		Diagram:     d.String(),
+		Services:    services,
+	}, hops, true
+}
+
+// sameServices reports whether a flow already covers exactly services.
+func sameServices(services []string, flows []FlowInfo) bool {
+	for _, f := range flows {
+		if len(f.Services) != len(services) {
+			continue
+		}
+		same := true
+		for i, s := range f.Services {
+			same = same && strings.EqualFold(s, services[i])
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// orphanServices lists the services that appear in no flow, other than
+// the catch-all of supporting calls: undocumented paths, or dead ones.
+func (g *CentralSiteGenerator) orphanServices() []string {
+	inFlow := make(map[string]bool)
+	for _, f := range g.Flows {
+		if f.Name == supportingFlowName {
+			continue
+		}
+		for _, s := range f.Services {
+			inFlow[strings.ToLower(s)] = true
+		}
+	}
+	var orphans []string
+	for _, r := range g.Repos {
+		if !inFlow[strings.ToLower(r.Name)] {
+			orphans = append(orphans, r.Name)
+		}
+	}
+	return orphans
+}
+
+// writeFlowCoverage reports the entry points, how many services the flows
+// cover, and the services no flow reaches.
+func (g *CentralSiteGenerator) writeFlowCoverage(b *strings.Builder) {
+	if len(g.Repos) == 0 || len(g.Flows) == 0 && len(g.entryPoints) == 0 {
+		return
+	}
+	visible := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		visible[r.Name] = true
+	}
+	orphans := g.orphanServices()
+
+	b.WriteString("## Flow Coverage\n\n")
+	covered := len(g.Repos) - len(orphans)
+	fmt.Fprintf(b, "%d of %d services (%d%%) appear in a flow.\n\n", covered, len(g.Repos), covered*100/len(g.Repos))
+
+	var entries []EntryPoint
+	for _, e := range g.entryPoints {
+		if visible[e.Service] {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > 0 {
+		b.WriteString("### Entry Points\n\n")
+		b.WriteString("| Service | Kind | Why |\n")
+		b.WriteString("|---------|------|-----|\n")
+		for _, e := range entries {
+			fmt.Fprintf(b, "| %s | %s | %s |\n", e.Service, entryKindLabels[e.Kind], e.Reason)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(orphans) > 0 {
+		b.WriteString("### Orphan Services\n\n")
+		b.WriteString("These services appear in no flow. Their paths may be undocumented, or dead.\n\n")
+		for _, o := range orphans {
+			b.WriteString("- " + o + "\n")
+		}
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,142 @@
+package site
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// allEnvironments returns the environments links and repos are tagged
+// with, in the order Environments gives and then by name. It is empty
+// when no link is tagged, since every environment then has the same
+// architecture.
+func (g *CentralSiteGenerator) allEnvironments() []string {
+	found := make(map[string]bool)
+	for _, l := range g.Links {
+		for _, e := range l.Environments {
+			found[e] = true
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	for _, r := range g.Repos {
+		if r.Environment != "" {
+			found[r.Environment] = true
+		}
+	}
+	var out, rest []string
+	for _, e := range g.Environments {
+		if found[e] && !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	for e := range found {
+		if !slices.Contains(out, e) {
+			rest = append(rest, e)
+		}
+	}
+	sort.Strings(rest)
+	return append(out, rest...)
+}
+
+// inEnvironment reports whether a link exists in env; every link exists
+// in "", which means all environments.
+func (l LinkInfo) inEnvironment(env string) bool {
+	return env == "" || len(l.Environments) == 0 || slices.Contains(l.Environments, env)
+}
+
+// environmentLabel renders the environments a link exists in, or "all".
+func (l LinkInfo) environmentLabel() string {
+	if len(l.Environments) == 0 {
+		return "all"
+	}
+	return strings.Join(l.Environments, ", ")
+}
+
+// writeArchitectureDiagram writes the system overview's Mermaid diagram of
+// the services, their external dependencies, and the links between them
+// in env, or in every environment when env is "". Repos deployed to
+// another environment, and externals nothing calls in env, are left out.
+// In the all-environments diagram, links that exist only in some
+// environments are dashed and labelled with them.
+func (g *CentralSiteGenerator) writeArchitectureDiagram(b *strings.Builder, env string) {
+	b.WriteString("```mermaid\ngraph TD\n")
+	// Build set of known repo names.
+	repoSet := make(map[string]bool)
+	for _, repo := range g.Repos {
+		repoSet[repo.Name] = true
+	}
+	var repos []RepoInfo
+	for _, repo := range g.Repos {
+		if env == "" || repo.Environment == "" || repo.Environment == env {
+			repos = append(repos, repo)
+		}
+	}
+	shown := make(map[string]bool)
+	for _, repo := range repos {
+		shown[repo.Name] = true
+	}
+	var links []LinkInfo
+	for _, link := range g.Links {
+		if link.inEnvironment(env) && (shown[link.FromRepo] || !repoSet[link.FromRepo]) && (shown[link.ToRepo] || !repoSet[link.ToRepo]) {
+			links = append(links, link)
+		}
+	}
+	// Define service nodes.
+	for _, repo := range repos {
+		displayName := repo.DisplayName
+		if displayName == "" {
+			displayName = repo.Name
+		}
+		nodeID := strings.ReplaceAll(repo.Name, "-", "_")
+		b.WriteString(fmt.Sprintf("    %s[\"%s<br/>%d files\"]\n", nodeID, displayName, repo.FileCount))
+	}
+	// Collect and define external dependency nodes.
+	externalNodes := make(map[string]bool)
+	for _, link := range links {
+		if !repoSet[link.ToRepo] {
+			externalNodes[link.ToRepo] = true
+		}
+		if !repoSet[link.FromRepo] {
+			externalNodes[link.FromRepo] = true
+		}
+	}
+	externals := make([]string, 0, len(externalNodes))
+	for extName := range externalNodes {
+		externals = append(externals, extName)
+	}
+	sort.Strings(externals)
+	for _, extName := range externals {
+		nodeID := strings.ReplaceAll(extName, "-", "_")
+		b.WriteString(fmt.Sprintf("    %s[(\"%s\")]\n", nodeID, extName))
+	}
+	// Define links between services.
+	for _, link := range links {
+		fromID := strings.ReplaceAll(link.FromRepo, "-", "_")
+		toID := strings.ReplaceAll(link.ToRepo, "-", "_")
+		label := link.LinkType
+		if label == "" {
+			label = "depends"
+		}
+		arrow := "-->"
+		if env == "" && len(link.Environments) > 0 {
+			arrow = "-.->"
+			label += " · " + strings.Join(link.Environments, ", ")
+		}
+		b.WriteString(fmt.Sprintf("    %s %s|%s| %s\n", fromID, arrow, label, toID))
+	}
+	// Style the nodes.
+	b.WriteString("\n    classDef svc fill:#1f6feb,stroke:#58a6ff,color:#fff,stroke-width:2px\n")
+	b.WriteString("    classDef ext fill:#30363d,stroke:#8b949e,color:#e6edf3,stroke-width:1px,stroke-dasharray:5\n")
+	for _, repo := range repos {
+		nodeID := strings.ReplaceAll(repo.Name, "-", "_")
+		b.WriteString(fmt.Sprintf("    class %s svc\n", nodeID))
+	}
+	for _, extName := range externals {
+		nodeID := strings.ReplaceAll(extName, "-", "_")
+		b.WriteString(fmt.Sprintf("    class %s ext\n", nodeID))
+	}
+	b.WriteString("```\n\n")
+}
@@ -0,0 +1,360 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+)
+
+// eventTopic is a topic or queue, with the services writing to and reading
+// from it.
+type eventTopic struct {
+	Name      string
+	Brokers   []string // the link types carrying it, e.g. kafka
+	Producers []string
+	Consumers []string
+	// Schema is the schema named like the topic in a producer's or
+	// consumer's source, when there is one.
+	Schema *topicSchema
+	// Versions are the recorded versions of the topic's schema, oldest
+	// first: its registry subject's, failing that its schema file's.
+	Versions []schemareg.Schema
+	// Failure is "dead letter" or "retry" when the topic takes another's
+	// failed messages; Of names that topic.
+	Failure string
+	Of      string
+}
+
+// topicSchema is where a topic's payload schema is declared.
+type topicSchema struct {
+	schemaFile
+	Repo string
+}
+
+// eventModel is the system's messaging: its topics, by name, and the async
+// links that name no topic.
+type eventModel struct {
+	topics  []eventTopic
+	unnamed []LinkInfo
+}
+
+// Topic names that take another topic's failed messages, with the other
+// topic's name as the first submatch.
+var (
+	deadLetterTopic = regexp.MustCompile(`(?i)^(?:(.+?)[._-](?:dlq|dlt|dead[._-]?letters?|parking[._-]?lot)|(?:dlq|dlt)[._-](.+))$`)
+	retryTopic      = regexp.MustCompile(`(?i)^(?:(.+?)[._-]retry(?:[._-][0-9a-z]+)?|retry[._-](.+))$`)
+)
+
+// collectEvents pivots the async links around their topics: a link's
+// endpoints are the topics its FromRepo produces and its ToRepo consumes.
+// It returns nil when no link is async.
+func (g *CentralSiteGenerator) collectEvents() *eventModel {
+	m := &eventModel{}
+	byName := make(map[string]*eventTopic)
+	for _, l := range g.Links {
+		if !isAsyncLink(l.LinkType) {
+			continue
+		}
+		if len(l.Endpoints) == 0 {
+			m.unnamed = append(m.unnamed, l)
+			continue
+		}
+		for _, topic := range l.Endpoints {
+			t, ok := byName[topic]
+			if !ok {
+				t = &eventTopic{Name: topic}
+				byName[topic] = t
+			}
+			if !slices.Contains(t.Brokers, l.LinkType) {
+				t.Brokers = append(t.Brokers, l.LinkType)
+			}
+			if !slices.Contains(t.Producers, l.FromRepo) {
+				t.Producers = append(t.Producers, l.FromRepo)
+			}
+			if !slices.Contains(t.Consumers, l.ToRepo) {
+				t.Consumers = append(t.Consumers, l.ToRepo)
+			}
+		}
+	}
+	if len(byName) == 0 && len(m.unnamed) == 0 {
+		return nil
+	}
+
+	// The schema comes from a producer, failing that a consumer. A failure
+	// topic carries the messages of the topic it serves, so it is also
+	// named like that topic's schema, which it inherits.
+	g.loadSchemas()
+	for _, t := range byName {
+		t.Failure, t.Of = failureTopic(t.Name)
+		name := cmp.Or(t.Of, t.Name)
+		for _, repo := range append(append([]string(nil), t.Producers...), t.Consumers...) {
+			if t.Schema != nil {
+				break
+			}
+			for _, s := range g.schemas[repo] {
+				if namedLike(s.Name, name) {
+					t.Schema = &topicSchema{schemaFile: s, Repo: repo}
+					break
+				}
+			}
+		}
+	}
+	for _, t := range byName {
+		for of := byName[t.Of]; t.Schema == nil && of != nil; of = byName[of.Of] {
+			t.Schema = of.Schema
+		}
+		t.Versions = g.schemaVersions(cmp.Or(t.Of, t.Name), t.Schema)
+		m.topics = append(m.topics, *t)
+	}
+	sort.Slice(m.topics, func(i, j int) bool { return m.topics[i].Name < m.topics[j].Name })
+	return m
+}
+
+// schemaVersions returns the recorded versions of the schema a topic
+// carries: those of its value subject in the registry, failing that its key
+// subject's, failing that those of the schema file declaring it.
+func (g *CentralSiteGenerator) schemaVersions(topic string, file *topicSchema) []schemareg.Schema {
+	subjects := []string{topic + "-value", topic + "-key"}
+	if file != nil {
+		subjects = append(subjects, schemareg.FileSubject(file.Repo, file.File, file.Name))
+	}
+	for _, subject := range subjects {
+		var versions []schemareg.Schema
+		for _, v := range g.SchemaVersions {
+			if v.Subject == subject {
+				versions = append(versions, v)
+			}
+		}
+		if len(versions) > 0 {
+			return versions
+		}
+	}
+	return nil
+}
+
+// failureTopic reports whether a topic is named as a dead-letter or retry
+// topic of another, e.g. "orders.dlq" or "orders-retry-5m", and names the
+// other.
+func failureTopic(name string) (failure, of string) {
+	for _, f := range []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{{"dead letter", deadLetterTopic}, {"retry", retryTopic}} {
+		if m := f.pattern.FindStringSubmatch(name); m != nil {
+			return f.kind, m[1] + m[2]
+		}
+	}
+	return "", ""
+}
+
+// failureTopics returns the dead-letter and retry topics serving topic.
+func (m *eventModel) failureTopics(topic string) (deadLetters, retries []string) {
+	for _, t := range m.topics {
+		switch {
+		case t.Of != topic:
+		case t.Failure == "dead letter":
+			deadLetters = append(deadLetters, t.Name)
+		default:
+			retries = append(retries, t.Name)
+		}
+	}
+	return deadLetters, retries
+}
+
+// writeEventsPage writes events.md, the architecture seen from its
+// messaging: every topic between the services producing it, on the left,
+// and those consuming it, on the right, with its schema and the
+// dead-letter and retry topics serving it.
+func (g *CentralSiteGenerator) writeEventsPage(stagingDir string) error {
+	m := g.events
+	var b strings.Builder
+	b.WriteString("# Events\n\n")
+	if len(m.topics) == 0 {
+		b.WriteString("None of the async links between services names its topics.\n\n")
+	} else {
+		fmt.Fprintf(&b, "The system's messaging, topic by topic: %d topics, with the services producing each on the left and those consuming it on the right. ", len(m.topics))
+		b.WriteString("Dead-letter and retry topics are tied to the topics they serve by name, as in `orders.dlq` or `orders-retry-5m`.\n\n")
+		b.WriteString("```mermaid\ngraph LR\n")
+		var producers, consumers []string
+		for _, t := range m.topics {
+			for _, p := range t.Producers {
+				if !slices.Contains(producers, p) {
+					producers = append(producers, p)
+				}
+			}
+			for _, c := range t.Consumers {
+				if !slices.Contains(consumers, c) {
+					consumers = append(consumers, c)
+				}
+			}
+		}
+		sort.Strings(producers)
+		sort.Strings(consumers)
+		b.WriteString("    subgraph Producers\n")
+		for _, p := range producers {
+			fmt.Fprintf(&b, "        %s[\"%s\"]\n", mermaidNodeID("producer_", p), p)
+		}
+		b.WriteString("    end\n")
+		b.WriteString("    subgraph Topics\n")
+		for _, t := range m.topics {
+			label := t.Name
+			if t.Schema != nil {
+				label += "<br/><i>" + t.Schema.Name + "</i>"
+			}
+			fmt.Fprintf(&b, "        %s([\"%s\"])\n", mermaidNodeID("topic_", t.Name), label)
+		}
+		b.WriteString("    end\n")
+		b.WriteString("    subgraph Consumers\n")
+		for _, c := range consumers {
+			fmt.Fprintf(&b, "        %s[\"%s\"]\n", mermaidNodeID("consumer_", c), c)
+		}
+		b.WriteString("    end\n")
+		for _, t := range m.topics {
+			for _, p := range t.Producers {
+				fmt.Fprintf(&b, "    %s --> %s\n", mermaidNodeID("producer_", p), mermaidNodeID("topic_", t.Name))
+			}
+			for _, c := range t.Consumers {
+				fmt.Fprintf(&b, "    %s --> %s\n", mermaidNodeID("topic_", t.Name), mermaidNodeID("consumer_", c))
+			}
+		}
+		var failures []string
+		for _, t := range m.topics {
+			if t.Failure == "" || !slices.ContainsFunc(m.topics, func(o eventTopic) bool { return o.Name == t.Of }) {
+				continue
+			}
+			fmt.Fprintf(&b, "    %s -.->|%s| %s\n", mermaidNodeID("topic_", t.Of), t.Failure, mermaidNodeID("topic_", t.Name))
+			failures = append(failures, mermaidNodeID("topic_", t.Name))
+		}
+		if len(failures) > 0 {
+			b.WriteString("    classDef failure fill:#fde2e1,stroke:#c0392b\n")
+			fmt.Fprintf(&b, "    class %s failure\n", strings.Join(failures, ","))
+		}
+		b.WriteString("```\n\n")
+
+		b.WriteString("## Topics\n\n")
+		b.WriteString("| Topic | Broker | Producers | Consumers | Schema | Failures |\n")
+		b.WriteString("|-------|--------|-----------|-----------|--------|----------|\n")
+		for _, t := range m.topics {
+			var schemas []string
+			if n := len(t.Versions); n > 0 && t.Versions[n-1].Repo == "" {
+				v := t.Versions[n-1]
+				schemas = append(schemas, fmt.Sprintf("`%s` v%d (%s)", v.Subject, v.Version, v.Type))
+			}
+			if s := t.Schema; s != nil {
+				schemas = append(schemas, fmt.Sprintf("`%s` (%s), in [%s](%s/index.md) `%s:%d`", s.Name, s.Kind, s.Repo, s.Repo, s.File, s.Line))
+			}
+			schema := cmp.Or(strings.Join(schemas, "; "), "—")
+			var failures []string
+			if t.Failure != "" {
+				failures = append(failures, fmt.Sprintf("%s topic of `%s`", t.Failure, t.Of))
+			}
+			deadLetters, retries := m.failureTopics(t.Name)
+			if len(deadLetters) > 0 {
+				failures = append(failures, "dead letters to "+codeList(deadLetters))
+			}
+			if len(retries) > 0 {
+				failures = append(failures, "retries on "+codeList(retries))
+			}
+			if len(failures) == 0 {
+				failures = []string{"—"}
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n", t.Name, strings.Join(t.Brokers, ", "), g.serviceLinks(t.Producers), g.serviceLinks(t.Consumers), schema, strings.Join(failures, "; "))
+		}
+		b.WriteString("\n")
+
+		g.writeSchemaHistory(&b, m)
+
+		b.WriteString("## By Service\n\n")
+		b.WriteString("| Service | Produces | Consumes |\n")
+		b.WriteString("|---------|----------|----------|\n")
+		for _, r := range g.Repos {
+			var produces, consumes []string
+			for _, t := range m.topics {
+				if slices.Contains(t.Producers, r.Name) {
+					produces = append(produces, t.Name)
+				}
+				if slices.Contains(t.Consumers, r.Name) {
+					consumes = append(consumes, t.Name)
+				}
+			}
+			if len(produces) == 0 && len(consumes) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "| [%s](%s/index.md) | %s | %s |\n", r.Name, r.Name, cmp.Or(codeList(produces), "—"), cmp.Or(codeList(consumes), "—"))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.unnamed) > 0 {
+		b.WriteString("## Unnamed Channels\n\n")
+		b.WriteString("Async links whose topics weren't detected:\n\n")
+		for _, l := range m.unnamed {
+			fmt.Fprintf(&b, "- %s → %s (%s)\n", l.FromRepo, l.ToRepo, l.LinkType)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "events.md"), []byte(b.String()), 0o644)
+}
+
+// writeSchemaHistory lists the recorded versions of each topic's schema,
+// newest first, with the fields each added, removed, or changed. Failure
+// topics, which carry their base topic's schema, are left out.
+func (g *CentralSiteGenerator) writeSchemaHistory(b *strings.Builder, m *eventModel) {
+	var topics []eventTopic
+	for _, t := range m.topics {
+		if t.Failure == "" && len(t.Versions) > 0 {
+			topics = append(topics, t)
+		}
+	}
+	if len(topics) == 0 {
+		return
+	}
+	b.WriteString("## Schema History\n\n")
+	b.WriteString("The versions of each topic's payload schema seen in the schema registry or the repos' schema files, with how each changed the fields.\n\n")
+	for _, t := range topics {
+		fmt.Fprintf(b, "### `%s`\n\n", t.Name)
+		b.WriteString("| Version | Seen | Changes |\n")
+		b.WriteString("|---------|------|---------|\n")
+		for i := len(t.Versions) - 1; i >= 0; i-- {
+			v := t.Versions[i]
+			changes := "first seen"
+			if i > 0 {
+				var list []string
+				for _, c := range v.Changes {
+					list = append(list, c.String())
+				}
+				changes = cmp.Or(strings.Join(list, "; "), "no field changes")
+			}
+			fmt.Fprintf(b, "| %s | %s | %s |\n", strings.ReplaceAll(v.Label(), "|", `\|`), v.SeenAt.Format("2006-01-02"), strings.ReplaceAll(changes, "|", `\|`))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// codeList renders names as a comma-separated list of code spans.
+func codeList(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(names, "`, `") + "`"
+}
+
+// serviceLinks renders service names as links to their docs, when they
+// are registered.
+func (g *CentralSiteGenerator) serviceLinks(names []string) string {
+	links := make([]string, len(names))
+	for i, n := range names {
+		links[i] = n
+		if slices.ContainsFunc(g.Repos, func(r RepoInfo) bool { return r.Name == n }) {
+			links[i] = fmt.Sprintf("[%s](%s/index.md)", n, n)
+		}
+	}
+	return strings.Join(links, ", ")
+}
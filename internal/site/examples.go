@@ -0,0 +1,384 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+const (
+	// maxExamplesPerEndpoint caps how many call sites an endpoint shows.
+	maxExamplesPerEndpoint = 6
+	// maxSnippetLines caps a snippet's length; longer enclosing functions
+	// fall back to a window around the call.
+	maxSnippetLines = 40
+)
+
+// CallExample is a call to an endpoint, taken from a caller's source.
+type CallExample struct {
+	Caller   string
+	Language string
+	File     string // repo-relative path of the calling file
+	Line     int    // 1-based line of the call
+	Code     string
+}
+
+// routeMarkers appear on lines that register a route rather than call one;
+// a caller that also serves the path (a gateway, say) shouldn't offer its
+// own route table as an example.
+var routeMarkers = []string{
+	"HandleFunc(", "Handle(", "@app.", "@router.", "app.get(", "app.post(", "router.",
+	"Mapping(", "@Path(", "route(", "Route(",
+}
+
+// harvestCallExamples finds, for every endpoint a link names, call sites in
+// the calling repo's analyzed source files. The result is keyed by target
+// repo, then endpoint.
+func (g *CentralSiteGenerator) harvestCallExamples() map[string]map[string][]CallExample {
+	byName := make(map[string]RepoInfo, len(g.Repos))
+	for _, r := range g.Repos {
+		byName[r.Name] = r
+	}
+
+	examples := make(map[string]map[string][]CallExample)
+	for _, link := range g.Links {
+		caller, ok := byName[link.FromRepo]
+		if !ok || caller.DocsDir == "" || len(link.Endpoints) == 0 {
+			continue
+		}
+		src := g.callerSource(caller)
+		if src == nil {
+			continue
+		}
+		for _, endpoint := range link.Endpoints {
+			ex, ok := src.find(endpoint)
+			if !ok {
+				continue
+			}
+			ex.Caller = caller.Name
+			if examples[link.ToRepo] == nil {
+				examples[link.ToRepo] = make(map[string][]CallExample)
+			}
+			if len(examples[link.ToRepo][endpoint]) < maxExamplesPerEndpoint {
+				examples[link.ToRepo][endpoint] = append(examples[link.ToRepo][endpoint], ex)
+			}
+		}
+	}
+	return examples
+}
+
+// callerSource is a caller repo's analyzed files, read on demand.
+type callerSource struct {
+	root     string
+	files    []string // repo-relative, sorted
+	analyses map[string]indexer.FileAnalysis
+	lines    map[string][]string
+}
+
+// callerSource returns the repo's source, loading it the first time; nil
+// when the repo has no analyses.
+func (g *CentralSiteGenerator) callerSource(repo RepoInfo) *callerSource {
+	src, ok := g.sources[repo.Name]
+	if !ok {
+		src = loadCallerSource(repo, g.repoAnalyses(repo))
+		if g.sources == nil {
+			g.sources = make(map[string]*callerSource)
+		}
+		g.sources[repo.Name] = src
+	}
+	return src
+}
+
+// loadCallerSource returns nil when the repo has no analyses to go by.
+func loadCallerSource(repo RepoInfo, analyses map[string]indexer.FileAnalysis) *callerSource {
+	if len(analyses) == 0 {
+		return nil
+	}
+	src := &callerSource{root: repo.sourceRoot(), analyses: analyses, lines: make(map[string][]string)}
+	for p, a := range analyses {
+		if a.Skip || isTestPath(p) {
+			continue
+		}
+		src.files = append(src.files, p)
+	}
+	sort.Strings(src.files)
+	return src
+}
+
+// find returns the first call site of endpoint, in path order.
+func (s *callerSource) find(endpoint string) (CallExample, bool) {
+	pattern := endpointPattern(endpoint)
+	if pattern == nil {
+		return CallExample{}, false
+	}
+	for _, p := range s.files {
+		lines := s.fileLines(p)
+		for i, line := range lines {
+			if !pattern.MatchString(line) || isRouteLine(line) {
+				continue
+			}
+			a := s.analyses[p]
+			start, end := snippetRange(a, i, len(lines))
+			return CallExample{
+				Language: a.Language,
+				File:     p,
+				Line:     i + 1,
+				Code:     dedent(lines[start:end]),
+			}, true
+		}
+	}
+	return CallExample{}, false
+}
+
+// fileLines returns the lines of p, reading it the first time.
+func (s *callerSource) fileLines(p string) []string {
+	lines, ok := s.lines[p]
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(p)))
+		if err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		}
+		s.lines[p] = lines
+	}
+	return lines
+}
+
+// endpointPattern matches an endpoint's appearance in calling code:
+//   - an HTTP path such as "POST /api/orders/{id}" by its literal path, or
+//     by its static prefix up to the first path parameter;
+//   - a gRPC method such as "orders.OrderService/CreateOrder" or a bare
+//     method name by a call to that name;
+//   - anything else, such as a topic, by a quoted string literal.
+//
+// It returns nil when the endpoint is too generic to search for.
+func endpointPattern(endpoint string) *regexp.Regexp {
+	fields := strings.Fields(endpoint)
+	if len(fields) == 0 {
+		return nil
+	}
+	target := fields[len(fields)-1]
+
+	if strings.HasPrefix(target, "/") {
+		target, _, _ = strings.Cut(target, "?")
+		segs := strings.Split(strings.Trim(target, "/"), "/")
+		var static []string
+		for _, seg := range segs {
+			if isPathParam(seg) {
+				break
+			}
+			static = append(static, seg)
+		}
+		if len(static) == 0 || static[0] == "" {
+			return nil
+		}
+		prefix := "/" + strings.Join(static, "/")
+		if len(static) < len(segs) {
+			return regexp.MustCompile(regexp.QuoteMeta(prefix + "/"))
+		}
+		return regexp.MustCompile(regexp.QuoteMeta(prefix) + `(?:[^\w/-]|$)`)
+	}
+
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		target = target[i+1:]
+	}
+	if isIdentifier(target) {
+		if len(target) < 4 {
+			return nil
+		}
+		return regexp.MustCompile(`\b` + regexp.QuoteMeta(target) + `\s*\(`)
+	}
+	return regexp.MustCompile("[\"'`]" + regexp.QuoteMeta(target) + "[\"'`]")
+}
+
+func isPathParam(seg string) bool {
+	return strings.HasPrefix(seg, "{") || strings.HasPrefix(seg, ":") ||
+		strings.HasPrefix(seg, "<") || seg == "*"
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func isRouteLine(line string) bool {
+	for _, m := range routeMarkers {
+		if strings.Contains(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestPath reports whether a file follows a common test naming
+// convention; tests call endpoints through mocks, not real clients.
+func isTestPath(p string) bool {
+	base := strings.ToLower(filepath.Base(p))
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.HasSuffix(stem, "_test") || strings.HasPrefix(stem, "test_") ||
+		strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec")
+}
+
+// snippetRange picks the lines to show for a call on line i (0-based): the
+// enclosing function when it is short enough, otherwise a window around
+// the call.
+func snippetRange(a indexer.FileAnalysis, i, n int) (start, end int) {
+	funcs := append([]indexer.FunctionDoc(nil), a.Functions...)
+	for _, c := range a.Classes {
+		funcs = append(funcs, c.Methods...)
+	}
+	best := -1
+	for j, fn := range funcs {
+		if fn.LineStart == 0 || fn.LineStart > i+1 || fn.LineEnd < i+1 || fn.LineEnd-fn.LineStart >= maxSnippetLines {
+			continue
+		}
+		if best < 0 || fn.LineEnd-fn.LineStart < funcs[best].LineEnd-funcs[best].LineStart {
+			best = j
+		}
+	}
+	if best >= 0 {
+		return funcs[best].LineStart - 1, min(funcs[best].LineEnd, n)
+	}
+	return max(i-3, 0), min(i+9, n)
+}
+
+// dedent removes the indentation common to all non-blank lines.
+func dedent(lines []string) string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		}
+		out[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// writeEndpointsPage writes <repo>/endpoints.md: each endpoint other
+// services call, who calls it, and how, with one code tab per language.
+func (g *CentralSiteGenerator) writeEndpointsPage(destDir string, repo RepoInfo, examples map[string][]CallExample) error {
+	callers := make(map[string][]string)
+	for _, l := range g.Links {
+		if l.ToRepo != repo.Name {
+			continue
+		}
+		for _, e := range l.Endpoints {
+			callers[e] = append(callers[e], l.FromRepo)
+		}
+	}
+	endpoints := make([]string, 0, len(callers))
+	for e := range callers {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+
+	displayName := repo.DisplayName
+	if displayName == "" {
+		displayName = repo.Name
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s Endpoints\n\n", displayName)
+	b.WriteString("The endpoints other services call, with call sites taken from their source.\n\n")
+	for _, e := range endpoints {
+		fmt.Fprintf(&b, "## %s\n\n", e)
+		fmt.Fprintf(&b, "Called by: %s\n\n", strings.Join(uniqueSorted(callers[e]), ", "))
+		if exs := examples[e]; len(exs) > 0 {
+			b.WriteString("### How other services call this\n\n")
+			writeCodeTabs(&b, exs)
+		}
+	}
+	return os.WriteFile(filepath.Join(destDir, "endpoints.md"), []byte(b.String()), 0o644)
+}
+
+// writeCodeTabs groups examples by language into a tab set; the site's
+// script turns each .code-tab into a tab.
+func writeCodeTabs(b *strings.Builder, examples []CallExample) {
+	var langs []string
+	byLang := make(map[string][]CallExample)
+	for _, ex := range examples {
+		lang := ex.Language
+		if lang == "" {
+			lang = "Other"
+		}
+		if byLang[lang] == nil {
+			langs = append(langs, lang)
+		}
+		byLang[lang] = append(byLang[lang], ex)
+	}
+	sort.Strings(langs)
+
+	b.WriteString("<div class=\"code-tabs\">\n")
+	for _, lang := range langs {
+		fmt.Fprintf(b, "<div class=\"code-tab\" data-label=\"%s\">\n\n", html.EscapeString(lang))
+		for _, ex := range byLang[lang] {
+			fmt.Fprintf(b, "**%s** — `%s:%d`\n\n", ex.Caller, ex.File, ex.Line)
+			fence := codeFence(ex.Code)
+			fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, fenceLanguage(ex.Language), ex.Code, fence)
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</div>\n\n")
+}
+
+// codeFence returns a backtick fence longer than any run inside code.
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// fenceLanguage maps a detected language name to a highlighter name.
+func fenceLanguage(language string) string {
+	switch language {
+	case "C++":
+		return "cpp"
+	case "C#":
+		return "csharp"
+	case "Shell":
+		return "bash"
+	}
+	return strings.ToLower(language)
+}
+
+func uniqueSorted(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	var out []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
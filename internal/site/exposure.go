@@ -0,0 +1,257 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/overrides"
+)
+
+// endpointExposure is whether one of a service's endpoints requires
+// authentication, and why we think so.
+type endpointExposure struct {
+	Route    string
+	Auth     string // an indexer.Auth* state
+	Evidence string
+	// Source is where the evidence is: the file and line registering the
+	// route, the config file with the deciding rule, or the correction.
+	Source string
+}
+
+// repoAuth is what a repo's source and config say about authentication.
+type repoAuth struct {
+	endpoints []endpointExposure
+	rules     []indexer.AuthRule // the repo's own security config, in order
+	sources   []string           // the file each rule is from
+}
+
+// assessExposure finds each repo's HTTP endpoints and whether they require
+// authentication, by repo name. Evidence at the route wins; then the
+// repo's security config, where the first matching rule decides; then the
+// API gateways and proxies anywhere in the org, where the most specific
+// rule decides. Corrections override all of them.
+func (g *CentralSiteGenerator) assessExposure() map[string][]endpointExposure {
+	scanned := make([]repoAuth, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		seen := make(map[string]bool)
+		for _, f := range g.repoScan(g.Repos[i]) {
+			for _, e := range f.Auth {
+				if seen[e.Route] {
+					continue
+				}
+				seen[e.Route] = true
+				scanned[i].endpoints = append(scanned[i].endpoints, endpointExposure{
+					Route: e.Route, Auth: e.Auth, Evidence: e.Evidence, Source: fmt.Sprintf("%s:%d", f.Path, e.Line),
+				})
+			}
+			for _, r := range f.Rules {
+				scanned[i].rules = append(scanned[i].rules, r)
+				scanned[i].sources = append(scanned[i].sources, f.Path)
+			}
+		}
+	})
+
+	type gatewayRule struct {
+		indexer.AuthRule
+		source string
+	}
+	var gateways []gatewayRule
+	for i, r := range g.Repos {
+		for j, rule := range scanned[i].rules {
+			if rule.Gateway {
+				gateways = append(gateways, gatewayRule{rule, r.Name + "/" + scanned[i].sources[j]})
+			}
+		}
+	}
+
+	exposure := make(map[string][]endpointExposure)
+	for i, repo := range g.Repos {
+		endpoints := scanned[i].endpoints
+		for k := range endpoints {
+			e := &endpoints[k]
+			if e.Auth != indexer.AuthNone {
+				continue
+			}
+			decided := false
+			for j, rule := range scanned[i].rules {
+				if !rule.Gateway && rule.Matches(e.Route) {
+					e.Auth, e.Evidence, e.Source = rule.Auth, rule.Evidence, scanned[i].sources[j]
+					decided = true
+					break
+				}
+			}
+			if decided {
+				continue
+			}
+			var best *gatewayRule
+			for j := range gateways {
+				if gateways[j].Matches(e.Route) && (best == nil || len(gateways[j].Pattern) > len(best.Pattern)) {
+					best = &gateways[j]
+				}
+			}
+			if best != nil {
+				e.Auth, e.Evidence, e.Source = best.Auth, best.Evidence, best.source
+			}
+		}
+		endpoints = g.applyAuthOverrides(repo.Name, endpoints)
+		if len(endpoints) > 0 {
+			exposure[repo.Name] = endpoints
+		}
+	}
+	return exposure
+}
+
+// applyAuthOverrides applies the authentication corrections for a service
+// to its endpoints. A correction for one endpoint beats one for all of
+// them; a correction for an endpoint analysis didn't find adds it.
+func (g *CentralSiteGenerator) applyAuthOverrides(service string, endpoints []endpointExposure) []endpointExposure {
+	var all *overrides.Override
+	specific := make(map[string]overrides.Override)
+	for _, o := range g.Overrides {
+		if !o.IsAuth() || !strings.EqualFold(o.Service, service) {
+			continue
+		}
+		if o.Endpoint == "*" {
+			all = &o
+		} else {
+			specific[strings.ToUpper(o.Endpoint)] = o
+		}
+	}
+	if all == nil && len(specific) == 0 {
+		return endpoints
+	}
+	apply := func(e *endpointExposure, o overrides.Override) {
+		switch {
+		case o.Public:
+			e.Auth = indexer.AuthPublic
+		case o.Remove:
+			e.Auth = indexer.AuthNone
+		default:
+			e.Auth = indexer.AuthRequired
+		}
+		e.Evidence, e.Source = o.AuthBy, "Corrected by "+o.Provenance()
+	}
+	for k := range endpoints {
+		if o, ok := specific[strings.ToUpper(endpoints[k].Route)]; ok {
+			apply(&endpoints[k], o)
+			delete(specific, strings.ToUpper(endpoints[k].Route))
+		} else if all != nil {
+			apply(&endpoints[k], *all)
+		}
+	}
+	added := make([]overrides.Override, 0, len(specific))
+	for _, o := range specific {
+		added = append(added, o)
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].Endpoint < added[j].Endpoint })
+	for _, o := range added {
+		e := endpointExposure{Route: o.Endpoint}
+		apply(&e, o)
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// internetFacing returns the services traffic from outside reaches
+// directly: frontends and public APIs.
+func (g *CentralSiteGenerator) internetFacing() map[string]bool {
+	facing := make(map[string]bool)
+	for _, e := range g.entryPoints {
+		if e.Kind == EntryPublicAPI || e.Kind == EntryUI {
+			facing[e.Service] = true
+		}
+	}
+	return facing
+}
+
+// authLabels name the authentication states on the site.
+var authLabels = map[string]string{
+	indexer.AuthRequired: "Required",
+	indexer.AuthPublic:   "Public on purpose",
+	indexer.AuthNone:     "None found",
+}
+
+// writeExposurePage writes exposure.md: the endpoints of internet-facing
+// services that nothing requires authentication for, then every service's
+// endpoints and what decided each.
+func (g *CentralSiteGenerator) writeExposurePage(stagingDir string) error {
+	facing := g.internetFacing()
+	var services []string
+	total := 0
+	type flagged struct{ service, route, source string }
+	var open []flagged
+	for _, r := range g.Repos {
+		endpoints := g.exposure[r.Name]
+		if len(endpoints) == 0 {
+			continue
+		}
+		services = append(services, r.Name)
+		total += len(endpoints)
+		if !facing[r.Name] {
+			continue
+		}
+		for _, e := range endpoints {
+			if e.Auth == indexer.AuthNone {
+				open = append(open, flagged{r.Name, e.Route, e.Source})
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Exposure Report\n\n")
+	fmt.Fprintf(&b, "%d HTTP endpoints across %d services, and whether each requires authentication: from middleware, decorators and annotations in the source, security config, and API gateway and proxy config. ", total, len(services))
+	fmt.Fprintf(&b, "%d endpoints on internet-facing services have no authentication found.\n\n", len(open))
+
+	b.WriteString("## Exposed Without Authentication\n\n")
+	if len(open) == 0 {
+		b.WriteString("Every endpoint of the internet-facing services requires authentication or is public on purpose.\n\n")
+	} else {
+		b.WriteString("These endpoints are on services that traffic from outside reaches directly, and nothing found requires authentication for them. Check each is meant to be open.\n\n")
+		b.WriteString("| Service | Endpoint | Registered in |\n")
+		b.WriteString("|---------|----------|---------------|\n")
+		for _, f := range open {
+			fmt.Fprintf(&b, "| [%s](%s/index.md) | `%s` | %s |\n", f.service, f.service, f.route, exposureSource(f.source))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Endpoints by Service\n\n")
+	for _, name := range services {
+		fmt.Fprintf(&b, "### [%s](%s/index.md)", name, name)
+		if facing[name] {
+			b.WriteString(" — internet-facing")
+		}
+		b.WriteString("\n\n")
+		b.WriteString("| Endpoint | Authentication | Evidence | Source |\n")
+		b.WriteString("|----------|----------------|----------|--------|\n")
+		for _, e := range g.exposure[name] {
+			evidence := "—"
+			if e.Evidence != "" {
+				evidence = "`" + strings.ReplaceAll(e.Evidence, "|", `\|`) + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", e.Route, authLabels[e.Auth], evidence, exposureSource(e.Source))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Corrections\n\n")
+	b.WriteString("Analysis can miss authentication done elsewhere, such as by a gateway whose config isn't in a registered repo. ")
+	b.WriteString("Correct it in conversation, e.g. \"orders' `GET /health` is public on purpose\" or \"every payments endpoint is authenticated by the API gateway\"; corrections apply on every regeneration.\n")
+	return os.WriteFile(filepath.Join(stagingDir, "exposure.md"), []byte(b.String()), 0o644)
+}
+
+// exposureSource renders a Source cell: file locations as code, corrections
+// as they are.
+func exposureSource(source string) string {
+	switch {
+	case source == "":
+		return "—"
+	case strings.HasPrefix(source, "Corrected by "):
+		return "*" + source + "*"
+	}
+	return "`" + source + "`"
+}
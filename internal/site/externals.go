@@ -0,0 +1,170 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/vendors"
+)
+
+// externalUse is a service's use of an external vendor, and what the
+// service map says it uses it for.
+type externalUse struct {
+	vendors.Use
+	Purposes []string // the reasons of the service's links to the vendor
+}
+
+// loadExternals finds the external SaaS and cloud vendors each repo uses,
+// by repo name: from the SDKs in its manifests and imports, the API hosts
+// in its code, and its links to external nodes of the service map.
+func (g *CentralSiteGenerator) loadExternals() map[string][]externalUse {
+	registered := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		registered[strings.ToLower(r.Name)] = true
+	}
+	loaded := make([][]externalUse, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
+		analyses := g.repoAnalyses(repo)
+		// Config files are often marked as boilerplate, but name hosts, so
+		// the scan covers them.
+		hosts := make(map[string][]indexer.Host)
+		for _, f := range g.repoScan(repo) {
+			if len(f.Hosts) > 0 {
+				hosts[f.Path] = f.Hosts
+			}
+		}
+		for _, u := range vendors.Detect(analyses, g.dependencies[repo.Name], hosts) {
+			loaded[i] = append(loaded[i], externalUse{Use: u})
+		}
+		for _, l := range g.Links {
+			if !strings.EqualFold(l.FromRepo, repo.Name) || registered[strings.ToLower(l.ToRepo)] {
+				continue
+			}
+			vendor, category, product, ok := vendors.Lookup(l.ToRepo)
+			if !ok {
+				continue
+			}
+			j := slices.IndexFunc(loaded[i], func(u externalUse) bool { return u.Vendor == vendor })
+			if j < 0 {
+				j = len(loaded[i])
+				loaded[i] = append(loaded[i], externalUse{Use: vendors.Use{Vendor: vendor, Category: category}})
+			}
+			u := &loaded[i][j]
+			if product != "" && !slices.Contains(u.Products, product) {
+				u.Products = append(u.Products, product)
+				sort.Strings(u.Products)
+			}
+			if evidence := "calls " + l.ToRepo + " in the service map"; !slices.Contains(u.Evidence, evidence) {
+				u.Evidence = append(u.Evidence, evidence)
+			}
+			if l.Reason != "" && !slices.Contains(u.Purposes, l.Reason) {
+				u.Purposes = append(u.Purposes, l.Reason)
+			}
+		}
+		sort.SliceStable(loaded[i], func(a, b int) bool {
+			return cmp.Less(strings.ToLower(loaded[i][a].Vendor), strings.ToLower(loaded[i][b].Vendor))
+		})
+	})
+	externals := make(map[string][]externalUse)
+	for i, r := range g.Repos {
+		if len(loaded[i]) > 0 {
+			externals[r.Name] = loaded[i]
+		}
+	}
+	return externals
+}
+
+// externalVendor is a vendor and the services using it, for the External
+// Dependencies page.
+type externalVendor struct {
+	Name, Category string
+	Products       []string
+	Users          []string // repo names, in registry order
+}
+
+// externalVendors pivots the services' uses around their vendors, those
+// used by the most services first.
+func (g *CentralSiteGenerator) externalVendors() []externalVendor {
+	var out []externalVendor
+	for _, r := range g.Repos {
+		for _, u := range g.externals[r.Name] {
+			i := slices.IndexFunc(out, func(v externalVendor) bool { return v.Name == u.Vendor })
+			if i < 0 {
+				i = len(out)
+				out = append(out, externalVendor{Name: u.Vendor, Category: u.Category})
+			}
+			for _, p := range u.Products {
+				if !slices.Contains(out[i].Products, p) {
+					out[i].Products = append(out[i].Products, p)
+				}
+			}
+			out[i].Users = append(out[i].Users, r.Name)
+		}
+	}
+	for i := range out {
+		sort.Strings(out[i].Products)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if len(out[i].Users) != len(out[j].Users) {
+			return len(out[i].Users) > len(out[j].Users)
+		}
+		return cmp.Less(strings.ToLower(out[i].Name), strings.ToLower(out[j].Name))
+	})
+	return out
+}
+
+// externalLabel names a vendor with the products a service uses, e.g.
+// "AWS (S3, SQS)".
+func externalLabel(name string, products []string) string {
+	if len(products) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(products, ", "))
+}
+
+// writeExternalDependenciesPage writes external-dependencies.md, the
+// catalog of the vendors the system depends on, with the services using
+// each and what for, for vendor risk reviews.
+func (g *CentralSiteGenerator) writeExternalDependenciesPage(stagingDir string) error {
+	catalog := g.externalVendors()
+	displayNames := make(map[string]string, len(g.Repos))
+	for _, r := range g.Repos {
+		displayNames[r.Name] = cmp.Or(r.DisplayName, r.Name)
+	}
+
+	var b strings.Builder
+	b.WriteString("# External Dependencies\n\n")
+	fmt.Fprintf(&b, "The %d external SaaS and cloud vendors %d services depend on, found in the SDKs their package manifests declare and their source imports, the API hosts their code calls, and the service map. For a vendor risk review, each vendor lists the services an outage, breach, or price change would reach, and what they use it for.\n\n", len(catalog), len(g.externals))
+	b.WriteString("| Vendor | Category | Products | Services |\n")
+	b.WriteString("|--------|----------|----------|----------|\n")
+	for _, v := range catalog {
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %s | %d |\n", v.Name, teamSlug(v.Name), v.Category, cmp.Or(strings.Join(v.Products, ", "), "—"), len(v.Users))
+	}
+	b.WriteString("\n")
+
+	for _, v := range catalog {
+		fmt.Fprintf(&b, "## %s\n\n", v.Name)
+		services := "1 service"
+		if len(v.Users) != 1 {
+			services = fmt.Sprintf("%d services", len(v.Users))
+		}
+		fmt.Fprintf(&b, "%s, used by %s.\n\n", v.Category, services)
+		b.WriteString("| Service | Uses | Used For | Evidence |\n")
+		b.WriteString("|---------|------|----------|----------|\n")
+		for _, name := range v.Users {
+			i := slices.IndexFunc(g.externals[name], func(u externalUse) bool { return u.Vendor == v.Name })
+			u := g.externals[name][i]
+			fmt.Fprintf(&b, "| [%s](%s/index.md) | %s | %s | %s |\n", displayNames[name], name,
+				cmp.Or(strings.Join(u.Products, ", "), "—"), cmp.Or(strings.ReplaceAll(strings.Join(u.Purposes, "; "), "|", `\|`), "—"), knobSources(u.Evidence))
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "external-dependencies.md"), []byte(b.String()), 0o644)
+}
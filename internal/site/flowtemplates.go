@@ -0,0 +1,306 @@
+package site
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+// FlowTemplate names the flow around an orchestrator service and groups
+// the orchestrator's calls into phases. See config.FlowTemplateConfig.
+type FlowTemplate struct {
+	Name         string
+	Orchestrator string   // substring of the orchestrator's name
+	Exclude      []string // skip orchestrators whose names contain any of these
+	Phases       []FlowPhase
+	Narrative    string        // text/template; empty to have the LLM write it
+	Budget       time.Duration // end-to-end latency budget; zero for the orchestrator's SLO
+}
+
+// FlowPhase is one step of a flow template.
+type FlowPhase struct {
+	Name       string
+	Services   []string // substrings of the target services' names
+	Sequential bool     // the phase's calls run one after another
+}
+
+// flowCall is an orchestrator's call to one of its targets.
+type flowCall struct {
+	to   string // lower-cased target name
+	link LinkInfo
+}
+
+// phaseCalls are the calls that fall into one phase of a flow. The last
+// phase of a templated flow may be unnamed, holding calls no phase matched.
+type phaseCalls struct {
+	name       string
+	sequential bool
+	calls      []flowCall
+}
+
+// matchesOrchestrator reports whether svc (lower-cased) is the template's
+// orchestrator.
+func (t FlowTemplate) matchesOrchestrator(svc string) bool {
+	if !strings.Contains(svc, strings.ToLower(t.Orchestrator)) {
+		return false
+	}
+	for _, ex := range t.Exclude {
+		if ex != "" && strings.Contains(svc, strings.ToLower(ex)) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignPhases puts each call in the first phase with a service pattern
+// matching its target, in phase order. Phases without calls are dropped;
+// unmatched calls go in a trailing unnamed phase.
+func assignPhases(phases []FlowPhase, calls []flowCall) []phaseCalls {
+	out := make([]phaseCalls, len(phases))
+	for i, p := range phases {
+		out[i].name, out[i].sequential = p.Name, p.Sequential
+	}
+	var rest []flowCall
+	for _, c := range calls {
+		placed := false
+		for i, p := range phases {
+			for _, pat := range p.Services {
+				if pat != "" && strings.Contains(c.to, strings.ToLower(pat)) {
+					out[i].calls = append(out[i].calls, c)
+					placed = true
+					break
+				}
+			}
+			if placed {
+				break
+			}
+		}
+		if !placed {
+			rest = append(rest, c)
+		}
+	}
+	kept := out[:0]
+	for _, p := range out {
+		if len(p.calls) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	if len(rest) > 0 {
+		kept = append(kept, phaseCalls{calls: rest})
+	}
+	return kept
+}
+
+// phasedDiagram draws the orchestrator's calls as a sequence diagram, with
+// a note opening each named phase and the calls of a phase that aren't
+// sequential in a par block.
+func phasedDiagram(orch string, phases []phaseCalls, displayName func(string) string) string {
+	var d strings.Builder
+	d.WriteString("sequenceDiagram\n")
+	fmt.Fprintf(&d, "    participant %s\n", orch)
+	added := map[string]bool{orch: true}
+	for _, p := range phases {
+		for _, c := range p.calls {
+			if dn := displayName(c.to); !added[dn] {
+				fmt.Fprintf(&d, "    participant %s\n", dn)
+				added[dn] = true
+			}
+		}
+	}
+	for _, p := range phases {
+		if p.name != "" {
+			fmt.Fprintf(&d, "    Note over %s: %s\n", orch, p.name)
+		}
+		steps := sequentialSteps(p.calls)
+		if !p.sequential && len(p.calls) > 1 {
+			steps = []callStep{{calls: p.calls, parallel: true}}
+		}
+		writeCallSteps(&d, "    ", orch, steps, displayName, nil)
+	}
+	return d.String()
+}
+
+// flowNarrativeData is what a template's narrative skeleton can refer to.
+type flowNarrativeData struct {
+	Orchestrator   string
+	Targets        []string
+	TargetCount    int
+	Phases         []flowPhaseData
+	CriticalPath   string // e.g. "230ms"
+	CriticalPathMs int
+}
+
+type flowPhaseData struct {
+	Name     string
+	Services []string
+}
+
+var narrativeFuncs = template.FuncMap{
+	"join": strings.Join,
+	"mul":  func(a, b int) int { return a * b },
+}
+
+// templateNarrative renders a template's narrative skeleton.
+func templateNarrative(skeleton, orch string, phases []phaseCalls, latency *LatencyModel, displayName func(string) string) (string, error) {
+	tmpl, err := template.New("narrative").Funcs(narrativeFuncs).Parse(skeleton)
+	if err != nil {
+		return "", err
+	}
+	data := flowNarrativeData{Orchestrator: orch}
+	if latency != nil {
+		total, _ := latency.CriticalPath()
+		data.CriticalPath, data.CriticalPathMs = formatLatency(total), int(total/time.Millisecond)
+	}
+	for _, p := range phases {
+		pd := flowPhaseData{Name: p.name}
+		for _, c := range p.calls {
+			pd.Services = append(pd.Services, displayName(c.to))
+		}
+		data.Targets = append(data.Targets, pd.Services...)
+		if p.name != "" {
+			data.Phases = append(data.Phases, pd)
+		}
+	}
+	data.TargetCount = len(data.Targets)
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// narrativeCacheEntry keeps an LLM-written narrative until its prompt, and
+// so the flow's calls, changes.
+type narrativeCacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Narrative   string `json:"narrative"`
+}
+
+// flowNarrative writes the narrative of a flow without a skeleton: by the
+// LLM when a provider is set, otherwise, or when the call fails, from the
+// calls themselves.
+func (g *CentralSiteGenerator) flowNarrative(flowName, orch string, phases []phaseCalls, displayName func(string) string) string {
+	if g.Provider == nil {
+		return fallbackFlowNarrative(orch, phases, displayName)
+	}
+	narrative, ok := g.cachedCompletion(flowName, "You document how requests flow through a microservice system. Write for engineers new to the system. Use only the services and calls given.", flowNarrativePrompt(flowName, orch, phases, displayName), 1024)
+	if !ok {
+		return fallbackFlowNarrative(orch, phases, displayName)
+	}
+	return narrative
+}
+
+// cachedCompletion asks the LLM to complete prompt, keeping the answer in
+// the narrative cache under key until the prompt changes. It reports false
+// when the call fails or the answer is empty.
+func (g *CentralSiteGenerator) cachedCompletion(key, system, prompt string, maxTokens int) (string, bool) {
+	sum := sha256.Sum256([]byte(prompt))
+	fingerprint := hex.EncodeToString(sum[:])
+	if g.narratives == nil {
+		g.narratives = make(map[string]narrativeCacheEntry)
+		if g.NarrativeCache != "" {
+			if data, err := os.ReadFile(g.NarrativeCache); err == nil {
+				_ = json.Unmarshal(data, &g.narratives)
+			}
+		}
+	}
+	// Audience-filtered variants see fewer calls, so they keep their own entries.
+	if g.Audience != "" {
+		key += " @" + g.Audience
+	}
+	if e, ok := g.narratives[key]; ok && e.Fingerprint == fingerprint {
+		return e.Narrative, true
+	}
+
+	resp, err := g.Provider.Complete(context.Background(), llm.CompletionRequest{
+		Model: g.Model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: system},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: 0.2,
+	})
+	if err != nil || strings.TrimSpace(resp.Content) == "" {
+		return "", false
+	}
+	answer := strings.TrimSpace(resp.Content)
+	g.narratives[key] = narrativeCacheEntry{Fingerprint: fingerprint, Narrative: answer}
+	return answer, true
+}
+
+// saveNarratives writes the narrative cache, if one is configured and any
+// narrative was written.
+func (g *CentralSiteGenerator) saveNarratives() {
+	if g.NarrativeCache == "" || len(g.narratives) == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(g.narratives, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(g.NarrativeCache), 0o755); err == nil {
+		_ = os.WriteFile(g.NarrativeCache, data, 0o644)
+	}
+}
+
+func flowNarrativePrompt(flowName, orch string, phases []phaseCalls, displayName func(string) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Flow: %s\nOrchestrator: %s\n\nCalls made by %s", flowName, orch, orch)
+	if len(phases) > 1 || len(phases) == 1 && phases[0].name != "" {
+		b.WriteString(", grouped into phases in order")
+	}
+	b.WriteString(":\n")
+	for _, p := range phases {
+		if p.name != "" {
+			fmt.Fprintf(&b, "\n%s\n", p.name)
+		} else if len(phases) > 1 {
+			b.WriteString("\nOther calls\n")
+		}
+		for _, c := range p.calls {
+			fmt.Fprintf(&b, "- %s (%s): %s", displayName(c.to), c.link.LinkType, operationLabel(c.link))
+			if c.link.Reason != "" {
+				b.WriteString(" — " + c.link.Reason)
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\nWrite the narrative for this flow in Markdown without headings: a one-sentence overview, then one short paragraph per phase or step with a bold label, saying what each call is for. Note which calls can run in parallel and which must be sequential, and end with the critical path.")
+	return b.String()
+}
+
+// fallbackFlowNarrative describes a flow from its calls alone.
+func fallbackFlowNarrative(orch string, phases []phaseCalls, displayName func(string) string) string {
+	var all []string
+	for _, p := range phases {
+		for _, c := range p.calls {
+			all = append(all, displayName(c.to))
+		}
+	}
+	if len(phases) == 1 && phases[0].name == "" {
+		return fmt.Sprintf("%s coordinates with %d services: %s.", orch, len(all), strings.Join(all, ", "))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s coordinates with %d services in %d steps:\n", orch, len(all), len(phases))
+	for _, p := range phases {
+		name := p.name
+		if name == "" {
+			name = "Other calls"
+		}
+		calls := make([]string, len(p.calls))
+		for i, c := range p.calls {
+			calls[i] = fmt.Sprintf("%s (%s)", displayName(c.to), operationLabel(c.link))
+		}
+		fmt.Fprintf(&b, "\n**%s:** %s\n", name, strings.Join(calls, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -1,18 +1,27 @@
 package site
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
 )
 
 // SiteGenerator converts markdown documentation into a static HTML site.
@@ -21,8 +30,51 @@ type SiteGenerator struct {
 	OutputDir   string
 	ProjectName string
 	LogoPath    string // Path to a logo image file (relative to project root).
+	// ShardSearch always splits the search index into per-directory shards,
+	// even when it is below the size threshold.
+	ShardSearch bool
+	// Artifacts, when set, renders through the artifact store: unchanged
+	// pages are reused from earlier runs, and files a previous run wrote
+	// that this one no longer produces are removed from OutputDir.
+	Artifacts *artifacts.Store
+	// Published is filled in by Generate when Artifacts is set.
+	Published artifacts.PublishStats
+	// Languages are the languages the site is published in, the source
+	// language first; with more than one, every page links to itself in
+	// the others. Language is the code of the one being generated.
+	Languages []SiteLanguage
+	Language  string
+	// Translator, when set, translates the docs into each of the other
+	// Languages, and a copy of the site is generated in each one's Dir.
+	Translator *Translator
+	// Concurrency is how many pages are rendered at once; zero means one
+	// per CPU.
+	Concurrency int
+	// Assets, when set, vendors D3 and Mermaid into the site or adds
+	// integrity hashes to their CDN script tags; see Assets.
+	Assets *Assets
+	// Theme, when set, brands the site; see Theme.
+	Theme *Theme
+	// SectionLogos maps top-level directories, such as the repos of the
+	// central site, to logo images shown on their pages.
+	SectionLogos map[string]string
+	// Nav retitles and reorders pages and directories in the sidebar, by
+	// path relative to DocsDir.
+	Nav map[string]NavEntry
+	// SectionGroups nests top-level directories under groups in the
+	// sidebar, e.g. a repo under its domain and bounded context, without
+	// moving their pages.
+	SectionGroups map[string][]string
+	// Validation, when set, collects the docs' broken links and Mermaid
+	// diagrams that don't parse, and the site gets a page listing them
+	// along with any issues already in it.
+	Validation *ValidationReport
 }
 
+// renderVersion is part of every page's memo key; bump it when page
+// rendering changes in a way the inputs do not capture.
+const renderVersion = "1"
+
 // NewSiteGenerator creates a SiteGenerator with the given directories.
 func NewSiteGenerator(docsDir, outputDir, projectName string) *SiteGenerator {
 	return &SiteGenerator{
@@ -37,13 +89,61 @@ type pageData struct {
 	Title       string
 	ProjectName string
 	LogoFile    string // Filename of the logo in the output dir (empty if none).
+	Lang        string
+	Languages   []languageLink
 	Content     template.HTML
 	TreeHTML    template.HTML
 	BasePath    string
+	Theme       themeFiles
+	Section     pageSection
+}
+
+// pageContext is what every page of a site is rendered with.
+type pageContext struct {
+	tmpl     *template.Template
+	tree     *FileTree
+	outDir   string
+	logoFile string
+	// pageSet is every page's path; it decides which links survive
+	// post-processing, so it is part of every page's memo key.
+	pageSet  string
+	theme    themeFiles
+	sections map[string]pageSection
+	// virtual are pages generated with the site rather than read from the
+	// docs, by path.
+	virtual map[string]string
 }
 
 // Generate builds the full static site from markdown files. Returns the number of pages generated.
 func (g *SiteGenerator) Generate() (int, error) {
+	if g.Artifacts == nil {
+		return g.generate(g.OutputDir)
+	}
+
+	// Render into a staging directory and publish from there, so OutputDir
+	// only sees the files that changed.
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(g.OutputDir)), 0o755); err != nil {
+		return 0, err
+	}
+	stageDir, err := os.MkdirTemp(filepath.Dir(filepath.Clean(g.OutputDir)), ".staging-site-")
+	if err != nil {
+		return 0, fmt.Errorf("creating staging dir: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	n, err := g.generate(stageDir)
+	if err != nil {
+		return 0, err
+	}
+	g.Published, err = g.Artifacts.Publish(context.Background(), stageDir, g.OutputDir)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// generate writes the site into outDir.
+func (g *SiteGenerator) generate(outDir string) (int, error) {
 	// Collect all markdown file paths.
 	var mdPaths []string
 	err := filepath.Walk(g.DocsDir, func(path string, info os.FileInfo, err error) error {
@@ -71,15 +171,31 @@ func (g *SiteGenerator) Generate() (int, error) {
 	titleMap := make(map[string]string)
 	for _, relPath := range mdPaths {
 		srcPath := filepath.Join(g.DocsDir, filepath.FromSlash(relPath))
-		content, err := os.ReadFile(srcPath)
-		if err == nil {
-			title := extractTitle(string(content), relPath)
+		if title, err := readTitle(srcPath, relPath); err == nil {
 			titleMap[relPath] = title
 		}
 	}
 
+	// Check the docs before they're rendered, and publish the report as a
+	// page of its own at the end of the sidebar.
+	nav := g.Nav
+	var virtual map[string]string
+	if g.Validation != nil {
+		g.Validation.checkDocs(g.DocsDir, mdPaths, g.Concurrency)
+		virtual = map[string]string{validationPage: g.Validation.markdown()}
+		mdPaths = append(mdPaths, validationPage)
+		titleMap[validationPage] = "Site Validation"
+		nav = maps.Clone(nav)
+		if nav == nil {
+			nav = make(map[string]NavEntry)
+		}
+		nav[validationPage] = NavEntry{Order: 1}
+	}
+
 	// Build file tree for sidebar navigation.
 	tree := BuildTree(mdPaths, titleMap)
+	tree.Arrange(nav)
+	tree.Group(g.SectionGroups)
 
 	// Build and write search index.
 	searchEntries, err := BuildSearchIndex(g.DocsDir)
@@ -87,19 +203,19 @@ func (g *SiteGenerator) Generate() (int, error) {
 		return 0, fmt.Errorf("building search index: %w", err)
 	}
 
-	if err := os.MkdirAll(g.OutputDir, 0o755); err != nil {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return 0, err
 	}
 
-	if err := WriteSearchIndex(searchEntries, filepath.Join(g.OutputDir, "search-index.json")); err != nil {
+	if _, err := WriteShardedSearchIndex(searchEntries, outDir, g.ShardSearch); err != nil {
 		return 0, fmt.Errorf("writing search index: %w", err)
 	}
 
 	// Write static assets.
-	if err := os.WriteFile(filepath.Join(g.OutputDir, "style.css"), []byte(cssContent), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(outDir, "style.css"), []byte(cssContent), 0o644); err != nil {
 		return 0, err
 	}
-	if err := os.WriteFile(filepath.Join(g.OutputDir, "script.js"), []byte(jsContent), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(outDir, "script.js"), []byte(jsContent), 0o644); err != nil {
 		return 0, err
 	}
 
@@ -108,55 +224,74 @@ func (g *SiteGenerator) Generate() (int, error) {
 	if g.LogoPath != "" {
 		logoData, err := os.ReadFile(g.LogoPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not read logo %s: %v\n", g.LogoPath, err)
+			slog.Warn("could not read logo", "phase", "assets", "path", g.LogoPath, "err", err)
 		} else {
 			logoFile = "logo" + filepath.Ext(g.LogoPath)
-			if err := os.WriteFile(filepath.Join(g.OutputDir, logoFile), logoData, 0o644); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: could not write logo: %v\n", err)
+			if err := os.WriteFile(filepath.Join(outDir, logoFile), logoData, 0o644); err != nil {
+				slog.Warn("could not write logo", "phase", "assets", "err", err)
 				logoFile = ""
 			}
 		}
 	}
 
-	// Initialize goldmark with extensions.
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM,
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("github"),
-			),
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-		goldmark.WithRendererOptions(
-			html.WithUnsafe(),
-		),
-	)
+	if g.Assets != nil {
+		if err := g.Assets.prepare(outDir); err != nil {
+			return 0, err
+		}
+	}
+
+	theme, err := g.Theme.write(outDir, newMarkdown())
+	if err != nil {
+		return 0, err
+	}
 
 	// Parse page template.
 	tmpl, err := template.New("page").Parse(pageTemplate)
 	if err != nil {
 		return 0, fmt.Errorf("parsing page template: %w", err)
 	}
+	pc := &pageContext{
+		tmpl:     tmpl,
+		tree:     tree,
+		outDir:   outDir,
+		logoFile: logoFile,
+		pageSet:  strings.Join(mdPaths, "\n"),
+		theme:    theme,
+		sections: g.writeSectionLogos(outDir, titleMap),
+		virtual:  virtual,
+	}
 
-	// Render each markdown file to HTML.
-	for _, relPath := range mdPaths {
-		if err := g.renderPage(md, tmpl, tree, relPath, logoFile); err != nil {
-			return 0, fmt.Errorf("rendering %s: %w", relPath, err)
+	// Render each markdown file to HTML. Each worker gets its own markdown
+	// converter; the template and tree are only read.
+	errs := make([]error, len(mdPaths))
+	converters := make(chan goldmark.Markdown, workerCount(g.Concurrency))
+	parallel(len(mdPaths), g.Concurrency, func(i int) {
+		var md goldmark.Markdown
+		select {
+		case md = <-converters:
+		default:
+			md = newMarkdown()
+		}
+		errs[i] = g.renderPage(md, pc, mdPaths[i])
+		converters <- md
+	})
+	for i, err := range errs {
+		if err != nil {
+			return 0, fmt.Errorf("rendering %s: %w", mdPaths[i], err)
 		}
 	}
 
-	// Copy any standalone HTML files (e.g., interactive map) directly to output.
+	// Copy any standalone HTML files (e.g., interactive map) and Structurizr
+	// workspaces directly to output.
 	_ = filepath.Walk(g.DocsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".html") {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".html") && !strings.HasSuffix(path, ".dsl") {
 			return nil
 		}
 		rel, err := filepath.Rel(g.DocsDir, path)
 		if err != nil {
 			return nil
 		}
-		outPath := filepath.Join(g.OutputDir, filepath.ToSlash(rel))
+		outPath := filepath.Join(outDir, filepath.ToSlash(rel))
 		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 			return nil
 		}
@@ -164,388 +299,299 @@ func (g *SiteGenerator) Generate() (int, error) {
 		if err != nil {
 			return nil
 		}
+		if g.Assets != nil {
+			data = g.Assets.rewrite(data, basePathFor(filepath.ToSlash(rel)))
+		}
 		_ = os.WriteFile(outPath, data, 0o644)
 		return nil
 	})
 
-	return len(mdPaths), nil
+	pages := len(mdPaths)
+	if g.Translator != nil {
+		n, err := g.generateTranslations(outDir)
+		if err != nil {
+			return 0, err
+		}
+		pages += n
+	}
+	return pages, nil
 }
 
-// renderPage converts a single markdown file to an HTML page.
-func (g *SiteGenerator) renderPage(md goldmark.Markdown, tmpl *template.Template, tree *FileTree, relPath, logoFile string) error {
-	srcPath := filepath.Join(g.DocsDir, filepath.FromSlash(relPath))
-	content, err := os.ReadFile(srcPath)
-	if err != nil {
-		return err
+// generateTranslations translates the docs into each language other than
+// the source, and generates that language's copy of the site under outDir.
+func (g *SiteGenerator) generateTranslations(outDir string) (int, error) {
+	pages := 0
+	for _, lang := range g.Languages {
+		if lang.Dir == "" {
+			continue
+		}
+		docsDir, err := os.MkdirTemp("", "autodoc-docs-"+lang.Code+"-")
+		if err != nil {
+			return 0, err
+		}
+		defer os.RemoveAll(docsDir)
+		if err := g.Translator.TranslateDocs(context.Background(), g.DocsDir, docsDir, lang.Code); err != nil {
+			return 0, fmt.Errorf("translating docs into %s: %w", lang.Code, err)
+		}
+
+		translated := *g
+		translated.DocsDir = docsDir
+		translated.Language = lang.Code
+		translated.Translator = nil
+		translated.Validation = nil
+		n, err := translated.generate(filepath.Join(outDir, filepath.FromSlash(lang.Dir)))
+		if err != nil {
+			return 0, fmt.Errorf("generating %s site: %w", lang.Code, err)
+		}
+		pages += n
+	}
+	return pages, nil
+}
+
+// languageLink is an entry of a page's language switcher.
+type languageLink struct {
+	Code    string
+	Name    string
+	URL     string
+	Current bool
+}
+
+// languageLinks links a page to itself in every language the site is
+// published in, or returns nil when there is only one.
+func (g *SiteGenerator) languageLinks(htmlRelPath, basePath string) []languageLink {
+	if len(g.Languages) < 2 {
+		return nil
+	}
+	// From the current language's root, back up to the source language's.
+	root := basePath
+	for _, lang := range g.Languages {
+		if lang.Code == g.currentLanguage() && lang.Dir != "" {
+			root += strings.Repeat("../", strings.Count(lang.Dir, "/")+1)
+		}
 	}
+	links := make([]languageLink, len(g.Languages))
+	for i, lang := range g.Languages {
+		url := root + htmlRelPath
+		if lang.Dir != "" {
+			url = root + lang.Dir + "/" + htmlRelPath
+		}
+		links[i] = languageLink{Code: lang.Code, Name: lang.Name, URL: url, Current: lang.Code == g.currentLanguage()}
+	}
+	return links
+}
+
+// currentLanguage is the code of the language being generated.
+func (g *SiteGenerator) currentLanguage() string {
+	switch {
+	case g.Language != "":
+		return g.Language
+	case len(g.Languages) > 0:
+		return g.Languages[0].Code
+	}
+	return "en"
+}
 
-	// Convert markdown to HTML.
-	var htmlBuf bytes.Buffer
-	if err := md.Convert(content, &htmlBuf); err != nil {
-		return fmt.Errorf("converting markdown: %w", err)
+// workerCount resolves a concurrency setting: zero or less means one
+// worker per CPU.
+func workerCount(concurrency int) int {
+	if concurrency > 0 {
+		return concurrency
 	}
+	return runtime.NumCPU()
+}
+
+// parallel calls fn for each index in [0, n) on up to workerCount(concurrency)
+// goroutines at once, and returns when every call has.
+func parallel(n, concurrency int, fn func(i int)) {
+	workers := min(workerCount(concurrency), n)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// newMarkdown returns the markdown converter pages are rendered with.
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle("github"),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			// Translated pages pin their headings' anchors with {#id}.
+			parser.WithAttribute(),
+		),
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+	)
+}
 
-	// Post-process the rendered HTML.
-	htmlContent := htmlBuf.String()
-	htmlContent = postProcessMermaid(htmlContent)
-	htmlContent = rewriteMDLinks(htmlContent)
-	htmlContent = reformatMetadataSummary(htmlContent)
-	htmlContent = removeEmptySections(htmlContent)
-	htmlContent = removeBrokenLinks(htmlContent, g.DocsDir)
-	htmlContent = cleanTableSummaries(htmlContent)
+// renderPage converts a single markdown file to an HTML page.
+func (g *SiteGenerator) renderPage(md goldmark.Markdown, pc *pageContext, relPath string) error {
+	content, err := g.readPage(pc, relPath)
+	if err != nil {
+		return err
+	}
 
 	// Determine output path.
 	htmlRelPath := mdPathToHTML(relPath)
-	outPath := filepath.Join(g.OutputDir, filepath.FromSlash(htmlRelPath))
+	outPath := filepath.Join(pc.outDir, filepath.FromSlash(htmlRelPath))
 
 	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 		return err
 	}
 
 	// Compute base path for CSS/JS references.
-	depth := strings.Count(htmlRelPath, "/")
-	basePath := ""
-	for i := 0; i < depth; i++ {
-		basePath += "../"
-	}
+	basePath := basePathFor(htmlRelPath)
 
 	// Extract title from first heading or use filename.
 	title := extractTitle(string(content), relPath)
 
 	// Build tree HTML with active path highlighting.
-	treeHTML := tree.ToHTML(relPath, basePath)
+	treeHTML := pc.tree.ToHTML(relPath, basePath)
+	section := sectionOf(pc.sections, relPath)
+	languages := g.languageLinks(htmlRelPath, basePath)
+
+	// Reuse the page rendered from the same inputs by an earlier run.
+	var memoKey string
+	if g.Artifacts != nil {
+		memoKey = artifacts.MemoKey(renderVersion, pageTemplate, relPath, string(content), g.ProjectName, pc.logoFile, treeHTML, pc.pageSet, g.currentLanguage(), fmt.Sprint(languages), g.Assets.key(), fmt.Sprint(pc.theme, section))
+		if page, ok := g.Artifacts.Lookup(context.Background(), memoKey); ok {
+			return os.WriteFile(outPath, page, 0o644)
+		}
+	}
 
 	data := pageData{
 		Title:       title,
 		ProjectName: g.ProjectName,
-		LogoFile:    logoFile,
-		Content:     template.HTML(htmlContent),
+		LogoFile:    pc.logoFile,
+		Lang:        g.currentLanguage(),
+		Languages:   languages,
+		Content:     contentMarker,
 		TreeHTML:    template.HTML(treeHTML),
 		BasePath:    basePath,
+		Theme:       pc.theme,
+		Section:     section,
+	}
+	var shell strings.Builder
+	if err := pc.tmpl.Execute(&shell, data); err != nil {
+		return err
+	}
+	head, tail, ok := strings.Cut(shell.String(), string(contentMarker))
+	if !ok {
+		return fmt.Errorf("page template has no content")
+	}
+	if g.Assets != nil {
+		head = string(g.Assets.rewrite([]byte(head), basePath))
 	}
 
+	// Stream the page to its file: the markdown is converted into a pipe
+	// and post-processed from there, so the rendered HTML is never held
+	// whole. The page is only kept in memory when it is to be cached.
 	f, err := os.Create(outPath)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-
-	return tmpl.Execute(f, data)
-}
-
-// extractTitle pulls the first # heading from markdown content, or falls back to the filename.
-func extractTitle(content, relPath string) string {
-	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "# ") {
-			return strings.TrimPrefix(line, "# ")
-		}
+	bw := bufio.NewWriter(f)
+	var out io.Writer = bw
+	var page *bytes.Buffer
+	if memoKey != "" {
+		page = new(bytes.Buffer)
+		out = io.MultiWriter(bw, page)
 	}
-	return strings.TrimSuffix(filepath.Base(relPath), ".md")
-}
 
-// postProcessMermaid is a no-op. Mermaid code blocks are left as
-// <pre><code class="language-mermaid"> and converted to mermaid divs
-// by JavaScript at runtime, which correctly handles HTML entity decoding
-// via the browser's textContent API.
-func postProcessMermaid(html string) string {
-	return html
-}
-
-// rewriteMDLinks changes .md links in HTML content to .html links.
-func rewriteMDLinks(content string) string {
-	// Rewrite href="...*.md" to href="...*.html"
-	result := content
-	search := `.md"`
-	replace := `.html"`
-	result = strings.ReplaceAll(result, search, replace)
-
-	search = `.md#`
-	replace = `.html#`
-	result = strings.ReplaceAll(result, search, replace)
-
-	return result
-}
-
-// reformatMetadataSummary transforms the verbose "File: ... Language: ... Summary: ... Purpose: ... Dependencies: ..."
-// blocks that the lite-tier LLM produces into nicely formatted HTML with metadata badges and clean sections.
-func reformatMetadataSummary(htmlContent string) string {
-	// The lite tier produces paragraphs like:
-	// <p>File: path Language: Go Summary: text Purpose: text Dependencies: list</p>
-	// We need to detect these and reformat them into structured HTML.
-
-	const pOpen = "<p>"
-	const pClose = "</p>"
-
-	var result strings.Builder
-	remaining := htmlContent
-
-	for {
-		idx := strings.Index(remaining, pOpen)
-		if idx == -1 {
-			result.WriteString(remaining)
-			break
-		}
-		pCloseIdx := strings.Index(remaining[idx:], pClose)
-		if pCloseIdx == -1 {
-			result.WriteString(remaining)
-			break
-		}
-		pCloseIdx += idx
-
-		content := remaining[idx+len(pOpen) : pCloseIdx]
-
-		// Check if this paragraph has the verbose metadata pattern
-		if strings.Contains(content, "File:") && strings.Contains(content, "Summary:") && strings.Contains(content, "Language:") {
-			formatted := formatMetadataBlock(content)
-			result.WriteString(remaining[:idx])
-			result.WriteString(formatted)
-			remaining = remaining[pCloseIdx+len(pClose):]
-		} else {
-			result.WriteString(remaining[:pCloseIdx+len(pClose)])
-			remaining = remaining[pCloseIdx+len(pClose):]
+	pr, pw := io.Pipe()
+	go func() {
+		if err := md.Convert(content, pw); err != nil {
+			pw.CloseWithError(fmt.Errorf("converting markdown: %w", err))
+			return
 		}
+		pw.Close()
+	}()
+	if _, err := io.WriteString(out, head); err != nil {
+		pr.CloseWithError(err)
+		return err
 	}
-
-	return result.String()
-}
-
-// formatMetadataBlock transforms a verbose metadata string into structured HTML.
-func formatMetadataBlock(text string) string {
-	fields := parseMetadataFields(text)
-
-	var b strings.Builder
-
-	// Language badge
-	if lang := fields["Language"]; lang != "" {
-		b.WriteString(fmt.Sprintf(`<div class="file-meta"><span class="meta-badge lang-badge">%s</span></div>`+"\n", lang))
+	if err := postProcessTo(out, pr, g.DocsDir); err != nil {
+		pr.CloseWithError(err)
+		return err
 	}
-
-	// Summary as main description
-	if summary := fields["Summary"]; summary != "" {
-		b.WriteString(fmt.Sprintf(`<p class="file-summary">%s</p>`+"\n", summary))
+	if _, err := io.WriteString(out, tail); err != nil {
+		return err
 	}
-
-	// Purpose as secondary info
-	if purpose := fields["Purpose"]; purpose != "" {
-		b.WriteString(fmt.Sprintf(`<div class="file-purpose"><strong>Purpose:</strong> %s</div>`+"\n", purpose))
+	if err := bw.Flush(); err != nil {
+		return err
 	}
-
-	// Dependencies as tag list
-	if deps := fields["Dependencies"]; deps != "" {
-		depList := strings.Split(deps, ",")
-		b.WriteString(`<div class="file-deps"><strong>Dependencies</strong><div class="dep-tags">`)
-		for _, dep := range depList {
-			dep = strings.TrimSpace(dep)
-			if dep == "" {
-				continue
-			}
-			// Clean up "(import)" / "(api_call)" suffixes for display
-			name := dep
-			depType := ""
-			if parenIdx := strings.Index(dep, " ("); parenIdx > 0 {
-				name = dep[:parenIdx]
-				depType = strings.Trim(dep[parenIdx+1:], " ()")
-			}
-			typeClass := "dep-import"
-			if depType == "api_call" {
-				typeClass = "dep-api"
-			}
-			b.WriteString(fmt.Sprintf(`<span class="dep-tag %s">%s</span>`, typeClass, name))
-		}
-		b.WriteString(`</div></div>` + "\n")
+	if err := f.Close(); err != nil {
+		return err
 	}
-
-	return b.String()
-}
-
-// parseMetadataFields extracts key-value pairs from the verbose metadata string.
-func parseMetadataFields(text string) map[string]string {
-	fields := make(map[string]string)
-	keys := []string{"File", "Language", "Summary", "Purpose", "Dependencies"}
-
-	for i, key := range keys {
-		marker := key + ":"
-		idx := strings.Index(text, marker)
-		if idx == -1 {
-			continue
-		}
-		after := text[idx+len(marker):]
-
-		// Find where this field ends (at the next field marker or end of text)
-		end := len(after)
-		for _, nextKey := range keys[i+1:] {
-			nextMarker := nextKey + ":"
-			if j := strings.Index(after, nextMarker); j > 0 && j < end {
-				end = j
-			}
+	if memoKey != "" {
+		if err := g.Artifacts.Remember(context.Background(), memoKey, page.Bytes()); err != nil {
+			slog.Warn("could not cache page", "phase", "render", "page", htmlRelPath, "err", err)
 		}
-		fields[key] = strings.TrimSpace(after[:end])
 	}
-
-	return fields
+	return nil
 }
 
-// removeEmptySections removes headings that have no content after them
-// (e.g., empty "Table of Contents" sections from lite tier).
-func removeEmptySections(htmlContent string) string {
-	// Remove <h2 id="...">Table of Contents</h2> followed by empty content
-	// Pattern: h2 tag, then only whitespace/empty elements until next h2 or end
-	lines := strings.Split(htmlContent, "\n")
-	var result []string
-	skip := false
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Check if this is a heading we might want to skip
-		if strings.Contains(trimmed, "Table of Contents</h2>") {
-			// Look ahead to see if there's actual content before next heading
-			hasContent := false
-			for j := i + 1; j < len(lines); j++ {
-				nextTrimmed := strings.TrimSpace(lines[j])
-				if nextTrimmed == "" {
-					continue
-				}
-				if strings.HasPrefix(nextTrimmed, "<h") {
-					break
-				}
-				// Check for actual content (not just empty lists or whitespace)
-				stripped := strings.ReplaceAll(nextTrimmed, "<p>", "")
-				stripped = strings.ReplaceAll(stripped, "</p>", "")
-				stripped = strings.TrimSpace(stripped)
-				if stripped != "" {
-					hasContent = true
-					break
-				}
-			}
-			if !hasContent {
-				skip = true
-				continue
-			}
-		}
-
-		// If we're skipping empty section content, stop at next heading
-		if skip {
-			if strings.HasPrefix(trimmed, "<h") && !strings.Contains(trimmed, "Table of Contents") {
-				skip = false
-			} else if trimmed == "" || trimmed == "<p></p>" {
-				continue
-			} else if !strings.HasPrefix(trimmed, "<h") {
-				skip = false
-			} else {
-				continue
-			}
-		}
-
-		result = append(result, line)
+// readPage returns the markdown of the page at relPath.
+func (g *SiteGenerator) readPage(pc *pageContext, relPath string) ([]byte, error) {
+	if page, ok := pc.virtual[relPath]; ok {
+		return []byte(page), nil
 	}
+	return os.ReadFile(filepath.Join(g.DocsDir, filepath.FromSlash(relPath)))
+}
 
-	return strings.Join(result, "\n")
+// basePathFor is the way from the page at relPath back to the site root,
+// for CSS, JS, and vendored library references.
+func basePathFor(relPath string) string {
+	return strings.Repeat("../", strings.Count(relPath, "/"))
 }
 
-// removeBrokenLinks removes list items and sections that link to non-existent files.
-func removeBrokenLinks(htmlContent, docsDir string) string {
-	// Remove <li> elements containing links to files that don't exist in docsDir.
-	// This catches the "Quick Links" section pointing to architecture.html when architecture.md doesn't exist.
-	lines := strings.Split(htmlContent, "\n")
-	var result []string
-	skipQuickLinks := false
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Check for "Quick Links" heading — if all its links are broken, remove the whole section
-		if strings.Contains(trimmed, "Quick Links</h2>") {
-			// Look ahead to see if any links are valid
-			hasValidLink := false
-			for j := i + 1; j < len(lines); j++ {
-				nextTrimmed := strings.TrimSpace(lines[j])
-				if strings.HasPrefix(nextTrimmed, "<h") && !strings.Contains(nextTrimmed, "Quick Links") {
-					break
-				}
-				// Check if any href points to an existing file
-				if strings.Contains(nextTrimmed, "href=") {
-					hrefStart := strings.Index(nextTrimmed, `href="`)
-					if hrefStart >= 0 {
-						hrefStart += 6
-						hrefEnd := strings.Index(nextTrimmed[hrefStart:], `"`)
-						if hrefEnd > 0 {
-							href := nextTrimmed[hrefStart : hrefStart+hrefEnd]
-							// Convert .html back to .md to check existence
-							mdHref := strings.TrimSuffix(href, ".html") + ".md"
-							if _, err := os.Stat(filepath.Join(docsDir, mdHref)); err == nil {
-								hasValidLink = true
-							}
-						}
-					}
-				}
-			}
-			if !hasValidLink {
-				skipQuickLinks = true
-				continue
-			}
-		}
+// contentMarker stands in for a page's content when the page template is
+// executed, marking where the streamed content goes.
+const contentMarker template.HTML = "<!--autodoc:content-->"
 
-		if skipQuickLinks {
-			if strings.HasPrefix(trimmed, "<h") && !strings.Contains(trimmed, "Quick Links") {
-				skipQuickLinks = false
-			} else {
-				continue
-			}
-		}
+// extractTitle pulls the first # heading from markdown content, or falls back to the filename.
+func extractTitle(content, relPath string) string {
+	title, _ := scanTitle(strings.NewReader(content), relPath)
+	return title
+}
 
-		result = append(result, line)
+// readTitle is extractTitle for a file, reading only as far as the heading.
+func readTitle(path, relPath string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
 	}
-
-	return strings.Join(result, "\n")
+	defer f.Close()
+	return scanTitle(f, relPath)
 }
 
-// cleanTableSummaries extracts just the Summary sentence from verbose metadata in table cells.
-func cleanTableSummaries(htmlContent string) string {
-	const tdOpen = "<td>"
-	const tdClose = "</td>"
-
-	var result strings.Builder
-	remaining := htmlContent
-
-	for {
-		idx := strings.Index(remaining, tdOpen)
-		if idx == -1 {
-			result.WriteString(remaining)
-			break
-		}
-		endIdx := strings.Index(remaining[idx:], tdClose)
-		if endIdx == -1 {
-			result.WriteString(remaining)
-			break
-		}
-		endIdx += idx
-
-		cellContent := remaining[idx+len(tdOpen) : endIdx]
-
-		if strings.Contains(cellContent, "Summary:") && strings.Contains(cellContent, "Language:") {
-			fields := parseMetadataFields(cellContent)
-			summary := fields["Summary"]
-			if summary == "" {
-				summary = fields["Purpose"]
-			}
-			if summary == "" {
-				summary = strings.TrimSpace(cellContent)
-				if len(summary) > 200 {
-					summary = summary[:200] + "..."
-				}
-			}
-			result.WriteString(remaining[:idx])
-			result.WriteString(tdOpen)
-			result.WriteString(summary)
-			result.WriteString(tdClose)
-			remaining = remaining[endIdx+len(tdClose):]
-		} else {
-			result.WriteString(remaining[:endIdx+len(tdClose)])
-			remaining = remaining[endIdx+len(tdClose):]
+func scanTitle(r io.Reader, relPath string) (string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimPrefix(line, "# "), nil
 		}
 	}
-
-	return result.String()
+	return strings.TrimSuffix(filepath.Base(relPath), ".md"), sc.Err()
 }
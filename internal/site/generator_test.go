@@ -1,11 +1,44 @@
 package site
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/costs"
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/golden"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+	"github.com/ziadkadry99/auto-doc/internal/metrics"
+	"github.com/ziadkadry99/auto-doc/internal/narratives"
+	"github.com/ziadkadry99/auto-doc/internal/overrides"
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+	"github.com/ziadkadry99/auto-doc/internal/review"
+	"github.com/ziadkadry99/auto-doc/internal/schemareg"
+	"github.com/ziadkadry99/auto-doc/internal/slo"
+	"github.com/ziadkadry99/auto-doc/internal/threatmodel"
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
 func TestBuildTree(t *testing.T) {
@@ -113,6 +146,29 @@ func TestTreeToHTML(t *testing.T) {
 	}
 }
 
+func TestTreeGroup(t *testing.T) {
+	tree := BuildTree([]string{"index.md", "orders/index.md", "payments/index.md", "search/index.md", "teams/index.md"}, nil)
+	tree.Group(map[string][]string{"orders": {"Commerce", "Checkout"}, "payments": {"Commerce"}})
+
+	var top []string
+	for _, c := range tree.Children {
+		top = append(top, c.Name)
+	}
+	if want := []string{"Commerce", "search", "teams", "index.md"}; !reflect.DeepEqual(top, want) {
+		t.Fatalf("top level = %v, want %v", top, want)
+	}
+	commerce := tree.Children[0]
+	if !commerce.IsGroup || len(commerce.Children) != 2 || commerce.Children[0].Title != "Checkout" || commerce.Children[1].Name != "payments" {
+		t.Errorf("Commerce group = %+v", commerce.Children)
+	}
+
+	html := tree.ToHTML("orders/index.md", "../")
+	if !strings.Contains(html, `<li class="dir expanded"><span class="dir-toggle">Commerce</span>`) ||
+		!strings.Contains(html, `<li class="dir expanded"><span class="dir-toggle">Checkout</span>`) {
+		t.Errorf("groups holding the active page should be expanded:\n%s", html)
+	}
+}
+
 func TestMdPathToHTML(t *testing.T) {
 	tests := []struct {
 		input, want string
@@ -169,7 +225,7 @@ func TestPostProcessMermaid(t *testing.T) {
 	input := `<p>Hello</p><pre><code class="language-mermaid">graph TD
 A --> B</code></pre><p>End</p>`
 
-	got := postProcessMermaid(input)
+	got := postProcess(input, t.TempDir())
 
 	// postProcessMermaid is now a no-op; mermaid code blocks are converted
 	// to mermaid divs by JavaScript at runtime for proper HTML entity handling.
@@ -183,7 +239,7 @@ A --> B</code></pre><p>End</p>`
 
 func TestRewriteMDLinks(t *testing.T) {
 	input := `<a href="config.go.md">link</a> and <a href="other.md#section">section</a>`
-	got := rewriteMDLinks(input)
+	got := postProcess(input, t.TempDir())
 
 	if strings.Contains(got, `.md"`) {
 		t.Error("should have rewritten .md to .html")
@@ -196,6 +252,177 @@ func TestRewriteMDLinks(t *testing.T) {
 	}
 }
 
+func TestPostProcessEdgeCases(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "guide.md"), "# Guide")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "metadata keeps inline markup and what follows",
+			input: `<p>File: a.go Language: Go Summary: Uses <code>sync.Mutex</code> for <em>safety</em>. Purpose: Locks. Dependencies: sync (import)</p><p>After</p>`,
+			want: []string{
+				`<p class="file-summary">Uses <code>sync.Mutex</code> for <em>safety</em>.</p>`,
+				`<span class="dep-tag dep-import">sync</span>`,
+				`<p>After</p>`,
+			},
+		},
+		{
+			name:    "field split inside a tag is closed",
+			input:   `<p>File: a.go Language: Go Summary: See <a href="x.md">the Purpose: docs</a></p><p>After</p>`,
+			want:    []string{`<p>After</p>`},
+			notWant: []string{`<a href="x.html">the</p>`},
+		},
+		{
+			name:    "dependency names are escaped",
+			input:   `<p>File: a.go Language: Go Summary: x Dependencies: &lt;script&gt;alert(1)&lt;/script&gt; (import)</p>`,
+			want:    []string{`&lt;script&gt;alert(1)&lt;/script&gt;`},
+			notWant: []string{`<script>`},
+		},
+		{
+			name:    "only relative page links are rewritten",
+			input:   `<p><a href="https://github.com/acme/app/blob/main/README.md">readme</a> <a href="../guide.md#setup">guide</a> <code>notes.md"</code></p>`,
+			want:    []string{`README.md"`, `href="../guide.html#setup"`, `notes.md&#34;`},
+			notWant: []string{`README.html`},
+		},
+		{
+			name:    "empty table of contents is removed",
+			input:   "<h2>Table of Contents</h2>\n<p></p>\n<h2>Overview</h2><p>Body</p>",
+			want:    []string{`<h2>Overview</h2>`},
+			notWant: []string{`Table of Contents`},
+		},
+		{
+			name:  "table of contents with entries is kept",
+			input: `<h2>Table of Contents</h2><ul><li>Overview</li></ul><h2>Overview</h2>`,
+			want:  []string{`Table of Contents`},
+		},
+		{
+			name:    "quick links with no existing pages are removed",
+			input:   `<h2>Quick Links</h2><ul><li><a href="architecture.html">Architecture</a></li></ul><h2>Next</h2>`,
+			want:    []string{`<h2>Next</h2>`},
+			notWant: []string{`Quick Links`},
+		},
+		{
+			name:  "quick links with an existing page are kept",
+			input: `<h2>Quick Links</h2><ul><li><a href="guide.md#intro">Guide</a></li></ul>`,
+			want:  []string{`Quick Links`, `href="guide.html#intro"`},
+		},
+		{
+			name:  "table summaries keep the summary only",
+			input: `<table><tr><td>File: a.go Language: Go Summary: Does <b>x</b>. Purpose: y</td><td>Language: Summary: plain</td></tr></table>`,
+			want:  []string{`<td>Does <b>x</b>.</td>`, `<td>plain</td>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := postProcess(tt.input, docsDir)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+// TestPostProcessStreaming checks that cleaning a page block by block gives
+// the same HTML as cleaning it as one tree, including sections that span
+// blocks and raw HTML that never closes.
+func TestPostProcessStreaming(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "guide.md"), "# Guide")
+	page := strings.Join([]string{
+		`<h1>Orders</h1>`,
+		`<h2>Table of Contents</h2>`, "\n", `<p></p>`,
+		`<h2>Quick Links</h2>`, `<ul><li><a href="architecture.md">Architecture</a></li></ul>`,
+		`<h2>Table of Contents</h2>`, `<ul><li><a href="guide.md#intro">Guide</a></li></ul>`,
+		`<h3>Files</h3>`,
+		`<p>File: a.go Language: Go Summary: Uses <code>sync.Mutex</code>. Dependencies: sync (import)</p>`,
+		`<table><tr><td>File: b.go Language: Go Summary: Does <b>x</b>.</td></tr></table>`,
+		`<div>stray</span> <p>closed by the div</div></p>`,
+		`<pre><code>` + strings.Repeat("line &lt;b&gt;\n", 1000) + `</code></pre>`,
+		`<h2>Quick Links</h2><ul><li><a href="guide.md">Guide</a></li></ul>`,
+		`<div>never closed <p>File: c.go Language: Go Summary: Trailing.`,
+	}, "")
+
+	body := newBody()
+	nodes, err := html.ParseFragment(strings.NewReader(page), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	cleanPage(body, docsDir)
+	want := renderInner(body)
+
+	if got := postProcess(page, docsDir); got != want {
+		t.Errorf("streamed page differs from the page cleaned as one tree:\ngot  %s\nwant %s", got, want)
+	}
+	if strings.Contains(want, "architecture.html") || strings.Count(want, "Table of Contents") != 1 {
+		t.Errorf("the empty and broken sections should be dropped:\n%s", want)
+	}
+}
+
+// FuzzPostProcess checks that post-processing never panics, and that pages
+// without any of the sections it rewrites keep their text exactly, whether
+// cleaned as one tree or streamed block by block.
+func FuzzPostProcess(f *testing.F) {
+	for _, seed := range []string{
+		`<p>Hello <a href="a.md">a</a></p>`,
+		`<p>File: a.go Language: Go Summary: x <code>y</code> Purpose: z Dependencies: a (import), b (api_call)</p>`,
+		`<p>File: Language: Summary: Purpose: Dependencies:</p>`,
+		`<p>File: a Language: <b>Go Summary: x</p>`,
+		"<h2>Table of Contents</h2>\n<h2>Quick Links</h2><ul><li><a href=\"x.html\">x</a></li></ul>",
+		`<table><tr><td>Summary: Language: <i>unclosed</td></tr></table>`,
+		`<pre><code class="language-mermaid">graph TD
+A["x"] --> B</code></pre>`,
+		`<div><p>Summary:</div></p><!-- File: -->`,
+	} {
+		f.Add(seed)
+	}
+	docsDir := f.TempDir()
+
+	f.Fuzz(func(t *testing.T, input string) {
+		got := postProcess(input, docsDir)
+
+		body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		nodes, err := html.ParseFragment(strings.NewReader(input), body)
+		if err != nil {
+			return
+		}
+		for _, n := range nodes {
+			body.AppendChild(n)
+		}
+		before := textContent(body)
+		if strings.Contains(before, "Summary:") || strings.Contains(before, "Table of Contents") || strings.Contains(before, "Quick Links") {
+			return
+		}
+		cleanPage(body, docsDir)
+		if after := textContent(body); after != before {
+			t.Errorf("text changed:\nbefore %q\nafter  %q\noutput %s", before, after, got)
+		}
+		// Streaming block by block keeps the same text as cleaning the page
+		// as one tree.
+		var streamed strings.Builder
+		for _, n := range parseInline(got) {
+			streamed.WriteString(textContent(n))
+		}
+		if streamed.String() != before {
+			t.Errorf("streamed text changed:\nbefore   %q\nstreamed %q\noutput %s", before, streamed.String(), got)
+		}
+	})
+}
+
 func TestSearchIndex(t *testing.T) {
 	// Create temp directory with test markdown files.
 	tmpDir := t.TempDir()
@@ -295,6 +522,7 @@ The system has several components.
 		"style.css",
 		"script.js",
 		"search-index.json",
+		"search-manifest.json",
 		"cmd/root.go.html",
 		"architecture.html",
 	}
@@ -373,6 +601,103 @@ The system has several components.
 	}
 }
 
+func TestSearchIndexBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "short.md"), "# Short\n\nTiny page.")
+	writeTestFile(t, filepath.Join(tmpDir, "long.md"), "# Long\n\n"+strings.Repeat("é", 1000))
+
+	entries, err := BuildSearchIndexWithBudget(tmpDir, SearchBudget{MaxEntryContent: 1500, MaxTotalContent: 600})
+	if err != nil {
+		t.Fatalf("BuildSearchIndexWithBudget error: %v", err)
+	}
+
+	total := 0
+	for _, e := range entries {
+		total += len(e.Content)
+		if !utf8.ValidString(e.Content) {
+			t.Errorf("%s: truncated content is not valid UTF-8", e.Path)
+		}
+		if e.Path == "short.html" && !strings.Contains(e.Content, "Tiny page.") {
+			t.Errorf("short page should keep its full content, got %q", e.Content)
+		}
+		if e.Path == "long.html" && e.Title != "Long" {
+			t.Errorf("title should survive truncation, got %q", e.Title)
+		}
+	}
+	if total > 600 {
+		t.Errorf("total content = %d bytes, want <= 600", total)
+	}
+}
+
+func TestWriteShardedSearchIndex(t *testing.T) {
+	entries := []SearchEntry{
+		{Path: "index.html", Title: "Home"},
+		{Path: "orders/api.html", Title: "Orders API"},
+		{Path: "orders/db.html", Title: "Orders DB"},
+		{Path: "payments/index.html", Title: "Payments"},
+	}
+
+	// Small indexes stay in a single file unless sharding is forced.
+	outDir := t.TempDir()
+	manifest, err := WriteShardedSearchIndex(entries, outDir, false)
+	if err != nil {
+		t.Fatalf("WriteShardedSearchIndex error: %v", err)
+	}
+	if len(manifest.Shards) != 1 || manifest.Shards[0].File != "search-index.json" {
+		t.Errorf("unexpected unsharded manifest: %+v", manifest.Shards)
+	}
+
+	outDir = t.TempDir()
+	manifest, err = WriteShardedSearchIndex(entries, outDir, true)
+	if err != nil {
+		t.Fatalf("WriteShardedSearchIndex error: %v", err)
+	}
+	if len(manifest.Shards) != 3 {
+		t.Fatalf("shards = %d, want 3", len(manifest.Shards))
+	}
+	for _, shard := range manifest.Shards {
+		data, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(shard.File)))
+		if err != nil {
+			t.Fatalf("reading shard %s: %v", shard.File, err)
+		}
+		var got []SearchEntry
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("parsing shard %s: %v", shard.File, err)
+		}
+		if len(got) != shard.Entries {
+			t.Errorf("shard %s entries = %d, manifest says %d", shard.Name, len(got), shard.Entries)
+		}
+		if shard.Name == "orders" && len(got) != 2 {
+			t.Errorf("orders shard entries = %d, want 2", len(got))
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "search-manifest.json"))
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	if !strings.Contains(string(data), `"name": "_root"`) {
+		t.Errorf("manifest should list the root shard:\n%s", data)
+	}
+
+	// A rebuild drops the shards of repos that are gone, and leaves the
+	// pages of a repo named "search" alone.
+	os.WriteFile(filepath.Join(outDir, "search", "index.html"), []byte("search repo"), 0o644)
+	if _, err := WriteShardedSearchIndex(entries[:1], outDir, true); err != nil {
+		t.Fatalf("WriteShardedSearchIndex error: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(outDir, "search", "*"))
+	if len(files) != 2 || !strings.HasSuffix(files[0], "000-_root.json") || !strings.HasSuffix(files[1], "index.html") {
+		t.Errorf("search/ after rebuild = %v", files)
+	}
+	if _, err := WriteShardedSearchIndex(entries, outDir, false); err != nil {
+		t.Fatalf("WriteShardedSearchIndex error: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(outDir, "search", "*.json")); len(files) != 0 {
+		t.Errorf("shards left beside the single index: %v", files)
+	}
+}
+
 func TestGenerateNoFiles(t *testing.T) {
 	docsDir := t.TempDir()
 	outputDir := t.TempDir()
@@ -387,6 +712,49 @@ func TestGenerateNoFiles(t *testing.T) {
 	}
 }
 
+func TestGenerateWithArtifacts(t *testing.T) {
+	root := t.TempDir()
+	docsDir := filepath.Join(root, "docs")
+	outputDir := filepath.Join(root, "site")
+	os.MkdirAll(filepath.Join(docsDir, "api"), 0o755)
+	os.WriteFile(filepath.Join(docsDir, "index.md"), []byte("# Home\n\n## Quick Links\n\n- [Orders](api/orders.md)\n"), 0o644)
+	os.WriteFile(filepath.Join(docsDir, "api", "orders.md"), []byte("# Orders\n"), 0o644)
+	store := artifacts.NewStore(artifacts.NewLocalBackend(filepath.Join(root, "artifacts")), 0)
+
+	generate := func() artifacts.PublishStats {
+		t.Helper()
+		gen := NewSiteGenerator(docsDir, outputDir, "test")
+		gen.Artifacts = store
+		if _, err := gen.Generate(); err != nil {
+			t.Fatal(err)
+		}
+		return gen.Published
+	}
+
+	first := generate()
+	if first.Written == 0 || first.Unchanged != 0 {
+		t.Errorf("first run = %+v", first)
+	}
+	if second := generate(); second.Written != 0 || second.Unchanged != first.Written {
+		t.Errorf("unchanged rerun = %+v, want every file unchanged", second)
+	}
+
+	os.Remove(filepath.Join(docsDir, "api", "orders.md"))
+	third := generate()
+	if third.Removed == 0 {
+		t.Errorf("run after deleting a page = %+v", third)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "api", "orders.html")); !os.IsNotExist(err) {
+		t.Error("page of a deleted doc is still in the output")
+	}
+	// Quick Links to missing pages are dropped, so the index must be
+	// re-rendered rather than reused.
+	index, _ := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if strings.Contains(string(index), "Quick Links") {
+		t.Error("index was reused with a link to the deleted page")
+	}
+}
+
 func TestMDLinksInGeneratedHTML(t *testing.T) {
 	docsDir := t.TempDir()
 	outputDir := t.TempDir()
@@ -412,7 +780,421 @@ func TestMDLinksInGeneratedHTML(t *testing.T) {
 	}
 }
 
+// fakeSearchStore returns its documents in order for any query, honouring
+// only the repo filter.
+type fakeSearchStore struct {
+	vectordb.VectorStore
+	docs []vectordb.Document
+}
+
+func (f *fakeSearchStore) Search(_ context.Context, _ string, limit int, filter *vectordb.SearchFilter) ([]vectordb.SearchResult, error) {
+	var results []vectordb.SearchResult
+	for _, d := range f.docs {
+		if filter != nil && filter.RepoID != nil && d.Metadata.RepoID != *filter.RepoID {
+			continue
+		}
+		results = append(results, vectordb.SearchResult{Document: d, Similarity: 0.9})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func TestSearchScope(t *testing.T) {
+	store := &fakeSearchStore{docs: []vectordb.Document{
+		{ID: "1", Content: "a", Metadata: vectordb.DocumentMetadata{FilePath: "internal/api/handler.go", RepoID: "orders", Type: vectordb.DocTypeFile, Language: "Go"}},
+		{ID: "2", Content: "b", Metadata: vectordb.DocumentMetadata{FilePath: "internal/db/store.go", RepoID: "orders", Type: vectordb.DocTypeFile, Language: "Go"}},
+		{ID: "3", Content: "c", Metadata: vectordb.DocumentMetadata{FilePath: "docs/adr/0001.md", RepoID: "payments", Type: vectordb.DocTypeDecision}},
+	}}
+
+	tests := []struct {
+		name  string
+		scope SearchScope
+		want  []string
+	}{
+		{"global", SearchScope{}, []string{"1", "2", "3"}},
+		{"repo", SearchScope{Kind: ScopeRepo, Repo: "payments"}, []string{"3"}},
+		{"directory with repo prefix", SearchScope{Kind: ScopeDirectory, Directory: "orders/internal/api"}, []string{"1"}},
+		{"directory without repo", SearchScope{Kind: ScopeDirectory, Directory: "internal"}, []string{"1", "2"}},
+		{"tags", SearchScope{Kind: ScopeTags, Tags: []string{"decision"}}, []string{"3"}},
+	}
+	for _, tt := range tests {
+		results, err := searchScoped(context.Background(), store, "q", 10, tt.scope)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Document.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := (SearchScope{Kind: ScopeRepo}).Validate(); err == nil {
+		t.Error("repo scope without repo should be invalid")
+	}
+	if err := (SearchScope{Kind: "team"}).Validate(); err == nil {
+		t.Error("unknown scope kind should be invalid")
+	}
+}
+
+func TestHandleSearchScope(t *testing.T) {
+	store := &fakeSearchStore{docs: []vectordb.Document{
+		{ID: "1", Content: "a", Metadata: vectordb.DocumentMetadata{FilePath: "main.go", RepoID: "orders"}},
+		{ID: "2", Content: "b", Metadata: vectordb.DocumentMetadata{FilePath: "main.go", RepoID: "payments"}},
+	}}
+
+	body := `{"query":"entry point","scope":{"kind":"repo","repo":"payments"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleSearch(w, req, store, nil, "", nil, nil)
+
+	var resp searchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v (%s)", err, w.Body.String())
+	}
+	if resp.Scope.Kind != ScopeRepo || len(resp.Results) != 1 || resp.Results[0].RepoID != "payments" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"query":"x","scope":{"kind":"tags"}}`))
+	w = httptest.NewRecorder()
+	handleSearch(w, req, store, nil, "", nil, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid scope: status = %d, want 400", w.Code)
+	}
+
+	denyPayments := func(r *http.Request, repo string) bool { return repo != "payments" }
+	req = httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"query":"entry point"}`))
+	w = httptest.NewRecorder()
+	handleSearch(w, req, store, nil, "", nil, denyPayments)
+	resp = searchResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Results) != 1 || resp.Results[0].RepoID != "orders" {
+		t.Errorf("access filter: unexpected results %+v", resp.Results)
+	}
+}
+
+// citingProvider answers with fixed text citing results, recording its prompts.
+type citingProvider struct {
+	llm.MockProvider
+	prompts []string
+}
+
+func (p *citingProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	return &llm.CompletionResponse{Content: strings.Repeat("Orders calls payments over gRPC [2][1], see [2] and [9]. ", 10)}, nil
+}
+
+// fakeFacts serves fixed architecture facts.
+type fakeFacts []contextengine.Fact
+
+func (f fakeFacts) GetCurrentFacts(context.Context, string, string, string) ([]contextengine.Fact, error) {
+	return append([]contextengine.Fact(nil), f...), nil
+}
+
+func TestHandleSearchAnswersQuestions(t *testing.T) {
+	store := &fakeSearchStore{docs: []vectordb.Document{
+		{ID: "1", Content: "a", Metadata: vectordb.DocumentMetadata{FilePath: "main.go", RepoID: "orders"}},
+		{ID: "2", Content: "b", Metadata: vectordb.DocumentMetadata{FilePath: "charge.go", RepoID: "payments"}},
+	}}
+	facts := fakeFacts{
+		{Scope: "service", ScopeID: "orders", Key: "purpose", Value: "takes orders"},
+		{Scope: "service", ScopeID: "ledger", Key: "purpose", Value: "books money"},
+	}
+	denyLedger := func(r *http.Request, repo string) bool { return repo != "ledger" }
+
+	search := func(provider llm.Provider, query string) searchResponse {
+		t.Helper()
+		body := fmt.Sprintf(`{"query":%q}`, query)
+		w := httptest.NewRecorder()
+		handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(body)), store, provider, "", facts, denyLedger)
+		var resp searchResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v (%s)", err, w.Body.String())
+		}
+		return resp
+	}
+
+	provider := &citingProvider{}
+	resp := search(provider, "How do orders get paid?")
+	if resp.Answer == "" || fmt.Sprint(resp.Citations) != "[2 1]" {
+		t.Errorf("answer %q, citations %v; want citations [2 1]", resp.Answer, resp.Citations)
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "orders.purpose = takes orders") {
+		t.Errorf("question prompt lacks recorded facts: %v", provider.prompts)
+	}
+	if strings.Contains(provider.prompts[0], "books money") {
+		t.Error("question prompt includes facts about a repo the user may not see")
+	}
+
+	provider = &citingProvider{}
+	search(provider, "payment retries")
+	if len(provider.prompts) != 1 || strings.Contains(provider.prompts[0], "takes orders") {
+		t.Errorf("keyword search prompt should not include facts: %v", provider.prompts)
+	}
+}
+
+func TestIsQuestion(t *testing.T) {
+	for query, want := range map[string]bool{
+		"How does checkout work": true,
+		"payments retries?":      true,
+		"what":                   true,
+		"OrderService":           false,
+		"whatever handler":       false,
+	} {
+		if got := isQuestion(query); got != want {
+			t.Errorf("isQuestion(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestSectionAnchorMatchesRenderedHeading(t *testing.T) {
+	md := goldmark.New(goldmark.WithParserOptions(parser.WithAutoHeadingID()))
+	for _, symbol := range []string{"handleSearch", "parse_config", "Store.Get", "HTTPServer"} {
+		var buf strings.Builder
+		if err := md.Convert([]byte("### "+symbol), &buf); err != nil {
+			t.Fatal(err)
+		}
+		anchor := sectionAnchor(vectordb.DocumentMetadata{Symbol: symbol, Type: vectordb.DocTypeFunction})
+		if !strings.Contains(buf.String(), `id="`+anchor+`"`) {
+			t.Errorf("anchor %q not found in %s", anchor, buf.String())
+		}
+	}
+
+	if got := sectionAnchor(vectordb.DocumentMetadata{Symbol: "dependencies", Type: vectordb.DocTypeFile}); got != "" {
+		t.Errorf("file-level document anchor = %q, want empty", got)
+	}
+}
+
 // writeTestFile is a helper that creates a file with intermediate directories.
+// cdnTransport serves every request with a script naming the URL, or fails
+// when down.
+type cdnTransport struct{ down bool }
+
+func (c cdnTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if c.down {
+		return nil, errors.New("network unreachable")
+	}
+	body := "/* " + r.URL.String() + " */"
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+}
+
+func TestSiteAssets(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home")
+	writeTestFile(t, filepath.Join(docsDir, "api", "handlers.md"), "# Handlers")
+	writeTestFile(t, filepath.Join(docsDir, "orders", "interactive-map.html"), `<script src="https://d3js.org/d3.v7.min.js"></script>`)
+	assetsDir := t.TempDir()
+
+	out := t.TempDir()
+	gen := NewSiteGenerator(docsDir, out, "Orders")
+	gen.Assets = &Assets{Offline: true, Dir: assetsDir, Client: &http.Client{Transport: cdnTransport{}}}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"index.html":                  `<script src="vendor/mermaid-10.9.1.min.js"></script>`,
+		"api/handlers.html":           `<script src="../vendor/mermaid-10.9.1.min.js"></script>`,
+		"orders/interactive-map.html": `<script src="../vendor/d3-7.9.0.min.js"></script>`,
+		"vendor/d3-7.9.0.min.js":      d3URL,
+	} {
+		data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s missing %q:\n%s", path, want, data)
+		}
+		if strings.HasSuffix(path, ".html") && strings.Contains(string(data), "https://") {
+			t.Errorf("offline page %s still loads from a CDN", path)
+		}
+	}
+
+	// The downloads are kept, so the network isn't needed again.
+	out = t.TempDir()
+	gen = NewSiteGenerator(docsDir, out, "Orders")
+	gen.Assets = &Assets{Dir: assetsDir, Client: &http.Client{Transport: cdnTransport{down: true}}}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	index, _ := os.ReadFile(filepath.Join(out, "index.html"))
+	want := `<script src="` + mermaidURL + `" integrity="` + integrity([]byte("/* "+mermaidURL+" */")) + `" crossorigin="anonymous"></script>`
+	if !strings.Contains(string(index), want) {
+		t.Errorf("CDN script tag should carry its integrity hash, want %s in:\n%s", want, index)
+	}
+	if _, err := os.Stat(filepath.Join(out, "vendor")); !os.IsNotExist(err) {
+		t.Error("an online site should not vendor the libraries")
+	}
+
+	gen = NewSiteGenerator(docsDir, t.TempDir(), "Orders")
+	gen.Assets = &Assets{Offline: true, Dir: t.TempDir(), Client: &http.Client{Transport: cdnTransport{down: true}}}
+	if _, err := gen.Generate(); err == nil || !strings.Contains(err.Error(), "d3-7.9.0.min.js") {
+		t.Errorf("an offline site without the libraries should fail naming them, got %v", err)
+	}
+}
+
+func TestSiteTheme(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home")
+	writeTestFile(t, filepath.Join(docsDir, "orders", "index.md"), "# Orders Service")
+	writeTestFile(t, filepath.Join(docsDir, "orders", "api.md"), "# API")
+	brand := t.TempDir()
+	writeTestFile(t, filepath.Join(brand, "site.css"), ".sidebar { border: 0; }")
+	writeTestFile(t, filepath.Join(brand, "site.js"), "console.log('hi')")
+	writeTestFile(t, filepath.Join(brand, "favicon.png"), "png")
+	writeTestFile(t, filepath.Join(brand, "orders.svg"), "<svg/>")
+
+	out := t.TempDir()
+	gen := NewSiteGenerator(docsDir, out, "Acme")
+	gen.Theme = &Theme{
+		PrimaryColor: "#0b5cad",
+		Font:         "Inter, sans-serif",
+		CSSPath:      filepath.Join(brand, "site.css"),
+		JSPath:       filepath.Join(brand, "site.js"),
+		FaviconPath:  filepath.Join(brand, "favicon.png"),
+		Footer:       "Acme Corp — [handbook](https://handbook.example.com)",
+	}
+	gen.SectionLogos = map[string]string{"orders": filepath.Join(brand, "orders.svg")}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	css := read("theme.css")
+	for _, want := range []string{"--accent: #0b5cad;", "--font: Inter, sans-serif;", "[data-theme=\"dark\"]", ".sidebar { border: 0; }"} {
+		if !strings.Contains(css, want) {
+			t.Errorf("theme.css missing %q:\n%s", want, css)
+		}
+	}
+	if read("theme.js") != "console.log('hi')" || read("favicon.png") != "png" || read("orders/logo.svg") != "<svg/>" {
+		t.Error("theme files should be copied into the site")
+	}
+
+	api := read("orders/api.html")
+	for _, want := range []string{
+		`<link rel="stylesheet" href="../theme.css">`,
+		`<link rel="icon" href="../favicon.png">`,
+		`<script src="../theme.js"></script>`,
+		`<footer class="site-footer"><p>Acme Corp — <a href="https://handbook.example.com">handbook</a></p>`,
+		`<img src="../orders/logo.svg" alt="" class="section-logo">Orders Service</a>`,
+	} {
+		if !strings.Contains(api, want) {
+			t.Errorf("orders/api.html missing %q", want)
+		}
+	}
+	if home := read("index.html"); strings.Contains(home, "section-logo") || !strings.Contains(home, `href="theme.css"`) {
+		t.Error("the home page should be themed but carry no section logo")
+	}
+}
+
+func TestSiteValidation(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home\n\n[API](api/) · [Handlers](api/handlers.md#serve) · [Map](interactive-map.html) · [Go](https://go.dev)")
+	writeTestFile(t, filepath.Join(docsDir, "api", "index.md"), "# API\n\n[Home](../index.md) · [Gone](removed.md) · [Spec](openapi.yaml) · [Up](../../README.md)")
+	writeTestFile(t, filepath.Join(docsDir, "api", "openapi.yaml"), "openapi: 3.0.0")
+	writeTestFile(t, filepath.Join(docsDir, "api", "handlers.md"), "# Handlers\n\n```mermaid\ngraph TD\n  A[Serve (HTTP)] --> B\n```\n\n```mermaid\nsequenceDiagram\n  loop retry\n    A->>B: call (with args)\n  end\n```")
+	writeTestFile(t, filepath.Join(docsDir, "interactive-map.html"), "<html></html>")
+
+	out := t.TempDir()
+	gen := NewSiteGenerator(docsDir, out, "Orders")
+	gen.Validation = &ValidationReport{}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []ValidationIssue{
+		{Kind: IssueMermaid, Page: "api/handlers.md", Detail: "line 2: unquoted label contains '('; quote the label"},
+		{Kind: IssueBrokenLink, Page: "api/index.md", Detail: "`removed.md` points to a page that does not exist"},
+		{Kind: IssueBrokenLink, Page: "api/index.md", Detail: "`openapi.yaml` is not published with the site"},
+		{Kind: IssueBrokenLink, Page: "api/index.md", Detail: "`../../README.md` points outside the site"},
+	}
+	if !reflect.DeepEqual(gen.Validation.Issues, want) {
+		t.Errorf("issues = %#v\nwant %#v", gen.Validation.Issues, want)
+	}
+	if got := gen.Validation.Summary(); got != "3 broken links, 1 Mermaid diagram that does not parse" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	page, err := os.ReadFile(filepath.Join(out, "site-validation.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Broken Links", `<a href="api/index.html">api/index.md</a>`, "removed.md"} {
+		if !strings.Contains(string(page), s) {
+			t.Errorf("validation page missing %q", s)
+		}
+	}
+	home, _ := os.ReadFile(filepath.Join(out, "index.html"))
+	if !strings.Contains(string(home), `href="site-validation.html"`) {
+		t.Error("the sidebar should link the validation page")
+	}
+}
+
+func TestCentralSiteValidation(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "orders", "docs")
+	writeTestFile(t, filepath.Join(docs, "index.md"), "# orders")
+	gen := &CentralSiteGenerator{
+		OutputDir:   filepath.Join(root, "site"),
+		ProjectName: "Test System",
+		Repos:       []RepoInfo{{Name: "orders", DocsDir: docs}, {Name: "payments", DocsDir: docs}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "fraud", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "fraud", LinkType: "grpc"},
+		},
+		Teams:      []TeamInfo{{Name: "checkout", Services: []string{"orders", "carts"}}},
+		Validation: &ValidationReport{},
+	}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := gen.Validation.Summary(); got != "1 broken link, 2 references to unregistered services" {
+		t.Errorf("Summary() = %q; issues %v", got, gen.Validation.Issues)
+	}
+	page, err := os.ReadFile(filepath.Join(gen.OutputDir, "site-validation.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"link orders → fraud names fraud, which is not registered", "team checkout names carts, which is not registered"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("validation page missing %q", want)
+		}
+	}
+}
+
+func TestCheckMermaid(t *testing.T) {
+	for _, tc := range []struct {
+		src, err string
+	}{
+		{"graph TD\n  A[\"Serve (HTTP)\"] --> B((circle))\n  B -->|retry (x3)| C[(db)]\n  C --> D>flag]", ""},
+		{"%% comment\nflowchart LR\n  subgraph one\n    A --> B\n  end", ""},
+		{"stateDiagram-v2\n  state Busy {\n    [*] --> Working\n  }", ""},
+		{"sequenceDiagram\n  alt ok\n    A->>B: hi\n  else failed\n    A->>C: hi\n  end", ""},
+		{"graphTD\n  A --> B", `unknown diagram type "graphTD"`},
+		{"graph TD\n  subgraph one\n    A --> B", "subgraph block is never closed"},
+		{"graph TD\n  A[start --> B", `line 2: unclosed '['`},
+		{"graph TD\n  A[\"start] --> B", "line 2: unterminated string"},
+		{"sequenceDiagram\n  A->>B: hi\n  end", "line 3: end without a block to close"},
+		{"classDiagram\n  class A {\n    +run()", "class block is never closed"},
+	} {
+		err := checkMermaid(tc.src)
+		if got := fmt.Sprint(err); (tc.err == "" && err != nil) || (tc.err != "" && got != tc.err) {
+			t.Errorf("checkMermaid(%q) = %v, want %q", tc.src, err, tc.err)
+		}
+	}
+}
+
 func writeTestFile(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -422,3 +1204,2364 @@ func writeTestFile(t *testing.T, path, content string) {
 		t.Fatal(err)
 	}
 }
+
+func TestVisibleTo(t *testing.T) {
+	tests := []struct {
+		visibility, audience string
+		want                 bool
+	}{
+		{"public", "public", true},
+		{"internal", "public", false},
+		{"", "public", false}, // unlabeled repos default to internal
+		{"", "internal", true},
+		{"restricted:payments", "internal", false},
+		{"restricted:payments", "team:payments", true},
+		{"restricted:payments", "team:search", false},
+		{"internal", "team:search", true},
+		{"restricted:payments", "all", true},
+		{"restricted:payments", "", true},
+	}
+	for _, tt := range tests {
+		if got := VisibleTo(tt.visibility, tt.audience); got != tt.want {
+			t.Errorf("VisibleTo(%q, %q) = %v, want %v", tt.visibility, tt.audience, got, tt.want)
+		}
+	}
+
+	if ValidateVisibility("restricted:") == nil || ValidateAudience("partners") == nil {
+		t.Error("expected invalid visibility and audience to be rejected")
+	}
+}
+
+func TestCentralSiteVariants(t *testing.T) {
+	root := t.TempDir()
+	repoDocs := func(name string) string {
+		dir := filepath.Join(root, name, "docs")
+		writeTestFile(t, filepath.Join(dir, "index.md"), "# "+name+"\n\nDocs for "+name+".")
+		return dir
+	}
+
+	gen := &CentralSiteGenerator{
+		OutputDir:   filepath.Join(root, "site"),
+		ProjectName: "Test System",
+		Repos: []RepoInfo{
+			{Name: "sdk", Summary: "Client SDK", DocsDir: repoDocs("sdk"), Visibility: VisibilityPublic},
+			{Name: "orders", Summary: "Orders", DocsDir: repoDocs("orders")},
+			{Name: "ledger", Summary: "Ledger", DocsDir: repoDocs("ledger"), Visibility: "restricted:finance"},
+		},
+		Links: []LinkInfo{{FromRepo: "sdk", ToRepo: "orders", LinkType: "http"}},
+		Metrics: &metrics.Snapshot{Window: "5m", Edges: []metrics.EdgeMetrics{
+			{From: "sdk", To: "orders", RequestRate: 5},
+			{From: "orders", To: "ledger", RequestRate: 2},
+		}},
+		Deprecations: []deprecation.Deprecation{
+			{Service: "sdk", Endpoint: "GET /v1/client", Source: deprecation.SourceConfig},
+			{Service: "ledger", Endpoint: "POST /v1/journal", Source: deprecation.SourceOpenAPI, Evidence: "ledger/openapi.yaml"},
+		},
+	}
+
+	counts, err := gen.GenerateVariants([]SiteVariant{
+		{Name: "partner", Audience: AudiencePublic},
+		{Name: "finance", Audience: "team:finance", OutputDir: filepath.Join(root, "finance-site")},
+	})
+	if err != nil {
+		t.Fatalf("GenerateVariants: %v", err)
+	}
+	if counts["partner"] == 0 || counts["finance"] == 0 {
+		t.Fatalf("unexpected page counts: %v", counts)
+	}
+
+	exists := func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}
+	partner := filepath.Join(root, "site-partner")
+	if !exists(filepath.Join(partner, "sdk", "index.html")) {
+		t.Error("partner site should include the public repo")
+	}
+	if exists(filepath.Join(partner, "orders")) || exists(filepath.Join(partner, "ledger")) {
+		t.Error("partner site should only include public repos")
+	}
+	if !exists(filepath.Join(root, "finance-site", "ledger", "index.html")) {
+		t.Error("finance site should include the repo restricted to finance")
+	}
+	if data, err := os.ReadFile(filepath.Join(partner, "service-map.html")); err != nil || strings.Contains(string(data), "ledger") {
+		t.Errorf("partner service map should not mention the restricted repo's metrics (err %v)", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(partner, "deprecations.html")); err != nil || strings.Contains(string(data), "ledger") || strings.Contains(string(data), "/v1/journal") {
+		t.Errorf("partner deprecations page should not list the restricted repo's deprecations (err %v)", err)
+	}
+	if len(gen.Repos) != 3 || len(gen.Links) != 1 || len(gen.Metrics.Edges) != 2 || len(gen.Deprecations) != 2 {
+		t.Error("GenerateVariants should not modify the generator's inputs")
+	}
+}
+
+func TestWriteChangelogPage(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{Changes: []ChangeInfo{
+		{
+			Date:      time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			Summary:   "order-service added dependency on fraud-service (POST /api/score)",
+			CommitSHA: "abcdef1234567",
+			CommitURL: "https://github.com/acme/order-service/commit/abcdef1234567",
+		},
+		{Date: time.Date(2024, 4, 29, 9, 0, 0, 0, time.UTC), Summary: "fraud-service was added"},
+		{Date: time.Date(2024, 4, 24, 9, 0, 0, 0, time.UTC), Summary: "legacy-service was removed", CommitSHA: "1234567890"},
+	}}
+	if err := gen.writeChangelogPage(dir); err != nil {
+		t.Fatalf("writeChangelogPage: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "changelog.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"## Week of 2024-04-29\n\n- **2024-05-02**: order-service added dependency on fraud-service (POST /api/score) ([`abcdef1`](https://github.com/acme/order-service/commit/abcdef1234567))\n- **2024-04-29**: fraud-service was added\n",
+		"## Week of 2024-04-22\n\n- **2024-04-24**: legacy-service was removed (`1234567`)\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("changelog missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Count(page, "## Week of") != 2 {
+		t.Errorf("expected two weekly groups:\n%s", page)
+	}
+}
+
+func TestWriteTeamPages(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders", Summary: "Order intake"}, {Name: "fraud"}, {Name: "ledger"}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "fraud", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "ledger", LinkType: "grpc"},
+			{FromRepo: "fraud", ToRepo: "ledger", LinkType: "grpc"},
+		},
+		Teams: []TeamInfo{
+			{Name: "checkout", DisplayName: "Checkout Team", SlackChannel: "#checkout", Services: []string{"orders"},
+				Members: []TeamMemberInfo{{UserID: "alex", Role: "lead"}}},
+			{Name: "risk", Email: "risk@example.com", Services: []string{"fraud", "ledger"}},
+		},
+	}
+	if err := gen.writeTeamPages(dir); err != nil {
+		t.Fatalf("writeTeamPages: %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, "teams", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	index := read("index.md")
+	for _, want := range []string{
+		"| [Checkout Team](checkout.md) | orders | 1 | #checkout |",
+		"T0[\"Checkout Team\"]",
+		"T0 -->|2| T1",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("teams index missing %q:\n%s", want, index)
+		}
+	}
+	// fraud -> ledger stays within the risk team.
+	if strings.Contains(index, "T1 -->") {
+		t.Errorf("links within a team should not appear in the graph:\n%s", index)
+	}
+
+	checkout := read("checkout.md")
+	for _, want := range []string{
+		"- [orders](../orders/index.md) — Order intake",
+		"| alex | lead |",
+		"## Depends On\n\n- [risk](risk.md): orders → fraud (http), orders → ledger (grpc)",
+	} {
+		if !strings.Contains(checkout, want) {
+			t.Errorf("checkout page missing %q:\n%s", want, checkout)
+		}
+	}
+	if risk := read("risk.md"); !strings.Contains(risk, "## Depended On By\n\n- [Checkout Team](checkout.md)") || !strings.Contains(risk, "mailto:risk@example.com") {
+		t.Errorf("unexpected risk page:\n%s", risk)
+	}
+
+	coupling := read("coupling.md")
+	for _, want := range []string{
+		"**2** links cross team boundaries",
+		"<tr><th>Checkout Team</th><td style=\"color:#888\">0</td><td style=\"background:rgba(220,38,38,0.90)\">2</td></tr>",
+		"| [Checkout Team](checkout.md) | 2 | 0 | 0 | 1 |",
+		"| [risk](risk.md) | 0 | 2 | 1 | 1 |",
+	} {
+		if !strings.Contains(coupling, want) {
+			t.Errorf("coupling page missing %q:\n%s", want, coupling)
+		}
+	}
+}
+
+func TestWriteRepoIndexWhoToPage(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{}
+	if err := gen.writeRepoIndex(dir, RepoInfo{
+		Name:   "payments",
+		Status: "ready",
+		OnCall: &OnCallInfo{Schedule: "pagerduty:P123", Responders: []string{"Ada Lovelace (L1)", "Grace Hopper (L2)"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	for _, want := range []string{"## Who to Page", "- Ada Lovelace (L1)", "- Grace Hopper (L2)", "`pagerduty:P123`"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("repo index missing %q:\n%s", want, page)
+		}
+	}
+
+	if err := gen.writeRepoIndex(dir, RepoInfo{Name: "ledger", OnCall: &OnCallInfo{Schedule: "opsgenie:ledger", Error: "opsgenie is not configured"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "index.md"))
+	if !strings.Contains(string(data), "On-call lookup for `opsgenie:ledger` failed: opsgenie is not configured") {
+		t.Errorf("expected lookup failure to be shown:\n%s", data)
+	}
+}
+
+func TestCentralSiteCustomPages(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "payments", "docs")
+	writeTestFile(t, filepath.Join(docs, "index.md"), "# payments\n\nPayments docs.")
+	pages := filepath.Join(root, "pages")
+	writeTestFile(t, filepath.Join(pages, "onboarding.md"), "---\ntitle: Start Here\norder: -10\n---\nWelcome aboard.")
+	writeTestFile(t, filepath.Join(pages, "rfcs", "index.md"), "---\nsection: RFC Index\norder: 5\n---\n# RFCs\n\nAll RFCs.")
+	writeTestFile(t, filepath.Join(pages, "rfcs", "0001.md"), "---\nsection: RFC Index\n---\n# RFC 1: Events")
+	writeTestFile(t, filepath.Join(pages, "runbook.md"), "---\nsection: payments\ntitle: Incident Runbook\n---\nPage the on-call.")
+	writeTestFile(t, filepath.Join(pages, "index.md"), "# Not the landing page")
+	writeTestFile(t, filepath.Join(pages, "partners.md"), "---\nvisibility: public\n---\n# Partner Guide")
+
+	gen := &CentralSiteGenerator{
+		OutputDir:   filepath.Join(root, "site"),
+		ProjectName: "Test System",
+		Repos:       []RepoInfo{{Name: "payments", Summary: "Payments", DocsDir: docs}},
+		PagesDir:    pages,
+	}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(gen.OutputDir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if page := read("onboarding.html"); !strings.Contains(page, "<h1") || !strings.Contains(page, "Start Here") || strings.Contains(page, "order:") {
+		t.Errorf("front matter should be stripped and the title added as a heading:\n%s", page)
+	}
+	if page := read("payments/runbook.html"); !strings.Contains(page, "Page the on-call.") {
+		t.Errorf("a page sectioned under a repo should sit in its directory:\n%s", page)
+	}
+	read("rfc-index/0001.html")
+	if home := read("index.html"); strings.Contains(home, "Not the landing page") {
+		t.Error("a custom page should not replace a generated one")
+	}
+
+	// The sidebar follows the front matter: onboarding first, the RFC
+	// section after the generated pages, named as written.
+	nav := read("system-overview.html")
+	start := strings.Index(nav, ">Start Here<")
+	overview := strings.Index(nav, ">System Overview<")
+	rfcs := strings.Index(nav, ">RFC Index<")
+	if start < 0 || overview < 0 || rfcs < 0 || start > overview || overview > rfcs {
+		t.Errorf("sidebar order: Start Here at %d, System Overview at %d, RFC Index at %d", start, overview, rfcs)
+	}
+	if !strings.Contains(nav, ">Incident Runbook<") {
+		t.Error("sidebar should name pages by their front matter title")
+	}
+
+	gen.Repos = []RepoInfo{{Name: "payments", DocsDir: docs, Visibility: VisibilityPublic}}
+	gen.OutputDir = filepath.Join(root, "partner")
+	gen.Audience = AudiencePublic
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(gen.OutputDir, "onboarding.html")); !os.IsNotExist(err) {
+		t.Error("internal pages should be left off a public site")
+	}
+	read("partners.html")
+}
+
+func TestQualityScores(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "orders", Summary: "Takes orders."},
+			{Name: "payments", Facts: map[string]string{"owner": "money"}},
+		},
+		Links: []LinkInfo{{FromRepo: "orders", ToRepo: "payments", Endpoints: []string{"POST /charges/{id}/capture", "GET /refunds"}}},
+		Flows: []FlowInfo{{Name: "Checkout", Services: []string{"orders", "payments"}}},
+		Teams: []TeamInfo{{Name: "shop", Services: []string{"orders"}}},
+		analyses: map[string]map[string]indexer.FileAnalysis{
+			"orders": {
+				"main.go":    {Summary: "Entry point.", Purpose: "Starts the server."},
+				"store.go":   {Summary: "Order storage."},
+				"go.sum":     {Skip: true},
+				"handler.go": {Truncation: &indexer.Truncation{}},
+			},
+			"payments": {
+				"charge.go": {Summary: "Charges cards.", Purpose: "Charging.", Functions: []indexer.FunctionDoc{{Name: "Capture", Summary: "Handles POST /charges/{id}/capture."}}},
+			},
+		},
+	}
+	scores := gen.scoreQuality()
+
+	orders := scores["orders"]
+	if got := fmt.Sprint(orders.Checks); got != "[{Coverage 20 30 1 files were too large to analyze in full} {Purpose 13 20 2 of 3 files have no stated purpose} {Endpoints 15 15 } {Ownership 15 15 } {Flows 20 20 }]" {
+		t.Errorf("orders checks = %s", got)
+	}
+	if orders.Total != 83 {
+		t.Errorf("orders total = %d, want 83", orders.Total)
+	}
+	payments := scores["payments"]
+	if got := fmt.Sprint(payments.Checks); got != "[{Coverage 30 30 } {Purpose 10 20 No service summary: tell the context engine what the service is for} {Endpoints 8 15 Called but not documented: `GET /refunds`} {Ownership 15 15 } {Flows 20 20 }]" {
+		t.Errorf("payments checks = %s", got)
+	}
+
+	gen.quality = scores
+	dir := t.TempDir()
+	if err := gen.writeQualityPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "quality.md"))
+	for _, want := range []string{
+		"| 1 | [orders](orders/index.md) | " + qualityBadge(83, "#orders") + " | 20/30 | 13/20 | 15/15 | 15/15 | 20/20 |",
+		"### payments {#payments}",
+		"- **Endpoints** (8/15): Called but not documented: `GET /refunds`",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("quality page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteSLOs(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{
+		Flows: []FlowInfo{{Name: "Checkout", Narrative: "Buying things.", Services: []string{"gateway", "payments", "email"}}},
+		SLOs: map[string][]slo.SLO{
+			"gateway": {{Service: "gateway", Availability: 99.99, Source: "config"}},
+			"payments": {
+				{Service: "payments", Availability: 99.5, LatencyPercentile: 99, Latency: 300 * time.Millisecond, Source: "fact"},
+				{Service: "payments", Endpoint: "POST /charges", Availability: 99.9, Source: "config"},
+			},
+		},
+	}
+
+	if err := gen.writeRepoIndex(dir, RepoInfo{Name: "Payments"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Service Level Objectives", "| Whole service | 99.5% | p99 < 300ms | fact |", "| `POST /charges` | 99.9% | — | config |"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("service page missing %q:\n%s", want, data)
+		}
+	}
+
+	// Repos with generated docs keep their own index; the sections are
+	// appended to it instead.
+	if got := gen.serviceSections(RepoInfo{Name: "payments"}); !strings.HasPrefix(got, "## Service Level Objectives") {
+		t.Errorf("serviceSections(payments) = %q", got)
+	}
+	if got := gen.serviceSections(RepoInfo{Name: "email"}); got != "" {
+		t.Errorf("serviceSections(email) = %q, want empty", got)
+	}
+
+	if err := gen.writeFlowsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "flows.md"))
+	for _, want := range []string{
+		"**Weakest link:** payments at 99.5% availability; the journey can promise at most 99.49% end to end",
+		"*No SLO declared for: email.*",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("flows page missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestCentralSiteExistingIndex(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "payments", "docs")
+	writeTestFile(t, filepath.Join(docs, "index.md"), "# Payments\n\nGenerated overview.")
+	gen := &CentralSiteGenerator{
+		OutputDir:   filepath.Join(root, "site"),
+		ProjectName: "Test System",
+		Repos:       []RepoInfo{{Name: "payments", DocsDir: docs}},
+		SLOs:        map[string][]slo.SLO{"payments": {{Service: "payments", Availability: 99.5, Source: "config"}}},
+	}
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(gen.OutputDir, "payments", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Generated overview.", "Service Level Objectives"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("payments index missing %q", want)
+		}
+	}
+
+	// An index the sections can't be added to fails the build.
+	os.Remove(filepath.Join(docs, "index.md"))
+	if err := os.MkdirAll(filepath.Join(docs, "index.md"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Generate(); err == nil || !strings.Contains(err.Error(), "writing payments index") {
+		t.Errorf("Generate() error = %v, want the index error", err)
+	}
+}
+
+func TestCentralSiteDependencies(t *testing.T) {
+	legacy := t.TempDir()
+	os.WriteFile(filepath.Join(legacy, "pom.xml"), []byte(`<project><dependencies>
+<dependency><groupId>org.apache.logging.log4j</groupId><artifactId>log4j-core</artifactId><version>2.14.1</version></dependency>
+</dependencies></project>`), 0o644)
+	mono := t.TempDir()
+	os.MkdirAll(filepath.Join(mono, "services", "search"), 0o755)
+	os.WriteFile(filepath.Join(mono, "build.gradle"), []byte(`dependencies {
+    implementation 'org.apache.logging.log4j:log4j-core:2.17.1'
+    testImplementation 'junit:junit:4.13.2'
+}`), 0o644)
+	os.WriteFile(filepath.Join(mono, "services", "search", "pom.xml"), []byte(`<project><dependencies>
+<dependency><groupId>org.apache.logging.log4j</groupId><artifactId>log4j-core</artifactId><version>2.14.1</version></dependency>
+</dependencies></project>`), 0o644)
+
+	gen := &CentralSiteGenerator{Repos: []RepoInfo{
+		{Name: "legacy", DocsDir: filepath.Join(legacy, ".autodoc", "docs")},
+		{Name: "platform", DocsDir: filepath.Join(mono, ".autodoc", "docs"), Excludes: []string{"services/search"}},
+		{Name: "search", DocsDir: filepath.Join(mono, ".autodoc", "docs"), Subdir: "services/search"},
+		{Name: "docs-only"},
+	}}
+	gen.dependencies = gen.loadDependencies()
+	if len(gen.dependencies) != 3 || len(gen.dependencies["platform"]) != 2 || len(gen.dependencies["search"]) != 1 {
+		t.Fatalf("dependencies = %+v", gen.dependencies)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeDependenciesPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "dependencies.md"))
+	for _, want := range []string{
+		"2 libraries in use across 3 services",
+		"| `org.apache.logging.log4j:log4j-core` | `2.14.1` | Maven | — | [legacy](legacy/dependencies.md), [search](search/dependencies.md) |",
+		"| `org.apache.logging.log4j:log4j-core` | `2.17.1` | Maven | — | [platform](platform/dependencies.md) |",
+		"| `junit:junit` | `4.13.2` | Maven | — | [platform](platform/dependencies.md) (dev) |",
+		"- `org.apache.logging.log4j:log4j-core` (Maven): 2.14.1, 2.17.1",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("dependencies page missing %q:\n%s", want, page)
+		}
+	}
+
+	if err := gen.writeServiceDependencies(dir, gen.Repos[1]); err != nil {
+		t.Fatal(err)
+	}
+	page, _ = os.ReadFile(filepath.Join(dir, "dependencies.md"))
+	if strings.Contains(string(page), "— platform") {
+		t.Error("an existing dependencies.md should not be overwritten")
+	}
+	serviceDir := t.TempDir()
+	if err := gen.writeServiceDependencies(serviceDir, gen.Repos[1]); err != nil {
+		t.Fatal(err)
+	}
+	page, _ = os.ReadFile(filepath.Join(serviceDir, "dependencies.md"))
+	if !strings.Contains(string(page), "| `junit:junit` | `4.13.2` | Maven | dev | — | `build.gradle` |") {
+		t.Errorf("service dependencies page:\n%s", page)
+	}
+	if sections := gen.serviceSections(gen.Repos[1]); !strings.Contains(sections, "declares 2 third-party libraries") {
+		t.Errorf("service sections = %q", sections)
+	}
+}
+
+func TestCentralSiteExposure(t *testing.T) {
+	web, orders, gateway := t.TempDir(), t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(web, "app.js"), []byte(`const app = express();
+app.get('/status', (req, res) => res.send('ok'));
+app.get('/me', requireAuth, (req, res) => res.json(req.user));
+app.post('/signup', (req, res) => res.sendStatus(201));
+`), 0o644)
+	indexer.SaveAnalyses(web, map[string]indexer.FileAnalysis{"app.js": {FilePath: "app.js", Language: "JavaScript"}})
+	os.WriteFile(filepath.Join(orders, "main.go"), []byte(`package main
+
+func routes(r chi.Router) {
+	r.Get("/api/orders", list)
+	r.Get("/health", health)
+}
+`), 0o644)
+	indexer.SaveAnalyses(orders, map[string]indexer.FileAnalysis{"main.go": {FilePath: "main.go", Language: "Go"}})
+	os.WriteFile(filepath.Join(gateway, "kong.yml"), []byte(`_format_version: "3.0"
+services:
+  - name: orders
+    plugins:
+      - name: key-auth
+    routes:
+      - paths: [/api/orders]
+`), 0o644)
+	indexer.SaveAnalyses(gateway, map[string]indexer.FileAnalysis{"kong.yml": {FilePath: "kong.yml", Language: "YAML"}})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "web", DocsDir: filepath.Join(web, ".autodoc", "docs")},
+			{Name: "orders", DocsDir: filepath.Join(orders, ".autodoc", "docs")},
+			{Name: "gateway", DocsDir: filepath.Join(gateway, ".autodoc", "docs")},
+		},
+		Overrides: []overrides.Override{
+			{Service: "web", Endpoint: "POST /signup", Remove: true, Public: true, ProvidedBy: "ada"},
+		},
+		entryPoints: []EntryPoint{{Service: "web", Kind: EntryUI}},
+	}
+	gen.exposure = gen.assessExposure()
+	var got []string
+	for _, name := range []string{"web", "orders"} {
+		for _, e := range gen.exposure[name] {
+			got = append(got, name+" "+e.Route+" "+e.Auth+" "+e.Evidence)
+		}
+	}
+	want := []string{
+		"web GET /status none ",
+		"web GET /me required requireAuth",
+		"web POST /signup public ",
+		"orders GET /api/orders required Kong key-auth plugin on /api/orders",
+		"orders GET /health none ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exposure =\n%q\nwant\n%q", got, want)
+	}
+	if _, ok := gen.exposure["gateway"]; ok {
+		t.Error("a repo without routes should have no endpoints")
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeExposurePage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "exposure.md"))
+	for _, want := range []string{
+		"5 HTTP endpoints across 2 services",
+		"1 endpoints on internet-facing services have no authentication found.",
+		"| [web](web/index.md) | `GET /status` | `app.js:2` |",
+		"### [web](web/index.md) — internet-facing",
+		"| `POST /signup` | Public on purpose | — | *Corrected by ada",
+		"| `GET /api/orders` | Required | `Kong key-auth plugin on /api/orders` | `gateway/kong.yml` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("exposure report missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), "| [orders](orders/index.md) | `GET /health`") {
+		t.Error("endpoints of services that aren't internet-facing should not be flagged")
+	}
+}
+
+func TestCentralSitePIIFlow(t *testing.T) {
+	users, billing, email := t.TempDir(), t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(users, "events.proto"), []byte(`syntax = "proto3";
+message UserRegistered {
+  string user_id = 1;
+  string email_address = 2;
+}
+`), 0o644)
+	indexer.SaveAnalyses(users, map[string]indexer.FileAnalysis{"events.proto": {FilePath: "events.proto", Language: "Protocol Buffers"}})
+	os.WriteFile(filepath.Join(billing, "schema.sql"), []byte(`CREATE TABLE cards (
+    id BIGINT PRIMARY KEY,
+    card_number TEXT,
+    billing_email TEXT
+);`), 0o644)
+	indexer.SaveAnalyses(billing, map[string]indexer.FileAnalysis{"schema.sql": {FilePath: "schema.sql", Language: "SQL"}})
+	indexer.SaveAnalyses(email, map[string]indexer.FileAnalysis{})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "users", DocsDir: filepath.Join(users, ".autodoc", "docs")},
+			{Name: "billing", DocsDir: filepath.Join(billing, ".autodoc", "docs")},
+			{Name: "email", DocsDir: filepath.Join(email, ".autodoc", "docs")},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "users", ToRepo: "email", LinkType: "kafka", Endpoints: []string{"users.user-registered.v1"}},
+			{FromRepo: "users", ToRepo: "billing", LinkType: "http"},
+		},
+		DataClasses: []dataclass.Class{
+			{Name: "card number", Category: "PCI", Fields: []string{"card_number", "pan"}},
+			{Name: "email", Category: "PII", Fields: []string{"email"}},
+			{Name: "phone", Category: "PII", Fields: []string{"phone"}},
+		},
+	}
+	gen.dataFlow = gen.trackSensitiveData()
+	if gen.dataFlow == nil {
+		t.Fatal("no sensitive data found")
+	}
+	want := map[string][]string{"users": {"email"}, "billing": {"card number", "email"}, "email": {"email"}}
+	if !reflect.DeepEqual(gen.dataFlow.services, want) {
+		t.Errorf("services = %v, want %v", gen.dataFlow.services, want)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writePIIFlowPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "pii-flow.md"))
+	for _, want := range []string{
+		"3 services and 1 topics carry them",
+		"    svc_users -->|email| topic_users_user_registered_v1\n    topic_users_user_registered_v1 --> svc_email",
+		"    svc_users -.->|email| svc_billing",
+		"### card number (PCI)",
+		"| [billing](billing/index.md) | `cards` (table) | `card_number` | `schema.sql:1` |",
+		"| [users](users/index.md) | `UserRegistered` (protobuf message) | `email_address` | `events.proto:2` |",
+		"**Topics:** `users.user-registered.v1` (UserRegistered)",
+		"No schema has a field matching `phone`.",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("PII flow page missing %q:\n%s", want, page)
+		}
+	}
+	if sections := gen.serviceSections(gen.Repos[1]); !strings.Contains(sections, "This service carries card number (PCI), email (PII)") {
+		t.Errorf("service sections = %q", sections)
+	}
+}
+
+func TestCentralSiteThreatModel(t *testing.T) {
+	orders := t.TempDir()
+	indexer.SaveAnalyses(orders, map[string]indexer.FileAnalysis{
+		"db.go": {FilePath: "db.go", Language: "Go", Dependencies: []indexer.Dependency{{Name: "postgres", Type: "database"}, {Name: "fmt", Type: "import"}}},
+	})
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "web"},
+			{Name: "orders", DocsDir: filepath.Join(orders, ".autodoc", "docs")},
+			{Name: "email"},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "web", ToRepo: "orders", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "email", LinkType: "kafka", Endpoints: []string{"order-placed"}},
+			{FromRepo: "orders", ToRepo: "stripe", LinkType: "http"},
+		},
+		Flows:       []FlowInfo{{Name: "Checkout", Services: []string{"web", "orders", "email"}}},
+		entryPoints: []EntryPoint{{Service: "web", Kind: EntryUI}},
+		exposure:    map[string][]endpointExposure{"orders": {{Route: "POST /orders", Auth: indexer.AuthNone}}},
+		ThreatNotes: []threatmodel.Note{
+			{Flow: "checkout", ThreatID: "web->orders/S", Status: threatmodel.StatusMitigated, Text: "mTLS between all services", ProvidedBy: "ada"},
+			{Flow: "Checkout", ThreatID: "email->smtp/I", Text: "Receipts include the customer's address", ProvidedBy: "grace"},
+			{Flow: "Refunds", ThreatID: "orders->email/T", Status: threatmodel.StatusAccepted},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeThreatModelPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "threat-model.md"))
+	for _, want := range []string{
+		"- Internet → web\n- orders → stripe (outside the system)\n- orders → postgres (data store)\n",
+		"**Data stores:** `postgres` (orders)",
+		"| `internet->web/D` | Denial of service | Is web rate-limited",
+		"| `web->orders/S` | Spoofing | Can orders tell calls from web apart from any other workload on the network? Check for mTLS or service credentials. | Mitigated: mTLS between all services *(ada)* |",
+		"| `web->orders/E` | Elevation of privilege | orders has 1 endpoints with no authentication found, e.g. `POST /orders`",
+		"| `orders->email/T` | Tampering | Messages on `order-placed` could be forged or replayed; does email validate them and handle duplicates safely? | Open |",
+		"| `orders->stripe/S` | Spoofing |",
+		"| `orders->postgres/T` | Tampering |",
+		"| `email->smtp/I` | Information disclosure | Receipts include the customer's address | Open *(grace)* |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("threat model missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), "postgres (data store)\n- orders → postgres") || strings.Contains(string(page), "Accepted") {
+		t.Errorf("threat model:\n%s", page)
+	}
+}
+
+func TestCentralSiteDomains(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "orders", Domain: "commerce", BoundedContext: "checkout", Environment: "prod"},
+			{Name: "cart", Domain: "commerce", BoundedContext: "checkout", Environment: "prod"},
+			{Name: "catalog", Domain: "commerce"},
+			{Name: "payments", Domain: "payments", Environment: "prod"},
+			{Name: "search"},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "cart", ToRepo: "orders", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"},
+			{FromRepo: "orders", ToRepo: "stripe", LinkType: "http"},
+			{FromRepo: "search", ToRepo: "catalog", LinkType: "http"},
+		},
+	}
+	dir := t.TempDir()
+	if err := gen.writeDomainPages(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(dir, "domains", "index.md"))
+	for _, want := range []string{
+		"| [Commerce](commerce.md) | Checkout | 3 | prod |",
+		"| [Payments](payments.md) |  | 1 | prod |",
+		"**Not in a domain:** [search](../search/index.md)",
+		"D_commerce -->|1| D_payments",
+	} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("domains/index.md missing %q:\n%s", want, index)
+		}
+	}
+
+	page, _ := os.ReadFile(filepath.Join(dir, "domains", "commerce.md"))
+	for _, want := range []string{
+		"**Services:** 3 · **Bounded contexts:** 1 · [Open in the service map](../service-map.html?domain=commerce)",
+		"| [catalog](../catalog/index.md) |  |  |",
+		"| [cart](../cart/index.md) | Checkout | prod |",
+		"    subgraph C_checkout[\"Checkout\"]\n        S_cart[\"cart\"]\n        S_orders[\"orders\"]\n    end",
+		"D_payments{{\"Payments domain\"}}",
+		"S_stripe([\"stripe\"])",
+		"S_cart -->|http| S_orders",
+		"S_orders -->|grpc| D_payments",
+		"S_search -->|http| S_catalog",
+		"## Depends On\n\n- [Payments](payments.md): orders → payments (grpc)",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("domains/commerce.md missing %q:\n%s", want, page)
+		}
+	}
+
+	groups := gen.sectionGroups()
+	if !reflect.DeepEqual(groups["orders"], []string{"Commerce", "Checkout"}) || !reflect.DeepEqual(groups["catalog"], []string{"Commerce"}) || groups["search"] != nil {
+		t.Errorf("sectionGroups = %v", groups)
+	}
+}
+
+func TestServiceMapClusters(t *testing.T) {
+	gen := &CentralSiteGenerator{Teams: []TeamInfo{
+		{Name: "checkout", DisplayName: "Checkout", Services: []string{"orders", "cart"}},
+		{Name: "platform", Services: []string{"cart", "auth"}},
+	}}
+	nodes := []serviceMapNode{{ID: "orders", Domain: "commerce"}, {ID: "cart"}, {ID: "auth"}, {ID: "search"}, {ID: "stripe", Status: "external"}}
+	clusters := gen.serviceMapClusters(nodes)
+	want := []serviceMapCluster{
+		{ID: "domain:commerce", Label: "Commerce", Kind: "domain"},
+		{ID: "team:checkout", Label: "Checkout", Kind: "team"},
+		{ID: "team:platform", Label: "platform", Kind: "team"},
+	}
+	if !reflect.DeepEqual(clusters, want) {
+		t.Errorf("clusters = %+v, want %+v", clusters, want)
+	}
+	var got []string
+	for _, n := range nodes {
+		got = append(got, n.Cluster)
+	}
+	if want := []string{"domain:commerce", "team:checkout", "team:platform", "", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("node clusters = %q, want %q", got, want)
+	}
+
+	// One cluster groups nothing.
+	nodes = []serviceMapNode{{ID: "orders"}, {ID: "cart"}}
+	if clusters := gen.serviceMapClusters(nodes); clusters != nil || nodes[0].Cluster != "" {
+		t.Errorf("single team clustered: %+v, %+v", clusters, nodes)
+	}
+}
+
+func TestServiceMapPaths(t *testing.T) {
+	edges := []serviceMapEdge{
+		{Source: "web", Target: "orders", LinkType: "http"},
+		{Source: "orders", Target: "payments", LinkType: "grpc"},
+		{Source: "web", Target: "payments", LinkType: "http"},
+		{Source: "payments", Target: "orders", LinkType: "kafka"},
+		{Source: "payments", Target: "ledger", LinkType: "kafka"},
+	}
+	paths := serviceMapPaths(edges)
+	if got, want := paths["web>ledger"], [][]int{{2, 4}, {0, 1, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("web>ledger = %v, want %v", got, want)
+	}
+	// The cycle between orders and payments is not followed round.
+	if got, want := paths["orders>orders"], [][]int(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("orders>orders = %v, want none", got)
+	}
+	if got, want := paths["web>orders"], [][]int{{0}, {2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("web>orders = %v, want %v", got, want)
+	}
+	if _, ok := paths["ledger>web"]; ok {
+		t.Error("found a path against the edges")
+	}
+
+	// Only the shortest maxPaths are kept.
+	edges = nil
+	for i := range maxPaths + 2 {
+		hop := fmt.Sprintf("hop%d", i)
+		edges = append(edges, serviceMapEdge{Source: "a", Target: hop}, serviceMapEdge{Source: hop, Target: "z"})
+	}
+	edges = append(edges, serviceMapEdge{Source: "a", Target: "z"})
+	got := serviceMapPaths(edges)["a>z"]
+	if len(got) != maxPaths || len(got[0]) != 1 {
+		t.Errorf("a>z = %v, want the direct edge first and %d paths", got, maxPaths)
+	}
+}
+
+func TestServiceMapHistory(t *testing.T) {
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	gen := &CentralSiteGenerator{History: []GraphSnapshot{
+		{TakenAt: day, Services: []string{"orders"}},
+		{TakenAt: day.Add(2 * time.Hour), Services: []string{"orders", "legacy-billing"}, Links: []LinkInfo{{FromRepo: "orders", ToRepo: "legacy-billing", LinkType: "http"}}},
+		{TakenAt: day.AddDate(0, 1, 0), Services: []string{"orders", "payments"}, Links: []LinkInfo{{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"}}},
+	}}
+	data := serviceMapData{
+		Nodes: []serviceMapNode{{ID: "orders"}, {ID: "payments"}},
+		Edges: []serviceMapEdge{{Source: "orders", Target: "payments", LinkType: "grpc"}},
+	}
+	steps := gen.serviceMapHistory(&data)
+	want := []serviceMapSnapshot{
+		{At: day.Add(2 * time.Hour), Services: []string{"orders", "legacy-billing"}, Links: []string{"orders>legacy-billing>http"}},
+		{At: day.AddDate(0, 1, 0), Services: []string{"orders", "payments"}, Links: []string{"orders>payments>grpc"}},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %+v, want %+v", steps, want)
+	}
+	if len(data.Nodes) != 3 || data.Nodes[2].ID != "legacy-billing" || data.Nodes[2].Status != "removed" {
+		t.Errorf("nodes = %+v, want legacy-billing added as removed", data.Nodes)
+	}
+	if len(data.Edges) != 2 || !data.Edges[1].Removed || data.Edges[1].Target != "legacy-billing" {
+		t.Errorf("edges = %+v, want the legacy-billing link added as removed", data.Edges)
+	}
+
+	// A restricted site's timeline leaves out services that are gone.
+	gen.Repos = []RepoInfo{{Name: "orders", Visibility: VisibilityPublic}, {Name: "payments"}}
+	gen.Audience = AudiencePublic
+	gen.applyAudience()
+	if got := gen.History[1]; !reflect.DeepEqual(got.Services, []string{"orders"}) || len(got.Links) != 0 {
+		t.Errorf("public snapshot = %+v", got)
+	}
+}
+
+func TestC4Page(t *testing.T) {
+	ordersDocs := t.TempDir()
+	components := `{"id":"orders","name":"orders","components":[{"id":"api","name":"API"},{"id":"store","name":"Store"}],"relationships":[{"from":"api","to":"store","label":"uses"}]}`
+	if err := os.WriteFile(filepath.Join(ordersDocs, "c4-components.json"), []byte(components), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen := &CentralSiteGenerator{
+		ProjectName: "Shop",
+		Repos:       []RepoInfo{{Name: "web", Language: "TypeScript"}, {Name: "orders", Language: "Go", DocsDir: ordersDocs}},
+		Links:       []LinkInfo{{FromRepo: "web", ToRepo: "orders", LinkType: "http"}, {FromRepo: "orders", ToRepo: "stripe", LinkType: "http"}},
+		entryPoints: []EntryPoint{{Service: "web", Kind: EntryUI}, {Service: "orders", Kind: EntryScheduler}},
+	}
+	dir := t.TempDir()
+	if err := gen.writeC4Page(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "c4.md"))
+	for _, want := range []string{
+		"C4Context\n",
+		`System_Ext(stripe, "stripe", "External dependency")`,
+		`Rel(user, web, "Uses", "UI app")`,
+		`Container(orders, "orders", "Go", "")`,
+		"| [orders](orders/index.md) | Go | [2 components](orders/c4-components.md) |",
+		"| [web](web/index.md) | TypeScript | — |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("c4.md lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), "Rel(user, orders") {
+		t.Error("a scheduler is not a person using the system")
+	}
+	workspace, err := os.ReadFile(filepath.Join(dir, "workspace.dsl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(workspace), `orders_api -> orders_store "uses"`) || !strings.Contains(string(workspace), `component orders "Components-orders"`) {
+		t.Errorf("workspace.dsl lacks the orders components:\n%s", workspace)
+	}
+}
+
+func TestCentralSiteRuntimeValidation(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{
+		Links: []LinkInfo{
+			{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Runtime: "observed", ObservedCalls: 42},
+			{FromRepo: "orders", ToRepo: "legacy", LinkType: "http", Runtime: "unobserved"},
+		},
+		RuntimeOnly: []LinkInfo{{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc", ObservedCalls: 7, Endpoints: []string{"payments.Payments/Charge"}}},
+	}
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| From | To | Type | Reason | In Production |",
+		"| ✓ observed (42 calls) |",
+		"## Runtime Validation",
+		"- orders → legacy (http)",
+		"- orders → payments (grpc, 7 calls): payments.Payments/Charge",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("system overview missing %q:\n%s", want, data)
+		}
+	}
+
+	// Without imported traces the table keeps its original shape.
+	gen = &CentralSiteGenerator{Links: []LinkInfo{{FromRepo: "gateway", ToRepo: "orders", LinkType: "http"}}}
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	if strings.Contains(string(data), "In Production") || strings.Contains(string(data), "Runtime Validation") {
+		t.Errorf("untraced overview mentions runtime data:\n%s", data)
+	}
+}
+
+func TestCentralSiteEnvironments(t *testing.T) {
+	dir := t.TempDir()
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders"}, {Name: "payments"}, {Name: "load-tester", Environment: "staging"}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"},
+			{FromRepo: "payments", ToRepo: "psp-mock", LinkType: "http", Environments: []string{"staging"}},
+			{FromRepo: "payments", ToRepo: "stripe", LinkType: "http", Environments: []string{"prod"}},
+			{FromRepo: "load-tester", ToRepo: "orders", LinkType: "http"},
+		},
+		Environments: []string{"prod"},
+	}
+	if envs := gen.allEnvironments(); !reflect.DeepEqual(envs, []string{"prod", "staging"}) {
+		t.Fatalf("allEnvironments = %v", envs)
+	}
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	page := string(data)
+	for _, want := range []string{
+		`<div class="code-tab" data-label="All environments">`,
+		"    payments -.->|http · staging| psp_mock\n",
+		"| From | To | Type | Reason | Environments |",
+		"| orders | payments | grpc |  | all |",
+		"| payments | stripe | http |  | prod |",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("system overview missing %q:\n%s", want, page)
+		}
+	}
+
+	tab := func(label string) string {
+		_, rest, _ := strings.Cut(page, `data-label="`+label+`">`)
+		body, _, _ := strings.Cut(rest, "</div>")
+		return body
+	}
+	prod, staging := tab("prod"), tab("staging")
+	if !strings.Contains(prod, "payments -->|http| stripe") || strings.Contains(prod, "psp_mock") || strings.Contains(prod, "load_tester") {
+		t.Errorf("prod diagram:\n%s", prod)
+	}
+	if !strings.Contains(staging, "payments -->|http| psp_mock") || strings.Contains(staging, "stripe") || !strings.Contains(staging, "load_tester -->|http| orders") {
+		t.Errorf("staging diagram:\n%s", staging)
+	}
+
+	// Untagged links keep the single diagram.
+	gen = &CentralSiteGenerator{Repos: []RepoInfo{{Name: "orders"}, {Name: "payments"}}, Links: []LinkInfo{{FromRepo: "orders", ToRepo: "payments"}}}
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	if strings.Contains(string(data), "code-tab") || strings.Contains(string(data), "Environments") {
+		t.Errorf("untagged overview has environment views:\n%s", data)
+	}
+}
+
+func TestHarvestCallExamples(t *testing.T) {
+	gateway := t.TempDir()
+	os.MkdirAll(filepath.Join(gateway, "client"), 0o755)
+	os.WriteFile(filepath.Join(gateway, "routes.go"), []byte("package main\n\nfunc routes() {\n\tmux.HandleFunc(\"/api/orders\", proxy)\n}\n"), 0o644)
+	os.WriteFile(filepath.Join(gateway, "client", "orders.go"), []byte(`package client
+
+func (c *Client) CreateOrder(o Order) error {
+	body, _ := json.Marshal(o)
+	resp, err := c.http.Post(c.base+"/api/orders", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) GetOrder(id string) (*http.Response, error) {
+	return c.http.Get(c.base + "/api/orders/" + id)
+}
+`), 0o644)
+	if err := indexer.SaveAnalyses(gateway, map[string]indexer.FileAnalysis{
+		"routes.go":        {FilePath: "routes.go", Language: "Go"},
+		"client/orders.go": {FilePath: "client/orders.go", Language: "Go", Functions: []indexer.FunctionDoc{{Name: "CreateOrder", LineStart: 3, LineEnd: 10}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	web := t.TempDir()
+	os.WriteFile(filepath.Join(web, "api.ts"), []byte("export async function order(id: string) {\n  const res = await fetch(`/api/orders/${id}`);\n  return res.json();\n}\n"), 0o644)
+	indexer.SaveAnalyses(web, map[string]indexer.FileAnalysis{"api.ts": {FilePath: "api.ts", Language: "TypeScript"}})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "gateway", DocsDir: filepath.Join(gateway, ".autodoc", "docs")},
+			{Name: "web", DocsDir: filepath.Join(web, ".autodoc", "docs")},
+			{Name: "orders"},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /api/orders", "GET /api/orders/{id}", "DELETE /api/carts"}},
+			{FromRepo: "web", ToRepo: "orders", LinkType: "http", Endpoints: []string{"GET /api/orders/{id}"}},
+		},
+	}
+	examples := gen.harvestCallExamples()["orders"]
+	create := examples["POST /api/orders"]
+	if len(create) != 1 || create[0].File != "client/orders.go" || create[0].Line != 5 {
+		t.Fatalf("POST /api/orders examples = %+v", create)
+	}
+	// The call sits in a short function, so the whole function is shown.
+	if !strings.HasPrefix(create[0].Code, "func (c *Client) CreateOrder") || !strings.HasSuffix(create[0].Code, "}") {
+		t.Errorf("snippet:\n%s", create[0].Code)
+	}
+	if get := examples["GET /api/orders/{id}"]; len(get) != 2 || get[0].Caller != "gateway" || get[1].Language != "TypeScript" {
+		t.Errorf("GET examples = %+v", get)
+	}
+	if _, ok := examples["DELETE /api/carts"]; ok {
+		t.Error("an endpoint that is never called in source should have no examples")
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeEndpointsPage(dir, RepoInfo{Name: "orders"}, examples); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "endpoints.md"))
+	for _, want := range []string{
+		"## GET /api/orders/{id}",
+		"Called by: gateway, web",
+		"### How other services call this",
+		`<div class="code-tab" data-label="Go">`,
+		`<div class="code-tab" data-label="TypeScript">`,
+		"**web** — `api.ts:2`",
+		"```typescript\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("endpoints page missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Count(string(data), "### How other services call this") != 2 {
+		t.Errorf("DELETE /api/carts should have no examples section:\n%s", data)
+	}
+}
+
+func TestEndpointPattern(t *testing.T) {
+	cases := []struct {
+		endpoint, line string
+		match          bool
+	}{
+		{"POST /api/orders", `post(base + "/api/orders", body)`, true},
+		{"POST /api/orders", `get("/api/orders/42")`, false},
+		{"GET /api/orders/{id}", "fetch(`/api/orders/${id}`)", true},
+		{"GET /api/orders/:id", `"/api/orders/" + id`, true},
+		{"orders.OrderService/CreateOrder", `client.CreateOrder(ctx, req)`, true},
+		{"orders.OrderService/CreateOrder", `// CreateOrder places an order`, false},
+		{"order-placed", `producer.send("order-placed", event)`, true},
+	}
+	for _, c := range cases {
+		if got := endpointPattern(c.endpoint).MatchString(c.line); got != c.match {
+			t.Errorf("endpointPattern(%q) on %q = %v, want %v", c.endpoint, c.line, got, c.match)
+		}
+	}
+	if endpointPattern("GET /{id}") != nil {
+		t.Error("a path with no static prefix should not be searched for")
+	}
+}
+
+func TestCentralSiteDeprecations(t *testing.T) {
+	soon := time.Now().AddDate(0, 0, 3)
+	sunset := time.Date(soon.Year(), soon.Month(), soon.Day(), 0, 0, 0, 0, time.UTC)
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "payments"}, {Name: "checkout"}, {Name: "orders"}},
+		Links: []LinkInfo{
+			{FromRepo: "checkout", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /api/v1/charges"}},
+			{FromRepo: "web", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /v1/charges"}},
+			{FromRepo: "orders", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"orders.placed"}},
+		},
+		Deprecations: []deprecation.Deprecation{
+			{Service: "payments", Endpoint: "POST /v1/charges", Sunset: sunset, Replacement: "POST /v2/charges", Source: deprecation.SourceOpenAPI, Evidence: "api/openapi.yaml"},
+			{Service: "payments", Endpoint: "GET /v1/refunds", Source: deprecation.SourceConfig},
+			{Service: "orders", Endpoint: "orders.placed", Note: "moving to orders.v2", Source: deprecation.SourceFact},
+		},
+	}
+	gen.deprecations = gen.collectDeprecations()
+	dir := t.TempDir()
+	if err := gen.writeDeprecationsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "deprecations.md"))
+	for _, want := range []string{
+		"3 deprecated endpoints and topics, 2 of them still used by 3 services",
+		"| `POST /v1/charges` | [payments](payments/index.md) | " + sunset.Format(time.DateOnly) + " | **in 3 days** | [checkout](checkout/index.md), web | `POST /v2/charges` | `api/openapi.yaml` |\n| `orders.placed` | [orders](orders/index.md) | — | no sunset date | mailer | moving to orders.v2 | conversation |",
+		"## No Longer Used",
+		"| `GET /v1/refunds` | [payments](payments/index.md) | — | config |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("deprecations page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteEvents(t *testing.T) {
+	orders := t.TempDir()
+	os.WriteFile(filepath.Join(orders, "order.avsc"), []byte(`{"type": "record", "name": "OrderPlaced", "fields": [{"name": "order_id", "type": "string"}]}`), 0o644)
+	indexer.SaveAnalyses(orders, map[string]indexer.FileAnalysis{"order.avsc": {FilePath: "order.avsc", Language: "Avro"}})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders", DocsDir: filepath.Join(orders, ".autodoc", "docs")}, {Name: "mailer"}, {Name: "billing"}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"orders.order-placed"}},
+			{FromRepo: "orders", ToRepo: "billing", LinkType: "kafka", Endpoints: []string{"orders.order-placed"}},
+			{FromRepo: "mailer", ToRepo: "ops", LinkType: "kafka", Endpoints: []string{"orders.order-placed.dlq"}},
+			{FromRepo: "billing", ToRepo: "ledger", LinkType: "amqp"},
+			{FromRepo: "orders", ToRepo: "billing", LinkType: "http"},
+		},
+		SchemaVersions: []schemareg.Schema{
+			{Subject: "orders.order-placed-value", Version: 1, Type: "AVRO", SeenAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{Subject: "orders.order-placed-value", Version: 2, Type: "AVRO", SeenAt: time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC),
+				Changes: []schemareg.FieldChange{{Field: "note", New: "null|string"}}},
+		},
+	}
+	gen.events = gen.collectEvents()
+	if gen.events == nil || len(gen.events.topics) != 2 {
+		t.Fatalf("events = %+v", gen.events)
+	}
+	if dlq := gen.events.topics[1]; dlq.Failure != "dead letter" || dlq.Of != "orders.order-placed" || dlq.Schema == nil || dlq.Schema.Name != "OrderPlaced" {
+		t.Errorf("dead-letter topic = %+v", dlq)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeEventsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "events.md"))
+	for _, want := range []string{
+		"        topic_orders_order_placed([\"orders.order-placed<br/><i>OrderPlaced</i>\"])",
+		"    producer_orders --> topic_orders_order_placed\n    topic_orders_order_placed --> consumer_mailer\n    topic_orders_order_placed --> consumer_billing",
+		"    topic_orders_order_placed -.->|dead letter| topic_orders_order_placed_dlq",
+		"| `orders.order-placed` | kafka | [orders](orders/index.md) | [mailer](mailer/index.md), [billing](billing/index.md) | `orders.order-placed-value` v2 (AVRO); `OrderPlaced` (Avro record), in [orders](orders/index.md) `order.avsc:1` | dead letters to `orders.order-placed.dlq` |",
+		"### `orders.order-placed`\n\n| Version | Seen | Changes |\n|---------|------|---------|\n| orders.order-placed-value v2 | 2026-04-02 | added note (null\\|string) |\n| orders.order-placed-value v1 | 2026-03-01 | first seen |\n\n## By Service",
+		"| `orders.order-placed.dlq` | kafka | [mailer](mailer/index.md) | ops |",
+		"| [mailer](mailer/index.md) | `orders.order-placed.dlq` | `orders.order-placed` |",
+		"- billing → ledger (amqp)",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("events page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), "http") {
+		t.Errorf("events page shows a synchronous call:\n%s", page)
+	}
+}
+
+func TestSequenceCallOrder(t *testing.T) {
+	checkout := t.TempDir()
+	os.WriteFile(filepath.Join(checkout, "handler.go"), []byte(`package main
+
+func placeOrder(ctx context.Context, o Order) error {
+	if err := post(ctx, "http://fraud:8080/check", o); err != nil {
+		return err
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return post(ctx, "/api/charge", o)
+	})
+	g.Go(func() error { return post(ctx, "http://inventory/reserve", o) })
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return publish(ctx, "order-placed", o)
+}
+`), 0o644)
+	if err := indexer.SaveAnalyses(checkout, map[string]indexer.FileAnalysis{
+		"handler.go": {FilePath: "handler.go", Language: "Go", Functions: []indexer.FunctionDoc{{Name: "placeOrder", LineStart: 3, LineEnd: 16}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	links := []LinkInfo{
+		{FromRepo: "web", ToRepo: "checkout", LinkType: "http"},
+		{FromRepo: "checkout", ToRepo: "mailer", LinkType: "kafka", Endpoints: []string{"order-placed"}},
+		{FromRepo: "checkout", ToRepo: "payments", LinkType: "http", Endpoints: []string{"POST /api/charge"}},
+		{FromRepo: "checkout", ToRepo: "inventory", LinkType: "http"},
+		{FromRepo: "checkout", ToRepo: "fraud", LinkType: "http"},
+	}
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "web"}, {Name: "checkout", DocsDir: filepath.Join(checkout, ".autodoc", "docs")}, {Name: "mailer"}, {Name: "payments"}, {Name: "inventory"}, {Name: "fraud"}},
+		Links: links,
+	}
+
+	// The calls are drawn in the order the handler makes them, the two
+	// made from goroutines in a par block.
+	want := `    checkout->>fraud: http
+    par
+        checkout->>payments: POST /api/charge
+    and
+        checkout->>inventory: http
+    end
+    checkout->>mailer: order-placed
+`
+	gen.synthesizeCanonicalFlows()
+	if len(gen.Flows) == 0 || !strings.HasSuffix(gen.Flows[0].Diagram, want) {
+		t.Fatalf("checkout flow diagram:\n%s", gen.Flows[0].Diagram)
+	}
+	// A flow's diagram follows each call into its target's calls.
+	diagram := gen.generateSequenceDiagram(FlowInfo{Services: []string{"web", "checkout", "payments", "fraud"}})
+	if !strings.Contains(diagram, "    web->>checkout: PlaceOrder()\n    checkout->>fraud: http\n    checkout->>payments: POST /api/charge\n") {
+		t.Errorf("flow diagram:\n%s", diagram)
+	}
+	if err := checkMermaid(diagram); err != nil {
+		t.Errorf("flow diagram does not parse: %v", err)
+	}
+
+	// Without source to go by, the LLM orders the calls.
+	provider := &answerProvider{answer: "Step 1: 4\nStep 2: 2, 3"}
+	gen = &CentralSiteGenerator{Links: links, Provider: provider}
+	steps := gen.callOrder("checkout", []flowCall{{to: "mailer", link: links[1]}, {to: "payments", link: links[2]}, {to: "inventory", link: links[3]}, {to: "fraud", link: links[4]}})
+	var got []string
+	for _, s := range steps {
+		var names []string
+		for _, c := range s.calls {
+			names = append(names, c.to)
+		}
+		got = append(got, fmt.Sprintf("%s/%v", strings.Join(names, "+"), s.parallel))
+	}
+	if strings.Join(got, " ") != "fraud/false payments+inventory/true mailer/false" {
+		t.Errorf("LLM call order = %v", got)
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "4. fraud (http)") {
+		t.Errorf("prompts = %q", provider.prompts)
+	}
+}
+
+// answerProvider answers every prompt with the same text.
+type answerProvider struct {
+	llm.MockProvider
+	answer  string
+	prompts []string
+}
+
+func (p *answerProvider) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	return &llm.CompletionResponse{Content: p.answer}, nil
+}
+
+func TestCentralSiteOverrides(t *testing.T) {
+	at := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "web"}, {Name: "orders"}, {Name: "payments"}, {Name: "fraud"}},
+		Links: []LinkInfo{
+			{FromRepo: "web", ToRepo: "orders", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "http"},
+		},
+		Flows: []FlowInfo{{Name: "Refunds", Services: []string{"web", "payments"}}},
+		Overrides: []overrides.Override{
+			{From: "orders", To: "payments", Remove: true, ProvidedBy: "ada", At: at},
+			{From: "Orders", To: "fraud", LinkType: "grpc", ProvidedBy: "ada", At: at},
+			{Flow: "Refunds", Remove: true, ProvidedBy: "grace", At: at},
+			{Flow: "Fraud Check", Services: []string{"web", "orders", "fraud"}, ProvidedBy: "grace", At: at},
+		},
+	}
+	gen.applyLinkOverrides()
+	if len(gen.Links) != 2 || gen.Links[1].FromRepo != "orders" || gen.Links[1].ToRepo != "fraud" || gen.Links[1].Override != "ada, 2026-03-04" {
+		t.Fatalf("links after overrides = %+v", gen.Links)
+	}
+	gen.applyFlowOverrides()
+	if len(gen.Flows) != 1 || gen.Flows[0].Name != "Fraud Check" || gen.Flows[0].Diagram == "" {
+		t.Fatalf("flows after overrides = %+v", gen.Flows)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	for _, want := range []string{
+		"| orders | fraud | grpc | Added by correction *(corrected: ada, 2026-03-04)* |",
+		"## Corrections",
+		"- orders no longer calls payments — *ada, 2026-03-04*",
+		`- flow "Refunds" is not a real flow — *grace, 2026-03-04*`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("system overview missing %q:\n%s", want, data)
+		}
+	}
+	if err := gen.writeFlowsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "flows.md"))
+	if !strings.Contains(string(data), "*Corrected: grace, 2026-03-04*") {
+		t.Errorf("flows page missing provenance:\n%s", data)
+	}
+}
+
+func TestCentralSiteNarrativeEdits(t *testing.T) {
+	at := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	locksFile := filepath.Join(t.TempDir(), "flow-narrative-locks.json")
+	generate := func(narrative string) *CentralSiteGenerator {
+		gen := &CentralSiteGenerator{
+			Flows: []FlowInfo{{Name: "Checkout", Narrative: narrative, Services: []string{"checkout", "payments"}}},
+			NarrativeEdits: []narratives.Edit{
+				{Flow: "checkout", Section: "overview", Text: "Checkout takes the order and charges for it.", Pinned: true, ProvidedBy: "ada", At: at},
+				{Flow: "Checkout", Section: "payment", Text: "**Payment:** payments captures the charge after shipping.", ProvidedBy: "grace", At: at},
+			},
+			NarrativeLocks: locksFile,
+		}
+		gen.applyNarrativeEdits()
+		return gen
+	}
+
+	gen := generate("Checkout coordinates with 1 service.\n\n**Payment:** payments (POST /charges)")
+	if want := "Checkout takes the order and charges for it.\n\n**Payment:** payments captures the charge after shipping."; gen.Flows[0].Narrative != want {
+		t.Errorf("edited narrative = %q, want %q", gen.Flows[0].Narrative, want)
+	}
+	if len(gen.NarrativeConflicts) != 0 {
+		t.Errorf("unexpected conflicts: %+v", gen.NarrativeConflicts)
+	}
+	dir := t.TempDir()
+	if err := gen.writeFlowsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "flows.md"))
+	if !strings.Contains(string(data), "*Edited: overview pinned by ada, 2026-03-04; payment amended by grace, 2026-03-04*") {
+		t.Errorf("flows page missing the edits' provenance:\n%s", data)
+	}
+
+	// The next run regenerates both sections differently: the pin holds,
+	// the amendment gives way, and both are reported once.
+	regenerated := "Checkout coordinates with 2 services.\n\n**Payment:** payments (POST /v2/charges)"
+	gen = generate(regenerated)
+	if want := "Checkout takes the order and charges for it.\n\n**Payment:** payments (POST /v2/charges)"; gen.Flows[0].Narrative != want {
+		t.Errorf("narrative after regeneration = %q, want %q", gen.Flows[0].Narrative, want)
+	}
+	if len(gen.NarrativeConflicts) != 2 || !gen.NarrativeConflicts[0].Edit.Pinned || gen.NarrativeConflicts[0].Services[1] != "payments" {
+		t.Errorf("conflicts = %+v, want the pin's and the amendment's", gen.NarrativeConflicts)
+	}
+	if gen = generate(regenerated); len(gen.NarrativeConflicts) != 0 {
+		t.Errorf("conflicts reported again: %+v", gen.NarrativeConflicts)
+	}
+}
+
+func TestCentralSiteReviewGate(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	gen := &CentralSiteGenerator{Review: review.NewStore(database)}
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.md", "# Home\n")
+	write("flows.md", "# Flows\n")
+	if _, _, err := gen.Review.Gate(ctx, "", map[string]string{"index.md": "# Home\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gen.Review.Approve(ctx, review.Decision{Path: "index.md", Reviewer: "ada"}); err != nil {
+		t.Fatal(err)
+	}
+
+	write("index.md", "# Home, changed\n")
+	if err := gen.gatePages(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.Pending) != 2 {
+		t.Errorf("pending = %+v, want index.md and flows.md", gen.Pending)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "flows.md")); !os.IsNotExist(err) {
+		t.Error("a page never approved should be left out")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "index.md")); string(data) != "# Home\n" {
+		t.Errorf("index.md = %q, want the approved content", data)
+	}
+}
+
+// promptRecorder records the prompts it is asked to complete.
+type promptRecorder struct {
+	llm.MockProvider
+	prompts []string
+}
+
+func (p *promptRecorder) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	return &llm.CompletionResponse{Content: "Booking checks the user, then books."}, nil
+}
+
+func TestSynthesizeFlowsFromTemplates(t *testing.T) {
+	links := []LinkInfo{
+		{FromRepo: "booking", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /orders"}},
+		{FromRepo: "booking", ToRepo: "security", LinkType: "http"},
+		{FromRepo: "booking", ToRepo: "mailer", LinkType: "kafka"},
+		{FromRepo: "booking-admin", ToRepo: "orders", LinkType: "http"},
+	}
+	templates := []FlowTemplate{{
+		Name:         "Booking",
+		Orchestrator: "booking",
+		Exclude:      []string{"admin"},
+		Phases: []FlowPhase{
+			{Name: "1. Security", Services: []string{"security"}},
+			{Name: "2. Order", Services: []string{"order"}},
+			{Name: "3. Unused", Services: []string{"seat"}},
+		},
+		Narrative: "{{.Orchestrator}} calls {{.TargetCount}} services in {{len .Phases}} phases; first {{(index .Phases 0).Name}}.",
+	}}
+	gen := &CentralSiteGenerator{Links: append([]LinkInfo(nil), links...), FlowTemplates: templates}
+	gen.synthesizeCanonicalFlows()
+	if len(gen.Flows) == 0 || gen.Flows[0].Name != "Booking" {
+		t.Fatalf("flows = %+v", gen.Flows)
+	}
+	booking := gen.Flows[0]
+	if booking.Narrative != "booking calls 3 services in 2 phases; first 1. Security." {
+		t.Errorf("narrative = %q", booking.Narrative)
+	}
+	// Calls are drawn phase by phase, unmatched calls last.
+	security := strings.Index(booking.Diagram, "Note over booking: 1. Security")
+	order := strings.Index(booking.Diagram, "booking->>orders: POST /orders")
+	mailer := strings.Index(booking.Diagram, "booking->>mailer")
+	if security < 0 || order < security || mailer < order || strings.Contains(booking.Diagram, "Unused") {
+		t.Errorf("diagram:\n%s", booking.Diagram)
+	}
+	if strings.Contains(strings.Join(booking.Services, ","), "booking-admin") {
+		t.Errorf("excluded orchestrator matched the template: %v", booking.Services)
+	}
+
+	// Without a skeleton the LLM writes the narrative, once per change to
+	// the flow's calls.
+	templates[0].Narrative = ""
+	provider := &promptRecorder{}
+	cache := filepath.Join(t.TempDir(), "flow-narratives.json")
+	for run := 0; run < 2; run++ {
+		gen = &CentralSiteGenerator{Links: append([]LinkInfo(nil), links...), FlowTemplates: templates, Provider: provider, NarrativeCache: cache}
+		gen.synthesizeCanonicalFlows()
+		if gen.Flows[0].Narrative != "Booking checks the user, then books." {
+			t.Errorf("run %d: narrative = %q", run, gen.Flows[0].Narrative)
+		}
+	}
+	if len(provider.prompts) != 1 {
+		t.Fatalf("provider called %d times, want once", len(provider.prompts))
+	}
+	for _, want := range []string{"1. Security\n- security (http)", "Other calls\n- mailer (kafka)"} {
+		if !strings.Contains(provider.prompts[0], want) {
+			t.Errorf("prompt missing %q:\n%s", want, provider.prompts[0])
+		}
+	}
+
+	// Without a provider the narrative is assembled from the calls.
+	gen = &CentralSiteGenerator{Links: append([]LinkInfo(nil), links...), FlowTemplates: templates}
+	gen.synthesizeCanonicalFlows()
+	if !strings.Contains(gen.Flows[0].Narrative, "**1. Security:** security (") {
+		t.Errorf("fallback narrative = %q", gen.Flows[0].Narrative)
+	}
+}
+
+func TestFlowCoverage(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "web-frontend"}, {Name: "api"}, {Name: "orders"}, {Name: "payments"},
+			{Name: "nightly-jobs"}, {Name: "ledger"}, {Name: "legacy-reports"},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "web-frontend", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /orders"}},
+			{FromRepo: "api", ToRepo: "orders", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"},
+			{FromRepo: "nightly-jobs", ToRepo: "ledger", LinkType: "http"},
+			{FromRepo: "payments", ToRepo: "ledger", LinkType: "kafka"},
+		},
+	}
+	gen.synthesizeCanonicalFlows()
+
+	want := []EntryPoint{
+		{Service: "web-frontend", Kind: EntryUI},
+		{Service: "api", Kind: EntryPublicAPI},
+		{Service: "nightly-jobs", Kind: EntryScheduler},
+	}
+	if len(gen.entryPoints) != len(want) {
+		t.Fatalf("entry points = %+v", gen.entryPoints)
+	}
+	for i, w := range want {
+		if e := gen.entryPoints[i]; e.Service != w.Service || e.Kind != w.Kind {
+			t.Errorf("entry point %d = %+v, want %s (%s)", i, e, w.Service, w.Kind)
+		}
+	}
+
+	// The frontend's flow follows calls past its direct callee.
+	var web FlowInfo
+	for _, f := range gen.Flows {
+		if f.Name == "web-frontend Entry Flow" {
+			web = f
+		}
+	}
+	if got := strings.Join(web.Services, ","); got != "ledger,orders,payments,web-frontend" {
+		t.Errorf("web-frontend flow services = %s", got)
+	}
+	if !strings.Contains(web.Narrative, "**Hop 3:** payments → ledger (kafka)") {
+		t.Errorf("narrative = %q", web.Narrative)
+	}
+	if !strings.Contains(web.Diagram, "orders->>payments") {
+		t.Errorf("diagram:\n%s", web.Diagram)
+	}
+
+	var b strings.Builder
+	gen.writeFlowCoverage(&b)
+	out := b.String()
+	for _, s := range []string{
+		"6 of 7 services (85%) appear in a flow.",
+		"| web-frontend | UI app | serves the user interface |",
+		"| nightly-jobs | Scheduler | runs on a schedule |",
+		"### Orphan Services",
+		"- legacy-reports\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("coverage missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestFlowLatencyBudget(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Links: []LinkInfo{
+			{FromRepo: "checkout", ToRepo: "auth", LinkType: "http"},
+			{FromRepo: "checkout", ToRepo: "cart", LinkType: "http", ObservedLatencyMs: 80},
+			{FromRepo: "checkout", ToRepo: "pricing", LinkType: "http"},
+			{FromRepo: "checkout", ToRepo: "payments", LinkType: "grpc"},
+			{FromRepo: "checkout", ToRepo: "mailer", LinkType: "kafka"},
+		},
+		FlowTemplates: []FlowTemplate{{
+			Name:         "Checkout",
+			Orchestrator: "checkout",
+			Phases: []FlowPhase{
+				{Name: "Lookup", Services: []string{"cart", "pricing"}},
+				{Name: "Charge", Services: []string{"auth", "payments"}, Sequential: true},
+			},
+			Narrative: "Takes about {{.CriticalPath}}.",
+			Budget:    400 * time.Millisecond,
+		}},
+		HopLatency:   30 * time.Millisecond,
+		LatencyHints: []LatencyHint{{From: "checkout", To: "payments", Latency: 300 * time.Millisecond}},
+	}
+	gen.synthesizeCanonicalFlows()
+	f := gen.Flows[0]
+	if f.Latency == nil || len(f.Latency.Phases) != 3 {
+		t.Fatalf("latency model = %+v", f.Latency)
+	}
+	// Lookup: the slower of cart (traces, 80ms) and pricing (default, 30ms).
+	// Charge: auth (30ms) then payments (hint, 300ms). The Kafka publish
+	// doesn't wait.
+	total, path := f.Latency.CriticalPath()
+	if total != 410*time.Millisecond || len(path) != 3 || path[0].To != "cart" || path[0].Source != LatencyFromTraces {
+		t.Errorf("critical path = %v %+v", total, path)
+	}
+	if f.Narrative != "Takes about 410ms." {
+		t.Errorf("narrative = %q", f.Narrative)
+	}
+	if !f.Latency.OverBudget() {
+		t.Error("410ms should exceed the 400ms budget")
+	}
+
+	var b strings.Builder
+	writeLatencyBudget(&b, f.Latency)
+	for _, s := range []string{
+		"| Charge | in order | checkout → auth 30ms (default)<br>checkout → payments 300ms (hint) | 330ms |",
+		"checkout → mailer (async, off the critical path)",
+		"**410ms**",
+		"10ms over the 400ms budget from the flow template",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("budget table missing %q:\n%s", s, b.String())
+		}
+	}
+	b.Reset()
+	gen.writeLatencyWarnings(&b)
+	if !strings.Contains(b.String(), "**Checkout**: critical path 410ms against a 400ms budget") {
+		t.Errorf("warnings = %q", b.String())
+	}
+
+	// Without a template budget, the orchestrator's latency SLO is the budget.
+	gen.FlowTemplates[0].Budget = 0
+	gen.SLOs = map[string][]slo.SLO{"checkout": {{Service: "checkout", Latency: time.Second, LatencyPercentile: 99}}}
+	gen.synthesizeCanonicalFlows()
+	if m := gen.Flows[0].Latency; m.Budget != time.Second || m.BudgetSource != "checkout p99 SLO" || m.OverBudget() {
+		t.Errorf("SLO budget = %+v", m)
+	}
+}
+
+// fakeRasterizer renders every diagram to the same bytes, and fails on
+// diagrams containing "broken".
+type fakeRasterizer struct{}
+
+func (fakeRasterizer) Rasterize(_ context.Context, source string) ([]byte, error) {
+	if strings.Contains(source, "broken") {
+		return nil, fmt.Errorf("parse error")
+	}
+	return []byte("PNG"), nil
+}
+
+func TestSinglePage(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home\n\nSee [the guide](guide/setup.md#install) and [notes](notes.txt).\n\n![logo](logo.png)\n")
+	writeTestFile(t, filepath.Join(docsDir, "guide", "setup.md"), "# Setup\n\n## Install\n\nBack [up](#install) or [home](../index.md).\n\n```mermaid\ngraph TD\nA --> B\n```\n\n```mermaid\nbroken\n```\n")
+	writeTestFile(t, filepath.Join(docsDir, "logo.png"), "IMG")
+
+	page := &SinglePage{DocsDir: docsDir, ProjectName: "Acme", Diagrams: fakeRasterizer{}}
+	out, err := page.Render(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	for _, s := range []string{
+		`<title>Acme</title>`,
+		`<li><a href="#page-index">Home</a></li>`,
+		`<section class="single-page-section" id="page-guide-setup">`,
+		`id="page-guide-setup--install"`,
+		`href="#page-guide-setup--install">the guide</a>`,
+		`href="#page-guide-setup--install">up</a>`,
+		`href="#page-index">home</a>`,
+		`src="data:image/png;base64,SU1H"`,
+		`<figure class="diagram"><img src="data:image/png;base64,UE5H" alt="Diagram"/></figure>`,
+		`language-mermaid`,
+		`.single-page .content { margin-left: 0; }`,
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("document missing %q", s)
+		}
+	}
+	// The link to a file the document doesn't hold becomes text, and
+	// nothing is loaded from the network.
+	for _, s := range []string{`notes.txt`, `<script`, `<link`} {
+		if strings.Contains(got, s) {
+			t.Errorf("document should not contain %q", s)
+		}
+	}
+	if strings.Index(got, `id="page-index"`) > strings.Index(got, `id="page-guide-setup"`) {
+		t.Error("home page should come first")
+	}
+	if len(page.Unrendered) != 1 || !strings.Contains(page.Unrendered[0].Error(), "guide/setup.md: parse error") {
+		t.Errorf("unrendered = %v", page.Unrendered)
+	}
+}
+
+func TestOutputAdapters(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home\n")
+	writeTestFile(t, filepath.Join(docsDir, "api", "index.md"), "# API Overview\n")
+	writeTestFile(t, filepath.Join(docsDir, "api", "01-handlers.go.md"), "# Handlers: \"v1\"\n")
+	writeTestFile(t, filepath.Join(docsDir, "api", "diagram.png"), "PNG")
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	mkdocsDir := t.TempDir()
+	a, err := NewAdapter(FormatMkDocs, docsDir, mkdocsDir, "Acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a.Write(); err != nil || n != 3 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := read(filepath.Join(mkdocsDir, "docs", "api", "01-handlers.go.md")); got != "---\ntitle: \"Handlers: \\\"v1\\\"\"\n---\n\n# Handlers: \"v1\"\n" {
+		t.Errorf("front matter = %q", got)
+	}
+	if read(filepath.Join(mkdocsDir, "docs", "api", "diagram.png")) != "PNG" {
+		t.Error("assets should be copied next to the pages")
+	}
+	cfg := read(filepath.Join(mkdocsDir, "mkdocs.yml"))
+	for _, s := range []string{
+		"site_name: \"Acme\"\n",
+		"nav:\n  - Home: index.md\n  - \"Api\":\n    - \"api/index.md\"\n    - \"Handlers: \\\"v1\\\"\": \"api/01-handlers.go.md\"\n",
+		"pymdownx.superfences",
+	} {
+		if !strings.Contains(cfg, s) {
+			t.Errorf("mkdocs.yml missing %q:\n%s", s, cfg)
+		}
+	}
+
+	docusaurusDir := t.TempDir()
+	a, err = NewAdapter(FormatDocusaurus, docsDir, docusaurusDir, "Acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(filepath.Join(docusaurusDir, "docs", "index.md")); !strings.Contains(got, "id: \"index\"\nsidebar_label: \"Home\"\nslug: /\n") {
+		t.Errorf("home front matter = %q", got)
+	}
+	if got := read(filepath.Join(docusaurusDir, "docs", "api", "01-handlers.go.md")); !strings.Contains(got, "id: \"01-handlers.go\"\n") {
+		t.Errorf("front matter should pin the id: %q", got)
+	}
+	sidebars := read(filepath.Join(docusaurusDir, "sidebars.js"))
+	js := strings.TrimSuffix(sidebars[strings.Index(sidebars, "module.exports = ")+len("module.exports = "):], ";\n")
+	var parsed struct {
+		Autodoc []struct {
+			Type, ID, Label string
+			Link            struct{ ID string }
+			Items           []struct{ ID string }
+		}
+	}
+	if err := json.Unmarshal([]byte(js), &parsed); err != nil {
+		t.Fatalf("sidebars.js: %v\n%s", err, sidebars)
+	}
+	if len(parsed.Autodoc) != 2 || parsed.Autodoc[0].ID != "index" ||
+		parsed.Autodoc[1].Type != "category" || parsed.Autodoc[1].Link.ID != "api/index" ||
+		len(parsed.Autodoc[1].Items) != 1 || parsed.Autodoc[1].Items[0].ID != "api/01-handlers.go" {
+		t.Errorf("sidebars = %+v", parsed)
+	}
+
+	if _, err := NewAdapter(FormatHTML, docsDir, t.TempDir(), "Acme"); err == nil {
+		t.Error("html is not a markdown adapter")
+	}
+	if err := ValidateFormat("hugo"); err == nil {
+		t.Error("unknown format should fail validation")
+	}
+}
+
+// fakeTranslator "translates" into Spanish word by word, and fails on
+// passages that say FAIL.
+type fakeTranslator struct {
+	llm.MockProvider
+	calls int
+}
+
+func (p *fakeTranslator) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.calls++
+	_, body, _ := strings.Cut(req.Messages[len(req.Messages)-1].Content, "\n\n")
+	if strings.Contains(body, "FAIL") {
+		return nil, fmt.Errorf("rate limited")
+	}
+	body = strings.NewReplacer("Setup", "Configuración", "Install", "Instalar", "Back", "Volver").Replace(body)
+	return &llm.CompletionResponse{Content: "```markdown\n" + body + "\n```"}, nil
+}
+
+func TestSiteTranslation(t *testing.T) {
+	docsDir := t.TempDir()
+	writeTestFile(t, filepath.Join(docsDir, "index.md"), "# Home\n\nSee [setup](guide/setup.md#install).\n")
+	writeTestFile(t, filepath.Join(docsDir, "guide", "setup.md"), "# Setup\n\n## Install\n\nBack [up](#install).\n\n```mermaid\ngraph TD\nInstall --> Back\n```\n\nFAIL\n")
+
+	provider := &fakeTranslator{}
+	outDir := t.TempDir()
+	gen := NewSiteGenerator(docsDir, outDir, "Acme")
+	gen.Artifacts = artifacts.NewStore(artifacts.NewLocalBackend(t.TempDir()), 0)
+	gen.Languages = NewSiteLanguages("", []string{"es"})
+	gen.Translator = &Translator{Provider: provider, Artifacts: gen.Artifacts}
+	n, err := gen.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("pages = %d, want 2 per language", n)
+	}
+
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(outDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	es := read("es/guide/setup.html")
+	for _, s := range []string{
+		`<html lang="es"`,
+		`<h2 id="install">Instalar</h2>`,
+		`href="#install">up</a>`,
+		"Install --&gt; Back",
+		"FAIL",
+		`<option value="../../guide/setup.html" lang="en">English</option>`,
+		`<option value="../../es/guide/setup.html" lang="es" selected>Español</option>`,
+	} {
+		if !strings.Contains(es, s) {
+			t.Errorf("es/guide/setup.html missing %q", s)
+		}
+	}
+	if en := read("guide/setup.html"); !strings.Contains(en, `<h2 id="install">Install</h2>`) || !strings.Contains(en, `<option value="../es/guide/setup.html" lang="es">Español</option>`) {
+		t.Error("source page should be untranslated and link to its translation")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "es", "search-index.json")); err != nil {
+		t.Errorf("translated site needs its own search index: %v", err)
+	}
+	if gen.Translator.Untranslated != 1 {
+		t.Errorf("untranslated = %d, want 1", gen.Translator.Untranslated)
+	}
+
+	// Translations are remembered; only the failed passage is retried.
+	calls := provider.calls
+	gen.Translator.Untranslated = 0
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if provider.calls-calls != 1 {
+		t.Errorf("second run made %d calls, want 1", provider.calls-calls)
+	}
+
+	if got := languageName("pt-BR", false); got != "Português (BR)" {
+		t.Errorf("languageName = %q", got)
+	}
+}
+
+// goldenSkip lists output that is copied verbatim from templates.go rather
+// than rendered from input.
+var goldenSkip = golden.Options{Skip: []string{"style.css", "script.js"}}
+
+// TestSiteGolden renders the fixture docs in testdata/docs and compares the
+// site with testdata/golden/site. Run with -update after an intended change.
+func TestSiteGolden(t *testing.T) {
+	out := t.TempDir()
+	gen := NewSiteGenerator(filepath.Join("testdata", "docs"), out, "Orders")
+	if _, err := gen.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	golden.CompareDir(t, filepath.Join("testdata", "golden", "site"), out, goldenSkip)
+}
+
+// goldenCentralSite is a small system exercising every section of the
+// central site: a repo with generated docs, SLOs, on-call, teams, the
+// changelog, and runtime validation.
+func goldenCentralSite(out string) *CentralSiteGenerator {
+	return &CentralSiteGenerator{
+		OutputDir:   out,
+		ProjectName: "Shop Platform",
+		Repos: []RepoInfo{
+			{Name: "gateway", DisplayName: "API Gateway", Summary: "Routes storefront traffic to backend services.", Status: "ready", FileCount: 12, Language: "Go"},
+			{Name: "orders", Summary: "Accepts orders, reserves stock, and charges the customer.", Status: "ready", FileCount: 3, Language: "Go", DocsDir: filepath.Join("testdata", "docs"),
+				OnCall: &OnCallInfo{Schedule: "pagerduty:PORD", Responders: []string{"Ada Lovelace (ada@example.com)"}}},
+			{Name: "payments", Summary: "Charges cards and issues refunds.", Status: "ready", FileCount: 8, Language: "Python"},
+			{Name: "notifications", Summary: "Sends order confirmation emails.", Status: "ready", FileCount: 5, Language: "TypeScript"},
+			{Name: "inventory", Summary: "Tracks stock levels per warehouse.", Status: "ready", FileCount: 6, Language: "Go"},
+		},
+		Links: []LinkInfo{
+			{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Reason: "Forwards order requests", Endpoints: []string{"POST /api/orders"}, Runtime: "observed", ObservedCalls: 120},
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "http", Reason: "Charges the customer", Endpoints: []string{"POST /api/charges"}, Runtime: "observed", ObservedCalls: 95},
+			{FromRepo: "orders", ToRepo: "inventory", LinkType: "grpc", Reason: "Reserves stock", Runtime: "unobserved"},
+			{FromRepo: "orders", ToRepo: "notifications", LinkType: "kafka", Reason: "Publishes order-placed events", Runtime: "unverifiable"},
+		},
+		RuntimeOnly: []LinkInfo{{FromRepo: "payments", ToRepo: "notifications", LinkType: "http", ObservedCalls: 4, Endpoints: []string{"POST /api/emails"}}},
+		Flows:       []FlowInfo{{Name: "Order Placement", Description: "A customer places an order.", Services: []string{"gateway", "orders", "payments", "inventory"}}},
+		Changes: []ChangeInfo{
+			{Date: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), Summary: "orders added dependency on inventory (grpc)", Services: []string{"orders", "inventory"}, CommitSHA: "abc1234", CommitURL: "https://example.com/commit/abc1234"},
+			{Date: time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC), Summary: "gateway added dependency on orders (POST /api/orders)", Services: []string{"gateway", "orders"}},
+		},
+		Teams: []TeamInfo{
+			{Name: "checkout", DisplayName: "Checkout", SlackChannel: "#checkout", Members: []TeamMemberInfo{{UserID: "ada", Role: "lead"}}, Services: []string{"gateway", "orders"}},
+			{Name: "money", DisplayName: "Payments", Email: "money@example.com", Services: []string{"payments"}},
+		},
+		SLOs: map[string][]slo.SLO{
+			"orders":   {{Service: "orders", Availability: 99.9, LatencyPercentile: 99, Latency: 400 * time.Millisecond, Source: "config"}},
+			"payments": {{Service: "payments", Availability: 99.95, Source: "fact"}},
+		},
+		Metrics: &metrics.Snapshot{
+			Window:    "5m",
+			FetchedAt: time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC),
+			Refresh:   30,
+			Edges: []metrics.EdgeMetrics{
+				{From: "gateway", To: "orders", RequestRate: 42.5, ErrorRate: 0.001, LatencyMs: 85},
+				{From: "orders", To: "payments", RequestRate: 30, ErrorRate: 0.07, LatencyMs: 410},
+			},
+		},
+	}
+}
+
+// TestCentralSiteGolden renders goldenCentralSite and compares the site with
+// testdata/golden/central. Run with -update after an intended change. The
+// site must come out the same however many repos and pages are worked on
+// at once.
+func TestCentralSiteGolden(t *testing.T) {
+	for _, workers := range []int{1, 8} {
+		t.Run(fmt.Sprintf("concurrency=%d", workers), func(t *testing.T) {
+			out := t.TempDir()
+			gen := goldenCentralSite(out)
+			gen.Concurrency = workers
+			if _, err := gen.Generate(); err != nil {
+				t.Fatal(err)
+			}
+			golden.CompareDir(t, filepath.Join("testdata", "golden", "central"), out, goldenSkip)
+		})
+	}
+}
+
+func TestLandingPageHealth(t *testing.T) {
+	now := time.Now()
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "orders", Health: &repohealth.Health{
+				Repo: "orders", LastCommitAt: now.AddDate(0, 0, -3), CIProvider: "GitHub Actions",
+				CIBadgeURL: "https://github.com/acme/orders/actions/workflows/ci.yml/badge.svg", OpenPRs: 1, HasTests: true,
+			}},
+			{Name: "legacy", Health: &repohealth.Health{Repo: "legacy", LastCommitAt: now.AddDate(0, 0, -200), CIProvider: "Jenkins", OpenPRs: -1}},
+			{Name: "fresh"},
+		},
+		QuietAfter: repohealth.DefaultQuietAfter,
+	}
+	dir := t.TempDir()
+	if err := gen.writeLandingPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "index.md"))
+	for _, want := range []string{
+		"| Service | Stack | Files | Status | Docs | Health | Summary |",
+		"| ![GitHub Actions](https://github.com/acme/orders/actions/workflows/ci.yml/badge.svg) · last commit 3 days ago · 1 open PR |",
+		"| Jenkins · **quiet**: last commit 6 months ago · no tests found |",
+		"| — |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("landing page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteTechStack(t *testing.T) {
+	checkout := func(gomod string) string {
+		root := t.TempDir()
+		os.MkdirAll(filepath.Join(root, ".autodoc", "docs"), 0o755)
+		os.WriteFile(filepath.Join(root, "go.mod"), []byte(gomod), 0o644)
+		indexer.SaveAnalyses(root, map[string]indexer.FileAnalysis{
+			"main.go": {FilePath: "main.go", Language: "Go", Dependencies: []indexer.Dependency{{Name: "github.com/lib/pq", Type: "import"}}},
+		})
+		return filepath.Join(root, ".autodoc", "docs")
+	}
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{
+			{Name: "orders", DocsDir: checkout("module orders\n\ngo 1.22\n\nrequire github.com/labstack/echo/v4 v4.11.4\n")},
+			{Name: "payments", DocsDir: checkout("module payments\n\ngo 1.21\n\nrequire github.com/gin-gonic/gin v1.9.1\n")},
+			{Name: "ledger"},
+		},
+	}
+	gen.dependencies = gen.loadDependencies()
+	gen.stacks = gen.loadStacks()
+	if len(gen.stacks) != 2 {
+		t.Fatalf("stacks = %+v; want orders and payments", gen.stacks)
+	}
+	if got := gen.serviceSections(gen.Repos[0]); !strings.Contains(got, "Built with Go 1.22, Echo and PostgreSQL; see [the full stack](tech-stack.md).") {
+		t.Errorf("orders sections:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeServiceTechStack(dir, gen.Repos[0]); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "tech-stack.md"))
+	if want := "| Web framework | Echo | `v4.11.4` | `go.mod` |\n| Database | PostgreSQL | — | `main.go` |"; !strings.Contains(string(page), want) {
+		t.Errorf("orders tech stack missing %q:\n%s", want, page)
+	}
+
+	if err := gen.writeTechStackPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ = os.ReadFile(filepath.Join(dir, "tech-stack.md"))
+	for _, want := range []string{
+		"| Go | 2 | [orders](orders/tech-stack.md), [payments](payments/tech-stack.md) |",
+		"## Web Frameworks\n\n| Technology | Versions | Services |\n|------------|----------|----------|\n| Echo | `v4.11.4` | [orders](orders/tech-stack.md) |\n| Gin | `v1.9.1` | [payments](payments/tech-stack.md) |",
+		"| PostgreSQL | — | [orders](orders/tech-stack.md), [payments](payments/tech-stack.md) |",
+		"- 2 Go web frameworks: Echo (1 service), Gin (1 service)",
+		"- Go in 2 versions: 1.21, 1.22",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("tech stack page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteCosts(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders", DisplayName: "Orders"}, {Name: "payments"}, {Name: "ledger"}},
+		Costs: []costs.MonthlyCost{
+			{Service: "", Month: "2026-09", Currency: "USD", Amount: 100},
+			{Service: "orders", Month: "2026-09", Currency: "USD", Amount: 1320},
+			{Service: "orders", Month: "2026-08", Currency: "USD", Amount: 1200},
+			{Service: "payments", Month: "2026-09", Currency: "USD", Amount: 580},
+		},
+	}
+	orders := gen.serviceSections(gen.Repos[0])
+	for _, want := range []string{
+		"This service cost $1,320.00 in September 2026 (+10% from August 2026)",
+		"| September 2026 | $1,320.00 | +10% |\n| August 2026 | $1,200.00 | — |",
+	} {
+		if !strings.Contains(orders, want) {
+			t.Errorf("orders sections missing %q:\n%s", want, orders)
+		}
+	}
+	if got := gen.serviceSections(gen.Repos[2]); strings.Contains(got, "## Cost") {
+		t.Errorf("ledger has no costs but got:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeSystemOverview(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "system-overview.md"))
+	want := "| [Orders](orders/index.md) | $1,320.00 | +10% | 66% |\n" +
+		"| [payments](payments/index.md) | $580.00 | new | 29% |\n" +
+		"| *Unattributed* | $100.00 | new | 5% |\n" +
+		"| **Total** | **$2,000.00** | | |"
+	if !strings.Contains(string(page), want) {
+		t.Errorf("system overview missing cost table:\n%s", page)
+	}
+
+	gen.Audience = "partners"
+	var b strings.Builder
+	gen.writeCostByService(&b)
+	if strings.Contains(b.String(), "Unattributed") {
+		t.Errorf("audience site shows unattributed spend:\n%s", b.String())
+	}
+}
+
+func TestCentralSiteConfiguration(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".autodoc", "docs"), 0o755)
+	files := map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\tport := cmp.Or(os.Getenv(\"PORT\"), \"8080\")\n\tkey := getEnv(\"STRIPE_API_KEY\", \"sk_test_123\")\n\tworkers := flag.Int(\"workers\", 4, \"worker count\")\n}\n",
+		"db.go":   "package main\n\nvar dsn = os.Getenv(\"PORT\")\n",
+	}
+	analyses := make(map[string]indexer.FileAnalysis)
+	for name, src := range files {
+		os.WriteFile(filepath.Join(root, name), []byte(src), 0o644)
+		analyses[name] = indexer.FileAnalysis{FilePath: name, Language: "Go"}
+	}
+	indexer.SaveAnalyses(root, analyses)
+
+	gen := &CentralSiteGenerator{Repos: []RepoInfo{{Name: "payments", DocsDir: filepath.Join(root, ".autodoc", "docs")}, {Name: "ledger"}}}
+	gen.configuration = gen.loadConfiguration()
+	if len(gen.configuration) != 1 {
+		t.Fatalf("configuration = %+v; want payments only", gen.configuration)
+	}
+	if got := gen.serviceSections(gen.Repos[0]); !strings.Contains(got, "This service reads 2 environment variables and 1 command-line flag; see [each one](configuration.md)") {
+		t.Errorf("payments sections:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeServiceConfiguration(dir, gen.Repos[0]); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "configuration.md"))
+	for _, want := range []string{
+		"## Environment Variables\n\n| Variable | Default | Read By | Read In |\n|----------|---------|---------|---------|\n" +
+			"| `PORT` | `8080` | os.Getenv | `db.go:3`, `main.go:4` |\n" +
+			"| `STRIPE_API_KEY` | *(hidden)* | getEnv | `main.go:5` |",
+		"## Command-Line Flags\n\n| Flag | Default | Read By | Defined In |\n|------|---------|---------|------------|\n| `--workers` | `4` | flag.Int | `main.go:6` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("configuration page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteScheduledJobs(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".autodoc", "docs"), 0o755)
+	os.MkdirAll(filepath.Join(root, "deploy"), 0o755)
+	files := map[string]indexer.FileAnalysis{
+		"main.go":          {Language: "Go"},
+		"reconcile.go":     {Language: "Go", Functions: []indexer.FunctionDoc{{Name: "reconcile"}}, Dependencies: []indexer.Dependency{{Name: "ledger", Type: "api_call"}, {Name: "payments_db", Type: "database"}, {Name: "fmt", Type: indexer.DepImport}}},
+		"deploy/cron.yaml": {Language: "YAML", Skip: true},
+	}
+	sources := map[string]string{
+		"main.go":          "package main\n\nfunc main() {\n\tc := cron.New()\n\tc.AddFunc(\"0 2 * * *\", reconcile)\n}\n",
+		"reconcile.go":     "package main\n\nfunc reconcile() {}\n",
+		"deploy/cron.yaml": "apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: ledger-close\nspec:\n  schedule: \"*/15 * * * *\"\n  jobTemplate:\n    spec:\n      template:\n        spec:\n          containers:\n            - name: close\n              image: registry.example.com/acme/ledger:1.4\n",
+	}
+	analyses := make(map[string]indexer.FileAnalysis)
+	for name, a := range files {
+		os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(sources[name]), 0o644)
+		a.FilePath = name
+		analyses[name] = a
+	}
+	indexer.SaveAnalyses(root, analyses)
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "payments", DocsDir: filepath.Join(root, ".autodoc", "docs")}, {Name: "ledger"}},
+		Links: []LinkInfo{{FromRepo: "ledger", ToRepo: "payments", LinkType: "http"}, {FromRepo: "payments", ToRepo: "ledger", LinkType: "http"}},
+	}
+	gen.jobs = gen.loadScheduledJobs()
+	if len(gen.jobs["payments"]) != 1 || len(gen.jobs["ledger"]) != 1 {
+		t.Fatalf("jobs = %+v; want one each for payments and ledger", gen.jobs)
+	}
+
+	// Both services are called, but their jobs make them entry points.
+	entries := gen.detectEntryPoints()
+	if len(entries) != 2 || entries[0].Kind != EntryScheduler || entries[0].Reason != "runs scheduled jobs: reconcile (daily at 02:00)" {
+		t.Errorf("entry points = %+v", entries)
+	}
+	if got := gen.serviceSections(gen.Repos[1]); !strings.Contains(got, "This service runs ledger-close (every 15 minutes); see [all scheduled jobs](../scheduled-jobs.md)") {
+		t.Errorf("ledger sections:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeScheduledJobsPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "scheduled-jobs.md"))
+	for _, want := range []string{
+		"2 scheduled jobs across 2 services",
+		"| reconcile | [payments](payments/index.md) | `0 2 * * *` | daily at 02:00 | [ledger](ledger/index.md) (api_call), `payments_db` (database) | `main.go:5` |",
+		"| ledger-close | [ledger](ledger/index.md) | `*/15 * * * *` | every 15 minutes | [payments](payments/index.md) (http) | `deploy/cron.yaml:2` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("scheduled jobs page missing %q:\n%s", want, page)
+		}
+	}
+
+	for schedule, want := range map[string]string{
+		"@daily":            "daily at 00:00",
+		"0 0 9 * * MON-FRI": "weekdays at 09:00",
+		"30 4 * * 0":        "weekly on Sunday at 04:30",
+		"0 3 1 * *":         "monthly on day 1 at 03:00",
+		"0 */6 * * *":       "every 6 hours",
+		"fixedRate=60000":   "",
+	} {
+		if got := describeSchedule(schedule); got != want {
+			t.Errorf("describeSchedule(%q) = %q; want %q", schedule, got, want)
+		}
+	}
+}
+
+func TestCentralSiteExternalDependencies(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".autodoc", "docs"), 0o755)
+	os.WriteFile(filepath.Join(root, "client.go"), []byte("package billing\n\n// Docs: https://twilio.com/docs\nconst api = \"https://api.stripe.com/v1\"\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "upload.go"), []byte("package billing\n"), 0o644)
+	indexer.SaveAnalyses(root, map[string]indexer.FileAnalysis{
+		"client.go": {FilePath: "client.go", Language: "Go"},
+		"upload.go": {FilePath: "upload.go", Language: "Go", Dependencies: []indexer.Dependency{{Name: "github.com/aws/aws-sdk-go-v2/service/s3", Type: "import"}}},
+	})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "payments", DocsDir: filepath.Join(root, ".autodoc", "docs")}, {Name: "orders"}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "http"},
+			{FromRepo: "orders", ToRepo: "Stripe", LinkType: "http", Reason: "refunds cancelled orders"},
+			{FromRepo: "orders", ToRepo: "SMTP", LinkType: "smtp"},
+		},
+	}
+	gen.externals = gen.loadExternals()
+	if len(gen.externals["payments"]) != 2 || len(gen.externals["orders"]) != 1 {
+		t.Fatalf("externals = %+v", gen.externals)
+	}
+	if got := gen.serviceSections(gen.Repos[0]); !strings.Contains(got, "This service uses AWS (S3), Stripe; see the [External Dependencies](../external-dependencies.md) catalog") {
+		t.Errorf("payments sections:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeExternalDependenciesPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "external-dependencies.md"))
+	for _, want := range []string{
+		"The 2 external SaaS and cloud vendors 2 services depend on",
+		"| [Stripe](#stripe) | Payments | — | 2 |\n| [AWS](#aws) | Cloud | S3 | 1 |",
+		"## Stripe\n\nPayments, used by 2 services.",
+		"| [payments](payments/index.md) | — | — | `api.stripe.com in client.go:4` |",
+		"| [orders](orders/index.md) | — | refunds cancelled orders | `calls Stripe in the service map` |",
+		"| [payments](payments/index.md) | S3 | — | `github.com/aws/aws-sdk-go-v2/service/s3 imported in upload.go` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("external dependencies page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(string(page), "SMTP") || strings.Contains(string(page), "Twilio") {
+		t.Errorf("external dependencies page lists SMTP or the commented Twilio URL:\n%s", page)
+	}
+}
+
+func TestCentralSiteDataStores(t *testing.T) {
+	repoWith := func(files map[string]string) string {
+		root := t.TempDir()
+		os.MkdirAll(filepath.Join(root, ".autodoc", "docs"), 0o755)
+		analyses := make(map[string]indexer.FileAnalysis)
+		for name, src := range files {
+			os.WriteFile(filepath.Join(root, name), []byte(src), 0o644)
+			analyses[name] = indexer.FileAnalysis{FilePath: name, Language: "Go"}
+		}
+		indexer.SaveAnalyses(root, analyses)
+		return filepath.Join(root, ".autodoc", "docs")
+	}
+	orders := repoWith(map[string]string{
+		"store.go": "package orders\n\nconst insert = \"INSERT INTO orders (id) VALUES ($1)\"\nconst find = \"SELECT name FROM customers WHERE id = $1\"\n",
+		"cache.go": "package orders\n\nimport \"github.com/redis/go-redis/v9\"\n\nfunc warm(rdb *redis.Client) { rdb.Set(ctx, \"k\", 1, 0) }\n",
+	})
+	billing := repoWith(map[string]string{
+		"invoice.go": "package billing\n\nconst q = \"SELECT total FROM orders WHERE id = $1\"\n",
+	})
+
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders", DocsDir: orders}, {Name: "billing", DocsDir: billing}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "orders-db", LinkType: "database"},
+			{FromRepo: "orders", ToRepo: "Redis", LinkType: "cache"},
+			{FromRepo: "orders", ToRepo: "billing", LinkType: "http"},
+		},
+	}
+	gen.dataAccess = gen.loadDataAccess()
+
+	if access, writes, reads := gen.edgeAccess("orders", "orders-db", "database"); access != "reads and writes" || !reflect.DeepEqual(writes, []string{"orders"}) || !reflect.DeepEqual(reads, []string{"customers"}) {
+		t.Errorf("orders -> orders-db access = %q, writes %v, reads %v", access, writes, reads)
+	}
+	if access, _, _ := gen.edgeAccess("orders", "Redis", "cache"); access != "writes" {
+		t.Errorf("orders -> Redis access = %q; want writes", access)
+	}
+	if access, _, _ := gen.edgeAccess("orders", "payments-api", "http"); access != "" {
+		t.Errorf("orders -> payments-api access = %q; want none", access)
+	}
+
+	if got := gen.serviceSections(gen.Repos[1]); !strings.Contains(got, "This service only reads `orders`; see the [Data Stores](../data-stores.md) page for the other services using them. It shares `orders` with other services") {
+		t.Errorf("billing sections:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeDataStoresPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "data-stores.md"))
+	for _, want := range []string{
+		"## Shared Tables\n\n",
+		"| `orders` | SQL | [orders](orders/index.md) | [billing](billing/index.md) |\n\n## All Tables",
+		"| `customers` | SQL | — | [orders](orders/index.md) | `store.go:4` |",
+		"| `Redis` | Redis | [orders](orders/index.md) | — | `cache.go:5` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("data stores page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestCentralSiteLibraryNodes(t *testing.T) {
+	gen := &CentralSiteGenerator{
+		Repos: []RepoInfo{{Name: "orders"}, {Name: "billing"}},
+		Links: []LinkInfo{
+			{FromRepo: "orders", ToRepo: "github.com/acme/lib-auth", LinkType: "library", Reason: "depends on github.com/acme/lib-auth v1.4.0 (go.mod)"},
+			{FromRepo: "billing", ToRepo: "github.com/acme/lib-auth", LinkType: "library"},
+			{FromRepo: "orders", ToRepo: "billing", LinkType: "http"},
+		},
+	}
+	dir := t.TempDir()
+	if err := gen.writeServiceMap(dir); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(filepath.Join(dir, "service-map.html"))
+	if !strings.Contains(string(html), `"id":"github.com/acme/lib-auth","label":"github.com/acme/lib-auth"`) ||
+		!strings.Contains(string(html), "Internal library, built into 2 services") {
+		t.Errorf("expected a node for the shared library:\n%s", html)
+	}
+}
+
+// keywordEmbedder embeds texts mentioning addresses close together, and
+// the rest apart from them.
+type keywordEmbedder struct{}
+
+func (keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, t := range texts {
+		vectors[i] = []float32{0, 1}
+		if strings.Contains(strings.ToLower(t), "address") {
+			vectors[i] = []float32{1, 0.1}
+		}
+	}
+	return vectors, nil
+}
+
+func (keywordEmbedder) Dimensions() int { return 2 }
+func (keywordEmbedder) Name() string    { return "keyword" }
+
+func TestCentralSiteConsolidation(t *testing.T) {
+	newGen := func() *CentralSiteGenerator {
+		gen := &CentralSiteGenerator{
+			Repos: []RepoInfo{
+				{Name: "checkout", Summary: "Validates shipping addresses and normalizes postal codes before checkout."},
+				{Name: "accounts", Summary: "Stores customer profiles and validates their postal addresses and postal codes."},
+				{Name: "geo", Summary: "Address validation and postal code normalization for shipping."},
+				{Name: "billing", Summary: "Creates invoices and charges customers each month."},
+			},
+			Links: []LinkInfo{{FromRepo: "web", ToRepo: "geo", LinkType: "http", Endpoints: []string{"POST /api/v1/addresses/validate"}}},
+		}
+		gen.exposure = map[string][]endpointExposure{
+			"checkout": {{Route: "POST /addresses/validate", Source: "routes.go:12"}, {Route: "GET /healthz", Source: "routes.go:13"}},
+		}
+		return gen
+	}
+
+	gen := newGen()
+	gen.overlaps = gen.findOverlaps()
+	if len(gen.overlaps) != 1 {
+		t.Fatalf("expected one cluster, got %+v", gen.overlaps)
+	}
+	c := gen.overlaps[0]
+	if got := c.services(); !reflect.DeepEqual(got, []string{"checkout", "accounts", "geo"}) {
+		t.Errorf("cluster services = %v", got)
+	}
+	if !reflect.DeepEqual(c.Shapes, []string{"POST /addresses/validate"}) {
+		t.Errorf("shared endpoints = %v", c.Shapes)
+	}
+	if !strings.HasPrefix(c.Name, "Postal") && !strings.HasPrefix(c.Name, "Address") {
+		t.Errorf("fallback name = %q; want the shared words", c.Name)
+	}
+	if got := gen.serviceSections(gen.Repos[2]); !strings.Contains(got, "## Overlapping Functionality") || !strings.Contains(got, "[checkout](../checkout/index.md), [accounts](../accounts/index.md)") {
+		t.Errorf("geo sections:\n%s", got)
+	}
+	if got := gen.serviceSections(gen.Repos[3]); strings.Contains(got, "Overlapping") {
+		t.Errorf("billing overlaps nothing:\n%s", got)
+	}
+
+	dir := t.TempDir()
+	if err := gen.writeConsolidationPage(dir); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := os.ReadFile(filepath.Join(dir, "consolidation.md"))
+	for _, want := range []string{
+		"# Consolidation Candidates\n\n1 group of services",
+		"comparing the words their summaries share",
+		"| [geo](geo/index.md) | Address validation and postal code normalization for shipping. | `POST /addresses/validate` |",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("consolidation page missing %q:\n%s", want, page)
+		}
+	}
+
+	// With embeddings and an LLM, the LLM names and assesses the cluster.
+	gen = newGen()
+	gen.Embedder = keywordEmbedder{}
+	provider := &answerProvider{answer: "Address validation\n\nAll three validate addresses; geo could own it."}
+	gen.Provider = provider
+	gen.overlaps = gen.findOverlaps()
+	if len(gen.overlaps) != 1 || gen.overlaps[0].Name != "Address validation" || gen.overlaps[0].Assessment != "All three validate addresses; geo could own it." {
+		t.Fatalf("LLM-assessed clusters = %+v", gen.overlaps)
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "- geo: Address validation") || !strings.Contains(provider.prompts[0], "Endpoints: POST /addresses/validate") {
+		t.Errorf("prompts = %q", provider.prompts)
+	}
+}
+
+func TestEndpointShape(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"POST /api/v1/addresses/:id/validate": "POST /addresses/{}/validate",
+		"GET /orders/{orderId}":               "GET /orders/{}",
+		"get /Orders/42?expand=items":         "GET /orders/{}",
+		"/users/<int:id>":                     "/users/{}",
+		"GET /healthz":                        "",
+		"GET /api/v2/metrics":                 "",
+	} {
+		if got := endpointShape(endpoint); got != want {
+			t.Errorf("endpointShape(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
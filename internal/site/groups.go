@@ -0,0 +1,341 @@
+package site
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// domainGroup is a business domain and the services in it, by bounded
+// context.
+type domainGroup struct {
+	Name     string
+	Contexts []contextGroup // by name; services without a context come first, under ""
+}
+
+// contextGroup is a bounded context and its services.
+type contextGroup struct {
+	Name     string
+	Services []string
+}
+
+// services returns every service in the domain.
+func (d domainGroup) services() []string {
+	var out []string
+	for _, c := range d.Contexts {
+		out = append(out, c.Services...)
+	}
+	return out
+}
+
+// title is the domain's name as shown in the sidebar and headings.
+func (d domainGroup) title() string {
+	return groupTitle(d.Name)
+}
+
+// groupTitle title-cases a group name given as a slug, e.g. "Order
+// Management" for "order-management"; names with capitals or spaces are
+// kept as given.
+func groupTitle(name string) string {
+	if strings.ToLower(name) != name || strings.Contains(name, " ") {
+		return name
+	}
+	return formatDirName(name)
+}
+
+// domains groups the repos by domain, in name order. Repos without a
+// domain are left out.
+func (g *CentralSiteGenerator) domains() []domainGroup {
+	byDomain := make(map[string]map[string][]string)
+	for _, r := range g.Repos {
+		if r.Domain == "" {
+			continue
+		}
+		if byDomain[r.Domain] == nil {
+			byDomain[r.Domain] = make(map[string][]string)
+		}
+		byDomain[r.Domain][r.BoundedContext] = append(byDomain[r.Domain][r.BoundedContext], r.Name)
+	}
+	out := make([]domainGroup, 0, len(byDomain))
+	for name, contexts := range byDomain {
+		d := domainGroup{Name: name}
+		for c, services := range contexts {
+			sort.Strings(services)
+			d.Contexts = append(d.Contexts, contextGroup{Name: c, Services: services})
+		}
+		sort.Slice(d.Contexts, func(i, j int) bool { return d.Contexts[i].Name < d.Contexts[j].Name })
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// sectionGroups places each grouped repo's docs in the sidebar under its
+// domain and, if it has one, its bounded context.
+func (g *CentralSiteGenerator) sectionGroups() map[string][]string {
+	groups := make(map[string][]string)
+	for _, r := range g.Repos {
+		if r.Domain == "" {
+			continue
+		}
+		path := []string{groupTitle(r.Domain)}
+		if r.BoundedContext != "" {
+			path = append(path, groupTitle(r.BoundedContext))
+		}
+		groups[r.Name] = path
+	}
+	return groups
+}
+
+// domainLink is a cross-domain dependency: the links from services in one
+// domain to services in another.
+type domainLink struct {
+	From, To string
+	Links    []LinkInfo
+}
+
+// domainLinks derives the dependencies between domains from the links
+// between their services, by domain pair.
+func (g *CentralSiteGenerator) domainLinks() []domainLink {
+	domainOf := make(map[string]string, len(g.Repos))
+	for _, r := range g.Repos {
+		domainOf[r.Name] = r.Domain
+	}
+	byPair := make(map[[2]string]*domainLink)
+	for _, l := range g.Links {
+		from, to := domainOf[l.FromRepo], domainOf[l.ToRepo]
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		key := [2]string{from, to}
+		if byPair[key] == nil {
+			byPair[key] = &domainLink{From: from, To: to}
+		}
+		byPair[key].Links = append(byPair[key].Links, l)
+	}
+	out := make([]domainLink, 0, len(byPair))
+	for _, d := range byPair {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+// writeDomainPages creates the domains/ section: an overview of every
+// domain with the dependencies between them, and a page per domain with
+// its services, bounded contexts, and diagram.
+func (g *CentralSiteGenerator) writeDomainPages(stagingDir string) error {
+	domains := g.domains()
+	dir := filepath.Join(stagingDir, "domains")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	links := g.domainLinks()
+
+	var b strings.Builder
+	b.WriteString("# Domains\n\n")
+	b.WriteString("Services grouped by business domain and bounded context. Each domain's page shows its services and how they connect, within the domain and to the others.\n\n")
+	b.WriteString("| Domain | Bounded Contexts | Services | Environments |\n")
+	b.WriteString("|--------|------------------|----------|--------------|\n")
+	for _, d := range domains {
+		var contexts []string
+		for _, c := range d.Contexts {
+			if c.Name != "" {
+				contexts = append(contexts, groupTitle(c.Name))
+			}
+		}
+		fmt.Fprintf(&b, "| [%s](%s.md) | %s | %d | %s |\n", d.title(), teamSlug(d.Name), strings.Join(contexts, ", "), len(d.services()), strings.Join(g.environments(d.services()), ", "))
+	}
+	b.WriteString("\n")
+
+	var ungrouped []string
+	for _, r := range g.Repos {
+		if r.Domain == "" {
+			ungrouped = append(ungrouped, fmt.Sprintf("[%s](../%s/index.md)", r.Name, r.Name))
+		}
+	}
+	if len(ungrouped) > 0 {
+		b.WriteString("**Not in a domain:** " + strings.Join(ungrouped, ", ") + "\n\n")
+	}
+
+	if len(links) > 0 {
+		b.WriteString("## Domain Dependencies\n\n")
+		b.WriteString("An arrow means services in one domain call services in the other; its label counts the links.\n\n")
+		b.WriteString("```mermaid\ngraph LR\n")
+		for _, d := range domains {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidNodeID("D_", d.Name), strings.ReplaceAll(d.title(), `"`, "'"))
+		}
+		for _, l := range links {
+			fmt.Fprintf(&b, "    %s -->|%d| %s\n", mermaidNodeID("D_", l.From), len(l.Links), mermaidNodeID("D_", l.To))
+		}
+		b.WriteString("```\n\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		if err := os.WriteFile(filepath.Join(dir, teamSlug(d.Name)+".md"), []byte(g.domainPage(d, links)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// environments returns the environments the services are deployed to,
+// sorted.
+func (g *CentralSiteGenerator) environments(services []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, r := range g.Repos {
+		if r.Environment != "" && !seen[r.Environment] && slices.Contains(services, r.Name) {
+			seen[r.Environment] = true
+			out = append(out, r.Environment)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// domainPage renders one domain's page.
+func (g *CentralSiteGenerator) domainPage(d domainGroup, links []domainLink) string {
+	services := d.services()
+	repos := make(map[string]RepoInfo, len(g.Repos))
+	for _, r := range g.Repos {
+		repos[r.Name] = r
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", d.title())
+	fmt.Fprintf(&b, "**Services:** %d", len(services))
+	named := 0
+	for _, c := range d.Contexts {
+		if c.Name != "" {
+			named++
+		}
+	}
+	if named > 0 {
+		fmt.Fprintf(&b, " · **Bounded contexts:** %d", named)
+	}
+	fmt.Fprintf(&b, " · [Open in the service map](../service-map.html?domain=%s)\n\n", url.QueryEscape(d.Name))
+
+	b.WriteString("## Services\n\n")
+	b.WriteString("| Service | Bounded Context | Environment | Summary |\n")
+	b.WriteString("|---------|-----------------|-------------|---------|\n")
+	for _, c := range d.Contexts {
+		for _, s := range c.Services {
+			r := repos[s]
+			summary := r.Summary
+			if len(summary) > 80 {
+				summary = summary[:77] + "..."
+			}
+			fmt.Fprintf(&b, "| [%s](../%s/index.md) | %s | %s | %s |\n", s, s, groupTitle(c.Name), r.Environment, strings.ReplaceAll(summary, "|", `\|`))
+		}
+	}
+	b.WriteString("\n")
+
+	// The diagram: the domain's services in a subgraph per bounded
+	// context, with each other domain they talk to collapsed into a node.
+	inDomain := make(map[string]bool, len(services))
+	for _, s := range services {
+		inDomain[s] = true
+	}
+	var edges []string
+	seen := make(map[string]bool)
+	otherDomains := make(map[string]bool)
+	externals := make(map[string]bool)
+	nodeID := func(service string) string {
+		switch other := repos[service].Domain; {
+		case inDomain[service]:
+		case other != "":
+			otherDomains[other] = true
+			return mermaidNodeID("D_", other)
+		default:
+			externals[service] = true
+		}
+		return mermaidNodeID("S_", service)
+	}
+	for _, l := range g.Links {
+		if !inDomain[l.FromRepo] && !inDomain[l.ToRepo] {
+			continue
+		}
+		edge := fmt.Sprintf("    %s -->|%s| %s\n", nodeID(l.FromRepo), l.LinkType, nodeID(l.ToRepo))
+		if l.LinkType == "" {
+			edge = fmt.Sprintf("    %s --> %s\n", nodeID(l.FromRepo), nodeID(l.ToRepo))
+		}
+		if !seen[edge] {
+			seen[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+	b.WriteString("## Diagram\n\n")
+	b.WriteString("Other domains are collapsed into one node each; services outside any domain are rounded.\n\n")
+	b.WriteString("```mermaid\ngraph LR\n")
+	for _, c := range d.Contexts {
+		indent := "    "
+		if c.Name != "" {
+			fmt.Fprintf(&b, "    subgraph %s[\"%s\"]\n", mermaidNodeID("C_", c.Name), strings.ReplaceAll(groupTitle(c.Name), `"`, "'"))
+			indent += "    "
+		}
+		for _, s := range c.Services {
+			fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, mermaidNodeID("S_", s), s)
+		}
+		if c.Name != "" {
+			b.WriteString("    end\n")
+		}
+	}
+	for _, o := range slices.Sorted(maps.Keys(otherDomains)) {
+		fmt.Fprintf(&b, "    %s{{\"%s domain\"}}\n", mermaidNodeID("D_", o), strings.ReplaceAll(groupTitle(o), `"`, "'"))
+	}
+	for _, e := range slices.Sorted(maps.Keys(externals)) {
+		fmt.Fprintf(&b, "    %s([\"%s\"])\n", mermaidNodeID("S_", e), e)
+	}
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	b.WriteString("```\n\n")
+
+	writeDeps := func(heading string, deps []domainLink, other func(domainLink) string) {
+		if len(deps) == 0 {
+			return
+		}
+		b.WriteString("## " + heading + "\n\n")
+		for _, dl := range deps {
+			name := other(dl)
+			fmt.Fprintf(&b, "- [%s](%s.md):", groupTitle(name), teamSlug(name))
+			for i, l := range dl.Links {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				fmt.Fprintf(&b, " %s → %s", l.FromRepo, l.ToRepo)
+				if l.LinkType != "" {
+					fmt.Fprintf(&b, " (%s)", l.LinkType)
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	var outbound, inbound []domainLink
+	for _, l := range links {
+		if l.From == d.Name {
+			outbound = append(outbound, l)
+		}
+		if l.To == d.Name {
+			inbound = append(inbound, l)
+		}
+	}
+	writeDeps("Depends On", outbound, func(l domainLink) string { return l.To })
+	writeDeps("Depended On By", inbound, func(l domainLink) string { return l.From })
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/repohealth"
+)
+
+// hasHealth reports whether any repo's health has been gathered, so the
+// landing page's services table shows a Health column.
+func hasHealth(repos []RepoInfo) bool {
+	for _, r := range repos {
+		if r.Health != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// healthCell renders a repo's health for the landing page: its CI badge,
+// how long since its last commit, flagged once it has gone quiet, its open
+// pull requests, and a warning when it has no tests.
+func healthCell(h *repohealth.Health, now time.Time, quietAfter time.Duration) string {
+	if h == nil {
+		return "—"
+	}
+	var parts []string
+	switch {
+	case h.CIBadgeURL != "":
+		parts = append(parts, fmt.Sprintf("![%s](%s)", h.CIProvider, h.CIBadgeURL))
+	case h.CIProvider != "":
+		parts = append(parts, h.CIProvider)
+	default:
+		parts = append(parts, "no CI")
+	}
+	commit := "last commit " + h.Age(now)
+	if h.LastCommitAt.IsZero() {
+		commit = h.Age(now)
+	}
+	if h.Quiet(now, quietAfter) {
+		commit = "**quiet**: " + commit
+	}
+	parts = append(parts, commit)
+	switch {
+	case h.OpenPRs == 1:
+		parts = append(parts, "1 open PR")
+	case h.OpenPRs >= 0:
+		parts = append(parts, fmt.Sprintf("%d open PRs", h.OpenPRs))
+	}
+	if !h.HasTests {
+		parts = append(parts, "no tests found")
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,165 @@
+package site
+
+import "time"
+
+// GraphSnapshot is the architecture at one point in time: the registered
+// services and the links between them.
+type GraphSnapshot struct {
+	TakenAt  time.Time
+	Services []string
+	Links    []LinkInfo // only FromRepo, ToRepo, and LinkType are set
+}
+
+// maxTimelineSteps caps the steps on the service map's timeline; older
+// snapshots are dropped.
+const maxTimelineSteps = 120
+
+// serviceMapSnapshot is a step on the service map's timeline.
+type serviceMapSnapshot struct {
+	At       time.Time `json:"at"`
+	Services []string  `json:"services"`
+	Links    []string  `json:"links"` // "from>to>type"
+}
+
+// serviceMapHistory returns the steps of the service map's timeline, the
+// last snapshot of each day, and adds the services and links that have
+// since gone to data, marked removed, so the timeline can show them.
+func (g *CentralSiteGenerator) serviceMapHistory(data *serviceMapData) []serviceMapSnapshot {
+	var kept []GraphSnapshot
+	for i, s := range g.History {
+		if i+1 == len(g.History) || !sameDay(s.TakenAt, g.History[i+1].TakenAt) {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) > maxTimelineSteps {
+		kept = kept[len(kept)-maxTimelineSteps:]
+	}
+
+	nodes := make(map[string]bool, len(data.Nodes))
+	for _, n := range data.Nodes {
+		nodes[n.ID] = true
+	}
+	addNode := func(id string) {
+		if !nodes[id] {
+			nodes[id] = true
+			data.Nodes = append(data.Nodes, serviceMapNode{ID: id, Label: id, Status: "removed", Summary: "No longer part of the system", DocLink: "#"})
+		}
+	}
+	edges := make(map[string]bool, len(data.Edges))
+	for _, e := range data.Edges {
+		edges[e.Source+">"+e.Target+">"+e.LinkType] = true
+	}
+
+	steps := make([]serviceMapSnapshot, len(kept))
+	for i, s := range kept {
+		steps[i] = serviceMapSnapshot{At: s.TakenAt.UTC(), Services: append([]string{}, s.Services...), Links: make([]string, len(s.Links))}
+		for _, svc := range s.Services {
+			addNode(svc)
+		}
+		for j, l := range s.Links {
+			key := l.FromRepo + ">" + l.ToRepo + ">" + l.LinkType
+			steps[i].Links[j] = key
+			if !edges[key] {
+				edges[key] = true
+				addNode(l.FromRepo)
+				addNode(l.ToRepo)
+				data.Edges = append(data.Edges, serviceMapEdge{Source: l.FromRepo, Target: l.ToRepo, LinkType: l.LinkType, Removed: true})
+			}
+		}
+	}
+	return steps
+}
+
+// sameDay reports whether two times fall on the same UTC day.
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format(time.DateOnly) == b.UTC().Format(time.DateOnly)
+}
+
+// serviceMapTimelineCSS styles the service map's timeline.
+const serviceMapTimelineCSS = `#timeline{position:fixed;left:50%;bottom:16px;transform:translateX(-50%);display:flex;align-items:center;gap:10px;background:var(--bg2);border:1px solid var(--bd);border-radius:8px;padding:6px 12px;font-size:12px;color:var(--tx2);z-index:10}
+#timeline[hidden]{display:none}
+#time-slider{width:320px;accent-color:var(--ac)}
+#time-label{min-width:220px}
+rect.appeared{animation:appear-node 1.5s}
+.edge.appeared{animation:appear-edge 1.5s}
+@keyframes appear-node{from{stroke:#3fb950;stroke-width:6px}}
+@keyframes appear-edge{from{stroke:#3fb950;stroke-opacity:1;stroke-width:4px}}
+`
+
+// serviceMapTimelineJS is the service map's timeline: a slider over the
+// snapshots from serviceMapHistory, and a play button that steps through
+// them, so services and links appear and disappear as they did. What
+// appeared since the previous step flashes green. It hooks into the group
+// filter through edgeInTime and timeSnapshot, which applyGroupFilter
+// consults, and shares the map script's variables.
+const serviceMapTimelineJS = `
+// ===== Timeline: how the architecture evolved =====
+var snapshots = data.history || [];
+// timeIndex is the snapshot shown, or null for now.
+var timeIndex = null, playing = null;
+snapshots.forEach(function(s){
+  s.serviceSet = {};
+  s.linkSet = {};
+  s.services.forEach(function(id){ s.serviceSet[id] = true; });
+  s.links.forEach(function(k){ s.linkSet[k] = true; });
+});
+var timeline = document.getElementById('timeline');
+var timeSlider = document.getElementById('time-slider');
+var timeLabel = document.getElementById('time-label');
+var playBtn = document.getElementById('play-btn');
+function timeSnapshot(){ return timeIndex == null ? null : snapshots[timeIndex]; }
+function edgeInTime(e){
+  var s = timeSnapshot();
+  return s ? !!s.linkSet[endpointId(e.source) + '>' + endpointId(e.target) + '>' + (e.linkType || '')] : !e.removed;
+}
+// visibleNow lists the services and links the map shows.
+function visibleNow(){
+  var v = {};
+  data.nodes.forEach(function(n){ if(nodeState[n.id]) v[n.id] = true; });
+  data.edges.forEach(function(e, i){ if(edgeState(e)) v['edge:' + i] = true; });
+  return v;
+}
+function plural(n, word){ return n + ' ' + word + (n === 1 ? '' : 's'); }
+function showTime(i){
+  var before = visibleNow();
+  timeIndex = i >= snapshots.length ? null : i;
+  timeSlider.value = i;
+  applyGroupFilter();
+  var after = visibleNow(), changes = [];
+  var added = {services: 0, links: 0}, gone = {services: 0, links: 0};
+  data.nodes.forEach(function(n){ if(after[n.id] && !before[n.id]) added.services++; if(before[n.id] && !after[n.id]) gone.services++; });
+  data.edges.forEach(function(e, i){ var k = 'edge:' + i; if(after[k] && !before[k]) added.links++; if(before[k] && !after[k]) gone.links++; });
+  nodeEls.classed('appeared', function(d){ return after[d.id] && !before[d.id]; });
+  edgeEls.classed('appeared', function(d, i){ return after['edge:' + i] && !before['edge:' + i]; });
+  if(added.services) changes.push('+' + plural(added.services, 'service'));
+  if(added.links) changes.push('+' + plural(added.links, 'link'));
+  if(gone.services) changes.push('−' + plural(gone.services, 'service'));
+  if(gone.links) changes.push('−' + plural(gone.links, 'link'));
+  var s = timeSnapshot();
+  timeLabel.textContent = (s ? new Date(s.at).toLocaleDateString() : 'Now') + (changes.length ? ' · ' + changes.join(', ') : '');
+}
+function stopPlaying(){
+  clearInterval(playing);
+  playing = null;
+  playBtn.textContent = '▶';
+}
+if(snapshots.length){
+  timeSlider.min = 0;
+  timeSlider.max = snapshots.length;
+  timeSlider.value = snapshots.length;
+  timeLabel.textContent = 'Now';
+  timeSlider.oninput = function(){ stopPlaying(); showTime(+timeSlider.value); };
+  // Play from the oldest snapshot, or on from the one shown.
+  playBtn.onclick = function(){
+    if(playing){ stopPlaying(); return; }
+    var i = timeIndex == null ? 0 : timeIndex;
+    playBtn.textContent = '⏸';
+    showTime(i);
+    playing = setInterval(function(){
+      if(i >= snapshots.length){ stopPlaying(); return; }
+      showTime(++i);
+    }, 1200);
+  };
+  timeline.hidden = false;
+}
+`
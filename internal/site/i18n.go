@@ -0,0 +1,279 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/ziadkadry99/auto-doc/internal/artifacts"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+// SiteLanguage is one language a site is published in.
+type SiteLanguage struct {
+	Code string
+	Name string
+	// Dir is where the language's copy of the site lives, relative to the
+	// source language's: "" for the source language itself.
+	Dir string
+}
+
+// languageNames are the names languages are shown by in the switcher, in
+// the language itself, and given to the LLM by, in English.
+var languageNames = map[string][2]string{
+	"ar": {"العربية", "Arabic"},
+	"de": {"Deutsch", "German"},
+	"en": {"English", "English"},
+	"es": {"Español", "Spanish"},
+	"fr": {"Français", "French"},
+	"hi": {"हिन्दी", "Hindi"},
+	"it": {"Italiano", "Italian"},
+	"ja": {"日本語", "Japanese"},
+	"ko": {"한국어", "Korean"},
+	"nl": {"Nederlands", "Dutch"},
+	"pl": {"Polski", "Polish"},
+	"pt": {"Português", "Portuguese"},
+	"ru": {"Русский", "Russian"},
+	"sv": {"Svenska", "Swedish"},
+	"tr": {"Türkçe", "Turkish"},
+	"uk": {"Українська", "Ukrainian"},
+	"zh": {"中文", "Chinese"},
+}
+
+// languageName returns a language's name in itself, or in English, falling
+// back to its code; regional variants such as pt-BR are named by their
+// base language and keep the code as a qualifier.
+func languageName(code string, english bool) string {
+	base, region, _ := strings.Cut(code, "-")
+	names, ok := languageNames[base]
+	if !ok {
+		return code
+	}
+	name := names[0]
+	if english {
+		name = names[1]
+	}
+	if region != "" {
+		name += " (" + region + ")"
+	}
+	return name
+}
+
+// NewSiteLanguages lists the languages of a site generated in source and
+// translated into targets, each target in a subdirectory named by its code.
+func NewSiteLanguages(source string, targets []string) []SiteLanguage {
+	if source == "" {
+		source = "en"
+	}
+	langs := []SiteLanguage{{Code: source, Name: languageName(source, false)}}
+	for _, t := range targets {
+		langs = append(langs, SiteLanguage{Code: t, Name: languageName(t, false), Dir: t})
+	}
+	return langs
+}
+
+// translateVersion is part of every translation's memo key; bump it when
+// the prompt changes.
+const translateVersion = "1"
+
+// maxTranslateChunk bounds how much prose goes to the LLM at once.
+const maxTranslateChunk = 6000
+
+// Translator translates generated markdown with an LLM. Code blocks
+// (Mermaid diagrams included) are left as they are, and headings keep the
+// anchors they had in the source language, so links into a page, and the
+// language switcher, land on the same section in every language.
+type Translator struct {
+	Provider llm.Provider
+	Model    string
+	// Artifacts, when set, remembers translations, so prose that hasn't
+	// changed since the last run isn't translated again.
+	Artifacts *artifacts.Store
+	// Untranslated counts the passages left in the source language because
+	// the LLM failed on them.
+	Untranslated int
+}
+
+// TranslateDocs writes a translation of every page in srcDir to dstDir,
+// and copies the other files as they are.
+func (t *Translator) TranslateDocs(ctx context.Context, srcDir, dstDir, lang string) error {
+	return filepath.Walk(srcDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, file)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+		if !strings.HasSuffix(file, ".md") {
+			return copyFile(file, dst)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return writeFile(dst, t.translateMarkdown(ctx, content, lang))
+	})
+}
+
+// translateMarkdown translates one page, passage by passage.
+func (t *Translator) translateMarkdown(ctx context.Context, content []byte, lang string) []byte {
+	var out strings.Builder
+	for _, p := range splitTranslatable(string(pinHeadingIDs(content))) {
+		if !p.prose || strings.TrimSpace(p.text) == "" {
+			out.WriteString(p.text)
+			continue
+		}
+		out.WriteString(t.translate(ctx, p.text, lang))
+	}
+	return []byte(out.String())
+}
+
+// translate translates one passage of prose, keeping the whitespace around
+// it, or returns it as it is when the LLM fails.
+func (t *Translator) translate(ctx context.Context, passage, lang string) string {
+	body := strings.TrimSpace(passage)
+	lead := passage[:strings.Index(passage, body)]
+	trail := passage[len(lead)+len(body):]
+
+	var key string
+	if t.Artifacts != nil {
+		key = artifacts.MemoKey("translate", translateVersion, lang, t.Model, body)
+		if cached, ok := t.Artifacts.Lookup(ctx, key); ok {
+			return lead + string(cached) + trail
+		}
+	}
+	resp, err := t.Provider.Complete(ctx, llm.CompletionRequest{
+		Model: t.Model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You translate software documentation written in Markdown. Translate the prose only. Keep the Markdown structure exactly: headings, lists, tables, emphasis, and line breaks. Leave unchanged: link targets, inline code, code identifiers, file paths, service names, URLs, HTML tags, and heading attributes such as {#overview}. Reply with the translated Markdown and nothing else."},
+			{Role: llm.RoleUser, Content: fmt.Sprintf("Translate into %s (%s):\n\n%s", languageName(lang, true), lang, body)},
+		},
+		MaxTokens:   4096,
+		Temperature: 0.1,
+	})
+	if err != nil || strings.TrimSpace(resp.Content) == "" {
+		t.Untranslated++
+		return passage
+	}
+	translated := unwrapMarkdownFence(strings.TrimSpace(resp.Content))
+	if key != "" {
+		_ = t.Artifacts.Remember(ctx, key, []byte(translated))
+	}
+	return lead + translated + trail
+}
+
+// unwrapMarkdownFence removes the ```markdown fence models sometimes wrap
+// their whole reply in.
+func unwrapMarkdownFence(s string) string {
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") {
+		return s
+	}
+	first, rest, ok := strings.Cut(s, "\n")
+	if !ok || strings.TrimSpace(strings.Trim(first, "`")) != "markdown" && strings.TrimSpace(strings.Trim(first, "`")) != "md" {
+		return s
+	}
+	return strings.TrimSpace(strings.TrimSuffix(rest, "```"))
+}
+
+// pinHeadingIDs gives every ATX heading the anchor it gets in the source
+// language as an explicit {#id} attribute, so translating its text doesn't
+// change the anchor.
+func pinHeadingIDs(content []byte) []byte {
+	doc := newMarkdown().Parser().Parse(text.NewReader(content))
+	type insert struct {
+		at int
+		id string
+	}
+	var inserts []insert
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok || h.Lines().Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+		id, ok := h.AttributeString("id")
+		idBytes, isBytes := id.([]byte)
+		if !ok || !isBytes {
+			return ast.WalkContinue, nil
+		}
+		seg := h.Lines().At(0)
+		start := bytes.LastIndexByte(content[:seg.Start], '\n') + 1
+		end := seg.Stop
+		if i := bytes.IndexByte(content[end:], '\n'); i >= 0 {
+			end += i
+		} else {
+			end = len(content)
+		}
+		line := content[start:end]
+		if !bytes.HasPrefix(bytes.TrimLeft(line, " "), []byte("#")) || bytes.Contains(line, []byte("{#")) {
+			return ast.WalkContinue, nil
+		}
+		end = start + len(bytes.TrimRight(line, " \t\r"))
+		inserts = append(inserts, insert{end, string(idBytes)})
+		return ast.WalkContinue, nil
+	})
+
+	var out bytes.Buffer
+	prev := 0
+	for _, in := range inserts {
+		out.Write(content[prev:in.at])
+		out.WriteString(" {#" + in.id + "}")
+		prev = in.at
+	}
+	out.Write(content[prev:])
+	return out.Bytes()
+}
+
+// translatable is a passage of a page: prose to translate, or code to
+// leave alone.
+type translatable struct {
+	text  string
+	prose bool
+}
+
+// splitTranslatable splits a page into fenced code blocks and passages of
+// prose, breaking long prose at blank lines so no passage grows much past
+// maxTranslateChunk.
+func splitTranslatable(page string) []translatable {
+	var parts []translatable
+	var cur strings.Builder
+	flush := func(prose bool) {
+		if cur.Len() > 0 {
+			parts = append(parts, translatable{text: cur.String(), prose: prose})
+			cur.Reset()
+		}
+	}
+
+	fence := ""
+	for _, line := range strings.SplitAfter(page, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case fence != "":
+			cur.WriteString(line)
+			if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+				fence = ""
+				flush(false)
+			}
+			continue
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush(true)
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			cur.WriteString(line)
+			continue
+		case strings.TrimSpace(line) == "" && cur.Len() >= maxTranslateChunk:
+			cur.WriteString(line)
+			flush(true)
+			continue
+		}
+		cur.WriteString(line)
+	}
+	flush(fence == "")
+	return parts
+}
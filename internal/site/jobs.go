@@ -0,0 +1,230 @@
+package site
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// scheduledJob is a job a service runs on a clock, and what it touches.
+type scheduledJob struct {
+	indexer.ScheduledJob
+	Service string
+	Source  string // file:line, in the repo it was found in
+	// Touches are the databases, topics, and services the job's code
+	// depends on, or for a CronJob, the services its image calls.
+	Touches []indexer.Dependency
+}
+
+// loadScheduledJobs finds the jobs scheduled in each repo, by the name of
+// the repo running them. A Kubernetes CronJob runs in the repo its image
+// is named after, when that repo is registered, wherever its manifest is.
+func (g *CentralSiteGenerator) loadScheduledJobs() map[string][]scheduledJob {
+	repoNames := make(map[string]string, len(g.Repos))
+	for _, r := range g.Repos {
+		repoNames[strings.ToLower(r.Name)] = r.Name
+	}
+	found := make([][]scheduledJob, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
+		analyses := g.repoAnalyses(repo)
+		// Manifests are often marked as boilerplate, but hold CronJobs, so
+		// the scan covers them.
+		for _, f := range g.repoScan(repo) {
+			for _, j := range f.Jobs {
+				job := scheduledJob{ScheduledJob: j, Service: repo.Name, Source: fmt.Sprintf("%s:%d", f.Path, j.Line)}
+				if j.Image != "" {
+					if owner, ok := repoNames[strings.ToLower(imageName(j.Image))]; ok {
+						job.Service = owner
+					}
+				} else {
+					job.Touches = jobTouches(analyses, f.Path, j.Handler)
+				}
+				found[i] = append(found[i], job)
+			}
+		}
+	})
+
+	jobs := make(map[string][]scheduledJob)
+	for _, list := range found {
+		for _, j := range list {
+			if j.Image != "" {
+				for _, l := range g.Links {
+					if strings.EqualFold(l.FromRepo, j.Service) {
+						j.Touches = append(j.Touches, indexer.Dependency{Name: l.ToRepo, Type: l.LinkType})
+					}
+				}
+			}
+			jobs[j.Service] = append(jobs[j.Service], j)
+		}
+	}
+	return jobs
+}
+
+// imageName returns the repository name of a container image, without its
+// registry, path, tag, or digest: "ledger" for
+// registry.example.com/acme/ledger:1.4.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = image[strings.LastIndex(image, "/")+1:]
+	image, _, _ = strings.Cut(image, ":")
+	return image
+}
+
+// jobTouches lists what a job depends on: the non-import dependencies of
+// the file scheduling it and of the files defining its handler.
+func jobTouches(analyses map[string]indexer.FileAnalysis, path, handler string) []indexer.Dependency {
+	files := []string{path}
+	if name := handler[strings.LastIndex(handler, ".")+1:]; name != "" {
+		for p, a := range analyses {
+			if p == path {
+				continue
+			}
+			if slices.ContainsFunc(a.Functions, func(f indexer.FunctionDoc) bool { return f.Name == name }) {
+				files = append(files, p)
+			}
+		}
+		sort.Strings(files[1:])
+	}
+	var out []indexer.Dependency
+	for _, f := range files {
+		for _, d := range analyses[f].Dependencies {
+			if d.Type != indexer.DepImport && !slices.Contains(out, d) {
+				out = append(out, d)
+			}
+		}
+	}
+	return out
+}
+
+// cronDescriptors describe the standard cron descriptors.
+var cronDescriptors = map[string]string{
+	"@yearly": "yearly", "@annually": "yearly", "@monthly": "monthly", "@weekly": "weekly",
+	"@daily": "daily at 00:00", "@midnight": "daily at 00:00", "@hourly": "hourly",
+}
+
+var weekdays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// describeSchedule says when a schedule runs in words, e.g. "daily at
+// 02:00" for "0 2 * * *"; "" for schedules it can't read, and those that
+// already read as words.
+func describeSchedule(schedule string) string {
+	if d, ok := cronDescriptors[schedule]; ok {
+		return d
+	}
+	if every, ok := strings.CutPrefix(schedule, "@every "); ok {
+		return "every " + every
+	}
+	if c, ok := strings.CutPrefix(schedule, "CronExpression."); ok {
+		return strings.ToLower(strings.ReplaceAll(c, "_", " "))
+	}
+	fields := strings.Fields(strings.ReplaceAll(schedule, "?", "*"))
+	switch len(fields) {
+	case 6: // with seconds, as Spring and Quartz write them
+		fields = fields[1:]
+	case 7: // with seconds and years
+		fields = fields[1:6]
+	case 5:
+	default:
+		return ""
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+	number := func(s string) (int, bool) {
+		n, err := strconv.Atoi(s)
+		return n, err == nil
+	}
+	m, minuteOK := number(minute)
+	h, hourOK := number(hour)
+	at := fmt.Sprintf("%02d:%02d", h, m)
+	if month != "*" {
+		return ""
+	}
+	switch {
+	case minuteOK && hourOK && dom == "*" && dow == "*":
+		return "daily at " + at
+	case minuteOK && hourOK && dom == "*" && (dow == "1-5" || strings.EqualFold(dow, "MON-FRI")):
+		return "weekdays at " + at
+	case minuteOK && hourOK && dom == "*":
+		if d, ok := number(dow); ok && d >= 0 && d <= 7 {
+			return fmt.Sprintf("weekly on %s at %s", weekdays[d%7], at)
+		}
+	case minuteOK && hourOK && dow == "*":
+		if d, ok := number(dom); ok {
+			return fmt.Sprintf("monthly on day %d at %s", d, at)
+		}
+	case dom != "*" || dow != "*":
+	case minuteOK && hour == "*":
+		return fmt.Sprintf("hourly at :%02d", m)
+	case minuteOK && strings.HasPrefix(hour, "*/"):
+		return "every " + strings.TrimPrefix(hour, "*/") + " hours"
+	case strings.HasPrefix(minute, "*/") && hour == "*":
+		return "every " + strings.TrimPrefix(minute, "*/") + " minutes"
+	case minute == "*" && hour == "*":
+		return "every minute"
+	}
+	return ""
+}
+
+// jobLabel names a job with when it runs, e.g. "nightly (daily at 02:00)".
+func jobLabel(j scheduledJob) string {
+	when := cmp.Or(describeSchedule(j.Schedule), j.Schedule)
+	if when == "" {
+		return j.Name
+	}
+	return fmt.Sprintf("%s (%s)", j.Name, when)
+}
+
+// jobsReason says which scheduled jobs make a service an entry point.
+func jobsReason(jobs []scheduledJob) string {
+	const shown = 3
+	labels := make([]string, 0, shown)
+	for _, j := range jobs[:min(shown, len(jobs))] {
+		labels = append(labels, jobLabel(j))
+	}
+	reason := "runs scheduled jobs: " + strings.Join(labels, ", ")
+	if len(jobs) > shown {
+		reason += fmt.Sprintf(" and %d more", len(jobs)-shown)
+	}
+	return reason
+}
+
+// writeScheduledJobsPage writes scheduled-jobs.md, listing every scheduled
+// job by service with its schedule and what it touches.
+func (g *CentralSiteGenerator) writeScheduledJobsPage(stagingDir string) error {
+	repoNames := make(map[string]string, len(g.Repos))
+	total := 0
+	for _, r := range g.Repos {
+		repoNames[strings.ToLower(r.Name)] = r.Name
+		total += len(g.jobs[r.Name])
+	}
+
+	var b strings.Builder
+	b.WriteString("# Scheduled Jobs\n\n")
+	fmt.Fprintf(&b, "%d scheduled jobs across %d services, found in cron libraries, scheduling annotations, Celery beat schedules, and Kubernetes CronJobs. Each one starts work on a clock rather than a request, so the flows treat its service as an entry point.\n\n", total, len(g.jobs))
+	b.WriteString("| Job | Service | Schedule | Runs | Touches | Defined In |\n")
+	b.WriteString("|-----|---------|----------|------|---------|------------|\n")
+	for _, r := range g.Repos {
+		for _, j := range g.jobs[r.Name] {
+			touches := make([]string, len(j.Touches))
+			for i, d := range j.Touches {
+				name := "`" + d.Name + "`"
+				if repo, ok := repoNames[strings.ToLower(d.Name)]; ok {
+					name = fmt.Sprintf("[%s](%s/index.md)", repo, repo)
+				}
+				touches[i] = fmt.Sprintf("%s (%s)", name, d.Type)
+			}
+			fmt.Fprintf(&b, "| %s | [%s](%s/index.md) | `%s` | %s | %s | `%s` |\n",
+				j.Name, r.Name, r.Name, strings.ReplaceAll(j.Schedule, "|", `\|`), cmp.Or(describeSchedule(j.Schedule), "—"),
+				cmp.Or(strings.Join(touches, ", "), "—"), j.Source)
+		}
+	}
+	b.WriteString("\n")
+	return os.WriteFile(filepath.Join(stagingDir, "scheduled-jobs.md"), []byte(b.String()), 0o644)
+}
@@ -0,0 +1,301 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/slo"
+)
+
+// Where a call's latency came from, most trusted first.
+const (
+	LatencyFromHint    = "hint"    // declared in central_site.latency_hints
+	LatencyFromTraces  = "traces"  // p95 of the calls in imported traces
+	LatencyFromMetrics = "metrics" // p99 from Prometheus service graph metrics
+	LatencyFromDefault = "default" // nothing known; the per-hop default
+)
+
+// defaultHopLatency is assumed for calls nothing measured or hinted at,
+// when the generator's HopLatency is unset.
+const defaultHopLatency = 50 * time.Millisecond
+
+// LatencyHint is the expected latency of one service's calls to another.
+type LatencyHint struct {
+	From    string
+	To      string
+	Latency time.Duration
+}
+
+// LatencyCall is one call in a flow's latency model.
+type LatencyCall struct {
+	From    string
+	To      string
+	Latency time.Duration
+	Source  string
+	// Async calls publish a message and don't wait for it to be handled,
+	// so they stay off the critical path.
+	Async bool
+}
+
+// LatencyPhase is one step of a flow. Phases run one after another; the
+// calls within a phase overlap when Parallel is set, and otherwise run in
+// order.
+type LatencyPhase struct {
+	Name     string
+	Parallel bool
+	Calls    []LatencyCall
+}
+
+// Duration is how long the phase holds up the flow: its slowest call when
+// parallel, the sum of its calls when sequential.
+func (p LatencyPhase) Duration() time.Duration {
+	var d time.Duration
+	for _, c := range p.Calls {
+		switch {
+		case c.Async:
+		case p.Parallel:
+			d = max(d, c.Latency)
+		default:
+			d += c.Latency
+		}
+	}
+	return d
+}
+
+// LatencyModel is the latency picture of one flow: its phases and, when
+// one is known, the end-to-end budget it must stay within.
+type LatencyModel struct {
+	Phases []LatencyPhase
+	Budget time.Duration
+	// BudgetSource says where the budget came from, e.g. "flow template"
+	// or "orders p99 SLO".
+	BudgetSource string
+}
+
+// CriticalPath returns the flow's end-to-end latency and the calls that
+// determine it: every synchronous call of a sequential phase, and the
+// slowest of a parallel one.
+func (m *LatencyModel) CriticalPath() (time.Duration, []LatencyCall) {
+	var total time.Duration
+	var path []LatencyCall
+	for _, p := range m.Phases {
+		total += p.Duration()
+		var slowest *LatencyCall
+		for i, c := range p.Calls {
+			switch {
+			case c.Async:
+			case !p.Parallel:
+				path = append(path, c)
+			case slowest == nil || c.Latency > slowest.Latency:
+				slowest = &p.Calls[i]
+			}
+		}
+		if slowest != nil {
+			path = append(path, *slowest)
+		}
+	}
+	return total, path
+}
+
+// OverBudget reports whether the critical path exceeds the budget.
+func (m *LatencyModel) OverBudget() bool {
+	total, _ := m.CriticalPath()
+	return m.Budget > 0 && total > m.Budget
+}
+
+// hopLatency is the latency assumed for calls nothing is known about.
+func (g *CentralSiteGenerator) hopLatency() time.Duration {
+	if g.HopLatency > 0 {
+		return g.HopLatency
+	}
+	return defaultHopLatency
+}
+
+// linkLatency returns the expected latency of a link, and where it came
+// from: a hint, then traces, then metrics, then the per-hop default.
+func (g *CentralSiteGenerator) linkLatency(l LinkInfo) (time.Duration, string) {
+	for _, h := range g.LatencyHints {
+		if strings.EqualFold(h.From, l.FromRepo) && strings.EqualFold(h.To, l.ToRepo) {
+			return h.Latency, LatencyFromHint
+		}
+	}
+	if l.ObservedLatencyMs > 0 {
+		return msDuration(l.ObservedLatencyMs), LatencyFromTraces
+	}
+	if g.Metrics != nil {
+		for _, e := range g.Metrics.Edges {
+			if strings.EqualFold(e.From, l.FromRepo) && strings.EqualFold(e.To, l.ToRepo) && e.LatencyMs > 0 {
+				return msDuration(e.LatencyMs), LatencyFromMetrics
+			}
+		}
+	}
+	return g.hopLatency(), LatencyFromDefault
+}
+
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Millisecond)
+}
+
+// latencyPhase models a group of calls.
+func (g *CentralSiteGenerator) latencyPhase(name string, parallel bool, calls []flowCall, displayName func(string) string) LatencyPhase {
+	p := LatencyPhase{Name: name, Parallel: parallel}
+	for _, c := range calls {
+		d, source := g.linkLatency(c.link)
+		p.Calls = append(p.Calls, LatencyCall{
+			From:    displayName(strings.ToLower(c.link.FromRepo)),
+			To:      displayName(c.to),
+			Latency: d,
+			Source:  source,
+			Async:   isAsyncLink(c.link.LinkType),
+		})
+	}
+	return p
+}
+
+// setBudget gives a flow the template's budget, or else the latency
+// target of the SLO of the service the flow starts at.
+func (g *CentralSiteGenerator) setBudget(m *LatencyModel, service string, budget time.Duration) {
+	if budget > 0 {
+		m.Budget, m.BudgetSource = budget, "flow template"
+		return
+	}
+	if s, ok := slo.ServiceLevel(g.SLOs[strings.ToLower(service)]); ok && s.Latency > 0 {
+		m.Budget = s.Latency
+		m.BudgetSource = fmt.Sprintf("%s p%g SLO", service, s.LatencyPercentile)
+	}
+}
+
+// orchestratorLatency models a flow with no template: the calls that
+// analyzeCallParallelism says must be sequential run first, in order, and
+// the rest in parallel after them.
+func (g *CentralSiteGenerator) orchestratorLatency(orch string, calls []flowCall, displayName func(string) string) *LatencyModel {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.to
+	}
+	_, sequential := g.analyzeCallParallelism(orch, names, nil)
+	isSequential := make(map[string]bool, len(sequential))
+	for _, s := range sequential {
+		isSequential[s] = true
+	}
+	var inOrder, parallel []flowCall
+	for _, c := range calls {
+		if isSequential[c.to] {
+			inOrder = append(inOrder, c)
+		} else {
+			parallel = append(parallel, c)
+		}
+	}
+	m := &LatencyModel{}
+	if len(inOrder) > 0 {
+		m.Phases = append(m.Phases, g.latencyPhase("Dependent calls", false, inOrder, displayName))
+	}
+	if len(parallel) > 0 {
+		m.Phases = append(m.Phases, g.latencyPhase("Independent calls", true, parallel, displayName))
+	}
+	g.setBudget(m, displayName(orch), 0)
+	return m
+}
+
+// chainLatency models a flow given as services in order, as corrections
+// define them: each service calls the next, one after another.
+func (g *CentralSiteGenerator) chainLatency(services []string) *LatencyModel {
+	var calls []flowCall
+	for i := 1; i < len(services); i++ {
+		link := LinkInfo{FromRepo: services[i-1], ToRepo: services[i]}
+		for _, l := range g.Links {
+			if strings.EqualFold(l.FromRepo, link.FromRepo) && strings.EqualFold(l.ToRepo, link.ToRepo) {
+				link = l
+				break
+			}
+		}
+		calls = append(calls, flowCall{to: strings.ToLower(link.ToRepo), link: link})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	m := &LatencyModel{Phases: []LatencyPhase{g.latencyPhase("", false, calls, g.repoName)}}
+	g.setBudget(m, services[0], 0)
+	return m
+}
+
+// formatLatency renders a duration to the millisecond, e.g. "230ms".
+func formatLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// writeLatencyBudget renders a flow's latency budget table on the flows
+// page: each phase, the critical path, and the budget it is held to.
+func writeLatencyBudget(b *strings.Builder, m *LatencyModel) {
+	if m == nil || len(m.Phases) == 0 {
+		return
+	}
+	total, path := m.CriticalPath()
+
+	b.WriteString("**Latency budget:**\n\n")
+	b.WriteString("| Phase | Runs | Calls | Time |\n")
+	b.WriteString("|-------|------|-------|------|\n")
+	for i, p := range m.Phases {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("Step %d", i+1)
+		}
+		runs := "—"
+		switch {
+		case len(p.Calls) < 2:
+		case p.Parallel:
+			runs = "in parallel"
+		default:
+			runs = "in order"
+		}
+		calls := make([]string, len(p.Calls))
+		for j, c := range p.Calls {
+			if c.Async {
+				calls[j] = fmt.Sprintf("%s → %s (async, off the critical path)", c.From, c.To)
+			} else {
+				calls[j] = fmt.Sprintf("%s → %s %s (%s)", c.From, c.To, formatLatency(c.Latency), c.Source)
+			}
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", name, runs, strings.Join(calls, "<br>"), formatLatency(p.Duration()))
+	}
+	steps := make([]string, len(path))
+	for i, c := range path {
+		steps[i] = c.From + " → " + c.To
+	}
+	fmt.Fprintf(b, "| **Critical path** | | %s | **%s** |\n\n", strings.Join(steps, ", then "), formatLatency(total))
+
+	switch {
+	case m.Budget == 0:
+		b.WriteString("*No latency budget: set one on the flow template, or declare a latency SLO for the service the flow starts at.*\n\n")
+	case total > m.Budget:
+		fmt.Fprintf(b, "> ⚠️ **Over budget:** the critical path takes %s, %s over the %s budget from the %s.\n\n",
+			formatLatency(total), formatLatency(total-m.Budget), formatLatency(m.Budget), m.BudgetSource)
+	default:
+		fmt.Fprintf(b, "**Budget:** %s from the %s; the critical path uses %d%% of it.\n\n",
+			formatLatency(m.Budget), m.BudgetSource, int(total*100/m.Budget))
+	}
+}
+
+// writeLatencyWarnings lists the flows whose critical path exceeds their
+// budget on the system overview.
+func (g *CentralSiteGenerator) writeLatencyWarnings(b *strings.Builder) {
+	var lines []string
+	for _, f := range g.Flows {
+		if f.Latency == nil || !f.Latency.OverBudget() {
+			continue
+		}
+		total, _ := f.Latency.CriticalPath()
+		lines = append(lines, fmt.Sprintf("- **%s**: critical path %s against a %s budget from the %s\n",
+			f.Name, formatLatency(total), formatLatency(f.Latency.Budget), f.Latency.BudgetSource))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("## Latency Budget Warnings\n\n")
+	b.WriteString("These flows' critical paths exceed their latency budgets. See [Cross-Service Flows](flows.md) for where the time goes.\n\n")
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,86 @@
+package site
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/narratives"
+)
+
+// NarrativeConflict is a regeneration that changed a section of a flow's
+// narrative someone edited in conversation.
+type NarrativeConflict struct {
+	narratives.Conflict
+	// Services are the flow's services, whose owners are told.
+	Services []string
+}
+
+// applyNarrativeEdits applies the edits made in conversation to the flows'
+// narratives. What each edit was made against is kept in the
+// NarrativeLocks file between runs, so that a regeneration changing an
+// edited section is noticed: pinned text is kept, an amendment gives way,
+// and either is added to NarrativeConflicts once.
+func (g *CentralSiteGenerator) applyNarrativeEdits() {
+	locks := make(map[string]map[string]narratives.Lock)
+	if g.NarrativeLocks != "" {
+		if data, err := os.ReadFile(g.NarrativeLocks); err == nil {
+			_ = json.Unmarshal(data, &locks)
+		}
+	}
+	if len(g.NarrativeEdits) == 0 && len(locks) == 0 {
+		return
+	}
+
+	// Audience-filtered variants generate their own narratives, so they
+	// keep their own locks, as they do their own cache entries.
+	suffix := ""
+	if g.Audience != "" {
+		suffix = " @" + g.Audience
+	}
+	used := make(map[string]bool)
+	for i, f := range g.Flows {
+		var edits []narratives.Edit
+		for _, e := range g.NarrativeEdits {
+			if strings.EqualFold(e.Flow, f.Name) {
+				edits = append(edits, e)
+			}
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		key := f.Name + suffix
+		used[key] = true
+		if locks[key] == nil {
+			locks[key] = make(map[string]narratives.Lock)
+		}
+		narrative, applied, conflicts := narratives.Apply(cmp.Or(f.Narrative, f.Description), edits, locks[key])
+		g.Flows[i].Narrative = narrative
+		described := make([]string, len(applied))
+		for j, e := range applied {
+			described[j] = e.Describe()
+		}
+		g.Flows[i].Edited = strings.Join(described, "; ")
+		for _, c := range conflicts {
+			g.NarrativeConflicts = append(g.NarrativeConflicts, NarrativeConflict{Conflict: c, Services: f.Services})
+		}
+	}
+	for key := range locks {
+		if _, audience, _ := strings.Cut(key, " @"); audience == g.Audience && !used[key] {
+			delete(locks, key)
+		}
+	}
+
+	if g.NarrativeLocks == "" {
+		return
+	}
+	data, err := json.MarshalIndent(locks, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(g.NarrativeLocks), 0o755); err == nil {
+		_ = os.WriteFile(g.NarrativeLocks, data, 0o644)
+	}
+}
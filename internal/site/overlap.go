@@ -0,0 +1,567 @@
+package site
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Similarity above which two units are taken to overlap: embeddings of
+// unrelated summaries are still fairly close, while shared words are rarer.
+const (
+	embeddingOverlap = 0.85
+	lexicalOverlap   = 0.4
+)
+
+// overlapUnit is a service, or a module of one, compared with the others
+// for duplicated functionality.
+type overlapUnit struct {
+	Service string
+	Module  string // the module's directory; empty for the whole service
+	Summary string
+	// Shapes are the endpoints it serves, with parameters and versions
+	// elided, e.g. "POST /addresses/{}/validate".
+	Shapes []string
+}
+
+// key identifies the unit, e.g. "checkout" or "checkout:internal/address".
+func (u overlapUnit) key() string {
+	if u.Module == "" {
+		return u.Service
+	}
+	return u.Service + ":" + u.Module
+}
+
+// overlapCluster is a set of units, in more than one service, whose
+// summaries and endpoints are alike enough that they likely duplicate each
+// other's functionality.
+type overlapCluster struct {
+	Name       string // the functionality, e.g. "Address validation"
+	Assessment string
+	Units      []overlapUnit
+	Similarity float64  // mean similarity of the overlapping pairs
+	Shapes     []string // endpoint shapes units in different services share
+}
+
+// services are the services the cluster's units are in, in order.
+func (c overlapCluster) services() []string {
+	var out []string
+	for _, u := range c.Units {
+		if !slices.Contains(out, u.Service) {
+			out = append(out, u.Service)
+		}
+	}
+	return out
+}
+
+// findOverlaps clusters the services, and the modules within them, whose
+// summaries are most alike, by embedding when an embedder is set and by
+// their shared words otherwise, with shared endpoint shapes raising the
+// similarity. The LLM, when set, names and assesses each cluster. Clusters
+// spanning the most services come first.
+func (g *CentralSiteGenerator) findOverlaps() []overlapCluster {
+	units := g.overlapUnits()
+	if len(units) < 2 {
+		return nil
+	}
+	texts := make([]string, len(units))
+	for i, u := range units {
+		texts[i] = u.Summary
+	}
+	lexical := tfidf(texts)
+	similarity, threshold := func(i, j int) float64 { return cosine(lexical[i], lexical[j]) }, lexicalOverlap
+	if g.Embedder != nil {
+		if vectors, err := g.Embedder.Embed(context.Background(), texts); err == nil && len(vectors) == len(texts) {
+			similarity, threshold = func(i, j int) float64 { return embeddingCosine(vectors[i], vectors[j]) }, embeddingOverlap
+		} else {
+			slog.Warn("comparing summaries by their words instead of embeddings", "phase", "overlaps", "err", err)
+		}
+	}
+
+	// Join overlapping units from different services into clusters.
+	parent := make([]int, len(units))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	type pair struct {
+		i, j  int
+		score float64
+	}
+	var pairs []pair
+	for i := range units {
+		for j := i + 1; j < len(units); j++ {
+			if units[i].Service == units[j].Service {
+				continue
+			}
+			score := similarity(i, j)
+			// Serving the same endpoints closes part of the remaining gap.
+			if len(units[i].Shapes) > 0 && len(units[j].Shapes) > 0 {
+				score += (1 - score) * 0.5 * jaccard(units[i].Shapes, units[j].Shapes)
+			}
+			if score >= threshold {
+				pairs = append(pairs, pair{i, j, score})
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range units {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], i)
+	}
+	var clusters []overlapCluster
+	for _, r := range roots {
+		if len(members[r]) < 2 {
+			continue
+		}
+		var c overlapCluster
+		for _, i := range members[r] {
+			c.Units = append(c.Units, units[i])
+		}
+		var total float64
+		var n int
+		for _, p := range pairs {
+			if find(p.i) == r {
+				total += p.score
+				n++
+			}
+		}
+		c.Similarity = total / float64(n)
+		c.Shapes = sharedShapes(c.Units)
+		c.Name, c.Assessment = g.assessOverlap(c, sharedTerms(texts, lexical, members[r]))
+		clusters = append(clusters, c)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if a, b := len(clusters[i].services()), len(clusters[j].services()); a != b {
+			return a > b
+		}
+		return clusters[i].Similarity > clusters[j].Similarity
+	})
+	return clusters
+}
+
+// overlapUnits lists the services with a summary, and their modules: the
+// directories with two or more summarized source files.
+func (g *CentralSiteGenerator) overlapUnits() []overlapUnit {
+	var units []overlapUnit
+	for _, repo := range g.Repos {
+		var shapes []string
+		routeDirs := make(map[string][]string)
+		for _, e := range g.exposure[repo.Name] {
+			s := endpointShape(e.Route)
+			if s == "" {
+				continue
+			}
+			if !slices.Contains(shapes, s) {
+				shapes = append(shapes, s)
+			}
+			file, _, _ := strings.Cut(e.Source, ":")
+			if dir := path.Dir(file); !slices.Contains(routeDirs[dir], s) {
+				routeDirs[dir] = append(routeDirs[dir], s)
+			}
+		}
+		for _, l := range g.Links {
+			if !strings.EqualFold(l.ToRepo, repo.Name) {
+				continue
+			}
+			for _, e := range l.Endpoints {
+				if s := endpointShape(e); s != "" && !slices.Contains(shapes, s) {
+					shapes = append(shapes, s)
+				}
+			}
+		}
+		if strings.TrimSpace(repo.Summary) != "" {
+			units = append(units, overlapUnit{Service: repo.Name, Summary: repo.Summary, Shapes: shapes})
+		}
+
+		analyses := g.repoAnalyses(repo)
+		paths := make([]string, 0, len(analyses))
+		for p, a := range analyses {
+			if !a.Skip && !isTestPath(p) && strings.TrimSpace(a.Summary) != "" {
+				paths = append(paths, p)
+			}
+		}
+		sort.Strings(paths)
+		byDir := make(map[string][]string)
+		var dirs []string
+		for _, p := range paths {
+			dir := path.Dir(p)
+			if dir == "." {
+				continue
+			}
+			if _, ok := byDir[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			byDir[dir] = append(byDir[dir], p)
+		}
+		var modules []overlapUnit
+		for _, dir := range dirs {
+			if len(byDir[dir]) < 2 {
+				continue
+			}
+			var summary []string
+			for _, p := range byDir[dir] {
+				summary = append(summary, strings.TrimSpace(analyses[p].Summary))
+			}
+			text := strings.Join(summary, " ")
+			if len(text) > 2000 {
+				text = strings.ToValidUTF8(text[:2000], "")
+			}
+			modules = append(modules, overlapUnit{Service: repo.Name, Module: dir, Summary: text, Shapes: routeDirs[dir]})
+		}
+		// A service with one module is compared as a whole.
+		if len(modules) > 1 {
+			units = append(units, modules...)
+		}
+	}
+	return units
+}
+
+var (
+	// shapeParam matches a path parameter, in any framework's syntax, or an
+	// ID.
+	shapeParam = regexp.MustCompile(`^(\{[^}]*\}|:\w+|<[^>]*>|\d+|[0-9a-f-]{32,36})$`)
+	// shapeSkipped are path segments that don't say what an endpoint does.
+	shapeSkipped = regexp.MustCompile(`^(api|v\d+(\.\d+)?)$`)
+	// shapeOperational are endpoints every service serves.
+	shapeOperational = regexp.MustCompile(`^/(health\w*|healthz|readyz|livez|ready|live|ping|metrics|version|status|docs|swagger.*|openapi.*)?$`)
+)
+
+// endpointShape is an endpoint such as "POST /api/v1/addresses/:id/validate"
+// with its parameters, API prefix, and version elided, so the same
+// operation matches across services: "POST /addresses/{}/validate". Health
+// checks and other endpoints every service serves give "".
+func endpointShape(endpoint string) string {
+	method, route, ok := strings.Cut(strings.TrimSpace(endpoint), " ")
+	if !ok {
+		method, route = "", method
+	}
+	route, _, _ = strings.Cut(route, "?")
+	var segments []string
+	for _, s := range strings.Split(strings.ToLower(route), "/") {
+		switch {
+		case s == "" || shapeSkipped.MatchString(s):
+		case shapeParam.MatchString(s):
+			segments = append(segments, "{}")
+		default:
+			segments = append(segments, s)
+		}
+	}
+	shape := "/" + strings.Join(segments, "/")
+	if shapeOperational.MatchString(shape) {
+		return ""
+	}
+	return strings.TrimSpace(strings.ToUpper(method) + " " + shape)
+}
+
+// sharedShapes are the endpoint shapes units in different services serve.
+func sharedShapes(units []overlapUnit) []string {
+	services := make(map[string][]string)
+	for _, u := range units {
+		for _, s := range u.Shapes {
+			if !slices.Contains(services[s], u.Service) {
+				services[s] = append(services[s], u.Service)
+			}
+		}
+	}
+	var shared []string
+	for s, in := range services {
+		if len(in) > 1 {
+			shared = append(shared, s)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// overlapStopwords are words that say little about what a unit does.
+var overlapStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "from": true, "into": true,
+	"its": true, "are": true, "was": true, "were": true, "has": true, "have": true, "such": true, "each": true,
+	"via": true, "using": true, "uses": true, "use": true, "used": true, "which": true, "when": true, "also": true,
+	"service": true, "microservice": true, "handles": true, "handle": true, "handling": true, "provides": true,
+	"provide": true, "responsible": true, "implements": true, "implementation": true, "file": true, "module": true,
+	"package": true, "contains": true, "defines": true, "function": true, "functions": true, "api": true,
+	"application": true, "system": true, "logic": true, "based": true, "including": true, "other": true,
+	"before": true, "after": true, "their": true, "them": true, "they": true, "then": true, "all": true,
+}
+
+// overlapSuffixes are stripped from words, longest first, so the forms of
+// a word share a stem: "validates", "validated", and "validation" all
+// give "valid".
+var overlapSuffixes = []string{"izations", "ization", "ations", "ation", "izes", "ized", "ize", "ates", "ated", "ate", "ing", "ies", "es", "ed", "s"}
+
+// overlapTerms splits a summary into the stems of its words, dropping
+// stopwords, so "Validates addresses" and "address validation" share
+// "address" and "valid".
+func overlapTerms(text string) []string {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len(w) < 3 || overlapStopwords[w] {
+			continue
+		}
+		for _, suffix := range overlapSuffixes {
+			stem, ok := strings.CutSuffix(w, suffix)
+			if !ok || len(stem) < 4 || strings.HasSuffix(w, "ss") {
+				continue
+			}
+			if suffix == "ies" {
+				stem += "y"
+			}
+			w = stem
+			break
+		}
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// tfidf weighs each text's words by how rare they are across the texts.
+func tfidf(texts []string) []map[string]float64 {
+	counts := make([]map[string]float64, len(texts))
+	df := make(map[string]int)
+	for i, t := range texts {
+		counts[i] = make(map[string]float64)
+		for _, w := range overlapTerms(t) {
+			if counts[i][w] == 0 {
+				df[w]++
+			}
+			counts[i][w]++
+		}
+	}
+	n := float64(len(texts))
+	for _, c := range counts {
+		for w, tf := range c {
+			c[w] = tf * math.Log(1+n/float64(df[w]))
+		}
+	}
+	return counts
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for w, x := range a {
+		dot += x * b[w]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func embeddingCosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func jaccard(a, b []string) float64 {
+	var both int
+	for _, x := range a {
+		if slices.Contains(b, x) {
+			both++
+		}
+	}
+	return float64(both) / float64(len(a)+len(b)-both)
+}
+
+// sharedTerms are the three weightiest words in more than one of the
+// members' summaries, each as the summaries first spell it.
+func sharedTerms(texts []string, weights []map[string]float64, members []int) []string {
+	in := make(map[string]int)
+	total := make(map[string]float64)
+	for _, i := range members {
+		for w, x := range weights[i] {
+			in[w]++
+			total[w] += x
+		}
+	}
+	var terms []string
+	for w, n := range in {
+		if n > 1 {
+			terms = append(terms, w)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if total[terms[i]] != total[terms[j]] {
+			return total[terms[i]] > total[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	terms = terms[:min(3, len(terms))]
+	for k, stem := range terms {
+	spelled:
+		for _, i := range members {
+			for _, w := range strings.FieldsFunc(strings.ToLower(texts[i]), func(r rune) bool { return !unicode.IsLetter(r) }) {
+				if t := overlapTerms(w); len(t) == 1 && t[0] == stem {
+					terms[k] = w
+					break spelled
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// assessOverlap names a cluster's functionality and says whether it is
+// worth consolidating: by the LLM when a provider is set, otherwise, or
+// when the call fails, from the words and endpoints its units share.
+func (g *CentralSiteGenerator) assessOverlap(c overlapCluster, terms []string) (name, assessment string) {
+	services := c.services()
+	name = "Similar functionality"
+	if len(terms) > 0 {
+		name = strings.Join(terms, ", ")
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	assessment = fmt.Sprintf("%d services describe similar functionality", len(services))
+	if len(c.Shapes) > 0 {
+		assessment += " and serve the same endpoints"
+	}
+	assessment += ". Compare them before building more of it, and consider consolidating it into one service or a shared library."
+	if g.Provider == nil {
+		return name, assessment
+	}
+
+	keys := make([]string, len(c.Units))
+	var b strings.Builder
+	b.WriteString("These services and modules of a microservice system look alike:\n\n")
+	for i, u := range c.Units {
+		keys[i] = u.key()
+		fmt.Fprintf(&b, "- %s: %s\n", u.key(), u.Summary)
+		if len(u.Shapes) > 0 {
+			fmt.Fprintf(&b, "  Endpoints: %s\n", strings.Join(u.Shapes, ", "))
+		}
+	}
+	b.WriteString("\nOn the first line, name the functionality they share in at most five words, with no other text. Then, in one short paragraph, say whether they really duplicate each other or only look alike, and if they do, how they could be consolidated.")
+	answer, ok := g.cachedCompletion("consolidation: "+strings.Join(keys, ", "), "You review the architecture of a microservice system for duplicated functionality. Be concrete and brief, and use only the services given.", b.String(), 512)
+	if !ok {
+		return name, assessment
+	}
+	first, rest, _ := strings.Cut(answer, "\n")
+	first = strings.TrimSpace(strings.Trim(strings.TrimPrefix(strings.TrimSpace(first), "Name:"), "*# "))
+	if first == "" || strings.TrimSpace(rest) == "" {
+		return name, assessment
+	}
+	return first, strings.TrimSpace(rest)
+}
+
+// overlapLabel names a unit on the consolidation page, e.g. "[checkout](checkout/index.md) `internal/address`".
+func overlapLabel(u overlapUnit, displayName string) string {
+	label := fmt.Sprintf("[%s](%s/index.md)", displayName, u.Service)
+	if u.Module != "" {
+		label += " `" + u.Module + "`"
+	}
+	return label
+}
+
+// writeConsolidationPage writes consolidation.md, the report of services
+// and modules that likely duplicate each other's functionality, as
+// candidates to consolidate.
+func (g *CentralSiteGenerator) writeConsolidationPage(stagingDir string) error {
+	displayNames := make(map[string]string, len(g.Repos))
+	for _, r := range g.Repos {
+		displayNames[r.Name] = cmp.Or(r.DisplayName, r.Name)
+	}
+	method := "the words their summaries share"
+	if g.Embedder != nil {
+		method = "embeddings of their summaries"
+	}
+
+	var b strings.Builder
+	b.WriteString("# Consolidation Candidates\n\n")
+	groups := "1 group"
+	if len(g.overlaps) != 1 {
+		groups = fmt.Sprintf("%d groups", len(g.overlaps))
+	}
+	fmt.Fprintf(&b, "%s of services and modules that likely duplicate each other's functionality, found by comparing %s and the shapes of the endpoints they serve. Each is a candidate to review, not a verdict: similar descriptions can hide real differences.\n\n", groups, method)
+	b.WriteString("| Functionality | Services | Similarity | Shared Endpoints |\n")
+	b.WriteString("|---------------|----------|------------|------------------|\n")
+	for _, c := range g.overlaps {
+		var names []string
+		for _, s := range c.services() {
+			names = append(names, fmt.Sprintf("[%s](%s/index.md)", displayNames[s], s))
+		}
+		shapes := "—"
+		if len(c.Shapes) > 0 {
+			shapes = knobSources(c.Shapes)
+		}
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %.0f%% | %s |\n", c.Name, teamSlug(c.Name), strings.Join(names, ", "), c.Similarity*100, shapes)
+	}
+	b.WriteString("\n")
+
+	for _, c := range g.overlaps {
+		fmt.Fprintf(&b, "## %s\n\n", c.Name)
+		b.WriteString(c.Assessment + "\n\n")
+		b.WriteString("| Where | Summary | Endpoints |\n")
+		b.WriteString("|-------|---------|-----------|\n")
+		for _, u := range c.Units {
+			summary := strings.Join(strings.Fields(u.Summary), " ")
+			if len(summary) > 300 {
+				summary = summary[:297] + "..."
+			}
+			endpoints := "—"
+			if len(u.Shapes) > 0 {
+				endpoints = knobSources(u.Shapes)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", overlapLabel(u, displayNames[u.Service]), strings.ReplaceAll(summary, "|", `\|`), endpoints)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "consolidation.md"), []byte(b.String()), 0o644)
+}
+
+// serviceOverlaps describes the clusters a service is in, each as its
+// functionality and the other services in it.
+func (g *CentralSiteGenerator) serviceOverlaps(service string) []string {
+	var out []string
+	for _, c := range g.overlaps {
+		services := c.services()
+		if !slices.Contains(services, service) {
+			continue
+		}
+		var others []string
+		for _, s := range services {
+			if s != service {
+				others = append(others, fmt.Sprintf("[%s](../%s/index.md)", s, s))
+			}
+		}
+		out = append(out, fmt.Sprintf("%s, with %s", c.Name, strings.Join(others, ", ")))
+	}
+	return out
+}
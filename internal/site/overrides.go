@@ -0,0 +1,134 @@
+package site
+
+import (
+	"fmt"
+	"strings"
+)
+
+// applyLinkOverrides suppresses and adds links as corrections dictate. It
+// runs after links are normalized and before flows are synthesized from
+// them, so corrected links shape the flows too.
+func (g *CentralSiteGenerator) applyLinkOverrides() {
+	for _, o := range g.Overrides {
+		if o.IsFlow() || o.IsAuth() {
+			continue
+		}
+		from, to := g.repoName(o.From), g.repoName(o.To)
+		i := -1
+		for j, l := range g.Links {
+			if strings.EqualFold(l.FromRepo, from) && strings.EqualFold(l.ToRepo, to) {
+				i = j
+				break
+			}
+		}
+		switch {
+		case o.Remove && i >= 0:
+			g.Links = append(g.Links[:i], g.Links[i+1:]...)
+		case o.Remove:
+		case i >= 0:
+			if o.LinkType != "" {
+				g.Links[i].LinkType = o.LinkType
+			}
+			if len(o.Endpoints) > 0 {
+				g.Links[i].Endpoints = o.Endpoints
+			}
+			g.Links[i].Override = o.Provenance()
+		default:
+			linkType := o.LinkType
+			if linkType == "" {
+				linkType = "http"
+			}
+			g.Links = append(g.Links, LinkInfo{
+				FromRepo:  from,
+				ToRepo:    to,
+				LinkType:  linkType,
+				Reason:    "Added by correction",
+				Endpoints: o.Endpoints,
+				Override:  o.Provenance(),
+			})
+		}
+	}
+}
+
+// applyFlowOverrides drops and (re)defines flows as corrections dictate.
+// It runs after flows are synthesized, which would otherwise replace them.
+func (g *CentralSiteGenerator) applyFlowOverrides() {
+	for _, o := range g.Overrides {
+		if !o.IsFlow() {
+			continue
+		}
+		i := -1
+		for j, f := range g.Flows {
+			if strings.EqualFold(f.Name, o.Flow) {
+				i = j
+				break
+			}
+		}
+		if o.Remove {
+			if i >= 0 {
+				g.Flows = append(g.Flows[:i], g.Flows[i+1:]...)
+			}
+			continue
+		}
+		services := make([]string, len(o.Services))
+		for j, s := range o.Services {
+			services[j] = g.repoName(s)
+		}
+		if i < 0 {
+			g.Flows = append(g.Flows, FlowInfo{Name: o.Flow})
+			i = len(g.Flows) - 1
+		}
+		g.Flows[i].Services = services
+		g.Flows[i].Override = o.Provenance()
+		g.Flows[i].Diagram = g.generateSequenceDiagram(g.Flows[i])
+		g.Flows[i].Latency = g.chainLatency(services)
+	}
+}
+
+// repoName returns the registered repo matching name case-insensitively,
+// or name itself.
+func (g *CentralSiteGenerator) repoName(name string) string {
+	for _, r := range g.Repos {
+		if strings.EqualFold(r.Name, name) {
+			return r.Name
+		}
+	}
+	return name
+}
+
+// writeCorrections lists the corrections applied to the generated graph,
+// with who made each, on the system overview. Corrections that involve a
+// service the audience can't see are left out, as are authentication
+// corrections, which the Exposure Report lists.
+func (g *CentralSiteGenerator) writeCorrections(b *strings.Builder) {
+	visible := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		visible[strings.ToLower(r.Name)] = true
+	}
+	var lines []string
+	for _, o := range g.Overrides {
+		if o.IsAuth() {
+			continue
+		}
+		services := o.Services
+		if !o.IsFlow() {
+			services = []string{o.From, o.To}
+		}
+		shown := true
+		for _, s := range services {
+			shown = shown && visible[strings.ToLower(s)]
+		}
+		if shown {
+			lines = append(lines, fmt.Sprintf("- %s — *%s*\n", o.Describe(), o.Provenance()))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("## Corrections\n\n")
+	b.WriteString("These corrections were given in conversation and override what analysis detects, on every regeneration.\n\n")
+	for _, l := range lines {
+		b.WriteString(l)
+	}
+	b.WriteString("\n")
+}
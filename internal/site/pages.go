@@ -0,0 +1,147 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pageFrontMatter is the YAML front matter of a hand-written page:
+//
+//	---
+//	title: Payments incident runbook
+//	section: payments
+//	order: -1
+//	visibility: internal
+//	---
+type pageFrontMatter struct {
+	// Title names the page in the sidebar, and heads it when the page has
+	// no heading of its own.
+	Title string `yaml:"title"`
+	// Section is the sidebar section the page goes in, e.g. "Runbooks", or
+	// a repo's name to put it beside that repo's docs; sections nest with
+	// "/". Without one, the page keeps its place in the pages directory.
+	Section string `yaml:"section"`
+	// Order sorts the page among its siblings; generated pages have order
+	// 0. A section's index page orders the section itself.
+	Order int `yaml:"order"`
+	// Visibility is who the page is published to, as for repos.
+	Visibility string `yaml:"visibility"`
+}
+
+// splitFrontMatter separates a page's front matter from its body. A page
+// without front matter is all body.
+func splitFrontMatter(content []byte) (pageFrontMatter, []byte, error) {
+	var fm pageFrontMatter
+	rest, ok := bytes.CutPrefix(content, []byte("---\n"))
+	if !ok {
+		return fm, content, nil
+	}
+	head, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		if head, ok = bytes.CutSuffix(rest, []byte("\n---")); !ok {
+			return fm, content, nil
+		}
+	}
+	if err := yaml.Unmarshal(head, &fm); err != nil {
+		return fm, nil, fmt.Errorf("parsing front matter: %w", err)
+	}
+	return fm, bytes.TrimLeft(body, "\n"), nil
+}
+
+// writeCustomPages merges the hand-written markdown in PagesDir into the
+// staging docs, placed by each page's front matter, and returns their
+// places in the sidebar. Generated pages win over hand-written ones at the
+// same path.
+func (g *CentralSiteGenerator) writeCustomPages(stagingDir string) (map[string]NavEntry, error) {
+	nav := make(map[string]NavEntry)
+	err := filepath.WalkDir(g.PagesDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return err
+		}
+		rel, err := filepath.Rel(g.PagesDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fm, body, err := splitFrontMatter(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		if !VisibleTo(fm.Visibility, g.Audience) {
+			return nil
+		}
+
+		dest := rel
+		if dir, titles := g.sectionDir(fm.Section); dir != "" {
+			dest = dir + "/" + filepath.Base(rel)
+			for d, title := range titles {
+				if _, ok := nav[d]; !ok {
+					nav[d] = NavEntry{Title: title}
+				}
+			}
+		}
+		destPath := filepath.Join(stagingDir, filepath.FromSlash(dest))
+		if _, err := os.Stat(destPath); err == nil {
+			slog.Warn("skipping custom page that would replace a generated one", "phase", "custom_pages", "path", rel, "page", dest)
+			return nil
+		}
+
+		if fm.Title != "" && !strings.HasPrefix(string(body), "# ") {
+			body = append([]byte("# "+fm.Title+"\n\n"), body...)
+		}
+		if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(destPath, body, 0o644); err != nil {
+			return err
+		}
+		nav[dest] = NavEntry{Title: fm.Title, Order: fm.Order}
+		if dir, ok := strings.CutSuffix(dest, "/index.md"); ok {
+			e := nav[dir]
+			e.Order = fm.Order
+			nav[dir] = e
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nav, nil
+}
+
+// sectionDir returns the staging directory for a section, and the titles
+// of the directories it adds to the sidebar. A section naming a repo is
+// that repo's directory.
+func (g *CentralSiteGenerator) sectionDir(section string) (string, map[string]string) {
+	titles := make(map[string]string)
+	var parts []string
+	for _, name := range strings.Split(section, "/") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		slug := teamSlug(name)
+		if len(parts) == 0 {
+			for _, repo := range g.Repos {
+				if repo.Name == name {
+					slug = repo.Name
+				}
+			}
+		}
+		parts = append(parts, slug)
+		if slug != name {
+			titles[strings.Join(parts, "/")] = name
+		}
+	}
+	return strings.Join(parts, "/"), titles
+}
@@ -0,0 +1,176 @@
+package site
+
+import "slices"
+
+// maxPathHops and maxPaths bound the paths serviceMapPaths finds: paths
+// longer than maxPathHops are left out, as are all but the maxPaths
+// shortest between any two services. pathFrontier caps the partial paths
+// followed from one service, so dense maps stay cheap to generate.
+const (
+	maxPathHops  = 6
+	maxPaths     = 5
+	pathFrontier = 5000
+)
+
+// serviceMapPaths finds the dependency paths between every pair of the
+// map's services for its route mode, keyed "from>to". Each path is the
+// indexes of its edges in edges, in order, and visits no service twice.
+// Paths between a pair are sorted shortest first.
+func serviceMapPaths(edges []serviceMapEdge) map[string][][]int {
+	out := make(map[string][]int)
+	for i, e := range edges {
+		out[e.Source] = append(out[e.Source], i)
+	}
+	paths := make(map[string][][]int)
+	for source := range out {
+		// Grow every path by one hop at a time, so the first paths found
+		// to a service are the shortest.
+		type partial struct {
+			edges []int
+			nodes []string
+		}
+		frontier := []partial{{nodes: []string{source}}}
+		for hop := 1; hop <= maxPathHops && len(frontier) > 0; hop++ {
+			var next []partial
+			for _, p := range frontier {
+				for _, i := range out[p.nodes[len(p.nodes)-1]] {
+					target := edges[i].Target
+					if slices.Contains(p.nodes, target) {
+						continue
+					}
+					q := partial{edges: append(slices.Clip(p.edges), i), nodes: append(slices.Clip(p.nodes), target)}
+					if key := source + ">" + target; len(paths[key]) < maxPaths {
+						paths[key] = append(paths[key], q.edges)
+					}
+					if len(next) < pathFrontier {
+						next = append(next, q)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+	return paths
+}
+
+// serviceMapRouteCSS styles the service map's route mode.
+const serviceMapRouteCSS = `#route-panel{position:fixed;left:16px;top:64px;width:340px;max-height:calc(100vh - 96px);overflow-y:auto;background:var(--bg2);border:1px solid var(--bd);border-radius:8px;padding:12px;font-size:13px;z-index:20}
+.route-ends{display:flex;gap:6px;margin-bottom:8px}
+.route-ends select{flex:1;min-width:0}
+#route-results p{color:var(--tx2);margin:4px 0;line-height:1.4}
+.route-path{padding:6px 4px;border-top:1px solid var(--bd);line-height:1.8}
+.route-path:hover{background:var(--bg3)}
+.route-type{color:var(--tx2);font-size:11px}
+.btn.active{border-color:var(--ac);color:var(--ac)}
+.edge.on-route{stroke:var(--ac)!important;stroke-opacity:1;stroke-width:3px}
+.off-route{opacity:0.12!important}
+`
+
+// serviceMapRouteJS is the service map's route mode: pick two services,
+// on the map or in the route panel, to highlight every path from one to
+// the other with its hop count and link types. The paths come from
+// serviceMapPaths, so the page needs no server. Route mode opens on
+// load for links like service-map.html?from=web&to=ledger.
+// Like serviceMapScaleJS it shares the map script's variables;
+// applyVisibility and drawGL dim what is off the route (onRoute).
+const serviceMapRouteJS = `
+// ===== Route mode: the paths between two services =====
+var MAX_HOPS = 6, MAX_PATHS = 5; // as serviceMapPaths finds them
+var paths = data.paths || {};
+var routeBtn = document.getElementById('route-btn');
+var routePanel = document.getElementById('route-panel');
+var routeFrom = document.getElementById('route-from'), routeTo = document.getElementById('route-to');
+var routeResults = document.getElementById('route-results');
+// onRoute holds the services and edges on the highlighted paths, or null.
+var routing = false, onRoute = null;
+function escapeHTML(s){ return String(s).replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/"/g, '&quot;'); }
+var routeOptions = data.nodes.filter(function(n){ return n.status !== 'removed'; }).sort(function(a, b){ return a.label.localeCompare(b.label); }).map(function(n){
+  return '<option value="' + escapeHTML(n.id) + '">' + escapeHTML(n.label) + '</option>';
+}).join('');
+routeFrom.innerHTML = '<option value="">From…</option>' + routeOptions;
+routeTo.innerHTML = '<option value="">To…</option>' + routeOptions;
+
+// routePaths returns the paths from one service to another that exist in
+// the chosen environment, shortest first, each as its list of edges.
+function routePaths(from, to){
+  var env = envFilter.value;
+  return (paths[from + '>' + to] || []).map(function(p){
+    return p.map(function(i){ return data.edges[i]; });
+  }).filter(function(p){
+    return p.every(function(e){ return !env || !e.environments || e.environments.indexOf(env) >= 0; });
+  });
+}
+function highlightRoute(found){
+  onRoute = {nodes: {}, edges: []};
+  found.forEach(function(p){
+    p.forEach(function(e){
+      onRoute.edges.push(e);
+      onRoute.nodes[e.source.id] = onRoute.nodes[e.target.id] = true;
+    });
+  });
+  // Open the clusters the paths run through.
+  clusters.forEach(function(c){
+    if(members[c.id].some(function(n){ return onRoute.nodes[n.id]; })) collapsed[c.id] = false;
+  });
+  applyVisibility();
+}
+function showRoute(){
+  var from = routeFrom.value, to = routeTo.value, html;
+  onRoute = null;
+  if(!routing || !from || !to || from === to){
+    routeResults.innerHTML = routing ? '<p>Pick two services here or on the map.</p>' : '';
+    applyVisibility();
+    return;
+  }
+  var found = routePaths(from, to);
+  if(!found.length){
+    html = '<p>No path from ' + escapeHTML(nodeById[from].label) + ' to ' + escapeHTML(nodeById[to].label) + ' within ' + MAX_HOPS + ' hops.</p>';
+    if(routePaths(to, from).length) html += '<p>There is one the other way; swap to see it.</p>';
+    routeResults.innerHTML = html;
+    applyVisibility();
+    return;
+  }
+  html = '<p>' + found.length + (found.length === 1 ? ' path' : ' paths') + (found.length === MAX_PATHS ? ', the shortest shown' : '') + '</p>';
+  found.forEach(function(p, i){
+    html += '<div class="route-path" data-i="' + i + '"><span class="badge">' + p.length + (p.length === 1 ? ' hop' : ' hops') + '</span> ' + escapeHTML(p[0].source.label);
+    p.forEach(function(e){ html += ' <span class="route-type">' + escapeHTML(e.linkType || 'depends') + ' →</span> ' + escapeHTML(e.target.label); });
+    html += '</div>';
+  });
+  routeResults.innerHTML = html;
+  // Hover a path to highlight it alone.
+  routeResults.querySelectorAll('.route-path').forEach(function(el){
+    el.onmouseenter = function(){ highlightRoute([found[+el.dataset.i]]); };
+    el.onmouseleave = function(){ highlightRoute(found); };
+  });
+  highlightRoute(found);
+  sim.alpha(0.3).restart();
+}
+// pickRouteEnd takes a service clicked in route mode as the start, or as
+// the end once a start is picked.
+function pickRouteEnd(id){
+  if(!routeFrom.value || routeTo.value){ routeFrom.value = id; routeTo.value = ''; }
+  else routeTo.value = id;
+  showRoute();
+}
+function setRouting(on){
+  routing = on;
+  routePanel.hidden = !on;
+  routeBtn.classList.toggle('active', on);
+  if(on){ infoPanel.classList.add('hidden'); selectedId = null; }
+  showRoute();
+}
+routeBtn.onclick = function(){ setRouting(!routing); };
+routeFrom.onchange = routeTo.onchange = showRoute;
+document.getElementById('route-swap').onclick = function(){
+  var from = routeFrom.value;
+  routeFrom.value = routeTo.value;
+  routeTo.value = from;
+  showRoute();
+};
+envFilter.addEventListener('change', function(){ if(routing) showRoute(); });
+if(nodeById[params.get('from')] && nodeById[params.get('to')]){
+  routeFrom.value = params.get('from');
+  routeTo.value = params.get('to');
+  setRouting(true);
+}
+`
@@ -0,0 +1,311 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+)
+
+// schemaFile is a schema or event definition declared in a repo's source.
+type schemaFile struct {
+	dataclass.Schema
+	File string // repo-relative
+}
+
+// sensitiveSchema is a schema whose fields carry declared data classes.
+type sensitiveSchema struct {
+	schemaFile
+	// Classes maps each class the schema carries to the fields holding it.
+	Classes map[string][]string
+}
+
+// sensitiveTopic is a topic carrying declared data classes between
+// services.
+type sensitiveTopic struct {
+	Name      string
+	Producers []string
+	Consumers []string
+	Classes   []string // in the order of g.DataClasses
+	// Schema names the schema matching the topic's name, when one decided
+	// what it carries; otherwise both ends' schemas carry the classes.
+	Schema string
+}
+
+// dataFlow is where declared data classes are: the schemas carrying them,
+// by repo name, the topics carrying them, and the classes each service
+// holds, whether in its own schemas or received from topics.
+type dataFlow struct {
+	schemas  map[string][]sensitiveSchema
+	topics   []sensitiveTopic
+	services map[string][]string // repo name → classes, in the order of g.DataClasses
+}
+
+// loadSchemas finds the schemas and event definitions in each repo's
+// analyzed files, once.
+func (g *CentralSiteGenerator) loadSchemas() {
+	if g.schemas != nil {
+		return
+	}
+	found := make([][]schemaFile, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		for _, f := range g.repoScan(g.Repos[i]) {
+			for _, s := range f.Schemas {
+				found[i] = append(found[i], schemaFile{Schema: s, File: f.Path})
+			}
+		}
+	})
+	g.schemas = make(map[string][]schemaFile)
+	for i, r := range g.Repos {
+		if len(found[i]) > 0 {
+			g.schemas[r.Name] = found[i]
+		}
+	}
+}
+
+// namedLike reports whether a schema is named like a topic: the words of
+// its name appear together in the topic's, so OrderPlaced matches
+// "orders.order-placed.v1".
+func namedLike(schema, topic string) bool {
+	name := strings.Join(dataclass.Words(schema), " ")
+	return name != "" && strings.Contains(" "+strings.Join(dataclass.Words(topic), " ")+" ", " "+name+" ")
+}
+
+// trackSensitiveData finds the schemas and event definitions in each repo
+// whose fields carry the declared data classes, and follows the classes
+// over the async links between services.
+func (g *CentralSiteGenerator) trackSensitiveData() *dataFlow {
+	if len(g.DataClasses) == 0 {
+		return nil
+	}
+	g.loadSchemas()
+	found := make([][]sensitiveSchema, len(g.Repos))
+	for i, r := range g.Repos {
+		for _, s := range g.schemas[r.Name] {
+			if classes := dataclass.Classify(s.Fields, g.DataClasses); len(classes) > 0 {
+				found[i] = append(found[i], sensitiveSchema{schemaFile: s, Classes: classes})
+			}
+		}
+	}
+
+	flow := &dataFlow{schemas: make(map[string][]sensitiveSchema), services: make(map[string][]string)}
+	held := make(map[string]map[string]bool) // repo name → classes its schemas carry
+	for i, r := range g.Repos {
+		if len(found[i]) == 0 {
+			continue
+		}
+		flow.schemas[r.Name] = found[i]
+		held[r.Name] = make(map[string]bool)
+		for _, s := range found[i] {
+			for c := range s.Classes {
+				held[r.Name][c] = true
+			}
+		}
+	}
+
+	// A topic carries what the schema named like it carries, in either
+	// end's repo; failing that, what both ends' schemas carry.
+	byName := make(map[string]*sensitiveTopic)
+	for _, l := range g.Links {
+		if !isAsyncLink(l.LinkType) {
+			continue
+		}
+		for _, topic := range l.Endpoints {
+			carried, schema := g.topicClasses(topic, flow.schemas[l.FromRepo], flow.schemas[l.ToRepo])
+			if len(carried) == 0 {
+				for _, c := range g.DataClasses {
+					if held[l.FromRepo][c.Name] && held[l.ToRepo][c.Name] {
+						carried = append(carried, c.Name)
+					}
+				}
+			}
+			if len(carried) == 0 {
+				continue
+			}
+			t, ok := byName[topic]
+			if !ok {
+				t = &sensitiveTopic{Name: topic, Schema: schema}
+				byName[topic] = t
+			}
+			if !slices.Contains(t.Producers, l.FromRepo) {
+				t.Producers = append(t.Producers, l.FromRepo)
+			}
+			if !slices.Contains(t.Consumers, l.ToRepo) {
+				t.Consumers = append(t.Consumers, l.ToRepo)
+			}
+			for _, c := range carried {
+				if !slices.Contains(t.Classes, c) {
+					t.Classes = append(t.Classes, c)
+				}
+			}
+		}
+	}
+	received := make(map[string]map[string]bool)
+	for _, t := range byName {
+		for _, consumer := range t.Consumers {
+			if received[consumer] == nil {
+				received[consumer] = make(map[string]bool)
+			}
+			for _, c := range t.Classes {
+				received[consumer][c] = true
+			}
+		}
+		flow.topics = append(flow.topics, *t)
+	}
+	sort.Slice(flow.topics, func(i, j int) bool { return flow.topics[i].Name < flow.topics[j].Name })
+
+	for _, r := range g.Repos {
+		for _, c := range g.DataClasses {
+			if held[r.Name][c.Name] || received[r.Name][c.Name] {
+				flow.services[r.Name] = append(flow.services[r.Name], c.Name)
+			}
+		}
+	}
+	if len(flow.services) == 0 {
+		return nil
+	}
+	return flow
+}
+
+// topicClasses returns the classes carried by the first schema named like
+// topic (see namedLike), and its name.
+func (g *CentralSiteGenerator) topicClasses(topic string, schemas ...[]sensitiveSchema) ([]string, string) {
+	for _, list := range schemas {
+		for _, s := range list {
+			if !namedLike(s.Name, topic) {
+				continue
+			}
+			var carried []string
+			for _, c := range g.DataClasses {
+				if _, ok := s.Classes[c.Name]; ok {
+					carried = append(carried, c.Name)
+				}
+			}
+			return carried, s.Name
+		}
+	}
+	return nil, ""
+}
+
+// classLabels renders class names with their categories.
+func (g *CentralSiteGenerator) classLabels(names []string) string {
+	labels := make([]string, len(names))
+	for i, n := range names {
+		labels[i] = n
+		for _, c := range g.DataClasses {
+			if c.Name == n {
+				labels[i] = c.Label()
+			}
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// mermaidNodeID makes a Mermaid node ID from a service or topic name.
+func mermaidNodeID(prefix, name string) string {
+	return prefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// writePIIFlowPage writes pii-flow.md: a diagram of the services and topics
+// carrying declared data classes, then, for each class, the schemas holding
+// it, as evidence for privacy and compliance reviews.
+func (g *CentralSiteGenerator) writePIIFlowPage(stagingDir string) error {
+	flow := g.dataFlow
+	var b strings.Builder
+	b.WriteString("# PII Flow\n\n")
+	fmt.Fprintf(&b, "Where the %d declared data classes are: %d services and %d topics carry them. ", len(g.DataClasses), len(flow.services), len(flow.topics))
+	b.WriteString("A service carries a class when one of its schemas or event definitions has a field holding it, or when it consumes a topic that carries it.\n\n")
+
+	b.WriteString("## Where Sensitive Data Flows\n\n")
+	b.WriteString("```mermaid\ngraph LR\n")
+	for _, r := range g.Repos {
+		if classes := flow.services[r.Name]; len(classes) > 0 {
+			fmt.Fprintf(&b, "    %s[\"%s<br/>%s\"]\n", mermaidNodeID("svc_", r.Name), r.Name, strings.Join(classes, ", "))
+		}
+	}
+	for _, t := range flow.topics {
+		fmt.Fprintf(&b, "    %s([\"%s\"])\n", mermaidNodeID("topic_", t.Name), t.Name)
+	}
+	for _, t := range flow.topics {
+		label := strings.Join(t.Classes, ", ")
+		for _, p := range t.Producers {
+			fmt.Fprintf(&b, "    %s -->|%s| %s\n", mermaidNodeID("svc_", p), label, mermaidNodeID("topic_", t.Name))
+		}
+		for _, c := range t.Consumers {
+			fmt.Fprintf(&b, "    %s --> %s\n", mermaidNodeID("topic_", t.Name), mermaidNodeID("svc_", c))
+		}
+	}
+	// Calls between services that both hold a class may pass it along.
+	for _, l := range g.Links {
+		if isAsyncLink(l.LinkType) {
+			continue
+		}
+		var shared []string
+		for _, c := range flow.services[l.FromRepo] {
+			if slices.Contains(flow.services[l.ToRepo], c) {
+				shared = append(shared, c)
+			}
+		}
+		if len(shared) > 0 {
+			fmt.Fprintf(&b, "    %s -.->|%s| %s\n", mermaidNodeID("svc_", l.FromRepo), strings.Join(shared, ", "), mermaidNodeID("svc_", l.ToRepo))
+		}
+	}
+	b.WriteString("```\n\n")
+	b.WriteString("Solid arrows are topics carrying the classes; dotted arrows are calls between services that both hold them.\n\n")
+
+	b.WriteString("## By Data Class\n\n")
+	for _, c := range g.DataClasses {
+		var rows []string
+		for _, r := range g.Repos {
+			for _, s := range flow.schemas[r.Name] {
+				if fields, ok := s.Classes[c.Name]; ok {
+					rows = append(rows, fmt.Sprintf("| [%s](%s/index.md) | `%s` (%s) | %s | `%s:%d` |", r.Name, r.Name, s.Name, s.Kind, "`"+strings.Join(fields, "`, `")+"`", s.File, s.Line))
+				}
+			}
+		}
+		var topics []string
+		for _, t := range flow.topics {
+			if slices.Contains(t.Classes, c.Name) {
+				topic := "`" + t.Name + "`"
+				if t.Schema != "" {
+					topic += " (" + t.Schema + ")"
+				}
+				topics = append(topics, topic)
+			}
+		}
+		fmt.Fprintf(&b, "### %s\n\n", c.Label())
+		if len(rows) == 0 && len(topics) == 0 {
+			fmt.Fprintf(&b, "No schema has a field matching %s.\n\n", "`"+strings.Join(c.Fields, "`, `")+"`")
+			continue
+		}
+		if len(rows) > 0 {
+			b.WriteString("| Service | Schema | Fields | Source |\n")
+			b.WriteString("|---------|--------|--------|--------|\n")
+			b.WriteString(strings.Join(rows, "\n") + "\n\n")
+		}
+		if len(topics) > 0 {
+			fmt.Fprintf(&b, "**Topics:** %s\n\n", strings.Join(topics, ", "))
+		}
+	}
+
+	b.WriteString("## Declaring Data Classes\n\n")
+	b.WriteString("Data classes are declared under `data_classes` in `.autodoc.yml`, with the field names that hold each, or in conversation, e.g. \"card numbers are PCI data, in fields like card_number or pan\".\n")
+	return os.WriteFile(filepath.Join(stagingDir, "pii-flow.md"), []byte(b.String()), 0o644)
+}
+
+// writeServiceSensitiveData renders the sensitive data section of a repo's
+// index page.
+func (g *CentralSiteGenerator) writeServiceSensitiveData(b *strings.Builder, repo RepoInfo) {
+	classes := g.dataFlow.services[repo.Name]
+	b.WriteString("## Sensitive Data\n\n")
+	fmt.Fprintf(b, "This service carries %s; see the [PII Flow](../pii-flow.md) for the schemas and topics involved.\n\n", g.classLabels(classes))
+}
@@ -0,0 +1,501 @@
+package site
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// postProcess cleans up a page's rendered markdown; see postProcessTo.
+func postProcess(content, docsDir string) string {
+	var b strings.Builder
+	if err := postProcessTo(&b, strings.NewReader(content), docsDir); err != nil {
+		return content
+	}
+	return b.String()
+}
+
+// postProcessTo streams a page's rendered markdown from r to w, cleaned up.
+// The HTML is tokenized in a single pass and split into top-level blocks;
+// each block is parsed on its own and every step rewrites its tree, so
+// LLM-written markup such as stray tags, attributes, or field labels inside
+// code can't leave the page unbalanced the way string scanning could, and
+// only the block being cleaned is held in memory rather than the page.
+// Sections the steps may drop are held until the next heading decides them.
+func postProcessTo(w io.Writer, r io.Reader, docsDir string) error {
+	c := &pageCleaner{w: w, docsDir: docsDir}
+	z := html.NewTokenizer(r)
+	var block bytes.Buffer
+	var open []string // elements open in the current block, outermost first
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			break
+		}
+		block.Write(z.Raw())
+		switch tt {
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if !isVoid(atom.Lookup(name)) {
+				open = append(open, string(name))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			// A stray end tag closes nothing; one closing an outer element
+			// closes everything inside it too.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					open = open[:i]
+					break
+				}
+			}
+		}
+		if len(open) == 0 {
+			if err := c.block(block.Bytes()); err != nil {
+				return err
+			}
+			block.Reset()
+		}
+	}
+	if block.Len() > 0 {
+		if err := c.block(block.Bytes()); err != nil {
+			return err
+		}
+	}
+	return c.flush()
+}
+
+// pageCleaner cleans and writes a page one top-level block at a time.
+type pageCleaner struct {
+	w       io.Writer
+	docsDir string
+	// held is a section that removeEmptySections or removeBrokenLinks may
+	// drop, from its heading up to the block before the next heading.
+	held *html.Node
+}
+
+// block cleans and writes one top-level block, or holds it when it opens
+// or belongs to a section that is not yet decided.
+func (c *pageCleaner) block(raw []byte) error {
+	body := newBody()
+	nodes, err := html.ParseFragment(bytes.NewReader(raw), body)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	if c.held != nil && isHeading(nodes[0]) {
+		if err := c.flush(); err != nil {
+			return err
+		}
+	}
+	if c.held == nil && len(nodes) == 1 && isDroppableSection(nodes[0]) {
+		c.held = body
+	}
+	if c.held != nil {
+		for _, n := range nodes {
+			c.held.AppendChild(n)
+		}
+		return nil
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	return c.write(body)
+}
+
+// flush cleans and writes the held section, if any.
+func (c *pageCleaner) flush() error {
+	if c.held == nil {
+		return nil
+	}
+	body := c.held
+	c.held = nil
+	return c.write(body)
+}
+
+func (c *pageCleaner) write(body *html.Node) error {
+	cleanPage(body, c.docsDir)
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(c.w, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newBody() *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+}
+
+// isVoid reports whether a is an element that has no end tag.
+func isVoid(a atom.Atom) bool {
+	switch a {
+	case atom.Area, atom.Base, atom.Br, atom.Col, atom.Embed, atom.Hr, atom.Img, atom.Input,
+		atom.Keygen, atom.Link, atom.Meta, atom.Param, atom.Source, atom.Track, atom.Wbr:
+		return true
+	}
+	return false
+}
+
+// cleanPage applies each post-processing step to a parsed page body.
+func cleanPage(body *html.Node, docsDir string) {
+	postProcessMermaid(body)
+	rewriteMDLinks(body)
+	reformatMetadataSummary(body)
+	removeEmptySections(body)
+	removeBrokenLinks(body, docsDir)
+	cleanTableSummaries(body)
+}
+
+// postProcessMermaid is a no-op. Mermaid code blocks are left as
+// <pre><code class="language-mermaid"> and converted to mermaid divs
+// by JavaScript at runtime, which correctly handles HTML entity decoding
+// via the browser's textContent API.
+func postProcessMermaid(root *html.Node) {}
+
+// rewriteMDLinks changes relative links to .md pages into links to the
+// rendered .html pages.
+func rewriteMDLinks(root *html.Node) {
+	walk(root, func(n *html.Node) {
+		if n.DataAtom != atom.A {
+			return
+		}
+		for i, a := range n.Attr {
+			if a.Key != "href" || strings.Contains(a.Val, "://") {
+				continue
+			}
+			path, frag, hasFrag := strings.Cut(a.Val, "#")
+			if !strings.HasSuffix(path, ".md") {
+				continue
+			}
+			n.Attr[i].Val = strings.TrimSuffix(path, ".md") + ".html"
+			if hasFrag {
+				n.Attr[i].Val += "#" + frag
+			}
+		}
+	})
+}
+
+// isMetadata reports whether text is the verbose "File: ... Language: ...
+// Summary: ..." block the lite-tier LLM writes instead of prose.
+func isMetadata(text string) bool {
+	return strings.Contains(text, "File:") && strings.Contains(text, "Summary:") && strings.Contains(text, "Language:")
+}
+
+// reformatMetadataSummary replaces paragraphs holding the verbose metadata
+// block with a language badge, summary, purpose, and dependency tags.
+func reformatMetadataSummary(root *html.Node) {
+	for _, p := range findAll(root, atom.P) {
+		if !isMetadata(textContent(p)) {
+			continue
+		}
+		for _, n := range formatMetadataBlock(renderInner(p)) {
+			p.Parent.InsertBefore(n, p)
+			p.Parent.InsertBefore(textNode("\n"), p)
+		}
+		p.Parent.RemoveChild(p)
+	}
+}
+
+// formatMetadataBlock turns the inner HTML of a metadata paragraph into
+// structured nodes. Summary and Purpose keep their inline markup.
+func formatMetadataBlock(text string) []*html.Node {
+	fields := parseMetadataFields(text)
+	var out []*html.Node
+
+	// Language badge
+	if lang := fragmentText(fields["Language"]); lang != "" {
+		badge := element(atom.Span, "meta-badge lang-badge", textNode(lang))
+		out = append(out, element(atom.Div, "file-meta", badge))
+	}
+
+	// Summary as main description
+	if fields["Summary"] != "" {
+		out = append(out, element(atom.P, "file-summary", parseInline(fields["Summary"])...))
+	}
+
+	// Purpose as secondary info
+	if fields["Purpose"] != "" {
+		children := append([]*html.Node{element(atom.Strong, "", textNode("Purpose:")), textNode(" ")}, parseInline(fields["Purpose"])...)
+		out = append(out, element(atom.Div, "file-purpose", children...))
+	}
+
+	// Dependencies as tag list
+	if deps := fragmentText(fields["Dependencies"]); deps != "" {
+		tags := element(atom.Div, "dep-tags")
+		for _, dep := range strings.Split(deps, ",") {
+			dep = strings.TrimSpace(dep)
+			if dep == "" {
+				continue
+			}
+			// Clean up "(import)" / "(api_call)" suffixes for display
+			name := dep
+			depType := ""
+			if parenIdx := strings.Index(dep, " ("); parenIdx > 0 {
+				name = dep[:parenIdx]
+				depType = strings.Trim(dep[parenIdx+1:], " ()")
+			}
+			typeClass := "dep-import"
+			if depType == "api_call" {
+				typeClass = "dep-api"
+			}
+			tags.AppendChild(element(atom.Span, "dep-tag "+typeClass, textNode(name)))
+		}
+		out = append(out, element(atom.Div, "file-deps", element(atom.Strong, "", textNode("Dependencies")), tags))
+	}
+
+	return out
+}
+
+// parseMetadataFields extracts key-value pairs from the verbose metadata string.
+func parseMetadataFields(text string) map[string]string {
+	fields := make(map[string]string)
+	keys := []string{"File", "Language", "Summary", "Purpose", "Dependencies"}
+
+	for i, key := range keys {
+		marker := key + ":"
+		idx := strings.Index(text, marker)
+		if idx == -1 {
+			continue
+		}
+		after := text[idx+len(marker):]
+
+		// Find where this field ends (at the next field marker or end of text)
+		end := len(after)
+		for _, nextKey := range keys[i+1:] {
+			nextMarker := nextKey + ":"
+			if j := strings.Index(after, nextMarker); j > 0 && j < end {
+				end = j
+			}
+		}
+		fields[key] = strings.TrimSpace(after[:end])
+	}
+
+	return fields
+}
+
+// removeEmptySections removes "Table of Contents" headings that have no
+// content before the next heading, as the lite tier writes them.
+func removeEmptySections(root *html.Node) {
+	for _, h := range findAll(root, atom.H2) {
+		if !isTableOfContents(h) {
+			continue
+		}
+		section := sectionAfter(h)
+		if hasContent(section) {
+			continue
+		}
+		for _, n := range append(section, h) {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// removeBrokenLinks removes a "Quick Links" section when none of its links
+// point to a page that exists in docsDir, e.g. links to architecture.html
+// when architecture.md was never generated.
+func removeBrokenLinks(root *html.Node, docsDir string) {
+	for _, h := range findAll(root, atom.H2) {
+		if !isQuickLinks(h) {
+			continue
+		}
+		section := sectionAfter(h)
+		valid := false
+		for _, n := range section {
+			for _, a := range findAll(n, atom.A) {
+				href, _, _ := strings.Cut(attr(a, "href"), "#")
+				if href == "" || strings.Contains(href, "://") {
+					continue
+				}
+				mdHref := strings.TrimSuffix(href, ".html") + ".md"
+				if _, err := os.Stat(filepath.Join(docsDir, filepath.FromSlash(mdHref))); err == nil {
+					valid = true
+				}
+			}
+		}
+		if valid {
+			continue
+		}
+		for _, n := range append(section, h) {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func isTableOfContents(h *html.Node) bool {
+	return strings.TrimSpace(textContent(h)) == "Table of Contents"
+}
+
+func isQuickLinks(h *html.Node) bool {
+	return strings.HasSuffix(strings.TrimSpace(textContent(h)), "Quick Links")
+}
+
+// isDroppableSection reports whether n heads a section that
+// removeEmptySections or removeBrokenLinks may remove.
+func isDroppableSection(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.DataAtom == atom.H2 && (isTableOfContents(n) || isQuickLinks(n))
+}
+
+// cleanTableSummaries reduces table cells holding the verbose metadata block
+// to just the Summary sentence.
+func cleanTableSummaries(root *html.Node) {
+	for _, td := range findAll(root, atom.Td) {
+		if !strings.Contains(textContent(td), "Summary:") || !strings.Contains(textContent(td), "Language:") {
+			continue
+		}
+		fields := parseMetadataFields(renderInner(td))
+		summary := fields["Summary"]
+		if summary == "" {
+			summary = fields["Purpose"]
+		}
+		var children []*html.Node
+		if summary != "" {
+			children = parseInline(summary)
+		} else {
+			text := strings.TrimSpace(textContent(td))
+			if len(text) > 200 {
+				text = truncateUTF8(text, 200) + "..."
+			}
+			children = []*html.Node{textNode(text)}
+		}
+		for td.FirstChild != nil {
+			td.RemoveChild(td.FirstChild)
+		}
+		for _, c := range children {
+			td.AppendChild(c)
+		}
+	}
+}
+
+// sectionAfter returns the siblings following heading h up to the next
+// heading.
+func sectionAfter(h *html.Node) []*html.Node {
+	var section []*html.Node
+	for n := h.NextSibling; n != nil && !isHeading(n); n = n.NextSibling {
+		section = append(section, n)
+	}
+	return section
+}
+
+// hasContent reports whether any node has non-blank content.
+func hasContent(nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if !isBlank(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlank reports whether n is whitespace or an empty paragraph.
+func isBlank(n *html.Node) bool {
+	switch {
+	case n.Type == html.TextNode:
+		return strings.TrimSpace(n.Data) == ""
+	case n.Type == html.CommentNode:
+		return true
+	case n.DataAtom == atom.P:
+		return n.FirstChild == nil || (n.FirstChild == n.LastChild && isBlank(n.FirstChild))
+	}
+	return false
+}
+
+func isHeading(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// walk calls fn for n and each of its descendants, in document order.
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// findAll returns the elements of type a under root, collected before any
+// are modified.
+func findAll(root *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	walk(root, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == a {
+			found = append(found, n)
+		}
+	})
+	return found
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	})
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func renderInner(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	return b.String()
+}
+
+// parseInline parses a fragment of a paragraph's inner HTML. Markup cut off
+// by field splitting is closed by the parser instead of leaking into the
+// rest of the page.
+func parseInline(fragment string) []*html.Node {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P})
+	if err != nil {
+		return []*html.Node{textNode(fragment)}
+	}
+	return nodes
+}
+
+// fragmentText returns the trimmed text of an HTML fragment.
+func fragmentText(fragment string) string {
+	var b strings.Builder
+	for _, n := range parseInline(fragment) {
+		b.WriteString(textContent(n))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func element(a atom.Atom, class string, children ...*html.Node) *html.Node {
+	n := &html.Node{Type: html.ElementNode, Data: a.String(), DataAtom: a}
+	if class != "" {
+		n.Attr = []html.Attribute{{Key: "class", Val: class}}
+	}
+	for _, c := range children {
+		n.AppendChild(c)
+	}
+	return n
+}
+
+func textNode(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
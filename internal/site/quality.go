@@ -0,0 +1,301 @@
+package site
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// QualityScore grades a service's generated docs out of 100, so teams can
+// see where their docs fall short and fill the gaps.
+type QualityScore struct {
+	Service string
+	Total   int
+	Checks  []QualityCheck
+}
+
+// QualityCheck is one part of a QualityScore.
+type QualityCheck struct {
+	Name   string
+	Points int
+	Max    int
+	// Gap says what would earn the missing points; empty when there are none.
+	Gap string
+}
+
+// Quality score weights; they add up to 100.
+const (
+	coverageWeight  = 30
+	purposeWeight   = 20
+	endpointsWeight = 15
+	ownershipWeight = 15
+	flowsWeight     = 20
+)
+
+// qualityBand names a score's badge color: good, fair, or poor.
+func qualityBand(score int) string {
+	switch {
+	case score >= 80:
+		return "good"
+	case score >= 50:
+		return "fair"
+	}
+	return "poor"
+}
+
+// qualityBadge is a score's badge, linking to the leaderboard at
+// leaderboard.
+func qualityBadge(score int, leaderboard string) string {
+	return fmt.Sprintf(`<a href="%s" class="quality-badge quality-%s" title="Documentation quality">%d</a>`, leaderboard, qualityBand(score), score)
+}
+
+// scoreQuality grades every repo's docs, by repo name.
+func (g *CentralSiteGenerator) scoreQuality() map[string]QualityScore {
+	owned := make(map[string]bool)
+	for _, t := range g.Teams {
+		for _, svc := range t.Services {
+			owned[svc] = true
+		}
+	}
+	inFlow := make(map[string]bool)
+	for _, f := range g.Flows {
+		for _, svc := range f.Services {
+			inFlow[svc] = true
+		}
+	}
+	called := make(map[string][]string) // repo -> endpoints other services call
+	for _, l := range g.Links {
+		called[l.ToRepo] = append(called[l.ToRepo], l.Endpoints...)
+	}
+
+	scores := make(map[string]QualityScore, len(g.Repos))
+	for _, repo := range g.Repos {
+		analyses := g.repoAnalyses(repo)
+		s := QualityScore{Service: repo.Name}
+		s.Checks = []QualityCheck{
+			coverageCheck(repo, analyses),
+			purposeCheck(repo, analyses),
+			endpointsCheck(analyses, uniqueSorted(called[repo.Name])),
+			ownershipCheck(repo, owned[repo.Name]),
+			flowsCheck(inFlow[repo.Name]),
+		}
+		for _, c := range s.Checks {
+			s.Total += c.Points
+		}
+		scores[repo.Name] = s
+	}
+	return scores
+}
+
+// points is the share of weight earned by done out of total.
+func points(weight, done, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return int(math.Round(float64(weight) * float64(done) / float64(total)))
+}
+
+// documentedFiles returns the analyses describing a file worth documenting.
+func documentedFiles(analyses map[string]indexer.FileAnalysis) []indexer.FileAnalysis {
+	var files []indexer.FileAnalysis
+	for _, a := range analyses {
+		if !a.Skip {
+			files = append(files, a)
+		}
+	}
+	return files
+}
+
+func coverageCheck(repo RepoInfo, analyses map[string]indexer.FileAnalysis) QualityCheck {
+	c := QualityCheck{Name: "Coverage", Max: coverageWeight}
+	files := documentedFiles(analyses)
+	if len(files) == 0 {
+		c.Gap = "No file analyses: run `autodoc generate` in the repo"
+		if repo.DocsDir == "" {
+			c.Gap = "No docs generated: run `autodoc generate` in the repo"
+		}
+		return c
+	}
+	var summarized, truncated int
+	for _, a := range files {
+		switch {
+		case a.Truncation != nil:
+			truncated++
+		case strings.TrimSpace(a.Summary) != "":
+			summarized++
+		}
+	}
+	c.Points = points(c.Max, summarized, len(files))
+	var gaps []string
+	if missing := len(files) - summarized - truncated; missing > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d of %d files have no summary", missing, len(files)))
+	}
+	if truncated > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d files were too large to analyze in full", truncated))
+	}
+	c.Gap = strings.Join(gaps, "; ")
+	return c
+}
+
+func purposeCheck(repo RepoInfo, analyses map[string]indexer.FileAnalysis) QualityCheck {
+	c := QualityCheck{Name: "Purpose", Max: purposeWeight}
+	half := c.Max / 2
+	var gaps []string
+	if repo.Summary != "" || repo.Facts["purpose"] != "" || repo.Facts["description"] != "" {
+		c.Points += half
+	} else {
+		gaps = append(gaps, "No service summary: tell the context engine what the service is for")
+	}
+	files := documentedFiles(analyses)
+	withPurpose := 0
+	for _, a := range files {
+		if strings.TrimSpace(a.Purpose) != "" {
+			withPurpose++
+		}
+	}
+	c.Points += points(c.Max-half, withPurpose, len(files))
+	if len(files) == 0 {
+		gaps = append(gaps, "No file analyses to state the files' purposes")
+	} else if missing := len(files) - withPurpose; missing > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d of %d files have no stated purpose", missing, len(files)))
+	}
+	c.Gap = strings.Join(gaps, "; ")
+	return c
+}
+
+// endpointsCheck scores how many of the endpoints other services call the
+// service's own docs mention. A service nobody calls loses nothing.
+func endpointsCheck(analyses map[string]indexer.FileAnalysis, endpoints []string) QualityCheck {
+	c := QualityCheck{Name: "Endpoints", Max: endpointsWeight}
+	var text strings.Builder
+	for _, a := range analyses {
+		text.WriteString(a.Summary + "\n" + a.Purpose + "\n" + strings.Join(a.KeyLogic, "\n") + "\n")
+		for _, f := range a.Functions {
+			text.WriteString(f.Name + "\n" + f.Signature + "\n" + f.Summary + "\n")
+		}
+	}
+	docs := text.String()
+
+	var keyed, documented int
+	var undocumented []string
+	for _, ep := range endpoints {
+		key := endpointKey(ep)
+		if key == "" {
+			continue
+		}
+		keyed++
+		if strings.Contains(docs, key) {
+			documented++
+		} else {
+			undocumented = append(undocumented, "`"+ep+"`")
+		}
+	}
+	if keyed == 0 {
+		c.Points = c.Max
+		return c
+	}
+	c.Points = points(c.Max, documented, keyed)
+	if len(undocumented) > 0 {
+		c.Gap = "Called but not documented: " + strings.Join(undocumented, ", ")
+	}
+	return c
+}
+
+// endpointKey is what a service's docs would mention of an endpoint: an
+// HTTP path up to its first parameter, a gRPC method's name, or a topic.
+func endpointKey(endpoint string) string {
+	fields := strings.Fields(endpoint)
+	if len(fields) == 0 {
+		return ""
+	}
+	key := fields[len(fields)-1]
+	if strings.HasPrefix(key, "/") {
+		if i := strings.IndexAny(key, "{:"); i >= 0 {
+			key = key[:i]
+		}
+		key = strings.TrimRight(key, "/")
+		return key
+	}
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		key = key[i+1:]
+	}
+	return key
+}
+
+func ownershipCheck(repo RepoInfo, owned bool) QualityCheck {
+	c := QualityCheck{Name: "Ownership", Max: ownershipWeight}
+	if owned || repo.Facts["owner"] != "" || repo.OnCall != nil {
+		c.Points = c.Max
+		return c
+	}
+	c.Gap = "No owner known: tell the context engine which team owns the service"
+	return c
+}
+
+func flowsCheck(inFlow bool) QualityCheck {
+	c := QualityCheck{Name: "Flows", Max: flowsWeight}
+	if inFlow {
+		c.Points = c.Max
+		return c
+	}
+	c.Gap = "In no documented flow: tell the context engine which flows the service takes part in"
+	return c
+}
+
+// writeQualityPage writes the documentation quality leaderboard: services
+// ranked by score, and what each could do to improve it.
+func (g *CentralSiteGenerator) writeQualityPage(stagingDir string) error {
+	ranked := make([]QualityScore, 0, len(g.quality))
+	for _, s := range g.quality {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Total != ranked[j].Total {
+			return ranked[i].Total > ranked[j].Total
+		}
+		return ranked[i].Service < ranked[j].Service
+	})
+
+	var b strings.Builder
+	b.WriteString("# Documentation Quality\n\n")
+	fmt.Fprintf(&b, "Each service's docs are scored out of 100: file coverage (%d), stated purpose (%d), endpoints other services call (%d), known ownership (%d), and taking part in a flow (%d). Most gaps can be filled by telling the context engine what it doesn't know; the score updates when the site is next generated.\n\n",
+		coverageWeight, purposeWeight, endpointsWeight, ownershipWeight, flowsWeight)
+
+	b.WriteString("| Rank | Service | Score |")
+	for _, c := range ranked[0].Checks {
+		fmt.Fprintf(&b, " %s |", c.Name)
+	}
+	b.WriteString("\n|------|---------|-------|")
+	for range ranked[0].Checks {
+		b.WriteString("------|")
+	}
+	b.WriteString("\n")
+	for i, s := range ranked {
+		fmt.Fprintf(&b, "| %d | [%s](%s/index.md) | %s |", i+1, s.Service, s.Service, qualityBadge(s.Total, "#"+teamSlug(s.Service)))
+		for _, c := range s.Checks {
+			fmt.Fprintf(&b, " %d/%d |", c.Points, c.Max)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## How to Improve\n")
+	for _, s := range ranked {
+		fmt.Fprintf(&b, "\n### %s {#%s}\n\n", s.Service, teamSlug(s.Service))
+		gaps := 0
+		for _, c := range s.Checks {
+			if c.Gap != "" {
+				fmt.Fprintf(&b, "- **%s** (%d/%d): %s\n", c.Name, c.Points, c.Max, c.Gap)
+				gaps++
+			}
+		}
+		if gaps == 0 {
+			b.WriteString("Nothing to improve.\n")
+		}
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "quality.md"), []byte(b.String()), 0o644)
+}
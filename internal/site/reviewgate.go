@@ -0,0 +1,55 @@
+package site
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gatePages puts the generated pages in stagingDir through the review
+// gate, leaving the content to publish for each: what was last approved,
+// or nothing for a page never approved. Pages still awaiting review are
+// listed in Pending.
+func (g *CentralSiteGenerator) gatePages(stagingDir string) error {
+	pages := make(map[string]string)
+	err := filepath.Walk(stagingDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(p, ".md") {
+			return err
+		}
+		rel, err := filepath.Rel(stagingDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		pages[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading pages: %w", err)
+	}
+
+	publish, pending, err := g.Review.Gate(context.Background(), g.Audience, pages)
+	if err != nil {
+		return err
+	}
+	g.Pending = pending
+	for rel, content := range pages {
+		p := filepath.Join(stagingDir, filepath.FromSlash(rel))
+		approved, ok := publish[rel]
+		switch {
+		case !ok:
+			err = os.Remove(p)
+		case approved != content:
+			err = os.WriteFile(p, []byte(approved), 0o644)
+		}
+		if err != nil {
+			return fmt.Errorf("holding back %s: %w", rel, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package site
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/vectordb"
+)
+
+// Search scope kinds accepted by /api/search.
+const (
+	ScopeGlobal    = "global"
+	ScopeRepo      = "repo"
+	ScopeDirectory = "directory"
+	ScopeTags      = "tags"
+)
+
+// scopeOverfetch is how many extra candidates are fetched per requested
+// result when results are filtered after the vector query.
+const scopeOverfetch = 5
+
+// SearchScope narrows a search to part of the documentation. The zero value
+// searches everything.
+type SearchScope struct {
+	Kind      string   `json:"kind,omitempty"`
+	Repo      string   `json:"repo,omitempty"`      // repo name, or top-level directory on single-repo sites
+	Directory string   `json:"directory,omitempty"` // directory path; on central sites it starts with the repo name
+	Tags      []string `json:"tags,omitempty"`      // document types or languages, e.g. "decision", "Go"
+}
+
+// Validate checks that the scope kind is known and has the field it needs.
+func (sc SearchScope) Validate() error {
+	switch sc.Kind {
+	case "", ScopeGlobal:
+		return nil
+	case ScopeRepo:
+		if sc.Repo == "" {
+			return fmt.Errorf("repo scope requires repo")
+		}
+	case ScopeDirectory:
+		if strings.Trim(sc.Directory, "/") == "" {
+			return fmt.Errorf("directory scope requires directory")
+		}
+	case ScopeTags:
+		if len(sc.Tags) == 0 {
+			return fmt.Errorf("tags scope requires at least one tag")
+		}
+	default:
+		return fmt.Errorf("unknown scope %q", sc.Kind)
+	}
+	return nil
+}
+
+// Matches reports whether a document falls inside the scope.
+func (sc SearchScope) Matches(doc vectordb.Document) bool {
+	meta := doc.Metadata
+	switch sc.Kind {
+	case ScopeRepo:
+		return meta.RepoID == sc.Repo || hasPathPrefix(meta.FilePath, sc.Repo)
+	case ScopeDirectory:
+		dir := strings.Trim(sc.Directory, "/")
+		if hasPathPrefix(meta.FilePath, dir) {
+			return true
+		}
+		return meta.RepoID != "" && hasPathPrefix(path.Join(meta.RepoID, meta.FilePath), dir)
+	case ScopeTags:
+		for _, tag := range sc.Tags {
+			if strings.EqualFold(tag, string(meta.Type)) || strings.EqualFold(tag, meta.Language) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// searchScoped runs a semantic search restricted to the scope. Repo scopes
+// use the store's repo filter when documents carry a repo ID; other scopes
+// over-fetch and filter the candidates.
+func searchScoped(ctx context.Context, store vectordb.VectorStore, query string, limit int, scope SearchScope) ([]vectordb.SearchResult, error) {
+	switch scope.Kind {
+	case "", ScopeGlobal:
+		return store.Search(ctx, query, limit, nil)
+	case ScopeRepo:
+		repo := scope.Repo
+		results, err := store.Search(ctx, query, limit, &vectordb.SearchFilter{RepoID: &repo})
+		if err != nil || len(results) > 0 {
+			return results, err
+		}
+	}
+
+	candidates, err := store.Search(ctx, query, limit*scopeOverfetch, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []vectordb.SearchResult
+	for _, r := range candidates {
+		if scope.Matches(r.Document) {
+			results = append(results, r)
+			if len(results) >= limit {
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// hasPathPrefix reports whether p is dir or lies under it.
+func hasPathPrefix(p, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -3,9 +3,13 @@ package site
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // SearchEntry represents a single searchable page in the documentation.
@@ -16,8 +20,29 @@ type SearchEntry struct {
 	Content string `json:"content"`
 }
 
-// BuildSearchIndex reads all .md files under docsDir and builds a search index.
+// SearchBudget bounds the size of the search index. Titles, summaries, and
+// paths are always kept; only page content is truncated.
+type SearchBudget struct {
+	MaxEntryContent int // content bytes kept per page
+	MaxTotalContent int // content bytes across the whole index
+}
+
+// DefaultSearchBudget keeps the index small enough to ship to browsers even
+// for central sites with hundreds of repos.
+var DefaultSearchBudget = SearchBudget{
+	MaxEntryContent: 2000,
+	MaxTotalContent: 16 << 20,
+}
+
+// BuildSearchIndex reads all .md files under docsDir and builds a search index
+// within DefaultSearchBudget.
 func BuildSearchIndex(docsDir string) ([]SearchEntry, error) {
+	return BuildSearchIndexWithBudget(docsDir, DefaultSearchBudget)
+}
+
+// BuildSearchIndexWithBudget reads all .md files under docsDir and builds a
+// search index whose content fits the given budget.
+func BuildSearchIndexWithBudget(docsDir string, budget SearchBudget) ([]SearchEntry, error) {
 	var entries []SearchEntry
 
 	err := filepath.Walk(docsDir, func(path string, info os.FileInfo, err error) error {
@@ -38,11 +63,63 @@ func BuildSearchIndex(docsDir string) ([]SearchEntry, error) {
 		if err != nil {
 			return err
 		}
+		if budget.MaxEntryContent > 0 {
+			entry.Content = truncateUTF8(entry.Content, budget.MaxEntryContent)
+		}
 		entries = append(entries, entry)
 		return nil
 	})
+	if err != nil {
+		return entries, err
+	}
+
+	applyContentBudget(entries, budget.MaxTotalContent)
+	return entries, nil
+}
+
+// applyContentBudget truncates entry content so the total stays within
+// maxTotal bytes. Every entry gets the same cap, so short pages keep their
+// full content and only the longest pages are shortened.
+func applyContentBudget(entries []SearchEntry, maxTotal int) {
+	if maxTotal <= 0 {
+		return
+	}
+	lengths := make([]int, len(entries))
+	total := 0
+	for i, e := range entries {
+		lengths[i] = len(e.Content)
+		total += lengths[i]
+	}
+	if total <= maxTotal {
+		return
+	}
+
+	// Find the largest per-entry cap whose total fits the budget.
+	sort.Ints(lengths)
+	limit, remaining := 0, maxTotal
+	for i, l := range lengths {
+		left := len(lengths) - i
+		if l*left > remaining {
+			limit = remaining / left
+			break
+		}
+		remaining -= l
+	}
 
-	return entries, err
+	for i := range entries {
+		entries[i].Content = truncateUTF8(entries[i].Content, limit)
+	}
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
 
 // parseMarkdownForSearch extracts title, summary, and content from a markdown file.
@@ -111,9 +188,6 @@ func parseMarkdownForSearch(filePath, relPath string) (SearchEntry, error) {
 			}
 		}
 	}
-	if len(content) > 2000 {
-		content = content[:2000]
-	}
 	entry.Content = content
 
 	if entry.Title == "" {
@@ -131,3 +205,135 @@ func WriteSearchIndex(entries []SearchEntry, outputPath string) error {
 	}
 	return os.WriteFile(outputPath, data, 0o644)
 }
+
+// searchShardThreshold is the index size above which the index is split into
+// per-repo shards instead of a single search-index.json.
+const searchShardThreshold = 1 << 20
+
+// rootShard holds pages that are not inside a top-level directory.
+const rootShard = "_root"
+
+// SearchShard describes one file of a sharded search index.
+type SearchShard struct {
+	Name    string `json:"name"` // top-level directory (repo) the shard covers
+	File    string `json:"file"` // path relative to the site root
+	Entries int    `json:"entries"`
+	Bytes   int    `json:"bytes"`
+}
+
+// SearchManifest lists the search index shards so the browser can load them
+// on demand. It is written to search-manifest.json.
+type SearchManifest struct {
+	Shards []SearchShard `json:"shards"`
+}
+
+// WriteShardedSearchIndex writes the search index under outputDir along with
+// search-manifest.json. When force is false and the index is below the shard
+// threshold, it is written as a single search-index.json; otherwise each
+// top-level directory (each repo on a central site) gets its own shard in
+// search/.
+func WriteShardedSearchIndex(entries []SearchEntry, outputDir string, force bool) (*SearchManifest, error) {
+	all, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shards or a single index left by an earlier build would otherwise
+	// still be served, with pages since removed or restricted.
+	if err := removeSearchShards(outputDir); err != nil {
+		return nil, err
+	}
+
+	manifest := &SearchManifest{}
+	if !force && len(all) < searchShardThreshold {
+		if err := WriteSearchIndex(entries, filepath.Join(outputDir, "search-index.json")); err != nil {
+			return nil, err
+		}
+		manifest.Shards = []SearchShard{{Name: "all", File: "search-index.json", Entries: len(entries), Bytes: len(all)}}
+		return manifest, writeSearchManifest(manifest, outputDir)
+	}
+
+	groups := make(map[string][]SearchEntry)
+	for _, e := range entries {
+		name := shardName(e.Path)
+		groups[name] = append(groups[name], e)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := os.Remove(filepath.Join(outputDir, "search-index.json")); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	shardDir := filepath.Join(outputDir, "search")
+	if err := os.MkdirAll(shardDir, 0o755); err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		data, err := json.Marshal(groups[name])
+		if err != nil {
+			return nil, err
+		}
+		file := fmt.Sprintf("search/%03d-%s.json", i, safeShardFile(name))
+		if err := os.WriteFile(filepath.Join(outputDir, filepath.FromSlash(file)), data, 0o644); err != nil {
+			return nil, err
+		}
+		manifest.Shards = append(manifest.Shards, SearchShard{Name: name, File: file, Entries: len(groups[name]), Bytes: len(data)})
+	}
+	return manifest, writeSearchManifest(manifest, outputDir)
+}
+
+// shardFileRe matches the files WriteShardedSearchIndex writes in search/.
+var shardFileRe = regexp.MustCompile(`^[0-9]{3}-.*\.json$`)
+
+// removeSearchShards deletes the shard files of an earlier build. Only
+// shard files are removed, since on a central site a repo named "search"
+// has its pages in the same directory.
+func removeSearchShards(outputDir string) error {
+	entries, err := os.ReadDir(filepath.Join(outputDir, "search"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() && shardFileRe.MatchString(e.Name()) {
+			if err := os.Remove(filepath.Join(outputDir, "search", e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeSearchManifest(manifest *SearchManifest, outputDir string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "search-manifest.json"), data, 0o644)
+}
+
+// shardName returns the shard a page belongs to: its top-level directory.
+func shardName(pagePath string) string {
+	if i := strings.Index(pagePath, "/"); i > 0 {
+		return pagePath[:i]
+	}
+	return rootShard
+}
+
+// safeShardFile maps a shard name to a file-name-safe form.
+func safeShardFile(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
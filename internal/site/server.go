@@ -9,30 +9,33 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
 	"github.com/ziadkadry99/auto-doc/internal/llm"
 	"github.com/ziadkadry99/auto-doc/internal/vectordb"
 )
 
+// AccessFilter reports whether the request's user may see a repo's docs.
+// A nil filter allows everything.
+type AccessFilter func(r *http.Request, repo string) bool
+
 // Serve starts a local HTTP file server for the static site.
 // If store is non-nil, an /api/search endpoint is available for semantic search.
 // If llmProvider is non-nil, search results include LLM-synthesized answers.
 func Serve(dir string, port int, open bool, store vectordb.VectorStore, llmProvider llm.Provider, model string) error {
-	addr := fmt.Sprintf(":%d", port)
-	url := fmt.Sprintf("http://localhost:%d", port)
-
-	if open {
-		go openBrowser(url)
-	}
-
-	fmt.Printf("Serving documentation at %s\n", url)
-	fmt.Println("Press Ctrl+C to stop.")
+	return ServeHandler(NewHandler(dir, store, llmProvider, model, nil, nil), port, open)
+}
 
+// NewHandler returns the handler for the static site and, when store is
+// non-nil, the /api/search endpoint. Answers to questions also draw on facts
+// when it is non-nil. Search results and facts from repos that access
+// rejects are left out.
+func NewHandler(dir string, store vectordb.VectorStore, llmProvider llm.Provider, model string, facts contextengine.FactSource, access AccessFilter) http.Handler {
 	mux := http.NewServeMux()
 
 	// API endpoint for semantic search.
 	if store != nil {
 		mux.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
-			handleSearch(w, r, store, llmProvider, model)
+			handleSearch(w, r, store, llmProvider, model, facts, access)
 		})
 	}
 
@@ -40,33 +43,78 @@ func Serve(dir string, port int, open bool, store vectordb.VectorStore, llmProvi
 	fs := http.FileServer(http.Dir(dir))
 	mux.Handle("/", fs)
 
-	return http.ListenAndServe(addr, mux)
+	return mux
+}
+
+// ServeHandler serves h on the given port until the server stops.
+func ServeHandler(h http.Handler, port int, open bool) error {
+	addr := fmt.Sprintf(":%d", port)
+	url := fmt.Sprintf("http://localhost:%d", port)
+
+	if open {
+		go openBrowser(url)
+	}
+
+	fmt.Printf("Serving documentation at %s\n", url)
+	fmt.Println("Press Ctrl+C to stop.")
+
+	return http.ListenAndServe(addr, h)
 }
 
 // searchRequest is the JSON body for the /api/search endpoint.
 type searchRequest struct {
-	Query string `json:"query"`
-	Limit int    `json:"limit,omitempty"`
+	Query string      `json:"query"`
+	Limit int         `json:"limit,omitempty"`
+	Scope SearchScope `json:"scope,omitempty"`
 }
 
 // searchResponse is the JSON response for the /api/search endpoint.
 type searchResponse struct {
-	Answer  string               `json:"answer,omitempty"`
-	Results []searchResponseItem `json:"results"`
+	Answer string `json:"answer,omitempty"`
+	// Citations are the numbers of the results the answer cites, in the
+	// order it first cites them.
+	Citations []int                `json:"citations,omitempty"`
+	Scope     SearchScope          `json:"scope"`
+	Results   []searchResponseItem `json:"results"`
 }
 
 // searchResponseItem is one result in the /api/search response.
 type searchResponseItem struct {
 	FilePath   string  `json:"file_path"`
+	RepoID     string  `json:"repo_id,omitempty"`
 	Symbol     string  `json:"symbol,omitempty"`
 	Type       string  `json:"type"`
 	Language   string  `json:"language,omitempty"`
 	Similarity float64 `json:"similarity"`
 	Content    string  `json:"content"`
 	LineStart  int     `json:"line_start,omitempty"`
+	LineEnd    int     `json:"line_end,omitempty"`
+	Anchor     string  `json:"anchor,omitempty"` // heading ID of the symbol's section on the doc page
 }
 
-func handleSearch(w http.ResponseWriter, r *http.Request, store vectordb.VectorStore, llmProvider llm.Provider, model string) {
+// sectionAnchor returns the heading ID the site gives a function or type
+// section, so citations can link straight to it. It mirrors goldmark's
+// automatic heading IDs. Other document types link to the top of the page.
+func sectionAnchor(meta vectordb.DocumentMetadata) string {
+	if meta.Symbol == "" || (meta.Type != vectordb.DocTypeFunction && meta.Type != vectordb.DocTypeClass) {
+		return ""
+	}
+	var b strings.Builder
+	for i := 0; i < len(meta.Symbol); i++ {
+		c := meta.Symbol[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(c + 'a' - 'A')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case c == ' ', c == '-', c == '_':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+func handleSearch(w http.ResponseWriter, r *http.Request, store vectordb.VectorStore, llmProvider llm.Provider, model string, facts contextengine.FactSource, access AccessFilter) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != http.MethodPost {
@@ -86,17 +134,28 @@ func handleSearch(w http.ResponseWriter, r *http.Request, store vectordb.VectorS
 		return
 	}
 
+	if err := req.Scope.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	if req.Scope.Kind == "" {
+		req.Scope.Kind = ScopeGlobal
+	}
+
 	limit := req.Limit
 	if limit <= 0 || limit > 20 {
 		limit = 8
 	}
 
 	ctx := context.Background()
-	results, err := store.Search(ctx, query, limit, nil)
+	results, err := searchScoped(ctx, store, query, limit, req.Scope)
 	if err != nil {
 		http.Error(w, fmt.Sprintf(`{"error":"search failed: %s"}`, err.Error()), http.StatusInternalServerError)
 		return
 	}
+	if access != nil {
+		results = filterByAccess(r, results, access)
+	}
 
 	items := make([]searchResponseItem, len(results))
 	for i, r := range results {
@@ -106,31 +165,67 @@ func handleSearch(w http.ResponseWriter, r *http.Request, store vectordb.VectorS
 		}
 		items[i] = searchResponseItem{
 			FilePath:   r.Document.Metadata.FilePath,
+			RepoID:     r.Document.Metadata.RepoID,
 			Symbol:     r.Document.Metadata.Symbol,
 			Type:       string(r.Document.Metadata.Type),
 			Language:   r.Document.Metadata.Language,
 			Similarity: float64(r.Similarity),
 			Content:    content,
 			LineStart:  r.Document.Metadata.LineStart,
+			LineEnd:    r.Document.Metadata.LineEnd,
+			Anchor:     sectionAnchor(r.Document.Metadata),
 		}
 	}
 
-	resp := searchResponse{Results: items}
+	resp := searchResponse{Scope: req.Scope, Results: items}
 
-	// Synthesize an LLM answer if provider is available.
-	if llmProvider != nil && len(results) > 0 {
-		answer := synthesizeAnswer(ctx, llmProvider, model, query, results)
-		if answer != "" {
-			resp.Answer = answer
+	// Synthesize an LLM answer if provider is available. Questions about the
+	// architecture also draw on the facts the team has recorded, as the MCP
+	// ask_architecture tool does, so they can be answered without results.
+	if llmProvider != nil {
+		var known string
+		if facts != nil && isQuestion(query) {
+			known = formatFacts(ctx, r, facts, access)
+		}
+		if len(results) > 0 || known != "" {
+			if answer := synthesizeAnswer(ctx, llmProvider, model, query, results, known); answer != "" {
+				resp.Answer = answer
+				resp.Citations = citedResults(answer, len(results))
+			}
 		}
 	}
 
 	json.NewEncoder(w).Encode(resp)
 }
 
-// synthesizeAnswer sends the query and search results to the LLM for a coherent answer.
-func synthesizeAnswer(ctx context.Context, provider llm.Provider, model string, query string, results []vectordb.SearchResult) string {
+// filterByAccess drops results from repos the user may not see. Documents
+// without a repo ID belong to the repo named by their first path segment.
+func filterByAccess(r *http.Request, results []vectordb.SearchResult, access AccessFilter) []vectordb.SearchResult {
+	kept := results[:0]
+	for _, res := range results {
+		repo := res.Document.Metadata.RepoID
+		if repo == "" {
+			if before, _, ok := strings.Cut(res.Document.Metadata.FilePath, "/"); ok {
+				repo = before
+			}
+		}
+		if repo == "" || access(r, repo) {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
+
+// synthesizeAnswer sends the query, search results, and any recorded
+// architecture facts to the LLM for a coherent answer.
+func synthesizeAnswer(ctx context.Context, provider llm.Provider, model string, query string, results []vectordb.SearchResult, facts string) string {
 	resultsContext := vectordb.FormatResults(results)
+	if len(results) == 0 {
+		resultsContext = "(No documentation excerpts matched.)\n"
+	}
+	if facts != "" {
+		resultsContext += "\nFacts the team has recorded about the architecture (these have no result number; cite only the numbered excerpts):\n\n" + facts
+	}
 
 	prompt := fmt.Sprintf(`A developer is exploring documentation for this codebase and asked: "%s"
 
@@ -144,7 +239,8 @@ Write a thorough, comprehensive answer that:
 3. References specific file paths in backticks (e.g. `+"`"+`path/to/file.go`+"`"+`).
 4. Never cut off a list — always complete it. If there are 4 items, name all 4.
 5. Include implementation details: function names, data flow, protocols, and configuration.
-6. For failure analysis / SPOF / "what if X goes down" questions: clearly distinguish between COMPLETE OUTAGE (system unusable), NEAR-COMPLETE OUTAGE (most features broken), HIGH BLAST RADIUS (many features affected), and DEGRADED (specific features lost). Not every service is a SPOF — be precise about severity levels.
+6. Cites its sources inline by result number, e.g. [1] or [2][3], right after the claim they support.
+7. For failure analysis / SPOF / "what if X goes down" questions: clearly distinguish between COMPLETE OUTAGE (system unusable), NEAR-COMPLETE OUTAGE (most features broken), HIGH BLAST RADIUS (many features affected), and DEGRADED (specific features lost). Not every service is a SPOF — be precise about severity levels.
 
 Your answer MUST be at least 200 words. Be thorough and detailed.
 Be factual and grounded in the provided context.`, query, resultsContext)
@@ -178,7 +274,7 @@ Context:
 Previous answer (too short):
 %s
 
-Write a much more detailed answer — at least 200 words. Include all relevant file paths, function names, data flow details, architectural context, and implementation specifics. Do not summarize — be comprehensive.`, query, resultsContext, answer)
+Write a much more detailed answer — at least 200 words. Cite sources inline by result number, e.g. [1]. Include all relevant file paths, function names, data flow details, architectural context, and implementation specifics. Do not summarize — be comprehensive.`, query, resultsContext, answer)
 
 		retryResp, retryErr := provider.Complete(ctx, llm.CompletionRequest{
 			Model: model,
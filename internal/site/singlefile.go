@@ -0,0 +1,303 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DiagramRasterizer renders Mermaid source to a PNG image.
+type DiagramRasterizer interface {
+	Rasterize(ctx context.Context, source string) ([]byte, error)
+}
+
+// SinglePage renders a docs directory into one self-contained HTML
+// document, for audits and offline sharing: the pages in sidebar order,
+// links between them turned into links within the document, the stylesheet
+// and images inlined, and Mermaid diagrams rasterized into embedded PNGs.
+// Unlike the site, it loads nothing from the network.
+type SinglePage struct {
+	DocsDir     string
+	ProjectName string
+	// Diagrams rasterizes Mermaid diagrams; when nil, or when a diagram
+	// fails to render, the diagram's source is shown instead.
+	Diagrams DiagramRasterizer
+	// Unrendered is filled in by Render with the diagrams left as source,
+	// and why.
+	Unrendered []error
+}
+
+// singlePageSection is one documentation page within the document.
+type singlePageSection struct {
+	ID      string
+	Title   string
+	Content template.HTML
+}
+
+// Render returns the document.
+func (p *SinglePage) Render(ctx context.Context) ([]byte, error) {
+	var mdPaths []string
+	err := filepath.Walk(p.DocsDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(file, ".md") {
+			rel, err := filepath.Rel(p.DocsDir, file)
+			if err != nil {
+				return err
+			}
+			mdPaths = append(mdPaths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking docs dir: %w", err)
+	}
+	if len(mdPaths) == 0 {
+		return nil, fmt.Errorf("no markdown files found in %s", p.DocsDir)
+	}
+
+	contents := make(map[string][]byte, len(mdPaths))
+	titleMap := make(map[string]string, len(mdPaths))
+	for _, rel := range mdPaths {
+		data, err := os.ReadFile(filepath.Join(p.DocsDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		contents[rel] = data
+		titleMap[rel] = extractTitle(string(data), rel)
+	}
+
+	// The home page opens the document; the rest follow the sidebar.
+	order := treeOrder(BuildTree(mdPaths, titleMap))
+	if _, ok := contents["index.md"]; ok {
+		rest := order[:0]
+		for _, rel := range order {
+			if rel != "index.md" {
+				rest = append(rest, rel)
+			}
+		}
+		order = append([]string{"index.md"}, rest...)
+	}
+
+	md := newMarkdown()
+	p.Unrendered = nil
+	sections := make([]singlePageSection, 0, len(order))
+	for _, rel := range order {
+		var buf bytes.Buffer
+		if err := md.Convert(contents[rel], &buf); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", rel, err)
+		}
+		body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		nodes, err := html.ParseFragment(&buf, body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", rel, err)
+		}
+		for _, n := range nodes {
+			body.AppendChild(n)
+		}
+		cleanPage(body, p.DocsDir)
+		p.inlinePage(ctx, body, rel, contents)
+		sections = append(sections, singlePageSection{
+			ID:      sectionID(rel),
+			Title:   titleMap[rel],
+			Content: template.HTML(renderInner(body)),
+		})
+	}
+
+	tmpl, err := template.New("single").Parse(singlePageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing single-page template: %w", err)
+	}
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, struct {
+		ProjectName string
+		CSS         template.CSS
+		Sections    []singlePageSection
+	}{p.ProjectName, template.CSS(cssContent + singlePageCSS), sections})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// treeOrder lists the tree's pages depth first, as the sidebar shows them.
+func treeOrder(t *FileTree) []string {
+	var out []string
+	for _, c := range t.Children {
+		if c.IsDir {
+			out = append(out, treeOrder(c)...)
+		} else {
+			out = append(out, c.Path)
+		}
+	}
+	return out
+}
+
+// sectionID is the anchor of a page within the document.
+func sectionID(rel string) string {
+	return "page-" + strings.NewReplacer("/", "-", ".", "-", " ", "-").Replace(strings.TrimSuffix(rel, ".md"))
+}
+
+// inlinePage makes a rendered page fit into the document: heading anchors
+// are made unique to the page, links to other pages point within the
+// document, links to anything else the document doesn't hold become plain
+// text, images are embedded, and Mermaid diagrams are rasterized.
+func (p *SinglePage) inlinePage(ctx context.Context, body *html.Node, rel string, pages map[string][]byte) {
+	id := sectionID(rel)
+	dir := path.Dir(rel)
+
+	walk(body, func(n *html.Node) {
+		for i, a := range n.Attr {
+			if a.Key == "id" {
+				n.Attr[i].Val = id + "--" + a.Val
+			}
+		}
+	})
+
+	for _, a := range findAll(body, atom.A) {
+		href := attr(a, "href")
+		switch {
+		case href == "" || strings.Contains(href, "://") || strings.HasPrefix(href, "mailto:"):
+			continue
+		case strings.HasPrefix(href, "#"):
+			setAttr(a, "href", "#"+id+"--"+strings.TrimPrefix(href, "#"))
+			continue
+		}
+		target, frag, _ := strings.Cut(href, "#")
+		target = path.Join(dir, target)
+		if md := strings.TrimSuffix(target, ".html") + ".md"; pages[md] != nil {
+			target = md
+		}
+		if pages[target] == nil {
+			unwrap(a)
+			continue
+		}
+		anchor := "#" + sectionID(target)
+		if frag != "" {
+			anchor += "--" + frag
+		}
+		setAttr(a, "href", anchor)
+	}
+
+	for _, img := range findAll(body, atom.Img) {
+		src := attr(img, "src")
+		if src == "" || strings.Contains(src, "://") || strings.HasPrefix(src, "data:") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.DocsDir, filepath.FromSlash(path.Join(dir, src))))
+		if err != nil {
+			continue
+		}
+		mediaType := mime.TypeByExtension(path.Ext(src))
+		if mediaType == "" {
+			mediaType = "application/octet-stream"
+		}
+		setAttr(img, "src", "data:"+mediaType+";base64,"+base64.StdEncoding.EncodeToString(data))
+	}
+
+	for _, code := range findAll(body, atom.Code) {
+		pre := code.Parent
+		if pre == nil || pre.DataAtom != atom.Pre || !strings.Contains(attr(code, "class"), "language-mermaid") {
+			continue
+		}
+		if p.Diagrams == nil {
+			continue
+		}
+		png, err := p.Diagrams.Rasterize(ctx, textContent(code))
+		if err != nil {
+			p.Unrendered = append(p.Unrendered, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		img := &html.Node{Type: html.ElementNode, Data: "img", DataAtom: atom.Img, Attr: []html.Attribute{
+			{Key: "src", Val: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)},
+			{Key: "alt", Val: "Diagram"},
+		}}
+		pre.Parent.InsertBefore(element(atom.Figure, "diagram", img), pre)
+		pre.Parent.RemoveChild(pre)
+	}
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// unwrap replaces n with its children.
+func unwrap(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		n.RemoveChild(c)
+		n.Parent.InsertBefore(c, n)
+		c = next
+	}
+	n.Parent.RemoveChild(n)
+}
+
+// singlePageTemplate lays the pages out one after another behind a table
+// of contents. It has no scripts: diagrams arrive as images.
+const singlePageTemplate = `<!DOCTYPE html>
+<html lang="en" data-theme="light">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>{{.ProjectName}}</title>
+  <style>{{.CSS}}</style>
+</head>
+<body class="single-page">
+  <main class="content">
+    <article class="page-content">
+      <header class="single-page-cover">
+        <h1>{{.ProjectName}}</h1>
+        <nav class="single-page-toc">
+          <h2>Contents</h2>
+          <ol>
+          {{- range .Sections}}
+            <li><a href="#{{.ID}}">{{.Title}}</a></li>
+          {{- end}}
+          </ol>
+        </nav>
+      </header>
+      {{- range .Sections}}
+      <section class="single-page-section" id="{{.ID}}">
+        {{.Content}}
+      </section>
+      {{- end}}
+    </article>
+  </main>
+</body>
+</html>`
+
+// singlePageCSS adapts the site's stylesheet to a document without a
+// sidebar, and to print.
+const singlePageCSS = `
+/* ============ Single-page export ============ */
+.single-page .content { margin-left: 0; }
+.single-page-section { border-top: 1px solid var(--border); margin-top: 48px; padding-top: 16px; }
+.single-page-toc ol { columns: 2; }
+figure.diagram { margin: 16px 0; text-align: center; }
+figure.diagram img { max-width: 100%; }
+
+@media print {
+  .single-page-section { border-top: none; margin-top: 0; break-before: page; }
+  .single-page-toc ol { columns: 1; }
+  pre { white-space: pre-wrap; word-break: break-word; }
+  a { color: inherit; text-decoration: none; }
+  figure.diagram, table, pre { break-inside: avoid; }
+}
+`
@@ -0,0 +1,96 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/dataclass"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// scannedFile is what the source scanners found in one of a repo's files,
+// for the pages built from them: configuration, data stores, scheduled
+// jobs, exposure, the PII flow, and external dependencies.
+type scannedFile struct {
+	Path     string // repo-relative
+	Analysis indexer.FileAnalysis
+	Config   []indexer.ConfigKnob
+	Data     []indexer.DataAccess
+	Jobs     []indexer.ScheduledJob
+	Auth     []indexer.EndpointAuth
+	Rules    []indexer.AuthRule
+	Hosts    []indexer.Host
+	Schemas  []dataclass.Schema
+}
+
+// schemaExts are the extensions of the files schemas are read from.
+var schemaExts = map[string]bool{
+	".proto": true, ".avsc": true, ".sql": true, ".graphql": true, ".graphqls": true,
+	".gql": true, ".json": true, ".yaml": true, ".yml": true,
+}
+
+// scanSources reads every repo's source files once, several repos at once,
+// and keeps what the scanners found in them in g.scans.
+func (g *CentralSiteGenerator) scanSources() {
+	loaded := make([][]scannedFile, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		loaded[i] = scanRepoSources(g.Repos[i], g.repoAnalyses(g.Repos[i]))
+	})
+	g.scans = make(map[string][]scannedFile, len(g.Repos))
+	for i, r := range g.Repos {
+		g.scans[r.Name] = loaded[i]
+	}
+}
+
+// repoScan returns what the scanners found in a repo's files, in path
+// order. Repos that scanSources did not see are read from disk.
+func (g *CentralSiteGenerator) repoScan(repo RepoInfo) []scannedFile {
+	if scan, ok := g.scans[repo.Name]; ok {
+		return scan
+	}
+	return scanRepoSources(repo, g.repoAnalyses(repo))
+}
+
+// scanRepoSources scans the repo's analyzed files, leaving out tests.
+// Files marked as boilerplate are only searched for what manifests and
+// config hold: scheduled jobs and hosts.
+func scanRepoSources(repo RepoInfo, analyses map[string]indexer.FileAnalysis) []scannedFile {
+	if len(analyses) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(analyses))
+	for p, a := range analyses {
+		if (!a.Skip || a.Language == "YAML") && !isTestPath(p) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	root := repo.sourceRoot()
+	var files []scannedFile
+	for _, p := range paths {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		a := analyses[p]
+		f := scannedFile{
+			Path:     p,
+			Analysis: a,
+			Jobs:     indexer.ScanScheduledJobs(content, a.Language),
+			Hosts:    indexer.ScanHosts(content, a.Language),
+		}
+		if !a.Skip {
+			f.Config = indexer.ScanConfig(content, a.Language)
+			f.Data = indexer.ScanDataAccess(content, a.Language)
+			f.Auth = indexer.ScanEndpointAuth(p, content, a.Language)
+			f.Rules = indexer.ScanAuthRules(p, content)
+			if schemaExts[strings.ToLower(filepath.Ext(p))] {
+				f.Schemas = dataclass.ScanSchemas(p, content)
+			}
+		}
+		files = append(files, f)
+	}
+	return files
+}
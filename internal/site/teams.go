@@ -0,0 +1,333 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/orgstructure"
+)
+
+// TeamInfo is an engineering team for the central site's team directory.
+type TeamInfo struct {
+	Name         string
+	DisplayName  string
+	SlackChannel string
+	Email        string
+	Members      []TeamMemberInfo
+	Services     []string // names of the repos the team owns
+}
+
+// TeamMemberInfo is one member of a team.
+type TeamMemberInfo struct {
+	UserID string
+	Role   string
+}
+
+// teamDependency is a set of cross-service links from services owned by one
+// team to services owned by another.
+type teamDependency struct {
+	From  string
+	To    string
+	Links []LinkInfo
+}
+
+func (t TeamInfo) title() string {
+	if t.DisplayName != "" {
+		return t.DisplayName
+	}
+	return t.Name
+}
+
+// teamSlug converts a team name into a page file name.
+func teamSlug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// teamDependencies derives inter-team dependencies from cross-service links
+// between services owned by different teams.
+func (g *CentralSiteGenerator) teamDependencies() []teamDependency {
+	owners := make(map[string][]string)
+	for _, t := range g.Teams {
+		for _, svc := range t.Services {
+			owners[svc] = append(owners[svc], t.Name)
+		}
+	}
+
+	byPair := make(map[[2]string]*teamDependency)
+	for _, l := range g.Links {
+		for _, from := range owners[l.FromRepo] {
+			for _, to := range owners[l.ToRepo] {
+				if from == to {
+					continue
+				}
+				key := [2]string{from, to}
+				if byPair[key] == nil {
+					byPair[key] = &teamDependency{From: from, To: to}
+				}
+				byPair[key].Links = append(byPair[key].Links, l)
+			}
+		}
+	}
+
+	deps := make([]teamDependency, 0, len(byPair))
+	for _, d := range byPair {
+		deps = append(deps, *d)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].From != deps[j].From {
+			return deps[i].From < deps[j].From
+		}
+		return deps[i].To < deps[j].To
+	})
+	return deps
+}
+
+// writeTeamPages creates the teams/ section: a directory page with the
+// inter-team dependency graph and a page per team.
+func (g *CentralSiteGenerator) writeTeamPages(stagingDir string) error {
+	teamsDir := filepath.Join(stagingDir, "teams")
+	if err := os.MkdirAll(teamsDir, 0o755); err != nil {
+		return err
+	}
+
+	titles := make(map[string]string, len(g.Teams))
+	for _, t := range g.Teams {
+		titles[t.Name] = t.title()
+	}
+	deps := g.teamDependencies()
+
+	var b strings.Builder
+	b.WriteString("# Teams\n\n")
+	b.WriteString("Engineering teams, the services they own, and how to reach them.\n\n")
+	b.WriteString("| Team | Services | Members | Contact |\n")
+	b.WriteString("|------|----------|---------|---------|\n")
+	for _, t := range g.Teams {
+		b.WriteString(fmt.Sprintf("| [%s](%s.md) | %s | %d | %s |\n",
+			t.title(), teamSlug(t.Name), strings.Join(t.Services, ", "), len(t.Members), teamContact(t)))
+	}
+	b.WriteString("\n")
+
+	if len(deps) > 0 {
+		b.WriteString("## Team Dependencies\n\n")
+		b.WriteString("An arrow means services owned by one team call services owned by the other.\n\n")
+		b.WriteString("```mermaid\ngraph LR\n")
+		ids := make(map[string]string, len(g.Teams))
+		for i, t := range g.Teams {
+			ids[t.Name] = fmt.Sprintf("T%d", i)
+			b.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", ids[t.Name], strings.ReplaceAll(t.title(), `"`, "'")))
+		}
+		for _, d := range deps {
+			b.WriteString(fmt.Sprintf("    %s -->|%d| %s\n", ids[d.From], len(d.Links), ids[d.To]))
+		}
+		b.WriteString("```\n\n")
+		b.WriteString("See the [team coupling report](coupling.md) for the full dependency matrix and coupling hotspots.\n\n")
+	}
+
+	if err := os.WriteFile(filepath.Join(teamsDir, "index.md"), []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(teamsDir, "coupling.md"), []byte(g.couplingPage(titles)), 0o644); err != nil {
+		return err
+	}
+
+	for _, t := range g.Teams {
+		var outbound, inbound []teamDependency
+		for _, d := range deps {
+			if d.From == t.Name {
+				outbound = append(outbound, d)
+			}
+			if d.To == t.Name {
+				inbound = append(inbound, d)
+			}
+		}
+		page := g.teamPage(t, titles, outbound, inbound)
+		if err := os.WriteFile(filepath.Join(teamsDir, teamSlug(t.Name)+".md"), []byte(page), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teamPage renders one team's page.
+func (g *CentralSiteGenerator) teamPage(t TeamInfo, titles map[string]string, outbound, inbound []teamDependency) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s\n\n", t.title()))
+
+	if t.SlackChannel != "" || t.Email != "" {
+		b.WriteString("## Contact\n\n")
+		if t.SlackChannel != "" {
+			b.WriteString(fmt.Sprintf("- **Slack:** %s\n", t.SlackChannel))
+		}
+		if t.Email != "" {
+			b.WriteString(fmt.Sprintf("- **Email:** [%s](mailto:%s)\n", t.Email, t.Email))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Owned Services\n\n")
+	if len(t.Services) == 0 {
+		b.WriteString("This team does not own any registered services.\n\n")
+	}
+	summaries := make(map[string]string, len(g.Repos))
+	for _, r := range g.Repos {
+		summaries[r.Name] = r.Summary
+	}
+	for _, svc := range t.Services {
+		b.WriteString(fmt.Sprintf("- [%s](../%s/index.md)", svc, svc))
+		if s := summaries[svc]; s != "" {
+			b.WriteString(" — " + s)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if len(t.Members) > 0 {
+		b.WriteString("## Members\n\n")
+		b.WriteString("| Member | Role |\n")
+		b.WriteString("|--------|------|\n")
+		for _, m := range t.Members {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", m.UserID, m.Role))
+		}
+		b.WriteString("\n")
+	}
+
+	writeDeps := func(heading string, deps []teamDependency, other func(teamDependency) string) {
+		if len(deps) == 0 {
+			return
+		}
+		b.WriteString("## " + heading + "\n\n")
+		for _, d := range deps {
+			name := other(d)
+			b.WriteString(fmt.Sprintf("- [%s](%s.md):", titles[name], teamSlug(name)))
+			for i, l := range d.Links {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString(fmt.Sprintf(" %s → %s", l.FromRepo, l.ToRepo))
+				if l.LinkType != "" {
+					b.WriteString(fmt.Sprintf(" (%s)", l.LinkType))
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	writeDeps("Depends On", outbound, func(d teamDependency) string { return d.To })
+	writeDeps("Depended On By", inbound, func(d teamDependency) string { return d.From })
+
+	return b.String()
+}
+
+// teamMatrix projects the cross-service links onto team ownership.
+func (g *CentralSiteGenerator) teamMatrix() *orgstructure.TeamMatrix {
+	owners := make(map[string][]string)
+	for _, t := range g.Teams {
+		for _, svc := range t.Services {
+			owners[svc] = append(owners[svc], t.Name)
+		}
+	}
+	deps := make([]orgstructure.Dependency, len(g.Links))
+	for i, l := range g.Links {
+		deps[i] = orgstructure.Dependency{From: l.FromRepo, To: l.ToRepo, Type: l.LinkType}
+	}
+	return orgstructure.BuildTeamMatrix(owners, deps)
+}
+
+// couplingPage renders the team-to-team dependency matrix as a heatmap,
+// followed by the coupling hotspots and per-team boundary load.
+func (g *CentralSiteGenerator) couplingPage(titles map[string]string) string {
+	m := g.teamMatrix()
+
+	var b strings.Builder
+	b.WriteString("# Team Coupling\n\n")
+	b.WriteString("The service dependency graph projected onto team ownership. Per Conway's law, " +
+		"services tend to mirror the communication structure of the teams that build them; " +
+		"boundaries that carry many links are where the organisation and the architecture disagree.\n\n")
+
+	if m.CrossTeam == 0 {
+		b.WriteString("No cross-service links cross a team boundary.\n")
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("**%d** links cross team boundaries", m.CrossTeam))
+	if m.Unowned > 0 {
+		b.WriteString(fmt.Sprintf("; %d more touch services without an owner", m.Unowned))
+	}
+	b.WriteString(".\n\n")
+
+	b.WriteString("## Dependency Matrix\n\n")
+	b.WriteString("Rows depend on columns: each cell counts links from services owned by the row's team " +
+		"to services owned by the column's team. The diagonal counts links within a team.\n\n")
+	highest := m.Max()
+	b.WriteString("<table class=\"team-matrix\">\n<thead><tr><th></th>")
+	for _, t := range m.Teams {
+		b.WriteString(fmt.Sprintf("<th>%s</th>", html.EscapeString(titles[t])))
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, from := range m.Teams {
+		b.WriteString(fmt.Sprintf("<tr><th>%s</th>", html.EscapeString(titles[from])))
+		for _, to := range m.Teams {
+			n := m.Counts[from][to]
+			switch {
+			case from == to:
+				b.WriteString(fmt.Sprintf("<td style=\"color:#888\">%d</td>", n))
+			case n == 0:
+				b.WriteString("<td></td>")
+			default:
+				// Scale the red channel's opacity with the cell's share of the busiest boundary.
+				alpha := 0.15 + 0.75*float64(n)/float64(highest)
+				b.WriteString(fmt.Sprintf("<td style=\"background:rgba(220,38,38,%.2f)\">%d</td>", alpha, n))
+			}
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n\n")
+
+	b.WriteString("## Coupling Hotspots\n\n")
+	if len(m.Hotspots) == 0 {
+		b.WriteString("No single team boundary carries a disproportionate share of the links.\n\n")
+	} else {
+		b.WriteString("| Teams | Links | Share of cross-team links |\n")
+		b.WriteString("|-------|-------|---------------------------|\n")
+		for _, h := range m.Hotspots {
+			b.WriteString(fmt.Sprintf("| [%s](%s.md) ↔ [%s](%s.md) | %d | %.0f%% |\n",
+				titles[h.TeamA], teamSlug(h.TeamA), titles[h.TeamB], teamSlug(h.TeamB), h.Links, h.Share*100))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Boundary Load\n\n")
+	b.WriteString("| Team | Outbound | Inbound | Internal | Partner Teams |\n")
+	b.WriteString("|------|----------|---------|----------|---------------|\n")
+	for _, tb := range m.Boundaries {
+		b.WriteString(fmt.Sprintf("| [%s](%s.md) | %d | %d | %d | %d |\n",
+			titles[tb.Team], teamSlug(tb.Team), tb.Outbound, tb.Inbound, tb.Internal, tb.Partners))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// teamContact renders a team's contact channels for the directory table.
+func teamContact(t TeamInfo) string {
+	var parts []string
+	if t.SlackChannel != "" {
+		parts = append(parts, t.SlackChannel)
+	}
+	if t.Email != "" {
+		parts = append(parts, fmt.Sprintf("[%s](mailto:%s)", t.Email, t.Email))
+	}
+	return strings.Join(parts, ", ")
+}
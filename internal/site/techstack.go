@@ -0,0 +1,260 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/techstack"
+)
+
+// loadStacks detects each repo's tech stack from its checkout, its
+// analyses, and the dependencies loadDependencies read, by repo name. Repos
+// where nothing was found are left out.
+func (g *CentralSiteGenerator) loadStacks() map[string]techstack.Stack {
+	loaded := make([]techstack.Stack, len(g.Repos))
+	parallel(len(g.Repos), g.Concurrency, func(i int) {
+		repo := g.Repos[i]
+		if repo.DocsDir == "" {
+			return
+		}
+		loaded[i] = techstack.Detect(repo.sourceRoot(), repo.Subdir, repo.Excludes, g.repoAnalyses(repo), g.dependencies[repo.Name])
+	})
+	stacks := make(map[string]techstack.Stack)
+	for i, r := range g.Repos {
+		if !loaded[i].Empty() {
+			stacks[r.Name] = loaded[i]
+		}
+	}
+	return stacks
+}
+
+// stackSummary lists a stack's runtimes, frameworks, and databases in a
+// sentence, e.g. "Go 1.22, Echo, PostgreSQL and Redis".
+func stackSummary(s techstack.Stack) string {
+	var parts []string
+	for _, c := range s.Components {
+		switch c.Category {
+		case techstack.CategoryBuild, techstack.CategoryORM:
+			continue
+		case techstack.CategoryRuntime:
+			parts = append(parts, strings.TrimSpace(c.Name+" "+c.Version))
+		default:
+			parts = append(parts, c.Name)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, s.Primary())
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// writeServiceTechStack writes a repo's tech-stack.md, unless its docs
+// already have one.
+func (g *CentralSiteGenerator) writeServiceTechStack(destDir string, repo RepoInfo) error {
+	path := filepath.Join(destDir, "tech-stack.md")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	displayName := repo.DisplayName
+	if displayName == "" {
+		displayName = repo.Name
+	}
+	s := g.stacks[repo.Name]
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Tech Stack — %s\n\n", displayName)
+	b.WriteString("Detected from this service's package manifests, the imports in its source, and its build files. The [org-wide matrix](../tech-stack.md) shows which other services use the same.\n\n")
+	if len(s.Languages) > 0 {
+		b.WriteString("## Languages\n\n")
+		b.WriteString("| Language | Files |\n")
+		b.WriteString("|----------|-------|\n")
+		for _, l := range s.Languages {
+			fmt.Fprintf(&b, "| %s | %d |\n", l.Name, l.Files)
+		}
+		b.WriteString("\n")
+	}
+	if len(s.Components) > 0 {
+		b.WriteString("## Components\n\n")
+		b.WriteString("| Category | Technology | Version | Found In |\n")
+		b.WriteString("|----------|------------|---------|----------|\n")
+		for _, c := range s.Components {
+			fmt.Fprintf(&b, "| %s | %s | %s | `%s` |\n", c.Category, c.Name, dependencyCell(c.Version), c.Evidence)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// technology is one component in use across the repos.
+type technology struct {
+	Name     string
+	Language string
+	Versions []string
+	Services []string
+}
+
+// stackHeadings title the org-wide page's section for each category.
+var stackHeadings = map[string]string{
+	techstack.CategoryRuntime:   "Runtimes",
+	techstack.CategoryWeb:       "Web Frameworks",
+	techstack.CategoryFrontend:  "Frontend Frameworks",
+	techstack.CategoryRPC:       "RPC",
+	techstack.CategoryORM:       "Data Access",
+	techstack.CategoryDatabase:  "Databases",
+	techstack.CategoryMessaging: "Messaging",
+	techstack.CategoryBuild:     "Build Tools",
+}
+
+// consolidated are the categories where using more than one technology
+// from the same language is worth a look, and what to call several of them.
+var consolidated = []struct{ category, kind string }{
+	{techstack.CategoryWeb, "web frameworks"},
+	{techstack.CategoryFrontend, "frontend frameworks"},
+	{techstack.CategoryORM, "data access libraries"},
+	{techstack.CategoryMessaging, "messaging clients"},
+}
+
+// writeTechStackPage writes the org-wide tech-stack.md: for each category,
+// the technologies in use and the services using each, then where the same
+// kind of framework is used in more than one flavor and runtimes are used
+// in more than one version, the candidates for platform consolidation.
+func (g *CentralSiteGenerator) writeTechStackPage(stagingDir string) error {
+	names := make([]string, 0, len(g.stacks))
+	for name := range g.stacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	languages := make(map[string][]string)
+	files := make(map[string]int)
+	byCategory := make(map[string][]*technology)
+	for _, name := range names {
+		s := g.stacks[name]
+		for _, l := range s.Languages {
+			languages[l.Name] = append(languages[l.Name], name)
+			files[l.Name] += l.Files
+		}
+		for _, c := range s.Components {
+			i := slices.IndexFunc(byCategory[c.Category], func(t *technology) bool { return t.Name == c.Name })
+			if i < 0 {
+				byCategory[c.Category] = append(byCategory[c.Category], &technology{Name: c.Name, Language: c.Language})
+				i = len(byCategory[c.Category]) - 1
+			}
+			t := byCategory[c.Category][i]
+			if c.Version != "" && !slices.Contains(t.Versions, c.Version) {
+				t.Versions = append(t.Versions, c.Version)
+			}
+			if !slices.Contains(t.Services, name) {
+				t.Services = append(t.Services, name)
+			}
+		}
+	}
+	serviceLinks := func(services []string) string {
+		links := make([]string, len(services))
+		for i, s := range services {
+			links[i] = fmt.Sprintf("[%s](%s/tech-stack.md)", s, s)
+		}
+		return strings.Join(links, ", ")
+	}
+	mostUsed := func(techs []*technology) {
+		sort.SliceStable(techs, func(i, j int) bool {
+			if len(techs[i].Services) != len(techs[j].Services) {
+				return len(techs[i].Services) > len(techs[j].Services)
+			}
+			return strings.ToLower(techs[i].Name) < strings.ToLower(techs[j].Name)
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString("# Tech Stack\n\n")
+	fmt.Fprintf(&b, "The languages, runtimes, frameworks, databases, and build tools of %d services, detected from their package manifests, the imports in their source, and their build files.\n\n", len(names))
+
+	if len(languages) > 0 {
+		langs := make([]string, 0, len(languages))
+		for l := range languages {
+			langs = append(langs, l)
+		}
+		sort.Slice(langs, func(i, j int) bool {
+			if len(languages[langs[i]]) != len(languages[langs[j]]) {
+				return len(languages[langs[i]]) > len(languages[langs[j]])
+			}
+			return langs[i] < langs[j]
+		})
+		b.WriteString("## Languages\n\n")
+		b.WriteString("| Language | Files | Services |\n")
+		b.WriteString("|----------|-------|----------|\n")
+		for _, l := range langs {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", l, files[l], serviceLinks(languages[l]))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, category := range techstack.Categories {
+		techs := byCategory[category]
+		if len(techs) == 0 {
+			continue
+		}
+		mostUsed(techs)
+		fmt.Fprintf(&b, "## %s\n\n", stackHeadings[category])
+		b.WriteString("| Technology | Versions | Services |\n")
+		b.WriteString("|------------|----------|----------|\n")
+		for _, t := range techs {
+			sort.Strings(t.Versions)
+			versions := "—"
+			if len(t.Versions) > 0 {
+				versions = "`" + strings.Join(t.Versions, "`, `") + "`"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", t.Name, versions, serviceLinks(t.Services))
+		}
+		b.WriteString("\n")
+	}
+
+	var candidates []string
+	for _, c := range consolidated {
+		byLanguage := make(map[string][]*technology)
+		var langs []string
+		for _, t := range byCategory[c.category] {
+			if _, ok := byLanguage[t.Language]; !ok {
+				langs = append(langs, t.Language)
+			}
+			byLanguage[t.Language] = append(byLanguage[t.Language], t)
+		}
+		sort.Strings(langs)
+		for _, l := range langs {
+			techs := byLanguage[l]
+			if len(techs) < 2 {
+				continue
+			}
+			used := make([]string, len(techs))
+			for i, t := range techs {
+				used[i] = fmt.Sprintf("%s (%d services)", t.Name, len(t.Services))
+				if len(t.Services) == 1 {
+					used[i] = t.Name + " (1 service)"
+				}
+			}
+			kind := c.kind
+			if l != "" {
+				kind = l + " " + kind
+			}
+			candidates = append(candidates, fmt.Sprintf("- %d %s: %s", len(techs), kind, strings.Join(used, ", ")))
+		}
+	}
+	for _, t := range byCategory[techstack.CategoryRuntime] {
+		if len(t.Versions) > 1 {
+			candidates = append(candidates, fmt.Sprintf("- %s in %d versions: %s", t.Name, len(t.Versions), strings.Join(t.Versions, ", ")))
+		}
+	}
+	if len(candidates) > 0 {
+		b.WriteString("## Consolidation Candidates\n\n")
+		b.WriteString("Where services use different frameworks or libraries for the same job, or different versions of a runtime:\n\n")
+		b.WriteString(strings.Join(candidates, "\n") + "\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "tech-stack.md"), []byte(b.String()), 0o644)
+}
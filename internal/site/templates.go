@@ -2,19 +2,28 @@ package site
 
 // pageTemplate is the Go html/template for each documentation page.
 const pageTemplate = `<!DOCTYPE html>
-<html lang="en" data-theme="light">
+<html lang="{{.Lang}}" data-theme="light">
 <head>
   <meta charset="UTF-8">
   <meta name="viewport" content="width=device-width, initial-scale=1.0">
   <title>{{.Title}} — {{.ProjectName}}</title>
   <link rel="stylesheet" href="{{.BasePath}}style.css">
-  <script src="https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.min.js"></script>
+  {{- if .Theme.CSS}}
+  <link rel="stylesheet" href="{{.BasePath}}{{.Theme.CSS}}">
+  {{- end}}
+  {{- if .Theme.Favicon}}
+  <link rel="icon" href="{{.BasePath}}{{.Theme.Favicon}}">
+  {{- end}}
+  <script src="` + mermaidURL + `"></script>
 </head>
 <body>
   <nav class="sidebar" id="sidebar">
     <div class="sidebar-header">
       {{if .LogoFile}}<a href="{{.BasePath}}index.html" class="sidebar-logo-link"><img src="{{.BasePath}}{{.LogoFile}}" alt="{{.ProjectName}}" class="sidebar-logo"></a>{{end}}
       <h2 class="project-title">{{.ProjectName}}</h2>
+      {{- if .Section.Logo}}
+      <a href="{{.BasePath}}{{.Section.Dir}}/index.html" class="section-logo-link"><img src="{{.BasePath}}{{.Section.Logo}}" alt="" class="section-logo">{{.Section.Name}}</a>
+      {{- end}}
       <input type="text" id="search-input" placeholder="Search docs..." autocomplete="off">
     </div>
     <div class="sidebar-tree" id="sidebar-tree">
@@ -36,6 +45,24 @@ const pageTemplate = `<!DOCTYPE html>
         <input type="text" id="ai-search-input" placeholder="Ask about this codebase..." autocomplete="off">
         <span class="ai-search-hint">Enter</span>
       </div>
+      <div class="ai-search-scope">
+        <select id="ai-search-scope" aria-label="Search scope">
+          <option value="global">Everywhere</option>
+          <option value="repo">This repo</option>
+          <option value="directory">This directory</option>
+          <option value="tags">Tags</option>
+        </select>
+        <input type="text" id="ai-search-tags" placeholder="decision, Go" autocomplete="off" hidden>
+      </div>
+      {{- if .Languages}}
+      <div class="language-switcher">
+        <select id="language-switcher" aria-label="Language">
+          {{- range .Languages}}
+          <option value="{{.URL}}" lang="{{.Code}}"{{if .Current}} selected{{end}}>{{.Name}}</option>
+          {{- end}}
+        </select>
+      </div>
+      {{- end}}
       <button class="theme-toggle" id="theme-toggle" aria-label="Toggle theme">
         <svg class="sun-icon" width="20" height="20" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
           <circle cx="12" cy="12" r="5"/><line x1="12" y1="1" x2="12" y2="3"/><line x1="12" y1="21" x2="12" y2="23"/><line x1="4.22" y1="4.22" x2="5.64" y2="5.64"/><line x1="18.36" y1="18.36" x2="19.78" y2="19.78"/><line x1="1" y1="12" x2="3" y2="12"/><line x1="21" y1="12" x2="23" y2="12"/><line x1="4.22" y1="19.78" x2="5.64" y2="18.36"/><line x1="18.36" y1="5.64" x2="19.78" y2="4.22"/>
@@ -49,8 +76,14 @@ const pageTemplate = `<!DOCTYPE html>
     <article class="page-content">
       {{.Content}}
     </article>
+    {{- if .Theme.Footer}}
+    <footer class="site-footer">{{.Theme.Footer}}</footer>
+    {{- end}}
   </main>
   <script src="{{.BasePath}}script.js"></script>
+  {{- if .Theme.JS}}
+  <script src="{{.BasePath}}{{.Theme.JS}}"></script>
+  {{- end}}
 </body>
 </html>`
 
@@ -76,6 +109,8 @@ const cssContent = `/* ============ CSS Variables ============ */
   --search-bg: #ffffff;
   --shadow: 0 1px 3px rgba(0,0,0,0.08);
   --shadow-lg: 0 4px 12px rgba(0,0,0,0.1);
+  --font: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+  --code-font: "JetBrains Mono", "Fira Code", "SF Mono", Consolas, monospace;
 }
 
 [data-theme="dark"] {
@@ -134,7 +169,7 @@ html {
 }
 
 body {
-  font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+  font-family: var(--font);
   color: var(--text);
   background: var(--bg);
   line-height: 1.7;
@@ -178,6 +213,39 @@ body {
   border-radius: 8px;
 }
 
+.section-logo-link {
+  display: flex;
+  align-items: center;
+  gap: 8px;
+  margin-bottom: 12px;
+  color: var(--text-secondary);
+  font-weight: 600;
+  text-decoration: none;
+}
+
+.section-logo {
+  max-width: 32px;
+  max-height: 32px;
+  border-radius: 6px;
+}
+
+.service-logo {
+  width: 20px;
+  height: 20px;
+  object-fit: contain;
+  vertical-align: middle;
+  margin-right: 6px;
+}
+
+.site-footer {
+  max-width: var(--content-max-width);
+  margin: 48px auto 0;
+  padding: 16px 0;
+  border-top: 1px solid var(--border);
+  color: var(--text-muted);
+  font-size: 0.85rem;
+}
+
 .project-title {
   font-size: 1.1rem;
   font-weight: 700;
@@ -422,7 +490,7 @@ body {
 
 /* ============ Code ============ */
 .page-content code {
-  font-family: "JetBrains Mono", "Fira Code", "SF Mono", Consolas, monospace;
+  font-family: var(--code-font);
   font-size: 0.88em;
   background: var(--code-bg);
   padding: 2px 6px;
@@ -471,6 +539,29 @@ body {
   border-color: var(--accent);
 }
 
+/* ============ Code example tabs ============ */
+.code-tab-bar {
+  display: flex;
+  gap: 4px;
+  border-bottom: 1px solid var(--border);
+  margin: 16px 0 12px;
+}
+
+.code-tab-bar button {
+  background: none;
+  border: none;
+  border-bottom: 2px solid transparent;
+  color: var(--text-muted);
+  cursor: pointer;
+  font-size: 13px;
+  padding: 6px 12px;
+}
+
+.code-tab-bar button.active {
+  color: var(--accent);
+  border-bottom-color: var(--accent);
+}
+
 /* ============ Tables ============ */
 .page-content table {
   width: 100%;
@@ -539,6 +630,25 @@ body {
   border: 1px solid var(--accent);
 }
 
+.quality-badge {
+  display: inline-block;
+  min-width: 2.2em;
+  font-size: 0.78rem;
+  font-weight: 700;
+  text-align: center;
+  padding: 2px 8px;
+  border-radius: 12px;
+  text-decoration: none;
+}
+
+.quality-good { background: #dcfce7; color: #166534; }
+.quality-fair { background: #fef3c7; color: #92400e; }
+.quality-poor { background: #fee2e2; color: #991b1b; }
+
+[data-theme="dark"] .quality-good { background: #14532d; color: #86efac; }
+[data-theme="dark"] .quality-fair { background: #78350f; color: #fcd34d; }
+[data-theme="dark"] .quality-poor { background: #7f1d1d; color: #fca5a5; }
+
 .file-summary {
   font-size: 1rem;
   line-height: 1.7;
@@ -583,7 +693,7 @@ body {
 .dep-tag {
   display: inline-block;
   font-size: 0.78rem;
-  font-family: "JetBrains Mono", "Fira Code", "SF Mono", Consolas, monospace;
+  font-family: var(--code-font);
   padding: 2px 8px;
   border-radius: 4px;
   background: var(--code-bg);
@@ -646,6 +756,49 @@ body {
   pointer-events: none;
 }
 
+.ai-search-scope {
+  display: flex;
+  align-items: center;
+  gap: 6px;
+  margin-right: 16px;
+}
+
+.ai-search-scope select,
+.ai-search-scope input {
+  padding: 7px 8px;
+  border: 1px solid var(--border);
+  border-radius: 8px;
+  font-size: 0.8rem;
+  background: var(--bg-secondary);
+  color: var(--text);
+  outline: none;
+}
+
+.ai-search-scope input {
+  width: 130px;
+}
+
+.language-switcher {
+  margin-right: 16px;
+}
+
+.language-switcher select {
+  padding: 7px 8px;
+  border: 1px solid var(--border);
+  border-radius: 8px;
+  font-size: 0.8rem;
+  background: var(--bg-secondary);
+  color: var(--text);
+  outline: none;
+}
+
+.ai-results-link {
+  margin-left: auto;
+  margin-right: 8px;
+  font-size: 0.78rem;
+  color: var(--text-muted);
+}
+
 .ai-search-results {
   display: none;
   max-width: var(--content-max-width);
@@ -717,11 +870,17 @@ body {
 
 .ai-result-path {
   font-size: 0.82rem;
-  font-family: "JetBrains Mono", "Fira Code", "SF Mono", Consolas, monospace;
+  font-family: var(--code-font);
   color: var(--accent);
   font-weight: 500;
 }
 
+.ai-result-num {
+  font-size: 0.75rem;
+  color: var(--text-muted);
+  font-weight: 600;
+}
+
 .ai-result-badge {
   font-size: 0.7rem;
   font-weight: 600;
@@ -841,8 +1000,32 @@ body {
   margin: 4px 0;
 }
 
+.ai-citation {
+  font-size: 0.75em;
+  vertical-align: super;
+  color: var(--accent);
+  text-decoration: none;
+  margin-left: 1px;
+}
+
+.ai-citation:hover { text-decoration: underline; }
+
+.ai-answer-sources {
+  margin-top: 12px;
+  padding-top: 10px;
+  border-top: 1px solid var(--border);
+}
+.ai-answer-sources ol {
+  margin: 0 0 0 20px;
+  padding: 0;
+  font-size: 0.85rem;
+}
+.ai-answer-sources li { margin: 2px 0; }
+.ai-answer-sources a { color: var(--accent); text-decoration: none; }
+.ai-answer-sources a:hover { text-decoration: underline; }
+
 .ai-answer-content code {
-  font-family: "JetBrains Mono", "Fira Code", "SF Mono", Consolas, monospace;
+  font-family: var(--code-font);
   font-size: 0.85em;
   background: var(--code-bg);
   padding: 1px 5px;
@@ -1186,6 +1369,15 @@ const jsContent = `(function() {
     }
   }
 
+  // ===== Language switcher =====
+  // Headings keep their anchors in every language, so the section carries over.
+  var languageSwitcher = document.getElementById("language-switcher");
+  if (languageSwitcher) {
+    languageSwitcher.addEventListener("change", function() {
+      window.location.href = languageSwitcher.value + window.location.hash;
+    });
+  }
+
   var stored = getStoredTheme();
   if (stored) {
     setTheme(stored);
@@ -1220,22 +1412,75 @@ const jsContent = `(function() {
     });
   });
 
-  // ===== Sidebar file filter (with search-index.json) =====
+  // ===== Sidebar file filter (with sharded search index) =====
   var searchInput = document.getElementById("search-input");
   var searchIndex = null;
-
-  // Load search index asynchronously
-  (function loadSearchIndex() {
-    var basePath = document.querySelector("link[rel=stylesheet]");
-    var base = "";
-    if (basePath) {
-      var href = basePath.getAttribute("href");
-      base = href.replace("style.css", "");
+  var searchShards = [];
+  var loadedShards = {};
+
+  // loadShard fetches one index shard and appends its entries to searchIndex.
+  function loadShard(shard) {
+    if (!loadedShards[shard.name]) {
+      loadedShards[shard.name] = fetch(getBasePath() + shard.file)
+        .then(function(r) { return r.json(); })
+        .then(function(data) { searchIndex = (searchIndex || []).concat(data); })
+        .catch(function() {});
     }
-    fetch(base + "search-index.json")
+    return loadedShards[shard.name];
+  }
+
+  // currentPagePath returns the path of the page being viewed relative to
+  // the site root, e.g. "orders/internal/api/handler.go.html".
+  function currentPagePath() {
+    var depth = (getBasePath().match(/\.\.\//g) || []).length;
+    var parts = window.location.pathname.split("/").filter(Boolean);
+    return parts.slice(Math.max(parts.length - depth - 1, 0)).join("/");
+  }
+
+  // currentShardName returns the shard for the page being viewed: its
+  // top-level directory (the repo on central sites), or "_root".
+  function currentShardName() {
+    var parts = currentPagePath().split("/");
+    return parts.length > 1 ? parts[0] : "_root";
+  }
+
+  // shardsForQuery picks the shards a query needs. A "repo/..." prefix
+  // selects that repo's shard. Other queries match page titles and paths
+  // from the sidebar and contents from the shards already loaded, and only
+  // load every shard when everywhere is set, as pressing Enter does.
+  function shardsForQuery(query, everywhere) {
+    var q = query.toLowerCase();
+    var picked = searchShards.filter(function(s) {
+      return q.indexOf(s.name.toLowerCase() + "/") === 0;
+    });
+    if (picked.length) return picked;
+    return everywhere ? searchShards : [];
+  }
+
+  // ensureShardsFor loads any shards the query needs that are not loaded yet.
+  // The promise resolves to true when new entries were added.
+  function ensureShardsFor(query, everywhere) {
+    var pending = shardsForQuery(query, everywhere).filter(function(s) { return !loadedShards[s.name]; });
+    if (pending.length === 0) return Promise.resolve(false);
+    return Promise.all(pending.map(loadShard)).then(function() { return true; });
+  }
+
+  // Load the shard manifest, then eagerly load only the shard for the repo
+  // being viewed plus top-level pages. Other shards load on demand.
+  (function loadSearchManifest() {
+    fetch(getBasePath() + "search-manifest.json")
       .then(function(r) { return r.json(); })
-      .then(function(data) { searchIndex = data; })
-      .catch(function() { searchIndex = null; });
+      .then(function(manifest) {
+        searchShards = manifest.shards || [];
+        var current = currentShardName();
+        searchShards.forEach(function(s) {
+          if (searchShards.length === 1 || s.name === current || s.name === "_root") loadShard(s);
+        });
+      })
+      .catch(function() {
+        searchShards = [{ name: "all", file: "search-index.json" }];
+        loadShard(searchShards[0]);
+      });
   })();
 
   if (searchInput && sidebarTree) {
@@ -1245,8 +1490,21 @@ const jsContent = `(function() {
       if (dir.classList.contains("expanded")) originalExpanded.push(dir);
     });
 
-    searchInput.addEventListener("input", function() {
-      var query = this.value.toLowerCase().trim();
+    function searchSidebar(everywhere) {
+      var query = searchInput.value.toLowerCase().trim();
+      filterSidebar(query);
+      if (query === "") return;
+      ensureShardsFor(query, everywhere).then(function(loaded) {
+        if (loaded && searchInput.value.toLowerCase().trim() === query) filterSidebar(query);
+      });
+    }
+
+    searchInput.addEventListener("input", function() { searchSidebar(false); });
+    searchInput.addEventListener("keydown", function(e) {
+      if (e.key === "Enter") searchSidebar(true);
+    });
+
+    function filterSidebar(query) {
       var items = sidebarTree.querySelectorAll("li");
 
       if (query === "") {
@@ -1292,7 +1550,7 @@ const jsContent = `(function() {
         dir.classList.toggle("hidden", !hasVisible);
         if (hasVisible) dir.classList.add("expanded");
       });
-    });
+    }
   }
 
   // ===== AI Search =====
@@ -1313,9 +1571,48 @@ const jsContent = `(function() {
     return div.innerHTML;
   }
 
-  function filePathToDocUrl(filePath, basePath) {
-    // Convert source file path (e.g. "internal/config/config.go") to doc page URL
-    return basePath + filePath + ".html";
+  function filePathToDocUrl(filePath, basePath, repoId) {
+    // Convert source file path (e.g. "internal/config/config.go") to doc page URL.
+    // Central sites nest each repo's pages under its name.
+    return basePath + (repoId ? repoId + "/" : "") + filePath + ".html";
+  }
+
+  function resultDocUrl(r, basePath) {
+    // Deep link to the cited function or type section when the result has one.
+    return filePathToDocUrl(r.file_path, basePath, r.repo_id) + (r.anchor ? "#" + r.anchor : "");
+  }
+
+  function lineRange(r) {
+    if (!r.line_start) return "";
+    return r.line_end > r.line_start ? ":" + r.line_start + "-" + r.line_end : ":" + r.line_start;
+  }
+
+  function linkCitations(html, results, basePath) {
+    // Turn [n] markers in an answer into links to the n-th result's doc section.
+    return html.replace(/\[(\d+)\]/g, function(m, n) {
+      var r = results[parseInt(n, 10) - 1];
+      if (!r) return m;
+      var label = r.file_path + lineRange(r) + (r.symbol ? " (" + r.symbol + ")" : "");
+      return '<a class="ai-citation" href="' + escapeHtml(resultDocUrl(r, basePath)) +
+        '" title="' + escapeHtml(label) + '">[' + n + ']</a>';
+    });
+  }
+
+  function answerSourcesHtml(citations, results, basePath) {
+    // List the doc pages the answer cites, once each, in citation order.
+    var seen = {};
+    var items = "";
+    (citations || []).forEach(function(n) {
+      var r = results[n - 1];
+      if (!r) return;
+      var url = resultDocUrl(r, basePath);
+      if (seen[url]) return;
+      seen[url] = true;
+      var label = (r.repo_id ? r.repo_id + ": " : "") + r.file_path + lineRange(r) + (r.symbol ? " (" + r.symbol + ")" : "");
+      items += '<li value="' + n + '"><a href="' + escapeHtml(url) + '">' + escapeHtml(label) + '</a></li>';
+    });
+    if (!items) return "";
+    return '<div class="ai-answer-sources"><div class="ai-answer-label">Sources</div><ol>' + items + '</ol></div>';
   }
 
   function formatAnswerHtml(text) {
@@ -1447,29 +1744,32 @@ const jsContent = `(function() {
     return s;
   }
 
-  function showAIResults(query, results, answer) {
+  function showAIResults(query, results, answer, scope, citations) {
     var base = getBasePath();
     var html = '<div class="ai-results-header">' +
-      '<h3>Results for "' + escapeHtml(query) + '"</h3>' +
+      '<h3>Results for "' + escapeHtml(query) + '" ' + escapeHtml(scopeLabel(scope)) + '</h3>' +
+      '<a class="ai-results-link" href="?' + escapeHtml(scopeToParams(query, scope).toString()) + '">Link to these results</a>' +
       '<button class="ai-results-close" id="ai-results-close">Close</button>' +
       '</div>';
 
     if (answer) {
       html += '<div class="ai-answer">';
       html += '<div class="ai-answer-label">AI Answer</div>';
-      html += '<div class="ai-answer-content">' + formatAnswerHtml(answer) + '</div>';
+      html += '<div class="ai-answer-content">' + linkCitations(formatAnswerHtml(answer), results, base) + '</div>';
+      html += answerSourcesHtml(citations, results, base);
       html += '</div>';
     }
 
     if (results.length === 0 && !answer) {
       html += '<div class="ai-search-empty">No relevant results found. Try rephrasing your question.</div>';
     } else {
-      results.forEach(function(r) {
-        var url = filePathToDocUrl(r.file_path, base);
+      results.forEach(function(r, i) {
+        var url = resultDocUrl(r, base);
         var badgeClass = "type-" + (r.type || "file");
         html += '<a class="ai-result-card" href="' + escapeHtml(url) + '">';
         html += '<div class="ai-result-top">';
-        html += '<span class="ai-result-path">' + escapeHtml(r.file_path) + '</span>';
+        html += '<span class="ai-result-num">[' + (i + 1) + ']</span>';
+        html += '<span class="ai-result-path">' + escapeHtml(r.file_path + lineRange(r)) + '</span>';
         if (r.type) {
           html += '<span class="ai-result-badge ' + badgeClass + '">' + escapeHtml(r.type) + '</span>';
         }
@@ -1511,11 +1811,81 @@ const jsContent = `(function() {
     });
   }
 
-  function localSearch(query) {
+  // ===== Search scope =====
+  var aiScopeSelect = document.getElementById("ai-search-scope");
+  var aiTagsInput = document.getElementById("ai-search-tags");
+  var sharedScope = null; // scope from a shared results URL, until the user changes it
+
+  // currentScope builds the /api/search scope from the scope selector and the
+  // page being viewed.
+  function currentScope() {
+    if (sharedScope) return sharedScope;
+    var kind = aiScopeSelect ? aiScopeSelect.value : "global";
+    var page = currentPagePath().split("/");
+    if (kind === "repo" && page.length > 1) return { kind: "repo", repo: page[0] };
+    if (kind === "directory" && page.length > 1) return { kind: "directory", directory: page.slice(0, -1).join("/") };
+    if (kind === "tags" && aiTagsInput) {
+      var tags = aiTagsInput.value.split(",").map(function(t) { return t.trim(); }).filter(Boolean);
+      if (tags.length) return { kind: "tags", tags: tags };
+    }
+    return { kind: "global" };
+  }
+
+  function scopeLabel(scope) {
+    if (!scope || scope.kind === "global") return "(everywhere)";
+    if (scope.kind === "repo") return "(in " + scope.repo + ")";
+    if (scope.kind === "directory") return "(in " + scope.directory + "/)";
+    return "(tagged " + scope.tags.join(", ") + ")";
+  }
+
+  // scopeToParams encodes a query and its scope for shareable result URLs.
+  function scopeToParams(query, scope) {
+    var params = new URLSearchParams();
+    params.set("q", query);
+    if (scope && scope.kind !== "global") {
+      params.set("scope", scope.kind);
+      if (scope.repo) params.set("repo", scope.repo);
+      if (scope.directory) params.set("dir", scope.directory);
+      if (scope.tags) params.set("tags", scope.tags.join(","));
+    }
+    return params;
+  }
+
+  function scopeFromParams(params) {
+    var kind = params.get("scope") || "global";
+    if (kind === "repo" && params.get("repo")) return { kind: "repo", repo: params.get("repo") };
+    if (kind === "directory" && params.get("dir")) return { kind: "directory", directory: params.get("dir") };
+    if (kind === "tags" && params.get("tags")) return { kind: "tags", tags: params.get("tags").split(",") };
+    return { kind: "global" };
+  }
+
+  if (aiScopeSelect) {
+    aiScopeSelect.addEventListener("change", function() {
+      sharedScope = null;
+      if (aiTagsInput) aiTagsInput.hidden = this.value !== "tags";
+    });
+  }
+  if (aiTagsInput) {
+    aiTagsInput.addEventListener("input", function() { sharedScope = null; });
+  }
+
+  // scopeShardQuery returns a query prefix that selects only the shards a
+  // scope can match, so scoped local searches load less of the index.
+  function scopeShardQuery(query, scope) {
+    if (scope.kind === "repo") return scope.repo + "/";
+    if (scope.kind === "directory") return scope.directory + "/";
+    return query;
+  }
+
+  function localSearch(query, scope) {
     if (!searchIndex) return [];
+    var prefix = "";
+    if (scope && scope.kind === "repo") prefix = scope.repo + "/";
+    if (scope && scope.kind === "directory") prefix = scope.directory + "/";
     var terms = query.toLowerCase().split(/\s+/);
     var scored = [];
     searchIndex.forEach(function(entry) {
+      if (prefix && entry.path.indexOf(prefix) !== 0) return;
       var text = ((entry.title || "") + " " + (entry.summary || "") + " " + (entry.content || "")).toLowerCase();
       var hits = 0;
       terms.forEach(function(t) { if (text.indexOf(t) >= 0) hits++; });
@@ -1532,44 +1902,65 @@ const jsContent = `(function() {
     return scored.slice(0, 10);
   }
 
-  if (aiSearchInput) {
-    aiSearchInput.addEventListener("keydown", function(e) {
-      if (e.key !== "Enter") return;
-      var query = this.value.trim();
-      if (!query) return;
-
-      showAILoading();
+  function runAISearch(query, scope) {
+    showAILoading();
+    if (window.history && window.history.replaceState) {
+      window.history.replaceState(null, "", "?" + scopeToParams(query, scope).toString() + window.location.hash);
+    }
 
-      fetch("/api/search", {
-        method: "POST",
-        headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ query: query, limit: 10 })
-      })
-      .then(function(r) {
-        var ct = r.headers.get("content-type") || "";
-        if (!r.ok || ct.indexOf("application/json") === -1) {
-          throw new Error("_fallback_");
-        }
-        return r.json();
-      })
-      .then(function(data) {
-        var results = Array.isArray(data) ? data : (data.results || []);
-        var answer = data.answer || "";
-        showAIResults(query, results, answer);
-      })
-      .catch(function(err) {
-        if (err.message === "_fallback_" || err instanceof SyntaxError) {
-          var local = localSearch(query);
+    fetch("/api/search", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ query: query, limit: 10, scope: scope })
+    })
+    .then(function(r) {
+      var ct = r.headers.get("content-type") || "";
+      if (!r.ok || ct.indexOf("application/json") === -1) {
+        throw new Error("_fallback_");
+      }
+      return r.json();
+    })
+    .then(function(data) {
+      var results = Array.isArray(data) ? data : (data.results || []);
+      var answer = data.answer || "";
+      showAIResults(query, results, answer, scope, data.citations);
+    })
+    .catch(function(err) {
+      if (err.message === "_fallback_" || err instanceof SyntaxError) {
+        ensureShardsFor(scopeShardQuery(query, scope)).then(function() {
+          var local = localSearch(query, scope);
           if (local.length > 0) {
-            showAIResults(query, local, "");
+            showAIResults(query, local, "", scope);
           } else {
             showAIError("No results found for your query.");
           }
-        } else {
-          showAIError(err.message || "Search failed.");
-        }
-      });
+        });
+      } else {
+        showAIError(err.message || "Search failed.");
+      }
+    });
+  }
+
+  if (aiSearchInput) {
+    aiSearchInput.addEventListener("keydown", function(e) {
+      if (e.key !== "Enter") return;
+      var query = this.value.trim();
+      if (!query) return;
+      runAISearch(query, currentScope());
     });
+
+    // Re-run a search shared by URL, with the scope it was made in.
+    var sharedParams = new URLSearchParams(window.location.search);
+    if (sharedParams.get("q")) {
+      sharedScope = scopeFromParams(sharedParams);
+      aiSearchInput.value = sharedParams.get("q");
+      if (aiScopeSelect) aiScopeSelect.value = sharedScope.kind;
+      if (aiTagsInput && sharedScope.tags) {
+        aiTagsInput.value = sharedScope.tags.join(", ");
+        aiTagsInput.hidden = false;
+      }
+      runAISearch(sharedParams.get("q"), sharedScope);
+    }
   }
 
   // ===== Copy buttons for code blocks =====
@@ -1590,6 +1981,27 @@ const jsContent = `(function() {
     pre.appendChild(btn);
   });
 
+  // ===== Code example tabs =====
+  document.querySelectorAll(".code-tabs").forEach(function(group) {
+    var tabs = Array.prototype.filter.call(group.children, function(el) {
+      return el.classList.contains("code-tab");
+    });
+    if (tabs.length === 0) return;
+    var bar = document.createElement("div");
+    bar.className = "code-tab-bar";
+    tabs.forEach(function(tab, i) {
+      var btn = document.createElement("button");
+      btn.textContent = tab.getAttribute("data-label");
+      btn.addEventListener("click", function() {
+        tabs.forEach(function(t, j) { t.hidden = j !== i; });
+        bar.querySelectorAll("button").forEach(function(b, j) { b.classList.toggle("active", j === i); });
+      });
+      bar.appendChild(btn);
+    });
+    group.insertBefore(bar, group.firstChild);
+    bar.firstChild.click();
+  });
+
   // ===== Architecture diagram JSON→SVG renderer =====
   function escSvg(s) {
     return String(s).replace(/&/g,"&amp;").replace(/</g,"&lt;").replace(/>/g,"&gt;").replace(/"/g,"&quot;");
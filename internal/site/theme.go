@@ -0,0 +1,176 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+)
+
+// Theme brands the generated site without forking its templates. Paths
+// are read at generation time and copied into the site.
+type Theme struct {
+	// PrimaryColor is the accent and link color, e.g. "#7048e8";
+	// PrimaryColorDark is the same in dark mode and defaults to it.
+	PrimaryColor     string
+	PrimaryColorDark string
+	// Font and CodeFont are CSS font-family lists for text and code.
+	Font     string
+	CodeFont string
+	// CSSPath and JSPath are a stylesheet and a script loaded on every page
+	// after the built-in ones.
+	CSSPath string
+	JSPath  string
+	// FaviconPath is the site's favicon.
+	FaviconPath string
+	// Footer is markdown shown at the foot of every page.
+	Footer string
+}
+
+// themeFiles is what a Theme adds to each page: paths relative to the site
+// root, and the rendered footer.
+type themeFiles struct {
+	CSS     string
+	JS      string
+	Favicon string
+	Footer  template.HTML
+}
+
+// write copies the theme's files into outDir.
+func (t *Theme) write(outDir string, md goldmark.Markdown) (themeFiles, error) {
+	var files themeFiles
+	if t == nil {
+		return files, nil
+	}
+
+	var css strings.Builder
+	if vars := t.variables(); vars != "" {
+		css.WriteString(vars)
+	}
+	if t.CSSPath != "" {
+		custom, err := os.ReadFile(t.CSSPath)
+		if err != nil {
+			return files, fmt.Errorf("reading theme stylesheet: %w", err)
+		}
+		css.Write(custom)
+	}
+	if css.Len() > 0 {
+		files.CSS = "theme.css"
+		if err := os.WriteFile(filepath.Join(outDir, files.CSS), []byte(css.String()), 0o644); err != nil {
+			return files, err
+		}
+	}
+
+	if t.JSPath != "" {
+		files.JS = "theme.js"
+		if err := copyFile(t.JSPath, filepath.Join(outDir, files.JS)); err != nil {
+			return files, fmt.Errorf("copying theme script: %w", err)
+		}
+	}
+	if t.FaviconPath != "" {
+		files.Favicon = "favicon" + filepath.Ext(t.FaviconPath)
+		if err := copyFile(t.FaviconPath, filepath.Join(outDir, files.Favicon)); err != nil {
+			return files, fmt.Errorf("copying favicon: %w", err)
+		}
+	}
+	if t.Footer != "" {
+		var footer bytes.Buffer
+		if err := md.Convert([]byte(t.Footer), &footer); err != nil {
+			return files, fmt.Errorf("rendering footer: %w", err)
+		}
+		files.Footer = template.HTML(footer.String())
+	}
+	return files, nil
+}
+
+// variables overrides the built-in stylesheet's colors and fonts.
+func (t *Theme) variables() string {
+	var light, dark []string
+	if t.PrimaryColor != "" {
+		light = append(light, accentVariables(t.PrimaryColor)...)
+	}
+	if c := t.PrimaryColorDark; c != "" {
+		dark = accentVariables(c)
+	} else if t.PrimaryColor != "" {
+		dark = light
+	}
+	if t.Font != "" {
+		light = append(light, "--font: "+t.Font+";")
+	}
+	if t.CodeFont != "" {
+		light = append(light, "--code-font: "+t.CodeFont+";")
+	}
+
+	var b strings.Builder
+	if len(light) > 0 {
+		fmt.Fprintf(&b, ":root {\n  %s\n}\n", strings.Join(light, "\n  "))
+	}
+	if len(dark) > 0 {
+		fmt.Fprintf(&b, "[data-theme=\"dark\"] {\n  %s\n}\n", strings.Join(dark, "\n  "))
+	}
+	return b.String()
+}
+
+func accentVariables(color string) []string {
+	return []string{
+		"--accent: " + color + ";",
+		"--accent-hover: color-mix(in srgb, " + color + " 85%, black);",
+		"--accent-light: color-mix(in srgb, " + color + " 12%, transparent);",
+		"--link: " + color + ";",
+	}
+}
+
+// pageSection is the top-level directory a page sits in, such as a repo on
+// the central site, when that directory has its own logo.
+type pageSection struct {
+	Dir  string
+	Name string
+	Logo string // relative to the site root
+}
+
+// writeSectionLogos copies each section's logo into its directory in
+// outDir, and returns the sections by directory. titles name a section
+// after its index page.
+func (g *SiteGenerator) writeSectionLogos(outDir string, titles map[string]string) map[string]pageSection {
+	sections := make(map[string]pageSection, len(g.SectionLogos))
+	dirs := make([]string, 0, len(g.SectionLogos))
+	for dir := range g.SectionLogos {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		path := g.SectionLogos[dir]
+		logo := sectionLogo(dir, path)
+		if err := copyFile(path, filepath.Join(outDir, filepath.FromSlash(logo))); err != nil {
+			slog.Warn("could not copy logo", "phase", "assets", "section", dir, "path", path, "err", err)
+			continue
+		}
+		name := titles[dir+"/index.md"]
+		if name == "" {
+			name = dir
+		}
+		sections[dir] = pageSection{Dir: dir, Name: name, Logo: logo}
+	}
+	return sections
+}
+
+// sectionLogo is where the logo at path is copied for the section in dir,
+// relative to the site root.
+func sectionLogo(dir, path string) string {
+	return dir + "/logo" + filepath.Ext(path)
+}
+
+// sectionOf returns the section the page at relPath belongs to.
+func sectionOf(sections map[string]pageSection, relPath string) pageSection {
+	dir, _, nested := strings.Cut(relPath, "/")
+	if !nested {
+		return pageSection{}
+	}
+	return sections[dir]
+}
@@ -0,0 +1,263 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+	"github.com/ziadkadry99/auto-doc/internal/threatmodel"
+)
+
+// threat is a candidate threat in a flow's starter threat model.
+type threat struct {
+	ID       string // "<from>-><to>/<STRIDE letter>", e.g. "orders->payments/T"
+	Category string
+	Text     string
+	Status   string
+	Note     string // the security team's note on the status
+	// Provenance is who refined the threat, and when; empty for generated
+	// threats nobody has noted.
+	Provenance string
+}
+
+// flowThreatModel is a flow's starter STRIDE threat model.
+type flowThreatModel struct {
+	Boundaries []string // trust boundaries the flow crosses
+	Stores     []string // "store (service)" for each data store the flow's services use
+	Threats    []threat
+}
+
+// threatModel infers a starter threat model for a flow: the trust
+// boundaries it crosses — from the internet into its internet-facing
+// services, out to dependencies outside the system, and into data stores —
+// and candidate threats at each boundary and each hop between its
+// services, then applies the notes made on them.
+func (g *CentralSiteGenerator) threatModel(f FlowInfo, facing map[string]bool, stores map[string][]string) flowThreatModel {
+	var m flowThreatModel
+	inFlow := make(map[string]bool, len(f.Services))
+	for _, s := range f.Services {
+		inFlow[s] = true
+	}
+	registered := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		registered[r.Name] = true
+	}
+	add := func(from, to, letter, text string) {
+		id := from + "->" + to + "/" + letter
+		m.Threats = append(m.Threats, threat{ID: id, Category: threatmodel.CategoryOf(id), Text: text, Status: threatmodel.StatusOpen})
+	}
+
+	for _, s := range f.Services {
+		if !facing[s] {
+			continue
+		}
+		m.Boundaries = append(m.Boundaries, "Internet → "+s)
+		text := fmt.Sprintf("Are callers from the internet authenticated before %s acts for them?", s)
+		if open := g.unauthenticatedEndpoints(s); len(open) > 0 {
+			text += fmt.Sprintf(" %d of its endpoints have no authentication found (see the [Exposure Report](exposure.md)).", len(open))
+		}
+		add("internet", s, "S", text)
+		add("internet", s, "D", fmt.Sprintf("Is %s rate-limited, so a flood of requests from the internet can't take the flow down?", s))
+	}
+
+	// Hops in flow order: by the position of the caller, then the callee.
+	pos := func(s string) int {
+		if i := slices.Index(f.Services, s); i >= 0 {
+			return i
+		}
+		return len(f.Services)
+	}
+	var hops []LinkInfo
+	for _, l := range g.Links {
+		if inFlow[l.FromRepo] && (inFlow[l.ToRepo] || !registered[l.ToRepo]) {
+			hops = append(hops, l)
+		}
+	}
+	sort.SliceStable(hops, func(i, j int) bool {
+		if pos(hops[i].FromRepo) != pos(hops[j].FromRepo) {
+			return pos(hops[i].FromRepo) < pos(hops[j].FromRepo)
+		}
+		return pos(hops[i].ToRepo) < pos(hops[j].ToRepo)
+	})
+	for _, l := range hops {
+		from, to := l.FromRepo, l.ToRepo
+		classes := g.sharedClasses(from, to)
+		switch {
+		case !registered[to]:
+			m.Boundaries = append(m.Boundaries, from+" → "+to+" (outside the system)")
+			add(from, to, "S", fmt.Sprintf("Does %s check it is talking to the real %s: TLS certificates verified, and signatures checked on any callbacks?", from, to))
+			text := fmt.Sprintf("Data %s sends to %s leaves the system; is only what %s needs shared?", from, to, to)
+			if held := g.heldClasses(from); len(held) > 0 {
+				text += fmt.Sprintf(" %s holds %s.", from, g.classLabels(held))
+			}
+			add(from, to, "I", text)
+		case isAsyncLink(l.LinkType):
+			topics := fmt.Sprintf("the topics %s consumes from %s", to, from)
+			if len(l.Endpoints) > 0 {
+				topics = "`" + strings.Join(l.Endpoints, "`, `") + "`"
+			}
+			add(from, to, "S", fmt.Sprintf("Can anything besides %s publish to %s? Check who may produce to it.", from, topics))
+			add(from, to, "T", fmt.Sprintf("Messages on %s could be forged or replayed; does %s validate them and handle duplicates safely?", topics, to))
+			add(from, to, "R", fmt.Sprintf("Can %s tell which producer sent a message, for audit?", to))
+			if len(classes) > 0 {
+				add(from, to, "I", fmt.Sprintf("Messages on %s carry %s; are they encrypted in transit and at rest in the broker, and kept out of logs?", topics, g.classLabels(classes)))
+			}
+			add(from, to, "D", fmt.Sprintf("Could a burst of messages on %s overwhelm %s? Check consumer limits and dead-letter handling.", topics, to))
+		default:
+			add(from, to, "S", fmt.Sprintf("Can %s tell calls from %s apart from any other workload on the network? Check for mTLS or service credentials.", to, from))
+			add(from, to, "T", fmt.Sprintf("Are calls from %s to %s protected in transit by TLS, and safe to replay?", from, to))
+			if len(classes) > 0 {
+				add(from, to, "I", fmt.Sprintf("The hop carries %s; is it encrypted in transit and kept out of logs and traces?", g.classLabels(classes)))
+			}
+			add(from, to, "D", fmt.Sprintf("A slow or failing %s holds up %s; are there timeouts, retries with backoff, and circuit breaking?", to, from))
+			if open := g.unauthenticatedEndpoints(to); len(open) > 0 {
+				add(from, to, "E", fmt.Sprintf("%s has %d endpoints with no authentication found, e.g. `%s`; anything that reaches it on the network can call them.", to, len(open), open[0]))
+			}
+		}
+	}
+
+	for _, s := range f.Services {
+		for _, store := range stores[s] {
+			m.Boundaries = append(m.Boundaries, s+" → "+store+" (data store)")
+			m.Stores = append(m.Stores, fmt.Sprintf("`%s` (%s)", store, s))
+			add(s, store, "T", fmt.Sprintf("Do %s's credentials for %s allow only what it needs, so a compromised %s can't alter other data?", s, store, s))
+			text := fmt.Sprintf("Is %s encrypted at rest, with its backups protected?", store)
+			if held := g.heldClasses(s); len(held) > 0 {
+				text += fmt.Sprintf(" %s holds %s.", s, g.classLabels(held))
+			}
+			add(s, store, "I", text)
+		}
+	}
+
+	g.applyThreatNotes(f.Name, &m)
+	return m
+}
+
+// unauthenticatedEndpoints returns a service's endpoints with no
+// authentication found, from the Exposure Report's analysis.
+func (g *CentralSiteGenerator) unauthenticatedEndpoints(service string) []string {
+	var open []string
+	for _, e := range g.exposure[service] {
+		if e.Auth == indexer.AuthNone {
+			open = append(open, e.Route)
+		}
+	}
+	return open
+}
+
+// heldClasses returns the data classes a service carries, from the PII
+// Flow's analysis.
+func (g *CentralSiteGenerator) heldClasses(service string) []string {
+	if g.dataFlow == nil {
+		return nil
+	}
+	return g.dataFlow.services[service]
+}
+
+// sharedClasses returns the data classes both services carry.
+func (g *CentralSiteGenerator) sharedClasses(a, b string) []string {
+	var shared []string
+	for _, c := range g.heldClasses(a) {
+		if slices.Contains(g.heldClasses(b), c) {
+			shared = append(shared, c)
+		}
+	}
+	return shared
+}
+
+// applyThreatNotes applies the notes made on a flow's threats, oldest
+// first, so the latest note on a threat wins. A note on a threat the model
+// doesn't have adds it.
+func (g *CentralSiteGenerator) applyThreatNotes(flow string, m *flowThreatModel) {
+	for _, n := range g.ThreatNotes {
+		if !strings.EqualFold(n.Flow, flow) {
+			continue
+		}
+		i := slices.IndexFunc(m.Threats, func(t threat) bool { return strings.EqualFold(t.ID, n.ThreatID) })
+		if i < 0 {
+			m.Threats = append(m.Threats, threat{ID: n.ThreatID, Category: threatmodel.CategoryOf(n.ThreatID), Status: threatmodel.StatusOpen})
+			i = len(m.Threats) - 1
+		}
+		t := &m.Threats[i]
+		if n.Status == "" {
+			t.Text = n.Text
+		} else {
+			t.Status, t.Note = n.Status, n.Text
+		}
+		t.Provenance = n.Provenance()
+	}
+}
+
+// storeDependencies returns the data stores each repo's analyses connect
+// to, by repo name.
+func (g *CentralSiteGenerator) storeDependencies() map[string][]string {
+	stores := make(map[string][]string)
+	for _, r := range g.Repos {
+		seen := make(map[string]bool)
+		for _, a := range g.repoAnalyses(r) {
+			for _, d := range a.Dependencies {
+				if d.Type == indexer.DepDatabase && !seen[strings.ToLower(d.Name)] {
+					seen[strings.ToLower(d.Name)] = true
+					stores[r.Name] = append(stores[r.Name], d.Name)
+				}
+			}
+		}
+		sort.Strings(stores[r.Name])
+	}
+	return stores
+}
+
+// writeThreatModelPage writes threat-model.md: a starter STRIDE threat
+// model for each flow, for security teams to refine in conversation.
+func (g *CentralSiteGenerator) writeThreatModelPage(stagingDir string) error {
+	facing := g.internetFacing()
+	stores := g.storeDependencies()
+
+	var b strings.Builder
+	b.WriteString("# Threat Model\n\n")
+	b.WriteString("A starter STRIDE threat model for each [cross-service flow](flows.md): the trust boundaries it crosses, the data stores it touches, and candidate threats at each boundary and hop. ")
+	b.WriteString("It is a place to start, not a verdict; refine it in conversation, e.g. \"in Checkout, orders->payments/T is mitigated: mTLS between all services\" or \"orders->payments/I is not applicable\", and the notes are kept on every regeneration.\n\n")
+	for _, f := range g.Flows {
+		m := g.threatModel(f, facing, stores)
+		fmt.Fprintf(&b, "## %s\n\n", f.Name)
+		if len(f.Services) > 0 {
+			b.WriteString("**Services involved:** " + strings.Join(f.Services, ", ") + "\n\n")
+		}
+		if len(m.Boundaries) > 0 {
+			b.WriteString("**Trust boundaries:**\n\n")
+			for _, boundary := range m.Boundaries {
+				b.WriteString("- " + boundary + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if len(m.Stores) > 0 {
+			b.WriteString("**Data stores:** " + strings.Join(m.Stores, ", ") + "\n\n")
+		}
+		if len(m.Threats) == 0 {
+			b.WriteString("*No hops or boundaries were found to model.*\n\n")
+			continue
+		}
+		b.WriteString("| ID | Category | Threat | Status |\n")
+		b.WriteString("|----|----------|--------|--------|\n")
+		for _, t := range m.Threats {
+			category := t.Category
+			if category == "" {
+				category = "—"
+			}
+			status := strings.ToUpper(t.Status[:1]) + t.Status[1:]
+			if t.Note != "" {
+				status += ": " + t.Note
+			}
+			if t.Provenance != "" {
+				status += " *(" + t.Provenance + ")*"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", t.ID, category, strings.ReplaceAll(t.Text, "|", `\|`), strings.ReplaceAll(status, "|", `\|`))
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(filepath.Join(stagingDir, "threat-model.md"), []byte(b.String()), 0o644)
+}
@@ -14,6 +14,20 @@ type FileTree struct {
 	Path     string // For files: full relative path. For dirs: directory path (e.g., "internal/config").
 	IsDir    bool
 	Children []*FileTree
+	// Order sorts the node among its siblings, lower first; see NavEntry.
+	Order int
+	// IsGroup marks a sidebar group: a directory node with no directory
+	// of its own, holding top-level directories; see Group.
+	IsGroup bool
+}
+
+// NavEntry places a page or directory in the sidebar. Order sorts it among
+// its siblings, lower first; nodes without an entry have order 0 and keep
+// the usual order among themselves. An empty Title keeps the page's heading
+// or the directory's name.
+type NavEntry struct {
+	Title string
+	Order int
 }
 
 // BuildTree constructs a FileTree from a list of relative file paths.
@@ -62,13 +76,100 @@ func BuildTree(paths []string, titleMap map[string]string) *FileTree {
 	return root
 }
 
-// sortTree recursively sorts tree children: directories first, then files, alphabetically.
+// Arrange retitles and reorders the tree's nodes by their paths in nav.
+func (t *FileTree) Arrange(nav map[string]NavEntry) {
+	if len(nav) == 0 {
+		return
+	}
+	var walk func(node *FileTree)
+	walk = func(node *FileTree) {
+		for _, child := range node.Children {
+			if e, ok := nav[child.Path]; ok {
+				if e.Title != "" {
+					child.Title = e.Title
+				}
+				child.Order = e.Order
+			}
+			if child.IsDir {
+				walk(child)
+			}
+		}
+	}
+	walk(t)
+	sortTree(t)
+}
+
+// Group nests the tree's top-level directories under sidebar groups, by
+// name: a directory with the path ["Commerce", "Checkout"] goes in the
+// Checkout group within the Commerce group. Groups sort among the other
+// directories by title.
+func (t *FileTree) Group(groups map[string][]string) {
+	if len(groups) == 0 {
+		return
+	}
+	var kept []*FileTree
+	for _, child := range t.Children {
+		path := groups[child.Name]
+		if !child.IsDir || len(path) == 0 {
+			kept = append(kept, child)
+			continue
+		}
+		parent := t
+		for i, title := range path {
+			var group *FileTree
+			for _, c := range parent.Children {
+				if c.IsGroup && c.Title == title {
+					group = c
+					break
+				}
+			}
+			if group == nil {
+				group = &FileTree{
+					Name:    title,
+					Title:   title,
+					Path:    "group:" + strings.Join(path[:i+1], "/"),
+					IsDir:   true,
+					IsGroup: true,
+				}
+				parent.Children = append(parent.Children, group)
+				if parent == t {
+					kept = append(kept, group)
+				}
+			}
+			parent = group
+		}
+		parent.Children = append(parent.Children, child)
+	}
+	t.Children = kept
+	sortTree(t)
+}
+
+// holds reports whether any directory under a group is one of the
+// active page's ancestors.
+func (t *FileTree) holds(activeAncestors map[string]bool) bool {
+	for _, c := range t.Children {
+		if c.IsDir && (activeAncestors[c.Path] || c.IsGroup && c.holds(activeAncestors)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTree recursively sorts tree children: a subdirectory's overview page
+// first, then by Order, then directories, then files, alphabetically.
 func sortTree(node *FileTree) {
 	sort.Slice(node.Children, func(i, j int) bool {
-		if node.Children[i].IsDir != node.Children[j].IsDir {
-			return node.Children[i].IsDir
+		a, b := node.Children[i], node.Children[j]
+		if a.isOverview() != b.isOverview() {
+			return a.isOverview()
 		}
-		return node.Children[i].Name < node.Children[j].Name
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		return a.Name < b.Name
 	})
 	for _, child := range node.Children {
 		if child.IsDir {
@@ -114,7 +215,7 @@ func renderChildren(b *strings.Builder, node *FileTree, activePath, basePath str
 	for _, child := range node.Children {
 		if child.IsDir {
 			expanded := ""
-			if activeAncestors[child.Path] {
+			if activeAncestors[child.Path] || child.IsGroup && child.holds(activeAncestors) {
 				expanded = "expanded"
 			}
 			dirLabel := child.Title
@@ -130,7 +231,9 @@ func renderChildren(b *strings.Builder, node *FileTree, activePath, basePath str
 			}
 			htmlPath := basePath + mdPathToHTML(child.Path)
 			displayName := child.Title
-			if displayName == "" {
+			if child.isOverview() {
+				displayName = "Overview"
+			} else if displayName == "" {
 				displayName = cleanDisplayName(child.Name)
 			}
 			activeClass := ""
@@ -143,6 +246,12 @@ func renderChildren(b *strings.Builder, node *FileTree, activePath, basePath str
 	b.WriteString("</ul>\n")
 }
 
+// isOverview reports whether the node is a subdirectory's index page. The
+// root index is the Home link and keeps its place among the files.
+func (t *FileTree) isOverview() bool {
+	return !t.IsDir && t.Name == "index.md" && t.Path != "index.md"
+}
+
 // mdPathToHTML converts a markdown path to its HTML equivalent.
 func mdPathToHTML(p string) string {
 	if strings.HasSuffix(p, ".md") {
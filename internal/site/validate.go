@@ -0,0 +1,416 @@
+package site
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Kinds of validation issue.
+const (
+	IssueBrokenLink     = "broken-link"
+	IssueMermaid        = "mermaid"
+	IssueUnknownService = "unknown-service"
+)
+
+// ValidationIssue is a problem found while generating a site.
+type ValidationIssue struct {
+	Kind string
+	// Page is the markdown page the issue is on, relative to the docs;
+	// empty for issues in the registry data, such as unknown services.
+	Page   string
+	Detail string
+}
+
+// ValidationReport collects what is wrong with a generated site: intra-site
+// links to pages that don't exist, Mermaid diagrams that won't parse, and,
+// on the central site, references to services that aren't registered.
+// Post-processing still drops dead Quick Links sections; the report says
+// which links were dead.
+type ValidationReport struct {
+	Issues []ValidationIssue
+}
+
+// add records an issue; on a nil report it does nothing.
+func (r *ValidationReport) add(issues ...ValidationIssue) {
+	if r == nil {
+		return
+	}
+	r.Issues = append(r.Issues, issues...)
+}
+
+// Count returns the number of issues of a kind.
+func (r *ValidationReport) Count(kind string) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary is a one-line count of the issues, e.g. "2 broken links, 1
+// Mermaid diagram that does not parse".
+func (r *ValidationReport) Summary() string {
+	if len(r.Issues) == 0 {
+		return "no issues"
+	}
+	var parts []string
+	for _, k := range issueKinds {
+		if n := r.Count(k.kind); n > 0 {
+			noun := k.plural
+			if n == 1 {
+				noun = k.singular
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, noun))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// issueKinds orders and names the kinds of issue in summaries and on the
+// report page.
+var issueKinds = []struct {
+	kind, heading, singular, plural string
+}{
+	{IssueBrokenLink, "Broken Links", "broken link", "broken links"},
+	{IssueMermaid, "Diagrams That Do Not Parse", "Mermaid diagram that does not parse", "Mermaid diagrams that do not parse"},
+	{IssueUnknownService, "Unregistered Services", "reference to an unregistered service", "references to unregistered services"},
+}
+
+// validationPage is where the report is published, relative to the site
+// root.
+const validationPage = "site-validation.md"
+
+// markdown renders the report as the site's validation page.
+func (r *ValidationReport) markdown() string {
+	var b strings.Builder
+	b.WriteString("# Site Validation\n\n")
+	if len(r.Issues) == 0 {
+		b.WriteString("No broken links, unparseable diagrams, or unregistered services were found.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Found %s when this site was generated.\n", r.Summary())
+	for _, k := range issueKinds {
+		if r.Count(k.kind) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n| Page | Problem |\n|------|---------|\n", k.heading)
+		for _, issue := range r.Issues {
+			if issue.Kind != k.kind {
+				continue
+			}
+			page := "—"
+			if issue.Page != "" {
+				page = fmt.Sprintf("[%s](%s)", issue.Page, issue.Page)
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", page, strings.ReplaceAll(issue.Detail, "|", `\|`))
+		}
+	}
+	return b.String()
+}
+
+// checkDocs looks for broken links and Mermaid diagrams that don't parse
+// in the pages at mdPaths, relative to docsDir.
+func (r *ValidationReport) checkDocs(docsDir string, mdPaths []string, concurrency int) {
+	pages := make(map[string]bool, len(mdPaths))
+	for _, p := range mdPaths {
+		pages[p] = true
+	}
+	found := make([][]ValidationIssue, len(mdPaths))
+	parallel(len(mdPaths), concurrency, func(i int) {
+		src, err := os.ReadFile(filepath.Join(docsDir, filepath.FromSlash(mdPaths[i])))
+		if err != nil {
+			return
+		}
+		found[i] = checkPage(docsDir, pages, mdPaths[i], src)
+	})
+	for _, issues := range found {
+		r.add(issues...)
+	}
+}
+
+// checkPage returns the issues in one markdown page.
+func checkPage(docsDir string, pages map[string]bool, page string, src []byte) []ValidationIssue {
+	var issues []ValidationIssue
+	doc := newMarkdown().Parser().Parse(text.NewReader(src))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var dest string
+		switch n := n.(type) {
+		case *ast.Link:
+			dest = string(n.Destination)
+		case *ast.Image:
+			dest = string(n.Destination)
+		case *ast.FencedCodeBlock:
+			if string(n.Language(src)) == "mermaid" {
+				var diagram strings.Builder
+				for i := 0; i < n.Lines().Len(); i++ {
+					line := n.Lines().At(i)
+					diagram.Write(line.Value(src))
+				}
+				if err := checkMermaid(diagram.String()); err != nil {
+					issues = append(issues, ValidationIssue{Kind: IssueMermaid, Page: page, Detail: err.Error()})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		default:
+			return ast.WalkContinue, nil
+		}
+		if problem := checkLink(docsDir, pages, page, dest); problem != "" {
+			issues = append(issues, ValidationIssue{Kind: IssueBrokenLink, Page: page, Detail: fmt.Sprintf("`%s` %s", dest, problem)})
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
+
+// checkLink says what is wrong with a link from page to dest, or returns
+// "" when it resolves. Links off the site are not followed.
+func checkLink(docsDir string, pages map[string]bool, page, dest string) string {
+	if dest == "" || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "/") {
+		return ""
+	}
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	target, _, _ := strings.Cut(dest, "#")
+	target, _, _ = strings.Cut(target, "?")
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if target == "" {
+		return ""
+	}
+	dir := strings.HasSuffix(target, "/")
+	target = path.Join(path.Dir(page), target)
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "points outside the site"
+	}
+	switch {
+	case target == ".":
+		target = "index.md"
+	case dir || pages[target+"/index.md"]:
+		target += "/index.md"
+	}
+
+	switch path.Ext(target) {
+	case ".md":
+		if pages[target] {
+			return ""
+		}
+	case ".html", ".dsl":
+		if pages[strings.TrimSuffix(target, ".html")+".md"] {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(docsDir, filepath.FromSlash(target))); err == nil {
+			return ""
+		}
+	default:
+		if _, err := os.Stat(filepath.Join(docsDir, filepath.FromSlash(target))); err == nil {
+			return "is not published with the site"
+		}
+	}
+	return "points to a page that does not exist"
+}
+
+// mermaidDiagrams are the diagram types Mermaid knows.
+var mermaidDiagrams = map[string]bool{
+	"graph": true, "flowchart": true, "flowchart-elk": true, "sequenceDiagram": true,
+	"classDiagram": true, "classDiagram-v2": true,
+	"stateDiagram": true, "stateDiagram-v2": true,
+	"erDiagram": true, "journey": true, "gantt": true, "pie": true,
+	"gitGraph": true, "mindmap": true, "timeline": true, "quadrantChart": true,
+	"requirementDiagram": true, "C4Context": true, "C4Container": true,
+	"C4Component": true, "C4Dynamic": true, "C4Deployment": true,
+	"sankey-beta": true, "xychart-beta": true, "block-beta": true,
+	"packet-beta": true, "architecture-beta": true, "kanban": true,
+}
+
+// sequenceBlocks open a block in a sequence diagram that "end" closes.
+var sequenceBlocks = map[string]bool{
+	"loop": true, "alt": true, "opt": true, "par": true, "critical": true,
+	"break": true, "rect": true, "box": true,
+}
+
+// asymmetricShape matches the start of a flowchart node like id>label].
+var asymmetricShape = regexp.MustCompile(`[A-Za-z0-9_]>$`)
+
+// checkMermaid catches the mistakes that stop Mermaid from rendering a
+// diagram: an unknown diagram type, blocks left open or closed twice, and,
+// in flowcharts, unbalanced brackets, unterminated strings, and unquoted
+// labels holding brackets. It is not a full parser; a diagram it passes
+// can still fail in the browser.
+func checkMermaid(src string) error {
+	lines := strings.Split(src, "\n")
+	i := 0
+	// Skip blank lines, comments, and a front matter block.
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "---"; i++ {
+			}
+			continue
+		}
+		if line != "" && !strings.HasPrefix(line, "%%") {
+			break
+		}
+	}
+	if i == len(lines) {
+		return fmt.Errorf("diagram is empty")
+	}
+	kind := strings.Fields(lines[i])[0]
+	if !mermaidDiagrams[kind] {
+		return fmt.Errorf("unknown diagram type %q", kind)
+	}
+
+	var open []string // blocks open at this line, outermost first
+	for n := i + 1; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		first := strings.Fields(line)[0]
+		lineNo := n + 1
+		switch kind {
+		case "graph", "flowchart", "flowchart-elk":
+			switch {
+			case first == "subgraph":
+				open = append(open, "subgraph")
+			case line == "end":
+				if len(open) == 0 {
+					return fmt.Errorf("line %d: end without subgraph", lineNo)
+				}
+				open = open[:len(open)-1]
+			}
+			switch first {
+			case "classDef", "class", "style", "linkStyle", "click", "direction":
+				continue
+			}
+			if err := checkFlowchartLine(line); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+		case "sequenceDiagram":
+			switch {
+			case sequenceBlocks[first]:
+				open = append(open, first)
+			case first == "else" || first == "and" || first == "option":
+				if len(open) == 0 {
+					return fmt.Errorf("line %d: %s outside a block", lineNo, first)
+				}
+			case line == "end":
+				if len(open) == 0 {
+					return fmt.Errorf("line %d: end without a block to close", lineNo)
+				}
+				open = open[:len(open)-1]
+			}
+		case "classDiagram", "classDiagram-v2", "stateDiagram", "stateDiagram-v2", "erDiagram":
+			if strings.HasSuffix(line, "{") {
+				open = append(open, first)
+			}
+			if strings.HasPrefix(line, "}") {
+				if len(open) == 0 {
+					return fmt.Errorf("line %d: } without a block to close", lineNo)
+				}
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%s block is never closed", open[len(open)-1])
+	}
+	return nil
+}
+
+// checkFlowchartLine checks a flowchart line's brackets and strings.
+// Shapes open with runs of brackets, like ((circle)) or [(database)]; a
+// bracket later in an unquoted label ends the label early.
+func checkFlowchartLine(line string) error {
+	closers := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []rune
+	inQuote, inEdgeLabel := false, false
+	prevOpener := false
+	for j, c := range line {
+		opener := false
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case c == '"':
+			inQuote = true
+		case inEdgeLabel:
+			inEdgeLabel = c != '|'
+		case c == '|' && len(stack) == 0:
+			inEdgeLabel = true
+		case c == '(' || c == '[' || c == '{':
+			if len(stack) > 0 && !prevOpener {
+				return fmt.Errorf("unquoted label contains %q; quote the label", c)
+			}
+			stack = append(stack, c)
+			opener = true
+		case c == '>' && len(stack) == 0 && asymmetricShape.MatchString(line[:j+1]):
+			stack = append(stack, '[')
+			opener = true
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 || stack[len(stack)-1] != closers[c] {
+				return fmt.Errorf("unbalanced %q", c)
+			}
+			stack = stack[:len(stack)-1]
+		}
+		prevOpener = opener
+	}
+	switch {
+	case inQuote:
+		return fmt.Errorf("unterminated string")
+	case len(stack) > 0:
+		return fmt.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+	return nil
+}
+
+// checkServiceReferences reports the flows and teams naming services that
+// aren't registered.
+func (g *CentralSiteGenerator) checkServiceReferences() {
+	if g.Validation == nil {
+		return
+	}
+	registered := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		registered[strings.ToLower(r.Name)] = true
+	}
+	for _, f := range g.Flows {
+		for _, svc := range f.Services {
+			if !registered[strings.ToLower(svc)] {
+				g.unknownService(svc, "flow "+f.Name)
+			}
+		}
+	}
+	for _, t := range g.Teams {
+		for _, svc := range t.Services {
+			if !registered[strings.ToLower(svc)] {
+				g.unknownService(svc, "team "+t.title())
+			}
+		}
+	}
+}
+
+// unknownService reports a reference to a service that isn't registered.
+func (g *CentralSiteGenerator) unknownService(name, where string) {
+	if g.Validation == nil {
+		return
+	}
+	issue := ValidationIssue{Kind: IssueUnknownService, Detail: fmt.Sprintf("%s names %s, which is not registered", where, name)}
+	if !slices.Contains(g.Validation.Issues, issue) {
+		g.Validation.add(issue)
+	}
+}
@@ -0,0 +1,57 @@
+package site
+
+// serviceMapViewJS gives the service map's state to mapviews.JS, which
+// follows it: the group and environment filters, the timeline's step, the
+// clusters, the route, the selected service, and the zoom. Like
+// serviceMapScaleJS it shares the map script's variables.
+const serviceMapViewJS = `
+// ===== Saved views: what the map shows, as URL params =====
+function viewState(){
+  var p = new URLSearchParams();
+  if(groupFilter.value) p.set('group', groupFilter.value);
+  if(envFilter.value) p.set('env', envFilter.value);
+  if(timeIndex != null) p.set('at', snapshots[timeIndex].at);
+  if(clusters.length){
+    if(clustered !== large) p.set('grouped', clustered ? '1' : '0');
+    if(clustered){
+      var open = clusters.filter(function(c){ return !collapsed[c.id]; });
+      if(open.length === clusters.length) p.set('expanded', '*');
+      else open.forEach(function(c){ p.append('expanded', c.id); });
+      if(!bundled) p.set('bundle', '0');
+    }
+  }
+  if(routing){
+    p.set('from', routeFrom.value);
+    p.set('to', routeTo.value);
+  } else if(selectedId) p.set('node', selectedId);
+  var t = d3.zoomTransform(zoomTarget);
+  if(t.k !== 1 || t.x || t.y) p.set('zoom', [t.k, t.x, t.y].map(function(v){ return +v.toFixed(2); }).join(','));
+  return p;
+}
+function applyViewState(p){
+  if(clusters.length){
+    var open = p.getAll('expanded');
+    clustered = p.has('grouped') ? p.get('grouped') === '1' : large;
+    bundled = clustered && p.get('bundle') !== '0';
+    clusters.forEach(function(c){ collapsed[c.id] = open.indexOf('*') < 0 && open.indexOf(c.id) < 0; });
+  }
+  groupFilter.value = p.get('group') || '';
+  envFilter.value = p.get('env') || '';
+  if(snapshots.length){
+    var i = snapshots.length;
+    snapshots.forEach(function(s, j){ if(s.at === p.get('at')) i = j; });
+    stopPlaying();
+    showTime(i);
+  } else applyGroupFilter();
+  routeFrom.value = p.get('from') || '';
+  routeTo.value = p.get('to') || '';
+  if(routing || p.has('from')) setRouting(p.has('from'));
+  if(!routing && nodeById[p.get('node')]) onClick(null, nodeById[p.get('node')]);
+  else if(!routing){ infoPanel.classList.add('hidden'); selectedId = null; }
+  var z = (p.get('zoom') || '').split(',').map(Number);
+  d3.select(zoomTarget).call(zoom.transform, z.length === 3 && z.every(isFinite) ? d3.zoomIdentity.translate(z[1], z[2]).scale(z[0]) : d3.zoomIdentity);
+  sim.alpha(0.3).restart();
+}
+zoom.on('end.view', viewChanged);
+document.getElementById('info-close').addEventListener('click', viewChanged);
+`
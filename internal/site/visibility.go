@@ -0,0 +1,252 @@
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/deprecation"
+	"github.com/ziadkadry99/auto-doc/internal/metrics"
+)
+
+// Repo visibility levels. Restricted repos name the team allowed to see
+// them, e.g. "restricted:payments".
+const (
+	VisibilityPublic     = "public"
+	VisibilityInternal   = "internal"
+	VisibilityRestricted = "restricted:"
+
+	// DefaultVisibility applies to repos without an explicit visibility, so
+	// nothing is published to partners unless marked public.
+	DefaultVisibility = VisibilityInternal
+)
+
+// Site audiences. An audience sees repos at or below its level:
+//
+//	public       public repos only
+//	internal     public and internal repos
+//	team:<name>  public, internal, and repos restricted to <name>
+//	all          every repo (the default)
+const (
+	AudienceAll      = "all"
+	AudiencePublic   = "public"
+	AudienceInternal = "internal"
+	AudienceTeam     = "team:"
+)
+
+// SiteVariant is one audience-filtered copy of the central site.
+type SiteVariant struct {
+	Name      string
+	Audience  string
+	OutputDir string
+}
+
+// ValidateVisibility checks a repo visibility value.
+func ValidateVisibility(v string) error {
+	switch {
+	case v == "", v == VisibilityPublic, v == VisibilityInternal:
+		return nil
+	case strings.HasPrefix(v, VisibilityRestricted) && len(v) > len(VisibilityRestricted):
+		return nil
+	}
+	return fmt.Errorf("invalid visibility %q: must be public, internal, or restricted:<team>", v)
+}
+
+// ValidateAudience checks a site audience value.
+func ValidateAudience(a string) error {
+	switch {
+	case a == "", a == AudienceAll, a == AudiencePublic, a == AudienceInternal:
+		return nil
+	case strings.HasPrefix(a, AudienceTeam) && len(a) > len(AudienceTeam):
+		return nil
+	}
+	return fmt.Errorf("invalid audience %q: must be all, public, internal, or team:<name>", a)
+}
+
+// VisibleTo reports whether a repo with the given visibility belongs on a
+// site for the audience.
+func VisibleTo(visibility, audience string) bool {
+	if visibility == "" {
+		visibility = DefaultVisibility
+	}
+	switch {
+	case audience == "" || audience == AudienceAll:
+		return true
+	case audience == AudiencePublic:
+		return visibility == VisibilityPublic
+	case audience == AudienceInternal:
+		return visibility == VisibilityPublic || visibility == VisibilityInternal
+	case strings.HasPrefix(audience, AudienceTeam):
+		if visibility == VisibilityPublic || visibility == VisibilityInternal {
+			return true
+		}
+		team := strings.TrimPrefix(audience, AudienceTeam)
+		return strings.EqualFold(strings.TrimPrefix(visibility, VisibilityRestricted), team)
+	}
+	return false
+}
+
+// applyAudience drops repos the audience may not see, along with links that
+// touch them, flows and changelog entries that involve them, and teams that
+// own none of the remaining repos, on the site and on the timeline. It is applied before and after link and
+// flow synthesis so nothing derived from a hidden repo leaks.
+func (g *CentralSiteGenerator) applyAudience() {
+	if g.Audience == "" || g.Audience == AudienceAll {
+		return
+	}
+
+	visible := make(map[string]bool, len(g.Repos))
+	known := make(map[string]bool, len(g.Repos))
+	for _, r := range g.Repos {
+		known[r.Name] = true
+	}
+	repos := g.Repos[:0]
+	for _, r := range g.Repos {
+		if VisibleTo(r.Visibility, g.Audience) {
+			repos = append(repos, r)
+			visible[r.Name] = true
+		}
+	}
+	g.Repos = repos
+
+	links := g.Links[:0]
+	for _, l := range g.Links {
+		if visible[l.FromRepo] && visible[l.ToRepo] {
+			links = append(links, l)
+		}
+	}
+	g.Links = links
+
+	runtimeOnly := g.RuntimeOnly[:0]
+	for _, l := range g.RuntimeOnly {
+		if visible[l.FromRepo] && visible[l.ToRepo] {
+			runtimeOnly = append(runtimeOnly, l)
+		}
+	}
+	g.RuntimeOnly = runtimeOnly
+
+	if g.Metrics != nil {
+		// The snapshot may be shared with other variants, so filter a copy.
+		m := *g.Metrics
+		m.Edges = make([]metrics.EdgeMetrics, 0, len(g.Metrics.Edges))
+		for _, e := range g.Metrics.Edges {
+			if visible[e.From] && visible[e.To] {
+				m.Edges = append(m.Edges, e)
+			}
+		}
+		g.Metrics = &m
+	}
+
+	flows := g.Flows[:0]
+	for _, f := range g.Flows {
+		ok := true
+		for _, svc := range f.Services {
+			if !visible[svc] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			flows = append(flows, f)
+		}
+	}
+	g.Flows = flows
+
+	changes := g.Changes[:0]
+	for _, c := range g.Changes {
+		ok := true
+		for _, svc := range c.Services {
+			if !visible[svc] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			changes = append(changes, c)
+		}
+	}
+	g.Changes = changes
+
+	// Services that have since gone have no visibility to check, so the
+	// timeline leaves them out; externals stay with their callers. The
+	// snapshots may be shared with other variants, so filter copies.
+	history := make([]GraphSnapshot, len(g.History))
+	for i, s := range g.History {
+		registered := make(map[string]bool, len(s.Services))
+		history[i] = GraphSnapshot{TakenAt: s.TakenAt}
+		for _, svc := range s.Services {
+			registered[svc] = true
+			if visible[svc] {
+				history[i].Services = append(history[i].Services, svc)
+			}
+		}
+		ok := func(svc string) bool { return visible[svc] || !registered[svc] && !known[svc] }
+		for _, l := range s.Links {
+			if ok(l.FromRepo) && ok(l.ToRepo) {
+				history[i].Links = append(history[i].Links, l)
+			}
+		}
+	}
+	g.History = history
+
+	teams := g.Teams[:0]
+	for _, t := range g.Teams {
+		var services []string
+		for _, svc := range t.Services {
+			if visible[svc] {
+				services = append(services, svc)
+			}
+		}
+		if len(services) > 0 {
+			t.Services = services
+			teams = append(teams, t)
+		}
+	}
+	g.Teams = teams
+
+	// A hidden provider's links are gone, so without this its deprecations
+	// would be listed as no longer used, naming it.
+	deprecations := g.Deprecations[:0]
+	for _, d := range g.Deprecations {
+		if visible[d.Service] {
+			deprecations = append(deprecations, d)
+		}
+	}
+	g.Deprecations = deprecations
+}
+
+// GenerateVariants builds one site per variant from the same inputs, e.g. a
+// partner-facing public subset next to the full internal site. It returns
+// the page count of each variant by name.
+func (g *CentralSiteGenerator) GenerateVariants(variants []SiteVariant) (map[string]int, error) {
+	counts := make(map[string]int, len(variants))
+	for _, v := range variants {
+		if err := ValidateAudience(v.Audience); err != nil {
+			return counts, fmt.Errorf("site variant %s: %w", v.Name, err)
+		}
+
+		// Generate mutates its inputs, so each variant gets its own copies.
+		variant := *g
+		variant.Audience = v.Audience
+		variant.OutputDir = v.OutputDir
+		if variant.OutputDir == "" {
+			variant.OutputDir = filepath.Join(filepath.Dir(g.OutputDir), filepath.Base(g.OutputDir)+"-"+v.Name)
+		}
+		variant.Repos = append([]RepoInfo(nil), g.Repos...)
+		variant.Links = append([]LinkInfo(nil), g.Links...)
+		variant.Flows = append([]FlowInfo(nil), g.Flows...)
+		variant.Changes = append([]ChangeInfo(nil), g.Changes...)
+		variant.Teams = append([]TeamInfo(nil), g.Teams...)
+		variant.Deprecations = append([]deprecation.Deprecation(nil), g.Deprecations...)
+		variant.SLOs = g.SLOs
+		variant.RuntimeOnly = append([]LinkInfo(nil), g.RuntimeOnly...)
+		variant.Validation = nil
+
+		n, err := variant.Generate()
+		if err != nil {
+			return counts, fmt.Errorf("site variant %s: %w", v.Name, err)
+		}
+		counts[v.Name] = n
+	}
+	return counts, nil
+}
@@ -0,0 +1,188 @@
+package siteauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// oidcProvider signs users in with the OpenID Connect authorization code
+// flow. Claims are read from the provider's userinfo endpoint using the
+// access token, so no ID token signature handling is needed.
+type oidcProvider struct {
+	oauth       *oauth2.Config
+	userInfoURL string
+	groupsClaim string
+}
+
+// oidcState is kept in a short-lived cookie between login and callback.
+type oidcState struct {
+	State string `json:"state"`
+	Next  string `json:"next"`
+}
+
+// discoverOIDC reads the provider's endpoints from its discovery document.
+func discoverOIDC(ctx context.Context, issuer, clientID, clientSecret, redirectURL, groupsClaim string) (*oidcProvider, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %s", resp.Status)
+	}
+
+	var doc struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing OIDC discovery document: %w", err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserInfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC discovery document for %s is missing required endpoints", issuer)
+	}
+
+	scopes := []string{"openid", "profile", "email"}
+	if groupsClaim == "groups" {
+		scopes = append(scopes, "groups")
+	}
+	return &oidcProvider{
+		oauth: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  doc.AuthorizationEndpoint,
+				TokenURL: doc.TokenEndpoint,
+			},
+		},
+		userInfoURL: doc.UserInfoEndpoint,
+		groupsClaim: groupsClaim,
+	}, nil
+}
+
+// handleLogin starts the authorization code flow.
+func (a *Authenticator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		http.Error(w, "could not start sign-in", http.StatusInternalServerError)
+		return
+	}
+	state := oidcState{State: hex.EncodeToString(buf), Next: safeNext(r.URL.Query().Get("next"))}
+	signed, err := signValue(a.secret, state)
+	if err != nil {
+		http.Error(w, "could not start sign-in", http.StatusInternalServerError)
+		return
+	}
+	setCookie(w, r, stateCookie, signed, 10*time.Minute)
+	http.Redirect(w, r, a.oidc.oauth.AuthCodeURL(state.State), http.StatusFound)
+}
+
+// handleCallback completes the flow and starts a session.
+func (a *Authenticator) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	var state oidcState
+	if err != nil || verifyValue(a.secret, cookie.Value, &state) != nil || state.State != r.URL.Query().Get("state") {
+		http.Error(w, "invalid sign-in state; please try again", http.StatusBadRequest)
+		return
+	}
+	clearCookie(w, stateCookie)
+
+	if msg := r.URL.Query().Get("error"); msg != "" {
+		http.Error(w, "sign-in failed: "+msg, http.StatusUnauthorized)
+		return
+	}
+
+	token, err := a.oidc.oauth.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, "sign-in failed: could not exchange authorization code", http.StatusUnauthorized)
+		return
+	}
+	id, err := a.oidc.userInfo(r.Context(), token)
+	if err != nil {
+		http.Error(w, "sign-in failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !a.allowedToSignIn(id) {
+		http.Error(w, "your account is not in a group allowed to view these docs", http.StatusForbidden)
+		return
+	}
+
+	if err := a.startSession(w, r, id); err != nil {
+		http.Error(w, "could not start session", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, state.Next, http.StatusFound)
+}
+
+// userInfo fetches the signed-in user's claims.
+func (p *oidcProvider) userInfo(ctx context.Context, token *oauth2.Token) (*Identity, error) {
+	resp, err := p.oauth.Client(ctx, token).Get(p.userInfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching user info: %s", resp.Status)
+	}
+
+	var claims map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("parsing user info: %w", err)
+	}
+	id := &Identity{
+		Subject: claimString(claims, "sub"),
+		Email:   claimString(claims, "email"),
+		Name:    claimString(claims, "name"),
+		Groups:  claimStrings(claims, p.groupsClaim),
+	}
+	if id.Subject == "" {
+		return nil, fmt.Errorf("user info has no subject")
+	}
+	return id, nil
+}
+
+func claimString(claims map[string]any, key string) string {
+	s, _ := claims[key].(string)
+	return s
+}
+
+// claimStrings reads a claim that may be a list or a comma-separated string.
+func claimStrings(claims map[string]any, key string) []string {
+	switch v := claims[key].(type) {
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return splitList(v)
+	}
+	return nil
+}
+
+// safeNext only allows redirects back to paths on this site.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
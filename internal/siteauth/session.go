@@ -0,0 +1,77 @@
+package siteauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookie names used by the authenticator.
+const (
+	sessionCookie = "autodoc_session"
+	stateCookie   = "autodoc_oidc_state"
+)
+
+// sessionTTL is how long a sign-in lasts before the user must sign in again.
+const sessionTTL = 12 * time.Hour
+
+var errInvalidCookie = errors.New("invalid or expired cookie")
+
+// signValue serialises v as JSON and appends an HMAC so it can be stored in
+// a cookie and trusted when it comes back.
+func signValue(secret []byte, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac(secret, payload)), nil
+}
+
+// verifyValue checks a value produced by signValue and decodes it into v.
+func verifyValue(secret []byte, signed string, v any) error {
+	payload, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return errInvalidCookie
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, payload)) {
+		return errInvalidCookie
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return errInvalidCookie
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errInvalidCookie
+	}
+	return nil
+}
+
+func mac(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// setCookie writes a signed, HTTP-only cookie that expires after ttl.
+func setCookie(w http.ResponseWriter, r *http.Request, name, value string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+}
@@ -0,0 +1,390 @@
+// Package siteauth adds optional sign-in and group-based authorization to
+// the served documentation site and the server APIs. Users sign in through
+// an OpenID Connect provider, or a trusted reverse proxy (for example a SAML
+// gateway) authenticates them and forwards their identity in headers.
+package siteauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+// Identity is the signed-in user.
+type Identity struct {
+	Subject string    `json:"sub"`
+	Email   string    `json:"email,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Groups  []string  `json:"groups,omitempty"`
+	Expires time.Time `json:"exp"`
+}
+
+// InGroup reports whether the user belongs to any of the groups.
+func (id *Identity) InGroup(groups []string) bool {
+	for _, want := range groups {
+		for _, have := range id.Groups {
+			if strings.EqualFold(want, have) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// IdentityFrom returns the signed-in user stored on the request context.
+func IdentityFrom(ctx context.Context) *Identity {
+	id, _ := ctx.Value(contextKey{}).(*Identity)
+	return id
+}
+
+// Authenticator signs users in and decides which repos' docs they may see.
+type Authenticator struct {
+	provider      string
+	userHeader    string
+	groupsHeader  string
+	allowedGroups []string
+	access        []config.RepoAccessRule
+	secret        []byte
+	oidc          *oidcProvider
+	languages     []string // directories of the site's translated copies
+	now           func() time.Time
+}
+
+// New creates an authenticator from the auth config. It returns nil when
+// authentication is disabled.
+func New(ctx context.Context, cfg config.AuthConfig) (*Authenticator, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+
+	a := &Authenticator{
+		provider:      cfg.Provider,
+		userHeader:    cfg.UserHeader,
+		groupsHeader:  cfg.GroupsHeader,
+		allowedGroups: cfg.AllowedGroups,
+		access:        cfg.Access,
+		now:           time.Now,
+	}
+	if a.userHeader == "" {
+		a.userHeader = "X-Forwarded-User"
+	}
+	if a.groupsHeader == "" {
+		a.groupsHeader = "X-Forwarded-Groups"
+	}
+
+	secret := cfg.SessionSecret
+	if secret == "" {
+		secret = os.Getenv("AUTODOC_SESSION_SECRET")
+	}
+	if secret != "" {
+		a.secret = []byte(secret)
+	} else {
+		a.secret = make([]byte, 32)
+		if _, err := rand.Read(a.secret); err != nil {
+			return nil, fmt.Errorf("generating session secret: %w", err)
+		}
+	}
+
+	switch cfg.Provider {
+	case "proxy":
+	case "oidc":
+		clientSecret := cfg.ClientSecret
+		if clientSecret == "" {
+			clientSecret = os.Getenv("AUTODOC_OIDC_CLIENT_SECRET")
+		}
+		groupsClaim := cfg.GroupsClaim
+		if groupsClaim == "" {
+			groupsClaim = "groups"
+		}
+		p, err := discoverOIDC(ctx, cfg.IssuerURL, cfg.ClientID, clientSecret, cfg.RedirectURL, groupsClaim)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc = p
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q", cfg.Provider)
+	}
+	return a, nil
+}
+
+// Middleware protects the documentation site. It serves the /auth/ sign-in
+// routes, sends signed-out browsers to the identity provider, and refuses
+// pages and search shards of repos the user's groups may not see.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			next.ServeHTTP(w, r)
+			return
+		case "/auth/login":
+			if a.oidc == nil {
+				http.NotFound(w, r)
+				return
+			}
+			a.handleLogin(w, r)
+			return
+		case "/auth/callback":
+			if a.oidc == nil {
+				http.NotFound(w, r)
+				return
+			}
+			a.handleCallback(w, r)
+			return
+		case "/auth/logout":
+			clearCookie(w, sessionCookie)
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		id := a.identify(r)
+		if id == nil {
+			a.unauthenticated(w, r)
+			return
+		}
+		if !a.allowedToSignIn(id) {
+			http.Error(w, "your account is not in a group allowed to view these docs", http.StatusForbidden)
+			return
+		}
+		// A translated copy of the site has the same layout in its own
+		// directory, so its pages are judged as the original's.
+		sitePath := a.trimLanguage(r.URL.Path)
+		if repo := repoForPath(sitePath); repo != "" && !a.CanAccessRepo(id, repo) {
+			http.Error(w, "you do not have access to the "+repo+" docs", http.StatusForbidden)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, id))
+		if sitePath == "/search-index.json" && len(a.access) > 0 {
+			a.serveFilteredIndex(w, r, next, id)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetLanguages sets the directories the site's translated copies are
+// published in, e.g. "es" for /es/, so their pages and search indexes are
+// authorized by the repos they document.
+func (a *Authenticator) SetLanguages(dirs []string) {
+	a.languages = dirs
+}
+
+// trimLanguage returns a site path without the directory of the translated
+// copy of the site it is in, if any.
+func (a *Authenticator) trimLanguage(p string) string {
+	p = path.Clean("/" + p)
+	for _, dir := range a.languages {
+		if rest, ok := strings.CutPrefix(p, "/"+dir+"/"); ok {
+			return "/" + rest
+		}
+	}
+	return p
+}
+
+// RequireAuth rejects API requests from users who are not signed in. Unlike
+// Middleware it never redirects and does not apply repo rules.
+func (a *Authenticator) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := a.identify(r)
+		if id == nil {
+			writeError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if !a.allowedToSignIn(id) {
+			writeError(w, http.StatusForbidden, "account is not in an allowed group")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// RequireGroups lets only members of groups through, behind RequireAuth.
+// Everyone else gets a 403, as does everyone when groups is empty.
+func RequireGroups(groups []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := IdentityFrom(r.Context()); id == nil || !id.InGroup(groups) {
+				writeError(w, http.StatusForbidden, "only members of "+strings.Join(groups, ", ")+" may do this")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CanAccessRepo reports whether the user may see a repo's docs. Repos not
+// covered by any access rule are visible to every signed-in user.
+func (a *Authenticator) CanAccessRepo(id *Identity, repo string) bool {
+	covered := false
+	for _, rule := range a.access {
+		if !matchRepo(rule.Repos, repo) {
+			continue
+		}
+		covered = true
+		if id != nil && id.InGroup(rule.Groups) {
+			return true
+		}
+	}
+	return !covered
+}
+
+// RepoAccess reports whether the request's user may see a repo's docs. It
+// has the shape of site.AccessFilter.
+func (a *Authenticator) RepoAccess(r *http.Request, repo string) bool {
+	return a.CanAccessRepo(IdentityFrom(r.Context()), repo)
+}
+
+// identify returns the user from the session cookie or proxy headers.
+func (a *Authenticator) identify(r *http.Request) *Identity {
+	if a.provider == "proxy" {
+		user := strings.TrimSpace(r.Header.Get(a.userHeader))
+		if user == "" {
+			return nil
+		}
+		return &Identity{Subject: user, Groups: splitList(r.Header.Get(a.groupsHeader))}
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	var id Identity
+	if err := verifyValue(a.secret, cookie.Value, &id); err != nil || a.now().After(id.Expires) {
+		return nil
+	}
+	return &id
+}
+
+// startSession stores the identity in a signed session cookie.
+func (a *Authenticator) startSession(w http.ResponseWriter, r *http.Request, id *Identity) error {
+	id.Expires = a.now().Add(sessionTTL)
+	signed, err := signValue(a.secret, id)
+	if err != nil {
+		return err
+	}
+	setCookie(w, r, sessionCookie, signed, sessionTTL)
+	return nil
+}
+
+func (a *Authenticator) allowedToSignIn(id *Identity) bool {
+	return len(a.allowedGroups) == 0 || id.InGroup(a.allowedGroups)
+}
+
+// unauthenticated sends browsers to sign in and gives API clients a 401.
+func (a *Authenticator) unauthenticated(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil || strings.HasPrefix(r.URL.Path, "/api/") || r.Method != http.MethodGet {
+		writeError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	http.Redirect(w, r, "/auth/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+}
+
+// serveFilteredIndex serves the unsharded search index without entries for
+// repos the user may not see.
+func (a *Authenticator) serveFilteredIndex(w http.ResponseWriter, r *http.Request, next http.Handler, id *Identity) {
+	rec := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	if rec.status != http.StatusOK {
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body)
+		return
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(rec.body, &entries); err != nil {
+		http.Error(w, "invalid search index", http.StatusInternalServerError)
+		return
+	}
+	kept := make([]map[string]any, 0, len(entries))
+	for _, e := range entries {
+		p, _ := e["path"].(string)
+		if repo := firstSegment(p); repo == "" || a.CanAccessRepo(id, repo) {
+			kept = append(kept, e)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-store")
+	json.NewEncoder(w).Encode(kept)
+}
+
+// repoForPath returns the repo a site path belongs to: the top-level
+// directory of a page, or the repo name of a search shard. Files at the site
+// root and API paths belong to no repo.
+func repoForPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if strings.HasPrefix(p, "api/") || strings.HasPrefix(p, "auth/") {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(p, "search/"); ok && !strings.Contains(rest, "/") {
+		// Shards are named NNN-<repo>.json.
+		name := strings.TrimSuffix(rest, ".json")
+		if i := strings.IndexByte(name, '-'); i >= 0 {
+			name = name[i+1:]
+		}
+		if name == "_root" {
+			return ""
+		}
+		return name
+	}
+	return firstSegment(p)
+}
+
+// firstSegment returns the top-level directory of p, or "" for files at the root.
+func firstSegment(p string) string {
+	seg, _, ok := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	if !ok {
+		return ""
+	}
+	return seg
+}
+
+func matchRepo(patterns []string, repo string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(pattern, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma- or space-separated header or claim value.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// bufferedResponse captures a handler's response so it can be rewritten.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) { b.status = status }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.body = append(b.body, p...)
+	return len(p), nil
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,220 @@
+package siteauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+)
+
+func newProxyAuth(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := New(context.Background(), config.AuthConfig{
+		Provider:      "proxy",
+		SessionSecret: "test-secret",
+		Access: []config.RepoAccessRule{
+			{Repos: []string{"payments*"}, Groups: []string{"finance"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func siteHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/search-index.json" || r.URL.Path == "/es/search-index.json" {
+			w.Write([]byte(`[{"path":"orders/main.html"},{"path":"payments-api/main.html"},{"path":"index.html"}]`))
+			return
+		}
+		w.Write([]byte("ok " + IdentityFrom(r.Context()).Subject))
+	})
+}
+
+func request(h http.Handler, path, user, groups string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != "" {
+		req.Header.Set("X-Forwarded-User", user)
+		req.Header.Set("X-Forwarded-Groups", groups)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewDisabled(t *testing.T) {
+	a, err := New(context.Background(), config.AuthConfig{})
+	if a != nil || err != nil {
+		t.Errorf("New with no provider = %v, %v; want nil, nil", a, err)
+	}
+}
+
+func TestProxyMiddleware(t *testing.T) {
+	a := newProxyAuth(t)
+	h := a.Middleware(siteHandler())
+
+	if w := request(h, "/index.html", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want 401", w.Code)
+	}
+	if w := request(h, "/orders/main.html", "ana", "eng"); w.Code != http.StatusOK || w.Body.String() != "ok ana" {
+		t.Errorf("unrestricted repo: status = %d, body %q", w.Code, w.Body.String())
+	}
+	if w := request(h, "/payments-api/main.html", "ana", "eng"); w.Code != http.StatusForbidden {
+		t.Errorf("restricted repo without group: status = %d, want 403", w.Code)
+	}
+	if w := request(h, "/search/002-payments-api.json", "ana", "eng"); w.Code != http.StatusForbidden {
+		t.Errorf("restricted shard without group: status = %d, want 403", w.Code)
+	}
+	if w := request(h, "/payments-api/main.html", "bo", "eng, finance"); w.Code != http.StatusOK {
+		t.Errorf("restricted repo with group: status = %d, want 200", w.Code)
+	}
+
+	w := request(h, "/search-index.json", "ana", "eng")
+	var entries []struct {
+		Path string `json:"path"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 2 || entries[0].Path != "orders/main.html" || entries[1].Path != "index.html" {
+		t.Errorf("filtered index: %+v", entries)
+	}
+}
+
+func TestTranslatedSite(t *testing.T) {
+	a := newProxyAuth(t)
+	a.SetLanguages([]string{"es", "pt-BR"})
+	h := a.Middleware(siteHandler())
+
+	if w := request(h, "/es/payments-api/main.html", "ana", "eng"); w.Code != http.StatusForbidden {
+		t.Errorf("translated restricted page without group: status = %d, want 403", w.Code)
+	}
+	if w := request(h, "/pt-BR/search/002-payments-api.json", "ana", "eng"); w.Code != http.StatusForbidden {
+		t.Errorf("translated restricted shard without group: status = %d, want 403", w.Code)
+	}
+	if w := request(h, "/es/orders/main.html", "ana", "eng"); w.Code != http.StatusOK {
+		t.Errorf("translated unrestricted page: status = %d, want 200", w.Code)
+	}
+	if w := request(h, "/es/payments-api/main.html", "bo", "finance"); w.Code != http.StatusOK {
+		t.Errorf("translated restricted page with group: status = %d, want 200", w.Code)
+	}
+
+	w := request(h, "/es/search-index.json", "ana", "eng")
+	var entries []struct {
+		Path string `json:"path"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &entries)
+	if len(entries) != 2 {
+		t.Errorf("translated index not filtered: %+v", entries)
+	}
+}
+
+func TestAllowedGroups(t *testing.T) {
+	a, _ := New(context.Background(), config.AuthConfig{Provider: "proxy", AllowedGroups: []string{"staff"}})
+	api := a.RequireAuth(siteHandler())
+
+	if w := request(api, "/api/notifications", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want 401", w.Code)
+	}
+	if w := request(api, "/api/notifications", "eve", "contractors"); w.Code != http.StatusForbidden {
+		t.Errorf("outside allowed groups: status = %d, want 403", w.Code)
+	}
+	if w := request(api, "/api/notifications", "ana", "Staff"); w.Code != http.StatusOK {
+		t.Errorf("allowed group: status = %d, want 200", w.Code)
+	}
+}
+
+func TestSessionCookie(t *testing.T) {
+	a := newProxyAuth(t)
+	a.provider = "oidc" // identify from the session cookie instead of headers
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/auth/callback", nil)
+	if err := a.startSession(w, req, &Identity{Subject: "ana", Groups: []string{"finance"}}); err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	cookie := w.Result().Cookies()[0]
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	id := a.identify(req)
+	if id == nil || id.Subject != "ana" || !a.CanAccessRepo(id, "payments") {
+		t.Fatalf("identify = %+v", id)
+	}
+
+	tampered := *cookie
+	tampered.Value = strings.Replace(cookie.Value, ".", "x.", 1)
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&tampered)
+	if a.identify(req) != nil {
+		t.Error("tampered cookie should not identify a user")
+	}
+
+	now = now.Add(sessionTTL + time.Minute)
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	if a.identify(req) != nil {
+		t.Error("expired session should not identify a user")
+	}
+}
+
+func TestOIDCLoginRedirect(t *testing.T) {
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization_endpoint": "https://idp.example.com/authorize",
+			"token_endpoint":         "https://idp.example.com/token",
+			"userinfo_endpoint":      "https://idp.example.com/userinfo",
+		})
+	}))
+	defer idp.Close()
+
+	a, err := New(context.Background(), config.AuthConfig{
+		Provider:    "oidc",
+		IssuerURL:   idp.URL,
+		ClientID:    "docs",
+		RedirectURL: "https://docs.example.com/auth/callback",
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	h := a.Middleware(siteHandler())
+
+	w := request(h, "/orders/main.html", "", "")
+	if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), "/auth/login?next=") {
+		t.Fatalf("signed-out page: status = %d, Location = %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = request(h, "/auth/login?next=//evil.example.com", "", "")
+	loc, _ := url.Parse(w.Header().Get("Location"))
+	if loc.Host != "idp.example.com" || loc.Query().Get("client_id") != "docs" || loc.Query().Get("state") == "" {
+		t.Errorf("login redirect = %s", loc)
+	}
+
+	var state oidcState
+	if err := verifyValue(a.secret, w.Result().Cookies()[0].Value, &state); err != nil || state.Next != "/" {
+		t.Errorf("state cookie = %+v, %v; want next \"/\"", state, err)
+	}
+}
+
+func TestRepoForPath(t *testing.T) {
+	tests := map[string]string{
+		"/index.html":                "",
+		"/orders/docs/main.html":     "orders",
+		"/search/001-orders.json":    "orders",
+		"/search/000-_root.json":     "",
+		"/api/search":                "",
+		"/orders/../payments/x.html": "payments",
+	}
+	for p, want := range tests {
+		if got := repoForPath(p); got != want {
+			t.Errorf("repoForPath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
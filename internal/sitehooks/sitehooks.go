@@ -0,0 +1,192 @@
+// Package sitehooks tells downstream systems, such as search crawlers,
+// portals, and chat bots, when a documentation site is published, so they
+// can react at once instead of polling the output directory. Each
+// configured endpoint is POSTed a JSON event summarising what changed,
+// signed with its secret the way Slack signs requests.
+package sitehooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventSitePublished is the type of the event sent after a site build.
+const EventSitePublished = "site.published"
+
+// Headers set on every delivery. The signature is "v1=" and the hex
+// HMAC-SHA256, keyed by the endpoint's secret, of "v1:<timestamp>:<body>".
+const (
+	HeaderEvent     = "X-Autodoc-Event"
+	HeaderDelivery  = "X-Autodoc-Delivery"
+	HeaderTimestamp = "X-Autodoc-Timestamp"
+	HeaderSignature = "X-Autodoc-Signature"
+)
+
+// Event describes a published site.
+type Event struct {
+	Event string `json:"event"` // EventSitePublished
+	ID    string `json:"id"`    // the same for every endpoint told of one publish
+	// Command is the command that built the site, e.g. "site --central"
+	// or "daemon".
+	Command string `json:"command"`
+	Project string `json:"project,omitempty"`
+	Format  string `json:"format,omitempty"` // html, mkdocs, or docusaurus
+	RunID   string `json:"run_id,omitempty"` // the build's entry in `autodoc runs`
+	Pages   int    `json:"pages"`
+	// Snapshot is the artifact snapshot the site was recorded as, which
+	// `autodoc artifacts restore` can roll back to.
+	Snapshot    string    `json:"snapshot,omitempty"`
+	Changes     Changes   `json:"changes"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Changes summarises what a publish changed in the output directory, by
+// slash-separated path relative to it.
+type Changes struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Hook is an endpoint told of every publish.
+type Hook struct {
+	URL string
+	// Secret signs the deliveries. A hook without one is not sent any.
+	Secret string
+}
+
+// ErrNoSecret is returned for a hook without a secret to sign its
+// deliveries with.
+var ErrNoSecret = errors.New("webhook has no secret to sign deliveries with")
+
+// attempts is how many times a delivery is tried before giving up.
+const attempts = 3
+
+// Sender delivers events to hooks.
+type Sender struct {
+	hooks   []Hook
+	client  *http.Client
+	backoff time.Duration // before the first retry, doubling after
+}
+
+// NewSender creates a Sender delivering to hooks.
+func NewSender(hooks []Hook) *Sender {
+	return &Sender{
+		hooks:   hooks,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		backoff: time.Second,
+	}
+}
+
+// Send delivers ev to every hook, filling in its ID, type, and time when
+// unset. Hooks without a secret fail with ErrNoSecret. A delivery that fails with a network error or a 5xx response is
+// retried; the errors of those that still fail are returned together.
+func (s *Sender) Send(ctx context.Context, ev Event) error {
+	if len(s.hooks) == 0 {
+		return nil
+	}
+	if ev.Event == "" {
+		ev.Event = EventSitePublished
+	}
+	if ev.ID == "" {
+		ev.ID = uuid.NewString()
+	}
+	if ev.PublishedAt.IsZero() {
+		ev.PublishedAt = time.Now().UTC()
+	}
+	// Receivers can tell "nothing" from "unknown" without nil checks.
+	for _, paths := range []*[]string{&ev.Changes.Added, &ev.Changes.Changed, &ev.Changes.Removed} {
+		if *paths == nil {
+			*paths = []string{}
+		}
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	var errs []error
+	for _, h := range s.hooks {
+		if h.Secret == "" {
+			errs = append(errs, fmt.Errorf("%s: %w", h.URL, ErrNoSecret))
+			continue
+		}
+		if err := s.deliver(ctx, h, ev, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver POSTs body to a hook, retrying failures the endpoint may recover
+// from.
+func (s *Sender) deliver(ctx context.Context, h Hook, ev Event, body []byte) error {
+	wait := s.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = s.post(ctx, h, ev, body); err == nil || !retry || attempt == attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (s *Sender) post(ctx context.Context, h Hook, ev Event, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "autodoc-webhook")
+	req.Header.Set(HeaderEvent, ev.Event)
+	req.Header.Set(HeaderDelivery, ev.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(h.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the signature of a delivery's body sent at timestamp, in
+// Unix seconds, for the X-Autodoc-Signature header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v1:%s:", timestamp)
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a delivery's body
+// sent at timestamp. Receivers written in Go can use it; they should also
+// reject timestamps more than a few minutes old, against replays.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,98 @@
+package sitehooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	var got Event
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails as an endpoint restarting would.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderEvent) != EventSitePublished {
+			t.Errorf("%s = %q", HeaderEvent, r.Header.Get(HeaderEvent))
+		}
+		if !Verify("s3cret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("decoding event: %v", err)
+		}
+		if got.ID != r.Header.Get(HeaderDelivery) {
+			t.Errorf("event ID %q, delivery %q", got.ID, r.Header.Get(HeaderDelivery))
+		}
+	}))
+	defer server.Close()
+
+	s := NewSender([]Hook{{URL: server.URL, Secret: "s3cret"}})
+	s.backoff = 0
+	err := s.Send(context.Background(), Event{
+		Command: "site --central",
+		Pages:   12,
+		Changes: Changes{Changed: []string{"flows.html"}, Unchanged: 11},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("delivered in %d attempts, want 2", calls.Load())
+	}
+	if got.Event != EventSitePublished || got.Pages != 12 || got.PublishedAt.IsZero() {
+		t.Errorf("event = %+v", got)
+	}
+	if len(got.Changes.Changed) != 1 || got.Changes.Added == nil || got.Changes.Removed == nil {
+		t.Errorf("changes = %+v, want the changed page and empty lists", got.Changes)
+	}
+}
+
+func TestSendFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer server.Close()
+
+	s := NewSender([]Hook{{URL: server.URL, Secret: "s3cret"}, {URL: server.URL + "/unsigned"}})
+	s.backoff = 0
+	err := s.Send(context.Background(), Event{})
+	if err == nil || !strings.Contains(err.Error(), "status 410") {
+		t.Errorf("Send = %v, want the 410", err)
+	}
+	if !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Send = %v, want the hook without a secret refused", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("endpoint called %d times, want once: a 4xx isn't retried, and unsigned deliveries aren't sent", calls.Load())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"site.published"}`)
+	sig := Sign("s3cret", "1767225600", body)
+	if !strings.HasPrefix(sig, "v1=") || !Verify("s3cret", "1767225600", body, sig) {
+		t.Errorf("signature %q does not verify", sig)
+	}
+	for _, tt := range []struct{ secret, timestamp, body string }{
+		{"other", "1767225600", string(body)},
+		{"s3cret", "1767225601", string(body)},
+		{"s3cret", "1767225600", `{"event":"site.deleted"}`},
+	} {
+		if Verify(tt.secret, tt.timestamp, []byte(tt.body), sig) {
+			t.Errorf("Verify(%+v) = true", tt)
+		}
+	}
+}
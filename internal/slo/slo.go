@@ -0,0 +1,261 @@
+// Package slo parses service level objectives declared for services and
+// endpoints, and propagates them through flows to find each end-to-end
+// journey's weakest link.
+package slo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// FactKey is the context-engine fact, scoped to a service, that declares the
+// service's SLO. Endpoint SLOs use "slo:<endpoint>", e.g. "slo:/api/orders".
+const FactKey = "slo"
+
+// SLO is the objective for a service, or one of its endpoints.
+type SLO struct {
+	Service  string `json:"service"`
+	Endpoint string `json:"endpoint,omitempty"` // empty for the whole service
+	// Availability is the target success ratio in percent, e.g. 99.9; zero
+	// when not declared.
+	Availability float64 `json:"availability,omitempty"`
+	// Latency is the target at LatencyPercentile, e.g. p99 < 300ms; zero
+	// when not declared.
+	Latency           time.Duration `json:"latency,omitempty"`
+	LatencyPercentile float64       `json:"latency_percentile,omitempty"`
+	Source            string        `json:"source"` // "config" or "fact"
+}
+
+// Parse reads an SLO spec such as "99.9%", "availability=99.95 p99=250ms",
+// or "99.9%, p95<120ms". Terms are separated by spaces or commas.
+func Parse(spec string) (SLO, error) {
+	var s SLO
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' || r == ';' })
+	for _, f := range fields {
+		key, value, hasOp := cutOperator(f)
+		switch {
+		case !hasOp && strings.HasSuffix(f, "%"):
+			v, err := parsePercent(f)
+			if err != nil {
+				return SLO{}, err
+			}
+			s.Availability = v
+		case hasOp && (key == "availability" || key == "uptime"):
+			v, err := parsePercent(value)
+			if err != nil {
+				return SLO{}, err
+			}
+			s.Availability = v
+		case hasOp && isPercentile(key):
+			p, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimPrefix(key, "latency_"), "p"), 64)
+			if err != nil || p <= 0 || p >= 100 {
+				return SLO{}, fmt.Errorf("bad latency percentile %q", key)
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return SLO{}, fmt.Errorf("bad latency target %q", value)
+			}
+			s.LatencyPercentile, s.Latency = p, d
+		case hasOp && key == "endpoint":
+			s.Endpoint = value
+		default:
+			return SLO{}, fmt.Errorf("unrecognised SLO term %q (want e.g. 99.9%% or p99=300ms)", f)
+		}
+	}
+	if s.Availability == 0 && s.Latency == 0 {
+		return SLO{}, fmt.Errorf("SLO %q declares neither availability nor latency", spec)
+	}
+	return s, nil
+}
+
+// cutOperator splits "key=value" or "key<value" into its parts.
+func cutOperator(term string) (key, value string, ok bool) {
+	i := strings.IndexAny(term, "=<")
+	if i <= 0 {
+		return "", "", false
+	}
+	return strings.ToLower(term[:i]), strings.TrimLeft(term[i+1:], "="), true
+}
+
+func isPercentile(key string) bool {
+	key = strings.TrimPrefix(key, "latency_")
+	return len(key) > 1 && key[0] == 'p'
+}
+
+func parsePercent(v string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil || f <= 0 || f > 100 {
+		return 0, fmt.Errorf("bad availability %q: want a percentage such as 99.9%%", v)
+	}
+	return f, nil
+}
+
+// String renders the objective, e.g. "99.9% availability, p99 < 300ms".
+func (s SLO) String() string {
+	var parts []string
+	if s.Availability > 0 {
+		parts = append(parts, FormatPercent(s.Availability)+" availability")
+	}
+	if s.Latency > 0 {
+		parts = append(parts, fmt.Sprintf("p%s < %s", strconv.FormatFloat(s.LatencyPercentile, 'f', -1, 64), s.Latency))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatPercent renders a percentage without trailing zeros, e.g. "99.95%".
+func FormatPercent(p float64) string {
+	return strconv.FormatFloat(math.Round(p*1000)/1000, 'f', -1, 64) + "%"
+}
+
+// Load collects the SLOs for services from config and from context facts.
+// A config entry overrides a fact for the same service and endpoint. Facts
+// that don't parse are skipped, so one bad declaration doesn't hide the rest.
+// The result maps lower-cased service names to their SLOs, service-wide first.
+func Load(ctx context.Context, declared []config.SLOConfig, facts contextengine.FactSource, services []string) (map[string][]SLO, error) {
+	type key struct{ service, endpoint string }
+	found := make(map[key]SLO)
+
+	if facts != nil {
+		for _, svc := range services {
+			fs, err := facts.GetCurrentFacts(ctx, "", "service", svc)
+			if err != nil {
+				return nil, fmt.Errorf("loading facts for %s: %w", svc, err)
+			}
+			for _, f := range fs {
+				endpoint, isSLO := strings.CutPrefix(f.Key, FactKey+":")
+				if !isSLO && f.Key != FactKey {
+					continue
+				}
+				s, err := Parse(f.Value)
+				if err != nil {
+					continue
+				}
+				s.Service, s.Source = svc, "fact"
+				if isSLO {
+					s.Endpoint = endpoint
+				}
+				found[key{strings.ToLower(svc), s.Endpoint}] = s
+			}
+		}
+	}
+
+	for _, d := range declared {
+		spec := d.Objective
+		if d.Availability != "" {
+			spec += " availability=" + d.Availability
+		}
+		if d.Latency != "" {
+			spec += " " + d.Latency
+		}
+		s, err := Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("slos: %s: %w", d.Service, err)
+		}
+		s.Service, s.Endpoint, s.Source = d.Service, d.Endpoint, "config"
+		found[key{strings.ToLower(d.Service), d.Endpoint}] = s
+	}
+
+	out := make(map[string][]SLO)
+	for k, s := range found {
+		out[k.service] = append(out[k.service], s)
+	}
+	for _, list := range out {
+		sort.Slice(list, func(i, j int) bool { return list[i].Endpoint < list[j].Endpoint })
+	}
+	return out, nil
+}
+
+// ServiceLevel returns the service-wide SLO, or the weakest endpoint SLO when
+// only endpoints are declared.
+func ServiceLevel(slos []SLO) (SLO, bool) {
+	if len(slos) == 0 {
+		return SLO{}, false
+	}
+	if slos[0].Endpoint == "" {
+		return slos[0], true
+	}
+	weakest := slos[0]
+	for _, s := range slos[1:] {
+		if weaker(s, weakest) {
+			weakest = s
+		}
+	}
+	return weakest, true
+}
+
+// weaker reports whether a promises less availability than b, or the same
+// availability with a slower latency target.
+func weaker(a, b SLO) bool {
+	av, bv := orHundred(a.Availability), orHundred(b.Availability)
+	if av != bv {
+		return av < bv
+	}
+	return a.Latency > b.Latency
+}
+
+func orHundred(v float64) float64 {
+	if v == 0 {
+		return 100
+	}
+	return v
+}
+
+// Journey is the SLO picture of one end-to-end flow.
+type Journey struct {
+	Links []SLO // the service-level objective of each covered service, in flow order
+	// Weakest is the link with the lowest availability target.
+	Weakest *SLO
+	// Slowest is the link with the largest latency target.
+	Slowest *SLO
+	// Composite is the availability of the journey when every service is
+	// called in series: the product of the links' targets. Zero when no
+	// link declares availability.
+	Composite float64
+	// Uncovered lists services in the flow without an SLO.
+	Uncovered []string
+}
+
+// ForFlow propagates per-service SLOs through a flow's services.
+func ForFlow(services []string, slos map[string][]SLO) Journey {
+	var j Journey
+	composite, declared := 1.0, false
+	seen := make(map[string]bool)
+	for _, svc := range services {
+		k := strings.ToLower(svc)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		s, ok := ServiceLevel(slos[k])
+		if !ok {
+			j.Uncovered = append(j.Uncovered, svc)
+			continue
+		}
+		j.Links = append(j.Links, s)
+		if s.Availability > 0 {
+			composite *= s.Availability / 100
+			declared = true
+		}
+	}
+	for i := range j.Links {
+		l := &j.Links[i]
+		if l.Availability > 0 && (j.Weakest == nil || weaker(*l, *j.Weakest)) {
+			j.Weakest = l
+		}
+		if l.Latency > 0 && (j.Slowest == nil || l.Latency > j.Slowest.Latency) {
+			j.Slowest = l
+		}
+	}
+	if declared {
+		j.Composite = composite * 100
+	}
+	return j
+}
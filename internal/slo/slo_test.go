@@ -0,0 +1,119 @@
+package slo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/config"
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec         string
+		availability float64
+		percentile   float64
+		latency      time.Duration
+	}{
+		{"99.9%", 99.9, 0, 0},
+		{"availability=99.95 p99=250ms", 99.95, 99, 250 * time.Millisecond},
+		{"99.9%, p95<120ms", 99.9, 95, 120 * time.Millisecond},
+		{"latency_p99.9=1s", 0, 99.9, time.Second},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if s.Availability != tt.availability || s.LatencyPercentile != tt.percentile || s.Latency != tt.latency {
+			t.Errorf("Parse(%q) = %+v", tt.spec, s)
+		}
+	}
+	for _, bad := range []string{"", "fast", "101%", "p99=soon", "p100=1s", "endpoint=/x"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+
+	s, _ := Parse("99.95% p99=250ms")
+	if s.String() != "99.95% availability, p99 < 250ms" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+type fakeFacts []contextengine.Fact
+
+func (f fakeFacts) GetCurrentFacts(ctx context.Context, repoID, scope, scopeID string) ([]contextengine.Fact, error) {
+	var out []contextengine.Fact
+	for _, fact := range f {
+		if fact.Scope == scope && fact.ScopeID == scopeID {
+			out = append(out, fact)
+		}
+	}
+	return out, nil
+}
+
+func TestLoad(t *testing.T) {
+	facts := fakeFacts{
+		{Scope: "service", ScopeID: "payments", Key: FactKey, Value: "99.5%"},
+		{Scope: "service", ScopeID: "payments", Key: FactKey + ":POST /charges", Value: "99.99% p95=120ms"},
+		{Scope: "service", ScopeID: "ledger", Key: FactKey, Value: "whenever"},
+		{Scope: "service", ScopeID: "ledger", Key: "owner", Value: "finance"},
+	}
+	declared := []config.SLOConfig{
+		{Service: "Payments", Objective: "99.95%", Latency: "p99=300ms"},
+		{Service: "orders", Endpoint: "GET /orders", Availability: "99.9%"},
+	}
+	slos, err := Load(context.Background(), declared, facts, []string{"payments", "ledger", "orders"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	payments := slos["payments"]
+	if len(payments) != 2 {
+		t.Fatalf("payments SLOs = %+v", payments)
+	}
+	if payments[0].Endpoint != "" || payments[0].Availability != 99.95 || payments[0].Source != "config" {
+		t.Errorf("config should override the service-wide fact, got %+v", payments[0])
+	}
+	if payments[1].Endpoint != "POST /charges" || payments[1].Source != "fact" {
+		t.Errorf("expected the endpoint fact, got %+v", payments[1])
+	}
+	if _, ok := slos["ledger"]; ok {
+		t.Error("unparseable facts should be skipped")
+	}
+	if s, ok := ServiceLevel(slos["orders"]); !ok || s.Endpoint != "GET /orders" {
+		t.Errorf("ServiceLevel should fall back to the endpoint SLO, got %+v", s)
+	}
+
+	if _, err := Load(context.Background(), []config.SLOConfig{{Service: "x", Objective: "soon"}}, nil, nil); err == nil {
+		t.Error("expected an error for an invalid config objective")
+	}
+}
+
+func TestForFlow(t *testing.T) {
+	slos := map[string][]SLO{
+		"gateway":  {{Service: "gateway", Availability: 99.99, LatencyPercentile: 99, Latency: 50 * time.Millisecond}},
+		"payments": {{Service: "payments", Availability: 99.5, LatencyPercentile: 99, Latency: 300 * time.Millisecond}},
+		"ledger":   {{Service: "ledger", Availability: 99.9, LatencyPercentile: 99, Latency: 900 * time.Millisecond}},
+	}
+	j := ForFlow([]string{"Gateway", "payments", "ledger", "email", "payments"}, slos)
+	if len(j.Links) != 3 || len(j.Uncovered) != 1 || j.Uncovered[0] != "email" {
+		t.Fatalf("unexpected journey %+v", j)
+	}
+	if j.Weakest == nil || j.Weakest.Service != "payments" {
+		t.Errorf("weakest = %+v, want payments", j.Weakest)
+	}
+	if j.Slowest == nil || j.Slowest.Service != "ledger" {
+		t.Errorf("slowest = %+v, want ledger", j.Slowest)
+	}
+	if got := FormatPercent(j.Composite); got != "99.391%" {
+		t.Errorf("composite = %s, want 99.391%%", got)
+	}
+
+	if j := ForFlow([]string{"email"}, slos); j.Weakest != nil || j.Composite != 0 {
+		t.Errorf("flow without SLOs should have no weakest link, got %+v", j)
+	}
+}
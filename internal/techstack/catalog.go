@@ -0,0 +1,181 @@
+package techstack
+
+import "strings"
+
+// entry maps a library, as a package manifest declares it or source imports
+// it, to the component of the stack it stands for.
+type entry struct {
+	// ecosystem is the importers.Dependency ecosystem the library is
+	// published in.
+	ecosystem string
+	// name is the library's name; it also matches its subpackages, as in
+	// github.com/jackc/pgx/v5 or sqlalchemy.orm. A Maven name ending in ":"
+	// matches every artifact of the group.
+	name      string
+	component string
+	category  string
+	language  string // the language the component is used from; "" for any
+}
+
+var catalog = []entry{
+	// Go
+	{"Go", "github.com/gin-gonic/gin", "Gin", CategoryWeb, "Go"},
+	{"Go", "github.com/labstack/echo", "Echo", CategoryWeb, "Go"},
+	{"Go", "github.com/gofiber/fiber", "Fiber", CategoryWeb, "Go"},
+	{"Go", "github.com/go-chi/chi", "chi", CategoryWeb, "Go"},
+	{"Go", "github.com/gorilla/mux", "Gorilla mux", CategoryWeb, "Go"},
+	{"Go", "google.golang.org/grpc", "gRPC", CategoryRPC, ""},
+	{"Go", "github.com/twitchtv/twirp", "Twirp", CategoryRPC, "Go"},
+	{"Go", "connectrpc.com/connect", "Connect", CategoryRPC, ""},
+	{"Go", "gorm.io/gorm", "GORM", CategoryORM, "Go"},
+	{"Go", "entgo.io/ent", "ent", CategoryORM, "Go"},
+	{"Go", "github.com/jmoiron/sqlx", "sqlx", CategoryORM, "Go"},
+	{"Go", "github.com/lib/pq", "PostgreSQL", CategoryDatabase, ""},
+	{"Go", "github.com/jackc/pgx", "PostgreSQL", CategoryDatabase, ""},
+	{"Go", "github.com/go-sql-driver/mysql", "MySQL", CategoryDatabase, ""},
+	{"Go", "github.com/mattn/go-sqlite3", "SQLite", CategoryDatabase, ""},
+	{"Go", "modernc.org/sqlite", "SQLite", CategoryDatabase, ""},
+	{"Go", "go.mongodb.org/mongo-driver", "MongoDB", CategoryDatabase, ""},
+	{"Go", "github.com/redis/go-redis", "Redis", CategoryDatabase, ""},
+	{"Go", "github.com/go-redis/redis", "Redis", CategoryDatabase, ""},
+	{"Go", "github.com/gocql/gocql", "Cassandra", CategoryDatabase, ""},
+	{"Go", "github.com/elastic/go-elasticsearch", "Elasticsearch", CategoryDatabase, ""},
+	{"Go", "github.com/aws/aws-sdk-go-v2/service/dynamodb", "DynamoDB", CategoryDatabase, ""},
+	{"Go", "github.com/segmentio/kafka-go", "Kafka", CategoryMessaging, ""},
+	{"Go", "github.com/IBM/sarama", "Kafka", CategoryMessaging, ""},
+	{"Go", "github.com/Shopify/sarama", "Kafka", CategoryMessaging, ""},
+	{"Go", "github.com/confluentinc/confluent-kafka-go", "Kafka", CategoryMessaging, ""},
+	{"Go", "github.com/rabbitmq/amqp091-go", "RabbitMQ", CategoryMessaging, ""},
+	{"Go", "github.com/streadway/amqp", "RabbitMQ", CategoryMessaging, ""},
+	{"Go", "github.com/nats-io/nats.go", "NATS", CategoryMessaging, ""},
+
+	// npm
+	{"npm", "express", "Express", CategoryWeb, "JavaScript"},
+	{"npm", "@nestjs/core", "NestJS", CategoryWeb, "JavaScript"},
+	{"npm", "fastify", "Fastify", CategoryWeb, "JavaScript"},
+	{"npm", "koa", "Koa", CategoryWeb, "JavaScript"},
+	{"npm", "@hapi/hapi", "hapi", CategoryWeb, "JavaScript"},
+	{"npm", "next", "Next.js", CategoryFrontend, "JavaScript"},
+	{"npm", "react", "React", CategoryFrontend, "JavaScript"},
+	{"npm", "vue", "Vue", CategoryFrontend, "JavaScript"},
+	{"npm", "@angular/core", "Angular", CategoryFrontend, "JavaScript"},
+	{"npm", "svelte", "Svelte", CategoryFrontend, "JavaScript"},
+	{"npm", "@grpc/grpc-js", "gRPC", CategoryRPC, ""},
+	{"npm", "prisma", "Prisma", CategoryORM, "JavaScript"},
+	{"npm", "@prisma/client", "Prisma", CategoryORM, "JavaScript"},
+	{"npm", "typeorm", "TypeORM", CategoryORM, "JavaScript"},
+	{"npm", "sequelize", "Sequelize", CategoryORM, "JavaScript"},
+	{"npm", "mongoose", "MongoDB", CategoryDatabase, ""},
+	{"npm", "pg", "PostgreSQL", CategoryDatabase, ""},
+	{"npm", "mysql", "MySQL", CategoryDatabase, ""},
+	{"npm", "mysql2", "MySQL", CategoryDatabase, ""},
+	{"npm", "mongodb", "MongoDB", CategoryDatabase, ""},
+	{"npm", "redis", "Redis", CategoryDatabase, ""},
+	{"npm", "ioredis", "Redis", CategoryDatabase, ""},
+	{"npm", "sqlite3", "SQLite", CategoryDatabase, ""},
+	{"npm", "better-sqlite3", "SQLite", CategoryDatabase, ""},
+	{"npm", "cassandra-driver", "Cassandra", CategoryDatabase, ""},
+	{"npm", "@elastic/elasticsearch", "Elasticsearch", CategoryDatabase, ""},
+	{"npm", "@aws-sdk/client-dynamodb", "DynamoDB", CategoryDatabase, ""},
+	{"npm", "kafkajs", "Kafka", CategoryMessaging, ""},
+	{"npm", "amqplib", "RabbitMQ", CategoryMessaging, ""},
+	{"npm", "vite", "Vite", CategoryBuild, ""},
+	{"npm", "webpack", "webpack", CategoryBuild, ""},
+	{"npm", "esbuild", "esbuild", CategoryBuild, ""},
+	{"npm", "turbo", "Turborepo", CategoryBuild, ""},
+	{"npm", "nx", "Nx", CategoryBuild, ""},
+
+	// PyPI
+	{"PyPI", "django", "Django", CategoryWeb, "Python"},
+	{"PyPI", "flask", "Flask", CategoryWeb, "Python"},
+	{"PyPI", "fastapi", "FastAPI", CategoryWeb, "Python"},
+	{"PyPI", "aiohttp", "aiohttp", CategoryWeb, "Python"},
+	{"PyPI", "starlette", "Starlette", CategoryWeb, "Python"},
+	{"PyPI", "grpcio", "gRPC", CategoryRPC, ""},
+	{"PyPI", "grpc", "gRPC", CategoryRPC, ""},
+	{"PyPI", "sqlalchemy", "SQLAlchemy", CategoryORM, "Python"},
+	{"PyPI", "peewee", "Peewee", CategoryORM, "Python"},
+	{"PyPI", "psycopg2", "PostgreSQL", CategoryDatabase, ""},
+	{"PyPI", "psycopg2-binary", "PostgreSQL", CategoryDatabase, ""},
+	{"PyPI", "psycopg", "PostgreSQL", CategoryDatabase, ""},
+	{"PyPI", "asyncpg", "PostgreSQL", CategoryDatabase, ""},
+	{"PyPI", "pymysql", "MySQL", CategoryDatabase, ""},
+	{"PyPI", "mysqlclient", "MySQL", CategoryDatabase, ""},
+	{"PyPI", "MySQLdb", "MySQL", CategoryDatabase, ""},
+	{"PyPI", "pymongo", "MongoDB", CategoryDatabase, ""},
+	{"PyPI", "motor", "MongoDB", CategoryDatabase, ""},
+	{"PyPI", "redis", "Redis", CategoryDatabase, ""},
+	{"PyPI", "cassandra-driver", "Cassandra", CategoryDatabase, ""},
+	{"PyPI", "elasticsearch", "Elasticsearch", CategoryDatabase, ""},
+	{"PyPI", "celery", "Celery", CategoryMessaging, "Python"},
+	{"PyPI", "kafka-python", "Kafka", CategoryMessaging, ""},
+	{"PyPI", "confluent-kafka", "Kafka", CategoryMessaging, ""},
+	{"PyPI", "pika", "RabbitMQ", CategoryMessaging, ""},
+
+	// Maven
+	{"Maven", "org.springframework.boot:", "Spring Boot", CategoryWeb, "Java"},
+	{"Maven", "io.quarkus:", "Quarkus", CategoryWeb, "Java"},
+	{"Maven", "io.micronaut:", "Micronaut", CategoryWeb, "Java"},
+	{"Maven", "io.dropwizard:", "Dropwizard", CategoryWeb, "Java"},
+	{"Maven", "io.grpc:", "gRPC", CategoryRPC, ""},
+	{"Maven", "org.hibernate:", "Hibernate", CategoryORM, "Java"},
+	{"Maven", "org.hibernate.orm:", "Hibernate", CategoryORM, "Java"},
+	{"Maven", "org.postgresql:postgresql", "PostgreSQL", CategoryDatabase, ""},
+	{"Maven", "mysql:mysql-connector-java", "MySQL", CategoryDatabase, ""},
+	{"Maven", "com.mysql:mysql-connector-j", "MySQL", CategoryDatabase, ""},
+	{"Maven", "org.mongodb:", "MongoDB", CategoryDatabase, ""},
+	{"Maven", "redis.clients:jedis", "Redis", CategoryDatabase, ""},
+	{"Maven", "io.lettuce:lettuce-core", "Redis", CategoryDatabase, ""},
+	{"Maven", "com.datastax.oss:", "Cassandra", CategoryDatabase, ""},
+	{"Maven", "org.apache.kafka:", "Kafka", CategoryMessaging, ""},
+	{"Maven", "com.rabbitmq:amqp-client", "RabbitMQ", CategoryMessaging, ""},
+
+	// Cargo
+	{"Cargo", "actix-web", "Actix Web", CategoryWeb, "Rust"},
+	{"Cargo", "axum", "axum", CategoryWeb, "Rust"},
+	{"Cargo", "rocket", "Rocket", CategoryWeb, "Rust"},
+	{"Cargo", "tonic", "gRPC", CategoryRPC, ""},
+	{"Cargo", "diesel", "Diesel", CategoryORM, "Rust"},
+	{"Cargo", "sqlx", "SQLx", CategoryORM, "Rust"},
+	{"Cargo", "tokio-postgres", "PostgreSQL", CategoryDatabase, ""},
+	{"Cargo", "redis", "Redis", CategoryDatabase, ""},
+	{"Cargo", "mongodb", "MongoDB", CategoryDatabase, ""},
+	{"Cargo", "rdkafka", "Kafka", CategoryMessaging, ""},
+}
+
+// lookup finds the catalog entry for a library of an ecosystem.
+func lookup(ecosystem, name string) (entry, bool) {
+	for _, e := range catalog {
+		if e.ecosystem != ecosystem {
+			continue
+		}
+		if strings.HasSuffix(e.name, ":") {
+			if strings.HasPrefix(name, e.name) {
+				return e, true
+			}
+			continue
+		}
+		if strings.EqualFold(name, e.name) || strings.HasPrefix(name, e.name+"/") || strings.HasPrefix(name, e.name+".") {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// databaseNames map words in the databases the analyses mention to the
+// database's name.
+var databaseNames = []struct{ word, name string }{
+	{"postgres", "PostgreSQL"},
+	{"mysql", "MySQL"},
+	{"mariadb", "MariaDB"},
+	{"mongo", "MongoDB"},
+	{"redis", "Redis"},
+	{"sqlite", "SQLite"},
+	{"dynamodb", "DynamoDB"},
+	{"cassandra", "Cassandra"},
+	{"elasticsearch", "Elasticsearch"},
+	{"sql server", "SQL Server"},
+	{"oracle", "Oracle"},
+	{"bigquery", "BigQuery"},
+	{"snowflake", "Snowflake"},
+}
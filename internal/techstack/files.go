@@ -0,0 +1,167 @@
+package techstack
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/walker"
+)
+
+// buildFiles are the files that show a build tool is used, by name.
+var buildFiles = map[string]string{
+	"Makefile":            "Make",
+	"GNUmakefile":         "Make",
+	"Dockerfile":          "Docker",
+	"docker-compose.yml":  "Docker Compose",
+	"docker-compose.yaml": "Docker Compose",
+	"compose.yml":         "Docker Compose",
+	"compose.yaml":        "Docker Compose",
+	"go.mod":              "Go modules",
+	"package-lock.json":   "npm",
+	"yarn.lock":           "Yarn",
+	"pnpm-lock.yaml":      "pnpm",
+	"bun.lockb":           "Bun",
+	"pom.xml":             "Maven",
+	"build.gradle":        "Gradle",
+	"build.gradle.kts":    "Gradle",
+	"poetry.lock":         "Poetry",
+	"Pipfile":             "Pipenv",
+	"uv.lock":             "uv",
+	"setup.py":            "setuptools",
+	"Cargo.toml":          "Cargo",
+	"WORKSPACE":           "Bazel",
+	"WORKSPACE.bazel":     "Bazel",
+	"MODULE.bazel":        "Bazel",
+	"CMakeLists.txt":      "CMake",
+	"Taskfile.yml":        "Task",
+	"justfile":            "just",
+}
+
+// scanFiles finds the build tools and runtimes the build files in dir of
+// the checkout at root declare, skipping the directories in exclude.
+func scanFiles(root, dir string, exclude []string) []Component {
+	if root == "" {
+		return nil
+	}
+	base := filepath.Join(root, filepath.FromSlash(dir))
+	var out []Component
+	filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		name := d.Name()
+		if d.IsDir() {
+			if p != base && (slices.ContainsFunc(walker.DefaultExcludes, func(e string) bool { return strings.EqualFold(e, name) }) ||
+				strings.HasPrefix(name, ".") || name == "testdata" || slices.Contains(exclude, rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if tool, ok := buildFiles[name]; ok {
+			out = append(out, Component{Name: tool, Category: CategoryBuild, Evidence: rel})
+		} else if strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile") {
+			out = append(out, Component{Name: "Docker", Category: CategoryBuild, Evidence: rel})
+		}
+		if strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt") {
+			out = append(out, Component{Name: "pip", Category: CategoryBuild, Evidence: rel})
+		}
+		out = append(out, runtimes(p, rel, name)...)
+		return nil
+	})
+	return out
+}
+
+var (
+	goDirective    = regexp.MustCompile(`(?m)^go\s+(\d+(?:\.\d+)*)\s*$`)
+	requiresPython = regexp.MustCompile(`(?m)^requires-python\s*=\s*["']([^"']+)["']`)
+	pomJava        = regexp.MustCompile(`<(?:java\.version|maven\.compiler\.release|maven\.compiler\.source)>\s*([\w.]+)\s*</`)
+	dockerFrom     = regexp.MustCompile(`(?im)^FROM\s+(?:--\S+\s+)*(\S+)`)
+	versionPrefix  = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)`)
+)
+
+// runtimeImages are the official images of runtimes, by the image's last
+// path segment, and asdf's tool names.
+var runtimeImages = map[string]string{
+	"golang":          "Go",
+	"node":            "Node.js",
+	"nodejs":          "Node.js",
+	"python":          "Python",
+	"openjdk":         "Java",
+	"eclipse-temurin": "Java",
+	"amazoncorretto":  "Java",
+	"java":            "Java",
+	"ruby":            "Ruby",
+	"rust":            "Rust",
+	"php":             "PHP",
+	"dotnet":          ".NET",
+	"aspnet":          ".NET",
+}
+
+// runtimes reads the runtimes, and the versions, a build file declares.
+func runtimes(path, rel, name string) []Component {
+	read := func() string {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return ""
+		}
+		return string(content)
+	}
+	runtime := func(runtime, version string) Component {
+		return Component{Name: runtime, Category: CategoryRuntime, Version: strings.TrimSpace(version), Evidence: rel}
+	}
+	switch {
+	case name == "go.mod":
+		if m := goDirective.FindStringSubmatch(read()); m != nil {
+			return []Component{runtime("Go", m[1])}
+		}
+		return []Component{runtime("Go", "")}
+	case name == "package.json":
+		var pkg struct {
+			Engines map[string]string `json:"engines"`
+		}
+		if json.Unmarshal([]byte(read()), &pkg) == nil && pkg.Engines["node"] != "" {
+			return []Component{runtime("Node.js", pkg.Engines["node"])}
+		}
+	case name == ".nvmrc" || name == ".node-version":
+		return []Component{runtime("Node.js", strings.TrimPrefix(strings.TrimSpace(read()), "v"))}
+	case name == ".python-version":
+		return []Component{runtime("Python", strings.TrimSpace(read()))}
+	case name == "pyproject.toml":
+		if m := requiresPython.FindStringSubmatch(read()); m != nil {
+			return []Component{runtime("Python", m[1])}
+		}
+	case name == "pom.xml":
+		if m := pomJava.FindStringSubmatch(read()); m != nil {
+			return []Component{runtime("Java", m[1])}
+		}
+	case name == ".tool-versions":
+		var out []Component
+		for _, line := range strings.Split(read(), "\n") {
+			if f := strings.Fields(line); len(f) >= 2 && runtimeImages[f[0]] != "" {
+				out = append(out, runtime(runtimeImages[f[0]], f[1]))
+			}
+		}
+		return out
+	case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile"):
+		var out []Component
+		for _, m := range dockerFrom.FindAllStringSubmatch(read(), -1) {
+			image, tag, _ := strings.Cut(m[1], ":")
+			if r := runtimeImages[image[strings.LastIndex(image, "/")+1:]]; r != "" {
+				version := ""
+				if v := versionPrefix.FindStringSubmatch(tag); v != nil {
+					version = v[1]
+				}
+				out = append(out, runtime(r, version))
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package techstack detects a service's technology stack: its languages,
+// and the runtimes, frameworks, data access libraries, databases, messaging
+// systems, and build tools it uses, from its package manifests, the
+// imports its analyses record, and the build files in its checkout.
+package techstack
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// Categories of component, in the order a stack lists them.
+const (
+	CategoryRuntime   = "Runtime"
+	CategoryWeb       = "Web framework"
+	CategoryFrontend  = "Frontend framework"
+	CategoryRPC       = "RPC"
+	CategoryORM       = "Data access"
+	CategoryDatabase  = "Database"
+	CategoryMessaging = "Messaging"
+	CategoryBuild     = "Build tool"
+)
+
+// Categories lists the categories in order.
+var Categories = []string{CategoryRuntime, CategoryWeb, CategoryFrontend, CategoryRPC, CategoryORM, CategoryDatabase, CategoryMessaging, CategoryBuild}
+
+// Stack is a service's technology stack.
+type Stack struct {
+	Languages  []Language  `json:"languages,omitempty"`  // most files first
+	Components []Component `json:"components,omitempty"` // by category, then name
+}
+
+// Language is a programming language and how many of the service's files
+// are written in it.
+type Language struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+}
+
+// Component is a runtime, framework, database, or tool in a stack.
+type Component struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// Language is what the component is used from, for frameworks and
+	// data access libraries; empty for those any language can use.
+	// JavaScript covers TypeScript.
+	Language string `json:"language,omitempty"`
+	Version  string `json:"version,omitempty"` // as declared, when known
+	// Evidence is the manifest, file, or import it was found by.
+	Evidence string `json:"evidence"`
+}
+
+// Empty reports whether nothing of the stack was found.
+func (s Stack) Empty() bool {
+	return len(s.Languages) == 0 && len(s.Components) == 0
+}
+
+// Primary returns the language most of the service is written in, or "".
+func (s Stack) Primary() string {
+	if len(s.Languages) == 0 {
+		return ""
+	}
+	return s.Languages[0].Name
+}
+
+// InCategory returns the components of a category.
+func (s Stack) InCategory(category string) []Component {
+	var out []Component
+	for _, c := range s.Components {
+		if c.Category == category {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// notCode are the languages of files that aren't programs.
+var notCode = map[string]bool{
+	"unknown": true, "": true, "YAML": true, "Docker": true,
+	"JSON": true, "XML": true, "Markdown": true, "Text": true,
+	"TOML": true, "INI": true, "Properties": true, "Shell": true,
+}
+
+// Languages counts the programming languages of the analyzed files, most
+// files first, ties by name.
+func Languages(analyses map[string]indexer.FileAnalysis) []Language {
+	counts := make(map[string]int)
+	for _, a := range analyses {
+		if !notCode[a.Language] {
+			counts[a.Language]++
+		}
+	}
+	out := make([]Language, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, Language{Name: name, Files: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Files != out[j].Files {
+			return out[i].Files > out[j].Files
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Detect finds the stack of the service in dir of the checkout at root
+// ("" for the whole checkout), leaving out the directories in exclude,
+// which belong to other services. Analyses and deps are the service's own,
+// as scoped by indexer.ScopeAnalyses and indexer.InServiceDir. Libraries
+// only needed to build or test count only when they are build tools.
+func Detect(root, dir string, exclude []string, analyses map[string]indexer.FileAnalysis, deps []importers.Dependency) Stack {
+	s := Stack{Languages: Languages(analyses)}
+	found := make(map[string]int) // category and name -> index in s.Components
+	add := func(c Component) {
+		key := c.Category + "\x00" + c.Name
+		if i, ok := found[key]; ok {
+			if s.Components[i].Version == "" {
+				s.Components[i].Version = c.Version
+			}
+			return
+		}
+		found[key] = len(s.Components)
+		s.Components = append(s.Components, c)
+	}
+
+	for _, d := range deps {
+		e, ok := lookup(d.Ecosystem, d.Name)
+		if !ok || (d.Dev && e.category != CategoryBuild) {
+			continue
+		}
+		add(Component{Name: e.component, Category: e.category, Language: e.language, Version: d.Version, Evidence: d.Manifest})
+	}
+
+	paths := make([]string, 0, len(analyses))
+	for p := range analyses {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		a := analyses[p]
+		for _, dep := range a.Dependencies {
+			switch dep.Type {
+			case "import":
+				if e, ok := lookup(importers.ImportEcosystem(a.Language), dep.Name); ok && e.category != CategoryBuild {
+					add(Component{Name: e.component, Category: e.category, Language: e.language, Evidence: p})
+				}
+			case "database":
+				name := strings.ToLower(dep.Name)
+				for _, db := range databaseNames {
+					if strings.Contains(name, db.word) {
+						add(Component{Name: db.name, Category: CategoryDatabase, Evidence: p})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for _, c := range scanFiles(root, dir, exclude) {
+		add(c)
+	}
+
+	sort.SliceStable(s.Components, func(i, j int) bool {
+		a, b := s.Components[i], s.Components[j]
+		if a.Category != b.Category {
+			return slices.Index(Categories, a.Category) < slices.Index(Categories, b.Category)
+		}
+		return cmp.Less(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return s
+}
@@ -0,0 +1,89 @@
+package techstack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		".tool-versions":                   "golang 1.22.4\nnodejs 20.11.0\n",
+		"services/web/.nvmrc":              "v20.11.0\n",
+		"services/orders/go.mod":           "module acme/orders\n\ngo 1.22\n",
+		"services/orders/Dockerfile":       "FROM golang:1.22-alpine AS build\nFROM gcr.io/distroless/static\n",
+		"services/orders/Makefile":         "build:\n",
+		"services/web/package.json":        `{"engines": {"node": ">=20"}}`,
+		"services/web/pnpm-lock.yaml":      "",
+		"services/orders/testdata/pom.xml": "<project/>",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analyses := map[string]indexer.FileAnalysis{
+		"services/orders/main.go": {Language: "Go", Dependencies: []indexer.Dependency{
+			{Name: "github.com/jackc/pgx/v5/pgxpool", Type: "import"},
+			{Name: "orders table", Type: "database"},
+		}},
+		"services/orders/cache.go":   {Language: "Go", Dependencies: []indexer.Dependency{{Name: "Redis cache", Type: "database"}}},
+		"services/orders/schema.sql": {Language: "SQL"},
+		"services/orders/app.yaml":   {Language: "YAML"},
+	}
+	deps := []importers.Dependency{
+		{Name: "github.com/labstack/echo/v4", Version: "v4.11.4", Ecosystem: "Go", Manifest: "services/orders/go.mod"},
+		{Name: "github.com/jackc/pgx/v5", Version: "v5.5.0", Ecosystem: "Go", Manifest: "services/orders/go.mod"},
+		{Name: "github.com/mattn/go-sqlite3", Version: "v1.14.0", Ecosystem: "Go", Manifest: "services/orders/go.mod", Dev: true},
+		{Name: "github.com/stretchr/testify", Version: "v1.9.0", Ecosystem: "Go", Manifest: "services/orders/go.mod"},
+	}
+	s := Detect(root, "services/orders", nil, analyses, deps)
+
+	if s.Primary() != "Go" || len(s.Languages) != 2 || s.Languages[0].Files != 2 {
+		t.Errorf("Languages = %+v", s.Languages)
+	}
+	var got []string
+	for _, c := range s.Components {
+		got = append(got, c.Category+": "+strings.TrimSpace(c.Name+" "+c.Version)+" ("+c.Evidence+")")
+	}
+	want := []string{
+		"Runtime: Go 1.22 (services/orders/Dockerfile)",
+		"Web framework: Echo v4.11.4 (services/orders/go.mod)",
+		"Database: PostgreSQL v5.5.0 (services/orders/go.mod)",
+		"Database: Redis (services/orders/cache.go)",
+		"Build tool: Docker (services/orders/Dockerfile)",
+		"Build tool: Go modules (services/orders/go.mod)",
+		"Build tool: Make (services/orders/Makefile)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Components:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if web := s.InCategory(CategoryWeb); len(web) != 1 || web[0].Language != "Go" {
+		t.Errorf("web frameworks = %+v", web)
+	}
+
+	web := Detect(root, "services/web", nil, map[string]indexer.FileAnalysis{"services/web/app.tsx": {Language: "TypeScript", Dependencies: []indexer.Dependency{{Name: "next/router", Type: "import"}}}}, nil)
+	if c := web.InCategory(CategoryFrontend); len(c) != 1 || c[0].Name != "Next.js" || c[0].Language != "JavaScript" {
+		t.Errorf("web frontend = %+v", c)
+	}
+	if c := web.InCategory(CategoryRuntime); len(c) != 1 || c[0].Name != "Node.js" || c[0].Version != "20.11.0" {
+		t.Errorf("web runtimes = %+v", c)
+	}
+	if c := web.InCategory(CategoryBuild); len(c) != 1 || c[0].Name != "pnpm" {
+		t.Errorf("web build tools = %+v", c)
+	}
+
+	whole := Detect(root, "", []string{"services/web"}, nil, nil)
+	if c := whole.InCategory(CategoryRuntime); len(c) != 2 || c[0].Name != "Go" || c[0].Version != "1.22.4" || c[1].Name != "Node.js" {
+		t.Errorf("checkout runtimes = %+v", c)
+	}
+}
@@ -0,0 +1,135 @@
+// Package threatmodel holds the STRIDE categories of the starter threat
+// models the central site generates for each flow, and the notes security
+// teams make on them in conversation, stored as context-engine facts, to
+// refine them rather than start from scratch.
+package threatmodel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+)
+
+// FactKeyPrefix is followed by a threat ID in a flow-scoped fact, e.g.
+// "threat:orders->payments/T". The value is a status, optionally followed
+// by a note, e.g. "mitigated: mTLS between all services"; or, for any
+// other value, a new description of the threat. An ID the starter model
+// doesn't have adds a threat.
+const FactKeyPrefix = "threat:"
+
+// Category is a STRIDE threat category.
+type Category struct {
+	Letter string // the letter ending threat IDs, e.g. "T"
+	Name   string
+}
+
+// STRIDE lists the categories in order.
+var STRIDE = []Category{
+	{"S", "Spoofing"},
+	{"T", "Tampering"},
+	{"R", "Repudiation"},
+	{"I", "Information disclosure"},
+	{"D", "Denial of service"},
+	{"E", "Elevation of privilege"},
+}
+
+// CategoryOf returns the category a threat ID ends in, e.g. "Tampering"
+// for "orders->payments/T", or "" when it ends in none.
+func CategoryOf(id string) string {
+	_, letter, ok := strings.Cut(id, "/")
+	if !ok {
+		return ""
+	}
+	for _, c := range STRIDE {
+		if strings.EqualFold(letter, c.Letter) {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// Statuses of a threat.
+const (
+	StatusOpen          = "open"
+	StatusMitigated     = "mitigated"
+	StatusAccepted      = "accepted"
+	StatusNotApplicable = "not applicable"
+)
+
+// statusWords map the words a note may start with to statuses.
+var statusWords = map[string]string{
+	"open": StatusOpen, "unmitigated": StatusOpen,
+	"mitigated": StatusMitigated, "fixed": StatusMitigated, "handled": StatusMitigated,
+	"accepted": StatusAccepted,
+	"n/a":      StatusNotApplicable, "na": StatusNotApplicable, "not-applicable": StatusNotApplicable, "irrelevant": StatusNotApplicable,
+}
+
+// Note is what a security team said about one threat of a flow.
+type Note struct {
+	Flow     string `json:"flow"`
+	ThreatID string `json:"threat_id"`
+	// Status is empty when the note only redescribes the threat.
+	Status string `json:"status,omitempty"`
+	// Text is the note on the status, e.g. how the threat is mitigated, or
+	// the threat's new description when Status is empty.
+	Text string `json:"text,omitempty"`
+
+	ProvidedBy string    `json:"provided_by,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Provenance renders who made the note and when, e.g. "ada, 2026-03-04".
+func (n Note) Provenance() string {
+	who := n.ProvidedBy
+	if who == "" {
+		who = "unknown"
+	}
+	if n.At.IsZero() {
+		return who
+	}
+	return who + ", " + n.At.Format("2006-01-02")
+}
+
+// FromFact parses a threat note. It reports false for other facts.
+func FromFact(f contextengine.Fact) (Note, bool) {
+	id, ok := strings.CutPrefix(f.Key, FactKeyPrefix)
+	id = strings.Join(strings.Fields(id), "")
+	value := strings.TrimSpace(f.Value)
+	if !ok || f.Scope != "flow" || f.ScopeID == "" || id == "" || value == "" {
+		return Note{}, false
+	}
+	n := Note{Flow: f.ScopeID, ThreatID: id, ProvidedBy: f.ProvidedBy, At: f.UpdatedAt}
+	first, rest, _ := strings.Cut(value, " ")
+	first = strings.ToLower(strings.TrimRight(first, ",;:.-"))
+	if first == "not" {
+		if next, tail, _ := strings.Cut(rest, " "); strings.EqualFold(strings.TrimRight(next, ",;:.-"), "applicable") {
+			first, rest = "n/a", tail
+		}
+	}
+	if status, ok := statusWords[first]; ok {
+		n.Status, n.Text = status, strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest), ",;:.-"))
+	} else {
+		n.Text = value
+	}
+	return n, true
+}
+
+// Load returns every current threat note, oldest first.
+func Load(ctx context.Context, facts contextengine.FactSource) ([]Note, error) {
+	fs, err := facts.GetCurrentFacts(ctx, "", "flow", "")
+	if err != nil {
+		return nil, fmt.Errorf("loading flow facts: %w", err)
+	}
+	var out []Note
+	for _, f := range fs {
+		if n, ok := FromFact(f); ok {
+			out = append(out, n)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package threatmodel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/contextengine"
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+func TestFromFact(t *testing.T) {
+	cases := []struct {
+		key, value       string
+		ok               bool
+		id, status, text string
+	}{
+		{"threat:orders->payments/T", "mitigated: mTLS between all services", true, "orders->payments/T", StatusMitigated, "mTLS between all services"},
+		{"threat: orders -> payments/D", "Not applicable - payments is async", true, "orders->payments/D", StatusNotApplicable, "payments is async"},
+		{"threat:internet->web/S", "Accepted", true, "internet->web/S", StatusAccepted, ""},
+		{"threat:orders->payments/I", "Card numbers are logged by the HTTP client", true, "orders->payments/I", "", "Card numbers are logged by the HTTP client"},
+		{"threat:orders->payments/T", "", false, "", "", ""},
+		{"exclude", "yes", false, "", "", ""},
+	}
+	for _, c := range cases {
+		n, ok := FromFact(contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: c.key, Value: c.value})
+		if ok != c.ok || n.ThreatID != c.id || n.Status != c.status || n.Text != c.text {
+			t.Errorf("FromFact(%s=%q) = %+v, %v", c.key, c.value, n, ok)
+		}
+	}
+	if got := CategoryOf("orders->payments/t"); got != "Tampering" {
+		t.Errorf("CategoryOf = %q", got)
+	}
+	if got := CategoryOf("orders->payments"); got != "" {
+		t.Errorf("CategoryOf without a letter = %q", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	store := contextengine.NewStore(database)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "threat:web->orders/S", Value: "mitigated by mTLS", ProvidedBy: "ada"})
+	time.Sleep(time.Millisecond)
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "threat:orders->fraud/R", Value: "open", ProvidedBy: "grace"})
+	store.SaveFact(ctx, contextengine.Fact{Scope: "flow", ScopeID: "Checkout", Key: "exclude", Value: "no"})
+
+	got, err := Load(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ThreatID != "web->orders/S" || got[1].Status != StatusOpen {
+		t.Errorf("Load = %+v, want both notes, oldest first", got)
+	}
+	if p := got[0].Provenance(); p[:5] != "ada, " {
+		t.Errorf("Provenance() = %q", p)
+	}
+}
@@ -0,0 +1,83 @@
+package traces
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// LinkCheck is a statically detected link and what traces say about it.
+type LinkCheck struct {
+	Link registry.ServiceLink `json:"link"`
+	// Observed is the runtime edge backing the link; nil when the link was
+	// not seen.
+	Observed *Edge `json:"observed,omitempty"`
+}
+
+// Report compares the static dependency graph with observed calls.
+type Report struct {
+	// Observed links were seen in production traces.
+	Observed []LinkCheck `json:"observed"`
+	// Unobserved links come from services that do emit traces but were never
+	// seen making the call: candidates for removal, or for dead code.
+	Unobserved []LinkCheck `json:"unobserved"`
+	// Unverifiable links come from services that emitted no traces at all, so
+	// their absence proves nothing.
+	Unverifiable []LinkCheck `json:"unverifiable"`
+	// Missing are runtime calls with no matching static link: dependencies
+	// the static analysis didn't detect.
+	Missing []Edge `json:"missing"`
+}
+
+// Compare checks links against observed edges. Links and edges are matched
+// on caller and callee, case-insensitively; the link type is not compared
+// because traces often label a call differently (e.g. gRPC over HTTP/2).
+func Compare(links []registry.ServiceLink, edges []Edge) Report {
+	type key struct{ from, to string }
+	byPair := make(map[key]*Edge, len(edges))
+	traced := make(map[string]bool) // services that made at least one observed call
+	for i := range edges {
+		e := &edges[i]
+		byPair[key{strings.ToLower(e.From), strings.ToLower(e.To)}] = e
+		traced[strings.ToLower(e.From)] = true
+	}
+
+	var r Report
+	linked := make(map[key]bool)
+	for _, l := range links {
+		k := key{strings.ToLower(l.FromRepo), strings.ToLower(l.ToRepo)}
+		linked[k] = true
+		switch e := byPair[k]; {
+		case e != nil:
+			r.Observed = append(r.Observed, LinkCheck{Link: l, Observed: e})
+		case traced[k.from]:
+			r.Unobserved = append(r.Unobserved, LinkCheck{Link: l})
+		default:
+			r.Unverifiable = append(r.Unverifiable, LinkCheck{Link: l})
+		}
+	}
+	for _, e := range edges {
+		if !linked[key{strings.ToLower(e.From), strings.ToLower(e.To)}] {
+			r.Missing = append(r.Missing, e)
+		}
+	}
+	sort.SliceStable(r.Missing, func(i, j int) bool { return r.Missing[i].Calls > r.Missing[j].Calls })
+	return r
+}
+
+// MissingLink converts a runtime-only call into a service link, for adding
+// it to the registry.
+func MissingLink(e Edge) registry.ServiceLink {
+	linkType := e.Protocol
+	if linkType == "" {
+		linkType = "http"
+	}
+	return registry.ServiceLink{
+		FromRepo:  e.From,
+		ToRepo:    e.To,
+		LinkType:  linkType,
+		Reason:    "Observed in production traces; not detected in code",
+		Endpoints: e.Endpoints,
+	}
+}
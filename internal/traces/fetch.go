@@ -0,0 +1,112 @@
+package traces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// FetchJaeger queries a Jaeger query service for recent traces of each
+// service, limit traces per service over the lookback window.
+func FetchJaeger(ctx context.Context, baseURL string, services []string, lookback time.Duration, limit int) ([]Span, error) {
+	end := time.Now()
+	var spans []Span
+	seen := make(map[string]bool) // trace IDs, since one trace spans many services
+	for _, svc := range services {
+		q := url.Values{}
+		q.Set("service", svc)
+		q.Set("start", strconv.FormatInt(end.Add(-lookback).UnixMicro(), 10))
+		q.Set("end", strconv.FormatInt(end.UnixMicro(), 10))
+		q.Set("limit", strconv.Itoa(limit))
+		data, err := get(ctx, strings.TrimRight(baseURL, "/")+"/api/traces?"+q.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("jaeger traces for %s: %w", svc, err)
+		}
+		got, err := ParseJaeger(data)
+		if err != nil {
+			return nil, fmt.Errorf("jaeger traces for %s: %w", svc, err)
+		}
+		for _, s := range got {
+			if !seen[s.TraceID+"/"+s.SpanID] {
+				seen[s.TraceID+"/"+s.SpanID] = true
+				spans = append(spans, s)
+			}
+		}
+	}
+	return spans, nil
+}
+
+// FetchTempo searches a Grafana Tempo instance for recent traces of each
+// service and downloads them in OTLP form.
+func FetchTempo(ctx context.Context, baseURL string, services []string, lookback time.Duration, limit int) ([]Span, error) {
+	base := strings.TrimRight(baseURL, "/")
+	end := time.Now()
+	traceIDs := make(map[string]bool)
+	var order []string
+	for _, svc := range services {
+		q := url.Values{}
+		q.Set("tags", "service.name="+svc)
+		q.Set("start", strconv.FormatInt(end.Add(-lookback).Unix(), 10))
+		q.Set("end", strconv.FormatInt(end.Unix(), 10))
+		q.Set("limit", strconv.Itoa(limit))
+		data, err := get(ctx, base+"/api/search?"+q.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("tempo search for %s: %w", svc, err)
+		}
+		var result struct {
+			Traces []struct {
+				TraceID string `json:"traceID"`
+			} `json:"traces"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("tempo search for %s: decoding response: %w", svc, err)
+		}
+		for _, t := range result.Traces {
+			if !traceIDs[t.TraceID] {
+				traceIDs[t.TraceID] = true
+				order = append(order, t.TraceID)
+			}
+		}
+	}
+
+	var spans []Span
+	for _, id := range order {
+		data, err := get(ctx, base+"/api/traces/"+url.PathEscape(id))
+		if err != nil {
+			return nil, fmt.Errorf("tempo trace %s: %w", id, err)
+		}
+		got, err := ParseOTLP(data)
+		if err != nil {
+			return nil, fmt.Errorf("tempo trace %s: %w", id, err)
+		}
+		spans = append(spans, got...)
+	}
+	return spans, nil
+}
+
+// get fetches endpoint and returns the body of a 200 response.
+func get(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,123 @@
+package traces
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+)
+
+// Store persists observed calls. Observations accumulate across imports, so
+// traces sampled over several days build up one picture; they are kept apart
+// from service links, which link discovery rewrites.
+type Store struct {
+	db *db.DB
+}
+
+// NewStore creates a Store backed by the given database.
+func NewStore(database *db.DB) *Store {
+	return &Store{db: database}
+}
+
+// Record merges edges into the stored observations: call and error counts
+// are added, endpoints unioned, the seen window widened, and latencies
+// averaged, weighted by calls.
+func (s *Store) Record(ctx context.Context, edges []Edge) error {
+	existing, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	type key struct{ from, to string }
+	prev := make(map[key]Edge, len(existing))
+	for _, e := range existing {
+		prev[key{e.From, e.To}] = e
+	}
+
+	for _, e := range edges {
+		if old, ok := prev[key{e.From, e.To}]; ok {
+			switch {
+			case e.LatencyMs == 0:
+				e.LatencyMs = old.LatencyMs
+			case old.LatencyMs > 0:
+				e.LatencyMs = (e.LatencyMs*float64(e.Calls) + old.LatencyMs*float64(old.Calls)) / float64(e.Calls+old.Calls)
+			}
+			e.Calls += old.Calls
+			e.Errors += old.Errors
+			for _, ep := range old.Endpoints {
+				if !contains(e.Endpoints, ep) {
+					e.Endpoints = append(e.Endpoints, ep)
+				}
+			}
+			sort.Strings(e.Endpoints)
+			if e.Protocol == "" {
+				e.Protocol = old.Protocol
+			}
+			if !old.FirstSeen.IsZero() && (e.FirstSeen.IsZero() || old.FirstSeen.Before(e.FirstSeen)) {
+				e.FirstSeen = old.FirstSeen
+			}
+			if old.LastSeen.After(e.LastSeen) {
+				e.LastSeen = old.LastSeen
+			}
+		}
+		endpoints, err := json.Marshal(e.Endpoints)
+		if err != nil {
+			return fmt.Errorf("marshalling endpoints: %w", err)
+		}
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO trace_observations (from_service, to_service, protocol, calls, errors, endpoints, latency_ms, first_seen, last_seen, imported_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(from_service, to_service) DO UPDATE SET
+				protocol=excluded.protocol, calls=excluded.calls, errors=excluded.errors, endpoints=excluded.endpoints,
+				latency_ms=excluded.latency_ms, first_seen=excluded.first_seen, last_seen=excluded.last_seen, imported_at=excluded.imported_at`,
+			e.From, e.To, e.Protocol, e.Calls, e.Errors, string(endpoints), e.LatencyMs,
+			nullTime(e.FirstSeen), nullTime(e.LastSeen), time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("saving observation %s -> %s: %w", e.From, e.To, err)
+		}
+	}
+	return nil
+}
+
+// List returns all observed calls, ordered by caller and callee.
+func (s *Store) List(ctx context.Context) ([]Edge, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT from_service, to_service, protocol, calls, errors, endpoints, latency_ms, first_seen, last_seen
+		FROM trace_observations ORDER BY from_service, to_service`)
+	if err != nil {
+		return nil, fmt.Errorf("querying trace observations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Edge
+	for rows.Next() {
+		var e Edge
+		var endpoints string
+		var first, last sql.NullTime
+		if err := rows.Scan(&e.From, &e.To, &e.Protocol, &e.Calls, &e.Errors, &endpoints, &e.LatencyMs, &first, &last); err != nil {
+			return nil, fmt.Errorf("scanning trace observation: %w", err)
+		}
+		_ = json.Unmarshal([]byte(endpoints), &e.Endpoints)
+		e.FirstSeen, e.LastSeen = first.Time, last.Time
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Clear deletes all observations, e.g. before re-importing a fresh window.
+func (s *Store) Clear(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM trace_observations`); err != nil {
+		return fmt.Errorf("clearing trace observations: %w", err)
+	}
+	return nil
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,449 @@
+// Package traces ingests distributed traces (OTLP/JSON exports, or the Jaeger
+// and Tempo query APIs) and turns them into the service-to-service calls seen
+// at runtime, so the statically detected dependency graph can be checked
+// against what production actually does.
+package traces
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Span is the subset of a trace span needed to find cross-service calls.
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+	Service  string
+	Name     string
+	Kind     string // server, client, producer, consumer, internal
+	Start    time.Time
+	Duration time.Duration // zero when the export doesn't say
+	Error    bool
+	Attrs    map[string]string
+}
+
+// Edge is one caller -> callee pair observed in traces.
+type Edge struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Protocol  string    `json:"protocol,omitempty"` // http, grpc, kafka, amqp, ...
+	Calls     int       `json:"calls"`
+	Errors    int       `json:"errors,omitempty"`
+	Endpoints []string  `json:"endpoints,omitempty"`
+	// LatencyMs is the 95th percentile duration of the calls, in
+	// milliseconds; zero when the spans carry no durations.
+	LatencyMs float64   `json:"latency_ms,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ParseOTLP reads an OTLP/JSON trace export: either one ExportTraceServiceRequest
+// ({"resourceSpans": [...]}) or newline-delimited requests, as written by the
+// collector's file exporter. Tempo's trace-by-ID API returns the same shape.
+func ParseOTLP(data []byte) ([]Span, error) {
+	type otlpValue struct {
+		StringValue *string  `json:"stringValue"`
+		IntValue    any      `json:"intValue"` // a string in proto3 JSON, a number elsewhere
+		BoolValue   *bool    `json:"boolValue"`
+		DoubleValue *float64 `json:"doubleValue"`
+	}
+	type otlpAttr struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	type otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId"`
+		Name              string     `json:"name"`
+		Kind              any        `json:"kind"`
+		StartTimeUnixNano any        `json:"startTimeUnixNano"`
+		EndTimeUnixNano   any        `json:"endTimeUnixNano"`
+		Attributes        []otlpAttr `json:"attributes"`
+		Status            struct {
+			Code any `json:"code"`
+		} `json:"status"`
+	}
+	type scopeSpans struct {
+		Spans []otlpSpan `json:"spans"`
+	}
+	type request struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttr `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []scopeSpans `json:"scopeSpans"`
+			// Older exporters used instrumentationLibrarySpans.
+			LibrarySpans []scopeSpans `json:"instrumentationLibrarySpans"`
+		} `json:"resourceSpans"`
+		// Tempo wraps the trace in {"batches": [...]} on some versions.
+		Batches json.RawMessage `json:"batches"`
+	}
+
+	value := func(v otlpValue) string {
+		switch {
+		case v.StringValue != nil:
+			return *v.StringValue
+		case v.IntValue != nil:
+			return fmt.Sprint(v.IntValue)
+		case v.BoolValue != nil:
+			return strconv.FormatBool(*v.BoolValue)
+		case v.DoubleValue != nil:
+			return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+		}
+		return ""
+	}
+
+	var spans []Span
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	for dec.More() {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return nil, fmt.Errorf("parsing OTLP JSON: %w", err)
+		}
+		if len(req.ResourceSpans) == 0 && len(req.Batches) > 0 {
+			wrapped := append(append([]byte(`{"resourceSpans":`), req.Batches...), '}')
+			if err := json.Unmarshal(wrapped, &req); err != nil {
+				return nil, fmt.Errorf("parsing OTLP batches: %w", err)
+			}
+		}
+		for _, rs := range req.ResourceSpans {
+			service := ""
+			for _, a := range rs.Resource.Attributes {
+				if a.Key == "service.name" {
+					service = value(a.Value)
+				}
+			}
+			for _, ss := range append(rs.ScopeSpans, rs.LibrarySpans...) {
+				for _, s := range ss.Spans {
+					span := Span{
+						TraceID:  s.TraceID,
+						SpanID:   s.SpanID,
+						ParentID: s.ParentSpanID,
+						Service:  service,
+						Name:     s.Name,
+						Kind:     otlpKind(s.Kind),
+						Start:    unixNano(s.StartTimeUnixNano),
+						Error:    fmt.Sprint(s.Status.Code) == "2" || fmt.Sprint(s.Status.Code) == "STATUS_CODE_ERROR",
+						Attrs:    make(map[string]string, len(s.Attributes)),
+					}
+					if end := unixNano(s.EndTimeUnixNano); !span.Start.IsZero() && end.After(span.Start) {
+						span.Duration = end.Sub(span.Start)
+					}
+					for _, a := range s.Attributes {
+						span.Attrs[a.Key] = value(a.Value)
+					}
+					spans = append(spans, span)
+				}
+			}
+		}
+	}
+	return spans, nil
+}
+
+// otlpKind maps an OTLP span kind, given as a number or enum name, to a
+// lower-case name.
+func otlpKind(k any) string {
+	switch fmt.Sprint(k) {
+	case "1", "SPAN_KIND_INTERNAL":
+		return "internal"
+	case "2", "SPAN_KIND_SERVER":
+		return "server"
+	case "3", "SPAN_KIND_CLIENT":
+		return "client"
+	case "4", "SPAN_KIND_PRODUCER":
+		return "producer"
+	case "5", "SPAN_KIND_CONSUMER":
+		return "consumer"
+	}
+	return ""
+}
+
+func unixNano(v any) time.Time {
+	n, err := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+// ParseJaeger reads the JSON returned by Jaeger's query API (/api/traces) or
+// its UI's "Download JSON" export.
+func ParseJaeger(data []byte) ([]Span, error) {
+	var body struct {
+		Data []struct {
+			TraceID string `json:"traceID"`
+			Spans   []struct {
+				TraceID       string `json:"traceID"`
+				SpanID        string `json:"spanID"`
+				OperationName string `json:"operationName"`
+				References    []struct {
+					RefType string `json:"refType"`
+					SpanID  string `json:"spanID"`
+				} `json:"references"`
+				StartTime int64  `json:"startTime"` // microseconds
+				Duration  int64  `json:"duration"`  // microseconds
+				ProcessID string `json:"processID"`
+				Tags      []struct {
+					Key   string `json:"key"`
+					Value any    `json:"value"`
+				} `json:"tags"`
+			} `json:"spans"`
+			Processes map[string]struct {
+				ServiceName string `json:"serviceName"`
+			} `json:"processes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("parsing Jaeger JSON: %w", err)
+	}
+
+	var spans []Span
+	for _, t := range body.Data {
+		for _, s := range t.Spans {
+			span := Span{
+				TraceID: orDefault(s.TraceID, t.TraceID),
+				SpanID:  s.SpanID,
+				Service: t.Processes[s.ProcessID].ServiceName,
+				Name:    s.OperationName,
+				Start:    time.UnixMicro(s.StartTime).UTC(),
+				Duration: time.Duration(s.Duration) * time.Microsecond,
+				Attrs:    make(map[string]string, len(s.Tags)),
+			}
+			for _, r := range s.References {
+				if r.RefType == "CHILD_OF" || span.ParentID == "" {
+					span.ParentID = r.SpanID
+				}
+			}
+			for _, tag := range s.Tags {
+				span.Attrs[tag.Key] = fmt.Sprint(tag.Value)
+			}
+			span.Kind = span.Attrs["span.kind"]
+			span.Error = span.Attrs["error"] == "true"
+			spans = append(spans, span)
+		}
+	}
+	return spans, nil
+}
+
+// Parse detects whether data is OTLP or Jaeger JSON and parses it.
+func Parse(data []byte) ([]Span, error) {
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	if strings.Contains(string(head), `"resourceSpans"`) || strings.Contains(string(head), `"batches"`) {
+		return ParseOTLP(data)
+	}
+	if strings.Contains(string(head), `"data"`) {
+		return ParseJaeger(data)
+	}
+	return nil, fmt.Errorf("unrecognised trace format: want OTLP JSON (resourceSpans) or Jaeger JSON (data)")
+}
+
+// Resolver maps the service names used in traces to registered repo names.
+type Resolver struct {
+	repos   map[string]string // lower-cased repo name -> repo name
+	aliases map[string]string // lower-cased trace service -> repo name
+}
+
+// NewResolver creates a resolver for the given repos. aliases maps trace
+// service names to repo names where they differ beyond case and common
+// suffixes such as "-service".
+func NewResolver(repos []string, aliases map[string]string) *Resolver {
+	r := &Resolver{repos: make(map[string]string), aliases: make(map[string]string)}
+	for _, name := range repos {
+		r.repos[strings.ToLower(name)] = name
+	}
+	for from, to := range aliases {
+		r.aliases[strings.ToLower(from)] = to
+	}
+	return r
+}
+
+// Resolve returns the repo name for a trace service, and whether it is a
+// registered repo. Unknown services are returned lower-cased.
+func (r *Resolver) Resolve(service string) (string, bool) {
+	s := strings.ToLower(strings.TrimSpace(service))
+	if repo, ok := r.aliases[s]; ok {
+		_, known := r.repos[strings.ToLower(repo)]
+		return repo, known
+	}
+	if repo, ok := r.repos[s]; ok {
+		return repo, true
+	}
+	for _, suffix := range []string{"-service", "_service", "service", "-svc", "-api", "-server"} {
+		if trimmed, ok := strings.CutSuffix(s, suffix); ok && trimmed != "" {
+			if repo, ok := r.repos[trimmed]; ok {
+				return repo, true
+			}
+		}
+	}
+	return s, false
+}
+
+// Edges extracts the cross-service calls in spans. A call is counted when a
+// span's parent belongs to a different service, or when a client or producer
+// span names a peer (peer.service, server.address) that never shows up as a
+// child span, e.g. because the callee isn't instrumented. Only calls made by
+// registered services are returned.
+func Edges(spans []Span, r *Resolver) []Edge {
+	type spanKey struct{ trace, span string }
+	byID := make(map[spanKey]*Span, len(spans))
+	for i := range spans {
+		byID[spanKey{spans[i].TraceID, spans[i].SpanID}] = &spans[i]
+	}
+
+	type edgeKey struct{ from, to string }
+	edges := make(map[edgeKey]*Edge)
+	durations := make(map[edgeKey][]time.Duration)
+	add := func(from, to string, s *Span) {
+		fromRepo, known := r.Resolve(from)
+		toRepo, _ := r.Resolve(to)
+		if !known || fromRepo == toRepo || toRepo == "" {
+			return
+		}
+		e := edges[edgeKey{fromRepo, toRepo}]
+		if e == nil {
+			e = &Edge{From: fromRepo, To: toRepo, FirstSeen: s.Start, LastSeen: s.Start}
+			edges[edgeKey{fromRepo, toRepo}] = e
+		}
+		e.Calls++
+		if s.Duration > 0 {
+			durations[edgeKey{fromRepo, toRepo}] = append(durations[edgeKey{fromRepo, toRepo}], s.Duration)
+		}
+		if s.Error {
+			e.Errors++
+		}
+		if p := protocol(s); e.Protocol == "" {
+			e.Protocol = p
+		}
+		if ep := endpoint(s); ep != "" && len(e.Endpoints) < 20 && !contains(e.Endpoints, ep) {
+			e.Endpoints = append(e.Endpoints, ep)
+		}
+		if !s.Start.IsZero() {
+			if e.FirstSeen.IsZero() || s.Start.Before(e.FirstSeen) {
+				e.FirstSeen = s.Start
+			}
+			if s.Start.After(e.LastSeen) {
+				e.LastSeen = s.Start
+			}
+		}
+	}
+
+	// Client spans whose call was already counted through a child span.
+	answered := make(map[spanKey]bool)
+	for i := range spans {
+		s := &spans[i]
+		if s.ParentID == "" {
+			continue
+		}
+		parent := byID[spanKey{s.TraceID, s.ParentID}]
+		if parent == nil || parent.Service == s.Service {
+			continue
+		}
+		answered[spanKey{parent.TraceID, parent.SpanID}] = true
+		add(parent.Service, s.Service, s)
+	}
+	for i := range spans {
+		s := &spans[i]
+		if (s.Kind != "client" && s.Kind != "producer") || answered[spanKey{s.TraceID, s.SpanID}] {
+			continue
+		}
+		if peer := peerService(s); peer != "" {
+			add(s.Service, peer, s)
+		}
+	}
+
+	out := make([]Edge, 0, len(edges))
+	for k, e := range edges {
+		sort.Strings(e.Endpoints)
+		e.LatencyMs = p95Ms(durations[k])
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+// p95Ms returns the 95th percentile of durations in milliseconds, or zero
+// for none.
+func p95Ms(durations []time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	i := (len(durations)*95+99)/100 - 1
+	return float64(durations[i]) / float64(time.Millisecond)
+}
+
+// peerService names the service a client span called, from the semantic
+// convention attributes, preferring the explicit peer.service.
+func peerService(s *Span) string {
+	for _, key := range []string{"peer.service", "server.address", "net.peer.name", "messaging.destination.name", "messaging.destination"} {
+		if v := s.Attrs[key]; v != "" {
+			if key == "server.address" || key == "net.peer.name" {
+				// Hostnames: keep the first label of "orders.prod.svc.cluster.local".
+				v, _, _ = strings.Cut(v, ".")
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// protocol classifies a span's call from its attributes.
+func protocol(s *Span) string {
+	switch {
+	case s.Attrs["rpc.system"] != "":
+		return s.Attrs["rpc.system"]
+	case s.Attrs["messaging.system"] != "":
+		switch m := s.Attrs["messaging.system"]; m {
+		case "rabbitmq":
+			return "amqp"
+		default:
+			return m
+		}
+	case s.Attrs["http.method"] != "" || s.Attrs["http.request.method"] != "" || s.Attrs["http.route"] != "":
+		return "http"
+	}
+	return ""
+}
+
+// endpoint names what was called: an HTTP route, an RPC method, or a
+// messaging destination.
+func endpoint(s *Span) string {
+	if svc, method := s.Attrs["rpc.service"], s.Attrs["rpc.method"]; svc != "" {
+		return svc + "/" + method
+	}
+	if route := orDefault(s.Attrs["http.route"], orDefault(s.Attrs["url.path"], s.Attrs["http.target"])); route != "" {
+		route, _, _ = strings.Cut(route, "?")
+		return route
+	}
+	return orDefault(s.Attrs["messaging.destination.name"], s.Attrs["messaging.destination"])
+}
+
+func orDefault(v, fallback string) string {
+	if v != "" {
+		return v
+	}
+	return fallback
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
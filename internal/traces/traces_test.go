@@ -0,0 +1,171 @@
+package traces
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// otlpExport is a checkout trace: gateway calls orders (both instrumented),
+// orders calls an uninstrumented payments service over gRPC, and publishes
+// to Kafka.
+const otlpExport = `{"resourceSpans":[
+ {"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"gateway"}}]},
+  "scopeSpans":[{"spans":[
+   {"traceId":"t1","spanId":"a","name":"GET /orders","kind":2,"startTimeUnixNano":"1700000000000000000"},
+   {"traceId":"t1","spanId":"b","parentSpanId":"a","name":"POST","kind":3,"startTimeUnixNano":"1700000000100000000",
+    "attributes":[{"key":"http.request.method","value":{"stringValue":"POST"}},{"key":"server.address","value":{"stringValue":"orders.prod.svc.cluster.local"}}]}
+  ]}]},
+ {"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"orders-service"}}]},
+  "scopeSpans":[{"spans":[
+   {"traceId":"t1","spanId":"c","parentSpanId":"b","name":"POST /api/orders","kind":"SPAN_KIND_SERVER","startTimeUnixNano":"1700000000200000000","endTimeUnixNano":"1700000000320000000",
+    "attributes":[{"key":"http.route","value":{"stringValue":"/api/orders"}},{"key":"http.request.method","value":{"stringValue":"POST"}}]},
+   {"traceId":"t1","spanId":"d","parentSpanId":"c","name":"payments.Charge","kind":3,"startTimeUnixNano":"1700000000300000000",
+    "attributes":[{"key":"rpc.system","value":{"stringValue":"grpc"}},{"key":"rpc.service","value":{"stringValue":"payments.Payments"}},{"key":"rpc.method","value":{"stringValue":"Charge"}},{"key":"peer.service","value":{"stringValue":"payments"}}],
+    "status":{"code":2}},
+   {"traceId":"t1","spanId":"e","parentSpanId":"c","name":"order-events publish","kind":4,
+    "attributes":[{"key":"messaging.system","value":{"stringValue":"kafka"}},{"key":"messaging.destination.name","value":{"stringValue":"order-events"}}]}
+  ]}]}
+]}`
+
+const jaegerExport = `{"data":[{"traceID":"j1","spans":[
+ {"traceID":"j1","spanID":"1","operationName":"GET /search","processID":"p1","startTime":1700000000000000,"tags":[{"key":"span.kind","value":"server"}]},
+ {"traceID":"j1","spanID":"2","operationName":"GET /api/products","processID":"p2","startTime":1700000000100000,"duration":45000,
+  "references":[{"refType":"CHILD_OF","spanID":"1"}],"tags":[{"key":"span.kind","value":"server"},{"key":"http.route","value":"/api/products"},{"key":"error","value":true}]}
+],"processes":{"p1":{"serviceName":"search"},"p2":{"serviceName":"catalog"}}}]}`
+
+func TestParseAndEdges(t *testing.T) {
+	spans, err := Parse([]byte(otlpExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 5 || spans[2].Service != "orders-service" || spans[2].Kind != "server" || !spans[3].Error {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+
+	r := NewResolver([]string{"gateway", "orders", "payments"}, nil)
+	edges := Edges(spans, r)
+	got := make(map[string]Edge)
+	for _, e := range edges {
+		got[e.From+"->"+e.To] = e
+	}
+	if len(edges) != 3 {
+		t.Fatalf("edges = %+v", edges)
+	}
+	// gateway -> orders is counted once, through the server span, even though
+	// the client span also names its peer.
+	if e := got["gateway->orders"]; e.Calls != 1 || e.Protocol != "http" || strings.Join(e.Endpoints, ",") != "/api/orders" || e.LatencyMs != 120 {
+		t.Errorf("gateway->orders = %+v", e)
+	}
+	if e := got["orders->payments"]; e.Calls != 1 || e.Errors != 1 || e.Protocol != "grpc" || e.Endpoints[0] != "payments.Payments/Charge" {
+		t.Errorf("orders->payments = %+v", e)
+	}
+	if e := got["orders->order-events"]; e.Protocol != "kafka" {
+		t.Errorf("orders->order-events = %+v", e)
+	}
+
+	spans, err = Parse([]byte(jaegerExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges = Edges(spans, NewResolver([]string{"search", "catalog"}, nil))
+	if len(edges) != 1 || edges[0].From != "search" || edges[0].To != "catalog" || edges[0].Errors != 1 || edges[0].LatencyMs != 45 {
+		t.Errorf("jaeger edges = %+v", edges)
+	}
+
+	if _, err := Parse([]byte(`{"spans":[]}`)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestResolver(t *testing.T) {
+	r := NewResolver([]string{"Orders", "payments"}, map[string]string{"billing-gw": "payments"})
+	tests := []struct {
+		in    string
+		want  string
+		known bool
+	}{
+		{"orders", "Orders", true},
+		{"ORDERS-SERVICE", "Orders", true},
+		{"payments-api", "payments", true},
+		{"billing-gw", "payments", true},
+		{"Stripe", "stripe", false},
+	}
+	for _, tt := range tests {
+		got, known := r.Resolve(tt.in)
+		if got != tt.want || known != tt.known {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, %v", tt.in, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	links := []registry.ServiceLink{
+		{FromRepo: "gateway", ToRepo: "orders", LinkType: "http"},
+		{FromRepo: "orders", ToRepo: "legacy-billing", LinkType: "http"},
+		{FromRepo: "batch", ToRepo: "orders", LinkType: "http"},
+	}
+	edges := []Edge{
+		{From: "gateway", To: "orders", Calls: 10},
+		{From: "orders", To: "payments", Protocol: "grpc", Calls: 7},
+	}
+	r := Compare(links, edges)
+	if len(r.Observed) != 1 || r.Observed[0].Observed.Calls != 10 {
+		t.Errorf("observed = %+v", r.Observed)
+	}
+	if len(r.Unobserved) != 1 || r.Unobserved[0].Link.ToRepo != "legacy-billing" {
+		t.Errorf("unobserved = %+v", r.Unobserved)
+	}
+	if len(r.Unverifiable) != 1 || r.Unverifiable[0].Link.FromRepo != "batch" {
+		t.Errorf("unverifiable = %+v", r.Unverifiable)
+	}
+	if len(r.Missing) != 1 || r.Missing[0].To != "payments" {
+		t.Errorf("missing = %+v", r.Missing)
+	}
+	if l := MissingLink(r.Missing[0]); l.LinkType != "grpc" || l.FromRepo != "orders" {
+		t.Errorf("MissingLink = %+v", l)
+	}
+}
+
+func TestStoreAccumulates(t *testing.T) {
+	d, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx := context.Background()
+	s := NewStore(d)
+
+	spans, _ := Parse([]byte(otlpExport))
+	edges := Edges(spans, NewResolver([]string{"gateway", "orders", "payments"}, nil))
+	for i := 0; i < 2; i++ {
+		if err := s.Record(ctx, edges); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("List = %+v", got)
+	}
+	for _, e := range got {
+		if e.Calls != 2 {
+			t.Errorf("%s -> %s calls = %d, want 2", e.From, e.To, e.Calls)
+		}
+	}
+	if got[0].From != "gateway" || got[0].FirstSeen.IsZero() || got[0].LatencyMs != 120 {
+		t.Errorf("first observation = %+v", got[0])
+	}
+
+	if err := s.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.List(ctx); len(got) != 0 {
+		t.Errorf("after Clear, List = %+v", got)
+	}
+}
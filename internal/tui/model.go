@@ -0,0 +1,499 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+type tab int
+
+const (
+	tabRepos tab = iota
+	tabNotifications
+	tabServices
+	numTabs
+)
+
+var tabNames = [numTabs]string{"Repos", "Notifications", "Services"}
+
+// Key is a key press: a printable character, or a named key such as "up",
+// "enter", "esc", "tab", or "ctrl+c".
+type Key string
+
+// Size is the terminal size, in cells.
+type Size struct {
+	Width, Height int
+}
+
+// Msg is an input to Update: a Key, a Size, a *Snapshot, or the error from
+// loading one.
+type Msg any
+
+// Cmd is what Update asks the caller to do next.
+type Cmd int
+
+const (
+	None Cmd = iota
+	Reload
+	Quit
+)
+
+// crumb is a service the dependency browser passed through, with the row it
+// left selected.
+type crumb struct {
+	service string
+	cursor  int
+}
+
+// Model is the dashboard state.
+type Model struct {
+	snap *Snapshot
+	err  error
+
+	tab    tab
+	cursor [numTabs]int
+
+	// focus is the service the dependency browser shows; empty lists every
+	// service. trail leads back to the list.
+	focus string
+	trail []crumb
+
+	width, height int
+	now           func() time.Time
+}
+
+// New returns a dashboard showing snap, which may be nil until the first
+// load finishes.
+func New(snap *Snapshot) *Model {
+	return &Model{snap: snap, width: 80, height: 24, now: time.Now}
+}
+
+// Update applies msg to the model.
+func (m *Model) Update(msg Msg) Cmd {
+	switch msg := msg.(type) {
+	case *Snapshot:
+		m.snap, m.err = msg, nil
+		if m.focus != "" && !contains(msg.services(), m.focus) {
+			m.focus, m.trail = "", nil
+		}
+		m.clampCursor()
+	case error:
+		m.err = msg
+	case Size:
+		m.width, m.height = msg.Width, msg.Height
+	case Key:
+		return m.key(msg)
+	}
+	return None
+}
+
+func (m *Model) key(k Key) Cmd {
+	switch k {
+	case "q", "ctrl+c":
+		return Quit
+	case "r":
+		return Reload
+	case "1", "2", "3":
+		m.tab = tab(k[0] - '1')
+	case "tab":
+		m.tab = (m.tab + 1) % numTabs
+	case "backtab":
+		m.tab = (m.tab + numTabs - 1) % numTabs
+	case "up", "k":
+		m.cursor[m.tab]--
+	case "down", "j":
+		m.cursor[m.tab]++
+	case "pgup":
+		m.cursor[m.tab] -= m.listHeight()
+	case "pgdown":
+		m.cursor[m.tab] += m.listHeight()
+	case "home", "g":
+		m.cursor[m.tab] = 0
+	case "end", "G":
+		m.cursor[m.tab] = m.rowCount() - 1
+	case "enter", "right", "l":
+		if m.tab == tabServices {
+			m.enter()
+		}
+	case "esc", "backspace", "left", "h":
+		if m.tab == tabServices {
+			m.back()
+		}
+	}
+	m.clampCursor()
+	return None
+}
+
+// enter moves the dependency browser to the selected service.
+func (m *Model) enter() {
+	var next string
+	if m.focus == "" {
+		if names := m.services(); len(names) > 0 {
+			next = names[m.cursor[tabServices]]
+		}
+	} else if edges := m.edges(); len(edges) > 0 {
+		next = edges[m.cursor[tabServices]].other
+	}
+	if next == "" {
+		return
+	}
+	m.trail = append(m.trail, crumb{service: m.focus, cursor: m.cursor[tabServices]})
+	m.focus = next
+	m.cursor[tabServices] = 0
+}
+
+// back returns the dependency browser to the previous service, or the list.
+func (m *Model) back() {
+	if len(m.trail) == 0 {
+		return
+	}
+	last := m.trail[len(m.trail)-1]
+	m.trail = m.trail[:len(m.trail)-1]
+	m.focus, m.cursor[tabServices] = last.service, last.cursor
+}
+
+func (m *Model) clampCursor() {
+	for t := range m.cursor {
+		n := m.rowCountFor(tab(t))
+		m.cursor[t] = max(0, min(m.cursor[t], n-1))
+	}
+}
+
+func (m *Model) rowCount() int { return m.rowCountFor(m.tab) }
+
+func (m *Model) rowCountFor(t tab) int {
+	if m.snap == nil {
+		return 0
+	}
+	switch t {
+	case tabRepos:
+		return len(m.snap.Repos)
+	case tabNotifications:
+		return len(m.snap.Notifications)
+	case tabServices:
+		if m.focus == "" {
+			return len(m.services())
+		}
+		return len(m.edges())
+	}
+	return 0
+}
+
+func (m *Model) services() []string {
+	if m.snap == nil {
+		return nil
+	}
+	return m.snap.services()
+}
+
+// edge is a link of the focused service, seen from its side.
+type edge struct {
+	outgoing bool
+	other    string
+	link     registry.ServiceLink
+}
+
+// edges lists what the focused service calls, then what calls it.
+func (m *Model) edges() []edge {
+	if m.snap == nil || m.focus == "" {
+		return nil
+	}
+	var out, in []edge
+	for _, l := range m.snap.Links {
+		switch m.focus {
+		case l.FromRepo:
+			out = append(out, edge{outgoing: true, other: l.ToRepo, link: l})
+		case l.ToRepo:
+			in = append(in, edge{other: l.FromRepo, link: l})
+		}
+	}
+	return append(out, in...)
+}
+
+// Layout: a tab bar and a blank line, the list, a detail pane, and a blank
+// line and the footer.
+const (
+	headerLines = 2
+	footerLines = 2
+	detailLines = 5
+)
+
+func (m *Model) listHeight() int {
+	return max(1, m.height-headerLines-footerLines-detailLines)
+}
+
+// View renders the dashboard as lines of text, styled with ANSI escapes.
+func (m *Model) View() []string {
+	lines := []string{m.tabBar(), ""}
+
+	var rows, detail []string
+	switch {
+	case m.snap == nil && m.err == nil:
+		rows = []string{dim("Loading…")}
+	case m.snap == nil:
+		rows = []string{red("Could not load: " + m.err.Error())}
+	case m.tab == tabRepos:
+		rows, detail = m.reposView()
+	case m.tab == tabNotifications:
+		rows, detail = m.notificationsView()
+	case m.tab == tabServices:
+		rows, detail = m.servicesView()
+	}
+
+	lines = append(lines, m.window(rows)...)
+	for len(lines) < headerLines+m.listHeight() {
+		lines = append(lines, "")
+	}
+	for i := 0; i < detailLines; i++ {
+		if i < len(detail) {
+			lines = append(lines, truncate(detail[i], m.width))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	return append(lines, "", m.footer())
+}
+
+// window returns the rows that fit the list area, scrolled to keep the
+// cursor in view. The first row is a column header when the tab has one.
+func (m *Model) window(rows []string) []string {
+	height := m.listHeight()
+	if len(rows) <= height {
+		return rows
+	}
+	header, body := rows[:1], rows[1:]
+	height--
+	start := max(0, min(m.cursor[m.tab]-height/2, len(body)-height))
+	return append(header, body[start:start+height]...)
+}
+
+func (m *Model) tabBar() string {
+	var parts []string
+	for t := tab(0); t < numTabs; t++ {
+		label := fmt.Sprintf(" %d %s ", t+1, tabNames[t])
+		if m.snap != nil {
+			switch t {
+			case tabRepos:
+				label = fmt.Sprintf(" %d %s (%d) ", t+1, tabNames[t], len(m.snap.Repos))
+			case tabNotifications:
+				label = fmt.Sprintf(" %d %s (%d) ", t+1, tabNames[t], len(m.snap.Notifications))
+			}
+		}
+		if t == m.tab {
+			label = reverse(label)
+		}
+		parts = append(parts, label)
+	}
+	return bold("autodoc") + "  " + strings.Join(parts, " ")
+}
+
+func (m *Model) footer() string {
+	help := "↑/↓ move  1-3/tab switch  r reload  q quit"
+	if m.tab == tabServices {
+		help = "↑/↓ move  enter open  esc back  1-3/tab switch  r reload  q quit"
+	}
+	status := ""
+	switch {
+	case m.err != nil && m.snap != nil:
+		status = red("reload failed: " + m.err.Error())
+	case m.snap != nil:
+		status = "updated " + ago(m.snap.LoadedAt, m.now())
+	}
+	return dim(help) + "   " + status
+}
+
+// row renders one selectable list row, highlighted under the cursor.
+func (m *Model) row(i int, text string) string {
+	text = truncate(text, m.width-2)
+	if i == m.cursor[m.tab] {
+		return reverse("> " + pad(text, m.width-2))
+	}
+	return "  " + text
+}
+
+func (m *Model) reposView() (rows, detail []string) {
+	if len(m.snap.Repos) == 0 {
+		return []string{dim("No repositories registered. Add one with `autodoc repo add`.")}, nil
+	}
+	nameWidth := 4
+	for _, r := range m.snap.Repos {
+		nameWidth = max(nameWidth, len(r.Name))
+	}
+	rows = append(rows, bold(fmt.Sprintf("  %-*s  %-10s  %6s  %s", nameWidth, "NAME", "STATUS", "FILES", "LAST INDEXED")))
+	counts := make(map[string]int)
+	for i, r := range m.snap.Repos {
+		counts[r.Status]++
+		line := fmt.Sprintf("%-*s  %s  %6d  %s", nameWidth, r.Name, statusStyle(r.Status, fmt.Sprintf("%-10s", r.Status)), r.FileCount, indexedAgo(r.LastIndexedAt, m.now()))
+		if i == m.cursor[tabRepos] {
+			line = fmt.Sprintf("%-*s  %-10s  %6d  %s", nameWidth, r.Name, r.Status, r.FileCount, indexedAgo(r.LastIndexedAt, m.now()))
+		}
+		rows = append(rows, m.row(i, line))
+	}
+
+	r := m.snap.Repos[m.cursor[tabRepos]]
+	detail = append(detail, bold(r.Name)+dim("  "+source(r)))
+	if r.Summary != "" {
+		detail = append(detail, r.Summary)
+	}
+	var tally []string
+	for _, s := range []string{"ready", "indexing", "pending", "error"} {
+		if counts[s] > 0 {
+			tally = append(tally, statusStyle(s, fmt.Sprintf("%d %s", counts[s], s)))
+		}
+	}
+	detail = append(detail, "", strings.Join(tally, "  "))
+	return rows, detail
+}
+
+func (m *Model) notificationsView() (rows, detail []string) {
+	if len(m.snap.Notifications) == 0 {
+		return []string{dim("No pending notifications.")}, nil
+	}
+	rows = append(rows, bold(fmt.Sprintf("  %-8s  %-8s  %s", "SEVERITY", "AGE", "TITLE")))
+	for i, n := range m.snap.Notifications {
+		sev := fmt.Sprintf("%-8s", n.Severity)
+		if i != m.cursor[tabNotifications] {
+			sev = severityStyle(n.Severity, sev)
+		}
+		rows = append(rows, m.row(i, fmt.Sprintf("%s  %-8s  %s", sev, ago(n.CreatedAt, m.now()), n.Title)))
+	}
+
+	n := m.snap.Notifications[m.cursor[tabNotifications]]
+	detail = append(detail, bold(n.Title)+dim("  "+string(n.Type)))
+	if n.Message != "" {
+		detail = append(detail, strings.SplitN(n.Message, "\n", 2)[0])
+	}
+	if len(n.AffectedServices) > 0 {
+		detail = append(detail, "services: "+strings.Join(n.AffectedServices, ", "))
+	}
+	if len(n.AffectedTeams) > 0 {
+		detail = append(detail, "teams: "+strings.Join(n.AffectedTeams, ", "))
+	}
+	return rows, detail
+}
+
+func (m *Model) servicesView() (rows, detail []string) {
+	if m.focus == "" {
+		names := m.services()
+		if len(names) == 0 {
+			return []string{dim("No services yet.")}, nil
+		}
+		calls, callers := make(map[string]int), make(map[string]int)
+		for _, l := range m.snap.Links {
+			calls[l.FromRepo]++
+			callers[l.ToRepo]++
+		}
+		rows = append(rows, bold(fmt.Sprintf("  %-30s  %5s  %9s", "SERVICE", "CALLS", "CALLED BY")))
+		for i, name := range names {
+			rows = append(rows, m.row(i, fmt.Sprintf("%-30s  %5d  %9d", name, calls[name], callers[name])))
+		}
+		return rows, []string{dim("Select a service to browse its dependencies.")}
+	}
+
+	edges := m.edges()
+	rows = append(rows, bold("  "+m.breadcrumbs()))
+	if len(edges) == 0 {
+		rows = append(rows, dim("  No known links."))
+	}
+	for i, e := range edges {
+		arrow := "← called by"
+		if e.outgoing {
+			arrow = "→ calls    "
+		}
+		line := fmt.Sprintf("%s  %-30s  %-6s  %s", arrow, e.other, e.link.LinkType, strings.Join(e.link.Endpoints, ", "))
+		rows = append(rows, m.row(i, line))
+	}
+
+	if r := m.snap.repo(m.focus); r != nil {
+		detail = append(detail, bold(r.Name)+"  "+statusStyle(r.Status, r.Status)+dim("  indexed "+indexedAgo(r.LastIndexedAt, m.now())))
+		if r.Summary != "" {
+			detail = append(detail, r.Summary)
+		}
+	} else {
+		detail = append(detail, bold(m.focus)+dim("  not registered"))
+	}
+	if len(edges) > 0 && edges[m.cursor[tabServices]].link.Reason != "" {
+		detail = append(detail, "", dim("link: "+edges[m.cursor[tabServices]].link.Reason))
+	}
+	return rows, detail
+}
+
+// breadcrumbs is the path the browser took to the focused service.
+func (m *Model) breadcrumbs() string {
+	var path []string
+	for _, c := range m.trail {
+		if c.service != "" {
+			path = append(path, c.service)
+		}
+	}
+	return strings.Join(append(path, m.focus), " › ")
+}
+
+func source(r registry.Repository) string {
+	switch {
+	case r.Parent != "":
+		return r.Parent + "/" + r.Subdir
+	case r.SourceURL != "":
+		return r.SourceURL
+	}
+	return r.SourceType
+}
+
+func indexedAgo(lastIndexed string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, lastIndexed)
+	if err != nil {
+		return "never"
+	}
+	return ago(t, now)
+}
+
+// ago renders how long before now t was, coarsely.
+func ago(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case t.IsZero():
+		return "never"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", max(0, int(d.Seconds())))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+func statusStyle(status, s string) string {
+	switch status {
+	case "ready":
+		return green(s)
+	case "error":
+		return red(s)
+	case "indexing", "pending":
+		return yellow(s)
+	}
+	return s
+}
+
+func severityStyle(sev notifications.Severity, s string) string {
+	switch sev {
+	case notifications.SeverityCritical:
+		return red(s)
+	case notifications.SeverityWarning:
+		return yellow(s)
+	}
+	return dim(s)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
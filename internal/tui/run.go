@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Run shows the dashboard on the terminal until the user quits. It loads a
+// snapshot at the start, every refresh interval, and when the user presses
+// r.
+func Run(ctx context.Context, in, out *os.File, load func(context.Context) (*Snapshot, error), refresh time.Duration) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(out.Fd())) {
+		return errors.New("the dashboard needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("entering raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	// Switch to the alternate screen and hide the cursor while drawing.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan Key)
+	readErr := make(chan error, 1)
+	go readKeys(in, keys, readErr)
+
+	loaded := make(chan Msg, 1)
+	loading := false
+	reload := func() {
+		if loading {
+			return
+		}
+		loading = true
+		go func() {
+			snap, err := load(ctx)
+			if err != nil {
+				loaded <- err
+				return
+			}
+			loaded <- snap
+		}()
+	}
+
+	m := New(nil)
+	reload()
+	reloadTicker := time.NewTicker(refresh)
+	defer reloadTicker.Stop()
+	// Redraw every second so the "updated ... ago" age stays current and a
+	// resized terminal is picked up.
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	for {
+		if w, h, err := term.GetSize(int(out.Fd())); err == nil {
+			m.Update(Size{Width: w, Height: h})
+		}
+		draw(out, m.View())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return fmt.Errorf("reading keys: %w", err)
+		case k := <-keys:
+			switch m.Update(k) {
+			case Quit:
+				return nil
+			case Reload:
+				reload()
+			}
+		case msg := <-loaded:
+			loading = false
+			m.Update(msg)
+		case <-reloadTicker.C:
+			reload()
+		case <-redraw.C:
+		}
+	}
+}
+
+// draw repaints the screen with lines, clearing what each line doesn't
+// cover.
+func draw(out *os.File, lines []string) {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(line + "\x1b[0m\x1b[K")
+	}
+	sb.WriteString("\x1b[J")
+	out.WriteString(sb.String())
+}
+
+func readKeys(in *os.File, keys chan<- Key, errs chan<- error) {
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			errs <- err
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// escapeKeys maps the escape sequences terminals send for special keys.
+var escapeKeys = map[string]Key{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[7~": "home", "[4~": "end", "[8~": "end",
+	"[5~": "pgup", "[6~": "pgdown", "[Z": "backtab",
+}
+
+// parseKeys splits what one read from the terminal returned into keys.
+func parseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			k, n := parseEscape(b[1:])
+			if k != "" {
+				keys = append(keys, k)
+			}
+			b = b[1+n:]
+			continue
+		case c == 3:
+			keys = append(keys, "ctrl+c")
+		case c == '\t':
+			keys = append(keys, "tab")
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+		case c == 127 || c == 8:
+			keys = append(keys, "backspace")
+		case c < 0x20:
+			// Other control keys do nothing.
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, Key(string(r)))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// parseEscape reads the rest of an escape sequence, returning the key and
+// how many bytes it took. A lone escape is the esc key; an unknown sequence
+// is no key.
+func parseEscape(b []byte) (Key, int) {
+	if len(b) == 0 || (b[0] != '[' && b[0] != 'O') {
+		return "esc", 0
+	}
+	n := 1
+	for n < len(b) && (b[n] >= '0' && b[n] <= '9' || b[n] == ';') {
+		n++
+	}
+	if n < len(b) {
+		n++ // the final byte
+	}
+	if k, ok := escapeKeys[string(b[:n])]; ok {
+		return k, n
+	}
+	return "", n
+}
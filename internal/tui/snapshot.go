@@ -0,0 +1,87 @@
+// Package tui is the interactive terminal dashboard behind `autodoc tui`. It
+// shows the registered repos and their index status, the pending change
+// notifications, and a service dependency browser, so operators can check
+// the state of a central server without opening the site.
+//
+// The dashboard follows the model-update-view pattern: Model holds the
+// state, Update applies a key press or a new Snapshot to it, and View
+// renders it as text. Run wires a Model to a raw-mode terminal.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+// maxNotifications caps how many pending notifications a snapshot loads.
+const maxNotifications = 200
+
+// Snapshot is the system state the dashboard shows.
+type Snapshot struct {
+	Repos []registry.Repository
+	Links []registry.ServiceLink
+	// Notifications are the ones not yet delivered and not muted, newest
+	// first.
+	Notifications []notifications.Notification
+	LoadedAt      time.Time
+}
+
+// Load reads a snapshot from the central database.
+func Load(ctx context.Context, database *db.DB) (*Snapshot, error) {
+	repos, err := registry.NewStore(database).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing repos: %w", err)
+	}
+	links, err := registry.NewStore(database).GetLinks(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("listing links: %w", err)
+	}
+	no := false
+	pending, err := notifications.NewStore(database).List(ctx, notifications.ListFilter{
+		Delivered:  &no,
+		Suppressed: &no,
+		Limit:      maxNotifications,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing notifications: %w", err)
+	}
+	return &Snapshot{Repos: repos, Links: links, Notifications: pending, LoadedAt: time.Now()}, nil
+}
+
+// services returns every service the snapshot knows of, registered or only
+// named by a link, sorted.
+func (s *Snapshot) services() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, r := range s.Repos {
+		add(r.Name)
+	}
+	for _, l := range s.Links {
+		add(l.FromRepo)
+		add(l.ToRepo)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// repo returns the registered repo named name, or nil.
+func (s *Snapshot) repo(name string) *registry.Repository {
+	for i := range s.Repos {
+		if s.Repos[i].Name == name {
+			return &s.Repos[i]
+		}
+	}
+	return nil
+}
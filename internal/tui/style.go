@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func style(code, s string) string { return "\x1b[" + code + "m" + s + "\x1b[0m" }
+
+func bold(s string) string    { return style("1", s) }
+func dim(s string) string     { return style("2", s) }
+func reverse(s string) string { return style("7", s) }
+func red(s string) string     { return style("31", s) }
+func green(s string) string   { return style("32", s) }
+func yellow(s string) string  { return style("33", s) }
+
+// escapeLen returns the length of the ANSI escape sequence at the start of
+// s, or 0.
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= '@' && c <= '~' {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// width is the number of cells s takes, not counting escapes. Every rune
+// counts as one cell.
+func width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if e := escapeLen(s[i:]); e > 0 {
+			i += e
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// truncate cuts s to at most w cells, ending with an ellipsis when it cuts.
+// Escapes are kept, and styling is reset after a cut.
+func truncate(s string, w int) string {
+	if width(s) <= w {
+		return s
+	}
+	if w <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	n := 0
+	for i := 0; i < len(s); {
+		if e := escapeLen(s[i:]); e > 0 {
+			sb.WriteString(s[i : i+e])
+			i += e
+			continue
+		}
+		if n == w-1 {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		sb.WriteString(s[i : i+size])
+		i += size
+		n++
+	}
+	sb.WriteString("…\x1b[0m")
+	return sb.String()
+}
+
+// pad extends s with spaces to w cells.
+func pad(s string, w int) string {
+	if n := width(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
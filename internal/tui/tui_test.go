@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziadkadry99/auto-doc/internal/db"
+	"github.com/ziadkadry99/auto-doc/internal/notifications"
+	"github.com/ziadkadry99/auto-doc/internal/registry"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Repos: []registry.Repository{
+			{Name: "gateway", Status: "ready", FileCount: 40, LastIndexedAt: now.Add(-2 * time.Hour).Format(time.RFC3339)},
+			{Name: "orders", Status: "error", FileCount: 12, Summary: "Takes orders."},
+			{Name: "payments", Status: "indexing"},
+		},
+		Links: []registry.ServiceLink{
+			{FromRepo: "gateway", ToRepo: "orders", LinkType: "http", Endpoints: []string{"POST /orders"}},
+			{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc", Reason: "charges the card"},
+			{FromRepo: "orders", ToRepo: "ledger", LinkType: "kafka"},
+		},
+		Notifications: []notifications.Notification{
+			{Type: notifications.TypeServiceAdded, Severity: notifications.SeverityWarning, Title: "payments added",
+				Message: "A new service appeared.", AffectedServices: []string{"payments"}, CreatedAt: now.Add(-5 * time.Minute)},
+		},
+		LoadedAt: now.Add(-3 * time.Second),
+	}
+}
+
+func newTestModel() *Model {
+	m := New(testSnapshot())
+	m.now = func() time.Time { return now }
+	m.Update(Size{Width: 100, Height: 20})
+	return m
+}
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;?]*[@-~]")
+
+func screen(m *Model) string {
+	return escapes.ReplaceAllString(strings.Join(m.View(), "\n"), "")
+}
+
+func press(m *Model, keys ...Key) {
+	for _, k := range keys {
+		m.Update(k)
+	}
+}
+
+func TestReposView(t *testing.T) {
+	m := newTestModel()
+	s := screen(m)
+	for _, want := range []string{"1 Repos (3)", "2 Notifications (1)", "gateway   ready", "2h ago", "orders    error", "never", "1 ready  1 indexing  1 error", "updated 3s ago"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("screen missing %q:\n%s", want, s)
+		}
+	}
+
+	press(m, "down")
+	if s := screen(m); !strings.Contains(s, "> orders") || !strings.Contains(s, "Takes orders.") {
+		t.Errorf("after down:\n%s", s)
+	}
+	press(m, "down", "down", "down")
+	if s := screen(m); !strings.Contains(s, "> payments") {
+		t.Errorf("cursor should stop at the last repo:\n%s", s)
+	}
+}
+
+func TestNotificationsView(t *testing.T) {
+	m := newTestModel()
+	press(m, "2")
+	s := screen(m)
+	for _, want := range []string{"warning   5m ago    payments added", "A new service appeared.", "services: payments"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("screen missing %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestDependencyBrowser(t *testing.T) {
+	m := newTestModel()
+	press(m, "3")
+	if s := screen(m); !strings.Contains(s, "ledger") || !strings.Contains(s, "> gateway") {
+		t.Fatalf("service list:\n%s", s)
+	}
+
+	// gateway → orders → payments, then back to orders.
+	press(m, "enter", "enter")
+	s := screen(m)
+	for _, want := range []string{"gateway › orders", "→ calls      payments", "→ calls      ledger", "← called by  gateway", "Takes orders."} {
+		if !strings.Contains(s, want) {
+			t.Errorf("orders missing %q:\n%s", want, s)
+		}
+	}
+	if !strings.Contains(s, "link: charges the card") {
+		t.Errorf("the selected link's reason should show:\n%s", s)
+	}
+	press(m, "enter")
+	if s := screen(m); !strings.Contains(s, "gateway › orders › payments") || !strings.Contains(s, "← called by  orders") {
+		t.Errorf("payments:\n%s", s)
+	}
+	press(m, "down", "enter")
+	press(m, "esc")
+	if m.focus != "payments" {
+		t.Errorf("focus after esc = %q, want payments", m.focus)
+	}
+	press(m, "esc", "esc", "esc")
+	if m.focus != "" || m.cursor[tabServices] != 0 {
+		t.Errorf("back at the list: focus %q, cursor %d; want the list with gateway's row", m.focus, m.cursor[tabServices])
+	}
+
+	press(m, "down", "enter")
+	if s := screen(m); !strings.Contains(s, "ledger  not registered") {
+		t.Errorf("unregistered service:\n%s", s)
+	}
+}
+
+func TestUpdateCommands(t *testing.T) {
+	m := newTestModel()
+	if cmd := m.Update(Key("r")); cmd != Reload {
+		t.Errorf("r = %v, want Reload", cmd)
+	}
+	if cmd := m.Update(Key("q")); cmd != Quit {
+		t.Errorf("q = %v, want Quit", cmd)
+	}
+
+	press(m, "3", "enter")
+	m.Update(&Snapshot{LoadedAt: now})
+	if m.focus != "" {
+		t.Errorf("a reload without the focused service should return to the list")
+	}
+}
+
+func TestScrolling(t *testing.T) {
+	snap := &Snapshot{LoadedAt: now}
+	for i := 0; i < 50; i++ {
+		snap.Repos = append(snap.Repos, registry.Repository{Name: "repo" + string(rune('A'+i%26)) + string(rune('a'+i/26)), Status: "ready"})
+	}
+	m := New(snap)
+	m.now = func() time.Time { return now }
+	m.Update(Size{Width: 80, Height: 20})
+	press(m, "end")
+	lines := m.View()
+	if len(lines) != 20 {
+		t.Errorf("view has %d lines, want the terminal's 20", len(lines))
+	}
+	if s := screen(m); !strings.Contains(s, "NAME") || !strings.Contains(s, "> repoXb") {
+		t.Errorf("scrolled to the end:\n%s", s)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[B\x1b[5~\x1b\r\t\x1b[Z\x7fq\x03é\x1b[99x"))
+	want := []Key{"j", "up", "down", "pgup", "esc", "enter", "tab", "backtab", "backspace", "q", "ctrl+c", "é"}
+	if strings.Join(keyStrings(got), " ") != strings.Join(keyStrings(want), " ") {
+		t.Errorf("parseKeys = %q, want %q", got, want)
+	}
+}
+
+func keyStrings(keys []Key) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = string(k)
+	}
+	return out
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate(red("abcdef"), 4); escapes.ReplaceAllString(got, "") != "abc…" || !strings.HasSuffix(got, "\x1b[0m") {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate("abc", 4); got != "abc" {
+		t.Errorf("truncate short = %q", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	database, err := db.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ctx := context.Background()
+
+	repos := registry.NewStore(database)
+	repos.Add(ctx, &registry.Repository{Name: "orders", SourceType: "local"})
+	repos.SaveLink(ctx, &registry.ServiceLink{FromRepo: "orders", ToRepo: "payments", LinkType: "grpc"})
+	notes := notifications.NewStore(database)
+	notes.Create(ctx, notifications.Notification{Type: notifications.TypeServiceAdded, Severity: notifications.SeverityInfo, Title: "pending"})
+	notes.Create(ctx, notifications.Notification{Type: notifications.TypeServiceAdded, Severity: notifications.SeverityInfo, Title: "sent", Delivered: true})
+	notes.Create(ctx, notifications.Notification{Type: notifications.TypeServiceAdded, Severity: notifications.SeverityInfo, Title: "muted", SuppressedBy: "m1"})
+
+	snap, err := Load(ctx, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Repos) != 1 || len(snap.Links) != 1 {
+		t.Errorf("repos %d, links %d; want 1 and 1", len(snap.Repos), len(snap.Links))
+	}
+	if len(snap.Notifications) != 1 || snap.Notifications[0].Title != "pending" {
+		t.Errorf("notifications = %+v; want only the pending one", snap.Notifications)
+	}
+	if got := strings.Join(snap.services(), ","); got != "orders,payments" {
+		t.Errorf("services = %s", got)
+	}
+}
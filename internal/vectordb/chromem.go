@@ -44,12 +44,31 @@ func (s *ChromemStore) AddDocuments(ctx context.Context, docs []Document) error
 		return nil
 	}
 
+	// Embed every document in one call, rather than one call per document
+	// as chromem would, so the embedder can batch them.
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embedding documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(docs))
+	}
+	return s.ImportDocuments(ctx, docs, vectors)
+}
+
+// ImportDocuments adds documents whose embeddings are already computed.
+func (s *ChromemStore) ImportDocuments(ctx context.Context, docs []Document, vectors [][]float32) error {
 	chromDocs := make([]chromem.Document, len(docs))
 	for i, doc := range docs {
 		chromDocs[i] = chromem.Document{
-			ID:       doc.ID,
-			Content:  doc.Content,
-			Metadata: metadataToMap(doc.Metadata),
+			ID:        doc.ID,
+			Content:   doc.Content,
+			Metadata:  metadataToMap(doc.Metadata),
+			Embedding: vectors[i],
 		}
 	}
 
@@ -78,46 +97,18 @@ func (s *ChromemStore) Search(ctx context.Context, query string, limit int, filt
 		return nil, fmt.Errorf("chromem query: %w", err)
 	}
 
-	// Deduplicate: max 2 results per file path AND per content hash.
-	// Content hash dedup merges identical files in different directories
-	// (e.g., proto files copied across service directories).
-	const maxPerFile = 2
-	const maxPerHash = 2
-	fileCount := make(map[string]int)
-	hashCount := make(map[string]int)
-	var searchResults []SearchResult
-
-	for _, r := range results {
-		meta := mapToMetadata(r.Metadata)
-		fp := meta.FilePath
-		ch := meta.ContentHash
-
-		if fileCount[fp] >= maxPerFile {
-			continue
-		}
-		// Skip if we already have enough results with the same content.
-		if ch != "" && hashCount[ch] >= maxPerHash {
-			continue
-		}
-
-		fileCount[fp]++
-		if ch != "" {
-			hashCount[ch]++
-		}
-		searchResults = append(searchResults, SearchResult{
+	candidates := make([]SearchResult, len(results))
+	for i, r := range results {
+		candidates[i] = SearchResult{
 			Document: Document{
 				ID:       r.ID,
 				Content:  r.Content,
-				Metadata: meta,
+				Metadata: mapToMetadata(r.Metadata),
 			},
 			Similarity: r.Similarity,
-		})
-		if len(searchResults) >= limit {
-			break
 		}
 	}
-
-	return searchResults, nil
+	return dedupeResults(candidates, limit), nil
 }
 
 func (s *ChromemStore) GetByFilePath(ctx context.Context, filePath string) ([]Document, error) {
@@ -175,28 +166,152 @@ func (s *ChromemStore) Load(ctx context.Context, dir string) error {
 	return nil
 }
 
+// MigrateMetadata upgrades the metadata of documents persisted by older
+// versions and returns how many documents were (or, with dryRun, would be)
+// upgraded. Reads already upgrade metadata in memory; this rewrites it so a
+// following Persist saves the current layout. Stored embeddings are reused,
+// so no embedding calls are made.
+func (s *ChromemStore) MigrateMetadata(ctx context.Context, dryRun bool) (int, error) {
+	results, err := s.all(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	upgraded := 0
+	for _, r := range results {
+		md, changed := upgradeMetadata(r.Metadata)
+		if !changed {
+			continue
+		}
+		upgraded++
+		if dryRun {
+			continue
+		}
+		doc := chromem.Document{ID: r.ID, Metadata: md, Embedding: r.Embedding, Content: r.Content}
+		if err := s.collection.AddDocument(ctx, doc); err != nil {
+			return upgraded, fmt.Errorf("rewriting document %s: %w", r.ID, err)
+		}
+	}
+	return upgraded, nil
+}
+
 func (s *ChromemStore) Count() int {
 	return s.collection.Count()
 }
 
+// Export returns every document with its embedding, so the index can be
+// moved to another backend without embedding it again.
+func (s *ChromemStore) Export(ctx context.Context) ([]Document, [][]float32, error) {
+	results, err := s.all(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs := make([]Document, len(results))
+	vectors := make([][]float32, len(results))
+	for i, r := range results {
+		docs[i] = Document{ID: r.ID, Content: r.Content, Metadata: mapToMetadata(r.Metadata)}
+		vectors[i] = r.Embedding
+	}
+	return docs, vectors, nil
+}
+
+// Documents returns every document in the store.
+func (s *ChromemStore) Documents(ctx context.Context) ([]Document, error) {
+	docs, _, err := s.Export(ctx)
+	return docs, err
+}
+
+// all lists every document in the collection, with its embedding.
+func (s *ChromemStore) all(ctx context.Context) ([]chromem.Result, error) {
+	count := s.collection.Count()
+	if count == 0 {
+		return nil, nil
+	}
+	dims := s.embedder.Dimensions()
+	if dims <= 0 {
+		return nil, fmt.Errorf("embedder %s reports no dimensions", s.embedder.Name())
+	}
+
+	// Any unit vector lists every document; similarity order is irrelevant.
+	probe := make([]float32, dims)
+	probe[0] = 1
+	results, err := s.collection.QueryEmbedding(ctx, probe, count, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing documents: %w", err)
+	}
+	return results, nil
+}
+
+// metadataSchemaVersion is the layout of document metadata. Documents
+// persisted before versioning carry no schema_version and are version 0.
+const metadataSchemaVersion = 2
+
+// metadataMigrations upgrade document metadata in place, indexed by the
+// version they upgrade from.
+var metadataMigrations = []func(m map[string]string){
+	// 0 -> 1: fill keys that older builds did not write.
+	func(m map[string]string) {
+		for _, k := range []string{"symbol", "repo_id"} {
+			if _, ok := m[k]; !ok {
+				m[k] = ""
+			}
+		}
+		for _, k := range []string{"line_start", "line_end"} {
+			if _, ok := m[k]; !ok {
+				m[k] = "0"
+			}
+		}
+	},
+	// 1 -> 2: give function and type documents their symbol IDs.
+	func(m map[string]string) {
+		switch DocumentType(m["type"]) {
+		case DocTypeFunction, DocTypeClass:
+			if m["symbol"] != "" {
+				m["symbol_id"] = SymbolID(m["repo_id"], m["file_path"], m["symbol"])
+			}
+		}
+	},
+}
+
+// upgradeMetadata returns m upgraded to metadataSchemaVersion. The input map
+// is not modified; changed reports whether any migration ran.
+func upgradeMetadata(m map[string]string) (map[string]string, bool) {
+	version, _ := strconv.Atoi(m["schema_version"])
+	if version >= metadataSchemaVersion {
+		return m, false
+	}
+	md := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		md[k] = v
+	}
+	for v := version; v < metadataSchemaVersion; v++ {
+		metadataMigrations[v](md)
+	}
+	md["schema_version"] = strconv.Itoa(metadataSchemaVersion)
+	return md, true
+}
+
 // metadataToMap converts DocumentMetadata to a flat map[string]string for chromem.
 func metadataToMap(m DocumentMetadata) map[string]string {
 	md := map[string]string{
-		"file_path":    m.FilePath,
-		"line_start":   strconv.Itoa(m.LineStart),
-		"line_end":     strconv.Itoa(m.LineEnd),
-		"content_hash": m.ContentHash,
-		"type":         string(m.Type),
-		"language":     m.Language,
-		"symbol":       m.Symbol,
-		"repo_id":      m.RepoID,
-		"last_updated": m.LastUpdated.Format(time.RFC3339),
+		"file_path":      m.FilePath,
+		"line_start":     strconv.Itoa(m.LineStart),
+		"line_end":       strconv.Itoa(m.LineEnd),
+		"content_hash":   m.ContentHash,
+		"type":           string(m.Type),
+		"language":       m.Language,
+		"symbol":         m.Symbol,
+		"symbol_id":      m.SymbolID,
+		"repo_id":        m.RepoID,
+		"last_updated":   m.LastUpdated.Format(time.RFC3339),
+		"schema_version": strconv.Itoa(metadataSchemaVersion),
 	}
 	return md
 }
 
 // mapToMetadata converts a flat map[string]string back to DocumentMetadata.
 func mapToMetadata(m map[string]string) DocumentMetadata {
+	m, _ = upgradeMetadata(m)
 	lineStart, _ := strconv.Atoi(m["line_start"])
 	lineEnd, _ := strconv.Atoi(m["line_end"])
 	lastUpdated, _ := time.Parse(time.RFC3339, m["last_updated"])
@@ -209,6 +324,7 @@ func mapToMetadata(m map[string]string) DocumentMetadata {
 		Type:        DocumentType(m["type"]),
 		Language:    m["language"],
 		Symbol:      m["symbol"],
+		SymbolID:    m["symbol_id"],
 		RepoID:      m["repo_id"],
 		LastUpdated: lastUpdated,
 	}
@@ -233,6 +349,9 @@ func buildWhereClause(filter *SearchFilter) map[string]string {
 	if filter.RepoID != nil {
 		where["repo_id"] = *filter.RepoID
 	}
+	if filter.Symbol != nil {
+		where["symbol"] = *filter.Symbol
+	}
 
 	if len(where) == 0 {
 		return nil
@@ -2,10 +2,28 @@ package vectordb
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	chromem "github.com/philippgille/chromem-go"
+
+	"github.com/ziadkadry99/auto-doc/internal/embeddings"
+	"github.com/ziadkadry99/auto-doc/internal/llm"
 )
 
 // mockEmbedder returns deterministic embeddings based on text content.
@@ -340,6 +358,54 @@ func TestChromemStore_PersistAndLoad(t *testing.T) {
 	}
 }
 
+func TestChromemStore_MigrateMetadata(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewChromemStore(newMockEmbedder(64))
+	if err != nil {
+		t.Fatalf("NewChromemStore: %v", err)
+	}
+	if err := store.AddDocuments(ctx, []Document{{ID: "file:new.go", Content: "new", Metadata: DocumentMetadata{FilePath: "new.go", Type: DocTypeFile}}}); err != nil {
+		t.Fatal(err)
+	}
+	// A document persisted before metadata was versioned.
+	legacy := chromem.Document{ID: "file:old.go", Content: "old", Metadata: map[string]string{"file_path": "old.go", "type": "file"}}
+	if err := store.collection.AddDocument(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := store.GetByFilePath(ctx, "old.go")
+	if err != nil || len(docs) != 1 || docs[0].Metadata.Type != DocTypeFile {
+		t.Fatalf("legacy document should be readable: %v %+v", err, docs)
+	}
+
+	n, err := store.MigrateMetadata(ctx, true)
+	if err != nil || n != 1 {
+		t.Fatalf("dry run: got %d, %v; want 1 pending", n, err)
+	}
+	if n, err = store.MigrateMetadata(ctx, false); err != nil || n != 1 {
+		t.Fatalf("migrate: got %d, %v; want 1 upgraded", n, err)
+	}
+	if n, _ = store.MigrateMetadata(ctx, true); n != 0 {
+		t.Errorf("expected nothing pending after migrating, got %d", n)
+	}
+	doc, err := store.collection.GetByID(ctx, "file:old.go")
+	if err != nil || doc.Metadata["schema_version"] != "2" || doc.Metadata["repo_id"] != "" || doc.Content != "old" {
+		t.Errorf("unexpected migrated document: %v %+v", err, doc)
+	}
+}
+
+func TestUpgradeMetadata_SymbolID(t *testing.T) {
+	v1 := map[string]string{"schema_version": "1", "file_path": "auth/verify.go", "type": "function", "symbol": "Verifier.Verify", "repo_id": "auth"}
+	md, changed := upgradeMetadata(v1)
+	if !changed || md["symbol_id"] != "auth:auth/verify.go#Verifier.Verify" {
+		t.Errorf("upgraded function metadata = %v", md)
+	}
+	file := map[string]string{"schema_version": "1", "file_path": "auth/verify.go", "type": "file", "symbol": "dependencies"}
+	if md, _ := upgradeMetadata(file); md["symbol_id"] != "" {
+		t.Errorf("file documents have no symbol ID, got %q", md["symbol_id"])
+	}
+}
+
 func TestFormatResults(t *testing.T) {
 	results := []SearchResult{
 		{
@@ -390,3 +456,601 @@ func searchString(s, sub string) bool {
 	}
 	return false
 }
+
+// countingEmbedder records the size of every request, and rejects requests
+// larger than limit.
+type countingEmbedder struct {
+	mockEmbedder
+	limit    int
+	requests []int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.requests = append(c.requests, len(texts))
+	if len(texts) > c.limit {
+		return nil, fmt.Errorf("batch too large")
+	}
+	return c.mockEmbedder.Embed(ctx, texts)
+}
+
+func TestChromemStore_EmbeddingCache(t *testing.T) {
+	ctx := context.Background()
+	docs := make([]Document, 50)
+	for i := range docs {
+		docs[i] = Document{ID: fmt.Sprintf("doc-%d", i), Content: fmt.Sprintf("chunk %d of the orders service", i),
+			Metadata: DocumentMetadata{FilePath: "orders.go", Type: DocTypeFile}}
+	}
+
+	inner := &countingEmbedder{mockEmbedder: mockEmbedder{dims: 64}, limit: 20}
+	cache := embeddings.NewCachedEmbedder(inner)
+	store, err := NewChromemStore(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if store.Count() != 50 || cache.Misses != 50 {
+		t.Fatalf("count = %d, misses = %d", store.Count(), cache.Misses)
+	}
+	// 32 texts is too many; the batch halves to 16 and then grows again
+	// until it fails once more.
+	if want := []int{32, 16, 32, 16, 18}; fmt.Sprint(inner.requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want %v", inner.requests, want)
+	}
+
+	// A rebuilt store embeds only the changed chunk, given the saved cache.
+	path := filepath.Join(t.TempDir(), embeddings.CacheFile)
+	if err := cache.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	inner.requests = nil
+	cache = embeddings.NewCachedEmbedder(inner)
+	if err := cache.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewChromemStore(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs[7].Content = "chunk 7 of the orders service, rewritten"
+	if err := store.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Hits != 49 || cache.Misses != 1 || fmt.Sprint(inner.requests) != "[1]" {
+		t.Errorf("hits = %d, misses = %d, requests = %v", cache.Hits, cache.Misses, inner.requests)
+	}
+	results, err := store.Search(ctx, "chunk 7 of the orders service, rewritten", 1, nil)
+	if err != nil || len(results) != 1 || results[0].Document.ID != "doc-7" {
+		t.Errorf("search = %+v, %v", results, err)
+	}
+}
+
+// fakeQdrant is an in-memory stand-in for the parts of Qdrant's REST API
+// the store uses.
+type fakeQdrant struct {
+	mu     sync.Mutex
+	exists bool
+	points map[string]qdrantPoint
+}
+
+func (f *fakeQdrant) matches(p qdrantPoint, filter map[string]any) bool {
+	must, _ := filter["must"].([]any)
+	for _, c := range must {
+		cond := c.(map[string]any)
+		value := cond["match"].(map[string]any)["value"]
+		if p.Payload[cond["key"].(string)] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	filter, _ := body["filter"].(map[string]any)
+	reply := func(result any) { json.NewEncoder(w).Encode(map[string]any{"result": result}) }
+
+	switch path := strings.TrimPrefix(r.URL.Path, "/collections/docs"); {
+	case path == "" && r.Method == http.MethodGet:
+		if !f.exists {
+			http.Error(w, `{"status":{"error":"Not found"}}`, http.StatusNotFound)
+			return
+		}
+		reply(map[string]any{})
+	case path == "" && r.Method == http.MethodPut:
+		f.exists = true
+		reply(true)
+	case !f.exists:
+		http.Error(w, `{"status":{"error":"Not found"}}`, http.StatusNotFound)
+	case path == "/index":
+		reply(map[string]any{})
+	case path == "/points" && r.Method == http.MethodPut:
+		var req struct{ Points []qdrantPoint }
+		data, _ := json.Marshal(body)
+		json.Unmarshal(data, &req)
+		for _, p := range req.Points {
+			f.points[p.ID] = p
+		}
+		reply(map[string]any{})
+	case path == "/points/search":
+		var query []float32
+		data, _ := json.Marshal(body["vector"])
+		json.Unmarshal(data, &query)
+		var out []qdrantPoint
+		for _, p := range f.points {
+			if !f.matches(p, filter) {
+				continue
+			}
+			var score float32
+			for i := range query {
+				score += query[i] * p.Vector[i]
+			}
+			out = append(out, qdrantPoint{ID: p.ID, Payload: p.Payload, Score: score})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+		reply(out)
+	case path == "/points/scroll":
+		var out []qdrantPoint
+		for _, p := range f.points {
+			if f.matches(p, filter) {
+				out = append(out, qdrantPoint{ID: p.ID, Payload: p.Payload})
+			}
+		}
+		reply(map[string]any{"points": out, "next_page_offset": nil})
+	case path == "/points/delete":
+		for id, p := range f.points {
+			if f.matches(p, filter) {
+				delete(f.points, id)
+			}
+		}
+		reply(map[string]any{})
+	case path == "/points/count":
+		reply(map[string]any{"count": len(f.points)})
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func TestQdrantStore_MigrateFromChromem(t *testing.T) {
+	ctx := context.Background()
+	embedder := newMockEmbedder(64)
+	fake := &fakeQdrant{points: make(map[string]qdrantPoint)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	local, err := NewChromemStore(embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []Document{
+		{ID: "auth", Content: "The authentication module handles user login", Metadata: DocumentMetadata{FilePath: "auth/login.go", Type: DocTypeFile, Language: "go"}},
+		{ID: "db", Content: "Database connection pool configuration", Metadata: DocumentMetadata{FilePath: "db/pool.go", Type: DocTypeFile, Language: "go"}},
+		{ID: "router", Content: "HTTP router setup and middleware chain", Metadata: DocumentMetadata{FilePath: "api/router.go", Type: DocTypeModule, Language: "go", RepoID: "api"}},
+	}
+	if err := local.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	exported, vectors, err := local.Export(ctx)
+	if err != nil || len(exported) != 3 {
+		t.Fatalf("Export = %d documents, %v", len(exported), err)
+	}
+
+	store := NewQdrantStore(srv.URL, "docs", "", embedder)
+	if n, err := store.Search(ctx, "login", 3, nil); err != nil || n != nil {
+		t.Fatalf("search before the collection exists = %v, %v", n, err)
+	}
+	var _ Importer = store
+	if err := store.ImportDocuments(ctx, exported, vectors); err != nil {
+		t.Fatalf("ImportDocuments: %v", err)
+	}
+	if n := store.Count(); n != 3 {
+		t.Errorf("Count = %d, want 3", n)
+	}
+
+	results, err := store.Search(ctx, "The authentication module handles user login", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "auth" || results[0].Document.Metadata.FilePath != "auth/login.go" {
+		t.Errorf("search results = %+v", results)
+	}
+	moduleType := DocTypeModule
+	results, err = store.Search(ctx, "login", 3, &SearchFilter{Type: &moduleType})
+	if err != nil || len(results) != 1 || results[0].Document.ID != "router" {
+		t.Errorf("filtered search = %+v, %v", results, err)
+	}
+
+	got, err := store.GetByFilePath(ctx, "db/pool.go")
+	if err != nil || len(got) != 1 || got[0].Content != docs[1].Content {
+		t.Errorf("GetByFilePath = %+v, %v", got, err)
+	}
+	if err := store.DeleteByRepoID(ctx, "api"); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.Count(); n != 2 {
+		t.Errorf("Count after DeleteByRepoID = %d, want 2", n)
+	}
+	if all, err := store.Documents(ctx); err != nil || len(all) != 2 {
+		t.Errorf("Documents = %d, %v", len(all), err)
+	}
+}
+
+// fakePostgres authenticates connections with a cleartext password and
+// answers each statement through respond, recording what it was sent. A
+// result's columns are text, except float8 and int8 ones named by cols.
+type fakePostgres struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	queries []string
+	args    [][]string
+	respond func(sql string) (cols []uint32, rows [][]string, errMsg string)
+}
+
+// fakeParam numbers the parameters of a statement.
+var fakeParam = regexp.MustCompile(`\$(\d+)`)
+
+func (f *fakePostgres) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.serveConn(conn)
+	}
+}
+
+func (f *fakePostgres) serveConn(conn net.Conn) {
+	defer conn.Close()
+	be := pgproto3.NewBackend(conn, conn)
+	if _, err := be.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	be.Send(&pgproto3.AuthenticationCleartextPassword{})
+	be.Flush()
+	be.SetAuthType(pgproto3.AuthTypeCleartextPassword)
+	if msg, err := be.Receive(); err != nil || msg.(*pgproto3.PasswordMessage).Password != "secret" {
+		be.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28P01", Message: "password authentication failed"})
+		be.Flush()
+		return
+	}
+	be.Send(&pgproto3.AuthenticationOk{})
+	be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	be.Flush()
+
+	record := func(sql string, args []string) {
+		f.mu.Lock()
+		f.queries = append(f.queries, sql)
+		f.args = append(f.args, args)
+		f.mu.Unlock()
+	}
+	describe := func(cols []uint32, n int) *pgproto3.RowDescription {
+		desc := &pgproto3.RowDescription{}
+		for i := range n {
+			oid := uint32(25)
+			if i < len(cols) && cols[i] != 0 {
+				oid = cols[i]
+			}
+			desc.Fields = append(desc.Fields, pgproto3.FieldDescription{Name: []byte(fmt.Sprint("c", i)), DataTypeOID: oid})
+		}
+		return desc
+	}
+	// value encodes a column in the format pgx asked for.
+	value := func(v string, oid uint32, binaryFormat bool) []byte {
+		switch {
+		case !binaryFormat:
+			return []byte(v)
+		case oid == 701:
+			x, _ := strconv.ParseFloat(v, 64)
+			return binary.BigEndian.AppendUint64(nil, math.Float64bits(x))
+		case oid == 20:
+			x, _ := strconv.ParseInt(v, 10, 64)
+			return binary.BigEndian.AppendUint64(nil, uint64(x))
+		}
+		return []byte(v)
+	}
+
+	var sql string
+	var bind *pgproto3.Bind
+	failed := false
+	// binaryFormat reports whether the bound portal's column i is binary.
+	binaryFormat := func(i int) bool {
+		codes := bind.ResultFormatCodes
+		return len(codes) == 1 && codes[0] == 1 || len(codes) > i && codes[i] == 1
+	}
+	for {
+		msg, err := be.Receive()
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			record(msg.String, nil)
+			if _, _, errMsg := f.respond(msg.String); errMsg != "" {
+				be.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "42601", Message: errMsg})
+			} else if strings.TrimSpace(msg.String) == ";" {
+				be.Send(&pgproto3.EmptyQueryResponse{})
+			} else {
+				be.Send(&pgproto3.CommandComplete{CommandTag: []byte("OK")})
+			}
+			be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			be.Flush()
+		case *pgproto3.Parse:
+			sql = msg.Query
+			if _, _, errMsg := f.respond(sql); errMsg != "" {
+				be.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "42P01", Message: errMsg})
+				failed = true
+				continue
+			}
+			be.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			if failed {
+				continue
+			}
+			if msg.ObjectType == 'S' {
+				params := &pgproto3.ParameterDescription{}
+				for _, m := range fakeParam.FindAllStringSubmatch(sql, -1) {
+					n, _ := strconv.Atoi(m[1])
+					for len(params.ParameterOIDs) < n {
+						params.ParameterOIDs = append(params.ParameterOIDs, 25)
+					}
+					if strings.Contains(sql, "LIMIT "+m[0]) {
+						params.ParameterOIDs[n-1] = 20
+					}
+				}
+				be.Send(params)
+			}
+			if cols, rows, _ := f.respond(sql); len(rows) > 0 {
+				desc := describe(cols, len(rows[0]))
+				for i := range desc.Fields {
+					if msg.ObjectType == 'P' && binaryFormat(i) {
+						desc.Fields[i].Format = 1
+					}
+				}
+				be.Send(desc)
+			} else {
+				be.Send(&pgproto3.NoData{})
+			}
+		case *pgproto3.Bind:
+			if failed {
+				continue
+			}
+			bind = msg
+			be.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			if failed {
+				continue
+			}
+			var args []string
+			for _, p := range bind.Parameters {
+				args = append(args, string(p))
+			}
+			record(sql, args)
+			cols, rows, _ := f.respond(sql)
+			for _, row := range rows {
+				desc := describe(cols, len(row))
+				data := &pgproto3.DataRow{}
+				for i, v := range row {
+					data.Values = append(data.Values, value(v, desc.Fields[i].DataTypeOID, binaryFormat(i)))
+				}
+				be.Send(data)
+			}
+			be.Send(&pgproto3.CommandComplete{CommandTag: []byte("OK")})
+		case *pgproto3.Sync:
+			failed = false
+			be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			be.Flush()
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+func TestPgvectorStore(t *testing.T) {
+	ctx := context.Background()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	fake := &fakePostgres{ln: ln, respond: func(sql string) ([]uint32, [][]string, string) {
+		switch {
+		case strings.HasPrefix(sql, "SELECT id, content, metadata::text, 1 -"):
+			md := `{"file_path":"auth/login.go","type":"file","language":"go","line_start":"3"}`
+			return []uint32{25, 25, 25, 701}, [][]string{{"auth", "login handling", md, "0.875"}}, ""
+		case strings.HasPrefix(sql, "SELECT count(*)"):
+			return []uint32{20}, [][]string{{"7"}}, ""
+		case strings.Contains(sql, "nonexistent"):
+			return nil, nil, `relation "nonexistent" does not exist`
+		}
+		return nil, nil, ""
+	}}
+	go fake.serve()
+
+	url := "postgres://autodoc:secret@" + ln.Addr().String() + "/autodoc?sslmode=disable"
+	if _, err := NewPgvectorStore(ctx, url, "docs; DROP TABLE users", newMockEmbedder(8)); err == nil {
+		t.Error("expected a table name that isn't an identifier to be refused")
+	}
+	store, err := NewPgvectorStore(ctx, url, "", newMockEmbedder(8))
+	if err != nil {
+		t.Fatalf("NewPgvectorStore: %v", err)
+	}
+	defer store.Close()
+
+	doc := Document{ID: "auth", Content: "login\x00 handling", Metadata: DocumentMetadata{FilePath: "auth/login.go", Type: DocTypeFile}}
+	if err := store.AddDocuments(ctx, []Document{doc}); err != nil {
+		t.Fatalf("AddDocuments: %v", err)
+	}
+	docType := DocTypeFile
+	results, err := store.Search(ctx, "login", 5, &SearchFilter{Type: &docType})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.Metadata.FilePath != "auth/login.go" || results[0].Document.Metadata.LineStart != 3 || results[0].Similarity != 0.875 {
+		t.Errorf("results = %+v", results)
+	}
+	if n := store.Count(); n != 7 {
+		t.Errorf("Count = %d, want 7", n)
+	}
+	if _, err := store.pool.Exec(ctx, "SELECT * FROM nonexistent"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if !strings.Contains(fake.queries[1], `CREATE TABLE IF NOT EXISTS "autodoc_documents"`) || !strings.Contains(fake.queries[1], "vector(8)") {
+		t.Errorf("create table = %q", fake.queries[1])
+	}
+	if !strings.Contains(fake.queries[2], `"autodoc_documents_file_path_idx" ON "autodoc_documents"`) {
+		t.Errorf("create index = %q", fake.queries[2])
+	}
+	var insert []pgvectorRow
+	for i, q := range fake.queries {
+		if strings.HasPrefix(q, `INSERT INTO "autodoc_documents"`) {
+			json.Unmarshal([]byte(fake.args[i][0]), &insert)
+		}
+		if strings.HasPrefix(q, "SELECT id, content, metadata::text, 1 -") {
+			if !strings.Contains(q, "WHERE type = $2") || fake.args[i][1] != "file" || binary.BigEndian.Uint64([]byte(fake.args[i][2])) != 15 {
+				t.Errorf("search = %q with %q", q, fake.args[i])
+			}
+		}
+	}
+	if len(insert) != 1 || insert[0].Content != "login handling" || insert[0].Metadata["file_path"] != "auth/login.go" || !strings.HasPrefix(insert[0].Embedding, "[") {
+		t.Errorf("inserted rows = %+v", insert)
+	}
+}
+
+func TestPostgresTLS(t *testing.T) {
+	// The server's certificate is verified unless the URL asks for less.
+	cfg, err := pgvectorConfig("postgres://autodoc@db/autodoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc := cfg.ConnConfig.TLSConfig; tc == nil || tc.InsecureSkipVerify || tc.ServerName != "db" || len(cfg.ConnConfig.Fallbacks) != 0 {
+		t.Errorf("default TLS config = %+v, fallbacks %d", tc, len(cfg.ConnConfig.Fallbacks))
+	}
+	cfg, err = pgvectorConfig("postgres://autodoc@db/autodoc?sslmode=disable")
+	if err != nil || cfg.ConnConfig.TLSConfig != nil {
+		t.Errorf("sslmode=disable: %v", err)
+	}
+	if _, err := pgvectorConfig("mysql://db/autodoc"); err == nil {
+		t.Error("expected a URL that isn't postgres:// to be refused")
+	}
+}
+
+func TestLexicalTerms(t *testing.T) {
+	got := strings.Join(lexicalTerms("parseHTTPRequest(ERR_QUOTA_EXCEEDED, v2)"), " ")
+	want := "parsehttprequest parse http request err_quota_exceeded err quota exceeded v2 v 2"
+	if got != want {
+		t.Errorf("lexicalTerms = %q, want %q", got, want)
+	}
+}
+
+func TestHybridStore_FindsExactIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewChromemStore(newMockEmbedder(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewHybridStore(local, nil)
+	docs := []Document{
+		{ID: "a", Content: "Billing checks each account against its plan limits", Metadata: DocumentMetadata{FilePath: "billing/limits.go", Type: DocTypeFile}},
+		{ID: "b", Content: "func rejectRequest returns ErrQuotaExceeded when the bucket is empty", Metadata: DocumentMetadata{FilePath: "ratelimit/bucket.go", Type: DocTypeFunction, Symbol: "rejectRequest"}},
+		{ID: "c", Content: "Quotas and limits are documented for every plan tier", Metadata: DocumentMetadata{FilePath: "docs/plans.md", Type: DocTypeFile}},
+		{ID: "d", Content: "The scheduler runs cleanup jobs nightly", Metadata: DocumentMetadata{FilePath: "jobs/cleanup.go", Type: DocTypeFile}},
+	}
+	if err := store.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := store.Search(ctx, "where is ErrQuotaExceeded returned", 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Document.ID != "b" {
+		t.Fatalf("expected the document defining the identifier first, got %+v", results)
+	}
+	if results[0].Similarity <= 0 || results[0].Similarity > 1 {
+		t.Errorf("fused similarity %v outside (0, 1]", results[0].Similarity)
+	}
+
+	fnType := DocTypeFunction
+	results, err = store.Search(ctx, "plan limits", 3, &SearchFilter{Type: &fnType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Document.Metadata.Type != DocTypeFunction {
+			t.Errorf("filtered search returned %s", r.Document.ID)
+		}
+	}
+
+	// Changes made through the store reach the built index.
+	if err := store.DeleteByFilePath(ctx, "ratelimit/bucket.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddDocuments(ctx, []Document{{ID: "e", Content: "Scheduler cron expressions", Metadata: DocumentMetadata{FilePath: "jobs/cron.go", Symbol: "parseCronSpec"}}}); err != nil {
+		t.Fatal(err)
+	}
+	results, err = store.Search(ctx, "parseCronSpec", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "e" {
+		t.Errorf("expected the added document, got %+v", results)
+	}
+	if hits := store.index.search("ErrQuotaExceeded", 5, nil); len(hits) != 0 {
+		t.Errorf("deleted document still indexed: %+v", hits)
+	}
+}
+
+// stubProvider answers every completion with a fixed response.
+type stubProvider struct {
+	content string
+	prompt  string
+}
+
+func (p *stubProvider) Complete(_ context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	p.prompt = req.Messages[len(req.Messages)-1].Content
+	return &llm.CompletionResponse{Content: p.content}, nil
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func TestHybridStore_Rerank(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewChromemStore(newMockEmbedder(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &stubProvider{content: "```json\n{\"scores\": [{\"id\": 1, \"score\": 2}, {\"id\": 2, \"score\": 9}]}\n```"}
+	store := NewHybridStore(local, NewLLMReranker(provider, "small-model"))
+	docs := []Document{
+		{ID: "login", Content: "login handler validates passwords", Metadata: DocumentMetadata{FilePath: "auth/login.go"}},
+		{ID: "session", Content: "session tokens expire after an hour", Metadata: DocumentMetadata{FilePath: "auth/session.go"}},
+	}
+	if err := store.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := store.Search(ctx, "login handler", 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(provider.prompt, "Query: login handler") || !strings.Contains(provider.prompt, "[2] auth/") {
+		t.Errorf("rerank prompt = %q", provider.prompt)
+	}
+	if len(results) != 2 || results[0].Similarity != 0.9 || results[1].Similarity != 0.2 {
+		t.Errorf("expected results in the reranker's order, got %+v", results)
+	}
+
+	// A reranker that fails leaves the fused order.
+	provider.content = "not json"
+	results, err = store.Search(ctx, "login handler", 2, nil)
+	if err != nil || len(results) != 2 || results[0].Document.ID != "login" {
+		t.Errorf("results after a failed rerank = %+v, %v", results, err)
+	}
+}
@@ -1,6 +1,9 @@
 package vectordb
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // DocumentType categorizes the kind of document stored in the vector DB.
 type DocumentType string
@@ -11,6 +14,7 @@ const (
 	DocTypeClass        DocumentType = "class"
 	DocTypeModule       DocumentType = "module"
 	DocTypeArchitecture DocumentType = "architecture"
+	DocTypeDecision     DocumentType = "decision"
 )
 
 // Document represents a piece of content to be stored and searched.
@@ -29,6 +33,7 @@ type DocumentMetadata struct {
 	Type        DocumentType
 	Language    string
 	Symbol      string
+	SymbolID    string // stable ID of a function or type document; see SymbolID
 	RepoID      string // For future Phase 4 central server support.
 	LastUpdated time.Time
 }
@@ -45,4 +50,29 @@ type SearchFilter struct {
 	FilePath *string
 	Language *string
 	RepoID   *string
+	Symbol   *string
+}
+
+// SymbolID returns the stable ID of a function, method, or type: its file
+// path and name, qualified by the repository when there is one, e.g.
+// "payments:internal/charge.go#Charger.Capture". It changes only when the
+// symbol is renamed or moved.
+func SymbolID(repoID, filePath, name string) string {
+	id := filePath + "#" + name
+	if repoID != "" {
+		id = repoID + ":" + id
+	}
+	return id
+}
+
+// ParseSymbolID splits an ID made by SymbolID. ok is false if id isn't one.
+func ParseSymbolID(id string) (repoID, filePath, name string, ok bool) {
+	loc, name, ok := strings.Cut(id, "#")
+	if !ok || name == "" || loc == "" {
+		return "", "", "", false
+	}
+	if repo, path, found := strings.Cut(loc, ":"); found {
+		return repo, path, name, true
+	}
+	return "", loc, name, true
 }
@@ -0,0 +1,214 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DocumentLister is implemented by stores that can list every document
+// they hold, which the hybrid store needs to build its lexical index.
+type DocumentLister interface {
+	Documents(ctx context.Context) ([]Document, error)
+}
+
+// Reranker reorders search candidates by how well they answer the query,
+// e.g. with a cross-encoder or an LLM. It returns the candidates it kept,
+// best first.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []SearchResult) ([]SearchResult, error)
+}
+
+// hybridOverfetch is how many candidates each retriever contributes per
+// result asked for.
+const hybridOverfetch = 3
+
+// rrfK damps the weight reciprocal rank fusion gives the top ranks; 60 is
+// the value from the original paper and works well without tuning.
+const rrfK = 60
+
+// HybridStore wraps a vector store so searches combine its semantic
+// results with a BM25 index over content, symbols, and file names, fused
+// by reciprocal rank, then optionally reranked. Every other method goes
+// to the wrapped store.
+//
+// The lexical index is built from the wrapped store's documents on the
+// first search and kept up to date as documents are added and deleted
+// through the hybrid store. Stores that can't list their documents get
+// plain semantic search.
+type HybridStore struct {
+	VectorStore
+
+	// Reranker, if set, reorders the fused candidates.
+	Reranker Reranker
+	// RefreshEvery rebuilds the lexical index when it is older than this,
+	// to pick up documents other processes wrote to a shared store. Zero
+	// never rebuilds it.
+	RefreshEvery time.Duration
+
+	mu    sync.Mutex
+	index *lexicalIndex
+	built time.Time
+}
+
+// NewHybridStore wraps store for hybrid search.
+func NewHybridStore(store VectorStore, reranker Reranker) *HybridStore {
+	return &HybridStore{VectorStore: store, Reranker: reranker}
+}
+
+func (h *HybridStore) AddDocuments(ctx context.Context, docs []Document) error {
+	if err := h.VectorStore.AddDocuments(ctx, docs); err != nil {
+		return err
+	}
+	h.update(func(x *lexicalIndex) {
+		for _, doc := range docs {
+			x.add(doc)
+		}
+	})
+	return nil
+}
+
+// ImportDocuments passes documents with precomputed embeddings to the
+// wrapped store, if it is an Importer.
+func (h *HybridStore) ImportDocuments(ctx context.Context, docs []Document, vectors [][]float32) error {
+	importer, ok := h.VectorStore.(Importer)
+	if !ok {
+		return fmt.Errorf("the vector store can't import documents")
+	}
+	if err := importer.ImportDocuments(ctx, docs, vectors); err != nil {
+		return err
+	}
+	h.update(func(x *lexicalIndex) {
+		for _, doc := range docs {
+			x.add(doc)
+		}
+	})
+	return nil
+}
+
+func (h *HybridStore) DeleteByFilePath(ctx context.Context, filePath string) error {
+	if err := h.VectorStore.DeleteByFilePath(ctx, filePath); err != nil {
+		return err
+	}
+	h.update(func(x *lexicalIndex) {
+		x.removeWhere(func(d Document) bool { return d.Metadata.FilePath == filePath })
+	})
+	return nil
+}
+
+func (h *HybridStore) DeleteByRepoID(ctx context.Context, repoID string) error {
+	if err := h.VectorStore.DeleteByRepoID(ctx, repoID); err != nil {
+		return err
+	}
+	h.update(func(x *lexicalIndex) {
+		x.removeWhere(func(d Document) bool { return d.Metadata.RepoID == repoID })
+	})
+	return nil
+}
+
+// Load loads the wrapped store and drops the lexical index, which is
+// rebuilt from the loaded documents on the next search.
+func (h *HybridStore) Load(ctx context.Context, dir string) error {
+	h.mu.Lock()
+	h.index = nil
+	h.mu.Unlock()
+	return h.VectorStore.Load(ctx, dir)
+}
+
+// update applies fn to the lexical index if it has been built. An index
+// that hasn't been built yet will include the change when it is.
+func (h *HybridStore) update(fn func(*lexicalIndex)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.index != nil {
+		fn(h.index)
+	}
+}
+
+// lexical returns the lexical index, building it if need be. It returns
+// nil if the wrapped store can't list its documents.
+func (h *HybridStore) lexical(ctx context.Context) (*lexicalIndex, error) {
+	lister, ok := h.VectorStore.(DocumentLister)
+	if !ok {
+		return nil, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.index != nil && (h.RefreshEvery == 0 || time.Since(h.built) < h.RefreshEvery) {
+		return h.index, nil
+	}
+	docs, err := lister.Documents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("building the lexical index: %w", err)
+	}
+	index := newLexicalIndex()
+	for _, doc := range docs {
+		index.add(doc)
+	}
+	h.index, h.built = index, time.Now()
+	return index, nil
+}
+
+// Search returns the best limit results from fusing semantic and lexical
+// retrieval, reranked when a reranker is set. Similarity is the fused
+// score, scaled so a document ranked first by both retrievers scores 1;
+// stores that can't list their documents keep their semantic scores.
+func (h *HybridStore) Search(ctx context.Context, query string, limit int, filter *SearchFilter) ([]SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	semantic, err := h.VectorStore.Search(ctx, query, limit*hybridOverfetch, filter)
+	if err != nil {
+		return nil, err
+	}
+	index, err := h.lexical(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := semantic
+	if index != nil {
+		h.mu.Lock()
+		lexical := index.search(query, limit*hybridOverfetch, filter)
+		h.mu.Unlock()
+		results = fuseRanks(semantic, lexical)
+	}
+	if h.Reranker != nil && len(results) > 1 {
+		candidates := results[:min(len(results), limit*2)]
+		if reranked, err := h.Reranker.Rerank(ctx, query, candidates); err == nil && len(reranked) > 0 {
+			results = reranked
+		}
+		// A failed rerank keeps the fused order: it only refines it.
+	}
+	return dedupeResults(results, limit), nil
+}
+
+// fuseRanks merges ranked result lists by reciprocal rank fusion: each
+// document scores the sum of 1/(rrfK+rank) over the lists it appears in.
+// Rank fusion needs no calibration between BM25 and cosine scores, which
+// aren't on comparable scales.
+func fuseRanks(lists ...[]SearchResult) []SearchResult {
+	best := float64(len(lists)) / float64(rrfK+1)
+	scores := make(map[string]float64)
+	docs := make(map[string]Document)
+	var order []string
+	for _, list := range lists {
+		for rank, r := range list {
+			id := r.Document.ID
+			if _, ok := docs[id]; !ok {
+				docs[id] = r.Document
+				order = append(order, id)
+			}
+			scores[id] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	results := make([]SearchResult, len(order))
+	for i, id := range order {
+		results[i] = SearchResult{Document: docs[id], Similarity: float32(scores[id] / best)}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	return results
+}
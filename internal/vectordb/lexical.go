@@ -0,0 +1,213 @@
+package vectordb
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters, at their usual values.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// symbolBoost is how many times a document's symbol and file name count
+// toward its terms, so a search for an identifier ranks the code that
+// defines it above code that merely mentions it.
+const symbolBoost = 3
+
+// lexicalIndex is an in-memory BM25 index over document content, symbols,
+// and file names. It finds exact identifiers that embeddings blur, e.g.
+// handleSearchCodebase or ERR_QUOTA_EXCEEDED.
+type lexicalIndex struct {
+	docs     map[string]*lexicalDoc
+	postings map[string]map[string]int // term -> document ID -> term frequency
+	totalLen int
+}
+
+type lexicalDoc struct {
+	doc    Document
+	length int
+	terms  map[string]int
+}
+
+func newLexicalIndex() *lexicalIndex {
+	return &lexicalIndex{
+		docs:     make(map[string]*lexicalDoc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// add indexes doc, replacing any document with the same ID.
+func (x *lexicalIndex) add(doc Document) {
+	x.remove(doc.ID)
+	terms := make(map[string]int)
+	length := 0
+	count := func(text string, weight int) {
+		for _, t := range lexicalTerms(text) {
+			terms[t] += weight
+			length += weight
+		}
+	}
+	count(doc.Content, 1)
+	count(doc.Metadata.Symbol, symbolBoost)
+	count(path.Base(doc.Metadata.FilePath), symbolBoost)
+
+	x.docs[doc.ID] = &lexicalDoc{doc: doc, length: length, terms: terms}
+	x.totalLen += length
+	for t, tf := range terms {
+		p := x.postings[t]
+		if p == nil {
+			p = make(map[string]int)
+			x.postings[t] = p
+		}
+		p[doc.ID] = tf
+	}
+}
+
+func (x *lexicalIndex) remove(id string) {
+	d, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for t := range d.terms {
+		delete(x.postings[t], id)
+		if len(x.postings[t]) == 0 {
+			delete(x.postings, t)
+		}
+	}
+	x.totalLen -= d.length
+	delete(x.docs, id)
+}
+
+// removeWhere removes every document match reports true for.
+func (x *lexicalIndex) removeWhere(match func(Document) bool) {
+	for id, d := range x.docs {
+		if match(d.doc) {
+			x.remove(id)
+		}
+	}
+}
+
+// search returns up to limit documents matching filter, best first, with
+// their BM25 scores as the similarity.
+func (x *lexicalIndex) search(query string, limit int, filter *SearchFilter) []SearchResult {
+	if len(x.docs) == 0 {
+		return nil
+	}
+	avgLen := float64(x.totalLen) / float64(len(x.docs))
+	n := float64(len(x.docs))
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range lexicalTerms(query) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		p := x.postings[t]
+		if len(p) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(len(p))+0.5)/(float64(len(p))+0.5))
+		for id, tf := range p {
+			dl := float64(x.docs[id].length)
+			f := float64(tf)
+			scores[id] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgLen))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		doc := x.docs[id].doc
+		if filter.matches(doc) {
+			results = append(results, SearchResult{Document: doc, Similarity: float32(score)})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		return results[i].Document.ID < results[j].Document.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// lexicalTerms splits text into lowercase terms. An identifier is kept
+// whole and also split at underscores and case changes, so "parseHTTPRequest"
+// matches queries for parsehttprequest, parse, http, and request.
+func lexicalTerms(text string) []string {
+	var terms []string
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, w := range words {
+		w = strings.Trim(w, "_")
+		if w == "" {
+			continue
+		}
+		terms = append(terms, strings.ToLower(w))
+		parts := identifierParts(w)
+		if len(parts) > 1 {
+			terms = append(terms, parts...)
+		}
+	}
+	return terms
+}
+
+// identifierParts splits an identifier at underscores and case changes,
+// lowercasing each part: "HTTPServer_url" -> http, server, url.
+func identifierParts(w string) []string {
+	var parts []string
+	runes := []rune(w)
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, strings.ToLower(string(runes[start:end])))
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '_' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev),
+			unicode.IsDigit(r) != unicode.IsDigit(prev):
+			// fooBar, v2
+			flush(i)
+			start = i
+		case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTTPServer: the S starts a new part.
+			flush(i)
+			start = i
+		}
+	}
+	flush(len(runes))
+	return parts
+}
+
+// matches reports whether doc passes the filter. A nil filter passes
+// everything.
+func (f *SearchFilter) matches(doc Document) bool {
+	if f == nil {
+		return true
+	}
+	md := doc.Metadata
+	return (f.Type == nil || md.Type == *f.Type) &&
+		(f.FilePath == nil || md.FilePath == *f.FilePath) &&
+		(f.Language == nil || md.Language == *f.Language) &&
+		(f.RepoID == nil || md.RepoID == *f.RepoID) &&
+		(f.Symbol == nil || md.Symbol == *f.Symbol)
+}
@@ -0,0 +1,344 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ziadkadry99/auto-doc/internal/embeddings"
+)
+
+// DefaultCollection is the table or collection the server-backed stores use
+// when none is configured.
+const DefaultCollection = "autodoc_documents"
+
+// pgvectorBatch is how many documents go in one INSERT.
+const pgvectorBatch = 200
+
+// PgvectorStore implements VectorStore on a Postgres table with the
+// pgvector extension, so every user and the central server can share one
+// index. The table is created on first use.
+type PgvectorStore struct {
+	pool     *pgxpool.Pool
+	table    string // the table name, as an identifier
+	name     string // the table name, unquoted, for its indexes' names
+	embedder embeddings.Embedder
+}
+
+// tableName matches the table names allowed: plain identifiers, short
+// enough for the names of the table's indexes to fit in 63 bytes.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,47}$`)
+
+// NewPgvectorStore connects to the database at url and creates table,
+// and the vector extension, if they don't exist yet.
+func NewPgvectorStore(ctx context.Context, url, table string, embedder embeddings.Embedder) (*PgvectorStore, error) {
+	if table == "" {
+		table = DefaultCollection
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid pgvector table name %q: use letters, digits, and underscores", table)
+	}
+	cfg, err := pgvectorConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+	s := &PgvectorStore{pool: pool, table: pgx.Identifier{table}.Sanitize(), name: table, embedder: embedder}
+	if err := s.createTable(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// pgvectorConfig parses a postgres:// URL. Like a browser, and unlike
+// libpq, the server's certificate is verified unless the URL asks for
+// less with sslmode.
+func pgvectorConfig(rawURL string) (*pgxpool.Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing postgres URL: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("postgres URL must start with postgres://, not %s://", u.Scheme)
+	}
+	q := u.Query()
+	switch mode := q.Get("sslmode"); mode {
+	case "":
+		q.Set("sslmode", "verify-full")
+		u.RawQuery = q.Encode()
+	case "disable", "allow", "prefer":
+		slog.Warn("postgres connections may be made without TLS", "host", u.Hostname(), "sslmode", mode)
+	}
+	cfg, err := pgxpool.ParseConfig(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("parsing postgres URL: %w", err)
+	}
+	cfg.ConnConfig.RuntimeParams["application_name"] = "autodoc"
+	return cfg, nil
+}
+
+func (s *PgvectorStore) createTable(ctx context.Context) error {
+	// An untyped vector column takes any dimensions, but can't be indexed.
+	column := "vector"
+	dims := s.embedder.Dimensions()
+	if dims > 0 {
+		column = fmt.Sprintf("vector(%d)", dims)
+	}
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id text PRIMARY KEY,
+	content text NOT NULL,
+	file_path text NOT NULL,
+	repo_id text NOT NULL,
+	type text NOT NULL,
+	language text NOT NULL,
+	metadata jsonb NOT NULL,
+	embedding %s NOT NULL
+)`, s.table, column),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (file_path)", s.index("file_path"), s.table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (repo_id)", s.index("repo_id"), s.table),
+	}
+	if dims > 0 {
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding vector_cosine_ops)", s.index("embedding"), s.table))
+	}
+	for _, stmt := range statements {
+		if _, err := s.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("creating pgvector table: %w", err)
+		}
+	}
+	return nil
+}
+
+// index is the identifier of the table's index on column.
+func (s *PgvectorStore) index(column string) string {
+	return pgx.Identifier{s.name + "_" + column + "_idx"}.Sanitize()
+}
+
+func (s *PgvectorStore) AddDocuments(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embedding documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(docs))
+	}
+	return s.ImportDocuments(ctx, docs, vectors)
+}
+
+// pgvectorRow is a document as passed to the INSERT, in a JSON array that
+// jsonb_to_recordset unpacks, so a batch takes one round trip.
+type pgvectorRow struct {
+	ID        string            `json:"id"`
+	Content   string            `json:"content"`
+	Metadata  map[string]string `json:"metadata"`
+	Embedding string            `json:"embedding"`
+}
+
+// ImportDocuments adds or replaces documents whose embeddings are already
+// computed.
+func (s *PgvectorStore) ImportDocuments(ctx context.Context, docs []Document, vectors [][]float32) error {
+	insert := fmt.Sprintf(`INSERT INTO %s (id, content, file_path, repo_id, type, language, metadata, embedding)
+SELECT d.id, d.content, d.metadata->>'file_path', d.metadata->>'repo_id', d.metadata->>'type', d.metadata->>'language', d.metadata, d.embedding::vector
+FROM jsonb_to_recordset($1::jsonb) AS d(id text, content text, metadata jsonb, embedding text)
+ON CONFLICT (id) DO UPDATE SET content = EXCLUDED.content, file_path = EXCLUDED.file_path, repo_id = EXCLUDED.repo_id,
+	type = EXCLUDED.type, language = EXCLUDED.language, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding`, s.table)
+
+	for start := 0; start < len(docs); start += pgvectorBatch {
+		end := min(start+pgvectorBatch, len(docs))
+		rows := make([]pgvectorRow, 0, end-start)
+		for i := start; i < end; i++ {
+			rows = append(rows, pgvectorRow{
+				ID: docs[i].ID,
+				// Postgres text can't hold NUL characters.
+				Content:   strings.ReplaceAll(docs[i].Content, "\x00", ""),
+				Metadata:  metadataToMap(docs[i].Metadata),
+				Embedding: vectorLiteral(vectors[i]),
+			})
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		if _, err := s.pool.Exec(ctx, insert, string(data)); err != nil {
+			return fmt.Errorf("inserting documents: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PgvectorStore) Search(ctx context.Context, query string, limit int, filter *SearchFilter) ([]SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embedding query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for the query", len(vectors))
+	}
+
+	args := []any{vectorLiteral(vectors[0])}
+	where := pgvectorWhere(filter, &args)
+	args = append(args, limit*3) // extra results for deduplication
+	sql := fmt.Sprintf(`SELECT id, content, metadata::text, 1 - (embedding <=> $1::vector)
+FROM %s%s ORDER BY embedding <=> $1::vector LIMIT $%d`, s.table, where, len(args))
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector query: %w", err)
+	}
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (SearchResult, error) {
+		var id, content, md string
+		var similarity float64
+		if err := row.Scan(&id, &content, &md, &similarity); err != nil {
+			return SearchResult{}, err
+		}
+		doc, err := pgvectorDocument(id, content, md)
+		return SearchResult{Document: doc, Similarity: float32(similarity)}, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pgvector query: %w", err)
+	}
+	return dedupeResults(results, limit), nil
+}
+
+// pgvectorWhere builds the WHERE clause for filter, appending its values
+// to args as parameters.
+func pgvectorWhere(filter *SearchFilter, args *[]any) string {
+	if filter == nil {
+		return ""
+	}
+	var conds []string
+	add := func(column string, value *string) {
+		if value != nil {
+			*args = append(*args, *value)
+			conds = append(conds, fmt.Sprintf("%s = $%d", column, len(*args)))
+		}
+	}
+	if filter.Type != nil {
+		t := string(*filter.Type)
+		add("type", &t)
+	}
+	add("file_path", filter.FilePath)
+	add("language", filter.Language)
+	add("repo_id", filter.RepoID)
+	add("metadata->>'symbol'", filter.Symbol)
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+func (s *PgvectorStore) GetByFilePath(ctx context.Context, filePath string) ([]Document, error) {
+	docs, err := s.documents(ctx, fmt.Sprintf("SELECT id, content, metadata::text FROM %s WHERE file_path = $1", s.table), filePath)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector query by file path: %w", err)
+	}
+	return docs, nil
+}
+
+// Documents returns every document in the table.
+func (s *PgvectorStore) Documents(ctx context.Context) ([]Document, error) {
+	docs, err := s.documents(ctx, fmt.Sprintf("SELECT id, content, metadata::text FROM %s", s.table))
+	if err != nil {
+		return nil, fmt.Errorf("pgvector list documents: %w", err)
+	}
+	return docs, nil
+}
+
+// documents runs a query selecting id, content, and metadata.
+func (s *PgvectorStore) documents(ctx context.Context, sql string, args ...any) ([]Document, error) {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Document, error) {
+		var id, content, md string
+		if err := row.Scan(&id, &content, &md); err != nil {
+			return Document{}, err
+		}
+		return pgvectorDocument(id, content, md)
+	})
+}
+
+func pgvectorDocument(id, content, metadata string) (Document, error) {
+	var md map[string]string
+	if err := json.Unmarshal([]byte(metadata), &md); err != nil {
+		return Document{}, fmt.Errorf("decoding metadata of %s: %w", id, err)
+	}
+	return Document{ID: id, Content: content, Metadata: mapToMetadata(md)}, nil
+}
+
+func (s *PgvectorStore) DeleteByFilePath(ctx context.Context, filePath string) error {
+	_, err := s.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE file_path = $1", s.table), filePath)
+	return err
+}
+
+func (s *PgvectorStore) DeleteByRepoID(ctx context.Context, repoID string) error {
+	_, err := s.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE repo_id = $1", s.table), repoID)
+	return err
+}
+
+// Persist does nothing: every change is already in the database.
+func (s *PgvectorStore) Persist(ctx context.Context, dir string) error {
+	return nil
+}
+
+// Load does nothing: the index is read from the database as it is queried.
+func (s *PgvectorStore) Load(ctx context.Context, dir string) error {
+	return nil
+}
+
+// Count returns the number of documents, or 0 if the database can't be
+// reached.
+func (s *PgvectorStore) Count() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var n int
+	if err := s.pool.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM %s", s.table)).Scan(&n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// Close closes the database connections.
+func (s *PgvectorStore) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// vectorLiteral formats v the way pgvector reads it, e.g. [0.1,0.2].
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,348 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ziadkadry99/auto-doc/internal/embeddings"
+)
+
+// qdrantBatch is how many points go in one upsert.
+const qdrantBatch = 256
+
+// qdrantIndexed are the payload fields searches and deletes filter on.
+var qdrantIndexed = []string{"file_path", "repo_id", "type", "language", "symbol"}
+
+// QdrantStore implements VectorStore on a Qdrant collection, through
+// Qdrant's REST API. The collection is created, with cosine distance and
+// indexes on the fields searches filter by, when the first documents are
+// added.
+type QdrantStore struct {
+	baseURL    string
+	collection string
+	apiKey     string
+	embedder   embeddings.Embedder
+	client     *http.Client
+
+	mu     sync.Mutex
+	exists bool
+}
+
+// NewQdrantStore creates a store for collection on the Qdrant server at
+// baseURL. apiKey may be empty for servers without authentication.
+func NewQdrantStore(baseURL, collection, apiKey string, embedder embeddings.Embedder) *QdrantStore {
+	if collection == "" {
+		collection = DefaultCollection
+	}
+	return &QdrantStore{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		collection: collection,
+		apiKey:     apiKey,
+		embedder:   embedder,
+		client:     &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// qdrantError is a non-2xx response.
+type qdrantError struct {
+	Status int
+	Body   string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.Status, e.Body)
+}
+
+func isQdrantNotFound(err error) bool {
+	var qe *qdrantError
+	return errors.As(err, &qe) && qe.Status == http.StatusNotFound
+}
+
+// call sends a request to the collection's endpoint path and decodes the
+// response's result into out, when out is non-nil.
+func (s *QdrantStore) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal qdrant request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	endpoint := s.baseURL + "/collections/" + url.PathEscape(s.collection) + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("create qdrant request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read qdrant response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &qdrantError{Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("decode qdrant response: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+// ensureCollection creates the collection for vectors of dims dimensions
+// unless it exists.
+func (s *QdrantStore) ensureCollection(ctx context.Context, dims int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exists {
+		return nil
+	}
+	err := s.call(ctx, http.MethodGet, "", nil, nil)
+	if isQdrantNotFound(err) {
+		err = s.call(ctx, http.MethodPut, "", map[string]any{
+			"vectors": map[string]any{"size": dims, "distance": "Cosine"},
+		}, nil)
+		for _, field := range qdrantIndexed {
+			if err != nil {
+				break
+			}
+			err = s.call(ctx, http.MethodPut, "/index?wait=true", map[string]any{
+				"field_name": field, "field_schema": "keyword",
+			}, nil)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("creating qdrant collection %s: %w", s.collection, err)
+	}
+	s.exists = true
+	return nil
+}
+
+func (s *QdrantStore) AddDocuments(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embedding documents: %w", err)
+	}
+	if len(vectors) != len(docs) {
+		return fmt.Errorf("embedder returned %d vectors for %d documents", len(vectors), len(docs))
+	}
+	return s.ImportDocuments(ctx, docs, vectors)
+}
+
+type qdrantPoint struct {
+	ID      string            `json:"id"`
+	Vector  []float32         `json:"vector,omitempty"`
+	Payload map[string]string `json:"payload"`
+	Score   float32           `json:"score,omitempty"`
+}
+
+// qdrantPointID maps a document ID to a point ID; Qdrant only accepts
+// UUIDs and integers.
+func qdrantPointID(docID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("autodoc:"+docID)).String()
+}
+
+// ImportDocuments adds or replaces documents whose embeddings are already
+// computed.
+func (s *QdrantStore) ImportDocuments(ctx context.Context, docs []Document, vectors [][]float32) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(vectors[0])); err != nil {
+		return err
+	}
+	for start := 0; start < len(docs); start += qdrantBatch {
+		end := min(start+qdrantBatch, len(docs))
+		points := make([]qdrantPoint, 0, end-start)
+		for i := start; i < end; i++ {
+			payload := metadataToMap(docs[i].Metadata)
+			payload["doc_id"] = docs[i].ID
+			payload["content"] = docs[i].Content
+			points = append(points, qdrantPoint{ID: qdrantPointID(docs[i].ID), Vector: vectors[i], Payload: payload})
+		}
+		if err := s.call(ctx, http.MethodPut, "/points?wait=true", map[string]any{"points": points}, nil); err != nil {
+			return fmt.Errorf("upserting points: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *QdrantStore) Search(ctx context.Context, query string, limit int, filter *SearchFilter) ([]SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embedding query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for the query", len(vectors))
+	}
+
+	body := map[string]any{
+		"vector":       vectors[0],
+		"limit":        limit * 3, // extra results for deduplication
+		"with_payload": true,
+	}
+	if f := qdrantFilter(buildWhereClause(filter)); f != nil {
+		body["filter"] = f
+	}
+	var points []qdrantPoint
+	if err := s.call(ctx, http.MethodPost, "/points/search", body, &points); err != nil {
+		if isQdrantNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("qdrant search: %w", err)
+	}
+	results := make([]SearchResult, len(points))
+	for i, p := range points {
+		results[i] = SearchResult{Document: qdrantDocument(p), Similarity: p.Score}
+	}
+	return dedupeResults(results, limit), nil
+}
+
+// qdrantFilter turns a where clause into a Qdrant filter matching every
+// field exactly.
+func qdrantFilter(where map[string]string) map[string]any {
+	if len(where) == 0 {
+		return nil
+	}
+	var must []map[string]any
+	for key, value := range where {
+		must = append(must, map[string]any{"key": key, "match": map[string]any{"value": value}})
+	}
+	return map[string]any{"must": must}
+}
+
+func qdrantDocument(p qdrantPoint) Document {
+	content := p.Payload["content"]
+	id := p.Payload["doc_id"]
+	delete(p.Payload, "content")
+	delete(p.Payload, "doc_id")
+	return Document{ID: id, Content: content, Metadata: mapToMetadata(p.Payload)}
+}
+
+func (s *QdrantStore) GetByFilePath(ctx context.Context, filePath string) ([]Document, error) {
+	docs, err := s.scroll(ctx, qdrantFilter(map[string]string{"file_path": filePath}))
+	if err != nil {
+		return nil, fmt.Errorf("qdrant scroll by file path: %w", err)
+	}
+	return docs, nil
+}
+
+// Documents returns every document in the collection.
+func (s *QdrantStore) Documents(ctx context.Context) ([]Document, error) {
+	docs, err := s.scroll(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("qdrant list documents: %w", err)
+	}
+	return docs, nil
+}
+
+// scroll pages through the documents matching filter, or all of them when
+// filter is nil. A missing collection holds no documents.
+func (s *QdrantStore) scroll(ctx context.Context, filter map[string]any) ([]Document, error) {
+	var docs []Document
+	var offset any
+	for {
+		body := map[string]any{
+			"limit":        qdrantBatch,
+			"with_payload": true,
+		}
+		if filter != nil {
+			body["filter"] = filter
+		}
+		if offset != nil {
+			body["offset"] = offset
+		}
+		var page struct {
+			Points []qdrantPoint `json:"points"`
+			Next   any           `json:"next_page_offset"`
+		}
+		if err := s.call(ctx, http.MethodPost, "/points/scroll", body, &page); err != nil {
+			if isQdrantNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for _, p := range page.Points {
+			docs = append(docs, qdrantDocument(p))
+		}
+		if page.Next == nil {
+			return docs, nil
+		}
+		offset = page.Next
+	}
+}
+
+func (s *QdrantStore) deleteWhere(ctx context.Context, where map[string]string) error {
+	err := s.call(ctx, http.MethodPost, "/points/delete?wait=true", map[string]any{"filter": qdrantFilter(where)}, nil)
+	if isQdrantNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *QdrantStore) DeleteByFilePath(ctx context.Context, filePath string) error {
+	return s.deleteWhere(ctx, map[string]string{"file_path": filePath})
+}
+
+func (s *QdrantStore) DeleteByRepoID(ctx context.Context, repoID string) error {
+	return s.deleteWhere(ctx, map[string]string{"repo_id": repoID})
+}
+
+// Persist does nothing: every change is already on the server.
+func (s *QdrantStore) Persist(ctx context.Context, dir string) error {
+	return nil
+}
+
+// Load does nothing: the index is read from the server as it is queried.
+func (s *QdrantStore) Load(ctx context.Context, dir string) error {
+	return nil
+}
+
+// Count returns the number of documents, or 0 if the server can't be
+// reached.
+func (s *QdrantStore) Count() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := s.call(ctx, http.MethodPost, "/points/count", map[string]any{"exact": true}, &result); err != nil {
+		return 0
+	}
+	return result.Count
+}
@@ -0,0 +1,116 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/llm"
+)
+
+// rerankExcerpt is how much of each candidate the reranker shows the LLM.
+const rerankExcerpt = 600
+
+// LLMReranker reranks search candidates by asking an LLM to grade how
+// well each one answers the query, in a single call. It acts as a
+// cross-encoder: the model reads the query and each candidate together,
+// which catches relevance that neither embeddings nor term overlap see.
+type LLMReranker struct {
+	provider llm.Provider
+	model    string
+}
+
+// NewLLMReranker creates a reranker that calls model through provider.
+func NewLLMReranker(provider llm.Provider, model string) *LLMReranker {
+	return &LLMReranker{provider: provider, model: model}
+}
+
+// Rerank orders results by the LLM's grades, best first, and sets each
+// similarity to its grade out of 1. Candidates the LLM didn't grade keep
+// their order after the graded ones.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, results []SearchResult) ([]SearchResult, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\n\n", query)
+	for i, res := range results {
+		content := res.Document.Content
+		if len(content) > rerankExcerpt {
+			content = content[:rerankExcerpt] + "..."
+		}
+		fmt.Fprintf(&sb, "[%d] %s", i+1, res.Document.Metadata.FilePath)
+		if res.Document.Metadata.Symbol != "" {
+			fmt.Fprintf(&sb, " (%s)", res.Document.Metadata.Symbol)
+		}
+		fmt.Fprintf(&sb, "\n%s\n\n", content)
+	}
+	sb.WriteString(`Grade how well each passage answers the query, from 0 (irrelevant) to 10 (answers it directly). Respond with JSON only: {"scores": [{"id": 1, "score": 7}, ...]}`)
+
+	resp, err := r.provider.Complete(ctx, llm.CompletionRequest{
+		Model: r.model,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You rank code documentation search results by relevance to a developer's query."},
+			{Role: llm.RoleUser, Content: sb.String()},
+		},
+		MaxTokens:   1024,
+		Temperature: 0,
+		JSONMode:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+
+	var graded struct {
+		Scores []struct {
+			ID    int     `json:"id"`
+			Score float64 `json:"score"`
+		} `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(resp.Content)), &graded); err != nil {
+		return nil, fmt.Errorf("parsing rerank response: %w", err)
+	}
+	if len(graded.Scores) == 0 {
+		return nil, fmt.Errorf("rerank response graded no results")
+	}
+
+	grades := make(map[int]float64, len(graded.Scores))
+	for _, s := range graded.Scores {
+		if s.ID >= 1 && s.ID <= len(results) {
+			grades[s.ID-1] = s.Score
+		}
+	}
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ga, oka := grades[order[a]]
+		gb, okb := grades[order[b]]
+		if oka != okb {
+			return oka
+		}
+		return ga > gb
+	})
+	out := make([]SearchResult, len(results))
+	for i, idx := range order {
+		out[i] = results[idx]
+		if g, ok := grades[idx]; ok {
+			out[i].Similarity = float32(min(max(g, 0), 10) / 10)
+		}
+	}
+	return out, nil
+}
+
+// stripCodeFence removes a ```json fence some models wrap JSON in even
+// when asked not to.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
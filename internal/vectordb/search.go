@@ -45,3 +45,38 @@ func FormatResults(results []SearchResult) string {
 
 	return sb.String()
 }
+
+// dedupeResults keeps the best limit results, ordered by similarity, with
+// at most two per file path and two per content hash. The hash limit
+// merges identical files in different directories (e.g., proto files
+// copied across service directories).
+func dedupeResults(results []SearchResult, limit int) []SearchResult {
+	const maxPerFile = 2
+	const maxPerHash = 2
+	fileCount := make(map[string]int)
+	hashCount := make(map[string]int)
+	var out []SearchResult
+
+	for _, r := range results {
+		fp := r.Document.Metadata.FilePath
+		ch := r.Document.Metadata.ContentHash
+
+		if fileCount[fp] >= maxPerFile {
+			continue
+		}
+		// Skip if we already have enough results with the same content.
+		if ch != "" && hashCount[ch] >= maxPerHash {
+			continue
+		}
+
+		fileCount[fp]++
+		if ch != "" {
+			hashCount[ch]++
+		}
+		out = append(out, r)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
@@ -28,3 +28,10 @@ type VectorStore interface {
 	// Count returns the total number of documents in the store.
 	Count() int
 }
+
+// Importer is implemented by stores that can take documents with their
+// embeddings already computed, so an index can move between backends
+// without being embedded again.
+type Importer interface {
+	ImportDocuments(ctx context.Context, docs []Document, vectors [][]float32) error
+}
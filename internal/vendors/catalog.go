@@ -0,0 +1,292 @@
+package vendors
+
+import "strings"
+
+// vendor is an external SaaS or cloud provider the catalog knows.
+type vendor struct {
+	name     string
+	category string
+	// domains are the DNS domains its APIs are served from; subdomains
+	// match too.
+	domains []string
+	// products name its products by the words its SDK packages and API
+	// hosts use for them, e.g. "sqs" for AWS's SQS.
+	products map[string]string
+}
+
+var vendorCatalog = []vendor{
+	{name: "Stripe", category: "Payments", domains: []string{"stripe.com"}},
+	{name: "PayPal", category: "Payments", domains: []string{"paypal.com"}},
+	{name: "Braintree", category: "Payments", domains: []string{"braintreegateway.com"}},
+	{name: "Adyen", category: "Payments", domains: []string{"adyen.com"}},
+	{name: "Plaid", category: "Banking", domains: []string{"plaid.com"}},
+	{name: "Twilio", category: "SMS and voice", domains: []string{"twilio.com"}},
+	{name: "SendGrid", category: "Email", domains: []string{"sendgrid.com", "sendgrid.net"}},
+	{name: "Mailgun", category: "Email", domains: []string{"mailgun.net", "mailgun.org"}},
+	{name: "Postmark", category: "Email", domains: []string{"postmarkapp.com"}},
+	{name: "Slack", category: "Chat notifications", domains: []string{"slack.com"}},
+	{name: "Auth0", category: "Authentication", domains: []string{"auth0.com"}},
+	{name: "Okta", category: "Authentication", domains: []string{"okta.com", "oktapreview.com"}},
+	{name: "Firebase", category: "App platform", domains: []string{"firebaseio.com", "firebase.googleapis.com", "fcm.googleapis.com"}},
+	{name: "Sentry", category: "Error tracking", domains: []string{"sentry.io"}},
+	{name: "Datadog", category: "Monitoring", domains: []string{"datadoghq.com", "datadoghq.eu"}},
+	{name: "New Relic", category: "Monitoring", domains: []string{"newrelic.com", "nr-data.net"}},
+	{name: "Segment", category: "Analytics", domains: []string{"segment.io", "segment.com"}},
+	{name: "Algolia", category: "Search", domains: []string{"algolia.net", "algolianet.com"}},
+	{name: "LaunchDarkly", category: "Feature flags", domains: []string{"launchdarkly.com"}},
+	{name: "HubSpot", category: "CRM", domains: []string{"hubapi.com", "hubspot.com"}},
+	{name: "Salesforce", category: "CRM", domains: []string{"salesforce.com", "force.com"}},
+	{name: "Shopify", category: "Commerce", domains: []string{"myshopify.com", "shopify.com"}},
+	{name: "OpenAI", category: "AI", domains: []string{"openai.com"}},
+	{name: "Anthropic", category: "AI", domains: []string{"anthropic.com"}},
+	{name: "GitHub", category: "Source control", domains: []string{"api.github.com"}},
+	{name: "AWS", category: "Cloud", domains: []string{"amazonaws.com"}, products: map[string]string{
+		"s3": "S3", "sqs": "SQS", "sns": "SNS", "ses": "SES", "sesv2": "SES", "email": "SES",
+		"dynamodb": "DynamoDB", "lambda": "Lambda", "kinesis": "Kinesis", "secretsmanager": "Secrets Manager",
+		"kms": "KMS", "cognito-idp": "Cognito", "cognitoidentityprovider": "Cognito", "execute-api": "API Gateway",
+		"eventbridge": "EventBridge", "events": "EventBridge", "sts": "STS", "ssm": "Systems Manager",
+	}},
+	{name: "Google Cloud", category: "Cloud", domains: []string{"googleapis.com"}, products: map[string]string{
+		"storage": "Cloud Storage", "pubsub": "Pub/Sub", "bigquery": "BigQuery", "firestore": "Firestore",
+		"spanner": "Spanner", "secretmanager": "Secret Manager", "tasks": "Cloud Tasks", "kms": "Cloud KMS",
+	}},
+	{name: "Azure", category: "Cloud", domains: []string{"core.windows.net", "azure.com", "servicebus.windows.net", "vault.azure.net"}, products: map[string]string{
+		"blob": "Blob Storage", "storage-blob": "Blob Storage", "azblob": "Blob Storage", "queue": "Queue Storage",
+		"storage-queue": "Queue Storage", "servicebus": "Service Bus", "service-bus": "Service Bus",
+		"cosmos": "Cosmos DB", "azcosmos": "Cosmos DB", "keyvault": "Key Vault", "keyvault-secrets": "Key Vault",
+		"vault": "Key Vault", "eventhub": "Event Hubs", "event-hubs": "Event Hubs",
+	}},
+}
+
+// library maps an SDK, as a package manifest declares it or source imports
+// it, to its vendor.
+type library struct {
+	ecosystem string // the importers.Dependency ecosystem
+	// name is the SDK's name; it also matches its subpackages. A name
+	// ending in "/", "-", ".", or ":" is a family of SDKs, and what follows
+	// it names the product, as in @aws-sdk/client-s3.
+	name   string
+	vendor string
+}
+
+var libraries = []library{
+	// Go
+	{"Go", "github.com/stripe/stripe-go", "Stripe"},
+	{"Go", "github.com/plaid/plaid-go", "Plaid"},
+	{"Go", "github.com/braintree-go/braintree-go", "Braintree"},
+	{"Go", "github.com/adyen/adyen-go-api-library", "Adyen"},
+	{"Go", "github.com/twilio/twilio-go", "Twilio"},
+	{"Go", "github.com/sendgrid/sendgrid-go", "SendGrid"},
+	{"Go", "github.com/mailgun/mailgun-go", "Mailgun"},
+	{"Go", "github.com/slack-go/slack", "Slack"},
+	{"Go", "github.com/auth0/go-auth0", "Auth0"},
+	{"Go", "github.com/okta/okta-sdk-golang", "Okta"},
+	{"Go", "github.com/okta/okta-jwt-verifier-golang", "Okta"},
+	{"Go", "firebase.google.com/go", "Firebase"},
+	{"Go", "github.com/getsentry/sentry-go", "Sentry"},
+	{"Go", "github.com/DataDog/datadog-go", "Datadog"},
+	{"Go", "gopkg.in/DataDog/dd-trace-go.v1", "Datadog"},
+	{"Go", "github.com/DataDog/dd-trace-go", "Datadog"},
+	{"Go", "github.com/newrelic/go-agent", "New Relic"},
+	{"Go", "github.com/segmentio/analytics-go", "Segment"},
+	{"Go", "github.com/algolia/algoliasearch-client-go", "Algolia"},
+	{"Go", "github.com/launchdarkly/go-server-sdk", "LaunchDarkly"},
+	{"Go", "github.com/sashabaranov/go-openai", "OpenAI"},
+	{"Go", "github.com/openai/openai-go", "OpenAI"},
+	{"Go", "github.com/anthropics/anthropic-sdk-go", "Anthropic"},
+	{"Go", "github.com/google/go-github", "GitHub"},
+	{"Go", "github.com/aws/aws-sdk-go-v2/service/", "AWS"},
+	{"Go", "github.com/aws/aws-sdk-go/service/", "AWS"},
+	{"Go", "github.com/aws/aws-sdk-go-v2", "AWS"},
+	{"Go", "github.com/aws/aws-sdk-go", "AWS"},
+	{"Go", "cloud.google.com/go/", "Google Cloud"},
+	{"Go", "github.com/Azure/azure-sdk-for-go/sdk/storage/", "Azure"},
+	{"Go", "github.com/Azure/azure-sdk-for-go/sdk/messaging/", "Azure"},
+	{"Go", "github.com/Azure/azure-sdk-for-go/sdk/data/", "Azure"},
+	{"Go", "github.com/Azure/azure-sdk-for-go", "Azure"},
+
+	// npm
+	{"npm", "stripe", "Stripe"},
+	{"npm", "@stripe/stripe-js", "Stripe"},
+	{"npm", "@paypal/checkout-server-sdk", "PayPal"},
+	{"npm", "@paypal/paypal-server-sdk", "PayPal"},
+	{"npm", "braintree", "Braintree"},
+	{"npm", "@adyen/api-library", "Adyen"},
+	{"npm", "plaid", "Plaid"},
+	{"npm", "twilio", "Twilio"},
+	{"npm", "@sendgrid/", "SendGrid"},
+	{"npm", "mailgun.js", "Mailgun"},
+	{"npm", "postmark", "Postmark"},
+	{"npm", "@slack/", "Slack"},
+	{"npm", "auth0", "Auth0"},
+	{"npm", "@auth0/", "Auth0"},
+	{"npm", "@okta/", "Okta"},
+	{"npm", "firebase-admin", "Firebase"},
+	{"npm", "firebase", "Firebase"},
+	{"npm", "@sentry/", "Sentry"},
+	{"npm", "dd-trace", "Datadog"},
+	{"npm", "@datadog/", "Datadog"},
+	{"npm", "newrelic", "New Relic"},
+	{"npm", "@segment/analytics-node", "Segment"},
+	{"npm", "analytics-node", "Segment"},
+	{"npm", "algoliasearch", "Algolia"},
+	{"npm", "@launchdarkly/", "LaunchDarkly"},
+	{"npm", "launchdarkly-node-server-sdk", "LaunchDarkly"},
+	{"npm", "@hubspot/api-client", "HubSpot"},
+	{"npm", "jsforce", "Salesforce"},
+	{"npm", "@shopify/shopify-api", "Shopify"},
+	{"npm", "openai", "OpenAI"},
+	{"npm", "@anthropic-ai/sdk", "Anthropic"},
+	{"npm", "@octokit/", "GitHub"},
+	{"npm", "@aws-sdk/client-", "AWS"},
+	{"npm", "aws-sdk", "AWS"},
+	{"npm", "@google-cloud/", "Google Cloud"},
+	{"npm", "@azure/", "Azure"},
+
+	// PyPI, by distribution and by import name
+	{"PyPI", "stripe", "Stripe"},
+	{"PyPI", "paypalrestsdk", "PayPal"},
+	{"PyPI", "braintree", "Braintree"},
+	{"PyPI", "Adyen", "Adyen"},
+	{"PyPI", "plaid-python", "Plaid"},
+	{"PyPI", "plaid", "Plaid"},
+	{"PyPI", "twilio", "Twilio"},
+	{"PyPI", "sendgrid", "SendGrid"},
+	{"PyPI", "postmarker", "Postmark"},
+	{"PyPI", "slack-sdk", "Slack"},
+	{"PyPI", "slack_sdk", "Slack"},
+	{"PyPI", "auth0-python", "Auth0"},
+	{"PyPI", "auth0", "Auth0"},
+	{"PyPI", "okta", "Okta"},
+	{"PyPI", "firebase-admin", "Firebase"},
+	{"PyPI", "firebase_admin", "Firebase"},
+	{"PyPI", "sentry-sdk", "Sentry"},
+	{"PyPI", "sentry_sdk", "Sentry"},
+	{"PyPI", "ddtrace", "Datadog"},
+	{"PyPI", "datadog", "Datadog"},
+	{"PyPI", "newrelic", "New Relic"},
+	{"PyPI", "segment-analytics-python", "Segment"},
+	{"PyPI", "algoliasearch", "Algolia"},
+	{"PyPI", "launchdarkly-server-sdk", "LaunchDarkly"},
+	{"PyPI", "ldclient", "LaunchDarkly"},
+	{"PyPI", "hubspot-api-client", "HubSpot"},
+	{"PyPI", "hubspot", "HubSpot"},
+	{"PyPI", "simple-salesforce", "Salesforce"},
+	{"PyPI", "simple_salesforce", "Salesforce"},
+	{"PyPI", "ShopifyAPI", "Shopify"},
+	{"PyPI", "shopify", "Shopify"},
+	{"PyPI", "openai", "OpenAI"},
+	{"PyPI", "anthropic", "Anthropic"},
+	{"PyPI", "PyGithub", "GitHub"},
+	{"PyPI", "github", "GitHub"},
+	{"PyPI", "boto3", "AWS"},
+	{"PyPI", "botocore", "AWS"},
+	{"PyPI", "aiobotocore", "AWS"},
+	{"PyPI", "google-cloud-", "Google Cloud"},
+	{"PyPI", "google.cloud.", "Google Cloud"},
+	{"PyPI", "azure-", "Azure"},
+	{"PyPI", "azure.", "Azure"},
+
+	// Maven
+	{"Maven", "com.stripe:", "Stripe"},
+	{"Maven", "com.paypal.sdk:", "PayPal"},
+	{"Maven", "com.braintreepayments.gateway:", "Braintree"},
+	{"Maven", "com.adyen:", "Adyen"},
+	{"Maven", "com.plaid:", "Plaid"},
+	{"Maven", "com.twilio.sdk:", "Twilio"},
+	{"Maven", "com.sendgrid:", "SendGrid"},
+	{"Maven", "com.slack.api:", "Slack"},
+	{"Maven", "com.auth0:", "Auth0"},
+	{"Maven", "com.okta.sdk:", "Okta"},
+	{"Maven", "com.google.firebase:", "Firebase"},
+	{"Maven", "io.sentry:", "Sentry"},
+	{"Maven", "com.datadoghq:", "Datadog"},
+	{"Maven", "com.newrelic.agent.java:", "New Relic"},
+	{"Maven", "com.segment.analytics.java:", "Segment"},
+	{"Maven", "com.algolia:", "Algolia"},
+	{"Maven", "com.launchdarkly:", "LaunchDarkly"},
+	{"Maven", "com.hubspot:", "HubSpot"},
+	{"Maven", "com.openai:", "OpenAI"},
+	{"Maven", "com.anthropic:", "Anthropic"},
+	{"Maven", "software.amazon.awssdk:", "AWS"},
+	{"Maven", "com.amazonaws:aws-java-sdk-", "AWS"},
+	{"Maven", "com.google.cloud:google-cloud-", "Google Cloud"},
+	{"Maven", "com.azure:azure-", "Azure"},
+
+	// Cargo
+	{"Cargo", "async-stripe", "Stripe"},
+	{"Cargo", "sentry", "Sentry"},
+	{"Cargo", "aws-sdk-", "AWS"},
+	{"Cargo", "aws-config", "AWS"},
+	{"Cargo", "octocrab", "GitHub"},
+}
+
+// familySeparators end the name of a family of SDKs.
+const familySeparators = "/-.:"
+
+// lookupLibrary finds the vendor, and product if it names one, of a
+// library of an ecosystem.
+func lookupLibrary(ecosystem, name string) (v vendor, product string, ok bool) {
+	for _, l := range libraries {
+		if l.ecosystem != ecosystem {
+			continue
+		}
+		var rest string
+		if strings.ContainsAny(l.name[len(l.name)-1:], familySeparators) {
+			after, found := strings.CutPrefix(name, l.name)
+			if !found || after == "" {
+				continue
+			}
+			rest = after
+		} else if !strings.EqualFold(name, l.name) && !strings.HasPrefix(name, l.name+"/") && !strings.HasPrefix(name, l.name+".") {
+			continue
+		}
+		v = vendorNamed(l.vendor)
+		return v, v.product(rest), true
+	}
+	return vendor{}, "", false
+}
+
+// lookupHost finds the vendor, and product if the host names one, that
+// serves a host.
+func lookupHost(host string) (v vendor, product string, ok bool) {
+	host = strings.ToLower(host)
+	for _, v := range vendorCatalog {
+		for _, d := range v.domains {
+			if host != d && !strings.HasSuffix(host, "."+d) {
+				continue
+			}
+			for _, label := range strings.Split(strings.TrimSuffix(host, d), ".") {
+				if p := v.product(label); p != "" {
+					return v, p, true
+				}
+			}
+			return v, "", true
+		}
+	}
+	return vendor{}, "", false
+}
+
+func vendorNamed(name string) vendor {
+	for _, v := range vendorCatalog {
+		if v.name == name {
+			return v
+		}
+	}
+	return vendor{name: name}
+}
+
+// product names the product a package suffix or host label stands for, as
+// in "s3" or "client-s3/commands"; "" when it names none the vendor has.
+func (v vendor) product(s string) string {
+	s = strings.ToLower(s)
+	s = s[:len(s)-len(strings.TrimLeft(s, "abcdefghijklmnopqrstuvwxyz0123456789-."))]
+	s = strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(s, "client-"), "aws-java-sdk-"), ".", "-")
+	if p, ok := v.products[s]; ok {
+		return p
+	}
+	if first, _, ok := strings.Cut(s, "-"); ok {
+		return v.products[first]
+	}
+	return ""
+}
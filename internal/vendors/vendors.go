@@ -0,0 +1,138 @@
+// Package vendors catalogs the external SaaS and cloud providers a service
+// depends on, such as Stripe, Twilio, S3, or Auth0, from the SDKs its
+// package manifests declare and its source imports, and the API hosts its
+// code calls.
+package vendors
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+// Use is a service's use of a vendor.
+type Use struct {
+	Vendor   string `json:"vendor"`
+	Category string `json:"category"` // what the vendor provides, e.g. "Payments"
+	// Products are the vendor's products in use, e.g. S3 and SQS for AWS;
+	// empty when the evidence names none.
+	Products []string `json:"products,omitempty"`
+	// Evidence says where the use was found, in order, e.g.
+	// "github.com/stripe/stripe-go/v76 in go.mod" or "api.stripe.com in
+	// billing/client.go:4".
+	Evidence []string `json:"evidence"`
+}
+
+// Detect finds the vendors a service uses: from the SDKs in deps, its
+// package manifests' libraries; the imports its analyses record; and hosts,
+// the hosts its files' code calls, by file path. Libraries only needed to
+// build or test don't count. Uses are sorted by vendor.
+func Detect(analyses map[string]indexer.FileAnalysis, deps []importers.Dependency, hosts map[string][]indexer.Host) []Use {
+	var uses []Use
+	add := func(v vendor, product, evidence string) {
+		i := slices.IndexFunc(uses, func(u Use) bool { return u.Vendor == v.name })
+		if i < 0 {
+			i = len(uses)
+			uses = append(uses, Use{Vendor: v.name, Category: v.category})
+		}
+		u := &uses[i]
+		if product != "" && !slices.Contains(u.Products, product) {
+			u.Products = append(u.Products, product)
+		}
+		if !slices.Contains(u.Evidence, evidence) {
+			u.Evidence = append(u.Evidence, evidence)
+		}
+	}
+
+	for _, d := range deps {
+		if d.Dev {
+			continue
+		}
+		if v, product, ok := lookupLibrary(d.Ecosystem, d.Name); ok {
+			add(v, product, fmt.Sprintf("%s in %s", d.Name, d.Manifest))
+		}
+	}
+	paths := make([]string, 0, len(analyses))
+	for p := range analyses {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		a := analyses[p]
+		for _, dep := range a.Dependencies {
+			if dep.Type != indexer.DepImport {
+				continue
+			}
+			if v, product, ok := lookupLibrary(importers.ImportEcosystem(a.Language), dep.Name); ok {
+				add(v, product, fmt.Sprintf("%s imported in %s", dep.Name, p))
+			}
+		}
+	}
+	hostPaths := make([]string, 0, len(hosts))
+	for p := range hosts {
+		hostPaths = append(hostPaths, p)
+	}
+	sort.Strings(hostPaths)
+	for _, p := range hostPaths {
+		for _, h := range hosts[p] {
+			if v, product, ok := lookupHost(h.Name); ok {
+				add(v, product, fmt.Sprintf("%s in %s:%d", h.Name, p, h.Line))
+			}
+		}
+	}
+
+	for i := range uses {
+		sort.Strings(uses[i].Products)
+	}
+	sort.Slice(uses, func(i, j int) bool { return cmp.Less(strings.ToLower(uses[i].Vendor), strings.ToLower(uses[j].Vendor)) })
+	return uses
+}
+
+// Lookup finds the vendor a dependency the service map names stands for,
+// such as "stripe", "Stripe API", "api.twilio.com", or "s3", with its
+// category and product.
+func Lookup(name string) (vendorName, category, product string, ok bool) {
+	if v, p, ok := lookupHost(name); ok && strings.Contains(name, ".") {
+		return v.name, v.category, p, true
+	}
+	key := strings.ToLower(name)
+	for _, suffix := range []string{" api", "-api", "_api", " sdk"} {
+		key = strings.TrimSuffix(key, suffix)
+	}
+	squash := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '_' {
+				return -1
+			}
+			return r
+		}, strings.ToLower(s))
+	}
+	for _, v := range vendorCatalog {
+		if squash(v.name) == squash(key) {
+			return v.name, v.category, "", true
+		}
+	}
+	// Products known by name alone, such as S3; an "API Gateway" is more
+	// often the system's own.
+	for _, v := range vendorCatalog {
+		for _, p := range v.products {
+			if squash(p) == squash(key) && p != "API Gateway" {
+				return v.name, v.category, p, true
+			}
+		}
+	}
+	// Products named for their vendor, such as "AWS S3".
+	for _, v := range vendorCatalog {
+		if rest, found := strings.CutPrefix(key, strings.ToLower(v.name)+" "); found {
+			if p := v.product(rest); p != "" {
+				return v.name, v.category, p, true
+			}
+		}
+	}
+	return "", "", "", false
+}
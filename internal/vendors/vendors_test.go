@@ -0,0 +1,85 @@
+package vendors
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ziadkadry99/auto-doc/internal/importers"
+	"github.com/ziadkadry99/auto-doc/internal/indexer"
+)
+
+func TestDetect(t *testing.T) {
+	deps := []importers.Dependency{
+		{Name: "github.com/stripe/stripe-go/v76", Ecosystem: "Go", Manifest: "go.mod"},
+		{Name: "github.com/aws/aws-sdk-go-v2/service/sqs", Ecosystem: "Go", Manifest: "go.mod"},
+		{Name: "github.com/getsentry/sentry-go", Ecosystem: "Go", Manifest: "go.mod", Dev: true},
+		{Name: "github.com/labstack/echo/v4", Ecosystem: "Go", Manifest: "go.mod"},
+	}
+	analyses := map[string]indexer.FileAnalysis{
+		"storage/upload.go": {Language: "Go", Dependencies: []indexer.Dependency{
+			{Name: "github.com/aws/aws-sdk-go-v2/service/s3", Type: "import"},
+			{Name: "github.com/aws/aws-sdk-go-v2/service/s3/types", Type: "import"},
+		}},
+		"notify/sms.py": {Language: "Python", Dependencies: []indexer.Dependency{{Name: "twilio.rest", Type: "import"}}},
+	}
+	hosts := map[string][]indexer.Host{
+		"billing/client.go": {{Name: "api.stripe.com", Line: 4}, {Name: "internal.acme.dev", Line: 9}},
+		"config/auth.go":    {{Name: "acme.eu.auth0.com", Line: 12}},
+	}
+
+	var got []string
+	for _, u := range Detect(analyses, deps, hosts) {
+		got = append(got, fmt.Sprintf("%s (%s) %s: %s", u.Vendor, u.Category, strings.Join(u.Products, "+"), strings.Join(u.Evidence, "; ")))
+	}
+	want := []string{
+		"Auth0 (Authentication) : acme.eu.auth0.com in config/auth.go:12",
+		"AWS (Cloud) S3+SQS: github.com/aws/aws-sdk-go-v2/service/sqs in go.mod; github.com/aws/aws-sdk-go-v2/service/s3 imported in storage/upload.go; github.com/aws/aws-sdk-go-v2/service/s3/types imported in storage/upload.go",
+		"Stripe (Payments) : github.com/stripe/stripe-go/v76 in go.mod; api.stripe.com in billing/client.go:4",
+		"Twilio (SMS and voice) : twilio.rest imported in notify/sms.py",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"stripe", "Stripe"},
+		{"Stripe API", "Stripe"},
+		{"send-grid", "SendGrid"},
+		{"sqs.us-east-1.amazonaws.com", "AWS SQS"},
+		{"s3", "AWS S3"},
+		{"AWS S3", "AWS S3"},
+		{"azure.storage.blob", ""},
+		{"api-gateway", ""},
+		{"smtp", ""},
+		{"payments", ""},
+	}
+	for _, tt := range tests {
+		vendor, _, product, _ := Lookup(tt.name)
+		if got := strings.TrimSpace(vendor + " " + product); got != tt.want {
+			t.Errorf("Lookup(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookupLibraryProducts(t *testing.T) {
+	tests := []struct{ ecosystem, name, want string }{
+		{"npm", "@aws-sdk/client-dynamodb", "AWS DynamoDB"},
+		{"npm", "@google-cloud/pubsub", "Google Cloud Pub/Sub"},
+		{"PyPI", "azure-storage-blob", "Azure Blob Storage"},
+		{"PyPI", "azure.servicebus", "Azure Service Bus"},
+		{"Maven", "software.amazon.awssdk:s3", "AWS S3"},
+		{"Go", "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob", "Azure Blob Storage"},
+		{"PyPI", "boto3", "AWS"},
+		{"npm", "stripe-mock", ""},
+	}
+	for _, tt := range tests {
+		v, product, _ := lookupLibrary(tt.ecosystem, tt.name)
+		if got := strings.TrimSpace(v.name + " " + product); got != tt.want {
+			t.Errorf("lookupLibrary(%q, %q) = %q; want %q", tt.ecosystem, tt.name, got, tt.want)
+		}
+	}
+}
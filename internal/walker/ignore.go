@@ -0,0 +1,104 @@
+package walker
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFile is the name of the file, in gitignore syntax, listing paths
+// autodoc leaves out of the index on top of .gitignore.
+const IgnoreFile = ".autodocignore"
+
+// ignoreRule is one line of a gitignore-syntax file.
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes what earlier rules ignored
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // a pattern with a slash matches from the root only
+}
+
+// ignoreRules are the rules of one or more ignore files, in order; the last
+// rule matching a path decides.
+type ignoreRules []ignoreRule
+
+// loadIgnoreFile reads a gitignore-syntax file. A missing file has no rules.
+func loadIgnoreFile(path string) ignoreRules {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(string(data))
+}
+
+// parseIgnore parses gitignore syntax: blank lines and # comments are
+// skipped, ! negates, a trailing / matches directories only, and a pattern
+// containing a / other than at the end is relative to the root.
+func parseIgnore(content string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			r.negate = true
+			line = rest
+		}
+		line = strings.TrimPrefix(line, `\`) // \# and \! escape a literal first character
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			r.dirOnly = true
+			line = rest
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ignored reports whether the rules ignore the file at relPath, which uses
+// forward slashes. As with git, a file in an ignored directory is ignored
+// even if a later rule would re-include the file itself.
+func (rules ignoreRules) ignored(relPath string) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	for i := strings.Index(relPath, "/"); i >= 0; {
+		if rules.match(relPath[:i], true) {
+			return true
+		}
+		next := strings.Index(relPath[i+1:], "/")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return rules.match(relPath, false)
+}
+
+// match applies the rules to one path, a directory if isDir.
+func (rules ignoreRules) match(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		pattern := r.pattern
+		if !r.anchored {
+			pattern = "**/" + pattern
+		}
+		if matched, _ := doublestar.Match(pattern, path); matched {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
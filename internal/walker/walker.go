@@ -30,11 +30,34 @@ type WalkerConfig struct {
 	Include     []string // Glob patterns — only matching files are included.
 	Exclude     []string // Glob patterns — matching files are excluded.
 	MaxFileSize int64    // Files larger than this are skipped (0 = use default).
+	// OnTooLarge, if set, is called for each file skipped for exceeding
+	// MaxFileSize.
+	OnTooLarge func(relPath string, size int64)
+	// Languages turns languages, as named by DetectLanguage, on or off;
+	// case doesn't matter. Languages not listed are on.
+	Languages map[string]bool
+	// OnSkip, if set, is called for each file Walk leaves out, with the
+	// reason. A directory the ignore files leave out is reported once, with
+	// a trailing slash, instead of file by file. Files in default-excluded
+	// directories are not reported.
+	OnSkip func(relPath string, reason SkipReason)
 }
 
+// SkipReason is why Walk left a file out.
+type SkipReason string
+
+const (
+	SkipIgnored    SkipReason = "ignored pattern" // .gitignore, .autodocignore, include, or exclude patterns
+	SkipLanguage   SkipReason = "language turned off"
+	SkipTooLarge   SkipReason = "too large"
+	SkipBinary     SkipReason = "binary"
+	SkipUnreadable SkipReason = "unreadable"
+)
+
 // Walk traverses the directory tree rooted at config.RootDir and returns
 // metadata for every source file that passes filtering. It skips binary
-// files, respects include/exclude patterns, and honours .gitignore files.
+// files, respects include/exclude patterns and language toggles, and
+// honours the root's .gitignore and .autodocignore files.
 func Walk(config WalkerConfig) ([]FileInfo, error) {
 	root, err := filepath.Abs(config.RootDir)
 	if err != nil {
@@ -46,10 +69,18 @@ func Walk(config WalkerConfig) ([]FileInfo, error) {
 		maxSize = DefaultMaxFileSize
 	}
 
-	// Load .gitignore patterns from root if present.
-	gitignorePatterns := loadGitignore(filepath.Join(root, ".gitignore"))
+	// Load .gitignore and .autodocignore rules from root if present; the
+	// latter's rules come last, so they can re-include what git ignores.
+	ignore := loadIgnoreFile(filepath.Join(root, ".gitignore"))
+	ignore = append(ignore, loadIgnoreFile(filepath.Join(root, IgnoreFile))...)
 
 	var files []FileInfo
+	skip := func(relPath string, reason SkipReason) error {
+		if config.OnSkip != nil {
+			config.OnSkip(filepath.ToSlash(relPath), reason)
+		}
+		return nil
+	}
 
 	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
@@ -59,11 +90,15 @@ func Walk(config WalkerConfig) ([]FileInfo, error) {
 
 		name := d.Name()
 
-		// Skip default-excluded directories.
+		// Skip default-excluded and ignored directories.
 		if d.IsDir() {
 			if shouldExcludeDir(name) {
 				return filepath.SkipDir
 			}
+			if relPath, err := filepath.Rel(root, path); err == nil && relPath != "." && ignore.match(filepath.ToSlash(relPath), true) {
+				skip(relPath+"/", SkipIgnored)
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -77,44 +112,52 @@ func Walk(config WalkerConfig) ([]FileInfo, error) {
 			return nil
 		}
 
-		// Check .gitignore patterns.
-		if matchesGitignore(relPath, gitignorePatterns) {
-			return nil
+		// Check .gitignore and .autodocignore rules.
+		if ignore.ignored(filepath.ToSlash(relPath)) {
+			return skip(relPath, SkipIgnored)
 		}
 
 		// Apply user-defined include/exclude filters.
 		if !MatchesInclude(relPath, config.Include) {
-			return nil
+			return skip(relPath, SkipIgnored)
 		}
 		if MatchesExclude(relPath, config.Exclude) {
-			return nil
+			return skip(relPath, SkipIgnored)
+		}
+
+		language := DetectLanguage(name)
+		if !languageEnabled(language, config.Languages) {
+			return skip(relPath, SkipLanguage)
 		}
 
 		info, err := d.Info()
 		if err != nil {
-			return nil
+			return skip(relPath, SkipUnreadable)
 		}
 
 		// Skip files exceeding the size limit.
 		if info.Size() > maxSize {
-			return nil
+			if config.OnTooLarge != nil {
+				config.OnTooLarge(filepath.ToSlash(relPath), info.Size())
+			}
+			return skip(relPath, SkipTooLarge)
 		}
 
 		// Skip binary files.
 		if isBinary(path) {
-			return nil
+			return skip(relPath, SkipBinary)
 		}
 
 		hash, err := hashFile(path)
 		if err != nil {
-			return nil
+			return skip(relPath, SkipUnreadable)
 		}
 
 		files = append(files, FileInfo{
 			Path:        path,
 			RelPath:     filepath.ToSlash(relPath),
 			Size:        info.Size(),
-			Language:    DetectLanguage(name),
+			Language:    language,
 			ContentHash: hash,
 			IsTest:      isTestFile(name, relPath),
 		})
@@ -195,62 +238,12 @@ func isTestFile(name, relPath string) bool {
 	return false
 }
 
-// loadGitignore reads a .gitignore file and returns its non-empty,
-// non-comment lines as patterns.
-func loadGitignore(path string) []string {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil
-	}
-
-	var patterns []string
-	for _, line := range strings.Split(string(data), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		patterns = append(patterns, line)
-	}
-	return patterns
-}
-
-// matchesGitignore checks if a relative path matches any gitignore pattern.
-func matchesGitignore(relPath string, patterns []string) bool {
-	if len(patterns) == 0 {
-		return false
-	}
-
-	normalized := filepath.ToSlash(relPath)
-
-	for _, pattern := range patterns {
-		pattern = strings.TrimSpace(pattern)
-
-		// Handle directory-only patterns (trailing /).
-		dirOnly := strings.HasSuffix(pattern, "/")
-		pattern = strings.TrimSuffix(pattern, "/")
-
-		// If the pattern has no slash, match against any path component.
-		if !strings.Contains(pattern, "/") {
-			// Check if any component of the path matches.
-			parts := strings.Split(normalized, "/")
-			for _, part := range parts {
-				if matched, _ := filepath.Match(pattern, part); matched {
-					if !dirOnly {
-						return true
-					}
-				}
-			}
-			// Also match the full basename.
-			base := filepath.Base(normalized)
-			if matched, _ := filepath.Match(pattern, base); matched && !dirOnly {
-				return true
-			}
-		} else {
-			// Pattern contains a slash — match against the full relative path.
-			if matched, _ := filepath.Match(pattern, normalized); matched {
-				return true
-			}
+// languageEnabled reports whether toggles leave language on.
+func languageEnabled(language string, toggles map[string]bool) bool {
+	for name, on := range toggles {
+		if strings.EqualFold(name, language) {
+			return on
 		}
 	}
-	return false
+	return true
 }
@@ -200,9 +200,13 @@ func TestWalk_SkipsLargeFiles(t *testing.T) {
 	}
 	os.WriteFile(filepath.Join(tmpDir, "big.txt"), big, 0644)
 
+	var tooLarge []string
 	files, err := Walk(WalkerConfig{
 		RootDir:     tmpDir,
 		MaxFileSize: 100, // 100 bytes
+		OnTooLarge: func(relPath string, size int64) {
+			tooLarge = append(tooLarge, relPath)
+		},
 	})
 	if err != nil {
 		t.Fatalf("Walk() error: %v", err)
@@ -213,6 +217,9 @@ func TestWalk_SkipsLargeFiles(t *testing.T) {
 			t.Error("big.txt should have been skipped (exceeds MaxFileSize)")
 		}
 	}
+	if len(tooLarge) != 1 || tooLarge[0] != "big.txt" {
+		t.Errorf("OnTooLarge reported %v, want [big.txt]", tooLarge)
+	}
 }
 
 func TestWalk_DefaultExcludeDirs(t *testing.T) {
@@ -284,6 +291,122 @@ func TestWalk_Gitignore(t *testing.T) {
 	}
 }
 
+func TestWalk_OnSkip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("*.log\ngenerated/\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "debug.log"), []byte("log data"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.tmp"), []byte("scratch"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "image.bin"), make([]byte, 10), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "big.txt"), []byte(strings.Repeat("A", 200)), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "node_modules"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "node_modules", "dep.js"), []byte("x"), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "generated", "assets"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "generated", "bundle.js"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "generated", "assets", "app.js"), []byte("x"), 0644)
+
+	skipped := make(map[string]SkipReason)
+	_, err := Walk(WalkerConfig{
+		RootDir:     tmpDir,
+		Exclude:     []string{"*.tmp"},
+		MaxFileSize: 100,
+		OnSkip: func(relPath string, reason SkipReason) {
+			skipped[relPath] = reason
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk() error: %v", err)
+	}
+
+	want := map[string]SkipReason{
+		"debug.log":  SkipIgnored,
+		"notes.tmp":  SkipIgnored,
+		"generated/": SkipIgnored,
+		"image.bin":  SkipBinary,
+		"big.txt":    SkipTooLarge,
+	}
+	if len(skipped) != len(want) {
+		t.Errorf("OnSkip reported %v, want %v", skipped, want)
+	}
+	for path, reason := range want {
+		if skipped[path] != reason {
+			t.Errorf("OnSkip(%q) = %q, want %q", path, skipped[path], reason)
+		}
+	}
+}
+
+func TestWalk_AutodocIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("gen/\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, IgnoreFile), []byte(`# vendored code, generated clients, fixtures
+third_party/
+/api/client_*.go
+**/fixtures/**
+*.pb.go
+!keep.pb.go
+`), 0644)
+	for _, rel := range []string{
+		"main.go",
+		"gen/types.go",
+		"third_party/lib/lib.go",
+		"api/client_users.go",
+		"api/server.go",
+		"internal/api/client_orders.go",
+		"internal/testdata/fixtures/order.json",
+		"proto/orders.pb.go",
+		"proto/keep.pb.go",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, rel)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, rel), []byte("package x"), 0644)
+	}
+
+	files, err := Walk(WalkerConfig{RootDir: tmpDir})
+	if err != nil {
+		t.Fatalf("Walk() error: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		if !strings.HasPrefix(f.RelPath, ".") {
+			got = append(got, f.RelPath)
+		}
+	}
+	sort.Strings(got)
+	want := []string{"api/server.go", "internal/api/client_orders.go", "main.go", "proto/keep.pb.go"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Walk() = %v, want %v", got, want)
+	}
+}
+
+func TestWalk_Languages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "data.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app.py"), []byte("print()"), 0644)
+
+	var skipped []string
+	files, err := Walk(WalkerConfig{
+		RootDir:   tmpDir,
+		Languages: map[string]bool{"json": false, "Go": true},
+		OnSkip: func(relPath string, reason SkipReason) {
+			if reason == SkipLanguage {
+				skipped = append(skipped, relPath)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk() error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected main.go and app.py, got %d files", len(files))
+	}
+	if len(skipped) != 1 || skipped[0] != "data.json" {
+		t.Errorf("skipped for language %v, want [data.json]", skipped)
+	}
+}
+
 func TestWalk_ContentHashConsistency(t *testing.T) {
 	dir := testdataDir(t)
 